@@ -0,0 +1,142 @@
+// Command jwt-keys manages the key pairs that sign access tokens. A rotation is
+//
+//	jwt-keys -command generate               # publish the new key in the JWKS
+//	jwt-keys -command activate -kid <new>    # once verifiers have fetched it
+//	jwt-keys -command retire -kid <old>      # once the old key's tokens expired
+//
+// The server picks a change up within JWT_KEY_CACHE_SECONDS.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+)
+
+func main() {
+	var (
+		command   = flag.String("command", "list", "Command: list, generate, activate, retire")
+		algorithm = flag.String("algorithm", models.SigningAlgorithmRS256, "Algorithm of a generated key: RS256 or EdDSA")
+		activate  = flag.Bool("activate", false, "Activate the generated key at once (only for a first key no one verifies yet)")
+		kid       = flag.String("kid", "", "Key ID, for activate and retire")
+	)
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if err := utils.InitLogger(&cfg.Logging); err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	if err := models.InitDatabase(&cfg.Database); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	if err := models.MigrateDatabase(); err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	// The private keys are sealed exactly as the server seals them, or it
+	// could not open them.
+	signingKeys := service.NewSigningKeyService(repository.NewSigningKeyRepository(models.DB),
+		utils.NewSecretBox(cfg.API.APIKeySecret, service.SigningKeySealContext), cfg.JWT)
+
+	switch *command {
+	case "list":
+		keys, err := signingKeys.List()
+		if err != nil {
+			log.Fatalf("Failed to list signing keys: %v", err)
+		}
+		printKeys(keys)
+
+	case "generate":
+		key, err := signingKeys.Generate(*algorithm)
+		if err != nil {
+			log.Fatalf("Failed to generate signing key: %v", err)
+		}
+		fmt.Printf("Generated %s key %s; it is published in the JWKS.\n", key.Algorithm, key.KID)
+		if !*activate {
+			fmt.Printf("Activate it once the services verifying our tokens have fetched it:\n")
+			fmt.Printf("  jwt-keys -command activate -kid %s\n", key.KID)
+			return
+		}
+		if _, err := signingKeys.Activate(key.KID); err != nil {
+			log.Fatalf("Failed to activate signing key: %v", err)
+		}
+		fmt.Printf("Activated key %s.\n", key.KID)
+
+	case "activate":
+		requireKID(*kid)
+		key, err := signingKeys.Activate(*kid)
+		if err != nil {
+			log.Fatalf("Failed to activate signing key: %v", err)
+		}
+		fmt.Printf("Activated key %s; tokens are signed with it from now on.\n", key.KID)
+		fmt.Printf("Retire the previous key once the tokens it signed have expired (JWT_EXPIRY_HOURS=%d).\n",
+			cfg.JWT.ExpiryHours)
+
+	case "retire":
+		requireKID(*kid)
+		key, err := signingKeys.Retire(*kid)
+		if errors.Is(err, service.ErrSigningKeyInUse) {
+			log.Fatalf("Key %s signs tokens; activate another key first", *kid)
+		}
+		if err != nil {
+			log.Fatalf("Failed to retire signing key: %v", err)
+		}
+		fmt.Printf("Retired key %s; tokens signed with it are refused from now on.\n", key.KID)
+
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", *command)
+		flag.Usage()
+		os.Exit(1)
+	}
+}
+
+func requireKID(kid string) {
+	if kid == "" {
+		log.Fatal("-kid is required")
+	}
+}
+
+func printKeys(keys []models.SigningKey) {
+	if len(keys) == 0 {
+		fmt.Println("No signing keys; access tokens are signed with JWT_SECRET.")
+		return
+	}
+	active := service.ActiveSigningKey(keys)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KID\tALGORITHM\tSTATUS\tCREATED\tACTIVATED\tRETIRED")
+	for _, key := range keys {
+		status := "published"
+		switch {
+		case !key.Published():
+			status = "retired"
+		case active != nil && key.KID == active.KID:
+			status = "signing"
+		case key.ActivatedAt != nil:
+			status = "verifying"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", key.KID, key.Algorithm, status,
+			key.CreatedAt.Format(time.RFC3339), formatTime(key.ActivatedAt), formatTime(key.RetiredAt))
+	}
+	w.Flush()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
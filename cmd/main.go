@@ -11,14 +11,19 @@ import (
 	"time"
 
 	"github.com/florinel-chis/gophercrm/internal/aeo"
+	"github.com/florinel-chis/gophercrm/internal/bulk"
 	"github.com/florinel-chis/gophercrm/internal/config"
 	"github.com/florinel-chis/gophercrm/internal/handler"
+	"github.com/florinel-chis/gophercrm/internal/inbound"
 	"github.com/florinel-chis/gophercrm/internal/mailer"
 	"github.com/florinel-chis/gophercrm/internal/middleware"
 	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/oidc"
 	"github.com/florinel-chis/gophercrm/internal/repository"
 	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/sla"
 	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/florinel-chis/gophercrm/internal/webhook"
 	"github.com/gin-gonic/gin"
 )
 
@@ -54,16 +59,55 @@ func main() {
 	if err := models.MigrateDatabase(); err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
+	// The global search reads full-text indexes AutoMigrate cannot declare.
+	if err := repository.EnsureSearchIndexes(models.DB); err != nil {
+		log.Fatalf("Failed to create search indexes: %v", err)
+	}
+
+	// Custom field values are read and written alongside every lead, customer,
+	// ticket and task the application loads or saves.
+	if err := models.DB.Use(repository.CustomFieldPlugin{}); err != nil {
+		log.Fatalf("Failed to register custom fields: %v", err)
+	}
 
 	// Initialize default configurations
 	configRepo := repository.NewConfigurationRepository(models.DB)
 	if err := configRepo.InitializeDefaults(); err != nil {
 		log.Printf("Warning: Failed to initialize default configurations: %v", err)
 	}
+	if err := repository.NewDealRepository(models.DB).InitializeDefaultStages(); err != nil {
+		log.Printf("Warning: Failed to initialize default pipeline stages: %v", err)
+	}
+	if err := repository.NewRoleRepository(models.DB).InitializeBuiltInRoles(); err != nil {
+		log.Printf("Warning: Failed to initialize built-in roles: %v", err)
+	}
+	// Link the leads and customers that predate accounts to one account per
+	// company. It only ever runs against an empty accounts table.
+	if backfill, err := repository.NewAccountRepository(models.DB).BackfillFromCompanies(); err != nil {
+		log.Printf("Warning: Failed to create accounts from company names: %v", err)
+	} else if backfill.AccountsCreated > 0 {
+		log.Printf("Created %d accounts from company names, linking %d leads and %d customers",
+			backfill.AccountsCreated, backfill.LeadsLinked, backfill.CustomersLinked)
+	}
+	// Fill the duplicate match keys of the leads and customers stored before
+	// they existed. Later writes keep them current.
+	if filled, err := repository.NewDuplicateRepository(models.DB).BackfillMatchKeys(); err != nil {
+		log.Printf("Warning: Failed to fill duplicate match keys: %v", err)
+	} else if filled > 0 {
+		log.Printf("Filled duplicate match keys of %d leads and customers", filled)
+	}
+	// Give the tickets opened before reply tokens existed one each, so their
+	// customers' replies can be threaded once agents quote the new token.
+	if filled, err := repository.NewTicketRepository(models.DB).BackfillReplyTokens(); err != nil {
+		log.Printf("Warning: Failed to fill ticket reply tokens: %v", err)
+	} else if filled > 0 {
+		log.Printf("Filled reply tokens of %d tickets", filled)
+	}
 
-	// Background workers (currently the AEO scheduler) live for as long as the
-	// process serves traffic. Cancelling this context is what stops them, and it
-	// happens on the same signal that shuts the HTTP server down.
+	// Background workers (the AEO scheduler, the SLA breach monitor, the
+	// inbound email poller and the webhook delivery worker) live for as long
+	// as the process serves traffic. Cancelling this context is what stops
+	// them, and it happens on the same signal that shuts the HTTP server down.
 	backgroundCtx, stopBackground := context.WithCancel(context.Background())
 	defer stopBackground()
 
@@ -87,8 +131,8 @@ func main() {
 
 	utils.Logger.Info("Shutting down server...")
 
-	// Stop the background workers first so no new AEO run starts while the
-	// server is draining.
+	// Stop the background workers first so no new AEO run or SLA sweep starts
+	// while the server is draining.
 	stopBackground()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -135,15 +179,23 @@ func setupRouter(backgroundCtx context.Context, cfg *config.Config) *gin.Engine
 		})
 	})
 
+	// The signing keys are shared by the auth service, which signs and
+	// verifies access tokens with them, and the JWKS that publishes them at
+	// the well-known path, outside the API prefix.
+	signingKeyService := service.NewSigningKeyService(repository.NewSigningKeyRepository(models.DB),
+		utils.NewSecretBox(cfg.API.APIKeySecret, service.SigningKeySealContext), cfg.JWT)
+	jwksHandler := handler.NewJWKSHandler(signingKeyService, cfg.JWT.KeyCacheSeconds)
+	router.GET("/.well-known/jwks.json", middleware.RateLimitGenerous(), jwksHandler.JWKS)
+
 	api := router.Group(cfg.API.Prefix)
 	{
-		setupDependencies(backgroundCtx, api, cfg)
+		setupDependencies(backgroundCtx, api, cfg, signingKeyService)
 	}
 
 	return router
 }
 
-func setupDependencies(backgroundCtx context.Context, router *gin.RouterGroup, cfg *config.Config) {
+func setupDependencies(backgroundCtx context.Context, router *gin.RouterGroup, cfg *config.Config, signingKeyService service.SigningKeyService) {
 	userRepo := repository.NewUserRepository(models.DB)
 	leadRepo := repository.NewLeadRepository(models.DB)
 	// Erasing a customer must also erase the lead it was converted from: the
@@ -163,25 +215,93 @@ func setupDependencies(backgroundCtx context.Context, router *gin.RouterGroup, c
 	bulkRepo := repository.NewBulkRepository(models.DB)
 	aeoRepo := repository.NewAEORepository(models.DB)
 	formRepo := repository.NewFormRepository(models.DB)
+	auditRepo := repository.NewAuditRepository(models.DB)
+	activityRepo := repository.NewActivityRepository(models.DB)
+	ticketCommentRepo := repository.NewTicketCommentRepository(models.DB)
+	slaRepo := repository.NewSLARepository(models.DB)
+	inboundEmailRepo := repository.NewInboundEmailRepository(models.DB)
+	webhookRepo := repository.NewWebhookRepository(models.DB)
+	dealRepo := repository.NewDealRepository(models.DB)
+	accountRepo := repository.NewAccountRepository(models.DB)
+	duplicateRepo := repository.NewDuplicateRepository(models.DB)
+	customFieldRepo := repository.NewCustomFieldRepository(models.DB)
+	searchRepo := repository.NewSearchRepository(models.DB)
+	savedViewRepo := repository.NewSavedViewRepository(models.DB)
+	importRepo := repository.NewImportRepository(models.DB)
+	roleRepo := repository.NewRoleRepository(models.DB)
+	twoFactorRepo := repository.NewTwoFactorRepository(models.DB)
+	ssoRepo := repository.NewSSORepository(models.DB)
+	sessionRepo := repository.NewSessionRepository(models.DB)
 
 	appMailer := mailer.NewFromConfig(cfg.SMTP)
 
+	configService := service.NewConfigurationService(configRepo,
+		utils.NewSecretBox(cfg.API.APIKeySecret, "configuration-secret"))
+	// The roles that must use a second factor are read from the settings at
+	// each login, so an administrator's change applies without a restart.
+	twoFactorService := service.NewTwoFactorService(userRepo, twoFactorRepo,
+		utils.NewSecretBox(cfg.API.APIKeySecret, "totp-secret"), cfg.API.APIKeySecret,
+		service.TwoFactorRequiredRolesFrom(configService))
+	authOptions := []service.AuthOption{service.WithTwoFactor(twoFactorService), service.WithSessionStore(sessionRepo),
+		service.WithSigningKeys(signingKeyService)}
+	if cfg.OIDC.Enabled() && cfg.OIDC.EnforceDomains {
+		authOptions = append(authOptions, service.WithSSOEnforcement(cfg.OIDC.Domains))
+	}
 	authService := service.NewAuthServiceWithSessions(
 		userRepo, apiKeyRepo, refreshTokenRepo, passwordResetRepo, appMailer,
-		cfg.JWT, cfg.App.BaseURL, cfg.API.APIKeySecret)
+		cfg.JWT, cfg.App.BaseURL, cfg.API.APIKeySecret, authOptions...)
 	userService := service.NewUserService(userRepo)
+	// Single sign-on, public forms, inbound email and the SLA worker write for
+	// nobody signed in, so they audit their changes themselves.
+	auditService := service.NewAuditService(auditRepo)
 	txManager := utils.NewTransactionManager(models.DB)
-	leadService := service.NewLeadService(leadRepo, customerRepo, txManager)
-	customerService := service.NewCustomerService(customerRepo, userRepo)
-	ticketService := service.NewTicketService(ticketRepo, customerRepo, userRepo)
-	taskService := service.NewTaskService(taskRepo, userRepo, leadRepo, customerRepo, labelRepo)
+	// Resolves custom roles, for the permission guards and for the services
+	// that check who may be handed a record.
+	roleService := service.NewRoleService(roleRepo)
+	// The entity services write the dashboard's activity feed as they commit
+	// each change, and queue the matching webhook events.
+	activityService := service.NewActivityService(activityRepo)
+	activityFeed := service.WithActivityFeed(activityService)
+	webhookService := service.NewWebhookService(webhookRepo,
+		utils.NewSecretBox(cfg.API.APIKeySecret, "webhook-secret"),
+		webhook.NewSender(time.Duration(cfg.Webhooks.TimeoutSeconds)*time.Second), cfg.Webhooks)
+	webhookFeed := service.WithWebhooks(webhookService)
+	leadService := service.NewLeadService(leadRepo, customerRepo, accountRepo, txManager, activityFeed, webhookFeed)
+	customerService := service.NewCustomerService(customerRepo, userRepo, roleService, accountRepo, activityFeed, webhookFeed)
+	slaService := service.NewSLAService(slaRepo, auditService, activityFeed)
+	ticketService := service.NewTicketServiceWithSLA(ticketRepo, customerRepo, userRepo, roleService, ticketCommentRepo, slaService, activityFeed, webhookFeed)
+	ticketCommentService := service.NewTicketCommentService(ticketCommentRepo, ticketRepo, activityFeed)
+	taskService := service.NewTaskService(taskRepo, userRepo, leadRepo, customerRepo, labelRepo, activityFeed)
+	dealService := service.NewDealService(dealRepo, customerRepo, leadRepo, userRepo, cfg.Deals, activityFeed)
+	accountService := service.NewAccountService(accountRepo, userRepo, activityFeed)
+	duplicateService := service.NewDuplicateService(duplicateRepo, leadRepo, customerRepo, txManager, activityFeed, webhookFeed)
 	labelService := service.NewLabelService(labelRepo)
+	customFieldService := service.NewCustomFieldService(customFieldRepo)
+	// Single sign-on stays off, and its routes answer 404, until an issuer
+	// and a client are configured.
+	var identityProvider service.IdentityProvider
+	if cfg.OIDC.Enabled() {
+		identityProvider = oidc.NewProvider(oidc.Config{
+			Issuer:       cfg.OIDC.Issuer,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+			Scopes:       cfg.OIDC.Scopes,
+		})
+	}
+	ssoService := service.NewSSOService(identityProvider, ssoRepo, userRepo, roleService, authService,
+		auditService, cfg.OIDC, cfg.API.APIKeySecret)
+	searchService := service.NewSearchService(searchRepo)
+	savedViewService := service.NewSavedViewService(savedViewRepo)
+	// Imports and queued bulk operations change records outside any request,
+	// so they audit those changes themselves.
+	importService := service.NewImportService(importRepo, bulkOperationRepo, customFieldRepo, userRepo, customerRepo,
+		leadService, customerService, auditService)
 	apiKeyService := service.NewAPIKeyService(apiKeyRepo, cfg.API.APIKeySecret)
-	configService := service.NewConfigurationService(configRepo,
-		utils.NewSecretBox(cfg.API.APIKeySecret, "configuration-secret"))
 	bulkService := service.NewBulkOperationService(
 		bulkOperationRepo, bulkRepo, userRepo, leadRepo, customerRepo,
-		taskRepo, ticketRepo, txManager, utils.Logger,
+		taskRepo, ticketRepo, txManager, auditService, utils.Logger, cfg.Bulk,
+		activityFeed, webhookFeed,
 	)
 
 	// AEO. Only engines with credentials are loaded, so a deployment that sets
@@ -209,23 +329,85 @@ func setupDependencies(backgroundCtx context.Context, router *gin.RouterGroup, c
 			return engine
 		}))
 
-	formService := service.NewFormService(formRepo, leadRepo, userRepo, appMailer,
-		txManager, cfg.Forms, cfg.API.Prefix)
+	formService := service.NewFormService(formRepo, leadRepo, userRepo, roleService, auditService, appMailer,
+		txManager, cfg.Forms, cfg.API.Prefix, webhookFeed)
+
+	// A lead owner that does not exist would fail every lead insert, and the
+	// message with it on every poll, so the gateway falls back to quarantine.
+	inboundCfg := cfg.Inbound
+	if inboundCfg.CapturesLeads() {
+		if _, err := userRepo.GetByID(inboundCfg.LeadOwnerID); err != nil {
+			utils.Logger.WithField("lead_owner_id", inboundCfg.LeadOwnerID).
+				Warn("Inbound email lead owner not found; unknown senders will be quarantined")
+			inboundCfg.UnknownSender = models.InboundUnknownSenderQuarantine
+		}
+	}
+	inboundEmailService := service.NewInboundEmailService(inboundEmailRepo, customerRepo, ticketRepo, leadRepo,
+		ticketService, ticketCommentService, auditService, inboundCfg, activityFeed)
 
 	authHandler := handler.NewAuthHandler(authService, userService)
 	userHandler := handler.NewUserHandler(userService)
 	leadHandler := handler.NewLeadHandler(leadService)
 	customerHandler := handler.NewCustomerHandler(customerService)
 	ticketHandler := handler.NewTicketHandler(ticketService, customerService)
+	ticketCommentHandler := handler.NewTicketCommentHandler(ticketCommentService, ticketService, customerService)
 	taskHandler := handler.NewTaskHandler(taskService)
 	labelHandler := handler.NewLabelHandler(labelService)
 	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
 	configHandler := handler.NewConfigurationHandler(configService)
-	dashboardHandler := handler.NewDashboardHandler(leadService, customerService, ticketService, taskService)
+	dashboardHandler := handler.NewDashboardHandler(leadService, customerService, ticketService, taskService, activityService)
 	bulkHandler := handler.NewBulkHandler(bulkService)
 	aeoHandler := handler.NewAEOHandler(aeoService)
 	formHandler := handler.NewFormHandler(formService)
 	formPublicHandler := handler.NewFormPublicHandler(formService, cfg.Forms, cfg.API.Prefix)
+	auditHandler := handler.NewAuditHandler(auditService, configService)
+	slaHandler := handler.NewSLAHandler(slaService)
+	inboundEmailHandler := handler.NewInboundEmailHandler(inboundEmailService)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+	dealHandler := handler.NewDealHandler(dealService)
+	accountHandler := handler.NewAccountHandler(accountService)
+	duplicateHandler := handler.NewDuplicateHandler(duplicateService, leadService, customerService)
+	customFieldHandler := handler.NewCustomFieldHandler(customFieldService)
+	searchHandler := handler.NewSearchHandler(searchService)
+	savedViewHandler := handler.NewSavedViewHandler(savedViewService)
+	importHandler := handler.NewImportHandler(importService)
+	roleHandler := handler.NewRoleHandler(roleService)
+	twoFactorHandler := handler.NewTwoFactorHandler(twoFactorService)
+	sessionHandler := handler.NewSessionHandler(authService)
+	ssoHandler := handler.NewSSOHandler(ssoService)
+
+	// Every write that goes through these handlers lands in the audit trail,
+	// attributed to the user and API key that made the request.
+	authHandler.SetAuditService(auditService)
+	userHandler.SetAuditService(auditService)
+	leadHandler.SetAuditService(auditService)
+	customerHandler.SetAuditService(auditService)
+	ticketHandler.SetAuditService(auditService)
+	taskHandler.SetAuditService(auditService)
+	labelHandler.SetAuditService(auditService)
+	formHandler.SetAuditService(auditService)
+	configHandler.SetAuditService(auditService)
+	slaHandler.SetAuditService(auditService)
+	inboundEmailHandler.SetAuditService(auditService)
+	webhookHandler.SetAuditService(auditService)
+	dealHandler.SetAuditService(auditService)
+	accountHandler.SetAuditService(auditService)
+	duplicateHandler.SetAuditService(auditService)
+	customFieldHandler.SetAuditService(auditService)
+	roleHandler.SetAuditService(auditService)
+	twoFactorHandler.SetAuditService(auditService)
+	// New leads come back with a warning when they may duplicate a record.
+	leadHandler.SetDuplicateService(duplicateService)
+	// The entity lists filter and sort by custom fields given as cf.<name>.
+	leadHandler.SetCustomFieldService(customFieldService)
+	customerHandler.SetCustomFieldService(customFieldService)
+	ticketHandler.SetCustomFieldService(customFieldService)
+	taskHandler.SetCustomFieldService(customFieldService)
+	// ... and run the saved view given as view_id.
+	leadHandler.SetSavedViewService(savedViewService)
+	customerHandler.SetSavedViewService(savedViewService)
+	ticketHandler.SetSavedViewService(savedViewService)
+	taskHandler.SetSavedViewService(savedViewService)
 
 	// Recover runs stranded by the previous process before anything can start a
 	// new one. The engine runs in-process and writes the terminal status itself,
@@ -241,6 +423,25 @@ func setupDependencies(backgroundCtx context.Context, router *gin.RouterGroup, c
 			Warn("Recovered AEO runs left behind by a previous process")
 	}
 
+	// Imports run in-process too, so one still pending or processing at boot
+	// was cut short. Its rows so far are recorded; failing it says the rest
+	// were never imported.
+	if recovered, err := importService.ReconcileUnfinished(); err != nil {
+		utils.Logger.WithError(err).Error("Failed to reconcile unfinished imports")
+	} else if recovered > 0 {
+		utils.Logger.WithField("imports", recovered).
+			Warn("Failed imports left unfinished by a previous process")
+	}
+
+	// Queued bulk operations run in-process as well. One still processing at
+	// boot was cut short; the pending ones are picked up by the workers below.
+	if recovered, err := bulkService.ReconcileInterrupted(); err != nil {
+		utils.Logger.WithError(err).Error("Failed to reconcile interrupted bulk operations")
+	} else if recovered > 0 {
+		utils.Logger.WithField("operations", recovered).
+			Warn("Failed bulk operations interrupted by a previous process")
+	}
+
 	// The daily run is opt-out. It is skipped entirely when no engine is
 	// configured, because every tick would otherwise log the same 503-shaped
 	// failure.
@@ -253,6 +454,34 @@ func setupDependencies(backgroundCtx context.Context, router *gin.RouterGroup, c
 		}).Info("AEO scheduler not started")
 	}
 
+	if cfg.SLA.BreachMonitorEnabled {
+		sla.StartBreachMonitor(backgroundCtx, slaService,
+			time.Duration(cfg.SLA.BreachMonitorIntervalSeconds)*time.Second)
+	} else {
+		utils.Logger.Info("SLA breach monitor not started: disabled")
+	}
+
+	if inboundCfg.Enabled() {
+		if maildir, err := inbound.OpenMaildir(inboundCfg.MaildirPath); err != nil {
+			utils.Logger.WithError(err).Error("Inbound email poller not started: maildir unavailable")
+		} else {
+			inbound.StartPoller(backgroundCtx, maildir, inboundEmailService,
+				time.Duration(inboundCfg.PollIntervalSeconds)*time.Second)
+		}
+	} else {
+		utils.Logger.Info("Inbound email poller not started: no maildir configured")
+	}
+
+	if cfg.Webhooks.DeliveryEnabled {
+		webhook.StartWorker(backgroundCtx, webhookService,
+			time.Duration(cfg.Webhooks.DeliveryIntervalSeconds)*time.Second)
+	} else {
+		utils.Logger.Info("Webhook delivery worker not started: disabled")
+	}
+
+	bulk.StartWorkers(backgroundCtx, bulkService, cfg.Bulk.Workers,
+		time.Duration(cfg.Bulk.PollIntervalSeconds)*time.Second)
+
 	// Public routes with strict rate limiting for auth endpoints
 	public := router.Group("")
 	{
@@ -263,10 +492,19 @@ func setupDependencies(backgroundCtx context.Context, router *gin.RouterGroup, c
 		{
 			authRoutes.POST("/register", authHandler.Register)
 			authRoutes.POST("/login", authHandler.Login)
+			// The second step of a login that needs one; the challenge from
+			// /login stands in for the credentials.
+			authRoutes.POST("/login/2fa", authHandler.LoginTwoFactor)
+			authRoutes.POST("/login/2fa/setup", authHandler.LoginTwoFactorSetup)
 			// Refresh is a credential exchange, so it stays on the strict tier.
 			authRoutes.POST("/refresh", authHandler.Refresh)
 			authRoutes.POST("/password-reset", authHandler.RequestPasswordReset)
 			authRoutes.POST("/password-reset/confirm", authHandler.ConfirmPasswordReset)
+			// Single sign-on: the frontend starts the attempt, and hands the
+			// identity provider's redirect back to the callback.
+			authRoutes.GET("/sso", ssoHandler.Config)
+			authRoutes.POST("/sso/start", ssoHandler.Start)
+			authRoutes.POST("/sso/callback", ssoHandler.Callback)
 		}
 
 		// Embedded forms: definition, submission intake and the email-confirm
@@ -277,21 +515,40 @@ func setupDependencies(backgroundCtx context.Context, router *gin.RouterGroup, c
 
 	// Protected routes with moderate rate limiting
 	protected := router.Group("")
+	protected.Use(middleware.LogAPIKeyUsage(apiKeyService)) // wraps Auth so the requests it refuses are logged too
 	protected.Use(middleware.Auth(authService))
-	protected.Use(middleware.RateLimitModerate()) // 60 req/min for authenticated users
+	protected.Use(middleware.LoadPermissions(roleService)) // resolves custom roles before any route guard
+	protected.Use(middleware.RateLimitModerate())          // 60 req/min for authenticated users
 	{
 		handler.SetupUserRoutes(protected, userHandler)
 		handler.SetupLeadRoutes(protected, leadHandler)
 		handler.SetupCustomerRoutes(protected, customerHandler)
 		handler.SetupTicketRoutes(protected, ticketHandler)
+		handler.SetupTicketCommentRoutes(protected, ticketCommentHandler)
 		handler.SetupTaskRoutes(protected, taskHandler)
 		handler.SetupLabelRoutes(protected, labelHandler)
 		handler.SetupAPIKeyRoutes(protected, apiKeyHandler)
 		handler.SetupConfigurationRoutes(protected, configHandler)
 		handler.SetupDashboardRoutes(protected, dashboardHandler)
 		handler.SetupBulkStatusRoutes(protected, bulkHandler)
+		handler.SetupBulkRoutes(protected, bulkHandler)
+		handler.SetupBulkOperationRoutes(protected, bulkHandler)
 		handler.SetupAEORoutes(protected, aeoHandler)
 		handler.SetupFormRoutes(protected, formHandler)
+		handler.SetupAuditRoutes(protected, auditHandler)
+		handler.SetupSLARoutes(protected, slaHandler)
+		handler.SetupInboundEmailRoutes(protected, inboundEmailHandler)
+		handler.SetupWebhookRoutes(protected, webhookHandler)
+		handler.SetupDealRoutes(protected, dealHandler)
+		handler.SetupAccountRoutes(protected, accountHandler)
+		handler.SetupDuplicateRoutes(protected, duplicateHandler)
+		handler.SetupCustomFieldRoutes(protected, customFieldHandler)
+		handler.SetupSearchRoutes(protected, searchHandler)
+		handler.SetupSavedViewRoutes(protected, savedViewHandler)
+		handler.SetupImportRoutes(protected, importHandler)
+		handler.SetupRoleRoutes(protected, roleHandler)
+		handler.SetupTwoFactorRoutes(protected, twoFactorHandler)
+		handler.SetupSessionRoutes(protected, sessionHandler)
 
 		protectedAuth := protected.Group("/auth")
 		{
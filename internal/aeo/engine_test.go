@@ -127,10 +127,10 @@ func (r *fakeAEORepo) GetPromptByID(uint) (*models.AEOPrompt, error) {
 }
 func (r *fakeAEORepo) UpdatePrompt(*models.AEOPrompt) error { return r.unexpected("UpdatePrompt") }
 func (r *fakeAEORepo) DeletePrompt(uint) error              { return r.unexpected("DeletePrompt") }
-func (r *fakeAEORepo) ListPrompts(bool, int, int, string, string) ([]models.AEOPrompt, error) {
+func (r *fakeAEORepo) ListPrompts(models.AEOPromptFilter, int, int, string, string) ([]models.AEOPrompt, error) {
 	return nil, r.unexpected("ListPrompts")
 }
-func (r *fakeAEORepo) CountPrompts(bool) (int64, error) { return 0, r.unexpected("CountPrompts") }
+func (r *fakeAEORepo) CountPrompts(models.AEOPromptFilter) (int64, error) { return 0, r.unexpected("CountPrompts") }
 func (r *fakeAEORepo) ListActivePrompts() ([]models.AEOPrompt, error) {
 	return nil, r.unexpected("ListActivePrompts")
 }
@@ -0,0 +1,100 @@
+package bulk
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// WorkerName identifies the bulk operation workers in logs.
+const WorkerName = "bulk-operation-worker"
+
+// Runner is the slice of the bulk operation service the workers need.
+// Depending on this instead of service.BulkOperationService keeps the workers
+// testable without a database.
+type Runner interface {
+	// RunNextQueued claims the oldest queued operation and runs it to the end,
+	// reporting whether there was one.
+	RunNextQueued() (bool, error)
+	// Queued receives whenever an operation is queued.
+	Queued() <-chan struct{}
+}
+
+// StartWorkers launches workers goroutines and returns immediately. Each runs
+// queued operations one after another until none is left, then waits to be
+// woken by a new one, or for interval to pass, which picks up anything a wake
+// signal missed. Each drains once at start, so operations queued before a
+// restart resume without waiting. The goroutines exit when ctx is cancelled;
+// an operation under way at that moment is failed by the next boot.
+func StartWorkers(ctx context.Context, runner Runner, workers int, interval time.Duration) {
+	if runner == nil {
+		logWorker(0).Warn("Bulk operation workers not started: no runner")
+		return
+	}
+	if workers <= 0 || interval <= 0 {
+		logWorker(0).WithFields(logrus.Fields{
+			"workers":  workers,
+			"interval": interval.String(),
+		}).Warn("Bulk operation workers not started: workers and interval must be positive")
+		return
+	}
+	for id := 1; id <= workers; id++ {
+		go workerLoop(ctx, runner, id, interval)
+	}
+}
+
+func workerLoop(ctx context.Context, runner Runner, id int, interval time.Duration) {
+	logWorker(id).WithField("interval", interval.String()).Info("Bulk operation worker started")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		drain(ctx, runner, id)
+
+		select {
+		case <-ctx.Done():
+			logWorker(id).Info("Bulk operation worker stopped")
+			return
+		case <-runner.Queued():
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain runs queued operations until none is left or ctx is cancelled. Every
+// outcome — including a panic inside the service — is contained here so the
+// loop always survives to wait for the next one.
+func drain(ctx context.Context, runner Runner, id int) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			logWorker(id).WithField("panic", recovered).Error("Bulk operation worker panicked")
+		}
+	}()
+
+	for ctx.Err() == nil {
+		ran, err := runner.RunNextQueued()
+		if err != nil {
+			logWorker(id).WithField("error", err.Error()).Error("Failed to claim a queued bulk operation")
+			return
+		}
+		if !ran {
+			return
+		}
+	}
+}
+
+func logWorker(id int) *logrus.Entry {
+	base := utils.Logger
+	if base == nil {
+		base = logrus.StandardLogger()
+	}
+	worker := WorkerName
+	if id > 0 {
+		worker = fmt.Sprintf("%s-%d", WorkerName, id)
+	}
+	return base.WithField("worker", worker)
+}
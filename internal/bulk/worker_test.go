@@ -0,0 +1,134 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRunner hands out a scripted number of queued operations and can be
+// scripted to fail or panic.
+type fakeRunner struct {
+	mu     sync.Mutex
+	queue  int
+	runs   int
+	err    error
+	panics bool
+	queued chan struct{}
+	ran    chan struct{}
+}
+
+func newFakeRunner() *fakeRunner {
+	return &fakeRunner{queued: make(chan struct{}, 1), ran: make(chan struct{}, 64)}
+}
+
+func (f *fakeRunner) RunNextQueued() (bool, error) {
+	f.mu.Lock()
+	shouldPanic, err := f.panics, f.err
+	ran := f.queue > 0 && err == nil && !shouldPanic
+	if ran {
+		f.queue--
+		f.runs++
+	}
+	f.mu.Unlock()
+
+	if shouldPanic {
+		panic("service exploded")
+	}
+	if ran {
+		f.ran <- struct{}{}
+	}
+	return ran, err
+}
+
+func (f *fakeRunner) Queued() <-chan struct{} {
+	return f.queued
+}
+
+// enqueue adds n operations and wakes a worker, the way the service does.
+func (f *fakeRunner) enqueue(n int) {
+	f.mu.Lock()
+	f.queue += n
+	f.mu.Unlock()
+	select {
+	case f.queued <- struct{}{}:
+	default:
+	}
+}
+
+func (f *fakeRunner) waitForRuns(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-f.ran:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("bulk workers ran %d operations, want %d", i, n)
+		}
+	}
+}
+
+func TestStartWorkers_DrainsWhatWasQueuedBeforeStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runner := newFakeRunner()
+	runner.queue = 3
+
+	StartWorkers(ctx, runner, 2, time.Hour)
+
+	runner.waitForRuns(t, 3)
+}
+
+func TestStartWorkers_WakesOnQueue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runner := newFakeRunner()
+
+	StartWorkers(ctx, runner, 1, time.Hour)
+	time.Sleep(20 * time.Millisecond)
+	runner.enqueue(2)
+
+	runner.waitForRuns(t, 2)
+}
+
+func TestStartWorkers_SurvivesFailuresAndPanics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runner := newFakeRunner()
+	runner.panics = true
+
+	StartWorkers(ctx, runner, 1, 10*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+
+	runner.mu.Lock()
+	runner.panics = false
+	runner.err = errors.New("db down")
+	runner.mu.Unlock()
+	time.Sleep(30 * time.Millisecond)
+
+	runner.mu.Lock()
+	runner.err = nil
+	runner.mu.Unlock()
+	runner.enqueue(1)
+
+	runner.waitForRuns(t, 1)
+}
+
+func TestStartWorkers_RefusesUnusableSettings(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runner := newFakeRunner()
+	runner.queue = 1
+
+	StartWorkers(ctx, nil, 1, time.Second)
+	StartWorkers(ctx, runner, 0, time.Second)
+	StartWorkers(ctx, runner, 1, 0)
+
+	time.Sleep(20 * time.Millisecond)
+	runner.mu.Lock()
+	defer runner.mu.Unlock()
+	assert.Zero(t, runner.runs)
+}
@@ -20,6 +20,12 @@ type Config struct {
 	App      AppConfig
 	AEO      AEOConfig
 	Forms    FormsConfig
+	SLA      SLAConfig
+	Inbound  InboundEmailConfig
+	Webhooks WebhookConfig
+	Deals    DealConfig
+	Bulk     BulkConfig
+	OIDC     OIDCConfig
 }
 
 type DatabaseConfig struct {
@@ -50,6 +56,15 @@ type JWTConfig struct {
 	CookieSameSite     string
 	CookieDomain       string
 	CookieSecure       bool
+	// RevocationCacheSeconds is how stale the in-memory copy of the access
+	// token revocation list may get: a token revoked through another
+	// instance is refused here within this many seconds.
+	RevocationCacheSeconds int
+	// KeyCacheSeconds is how stale the in-memory copy of the signing keys may
+	// get: a key activated or retired through the jwt-keys command is used
+	// here within this many seconds. A token naming a key not in the copy
+	// reloads it at once.
+	KeyCacheSeconds int
 }
 
 type CSRFConfig struct {
@@ -149,6 +164,132 @@ func (f FormsConfig) RecaptchaActive() bool {
 	return f.RecaptchaSiteKey != "" && f.RecaptchaSecret != ""
 }
 
+// SLAConfig controls the background worker that flags missed SLA deadlines.
+// The policies themselves are data, managed through the API.
+type SLAConfig struct {
+	BreachMonitorEnabled bool
+	// BreachMonitorIntervalSeconds is how often the worker sweeps, and so how
+	// late a breach can be flagged. Values below 1 fall back to the default.
+	BreachMonitorIntervalSeconds int
+}
+
+// InboundEmailConfig controls the email-to-ticket gateway. It is off until a
+// Maildir is configured; the mail server delivers into that directory and the
+// poller turns each message into a ticket, a reply or a lead.
+type InboundEmailConfig struct {
+	MaildirPath string
+	// PollIntervalSeconds is how often the Maildir is read. Values below 1
+	// fall back to the default.
+	PollIntervalSeconds int
+	// UnknownSender is what happens to mail from an address that is not a
+	// customer: "quarantine" (held for review) or "lead". Any other value
+	// means quarantine, so a typo never turns strangers' mail into leads.
+	UnknownSender string
+	// LeadOwnerID owns the leads created from unknown senders. Without one
+	// the lead policy cannot apply and unknown senders are quarantined.
+	LeadOwnerID uint
+}
+
+// Enabled reports whether the gateway has a Maildir to read.
+func (c InboundEmailConfig) Enabled() bool {
+	return c.MaildirPath != ""
+}
+
+// CapturesLeads reports whether unknown senders become leads.
+func (c InboundEmailConfig) CapturesLeads() bool {
+	return c.UnknownSender == "lead" && c.LeadOwnerID != 0
+}
+
+// WebhookConfig controls the worker that sends queued webhook deliveries. The
+// subscriptions themselves are data, managed through the API; with the worker
+// disabled events are still queued, and go out once it is enabled again.
+type WebhookConfig struct {
+	DeliveryEnabled bool
+	// DeliveryIntervalSeconds is how often the queue is checked for due
+	// deliveries. Values below 1 fall back to the default.
+	DeliveryIntervalSeconds int
+	// MaxAttempts is how many times a delivery is tried before it is
+	// dead-lettered. Values below 1 fall back to the default.
+	MaxAttempts int
+	// TimeoutSeconds bounds one delivery request. Values below 1 fall back to
+	// the default.
+	TimeoutSeconds int
+}
+
+// DealConfig holds the sales pipeline's settings. The stages themselves are
+// data, managed through the API.
+type DealConfig struct {
+	// DefaultCurrency is the ISO 4217 code a deal gets when none is given,
+	// and the currency the pipeline reports use unless asked for another. A
+	// value that is not three letters falls back to USD.
+	DefaultCurrency string
+}
+
+// BulkConfig sizes the worker pool that runs queued bulk operations. With
+// fewer workers, operations wait longer in the queue; none is ever dropped.
+type BulkConfig struct {
+	// Workers is how many operations run at once. Values below 1 fall back to
+	// the default.
+	Workers int
+	// PollIntervalSeconds is how often an idle worker looks for queued
+	// operations it was not woken for, such as those left by a previous
+	// process. Values below 1 fall back to the default.
+	PollIntervalSeconds int
+	// RevertWindowHours is how long after it finishes a bulk update, delete
+	// or action can be reverted. Values below 1 fall back to the default.
+	RevertWindowHours int
+}
+
+// OIDCConfig configures single sign-on through an OpenID Connect provider.
+// It is off until an issuer and a client ID are set; local passwords keep
+// working alongside it unless EnforceDomains says otherwise.
+type OIDCConfig struct {
+	// Issuer is the provider's issuer URL; its discovery document is read
+	// from <Issuer>/.well-known/openid-configuration.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the frontend page the provider sends the browser back
+	// to. It must be registered with the provider as-is.
+	RedirectURL string
+	// Scopes are requested in addition to "openid".
+	Scopes []string
+	// RoleClaim names the ID token claim matched against RoleMapping. It may
+	// hold a string or a list of strings, such as group names.
+	RoleClaim string
+	// RoleMapping maps claim values to roles; the first entry whose value the
+	// claim holds wins.
+	RoleMapping []OIDCRoleMapping
+	// DefaultRole is given to a user no mapping matches. Empty means such a
+	// user is refused.
+	DefaultRole string
+	// Domains restricts sign-in to accounts with an email in one of these
+	// domains. Empty allows any domain.
+	Domains []string
+	// EnforceDomains disables password login and password reset for users
+	// whose email is in one of Domains, so they can only sign in through the
+	// provider.
+	EnforceDomains bool
+	// JITProvisioning creates an account on the first sign-in of someone who
+	// has none. Without it, only existing users can sign in.
+	JITProvisioning bool
+	// SyncRoles brings the role of a linked user in line with the mapping at
+	// every sign-in. Without it, the mapping only sets the role of accounts
+	// it provisions, and roles are managed here.
+	SyncRoles bool
+}
+
+// OIDCRoleMapping gives users whose role claim holds Value the role Role.
+type OIDCRoleMapping struct {
+	Value string
+	Role  string
+}
+
+// Enabled reports whether single sign-on is configured.
+func (c OIDCConfig) Enabled() bool {
+	return c.Issuer != "" && c.ClientID != ""
+}
+
 type RateLimitConfig struct {
 	PublicEndpoints  int
 	AuthenticatedAPI int
@@ -199,10 +340,12 @@ func Load() (*Config, error) {
 			// REFRESH_TOKEN_EXPIRY_DAYS is the canonical variable; the older
 			// JWT_REFRESH_TOKEN_DAYS is honoured as a fallback for existing
 			// environments. Default is 30 days.
-			RefreshTokenDays: getEnvAsInt("REFRESH_TOKEN_EXPIRY_DAYS", getEnvAsInt("JWT_REFRESH_TOKEN_DAYS", 30)),
-			CookieSameSite:   getEnv("JWT_COOKIE_SAMESITE", "Lax"),
-			CookieDomain:     getEnv("JWT_COOKIE_DOMAIN", ""),
-			CookieSecure:     resolveCookieSecure(getEnv("SERVER_MODE", "development")),
+			RefreshTokenDays:       getEnvAsInt("REFRESH_TOKEN_EXPIRY_DAYS", getEnvAsInt("JWT_REFRESH_TOKEN_DAYS", 30)),
+			CookieSameSite:         getEnv("JWT_COOKIE_SAMESITE", "Lax"),
+			CookieDomain:           getEnv("JWT_COOKIE_DOMAIN", ""),
+			CookieSecure:           resolveCookieSecure(getEnv("SERVER_MODE", "development")),
+			RevocationCacheSeconds: atLeastOne(getEnvAsInt("JWT_REVOCATION_CACHE_SECONDS", 30), 30),
+			KeyCacheSeconds:        atLeastOne(getEnvAsInt("JWT_KEY_CACHE_SECONDS", 60), 60),
 		},
 		Logging: LoggingConfig{
 			Level:  getEnv("LOG_LEVEL", "info"),
@@ -247,6 +390,44 @@ func Load() (*Config, error) {
 			RecaptchaSecret:   getEnv("RECAPTCHA_SECRET_KEY", ""),
 			RecaptchaMinScore: clampUnitInterval(getEnvAsFloat("RECAPTCHA_MIN_SCORE", 0.5)),
 		},
+		SLA: SLAConfig{
+			BreachMonitorEnabled:         getEnvAsBool("SLA_BREACH_MONITOR_ENABLED", true),
+			BreachMonitorIntervalSeconds: atLeastOne(getEnvAsInt("SLA_BREACH_MONITOR_INTERVAL_SECONDS", 60), 60),
+		},
+		Inbound: InboundEmailConfig{
+			MaildirPath:         getEnv("INBOUND_EMAIL_MAILDIR", ""),
+			PollIntervalSeconds: atLeastOne(getEnvAsInt("INBOUND_EMAIL_POLL_INTERVAL_SECONDS", 30), 30),
+			UnknownSender:       unknownSenderPolicy(getEnv("INBOUND_EMAIL_UNKNOWN_SENDER", "quarantine")),
+			LeadOwnerID:         uint(max(getEnvAsInt("INBOUND_EMAIL_LEAD_OWNER_ID", 0), 0)),
+		},
+		Webhooks: WebhookConfig{
+			DeliveryEnabled:         getEnvAsBool("WEBHOOK_DELIVERY_ENABLED", true),
+			DeliveryIntervalSeconds: atLeastOne(getEnvAsInt("WEBHOOK_DELIVERY_INTERVAL_SECONDS", 10), 10),
+			MaxAttempts:             atLeastOne(getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 10), 10),
+			TimeoutSeconds:          atLeastOne(getEnvAsInt("WEBHOOK_TIMEOUT_SECONDS", 10), 10),
+		},
+		Deals: DealConfig{
+			DefaultCurrency: currencyCode(getEnv("DEAL_DEFAULT_CURRENCY", "USD"), "USD"),
+		},
+		Bulk: BulkConfig{
+			Workers:             atLeastOne(getEnvAsInt("BULK_WORKERS", 2), 2),
+			PollIntervalSeconds: atLeastOne(getEnvAsInt("BULK_POLL_INTERVAL_SECONDS", 30), 30),
+			RevertWindowHours:   atLeastOne(getEnvAsInt("BULK_REVERT_WINDOW_HOURS", 24), 24),
+		},
+		OIDC: OIDCConfig{
+			Issuer:          strings.TrimRight(getEnv("OIDC_ISSUER", ""), "/"),
+			ClientID:        getEnv("OIDC_CLIENT_ID", ""),
+			ClientSecret:    getEnv("OIDC_CLIENT_SECRET", ""),
+			RedirectURL:     getEnv("OIDC_REDIRECT_URL", strings.TrimRight(getEnv("APP_BASE_URL", "http://localhost:5173"), "/")+"/auth/sso/callback"),
+			Scopes:          parseCommaList(getEnv("OIDC_SCOPES", "email,profile")),
+			RoleClaim:       getEnv("OIDC_ROLE_CLAIM", "groups"),
+			RoleMapping:     parseRoleMapping(getEnv("OIDC_ROLE_MAPPING", "")),
+			DefaultRole:     strings.TrimSpace(getEnv("OIDC_DEFAULT_ROLE", "")),
+			Domains:         lowerAll(parseCommaList(getEnv("OIDC_DOMAINS", ""))),
+			EnforceDomains:  getEnvAsBool("OIDC_ENFORCE_DOMAINS", false),
+			JITProvisioning: getEnvAsBool("OIDC_JIT_PROVISIONING", true),
+			SyncRoles:       getEnvAsBool("OIDC_SYNC_ROLES", false),
+		},
 	}
 
 	if config.Server.Mode == "production" && !config.JWT.CookieSecure {
@@ -314,6 +495,39 @@ func clampHour(hour int) int {
 	return hour
 }
 
+// unknownSenderPolicy normalises INBOUND_EMAIL_UNKNOWN_SENDER; anything but
+// "lead" is quarantine.
+func unknownSenderPolicy(value string) string {
+	if strings.EqualFold(strings.TrimSpace(value), "lead") {
+		return "lead"
+	}
+	return "quarantine"
+}
+
+// atLeastOne returns value, or fallback when value is below 1, so a mistyped
+// interval never makes a worker spin.
+func atLeastOne(value, fallback int) int {
+	if value < 1 {
+		return fallback
+	}
+	return value
+}
+
+// currencyCode upper-cases a three-letter currency code, or returns fallback
+// for anything else.
+func currencyCode(value, fallback string) string {
+	code := strings.ToUpper(strings.TrimSpace(value))
+	if len(code) != 3 {
+		return fallback
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return fallback
+		}
+	}
+	return code
+}
+
 // clampUnitInterval keeps a probability-like setting inside [0,1] instead of
 // rejecting it, so a mistyped threshold never prevents the application from
 // starting.
@@ -344,6 +558,29 @@ func parseTrustedProxies(val string) []string {
 	return parseCommaList(val)
 }
 
+// parseRoleMapping reads "value=role" pairs from a comma-separated list,
+// keeping their order. Entries without both halves are skipped.
+func parseRoleMapping(val string) []OIDCRoleMapping {
+	var mappings []OIDCRoleMapping
+	for _, entry := range parseCommaList(val) {
+		value, role, ok := strings.Cut(entry, "=")
+		value, role = strings.TrimSpace(value), strings.TrimSpace(role)
+		if !ok || value == "" || role == "" {
+			continue
+		}
+		mappings = append(mappings, OIDCRoleMapping{Value: value, Role: role})
+	}
+	return mappings
+}
+
+// lowerAll lower-cases every entry, for lists compared case-insensitively.
+func lowerAll(values []string) []string {
+	for i, v := range values {
+		values[i] = strings.ToLower(v)
+	}
+	return values
+}
+
 // parseCommaList splits a comma-separated env value into trimmed, non-empty
 // entries; nil when the value is empty.
 func parseCommaList(val string) []string {
@@ -26,6 +26,17 @@ func withCleanEnv(t *testing.T, setVars map[string]string, fn func()) {
 		"AEO_SCHEDULE_ENABLED", "AEO_SCHEDULE_HOUR",
 		// Forms keys, for the same reason as the AEO ones.
 		"PUBLIC_BASE_URL", "RECAPTCHA_SITE_KEY", "RECAPTCHA_SECRET_KEY", "RECAPTCHA_MIN_SCORE",
+		"SLA_BREACH_MONITOR_ENABLED", "SLA_BREACH_MONITOR_INTERVAL_SECONDS",
+		"INBOUND_EMAIL_MAILDIR", "INBOUND_EMAIL_POLL_INTERVAL_SECONDS",
+		"INBOUND_EMAIL_UNKNOWN_SENDER", "INBOUND_EMAIL_LEAD_OWNER_ID",
+		"WEBHOOK_DELIVERY_ENABLED", "WEBHOOK_DELIVERY_INTERVAL_SECONDS",
+		"WEBHOOK_MAX_ATTEMPTS", "WEBHOOK_TIMEOUT_SECONDS",
+		"DEAL_DEFAULT_CURRENCY",
+		"BULK_WORKERS", "BULK_POLL_INTERVAL_SECONDS",
+		"APP_BASE_URL", "OIDC_ISSUER", "OIDC_CLIENT_ID", "OIDC_CLIENT_SECRET", "OIDC_REDIRECT_URL",
+		"OIDC_SCOPES", "OIDC_ROLE_CLAIM", "OIDC_ROLE_MAPPING", "OIDC_DEFAULT_ROLE",
+		"OIDC_DOMAINS", "OIDC_ENFORCE_DOMAINS", "OIDC_JIT_PROVISIONING", "OIDC_SYNC_ROLES",
+		"JWT_REVOCATION_CACHE_SECONDS", "JWT_KEY_CACHE_SECONDS",
 	}
 
 	// Save originals.
@@ -383,6 +394,221 @@ func TestLoad_FormsOverrides(t *testing.T) {
 	})
 }
 
+func TestLoad_SLAMonitorSettings(t *testing.T) {
+	withCleanEnv(t, map[string]string{
+		"JWT_SECRET": validSecret(),
+	}, func() {
+		cfg, err := Load()
+		assert.NoError(t, err)
+		assert.True(t, cfg.SLA.BreachMonitorEnabled)
+		assert.Equal(t, 60, cfg.SLA.BreachMonitorIntervalSeconds)
+	})
+
+	withCleanEnv(t, map[string]string{
+		"JWT_SECRET":                          validSecret(),
+		"SLA_BREACH_MONITOR_ENABLED":          "false",
+		"SLA_BREACH_MONITOR_INTERVAL_SECONDS": "0",
+	}, func() {
+		cfg, err := Load()
+		assert.NoError(t, err)
+		assert.False(t, cfg.SLA.BreachMonitorEnabled)
+		assert.Equal(t, 60, cfg.SLA.BreachMonitorIntervalSeconds, "a zero interval falls back to the default")
+	})
+}
+
+func TestLoad_InboundEmailDefaults(t *testing.T) {
+	withCleanEnv(t, map[string]string{
+		"JWT_SECRET": validSecret(),
+	}, func() {
+		cfg, err := Load()
+		assert.NoError(t, err)
+		assert.False(t, cfg.Inbound.Enabled(), "the gateway is off until a maildir is set")
+		assert.Equal(t, 30, cfg.Inbound.PollIntervalSeconds)
+		assert.Equal(t, "quarantine", cfg.Inbound.UnknownSender)
+		assert.False(t, cfg.Inbound.CapturesLeads())
+	})
+}
+
+func TestLoad_InboundEmailUnknownSenderPolicy(t *testing.T) {
+	cases := []struct {
+		policy   string
+		owner    string
+		expected string
+		captures bool
+	}{
+		{policy: "lead", owner: "7", expected: "lead", captures: true},
+		{policy: " LEAD ", owner: "7", expected: "lead", captures: true},
+		// Without an owner there is nobody to give the lead to.
+		{policy: "lead", owner: "", expected: "lead", captures: false},
+		{policy: "lead", owner: "-3", expected: "lead", captures: false},
+		// A typo must not turn strangers' mail into leads.
+		{policy: "leads", owner: "7", expected: "quarantine", captures: false},
+		{policy: "", owner: "7", expected: "quarantine", captures: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.policy+"/"+tc.owner, func(t *testing.T) {
+			withCleanEnv(t, map[string]string{
+				"JWT_SECRET":                          validSecret(),
+				"INBOUND_EMAIL_MAILDIR":               "/var/mail/support",
+				"INBOUND_EMAIL_POLL_INTERVAL_SECONDS": "-5",
+				"INBOUND_EMAIL_UNKNOWN_SENDER":        tc.policy,
+				"INBOUND_EMAIL_LEAD_OWNER_ID":         tc.owner,
+			}, func() {
+				cfg, err := Load()
+				assert.NoError(t, err)
+				assert.True(t, cfg.Inbound.Enabled())
+				assert.Equal(t, 30, cfg.Inbound.PollIntervalSeconds)
+				assert.Equal(t, tc.expected, cfg.Inbound.UnknownSender)
+				assert.Equal(t, tc.captures, cfg.Inbound.CapturesLeads())
+			})
+		})
+	}
+}
+
+func TestLoad_WebhookDefaults(t *testing.T) {
+	withCleanEnv(t, map[string]string{
+		"JWT_SECRET": validSecret(),
+	}, func() {
+		cfg, err := Load()
+		assert.NoError(t, err)
+		assert.True(t, cfg.Webhooks.DeliveryEnabled)
+		assert.Equal(t, 10, cfg.Webhooks.DeliveryIntervalSeconds)
+		assert.Equal(t, 10, cfg.Webhooks.MaxAttempts)
+		assert.Equal(t, 10, cfg.Webhooks.TimeoutSeconds)
+	})
+}
+
+func TestLoad_WebhookSettingsBelowOneFallBack(t *testing.T) {
+	withCleanEnv(t, map[string]string{
+		"JWT_SECRET":                        validSecret(),
+		"WEBHOOK_DELIVERY_ENABLED":          "false",
+		"WEBHOOK_DELIVERY_INTERVAL_SECONDS": "0",
+		"WEBHOOK_MAX_ATTEMPTS":              "-1",
+		"WEBHOOK_TIMEOUT_SECONDS":           "5",
+	}, func() {
+		cfg, err := Load()
+		assert.NoError(t, err)
+		assert.False(t, cfg.Webhooks.DeliveryEnabled)
+		assert.Equal(t, 10, cfg.Webhooks.DeliveryIntervalSeconds)
+		assert.Equal(t, 10, cfg.Webhooks.MaxAttempts)
+		assert.Equal(t, 5, cfg.Webhooks.TimeoutSeconds)
+	})
+}
+
+func TestLoad_BulkWorkerSettings(t *testing.T) {
+	withCleanEnv(t, map[string]string{
+		"JWT_SECRET": validSecret(),
+	}, func() {
+		cfg, err := Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 2, cfg.Bulk.Workers)
+		assert.Equal(t, 30, cfg.Bulk.PollIntervalSeconds)
+		assert.Equal(t, 24, cfg.Bulk.RevertWindowHours)
+	})
+
+	withCleanEnv(t, map[string]string{
+		"JWT_SECRET":                 validSecret(),
+		"BULK_WORKERS":               "0",
+		"BULK_POLL_INTERVAL_SECONDS": "5",
+		"BULK_REVERT_WINDOW_HOURS":   "-1",
+	}, func() {
+		cfg, err := Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 2, cfg.Bulk.Workers)
+		assert.Equal(t, 5, cfg.Bulk.PollIntervalSeconds)
+		assert.Equal(t, 24, cfg.Bulk.RevertWindowHours)
+	})
+}
+
+func TestLoad_OIDCDefaults(t *testing.T) {
+	withCleanEnv(t, map[string]string{"JWT_SECRET": validSecret()}, func() {
+		cfg, err := Load()
+		assert.NoError(t, err)
+		assert.False(t, cfg.OIDC.Enabled())
+		assert.Equal(t, "http://localhost:5173/auth/sso/callback", cfg.OIDC.RedirectURL)
+		assert.Equal(t, []string{"email", "profile"}, cfg.OIDC.Scopes)
+		assert.Equal(t, "groups", cfg.OIDC.RoleClaim)
+		assert.True(t, cfg.OIDC.JITProvisioning)
+		assert.False(t, cfg.OIDC.EnforceDomains)
+		assert.False(t, cfg.OIDC.SyncRoles)
+	})
+}
+
+func TestLoad_OIDCOverrides(t *testing.T) {
+	withCleanEnv(t, map[string]string{
+		"JWT_SECRET":           validSecret(),
+		"OIDC_ISSUER":          "https://idp.example.com/",
+		"OIDC_CLIENT_ID":       "gophercrm",
+		"OIDC_ROLE_MAPPING":    "crm-admins=admin, broken, =sales, crm-support = support",
+		"OIDC_DOMAINS":         "Example.com, corp.example.com",
+		"OIDC_ENFORCE_DOMAINS": "true",
+		"OIDC_SYNC_ROLES":      "true",
+	}, func() {
+		cfg, err := Load()
+		assert.NoError(t, err)
+		assert.True(t, cfg.OIDC.Enabled())
+		assert.Equal(t, "https://idp.example.com", cfg.OIDC.Issuer)
+		assert.Equal(t, []OIDCRoleMapping{
+			{Value: "crm-admins", Role: "admin"},
+			{Value: "crm-support", Role: "support"},
+		}, cfg.OIDC.RoleMapping)
+		assert.Equal(t, []string{"example.com", "corp.example.com"}, cfg.OIDC.Domains)
+		assert.True(t, cfg.OIDC.EnforceDomains)
+		assert.True(t, cfg.OIDC.SyncRoles)
+	})
+}
+
+func TestLoad_RevocationCacheSeconds(t *testing.T) {
+	cases := map[string]int{"": 30, "5": 5, "0": 30, "-1": 30}
+	for value, expected := range cases {
+		env := map[string]string{"JWT_SECRET": validSecret()}
+		if value != "" {
+			env["JWT_REVOCATION_CACHE_SECONDS"] = value
+		}
+		withCleanEnv(t, env, func() {
+			cfg, err := Load()
+			assert.NoError(t, err)
+			assert.Equal(t, expected, cfg.JWT.RevocationCacheSeconds, "JWT_REVOCATION_CACHE_SECONDS=%q", value)
+		})
+	}
+}
+
+func TestLoad_KeyCacheSeconds(t *testing.T) {
+	cases := map[string]int{"": 60, "300": 300, "0": 60}
+	for value, expected := range cases {
+		env := map[string]string{"JWT_SECRET": validSecret()}
+		if value != "" {
+			env["JWT_KEY_CACHE_SECONDS"] = value
+		}
+		withCleanEnv(t, env, func() {
+			cfg, err := Load()
+			assert.NoError(t, err)
+			assert.Equal(t, expected, cfg.JWT.KeyCacheSeconds, "JWT_KEY_CACHE_SECONDS=%q", value)
+		})
+	}
+}
+
+func TestLoad_DealDefaultCurrency(t *testing.T) {
+	cases := map[string]string{
+		"":     "USD",
+		"eur":  "EUR",
+		" GBP": "GBP",
+		"EURO": "USD",
+		"E1R":  "USD",
+	}
+	for value, expected := range cases {
+		env := map[string]string{"JWT_SECRET": validSecret()}
+		if value != "" {
+			env["DEAL_DEFAULT_CURRENCY"] = value
+		}
+		withCleanEnv(t, env, func() {
+			cfg, err := Load()
+			assert.NoError(t, err)
+			assert.Equal(t, expected, cfg.Deals.DefaultCurrency, "DEAL_DEFAULT_CURRENCY=%q", value)
+		})
+	}
+}
+
 func TestFormsConfig_RecaptchaActiveNeedsBothKeys(t *testing.T) {
 	assert.False(t, FormsConfig{}.RecaptchaActive())
 	assert.False(t, FormsConfig{RecaptchaSiteKey: "site"}.RecaptchaActive())
@@ -21,8 +21,8 @@ var (
 	ErrCustomerNotFound        = errors.New("customer not found")
 	ErrLeadNotFound            = errors.New("lead not found")
 	ErrForbidden               = errors.New("forbidden")
-	ErrInvalidAssigneeRole     = errors.New("tickets can only be assigned to support or admin users")
-	ErrInvalidCustomerAssignee = errors.New("customers can only be assigned to sales or admin users")
+	ErrInvalidAssigneeRole     = errors.New("tickets can only be assigned to users whose role may update tickets")
+	ErrInvalidCustomerAssignee = errors.New("customers can only be assigned to users whose role may update customers")
 	ErrInactiveUser            = errors.New("cannot assign task to inactive user")
 	ErrClosedTicketReopen      = errors.New("cannot reopen closed ticket")
 	ErrCompletedTaskModify     = errors.New("cannot change status of completed task")
@@ -43,6 +43,32 @@ var (
 	ErrInvalidLabelColor  = errors.New("label color must be a hex value of the form #RRGGBB")
 	ErrLabelNotFound      = errors.New("label not found")
 
+	// ErrDuplicateAccount is answered with 409. Account names are compared by
+	// models.AccountNameKey, so "ACME Inc." collides with "Acme": two accounts
+	// for one organization would split its contacts between them.
+	ErrDuplicateAccount = errors.New("an account with this name already exists")
+
+	// ErrImportStarted is answered with 409: an import runs once, and a file
+	// whose import failed part way is imported again from its failed rows.
+	ErrImportStarted = errors.New("the import has already been started")
+
+	// ErrBulkOperationFinished is answered with 409: a bulk operation can only
+	// be cancelled while it is queued or running.
+	ErrBulkOperationFinished = errors.New("the bulk operation has already finished")
+
+	// ErrBulkRevertExpired and ErrBulkOperationReverted are answered with 409:
+	// a bulk operation can be reverted once, within the revert window.
+	// ErrBulkRowChanged is not answered on its own; it is why a record is left
+	// out of a revert.
+	ErrBulkRevertExpired     = errors.New("the bulk operation can no longer be reverted")
+	ErrBulkOperationReverted = errors.New("the bulk operation has already been reverted")
+	ErrBulkRowChanged        = errors.New("the record has changed since the bulk operation")
+
+	// ErrBuiltInRole and ErrRoleInUse are answered with 409: the built-in
+	// roles are fixed, and a role cannot be deleted while users hold it.
+	ErrBuiltInRole = errors.New("built-in roles cannot be changed")
+	ErrRoleInUse   = errors.New("the role is still assigned to users")
+
 	// AEO errors. The two conflict sentinels are answered with 409;
 	// ErrProfileNotConfigured is the exception that is answered with 404 on
 	// GET /aeo/profile (an unconfigured profile is a missing resource there)
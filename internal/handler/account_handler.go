@@ -0,0 +1,572 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/middleware"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type AccountHandler struct {
+	auditTrail
+	accountService service.AccountService
+}
+
+func NewAccountHandler(accountService service.AccountService) *AccountHandler {
+	return &AccountHandler{accountService: accountService}
+}
+
+// AccountRequest is the body of POST /accounts. Without owner_id an account
+// created by a sales user is theirs and one created by an admin has no owner.
+type AccountRequest struct {
+	Name       string             `json:"name" binding:"required,max=200"`
+	Domain     string             `json:"domain,omitempty" binding:"max=255"`
+	Industry   string             `json:"industry,omitempty" binding:"max=100"`
+	Size       models.AccountSize `json:"size,omitempty"`
+	Phone      string             `json:"phone,omitempty" binding:"max=50"`
+	Address    string             `json:"address,omitempty" binding:"max=255"`
+	City       string             `json:"city,omitempty" binding:"max=100"`
+	State      string             `json:"state,omitempty" binding:"max=100"`
+	Country    string             `json:"country,omitempty" binding:"max=100"`
+	PostalCode string             `json:"postal_code,omitempty" binding:"max=20"`
+	Notes      string             `json:"notes,omitempty"`
+	OwnerID    *uint              `json:"owner_id,omitempty"`
+}
+
+// UpdateAccountRequest is the body of PUT /accounts/:id. Omitted fields are
+// left alone; owner_id 0 leaves the account without an owner.
+type UpdateAccountRequest struct {
+	Name       *string             `json:"name,omitempty" binding:"omitempty,max=200"`
+	Domain     *string             `json:"domain,omitempty" binding:"omitempty,max=255"`
+	Industry   *string             `json:"industry,omitempty" binding:"omitempty,max=100"`
+	Size       *models.AccountSize `json:"size,omitempty"`
+	Phone      *string             `json:"phone,omitempty" binding:"omitempty,max=50"`
+	Address    *string             `json:"address,omitempty" binding:"omitempty,max=255"`
+	City       *string             `json:"city,omitempty" binding:"omitempty,max=100"`
+	State      *string             `json:"state,omitempty" binding:"omitempty,max=100"`
+	Country    *string             `json:"country,omitempty" binding:"omitempty,max=100"`
+	PostalCode *string             `json:"postal_code,omitempty" binding:"omitempty,max=20"`
+	Notes      *string             `json:"notes,omitempty"`
+	OwnerID    *uint               `json:"owner_id,omitempty"`
+}
+
+// Create godoc
+// @Summary Create an account
+// @Description Add an organization that leads and customers can be linked to (admin and sales roles). The name must not match an existing account once case, punctuation and legal forms such as "Inc." are ignored. Sales users own the accounts they create; admins may name any owner or none.
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param request body AccountRequest true "Account"
+// @Success 201 {object} utils.APIResponse{data=models.Account} "Account created successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid account"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin or sales role required; sales users cannot name another owner"
+// @Failure 409 {object} utils.APIResponse{error=utils.APIError} "An account with this name already exists"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /accounts [post]
+func (h *AccountHandler) Create(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "AccountHandler.Create")
+
+	var req AccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	currentUserID := c.GetUint("user_id")
+	ownerID := req.OwnerID
+	if !middleware.HasPermission(c, models.PermAccountsAssign) {
+		if ownerID != nil && *ownerID != currentUserID {
+			utils.RespondForbidden(c, "You can only create accounts you own")
+			return
+		}
+		ownerID = &currentUserID
+	} else if ownerID != nil && *ownerID == 0 {
+		ownerID = nil
+	}
+
+	account := &models.Account{
+		Name:       req.Name,
+		Domain:     req.Domain,
+		Industry:   req.Industry,
+		Size:       req.Size,
+		Phone:      req.Phone,
+		Address:    req.Address,
+		City:       req.City,
+		State:      req.State,
+		Country:    req.Country,
+		PostalCode: req.PostalCode,
+		Notes:      req.Notes,
+		OwnerID:    ownerID,
+	}
+	if err := h.accountService.Create(account); err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityAccount, account.ID, models.AuditActionCreate, nil, account)
+
+	utils.LogHandlerResponse(logger, http.StatusCreated, account)
+	utils.RespondSuccess(c, http.StatusCreated, account)
+}
+
+// List godoc
+// @Summary List accounts
+// @Description One page of accounts ordered by name (admin, sales and support roles).
+// @Tags accounts
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param offset query int false "Offset for pagination" default(0)
+// @Param limit query int false "Limit for pagination" default(20)
+// @Param search query string false "Match name, domain or industry"
+// @Param owner_id query int false "Only accounts of this owner"
+// @Param industry query string false "Only accounts in this industry"
+// @Param cursor query string false "Page by keyset instead of offset: empty for the first page, then the meta.next_cursor of the previous one"
+// @Success 200 {object} utils.APIResponse{data=object{accounts=[]models.Account,total=int},meta=utils.APIMeta} "Accounts retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid owner_id or cursor"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin, sales or support role required"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /accounts [get]
+func (h *AccountHandler) List(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "AccountHandler.List")
+
+	filter := models.AccountFilter{Search: c.Query("search"), Industry: c.Query("industry")}
+	if raw := c.Query("owner_id"); raw != "" {
+		ownerID, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			utils.RespondBadRequest(c, "Invalid owner_id")
+			return
+		}
+		filter.OwnerID = uint(ownerID)
+	}
+
+	offset, limit := utils.ParseOffsetLimit(c)
+	// Accounts are listed by name, and the cursor is taken in that order.
+	cursor, ok := parseListCursor(c, logger, "accounts", "name", "asc", models.CustomFieldQuery{})
+	if !ok {
+		return
+	}
+	filter.Cursor = cursor
+	fetchOffset, fetchLimit := cursorWindow(cursor, offset, limit)
+
+	accounts, total, err := h.accountService.List(filter, fetchOffset, fetchLimit)
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+	meta := accountPageMeta(c, offset, limit, total)
+	if cursor != nil {
+		var next string
+		if accounts, next, err = cursorPage(cursor, accounts, limit); err != nil {
+			logger.WithError(err).Error("Failed to encode the next cursor")
+			utils.RespondInternalError(c)
+			return
+		}
+		meta = cursorMeta(c, limit, total, next)
+	}
+	respondAccountPage(c, logger, "accounts", accounts, total, meta)
+}
+
+// Get godoc
+// @Summary Get an account
+// @Description One account with its owner (admin, sales and support roles).
+// @Tags accounts
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Account ID"
+// @Success 200 {object} utils.APIResponse{data=models.Account} "Account retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid account ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin, sales or support role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Account not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /accounts/{id} [get]
+func (h *AccountHandler) Get(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "AccountHandler.Get")
+
+	id, ok := accountID(c)
+	if !ok {
+		return
+	}
+	account, err := h.accountService.GetByID(id)
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, account)
+	utils.RespondSuccess(c, http.StatusOK, account)
+}
+
+// Update godoc
+// @Summary Update an account
+// @Description Change an account's fields (admin and sales roles). Omitted fields are left alone. Only admins can change the owner; owner_id 0 leaves the account without one.
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Account ID"
+// @Param request body UpdateAccountRequest true "Changed fields"
+// @Success 200 {object} utils.APIResponse{data=models.Account} "Account updated successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid account ID or fields"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin or sales role required, or reassignment by a non-admin"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Account not found"
+// @Failure 409 {object} utils.APIResponse{error=utils.APIError} "Another account has this name"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /accounts/{id} [put]
+func (h *AccountHandler) Update(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "AccountHandler.Update")
+
+	id, ok := accountID(c)
+	if !ok {
+		return
+	}
+	var req UpdateAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	account, err := h.accountService.GetByID(id)
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+	if req.OwnerID != nil {
+		var ownerID *uint
+		if *req.OwnerID != 0 {
+			ownerID = req.OwnerID
+		}
+		if !sameAccountOwner(ownerID, account.OwnerID) && !middleware.HasPermission(c, models.PermAccountsAssign) {
+			utils.RespondForbidden(c, "Only administrators can reassign accounts")
+			return
+		}
+		account.OwnerID, account.Owner = ownerID, nil
+	}
+
+	before := h.auditState(account)
+	if req.Name != nil {
+		account.Name = *req.Name
+	}
+	if req.Domain != nil {
+		account.Domain = *req.Domain
+	}
+	if req.Industry != nil {
+		account.Industry = *req.Industry
+	}
+	if req.Phone != nil {
+		account.Phone = *req.Phone
+	}
+	if req.Address != nil {
+		account.Address = *req.Address
+	}
+	if req.City != nil {
+		account.City = *req.City
+	}
+	if req.State != nil {
+		account.State = *req.State
+	}
+	if req.Country != nil {
+		account.Country = *req.Country
+	}
+	if req.PostalCode != nil {
+		account.PostalCode = *req.PostalCode
+	}
+	if req.Notes != nil {
+		account.Notes = *req.Notes
+	}
+	if req.Size != nil {
+		account.Size = *req.Size
+	}
+
+	updated, err := h.accountService.Update(account)
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityAccount, updated.ID, models.AuditActionUpdate, before, updated)
+
+	utils.LogHandlerResponse(logger, http.StatusOK, updated)
+	utils.RespondSuccess(c, http.StatusOK, updated)
+}
+
+// Delete godoc
+// @Summary Delete an account
+// @Description Delete an account (admin role only). Its leads and customers are unlinked, not deleted, and keep their company text.
+// @Tags accounts
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Account ID"
+// @Success 204 "Account deleted"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid account ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Account not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /accounts/{id} [delete]
+func (h *AccountHandler) Delete(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "AccountHandler.Delete")
+
+	id, ok := accountID(c)
+	if !ok {
+		return
+	}
+	before := h.auditLoad(func() (interface{}, error) { return h.accountService.GetByID(id) })
+	if err := h.accountService.Delete(id); err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityAccount, id, models.AuditActionDelete, before, nil)
+
+	utils.LogHandlerResponse(logger, http.StatusNoContent, nil)
+	c.Status(http.StatusNoContent)
+}
+
+// GetSummary godoc
+// @Summary Account summary
+// @Description Roll up everything attached to an account: its leads and customers, the tickets of its customers, the tasks of its leads and customers, and the deals of its customers, counted by outcome with the open pipeline totalled per currency. Admins see everything; sales users count only the leads and deals they own and the tasks assigned to them; support users see no leads or deals and only the tasks assigned to them.
+// @Tags accounts
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Account ID"
+// @Success 200 {object} utils.APIResponse{data=models.AccountSummary} "Summary retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid account ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin, sales or support role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Account not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /accounts/{id}/summary [get]
+func (h *AccountHandler) GetSummary(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "AccountHandler.GetSummary")
+
+	id, ok := accountID(c)
+	if !ok {
+		return
+	}
+	scope := models.AccountScope{
+		LeadOwnerID:    scopedOwner(c, models.PermLeadsReadAny),
+		DealOwnerID:    scopedOwner(c, models.PermDealsReadAny),
+		TaskAssigneeID: scopedOwner(c, models.PermTasksReadAny),
+		SkipLeads:      !middleware.HasPermission(c, models.PermLeadsRead),
+		SkipDeals:      !middleware.HasPermission(c, models.PermDealsRead),
+	}
+
+	summary, err := h.accountService.Summary(id, scope)
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, summary)
+	utils.RespondSuccess(c, http.StatusOK, summary)
+}
+
+// ListLeads godoc
+// @Summary List an account's leads
+// @Description The leads linked to an account, newest first (admin and sales roles; sales users see only their own leads).
+// @Tags accounts
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Account ID"
+// @Param offset query int false "Offset for pagination" default(0)
+// @Param limit query int false "Limit for pagination" default(20)
+// @Success 200 {object} utils.APIResponse{data=object{leads=[]models.Lead,total=int},meta=utils.APIMeta} "Leads retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid account ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin or sales role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Account not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /accounts/{id}/leads [get]
+func (h *AccountHandler) ListLeads(c *gin.Context) {
+	h.listRollup(c, "AccountHandler.ListLeads", "leads", func(id uint, offset, limit int) (interface{}, int64, error) {
+		return h.accountService.ListLeads(id, scopedOwner(c, models.PermLeadsReadAny), offset, limit)
+	})
+}
+
+// ListCustomers godoc
+// @Summary List an account's customers
+// @Description The customers linked to an account, newest first (admin, sales and support roles).
+// @Tags accounts
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Account ID"
+// @Param offset query int false "Offset for pagination" default(0)
+// @Param limit query int false "Limit for pagination" default(20)
+// @Success 200 {object} utils.APIResponse{data=object{customers=[]models.Customer,total=int},meta=utils.APIMeta} "Customers retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid account ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin, sales or support role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Account not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /accounts/{id}/customers [get]
+func (h *AccountHandler) ListCustomers(c *gin.Context) {
+	h.listRollup(c, "AccountHandler.ListCustomers", "customers", func(id uint, offset, limit int) (interface{}, int64, error) {
+		return h.accountService.ListCustomers(id, offset, limit)
+	})
+}
+
+// ListTickets godoc
+// @Summary List an account's tickets
+// @Description The tickets of the customers linked to an account, newest first (admin, sales and support roles).
+// @Tags accounts
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Account ID"
+// @Param offset query int false "Offset for pagination" default(0)
+// @Param limit query int false "Limit for pagination" default(20)
+// @Success 200 {object} utils.APIResponse{data=object{tickets=[]models.Ticket,total=int},meta=utils.APIMeta} "Tickets retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid account ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin, sales or support role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Account not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /accounts/{id}/tickets [get]
+func (h *AccountHandler) ListTickets(c *gin.Context) {
+	h.listRollup(c, "AccountHandler.ListTickets", "tickets", func(id uint, offset, limit int) (interface{}, int64, error) {
+		return h.accountService.ListTickets(id, offset, limit)
+	})
+}
+
+// ListTasks godoc
+// @Summary List an account's tasks
+// @Description The tasks linked to the leads and customers of an account, newest first (admin, sales and support roles; non-admins see only the tasks assigned to them).
+// @Tags accounts
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Account ID"
+// @Param offset query int false "Offset for pagination" default(0)
+// @Param limit query int false "Limit for pagination" default(20)
+// @Success 200 {object} utils.APIResponse{data=object{tasks=[]models.Task,total=int},meta=utils.APIMeta} "Tasks retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid account ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin, sales or support role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Account not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /accounts/{id}/tasks [get]
+func (h *AccountHandler) ListTasks(c *gin.Context) {
+	h.listRollup(c, "AccountHandler.ListTasks", "tasks", func(id uint, offset, limit int) (interface{}, int64, error) {
+		return h.accountService.ListTasks(id, scopedOwner(c, models.PermTasksReadAny), offset, limit)
+	})
+}
+
+// ListDeals godoc
+// @Summary List an account's deals
+// @Description The deals of the customers linked to an account, newest first (admin and sales roles; sales users see only their own deals).
+// @Tags accounts
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Account ID"
+// @Param offset query int false "Offset for pagination" default(0)
+// @Param limit query int false "Limit for pagination" default(20)
+// @Success 200 {object} utils.APIResponse{data=object{deals=[]models.Deal,total=int},meta=utils.APIMeta} "Deals retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid account ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin or sales role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Account not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /accounts/{id}/deals [get]
+func (h *AccountHandler) ListDeals(c *gin.Context) {
+	h.listRollup(c, "AccountHandler.ListDeals", "deals", func(id uint, offset, limit int) (interface{}, int64, error) {
+		return h.accountService.ListDeals(id, scopedOwner(c, models.PermDealsReadAny), offset, limit)
+	})
+}
+
+// listRollup serves one page of an account rollup; list keeps the caller to
+// the records they may read.
+func (h *AccountHandler) listRollup(c *gin.Context, operation, key string, list func(id uint, offset, limit int) (interface{}, int64, error)) {
+	logger := utils.LogHandlerStart(c, operation)
+
+	id, ok := accountID(c)
+	if !ok {
+		return
+	}
+	offset, limit := utils.ParseOffsetLimit(c)
+	records, total, err := list(id, offset, limit)
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+	respondAccountPage(c, logger, key, records, total, accountPageMeta(c, offset, limit, total))
+}
+
+func accountPageMeta(c *gin.Context, offset, limit int, total int64) *utils.APIMeta {
+	return &utils.APIMeta{
+		RequestID:  c.GetString("request_id"),
+		Page:       (offset / limit) + 1,
+		PerPage:    limit,
+		Total:      total,
+		TotalPages: (total + int64(limit) - 1) / int64(limit),
+	}
+}
+
+func respondAccountPage(c *gin.Context, logger *logrus.Entry, key string, records interface{}, total int64, meta *utils.APIMeta) {
+	responseData := gin.H{key: records, "total": total}
+	utils.LogHandlerResponse(logger, http.StatusOK, responseData)
+	utils.RespondSuccessWithMeta(c, http.StatusOK, responseData, meta)
+}
+
+func accountID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid account ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func sameAccountOwner(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func (h *AccountHandler) respondError(c *gin.Context, logger *logrus.Entry, err error) {
+	switch {
+	case errors.Is(err, apperrors.ErrDuplicateAccount):
+		logger.WithError(err).Warn("Duplicate account")
+		utils.RespondConflict(c, err.Error())
+	case errors.Is(err, apperrors.ErrValidation):
+		logger.WithError(err).Warn("Invalid account request")
+		utils.RespondBadRequest(c, err.Error())
+	case apperrors.IsNotFound(err):
+		logger.WithError(err).Warn("Account not found")
+		utils.RespondNotFound(c, "Account not found")
+	default:
+		logger.WithError(err).Error("Account operation failed")
+		utils.RespondInternalError(c)
+	}
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+var _ service.AccountService = (*mocks.AccountService)(nil)
+
+type AccountHandlerTestSuite struct {
+	suite.Suite
+	mockService *mocks.AccountService
+	mockAudit   *mocks.AuditService
+	handler     *AccountHandler
+}
+
+func (suite *AccountHandlerTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *AccountHandlerTestSuite) SetupTest() {
+	suite.mockService = new(mocks.AccountService)
+	suite.mockAudit = new(mocks.AuditService)
+	suite.handler = NewAccountHandler(suite.mockService)
+}
+
+func (suite *AccountHandlerTestSuite) TearDownTest() {
+	suite.mockService.AssertExpectations(suite.T())
+	suite.mockAudit.AssertExpectations(suite.T())
+}
+
+func (suite *AccountHandlerTestSuite) do(role models.UserRole, userID uint, method, path string, body interface{}) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Set("user_role", string(role))
+		c.Next()
+	})
+	SetupAccountRoutes(router.Group(""), suite.handler)
+
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (suite *AccountHandlerTestSuite) TestRoutes_RoleGuards() {
+	w := suite.do(models.RoleSupport, 3, http.MethodPost, "/accounts", gin.H{"name": "Acme"})
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = suite.do(models.RoleSupport, 3, http.MethodGet, "/accounts/4/deals", nil)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = suite.do(models.RoleSales, 5, http.MethodDelete, "/accounts/4", nil)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	suite.mockService.On("ListTickets", uint(4), 0, 20).Return([]models.Ticket{}, int64(0), nil)
+	w = suite.do(models.RoleSupport, 3, http.MethodGet, "/accounts/4/tickets", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *AccountHandlerTestSuite) TestCreate_SalesOwnsTheAccount() {
+	suite.handler.SetAuditService(suite.mockAudit)
+	suite.mockService.On("Create", mock.MatchedBy(func(a *models.Account) bool {
+		return a.Name == "Acme" && a.OwnerID != nil && *a.OwnerID == 5
+	})).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Account).ID = 4
+	}).Return(nil)
+	suite.mockAudit.On("Record", mock.Anything, models.AuditEntityAccount, uint(4), models.AuditActionCreate,
+		mock.Anything, mock.Anything).Return(nil)
+
+	w := suite.do(models.RoleSales, 5, http.MethodPost, "/accounts", gin.H{"name": "Acme"})
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	w = suite.do(models.RoleSales, 5, http.MethodPost, "/accounts", gin.H{"name": "Acme", "owner_id": 6})
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *AccountHandlerTestSuite) TestCreate_AdminMayLeaveItUnowned() {
+	suite.mockService.On("Create", mock.MatchedBy(func(a *models.Account) bool {
+		return a.OwnerID == nil
+	})).Return(nil)
+
+	w := suite.do(models.RoleAdmin, 1, http.MethodPost, "/accounts", gin.H{"name": "Acme"})
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+}
+
+func (suite *AccountHandlerTestSuite) TestCreate_Duplicate() {
+	suite.mockService.On("Create", mock.Anything).
+		Return(fmt.Errorf("account 2 is already named \"Acme\": %w", apperrors.ErrDuplicateAccount))
+
+	w := suite.do(models.RoleAdmin, 1, http.MethodPost, "/accounts", gin.H{"name": "ACME Inc."})
+
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func (suite *AccountHandlerTestSuite) TestUpdate_OnlyAdminsReassign() {
+	owner := uint(5)
+	suite.mockService.On("GetByID", uint(4)).Return(&models.Account{BaseModel: models.BaseModel{ID: 4}, Name: "Acme", OwnerID: &owner}, nil)
+
+	w := suite.do(models.RoleSales, 5, http.MethodPut, "/accounts/4", gin.H{"owner_id": 6})
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	suite.mockService.On("Update", mock.MatchedBy(func(a *models.Account) bool {
+		return a.OwnerID == nil && a.Name == "Acme" && a.Industry == "Retail"
+	})).Return(&models.Account{BaseModel: models.BaseModel{ID: 4}}, nil)
+	w = suite.do(models.RoleAdmin, 1, http.MethodPut, "/accounts/4", gin.H{"owner_id": 0, "industry": "Retail"})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *AccountHandlerTestSuite) TestSummary_ScopesByRole() {
+	suite.mockService.On("Summary", uint(4), models.AccountScope{}).Return(&models.AccountSummary{AccountID: 4}, nil).Once()
+	suite.mockService.On("Summary", uint(4), models.AccountScope{LeadOwnerID: 5, DealOwnerID: 5, TaskAssigneeID: 5}).Return(&models.AccountSummary{AccountID: 4}, nil).Once()
+	suite.mockService.On("Summary", uint(4), models.AccountScope{LeadOwnerID: 3, DealOwnerID: 3, TaskAssigneeID: 3, SkipLeads: true, SkipDeals: true}).Return(&models.AccountSummary{AccountID: 4}, nil).Once()
+
+	for role, userID := range map[models.UserRole]uint{models.RoleAdmin: 1, models.RoleSales: 5, models.RoleSupport: 3} {
+		w := suite.do(role, userID, http.MethodGet, "/accounts/4/summary", nil)
+		assert.Equal(suite.T(), http.StatusOK, w.Code, role)
+	}
+}
+
+func (suite *AccountHandlerTestSuite) TestListDeals_SalesSeesOwnDeals() {
+	suite.mockService.On("ListDeals", uint(4), uint(5), 0, 20).Return([]models.Deal{{Title: "Renewal"}}, int64(1), nil)
+
+	w := suite.do(models.RoleSales, 5, http.MethodGet, "/accounts/4/deals", nil)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var response utils.APIResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), int64(1), response.Meta.Total)
+}
+
+func (suite *AccountHandlerTestSuite) TestGet_NotFound() {
+	suite.mockService.On("GetByID", uint(4)).Return(nil, fmt.Errorf("account 4: %w", apperrors.ErrNotFound))
+
+	w := suite.do(models.RoleSupport, 3, http.MethodGet, "/accounts/4", nil)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func TestAccountHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(AccountHandlerTestSuite))
+}
@@ -187,8 +187,10 @@ func (h *AEOHandler) SaveProfile(c *gin.Context) {
 // @Param limit query int false "Page size (max 100)" default(20)
 // @Param sort_by query string false "Sort column" Enums(id, text, is_active, created_at, updated_at)
 // @Param sort_order query string false "Sort direction" Enums(asc, desc) default(desc)
+// @Param filter query string false "Structured filter, e.g. text contains pricing and created_at gte -30d. Fields: id, text, is_active, created_by_id, created_at, updated_at"
+// @Param cursor query string false "Page by keyset instead of offset: empty for the first page, then the meta.next_cursor of the previous one, with the same sort_by and sort_order"
 // @Success 200 {object} utils.APIResponse{data=[]models.AEOPrompt,meta=utils.APIMeta} "Prompts retrieved successfully"
-// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Unsupported sort column"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Unsupported sort column, invalid filter or invalid cursor"
 // @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
 // @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin, sales or support role required"
 // @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
@@ -206,8 +208,25 @@ func (h *AEOHandler) ListPrompts(c *gin.Context) {
 		utils.RespondBadRequest(c, err.Error())
 		return
 	}
+	where, ok := parseListFilter(c, logger, "aeo_prompts")
+	if !ok {
+		return
+	}
+
+	// Without a sort_by the list is newest first whatever sort_order says,
+	// and the cursor is taken in that order.
+	cursorSortBy, cursorOrder := sortBy, sortOrder
+	if cursorSortBy == "" {
+		cursorSortBy, cursorOrder = "created_at", "desc"
+	}
+	cursor, ok := parseListCursor(c, logger, "aeo_prompts", cursorSortBy, cursorOrder, models.CustomFieldQuery{})
+	if !ok {
+		return
+	}
+	fetchOffset, fetchLimit := cursorWindow(cursor, offset, limit)
 
-	prompts, total, err := h.aeoService.ListPrompts(from, to, activeOnly, offset, limit, sortBy, sortOrder)
+	filter := models.AEOPromptFilter{ActiveOnly: activeOnly, Where: where, Cursor: cursor}
+	prompts, total, err := h.aeoService.ListPrompts(from, to, filter, fetchOffset, fetchLimit, sortBy, sortOrder)
 	if err != nil {
 		h.respondError(c, logger, err, "AEO prompt not found")
 		return
@@ -217,6 +236,15 @@ func (h *AEOHandler) ListPrompts(c *gin.Context) {
 	}
 
 	meta := aeoListMeta(c, offset, limit, total)
+	if cursor != nil {
+		var next string
+		if prompts, next, err = cursorPage(cursor, prompts, limit); err != nil {
+			logger.WithError(err).Error("Failed to encode the next cursor")
+			utils.RespondInternalError(c)
+			return
+		}
+		meta = cursorMeta(c, limit, total, next)
+	}
 	utils.LogHandlerResponse(logger, http.StatusOK, prompts)
 	utils.RespondSuccessWithMeta(c, http.StatusOK, prompts, meta)
 }
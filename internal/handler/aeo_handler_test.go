@@ -286,7 +286,7 @@ func (suite *AEOHandlerTestSuite) TestListPrompts_DefaultsToA30DayWindow() {
 	suite.mockService.On("ListPrompts",
 		mock.MatchedBy(func(from time.Time) bool { return from.Equal(expectedTo.AddDate(0, 0, -30)) }),
 		mock.MatchedBy(func(to time.Time) bool { return to.Equal(expectedTo) }),
-		false, 0, 20, "", "desc").
+		models.AEOPromptFilter{}, 0, 20, "", "desc").
 		Return([]models.AEOPrompt{{
 			BaseModel: models.BaseModel{ID: 4}, Text: "Which CRM?", IsActive: true, Visibility: 42.5,
 		}}, int64(1), nil)
@@ -309,7 +309,7 @@ func (suite *AEOHandlerTestSuite) TestListPrompts_HonoursTheSupportedWindows() {
 
 		suite.mockService.On("ListPrompts",
 			mock.MatchedBy(func(from time.Time) bool { return from.Equal(expectedFrom) }),
-			mock.Anything, true, 0, 20, "", "desc").
+			mock.Anything, models.AEOPromptFilter{ActiveOnly: true}, 0, 20, "", "desc").
 			Return([]models.AEOPrompt{}, int64(0), nil).Once()
 
 		w := suite.do(http.MethodGet, fmt.Sprintf("/aeo/prompts?days=%d&active_only=true", days), nil)
@@ -324,7 +324,7 @@ func (suite *AEOHandlerTestSuite) TestListPrompts_UnsupportedWindowFallsBackTo30
 
 	suite.mockService.On("ListPrompts",
 		mock.MatchedBy(func(from time.Time) bool { return from.Equal(expectedTo.AddDate(0, 0, -30)) }),
-		mock.Anything, false, 0, 20, "", "desc").
+		mock.Anything, models.AEOPromptFilter{}, 0, 20, "", "desc").
 		Return([]models.AEOPrompt{}, int64(0), nil)
 
 	w := suite.do(http.MethodGet, "/aeo/prompts?days=365", nil)
@@ -338,6 +338,44 @@ func (suite *AEOHandlerTestSuite) TestListPrompts_UnknownSortColumnIs400() {
 		mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
+// Without a sort_by the cursor is taken in the list's own order, newest
+// first, whatever sort_order says.
+func (suite *AEOHandlerTestSuite) TestListPrompts_CursorPages() {
+	created := time.Date(2026, 8, 1, 9, 0, 0, 0, time.UTC)
+	suite.mockService.On("ListPrompts", mock.Anything, mock.Anything,
+		mock.MatchedBy(func(f models.AEOPromptFilter) bool {
+			return f.Cursor != nil && f.Cursor.After == nil && f.Cursor.SortBy == "created_at" && f.Cursor.Desc
+		}), 0, 3, "", "asc").
+		Return([]models.AEOPrompt{
+			{BaseModel: models.BaseModel{ID: 9, CreatedAt: created.Add(time.Hour)}},
+			{BaseModel: models.BaseModel{ID: 8, CreatedAt: created}},
+			{BaseModel: models.BaseModel{ID: 7, CreatedAt: created}},
+		}, int64(3), nil).Once()
+
+	w := suite.do(http.MethodGet, "/aeo/prompts?cursor=&limit=2&offset=40&sort_order=asc", nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	response := decodeResponse(suite.T(), w)
+	suite.Require().NotNil(response.Meta)
+	assert.Zero(suite.T(), response.Meta.Page, "a cursor has no page number")
+	next := response.Meta.NextCursor
+	suite.Require().NotEmpty(next)
+
+	suite.mockService.On("ListPrompts", mock.Anything, mock.Anything,
+		mock.MatchedBy(func(f models.AEOPromptFilter) bool {
+			return f.Cursor != nil && f.Cursor.After != nil && f.Cursor.After.ID == 8 &&
+				created.Equal(f.Cursor.After.Value.(time.Time))
+		}), 0, 3, "", "asc").
+		Return([]models.AEOPrompt{{BaseModel: models.BaseModel{ID: 7, CreatedAt: created}}}, int64(3), nil).Once()
+
+	w = suite.do(http.MethodGet, "/aeo/prompts?limit=2&sort_order=asc&cursor="+next, nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	response = decodeResponse(suite.T(), w)
+	assert.Empty(suite.T(), response.Meta.NextCursor, "the last page has no next cursor")
+
+	w = suite.do(http.MethodGet, "/aeo/prompts?sort_by=text&cursor="+next, nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code, "a cursor replayed under another sort")
+}
+
 func (suite *AEOHandlerTestSuite) TestListPrompts_EmptyResultIsAnArrayNotNull() {
 	suite.mockService.On("ListPrompts",
 		mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
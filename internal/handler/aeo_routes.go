@@ -14,8 +14,8 @@ import (
 // admin-only.
 func SetupAEORoutes(router *gin.RouterGroup, h *AEOHandler) {
 	group := router.Group("/aeo")
-	group.Use(middleware.RequireRole(models.RoleAdmin, models.RoleSales, models.RoleSupport))
-	write := middleware.RequireRole(models.RoleAdmin, models.RoleSales)
+	group.Use(middleware.RequirePermission(models.PermAEORead))
+	write := middleware.RequirePermission(models.PermAEOWrite)
 	{
 		group.GET("/profile", h.GetProfile)
 		group.PUT("/profile", write, h.SaveProfile)
@@ -24,7 +24,7 @@ func SetupAEORoutes(router *gin.RouterGroup, h *AEOHandler) {
 		group.POST("/prompts/generate", write, h.GeneratePrompts)
 		group.GET("/prompts/:id/answers", h.ListPromptAnswers)
 		group.PUT("/prompts/:id", write, h.UpdatePrompt)
-		group.DELETE("/prompts/:id", middleware.RequireRole(models.RoleAdmin), h.DeletePrompt)
+		group.DELETE("/prompts/:id", middleware.RequirePermission(models.PermAEODelete), h.DeletePrompt)
 		group.POST("/prompts/:id/run", write, h.RunPrompt)
 		group.POST("/runs", write, h.CreateRun)
 		group.GET("/runs", h.ListRuns)
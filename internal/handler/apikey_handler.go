@@ -7,10 +7,12 @@ import (
 	"time"
 
 	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/middleware"
 	"github.com/florinel-chis/gophercrm/internal/models"
 	"github.com/florinel-chis/gophercrm/internal/service"
 	"github.com/florinel-chis/gophercrm/internal/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
 type APIKeyHandler struct {
@@ -28,6 +30,14 @@ type CreateAPIKeyRequest struct {
 	// value produces a plain 400 with a message naming the field, instead of the
 	// opaque unmarshal error the time codec would raise.
 	ExpiresAt string `json:"expires_at"`
+	// Scopes are what the key may do, as entity:read or entity:write; *:read
+	// and *:write cover every entity.
+	Scopes []models.APIKeyScope `json:"scopes" binding:"required,min=1"`
+	// AllowedCIDRs are the networks the key may be used from, in CIDR notation
+	// or as single addresses. Empty allows every address.
+	AllowedCIDRs []string `json:"allowed_cidrs"`
+	// RateLimitPerMinute caps the key's requests a minute; 0 sets no cap.
+	RateLimitPerMinute int `json:"rate_limit_per_minute"`
 }
 
 type CreateAPIKeyResponse struct {
@@ -42,10 +52,30 @@ type UpdateAPIKeyRequest struct {
 	IsActive *bool   `json:"is_active"`
 }
 
+// callingKeyCovers reports whether the API key the request is made with is at
+// least as broad as restrictions, answering 403 when it is not. Otherwise a
+// key allowed to write api_keys could mint or revive for its owner a key
+// without its scopes, networks or rate limit, and escape them all.
+func (h *APIKeyHandler) callingKeyCovers(c *gin.Context, logger *logrus.Entry, restrictions models.APIKeyRestrictions) bool {
+	caller, err := h.apiKeyService.GetByID(c.GetUint("api_key_id"), c.GetUint("user_id"))
+	if err != nil {
+		logger.WithError(err).Error("Failed to load the calling API key")
+		utils.RespondInternalError(c)
+		return false
+	}
+	if !caller.Covers(restrictions) {
+		utils.RespondForbidden(c, "An API key cannot grant more scopes, networks or requests than it holds itself")
+		return false
+	}
+	return true
+}
+
 // Create godoc
 // @Summary Create a new API key
 // @Description Create an API key for the authenticated user. The plaintext key is returned only in this response — only its HMAC hash is stored, so it can never be retrieved again. Any authenticated role may create a key, and the key always belongs to the caller.
 // @Description
+// @Description `scopes` are required and name what the key may do, each as `entity:read` or `entity:write` (write includes read); `*:read` and `*:write` cover every entity. A request outside the key's scopes is refused with 403, and the key never does more than its owner may. `allowed_cidrs` optionally holds the key to networks (CIDR notation or single addresses), and `rate_limit_per_minute` to a request rate of its own (0 for none, at most 10000). These restrictions are fixed once the key is minted. A request made with an API key may only create a key its own restrictions cover — no scope, network or request rate beyond its own — and is otherwise refused with 403.
+// @Description
 // @Description The optional `expires_at` field is an RFC3339 timestamp (for example `2026-12-31T23:59:59Z`). Omit it and the key never expires. A timestamp in the past is rejected with 400 rather than minting a credential that could never authenticate. Expiry is enforced on every request in AuthService.ValidateAPIKey and cannot be lifted afterwards — only revocation is reversible.
 // @Tags api-keys
 // @Accept json
@@ -54,8 +84,9 @@ type UpdateAPIKeyRequest struct {
 // @Security ApiKeyAuth
 // @Param request body CreateAPIKeyRequest true "API key creation request"
 // @Success 201 {object} utils.APIResponse{data=CreateAPIKeyResponse} "API key created successfully; contains the plaintext key"
-// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid request data, or expires_at is not RFC3339 or lies in the past"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid request data, an unknown scope or network, or expires_at is not RFC3339 or lies in the past"
 // @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - the calling API key does not cover the requested restrictions"
 // @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
 // @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
 // @Router /api-keys [post]
@@ -86,8 +117,30 @@ func (h *APIKeyHandler) Create(c *gin.Context) {
 
 	userID := c.GetUint("user_id")
 
-	key, apiKey, err := h.apiKeyService.Generate(userID, req.Name, expiresAt)
+	restrictions := models.APIKeyRestrictions{
+		Scopes:             req.Scopes,
+		AllowedCIDRs:       req.AllowedCIDRs,
+		RateLimitPerMinute: req.RateLimitPerMinute,
+	}
+
+	if c.GetUint("api_key_id") != 0 {
+		if err := restrictions.Validate(); err != nil {
+			logger.WithError(err).Warn("Invalid API key restrictions")
+			utils.RespondBadRequest(c, err.Error())
+			return
+		}
+		if !h.callingKeyCovers(c, logger, restrictions) {
+			return
+		}
+	}
+
+	key, apiKey, err := h.apiKeyService.GenerateRestricted(userID, req.Name, expiresAt, restrictions)
 	if err != nil {
+		if errors.Is(err, apperrors.ErrValidation) {
+			logger.WithError(err).Warn("Invalid API key restrictions")
+			utils.RespondBadRequest(c, err.Error())
+			return
+		}
 		logger.WithError(err).Error("Failed to generate API key")
 		utils.RespondInternalError(c)
 		return
@@ -183,7 +236,7 @@ func (h *APIKeyHandler) Get(c *gin.Context) {
 // @Summary Update an API key
 // @Description Rename an API key and/or change whether it is active. Only the fields present in the body are applied; a body carrying neither `name` nor `is_active` is rejected with 400 rather than treated as a successful no-op. Only the owner may update a key — there is no admin override, and a key belonging to another user is rejected with 403.
 // @Description
-// @Description Setting `is_active` to true on a revoked key **reactivates** it. Revocation is an owner-controlled flag, not a tombstone, so the owner who turned it off may turn it back on; the plaintext key is unchanged and starts working again. Callers wanting an irreversible kill should create a new key and stop using the old one. Expiry is not affected by this endpoint: an expired key stays unusable no matter what `is_active` says, because expiry is checked separately at authentication time.
+// @Description Setting `is_active` to true on a revoked key **reactivates** it. Revocation is an owner-controlled flag, not a tombstone, so the owner who turned it off may turn it back on; the plaintext key is unchanged and starts working again. Callers wanting an irreversible kill should create a new key and stop using the old one. Expiry is not affected by this endpoint: an expired key stays unusable no matter what `is_active` says, because expiry is checked separately at authentication time. Made with an API key, the request may only reactivate a key the calling key's restrictions cover, and is otherwise refused with 403.
 // @Tags api-keys
 // @Accept json
 // @Produce json
@@ -194,7 +247,7 @@ func (h *APIKeyHandler) Get(c *gin.Context) {
 // @Success 200 {object} utils.APIResponse{data=models.APIKey} "API key updated successfully"
 // @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid API key ID, invalid name, or no updatable field supplied"
 // @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
-// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - the API key belongs to another user"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - the API key belongs to another user, or the calling API key does not cover it"
 // @Failure 404 {object} utils.APIResponse{error=utils.APIError} "API key not found"
 // @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
 // @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
@@ -222,6 +275,15 @@ func (h *APIKeyHandler) Update(c *gin.Context) {
 
 	userID := c.GetUint("user_id")
 
+	// Reactivating a revoked key brings its restrictions back into force, so
+	// a key may only reactivate one it covers.
+	if req.IsActive != nil && *req.IsActive && c.GetUint("api_key_id") != 0 {
+		target, err := h.apiKeyService.GetByID(uint(id), userID)
+		if err == nil && !h.callingKeyCovers(c, logger, target.APIKeyRestrictions) {
+			return
+		}
+	}
+
 	apiKey, err := h.apiKeyService.Update(uint(id), userID, req.Name, req.IsActive)
 	if err != nil {
 		if errors.Is(err, apperrors.ErrForbidden) {
@@ -292,3 +354,66 @@ func (h *APIKeyHandler) Revoke(c *gin.Context) {
 	utils.LogHandlerResponse(logger, http.StatusOK, nil)
 	utils.RespondSuccess(c, http.StatusOK, gin.H{"message": "API key revoked successfully"})
 }
+
+// Usage godoc
+// @Summary List an API key's requests
+// @Description The request log of one API key, newest first: the method, path, response status, client address and time of every request made with it, including those its scopes, networks or rate limit turned away. The owner may read their own keys' logs; holders of `audit:read` may read any key's.
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "API key ID"
+// @Param offset query int false "Pagination offset" default(0)
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Success 200 {object} utils.APIResponse{data=object{usage=[]models.APIKeyUsage,total=int},meta=utils.APIMeta} "Usage retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid API key ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - the API key belongs to another user"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "API key not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /api-keys/{id}/usage [get]
+func (h *APIKeyHandler) Usage(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "APIKeyHandler.Usage")
+
+	idParam := c.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid API key ID")
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	anyKey := middleware.HasPermission(c, models.PermAuditRead)
+	offset, limit := utils.ParseOffsetLimit(c)
+
+	usage, total, err := h.apiKeyService.ListUsage(uint(id), userID, anyKey, offset, limit)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrForbidden) {
+			logger.Warn("Unauthorized attempt to read API key usage")
+			utils.RespondForbidden(c, "You are not authorized to view this API key")
+			return
+		}
+		if apperrors.IsNotFound(err) {
+			logger.WithError(err).Warn("API key not found")
+			utils.RespondNotFound(c, "API key not found")
+			return
+		}
+
+		logger.WithError(err).Error("Failed to list API key usage")
+		utils.RespondInternalError(c)
+		return
+	}
+
+	meta := &utils.APIMeta{
+		RequestID:  c.GetString("request_id"),
+		Page:       (offset / limit) + 1,
+		PerPage:    limit,
+		Total:      total,
+		TotalPages: (total + int64(limit) - 1) / int64(limit),
+	}
+
+	responseData := gin.H{"usage": usage, "total": total}
+	utils.LogHandlerResponse(logger, http.StatusOK, responseData)
+	utils.RespondSuccessWithMeta(c, http.StatusOK, responseData, meta)
+}
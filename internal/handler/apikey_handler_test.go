@@ -23,6 +23,10 @@ import (
 	"gorm.io/gorm"
 )
 
+// leadsReadRestrictions is what a key minted with only the leads:read scope is
+// held to.
+var leadsReadRestrictions = models.APIKeyRestrictions{Scopes: []models.APIKeyScope{"leads:read"}}
+
 type APIKeyHandlerTestSuite struct {
 	suite.Suite
 	mockService *mocks.APIKeyService
@@ -68,10 +72,10 @@ func (suite *APIKeyHandlerTestSuite) TestCreate_Success() {
 		Name:      "Test Key",
 	}
 
-	suite.mockService.On("Generate", uint(1), "Test Key", (*time.Time)(nil)).
+	suite.mockService.On("GenerateRestricted", uint(1), "Test Key", (*time.Time)(nil), leadsReadRestrictions).
 		Return("raw-api-key-value", expectedAPIKey, nil)
 
-	payload := CreateAPIKeyRequest{Name: "Test Key"}
+	payload := CreateAPIKeyRequest{Name: "Test Key", Scopes: leadsReadRestrictions.Scopes}
 	body, _ := json.Marshal(payload)
 	req := httptest.NewRequest(http.MethodPost, "/api-keys", bytes.NewBuffer(body))
 	req.Header.Set("Content-Type", "application/json")
@@ -91,10 +95,10 @@ func (suite *APIKeyHandlerTestSuite) TestCreate_Success() {
 func (suite *APIKeyHandlerTestSuite) TestCreate_Error() {
 	suite.router.POST("/api-keys", suite.handler.Create)
 
-	suite.mockService.On("Generate", uint(1), "Test Key", (*time.Time)(nil)).
+	suite.mockService.On("GenerateRestricted", uint(1), "Test Key", (*time.Time)(nil), leadsReadRestrictions).
 		Return("", nil, errors.New("generation failed"))
 
-	payload := CreateAPIKeyRequest{Name: "Test Key"}
+	payload := CreateAPIKeyRequest{Name: "Test Key", Scopes: leadsReadRestrictions.Scopes}
 	body, _ := json.Marshal(payload)
 	req := httptest.NewRequest(http.MethodPost, "/api-keys", bytes.NewBuffer(body))
 	req.Header.Set("Content-Type", "application/json")
@@ -111,6 +115,80 @@ func (suite *APIKeyHandlerTestSuite) TestCreate_Error() {
 	assert.Equal(suite.T(), "INTERNAL_ERROR", response.Error.Code)
 }
 
+// callingKey is the key the API-key-authenticated requests below are made
+// with: allowed to manage keys and read leads, from one network, at 60
+// requests a minute.
+func callingKey() *models.APIKey {
+	return &models.APIKey{
+		BaseModel: models.BaseModel{ID: 9},
+		UserID:    1,
+		APIKeyRestrictions: models.APIKeyRestrictions{
+			Scopes:             []models.APIKeyScope{"api_keys:write", "leads:read"},
+			AllowedCIDRs:       []string{"10.0.0.0/8"},
+			RateLimitPerMinute: 60,
+		},
+	}
+}
+
+// withCallingKey marks the request as made with callingKey.
+func withCallingKey(c *gin.Context) {
+	c.Set("api_key_id", uint(9))
+	c.Next()
+}
+
+func (suite *APIKeyHandlerTestSuite) TestCreate_WithAPIKeyCannotEscalate() {
+	suite.router.POST("/api-keys", withCallingKey, suite.handler.Create)
+	suite.mockService.On("GetByID", uint(9), uint(1)).Return(callingKey(), nil)
+
+	for name, payload := range map[string]CreateAPIKeyRequest{
+		"every scope":   {Name: "Escape", Scopes: []models.APIKeyScope{"*:write"}, AllowedCIDRs: []string{"10.0.0.0/8"}, RateLimitPerMinute: 60},
+		"no networks":   {Name: "Escape", Scopes: []models.APIKeyScope{"leads:read"}, RateLimitPerMinute: 60},
+		"no rate limit": {Name: "Escape", Scopes: []models.APIKeyScope{"leads:read"}, AllowedCIDRs: []string{"10.0.0.0/8"}},
+	} {
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest(http.MethodPost, "/api-keys", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+
+		suite.router.ServeHTTP(rec, req)
+
+		assert.Equal(suite.T(), http.StatusForbidden, rec.Code, name)
+	}
+	suite.mockService.AssertNotCalled(suite.T(), "GenerateRestricted", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	narrower := models.APIKeyRestrictions{Scopes: []models.APIKeyScope{"leads:read"}, AllowedCIDRs: []string{"10.1.0.0/16"}, RateLimitPerMinute: 30}
+	suite.mockService.On("GenerateRestricted", uint(1), "Narrower", (*time.Time)(nil), narrower).
+		Return("raw-api-key-value", &models.APIKey{BaseModel: models.BaseModel{ID: 10}, UserID: 1}, nil)
+	body, _ := json.Marshal(CreateAPIKeyRequest{Name: "Narrower", Scopes: narrower.Scopes,
+		AllowedCIDRs: narrower.AllowedCIDRs, RateLimitPerMinute: narrower.RateLimitPerMinute})
+	req := httptest.NewRequest(http.MethodPost, "/api-keys", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(rec, req)
+
+	assert.Equal(suite.T(), http.StatusCreated, rec.Code, "a key it covers may be created")
+}
+
+func (suite *APIKeyHandlerTestSuite) TestUpdate_WithAPIKeyCannotReactivateBroaderKey() {
+	suite.router.PUT("/api-keys/:id", withCallingKey, suite.handler.Update)
+	suite.mockService.On("GetByID", uint(9), uint(1)).Return(callingKey(), nil)
+	suite.mockService.On("GetByID", uint(5), uint(1)).Return(&models.APIKey{
+		BaseModel:          models.BaseModel{ID: 5},
+		UserID:             1,
+		APIKeyRestrictions: models.APIKeyRestrictions{Scopes: []models.APIKeyScope{"*:write"}},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodPut, "/api-keys/5", bytes.NewBufferString(`{"is_active":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(rec, req)
+
+	assert.Equal(suite.T(), http.StatusForbidden, rec.Code)
+	suite.mockService.AssertNotCalled(suite.T(), "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 func (suite *APIKeyHandlerTestSuite) TestList_Success() {
 	suite.router.GET("/api-keys", suite.handler.List)
 
@@ -276,11 +354,11 @@ func (suite *APIKeyHandlerTestSuite) TestCreate_WithFutureExpiresAt() {
 	}
 
 	// The handler must hand the parsed instant down, not a string.
-	suite.mockService.On("Generate", uint(1), "Expiring Key", mock.MatchedBy(func(t *time.Time) bool {
+	suite.mockService.On("GenerateRestricted", uint(1), "Expiring Key", mock.MatchedBy(func(t *time.Time) bool {
 		return t != nil && t.Equal(expires)
-	})).Return("raw-api-key-value", expectedAPIKey, nil)
+	}), leadsReadRestrictions).Return("raw-api-key-value", expectedAPIKey, nil)
 
-	body := []byte(fmt.Sprintf(`{"name":"Expiring Key","expires_at":%q}`, expires.Format(time.RFC3339)))
+	body := []byte(fmt.Sprintf(`{"name":"Expiring Key","scopes":["leads:read"],"expires_at":%q}`, expires.Format(time.RFC3339)))
 	req := httptest.NewRequest(http.MethodPost, "/api-keys", bytes.NewBuffer(body))
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
@@ -304,7 +382,7 @@ func (suite *APIKeyHandlerTestSuite) TestCreate_PastExpiresAt() {
 	suite.router.POST("/api-keys", suite.handler.Create)
 
 	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
-	body := []byte(fmt.Sprintf(`{"name":"Stale Key","expires_at":%q}`, past))
+	body := []byte(fmt.Sprintf(`{"name":"Stale Key","scopes":["leads:read"],"expires_at":%q}`, past))
 	req := httptest.NewRequest(http.MethodPost, "/api-keys", bytes.NewBuffer(body))
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
@@ -317,13 +395,52 @@ func (suite *APIKeyHandlerTestSuite) TestCreate_PastExpiresAt() {
 	err := json.Unmarshal(rec.Body.Bytes(), &response)
 	assert.NoError(suite.T(), err)
 	assert.False(suite.T(), response.Success)
-	suite.mockService.AssertNotCalled(suite.T(), "Generate", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockService.AssertNotCalled(suite.T(), "GenerateRestricted", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func (suite *APIKeyHandlerTestSuite) TestCreate_MalformedExpiresAt() {
 	suite.router.POST("/api-keys", suite.handler.Create)
 
-	body := []byte(`{"name":"Bad Expiry","expires_at":"next tuesday"}`)
+	body := []byte(`{"name":"Bad Expiry","scopes":["leads:read"],"expires_at":"next tuesday"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api-keys", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(rec, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, rec.Code)
+	suite.mockService.AssertNotCalled(suite.T(), "GenerateRestricted", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// --- Create with restrictions ------------------------------------------------
+
+func (suite *APIKeyHandlerTestSuite) TestCreate_PassesRestrictions() {
+	suite.router.POST("/api-keys", suite.handler.Create)
+
+	restrictions := models.APIKeyRestrictions{
+		Scopes:             []models.APIKeyScope{"leads:write", "customers:read"},
+		AllowedCIDRs:       []string{"10.0.0.0/8"},
+		RateLimitPerMinute: 30,
+	}
+	suite.mockService.On("GenerateRestricted", uint(1), "Integration", (*time.Time)(nil), restrictions).
+		Return("raw-api-key-value", &models.APIKey{BaseModel: models.BaseModel{ID: 1}, APIKeyRestrictions: restrictions}, nil)
+
+	body := []byte(`{"name":"Integration","scopes":["leads:write","customers:read"],"allowed_cidrs":["10.0.0.0/8"],"rate_limit_per_minute":30}`)
+	req := httptest.NewRequest(http.MethodPost, "/api-keys", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(rec, req)
+
+	assert.Equal(suite.T(), http.StatusCreated, rec.Code)
+	assert.Contains(suite.T(), rec.Body.String(), `"scopes":["leads:write","customers:read"]`)
+}
+
+// A key must be minted with at least one scope; there is no implicit default.
+func (suite *APIKeyHandlerTestSuite) TestCreate_MissingScopes() {
+	suite.router.POST("/api-keys", suite.handler.Create)
+
+	body := []byte(`{"name":"Unscoped Key"}`)
 	req := httptest.NewRequest(http.MethodPost, "/api-keys", bytes.NewBuffer(body))
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
@@ -331,7 +448,24 @@ func (suite *APIKeyHandlerTestSuite) TestCreate_MalformedExpiresAt() {
 	suite.router.ServeHTTP(rec, req)
 
 	assert.Equal(suite.T(), http.StatusBadRequest, rec.Code)
-	suite.mockService.AssertNotCalled(suite.T(), "Generate", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockService.AssertNotCalled(suite.T(), "GenerateRestricted", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *APIKeyHandlerTestSuite) TestCreate_InvalidRestrictions() {
+	suite.router.POST("/api-keys", suite.handler.Create)
+
+	suite.mockService.On("GenerateRestricted", uint(1), "Bad Scope", (*time.Time)(nil), mock.Anything).
+		Return("", nil, fmt.Errorf("%w: unknown scope %q: %w", models.ErrInvalidAPIKey, "leads:delete", apperrors.ErrValidation))
+
+	body := []byte(`{"name":"Bad Scope","scopes":["leads:delete"]}`)
+	req := httptest.NewRequest(http.MethodPost, "/api-keys", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(rec, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, rec.Code)
+	assert.Contains(suite.T(), rec.Body.String(), "leads:delete")
 }
 
 // --- Get ---------------------------------------------------------------------
@@ -655,6 +789,61 @@ func (suite *APIKeyHandlerTestSuite) TestUpdate_InternalError() {
 	assert.Equal(suite.T(), "INTERNAL_ERROR", response.Error.Code)
 }
 
+// --- Usage -------------------------------------------------------------------
+
+// The stub context is an admin, whose audit:read lets them read any key's log.
+func (suite *APIKeyHandlerTestSuite) TestUsage_AuditorReadsAnyKey() {
+	suite.router.GET("/api-keys/:id/usage", suite.handler.Usage)
+
+	usage := []models.APIKeyUsage{
+		{ID: 2, APIKeyID: 5, Method: "POST", Endpoint: "/api/v1/leads", Status: 201, IPAddress: "10.0.0.7"},
+		{ID: 1, APIKeyID: 5, Method: "DELETE", Endpoint: "/api/v1/users/3", Status: 403, IPAddress: "10.0.0.7"},
+	}
+	suite.mockService.On("ListUsage", uint(5), uint(1), true, 0, 20).Return(usage, int64(2), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api-keys/5/usage", nil)
+	rec := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(rec, req)
+
+	assert.Equal(suite.T(), http.StatusOK, rec.Code)
+	var response utils.APIResponse
+	suite.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &response))
+	data := response.Data.(map[string]interface{})
+	assert.Equal(suite.T(), float64(2), data["total"])
+	assert.Len(suite.T(), data["usage"], 2)
+	assert.Equal(suite.T(), int64(2), response.Meta.Total)
+}
+
+func (suite *APIKeyHandlerTestSuite) TestUsage_OwnerScopedWithoutAuditRead() {
+	asSales := func(c *gin.Context) { c.Set("user_role", "sales") }
+	suite.router.GET("/api-keys/:id/usage", asSales, suite.handler.Usage)
+
+	suite.mockService.On("ListUsage", uint(5), uint(1), false, 0, 20).
+		Return(nil, int64(0), fmt.Errorf("api key %d belongs to another user: %w", 5, apperrors.ErrForbidden))
+
+	req := httptest.NewRequest(http.MethodGet, "/api-keys/5/usage", nil)
+	rec := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(rec, req)
+
+	assert.Equal(suite.T(), http.StatusForbidden, rec.Code)
+}
+
+func (suite *APIKeyHandlerTestSuite) TestUsage_NotFound() {
+	suite.router.GET("/api-keys/:id/usage", suite.handler.Usage)
+
+	suite.mockService.On("ListUsage", uint(9), uint(1), true, 0, 20).
+		Return(nil, int64(0), fmt.Errorf("api key %d not found: %w", 9, apperrors.ErrNotFound))
+
+	req := httptest.NewRequest(http.MethodGet, "/api-keys/9/usage", nil)
+	rec := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(rec, req)
+
+	assert.Equal(suite.T(), http.StatusNotFound, rec.Code)
+}
+
 func TestAPIKeyHandlerTestSuite(t *testing.T) {
 	suite.Run(t, new(APIKeyHandlerTestSuite))
 }
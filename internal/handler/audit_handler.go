@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type AuditHandler struct {
+	auditService  service.AuditService
+	configService service.ConfigurationService
+}
+
+// NewAuditHandler builds the audit endpoints. The configuration service is
+// only used to resolve the key in a configuration's history path to its id.
+func NewAuditHandler(auditService service.AuditService, configService service.ConfigurationService) *AuditHandler {
+	return &AuditHandler{auditService: auditService, configService: configService}
+}
+
+// List godoc
+// @Summary List audit events
+// @Description List the audit trail across every audited entity, newest first (admin role only). Each event names the actor — the user, and the API key when the request used one — the entity, the action (create, update, delete) and a field-level before/after diff of the entity's own fields. Personal data quoted in a diff is replaced with "[erased]" once the person is erased, and the value of a sensitive configuration entry is never recorded.
+// @Tags audit
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param entity_type query string false "Filter by entity type" Enums(user, lead, customer, ticket, task, label, form, configuration, deal, pipeline_stage, account, custom_field)
+// @Param entity_id query int false "Filter by entity ID"
+// @Param actor_user_id query int false "Filter by the acting user's ID"
+// @Param action query string false "Filter by action" Enums(create, update, delete)
+// @Param from query string false "Only events at or after this RFC3339 timestamp"
+// @Param to query string false "Only events before this RFC3339 timestamp"
+// @Param offset query int false "Pagination offset" default(0)
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Param cursor query string false "Page by keyset instead of offset: empty for the first page, then the meta.next_cursor of the previous one"
+// @Success 200 {object} utils.APIResponse{data=object{events=[]models.AuditEvent,total=int},meta=utils.APIMeta} "Audit events retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid filter or cursor"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /audit [get]
+func (h *AuditHandler) List(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "AuditHandler.List")
+
+	filter, ok := parseAuditFilter(c)
+	if !ok {
+		return
+	}
+	offset, limit := utils.ParseOffsetLimit(c)
+	// The trail is read newest first, and the cursor is taken in that order.
+	cursor, ok := parseListCursor(c, logger, "audit_events", "created_at", "desc", models.CustomFieldQuery{})
+	if !ok {
+		return
+	}
+	filter.Cursor = cursor
+	fetchOffset, fetchLimit := cursorWindow(cursor, offset, limit)
+
+	events, total, err := h.auditService.List(filter, fetchOffset, fetchLimit)
+	if err != nil {
+		logger.WithError(err).Error("Failed to list audit events")
+		utils.RespondInternalError(c)
+		return
+	}
+	meta := auditPageMeta(c, offset, limit, total)
+	if cursor != nil {
+		var next string
+		if events, next, err = cursorPage(cursor, events, limit); err != nil {
+			logger.WithError(err).Error("Failed to encode the next cursor")
+			utils.RespondInternalError(c)
+			return
+		}
+		meta = cursorMeta(c, limit, total, next)
+	}
+
+	respondAuditEvents(c, logger, events, total, meta)
+}
+
+// History returns the handler for one entity type's history endpoint.
+//
+// History godoc
+// @Summary Get an entity's audit history
+// @Description List the audit trail of a single record, newest first (admin role only). The history of an erased user, lead or customer stays available with its personal data scrubbed.
+// @Tags audit
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Entity ID"
+// @Param offset query int false "Pagination offset" default(0)
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Success 200 {object} utils.APIResponse{data=object{events=[]models.AuditEvent,total=int},meta=utils.APIMeta} "Audit history retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /users/{id}/history [get]
+// @Router /leads/{id}/history [get]
+// @Router /customers/{id}/history [get]
+// @Router /tickets/{id}/history [get]
+// @Router /tasks/{id}/history [get]
+// @Router /labels/{id}/history [get]
+// @Router /forms/{id}/history [get]
+func (h *AuditHandler) History(entityType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := utils.LogHandlerStart(c, "AuditHandler.History")
+
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			utils.RespondBadRequest(c, "Invalid ID")
+			return
+		}
+
+		h.respondHistory(c, logger, entityType, uint(id))
+	}
+}
+
+// ConfigurationHistory godoc
+// @Summary Get a configuration entry's audit history
+// @Description List the audit trail of one configuration entry, newest first (admin role only). Writes to a sensitive entry appear with the value "[redacted]".
+// @Tags audit
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param key path string true "Configuration key"
+// @Param offset query int false "Pagination offset" default(0)
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Success 200 {object} utils.APIResponse{data=object{events=[]models.AuditEvent,total=int},meta=utils.APIMeta} "Audit history retrieved successfully"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Configuration not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /configurations/{key}/history [get]
+func (h *AuditHandler) ConfigurationHistory(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "AuditHandler.ConfigurationHistory")
+
+	config, err := h.configService.GetByKey(c.Param("key"))
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			logger.WithError(err).Warn("Configuration not found")
+			utils.RespondNotFound(c, "Configuration not found")
+			return
+		}
+		logger.WithError(err).Error("Failed to get configuration")
+		utils.RespondInternalError(c)
+		return
+	}
+
+	h.respondHistory(c, logger, models.AuditEntityConfiguration, config.ID)
+}
+
+func (h *AuditHandler) respondHistory(c *gin.Context, logger *logrus.Entry, entityType string, id uint) {
+	offset, limit := utils.ParseOffsetLimit(c)
+
+	events, total, err := h.auditService.History(entityType, id, offset, limit)
+	if err != nil {
+		logger.WithError(err).Error("Failed to get audit history")
+		utils.RespondInternalError(c)
+		return
+	}
+
+	respondAuditEvents(c, logger, events, total, auditPageMeta(c, offset, limit, total))
+}
+
+func auditPageMeta(c *gin.Context, offset, limit int, total int64) *utils.APIMeta {
+	return &utils.APIMeta{
+		RequestID:  c.GetString("request_id"),
+		Page:       (offset / limit) + 1,
+		PerPage:    limit,
+		Total:      total,
+		TotalPages: (total + int64(limit) - 1) / int64(limit),
+	}
+}
+
+func respondAuditEvents(c *gin.Context, logger *logrus.Entry, events []models.AuditEvent, total int64, meta *utils.APIMeta) {
+	responseData := gin.H{"events": events, "total": total}
+	utils.LogHandlerResponse(logger, http.StatusOK, responseData)
+	utils.RespondSuccessWithMeta(c, http.StatusOK, responseData, meta)
+}
+
+// parseAuditFilter reads the listing's query filters. A malformed id or
+// timestamp is rejected rather than ignored: silently dropping a filter would
+// answer with more of the trail than the caller asked for.
+func parseAuditFilter(c *gin.Context) (models.AuditFilter, bool) {
+	filter := models.AuditFilter{
+		EntityType: c.Query("entity_type"),
+		Action:     models.AuditAction(c.Query("action")),
+	}
+
+	for name, target := range map[string]*uint{
+		"entity_id":     &filter.EntityID,
+		"actor_user_id": &filter.ActorUserID,
+	} {
+		if raw := c.Query(name); raw != "" {
+			parsed, err := strconv.ParseUint(raw, 10, 32)
+			if err != nil {
+				utils.RespondBadRequest(c, "Invalid "+name)
+				return filter, false
+			}
+			*target = uint(parsed)
+		}
+	}
+
+	for name, target := range map[string]**time.Time{
+		"from": &filter.From,
+		"to":   &filter.To,
+	} {
+		if raw := c.Query(name); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				utils.RespondBadRequest(c, "Invalid "+name+": expected an RFC3339 timestamp")
+				return filter, false
+			}
+			*target = &parsed
+		}
+	}
+
+	return filter, true
+}
@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+)
+
+var _ service.AuditService = (*mocks.AuditService)(nil)
+
+type AuditHandlerTestSuite struct {
+	suite.Suite
+	mockAudit  *mocks.AuditService
+	mockConfig *mockConfigurationService
+	role       models.UserRole
+	apiKeyID   uint
+	router     *gin.Engine
+}
+
+func (suite *AuditHandlerTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *AuditHandlerTestSuite) SetupTest() {
+	suite.mockAudit = new(mocks.AuditService)
+	suite.mockConfig = new(mockConfigurationService)
+	suite.role = models.RoleAdmin
+	suite.apiKeyID = 0
+
+	suite.router = gin.New()
+	suite.router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		c.Set("user_role", string(suite.role))
+		if suite.apiKeyID != 0 {
+			c.Set("api_key_id", suite.apiKeyID)
+		}
+		c.Next()
+	})
+	SetupAuditRoutes(suite.router.Group(""), NewAuditHandler(suite.mockAudit, suite.mockConfig))
+}
+
+func (suite *AuditHandlerTestSuite) TearDownTest() {
+	suite.mockAudit.AssertExpectations(suite.T())
+	suite.mockConfig.AssertExpectations(suite.T())
+}
+
+func (suite *AuditHandlerTestSuite) get(path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func (suite *AuditHandlerTestSuite) TestList_PassesFiltersAndPagination() {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	expected := models.AuditFilter{
+		EntityType:  models.AuditEntityLead,
+		EntityID:    4,
+		ActorUserID: 2,
+		Action:      models.AuditActionUpdate,
+		From:        &from,
+	}
+	events := []models.AuditEvent{{ID: 11, EntityType: models.AuditEntityLead, EntityID: 4, Action: models.AuditActionUpdate}}
+	suite.mockAudit.On("List", expected, 10, 5).Return(events, int64(12), nil)
+
+	w := suite.get("/audit?entity_type=lead&entity_id=4&actor_user_id=2&action=update&from=2026-01-01T00:00:00Z&offset=10&limit=5")
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var response utils.APIResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	data := response.Data.(map[string]interface{})
+	assert.Equal(suite.T(), float64(12), data["total"])
+	assert.Len(suite.T(), data["events"], 1)
+	assert.Equal(suite.T(), 3, response.Meta.Page)
+}
+
+func (suite *AuditHandlerTestSuite) TestList_RejectsMalformedFilters() {
+	for _, query := range []string{"entity_id=abc", "actor_user_id=-1", "from=yesterday", "to=2026-13-01"} {
+		w := suite.get("/audit?" + query)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func (suite *AuditHandlerTestSuite) TestList_ServiceErrorIs500() {
+	suite.mockAudit.On("List", models.AuditFilter{}, 0, 20).Return(nil, int64(0), errors.New("db down"))
+
+	w := suite.get("/audit")
+
+	assert.Equal(suite.T(), http.StatusInternalServerError, w.Code)
+}
+
+func (suite *AuditHandlerTestSuite) TestAuditIsAdminOnly() {
+	suite.role = models.RoleSales
+
+	for _, path := range []string{"/audit", "/leads/1/history", "/configurations/app.name/history"} {
+		w := suite.get(path)
+		assert.Equal(suite.T(), http.StatusForbidden, w.Code, path)
+	}
+}
+
+func (suite *AuditHandlerTestSuite) TestHistory_ScopesToTheRouteEntity() {
+	for path, entityType := range map[string]string{
+		"/users/3/history":         models.AuditEntityUser,
+		"/leads/3/history":         models.AuditEntityLead,
+		"/customers/3/history":     models.AuditEntityCustomer,
+		"/tickets/3/history":       models.AuditEntityTicket,
+		"/tasks/3/history":         models.AuditEntityTask,
+		"/labels/3/history":        models.AuditEntityLabel,
+		"/forms/3/history":         models.AuditEntityForm,
+		"/deals/3/history":         models.AuditEntityDeal,
+		"/accounts/3/history":      models.AuditEntityAccount,
+		"/custom-fields/3/history": models.AuditEntityCustomField,
+	} {
+		suite.mockAudit.On("History", entityType, uint(3), 0, 20).Return([]models.AuditEvent{}, int64(0), nil).Once()
+
+		w := suite.get(path)
+		assert.Equal(suite.T(), http.StatusOK, w.Code, path)
+	}
+}
+
+func (suite *AuditHandlerTestSuite) TestHistory_InvalidID() {
+	w := suite.get("/tickets/abc/history")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *AuditHandlerTestSuite) TestConfigurationHistory_ResolvesKey() {
+	cfg := &models.Configuration{Key: "app.name"}
+	cfg.ID = 8
+	suite.mockConfig.On("GetByKey", "app.name").Return(cfg, nil)
+	suite.mockAudit.On("History", models.AuditEntityConfiguration, uint(8), 0, 20).Return([]models.AuditEvent{}, int64(0), nil)
+
+	w := suite.get("/configurations/app.name/history")
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *AuditHandlerTestSuite) TestConfigurationHistory_UnknownKey() {
+	suite.mockConfig.On("GetByKey", "nope").Return(nil, apperrors.ErrNotFound)
+
+	w := suite.get("/configurations/nope/history")
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+// The entity handlers attribute each write to the request's user and, when
+// the request came in on an API key, to the key as well.
+func (suite *AuditHandlerTestSuite) TestWritesAreRecordedForTheRequestActor() {
+	suite.apiKeyID = 6
+	labels := new(mocks.LabelService)
+	labelHandler := NewLabelHandler(labels)
+	labelHandler.SetAuditService(suite.mockAudit)
+	SetupLabelRoutes(suite.router.Group(""), labelHandler)
+
+	labels.On("Create", mock.AnythingOfType("*models.Label")).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Label).ID = 5
+	})
+	suite.mockAudit.On("Record",
+		mock.MatchedBy(func(actor models.AuditActor) bool {
+			return actor.Type == models.AuditActorAPIKey &&
+				actor.UserID != nil && *actor.UserID == 1 &&
+				actor.APIKeyID != nil && *actor.APIKeyID == 6
+		}),
+		models.AuditEntityLabel, uint(5), models.AuditActionCreate, nil, mock.AnythingOfType("*models.Label"),
+	).Return(nil)
+
+	body, _ := json.Marshal(map[string]string{"name": "Urgent", "color": "#FF0000"})
+	req := httptest.NewRequest(http.MethodPost, "/labels", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	labels.AssertExpectations(suite.T())
+}
+
+// The write has already been committed when the trail is written, so a
+// failure to record must not turn a successful response into an error.
+func (suite *AuditHandlerTestSuite) TestRecordFailureDoesNotFailTheWrite() {
+	labels := new(mocks.LabelService)
+	labelHandler := NewLabelHandler(labels)
+	labelHandler.SetAuditService(suite.mockAudit)
+	SetupLabelRoutes(suite.router.Group(""), labelHandler)
+
+	labels.On("GetByID", uint(5)).Return(&models.Label{Name: "Urgent"}, nil)
+	labels.On("Delete", uint(5)).Return(nil)
+	suite.mockAudit.On("Record", mock.Anything, models.AuditEntityLabel, uint(5), models.AuditActionDelete, mock.Anything, nil).
+		Return(errors.New("db down"))
+
+	req := httptest.NewRequest(http.MethodDelete, "/labels/5", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+	labels.AssertExpectations(suite.T())
+}
+
+func TestAuditHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(AuditHandlerTestSuite))
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"github.com/florinel-chis/gophercrm/internal/middleware"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAuditRoutes mounts the audit trail: the cross-entity listing and one
+// history endpoint per audited entity. Every route is admin-only — a diff can
+// quote any field of any record, so no narrower role may read it, even for a
+// record that role could otherwise see.
+//
+// The history routes live under the entity paths but are registered here, so
+// the entity Setup* functions keep their signatures for the suites that mount
+// them.
+func SetupAuditRoutes(router *gin.RouterGroup, h *AuditHandler) {
+	admin := middleware.RequirePermission(models.PermAuditRead)
+
+	router.GET("/audit", admin, h.List)
+	router.GET("/users/:id/history", admin, h.History(models.AuditEntityUser))
+	router.GET("/leads/:id/history", admin, h.History(models.AuditEntityLead))
+	router.GET("/customers/:id/history", admin, h.History(models.AuditEntityCustomer))
+	router.GET("/tickets/:id/history", admin, h.History(models.AuditEntityTicket))
+	router.GET("/tasks/:id/history", admin, h.History(models.AuditEntityTask))
+	router.GET("/labels/:id/history", admin, h.History(models.AuditEntityLabel))
+	router.GET("/forms/:id/history", admin, h.History(models.AuditEntityForm))
+	router.GET("/deals/:id/history", admin, h.History(models.AuditEntityDeal))
+	router.GET("/accounts/:id/history", admin, h.History(models.AuditEntityAccount))
+	router.GET("/custom-fields/:id/history", admin, h.History(models.AuditEntityCustomField))
+	router.GET("/configurations/:key/history", admin, h.ConfigurationHistory)
+}
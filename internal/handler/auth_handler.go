@@ -9,11 +9,13 @@ import (
 	"github.com/florinel-chis/gophercrm/internal/service"
 	"github.com/florinel-chis/gophercrm/internal/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
 type AuthHandler struct {
 	authService service.AuthService
 	userService service.UserService
+	auditTrail
 }
 
 func NewAuthHandler(authService service.AuthService, userService service.UserService) *AuthHandler {
@@ -50,6 +52,29 @@ type AuthResponse struct {
 	Token        string       `json:"token"`
 	RefreshToken string       `json:"refresh_token,omitempty"`
 	User         *models.User `json:"user"`
+	// RecoveryCodes is set only by the login that enrolled the user in
+	// two-factor authentication; the codes are never shown again.
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+// TwoFactorChallengeResponse answers a login whose password was right but
+// which needs a second factor. The challenge goes to POST /auth/login/2fa
+// with a code; with setup_required the user must first enroll an
+// authenticator through POST /auth/login/2fa/setup.
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	Challenge         string `json:"challenge"`
+	SetupRequired     bool   `json:"setup_required"`
+	ExpiresIn         int    `json:"expires_in"`
+}
+
+type TwoFactorLoginRequest struct {
+	Challenge string `json:"challenge" binding:"required"`
+	Code      string `json:"code" binding:"required"`
+}
+
+type TwoFactorLoginSetupRequest struct {
+	Challenge string `json:"challenge" binding:"required"`
 }
 
 type RefreshRequest struct {
@@ -57,7 +82,7 @@ type RefreshRequest struct {
 }
 
 // LogoutRequest is optional: logout with no body (or an empty one) revokes
-// every refresh token of the authenticated user.
+// every session of the authenticated user.
 type LogoutRequest struct {
 	RefreshToken string `json:"refresh_token"`
 }
@@ -82,12 +107,13 @@ type MessageResponse struct {
 
 // Register godoc
 // @Summary Register a new account
-// @Description Public self-service registration. The account is always created with the customer role — the request body carries no role field and no client input can influence it. Elevated roles are assignable only through the admin-guarded POST /users endpoint. On success a JWT for the new account is returned alongside the user. The password must be at least 10 characters and contain an uppercase letter, a lowercase letter, a digit and a special character.
+// @Description Public self-service registration. The account is always created with the customer role — the request body carries no role field and no client input can influence it. Elevated roles are assignable only through the admin-guarded POST /users endpoint. On success a JWT and refresh token for the new account are returned alongside the user; should the customer role require two-factor authentication, the response instead carries the setup challenge a login would (see POST /auth/login/2fa/setup), and no token is issued before the second factor is enrolled. The password must be at least 10 characters and contain an uppercase letter, a lowercase letter, a digit and a special character.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body RegisterRequest true "Registration request"
-// @Success 201 {object} utils.APIResponse{data=AuthResponse} "Account created; JWT and user returned"
+// @Success 201 {object} utils.APIResponse{data=AuthResponse} "Account created; JWT, refresh token and user returned"
+// @Success 201 {object} utils.APIResponse{data=TwoFactorChallengeResponse} "Account created; two-factor enrollment required before any token"
 // @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Malformed body, failed field validation, or password complexity not met"
 // @Failure 409 {object} utils.APIResponse{error=utils.APIError} "A user with this email already exists"
 // @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Rate limit exceeded (10 requests per minute per IP)"
@@ -123,33 +149,44 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		}
 		return
 	}
+	userID := user.ID
+	h.recordAuditAs(models.AuditActor{Type: models.AuditActorUser, UserID: &userID},
+		models.AuditEntityUser, user.ID, models.AuditActionCreate, nil, user)
 
-	token, err := h.authService.GenerateJWT(user)
+	tokens, err := h.authService.LoginRegistered(user, clientInfo(c))
 	if err != nil {
-		logger.WithError(err).Error("Failed to generate token")
+		logger.WithError(err).Error("Failed to log in the new account")
 		utils.RespondInternalError(c)
 		return
 	}
 
+	if tokens.TwoFactorChallenge != "" {
+		respondTwoFactorChallenge(c, logger, http.StatusCreated, tokens)
+		return
+	}
+
 	response := AuthResponse{
-		Token: token,
-		User:  user,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		User:         user,
 	}
 
-	utils.LogHandlerResponse(logger, http.StatusCreated, response)
+	utils.LogHandlerResponse(logger, http.StatusCreated, "account created")
 	utils.RespondSuccess(c, http.StatusCreated, response)
 }
 
 // Login godoc
 // @Summary Authenticate and obtain a JWT
-// @Description Exchange email and password for a JWT bearer token, a refresh token and the authenticated user. After 5 consecutive failed attempts the account is locked for 15 minutes; while locked, and for a deactivated account, the response is the same 401 with the same generic message as a wrong password, so no account state is disclosed. The JWT's lifetime is fixed by server configuration; the refresh token is a single-use opaque value for POST /auth/refresh, stored server-side only as a hash.
+// @Description Exchange email and password for a JWT bearer token, a refresh token and the authenticated user. After 5 consecutive failed attempts the account is locked for 15 minutes; while locked, and for a deactivated account, the response is the same 401 with the same generic message as a wrong password, so no account state is disclosed. The JWT's lifetime is fixed by server configuration; the refresh token is a single-use opaque value for POST /auth/refresh, stored server-side only as a hash. When the account uses two-factor authentication, or its role requires it, the response carries a five-minute challenge instead of tokens (see POST /auth/login/2fa).
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body LoginRequest true "Login request"
 // @Success 200 {object} utils.APIResponse{data=AuthResponse} "Authenticated; JWT, refresh token and user returned"
+// @Success 200 {object} utils.APIResponse{data=TwoFactorChallengeResponse} "Password accepted; a second factor is required"
 // @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Malformed body or failed field validation"
 // @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Invalid email or password, account locked, or account deactivated"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "The email's domain must sign in with single sign-on"
 // @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Rate limit exceeded (10 requests per minute per IP)"
 // @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
 // @Router /auth/login [post]
@@ -162,13 +199,22 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	tokens, err := h.authService.LoginWithTokens(req.Email, req.Password)
+	tokens, err := h.authService.LoginWithTokens(req.Email, req.Password, clientInfo(c))
 	if err != nil {
 		logger.WithError(err).Warn("Login failed")
+		if errors.Is(err, service.ErrSSORequired) {
+			utils.RespondForbidden(c, "This account signs in with single sign-on")
+			return
+		}
 		utils.RespondUnauthorized(c, "Invalid email or password")
 		return
 	}
 
+	if tokens.TwoFactorChallenge != "" {
+		respondTwoFactorChallenge(c, logger, http.StatusOK, tokens)
+		return
+	}
+
 	response := AuthResponse{
 		Token:        tokens.AccessToken,
 		RefreshToken: tokens.RefreshToken,
@@ -180,6 +226,106 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	utils.RespondSuccess(c, http.StatusOK, response)
 }
 
+// respondTwoFactorChallenge answers a password accepted by Login or Register
+// with the challenge of the second step instead of tokens.
+func respondTwoFactorChallenge(c *gin.Context, logger *logrus.Entry, status int, tokens *service.AuthTokens) {
+	response := TwoFactorChallengeResponse{
+		TwoFactorRequired: true,
+		Challenge:         tokens.TwoFactorChallenge,
+		SetupRequired:     tokens.TwoFactorSetupRequired,
+		ExpiresIn:         int(service.TwoFactorChallengeTTL.Seconds()),
+	}
+	utils.LogHandlerResponse(logger, status, "second factor required")
+	utils.RespondSuccess(c, status, response)
+}
+
+// LoginTwoFactor godoc
+// @Summary Complete a login with a second factor
+// @Description Public. Exchanges the challenge of POST /auth/login and a code for the same tokens a login without a second factor returns. The code is a six-digit code of the user's authenticator, or one of their recovery codes; each is accepted once. A wrong code counts towards the account lockout. For a setup challenge the code confirms the authenticator enrolled through POST /auth/login/2fa/setup, and the response also carries the recovery codes, shown only this once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body TwoFactorLoginRequest true "Challenge and code"
+// @Success 200 {object} utils.APIResponse{data=AuthResponse} "Authenticated; JWT, refresh token and user returned"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Malformed body, or a setup challenge whose enrollment was not started"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Invalid or expired challenge, invalid code, or account locked"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Rate limit exceeded (10 requests per minute per IP)"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /auth/login/2fa [post]
+func (h *AuthHandler) LoginTwoFactor(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "AuthHandler.LoginTwoFactor")
+
+	var req TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	tokens, err := h.authService.CompleteTwoFactorLogin(req.Challenge, req.Code, clientInfo(c))
+	if err != nil {
+		logger.WithError(err).Warn("Second factor rejected")
+		switch {
+		case errors.Is(err, service.ErrTwoFactorNotEnabled):
+			utils.RespondBadRequest(c, "Two-factor setup has not been started")
+		case errors.Is(err, service.ErrInvalidTwoFactorCode):
+			utils.RespondUnauthorized(c, "Invalid two-factor code")
+		default:
+			// An expired challenge and a locked account read the same.
+			utils.RespondUnauthorized(c, "Invalid or expired two-factor challenge")
+		}
+		return
+	}
+
+	response := AuthResponse{
+		Token:         tokens.AccessToken,
+		RefreshToken:  tokens.RefreshToken,
+		User:          tokens.User,
+		RecoveryCodes: tokens.RecoveryCodes,
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, "login succeeded")
+	utils.RespondSuccess(c, http.StatusOK, response)
+}
+
+// LoginTwoFactorSetup godoc
+// @Summary Enroll an authenticator during login
+// @Description Public. For a user whose role requires two-factor authentication but who has none yet: exchanges the setup challenge of POST /auth/login for a new TOTP secret and its otpauth:// URI. The login then completes at POST /auth/login/2fa with a code of that authenticator. Calling it again replaces the secret.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body TwoFactorLoginSetupRequest true "Setup challenge"
+// @Success 200 {object} utils.APIResponse{data=models.TwoFactorSetup} "Secret and otpauth URI"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Malformed body"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Invalid or expired challenge, not a setup challenge, or account locked"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Rate limit exceeded (10 requests per minute per IP)"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /auth/login/2fa/setup [post]
+func (h *AuthHandler) LoginTwoFactorSetup(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "AuthHandler.LoginTwoFactorSetup")
+
+	var req TwoFactorLoginSetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	setup, err := h.authService.BeginTwoFactorSetup(req.Challenge)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTwoFactorChallenge) {
+			logger.WithError(err).Warn("Setup challenge rejected")
+			utils.RespondUnauthorized(c, "Invalid or expired two-factor challenge")
+			return
+		}
+		logger.WithError(err).Error("Two-factor setup failed")
+		utils.RespondInternalError(c)
+		return
+	}
+
+	// The secret is deliberately not logged.
+	utils.LogHandlerResponse(logger, http.StatusOK, "two-factor setup started")
+	utils.RespondSuccess(c, http.StatusOK, setup)
+}
+
 // Refresh godoc
 // @Summary Exchange a refresh token for a new JWT
 // @Description Rotates the session: the presented refresh token is revoked and a fresh JWT, refresh token and the user are returned — the same shape as login. Any invalid token (unknown, expired, revoked, or belonging to a deactivated or erased account) yields the same generic 401; the reason is never disclosed. Replaying a consumed token always fails, so clients must persist the replacement from every response.
@@ -202,7 +348,7 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 		return
 	}
 
-	tokens, err := h.authService.RefreshAccessToken(req.RefreshToken)
+	tokens, err := h.authService.RefreshAccessToken(req.RefreshToken, clientInfo(c))
 	if err != nil {
 		// Deliberately generic: every rejection reads the same. Internal
 		// failures are logged server-side but still answered with 401 rather
@@ -223,8 +369,8 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 }
 
 // Logout godoc
-// @Summary Log out and revoke refresh tokens
-// @Description Revokes refresh tokens of the authenticated user. With no body (or an empty one) every session of the user is revoked; with a refresh_token only that token is revoked, and only when it belongs to the caller. Logout is idempotent: unknown or already-revoked tokens still yield 200 and disclose nothing. The JWT itself stays valid until its expiry — clients must discard it.
+// @Summary Log out and revoke sessions
+// @Description Revokes sessions of the authenticated user. With no body (or an empty one) every session of the user is revoked; with a refresh_token only the session of that token is revoked, and only when it belongs to the caller. The JWT the request was made with is revoked in either case, as are the JWTs of every revoked session. Logout is idempotent: unknown or already-revoked tokens still yield 200 and disclose nothing.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -255,7 +401,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		}
 	}
 
-	if err := h.authService.Logout(userID, req.RefreshToken); err != nil {
+	if err := h.authService.Logout(userID, req.RefreshToken, currentAccessToken(c)); err != nil {
 		logger.WithError(err).Error("Logout failed")
 		utils.RespondInternalError(c)
 		return
@@ -268,14 +414,14 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 
 // ChangePassword godoc
 // @Summary Change the authenticated user's password
-// @Description Re-authenticates with the current password, then replaces it. The new password must be at least 10 characters with an uppercase letter, a lowercase letter, a digit and a special character. On success every refresh token of the user is revoked, ending all other sessions. A wrong current password is a 400 validation failure of the request — the session presenting it is still authenticated, so it is not a 401.
+// @Description Re-authenticates with the current password, then replaces it. The new password must be at least 10 characters with an uppercase letter, a lowercase letter, a digit and a special character. On success every session of the user is revoked, this one included: their refresh tokens and JWTs stop working, and the client logs in again with the new password. A wrong current password is a 400 validation failure of the request — the session presenting it is still authenticated, so it is not a 401.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Security ApiKeyAuth
 // @Param request body ChangePasswordRequest true "Password change request"
-// @Success 200 {object} utils.APIResponse{data=MessageResponse} "Password changed; other sessions revoked"
+// @Success 200 {object} utils.APIResponse{data=MessageResponse} "Password changed; every session revoked"
 // @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Malformed body, wrong current password, or new password fails the complexity policy"
 // @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
 // @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
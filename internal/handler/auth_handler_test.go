@@ -14,6 +14,7 @@ import (
 
 	"github.com/florinel-chis/gophercrm/internal/config"
 	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
 	"github.com/florinel-chis/gophercrm/internal/models"
 	"github.com/florinel-chis/gophercrm/internal/service"
 	"github.com/florinel-chis/gophercrm/internal/utils"
@@ -28,8 +29,40 @@ func (m *MockAuthService) Login(email, password string) (string, error) {
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockAuthService) LoginWithTokens(email, password string) (*service.AuthTokens, error) {
-	args := m.Called(email, password)
+func (m *MockAuthService) LoginWithTokens(email, password string, client service.ClientInfo) (*service.AuthTokens, error) {
+	args := m.Called(email, password, client)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.AuthTokens), args.Error(1)
+}
+
+func (m *MockAuthService) CompleteTwoFactorLogin(challenge, code string, client service.ClientInfo) (*service.AuthTokens, error) {
+	args := m.Called(challenge, code, client)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.AuthTokens), args.Error(1)
+}
+
+func (m *MockAuthService) BeginTwoFactorSetup(challenge string) (*models.TwoFactorSetup, error) {
+	args := m.Called(challenge)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TwoFactorSetup), args.Error(1)
+}
+
+func (m *MockAuthService) LoginRegistered(user *models.User, client service.ClientInfo) (*service.AuthTokens, error) {
+	args := m.Called(user, client)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.AuthTokens), args.Error(1)
+}
+
+func (m *MockAuthService) LoginWithSSO(user *models.User, client service.ClientInfo) (*service.AuthTokens, error) {
+	args := m.Called(user, client)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -44,6 +77,13 @@ func (m *MockAuthService) ValidateToken(token string) (*models.User, error) {
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *MockAuthService) AuthenticateToken(token string) (*models.User, *service.AccessTokenClaims, error) {
+	args := m.Called(token)
+	user, _ := args.Get(0).(*models.User)
+	claims, _ := args.Get(1).(*service.AccessTokenClaims)
+	return user, claims, args.Error(2)
+}
+
 func (m *MockAuthService) ValidateAPIKey(key string) (*models.User, error) {
 	args := m.Called(key)
 	if args.Get(0) == nil {
@@ -52,6 +92,14 @@ func (m *MockAuthService) ValidateAPIKey(key string) (*models.User, error) {
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *MockAuthService) AuthenticateAPIKey(key string) (*models.User, *models.APIKey, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*models.User), args.Get(1).(*models.APIKey), args.Error(2)
+}
+
 func (m *MockAuthService) GenerateJWT(user *models.User) (string, error) {
 	args := m.Called(user)
 	return args.String(0), args.Error(1)
@@ -65,8 +113,8 @@ func (m *MockAuthService) GenerateTokens(user *models.User) (*service.AuthTokens
 	return args.Get(0).(*service.AuthTokens), args.Error(1)
 }
 
-func (m *MockAuthService) RefreshAccessToken(refreshToken string) (*service.AuthTokens, error) {
-	args := m.Called(refreshToken)
+func (m *MockAuthService) RefreshAccessToken(refreshToken string, client service.ClientInfo) (*service.AuthTokens, error) {
+	args := m.Called(refreshToken, client)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -78,8 +126,19 @@ func (m *MockAuthService) InvalidateRefreshToken(refreshToken string) error {
 	return args.Error(0)
 }
 
-func (m *MockAuthService) Logout(userID uint, refreshToken string) error {
-	args := m.Called(userID, refreshToken)
+func (m *MockAuthService) Logout(userID uint, refreshToken string, current *service.AccessTokenClaims) error {
+	args := m.Called(userID, refreshToken, current)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ListSessions(userID, currentSessionID uint) ([]models.Session, error) {
+	args := m.Called(userID, currentSessionID)
+	sessions, _ := args.Get(0).([]models.Session)
+	return sessions, args.Error(1)
+}
+
+func (m *MockAuthService) RevokeSession(userID, sessionID uint) error {
+	args := m.Called(userID, sessionID)
 	return args.Error(0)
 }
 
@@ -170,10 +229,27 @@ func (m *MockUserService) Search(query string, offset, limit int, sortBy, sortOr
 	return args.Get(0).([]models.User), args.Get(1).(int64), args.Error(2)
 }
 
+func (m *MockUserService) ListFiltered(filter models.UserFilter, offset, limit int) ([]models.User, int64, error) {
+	args := m.Called(filter, offset, limit)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]models.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserService) StreamExport(filter models.UserFilter, fn func([]models.User) error) error {
+	args := m.Called(filter, fn)
+	return args.Error(0)
+}
+
 // Ensure the mocks satisfy the service interfaces
 var _ service.AuthService = (*MockAuthService)(nil)
 var _ service.UserService = (*MockUserService)(nil)
 
+// authHandlerAccessToken is the token the simulated auth middleware of the
+// suite authenticates requests with.
+var authHandlerAccessToken = &service.AccessTokenClaims{TokenID: "jti-42", SessionID: 9}
+
 type AuthHandlerTestSuite struct {
 	suite.Suite
 	mockAuthService *MockAuthService
@@ -212,6 +288,8 @@ func (suite *AuthHandlerTestSuite) SetupTest() {
 
 	suite.router.POST("/auth/register", suite.handler.Register)
 	suite.router.POST("/auth/login", suite.handler.Login)
+	suite.router.POST("/auth/login/2fa", suite.handler.LoginTwoFactor)
+	suite.router.POST("/auth/login/2fa/setup", suite.handler.LoginTwoFactorSetup)
 	suite.router.POST("/auth/refresh", suite.handler.Refresh)
 	suite.router.POST("/auth/password-reset", suite.handler.RequestPasswordReset)
 	suite.router.POST("/auth/password-reset/confirm", suite.handler.ConfirmPasswordReset)
@@ -221,6 +299,7 @@ func (suite *AuthHandlerTestSuite) SetupTest() {
 	authed := suite.router.Group("")
 	authed.Use(func(c *gin.Context) {
 		c.Set("user_id", uint(42))
+		c.Set("access_token", authHandlerAccessToken)
 		c.Next()
 	})
 	authed.POST("/auth/logout", suite.handler.Logout)
@@ -241,7 +320,8 @@ func (suite *AuthHandlerTestSuite) TestRegister_RoleInjection_ForcedToCustomer()
 		capturedUser = u
 		return u.Email == "attacker@example.com"
 	}), "SecurePass1!").Return(nil)
-	suite.mockAuthService.On("GenerateJWT", mock.AnythingOfType("*models.User")).Return("test-token", nil)
+	suite.mockAuthService.On("LoginRegistered", mock.AnythingOfType("*models.User"), mock.Anything).
+		Return(&service.AuthTokens{AccessToken: "test-token"}, nil)
 
 	requestBody := map[string]interface{}{
 		"email":      "attacker@example.com",
@@ -269,7 +349,8 @@ func (suite *AuthHandlerTestSuite) TestRegister_NoRole_DefaultsToCustomer() {
 		capturedUser = u
 		return u.Email == "new@example.com"
 	}), "SecurePass1!").Return(nil)
-	suite.mockAuthService.On("GenerateJWT", mock.AnythingOfType("*models.User")).Return("test-token", nil)
+	suite.mockAuthService.On("LoginRegistered", mock.AnythingOfType("*models.User"), mock.Anything).
+		Return(&service.AuthTokens{AccessToken: "test-token"}, nil)
 
 	requestBody := map[string]interface{}{
 		"email":      "new@example.com",
@@ -344,7 +425,8 @@ func (suite *AuthHandlerTestSuite) TestRegister_Success() {
 			u.LastName == "User" &&
 			u.Role == models.RoleCustomer
 	}), "SecurePass1!").Return(nil)
-	suite.mockAuthService.On("GenerateJWT", mock.AnythingOfType("*models.User")).Return("jwt-token", nil)
+	suite.mockAuthService.On("LoginRegistered", mock.AnythingOfType("*models.User"), mock.Anything).
+		Return(&service.AuthTokens{AccessToken: "jwt-token", RefreshToken: "refresh-token"}, nil)
 
 	requestBody := map[string]interface{}{
 		"email":      "ok@example.com",
@@ -368,6 +450,61 @@ func (suite *AuthHandlerTestSuite) TestRegister_Success() {
 	data, ok := response.Data.(map[string]interface{})
 	assert.True(suite.T(), ok)
 	assert.Equal(suite.T(), "jwt-token", data["token"])
+	assert.Equal(suite.T(), "refresh-token", data["refresh_token"])
+}
+
+// When the customer role requires a second factor, registering yields the
+// setup challenge of a login, not tokens.
+func (suite *AuthHandlerTestSuite) TestRegister_SecondFactorRequired_ReturnsChallenge() {
+	suite.mockUserService.On("Register", mock.AnythingOfType("*models.User"), "SecurePass1!").Return(nil)
+	suite.mockAuthService.On("LoginRegistered", mock.AnythingOfType("*models.User"), mock.Anything).
+		Return(&service.AuthTokens{TwoFactorChallenge: "challenge", TwoFactorSetupRequired: true}, nil)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"email": "ok@example.com", "password": "SecurePass1!", "first_name": "Good", "last_name": "User",
+	})
+	req := httptest.NewRequest("POST", "/auth/register", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	var response utils.APIResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	data, ok := response.Data.(map[string]interface{})
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), true, data["two_factor_required"])
+	assert.Equal(suite.T(), true, data["setup_required"])
+	assert.Equal(suite.T(), "challenge", data["challenge"])
+	assert.NotContains(suite.T(), data, "token")
+}
+
+// A self-registration is nobody's doing but the new user's, so the audit
+// trail attributes the account to itself.
+func (suite *AuthHandlerTestSuite) TestRegister_AuditedAsTheNewUser() {
+	audit := new(mocks.AuditService)
+	defer audit.AssertExpectations(suite.T())
+	suite.handler.SetAuditService(audit)
+	suite.mockUserService.On("Register", mock.AnythingOfType("*models.User"), "SecurePass1!").Run(func(args mock.Arguments) {
+		args.Get(0).(*models.User).ID = 17
+	}).Return(nil)
+	suite.mockAuthService.On("LoginRegistered", mock.AnythingOfType("*models.User"), mock.Anything).
+		Return(&service.AuthTokens{AccessToken: "jwt-token", RefreshToken: "refresh-token"}, nil)
+	audit.On("Record", mock.MatchedBy(func(actor models.AuditActor) bool {
+		return actor.Type == models.AuditActorUser && actor.UserID != nil && *actor.UserID == 17 && actor.APIKeyID == nil
+	}), models.AuditEntityUser, uint(17), models.AuditActionCreate, nil, mock.AnythingOfType("*models.User")).Return(nil).Once()
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"email": "ok@example.com", "password": "SecurePass1!", "first_name": "Good", "last_name": "User",
+	})
+	req := httptest.NewRequest("POST", "/auth/register", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
 }
 
 // LoginRequest no longer declares a remember_me field — the backend never read
@@ -380,7 +517,7 @@ func (suite *AuthHandlerTestSuite) TestLogin_UnknownRememberMeField_Ignored() {
 		Email: "user@example.com",
 		Role:  models.RoleCustomer,
 	}
-	suite.mockAuthService.On("LoginWithTokens", "user@example.com", "SecurePass1!").
+	suite.mockAuthService.On("LoginWithTokens", "user@example.com", "SecurePass1!", mock.Anything).
 		Return(&service.AuthTokens{AccessToken: "jwt-token", RefreshToken: "refresh-1", User: user}, nil)
 
 	requestBody := map[string]interface{}{
@@ -408,7 +545,7 @@ func (suite *AuthHandlerTestSuite) TestLogin_UnknownRememberMeField_Ignored() {
 
 func (suite *AuthHandlerTestSuite) TestLogin_ResponseIncludesRefreshToken() {
 	user := &models.User{Email: "user@example.com", Role: models.RoleCustomer}
-	suite.mockAuthService.On("LoginWithTokens", "user@example.com", "SecurePass1!").
+	suite.mockAuthService.On("LoginWithTokens", "user@example.com", "SecurePass1!", mock.Anything).
 		Return(&service.AuthTokens{AccessToken: "jwt-token", RefreshToken: "refresh-raw", User: user}, nil)
 
 	body, _ := json.Marshal(map[string]string{"email": "user@example.com", "password": "SecurePass1!"})
@@ -428,9 +565,125 @@ func (suite *AuthHandlerTestSuite) TestLogin_ResponseIncludesRefreshToken() {
 	assert.NotNil(suite.T(), data["user"])
 }
 
+func (suite *AuthHandlerTestSuite) TestLogin_TwoFactorChallenge() {
+	suite.mockAuthService.On("LoginWithTokens", "admin@example.com", "SecurePass1!", mock.Anything).
+		Return(&service.AuthTokens{TwoFactorChallenge: "challenge-jwt", TwoFactorSetupRequired: true}, nil)
+
+	body, _ := json.Marshal(map[string]string{"email": "admin@example.com", "password": "SecurePass1!"})
+	req := httptest.NewRequest("POST", "/auth/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var response utils.APIResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	data, ok := response.Data.(map[string]interface{})
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), true, data["two_factor_required"])
+	assert.Equal(suite.T(), "challenge-jwt", data["challenge"])
+	assert.Equal(suite.T(), true, data["setup_required"])
+	assert.Equal(suite.T(), float64(300), data["expires_in"])
+	assert.NotContains(suite.T(), data, "token", "no access token before the second factor")
+}
+
+func (suite *AuthHandlerTestSuite) TestLogin_SSODomain_Forbidden() {
+	suite.mockAuthService.On("LoginWithTokens", "staff@example.com", "SecurePass1!", mock.Anything).
+		Return(nil, service.ErrSSORequired)
+
+	body, _ := json.Marshal(map[string]string{"email": "staff@example.com", "password": "SecurePass1!"})
+	req := httptest.NewRequest("POST", "/auth/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "single sign-on")
+}
+
+func (suite *AuthHandlerTestSuite) TestLoginTwoFactor_Success() {
+	user := &models.User{Email: "admin@example.com", Role: models.RoleAdmin}
+	suite.mockAuthService.On("CompleteTwoFactorLogin", "challenge-jwt", "123456", mock.Anything).
+		Return(&service.AuthTokens{AccessToken: "jwt-token", RefreshToken: "refresh-raw", User: user,
+			RecoveryCodes: []string{"abcde-fghij"}}, nil)
+
+	body, _ := json.Marshal(map[string]string{"challenge": "challenge-jwt", "code": "123456"})
+	req := httptest.NewRequest("POST", "/auth/login/2fa", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var response utils.APIResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	data, ok := response.Data.(map[string]interface{})
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), "jwt-token", data["token"])
+	assert.Equal(suite.T(), "refresh-raw", data["refresh_token"])
+	assert.Equal(suite.T(), []interface{}{"abcde-fghij"}, data["recovery_codes"])
+}
+
+func (suite *AuthHandlerTestSuite) TestLoginTwoFactor_Rejections() {
+	suite.mockAuthService.On("CompleteTwoFactorLogin", "challenge-jwt", "000000", mock.Anything).
+		Return(nil, service.ErrInvalidTwoFactorCode)
+	suite.mockAuthService.On("CompleteTwoFactorLogin", "expired-jwt", "123456", mock.Anything).
+		Return(nil, service.ErrInvalidTwoFactorChallenge)
+
+	for _, tc := range []struct {
+		challenge, code, message string
+	}{
+		{"challenge-jwt", "000000", "Invalid two-factor code"},
+		{"expired-jwt", "123456", "Invalid or expired two-factor challenge"},
+	} {
+		body, _ := json.Marshal(map[string]string{"challenge": tc.challenge, "code": tc.code})
+		req := httptest.NewRequest("POST", "/auth/login/2fa", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+		var response utils.APIResponse
+		assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(suite.T(), tc.message, response.Error.Message)
+	}
+}
+
+func (suite *AuthHandlerTestSuite) TestLoginTwoFactorSetup() {
+	suite.mockAuthService.On("BeginTwoFactorSetup", "setup-jwt").
+		Return(&models.TwoFactorSetup{Secret: "JBSWY3DPEHPK3PXP", OTPAuthURI: "otpauth://totp/x"}, nil)
+	suite.mockAuthService.On("BeginTwoFactorSetup", "login-jwt").
+		Return(nil, service.ErrInvalidTwoFactorChallenge)
+
+	body, _ := json.Marshal(map[string]string{"challenge": "setup-jwt"})
+	req := httptest.NewRequest("POST", "/auth/login/2fa/setup", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var response utils.APIResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	data, ok := response.Data.(map[string]interface{})
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), "JBSWY3DPEHPK3PXP", data["secret"])
+	assert.Equal(suite.T(), "otpauth://totp/x", data["otpauth_uri"])
+
+	body, _ = json.Marshal(map[string]string{"challenge": "login-jwt"})
+	req = httptest.NewRequest("POST", "/auth/login/2fa/setup", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
 func (suite *AuthHandlerTestSuite) TestRefresh_Success_MirrorsLoginShape() {
 	user := &models.User{Email: "user@example.com", Role: models.RoleCustomer}
-	suite.mockAuthService.On("RefreshAccessToken", "old-refresh").
+	suite.mockAuthService.On("RefreshAccessToken", "old-refresh", mock.Anything).
 		Return(&service.AuthTokens{AccessToken: "new-jwt", RefreshToken: "new-refresh", User: user}, nil)
 
 	body, _ := json.Marshal(map[string]string{"refresh_token": "old-refresh"})
@@ -452,7 +705,7 @@ func (suite *AuthHandlerTestSuite) TestRefresh_Success_MirrorsLoginShape() {
 }
 
 func (suite *AuthHandlerTestSuite) TestRefresh_InvalidToken_Generic401() {
-	suite.mockAuthService.On("RefreshAccessToken", "revoked-or-expired").
+	suite.mockAuthService.On("RefreshAccessToken", "revoked-or-expired", mock.Anything).
 		Return(nil, service.ErrInvalidRefreshToken)
 
 	body, _ := json.Marshal(map[string]string{"refresh_token": "revoked-or-expired"})
@@ -479,13 +732,13 @@ func (suite *AuthHandlerTestSuite) TestRefresh_MissingBody_BadRequest() {
 	suite.router.ServeHTTP(w, req)
 
 	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
-	suite.mockAuthService.AssertNotCalled(suite.T(), "RefreshAccessToken", mock.Anything)
+	suite.mockAuthService.AssertNotCalled(suite.T(), "RefreshAccessToken", mock.Anything, mock.Anything)
 }
 
 // The frontend calls logout with no body at all (AuthContext.tsx); that path
 // must revoke every session of the authenticated user and return 200.
 func (suite *AuthHandlerTestSuite) TestLogout_NoBody_RevokesAllSessions() {
-	suite.mockAuthService.On("Logout", uint(42), "").Return(nil)
+	suite.mockAuthService.On("Logout", uint(42), "", authHandlerAccessToken).Return(nil)
 
 	req := httptest.NewRequest("POST", "/auth/logout", nil)
 	w := httptest.NewRecorder()
@@ -502,7 +755,7 @@ func (suite *AuthHandlerTestSuite) TestLogout_NoBody_RevokesAllSessions() {
 }
 
 func (suite *AuthHandlerTestSuite) TestLogout_WithRefreshToken_RevokesThatToken() {
-	suite.mockAuthService.On("Logout", uint(42), "my-refresh").Return(nil)
+	suite.mockAuthService.On("Logout", uint(42), "my-refresh", authHandlerAccessToken).Return(nil)
 
 	body, _ := json.Marshal(map[string]string{"refresh_token": "my-refresh"})
 	req := httptest.NewRequest("POST", "/auth/logout", bytes.NewBuffer(body))
@@ -512,7 +765,7 @@ func (suite *AuthHandlerTestSuite) TestLogout_WithRefreshToken_RevokesThatToken(
 	suite.router.ServeHTTP(w, req)
 
 	assert.Equal(suite.T(), http.StatusOK, w.Code)
-	suite.mockAuthService.AssertCalled(suite.T(), "Logout", uint(42), "my-refresh")
+	suite.mockAuthService.AssertCalled(suite.T(), "Logout", uint(42), "my-refresh", authHandlerAccessToken)
 }
 
 func (suite *AuthHandlerTestSuite) TestChangePassword_Success() {
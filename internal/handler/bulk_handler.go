@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/middleware"
 	"github.com/florinel-chis/gophercrm/internal/models"
 	"github.com/florinel-chis/gophercrm/internal/service"
 	"github.com/florinel-chis/gophercrm/internal/utils"
@@ -21,154 +22,174 @@ func NewBulkHandler(bulkService service.BulkOperationService) *BulkHandler {
 }
 
 // Generic bulk operation handlers
+//
+// Bulk create, update, delete and action requests are queued rather than run
+// while the client waits: each handler answers 202 with the pending operation,
+// whose progress is then read from GET /bulk-operations/:id.
 
-// BulkCreate handles POST /{resource}/bulk/create
+// BulkCreate handles POST /bulk/{resource}/create
 func (h *BulkHandler) BulkCreate(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "BulkHandler.BulkCreate")
 	
 	resourceType := c.Param("resource")
 	userID := c.GetUint("user_id")
 	
-	var req models.BulkCreateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.Error(err).SetType(gin.ErrorTypeBind)
-		return
-	}
-
 	// Validate resource type
 	if !h.isValidResourceType(resourceType) {
 		utils.RespondBadRequest(c, "Invalid resource type")
 		return
 	}
 
-	// Check permissions for the resource type
+	// Check permissions for the resource type before reading the body
 	if !h.hasCreatePermission(c, resourceType) {
 		utils.RespondForbidden(c, "Insufficient permissions for bulk create")
 		return
 	}
 
-	response, err := h.bulkService.ProcessBulkCreate(userID, resourceType, &req)
+	var req models.BulkCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	if !bulkUserRolesAllowed(c, resourceType, createSetsRole(&req)) {
+		utils.RespondForbidden(c, "Only users who manage roles can set roles in bulk")
+		return
+	}
+
+	operation, err := h.bulkService.QueueBulkCreate(userID, middleware.Permissions(c), resourceType, &req)
 	if err != nil {
 		logger.WithError(err).Error("Bulk create failed")
-		apperrors.HandleError(c, err)
+		respondBulkError(c, err)
 		return
 	}
 
-	utils.LogHandlerResponse(logger, http.StatusOK, response)
-	utils.RespondSuccess(c, http.StatusOK, response)
+	utils.LogHandlerResponse(logger, http.StatusAccepted, operation)
+	utils.RespondSuccess(c, http.StatusAccepted, operation)
 }
 
-// BulkUpdate handles PUT /{resource}/bulk/update
+// BulkUpdate handles PUT /bulk/{resource}/update
 func (h *BulkHandler) BulkUpdate(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "BulkHandler.BulkUpdate")
 	
 	resourceType := c.Param("resource")
 	userID := c.GetUint("user_id")
 	
-	var req models.BulkUpdateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.Error(err).SetType(gin.ErrorTypeBind)
-		return
-	}
-
 	// Validate resource type
 	if !h.isValidResourceType(resourceType) {
 		utils.RespondBadRequest(c, "Invalid resource type")
 		return
 	}
 
-	// Check permissions for the resource type
+	// Check permissions for the resource type before reading the body
 	if !h.hasUpdatePermission(c, resourceType) {
 		utils.RespondForbidden(c, "Insufficient permissions for bulk update")
 		return
 	}
 
-	response, err := h.bulkService.ProcessBulkUpdate(userID, resourceType, &req)
+	var req models.BulkUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	if !bulkUserRolesAllowed(c, resourceType, updateSetsRole(&req)) {
+		utils.RespondForbidden(c, "Only users who manage roles can set roles in bulk")
+		return
+	}
+
+	operation, err := h.bulkService.QueueBulkUpdate(userID, middleware.Permissions(c), resourceType, &req)
 	if err != nil {
 		logger.WithError(err).Error("Bulk update failed")
-		apperrors.HandleError(c, err)
+		respondBulkError(c, err)
 		return
 	}
 
-	utils.LogHandlerResponse(logger, http.StatusOK, response)
-	utils.RespondSuccess(c, http.StatusOK, response)
+	utils.LogHandlerResponse(logger, http.StatusAccepted, operation)
+	utils.RespondSuccess(c, http.StatusAccepted, operation)
 }
 
-// BulkDelete handles DELETE /{resource}/bulk/delete
+// BulkDelete handles DELETE /bulk/{resource}/delete
 func (h *BulkHandler) BulkDelete(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "BulkHandler.BulkDelete")
 	
 	resourceType := c.Param("resource")
 	userID := c.GetUint("user_id")
 	
-	var req models.BulkDeleteRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.Error(err).SetType(gin.ErrorTypeBind)
-		return
-	}
-
 	// Validate resource type
 	if !h.isValidResourceType(resourceType) {
 		utils.RespondBadRequest(c, "Invalid resource type")
 		return
 	}
 
-	// Check permissions for the resource type
+	// Check permissions for the resource type before reading the body
 	if !h.hasDeletePermission(c, resourceType) {
 		utils.RespondForbidden(c, "Insufficient permissions for bulk delete")
 		return
 	}
 
-	response, err := h.bulkService.ProcessBulkDelete(userID, resourceType, &req)
+	var req models.BulkDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	operation, err := h.bulkService.QueueBulkDelete(userID, middleware.Permissions(c), resourceType, &req)
 	if err != nil {
 		logger.WithError(err).Error("Bulk delete failed")
-		apperrors.HandleError(c, err)
+		respondBulkError(c, err)
 		return
 	}
 
-	utils.LogHandlerResponse(logger, http.StatusOK, response)
-	utils.RespondSuccess(c, http.StatusOK, response)
+	utils.LogHandlerResponse(logger, http.StatusAccepted, operation)
+	utils.RespondSuccess(c, http.StatusAccepted, operation)
 }
 
-// BulkAction handles POST /{resource}/bulk/action
+// BulkAction handles POST /bulk/{resource}/action
 func (h *BulkHandler) BulkAction(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "BulkHandler.BulkAction")
 	
 	resourceType := c.Param("resource")
 	userID := c.GetUint("user_id")
 	
-	var req models.BulkActionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.Error(err).SetType(gin.ErrorTypeBind)
-		return
-	}
-
 	// Validate resource type
 	if !h.isValidResourceType(resourceType) {
 		utils.RespondBadRequest(c, "Invalid resource type")
 		return
 	}
 
-	// Check permissions for the resource type and action
-	if !h.hasActionPermission(c, resourceType, req.Action) {
+	// Check permissions for the resource type before reading the body
+	if !h.hasActionPermission(c, resourceType) {
 		utils.RespondForbidden(c, "Insufficient permissions for bulk action")
 		return
 	}
 
-	response, err := h.bulkService.ProcessBulkAction(userID, resourceType, &req)
+	var req models.BulkActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	if !bulkUserRolesAllowed(c, resourceType, req.Action == models.UserBulkActionTypes.ChangeRole) {
+		utils.RespondForbidden(c, "Only users who manage roles can set roles in bulk")
+		return
+	}
+
+	operation, err := h.bulkService.QueueBulkAction(userID, middleware.Permissions(c), resourceType, &req)
 	if err != nil {
 		logger.WithError(err).Error("Bulk action failed")
-		apperrors.HandleError(c, err)
+		respondBulkError(c, err)
 		return
 	}
 
-	utils.LogHandlerResponse(logger, http.StatusOK, response)
-	utils.RespondSuccess(c, http.StatusOK, response)
+	utils.LogHandlerResponse(logger, http.StatusAccepted, operation)
+	utils.RespondSuccess(c, http.StatusAccepted, operation)
 }
 
 // Bulk operation status and management
 
-// GetBulkOperation handles GET /bulk/operations/{id}
+// GetBulkOperation handles GET /bulk-operations/{id}. The counters of an
+// operation still running show how far it has got.
 func (h *BulkHandler) GetBulkOperation(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "BulkHandler.GetBulkOperation")
 	
@@ -185,11 +206,10 @@ func (h *BulkHandler) GetBulkOperation(c *gin.Context) {
 		return
 	}
 
-	// Check permissions - users can only view their own operations unless admin
+	// Users can only view their own operations without bulk_operations:manage
 	currentUserID := c.GetUint("user_id")
-	currentUserRole := c.GetString("user_role")
-	
-	if operation.UserID != currentUserID && currentUserRole != string(models.RoleAdmin) {
+
+	if operation.UserID != currentUserID && !middleware.HasPermission(c, models.PermBulkOperationsManage) {
 		utils.RespondForbidden(c, "You can only view your own bulk operations")
 		return
 	}
@@ -198,25 +218,67 @@ func (h *BulkHandler) GetBulkOperation(c *gin.Context) {
 	utils.RespondSuccess(c, http.StatusOK, operation)
 }
 
-// ListBulkOperations handles GET /bulk/operations
+// CancelBulkOperation handles POST /bulk-operations/{id}/cancel. A queued
+// operation never starts; a running one stops before its next chunk, keeping
+// what the chunks so far did.
+func (h *BulkHandler) CancelBulkOperation(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "BulkHandler.CancelBulkOperation")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid operation ID")
+		return
+	}
+
+	operation, err := h.bulkService.CancelBulkOperation(uint(id), c.GetUint("user_id"), middleware.Permissions(c))
+	if err != nil {
+		logger.WithError(err).Warn("Bulk operation not cancelled")
+		respondBulkError(c, err)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, operation)
+	utils.RespondSuccess(c, http.StatusOK, operation)
+}
+
+// RevertBulkOperation handles POST /bulk-operations/{id}/revert. Every row a
+// finished update, delete or action changed is put back, unless it changed
+// since or was erased; the response names the rows left alone and why.
+func (h *BulkHandler) RevertBulkOperation(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "BulkHandler.RevertBulkOperation")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid operation ID")
+		return
+	}
+
+	result, err := h.bulkService.RevertBulkOperation(uint(id), c.GetUint("user_id"), middleware.Permissions(c))
+	if err != nil {
+		logger.WithError(err).Warn("Bulk operation not reverted")
+		respondBulkError(c, err)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, result)
+	utils.RespondSuccess(c, http.StatusOK, result)
+}
+
+// ListBulkOperations handles GET /bulk-operations, newest first: a user's own
+// operations, or everyone's with bulk_operations:manage.
 func (h *BulkHandler) ListBulkOperations(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "BulkHandler.ListBulkOperations")
 	
 	offset, limit := utils.ParseOffsetLimit(c)
-	
-	if limit > 100 {
-		limit = 100
-	}
 
 	currentUserID := c.GetUint("user_id")
-	currentUserRole := c.GetString("user_role")
 
 	var operations []models.BulkOperation
 	var total int64
 	var err error
 
-	// Admin can see all operations, others only their own
-	if currentUserRole == string(models.RoleAdmin) {
+	// bulk_operations:manage sees all operations, others only their own
+	if middleware.HasPermission(c, models.PermBulkOperationsManage) {
 		operations, total, err = h.bulkService.ListAllBulkOperations(offset, limit)
 	} else {
 		operations, total, err = h.bulkService.GetUserBulkOperations(currentUserID, offset, limit)
@@ -229,10 +291,11 @@ func (h *BulkHandler) ListBulkOperations(c *gin.Context) {
 	}
 
 	meta := &utils.APIMeta{
-		RequestID: c.GetString("request_id"),
-		Page:      (offset / limit) + 1,
-		PerPage:   limit,
-		Total:     total,
+		RequestID:  c.GetString("request_id"),
+		Page:       (offset / limit) + 1,
+		PerPage:    limit,
+		Total:      total,
+		TotalPages: (total + int64(limit) - 1) / int64(limit),
 	}
 
 	utils.LogHandlerResponse(logger, http.StatusOK, gin.H{"operations": operations})
@@ -246,10 +309,9 @@ func (h *BulkHandler) BulkCreateUsers(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "BulkHandler.BulkCreateUsers")
 	
 	userID := c.GetUint("user_id")
-	userRole := c.GetString("user_role")
 	
 	// Only admins can bulk create users
-	if userRole != string(models.RoleAdmin) {
+	if !h.hasCreatePermission(c, "users") {
 		utils.RespondForbidden(c, "Only administrators can bulk create users")
 		return
 	}
@@ -260,25 +322,29 @@ func (h *BulkHandler) BulkCreateUsers(c *gin.Context) {
 		return
 	}
 
-	response, err := h.bulkService.BulkCreateUsers(&req, userID)
+	if !bulkUserRolesAllowed(c, "users", createSetsRole(&req)) {
+		utils.RespondForbidden(c, "Only users who manage roles can set roles in bulk")
+		return
+	}
+
+	operation, err := h.bulkService.QueueBulkCreate(userID, middleware.Permissions(c), "users", &req)
 	if err != nil {
 		logger.WithError(err).Error("Bulk create users failed")
-		apperrors.HandleError(c, err)
+		respondBulkError(c, err)
 		return
 	}
 
-	utils.LogHandlerResponse(logger, http.StatusOK, response)
-	utils.RespondSuccess(c, http.StatusOK, response)
+	utils.LogHandlerResponse(logger, http.StatusAccepted, operation)
+	utils.RespondSuccess(c, http.StatusAccepted, operation)
 }
 
 func (h *BulkHandler) BulkUpdateUsers(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "BulkHandler.BulkUpdateUsers")
 	
 	userID := c.GetUint("user_id")
-	userRole := c.GetString("user_role")
 	
 	// Only admins can bulk update users
-	if userRole != string(models.RoleAdmin) {
+	if !h.hasUpdatePermission(c, "users") {
 		utils.RespondForbidden(c, "Only administrators can bulk update users")
 		return
 	}
@@ -289,25 +355,29 @@ func (h *BulkHandler) BulkUpdateUsers(c *gin.Context) {
 		return
 	}
 
-	response, err := h.bulkService.BulkUpdateUsers(&req, userID)
+	if !bulkUserRolesAllowed(c, "users", updateSetsRole(&req)) {
+		utils.RespondForbidden(c, "Only users who manage roles can set roles in bulk")
+		return
+	}
+
+	operation, err := h.bulkService.QueueBulkUpdate(userID, middleware.Permissions(c), "users", &req)
 	if err != nil {
 		logger.WithError(err).Error("Bulk update users failed")
-		apperrors.HandleError(c, err)
+		respondBulkError(c, err)
 		return
 	}
 
-	utils.LogHandlerResponse(logger, http.StatusOK, response)
-	utils.RespondSuccess(c, http.StatusOK, response)
+	utils.LogHandlerResponse(logger, http.StatusAccepted, operation)
+	utils.RespondSuccess(c, http.StatusAccepted, operation)
 }
 
 func (h *BulkHandler) BulkDeleteUsers(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "BulkHandler.BulkDeleteUsers")
 	
 	userID := c.GetUint("user_id")
-	userRole := c.GetString("user_role")
 	
 	// Only admins can bulk delete users
-	if userRole != string(models.RoleAdmin) {
+	if !h.hasDeletePermission(c, "users") {
 		utils.RespondForbidden(c, "Only administrators can bulk delete users")
 		return
 	}
@@ -318,25 +388,24 @@ func (h *BulkHandler) BulkDeleteUsers(c *gin.Context) {
 		return
 	}
 
-	response, err := h.bulkService.BulkDeleteUsers(&req, userID)
+	operation, err := h.bulkService.QueueBulkDelete(userID, middleware.Permissions(c), "users", &req)
 	if err != nil {
 		logger.WithError(err).Error("Bulk delete users failed")
-		apperrors.HandleError(c, err)
+		respondBulkError(c, err)
 		return
 	}
 
-	utils.LogHandlerResponse(logger, http.StatusOK, response)
-	utils.RespondSuccess(c, http.StatusOK, response)
+	utils.LogHandlerResponse(logger, http.StatusAccepted, operation)
+	utils.RespondSuccess(c, http.StatusAccepted, operation)
 }
 
 func (h *BulkHandler) BulkActionUsers(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "BulkHandler.BulkActionUsers")
 	
 	userID := c.GetUint("user_id")
-	userRole := c.GetString("user_role")
 	
 	// Only admins can perform bulk actions on users
-	if userRole != string(models.RoleAdmin) {
+	if !h.hasActionPermission(c, "users") {
 		utils.RespondForbidden(c, "Only administrators can perform bulk actions on users")
 		return
 	}
@@ -347,15 +416,20 @@ func (h *BulkHandler) BulkActionUsers(c *gin.Context) {
 		return
 	}
 
-	response, err := h.bulkService.BulkActionUsers(&req, userID)
+	if !bulkUserRolesAllowed(c, "users", req.Action == models.UserBulkActionTypes.ChangeRole) {
+		utils.RespondForbidden(c, "Only users who manage roles can set roles in bulk")
+		return
+	}
+
+	operation, err := h.bulkService.QueueBulkAction(userID, middleware.Permissions(c), "users", &req)
 	if err != nil {
 		logger.WithError(err).Error("Bulk action users failed")
-		apperrors.HandleError(c, err)
+		respondBulkError(c, err)
 		return
 	}
 
-	utils.LogHandlerResponse(logger, http.StatusOK, response)
-	utils.RespondSuccess(c, http.StatusOK, response)
+	utils.LogHandlerResponse(logger, http.StatusAccepted, operation)
+	utils.RespondSuccess(c, http.StatusAccepted, operation)
 }
 
 // Bulk status updates
@@ -386,7 +460,7 @@ type BulkTaskStatusRequest struct {
 
 // BulkUpdateLeadStatus godoc
 // @Summary Update the status of several leads at once
-// @Description Sets the same status on up to 100 leads in a single all-or-nothing transaction. Requires the sales or admin role; sales users may only update leads they own. If any listed lead is missing or not owned by the caller, nothing is written and the offending IDs are named in the error details.
+// @Description Sets the same status on up to 100 leads in a single all-or-nothing transaction. Requires leads:update; without leads:update:any only leads the caller owns may be updated. If any listed lead is missing or not owned by the caller, nothing is written and the offending IDs are named in the error details.
 // @Tags leads
 // @Accept json
 // @Produce json
@@ -396,7 +470,7 @@ type BulkTaskStatusRequest struct {
 // @Success 200 {object} utils.APIResponse{data=models.BulkStatusUpdateResult} "Every listed lead was updated"
 // @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Empty ID list, more than 100 IDs, or an invalid lead status"
 // @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
-// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - requires sales or admin role; sales users can only update leads they own, and the not-owned IDs are listed in the error details"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - requires leads:update; without leads:update:any only leads the caller owns, and the not-owned IDs are listed in the error details"
 // @Failure 404 {object} utils.APIResponse{error=utils.APIError} "One or more leads do not exist; the missing IDs are listed in the error details"
 // @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
 // @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
@@ -411,7 +485,7 @@ func (h *BulkHandler) BulkUpdateLeadStatus(c *gin.Context) {
 	}
 
 	result, err := h.bulkService.BulkSetLeadStatus(
-		c.GetUint("user_id"), models.UserRole(c.GetString("user_role")), req.LeadIDs, req.Status)
+		c.GetUint("user_id"), middleware.Permissions(c), req.LeadIDs, req.Status)
 	if err != nil {
 		logger.WithError(err).Warn("Bulk lead status update rejected")
 		respondBulkStatusError(c, err)
@@ -424,7 +498,7 @@ func (h *BulkHandler) BulkUpdateLeadStatus(c *gin.Context) {
 
 // BulkUpdateTicketStatus godoc
 // @Summary Update the status of several tickets at once
-// @Description Sets the same status on up to 100 tickets in a single all-or-nothing transaction. Admins may update any ticket, support users only tickets assigned to them; sales users are read-only on tickets and customers cannot update them. A closed ticket cannot be reopened. If any listed ticket is missing, not assigned to the caller, or closed, nothing is written and the offending IDs are named in the error details.
+// @Description Sets the same status on up to 100 tickets in a single all-or-nothing transaction. Requires tickets:update; without tickets:update:any only tickets assigned to the caller may be updated. A closed ticket cannot be reopened. If any listed ticket is missing, not assigned to the caller, or closed, nothing is written and the offending IDs are named in the error details.
 // @Tags tickets
 // @Accept json
 // @Produce json
@@ -434,7 +508,7 @@ func (h *BulkHandler) BulkUpdateLeadStatus(c *gin.Context) {
 // @Success 200 {object} utils.APIResponse{data=models.BulkStatusUpdateResult} "Every listed ticket was updated"
 // @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Empty ID list, more than 100 IDs, an invalid ticket status, or an attempt to reopen a closed ticket"
 // @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
-// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - sales and customer users cannot update tickets; support users can only update tickets assigned to them, and those IDs are listed in the error details"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - requires tickets:update; without tickets:update:any only tickets assigned to the caller, and those IDs are listed in the error details"
 // @Failure 404 {object} utils.APIResponse{error=utils.APIError} "One or more tickets do not exist; the missing IDs are listed in the error details"
 // @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
 // @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
@@ -442,17 +516,11 @@ func (h *BulkHandler) BulkUpdateLeadStatus(c *gin.Context) {
 func (h *BulkHandler) BulkUpdateTicketStatus(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "BulkHandler.BulkUpdateTicketStatus")
 
-	currentUserRole := models.UserRole(c.GetString("user_role"))
-
-	// Mirrors the single-ticket update, which turns these roles away before it
-	// looks at the body at all: an unauthorized caller learns nothing about
+	// Mirrors the single-ticket update, which turns these callers away before
+	// it looks at the body at all: an unauthorized caller learns nothing about
 	// which payloads would have been well formed.
-	if currentUserRole == models.RoleCustomer {
-		utils.RespondForbidden(c, "Customers cannot update tickets")
-		return
-	}
-	if currentUserRole == models.RoleSales {
-		utils.RespondForbidden(c, "Sales users cannot update tickets")
+	if !middleware.HasPermission(c, models.PermTicketsUpdate) {
+		utils.RespondForbidden(c, "Insufficient permissions to update tickets")
 		return
 	}
 
@@ -463,7 +531,7 @@ func (h *BulkHandler) BulkUpdateTicketStatus(c *gin.Context) {
 	}
 
 	result, err := h.bulkService.BulkSetTicketStatus(
-		c.GetUint("user_id"), currentUserRole, req.TicketIDs, req.Status)
+		c.GetUint("user_id"), middleware.Permissions(c), req.TicketIDs, req.Status)
 	if err != nil {
 		logger.WithError(err).Warn("Bulk ticket status update rejected")
 		respondBulkStatusError(c, err)
@@ -476,7 +544,7 @@ func (h *BulkHandler) BulkUpdateTicketStatus(c *gin.Context) {
 
 // BulkUpdateTaskStatus godoc
 // @Summary Update the status of several tasks at once
-// @Description Sets the same status on up to 100 tasks in a single all-or-nothing transaction. Admins may update any task, every other role only tasks assigned to them. The status of a completed task cannot be changed. If any listed task is missing, not assigned to the caller, or already completed, nothing is written and the offending IDs are named in the error details.
+// @Description Sets the same status on up to 100 tasks in a single all-or-nothing transaction. Without tasks:update:any only tasks assigned to the caller may be updated. The status of a completed task cannot be changed. If any listed task is missing, not assigned to the caller, or already completed, nothing is written and the offending IDs are named in the error details.
 // @Tags tasks
 // @Accept json
 // @Produce json
@@ -486,7 +554,7 @@ func (h *BulkHandler) BulkUpdateTicketStatus(c *gin.Context) {
 // @Success 200 {object} utils.APIResponse{data=models.BulkStatusUpdateResult} "Every listed task was updated"
 // @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Empty ID list, more than 100 IDs, an invalid task status, or an attempt to change the status of a completed task"
 // @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
-// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - non-admin users can only update tasks assigned to them, and those IDs are listed in the error details"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - without tasks:update:any only tasks assigned to the caller, and those IDs are listed in the error details"
 // @Failure 404 {object} utils.APIResponse{error=utils.APIError} "One or more tasks do not exist; the missing IDs are listed in the error details"
 // @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
 // @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
@@ -501,7 +569,7 @@ func (h *BulkHandler) BulkUpdateTaskStatus(c *gin.Context) {
 	}
 
 	result, err := h.bulkService.BulkSetTaskStatus(
-		c.GetUint("user_id"), models.UserRole(c.GetString("user_role")), req.TaskIDs, req.Status)
+		c.GetUint("user_id"), middleware.Permissions(c), req.TaskIDs, req.Status)
 	if err != nil {
 		logger.WithError(err).Warn("Bulk task status update rejected")
 		respondBulkStatusError(c, err)
@@ -542,6 +610,35 @@ func respondBulkStatusError(c *gin.Context, err error) {
 	}
 }
 
+// respondBulkError maps a refused queued bulk operation onto the unified
+// response shape. A refusal that names records carries them in the details, as
+// respondBulkStatusError does.
+func respondBulkError(c *gin.Context, err error) {
+	var details interface{}
+	message := err.Error()
+	if appErr, ok := apperrors.AsAppError(err); ok {
+		details = appErr.Details
+		message = appErr.Message
+	}
+
+	switch {
+	case errors.Is(err, apperrors.ErrValidation),
+		errors.Is(err, apperrors.ErrCompletedTaskModify),
+		errors.Is(err, apperrors.ErrClosedTicketReopen):
+		utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeBadRequest, message, details)
+	case errors.Is(err, apperrors.ErrForbidden):
+		utils.RespondError(c, http.StatusForbidden, utils.ErrCodeForbidden, message, details)
+	case errors.Is(err, apperrors.ErrBulkOperationFinished),
+		errors.Is(err, apperrors.ErrBulkOperationReverted),
+		errors.Is(err, apperrors.ErrBulkRevertExpired):
+		utils.RespondConflict(c, message)
+	case apperrors.IsNotFound(err):
+		utils.RespondNotFound(c, "Bulk operation not found")
+	default:
+		utils.RespondInternalError(c)
+	}
+}
+
 // Helper methods
 
 func (h *BulkHandler) isValidResourceType(resourceType string) bool {
@@ -554,71 +651,52 @@ func (h *BulkHandler) isValidResourceType(resourceType string) bool {
 	return false
 }
 
+// The permission helpers are the coarse, permission-only half of the check,
+// made before the body is read. Which records the caller may change is decided
+// by the service when the request is queued.
+
 func (h *BulkHandler) hasCreatePermission(c *gin.Context, resourceType string) bool {
-	userRole := c.GetString("user_role")
-	
-	switch resourceType {
-	case "users":
-		return userRole == string(models.RoleAdmin)
-	case "leads":
-		return userRole == string(models.RoleAdmin) || userRole == string(models.RoleSales)
-	case "customers":
-		return userRole == string(models.RoleAdmin) || userRole == string(models.RoleSales)
-	case "tasks", "tickets":
-		return true // All authenticated users can create tasks and tickets
-	default:
-		return false
-	}
+	return hasBulkPermission(c, resourceType, models.BulkCreate)
 }
 
 func (h *BulkHandler) hasUpdatePermission(c *gin.Context, resourceType string) bool {
-	userRole := c.GetString("user_role")
-	
-	switch resourceType {
-	case "users":
-		return userRole == string(models.RoleAdmin)
-	case "leads":
-		return userRole == string(models.RoleAdmin) || userRole == string(models.RoleSales)
-	case "customers":
-		return userRole == string(models.RoleAdmin) || userRole == string(models.RoleSales)
-	case "tasks", "tickets":
-		return true // All authenticated users can update tasks and tickets
-	default:
-		return false
-	}
+	return hasBulkPermission(c, resourceType, models.BulkUpdate)
 }
 
 func (h *BulkHandler) hasDeletePermission(c *gin.Context, resourceType string) bool {
-	userRole := c.GetString("user_role")
-	
-	switch resourceType {
-	case "users":
-		return userRole == string(models.RoleAdmin)
-	case "leads":
-		return userRole == string(models.RoleAdmin) || userRole == string(models.RoleSales)
-	case "customers":
-		return userRole == string(models.RoleAdmin)
-	case "tasks", "tickets":
-		return userRole == string(models.RoleAdmin) || userRole == string(models.RoleSales) || userRole == string(models.RoleSupport)
-	default:
-		return false
+	return hasBulkPermission(c, resourceType, models.BulkDelete)
+}
+
+func (h *BulkHandler) hasActionPermission(c *gin.Context, resourceType string) bool {
+	return hasBulkPermission(c, resourceType, models.BulkAction)
+}
+
+func hasBulkPermission(c *gin.Context, resourceType string, operationType models.BulkOperationType) bool {
+	permission, ok := service.BulkPermission(resourceType, operationType)
+	return ok && middleware.HasPermission(c, permission)
+}
+
+// bulkUserRolesAllowed reports whether a bulk request may set the roles of
+// users: only a caller who manages roles hands them out in bulk, since the
+// single-user endpoints' check of each granted role is not repeated per item.
+func bulkUserRolesAllowed(c *gin.Context, resourceType string, setsRole bool) bool {
+	return resourceType != "users" || !setsRole || middleware.HasPermission(c, models.PermRolesManage)
+}
+
+func createSetsRole(req *models.BulkCreateRequest) bool {
+	for _, item := range req.Items {
+		if _, ok := item["role"]; ok {
+			return true
+		}
 	}
+	return false
 }
 
-func (h *BulkHandler) hasActionPermission(c *gin.Context, resourceType, action string) bool {
-	userRole := c.GetString("user_role")
-	
-	switch resourceType {
-	case "users":
-		return userRole == string(models.RoleAdmin)
-	case "leads":
-		return userRole == string(models.RoleAdmin) || userRole == string(models.RoleSales)
-	case "customers":
-		return userRole == string(models.RoleAdmin) || userRole == string(models.RoleSales)
-	case "tasks", "tickets":
-		// Most task/ticket actions can be performed by admin, sales, and support
-		return userRole == string(models.RoleAdmin) || userRole == string(models.RoleSales) || userRole == string(models.RoleSupport)
-	default:
-		return false
+func updateSetsRole(req *models.BulkUpdateRequest) bool {
+	for _, item := range req.Items {
+		if _, ok := item.Updates["role"]; ok {
+			return true
+		}
 	}
-}
\ No newline at end of file
+	return false
+}
@@ -26,35 +26,35 @@ import (
 type stubBulkStatusService struct {
 	service.BulkOperationService
 
-	called     bool
-	gotActorID uint
-	gotRole    models.UserRole
-	gotIDs     []uint
-	gotStatus  string
-	result     *models.BulkStatusUpdateResult
-	err        error
+	called         bool
+	gotActorID     uint
+	gotPermissions models.PermissionSet
+	gotIDs         []uint
+	gotStatus      string
+	result         *models.BulkStatusUpdateResult
+	err            error
 }
 
-func (s *stubBulkStatusService) record(actorID uint, role models.UserRole, ids []uint, status string) {
+func (s *stubBulkStatusService) record(actorID uint, permissions models.PermissionSet, ids []uint, status string) {
 	s.called = true
 	s.gotActorID = actorID
-	s.gotRole = role
+	s.gotPermissions = permissions
 	s.gotIDs = ids
 	s.gotStatus = status
 }
 
-func (s *stubBulkStatusService) BulkSetLeadStatus(actorID uint, role models.UserRole, ids []uint, status models.LeadStatus) (*models.BulkStatusUpdateResult, error) {
-	s.record(actorID, role, ids, string(status))
+func (s *stubBulkStatusService) BulkSetLeadStatus(actorID uint, permissions models.PermissionSet, ids []uint, status models.LeadStatus) (*models.BulkStatusUpdateResult, error) {
+	s.record(actorID, permissions, ids, string(status))
 	return s.result, s.err
 }
 
-func (s *stubBulkStatusService) BulkSetTicketStatus(actorID uint, role models.UserRole, ids []uint, status models.TicketStatus) (*models.BulkStatusUpdateResult, error) {
-	s.record(actorID, role, ids, string(status))
+func (s *stubBulkStatusService) BulkSetTicketStatus(actorID uint, permissions models.PermissionSet, ids []uint, status models.TicketStatus) (*models.BulkStatusUpdateResult, error) {
+	s.record(actorID, permissions, ids, string(status))
 	return s.result, s.err
 }
 
-func (s *stubBulkStatusService) BulkSetTaskStatus(actorID uint, role models.UserRole, ids []uint, status models.TaskStatus) (*models.BulkStatusUpdateResult, error) {
-	s.record(actorID, role, ids, string(status))
+func (s *stubBulkStatusService) BulkSetTaskStatus(actorID uint, permissions models.PermissionSet, ids []uint, status models.TaskStatus) (*models.BulkStatusUpdateResult, error) {
+	s.record(actorID, permissions, ids, string(status))
 	return s.result, s.err
 }
 
@@ -168,7 +168,7 @@ func (suite *BulkStatusHandlerTestSuite) TestBulkUpdateLeadStatus_Success() {
 
 	suite.True(suite.stub.called)
 	suite.Equal(uint(7), suite.stub.gotActorID)
-	suite.Equal(models.RoleAdmin, suite.stub.gotRole)
+	suite.Equal(models.BuiltInPermissions(models.RoleAdmin), suite.stub.gotPermissions)
 	suite.Equal([]uint{1, 2}, suite.stub.gotIDs)
 	suite.Equal("qualified", suite.stub.gotStatus)
 }
@@ -0,0 +1,351 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+)
+
+// stubBulkQueueService implements service.BulkOperationService by embedding
+// the interface, like stubBulkStatusService: only queueing, reading,
+// cancelling and reverting operations are exercised here.
+type stubBulkQueueService struct {
+	service.BulkOperationService
+
+	gotUserID      uint
+	gotPermissions models.PermissionSet
+	gotResource    string
+	gotAction      *models.BulkActionRequest
+	operation      *models.BulkOperation
+	listedAll      bool
+	reverted       *models.BulkRevertResult
+	err            error
+}
+
+func (s *stubBulkQueueService) QueueBulkCreate(userID uint, permissions models.PermissionSet, resourceType string, request *models.BulkCreateRequest) (*models.BulkOperation, error) {
+	s.gotUserID, s.gotPermissions, s.gotResource = userID, permissions, resourceType
+	return s.operation, s.err
+}
+
+func (s *stubBulkQueueService) QueueBulkAction(userID uint, permissions models.PermissionSet, resourceType string, request *models.BulkActionRequest) (*models.BulkOperation, error) {
+	s.gotUserID, s.gotPermissions, s.gotResource, s.gotAction = userID, permissions, resourceType, request
+	return s.operation, s.err
+}
+
+func (s *stubBulkQueueService) QueueBulkUpdate(userID uint, permissions models.PermissionSet, resourceType string, request *models.BulkUpdateRequest) (*models.BulkOperation, error) {
+	s.gotUserID, s.gotPermissions, s.gotResource = userID, permissions, resourceType
+	return s.operation, s.err
+}
+
+func (s *stubBulkQueueService) GetUserBulkOperations(userID uint, offset, limit int) ([]models.BulkOperation, int64, error) {
+	s.gotUserID = userID
+	return []models.BulkOperation{*s.operation}, 45, s.err
+}
+
+func (s *stubBulkQueueService) ListAllBulkOperations(offset, limit int) ([]models.BulkOperation, int64, error) {
+	s.listedAll = true
+	return []models.BulkOperation{*s.operation}, 45, s.err
+}
+
+func (s *stubBulkQueueService) GetBulkOperationWithItems(id uint) (*models.BulkOperation, error) {
+	return s.operation, s.err
+}
+
+func (s *stubBulkQueueService) CancelBulkOperation(id uint, userID uint, permissions models.PermissionSet) (*models.BulkOperation, error) {
+	s.gotUserID, s.gotPermissions = userID, permissions
+	return s.operation, s.err
+}
+
+func (s *stubBulkQueueService) RevertBulkOperation(id uint, userID uint, permissions models.PermissionSet) (*models.BulkRevertResult, error) {
+	s.gotUserID, s.gotPermissions = userID, permissions
+	return s.reverted, s.err
+}
+
+type BulkQueueHandlerTestSuite struct {
+	suite.Suite
+	stub   *stubBulkQueueService
+	router *gin.Engine
+	role   models.UserRole
+	// permissions, when set, stand for a custom role resolved by
+	// LoadPermissions.
+	permissions models.PermissionSet
+}
+
+func TestBulkQueueHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(BulkQueueHandlerTestSuite))
+}
+
+func (suite *BulkQueueHandlerTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *BulkQueueHandlerTestSuite) SetupTest() {
+	suite.stub = &stubBulkQueueService{operation: &models.BulkOperation{
+		UserID:       7,
+		ResourceType: "leads",
+		Type:         models.BulkCreate,
+		Status:       models.StatusPending,
+		TotalItems:   2,
+	}}
+	suite.role = models.RoleSales
+	suite.permissions = nil
+	handler := NewBulkHandler(suite.stub)
+
+	suite.router = gin.New()
+	suite.router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(7))
+		c.Set("user_role", string(suite.role))
+		if suite.permissions != nil {
+			c.Set("permissions", suite.permissions)
+		}
+		c.Next()
+	})
+	SetupBulkRoutes(suite.router.Group(""), handler)
+	SetupBulkOperationRoutes(suite.router.Group(""), handler)
+}
+
+func (suite *BulkQueueHandlerTestSuite) do(method, path string, body interface{}) *httptest.ResponseRecorder {
+	payload, err := json.Marshal(body)
+	suite.Require().NoError(err)
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *BulkQueueHandlerTestSuite) TestBulkCreate_Queued() {
+	w := suite.do(http.MethodPost, "/bulk/leads/create", models.BulkCreateRequest{
+		Items: []map[string]interface{}{{"first_name": "Ada"}, {"first_name": "Grace"}},
+	})
+
+	suite.Equal(http.StatusAccepted, w.Code)
+	suite.Contains(w.Body.String(), `"status":"pending"`)
+	suite.Contains(w.Body.String(), `"total_items":2`)
+	suite.Equal(uint(7), suite.stub.gotUserID)
+	suite.Equal(models.BuiltInPermissions(models.RoleSales), suite.stub.gotPermissions)
+	suite.Equal("leads", suite.stub.gotResource)
+}
+
+// TestBulk_RoleGuards turns away roles the single-record endpoints would
+// refuse before the service is asked anything.
+func (suite *BulkQueueHandlerTestSuite) TestBulk_RoleGuards() {
+	cases := map[string]struct {
+		role   models.UserRole
+		method string
+		path   string
+	}{
+		"sales creating tickets":   {models.RoleSales, http.MethodPost, "/bulk/tickets/create"},
+		"sales deleting customers": {models.RoleSales, http.MethodDelete, "/bulk/customers/delete"},
+		"support updating leads":   {models.RoleSupport, http.MethodPut, "/bulk/leads/update"},
+		"support deleting tasks":   {models.RoleSupport, http.MethodDelete, "/bulk/tasks/delete"},
+		"customer acting on leads": {models.RoleCustomer, http.MethodPost, "/bulk/leads/action"},
+		"sales creating users":     {models.RoleSales, http.MethodPost, "/bulk/users/create"},
+		"support acting on users":  {models.RoleSupport, http.MethodPost, "/bulk/users/action"},
+	}
+	for name, tc := range cases {
+		suite.Run(name, func() {
+			suite.SetupTest()
+			suite.role = tc.role
+			w := suite.do(tc.method, tc.path, map[string]interface{}{"ids": []uint{1}})
+			suite.Equal(http.StatusForbidden, w.Code)
+			suite.Empty(suite.stub.gotResource, "the service is never asked")
+		})
+	}
+}
+
+func (suite *BulkQueueHandlerTestSuite) TestBulk_UnknownResource() {
+	w := suite.do(http.MethodPut, "/bulk/deals/update", models.BulkUpdateRequest{})
+	suite.Equal(http.StatusBadRequest, w.Code)
+}
+
+// TestBulkUpdate_RefusalNamesTheRecords carries the IDs the service refused
+// through to the client, as the bulk status endpoints do.
+func (suite *BulkQueueHandlerTestSuite) TestBulkUpdate_RefusalNamesTheRecords() {
+	suite.role = models.RoleSupport
+	suite.stub.operation = nil
+	suite.stub.err = apperrors.Wrap(apperrors.ErrForbidden, apperrors.CodeInsufficientPermissions,
+		"You can only update tickets assigned to you").WithDetail("forbidden_ids", []uint{4, 9})
+
+	w := suite.do(http.MethodPut, "/bulk/tickets/update", models.BulkUpdateRequest{Items: []models.BulkUpdateItem{{ID: 4}, {ID: 9}}})
+
+	suite.Equal(http.StatusForbidden, w.Code)
+	suite.Contains(w.Body.String(), "You can only update tickets assigned to you")
+	suite.Contains(w.Body.String(), `"forbidden_ids":[4,9]`)
+	suite.Equal(models.BuiltInPermissions(models.RoleSupport), suite.stub.gotPermissions)
+}
+
+func (suite *BulkQueueHandlerTestSuite) TestBulkUpdate_ClosedTicketIsBadRequest() {
+	suite.role = models.RoleAdmin
+	suite.stub.operation = nil
+	suite.stub.err = apperrors.Wrap(apperrors.ErrClosedTicketReopen, apperrors.CodeInvalidStatusTransition,
+		"Cannot reopen closed tickets").WithDetail("closed_ids", []uint{4})
+
+	w := suite.do(http.MethodPut, "/bulk/tickets/update", models.BulkUpdateRequest{Items: []models.BulkUpdateItem{{ID: 4}}})
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Contains(w.Body.String(), `"closed_ids":[4]`)
+}
+
+func (suite *BulkQueueHandlerTestSuite) TestListBulkOperations_OwnOrAll() {
+	w := suite.do(http.MethodGet, "/bulk-operations?limit=20", nil)
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.False(suite.stub.listedAll)
+	suite.Equal(uint(7), suite.stub.gotUserID)
+	suite.Contains(w.Body.String(), `"total":45`)
+	suite.Contains(w.Body.String(), `"total_pages":3`)
+
+	suite.role = models.RoleAdmin
+	w = suite.do(http.MethodGet, "/bulk-operations", nil)
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.True(suite.stub.listedAll)
+}
+
+func (suite *BulkQueueHandlerTestSuite) TestBulkCreateUsers_QueuedForUsers() {
+	suite.role = models.RoleAdmin
+	w := suite.do(http.MethodPost, "/bulk/users/create", models.BulkCreateRequest{
+		Items: []map[string]interface{}{{"email": "ada@example.com"}},
+	})
+
+	suite.Equal(http.StatusAccepted, w.Code)
+	suite.Equal("users", suite.stub.gotResource)
+	suite.Equal(models.BuiltInPermissions(models.RoleAdmin), suite.stub.gotPermissions)
+}
+
+func (suite *BulkQueueHandlerTestSuite) TestBulkUsers_SettingRolesNeedsRolesManage() {
+	suite.role = models.RoleAdmin
+	suite.permissions = models.NewPermissionSet(models.PermUsersCreate, models.PermUsersUpdate)
+
+	w := suite.do(http.MethodPost, "/bulk/users/create", models.BulkCreateRequest{
+		Items: []map[string]interface{}{{"email": "ada@example.com", "role": "admin"}},
+	})
+	suite.Equal(http.StatusForbidden, w.Code)
+
+	w = suite.do(http.MethodPost, "/bulk/users/action", models.BulkActionRequest{
+		IDs: []uint{3}, Action: models.UserBulkActionTypes.ChangeRole, Parameters: map[string]interface{}{"role": "admin"},
+	})
+	suite.Equal(http.StatusForbidden, w.Code)
+
+	w = suite.do(http.MethodDelete, "/bulk/users/delete", models.BulkDeleteRequest{IDs: []uint{3}})
+	suite.Equal(http.StatusForbidden, w.Code, "a custom role holds only the permissions it lists")
+
+	w = suite.do(http.MethodPost, "/bulk/users/create", models.BulkCreateRequest{
+		Items: []map[string]interface{}{{"email": "ada@example.com"}},
+	})
+	suite.Equal(http.StatusAccepted, w.Code)
+	suite.Equal(suite.permissions, suite.stub.gotPermissions, "the service decides with the caller's own permissions")
+}
+
+func (suite *BulkQueueHandlerTestSuite) TestBulkAction_InvalidIsBadRequest() {
+	suite.stub.operation = nil
+	suite.stub.err = fmt.Errorf("unsupported leads action \"explode\": %w", apperrors.ErrValidation)
+
+	w := suite.do(http.MethodPost, "/bulk/leads/action", models.BulkActionRequest{IDs: []uint{1}, Action: "explode"})
+
+	suite.Equal(http.StatusBadRequest, w.Code)
+	suite.Contains(w.Body.String(), "unsupported leads action")
+	suite.Equal("explode", suite.stub.gotAction.Action)
+}
+
+func (suite *BulkQueueHandlerTestSuite) TestGetBulkOperation_ShowsProgress() {
+	suite.stub.operation.Status = models.StatusProcessing
+	suite.stub.operation.SuccessCount = 1
+
+	w := suite.do(http.MethodGet, "/bulk-operations/3", nil)
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Contains(w.Body.String(), `"status":"processing"`)
+	suite.Contains(w.Body.String(), `"success_count":1`)
+	suite.NotContains(w.Body.String(), `"request"`, "the queued request is never sent back")
+}
+
+func (suite *BulkQueueHandlerTestSuite) TestGetBulkOperation_OtherUsersForbidden() {
+	suite.stub.operation.UserID = 9
+
+	w := suite.do(http.MethodGet, "/bulk-operations/3", nil)
+
+	suite.Equal(http.StatusForbidden, w.Code)
+}
+
+func (suite *BulkQueueHandlerTestSuite) TestCancel() {
+	suite.role = models.RoleSupport
+	suite.stub.operation.Status = models.StatusCancelled
+
+	w := suite.do(http.MethodPost, "/bulk-operations/3/cancel", nil)
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Contains(w.Body.String(), `"status":"cancelled"`)
+	suite.Equal(uint(7), suite.stub.gotUserID)
+	suite.Equal(models.BuiltInPermissions(models.RoleSupport), suite.stub.gotPermissions)
+}
+
+func (suite *BulkQueueHandlerTestSuite) TestCancel_Refusals() {
+	cases := map[string]struct {
+		err    error
+		status int
+	}{
+		"finished":  {fmt.Errorf("bulk operation 3 is completed: %w", apperrors.ErrBulkOperationFinished), http.StatusConflict},
+		"not owner": {fmt.Errorf("you can only cancel your own bulk operations: %w", apperrors.ErrForbidden), http.StatusForbidden},
+		"missing":   {fmt.Errorf("bulk operation 3: %w", apperrors.ErrNotFound), http.StatusNotFound},
+		"an import": {fmt.Errorf("bulk operation 3 was not queued and cannot be cancelled: %w", apperrors.ErrValidation), http.StatusBadRequest},
+	}
+	for name, tc := range cases {
+		suite.Run(name, func() {
+			suite.stub.operation, suite.stub.err = nil, tc.err
+			w := suite.do(http.MethodPost, "/bulk-operations/3/cancel", nil)
+			suite.Equal(tc.status, w.Code)
+		})
+	}
+}
+
+func (suite *BulkQueueHandlerTestSuite) TestCancel_InvalidID() {
+	w := suite.do(http.MethodPost, "/bulk-operations/abc/cancel", nil)
+	suite.Equal(http.StatusBadRequest, w.Code)
+}
+
+func (suite *BulkQueueHandlerTestSuite) TestRevert() {
+	suite.stub.reverted = &models.BulkRevertResult{OperationID: 3, Reverted: 1, Refused: []models.BulkRevertRefusal{
+		{ResourceID: 9, Reason: "changed", Message: "the record was changed after the operation"},
+	}}
+
+	w := suite.do(http.MethodPost, "/bulk-operations/3/revert", nil)
+
+	suite.Equal(http.StatusOK, w.Code)
+	suite.Contains(w.Body.String(), `"reverted":1`)
+	suite.Contains(w.Body.String(), `"resource_id":9,"reason":"changed"`)
+	suite.Equal(models.BuiltInPermissions(models.RoleSales), suite.stub.gotPermissions)
+}
+
+func (suite *BulkQueueHandlerTestSuite) TestRevert_Refusals() {
+	cases := map[string]struct {
+		err    error
+		status int
+	}{
+		"reverted":  {fmt.Errorf("bulk operation 3: %w", apperrors.ErrBulkOperationReverted), http.StatusConflict},
+		"expired":   {fmt.Errorf("bulk operation 3 finished more than 24h0m0s ago: %w", apperrors.ErrBulkRevertExpired), http.StatusConflict},
+		"not owner": {fmt.Errorf("you can only revert your own bulk operations: %w", apperrors.ErrForbidden), http.StatusForbidden},
+		"a create":  {fmt.Errorf("bulk operation 3 is a create, which cannot be reverted: %w", apperrors.ErrValidation), http.StatusBadRequest},
+	}
+	for name, tc := range cases {
+		suite.Run(name, func() {
+			suite.stub.err = tc.err
+			w := suite.do(http.MethodPost, "/bulk-operations/3/revert", nil)
+			suite.Equal(tc.status, w.Code)
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/middleware"
 	"github.com/florinel-chis/gophercrm/internal/models"
 	"github.com/florinel-chis/gophercrm/internal/service"
 	"github.com/florinel-chis/gophercrm/internal/utils"
@@ -13,6 +14,7 @@ import (
 )
 
 type ConfigurationHandler struct {
+	auditTrail
 	configService service.ConfigurationService
 }
 
@@ -57,6 +59,21 @@ func newConfigurationResponse(config models.Configuration) ConfigurationResponse
 	return response
 }
 
+// auditConfiguration snapshots an entry for the audit trail through the same
+// masking the API applies, so a sensitive value never reaches the trail. A
+// sensitive entry that was just written is marked as such instead: masked on
+// both sides, the write would otherwise compare equal and go unrecorded.
+func (h *ConfigurationHandler) auditConfiguration(config *models.Configuration, written bool) map[string]interface{} {
+	if config == nil {
+		return nil
+	}
+	snapshot := h.auditState(newConfigurationResponse(*config))
+	if snapshot != nil && written && config.IsSensitive {
+		snapshot["value"] = models.AuditRedactedValue
+	}
+	return snapshot
+}
+
 // newConfigurationResponses masks a list. It returns an empty slice rather than
 // nil so the JSON is always an array.
 func newConfigurationResponses(configs []models.Configuration) []ConfigurationResponse {
@@ -84,11 +101,8 @@ func newConfigurationResponses(configs []models.Configuration) []ConfigurationRe
 func (h *ConfigurationHandler) GetAll(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "ConfigurationHandler.GetAll")
 
-	currentUserRole := c.GetString("user_role")
-
-	// Only admin users can view all configurations
-	if currentUserRole != string(models.RoleAdmin) {
-		utils.RespondForbidden(c, "Only admin users can view configurations")
+	if !middleware.HasPermission(c, models.PermConfigurationsRead) {
+		utils.RespondForbidden(c, "Insufficient permissions to view configurations")
 		return
 	}
 
@@ -122,11 +136,8 @@ func (h *ConfigurationHandler) GetAll(c *gin.Context) {
 func (h *ConfigurationHandler) GetByCategory(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "ConfigurationHandler.GetByCategory")
 
-	currentUserRole := c.GetString("user_role")
-
-	// Only admin users can view configurations
-	if currentUserRole != string(models.RoleAdmin) {
-		utils.RespondForbidden(c, "Only admin users can view configurations")
+	if !middleware.HasPermission(c, models.PermConfigurationsRead) {
+		utils.RespondForbidden(c, "Insufficient permissions to view configurations")
 		return
 	}
 
@@ -167,11 +178,8 @@ func (h *ConfigurationHandler) GetByCategory(c *gin.Context) {
 func (h *ConfigurationHandler) GetByKey(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "ConfigurationHandler.GetByKey")
 
-	currentUserRole := c.GetString("user_role")
-
-	// Only admin users can view configurations
-	if currentUserRole != string(models.RoleAdmin) {
-		utils.RespondForbidden(c, "Only admin users can view configurations")
+	if !middleware.HasPermission(c, models.PermConfigurationsRead) {
+		utils.RespondForbidden(c, "Insufficient permissions to view configurations")
 		return
 	}
 
@@ -220,11 +228,8 @@ func (h *ConfigurationHandler) GetByKey(c *gin.Context) {
 func (h *ConfigurationHandler) Set(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "ConfigurationHandler.Set")
 
-	currentUserRole := c.GetString("user_role")
-
-	// Only admin users can modify configurations
-	if currentUserRole != string(models.RoleAdmin) {
-		utils.RespondForbidden(c, "Only admin users can modify configurations")
+	if !middleware.HasPermission(c, models.PermConfigurationsUpdate) {
+		utils.RespondForbidden(c, "Insufficient permissions to modify configurations")
 		return
 	}
 
@@ -254,6 +259,13 @@ func (h *ConfigurationHandler) Set(c *gin.Context) {
 		return
 	}
 
+	before := h.auditLoad(func() (interface{}, error) {
+		existing, err := h.configService.GetByKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return h.auditConfiguration(existing, false), nil
+	})
 	if err := h.configService.Set(key, value); err != nil {
 		logger.WithError(err).Error("Failed to set configuration")
 		switch {
@@ -277,6 +289,8 @@ func (h *ConfigurationHandler) Set(c *gin.Context) {
 		return
 	}
 
+	h.recordAudit(c, models.AuditEntityConfiguration, config.ID, models.AuditActionUpdate, before, h.auditConfiguration(config, true))
+
 	response := newConfigurationResponse(*config)
 	utils.LogHandlerResponse(logger, http.StatusOK, response)
 	utils.RespondSuccess(c, http.StatusOK, response)
@@ -302,11 +316,8 @@ func (h *ConfigurationHandler) Set(c *gin.Context) {
 func (h *ConfigurationHandler) Reset(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "ConfigurationHandler.Reset")
 
-	currentUserRole := c.GetString("user_role")
-
-	// Only admin users can reset configurations
-	if currentUserRole != string(models.RoleAdmin) {
-		utils.RespondForbidden(c, "Only admin users can reset configurations")
+	if !middleware.HasPermission(c, models.PermConfigurationsUpdate) {
+		utils.RespondForbidden(c, "Insufficient permissions to reset configurations")
 		return
 	}
 
@@ -316,6 +327,13 @@ func (h *ConfigurationHandler) Reset(c *gin.Context) {
 		return
 	}
 
+	before := h.auditLoad(func() (interface{}, error) {
+		existing, err := h.configService.GetByKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return h.auditConfiguration(existing, false), nil
+	})
 	if err := h.configService.Reset(key); err != nil {
 		logger.WithError(err).Error("Failed to reset configuration")
 		switch {
@@ -337,6 +355,8 @@ func (h *ConfigurationHandler) Reset(c *gin.Context) {
 		return
 	}
 
+	h.recordAudit(c, models.AuditEntityConfiguration, config.ID, models.AuditActionUpdate, before, h.auditConfiguration(config, true))
+
 	response := newConfigurationResponse(*config)
 	utils.LogHandlerResponse(logger, http.StatusOK, response)
 	utils.RespondSuccess(c, http.StatusOK, response)
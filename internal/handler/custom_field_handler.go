@@ -0,0 +1,342 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// customFieldParamPrefix marks the list query parameters, and the sort_by
+// values, that name a custom field rather than a column: ?cf.region=emea
+// filters on the region field, ?sort_by=cf.region sorts by it.
+const customFieldParamPrefix = "cf."
+
+type CustomFieldHandler struct {
+	auditTrail
+	customFieldService service.CustomFieldService
+}
+
+func NewCustomFieldHandler(customFieldService service.CustomFieldService) *CustomFieldHandler {
+	return &CustomFieldHandler{customFieldService: customFieldService}
+}
+
+// CreateCustomFieldRequest is the body of POST /custom-fields.
+type CreateCustomFieldRequest struct {
+	EntityType string                 `json:"entity_type" binding:"required,oneof=lead customer ticket task"`
+	Name       string                 `json:"name" binding:"required,max=50"`
+	Label      string                 `json:"label,omitempty" binding:"omitempty,max=100"`
+	Type       models.CustomFieldType `json:"type" binding:"required,oneof=text number date select multi_select boolean"`
+	Required   bool                   `json:"required"`
+	// Options are the allowed values of a select or multi_select field.
+	Options  []string `json:"options,omitempty"`
+	Position int      `json:"position"`
+}
+
+// UpdateCustomFieldRequest is the body of PUT /custom-fields/:id. Omitted
+// fields are left alone; the entity type, name and type cannot be changed.
+type UpdateCustomFieldRequest struct {
+	Label    *string  `json:"label,omitempty" binding:"omitempty,max=100"`
+	Required *bool    `json:"required,omitempty"`
+	Options  []string `json:"options,omitempty"`
+	Position *int     `json:"position,omitempty"`
+}
+
+// List godoc
+// @Summary List custom fields
+// @Description The custom field definitions of one entity type, or of every entity type, ordered by entity type, position and id. Available to the admin, sales and support roles, since the fields appear in the forms of all three.
+// @Tags custom-fields
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param entity_type query string false "Only the fields of this entity type" Enums(lead, customer, ticket, task)
+// @Success 200 {object} utils.APIResponse{data=[]models.CustomFieldDefinition} "Custom fields retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Unknown entity type"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin, sales or support role required"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /custom-fields [get]
+func (h *CustomFieldHandler) List(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "CustomFieldHandler.List")
+
+	definitions, err := h.customFieldService.ListDefinitions(c.Query("entity_type"))
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, definitions)
+	utils.RespondSuccess(c, http.StatusOK, definitions)
+}
+
+// Get godoc
+// @Summary Get a custom field
+// @Description One custom field definition. Available to the admin, sales and support roles.
+// @Tags custom-fields
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Custom field ID"
+// @Success 200 {object} utils.APIResponse{data=models.CustomFieldDefinition} "Custom field retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid custom field ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin, sales or support role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Custom field not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /custom-fields/{id} [get]
+func (h *CustomFieldHandler) Get(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "CustomFieldHandler.Get")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid custom field ID")
+		return
+	}
+
+	definition, err := h.customFieldService.GetDefinition(uint(id))
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, definition)
+	utils.RespondSuccess(c, http.StatusOK, definition)
+}
+
+// Create godoc
+// @Summary Create a custom field
+// @Description Define a custom field of leads, customers, tickets or tasks (admin role only). The name is the key of the value in the records' custom_fields: lowercase letters, digits and underscores, starting with a letter, unique per entity type. The label defaults to the name. Select and multi_select fields need between 1 and 50 distinct options; other types take none. A required field must be given whenever a record is created through the API or a bulk operation. An entity type holds at most 50 fields.
+// @Tags custom-fields
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param request body CreateCustomFieldRequest true "Custom field"
+// @Success 201 {object} utils.APIResponse{data=models.CustomFieldDefinition} "Custom field created successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid definition, taken name or too many fields"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /custom-fields [post]
+func (h *CustomFieldHandler) Create(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "CustomFieldHandler.Create")
+
+	var req CreateCustomFieldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	definition := &models.CustomFieldDefinition{
+		EntityType: req.EntityType,
+		Name:       req.Name,
+		Label:      req.Label,
+		Type:       req.Type,
+		Required:   req.Required,
+		Options:    req.Options,
+		Position:   req.Position,
+	}
+	if err := h.customFieldService.CreateDefinition(definition); err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityCustomField, definition.ID, models.AuditActionCreate, nil, definition)
+
+	utils.LogHandlerResponse(logger, http.StatusCreated, definition)
+	utils.RespondSuccess(c, http.StatusCreated, definition)
+}
+
+// Update godoc
+// @Summary Update a custom field
+// @Description Change the label, required flag, options or position of a custom field (admin role only). The entity type, name and type are fixed. Values already stored under an option that is removed are kept until the record is next saved with another value. Making a field required does not touch existing records.
+// @Tags custom-fields
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Custom field ID"
+// @Param request body UpdateCustomFieldRequest true "Custom field changes"
+// @Success 200 {object} utils.APIResponse{data=models.CustomFieldDefinition} "Custom field updated successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid custom field ID or definition"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Custom field not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /custom-fields/{id} [put]
+func (h *CustomFieldHandler) Update(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "CustomFieldHandler.Update")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid custom field ID")
+		return
+	}
+
+	var req UpdateCustomFieldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	definition, err := h.customFieldService.GetDefinition(uint(id))
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	before := h.auditState(definition)
+
+	if req.Label != nil {
+		definition.Label = *req.Label
+	}
+	if req.Required != nil {
+		definition.Required = *req.Required
+	}
+	if req.Options != nil {
+		definition.Options = req.Options
+	}
+	if req.Position != nil {
+		definition.Position = *req.Position
+	}
+
+	definition, err = h.customFieldService.UpdateDefinition(definition)
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityCustomField, definition.ID, models.AuditActionUpdate, before, definition)
+
+	utils.LogHandlerResponse(logger, http.StatusOK, definition)
+	utils.RespondSuccess(c, http.StatusOK, definition)
+}
+
+// Delete godoc
+// @Summary Delete a custom field
+// @Description Delete a custom field and every value stored under it (admin role only). The deletion is permanent, and the name becomes free for a new field.
+// @Tags custom-fields
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Custom field ID"
+// @Success 204 "Custom field deleted"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid custom field ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Custom field not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /custom-fields/{id} [delete]
+func (h *CustomFieldHandler) Delete(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "CustomFieldHandler.Delete")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid custom field ID")
+		return
+	}
+
+	before := h.auditLoad(func() (interface{}, error) { return h.customFieldService.GetDefinition(uint(id)) })
+	if err := h.customFieldService.DeleteDefinition(uint(id)); err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityCustomField, uint(id), models.AuditActionDelete, before, nil)
+
+	utils.LogHandlerResponse(logger, http.StatusNoContent, nil)
+	c.Status(http.StatusNoContent)
+}
+
+func (h *CustomFieldHandler) respondError(c *gin.Context, logger *logrus.Entry, err error) {
+	switch {
+	case errors.Is(err, apperrors.ErrValidation):
+		logger.WithError(err).Warn("Invalid custom field request")
+		utils.RespondBadRequest(c, err.Error())
+	case apperrors.IsNotFound(err):
+		logger.WithError(err).Warn("Custom field not found")
+		utils.RespondNotFound(c, "Custom field not found")
+	default:
+		logger.WithError(err).Error("Custom field operation failed")
+		utils.RespondInternalError(c)
+	}
+}
+
+// customFieldFilters is embedded by the handlers of the entities that carry
+// custom fields, and turns the cf.<name> parameters of their list requests
+// into a models.CustomFieldQuery. Like auditTrail it is optional: without the
+// service the parameters are ignored and the lists behave as before.
+type customFieldFilters struct {
+	customFieldService service.CustomFieldService
+}
+
+// SetCustomFieldService wires custom field filtering into the handler.
+func (f *customFieldFilters) SetCustomFieldService(customFieldService service.CustomFieldService) {
+	f.customFieldService = customFieldService
+}
+
+// customFieldQuery reads the custom field filters and sort of a list request.
+// A repeated parameter must match every one of its values. An empty query
+// means the request names no custom field; an error wraps
+// apperrors.ErrValidation when the request is at fault.
+func (f *customFieldFilters) customFieldQuery(c *gin.Context, entityType string) (models.CustomFieldQuery, error) {
+	if f.customFieldService == nil {
+		return models.CustomFieldQuery{}, nil
+	}
+
+	filters := map[string][]string{}
+	for key, values := range c.Request.URL.Query() {
+		if name, ok := strings.CutPrefix(key, customFieldParamPrefix); ok {
+			filters[name] = values
+		}
+	}
+	var sortField string
+	sortBy, sortOrder := listSort(c)
+	if name, ok := strings.CutPrefix(sortBy, customFieldParamPrefix); ok {
+		sortField = name
+	}
+
+	return f.customFieldService.ResolveQuery(entityType, filters, sortField, sortOrder)
+}
+
+// respondCustomFieldQueryError answers a list request whose custom field
+// parameters could not be resolved.
+func respondCustomFieldQueryError(c *gin.Context, logger *logrus.Entry, err error) {
+	if errors.Is(err, apperrors.ErrValidation) {
+		logger.WithError(err).Warn("Invalid custom field filter")
+		utils.RespondBadRequest(c, err.Error())
+		return
+	}
+	logger.WithError(err).Error("Failed to resolve custom field filter")
+	utils.RespondInternalError(c)
+}
+
+// requestCustomFields is the custom field map of a record created through the
+// API. It is never nil, even when the request sends none, so that the
+// required fields are enforced: a nil map would leave the values alone.
+func requestCustomFields(values map[string]interface{}) map[string]interface{} {
+	if values == nil {
+		return map[string]interface{}{}
+	}
+	return values
+}
+
+// customFieldDefinitions returns the custom fields of entityType, or none when
+// the service is not wired.
+func (f *customFieldFilters) customFieldDefinitions(entityType string) ([]models.CustomFieldDefinition, error) {
+	if f.customFieldService == nil {
+		return nil, nil
+	}
+	return f.customFieldService.ListDefinitions(entityType)
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	servicemocks "github.com/florinel-chis/gophercrm/internal/service/mocks"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+var _ service.CustomFieldService = (*mocks.CustomFieldService)(nil)
+
+type CustomFieldHandlerTestSuite struct {
+	suite.Suite
+	mockService     *mocks.CustomFieldService
+	mockLeadService *servicemocks.LeadService
+	mockAudit       *mocks.AuditService
+	handler         *CustomFieldHandler
+	leadHandler     *LeadHandler
+}
+
+func (suite *CustomFieldHandlerTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *CustomFieldHandlerTestSuite) SetupTest() {
+	suite.mockService = new(mocks.CustomFieldService)
+	suite.mockLeadService = new(servicemocks.LeadService)
+	suite.mockAudit = new(mocks.AuditService)
+	suite.handler = NewCustomFieldHandler(suite.mockService)
+	suite.handler.SetAuditService(suite.mockAudit)
+	suite.leadHandler = NewLeadHandler(suite.mockLeadService)
+	suite.leadHandler.SetCustomFieldService(suite.mockService)
+}
+
+func (suite *CustomFieldHandlerTestSuite) TearDownTest() {
+	suite.mockService.AssertExpectations(suite.T())
+	suite.mockLeadService.AssertExpectations(suite.T())
+	suite.mockAudit.AssertExpectations(suite.T())
+}
+
+func (suite *CustomFieldHandlerTestSuite) do(role models.UserRole, userID uint, method, path string, body interface{}) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Set("user_role", string(role))
+		c.Next()
+	})
+	SetupCustomFieldRoutes(router.Group(""), suite.handler)
+	router.GET("/leads", suite.leadHandler.List)
+
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (suite *CustomFieldHandlerTestSuite) TestRoutes_RoleGuards() {
+	suite.mockService.On("ListDefinitions", models.AuditEntityTicket).Return([]models.CustomFieldDefinition{}, nil)
+
+	w := suite.do(models.RoleSupport, 3, http.MethodGet, "/custom-fields?entity_type=ticket", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code, "every staff role reads the definitions")
+
+	w = suite.do(models.RoleSales, 5, http.MethodPost, "/custom-fields",
+		gin.H{"entity_type": "lead", "name": "region", "type": "text"})
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = suite.do(models.RoleSupport, 3, http.MethodDelete, "/custom-fields/1", nil)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = suite.do(models.RoleCustomer, 9, http.MethodGet, "/custom-fields", nil)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *CustomFieldHandlerTestSuite) TestCreate_Success() {
+	suite.mockService.On("CreateDefinition", mock.MatchedBy(func(d *models.CustomFieldDefinition) bool {
+		return d.EntityType == models.AuditEntityLead && d.Name == "region" && d.Type == models.CustomFieldSelect
+	})).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.CustomFieldDefinition).ID = 4
+	})
+	suite.mockAudit.On("Record", mock.Anything, models.AuditEntityCustomField, uint(4), models.AuditActionCreate,
+		nil, mock.Anything).Return(nil)
+
+	w := suite.do(models.RoleAdmin, 1, http.MethodPost, "/custom-fields",
+		gin.H{"entity_type": "lead", "name": "region", "type": "select", "options": []string{"emea", "apac"}})
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+}
+
+func (suite *CustomFieldHandlerTestSuite) TestCreate_InvalidDefinition() {
+	suite.mockService.On("CreateDefinition", mock.Anything).
+		Return(fmt.Errorf("a select field needs at least one option: %w", apperrors.ErrValidation))
+
+	w := suite.do(models.RoleAdmin, 1, http.MethodPost, "/custom-fields",
+		gin.H{"entity_type": "lead", "name": "region", "type": "select"})
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "needs at least one option")
+}
+
+func (suite *CustomFieldHandlerTestSuite) TestUpdate_KeepsOmittedFields() {
+	existing := &models.CustomFieldDefinition{ID: 2, EntityType: models.AuditEntityLead, Name: "region", Label: "Region",
+		Type: models.CustomFieldSelect, Options: []string{"emea"}, Position: 3}
+	suite.mockService.On("GetDefinition", uint(2)).Return(existing, nil)
+	suite.mockService.On("UpdateDefinition", mock.MatchedBy(func(d *models.CustomFieldDefinition) bool {
+		return d.Label == "Region" && d.Required && d.Position == 3 && len(d.Options) == 1
+	})).Return(existing, nil)
+	suite.mockAudit.On("Record", mock.Anything, models.AuditEntityCustomField, uint(2), models.AuditActionUpdate,
+		mock.Anything, mock.Anything).Return(nil)
+
+	w := suite.do(models.RoleAdmin, 1, http.MethodPut, "/custom-fields/2", gin.H{"required": true})
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *CustomFieldHandlerTestSuite) TestDelete_NotFound() {
+	suite.mockService.On("GetDefinition", uint(8)).Return(nil, fmt.Errorf("custom field 8: %w", apperrors.ErrNotFound))
+	suite.mockService.On("DeleteDefinition", uint(8)).Return(fmt.Errorf("custom field 8: %w", apperrors.ErrNotFound))
+
+	w := suite.do(models.RoleAdmin, 1, http.MethodDelete, "/custom-fields/8", nil)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *CustomFieldHandlerTestSuite) TestLeadList_FiltersByCustomField() {
+	query := models.CustomFieldQuery{Filters: []models.CustomFieldFilter{{FieldID: 1, Value: "emea"}}}
+	suite.mockService.On("ResolveQuery", models.AuditEntityLead, map[string][]string{"region": {"emea"}}, "", "asc").
+		Return(query, nil)
+	suite.mockLeadService.On("ListFiltered", models.LeadFilter{
+		Search: "acme", SortOrder: "asc", CustomFields: query, OwnerID: 5,
+	}, 0, mock.Anything).Return([]models.Lead{{BaseModel: models.BaseModel{ID: 7}}}, int64(1), nil)
+
+	w := suite.do(models.RoleSales, 5, http.MethodGet, "/leads?search=acme&cf.region=emea", nil)
+
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	var response struct {
+		Data struct {
+			Total int64 `json:"total"`
+		} `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), int64(1), response.Data.Total)
+}
+
+func (suite *CustomFieldHandlerTestSuite) TestLeadList_InvalidCustomField() {
+	suite.mockService.On("ResolveQuery", models.AuditEntityLead, map[string][]string{}, "colour", "asc").
+		Return(models.CustomFieldQuery{}, fmt.Errorf("unknown custom field %q for lead: %w", "colour", apperrors.ErrValidation))
+
+	w := suite.do(models.RoleAdmin, 1, http.MethodGet, "/leads?sort_by=cf.colour", nil)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "unknown custom field")
+}
+
+func TestCustomFieldHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(CustomFieldHandlerTestSuite))
+}
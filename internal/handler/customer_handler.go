@@ -1,13 +1,12 @@
 package handler
 
 import (
-	"encoding/csv"
 	"errors"
 	"net/http"
 	"strconv"
-	"time"
 
 	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/middleware"
 	"github.com/florinel-chis/gophercrm/internal/models"
 	"github.com/florinel-chis/gophercrm/internal/service"
 	"github.com/florinel-chis/gophercrm/internal/utils"
@@ -15,6 +14,9 @@ import (
 )
 
 type CustomerHandler struct {
+	auditTrail
+	customFieldFilters
+	savedViews
 	customerService service.CustomerService
 }
 
@@ -35,6 +37,9 @@ type CreateCustomerRequest struct {
 	Country    string `json:"country,omitempty"`
 	PostalCode string `json:"postal_code,omitempty"`
 	Notes      string `json:"notes,omitempty"`
+	AccountID  *uint  `json:"account_id,omitempty"`
+	// CustomFields holds the values of the customer custom fields, by name.
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
 }
 
 type UpdateCustomerRequest struct {
@@ -50,6 +55,9 @@ type UpdateCustomerRequest struct {
 	Country    string `json:"country,omitempty"`
 	PostalCode string `json:"postal_code,omitempty"`
 	Notes      string `json:"notes,omitempty"`
+	AccountID  *uint  `json:"account_id,omitempty"`
+	// CustomFields holds the values of the customer custom fields, by name.
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
 }
 
 // AssignCustomerRequest carries the staff account a customer is being handed to.
@@ -57,18 +65,27 @@ type AssignCustomerRequest struct {
 	UserID uint `json:"user_id" binding:"required"`
 }
 
-// customerExportColumns is the CSV header, and the order every data row is
-// written in. It is the file's contract with whatever spreadsheet or import
-// script consumes the download, so columns are appended, never reordered or
-// renamed in place.
-var customerExportColumns = []string{
-	"id", "first_name", "last_name", "email", "phone", "company",
-	"address", "notes", "assigned_to_id", "created_at", "updated_at",
+// customerExportColumns are the columns of the customer export, in the order
+// a download without the columns parameter writes them. It is the file's
+// contract with whatever spreadsheet or import script consumes the download,
+// so columns are appended, never reordered or renamed in place.
+var customerExportColumns = []exportColumn[models.Customer]{
+	{"id", func(c *models.Customer) interface{} { return c.ID }},
+	{"first_name", func(c *models.Customer) interface{} { return c.FirstName }},
+	{"last_name", func(c *models.Customer) interface{} { return c.LastName }},
+	{"email", func(c *models.Customer) interface{} { return c.Email }},
+	{"phone", func(c *models.Customer) interface{} { return c.Phone }},
+	{"company", func(c *models.Customer) interface{} { return c.Company }},
+	{"address", func(c *models.Customer) interface{} { return c.Address }},
+	{"notes", func(c *models.Customer) interface{} { return c.Notes }},
+	{"assigned_to_id", func(c *models.Customer) interface{} { return exportOptional(c.AssignedToID) }},
+	{"created_at", func(c *models.Customer) interface{} { return c.CreatedAt }},
+	{"updated_at", func(c *models.Customer) interface{} { return c.UpdatedAt }},
 }
 
 // Create godoc
 // @Summary Create a new customer
-// @Description Create a new customer (admin and sales roles only)
+// @Description Create a new customer (admin and sales roles only). Without account_id the customer is linked to the account its company names, if one exists.
 // @Tags customers
 // @Accept json
 // @Produce json
@@ -76,7 +93,7 @@ var customerExportColumns = []string{
 // @Security ApiKeyAuth
 // @Param request body CreateCustomerRequest true "Customer creation request"
 // @Success 201 {object} utils.APIResponse{data=models.Customer} "Customer created successfully"
-// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid request data"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid request data or unknown account_id"
 // @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
 // @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin or Sales role required"
 // @Failure 409 {object} utils.APIResponse{error=utils.APIError} "Customer with this email already exists"
@@ -86,10 +103,8 @@ var customerExportColumns = []string{
 func (h *CustomerHandler) Create(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "CustomerHandler.Create")
 
-	currentUserRole := c.GetString("user_role")
-	
 	// Only admin and sales users can create customers
-	if currentUserRole != string(models.RoleAdmin) && currentUserRole != string(models.RoleSales) {
+	if !middleware.HasPermission(c, models.PermCustomersCreate) {
 		utils.RespondForbidden(c, "Insufficient permissions to create customers")
 		return
 	}
@@ -113,18 +128,24 @@ func (h *CustomerHandler) Create(c *gin.Context) {
 		Country:    req.Country,
 		PostalCode: req.PostalCode,
 		Notes:      req.Notes,
+		AccountID:  req.AccountID,
 	}
+	customer.CustomFields = requestCustomFields(req.CustomFields)
 
 	if err := h.customerService.Create(customer); err != nil {
 		logger.WithError(err).Error("Failed to create customer")
 		if errors.Is(err, apperrors.ErrDuplicateEmail) {
 			utils.RespondConflict(c, "customer with this email already exists")
+		} else if errors.Is(err, apperrors.ErrValidation) {
+			utils.RespondBadRequest(c, err.Error())
 		} else {
 			utils.RespondInternalError(c)
 		}
 		return
 	}
 
+	h.recordAudit(c, models.AuditEntityCustomer, customer.ID, models.AuditActionCreate, nil, customer)
+
 	utils.LogHandlerResponse(logger, http.StatusCreated, customer)
 	utils.RespondSuccess(c, http.StatusCreated, customer)
 }
@@ -142,21 +163,26 @@ func (h *CustomerHandler) Create(c *gin.Context) {
 // @Param sort_by query string false "Sort column; ignored unless one of the allowed values" Enums(created_at, updated_at, first_name, last_name, email, company)
 // @Param sort_order query string false "Sort direction; anything else falls back to asc" Enums(asc, desc) default(asc)
 // @Param search query string false "Free-text search across customer fields; takes precedence over sort_by"
+// @Param cf.<name> query string false "Only customers whose custom field <name> holds this value; repeat to require several values. sort_by=cf.<name> sorts by the field"
+// @Param filter query string false "Structured filter, e.g. country eq DE and created_at gte 2026-01-01. Fields: id, first_name, last_name, email, phone, company, position, city, state, country, postal_code, account_id, assigned_to_id, user_id, created_at, updated_at"
+// @Param view_id query int false "Run this saved view: its filter applies together with filter, and its sort unless sort_by is given"
+// @Param cursor query string false "Page by keyset instead of offset: empty for the first page, then the meta.next_cursor of the previous one, with the same sort_by and sort_order. Not combinable with a custom field sort"
 // @Success 200 {object} utils.APIResponse{data=object{customers=[]models.Customer,total=integer},meta=utils.APIMeta} "Customers retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid filter, unknown custom field or invalid custom field value"
 // @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
 // @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin, Sales or Support role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Saved view not found"
 // @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
 // @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
 // @Router /customers [get]
 func (h *CustomerHandler) List(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "CustomerHandler.List")
 
-	currentUserRole := c.GetString("user_role")
+	if !h.applySavedView(c, logger, models.AuditEntityCustomer) {
+		return
+	}
 
-	// Only admin, sales, and support users can list customers
-	if currentUserRole != string(models.RoleAdmin) &&
-		currentUserRole != string(models.RoleSales) &&
-		currentUserRole != string(models.RoleSupport) {
+	if !middleware.HasPermission(c, models.PermCustomersRead) {
 		utils.RespondForbidden(c, "Insufficient permissions to list customers")
 		return
 	}
@@ -171,8 +197,7 @@ func (h *CustomerHandler) List(c *gin.Context) {
 	}
 
 	// Parse and validate sort parameters
-	sortBy := c.Query("sort_by")
-	sortOrder := c.DefaultQuery("sort_order", "asc")
+	sortBy, sortOrder := listSort(c)
 
 	if !customerSortColumns[sortBy] {
 		sortBy = ""
@@ -183,11 +208,34 @@ func (h *CustomerHandler) List(c *gin.Context) {
 
 	search := c.Query("search")
 
+	customFields, err := h.customFieldQuery(c, models.AuditEntityCustomer)
+	if err != nil {
+		respondCustomFieldQueryError(c, logger, err)
+		return
+	}
+	where, ok := parseListFilter(c, logger, "customers")
+	if !ok {
+		return
+	}
+	cursor, ok := parseListCursor(c, logger, "customers", sortBy, sortOrder, customFields)
+	if !ok {
+		return
+	}
+	fetchOffset, fetchLimit := cursorWindow(cursor, offset, limit)
+
 	var customers []models.Customer
 	var total int64
-	var err error
 
-	if search != "" {
+	if !customFields.Empty() || where != nil || cursor != nil {
+		customers, total, err = h.customerService.ListFiltered(models.CustomerFilter{
+			Search:       search,
+			SortBy:       sortBy,
+			SortOrder:    sortOrder,
+			CustomFields: customFields,
+			Where:        where,
+			Cursor:       cursor,
+		}, fetchOffset, fetchLimit)
+	} else if search != "" {
 		customers, total, err = h.customerService.Search(search, offset, limit, sortBy, sortOrder)
 	} else if sortBy != "" {
 		customers, total, err = h.customerService.ListSorted(offset, limit, sortBy, sortOrder)
@@ -200,6 +248,14 @@ func (h *CustomerHandler) List(c *gin.Context) {
 		utils.RespondInternalError(c)
 		return
 	}
+	var next string
+	if cursor != nil {
+		if customers, next, err = cursorPage(cursor, customers, limit); err != nil {
+			logger.WithError(err).Error("Failed to encode the next cursor")
+			utils.RespondInternalError(c)
+			return
+		}
+	}
 
 	meta := &utils.APIMeta{
 		RequestID:  c.GetString("request_id"),
@@ -208,6 +264,9 @@ func (h *CustomerHandler) List(c *gin.Context) {
 		Total:      total,
 		TotalPages: (total + int64(limit) - 1) / int64(limit),
 	}
+	if cursor != nil {
+		meta = cursorMeta(c, limit, total, next)
+	}
 
 	responseData := gin.H{"customers": customers, "total": total}
 	utils.LogHandlerResponse(logger, http.StatusOK, responseData)
@@ -233,12 +292,7 @@ func (h *CustomerHandler) List(c *gin.Context) {
 func (h *CustomerHandler) Get(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "CustomerHandler.Get")
 	
-	currentUserRole := c.GetString("user_role")
-	
-	// Only admin, sales, and support users can get customers
-	if currentUserRole != string(models.RoleAdmin) && 
-	   currentUserRole != string(models.RoleSales) && 
-	   currentUserRole != string(models.RoleSupport) {
+	if !middleware.HasPermission(c, models.PermCustomersRead) {
 		utils.RespondForbidden(c, "Insufficient permissions to view customers")
 		return
 	}
@@ -270,7 +324,7 @@ func (h *CustomerHandler) Get(c *gin.Context) {
 
 // Update godoc
 // @Summary Update a customer
-// @Description Update a customer (admin and sales roles only). Only non-empty fields in the request are applied; empty fields leave the stored value unchanged.
+// @Description Update a customer (admin and sales roles only). Only non-empty fields in the request are applied; empty fields leave the stored value unchanged. account_id 0 unlinks the customer from its account.
 // @Tags customers
 // @Accept json
 // @Produce json
@@ -279,7 +333,7 @@ func (h *CustomerHandler) Get(c *gin.Context) {
 // @Param id path int true "Customer ID"
 // @Param request body UpdateCustomerRequest true "Customer update request"
 // @Success 200 {object} utils.APIResponse{data=models.Customer} "Customer updated successfully"
-// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid customer ID or request data"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid customer ID or request data, or an unknown account_id"
 // @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
 // @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin or Sales role required"
 // @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Customer not found"
@@ -290,10 +344,8 @@ func (h *CustomerHandler) Get(c *gin.Context) {
 func (h *CustomerHandler) Update(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "CustomerHandler.Update")
 	
-	currentUserRole := c.GetString("user_role")
-	
 	// Only admin and sales users can update customers
-	if currentUserRole != string(models.RoleAdmin) && currentUserRole != string(models.RoleSales) {
+	if !middleware.HasPermission(c, models.PermCustomersUpdate) {
 		utils.RespondForbidden(c, "Insufficient permissions to update customers")
 		return
 	}
@@ -324,6 +376,8 @@ func (h *CustomerHandler) Update(c *gin.Context) {
 		return
 	}
 
+	before := h.auditState(customer)
+
 	// Apply updates
 	if req.FirstName != "" {
 		customer.FirstName = req.FirstName
@@ -361,17 +415,27 @@ func (h *CustomerHandler) Update(c *gin.Context) {
 	if req.Notes != "" {
 		customer.Notes = req.Notes
 	}
+	if req.AccountID != nil {
+		customer.AccountID = req.AccountID
+	}
+	if req.CustomFields != nil {
+		customer.CustomFields = req.CustomFields
+	}
 
 	if err := h.customerService.Update(customer); err != nil {
 		logger.WithError(err).Error("Failed to update customer")
 		if errors.Is(err, apperrors.ErrDuplicateEmail) {
 			utils.RespondConflict(c, "customer with this email already exists")
+		} else if errors.Is(err, apperrors.ErrValidation) {
+			utils.RespondBadRequest(c, err.Error())
 		} else {
 			utils.RespondInternalError(c)
 		}
 		return
 	}
 
+	h.recordAudit(c, models.AuditEntityCustomer, customer.ID, models.AuditActionUpdate, before, customer)
+
 	utils.LogHandlerResponse(logger, http.StatusOK, customer)
 	utils.RespondSuccess(c, http.StatusOK, customer)
 }
@@ -395,10 +459,8 @@ func (h *CustomerHandler) Update(c *gin.Context) {
 func (h *CustomerHandler) Delete(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "CustomerHandler.Delete")
 	
-	currentUserRole := c.GetString("user_role")
-	
 	// Only admin users can delete customers
-	if currentUserRole != string(models.RoleAdmin) {
+	if !middleware.HasPermission(c, models.PermCustomersDelete) {
 		utils.RespondForbidden(c, "Only administrators can delete customers")
 		return
 	}
@@ -423,6 +485,10 @@ func (h *CustomerHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	// Deleting a customer erases them; the delete event carries no state so
+	// it cannot quote the personal data the erasure just removed.
+	h.recordAudit(c, models.AuditEntityCustomer, uint(id), models.AuditActionDelete, nil, nil)
+
 	utils.LogHandlerResponse(logger, http.StatusNoContent, nil)
 	c.Status(http.StatusNoContent)
 }
@@ -439,158 +505,91 @@ var customerSortColumns = map[string]bool{
 }
 
 // Export godoc
-// @Summary Export customers as CSV
-// @Description Download every customer matching the optional filters as a CSV file. ADMIN ROLE ONLY, and deliberately narrower than the list endpoint, which sales and support can also read: a single request here egresses the personal data of the entire customer base in a form that leaves the application, so the GDPR data-minimisation principle puts it out of reach of the roles that only need to work one record at a time.
+// @Summary Export customers as CSV, XLSX or NDJSON
+// @Description Download every customer matching the optional filters as a file. ADMIN ROLE ONLY, and deliberately narrower than the list endpoint, which sales and support can also read: a single request here egresses the personal data of the entire customer base in a form that leaves the application, so the GDPR data-minimisation principle puts it out of reach of the roles that only need to work one record at a time.
 // @Description
-// @Description The response is NOT the utils.APIResponse envelope. It is the raw CSV body — Content-Type text/csv; charset=utf-8, Content-Disposition attachment; filename=customers-export.csv — because the client saves it as a file. Errors before the file starts are still reported through the ordinary JSON envelope.
+// @Description The response is NOT the utils.APIResponse envelope. It is the raw file — by default CSV, Content-Type text/csv; charset=utf-8, Content-Disposition attachment; filename=customers-export.csv; with format=xlsx an Excel workbook, with format=ndjson one JSON object per line — because the client saves it as a file. Errors before the file starts are still reported through the ordinary JSON envelope.
 // @Description
-// @Description Columns, in order: id, first_name, last_name, email, phone, company, address, notes, assigned_to_id, created_at, updated_at. Timestamps are RFC3339. An unassigned customer exports an empty assigned_to_id cell. Fields that a spreadsheet would treat as a formula are prefixed with an apostrophe.
+// @Description Columns, in order: id, first_name, last_name, email, phone, company, address, notes, assigned_to_id, created_at, updated_at, then one cf.<name> column per customer custom field, in the fields' position order. Timestamps are RFC3339. An unassigned customer exports an empty assigned_to_id cell. A multi_select value is its options joined with semicolons. Fields that a spreadsheet would treat as a formula are prefixed with an apostrophe in CSV and XLSX; NDJSON keeps every value as it is, with its JSON type. columns picks and orders a subset.
 // @Description
-// @Description The export is not paginated: every matching row is included. Soft-deleted (erased) customers are excluded.
+// @Description The export is not paginated: every matching row is included. Soft-deleted (erased) customers are excluded. Rows are streamed as they are read, in batches, so an export of any size holds constant memory on the server; a failure after the file has started drops the connection rather than ending a short file.
 // @Tags customers
 // @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/x-ndjson
 // @Security BearerAuth
 // @Security ApiKeyAuth
+// @Param format query string false "File format" Enums(csv, xlsx, ndjson) default(csv)
+// @Param columns query string false "Comma-separated columns to write, in this order; all of them by default"
 // @Param search query string false "Free-text search across first name, last name, email, company, phone and notes"
 // @Param sort_by query string false "Sort column; ignored unless one of the allowed values" Enums(created_at, updated_at, first_name, last_name, email, company)
 // @Param sort_order query string false "Sort direction; ignored unless sort_by is supplied" Enums(asc, desc) default(asc)
-// @Success 200 {string} string "CSV file of customers (raw body, not the API envelope)"
+// @Param cf.<name> query string false "Only customers whose custom field <name> holds this value"
+// @Param filter query string false "Structured filter, as on the list endpoint"
+// @Param view_id query int false "Export what this saved view shows"
+// @Success 200 {string} string "File of customers (raw body, not the API envelope)"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid format, column, filter or custom field, or a custom field sort"
 // @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
 // @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Saved view not found"
 // @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
 // @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
 // @Router /customers/export [get]
 func (h *CustomerHandler) Export(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "CustomerHandler.Export")
 
-	// Belt and braces with the route-level RequireRole(admin) guard. A bulk PII
+	// Belt and braces with the route-level permission guard. A bulk PII
 	// download is the last endpoint that should depend on a single check.
-	if c.GetString("user_role") != string(models.RoleAdmin) {
+	if !middleware.HasPermission(c, models.PermCustomersExport) {
 		utils.RespondForbidden(c, "Only administrators can export customers")
 		return
 	}
 
-	search := c.Query("search")
-	sortBy := c.Query("sort_by")
-	sortOrder := c.DefaultQuery("sort_order", "asc")
-
-	if !customerSortColumns[sortBy] {
-		sortBy = ""
-	}
-	if sortBy == "" {
-		// A direction without a column means nothing; do not pass one down.
-		sortOrder = ""
-	} else if sortOrder != "asc" && sortOrder != "desc" {
-		sortOrder = "asc"
+	if !h.applySavedView(c, logger, models.AuditEntityCustomer) {
+		return
 	}
 
-	// The rows are fetched in full before a single byte is written. Streaming
-	// straight from the database would mean a failure halfway through arriving
-	// after a 200 and half a file, which the client would save as a complete
-	// export.
-	customers, err := h.customerService.ExportAll(search, sortBy, sortOrder)
+	sortBy, sortOrder := exportSort(c, customerSortColumns)
+	customFields, err := h.customFieldQuery(c, models.AuditEntityCustomer)
 	if err != nil {
-		logger.WithError(err).Error("Failed to export customers")
-		utils.RespondInternalError(c)
+		respondCustomFieldQueryError(c, logger, err)
 		return
 	}
-
-	c.Header("Content-Type", "text/csv; charset=utf-8")
-	c.Header("Content-Disposition", "attachment; filename=customers-export.csv")
-	c.Status(http.StatusOK)
-
-	writer := csv.NewWriter(c.Writer)
-	if err := writer.Write(customerExportColumns); err != nil {
-		// The status line is already out; all that is left is to record it.
-		logger.WithError(err).Error("Failed to write CSV header")
+	if customFields.Sort != nil {
+		utils.RespondBadRequest(c, "an export cannot be sorted by a custom field")
 		return
 	}
-
-	for i := range customers {
-		if err := writer.Write(customerExportRecord(&customers[i])); err != nil {
-			logger.WithError(err).WithField("customer_id", customers[i].ID).Error("Failed to write CSV row")
-			return
-		}
-	}
-
-	writer.Flush()
-	if err := writer.Error(); err != nil {
-		logger.WithError(err).Error("Failed to flush CSV export")
+	where, ok := parseListFilter(c, logger, "customers")
+	if !ok {
 		return
 	}
-
-	logger.WithField("count", len(customers)).Info("Customer export written")
-}
-
-// customerExportRecord renders one customer as a CSV row, in the column order
-// pinned by customerExportColumns.
-func customerExportRecord(customer *models.Customer) []string {
-	assignedTo := ""
-	if customer.AssignedToID != nil {
-		assignedTo = strconv.FormatUint(uint64(*customer.AssignedToID), 10)
-	}
-
-	return []string{
-		strconv.FormatUint(uint64(customer.ID), 10),
-		csvSafeField(customer.FirstName),
-		csvSafeField(customer.LastName),
-		csvSafeField(customer.Email),
-		csvSafeField(customer.Phone),
-		csvSafeField(customer.Company),
-		csvSafeField(customer.Address),
-		csvSafeField(customer.Notes),
-		assignedTo,
-		customer.CreatedAt.Format(time.RFC3339),
-		customer.UpdatedAt.Format(time.RFC3339),
+	filter := models.CustomerFilter{
+		Search:       c.Query("search"),
+		SortBy:       sortBy,
+		SortOrder:    sortOrder,
+		CustomFields: customFields,
+		Where:        where,
 	}
-}
 
-// csvSafeField blunts spreadsheet formula injection. A cell that opens with =,
-// @, a tab or a carriage return is executed as a formula by Excel and by
-// LibreOffice, so text that arrived from outside (notes carried over from a
-// converted lead, a company name) could run when an administrator opens the
-// download. Prefixing an apostrophe makes the spreadsheet treat it as literal
-// text.
-//
-// A leading + or - is treated separately: those overwhelmingly begin phone
-// numbers and negative figures, and mangling every international dialling code
-// would be a worse outcome than the risk. They are escaped only when what
-// follows is not a plain number.
-func csvSafeField(value string) string {
-	if value == "" {
-		return value
-	}
-
-	switch value[0] {
-	case '=', '@', '\t', '\r':
-		return "'" + value
-	case '+', '-':
-		if !isNumericLike(value[1:]) {
-			return "'" + value
-		}
+	definitions, err := h.customFieldDefinitions(models.AuditEntityCustomer)
+	if err != nil {
+		logger.WithError(err).Error("Failed to load customer custom fields for export")
+		utils.RespondInternalError(c)
+		return
 	}
-	return value
-}
+	columns := append(append([]exportColumn[models.Customer]{}, customerExportColumns...),
+		customFieldColumns(definitions, func(c *models.Customer) map[string]interface{} { return c.CustomFields })...)
 
-// isNumericLike reports whether the rest of a +/- prefixed value looks like a
-// phone number or a plain figure rather than a formula.
-func isNumericLike(value string) bool {
-	for _, r := range value {
-		switch {
-		case r >= '0' && r <= '9':
-		case r == ' ', r == '-', r == '(', r == ')', r == '.', r == '+', r == ',':
-		default:
-			return false
-		}
-	}
-	return true
+	writeExport(c, logger, "customers", columns, func(fn func([]models.Customer) error) error {
+		return h.customerService.StreamExport(filter, fn)
+	})
 }
 
 // Assign godoc
 // @Summary Assign a customer to a user
-// @Description Set the staff account that owns this customer relationship (admin and sales roles only; the route is guarded by middleware.RequireRole(admin, sales) and the handler repeats the check).
+// @Description Set the staff account that owns this customer relationship (admin and sales roles only; the route is guarded by middleware.RequirePermission(customers:assign) and the handler repeats the check).
 // @Description
-// @Description The target account must exist, must be active, and must hold the admin or sales role: customer ownership is a sales function, so support accounts are rejected the same way a support-only ticket assignee is, and a customer-role account is rejected outright — handing it a book of other people's records would be a data-protection incident.
+// @Description The target account must exist, must be active, and must hold a role, built-in or custom, with the customers:update permission (of the built-in roles, admin and sales): customer ownership is a sales function, so support accounts are rejected the same way a ticket assignee who may not update tickets is, and a customer-role account is rejected outright — handing it a book of other people's records would be a data-protection incident.
 // @Description
 // @Description A missing customer or a missing user is 404. A user that exists but is deactivated or holds the wrong role is 400, because the request identified a real account and was refused on its merits.
 // @Tags customers
@@ -611,10 +610,8 @@ func isNumericLike(value string) bool {
 func (h *CustomerHandler) Assign(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "CustomerHandler.Assign")
 
-	currentUserRole := c.GetString("user_role")
-
 	// Only admin and sales users can assign customers
-	if currentUserRole != string(models.RoleAdmin) && currentUserRole != string(models.RoleSales) {
+	if !middleware.HasPermission(c, models.PermCustomersAssign) {
 		utils.RespondForbidden(c, "Insufficient permissions to assign customers")
 		return
 	}
@@ -631,6 +628,7 @@ func (h *CustomerHandler) Assign(c *gin.Context) {
 		return
 	}
 
+	before := h.auditLoad(func() (interface{}, error) { return h.customerService.GetByID(uint(id)) })
 	customer, err := h.customerService.Assign(uint(id), req.UserID)
 	if err != nil {
 		switch {
@@ -647,7 +645,7 @@ func (h *CustomerHandler) Assign(c *gin.Context) {
 			utils.RespondBadRequest(c, "Cannot assign a customer to a deactivated user")
 		case errors.Is(err, apperrors.ErrInvalidCustomerAssignee):
 			logger.WithError(err).Warn("Assignee holds a role that cannot own customers")
-			utils.RespondBadRequest(c, "Customers can only be assigned to sales or admin users")
+			utils.RespondBadRequest(c, "Customers can only be assigned to users whose role may update customers")
 		default:
 			logger.WithError(err).Error("Failed to assign customer")
 			utils.RespondInternalError(c)
@@ -655,6 +653,8 @@ func (h *CustomerHandler) Assign(c *gin.Context) {
 		return
 	}
 
+	h.recordAudit(c, models.AuditEntityCustomer, customer.ID, models.AuditActionUpdate, before, customer)
+
 	utils.LogHandlerResponse(logger, http.StatusOK, customer)
 	utils.RespondSuccess(c, http.StatusOK, customer)
 }
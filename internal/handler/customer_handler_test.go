@@ -726,6 +726,19 @@ var exportCSVColumns = []string{
 	"address", "notes", "assigned_to_id", "created_at", "updated_at",
 }
 
+// streamCustomers makes the service mock hand each batch to the export's
+// callback, stopping at the first error like the real one.
+func streamCustomers(batches ...[]models.Customer) func(mock.Arguments) {
+	return func(args mock.Arguments) {
+		fn := args.Get(1).(func([]models.Customer) error)
+		for _, batch := range batches {
+			if fn(batch) != nil {
+				return
+			}
+		}
+	}
+}
+
 func (suite *CustomerHandlerTestSuite) TestExport_Success() {
 	suite.router.GET("/customers/export", suite.handler.Export)
 
@@ -745,7 +758,7 @@ func (suite *CustomerHandlerTestSuite) TestExport_Success() {
 		},
 	}
 
-	suite.mockService.On("ExportAll", "", "", "").Return(customers, nil)
+	suite.mockService.On("StreamExport", models.CustomerFilter{}, mock.Anything).Run(streamCustomers(customers)).Return(nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/customers/export", nil)
 	rec := httptest.NewRecorder()
@@ -772,9 +785,9 @@ func (suite *CustomerHandlerTestSuite) TestExport_Success() {
 func (suite *CustomerHandlerTestSuite) TestExport_HonoursSearchFilter() {
 	suite.router.GET("/customers/export", suite.handler.Export)
 
-	suite.mockService.On("ExportAll", "acme", "", "").Return([]models.Customer{
+	suite.mockService.On("StreamExport", models.CustomerFilter{Search: "acme"}, mock.Anything).Run(streamCustomers([]models.Customer{
 		{BaseModel: models.BaseModel{ID: 1}, FirstName: "John", LastName: "Doe", Email: "john@acme.com", Company: "Acme Corp"},
-	}, nil)
+	})).Return(nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/customers/export?search=acme", nil)
 	rec := httptest.NewRecorder()
@@ -791,7 +804,7 @@ func (suite *CustomerHandlerTestSuite) TestExport_HonoursSearchFilter() {
 func (suite *CustomerHandlerTestSuite) TestExport_HonoursSortParameters() {
 	suite.router.GET("/customers/export", suite.handler.Export)
 
-	suite.mockService.On("ExportAll", "", "email", "desc").Return([]models.Customer{}, nil)
+	suite.mockService.On("StreamExport", models.CustomerFilter{SortBy: "email", SortOrder: "desc"}, mock.Anything).Return(nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/customers/export?sort_by=email&sort_order=desc", nil)
 	rec := httptest.NewRecorder()
@@ -806,7 +819,7 @@ func (suite *CustomerHandlerTestSuite) TestExport_HonoursSortParameters() {
 func (suite *CustomerHandlerTestSuite) TestExport_RejectsUnknownSortColumn() {
 	suite.router.GET("/customers/export", suite.handler.Export)
 
-	suite.mockService.On("ExportAll", "", "", "").Return([]models.Customer{}, nil)
+	suite.mockService.On("StreamExport", models.CustomerFilter{}, mock.Anything).Return(nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/customers/export?sort_by=password;DROP+TABLE+customers", nil)
 	rec := httptest.NewRecorder()
@@ -819,7 +832,7 @@ func (suite *CustomerHandlerTestSuite) TestExport_RejectsUnknownSortColumn() {
 func (suite *CustomerHandlerTestSuite) TestExport_EmptyDatabaseYieldsHeaderOnly() {
 	suite.router.GET("/customers/export", suite.handler.Export)
 
-	suite.mockService.On("ExportAll", "", "", "").Return([]models.Customer{}, nil)
+	suite.mockService.On("StreamExport", models.CustomerFilter{}, mock.Anything).Return(nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/customers/export", nil)
 	rec := httptest.NewRecorder()
@@ -857,7 +870,7 @@ func (suite *CustomerHandlerTestSuite) TestExport_ForbiddenForSalesUser() {
 func (suite *CustomerHandlerTestSuite) TestExport_ServiceFailureIsInternalError() {
 	suite.router.GET("/customers/export", suite.handler.Export)
 
-	suite.mockService.On("ExportAll", "", "", "").Return(nil, errors.New("connection refused"))
+	suite.mockService.On("StreamExport", models.CustomerFilter{}, mock.Anything).Return(errors.New("connection refused"))
 
 	req := httptest.NewRequest(http.MethodGet, "/customers/export", nil)
 	rec := httptest.NewRecorder()
@@ -872,6 +885,28 @@ func (suite *CustomerHandlerTestSuite) TestExport_ServiceFailureIsInternalError(
 	assert.False(suite.T(), response.Success)
 }
 
+// Rows go out as they are read. A failure after the first batch cannot turn
+// into a 500 any more, so the connection is dropped instead of ending the
+// response with a file that looks complete.
+func (suite *CustomerHandlerTestSuite) TestExport_FailureAfterStartAbortsConnection() {
+	suite.router.GET("/customers/export", suite.handler.Export)
+
+	suite.mockService.On("StreamExport", models.CustomerFilter{}, mock.Anything).
+		Run(streamCustomers([]models.Customer{{BaseModel: models.BaseModel{ID: 1}, FirstName: "John", LastName: "Doe"}})).
+		Return(errors.New("connection reset"))
+
+	req := httptest.NewRequest(http.MethodGet, "/customers/export", nil)
+	rec := httptest.NewRecorder()
+
+	assert.PanicsWithValue(suite.T(), http.ErrAbortHandler, func() { suite.router.ServeHTTP(rec, req) })
+	assert.Equal(suite.T(), http.StatusOK, rec.Code)
+	assert.True(suite.T(), rec.Flushed, "the first batch was streamed before the failure")
+
+	records, err := csv.NewReader(strings.NewReader(rec.Body.String())).ReadAll()
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), records, 2, "the header and the batch that was read")
+}
+
 // --- Assign ------------------------------------------------------------------
 
 func (suite *CustomerHandlerTestSuite) TestAssign_Success() {
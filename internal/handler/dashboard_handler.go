@@ -1,12 +1,15 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"sort"
 	"strconv"
 	"time"
 
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/middleware"
 	"github.com/florinel-chis/gophercrm/internal/models"
 	"github.com/florinel-chis/gophercrm/internal/service"
 	"github.com/florinel-chis/gophercrm/internal/utils"
@@ -18,6 +21,7 @@ type DashboardHandler struct {
 	customerService service.CustomerService
 	ticketService   service.TicketService
 	taskService     service.TaskService
+	activityService service.ActivityService
 }
 
 func NewDashboardHandler(
@@ -25,12 +29,14 @@ func NewDashboardHandler(
 	customerService service.CustomerService,
 	ticketService service.TicketService,
 	taskService service.TaskService,
+	activityService service.ActivityService,
 ) *DashboardHandler {
 	return &DashboardHandler{
 		leadService:     leadService,
 		customerService: customerService,
 		ticketService:   ticketService,
 		taskService:     taskService,
+		activityService: activityService,
 	}
 }
 
@@ -124,7 +130,8 @@ type ChartData struct {
 	Datasets []ChartDataset `json:"datasets"`
 }
 
-// ActivityUser is the actor shown against an activity entry.
+// ActivityUser is the user shown against an activity entry: the owner of the
+// record the entry is about.
 //
 // Username carries the user's email address: models.User has no username
 // column, and the email is the only human-readable handle an account has. The
@@ -138,14 +145,16 @@ type ActivityUser struct {
 	LastName  string `json:"last_name"`
 }
 
-// Activity is one entry of the recent-activity feed. There is no activity or
-// audit table in this schema, so entries are synthesised from the timestamps
-// already carried by leads, tickets and tasks.
+// Activity is one entry of the activity feed, projected from a stored
+// models.ActivityEvent. ID stays a string, as it was when the feed was
+// synthesised from the entity tables, so existing clients keep parsing it.
 type Activity struct {
 	ID          string       `json:"id"`
 	Type        string       `json:"type"`
 	Title       string       `json:"title"`
 	Description string       `json:"description"`
+	EntityType  string       `json:"entity_type"`
+	EntityID    uint         `json:"entity_id"`
 	User        ActivityUser `json:"user"`
 	CreatedAt   time.Time    `json:"created_at"`
 }
@@ -443,146 +452,115 @@ func (h *DashboardHandler) GetSalesPerformance(c *gin.Context) {
 }
 
 // GetActivities godoc
-// @Summary Recent activity feed
-// @Description Recent activity across the CRM, newest first, as a bare array. There is no activity or audit table in this schema, so entries are synthesised from the rows themselves: leads created (lead_created) and converted (lead_converted), tickets created (ticket_created) and resolved or closed (ticket_resolved), and tasks completed (task_completed). Each source contributes at most `limit` candidates; they are merged, sorted by event time descending and truncated to `limit`. Events with no dedicated timestamp column use updated_at as the event time, so a later edit moves the entry. Each id is stable and of the form "lead-42-created". The user is the lead's owner or the ticket's or task's assignee, with username carrying the account's email address because there is no username column; when the record has no assignee the user object is present but zero-valued. The feed is system-wide and is not narrowed to the caller's own records. Restricted to the admin, sales and support roles; customer-role callers receive 403.
+// @Summary Activity feed
+// @Description The activity feed, newest first, as a bare array. Entries are written by the services at the moment a lead, customer, ticket or task is created, edited, reassigned, moved between statuses, converted, resolved, completed or deleted; the type names the entity and what happened (lead_created, ticket_status_changed, task_reassigned and so on). The user is the record's owner when the event happened — the lead's owner, the customer's account manager, the ticket's or task's assignee — with username carrying the account's email address because there is no username column; when the record had no owner the user object is present but zero-valued. Admins see every entry and may narrow the feed to one owner with user_id. Every other role sees only the entries about records they own now, including those from before the record was reassigned to them, and asking for another user's feed is answered with 403. Pagination is by cursor: when more entries exist, meta.next_cursor holds the value to pass as cursor to fetch the next page; it is absent on the last page. Restricted to the admin, sales and support roles; customer-role callers receive 403.
 // @Tags dashboard
 // @Produce json
 // @Security BearerAuth
 // @Security ApiKeyAuth
 // @Param limit query int false "Maximum entries to return, capped at 50" minimum(1) maximum(50) default(10)
-// @Success 200 {object} utils.APIResponse{data=[]Activity} "Activity feed retrieved successfully"
+// @Param cursor query string false "meta.next_cursor of the previous page"
+// @Param entity_type query string false "Only entries about this entity type" Enums(lead, customer, ticket, task, deal, account)
+// @Param entity_id query int false "Only entries about this record; combine with entity_type"
+// @Param user_id query int false "Only entries about records this user currently owns; admins only, or the caller's own id"
+// @Success 200 {object} utils.APIResponse{data=[]Activity,meta=utils.APIMeta} "Activity feed retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid filter or cursor"
 // @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
-// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin, sales or support role required"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin, sales or support role required, or another user's feed requested"
 // @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
 // @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
 // @Router /dashboard/activities [get]
 func (h *DashboardHandler) GetActivities(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "DashboardHandler.GetActivities")
 
-	limit := parseDashboardLimit(c, defaultActivityLimit)
-	activities := make([]Activity, 0, limit)
-
-	// Each source is asked for `limit` rows: any one of them could supply the
-	// whole page, and none of them can supply more than it.
-	createdLeads, err := h.leadService.GetRecent(limit)
-	if err != nil {
-		logger.WithError(err).Error("Failed to get recent leads")
-		utils.RespondInternalError(c)
-		return
-	}
-	for _, lead := range createdLeads {
-		activities = append(activities, Activity{
-			ID:          fmt.Sprintf("lead-%d-created", lead.ID),
-			Type:        "lead_created",
-			Title:       "New lead",
-			Description: describePerson(lead.FirstName, lead.LastName, lead.Company) + " was added as a lead",
-			User:        activityUserFrom(&lead.Owner),
-			CreatedAt:   lead.CreatedAt,
-		})
-	}
-
-	convertedLeads, err := h.leadService.GetRecentlyConverted(limit)
-	if err != nil {
-		logger.WithError(err).Error("Failed to get recently converted leads")
-		utils.RespondInternalError(c)
+	filter, ok := parseActivityFilter(c)
+	if !ok {
 		return
 	}
-	for _, lead := range convertedLeads {
-		activities = append(activities, Activity{
-			ID:          fmt.Sprintf("lead-%d-converted", lead.ID),
-			Type:        "lead_converted",
-			Title:       "Lead converted",
-			Description: describePerson(lead.FirstName, lead.LastName, lead.Company) + " was converted to a customer",
-			User:        activityUserFrom(&lead.Owner),
-			CreatedAt:   lead.UpdatedAt,
-		})
-	}
 
-	createdTickets, err := h.ticketService.GetRecent(limit)
-	if err != nil {
-		logger.WithError(err).Error("Failed to get recent tickets")
-		utils.RespondInternalError(c)
-		return
-	}
-	for _, ticket := range createdTickets {
-		activities = append(activities, Activity{
-			ID:          fmt.Sprintf("ticket-%d-created", ticket.ID),
-			Type:        "ticket_created",
-			Title:       "New ticket",
-			Description: ticket.Title,
-			User:        activityUserFrom(ticket.AssignedTo),
-			CreatedAt:   ticket.CreatedAt,
-		})
+	// Whoever may read the audit trail reads the whole feed; anyone else reads
+	// the entries about their own records, which also hides the unowned ones.
+	callerID := c.GetUint("user_id")
+	if !middleware.HasPermission(c, models.PermAuditRead) {
+		if filter.OwnerID != 0 && filter.OwnerID != callerID {
+			utils.RespondForbidden(c, "You can only view activity on your own records")
+			return
+		}
+		filter.OwnerID = callerID
 	}
 
-	resolvedTickets, err := h.ticketService.GetRecentlyResolved(limit)
+	limit := parseDashboardLimit(c, defaultActivityLimit)
+	events, next, err := h.activityService.Feed(filter, c.Query("cursor"), limit)
 	if err != nil {
-		logger.WithError(err).Error("Failed to get recently resolved tickets")
+		if errors.Is(err, apperrors.ErrValidation) {
+			utils.RespondBadRequest(c, "Invalid cursor")
+			return
+		}
+		logger.WithError(err).Error("Failed to get activity feed")
 		utils.RespondInternalError(c)
 		return
 	}
-	for _, ticket := range resolvedTickets {
-		activities = append(activities, Activity{
-			ID:          fmt.Sprintf("ticket-%d-resolved", ticket.ID),
-			Type:        "ticket_resolved",
-			Title:       "Ticket resolved",
-			Description: ticket.Title,
-			User:        activityUserFrom(ticket.AssignedTo),
-			CreatedAt:   ticket.UpdatedAt,
-		})
-	}
 
-	completedTasks, err := h.taskService.GetRecentlyCompleted(limit)
-	if err != nil {
-		logger.WithError(err).Error("Failed to get recently completed tasks")
-		utils.RespondInternalError(c)
-		return
-	}
-	for _, task := range completedTasks {
+	activities := make([]Activity, 0, len(events))
+	for i := range events {
+		event := &events[i]
 		activities = append(activities, Activity{
-			ID:          fmt.Sprintf("task-%d-completed", task.ID),
-			Type:        "task_completed",
-			Title:       "Task completed",
-			Description: task.Title,
-			User:        activityUserFrom(&task.AssignedTo),
-			CreatedAt:   task.UpdatedAt,
+			ID:          strconv.FormatUint(uint64(event.ID), 10),
+			Type:        string(event.Type),
+			Title:       event.Title,
+			Description: event.Description,
+			EntityType:  event.EntityType,
+			EntityID:    event.EntityID,
+			User:        activityUserFrom(event.Owner),
+			CreatedAt:   event.CreatedAt,
 		})
 	}
 
-	// Newest first. The tie-break on ID keeps the order stable when two events
-	// share a timestamp, which they routinely do on second-granularity columns.
-	sort.SliceStable(activities, func(i, j int) bool {
-		if activities[i].CreatedAt.Equal(activities[j].CreatedAt) {
-			return activities[i].ID < activities[j].ID
-		}
-		return activities[i].CreatedAt.After(activities[j].CreatedAt)
-	})
-
-	if len(activities) > limit {
-		activities = activities[:limit]
-	}
-
 	utils.LogHandlerResponse(logger, http.StatusOK, activities)
-	utils.RespondSuccess(c, http.StatusOK, activities)
+	utils.RespondSuccessWithMeta(c, http.StatusOK, activities, &utils.APIMeta{NextCursor: next})
+}
+
+// activityEntityTypes are the entities that write to the feed.
+var activityEntityTypes = map[string]bool{
+	models.AuditEntityLead:     true,
+	models.AuditEntityCustomer: true,
+	models.AuditEntityTicket:   true,
+	models.AuditEntityTask:     true,
+	models.AuditEntityDeal:     true,
+	models.AuditEntityAccount:  true,
 }
 
-// describePerson renders a lead as "First Last (Company)", degrading gracefully
-// when either half is missing.
-func describePerson(firstName, lastName, company string) string {
-	name := firstName
-	if lastName != "" {
-		if name != "" {
-			name += " "
+// parseActivityFilter reads the feed's query filters. As with the audit
+// listing, a malformed filter is rejected rather than dropped, since dropping
+// it would answer with more of the feed than was asked for.
+func parseActivityFilter(c *gin.Context) (models.ActivityFilter, bool) {
+	filter := models.ActivityFilter{EntityType: c.Query("entity_type")}
+	if filter.EntityType != "" && !activityEntityTypes[filter.EntityType] {
+		utils.RespondBadRequest(c, "Invalid entity_type")
+		return filter, false
+	}
+
+	for name, target := range map[string]*uint{
+		"entity_id": &filter.EntityID,
+		"user_id":   &filter.OwnerID,
+	} {
+		if raw := c.Query(name); raw != "" {
+			parsed, err := strconv.ParseUint(raw, 10, 32)
+			if err != nil || parsed == 0 {
+				utils.RespondBadRequest(c, "Invalid "+name)
+				return filter, false
+			}
+			*target = uint(parsed)
 		}
-		name += lastName
-	}
-	if name == "" {
-		name = "Unnamed lead"
 	}
-	if company != "" {
-		return name + " (" + company + ")"
+
+	// An id is only meaningful within one entity type.
+	if filter.EntityID != 0 && filter.EntityType == "" {
+		utils.RespondBadRequest(c, "entity_id requires entity_type")
+		return filter, false
 	}
-	return name
+
+	return filter, true
 }
 
 // activityUserFrom projects a user onto the feed's actor shape. A nil user, or
@@ -621,7 +599,7 @@ func (h *DashboardHandler) GetUpcomingTasks(c *gin.Context) {
 
 	var tasks []models.Task
 	var err error
-	if c.GetString("user_role") == string(models.RoleAdmin) {
+	if middleware.HasPermission(c, models.PermTasksReadAny) {
 		tasks, err = h.taskService.GetUpcoming(limit)
 	} else {
 		tasks, err = h.taskService.GetUpcomingByAssignee(c.GetUint("user_id"), limit)
@@ -688,14 +666,13 @@ func (h *DashboardHandler) GetNewLeads(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "DashboardHandler.GetNewLeads")
 
 	limit := parseDashboardLimit(c, defaultWidgetLimit)
-	role := c.GetString("user_role")
 
 	var leads []models.Lead
 	var err error
-	switch role {
-	case string(models.RoleAdmin):
+	switch {
+	case middleware.HasPermission(c, models.PermLeadsReadAny):
 		leads, err = h.leadService.GetRecent(limit)
-	case string(models.RoleSales):
+	case middleware.HasPermission(c, models.PermLeadsReadOwn):
 		// Sales users own a subset of the pipeline and see only that subset,
 		// exactly as LeadHandler.List narrows them.
 		leads, err = h.leadService.GetRecentByOwner(c.GetUint("user_id"), limit)
@@ -2,12 +2,14 @@ package handler
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
 	"github.com/florinel-chis/gophercrm/internal/middleware"
 	"github.com/florinel-chis/gophercrm/internal/mocks"
 	"github.com/florinel-chis/gophercrm/internal/models"
@@ -26,6 +28,7 @@ type DashboardHandlerTestSuite struct {
 	mockCustomerService *mocks.CustomerService
 	mockTicketService   *mocks.TicketService
 	mockTaskService     *MockTaskService
+	mockActivityService *mocks.ActivityService
 	handler             *DashboardHandler
 }
 
@@ -43,11 +46,13 @@ func (suite *DashboardHandlerTestSuite) SetupTest() {
 	suite.mockCustomerService = new(mocks.CustomerService)
 	suite.mockTicketService = new(mocks.TicketService)
 	suite.mockTaskService = new(MockTaskService)
+	suite.mockActivityService = new(mocks.ActivityService)
 	suite.handler = NewDashboardHandler(
 		suite.mockLeadService,
 		suite.mockCustomerService,
 		suite.mockTicketService,
 		suite.mockTaskService,
+		suite.mockActivityService,
 	)
 }
 
@@ -56,6 +61,7 @@ func (suite *DashboardHandlerTestSuite) TearDownTest() {
 	suite.mockCustomerService.AssertExpectations(suite.T())
 	suite.mockTicketService.AssertExpectations(suite.T())
 	suite.mockTaskService.AssertExpectations(suite.T())
+	suite.mockActivityService.AssertExpectations(suite.T())
 }
 
 // newRouterWithRole wires the real dashboard routes (including their
@@ -174,6 +180,8 @@ type activityJSON struct {
 	Type        string `json:"type"`
 	Title       string `json:"title"`
 	Description string `json:"description"`
+	EntityType  string `json:"entity_type"`
+	EntityID    uint   `json:"entity_id"`
 	User        struct {
 		ID        uint   `json:"id"`
 		Username  string `json:"username"`
@@ -422,145 +430,143 @@ func (suite *DashboardHandlerTestSuite) TestGetSalesPerformance_CustomerForbidde
 	assert.Equal(suite.T(), http.StatusForbidden, rec.Code)
 }
 
-func (suite *DashboardHandlerTestSuite) TestGetActivities_MergedAndSortedDescending() {
+func (suite *DashboardHandlerTestSuite) decodeActivities(rec *httptest.ResponseRecorder) ([]activityJSON, string) {
+	var env struct {
+		Success bool           `json:"success"`
+		Data    []activityJSON `json:"data"`
+		Meta    struct {
+			NextCursor string `json:"next_cursor"`
+		} `json:"meta"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(rec.Body.Bytes(), &env))
+	require.True(suite.T(), env.Success)
+	return env.Data, env.Meta.NextCursor
+}
+
+func (suite *DashboardHandlerTestSuite) TestGetActivities_AdminSeesWholeFeed() {
 	router := suite.newAnalyticsRouter(models.RoleAdmin, 1)
 
 	owner := models.User{Email: "sales@example.com", FirstName: "Sam", LastName: "Sales"}
 	owner.ID = 7
-	assignee := models.User{Email: "support@example.com", FirstName: "Sue", LastName: "Support"}
-	assignee.ID = 9
+	createdAt := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	events := []models.ActivityEvent{
+		{ID: 12, CreatedAt: createdAt, EntityType: models.AuditEntityLead, EntityID: 42,
+			Type: models.ActivityLeadStatusChanged, Title: "Lead status changed",
+			Description: "Lea Dee (ACME) moved from new to contacted", OwnerID: &owner.ID, Owner: &owner},
+		{ID: 11, CreatedAt: createdAt.Add(-time.Hour), EntityType: models.AuditEntityTicket, EntityID: 5,
+			Type: models.ActivityTicketDeleted, Title: "Ticket deleted", Description: "Printer on fire"},
+	}
+	suite.mockActivityService.On("Feed", models.ActivityFilter{}, "", 10).Return(events, "next-page", nil)
 
-	base := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	rec := suite.doGet(router, "/dashboard/activities")
 
-	lead := models.Lead{FirstName: "Lea", LastName: "Dee", Company: "ACME", OwnerID: 7, Owner: owner}
-	lead.ID = 42
-	lead.CreatedAt = base.Add(-4 * time.Hour)
+	assert.Equal(suite.T(), http.StatusOK, rec.Code)
+	activities, next := suite.decodeActivities(rec)
+	require.Len(suite.T(), activities, 2)
+	assert.Equal(suite.T(), "next-page", next)
+
+	assert.Equal(suite.T(), "12", activities[0].ID)
+	assert.Equal(suite.T(), "lead_status_changed", activities[0].Type)
+	assert.Equal(suite.T(), "lead", activities[0].EntityType)
+	assert.Equal(suite.T(), uint(42), activities[0].EntityID)
+	assert.Equal(suite.T(), "Lea Dee (ACME) moved from new to contacted", activities[0].Description)
+	// username is the user's email: models.User has no username column.
+	assert.Equal(suite.T(), "sales@example.com", activities[0].User.Username)
+	assert.Equal(suite.T(), uint(7), activities[0].User.ID)
+	assert.True(suite.T(), activities[0].CreatedAt.Equal(createdAt))
 
-	converted := models.Lead{FirstName: "Con", LastName: "Verted", OwnerID: 7, Owner: owner, Status: models.LeadStatusConverted}
-	converted.ID = 43
-	converted.CreatedAt = base.Add(-100 * time.Hour)
-	converted.UpdatedAt = base.Add(-1 * time.Hour)
+	// An entry about a record nobody owned still carries a user object.
+	assert.Equal(suite.T(), "ticket_deleted", activities[1].Type)
+	assert.Equal(suite.T(), uint(0), activities[1].User.ID)
+	assert.Equal(suite.T(), "", activities[1].User.Username)
+}
 
-	ticket := models.Ticket{Title: "Printer on fire", AssignedToID: &assignee.ID, AssignedTo: &assignee}
-	ticket.ID = 5
-	ticket.CreatedAt = base.Add(-3 * time.Hour)
+func (suite *DashboardHandlerTestSuite) TestGetActivities_AdminFiltersByUserAndEntity() {
+	router := suite.newAnalyticsRouter(models.RoleAdmin, 1)
+	suite.mockActivityService.On("Feed",
+		models.ActivityFilter{EntityType: models.AuditEntityTask, EntityID: 3, OwnerID: 9}, "abc", 5).
+		Return([]models.ActivityEvent{}, "", nil)
 
-	resolved := models.Ticket{Title: "Password reset", Status: models.TicketStatusResolved, AssignedToID: &assignee.ID, AssignedTo: &assignee}
-	resolved.ID = 6
-	resolved.UpdatedAt = base.Add(-2 * time.Hour)
+	rec := suite.doGet(router, "/dashboard/activities?entity_type=task&entity_id=3&user_id=9&cursor=abc&limit=5")
 
-	task := models.Task{Title: "Call back", Status: models.TaskStatusCompleted, AssignedToID: 9, AssignedTo: assignee}
-	task.ID = 11
-	task.UpdatedAt = base.Add(-5 * time.Hour)
+	assert.Equal(suite.T(), http.StatusOK, rec.Code)
+	suite.assertEmptyDataArray(rec)
+	assert.NotContains(suite.T(), rec.Body.String(), "next_cursor")
+}
 
-	suite.mockLeadService.On("GetRecent", 10).Return([]models.Lead{lead}, nil)
-	suite.mockLeadService.On("GetRecentlyConverted", 10).Return([]models.Lead{converted}, nil)
-	suite.mockTicketService.On("GetRecent", 10).Return([]models.Ticket{ticket}, nil)
-	suite.mockTicketService.On("GetRecentlyResolved", 10).Return([]models.Ticket{resolved}, nil)
-	suite.mockTaskService.On("GetRecentlyCompleted", 10).Return([]models.Task{task}, nil)
+// Anyone but an admin sees the activity on their own records only; the scope
+// is applied whether or not they asked for it.
+func (suite *DashboardHandlerTestSuite) TestGetActivities_NonAdminScopedToOwnRecords() {
+	router := suite.newAnalyticsRouter(models.RoleSales, 7)
+	suite.mockActivityService.On("Feed", models.ActivityFilter{OwnerID: 7}, "", 10).
+		Return([]models.ActivityEvent{}, "", nil)
 
 	rec := suite.doGet(router, "/dashboard/activities")
 
 	assert.Equal(suite.T(), http.StatusOK, rec.Code)
+}
 
-	var env dashboardEnvelope
-	require.NoError(suite.T(), json.Unmarshal(rec.Body.Bytes(), &env))
-	var activities []activityJSON
-	require.NoError(suite.T(), json.Unmarshal(env.Data, &activities))
-	require.Len(suite.T(), activities, 5)
-
-	assert.Equal(suite.T(), []string{
-		"lead-43-converted",
-		"ticket-6-resolved",
-		"ticket-5-created",
-		"lead-42-created",
-		"task-11-completed",
-	}, []string{activities[0].ID, activities[1].ID, activities[2].ID, activities[3].ID, activities[4].ID})
-
-	assert.Equal(suite.T(), "lead_converted", activities[0].Type)
-	assert.Equal(suite.T(), "ticket_resolved", activities[1].Type)
-	assert.Equal(suite.T(), "ticket_created", activities[2].Type)
-	assert.Equal(suite.T(), "lead_created", activities[3].Type)
-	assert.Equal(suite.T(), "task_completed", activities[4].Type)
+func (suite *DashboardHandlerTestSuite) TestGetActivities_NonAdminMayNameThemselves() {
+	router := suite.newAnalyticsRouter(models.RoleSupport, 9)
+	suite.mockActivityService.On("Feed", models.ActivityFilter{OwnerID: 9}, "", 10).
+		Return([]models.ActivityEvent{}, "", nil)
 
-	// username is the user's email: models.User has no username column.
-	assert.Equal(suite.T(), "sales@example.com", activities[3].User.Username)
-	assert.Equal(suite.T(), uint(7), activities[3].User.ID)
-	assert.Equal(suite.T(), "Sam", activities[3].User.FirstName)
-	assert.Equal(suite.T(), "Sales", activities[3].User.LastName)
-	assert.True(suite.T(), activities[0].CreatedAt.Equal(base.Add(-1*time.Hour)))
+	rec := suite.doGet(router, "/dashboard/activities?user_id=9")
+
+	assert.Equal(suite.T(), http.StatusOK, rec.Code)
 }
 
-// Tickets need not be assigned to anyone; an unassigned one must still produce
-// an activity rather than panicking on the nil *User.
-func (suite *DashboardHandlerTestSuite) TestGetActivities_UnassignedTicketIsGraceful() {
-	router := suite.newAnalyticsRouter(models.RoleSupport, 1)
+func (suite *DashboardHandlerTestSuite) TestGetActivities_NonAdminCannotReadAnotherUsersFeed() {
+	router := suite.newAnalyticsRouter(models.RoleSales, 7)
 
-	ticket := models.Ticket{Title: "Orphan"}
-	ticket.ID = 8
-	ticket.CreatedAt = time.Now()
+	rec := suite.doGet(router, "/dashboard/activities?user_id=8")
 
-	suite.mockLeadService.On("GetRecent", 10).Return([]models.Lead{}, nil)
-	suite.mockLeadService.On("GetRecentlyConverted", 10).Return([]models.Lead{}, nil)
-	suite.mockTicketService.On("GetRecent", 10).Return([]models.Ticket{ticket}, nil)
-	suite.mockTicketService.On("GetRecentlyResolved", 10).Return([]models.Ticket{}, nil)
-	suite.mockTaskService.On("GetRecentlyCompleted", 10).Return([]models.Task{}, nil)
+	assert.Equal(suite.T(), http.StatusForbidden, rec.Code)
+	suite.mockActivityService.AssertNotCalled(suite.T(), "Feed", mock.Anything, mock.Anything, mock.Anything)
+}
 
-	rec := suite.doGet(router, "/dashboard/activities")
+func (suite *DashboardHandlerTestSuite) TestGetActivities_InvalidFiltersRejected() {
+	router := suite.newAnalyticsRouter(models.RoleAdmin, 1)
 
-	assert.Equal(suite.T(), http.StatusOK, rec.Code)
-	var env dashboardEnvelope
-	require.NoError(suite.T(), json.Unmarshal(rec.Body.Bytes(), &env))
-	var activities []activityJSON
-	require.NoError(suite.T(), json.Unmarshal(env.Data, &activities))
-	require.Len(suite.T(), activities, 1)
-	assert.Equal(suite.T(), uint(0), activities[0].User.ID)
-	assert.Equal(suite.T(), "", activities[0].User.Username)
+	for _, path := range []string{
+		"/dashboard/activities?entity_type=label",
+		"/dashboard/activities?entity_type=lead&entity_id=abc",
+		"/dashboard/activities?entity_id=3",
+		"/dashboard/activities?user_id=-1",
+	} {
+		rec := suite.doGet(router, path)
+		assert.Equal(suite.T(), http.StatusBadRequest, rec.Code, path)
+	}
+	suite.mockActivityService.AssertNotCalled(suite.T(), "Feed", mock.Anything, mock.Anything, mock.Anything)
 }
 
-func (suite *DashboardHandlerTestSuite) TestGetActivities_LimitIsCappedAtFifty() {
+func (suite *DashboardHandlerTestSuite) TestGetActivities_InvalidCursor() {
 	router := suite.newAnalyticsRouter(models.RoleAdmin, 1)
+	suite.mockActivityService.On("Feed", models.ActivityFilter{}, "garbage", 10).
+		Return(nil, "", fmt.Errorf("invalid activity cursor: %w", apperrors.ErrValidation))
 
-	suite.mockLeadService.On("GetRecent", 50).Return([]models.Lead{}, nil)
-	suite.mockLeadService.On("GetRecentlyConverted", 50).Return([]models.Lead{}, nil)
-	suite.mockTicketService.On("GetRecent", 50).Return([]models.Ticket{}, nil)
-	suite.mockTicketService.On("GetRecentlyResolved", 50).Return([]models.Ticket{}, nil)
-	suite.mockTaskService.On("GetRecentlyCompleted", 50).Return([]models.Task{}, nil)
-
-	rec := suite.doGet(router, "/dashboard/activities?limit=500")
+	rec := suite.doGet(router, "/dashboard/activities?cursor=garbage")
 
-	assert.Equal(suite.T(), http.StatusOK, rec.Code)
-	suite.assertEmptyDataArray(rec)
+	assert.Equal(suite.T(), http.StatusBadRequest, rec.Code)
 }
 
-// More candidate events than the requested limit must be truncated after the
-// merge, keeping the most recent ones.
-func (suite *DashboardHandlerTestSuite) TestGetActivities_TruncatedToLimitAfterMerge() {
+func (suite *DashboardHandlerTestSuite) TestGetActivities_ServiceError() {
 	router := suite.newAnalyticsRouter(models.RoleAdmin, 1)
+	suite.mockActivityService.On("Feed", models.ActivityFilter{}, "", 10).Return(nil, "", assert.AnError)
 
-	now := time.Now()
-	oldLead := models.Lead{FirstName: "Old"}
-	oldLead.ID = 1
-	oldLead.CreatedAt = now.Add(-48 * time.Hour)
-	newTicket := models.Ticket{Title: "New"}
-	newTicket.ID = 2
-	newTicket.CreatedAt = now
+	rec := suite.doGet(router, "/dashboard/activities")
 
-	suite.mockLeadService.On("GetRecent", 1).Return([]models.Lead{oldLead}, nil)
-	suite.mockLeadService.On("GetRecentlyConverted", 1).Return([]models.Lead{}, nil)
-	suite.mockTicketService.On("GetRecent", 1).Return([]models.Ticket{newTicket}, nil)
-	suite.mockTicketService.On("GetRecentlyResolved", 1).Return([]models.Ticket{}, nil)
-	suite.mockTaskService.On("GetRecentlyCompleted", 1).Return([]models.Task{}, nil)
+	assert.Equal(suite.T(), http.StatusInternalServerError, rec.Code)
+}
 
-	rec := suite.doGet(router, "/dashboard/activities?limit=1")
+func (suite *DashboardHandlerTestSuite) TestGetActivities_LimitIsCappedAtFifty() {
+	router := suite.newAnalyticsRouter(models.RoleAdmin, 1)
+	suite.mockActivityService.On("Feed", models.ActivityFilter{}, "", 50).Return([]models.ActivityEvent{}, "", nil)
+
+	rec := suite.doGet(router, "/dashboard/activities?limit=500")
 
 	assert.Equal(suite.T(), http.StatusOK, rec.Code)
-	var env dashboardEnvelope
-	require.NoError(suite.T(), json.Unmarshal(rec.Body.Bytes(), &env))
-	var activities []activityJSON
-	require.NoError(suite.T(), json.Unmarshal(env.Data, &activities))
-	require.Len(suite.T(), activities, 1)
-	assert.Equal(suite.T(), "ticket-2-created", activities[0].ID)
+	suite.assertEmptyDataArray(rec)
 }
 
 func (suite *DashboardHandlerTestSuite) TestGetUpcomingTasks_AdminSeesEveryAssignee() {
@@ -0,0 +1,779 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/middleware"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// dealDateLayout is the format of a deal's expected close date on the wire.
+const dealDateLayout = "2006-01-02"
+
+// dealMonthLayout is the format of the forecast's month bounds.
+const dealMonthLayout = "2006-01"
+
+// defaultForecastMonths is how many months the forecast covers when the caller
+// gives no to.
+const defaultForecastMonths = 12
+
+type DealHandler struct {
+	auditTrail
+	dealService service.DealService
+}
+
+func NewDealHandler(dealService service.DealService) *DealHandler {
+	return &DealHandler{dealService: dealService}
+}
+
+// CreateDealRequest is the body of POST /deals. Without stage_id the deal
+// starts in the first open stage; without probability it takes the stage's.
+type CreateDealRequest struct {
+	Title      string  `json:"title" binding:"required,max=200"`
+	CustomerID uint    `json:"customer_id" binding:"required"`
+	LeadID     *uint   `json:"lead_id,omitempty"`
+	Amount     float64 `json:"amount" binding:"min=0"`
+	// Currency is an ISO 4217 code; it defaults to DEAL_DEFAULT_CURRENCY.
+	Currency string `json:"currency,omitempty" binding:"omitempty,len=3"`
+	// ExpectedCloseDate is a YYYY-MM-DD date.
+	ExpectedCloseDate string `json:"expected_close_date,omitempty"`
+	StageID           uint   `json:"stage_id,omitempty"`
+	Probability       *int   `json:"probability,omitempty" binding:"omitempty,min=0,max=100"`
+	OwnerID           *uint  `json:"owner_id,omitempty"`
+	Notes             string `json:"notes,omitempty"`
+}
+
+// UpdateDealRequest is the body of PUT /deals/:id. Omitted fields are left
+// alone; an empty expected_close_date clears the date. The stage is moved
+// with PUT /deals/:id/stage.
+type UpdateDealRequest struct {
+	Title             *string  `json:"title,omitempty" binding:"omitempty,max=200"`
+	CustomerID        *uint    `json:"customer_id,omitempty"`
+	LeadID            *uint    `json:"lead_id,omitempty"`
+	Amount            *float64 `json:"amount,omitempty" binding:"omitempty,min=0"`
+	Currency          *string  `json:"currency,omitempty" binding:"omitempty,len=3"`
+	ExpectedCloseDate *string  `json:"expected_close_date,omitempty"`
+	Probability       *int     `json:"probability,omitempty" binding:"omitempty,min=0,max=100"`
+	OwnerID           *uint    `json:"owner_id,omitempty"`
+	Notes             *string  `json:"notes,omitempty"`
+}
+
+// MoveDealStageRequest is the body of PUT /deals/:id/stage.
+type MoveDealStageRequest struct {
+	StageID uint `json:"stage_id" binding:"required"`
+	// Probability overrides the stage's default in an open stage.
+	Probability *int `json:"probability,omitempty" binding:"omitempty,min=0,max=100"`
+}
+
+// PipelineStageRequest is the body of both stage create and update.
+type PipelineStageRequest struct {
+	Name        string             `json:"name" binding:"required,max=100"`
+	Position    int                `json:"position"`
+	Probability int                `json:"probability" binding:"min=0,max=100"`
+	Outcome     models.DealOutcome `json:"outcome,omitempty" binding:"omitempty,oneof=open won lost"`
+}
+
+func (r *PipelineStageRequest) stage() *models.PipelineStage {
+	return &models.PipelineStage{Name: r.Name, Position: r.Position, Probability: r.Probability, Outcome: r.Outcome}
+}
+
+// parseDealDate reads a YYYY-MM-DD date as midnight UTC; an empty value is no
+// date.
+func parseDealDate(raw string) (*time.Time, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, true
+	}
+	date, err := time.Parse(dealDateLayout, raw)
+	if err != nil {
+		return nil, false
+	}
+	return &date, true
+}
+
+// ListStages godoc
+// @Summary List pipeline stages
+// @Description Every stage of the sales pipeline in order, with the win probability a deal takes when it enters it and whether reaching it wins or loses the deal. Available to the admin, sales and support roles.
+// @Tags deals
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.APIResponse{data=[]models.PipelineStage} "Pipeline stages retrieved successfully"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin, sales or support role required"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /pipeline-stages [get]
+func (h *DealHandler) ListStages(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "DealHandler.ListStages")
+
+	stages, err := h.dealService.ListStages()
+	if err != nil {
+		h.respondError(c, logger, err, "Pipeline stage not found")
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, stages)
+	utils.RespondSuccess(c, http.StatusOK, stages)
+}
+
+// CreateStage godoc
+// @Summary Create a pipeline stage
+// @Description Add a stage to the sales pipeline (admin role only). Stages are ordered by position. The outcome defaults to open; a won stage always carries probability 100 and a lost stage 0. Stage names are unique.
+// @Tags deals
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param request body PipelineStageRequest true "Pipeline stage"
+// @Success 201 {object} utils.APIResponse{data=models.PipelineStage} "Pipeline stage created successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid stage or duplicate name"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /pipeline-stages [post]
+func (h *DealHandler) CreateStage(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "DealHandler.CreateStage")
+
+	var req PipelineStageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	stage := req.stage()
+	if err := h.dealService.CreateStage(stage); err != nil {
+		h.respondError(c, logger, err, "Pipeline stage not found")
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityPipelineStage, stage.ID, models.AuditActionCreate, nil, stage)
+
+	utils.LogHandlerResponse(logger, http.StatusCreated, stage)
+	utils.RespondSuccess(c, http.StatusCreated, stage)
+}
+
+// UpdateStage godoc
+// @Summary Update a pipeline stage
+// @Description Replace a stage's name, position, probability and outcome (admin role only). The new probability applies to deals entering the stage from now on; deals already in it keep theirs. The outcome of a stage that holds deals cannot change.
+// @Tags deals
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Stage ID"
+// @Param request body PipelineStageRequest true "Pipeline stage"
+// @Success 200 {object} utils.APIResponse{data=models.PipelineStage} "Pipeline stage updated successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid stage ID, stage or duplicate name"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Stage not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /pipeline-stages/{id} [put]
+func (h *DealHandler) UpdateStage(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "DealHandler.UpdateStage")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid stage ID")
+		return
+	}
+
+	var req PipelineStageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	before := h.auditLoad(func() (interface{}, error) { return h.dealService.GetStage(uint(id)) })
+	stage, err := h.dealService.UpdateStage(uint(id), req.stage())
+	if err != nil {
+		h.respondError(c, logger, err, "Pipeline stage not found")
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityPipelineStage, stage.ID, models.AuditActionUpdate, before, stage)
+
+	utils.LogHandlerResponse(logger, http.StatusOK, stage)
+	utils.RespondSuccess(c, http.StatusOK, stage)
+}
+
+// DeleteStage godoc
+// @Summary Delete a pipeline stage
+// @Description Remove a stage no deal has ever been in (admin role only). A stage that still holds deals, deleted deals included, is refused with 400; move the deals out first.
+// @Tags deals
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Stage ID"
+// @Success 204 "Pipeline stage deleted"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid stage ID, or the stage holds deals"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Stage not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /pipeline-stages/{id} [delete]
+func (h *DealHandler) DeleteStage(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "DealHandler.DeleteStage")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid stage ID")
+		return
+	}
+
+	before := h.auditLoad(func() (interface{}, error) { return h.dealService.GetStage(uint(id)) })
+	if err := h.dealService.DeleteStage(uint(id)); err != nil {
+		h.respondError(c, logger, err, "Pipeline stage not found")
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityPipelineStage, uint(id), models.AuditActionDelete, before, nil)
+
+	utils.LogHandlerResponse(logger, http.StatusNoContent, nil)
+	c.Status(http.StatusNoContent)
+}
+
+// Create godoc
+// @Summary Create a deal
+// @Description Create a deal for a customer, optionally linked to the lead it came from (admin and sales roles). Without stage_id the deal starts in the first open stage; without probability it takes the stage's default. Sales users create deals they own; admins must name the owner.
+// @Tags deals
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param request body CreateDealRequest true "Deal"
+// @Success 201 {object} utils.APIResponse{data=models.Deal} "Deal created successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid deal, or unknown customer, lead, owner or stage"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin or sales role required, or a sales user naming another owner"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /deals [post]
+func (h *DealHandler) Create(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "DealHandler.Create")
+
+	var req CreateDealRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	currentUserID := c.GetUint("user_id")
+	ownerID := currentUserID
+	if middleware.HasPermission(c, models.PermDealsAssign) {
+		if req.OwnerID == nil {
+			utils.RespondBadRequest(c, "owner_id is required")
+			return
+		}
+		ownerID = *req.OwnerID
+	} else if req.OwnerID != nil && *req.OwnerID != currentUserID {
+		utils.RespondForbidden(c, "Only administrators can create deals for other users")
+		return
+	}
+
+	closes, ok := parseDealDate(req.ExpectedCloseDate)
+	if !ok {
+		utils.RespondBadRequest(c, "Invalid expected_close_date: expected YYYY-MM-DD")
+		return
+	}
+
+	deal := &models.Deal{
+		Title:             req.Title,
+		CustomerID:        req.CustomerID,
+		LeadID:            req.LeadID,
+		Amount:            req.Amount,
+		Currency:          req.Currency,
+		ExpectedCloseDate: closes,
+		OwnerID:           ownerID,
+		StageID:           req.StageID,
+		Notes:             req.Notes,
+	}
+	if err := h.dealService.Create(deal, req.Probability, currentUserID); err != nil {
+		h.respondError(c, logger, err, "Deal not found")
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityDeal, deal.ID, models.AuditActionCreate, nil, deal)
+
+	utils.LogHandlerResponse(logger, http.StatusCreated, deal)
+	utils.RespondSuccess(c, http.StatusCreated, deal)
+}
+
+// List godoc
+// @Summary List deals
+// @Description One page of deals, newest first (admin and sales roles). Sales users only see their own deals; admins see every deal and may narrow the list to one owner.
+// @Tags deals
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param offset query int false "Offset for pagination" default(0)
+// @Param limit query int false "Limit for pagination" default(20)
+// @Param owner_id query int false "Only deals of this owner (admins only)"
+// @Param customer_id query int false "Only deals of this customer"
+// @Param stage_id query int false "Only deals in this stage"
+// @Param outcome query string false "Only open, won or lost deals" Enums(open, won, lost)
+// @Param cursor query string false "Page by keyset instead of offset: empty for the first page, then the meta.next_cursor of the previous one"
+// @Success 200 {object} utils.APIResponse{data=object{deals=[]models.Deal,total=int},meta=utils.APIMeta} "Deals retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid filter or cursor"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin or sales role required"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /deals [get]
+func (h *DealHandler) List(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "DealHandler.List")
+
+	var filter models.DealFilter
+	for name, target := range map[string]*uint{"owner_id": &filter.OwnerID, "customer_id": &filter.CustomerID, "stage_id": &filter.StageID} {
+		if raw := c.Query(name); raw != "" {
+			parsed, err := strconv.ParseUint(raw, 10, 32)
+			if err != nil {
+				utils.RespondBadRequest(c, "Invalid "+name)
+				return
+			}
+			*target = uint(parsed)
+		}
+	}
+	switch outcome := models.DealOutcome(c.Query("outcome")); outcome {
+	case "", models.DealOutcomeOpen, models.DealOutcomeWon, models.DealOutcomeLost:
+		filter.Outcome = outcome
+	default:
+		utils.RespondBadRequest(c, "Invalid outcome")
+		return
+	}
+	if !middleware.HasPermission(c, models.PermDealsReadAny) {
+		filter.OwnerID = c.GetUint("user_id")
+	}
+
+	offset, limit := utils.ParseOffsetLimit(c)
+	// Deals are listed newest first, which is by id.
+	cursor, ok := parseListCursor(c, logger, "deals", "", "desc", models.CustomFieldQuery{})
+	if !ok {
+		return
+	}
+	filter.Cursor = cursor
+	fetchOffset, fetchLimit := cursorWindow(cursor, offset, limit)
+
+	deals, total, err := h.dealService.List(filter, fetchOffset, fetchLimit)
+	if err != nil {
+		h.respondError(c, logger, err, "Deal not found")
+		return
+	}
+
+	meta := &utils.APIMeta{
+		RequestID:  c.GetString("request_id"),
+		Page:       (offset / limit) + 1,
+		PerPage:    limit,
+		Total:      total,
+		TotalPages: (total + int64(limit) - 1) / int64(limit),
+	}
+	if cursor != nil {
+		var next string
+		if deals, next, err = cursorPage(cursor, deals, limit); err != nil {
+			logger.WithError(err).Error("Failed to encode the next cursor")
+			utils.RespondInternalError(c)
+			return
+		}
+		meta = cursorMeta(c, limit, total, next)
+	}
+
+	responseData := gin.H{"deals": deals, "total": total}
+	utils.LogHandlerResponse(logger, http.StatusOK, responseData)
+	utils.RespondSuccessWithMeta(c, http.StatusOK, responseData, meta)
+}
+
+// Get godoc
+// @Summary Get a deal
+// @Description One deal with its customer, owner and stage (admin and sales roles; sales users only their own).
+// @Tags deals
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Deal ID"
+// @Success 200 {object} utils.APIResponse{data=models.Deal} "Deal retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid deal ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - not your deal"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Deal not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /deals/{id} [get]
+func (h *DealHandler) Get(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "DealHandler.Get")
+
+	deal, ok := h.loadOwnDeal(c, logger, "view")
+	if !ok {
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, deal)
+	utils.RespondSuccess(c, http.StatusOK, deal)
+}
+
+// Update godoc
+// @Summary Update a deal
+// @Description Change a deal's fields (admin and sales roles; sales users only their own). Omitted fields are left alone and an empty expected_close_date clears the date. The stage is not changed here; use PUT /deals/{id}/stage. A won or lost deal keeps probability 100 or 0. Only admins can reassign a deal.
+// @Tags deals
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Deal ID"
+// @Param request body UpdateDealRequest true "Changed fields"
+// @Success 200 {object} utils.APIResponse{data=models.Deal} "Deal updated successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid deal ID or fields"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - not your deal, or reassignment by a non-admin"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Deal not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /deals/{id} [put]
+func (h *DealHandler) Update(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "DealHandler.Update")
+
+	var req UpdateDealRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	deal, ok := h.loadOwnDeal(c, logger, "update")
+	if !ok {
+		return
+	}
+	if req.OwnerID != nil && *req.OwnerID != deal.OwnerID && !middleware.HasPermission(c, models.PermDealsAssign) {
+		utils.RespondForbidden(c, "Only administrators can reassign deals")
+		return
+	}
+
+	before := h.auditState(deal)
+	if req.Title != nil {
+		deal.Title = *req.Title
+	}
+	if req.CustomerID != nil {
+		deal.CustomerID = *req.CustomerID
+	}
+	if req.LeadID != nil {
+		deal.LeadID = req.LeadID
+	}
+	if req.Amount != nil {
+		deal.Amount = *req.Amount
+	}
+	if req.Currency != nil {
+		deal.Currency = *req.Currency
+	}
+	if req.ExpectedCloseDate != nil {
+		closes, ok := parseDealDate(*req.ExpectedCloseDate)
+		if !ok {
+			utils.RespondBadRequest(c, "Invalid expected_close_date: expected YYYY-MM-DD")
+			return
+		}
+		deal.ExpectedCloseDate = closes
+	}
+	if req.Probability != nil {
+		deal.Probability = *req.Probability
+	}
+	if req.OwnerID != nil {
+		deal.OwnerID = *req.OwnerID
+	}
+	if req.Notes != nil {
+		deal.Notes = *req.Notes
+	}
+
+	updated, err := h.dealService.Update(deal)
+	if err != nil {
+		h.respondError(c, logger, err, "Deal not found")
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityDeal, updated.ID, models.AuditActionUpdate, before, updated)
+
+	utils.LogHandlerResponse(logger, http.StatusOK, updated)
+	utils.RespondSuccess(c, http.StatusOK, updated)
+}
+
+// MoveStage godoc
+// @Summary Move a deal to another stage
+// @Description Move a deal through the pipeline and record the move in its stage history (admin and sales roles; sales users only their own). The deal takes the new stage's probability unless one is given; a won stage sets 100 and a lost stage 0, and both close the deal. Moving a closed deal back into an open stage reopens it. Moving to the current stage only changes the probability.
+// @Tags deals
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Deal ID"
+// @Param request body MoveDealStageRequest true "Target stage"
+// @Success 200 {object} utils.APIResponse{data=models.Deal} "Deal moved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid deal ID, unknown stage or invalid probability"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - not your deal"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Deal not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /deals/{id}/stage [put]
+func (h *DealHandler) MoveStage(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "DealHandler.MoveStage")
+
+	var req MoveDealStageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	deal, ok := h.loadOwnDeal(c, logger, "update")
+	if !ok {
+		return
+	}
+
+	before := h.auditState(deal)
+	moved, err := h.dealService.MoveToStage(deal.ID, req.StageID, req.Probability, c.GetUint("user_id"))
+	if err != nil {
+		h.respondError(c, logger, err, "Deal not found")
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityDeal, moved.ID, models.AuditActionUpdate, before, moved)
+
+	utils.LogHandlerResponse(logger, http.StatusOK, moved)
+	utils.RespondSuccess(c, http.StatusOK, moved)
+}
+
+// StageHistory godoc
+// @Summary Get a deal's stage history
+// @Description Every stage the deal has been in, oldest first, with who moved it and the probability it took (admin and sales roles; sales users only their own). The first entry is the stage the deal was created in and has no from stage. Stage names are as they were at the time.
+// @Tags deals
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Deal ID"
+// @Success 200 {object} utils.APIResponse{data=[]models.DealStageChange} "Stage history retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid deal ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - not your deal"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Deal not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /deals/{id}/stage-history [get]
+func (h *DealHandler) StageHistory(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "DealHandler.StageHistory")
+
+	deal, ok := h.loadOwnDeal(c, logger, "view")
+	if !ok {
+		return
+	}
+
+	history, err := h.dealService.StageHistory(deal.ID)
+	if err != nil {
+		h.respondError(c, logger, err, "Deal not found")
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, history)
+	utils.RespondSuccess(c, http.StatusOK, history)
+}
+
+// Delete godoc
+// @Summary Delete a deal
+// @Description Delete a deal (admin and sales roles; sales users only their own). Its stage history is kept.
+// @Tags deals
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Deal ID"
+// @Success 204 "Deal deleted"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid deal ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - not your deal"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Deal not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /deals/{id} [delete]
+func (h *DealHandler) Delete(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "DealHandler.Delete")
+
+	deal, ok := h.loadOwnDeal(c, logger, "delete")
+	if !ok {
+		return
+	}
+
+	before := h.auditState(deal)
+	if err := h.dealService.Delete(deal.ID); err != nil {
+		h.respondError(c, logger, err, "Deal not found")
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityDeal, deal.ID, models.AuditActionDelete, before, nil)
+
+	utils.LogHandlerResponse(logger, http.StatusNoContent, nil)
+	c.Status(http.StatusNoContent)
+}
+
+// loadOwnDeal reads the deal named by the path and checks the caller may act
+// on it: on any deal with the any scope of the permission action takes, on
+// their own otherwise. It has answered the request when ok is false.
+func (h *DealHandler) loadOwnDeal(c *gin.Context, logger *logrus.Entry, action string) (*models.Deal, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid deal ID")
+		return nil, false
+	}
+
+	deal, err := h.dealService.GetByID(uint(id))
+	if err != nil {
+		h.respondError(c, logger, err, "Deal not found")
+		return nil, false
+	}
+	anyDeal := models.PermDealsWriteAny
+	if action == "view" {
+		anyDeal = models.PermDealsReadAny
+	}
+	if !middleware.HasPermission(c, anyDeal) && deal.OwnerID != c.GetUint("user_id") {
+		utils.RespondForbidden(c, "You can only "+action+" your own deals")
+		return nil, false
+	}
+	return deal, true
+}
+
+// GetPipeline godoc
+// @Summary Deal pipeline per stage
+// @Description The number of deals, their total value and their weighted value (amount × probability / 100) in every pipeline stage, in pipeline order, stages without deals included with zeros. Amounts are never converted, so the report covers one currency, DEAL_DEFAULT_CURRENCY unless another is asked for. Admins see every deal and may narrow the report to one owner; sales users see their own deals. Restricted to the admin and sales roles.
+// @Tags dashboard
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param currency query string false "ISO 4217 currency code (default DEAL_DEFAULT_CURRENCY)"
+// @Param owner_id query int false "Only deals of this owner (admins only)"
+// @Success 200 {object} utils.APIResponse{data=[]models.DealPipelineRow} "Pipeline retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid currency or owner"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin or sales role required, or another owner requested"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /dashboard/deals/pipeline [get]
+func (h *DealHandler) GetPipeline(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "DealHandler.GetPipeline")
+
+	filter, ok := parseDealReportFilter(c)
+	if !ok {
+		return
+	}
+
+	rows, err := h.dealService.Pipeline(filter)
+	if err != nil {
+		h.respondError(c, logger, err, "Deal not found")
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, rows)
+	utils.RespondSuccess(c, http.StatusOK, rows)
+}
+
+// GetForecast godoc
+// @Summary Deal forecast per month, stage and owner
+// @Description The open and won deals expected to close in each month of the window, one row per month, stage and owner, with their count, total value and weighted value (amount × probability / 100). Lost deals and deals without an expected close date are left out. Rows are ordered by month, then pipeline order, then owner name. The window runs from the from month to the to month, both included; it defaults to the current month and the 11 after it. As with the pipeline report, one currency is covered at a time and sales users see only their own deals. Restricted to the admin and sales roles.
+// @Tags dashboard
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param from query string false "First month, YYYY-MM (default the current month)"
+// @Param to query string false "Last month, YYYY-MM (default 11 months after from)"
+// @Param currency query string false "ISO 4217 currency code (default DEAL_DEFAULT_CURRENCY)"
+// @Param owner_id query int false "Only deals of this owner (admins only)"
+// @Success 200 {object} utils.APIResponse{data=[]models.DealForecastRow} "Forecast retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid window, currency or owner"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin or sales role required, or another owner requested"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /dashboard/deals/forecast [get]
+func (h *DealHandler) GetForecast(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "DealHandler.GetForecast")
+
+	filter, ok := parseDealReportFilter(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if raw := c.Query("from"); raw != "" {
+		parsed, err := time.Parse(dealMonthLayout, raw)
+		if err != nil {
+			utils.RespondBadRequest(c, "Invalid from: expected YYYY-MM")
+			return
+		}
+		from = parsed
+	}
+	to := from.AddDate(0, defaultForecastMonths, 0)
+	if raw := c.Query("to"); raw != "" {
+		parsed, err := time.Parse(dealMonthLayout, raw)
+		if err != nil {
+			utils.RespondBadRequest(c, "Invalid to: expected YYYY-MM")
+			return
+		}
+		to = parsed.AddDate(0, 1, 0)
+	}
+	if !from.Before(to) {
+		utils.RespondBadRequest(c, "from must not be after to")
+		return
+	}
+	filter.CloseFrom, filter.CloseTo = &from, &to
+
+	rows, err := h.dealService.Forecast(filter)
+	if err != nil {
+		h.respondError(c, logger, err, "Deal not found")
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, rows)
+	utils.RespondSuccess(c, http.StatusOK, rows)
+}
+
+// parseDealReportFilter reads the currency and owner of a deal report and
+// scopes it to the caller's own deals unless the caller is an admin. It has
+// answered the request when ok is false.
+func parseDealReportFilter(c *gin.Context) (models.DealReportFilter, bool) {
+	filter := models.DealReportFilter{Currency: c.Query("currency")}
+	if raw := c.Query("owner_id"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			utils.RespondBadRequest(c, "Invalid owner_id")
+			return filter, false
+		}
+		filter.OwnerID = uint(parsed)
+	}
+
+	callerID := c.GetUint("user_id")
+	if !middleware.HasPermission(c, models.PermDealsReadAny) {
+		if filter.OwnerID != 0 && filter.OwnerID != callerID {
+			utils.RespondForbidden(c, "You can only report on your own deals")
+			return filter, false
+		}
+		filter.OwnerID = callerID
+	}
+	return filter, true
+}
+
+func (h *DealHandler) respondError(c *gin.Context, logger *logrus.Entry, err error, notFound string) {
+	switch {
+	case errors.Is(err, apperrors.ErrValidation):
+		logger.WithError(err).Warn("Invalid deal request")
+		utils.RespondBadRequest(c, err.Error())
+	case apperrors.IsNotFound(err):
+		logger.WithError(err).Warn(notFound)
+		utils.RespondNotFound(c, notFound)
+	default:
+		logger.WithError(err).Error("Deal operation failed")
+		utils.RespondInternalError(c)
+	}
+}
@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+var _ service.DealService = (*mocks.DealService)(nil)
+
+type DealHandlerTestSuite struct {
+	suite.Suite
+	mockService *mocks.DealService
+	mockAudit   *mocks.AuditService
+	handler     *DealHandler
+}
+
+func (suite *DealHandlerTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *DealHandlerTestSuite) SetupTest() {
+	suite.mockService = new(mocks.DealService)
+	suite.mockAudit = new(mocks.AuditService)
+	suite.handler = NewDealHandler(suite.mockService)
+}
+
+func (suite *DealHandlerTestSuite) TearDownTest() {
+	suite.mockService.AssertExpectations(suite.T())
+	suite.mockAudit.AssertExpectations(suite.T())
+}
+
+func (suite *DealHandlerTestSuite) do(role models.UserRole, userID uint, method, path string, body interface{}) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Set("user_role", string(role))
+		c.Next()
+	})
+	SetupDealRoutes(router.Group(""), suite.handler)
+
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (suite *DealHandlerTestSuite) TestRoutes_RoleGuards() {
+	w := suite.do(models.RoleSupport, 3, http.MethodGet, "/deals", nil)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = suite.do(models.RoleSales, 3, http.MethodPost, "/pipeline-stages", gin.H{"name": "Demo"})
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	suite.mockService.On("ListStages").Return([]models.PipelineStage{}, nil)
+	w = suite.do(models.RoleSupport, 3, http.MethodGet, "/pipeline-stages", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *DealHandlerTestSuite) TestCreate_SalesOwnsTheDeal() {
+	suite.handler.SetAuditService(suite.mockAudit)
+	suite.mockService.On("Create", mock.MatchedBy(func(d *models.Deal) bool {
+		return d.OwnerID == 5 && d.CustomerID == 2 && d.ExpectedCloseDate != nil &&
+			d.ExpectedCloseDate.Equal(time.Date(2026, 11, 30, 0, 0, 0, 0, time.UTC))
+	}), (*int)(nil), uint(5)).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Deal).ID = 11
+	}).Return(nil)
+	suite.mockAudit.On("Record", mock.Anything, models.AuditEntityDeal, uint(11), models.AuditActionCreate,
+		mock.Anything, mock.Anything).Return(nil)
+
+	w := suite.do(models.RoleSales, 5, http.MethodPost, "/deals", gin.H{
+		"title": "Renewal", "customer_id": 2, "amount": 1200, "expected_close_date": "2026-11-30",
+	})
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+}
+
+func (suite *DealHandlerTestSuite) TestCreate_OwnerRules() {
+	w := suite.do(models.RoleSales, 5, http.MethodPost, "/deals", gin.H{"title": "Renewal", "customer_id": 2, "owner_id": 6})
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = suite.do(models.RoleAdmin, 1, http.MethodPost, "/deals", gin.H{"title": "Renewal", "customer_id": 2})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = suite.do(models.RoleSales, 5, http.MethodPost, "/deals", gin.H{"title": "Renewal", "customer_id": 2, "expected_close_date": "30/11/2026"})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *DealHandlerTestSuite) TestCreate_InvalidReference() {
+	suite.mockService.On("Create", mock.Anything, mock.Anything, uint(5)).
+		Return(fmt.Errorf("customer 9 does not exist: %w", apperrors.ErrValidation))
+
+	w := suite.do(models.RoleSales, 5, http.MethodPost, "/deals", gin.H{"title": "Renewal", "customer_id": 9})
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *DealHandlerTestSuite) TestList_SalesIsScopedToOwnDeals() {
+	suite.mockService.On("List", models.DealFilter{OwnerID: 5, Outcome: models.DealOutcomeOpen}, 0, 20).
+		Return([]models.Deal{{Title: "Renewal", OwnerID: 5}}, int64(1), nil)
+
+	w := suite.do(models.RoleSales, 5, http.MethodGet, "/deals?owner_id=6&outcome=open", nil)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var response utils.APIResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), int64(1), response.Meta.Total)
+}
+
+func (suite *DealHandlerTestSuite) TestGet_AnotherSalesUsersDealIsForbidden() {
+	suite.mockService.On("GetByID", uint(11)).Return(&models.Deal{BaseModel: models.BaseModel{ID: 11}, OwnerID: 6}, nil)
+
+	w := suite.do(models.RoleSales, 5, http.MethodGet, "/deals/11", nil)
+
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *DealHandlerTestSuite) TestUpdate_OnlyAdminsReassign() {
+	suite.mockService.On("GetByID", uint(11)).Return(&models.Deal{BaseModel: models.BaseModel{ID: 11}, OwnerID: 5}, nil)
+
+	w := suite.do(models.RoleSales, 5, http.MethodPut, "/deals/11", gin.H{"owner_id": 6})
+
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *DealHandlerTestSuite) TestUpdate_AppliesOnlyTheGivenFields() {
+	closes := time.Date(2026, 11, 30, 0, 0, 0, 0, time.UTC)
+	suite.mockService.On("GetByID", uint(11)).Return(&models.Deal{
+		BaseModel: models.BaseModel{ID: 11}, Title: "Renewal", Amount: 100, OwnerID: 5, ExpectedCloseDate: &closes,
+	}, nil)
+	suite.mockService.On("Update", mock.MatchedBy(func(d *models.Deal) bool {
+		return d.Title == "Renewal" && d.Amount == 250 && d.ExpectedCloseDate == nil
+	})).Return(&models.Deal{BaseModel: models.BaseModel{ID: 11}}, nil)
+
+	w := suite.do(models.RoleSales, 5, http.MethodPut, "/deals/11", gin.H{"amount": 250, "expected_close_date": ""})
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *DealHandlerTestSuite) TestMoveStage_PassesTheActor() {
+	suite.mockService.On("GetByID", uint(11)).Return(&models.Deal{BaseModel: models.BaseModel{ID: 11}, OwnerID: 5}, nil)
+	suite.mockService.On("MoveToStage", uint(11), uint(5), (*int)(nil), uint(5)).
+		Return(&models.Deal{BaseModel: models.BaseModel{ID: 11}, StageID: 5}, nil)
+
+	w := suite.do(models.RoleSales, 5, http.MethodPut, "/deals/11/stage", gin.H{"stage_id": 5})
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *DealHandlerTestSuite) TestDeleteStage_InUse() {
+	suite.mockService.On("DeleteStage", uint(3)).Return(fmt.Errorf("in use: %w", apperrors.ErrValidation))
+
+	w := suite.do(models.RoleAdmin, 1, http.MethodDelete, "/pipeline-stages/3", nil)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *DealHandlerTestSuite) TestForecast_DefaultWindowAndScoping() {
+	suite.mockService.On("Forecast", mock.MatchedBy(func(f models.DealReportFilter) bool {
+		now := time.Now().UTC()
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return f.OwnerID == 5 && f.Currency == "EUR" &&
+			f.CloseFrom.Equal(start) && f.CloseTo.Equal(start.AddDate(0, 12, 0))
+	})).Return([]models.DealForecastRow{}, nil)
+
+	w := suite.do(models.RoleSales, 5, http.MethodGet, "/dashboard/deals/forecast?currency=EUR", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = suite.do(models.RoleSales, 5, http.MethodGet, "/dashboard/deals/forecast?owner_id=6", nil)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *DealHandlerTestSuite) TestForecast_ExplicitWindowIncludesTheLastMonth() {
+	suite.mockService.On("Forecast", mock.MatchedBy(func(f models.DealReportFilter) bool {
+		return f.OwnerID == 0 &&
+			f.CloseFrom.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) &&
+			f.CloseTo.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC))
+	})).Return([]models.DealForecastRow{}, nil)
+
+	w := suite.do(models.RoleAdmin, 1, http.MethodGet, "/dashboard/deals/forecast?from=2026-01&to=2026-03", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = suite.do(models.RoleAdmin, 1, http.MethodGet, "/dashboard/deals/forecast?from=2026-04&to=2026-03", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *DealHandlerTestSuite) TestPipeline_InvalidCurrency() {
+	suite.mockService.On("Pipeline", models.DealReportFilter{Currency: "dollars"}).
+		Return(nil, fmt.Errorf("bad currency: %w", apperrors.ErrValidation))
+
+	w := suite.do(models.RoleAdmin, 1, http.MethodGet, "/dashboard/deals/pipeline?currency=dollars", nil)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func TestDealHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(DealHandlerTestSuite))
+}
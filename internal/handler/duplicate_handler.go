@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/middleware"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type DuplicateHandler struct {
+	auditTrail
+	duplicateService service.DuplicateService
+	leadService      service.LeadService
+	customerService  service.CustomerService
+}
+
+// NewDuplicateHandler takes the lead and customer services for the audit
+// trail's before-state of a merge survivor.
+func NewDuplicateHandler(duplicateService service.DuplicateService, leadService service.LeadService, customerService service.CustomerService) *DuplicateHandler {
+	return &DuplicateHandler{
+		duplicateService: duplicateService,
+		leadService:      leadService,
+		customerService:  customerService,
+	}
+}
+
+// MergeRequest is the body of POST /leads/merge and POST /customers/merge.
+// fields maps a field's JSON name to "survivor" or "merged"; a field left out
+// keeps the survivor's value, or takes the merged record's when the survivor
+// has none.
+type MergeRequest struct {
+	SurvivorID uint                        `json:"survivor_id" binding:"required"`
+	MergedID   uint                        `json:"merged_id" binding:"required"`
+	Fields     map[string]models.MergeSide `json:"fields,omitempty"`
+}
+
+func (r MergeRequest) toModel() models.MergeRequest {
+	return models.MergeRequest{SurvivorID: r.SurvivorID, MergedID: r.MergedID, Fields: r.Fields}
+}
+
+// LeadDuplicates godoc
+// @Summary Find possible duplicates of a lead
+// @Description Score the leads and customers that may describe the same person as the lead: a matching email (ignoring case, "+tags" and Gmail dots) weighs 50, a matching phone number (its last ten digits) 30, and a similar name at the same company 20. Best candidates first. Sales users may only check their own leads and only see their own leads among the candidates.
+// @Tags leads
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Lead ID"
+// @Param limit query int false "Maximum number of candidates (max 100)" default(20)
+// @Success 200 {object} utils.APIResponse{data=[]models.DuplicateCandidate} "Duplicate candidates"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid lead ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - requires sales or admin role; sales users can only check their own leads"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Lead not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /leads/{id}/duplicates [get]
+func (h *DuplicateHandler) LeadDuplicates(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "DuplicateHandler.LeadDuplicates")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid lead ID")
+		return
+	}
+	_, limit := utils.ParseOffsetLimit(c)
+
+	candidates, err := h.duplicateService.LeadDuplicates(uint(id), scopedOwner(c, models.PermLeadsReadAny), limit)
+	if err != nil {
+		h.respondError(c, logger, err, "Lead not found")
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, candidates)
+	utils.RespondSuccess(c, http.StatusOK, candidates)
+}
+
+// MergeLeads godoc
+// @Summary Merge two leads
+// @Description Fold merged_id into survivor_id in one transaction (sales and admin roles). The survivor takes the chosen field values; the merged lead's tasks, form submissions, inbound emails and deals move to it, as does its conversion link when the survivor was not converted; then the merged lead is erased. Sales users may only merge their own leads. Leads converted into different customers cannot be merged until the customers are.
+// @Tags leads
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param request body MergeRequest true "Survivor, merged lead and field choices (first_name, last_name, email, phone, company, account_id, position, source, status, classification, external_id, notes, owner_id)"
+// @Success 200 {object} utils.APIResponse{data=models.LeadMerge} "Leads merged"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid merge request"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - requires sales or admin role; sales users can only merge their own leads"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Lead not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /leads/merge [post]
+func (h *DuplicateHandler) MergeLeads(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "DuplicateHandler.MergeLeads")
+
+	var req MergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	before := h.auditLoad(func() (interface{}, error) { return h.leadService.GetByID(req.SurvivorID) })
+	result, err := h.duplicateService.MergeLeads(req.toModel(), scopedOwner(c, models.PermLeadsDeleteAny))
+	if err != nil {
+		h.respondError(c, logger, err, "Lead not found")
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityLead, result.Lead.ID, models.AuditActionUpdate, before, result.Lead)
+	h.recordAudit(c, models.AuditEntityLead, result.MergedID, models.AuditActionDelete, nil, nil)
+
+	utils.LogHandlerResponse(logger, http.StatusOK, result)
+	utils.RespondSuccess(c, http.StatusOK, result)
+}
+
+// MergeCustomers godoc
+// @Summary Merge two customers
+// @Description Fold merged_id into survivor_id in one transaction (admin role only). The survivor takes the chosen field values; the merged customer's tasks, tickets, deals and inbound emails move to it, the leads converted into it now link to the survivor, and its portal login moves over when the survivor has none; then the merged customer is erased. Customers that both have a portal login cannot be merged.
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param request body MergeRequest true "Survivor, merged customer and field choices (first_name, last_name, email, phone, company, account_id, position, address, city, state, country, postal_code, notes, assigned_to_id)"
+// @Success 200 {object} utils.APIResponse{data=models.CustomerMerge} "Customers merged"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid merge request"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - requires admin role"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Customer not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /customers/merge [post]
+func (h *DuplicateHandler) MergeCustomers(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "DuplicateHandler.MergeCustomers")
+
+	var req MergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	before := h.auditLoad(func() (interface{}, error) { return h.customerService.GetByID(req.SurvivorID) })
+	result, err := h.duplicateService.MergeCustomers(req.toModel())
+	if err != nil {
+		h.respondError(c, logger, err, "Customer not found")
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityCustomer, result.Customer.ID, models.AuditActionUpdate, before, result.Customer)
+	h.recordAudit(c, models.AuditEntityCustomer, result.MergedID, models.AuditActionDelete, nil, nil)
+
+	utils.LogHandlerResponse(logger, http.StatusOK, result)
+	utils.RespondSuccess(c, http.StatusOK, result)
+}
+
+// scopedOwner is the user the caller's access is limited to: themselves, or
+// no one (0) when they hold readAny, the any scope of the permission at hand.
+func scopedOwner(c *gin.Context, readAny models.Permission) uint {
+	if middleware.HasPermission(c, readAny) {
+		return 0
+	}
+	return c.GetUint("user_id")
+}
+
+func (h *DuplicateHandler) respondError(c *gin.Context, logger *logrus.Entry, err error, notFound string) {
+	switch {
+	case errors.Is(err, apperrors.ErrValidation):
+		logger.WithError(err).Warn("Invalid duplicate request")
+		utils.RespondBadRequest(c, err.Error())
+	case errors.Is(err, apperrors.ErrForbidden):
+		logger.WithError(err).Warn("Duplicate request on another user's lead")
+		utils.RespondForbidden(c, "You can only merge and check your own leads")
+	case apperrors.IsNotFound(err):
+		logger.WithError(err).Warn("Duplicate request on a missing record")
+		utils.RespondNotFound(c, notFound)
+	default:
+		logger.WithError(err).Error("Duplicate operation failed")
+		utils.RespondInternalError(c)
+	}
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	servicemocks "github.com/florinel-chis/gophercrm/internal/service/mocks"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+var _ service.DuplicateService = (*mocks.DuplicateService)(nil)
+
+type DuplicateHandlerTestSuite struct {
+	suite.Suite
+	mockService         *mocks.DuplicateService
+	mockLeadService     *servicemocks.LeadService
+	mockCustomerService *mocks.CustomerService
+	mockAudit           *mocks.AuditService
+	handler             *DuplicateHandler
+}
+
+func (suite *DuplicateHandlerTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *DuplicateHandlerTestSuite) SetupTest() {
+	suite.mockService = new(mocks.DuplicateService)
+	suite.mockLeadService = new(servicemocks.LeadService)
+	suite.mockCustomerService = new(mocks.CustomerService)
+	suite.mockAudit = new(mocks.AuditService)
+	suite.handler = NewDuplicateHandler(suite.mockService, suite.mockLeadService, suite.mockCustomerService)
+}
+
+func (suite *DuplicateHandlerTestSuite) TearDownTest() {
+	suite.mockService.AssertExpectations(suite.T())
+	suite.mockLeadService.AssertExpectations(suite.T())
+	suite.mockCustomerService.AssertExpectations(suite.T())
+	suite.mockAudit.AssertExpectations(suite.T())
+}
+
+func (suite *DuplicateHandlerTestSuite) do(role models.UserRole, userID uint, method, path string, body interface{}) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Set("user_role", string(role))
+		c.Next()
+	})
+	SetupDuplicateRoutes(router.Group(""), suite.handler)
+
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (suite *DuplicateHandlerTestSuite) TestRoutes_RoleGuards() {
+	w := suite.do(models.RoleSupport, 3, http.MethodGet, "/leads/1/duplicates", nil)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = suite.do(models.RoleSupport, 3, http.MethodPost, "/leads/merge", gin.H{"survivor_id": 1, "merged_id": 2})
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = suite.do(models.RoleSales, 5, http.MethodPost, "/customers/merge", gin.H{"survivor_id": 1, "merged_id": 2})
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *DuplicateHandlerTestSuite) TestLeadDuplicates_ScopesSalesToTheirLeads() {
+	suite.mockService.On("LeadDuplicates", uint(1), uint(5), 20).Return([]models.DuplicateCandidate{
+		{EntityType: models.AuditEntityLead, ID: 2, Score: 50, Reasons: []models.DuplicateReason{models.DuplicateReasonEmail}},
+	}, nil)
+	suite.mockService.On("LeadDuplicates", uint(3), uint(5), 20).
+		Return(nil, fmt.Errorf("lead 3 belongs to another user: %w", apperrors.ErrForbidden))
+	suite.mockService.On("LeadDuplicates", uint(4), uint(0), 5).
+		Return(nil, fmt.Errorf("lead 4: %w", apperrors.ErrNotFound))
+
+	w := suite.do(models.RoleSales, 5, http.MethodGet, "/leads/1/duplicates", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var response struct {
+		Data []models.DuplicateCandidate `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(suite.T(), response.Data, 1)
+	assert.Equal(suite.T(), 50, response.Data[0].Score)
+
+	w = suite.do(models.RoleSales, 5, http.MethodGet, "/leads/3/duplicates", nil)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	w = suite.do(models.RoleAdmin, 1, http.MethodGet, "/leads/4/duplicates?limit=5", nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *DuplicateHandlerTestSuite) TestMergeLeads_AuditsBothSides() {
+	suite.handler.SetAuditService(suite.mockAudit)
+	suite.mockLeadService.On("GetByID", uint(1)).Return(&models.Lead{BaseModel: models.BaseModel{ID: 1}, FirstName: "Ada"}, nil)
+	suite.mockService.On("MergeLeads", models.MergeRequest{
+		SurvivorID: 1, MergedID: 2, Fields: map[string]models.MergeSide{"email": models.MergeSideMerged},
+	}, uint(5)).Return(&models.LeadMerge{
+		Lead: &models.Lead{BaseModel: models.BaseModel{ID: 1}, FirstName: "Ada"}, MergedID: 2,
+	}, nil)
+	suite.mockAudit.On("Record", mock.Anything, models.AuditEntityLead, uint(1), models.AuditActionUpdate,
+		mock.Anything, mock.Anything).Return(nil)
+	suite.mockAudit.On("Record", mock.Anything, models.AuditEntityLead, uint(2), models.AuditActionDelete,
+		nil, nil).Return(nil)
+
+	w := suite.do(models.RoleSales, 5, http.MethodPost, "/leads/merge",
+		gin.H{"survivor_id": 1, "merged_id": 2, "fields": gin.H{"email": "merged"}})
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *DuplicateHandlerTestSuite) TestMergeLeads_Invalid() {
+	suite.mockService.On("MergeLeads", mock.Anything, uint(0)).
+		Return(nil, fmt.Errorf("leads 1 and 2 were converted into different customers: %w", apperrors.ErrValidation))
+	w := suite.do(models.RoleAdmin, 1, http.MethodPost, "/leads/merge", gin.H{"survivor_id": 1, "merged_id": 2})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *DuplicateHandlerTestSuite) TestMergeCustomers_Admin() {
+	suite.mockService.On("MergeCustomers", models.MergeRequest{SurvivorID: 1, MergedID: 2}).Return(&models.CustomerMerge{
+		Customer: &models.Customer{BaseModel: models.BaseModel{ID: 1}}, MergedID: 2, Moved: models.MergeCounts{Tickets: 3},
+	}, nil)
+
+	w := suite.do(models.RoleAdmin, 1, http.MethodPost, "/customers/merge", gin.H{"survivor_id": 1, "merged_id": 2})
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var response struct {
+		Data models.CustomerMerge `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), int64(3), response.Data.Moved.Tickets)
+}
+
+func (suite *DuplicateHandlerTestSuite) TestLeadCreate_WarnsAboutPossibleDuplicates() {
+	leadHandler := NewLeadHandler(suite.mockLeadService)
+	leadHandler.SetDuplicateService(suite.mockService)
+	suite.mockLeadService.On("Create", mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Lead).ID = 9
+	}).Return(nil)
+	suite.mockService.On("FindForLead", mock.MatchedBy(func(l *models.Lead) bool { return l.ID == 9 }), uint(5), duplicateWarningLimit).
+		Return([]models.DuplicateCandidate{{EntityType: models.AuditEntityCustomer, ID: 4, Score: 80}}, nil)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(5))
+		c.Set("user_role", string(models.RoleSales))
+		c.Next()
+	})
+	router.POST("/leads", leadHandler.Create)
+	body, _ := json.Marshal(gin.H{"first_name": "Ada", "last_name": "Lovelace", "email": "ada@example.com"})
+	req := httptest.NewRequest(http.MethodPost, "/leads", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code, "a possible duplicate is still created")
+	var response utils.APIResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(suite.T(), response.Meta.Warnings, 1)
+	assert.Equal(suite.T(), "POSSIBLE_DUPLICATE", response.Meta.Warnings[0].Code)
+}
+
+func TestDuplicateHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(DuplicateHandlerTestSuite))
+}
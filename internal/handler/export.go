@@ -0,0 +1,362 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// The export endpoints download a whole list as a file rather than a page of
+// it as JSON. They share the list's search, filter and sort parameters, and
+// add two of their own:
+//
+//   - format picks the file: csv (the default), xlsx or ndjson.
+//   - columns picks and orders the columns, comma-separated, out of those the
+//     export offers; without it every column is written in its default order.
+const (
+	exportFormatParam  = "format"
+	exportColumnsParam = "columns"
+)
+
+// exportFormat is a file format an export can be written in.
+type exportFormat struct {
+	contentType string
+	extension   string
+	newWriter   func(w io.Writer) exportWriter
+}
+
+// exportFormats are the formats by the name the format parameter takes.
+var exportFormats = map[string]exportFormat{
+	"csv":    {contentType: "text/csv; charset=utf-8", extension: "csv", newWriter: newCSVExportWriter},
+	"xlsx":   {contentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", extension: "xlsx", newWriter: newXLSXExportWriter},
+	"ndjson": {contentType: "application/x-ndjson", extension: "ndjson", newWriter: newNDJSONExportWriter},
+}
+
+// exportWriter renders the rows of an export in one file format. A value is
+// nil for an empty cell, or a string, a bool, an integer, a float64, a
+// time.Time or a []string.
+type exportWriter interface {
+	// Header starts the file with the names of its columns.
+	Header(columns []string) error
+	// Row writes one record, a value per column.
+	Row(values []interface{}) error
+	// Flush hands what has been written so far to the underlying writer.
+	Flush() error
+	// Close ends the file.
+	Close() error
+}
+
+// exportColumn is one column an export of T can carry.
+type exportColumn[T any] struct {
+	Name  string
+	Value func(*T) interface{}
+}
+
+// exportOptional is the value of a nullable column, nil for a null.
+func exportOptional[V any](value *V) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+// customFieldColumns are the export columns of the custom fields of T, one
+// cf.<name> column per field in the fields' position order.
+func customFieldColumns[T any](definitions []models.CustomFieldDefinition, values func(*T) map[string]interface{}) []exportColumn[T] {
+	columns := make([]exportColumn[T], len(definitions))
+	for i, definition := range definitions {
+		name := definition.Name
+		columns[i] = exportColumn[T]{
+			Name:  customFieldParamPrefix + name,
+			Value: func(record *T) interface{} { return values(record)[name] },
+		}
+	}
+	return columns
+}
+
+// selectExportColumns narrows columns to those the columns parameter names, in
+// its order. It answers 400 and returns false for a name the export does not
+// offer.
+func selectExportColumns[T any](c *gin.Context, columns []exportColumn[T]) ([]exportColumn[T], bool) {
+	raw := strings.TrimSpace(c.Query(exportColumnsParam))
+	if raw == "" {
+		return columns, true
+	}
+	byName := make(map[string]exportColumn[T], len(columns))
+	for _, column := range columns {
+		byName[column.Name] = column
+	}
+	selected := []exportColumn[T]{}
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		column, ok := byName[name]
+		if !ok {
+			utils.RespondBadRequest(c, fmt.Sprintf("unknown export column %q", name))
+			return nil, false
+		}
+		selected = append(selected, column)
+	}
+	if len(selected) == 0 {
+		utils.RespondBadRequest(c, "columns names no column")
+		return nil, false
+	}
+	return selected, true
+}
+
+// writeExport answers an export request with the file of every row stream
+// hands over, in the format and with the columns the request asks for; name
+// is the file's base name. It answers 400 for an unknown format or column.
+//
+// Rows are written as each batch is read, so an export holds one batch in
+// memory however large the list is. The status line goes out with the first
+// batch: a failure before it is still an ordinary 500 in the JSON envelope. A
+// failure after it drops the connection, since ending the response would
+// leave the client a short file that looks complete.
+func writeExport[T any](c *gin.Context, logger *logrus.Entry, name string, columns []exportColumn[T], stream func(fn func([]T) error) error) {
+	formatName := c.DefaultQuery(exportFormatParam, "csv")
+	format, ok := exportFormats[formatName]
+	if !ok {
+		utils.RespondBadRequest(c, "format must be one of csv, xlsx or ndjson")
+		return
+	}
+	columns, ok = selectExportColumns(c, columns)
+	if !ok {
+		return
+	}
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name
+	}
+
+	writer := format.newWriter(c.Writer)
+	started := false
+	start := func() error {
+		started = true
+		c.Header("Content-Type", format.contentType)
+		c.Header("Content-Disposition", "attachment; filename="+name+"-export."+format.extension)
+		c.Status(http.StatusOK)
+		return writer.Header(names)
+	}
+
+	count := 0
+	values := make([]interface{}, len(columns))
+	err := stream(func(batch []T) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		for i := range batch {
+			for j, column := range columns {
+				values[j] = column.Value(&batch[i])
+			}
+			if err := writer.Row(values); err != nil {
+				return err
+			}
+		}
+		count += len(batch)
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err == nil && !started {
+		// Nothing matched: the file is the header alone.
+		err = start()
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	logger = logger.WithField("format", formatName)
+	if err != nil && !started {
+		logger.WithError(err).Error("Failed to export " + name)
+		utils.RespondInternalError(c)
+		return
+	}
+	if err != nil {
+		logger.WithError(err).WithField("count", count).Error("Export failed after it started")
+		panic(http.ErrAbortHandler)
+	}
+
+	logger.WithField("count", count).Info("Export written")
+}
+
+// exportSort reads the sort of an export request, as listSort does for a list,
+// and drops a column outside allowed, the SQL-injection guard. Without a
+// column there is no direction either.
+func exportSort(c *gin.Context, allowed map[string]bool) (sortBy, sortOrder string) {
+	sortBy, sortOrder = listSort(c)
+	if !allowed[sortBy] {
+		return "", ""
+	}
+	if sortOrder != "asc" && sortOrder != "desc" {
+		sortOrder = "asc"
+	}
+	return sortBy, sortOrder
+}
+
+// exportText renders a cell value as text, the way CSV and XLSX spreadsheets
+// show it: timestamps as RFC3339, a multi_select value as its options joined
+// with semicolons, nil as nothing.
+func exportText(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case time.Time:
+		return value.Format(time.RFC3339)
+	case []string:
+		return strings.Join(value, ";")
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// csvExportWriter writes an export as CSV. Every text cell goes through
+// csvSafeField.
+type csvExportWriter struct {
+	writer *csv.Writer
+	record []string
+}
+
+func newCSVExportWriter(w io.Writer) exportWriter {
+	return &csvExportWriter{writer: csv.NewWriter(w)}
+}
+
+func (w *csvExportWriter) Header(columns []string) error {
+	return w.writer.Write(columns)
+}
+
+func (w *csvExportWriter) Row(values []interface{}) error {
+	w.record = w.record[:0]
+	for _, value := range values {
+		text := exportText(value)
+		switch value.(type) {
+		case string, []string:
+			text = csvSafeField(text)
+		}
+		w.record = append(w.record, text)
+	}
+	return w.writer.Write(w.record)
+}
+
+func (w *csvExportWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func (w *csvExportWriter) Close() error {
+	return w.Flush()
+}
+
+// ndjsonExportWriter writes an export as newline-delimited JSON, one object
+// per row with its keys in column order. Values keep their JSON types, so
+// nothing is escaped for spreadsheets: the format is for programs.
+type ndjsonExportWriter struct {
+	w       io.Writer
+	columns [][]byte
+	line    bytes.Buffer
+}
+
+func newNDJSONExportWriter(w io.Writer) exportWriter {
+	return &ndjsonExportWriter{w: w}
+}
+
+func (w *ndjsonExportWriter) Header(columns []string) error {
+	w.columns = make([][]byte, len(columns))
+	for i, column := range columns {
+		key, err := json.Marshal(column)
+		if err != nil {
+			return err
+		}
+		w.columns[i] = key
+	}
+	return nil
+}
+
+func (w *ndjsonExportWriter) Row(values []interface{}) error {
+	w.line.Reset()
+	w.line.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			w.line.WriteByte(',')
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		w.line.Write(w.columns[i])
+		w.line.WriteByte(':')
+		w.line.Write(encoded)
+	}
+	w.line.WriteString("}\n")
+	_, err := w.w.Write(w.line.Bytes())
+	return err
+}
+
+func (w *ndjsonExportWriter) Flush() error {
+	return nil
+}
+
+func (w *ndjsonExportWriter) Close() error {
+	return nil
+}
+
+// csvSafeField blunts spreadsheet formula injection. A cell that opens with =,
+// @, a tab or a carriage return is executed as a formula by Excel and by
+// LibreOffice, so text that arrived from outside (notes carried over from a
+// converted lead, a company name) could run when an administrator opens the
+// download. Prefixing an apostrophe makes the spreadsheet treat it as literal
+// text.
+//
+// A leading + or - is treated separately: those overwhelmingly begin phone
+// numbers and negative figures, and mangling every international dialling code
+// would be a worse outcome than the risk. They are escaped only when what
+// follows is not a plain number.
+func csvSafeField(value string) string {
+	if value == "" {
+		return value
+	}
+
+	switch value[0] {
+	case '=', '@', '\t', '\r':
+		return "'" + value
+	case '+', '-':
+		if !isNumericLike(value[1:]) {
+			return "'" + value
+		}
+	}
+	return value
+}
+
+// isNumericLike reports whether the rest of a +/- prefixed value looks like a
+// phone number or a plain figure rather than a formula.
+func isNumericLike(value string) bool {
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+		case r == ' ', r == '-', r == '(', r == ')', r == '.', r == '+', r == ',':
+		default:
+			return false
+		}
+	}
+	return true
+}
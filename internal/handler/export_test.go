@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exportFixture is one file's worth of rows, covering every value type a
+// column can produce.
+var exportFixture = struct {
+	columns []string
+	rows    [][]interface{}
+}{
+	columns: []string{"id", "name", "tags", "score", "active", "due", "owner_id"},
+	rows: [][]interface{}{
+		{uint(1), "=HYPERLINK(\"http://evil\")", []string{"a", "b"}, 4.5, true,
+			time.Date(2026, 5, 1, 9, 30, 0, 0, time.UTC), nil},
+		{uint(2), "+49 30 1234", []string{}, float64(-2), false, nil, uint(7)},
+	},
+}
+
+func writeExportFixture(t *testing.T, format string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := exportFormats[format].newWriter(&buf)
+	require.NoError(t, writer.Header(exportFixture.columns))
+	for _, row := range exportFixture.rows {
+		require.NoError(t, writer.Row(row))
+		require.NoError(t, writer.Flush())
+	}
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func TestCSVExportWriter(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewReader(writeExportFixture(t, "csv"))).ReadAll()
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]string{
+		exportFixture.columns,
+		{"1", "'=HYPERLINK(\"http://evil\")", "a;b", "4.5", "true", "2026-05-01T09:30:00Z", ""},
+		{"2", "+49 30 1234", "", "-2", "false", "", "7"},
+	}, records)
+}
+
+func TestNDJSONExportWriter(t *testing.T) {
+	lines := strings.Split(strings.TrimSuffix(string(writeExportFixture(t, "ndjson")), "\n"), "\n")
+	require.Len(t, lines, 2, "one line per row and no header line")
+
+	// Keys come in column order, and values keep their JSON types unescaped.
+	assert.Equal(t, `{"id":1,"name":"=HYPERLINK(\"http://evil\")","tags":["a","b"],"score":4.5,"active":true,`+
+		`"due":"2026-05-01T09:30:00Z","owner_id":null}`, lines[0])
+	var row map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &row))
+	assert.Equal(t, float64(7), row["owner_id"])
+}
+
+// xlsxSheet is the part of a worksheet the tests read back.
+type xlsxSheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R      string `xml:"r,attr"`
+			T      string `xml:"t,attr"`
+			V      string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func TestXLSXExportWriter(t *testing.T) {
+	raw := writeExportFixture(t, "xlsx")
+	archive, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+	require.NoError(t, err)
+
+	parts := map[string]*zip.File{}
+	for _, file := range archive.File {
+		parts[file.Name] = file
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels"} {
+		require.Contains(t, parts, name)
+	}
+	require.Contains(t, parts, "xl/worksheets/sheet1.xml")
+	sheetFile, err := parts["xl/worksheets/sheet1.xml"].Open()
+	require.NoError(t, err)
+	content, err := io.ReadAll(sheetFile)
+	require.NoError(t, err)
+
+	var sheet xlsxSheet
+	require.NoError(t, xml.Unmarshal(content, &sheet))
+	require.Len(t, sheet.Rows, 3)
+
+	header := sheet.Rows[0].Cells
+	require.Len(t, header, len(exportFixture.columns))
+	assert.Equal(t, "A1", header[0].R)
+	assert.Equal(t, "G1", header[6].R)
+	assert.Equal(t, "owner_id", header[6].Inline)
+
+	first := sheet.Rows[1].Cells
+	require.Len(t, first, 6, "a null leaves its cell out")
+	assert.Equal(t, "", first[0].T, "numbers are numeric cells")
+	assert.Equal(t, "1", first[0].V)
+	assert.Equal(t, "inlineStr", first[1].T)
+	assert.Equal(t, "'=HYPERLINK(\"http://evil\")", first[1].Inline)
+	assert.Equal(t, "a;b", first[2].Inline)
+	assert.Equal(t, "4.5", first[3].V)
+	assert.Equal(t, "b", first[4].T)
+	assert.Equal(t, "1", first[4].V)
+	assert.Equal(t, "2026-05-01T09:30:00Z", first[5].Inline)
+
+	second := sheet.Rows[2].Cells
+	assert.Equal(t, "G3", second[len(second)-1].R)
+	assert.Equal(t, "7", second[len(second)-1].V)
+}
+
+func TestXLSXExportWriter_EscapesAndTruncatesText(t *testing.T) {
+	var buf bytes.Buffer
+	writer := newXLSXExportWriter(&buf)
+	require.NoError(t, writer.Header([]string{"notes"}))
+	long := strings.Repeat("é", xlsxMaxCellText+10)
+	require.NoError(t, writer.Row([]interface{}{"<b>Tom & Jerry</b>"}))
+	require.NoError(t, writer.Row([]interface{}{long}))
+	require.NoError(t, writer.Close())
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	var sheet xlsxSheet
+	for _, file := range archive.File {
+		if file.Name == "xl/worksheets/sheet1.xml" {
+			part, err := file.Open()
+			require.NoError(t, err)
+			require.NoError(t, xml.NewDecoder(bufio.NewReader(part)).Decode(&sheet))
+		}
+	}
+	require.Len(t, sheet.Rows, 3)
+	assert.Equal(t, "<b>Tom & Jerry</b>", sheet.Rows[1].Cells[0].Inline)
+	assert.Equal(t, strings.Repeat("é", xlsxMaxCellText), sheet.Rows[2].Cells[0].Inline)
+}
+
+func TestXLSXColumnName(t *testing.T) {
+	for index, name := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		assert.Equal(t, name, xlsxColumnName(index))
+	}
+}
+
+func TestCSVSafeField(t *testing.T) {
+	for value, want := range map[string]string{
+		"":                "",
+		"plain":           "plain",
+		"=SUM(A1:A2)":     "'=SUM(A1:A2)",
+		"@cmd":            "'@cmd",
+		"\tindent":        "'\tindent",
+		"+1 (555) 010-99": "+1 (555) 010-99",
+		"-42.5":           "-42.5",
+		"+cmd|' /C calc'": "'+cmd|' /C calc'",
+		"-2+3+cmd":        "'-2+3+cmd",
+	} {
+		assert.Equal(t, want, csvSafeField(value), value)
+	}
+}
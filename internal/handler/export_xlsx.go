@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+)
+
+// An XLSX file is a zip of XML parts. xlsxExportWriter writes the smallest
+// workbook Excel, LibreOffice and Google Sheets open — one worksheet, no
+// styles — and streams the worksheet part as rows arrive, so it holds no more
+// of the file than the CSV writer does.
+
+// xlsxStaticParts are the parts of the workbook that do not depend on the
+// rows, by their path in the zip.
+var xlsxStaticParts = []struct{ name, content string }{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Export" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// xlsxMaxCellText is the most characters a spreadsheet cell holds; Excel
+// refuses a file with a longer one.
+const xlsxMaxCellText = 32767
+
+// xlsxExportWriter writes an export as an XLSX workbook. Text cells are
+// inline strings, which a spreadsheet never evaluates, but they still go
+// through csvSafeField so that a cell reads the same in the XLSX and the CSV
+// download, and stays inert when the sheet is saved on as CSV.
+type xlsxExportWriter struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	row   int
+}
+
+func newXLSXExportWriter(w io.Writer) exportWriter {
+	return &xlsxExportWriter{zip: zip.NewWriter(w)}
+}
+
+func (w *xlsxExportWriter) Header(columns []string) error {
+	for _, part := range xlsxStaticParts {
+		entry, err := w.zip.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(entry, part.content); err != nil {
+			return err
+		}
+	}
+	entry, err := w.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	w.sheet = bufio.NewWriter(entry)
+	w.sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		values[i] = column
+	}
+	return w.Row(values)
+}
+
+func (w *xlsxExportWriter) Row(values []interface{}) error {
+	w.row++
+	row := strconv.Itoa(w.row)
+	w.sheet.WriteString(`<row r="` + row + `">`)
+	for i, value := range values {
+		ref := xlsxColumnName(i) + row
+		switch value := value.(type) {
+		case nil:
+		case bool:
+			v := "0"
+			if value {
+				v = "1"
+			}
+			w.sheet.WriteString(`<c r="` + ref + `" t="b"><v>` + v + `</v></c>`)
+		case int, int64, uint, uint64, float64:
+			w.sheet.WriteString(`<c r="` + ref + `"><v>` + exportText(value) + `</v></c>`)
+		default:
+			text := exportText(value)
+			switch value.(type) {
+			case string, []string:
+				text = csvSafeField(text)
+			}
+			w.sheet.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+			if err := xml.EscapeText(w.sheet, []byte(xlsxCellText(text))); err != nil {
+				return err
+			}
+			w.sheet.WriteString(`</t></is></c>`)
+		}
+	}
+	_, err := w.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (w *xlsxExportWriter) Flush() error {
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+	return w.zip.Flush()
+}
+
+func (w *xlsxExportWriter) Close() error {
+	w.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+	return w.zip.Close()
+}
+
+// xlsxColumnName is the letter name of the zero-based column index: A to Z,
+// then AA and on.
+func xlsxColumnName(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+// xlsxCellText cuts text to what a cell can hold, on a character boundary.
+func xlsxCellText(text string) string {
+	if len(text) <= xlsxMaxCellText {
+		return text
+	}
+	count := 0
+	for i := range text {
+		if count == xlsxMaxCellText {
+			return text[:i]
+		}
+		count++
+	}
+	return text
+}
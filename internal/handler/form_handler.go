@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/middleware"
 	"github.com/florinel-chis/gophercrm/internal/models"
 	"github.com/florinel-chis/gophercrm/internal/service"
 	"github.com/florinel-chis/gophercrm/internal/utils"
@@ -18,6 +19,7 @@ import (
 // definitions and the submissions they collected. The visitor-facing endpoints
 // live in their own handler; nothing here is reachable without authentication.
 type FormHandler struct {
+	auditTrail
 	formService service.FormService
 }
 
@@ -155,7 +157,7 @@ func (h *FormHandler) List(c *gin.Context) {
 
 // Create godoc
 // @Summary Create a form
-// @Description Create a form definition (admin and sales only). The server assigns the public identifier used by the embed script and records the author. The definition is validated before it is stored: 1 to 50 fields with unique machine names matching ^[a-z][a-z0-9_]{0,49}$, exactly one field of type email named "email", select fields carrying 1 to 50 options, hidden fields never required, a redirect action requiring an http(s) redirect_url, and a create_lead form requiring a default_owner_id that names an active admin or sales user. Omitting create_lead means true. A rejected definition is answered with 400, with the offending fields in error.details when the service could attribute the failure.
+// @Description Create a form definition (admin and sales only). The server assigns the public identifier used by the embed script and records the author. The definition is validated before it is stored: 1 to 50 fields with unique machine names matching ^[a-z][a-z0-9_]{0,49}$, exactly one field of type email named "email", select fields carrying 1 to 50 options, hidden fields never required, a redirect action requiring an http(s) redirect_url, and a create_lead form requiring a default_owner_id that names an active user whose role, built-in or custom, holds leads:update (of the built-in roles, admin and sales). Omitting create_lead means true. A rejected definition is answered with 400, with the offending fields in error.details when the service could attribute the failure. Creating a form as published takes the forms:publish permission.
 // @Tags forms
 // @Accept json
 // @Produce json
@@ -179,11 +181,17 @@ func (h *FormHandler) Create(c *gin.Context) {
 	}
 
 	form := req.toModel()
+	if form.Status == models.FormStatusPublished && !middleware.HasPermission(c, models.PermFormsPublish) {
+		utils.RespondForbidden(c, "Insufficient permissions to publish forms")
+		return
+	}
 	if err := h.formService.Create(form, c.GetUint("user_id")); err != nil {
 		h.respondError(c, logger, err, "Form not found")
 		return
 	}
 
+	h.recordAudit(c, models.AuditEntityForm, form.ID, models.AuditActionCreate, nil, form)
+
 	utils.LogHandlerResponse(logger, http.StatusCreated, form)
 	utils.RespondSuccess(c, http.StatusCreated, form)
 }
@@ -224,7 +232,7 @@ func (h *FormHandler) Get(c *gin.Context) {
 
 // Update godoc
 // @Summary Update a form
-// @Description Replace a form definition wholesale (admin and sales only), including its publication status. The body carries the complete document: a field left out is cleared, not kept. The public identifier and the author are immutable and are carried over from the stored row, so an embedded form keeps working across edits. Omitting create_lead means true. Validation is identical to creation.
+// @Description Replace a form definition wholesale (admin and sales only), including its publication status. The body carries the complete document: a field left out is cleared, not kept. The public identifier and the author are immutable and are carried over from the stored row, so an embedded form keeps working across edits. Omitting create_lead means true. Validation is identical to creation. Publishing or unpublishing a form takes the forms:publish permission.
 // @Tags forms
 // @Accept json
 // @Produce json
@@ -255,11 +263,31 @@ func (h *FormHandler) Update(c *gin.Context) {
 	}
 
 	form := (*CreateFormRequest)(&req).toModel()
+	// Publishing and unpublishing take their own permission; the stored status
+	// is only read for a caller who lacks it.
+	if !middleware.HasPermission(c, models.PermFormsPublish) {
+		existing, err := h.formService.GetByID(id)
+		if err != nil {
+			h.respondError(c, logger, err, "Form not found")
+			return
+		}
+		if (existing.Status == models.FormStatusPublished) != (form.Status == models.FormStatusPublished) {
+			utils.RespondForbidden(c, "Insufficient permissions to publish or unpublish forms")
+			return
+		}
+	}
+
+	before := h.auditLoad(func() (interface{}, error) { return h.formService.GetByID(id) })
 	if err := h.formService.Update(id, form); err != nil {
 		h.respondError(c, logger, err, "Form not found")
 		return
 	}
 
+	// The request model carries only what the client sent; the stored form
+	// is the honest after-state.
+	h.recordAudit(c, models.AuditEntityForm, id, models.AuditActionUpdate, before,
+		h.auditLoad(func() (interface{}, error) { return h.formService.GetByID(id) }))
+
 	utils.LogHandlerResponse(logger, http.StatusOK, form)
 	utils.RespondSuccess(c, http.StatusOK, form)
 }
@@ -288,11 +316,14 @@ func (h *FormHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	before := h.auditLoad(func() (interface{}, error) { return h.formService.GetByID(id) })
 	if err := h.formService.Delete(id); err != nil {
 		h.respondError(c, logger, err, "Form not found")
 		return
 	}
 
+	h.recordAudit(c, models.AuditEntityForm, id, models.AuditActionDelete, before, nil)
+
 	utils.LogHandlerResponse(logger, http.StatusNoContent, nil)
 	c.Status(http.StatusNoContent)
 }
@@ -308,8 +339,10 @@ func (h *FormHandler) Delete(c *gin.Context) {
 // @Param offset query int false "Pagination offset (default 0)"
 // @Param limit query int false "Page size (default 20, maximum 100)"
 // @Param status query string false "Filter by submission state" Enums(received, pending, confirmed, spam)
+// @Param filter query string false "Structured filter, e.g. status ne spam and created_at gte -7d and lead_id ne null. Fields: id, email, status, spam_reason, lead_id, ip_address, referrer, confirmed_at, created_at"
+// @Param cursor query string false "Page by keyset instead of offset: empty for the first page, then the meta.next_cursor of the previous one"
 // @Success 200 {object} utils.APIResponse{data=[]models.FormSubmission} "Submissions retrieved successfully"
-// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid form ID"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid form ID, filter or cursor"
 // @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
 // @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin, sales or support role required"
 // @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Form not found"
@@ -325,9 +358,19 @@ func (h *FormHandler) ListSubmissions(c *gin.Context) {
 	}
 
 	offset, limit := utils.ParseOffsetLimit(c)
-	status := strings.TrimSpace(c.Query("status"))
+	where, ok := parseListFilter(c, logger, "form_submissions")
+	if !ok {
+		return
+	}
+	// Submissions have one order, newest first, and the cursor is taken in it.
+	cursor, ok := parseListCursor(c, logger, "form_submissions", "created_at", "desc", models.CustomFieldQuery{})
+	if !ok {
+		return
+	}
+	filter := models.FormSubmissionFilter{Status: strings.TrimSpace(c.Query("status")), Where: where, Cursor: cursor}
+	fetchOffset, fetchLimit := cursorWindow(cursor, offset, limit)
 
-	submissions, total, err := h.formService.ListSubmissions(id, offset, limit, status)
+	submissions, total, err := h.formService.ListSubmissions(id, filter, fetchOffset, fetchLimit)
 	if err != nil {
 		h.respondError(c, logger, err, "Form not found")
 		return
@@ -337,10 +380,102 @@ func (h *FormHandler) ListSubmissions(c *gin.Context) {
 	}
 
 	meta := formListMeta(c, offset, limit, total)
+	if cursor != nil {
+		var next string
+		if submissions, next, err = cursorPage(cursor, submissions, limit); err != nil {
+			logger.WithError(err).Error("Failed to encode the next cursor")
+			utils.RespondInternalError(c)
+			return
+		}
+		meta = cursorMeta(c, limit, total, next)
+	}
 	utils.LogHandlerResponse(logger, http.StatusOK, submissions)
 	utils.RespondSuccessWithMeta(c, http.StatusOK, submissions, meta)
 }
 
+// formSubmissionExportColumns are the fixed columns of the submission export,
+// in the order a download without the columns parameter writes them. A
+// data.<name> column per field of the form follows them.
+var formSubmissionExportColumns = []exportColumn[models.FormSubmission]{
+	{"id", func(s *models.FormSubmission) interface{} { return s.ID }},
+	{"created_at", func(s *models.FormSubmission) interface{} { return s.CreatedAt }},
+	{"status", func(s *models.FormSubmission) interface{} { return string(s.Status) }},
+	{"email", func(s *models.FormSubmission) interface{} { return s.Email }},
+	{"spam_reason", func(s *models.FormSubmission) interface{} { return s.SpamReason }},
+	{"lead_id", func(s *models.FormSubmission) interface{} { return exportOptional(s.LeadID) }},
+	{"ip_address", func(s *models.FormSubmission) interface{} { return s.IPAddress }},
+	{"user_agent", func(s *models.FormSubmission) interface{} { return s.UserAgent }},
+	{"referrer", func(s *models.FormSubmission) interface{} { return s.Referrer }},
+	{"confirmed_at", func(s *models.FormSubmission) interface{} { return exportOptional(s.ConfirmedAt) }},
+}
+
+// ExportSubmissions godoc
+// @Summary Export the submissions of a form as CSV, XLSX or NDJSON
+// @Description Download every submission of one form matching the list parameters as a file, newest first (admin role only: the submissions carry what visitors typed, addresses included). There is no pagination: every matching row is included, streamed in batches. The response is the raw file, not the utils.APIResponse envelope; errors before the file starts are still reported through the envelope, and a failure after it has started drops the connection.
+// @Description
+// @Description Columns, in order: id, created_at, status, email, spam_reason, lead_id, ip_address, user_agent, referrer, confirmed_at, then one data.<name> column per field of the form as it is defined now, in the form's field order. columns picks and orders a subset. Timestamps are RFC3339 and a missing value is an empty cell. In CSV and XLSX a text cell a spreadsheet would run as a formula is prefixed with an apostrophe — submissions are typed by anonymous visitors, so this matters most here; NDJSON keeps every value as it is.
+// @Tags forms
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/x-ndjson
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Form ID"
+// @Param format query string false "File format" Enums(csv, xlsx, ndjson) default(csv)
+// @Param columns query string false "Comma-separated columns to write, in this order; all of them by default"
+// @Param status query string false "Filter by submission state" Enums(received, pending, confirmed, spam)
+// @Param filter query string false "Structured filter, as on the list endpoint"
+// @Success 200 {string} string "File of submissions (raw body, not the API envelope)"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid form ID, format, column or filter"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Form not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /forms/{id}/submissions/export [get]
+func (h *FormHandler) ExportSubmissions(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "FormHandler.ExportSubmissions")
+
+	// Repeats the route's permission guard, as the customer export does.
+	if !middleware.HasPermission(c, models.PermFormsExport) {
+		utils.RespondForbidden(c, "Only administrators can export form submissions")
+		return
+	}
+	id, ok := formPathID(c, "Invalid form ID")
+	if !ok {
+		return
+	}
+	where, ok := parseListFilter(c, logger, "form_submissions")
+	if !ok {
+		return
+	}
+	filter := models.FormSubmissionFilter{Status: strings.TrimSpace(c.Query("status")), Where: where}
+
+	form, err := h.formService.GetByID(id)
+	if err != nil {
+		h.respondError(c, logger, err, "Form not found")
+		return
+	}
+	columns := append([]exportColumn[models.FormSubmission]{}, formSubmissionExportColumns...)
+	for _, field := range form.Fields {
+		name := field.Name
+		columns = append(columns, exportColumn[models.FormSubmission]{
+			Name: "data." + name,
+			Value: func(s *models.FormSubmission) interface{} {
+				if value, ok := s.Data[name]; ok {
+					return value
+				}
+				return nil
+			},
+		})
+	}
+
+	writeExport(c, logger, "form-"+strconv.FormatUint(uint64(id), 10)+"-submissions", columns,
+		func(fn func([]models.FormSubmission) error) error {
+			return h.formService.StreamSubmissions(id, filter, fn)
+		})
+}
+
 // GetSubmission godoc
 // @Summary Get a submission
 // @Description Get a single submission with the values it carried, the address it came from and the lead it produced, if any. Available to admin, sales and support; the customer role is rejected with 403.
@@ -2,12 +2,16 @@ package handler
 
 import (
 	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -31,7 +35,8 @@ type fakeFormService struct {
 	listFn                func(offset, limit int, status, sortBy, sortOrder string) ([]models.Form, map[uint]int64, int64, error)
 	updateFn              func(id uint, form *models.Form) error
 	deleteFn              func(id uint) error
-	listSubmissionsFn     func(formID uint, offset, limit int, status string) ([]models.FormSubmission, int64, error)
+	listSubmissionsFn     func(formID uint, filter models.FormSubmissionFilter, offset, limit int) ([]models.FormSubmission, int64, error)
+	streamSubmissionsFn   func(formID uint, filter models.FormSubmissionFilter, fn func([]models.FormSubmission) error) error
 	getSubmissionFn       func(id uint) (*models.FormSubmission, error)
 	createdActorID        uint
 	createdForm           *models.Form
@@ -40,7 +45,7 @@ type fakeFormService struct {
 	listedStatus          string
 	listedSortBy          string
 	submissionsFormID     uint
-	submissionsStatus     string
+	submissionsFilter     models.FormSubmissionFilter
 	requestedSubmissionID uint
 }
 
@@ -90,15 +95,24 @@ func (f *fakeFormService) Delete(id uint) error {
 	return nil
 }
 
-func (f *fakeFormService) ListSubmissions(formID uint, offset, limit int, status string) ([]models.FormSubmission, int64, error) {
+func (f *fakeFormService) ListSubmissions(formID uint, filter models.FormSubmissionFilter, offset, limit int) ([]models.FormSubmission, int64, error) {
 	f.submissionsFormID = formID
-	f.submissionsStatus = status
+	f.submissionsFilter = filter
 	if f.listSubmissionsFn != nil {
-		return f.listSubmissionsFn(formID, offset, limit, status)
+		return f.listSubmissionsFn(formID, filter, offset, limit)
 	}
 	return nil, 0, nil
 }
 
+func (f *fakeFormService) StreamSubmissions(formID uint, filter models.FormSubmissionFilter, fn func([]models.FormSubmission) error) error {
+	f.submissionsFormID = formID
+	f.submissionsFilter = filter
+	if f.streamSubmissionsFn != nil {
+		return f.streamSubmissionsFn(formID, filter, fn)
+	}
+	return nil
+}
+
 func (f *fakeFormService) GetSubmission(id uint) (*models.FormSubmission, error) {
 	f.requestedSubmissionID = id
 	if f.getSubmissionFn != nil {
@@ -227,6 +241,9 @@ func (suite *FormHandlerTestSuite) TestRoleMatrix() {
 		{"customer cannot list submissions", models.RoleCustomer, http.MethodGet, "/forms/7/submissions", nil, http.StatusForbidden},
 		{"support reads a submission", models.RoleSupport, http.MethodGet, "/forms/submissions/5", nil, http.StatusOK},
 		{"customer cannot read a submission", models.RoleCustomer, http.MethodGet, "/forms/submissions/5", nil, http.StatusForbidden},
+		{"admin exports submissions", models.RoleAdmin, http.MethodGet, "/forms/7/submissions/export", nil, http.StatusOK},
+		{"sales cannot export submissions", models.RoleSales, http.MethodGet, "/forms/7/submissions/export", nil, http.StatusForbidden},
+		{"support cannot export submissions", models.RoleSupport, http.MethodGet, "/forms/7/submissions/export", nil, http.StatusForbidden},
 	}
 
 	for _, tc := range cases {
@@ -471,7 +488,7 @@ func (suite *FormHandlerTestSuite) TestDelete_MissingFormIsNotFound() {
 // ---------------------------------------------------------------------------
 
 func (suite *FormHandlerTestSuite) TestListSubmissions_Envelope() {
-	suite.fakeService.listSubmissionsFn = func(formID uint, offset, limit int, status string) ([]models.FormSubmission, int64, error) {
+	suite.fakeService.listSubmissionsFn = func(formID uint, filter models.FormSubmissionFilter, offset, limit int) ([]models.FormSubmission, int64, error) {
 		return []models.FormSubmission{
 			{
 				BaseModel:  models.BaseModel{ID: 9},
@@ -502,7 +519,62 @@ func (suite *FormHandlerTestSuite) TestListSubmissions_Envelope() {
 	assert.Equal(suite.T(), int64(1), response.Meta.Total)
 
 	assert.Equal(suite.T(), uint(7), suite.fakeService.submissionsFormID)
-	assert.Equal(suite.T(), "spam", suite.fakeService.submissionsStatus)
+	assert.Equal(suite.T(), "spam", suite.fakeService.submissionsFilter.Status)
+	assert.Nil(suite.T(), suite.fakeService.submissionsFilter.Where)
+}
+
+func (suite *FormHandlerTestSuite) TestListSubmissions_Filter() {
+	w := suite.do(http.MethodGet, "/forms/7/submissions?filter="+url.QueryEscape("status ne spam and lead_id ne null"), nil)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	where := suite.fakeService.submissionsFilter.Where
+	suite.Require().NotNil(where)
+	assert.Len(suite.T(), where.Group, 2)
+
+	w = suite.do(http.MethodGet, "/forms/7/submissions?filter="+url.QueryEscape("user_agent contains bot"), nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *FormHandlerTestSuite) TestListSubmissions_CursorPages() {
+	created := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	var gotOffset, gotLimit int
+	suite.fakeService.listSubmissionsFn = func(formID uint, filter models.FormSubmissionFilter, offset, limit int) ([]models.FormSubmission, int64, error) {
+		gotOffset, gotLimit = offset, limit
+		subs := []models.FormSubmission{}
+		for id := uint(3); id > 0 && len(subs) < limit; id-- {
+			if filter.Cursor.After == nil || id < filter.Cursor.After.ID {
+				subs = append(subs, models.FormSubmission{BaseModel: models.BaseModel{ID: id, CreatedAt: created}, FormID: formID})
+			}
+		}
+		return subs, 3, nil
+	}
+
+	w := suite.do(http.MethodGet, "/forms/7/submissions?cursor=&limit=2&offset=40", nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var response struct {
+		Data []models.FormSubmission `json:"data"`
+		Meta *utils.APIMeta          `json:"meta"`
+	}
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), 0, gotOffset, "a cursor page ignores the offset")
+	assert.Equal(suite.T(), 3, gotLimit, "one row more than the page tells whether another follows")
+	suite.Require().Len(response.Data, 2)
+	cursor := suite.fakeService.submissionsFilter.Cursor
+	suite.Require().NotNil(cursor)
+	assert.Equal(suite.T(), "created_at", cursor.SortBy)
+	assert.True(suite.T(), cursor.Desc, "newest first")
+	suite.Require().NotEmpty(response.Meta.NextCursor)
+
+	w = suite.do(http.MethodGet, "/forms/7/submissions?limit=2&cursor="+response.Meta.NextCursor, nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	response.Meta = nil
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	suite.Require().Len(response.Data, 1)
+	assert.Equal(suite.T(), uint(1), response.Data[0].ID)
+	assert.Empty(suite.T(), response.Meta.NextCursor, "the last page has no next cursor")
+
+	w = suite.do(http.MethodGet, "/forms/7/submissions?cursor=not-a-cursor", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
 }
 
 func (suite *FormHandlerTestSuite) TestListSubmissions_EmptyPageIsAnArrayNotNull() {
@@ -513,7 +585,7 @@ func (suite *FormHandlerTestSuite) TestListSubmissions_EmptyPageIsAnArrayNotNull
 }
 
 func (suite *FormHandlerTestSuite) TestListSubmissions_MissingFormIsNotFound() {
-	suite.fakeService.listSubmissionsFn = func(formID uint, offset, limit int, status string) ([]models.FormSubmission, int64, error) {
+	suite.fakeService.listSubmissionsFn = func(formID uint, filter models.FormSubmissionFilter, offset, limit int) ([]models.FormSubmission, int64, error) {
 		return nil, 0, fmt.Errorf("form %d not found: %w", formID, apperrors.ErrNotFound)
 	}
 
@@ -522,6 +594,54 @@ func (suite *FormHandlerTestSuite) TestListSubmissions_MissingFormIsNotFound() {
 	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
 }
 
+// The export writes a column per field of the form, after the fixed ones, and
+// escapes what visitors typed the same way the customer export does.
+func (suite *FormHandlerTestSuite) TestExportSubmissions_WritesAFieldColumnPerFormField() {
+	suite.fakeService.getByIDFn = func(id uint) (*models.Form, error) {
+		return &models.Form{BaseModel: models.BaseModel{ID: id}, Fields: []models.FormFieldDef{
+			{Name: "email", Type: "email"}, {Name: "message", Type: "textarea"},
+		}}, nil
+	}
+	suite.fakeService.streamSubmissionsFn = func(formID uint, filter models.FormSubmissionFilter, fn func([]models.FormSubmission) error) error {
+		return fn([]models.FormSubmission{{
+			BaseModel: models.BaseModel{ID: 3},
+			Email:     "ada@example.com",
+			Status:    models.FormSubmissionReceived,
+			Data:      map[string]string{"email": "ada@example.com", "message": "=cmd|' /C calc'!A0"},
+		}})
+	}
+
+	w := suite.do(http.MethodGet, "/forms/7/submissions/export?status=received&columns=id,status,data.email,data.message", nil)
+
+	suite.Require().Equal(http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "attachment; filename=form-7-submissions-export.csv", w.Header().Get("Content-Disposition"))
+	assert.Equal(suite.T(), uint(7), suite.fakeService.submissionsFormID)
+	assert.Equal(suite.T(), "received", suite.fakeService.submissionsFilter.Status)
+	records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), [][]string{
+		{"id", "status", "data.email", "data.message"},
+		{"3", "received", "ada@example.com", "'=cmd|' /C calc'!A0"},
+	}, records)
+}
+
+func (suite *FormHandlerTestSuite) TestExportSubmissions_MissingFormIsNotFound() {
+	suite.fakeService.getByIDFn = func(id uint) (*models.Form, error) {
+		return nil, fmt.Errorf("form %d not found: %w", id, apperrors.ErrNotFound)
+	}
+
+	w := suite.do(http.MethodGet, "/forms/404/submissions/export", nil)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *FormHandlerTestSuite) TestExportSubmissions_UnknownColumnIsABadRequest() {
+	w := suite.do(http.MethodGet, "/forms/7/submissions/export?columns=id,data.nope", nil)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `unknown export column \"data.nope\"`)
+}
+
 func (suite *FormHandlerTestSuite) TestGetSubmission_Success() {
 	w := suite.do(http.MethodGet, "/forms/submissions/5", nil)
 
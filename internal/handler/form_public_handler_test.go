@@ -59,7 +59,11 @@ func (s *formPublicServiceStub) List(int, int, string, string, string) ([]models
 func (s *formPublicServiceStub) Update(uint, *models.Form) error { panic("not a public route") }
 func (s *formPublicServiceStub) Delete(uint) error               { panic("not a public route") }
 
-func (s *formPublicServiceStub) ListSubmissions(uint, int, int, string) ([]models.FormSubmission, int64, error) {
+func (s *formPublicServiceStub) ListSubmissions(uint, models.FormSubmissionFilter, int, int) ([]models.FormSubmission, int64, error) {
+	panic("not a public route")
+}
+
+func (s *formPublicServiceStub) StreamSubmissions(uint, models.FormSubmissionFilter, func([]models.FormSubmission) error) error {
 	panic("not a public route")
 }
 
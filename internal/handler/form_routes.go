@@ -11,7 +11,7 @@ import (
 // rejected with 403 even on the read-only routes. Support may read the
 // definitions and the submissions but not change what is published, editing is
 // admin and sales, and deleting a form — which takes a published address off
-// the air — is admin-only.
+// the air — and exporting its submissions are admin-only.
 //
 // The submission-detail route is registered before the form-detail route
 // because both live one segment below /forms: gin resolves the static
@@ -19,15 +19,16 @@ import (
 // in this order.
 func SetupFormRoutes(router *gin.RouterGroup, h *FormHandler) {
 	group := router.Group("/forms")
-	group.Use(middleware.RequireRole(models.RoleAdmin, models.RoleSales, models.RoleSupport))
-	write := middleware.RequireRole(models.RoleAdmin, models.RoleSales)
+	group.Use(middleware.RequirePermission(models.PermFormsRead))
+	write := middleware.RequirePermission(models.PermFormsWrite)
 	{
 		group.GET("", h.List)
 		group.POST("", write, h.Create)
 		group.GET("/submissions/:id", h.GetSubmission)
 		group.GET("/:id", h.Get)
 		group.PUT("/:id", write, h.Update)
-		group.DELETE("/:id", middleware.RequireRole(models.RoleAdmin), h.Delete)
+		group.DELETE("/:id", middleware.RequirePermission(models.PermFormsDelete), h.Delete)
 		group.GET("/:id/submissions", h.ListSubmissions)
+		group.GET("/:id/submissions/export", middleware.RequirePermission(models.PermFormsExport), h.ExportSubmissions)
 	}
 }
@@ -0,0 +1,342 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/middleware"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// importUploadMaxBody caps an upload request: the file's own limit plus room
+// for the multipart envelope and the other form fields.
+const importUploadMaxBody = models.ImportMaxFileSize + 1<<20
+
+type ImportHandler struct {
+	importService service.ImportService
+}
+
+func NewImportHandler(importService service.ImportService) *ImportHandler {
+	return &ImportHandler{importService: importService}
+}
+
+// Upload godoc
+// @Summary Upload a CSV file to import
+// @Description First step of an import: upload a CSV file of leads or customers as multipart/form-data. The first row is the header and names every column. The file is stored as it is and nothing is imported yet; the response carries the columns, the first rows and a suggested mapping of the columns whose names match a field. Files are capped at 20 MB and 50,000 rows, and must be UTF-8.
+// @Tags imports
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param file formData file true "CSV file"
+// @Param entity_type formData string true "What the rows are" Enums(lead, customer)
+// @Param delimiter formData string false "Field separator: , (default), ; or tab"
+// @Success 201 {object} utils.APIResponse{data=models.Import} "File uploaded successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Missing or unreadable file, unknown entity type or delimiter"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - imports:run required"
+// @Failure 413 {object} utils.APIResponse{error=utils.APIError} "File exceeds 20 MB"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /imports [post]
+func (h *ImportHandler) Upload(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "ImportHandler.Upload")
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, importUploadMaxBody)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			logger.WithError(err).Warn("Import file exceeds the size limit")
+			utils.RespondError(c, http.StatusRequestEntityTooLarge, utils.ErrCodeValidation,
+				fmt.Sprintf("The file is larger than %d MB", models.ImportMaxFileSize>>20), nil)
+			return
+		}
+		logger.WithError(err).Warn("Import upload without a file")
+		utils.RespondBadRequest(c, "A CSV file is required in the file field")
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		logger.WithError(err).Error("Failed to open the uploaded file")
+		utils.RespondInternalError(c)
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		logger.WithError(err).Error("Failed to read the uploaded file")
+		utils.RespondInternalError(c)
+		return
+	}
+
+	imp, err := h.importService.Upload(c.GetUint("user_id"), c.PostForm("entity_type"), header.Filename,
+		content, c.PostForm("delimiter"))
+	if err != nil {
+		respondImportError(c, logger, err)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusCreated, imp)
+	utils.RespondSuccess(c, http.StatusCreated, imp)
+}
+
+// List godoc
+// @Summary List imports
+// @Description The caller's imports, or every import with imports:manage, newest first, each with the state of its bulk operation once started.
+// @Tags imports
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param offset query int false "Pagination offset" default(0)
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Success 200 {object} utils.APIResponse{data=object{imports=[]models.Import,total=int},meta=utils.APIMeta} "Imports retrieved successfully"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - imports:run required"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /imports [get]
+func (h *ImportHandler) List(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "ImportHandler.List")
+
+	offset, limit := utils.ParseOffsetLimit(c)
+	imports, total, err := h.importService.List(c.GetUint("user_id"), middleware.Permissions(c), offset, limit)
+	if err != nil {
+		respondImportError(c, logger, err)
+		return
+	}
+
+	meta := &utils.APIMeta{
+		RequestID:  c.GetString("request_id"),
+		Page:       (offset / limit) + 1,
+		PerPage:    limit,
+		Total:      total,
+		TotalPages: (total + int64(limit) - 1) / int64(limit),
+	}
+	responseData := gin.H{"imports": imports, "total": total}
+	utils.LogHandlerResponse(logger, http.StatusOK, responseData)
+	utils.RespondSuccessWithMeta(c, http.StatusOK, responseData, meta)
+}
+
+// Get godoc
+// @Summary Get an import
+// @Description One import the caller uploaded, or any import with imports:manage. Once started, its operation carries the progress: total_items, success_count and failure_count, and a status of pending, processing, completed, partial or failed.
+// @Tags imports
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Import ID"
+// @Success 200 {object} utils.APIResponse{data=models.Import} "Import retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid import ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - imports:run required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Import not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /imports/{id} [get]
+func (h *ImportHandler) Get(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "ImportHandler.Get")
+
+	id, ok := parseImportID(c)
+	if !ok {
+		return
+	}
+
+	imp, err := h.importService.Get(id, c.GetUint("user_id"), middleware.Permissions(c))
+	if err != nil {
+		respondImportError(c, logger, err)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, imp)
+	utils.RespondSuccess(c, http.StatusOK, imp)
+}
+
+// DryRun godoc
+// @Summary Dry-run an import
+// @Description Check every row of an uploaded file against a mapping of its columns to fields — a field name, or cf.<name> for a custom field — and report the rows that would fail, without writing anything. A mapping's values replace cell values before they are checked, to map a file's own status or classification labels to ours. first_name, last_name and email must be mapped, as must required custom fields. For a lead import owner_id is the owner of every lead: the caller's own id, which is also the default, or any active user for a caller with leads:assign, who must give one. Customer emails are also checked against each other and the existing customers. The errors list stops at 1,000; the counts cover every row.
+// @Tags imports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Import ID"
+// @Param request body models.ImportOptions true "Column mapping"
+// @Success 200 {object} utils.APIResponse{data=models.ImportDryRun} "Dry run finished"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid import ID or mapping"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - imports:run required, or another owner named without leads:assign"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Import not found"
+// @Failure 409 {object} utils.APIResponse{error=utils.APIError} "The import has already been started"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /imports/{id}/dry-run [post]
+func (h *ImportHandler) DryRun(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "ImportHandler.DryRun")
+
+	id, ok := parseImportID(c)
+	if !ok {
+		return
+	}
+	var options models.ImportOptions
+	if err := c.ShouldBindJSON(&options); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	result, err := h.importService.DryRun(id, c.GetUint("user_id"), middleware.Permissions(c), options)
+	if err != nil {
+		respondImportError(c, logger, err)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, result)
+	utils.RespondSuccess(c, http.StatusOK, result)
+}
+
+// Start godoc
+// @Summary Start an import
+// @Description Import the rows of an uploaded file with a mapping, as for a dry run, in the background. The response is immediate: poll GET /imports/{id} for the progress of its operation. Every row is created on its own, exactly as the create endpoint would, so a row that fails does not stop the others; the rows that failed can be downloaded from /imports/{id}/failed-rows. An import is started once.
+// @Tags imports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Import ID"
+// @Param request body models.ImportOptions true "Column mapping"
+// @Success 202 {object} utils.APIResponse{data=models.Import} "Import started"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid import ID or mapping"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - imports:run required, or another owner named without leads:assign"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Import not found"
+// @Failure 409 {object} utils.APIResponse{error=utils.APIError} "The import has already been started"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /imports/{id}/start [post]
+func (h *ImportHandler) Start(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "ImportHandler.Start")
+
+	id, ok := parseImportID(c)
+	if !ok {
+		return
+	}
+	var options models.ImportOptions
+	if err := c.ShouldBindJSON(&options); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	imp, err := h.importService.Start(id, c.GetUint("user_id"), middleware.Permissions(c), options)
+	if err != nil {
+		respondImportError(c, logger, err)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusAccepted, imp)
+	utils.RespondSuccess(c, http.StatusAccepted, imp)
+}
+
+// FailedRows godoc
+// @Summary Download the failed rows of an import
+// @Description The response is NOT the utils.APIResponse envelope: it is a CSV file, Content-Disposition attachment; filename=import-{id}-failed-rows.csv, written with the delimiter of the uploaded file. It has the file's own header and the rows the import could not create, as they were uploaded, followed by two columns: import_row, the row's number in the uploaded file, and import_error, what was wrong with it. Fix the rows and upload the file again to import them. Rows appear as the import processes them, so an import still running gives the failures so far.
+// @Tags imports
+// @Produce text/csv
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Import ID"
+// @Success 200 {file} file "CSV file of the failed rows"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid import ID, or the import has not been started"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - imports:run required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Import not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /imports/{id}/failed-rows [get]
+func (h *ImportHandler) FailedRows(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "ImportHandler.FailedRows")
+
+	id, ok := parseImportID(c)
+	if !ok {
+		return
+	}
+
+	imp, rows, err := h.importService.FailedRows(id, c.GetUint("user_id"), middleware.Permissions(c))
+	if err != nil {
+		respondImportError(c, logger, err)
+		return
+	}
+
+	// The cells go out exactly as they were uploaded, so that the file can be
+	// fixed and imported again; only the error, which is ours, is made safe
+	// for a spreadsheet.
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Comma, _ = utf8.DecodeRuneInString(imp.Delimiter)
+	header := append(append([]string{}, imp.Columns...), "import_row", "import_error")
+	if err := writer.Write(header); err != nil {
+		logger.WithError(err).Error("Failed to write the failed rows")
+		utils.RespondInternalError(c)
+		return
+	}
+	for _, row := range rows {
+		record := make([]string, len(imp.Columns), len(header))
+		copy(record, row.Values)
+		record = append(record, strconv.Itoa(row.Row), csvSafeField(row.Error))
+		if err := writer.Write(record); err != nil {
+			logger.WithError(err).Error("Failed to write the failed rows")
+			utils.RespondInternalError(c)
+			return
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		logger.WithError(err).Error("Failed to write the failed rows")
+		utils.RespondInternalError(c)
+		return
+	}
+
+	logger.WithField("rows", len(rows)).Info("Failed import rows downloaded")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=import-%d-failed-rows.csv", imp.ID))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// parseImportID reads the :id parameter, answering 400 when it is not one.
+func parseImportID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid import ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondImportError(c *gin.Context, logger *logrus.Entry, err error) {
+	switch {
+	case errors.Is(err, apperrors.ErrValidation):
+		logger.WithError(err).Warn("Invalid import request")
+		utils.RespondBadRequest(c, err.Error())
+	case errors.Is(err, apperrors.ErrForbidden):
+		logger.WithError(err).Warn("Import refused")
+		utils.RespondForbidden(c, err.Error())
+	case errors.Is(err, apperrors.ErrImportStarted):
+		logger.WithError(err).Warn("Import already started")
+		utils.RespondConflict(c, "The import has already been started")
+	case apperrors.IsNotFound(err):
+		logger.WithError(err).Warn("Import not found")
+		utils.RespondNotFound(c, "Import not found")
+	default:
+		logger.WithError(err).Error("Import operation failed")
+		utils.RespondInternalError(c)
+	}
+}
@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+var _ service.ImportService = (*mocks.ImportService)(nil)
+
+type ImportHandlerTestSuite struct {
+	suite.Suite
+	mockService *mocks.ImportService
+	handler     *ImportHandler
+}
+
+func (suite *ImportHandlerTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *ImportHandlerTestSuite) SetupTest() {
+	suite.mockService = new(mocks.ImportService)
+	suite.handler = NewImportHandler(suite.mockService)
+}
+
+func (suite *ImportHandlerTestSuite) TearDownTest() {
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+func (suite *ImportHandlerTestSuite) serve(role models.UserRole, userID uint, req *http.Request) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Set("user_role", string(role))
+		c.Next()
+	})
+	SetupImportRoutes(router.Group(""), suite.handler)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (suite *ImportHandlerTestSuite) do(role models.UserRole, userID uint, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	return suite.serve(role, userID, req)
+}
+
+// upload builds a multipart upload of content with the given form fields.
+func (suite *ImportHandlerTestSuite) upload(content []byte, fields map[string]string) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if content != nil {
+		part, err := writer.CreateFormFile("file", "leads.csv")
+		require.NoError(suite.T(), err)
+		_, err = part.Write(content)
+		require.NoError(suite.T(), err)
+	}
+	for name, value := range fields {
+		require.NoError(suite.T(), writer.WriteField(name, value))
+	}
+	require.NoError(suite.T(), writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/imports", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func (suite *ImportHandlerTestSuite) TestUpload() {
+	content := []byte("First Name,Last Name,Email\nAda,Lovelace,ada@example.com\n")
+	suite.mockService.On("Upload", uint(5), "lead", "leads.csv", content, ";").
+		Return(&models.Import{ID: 3, UserID: 5, EntityType: "lead", RowCount: 1,
+			Columns: []string{"First Name", "Last Name", "Email"}}, nil)
+
+	w := suite.serve(models.RoleSales, 5, suite.upload(content, map[string]string{"entity_type": "lead", "delimiter": ";"}))
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"row_count":1`)
+	assert.NotContains(suite.T(), w.Body.String(), "content", "the file itself is never sent back")
+}
+
+func (suite *ImportHandlerTestSuite) TestUpload_MissingFile() {
+	w := suite.serve(models.RoleSales, 5, suite.upload(nil, map[string]string{"entity_type": "lead"}))
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *ImportHandlerTestSuite) TestUpload_TooLarge() {
+	content := bytes.Repeat([]byte("x"), importUploadMaxBody)
+	w := suite.serve(models.RoleSales, 5, suite.upload(content, map[string]string{"entity_type": "lead"}))
+	assert.Equal(suite.T(), http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func (suite *ImportHandlerTestSuite) TestUpload_Invalid() {
+	suite.mockService.On("Upload", uint(5), "deal", "leads.csv", mock.Anything, "").
+		Return(nil, fmt.Errorf("\"deal\" cannot be imported: %w", apperrors.ErrValidation))
+	w := suite.serve(models.RoleSales, 5, suite.upload([]byte("a\n1\n"), map[string]string{"entity_type": "deal"}))
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "cannot be imported")
+}
+
+func (suite *ImportHandlerTestSuite) TestSupportRoleRefused() {
+	w := suite.do(models.RoleSupport, 9, http.MethodGet, "/imports", nil)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *ImportHandlerTestSuite) TestList() {
+	suite.mockService.On("List", uint(5), models.BuiltInPermissions(models.RoleSales), 20, 10).
+		Return([]models.Import{{ID: 3}, {ID: 2}}, int64(22), nil)
+
+	w := suite.do(models.RoleSales, 5, http.MethodGet, "/imports?offset=20&limit=10", nil)
+
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	var response struct {
+		Data struct {
+			Imports []models.Import `json:"imports"`
+			Total   int64           `json:"total"`
+		} `json:"data"`
+		Meta utils.APIMeta `json:"meta"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(suite.T(), response.Data.Imports, 2)
+	assert.Equal(suite.T(), 3, response.Meta.Page)
+	assert.Equal(suite.T(), int64(3), response.Meta.TotalPages)
+}
+
+func (suite *ImportHandlerTestSuite) TestGet_NotVisible() {
+	suite.mockService.On("Get", uint(3), uint(6), models.BuiltInPermissions(models.RoleSales)).
+		Return(nil, fmt.Errorf("import 3: %w", apperrors.ErrNotFound))
+	w := suite.do(models.RoleSales, 6, http.MethodGet, "/imports/3", nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *ImportHandlerTestSuite) TestGet_InvalidID() {
+	w := suite.do(models.RoleSales, 6, http.MethodGet, "/imports/abc", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *ImportHandlerTestSuite) TestDryRun() {
+	options := models.ImportOptions{Mapping: []models.ImportColumnMapping{
+		{Column: "Rating", Field: "classification", Values: map[string]string{"Hot": "hot_lead"}},
+	}}
+	suite.mockService.On("DryRun", uint(3), uint(5), models.BuiltInPermissions(models.RoleSales), options).
+		Return(&models.ImportDryRun{TotalRows: 2, ValidRows: 1, InvalidRows: 1,
+			Errors: []models.ImportRowError{{Row: 3, Column: "Email", Message: "email is required"}}}, nil)
+
+	w := suite.do(models.RoleSales, 5, http.MethodPost, "/imports/3/dry-run", options)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"invalid_rows":1`)
+	assert.Contains(suite.T(), w.Body.String(), "email is required")
+}
+
+func (suite *ImportHandlerTestSuite) TestDryRun_OtherOwner() {
+	suite.mockService.On("DryRun", uint(3), uint(5), models.BuiltInPermissions(models.RoleSales), mock.Anything).
+		Return(nil, fmt.Errorf("you can only import leads you own: %w", apperrors.ErrForbidden))
+	w := suite.do(models.RoleSales, 5, http.MethodPost, "/imports/3/dry-run", gin.H{"owner_id": 9})
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *ImportHandlerTestSuite) TestStart() {
+	operationID := uint(11)
+	suite.mockService.On("Start", uint(3), uint(5), models.BuiltInPermissions(models.RoleSales), mock.Anything).
+		Return(&models.Import{ID: 3, OperationID: &operationID,
+			Operation: &models.BulkOperation{Status: models.StatusPending, TotalItems: 2}}, nil)
+
+	w := suite.do(models.RoleSales, 5, http.MethodPost, "/imports/3/start", gin.H{"mapping": []gin.H{{"column": "Email", "field": "email"}}})
+
+	assert.Equal(suite.T(), http.StatusAccepted, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"operation_id":11`)
+}
+
+func (suite *ImportHandlerTestSuite) TestStart_AlreadyStarted() {
+	suite.mockService.On("Start", uint(3), uint(5), models.BuiltInPermissions(models.RoleSales), mock.Anything).
+		Return(nil, fmt.Errorf("import 3: %w", apperrors.ErrImportStarted))
+	w := suite.do(models.RoleSales, 5, http.MethodPost, "/imports/3/start", gin.H{})
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func (suite *ImportHandlerTestSuite) TestFailedRows() {
+	suite.mockService.On("FailedRows", uint(3), uint(5), models.BuiltInPermissions(models.RoleSales)).
+		Return(&models.Import{ID: 3, Delimiter: ";", Columns: []string{"Name", "Email"}},
+			[]models.ImportFailedRow{
+				{Row: 4, Values: []string{"=Ada", "not-an-email"}, Error: "Email: \"not-an-email\" is not a valid email address"},
+				{Row: 9, Values: []string{"Grace"}, Error: "=the row is short"},
+			}, nil)
+
+	w := suite.do(models.RoleSales, 5, http.MethodGet, "/imports/3/failed-rows", nil)
+
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), "attachment; filename=import-3-failed-rows.csv", w.Header().Get("Content-Disposition"))
+	reader := csv.NewReader(w.Body)
+	reader.Comma = ';'
+	records, err := reader.ReadAll()
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), [][]string{
+		{"Name", "Email", "import_row", "import_error"},
+		{"=Ada", "not-an-email", "4", "Email: \"not-an-email\" is not a valid email address"},
+		{"Grace", "", "9", "'=the row is short"},
+	}, records, "cells go back as uploaded; only the error is made spreadsheet-safe")
+}
+
+func (suite *ImportHandlerTestSuite) TestFailedRows_NotStarted() {
+	suite.mockService.On("FailedRows", uint(3), uint(5), models.BuiltInPermissions(models.RoleAdmin)).
+		Return(nil, nil, fmt.Errorf("import 3 has not been started: %w", apperrors.ErrValidation))
+	w := suite.do(models.RoleAdmin, 5, http.MethodGet, "/imports/3/failed-rows", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	body, _ := io.ReadAll(w.Body)
+	assert.Contains(suite.T(), string(body), "has not been started")
+}
+
+func TestImportHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(ImportHandlerTestSuite))
+}
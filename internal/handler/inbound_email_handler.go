@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type InboundEmailHandler struct {
+	auditTrail
+	inboundService service.InboundEmailService
+}
+
+func NewInboundEmailHandler(inboundService service.InboundEmailService) *InboundEmailHandler {
+	return &InboundEmailHandler{inboundService: inboundService}
+}
+
+// List godoc
+// @Summary List received emails
+// @Description One page of the email gateway's log, newest first: every message the support mailbox received and what became of it. Filter with status to review the quarantine. Sender, subject and body are only kept on quarantined messages; the others link to the ticket, customer or lead they became. Available to the admin and support roles.
+// @Tags inbound-emails
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param status query string false "Outcome" Enums(ticket_created, reply_added, lead_captured, quarantined, ignored)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Param limit query int false "Limit for pagination" default(20)
+// @Success 200 {object} utils.APIResponse{data=object{emails=[]models.InboundEmail,total=int}} "Received emails retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Unknown status"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin or support role required"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /inbound-emails [get]
+func (h *InboundEmailHandler) List(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "InboundEmailHandler.List")
+
+	offset, limit := utils.ParseOffsetLimit(c)
+	emails, total, err := h.inboundService.List(models.InboundEmailStatus(c.Query("status")), offset, limit)
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	meta := &utils.APIMeta{
+		RequestID:  c.GetString("request_id"),
+		Page:       (offset / limit) + 1,
+		PerPage:    limit,
+		Total:      total,
+		TotalPages: (total + int64(limit) - 1) / int64(limit),
+	}
+
+	responseData := gin.H{"emails": emails, "total": total}
+	utils.LogHandlerResponse(logger, http.StatusOK, responseData)
+	utils.RespondSuccessWithMeta(c, http.StatusOK, responseData, meta)
+}
+
+// Get godoc
+// @Summary Get a received email
+// @Description One entry of the email gateway's log. Available to the admin and support roles.
+// @Tags inbound-emails
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Inbound email ID"
+// @Success 200 {object} utils.APIResponse{data=models.InboundEmail} "Received email retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid inbound email ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin or support role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Inbound email not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /inbound-emails/{id} [get]
+func (h *InboundEmailHandler) Get(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "InboundEmailHandler.Get")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid inbound email ID")
+		return
+	}
+
+	email, err := h.inboundService.GetByID(uint(id))
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, email)
+	utils.RespondSuccess(c, http.StatusOK, email)
+}
+
+// Release godoc
+// @Summary Release a quarantined email
+// @Description Route a quarantined message again, typically after its sender was added as a customer: it opens a ticket, or is added to the ticket its subject names, or becomes a lead when the gateway captures unknown senders. The stored copy of the message is dropped once it lives on in that record. Available to the admin and support roles.
+// @Tags inbound-emails
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Inbound email ID"
+// @Success 200 {object} utils.APIResponse{data=models.InboundEmail} "Email released"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid inbound email ID, not quarantined, or the sender is still unknown"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin or support role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Inbound email not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /inbound-emails/{id}/release [post]
+func (h *InboundEmailHandler) Release(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "InboundEmailHandler.Release")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid inbound email ID")
+		return
+	}
+
+	before := h.auditLoad(func() (interface{}, error) {
+		email, err := h.inboundService.GetByID(uint(id))
+		if err != nil {
+			return nil, err
+		}
+		return auditedInboundEmail(email), nil
+	})
+	email, err := h.inboundService.Release(uint(id))
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityInboundEmail, email.ID, models.AuditActionUpdate, before, auditedInboundEmail(email))
+
+	utils.LogHandlerResponse(logger, http.StatusOK, email)
+	utils.RespondSuccess(c, http.StatusOK, email)
+}
+
+// Delete godoc
+// @Summary Delete a received email
+// @Description Discard an entry of the email gateway's log, and with a quarantined message the stored copy of it (admin role only). A redelivery of the same message is then handled as new.
+// @Tags inbound-emails
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Inbound email ID"
+// @Success 204 "Inbound email deleted"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid inbound email ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Inbound email not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /inbound-emails/{id} [delete]
+func (h *InboundEmailHandler) Delete(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "InboundEmailHandler.Delete")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid inbound email ID")
+		return
+	}
+
+	before := h.auditLoad(func() (interface{}, error) {
+		email, err := h.inboundService.GetByID(uint(id))
+		if err != nil {
+			return nil, err
+		}
+		return auditedInboundEmail(email), nil
+	})
+	if err := h.inboundService.Delete(uint(id)); err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityInboundEmail, uint(id), models.AuditActionDelete, before, nil)
+
+	utils.LogHandlerResponse(logger, http.StatusNoContent, nil)
+	c.Status(http.StatusNoContent)
+}
+
+// auditedInboundEmail is the part of a log entry the audit trail keeps. The
+// quarantined copy of a message is left out: the audit trail outlives the
+// entry, and must not keep a stranger's mail after it was discarded.
+func auditedInboundEmail(email *models.InboundEmail) *models.InboundEmail {
+	audited := *email
+	audited.FromAddress, audited.FromName, audited.Subject, audited.Body = "", "", "", ""
+	return &audited
+}
+
+func (h *InboundEmailHandler) respondError(c *gin.Context, logger *logrus.Entry, err error) {
+	switch {
+	case errors.Is(err, apperrors.ErrValidation):
+		logger.WithError(err).Warn("Invalid inbound email request")
+		utils.RespondBadRequest(c, err.Error())
+	case apperrors.IsNotFound(err):
+		logger.WithError(err).Warn("Inbound email not found")
+		utils.RespondNotFound(c, "Inbound email not found")
+	default:
+		logger.WithError(err).Error("Inbound email operation failed")
+		utils.RespondInternalError(c)
+	}
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+var _ service.InboundEmailService = (*mocks.InboundEmailService)(nil)
+
+type InboundEmailHandlerTestSuite struct {
+	suite.Suite
+	mockService *mocks.InboundEmailService
+	mockAudit   *mocks.AuditService
+	handler     *InboundEmailHandler
+}
+
+func (suite *InboundEmailHandlerTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *InboundEmailHandlerTestSuite) SetupTest() {
+	suite.mockService = new(mocks.InboundEmailService)
+	suite.mockAudit = new(mocks.AuditService)
+	suite.handler = NewInboundEmailHandler(suite.mockService)
+}
+
+func (suite *InboundEmailHandlerTestSuite) TearDownTest() {
+	suite.mockService.AssertExpectations(suite.T())
+	suite.mockAudit.AssertExpectations(suite.T())
+}
+
+func (suite *InboundEmailHandlerTestSuite) do(role models.UserRole, method, path string) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		c.Set("user_role", string(role))
+		c.Next()
+	})
+	SetupInboundEmailRoutes(router.Group(""), suite.handler)
+
+	req := httptest.NewRequest(method, path, nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (suite *InboundEmailHandlerTestSuite) TestList_FiltersByStatus() {
+	emails := []models.InboundEmail{{ID: 2, Status: models.InboundEmailQuarantined, FromAddress: "stranger@example.com"}}
+	suite.mockService.On("List", models.InboundEmailQuarantined, 0, 20).Return(emails, int64(1), nil)
+
+	w := suite.do(models.RoleSupport, http.MethodGet, "/inbound-emails?status=quarantined")
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var response utils.APIResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), int64(1), response.Meta.Total)
+}
+
+func (suite *InboundEmailHandlerTestSuite) TestList_UnknownStatus() {
+	suite.mockService.On("List", models.InboundEmailStatus("bounced"), 0, 20).
+		Return(nil, int64(0), fmt.Errorf("unknown status: %w", apperrors.ErrValidation))
+
+	w := suite.do(models.RoleAdmin, http.MethodGet, "/inbound-emails?status=bounced")
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *InboundEmailHandlerTestSuite) TestRoutes_AdminAndSupportOnly() {
+	for _, role := range []models.UserRole{models.RoleSales, models.RoleCustomer} {
+		w := suite.do(role, http.MethodGet, "/inbound-emails")
+		assert.Equal(suite.T(), http.StatusForbidden, w.Code, role)
+	}
+	w := suite.do(models.RoleSupport, http.MethodDelete, "/inbound-emails/2")
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code, "discarding mail is admin-only")
+}
+
+func (suite *InboundEmailHandlerTestSuite) TestGet_NotFound() {
+	suite.mockService.On("GetByID", uint(9)).Return(nil, fmt.Errorf("missing: %w", apperrors.ErrNotFound))
+
+	w := suite.do(models.RoleAdmin, http.MethodGet, "/inbound-emails/9")
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *InboundEmailHandlerTestSuite) TestRelease_AuditsWithoutTheMessage() {
+	suite.handler.SetAuditService(suite.mockAudit)
+	ticketID := uint(11)
+	suite.mockService.On("GetByID", uint(2)).Return(&models.InboundEmail{
+		ID: 2, Status: models.InboundEmailQuarantined, FromAddress: "agnes@example.com", Body: "Please",
+	}, nil)
+	suite.mockService.On("Release", uint(2)).Return(&models.InboundEmail{
+		ID: 2, Status: models.InboundEmailTicketCreated, TicketID: &ticketID,
+	}, nil)
+	suite.mockAudit.On("Record", mock.Anything, models.AuditEntityInboundEmail, uint(2), models.AuditActionUpdate,
+		mock.MatchedBy(func(before map[string]interface{}) bool {
+			_, hasBody := before["body"]
+			_, hasSender := before["from_address"]
+			return before["status"] == "quarantined" && !hasBody && !hasSender
+		}), mock.Anything).Return(nil)
+
+	w := suite.do(models.RoleSupport, http.MethodPost, "/inbound-emails/2/release")
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *InboundEmailHandlerTestSuite) TestRelease_SenderStillUnknown() {
+	suite.mockService.On("Release", uint(2)).Return(nil, fmt.Errorf("not a customer: %w", apperrors.ErrValidation))
+
+	w := suite.do(models.RoleAdmin, http.MethodPost, "/inbound-emails/2/release")
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *InboundEmailHandlerTestSuite) TestDelete() {
+	suite.mockService.On("Delete", uint(2)).Return(nil)
+
+	w := suite.do(models.RoleAdmin, http.MethodDelete, "/inbound-emails/2")
+
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+}
+
+func TestInboundEmailHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(InboundEmailHandlerTestSuite))
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSHandler publishes the public keys that verify our access tokens, so
+// that other services can verify them without sharing a secret.
+type JWKSHandler struct {
+	signingKeys service.SigningKeyService
+	// maxAge is how long clients may cache the key set, in seconds.
+	maxAge int
+}
+
+func NewJWKSHandler(signingKeys service.SigningKeyService, maxAge int) *JWKSHandler {
+	return &JWKSHandler{signingKeys: signingKeys, maxAge: maxAge}
+}
+
+// JWKS serves GET /.well-known/jwks.json, outside the API prefix and its
+// response envelope: the body is the bare JWK set of RFC 7517, empty while
+// access tokens are signed with JWT_SECRET. A key is listed from the moment
+// it is generated until it is retired.
+func (h *JWKSHandler) JWKS(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "JWKSHandler.JWKS")
+
+	jwks, err := h.signingKeys.JWKS()
+	if err != nil {
+		logger.WithError(err).Error("Failed to load signing keys")
+		utils.RespondInternalError(c)
+		return
+	}
+
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", h.maxAge))
+	utils.LogHandlerResponse(logger, http.StatusOK, gin.H{"keys": len(jwks.Keys)})
+	c.JSON(http.StatusOK, jwks)
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockSigningKeyService struct {
+	mock.Mock
+}
+
+func (m *MockSigningKeyService) Generate(algorithm string) (*models.SigningKey, error) {
+	args := m.Called(algorithm)
+	key, _ := args.Get(0).(*models.SigningKey)
+	return key, args.Error(1)
+}
+
+func (m *MockSigningKeyService) Activate(kid string) (*models.SigningKey, error) {
+	args := m.Called(kid)
+	key, _ := args.Get(0).(*models.SigningKey)
+	return key, args.Error(1)
+}
+
+func (m *MockSigningKeyService) Retire(kid string) (*models.SigningKey, error) {
+	args := m.Called(kid)
+	key, _ := args.Get(0).(*models.SigningKey)
+	return key, args.Error(1)
+}
+
+func (m *MockSigningKeyService) List() ([]models.SigningKey, error) {
+	args := m.Called()
+	keys, _ := args.Get(0).([]models.SigningKey)
+	return keys, args.Error(1)
+}
+
+func (m *MockSigningKeyService) JWKS() (*models.JWKS, error) {
+	args := m.Called()
+	jwks, _ := args.Get(0).(*models.JWKS)
+	return jwks, args.Error(1)
+}
+
+func (m *MockSigningKeyService) Sign(claims jwt.Claims) (string, error) {
+	args := m.Called(claims)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSigningKeyService) VerificationKey(token *jwt.Token) (interface{}, error) {
+	args := m.Called(token)
+	return args.Get(0), args.Error(1)
+}
+
+func (m *MockSigningKeyService) SecretAccepted() (bool, error) {
+	args := m.Called()
+	return args.Bool(0), args.Error(1)
+}
+
+func newJWKSTestRouter(signingKeys *MockSigningKeyService) *gin.Engine {
+	utils.InitLogger(&config.LoggingConfig{Level: "error", Format: "json"})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/.well-known/jwks.json", NewJWKSHandler(signingKeys, 60).JWKS)
+	return router
+}
+
+func TestJWKSHandler_ServesTheBareKeySet(t *testing.T) {
+	signingKeys := new(MockSigningKeyService)
+	signingKeys.On("JWKS").Return(&models.JWKS{Keys: []models.JWK{
+		{KeyType: "OKP", KeyID: "kid-1", Use: "sig", Algorithm: "EdDSA", Curve: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+	}}, nil)
+
+	w := httptest.NewRecorder()
+	newJWKSTestRouter(signingKeys).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "public, max-age=60", w.Header().Get("Cache-Control"))
+	var body map[string][]map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Len(t, body["keys"], 1, "no response envelope")
+	assert.Equal(t, map[string]string{"kty": "OKP", "kid": "kid-1", "use": "sig", "alg": "EdDSA",
+		"crv": "Ed25519", "x": "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}, body["keys"][0])
+}
+
+func TestJWKSHandler_EmptyWithoutKeys(t *testing.T) {
+	signingKeys := new(MockSigningKeyService)
+	signingKeys.On("JWKS").Return(&models.JWKS{Keys: []models.JWK{}}, nil)
+
+	w := httptest.NewRecorder()
+	newJWKSTestRouter(signingKeys).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"keys":[]}`, w.Body.String())
+}
+
+func TestJWKSHandler_StoreFailure(t *testing.T) {
+	signingKeys := new(MockSigningKeyService)
+	signingKeys.On("JWKS").Return(nil, errors.New("db down"))
+
+	w := httptest.NewRecorder()
+	newJWKSTestRouter(signingKeys).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
@@ -14,6 +14,7 @@ import (
 )
 
 type LabelHandler struct {
+	auditTrail
 	labelService service.LabelService
 }
 
@@ -95,6 +96,8 @@ func (h *LabelHandler) Create(c *gin.Context) {
 		return
 	}
 
+	h.recordAudit(c, models.AuditEntityLabel, label.ID, models.AuditActionCreate, nil, label)
+
 	utils.LogHandlerResponse(logger, http.StatusCreated, label)
 	utils.RespondSuccess(c, http.StatusCreated, label)
 }
@@ -139,6 +142,8 @@ func (h *LabelHandler) Update(c *gin.Context) {
 		return
 	}
 
+	before := h.auditState(label)
+
 	if req.Name != "" {
 		label.Name = req.Name
 	}
@@ -157,6 +162,8 @@ func (h *LabelHandler) Update(c *gin.Context) {
 		label = refreshed
 	}
 
+	h.recordAudit(c, models.AuditEntityLabel, label.ID, models.AuditActionUpdate, before, label)
+
 	utils.LogHandlerResponse(logger, http.StatusOK, label)
 	utils.RespondSuccess(c, http.StatusOK, label)
 }
@@ -186,11 +193,14 @@ func (h *LabelHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	before := h.auditLoad(func() (interface{}, error) { return h.labelService.GetByID(uint(id)) })
 	if err := h.labelService.Delete(uint(id)); err != nil {
 		h.respondError(c, logger, err)
 		return
 	}
 
+	h.recordAudit(c, models.AuditEntityLabel, uint(id), models.AuditActionDelete, before, nil)
+
 	utils.LogHandlerResponse(logger, http.StatusNoContent, nil)
 	c.Status(http.StatusNoContent)
 }
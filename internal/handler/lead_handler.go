@@ -7,6 +7,7 @@ import (
 	"time"
 
 	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/middleware"
 	"github.com/florinel-chis/gophercrm/internal/models"
 	"github.com/florinel-chis/gophercrm/internal/service"
 	"github.com/florinel-chis/gophercrm/internal/utils"
@@ -14,13 +15,49 @@ import (
 )
 
 type LeadHandler struct {
+	auditTrail
+	customFieldFilters
+	savedViews
 	leadService service.LeadService
+	duplicates  service.DuplicateService
 }
 
 func NewLeadHandler(leadService service.LeadService) *LeadHandler {
 	return &LeadHandler{leadService: leadService}
 }
 
+// duplicateWarningLimit is how many candidates a possible-duplicate warning
+// lists.
+const duplicateWarningLimit = 5
+
+// SetDuplicateService makes Create warn about possible duplicates of the lead
+// it created. Without one, Create does not look.
+func (h *LeadHandler) SetDuplicateService(duplicates service.DuplicateService) {
+	h.duplicates = duplicates
+}
+
+// duplicateWarnings returns a POSSIBLE_DUPLICATE warning listing the leads and
+// customers the new lead may duplicate, among those the caller can see. The
+// lead is already stored, so a failed lookup is logged rather than reported.
+func (h *LeadHandler) duplicateWarnings(c *gin.Context, lead *models.Lead) []utils.APIWarning {
+	if h.duplicates == nil {
+		return nil
+	}
+	candidates, err := h.duplicates.FindForLead(lead, scopedOwner(c, models.PermLeadsReadAny), duplicateWarningLimit)
+	if err != nil {
+		utils.Logger.WithError(err).WithField("lead_id", lead.ID).Warn("Failed to look for duplicate leads")
+		return nil
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	return []utils.APIWarning{{
+		Code:    "POSSIBLE_DUPLICATE",
+		Message: "This lead may duplicate an existing lead or customer",
+		Details: candidates,
+	}}
+}
+
 type CreateLeadRequest struct {
 	FirstName      string                    `json:"first_name" binding:"required"`
 	LastName       string                    `json:"last_name" binding:"required"`
@@ -34,6 +71,8 @@ type CreateLeadRequest struct {
 	ExternalID     string                    `json:"external_id,omitempty"`
 	Notes          string                    `json:"notes,omitempty"`
 	OwnerID        *uint                     `json:"owner_id,omitempty"`
+	AccountID      *uint                     `json:"account_id,omitempty"`
+	CustomFields   map[string]interface{}    `json:"custom_fields,omitempty"`
 	CreatedAt      *string                   `json:"created_at,omitempty"` // ISO8601 timestamp for import
 }
 
@@ -50,6 +89,9 @@ type UpdateLeadRequest struct {
 	ExternalID     string                   `json:"external_id,omitempty"`
 	Notes          string                   `json:"notes,omitempty"`
 	OwnerID        *uint                    `json:"owner_id,omitempty"`
+	AccountID      *uint                    `json:"account_id,omitempty"`
+	// CustomFields sets the fields it names; a null value clears one.
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
 }
 
 type ConvertLeadRequest struct {
@@ -61,15 +103,15 @@ type ConvertLeadRequest struct {
 
 // Create godoc
 // @Summary Create a new lead
-// @Description Create a new lead (sales and admin roles only)
+// @Description Create a new lead (sales and admin roles only). Without account_id the lead is linked to the account its company names, if one exists. A lead that may duplicate an existing lead or customer is still created, with a POSSIBLE_DUPLICATE warning in meta.warnings listing the candidates.
 // @Tags leads
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Security ApiKeyAuth
 // @Param request body CreateLeadRequest true "Lead creation request"
-// @Success 201 {object} utils.APIResponse{data=models.Lead} "Lead created successfully"
-// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid request data, or missing owner_id for admin users"
+// @Success 201 {object} utils.APIResponse{data=models.Lead,meta=utils.APIMeta} "Lead created successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid request data, missing owner_id for admin users, or an unknown account_id"
 // @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
 // @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - requires sales or admin role; sales users can only assign leads to themselves"
 // @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
@@ -85,7 +127,7 @@ func (h *LeadHandler) Create(c *gin.Context) {
 	}
 
 	currentUserID := c.GetUint("user_id")
-	currentUserRole := c.GetString("user_role")
+	canAssign := middleware.HasPermission(c, models.PermLeadsAssign)
 
 	lead := &models.Lead{
 		FirstName:      req.FirstName,
@@ -99,6 +141,8 @@ func (h *LeadHandler) Create(c *gin.Context) {
 		Classification: req.Classification,
 		ExternalID:     req.ExternalID,
 		Notes:          req.Notes,
+		AccountID:      req.AccountID,
+		CustomFields:   requestCustomFields(req.CustomFields),
 	}
 
 	// Set custom created_at for imports (preserves original submission date)
@@ -123,15 +167,15 @@ func (h *LeadHandler) Create(c *gin.Context) {
 
 	// Set owner - if not specified, assign to current user (for sales), or require for admin
 	if req.OwnerID != nil {
-		// Only admins can assign leads to other users
-		if currentUserRole != string(models.RoleAdmin) && *req.OwnerID != currentUserID {
+		// Only users who may assign leads can give them to other users
+		if !canAssign && *req.OwnerID != currentUserID {
 			utils.RespondForbidden(c, "You can only assign leads to yourself")
 			return
 		}
 		lead.OwnerID = *req.OwnerID
 	} else {
 		// Default to current user for sales, require explicit assignment for admin
-		if currentUserRole == string(models.RoleAdmin) {
+		if canAssign {
 			utils.RespondBadRequest(c, "Owner ID is required for admin users")
 			return
 		}
@@ -139,18 +183,25 @@ func (h *LeadHandler) Create(c *gin.Context) {
 	}
 
 	if err := h.leadService.Create(lead); err != nil {
+		if errors.Is(err, apperrors.ErrValidation) {
+			logger.WithError(err).Warn("Invalid lead")
+			utils.RespondBadRequest(c, err.Error())
+			return
+		}
 		logger.WithError(err).Error("Failed to create lead")
 		utils.RespondInternalError(c)
 		return
 	}
 
+	h.recordAudit(c, models.AuditEntityLead, lead.ID, models.AuditActionCreate, nil, lead)
+
 	utils.LogHandlerResponse(logger, http.StatusCreated, lead)
-	utils.RespondSuccess(c, http.StatusCreated, lead)
+	utils.RespondSuccessWithMeta(c, http.StatusCreated, lead, &utils.APIMeta{Warnings: h.duplicateWarnings(c, lead)})
 }
 
 // List godoc
 // @Summary List leads
-// @Description List leads (sales and admin roles only). Sales users see only leads they own, and the search, classification and sort parameters are ignored for them. Admins see all leads.
+// @Description List leads (sales and admin roles only). Sales users see only leads they own, and the search, classification and sort parameters are ignored for them unless the request also has a filter or filters or sorts by a custom field, in which case every parameter applies within their own leads. Admins see all leads.
 // @Tags leads
 // @Accept json
 // @Produce json
@@ -161,17 +212,27 @@ func (h *LeadHandler) Create(c *gin.Context) {
 // @Param limit query int false "Page size (max 100)" default(20)
 // @Param search query string false "Search across lead fields (admin listing only)"
 // @Param classification query string false "Filter by classification (admin listing only)" Enums(unclassified, test, spam, lead, hot_lead)
-// @Param sort_by query string false "Sort column (admin listing only)" Enums(created_at, updated_at, first_name, last_name, email, company, status, classification, source)
+// @Param sort_by query string false "Sort column (admin listing only), or cf.<name> to sort by a custom field" Enums(created_at, updated_at, first_name, last_name, email, company, status, classification, source)
 // @Param sort_order query string false "Sort direction" Enums(asc, desc) default(asc)
+// @Param cf.<name> query string false "Only leads whose custom field <name> holds this value; repeat to require several values"
+// @Param filter query string false "Structured filter, e.g. status eq qualified and source eq webinar and created_at gte -30d and owner_id eq 4. Fields: id, first_name, last_name, email, phone, company, position, source, status, classification, owner_id, account_id, customer_id, created_at, updated_at"
+// @Param view_id query int false "Run this saved view: its filter applies together with filter, and its sort unless sort_by is given"
+// @Param cursor query string false "Page by keyset instead of offset: empty for the first page, then the meta.next_cursor of the previous one, with the same sort_by and sort_order. Not combinable with a custom field sort"
 // @Success 200 {object} utils.APIResponse{meta=utils.APIMeta} "Leads list; data contains a leads array and a total count"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid filter, unknown custom field or invalid custom field value"
 // @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
 // @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - requires sales or admin role"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Saved view not found"
 // @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
 // @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
 // @Router /leads [get]
 func (h *LeadHandler) List(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "LeadHandler.List")
 
+	if !h.applySavedView(c, logger, models.AuditEntityLead) {
+		return
+	}
+
 	// Support both page-based and offset-based pagination
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
 	offset, limit := utils.ParseOffsetLimit(c)
@@ -183,22 +244,9 @@ func (h *LeadHandler) List(c *gin.Context) {
 	}
 
 	// Parse and validate sort parameters
-	sortBy := c.Query("sort_by")
-	sortOrder := c.DefaultQuery("sort_order", "asc")
-
-	allowedSortColumns := map[string]bool{
-		"created_at":     true,
-		"updated_at":     true,
-		"first_name":     true,
-		"last_name":      true,
-		"email":          true,
-		"company":        true,
-		"status":         true,
-		"classification": true,
-		"source":         true,
-	}
-
-	if !allowedSortColumns[sortBy] {
+	sortBy, sortOrder := listSort(c)
+
+	if !leadSortColumns[sortBy] {
 		sortBy = ""
 	}
 	if sortOrder != "asc" && sortOrder != "desc" {
@@ -208,21 +256,44 @@ func (h *LeadHandler) List(c *gin.Context) {
 	search := c.Query("search")
 
 	currentUserID := c.GetUint("user_id")
-	currentUserRole := c.GetString("user_role")
+	readAny := middleware.HasPermission(c, models.PermLeadsReadAny)
+
+	customFields, err := h.customFieldQuery(c, models.AuditEntityLead)
+	if err != nil {
+		respondCustomFieldQueryError(c, logger, err)
+		return
+	}
+	where, ok := parseListFilter(c, logger, "leads")
+	if !ok {
+		return
+	}
+	cursor, ok := parseListCursor(c, logger, "leads", sortBy, sortOrder, customFields)
+	if !ok {
+		return
+	}
+	fetchOffset, fetchLimit := cursorWindow(cursor, offset, limit)
 
 	var leads []models.Lead
 	var total int64
-	var err error
 
-	// Role-based filtering: only admin and sales users can see all leads
-	// Other roles can only see their own leads
-	isAdminOrSales := currentUserRole == string(models.RoleAdmin) || currentUserRole == string(models.RoleSales)
-
-	if currentUserRole == string(models.RoleSales) {
-		// For sales users, only show their own leads
-		leads, total, err = h.leadService.GetByOwner(currentUserID, offset, limit)
-	} else if !isAdminOrSales {
-		// Non-admin, non-sales users can only see their own leads
+	if !customFields.Empty() || where != nil || cursor != nil {
+		// Filters and custom field sorts combine with every other parameter,
+		// within the caller's own leads unless they may read any lead
+		filter := models.LeadFilter{
+			Search:         search,
+			Classification: models.LeadClassification(classification),
+			SortBy:         sortBy,
+			SortOrder:      sortOrder,
+			CustomFields:   customFields,
+			Where:          where,
+			Cursor:         cursor,
+		}
+		if !readAny {
+			filter.OwnerID = currentUserID
+		}
+		leads, total, err = h.leadService.ListFiltered(filter, fetchOffset, fetchLimit)
+	} else if !readAny {
+		// Users who may only read their own leads, such as sales, see their own
 		leads, total, err = h.leadService.GetByOwner(currentUserID, offset, limit)
 	} else if search != "" {
 		// Search across lead fields
@@ -243,6 +314,14 @@ func (h *LeadHandler) List(c *gin.Context) {
 		utils.RespondInternalError(c)
 		return
 	}
+	var next string
+	if cursor != nil {
+		if leads, next, err = cursorPage(cursor, leads, limit); err != nil {
+			logger.WithError(err).Error("Failed to encode the next cursor")
+			utils.RespondInternalError(c)
+			return
+		}
+	}
 
 	meta := &utils.APIMeta{
 		RequestID:  c.GetString("request_id"),
@@ -251,12 +330,128 @@ func (h *LeadHandler) List(c *gin.Context) {
 		Total:      total,
 		TotalPages: (total + int64(limit) - 1) / int64(limit),
 	}
+	if cursor != nil {
+		meta = cursorMeta(c, limit, total, next)
+	}
 
 	responseData := gin.H{"leads": leads, "total": total}
 	utils.LogHandlerResponse(logger, http.StatusOK, responseData)
 	utils.RespondSuccessWithMeta(c, http.StatusOK, responseData, meta)
 }
 
+// leadSortColumns is the sort allowlist shared by the list and export
+// endpoints.
+var leadSortColumns = map[string]bool{
+	"created_at":     true,
+	"updated_at":     true,
+	"first_name":     true,
+	"last_name":      true,
+	"email":          true,
+	"company":        true,
+	"status":         true,
+	"classification": true,
+	"source":         true,
+}
+
+// leadExportColumns are the columns of the lead export, in the order a
+// download without the columns parameter writes them.
+var leadExportColumns = []exportColumn[models.Lead]{
+	{"id", func(l *models.Lead) interface{} { return l.ID }},
+	{"first_name", func(l *models.Lead) interface{} { return l.FirstName }},
+	{"last_name", func(l *models.Lead) interface{} { return l.LastName }},
+	{"email", func(l *models.Lead) interface{} { return l.Email }},
+	{"phone", func(l *models.Lead) interface{} { return l.Phone }},
+	{"company", func(l *models.Lead) interface{} { return l.Company }},
+	{"position", func(l *models.Lead) interface{} { return l.Position }},
+	{"source", func(l *models.Lead) interface{} { return l.Source }},
+	{"status", func(l *models.Lead) interface{} { return string(l.Status) }},
+	{"classification", func(l *models.Lead) interface{} { return string(l.Classification) }},
+	{"external_id", func(l *models.Lead) interface{} { return l.ExternalID }},
+	{"notes", func(l *models.Lead) interface{} { return l.Notes }},
+	{"owner_id", func(l *models.Lead) interface{} { return l.OwnerID }},
+	{"account_id", func(l *models.Lead) interface{} { return exportOptional(l.AccountID) }},
+	{"customer_id", func(l *models.Lead) interface{} { return exportOptional(l.CustomerID) }},
+	{"created_at", func(l *models.Lead) interface{} { return l.CreatedAt }},
+	{"updated_at", func(l *models.Lead) interface{} { return l.UpdatedAt }},
+}
+
+// Export godoc
+// @Summary Export leads as CSV, XLSX or NDJSON
+// @Description Download every lead matching the list parameters as a file (admin role only: like the customer export, a single request egresses the personal data of every lead). There is no pagination: every matching row is included, streamed in batches. The response is the raw file, not the utils.APIResponse envelope; errors before the file starts are still reported through the envelope, and a failure after it has started drops the connection.
+// @Description
+// @Description Columns, in order: id, first_name, last_name, email, phone, company, position, source, status, classification, external_id, notes, owner_id, account_id, customer_id, created_at, updated_at, then one cf.<name> column per lead custom field. columns picks and orders a subset. Timestamps are RFC3339 and a null is an empty cell. In CSV and XLSX a text cell a spreadsheet would run as a formula is prefixed with an apostrophe; NDJSON keeps every value as it is, with its JSON type.
+// @Tags leads
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/x-ndjson
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param format query string false "File format" Enums(csv, xlsx, ndjson) default(csv)
+// @Param columns query string false "Comma-separated columns to write, in this order; all of them by default"
+// @Param search query string false "Search across lead fields"
+// @Param classification query string false "Only leads of this classification" Enums(unclassified, test, spam, lead, hot_lead)
+// @Param sort_by query string false "Sort column; ignored unless one of the allowed values" Enums(created_at, updated_at, first_name, last_name, email, company, status, classification, source)
+// @Param sort_order query string false "Sort direction" Enums(asc, desc) default(asc)
+// @Param cf.<name> query string false "Only leads whose custom field <name> holds this value"
+// @Param filter query string false "Structured filter, as on the list endpoint"
+// @Param view_id query int false "Export what this saved view shows"
+// @Success 200 {string} string "File of leads (raw body, not the API envelope)"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid format, column, filter or custom field, or a custom field sort"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Saved view not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /leads/export [get]
+func (h *LeadHandler) Export(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "LeadHandler.Export")
+
+	// Repeats the route's permission guard, as the customer export does.
+	if !middleware.HasPermission(c, models.PermLeadsExport) {
+		utils.RespondForbidden(c, "Only administrators can export leads")
+		return
+	}
+	if !h.applySavedView(c, logger, models.AuditEntityLead) {
+		return
+	}
+
+	sortBy, sortOrder := exportSort(c, leadSortColumns)
+	customFields, err := h.customFieldQuery(c, models.AuditEntityLead)
+	if err != nil {
+		respondCustomFieldQueryError(c, logger, err)
+		return
+	}
+	if customFields.Sort != nil {
+		utils.RespondBadRequest(c, "an export cannot be sorted by a custom field")
+		return
+	}
+	where, ok := parseListFilter(c, logger, "leads")
+	if !ok {
+		return
+	}
+	filter := models.LeadFilter{
+		Search:         c.Query("search"),
+		Classification: models.LeadClassification(c.Query("classification")),
+		SortBy:         sortBy,
+		SortOrder:      sortOrder,
+		CustomFields:   customFields,
+		Where:          where,
+	}
+
+	definitions, err := h.customFieldDefinitions(models.AuditEntityLead)
+	if err != nil {
+		logger.WithError(err).Error("Failed to load lead custom fields for export")
+		utils.RespondInternalError(c)
+		return
+	}
+	columns := append(append([]exportColumn[models.Lead]{}, leadExportColumns...),
+		customFieldColumns(definitions, func(l *models.Lead) map[string]interface{} { return l.CustomFields })...)
+
+	writeExport(c, logger, "leads", columns, func(fn func([]models.Lead) error) error {
+		return h.leadService.StreamExport(filter, fn)
+	})
+}
+
 // Get godoc
 // @Summary Get a lead
 // @Description Get a lead by ID (sales and admin roles only; sales users can only view their own leads)
@@ -291,9 +486,8 @@ func (h *LeadHandler) Get(c *gin.Context) {
 
 	// Permission check: sales users can only view their own leads
 	currentUserID := c.GetUint("user_id")
-	currentUserRole := c.GetString("user_role")
 	
-	if currentUserRole == string(models.RoleSales) && lead.OwnerID != currentUserID {
+	if !middleware.HasPermission(c, models.PermLeadsReadAny) && lead.OwnerID != currentUserID {
 		utils.RespondForbidden(c, "You can only view your own leads")
 		return
 	}
@@ -304,7 +498,7 @@ func (h *LeadHandler) Get(c *gin.Context) {
 
 // Update godoc
 // @Summary Update a lead
-// @Description Update a lead by ID (sales and admin roles only; sales users can only update their own leads, and only admins can reassign the owner)
+// @Description Update a lead by ID (sales and admin roles only; sales users can only update their own leads, and only admins can reassign the owner). account_id 0 unlinks the lead from its account.
 // @Tags leads
 // @Accept json
 // @Produce json
@@ -313,7 +507,7 @@ func (h *LeadHandler) Get(c *gin.Context) {
 // @Param id path int true "Lead ID"
 // @Param request body UpdateLeadRequest true "Lead update request; empty fields are left unchanged"
 // @Success 200 {object} utils.APIResponse{data=models.Lead} "Lead updated successfully"
-// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid lead ID or request data"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid lead ID or request data, or an unknown account_id"
 // @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
 // @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - sales users can only update their own leads; only admins can reassign owners"
 // @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Lead not found"
@@ -344,10 +538,9 @@ func (h *LeadHandler) Update(c *gin.Context) {
 	}
 
 	currentUserID := c.GetUint("user_id")
-	currentUserRole := c.GetString("user_role")
 	
 	// Permission check: sales users can only update their own leads
-	if currentUserRole == string(models.RoleSales) && lead.OwnerID != currentUserID {
+	if !middleware.HasPermission(c, models.PermLeadsUpdateAny) && lead.OwnerID != currentUserID {
 		utils.RespondForbidden(c, "You can only update your own leads")
 		return
 	}
@@ -390,20 +583,34 @@ func (h *LeadHandler) Update(c *gin.Context) {
 	
 	// Only admins can reassign leads
 	if req.OwnerID != nil {
-		if currentUserRole != string(models.RoleAdmin) {
+		if !middleware.HasPermission(c, models.PermLeadsAssign) {
 			utils.RespondForbidden(c, "Only administrators can reassign leads")
 			return
 		}
 		updates["owner_id"] = *req.OwnerID
 	}
+	if req.AccountID != nil {
+		updates["account_id"] = *req.AccountID
+	}
+	if req.CustomFields != nil {
+		updates["custom_fields"] = req.CustomFields
+	}
 
+	before := h.auditState(lead)
 	updatedLead, err := h.leadService.Update(uint(id), updates)
 	if err != nil {
+		if errors.Is(err, apperrors.ErrValidation) {
+			logger.WithError(err).Warn("Invalid lead update")
+			utils.RespondBadRequest(c, err.Error())
+			return
+		}
 		logger.WithError(err).Error("Failed to update lead")
 		utils.RespondInternalError(c)
 		return
 	}
 
+	h.recordAudit(c, models.AuditEntityLead, updatedLead.ID, models.AuditActionUpdate, before, updatedLead)
+
 	utils.LogHandlerResponse(logger, http.StatusOK, updatedLead)
 	utils.RespondSuccess(c, http.StatusOK, updatedLead)
 }
@@ -443,10 +650,9 @@ func (h *LeadHandler) Delete(c *gin.Context) {
 	}
 
 	currentUserID := c.GetUint("user_id")
-	currentUserRole := c.GetString("user_role")
 	
 	// Permission check: only admins or lead owners can delete
-	if currentUserRole != string(models.RoleAdmin) && lead.OwnerID != currentUserID {
+	if !middleware.HasPermission(c, models.PermLeadsDeleteAny) && lead.OwnerID != currentUserID {
 		utils.RespondForbidden(c, "You can only delete your own leads")
 		return
 	}
@@ -457,6 +663,10 @@ func (h *LeadHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	// Deleting a lead erases it; the delete event carries no state so it
+	// cannot quote the personal data the erasure just removed.
+	h.recordAudit(c, models.AuditEntityLead, uint(id), models.AuditActionDelete, nil, nil)
+
 	utils.LogHandlerResponse(logger, http.StatusNoContent, nil)
 	c.Status(http.StatusNoContent)
 }
@@ -503,10 +713,9 @@ func (h *LeadHandler) ConvertToCustomer(c *gin.Context) {
 	}
 
 	currentUserID := c.GetUint("user_id")
-	currentUserRole := c.GetString("user_role")
 	
 	// Permission check: only admins or lead owners can convert
-	if currentUserRole != string(models.RoleAdmin) && lead.OwnerID != currentUserID {
+	if !middleware.HasPermission(c, models.PermLeadsUpdateAny) && lead.OwnerID != currentUserID {
 		utils.RespondForbidden(c, "You can only convert your own leads")
 		return
 	}
@@ -522,6 +731,7 @@ func (h *LeadHandler) ConvertToCustomer(c *gin.Context) {
 		Notes:     req.Notes,
 	}
 
+	before := h.auditState(lead)
 	customer, err := h.leadService.ConvertToCustomer(uint(id), customerData)
 	if err != nil {
 		logger.WithError(err).Error("Failed to convert lead")
@@ -533,6 +743,12 @@ func (h *LeadHandler) ConvertToCustomer(c *gin.Context) {
 		return
 	}
 
+	// A conversion is two changes: the lead moves to converted and a
+	// customer is created from it.
+	h.recordAudit(c, models.AuditEntityLead, uint(id), models.AuditActionUpdate, before,
+		h.auditLoad(func() (interface{}, error) { return h.leadService.GetByID(uint(id)) }))
+	h.recordAudit(c, models.AuditEntityCustomer, customer.ID, models.AuditActionCreate, nil, customer)
+
 	utils.LogHandlerResponse(logger, http.StatusOK, customer)
 	utils.RespondSuccess(c, http.StatusOK, customer)
 }
\ No newline at end of file
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/florinel-chis/gophercrm/internal/config"
@@ -663,6 +664,113 @@ func (suite *LeadHandlerTestSuite) TestList_CustomerUserWithClassificationSeesOn
 	assert.Equal(suite.T(), http.StatusOK, rec.Code)
 }
 
+func (suite *LeadHandlerTestSuite) TestList_Filter() {
+	suite.router.GET("/leads", suite.handler.List)
+
+	suite.mockService.On("ListFiltered", mock.MatchedBy(func(f models.LeadFilter) bool {
+		return f.OwnerID == 0 && f.Where != nil && f.Where.Or && len(f.Where.Group) == 2
+	}), 0, 20).Return([]models.Lead{}, int64(0), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/leads?filter="+url.QueryEscape("status eq new or owner_id in (2, 3)"), nil)
+	rec := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(rec, req)
+
+	assert.Equal(suite.T(), http.StatusOK, rec.Code)
+}
+
+func (suite *LeadHandlerTestSuite) TestList_FilterKeepsOwnerScoping() {
+	suite.router.Use(func(c *gin.Context) {
+		c.Set("user_role", "sales")
+		c.Set("user_id", uint(3))
+	})
+	suite.router.GET("/leads", suite.handler.List)
+
+	suite.mockService.On("ListFiltered", mock.MatchedBy(func(f models.LeadFilter) bool {
+		return f.OwnerID == 3 && f.Where != nil
+	}), 0, 20).Return([]models.Lead{}, int64(0), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/leads?filter="+url.QueryEscape("owner_id eq 9"), nil)
+	rec := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(rec, req)
+
+	assert.Equal(suite.T(), http.StatusOK, rec.Code)
+}
+
+func (suite *LeadHandlerTestSuite) TestList_InvalidFilter() {
+	suite.router.GET("/leads", suite.handler.List)
+
+	req := httptest.NewRequest(http.MethodGet, "/leads?filter="+url.QueryEscape("notes contains secret"), nil)
+	rec := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(rec, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, rec.Code)
+	assert.Contains(suite.T(), rec.Body.String(), `unknown field \"notes\" for leads`)
+	suite.mockService.AssertNotCalled(suite.T(), "ListFiltered", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *LeadHandlerTestSuite) TestExport_NDJSONWithColumnsAndFilters() {
+	suite.router.GET("/leads/export", suite.handler.Export)
+
+	suite.mockService.On("StreamExport", mock.MatchedBy(func(f models.LeadFilter) bool {
+		return f.Search == "acme" && f.Classification == models.LeadClassificationHotLead &&
+			f.SortBy == "email" && f.SortOrder == "desc" && f.Where != nil && f.OwnerID == 0
+	}), mock.Anything).Run(func(args mock.Arguments) {
+		fn := args.Get(1).(func([]models.Lead) error)
+		_ = fn([]models.Lead{{BaseModel: models.BaseModel{ID: 4}, Email: "=ada@acme.com", Status: models.LeadStatusQualified}})
+	}).Return(nil)
+
+	query := url.Values{
+		"format":         {"ndjson"},
+		"columns":        {"email,id,account_id,status"},
+		"search":         {"acme"},
+		"classification": {"hot_lead"},
+		"sort_by":        {"email"},
+		"sort_order":     {"desc"},
+		"filter":         {"status eq qualified"},
+	}
+	req := httptest.NewRequest(http.MethodGet, "/leads/export?"+query.Encode(), nil)
+	rec := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(rec, req)
+
+	assert.Equal(suite.T(), http.StatusOK, rec.Code)
+	assert.Equal(suite.T(), "application/x-ndjson", rec.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), "attachment; filename=leads-export.ndjson", rec.Header().Get("Content-Disposition"))
+	// NDJSON is for programs: values keep their types and are not escaped.
+	assert.Equal(suite.T(), `{"email":"=ada@acme.com","id":4,"account_id":null,"status":"qualified"}`+"\n", rec.Body.String())
+}
+
+func (suite *LeadHandlerTestSuite) TestExport_InvalidFormatOrColumn() {
+	suite.router.GET("/leads/export", suite.handler.Export)
+
+	for _, query := range []string{"format=pdf", "columns=id,password"} {
+		req := httptest.NewRequest(http.MethodGet, "/leads/export?"+query, nil)
+		rec := httptest.NewRecorder()
+
+		suite.router.ServeHTTP(rec, req)
+
+		assert.Equal(suite.T(), http.StatusBadRequest, rec.Code, query)
+	}
+	suite.mockService.AssertNotCalled(suite.T(), "StreamExport", mock.Anything, mock.Anything)
+}
+
+func (suite *LeadHandlerTestSuite) TestExport_ForbiddenForSalesUser() {
+	req := httptest.NewRequest(http.MethodGet, "/leads/export", nil)
+	rec := httptest.NewRecorder()
+
+	ctx, _ := gin.CreateTestContext(rec)
+	ctx.Set("user_id", uint(2))
+	ctx.Set("user_role", "sales")
+	ctx.Request = req
+
+	suite.handler.Export(ctx)
+
+	assert.Equal(suite.T(), http.StatusForbidden, rec.Code)
+}
+
 func TestLeadHandlerTestSuite(t *testing.T) {
 	suite.Run(t, new(LeadHandlerTestSuite))
 }
\ No newline at end of file
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// listCursorParam is the query parameter carrying the keyset cursor of a list
+// request. Its presence pages the list by cursor instead of by offset; an
+// empty value asks for the first page.
+const listCursorParam = "cursor"
+
+// parseListCursor reads the cursor of a list request for table, a key of
+// utils.AllowedSortColumns, sorted by sortBy (empty for the default order,
+// which pages by id) as the handler resolved it. It returns nil for a request
+// paged by offset, and answers 400 and returns false for a cursor that cannot
+// be read or was returned for another sort, or one combined with a custom
+// field sort, which has no column to seek on.
+func parseListCursor(c *gin.Context, logger *logrus.Entry, table, sortBy, sortOrder string, customFields models.CustomFieldQuery) (*models.ListCursor, bool) {
+	raw, ok := c.GetQuery(listCursorParam)
+	if !ok {
+		return nil, true
+	}
+	if customFields.Sort != nil {
+		utils.RespondBadRequest(c, "cursor pagination cannot be combined with a custom field sort")
+		return nil, false
+	}
+	desc := sortOrder == "desc"
+	if raw == "" {
+		return &models.ListCursor{SortBy: sortBy, Desc: desc}, true
+	}
+	cursor, err := utils.DecodeCursor(table, raw, sortBy, desc)
+	if err != nil {
+		logger.WithError(err).Warn("Invalid list cursor")
+		utils.RespondBadRequest(c, err.Error())
+		return nil, false
+	}
+	return cursor, true
+}
+
+// cursorPage trims the limit+1 rows a keyset page is read with to the page,
+// and returns the cursor of the next page, empty when this is the last one.
+func cursorPage[T any](cursor *models.ListCursor, rows []T, limit int) ([]T, string, error) {
+	if len(rows) <= limit {
+		return rows, "", nil
+	}
+	rows = rows[:limit]
+	next, err := utils.EncodeCursor(cursor, &rows[limit-1])
+	return rows, next, err
+}
+
+// cursorMeta is the meta of a keyset page. A cursor has no page number, so
+// there is none, only the total of the list and the cursor of the next page.
+func cursorMeta(c *gin.Context, limit int, total int64, next string) *utils.APIMeta {
+	return &utils.APIMeta{
+		RequestID:  c.GetString("request_id"),
+		PerPage:    limit,
+		Total:      total,
+		NextCursor: next,
+	}
+}
+
+// cursorWindow returns the offset and limit a list is read with: those of the
+// request when it pages by offset, and for a keyset page no offset and one
+// row more than the page holds, which tells whether there is a next one.
+func cursorWindow(cursor *models.ListCursor, offset, limit int) (int, int) {
+	if cursor == nil {
+		return offset, limit
+	}
+	return 0, limit + 1
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// listFilterParam is the query parameter carrying the structured filter of a
+// list request; utils.ParseFilter documents its grammar.
+const listFilterParam = "filter"
+
+// parseListFilter reads the structured filter of a list request for entity, a
+// key of utils.AllowedFilterFields. When the request runs a saved view, the
+// view's filter and the request's own must both hold. It returns nil when
+// there is no filter, and answers 400 and returns false when one cannot be
+// parsed.
+func parseListFilter(c *gin.Context, logger *logrus.Entry, entity string) (*models.FilterExpr, bool) {
+	where, err := utils.ParseFilter(entity, c.Query(listFilterParam))
+	if err != nil {
+		logger.WithError(err).Warn("Invalid list filter")
+		utils.RespondBadRequest(c, err.Error())
+		return nil, false
+	}
+	if view := runningSavedView(c); view != nil {
+		// Checked when the view was saved; an error here means the filter
+		// grammar changed since.
+		viewWhere, err := utils.ParseFilter(entity, view.Filter)
+		if err != nil {
+			logger.WithError(err).Warn("Invalid saved view filter")
+			utils.RespondBadRequest(c, "saved view: "+err.Error())
+			return nil, false
+		}
+		switch {
+		case where == nil:
+			where = viewWhere
+		case viewWhere != nil:
+			where = &models.FilterExpr{Group: []models.FilterExpr{*viewWhere, *where}}
+		}
+	}
+	return where, true
+}
+
+// listSort reads the sort_by and sort_order of a list request, unchecked.
+// A request without a sort_by takes the sort of the saved view it runs, if
+// any; the order defaults to ascending.
+func listSort(c *gin.Context) (sortBy, sortOrder string) {
+	if sortBy = c.Query("sort_by"); sortBy == "" {
+		if view := runningSavedView(c); view != nil && view.SortBy != "" {
+			return view.SortBy, view.SortOrder
+		}
+	}
+	return sortBy, c.DefaultQuery("sort_order", "asc")
+}
@@ -0,0 +1,348 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/middleware"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type RoleHandler struct {
+	auditTrail
+	roleService service.RoleService
+}
+
+func NewRoleHandler(roleService service.RoleService) *RoleHandler {
+	return &RoleHandler{roleService: roleService}
+}
+
+// CreateRoleRequest is the body of POST /roles.
+type CreateRoleRequest struct {
+	Name        string              `json:"name" binding:"required,max=20"`
+	Description string              `json:"description" binding:"max=255"`
+	BaseRole    models.UserRole     `json:"base_role" binding:"required,oneof=admin sales support customer"`
+	Permissions []models.Permission `json:"permissions"`
+}
+
+// UpdateRoleRequest is the body of PUT /roles/:name. It replaces the role's
+// description, base role and permissions; the name cannot be changed.
+type UpdateRoleRequest struct {
+	Description string              `json:"description" binding:"max=255"`
+	BaseRole    models.UserRole     `json:"base_role" binding:"required,oneof=admin sales support customer"`
+	Permissions []models.Permission `json:"permissions"`
+}
+
+// AssignRoleRequest is the body of PUT /users/:id/role.
+type AssignRoleRequest struct {
+	Role string `json:"role" binding:"required,max=20"`
+}
+
+// ListPermissions godoc
+// @Summary List permissions
+// @Description Every permission a role can hold, with what it allows. Scoped permissions come in an own and an any variant: leads:read:own lets a role read the leads it owns, leads:read:any every lead, and the any scope includes the own one. Requires roles:manage.
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.APIResponse{data=[]models.PermissionDefinition} "Permissions retrieved successfully"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - roles:manage permission required"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Router /permissions [get]
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "RoleHandler.ListPermissions")
+
+	utils.LogHandlerResponse(logger, http.StatusOK, models.PermissionRegistry)
+	utils.RespondSuccess(c, http.StatusOK, models.PermissionRegistry)
+}
+
+// List godoc
+// @Summary List roles
+// @Description The built-in roles (admin, sales, support and customer), then the custom ones by name, each with its permissions. Requires roles:manage.
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.APIResponse{data=[]models.Role} "Roles retrieved successfully"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - roles:manage permission required"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /roles [get]
+func (h *RoleHandler) List(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "RoleHandler.List")
+
+	roles, err := h.roleService.List()
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, roles)
+	utils.RespondSuccess(c, http.StatusOK, roles)
+}
+
+// Get godoc
+// @Summary Get a role
+// @Description One role and its permissions. Requires roles:manage.
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param name path string true "Role name"
+// @Success 200 {object} utils.APIResponse{data=models.Role} "Role retrieved successfully"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - roles:manage permission required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Role not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /roles/{name} [get]
+func (h *RoleHandler) Get(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "RoleHandler.Get")
+
+	role, err := h.roleService.Get(c.Param("name"))
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, role)
+	utils.RespondSuccess(c, http.StatusOK, role)
+}
+
+// Create godoc
+// @Summary Create a role
+// @Description Define a custom role (requires roles:manage). The name is 2 to 20 lowercase letters, digits, dashes or underscores, starting with a letter, and cannot be one of the built-in roles. The permissions, from GET /permissions, decide which endpoints the role's users reach; the base role — one of admin, sales, support or customer — decides the record rules within them, such as a support user seeing only the tickets assigned to them. A caller cannot create a role with permissions they do not hold themselves, nor base it on a role whose permissions they do not hold.
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param request body CreateRoleRequest true "Role"
+// @Success 201 {object} utils.APIResponse{data=models.Role} "Role created successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid name, base role or permission, or a taken name"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - roles:manage permission required, or the role holds a permission the caller does not"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /roles [post]
+func (h *RoleHandler) Create(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "RoleHandler.Create")
+
+	var req CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	role := &models.Role{
+		Name:        req.Name,
+		Description: req.Description,
+		BaseRole:    req.BaseRole,
+		Permissions: req.Permissions,
+	}
+	// An invalid role is left for the service to refuse with a 400.
+	if role.ValidateRole() == nil && !canGrant(c, role.BaseRole, role.PermissionSet()) {
+		utils.RespondForbidden(c, "You cannot grant permissions you do not hold")
+		return
+	}
+	if err := h.roleService.Create(role); err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityRole, role.ID, models.AuditActionCreate, nil, role)
+
+	utils.LogHandlerResponse(logger, http.StatusCreated, role)
+	utils.RespondSuccess(c, http.StatusCreated, role)
+}
+
+// Update godoc
+// @Summary Update a role
+// @Description Replace the description, base role and permissions of a custom role (requires roles:manage). The name is fixed, and the built-in roles cannot be changed (409). The change applies to the role's users from their next request. A caller cannot give a role permissions they do not hold themselves, nor a base role whose permissions they do not hold.
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param name path string true "Role name"
+// @Param request body UpdateRoleRequest true "Role"
+// @Success 200 {object} utils.APIResponse{data=models.Role} "Role updated successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid base role or permission"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - roles:manage permission required, or the role holds a permission the caller does not"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Role not found"
+// @Failure 409 {object} utils.APIResponse{error=utils.APIError} "Built-in roles cannot be changed"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /roles/{name} [put]
+func (h *RoleHandler) Update(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "RoleHandler.Update")
+
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	role := &models.Role{
+		Name:        c.Param("name"),
+		Description: req.Description,
+		BaseRole:    req.BaseRole,
+		Permissions: req.Permissions,
+	}
+	// An invalid role is left for the service to refuse with a 400.
+	if role.ValidateRole() == nil && !canGrant(c, role.BaseRole, role.PermissionSet()) {
+		utils.RespondForbidden(c, "You cannot grant permissions you do not hold")
+		return
+	}
+
+	before := h.auditLoad(func() (interface{}, error) { return h.roleService.Get(role.Name) })
+	updated, err := h.roleService.Update(role)
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityRole, updated.ID, models.AuditActionUpdate, before, updated)
+
+	utils.LogHandlerResponse(logger, http.StatusOK, updated)
+	utils.RespondSuccess(c, http.StatusOK, updated)
+}
+
+// Delete godoc
+// @Summary Delete a role
+// @Description Delete a custom role (requires roles:manage). A role still held by a user, deactivated and erased users included, cannot be deleted (409): move its users to another role first. The built-in roles cannot be deleted (409).
+// @Tags roles
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param name path string true "Role name"
+// @Success 204 "Role deleted"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - roles:manage permission required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Role not found"
+// @Failure 409 {object} utils.APIResponse{error=utils.APIError} "Built-in role, or a role users still hold"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /roles/{name} [delete]
+func (h *RoleHandler) Delete(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "RoleHandler.Delete")
+
+	name := c.Param("name")
+	before, err := h.roleService.Get(name)
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+	if err := h.roleService.Delete(name); err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityRole, before.ID, models.AuditActionDelete, before, nil)
+
+	utils.LogHandlerResponse(logger, http.StatusNoContent, nil)
+	c.Status(http.StatusNoContent)
+}
+
+// AssignUser godoc
+// @Summary Assign a role to a user
+// @Description Give a user a built-in or custom role (requires roles:manage). It takes effect from the user's next request. A caller cannot hand out a role with permissions they do not hold themselves, and cannot change their own role.
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Param request body AssignRoleRequest true "Role"
+// @Success 200 {object} utils.APIResponse{data=models.User} "Role assigned successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid user ID, unknown role or attempt to change your own role"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - roles:manage permission required, or the role holds a permission the caller does not"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "User not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /users/{id}/role [put]
+func (h *RoleHandler) AssignUser(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "RoleHandler.AssignUser")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid user ID")
+		return
+	}
+	if uint(id) == c.GetUint("user_id") {
+		utils.RespondBadRequest(c, "You cannot change your own role")
+		return
+	}
+
+	var req AssignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	baseRole, permissions, err := h.roleService.Resolve(models.UserRole(req.Role))
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			utils.RespondBadRequest(c, "There is no role named "+strconv.Quote(req.Role))
+			return
+		}
+		h.respondError(c, logger, err)
+		return
+	}
+	if !canGrant(c, baseRole, permissions) {
+		utils.RespondForbidden(c, "You cannot grant a role with permissions you do not hold")
+		return
+	}
+
+	user, err := h.roleService.AssignUser(uint(id), req.Role)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			logger.WithError(err).Warn("User not found")
+			utils.RespondNotFound(c, "User not found")
+			return
+		}
+		h.respondError(c, logger, err)
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityUser, user.ID, models.AuditActionUpdate, nil, user)
+
+	utils.LogHandlerResponse(logger, http.StatusOK, user)
+	utils.RespondSuccess(c, http.StatusOK, user)
+}
+
+// canGrant reports whether the caller may hand out a role: they must hold its
+// permissions, and those of its base role, whose record rules its users get.
+func canGrant(c *gin.Context, baseRole models.UserRole, permissions models.PermissionSet) bool {
+	held := middleware.Permissions(c)
+	return held.Covers(permissions) && held.Covers(models.BuiltInPermissions(baseRole))
+}
+
+func (h *RoleHandler) respondError(c *gin.Context, logger *logrus.Entry, err error) {
+	switch {
+	case errors.Is(err, apperrors.ErrValidation):
+		logger.WithError(err).Warn("Invalid role request")
+		utils.RespondBadRequest(c, err.Error())
+	case apperrors.IsNotFound(err):
+		logger.WithError(err).Warn("Role not found")
+		utils.RespondNotFound(c, "Role not found")
+	case errors.Is(err, apperrors.ErrBuiltInRole):
+		logger.WithError(err).Warn("Attempt to change a built-in role")
+		utils.RespondConflict(c, "Built-in roles cannot be changed or deleted")
+	case errors.Is(err, apperrors.ErrRoleInUse):
+		logger.WithError(err).Warn("Attempt to delete a role in use")
+		utils.RespondConflict(c, "The role is still held by users")
+	default:
+		logger.WithError(err).Error("Role operation failed")
+		utils.RespondInternalError(c)
+	}
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var _ service.RoleService = (*mocks.RoleService)(nil)
+
+type RoleHandlerTestSuite struct {
+	suite.Suite
+	mockService *mocks.RoleService
+	mockAudit   *mocks.AuditService
+	handler     *RoleHandler
+}
+
+func (suite *RoleHandlerTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *RoleHandlerTestSuite) SetupTest() {
+	suite.mockService = new(mocks.RoleService)
+	suite.mockAudit = new(mocks.AuditService)
+	suite.handler = NewRoleHandler(suite.mockService)
+	suite.handler.SetAuditService(suite.mockAudit)
+}
+
+func (suite *RoleHandlerTestSuite) TearDownTest() {
+	suite.mockService.AssertExpectations(suite.T())
+	suite.mockAudit.AssertExpectations(suite.T())
+}
+
+// do sends a request as a user of a built-in role, or, given permissions, as
+// a user of a custom role holding them, the way LoadPermissions leaves it.
+func (suite *RoleHandlerTestSuite) do(role models.UserRole, permissions models.PermissionSet, method, path string, body interface{}) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		c.Set("user_role", string(role))
+		if permissions != nil {
+			c.Set("permissions", permissions)
+		}
+		c.Next()
+	})
+	SetupRoleRoutes(router.Group(""), suite.handler)
+
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (suite *RoleHandlerTestSuite) TestRoutes_RequireRolesManage() {
+	for _, route := range []struct{ method, path string }{
+		{http.MethodGet, "/permissions"},
+		{http.MethodGet, "/roles"},
+		{http.MethodPost, "/roles"},
+		{http.MethodDelete, "/roles/auditor"},
+		{http.MethodPut, "/users/3/role"},
+	} {
+		w := suite.do(models.RoleSales, nil, route.method, route.path, nil)
+		assert.Equal(suite.T(), http.StatusForbidden, w.Code, "%s %s", route.method, route.path)
+	}
+}
+
+func (suite *RoleHandlerTestSuite) TestListPermissions() {
+	w := suite.do(models.RoleAdmin, nil, http.MethodGet, "/permissions", nil)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"name":"leads:read:own"`)
+	assert.Contains(suite.T(), w.Body.String(), `"name":"roles:manage"`)
+}
+
+func (suite *RoleHandlerTestSuite) TestCreate_Success() {
+	suite.mockService.On("Create", mock.MatchedBy(func(r *models.Role) bool {
+		return r.Name == "auditor" && r.BaseRole == models.RoleSupport && len(r.Permissions) == 2
+	})).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Role).ID = 5
+	})
+	suite.mockAudit.On("Record", mock.Anything, models.AuditEntityRole, uint(5), models.AuditActionCreate,
+		nil, mock.Anything).Return(nil)
+
+	w := suite.do(models.RoleAdmin, nil, http.MethodPost, "/roles", gin.H{
+		"name": "auditor", "base_role": "support", "permissions": []string{"audit:read", "tickets:export"},
+	})
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+}
+
+func (suite *RoleHandlerTestSuite) TestCreate_InvalidRole() {
+	suite.mockService.On("Create", mock.Anything).
+		Return(fmt.Errorf("%w: unknown permission %q: %w", models.ErrInvalidRole, "leads:fly", apperrors.ErrValidation))
+
+	w := suite.do(models.RoleAdmin, nil, http.MethodPost, "/roles", gin.H{
+		"name": "auditor", "base_role": "support", "permissions": []string{"leads:fly"},
+	})
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "unknown permission")
+}
+
+func (suite *RoleHandlerTestSuite) TestCreate_CannotGrantMoreThanHeld() {
+	manager := models.NewPermissionSet(models.PermRolesManage, models.PermAuditRead)
+
+	w := suite.do(models.RoleSupport, manager, http.MethodPost, "/roles", gin.H{
+		"name": "exporter", "base_role": "customer", "permissions": []string{"users:export"},
+	})
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code, "a permission the caller lacks")
+
+	w = suite.do(models.RoleSupport, manager, http.MethodPost, "/roles", gin.H{
+		"name": "auditor", "base_role": "admin", "permissions": []string{"audit:read"},
+	})
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code, "a base role whose permissions the caller lacks")
+}
+
+func (suite *RoleHandlerTestSuite) TestUpdate_BuiltInRole() {
+	suite.mockService.On("Get", "sales").Return(&models.Role{ID: 2, Name: "sales", BuiltIn: true}, nil)
+	suite.mockService.On("Update", mock.Anything).Return(nil, fmt.Errorf("role %q: %w", "sales", apperrors.ErrBuiltInRole))
+
+	w := suite.do(models.RoleAdmin, nil, http.MethodPut, "/roles/sales", gin.H{"base_role": "sales"})
+
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func (suite *RoleHandlerTestSuite) TestDelete() {
+	suite.mockService.On("Get", "auditor").Return(&models.Role{ID: 5, Name: "auditor"}, nil)
+	suite.mockService.On("Delete", "auditor").Return(fmt.Errorf("role %q: %w", "auditor", apperrors.ErrRoleInUse)).Once()
+
+	w := suite.do(models.RoleAdmin, nil, http.MethodDelete, "/roles/auditor", nil)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "still held by users")
+
+	suite.mockService.On("Delete", "auditor").Return(nil).Once()
+	suite.mockAudit.On("Record", mock.Anything, models.AuditEntityRole, uint(5), models.AuditActionDelete,
+		mock.Anything, nil).Return(nil)
+
+	w = suite.do(models.RoleAdmin, nil, http.MethodDelete, "/roles/auditor", nil)
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+}
+
+func (suite *RoleHandlerTestSuite) TestAssignUser() {
+	suite.mockService.On("Resolve", models.UserRole("auditor")).
+		Return(models.RoleSupport, models.NewPermissionSet(models.PermAuditRead), nil)
+	suite.mockService.On("AssignUser", uint(3), "auditor").
+		Return(&models.User{BaseModel: models.BaseModel{ID: 3}, Role: "auditor"}, nil)
+	suite.mockAudit.On("Record", mock.Anything, models.AuditEntityUser, uint(3), models.AuditActionUpdate,
+		nil, mock.Anything).Return(nil)
+
+	w := suite.do(models.RoleAdmin, nil, http.MethodPut, "/users/3/role", gin.H{"role": "auditor"})
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"role":"auditor"`)
+}
+
+func (suite *RoleHandlerTestSuite) TestAssignUser_UnknownRole() {
+	suite.mockService.On("Resolve", models.UserRole("ghost")).
+		Return(models.UserRole(""), nil, fmt.Errorf("role %q: %w", "ghost", apperrors.ErrNotFound))
+
+	w := suite.do(models.RoleAdmin, nil, http.MethodPut, "/users/3/role", gin.H{"role": "ghost"})
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "There is no role named")
+}
+
+func (suite *RoleHandlerTestSuite) TestAssignUser_OwnRole() {
+	w := suite.do(models.RoleAdmin, nil, http.MethodPut, "/users/1/role", gin.H{"role": "sales"})
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func TestRoleHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(RoleHandlerTestSuite))
+}
@@ -27,8 +27,24 @@ func TestAllRouteSetupsCoexist(t *testing.T) {
 	SetupAPIKeyRoutes(group, &APIKeyHandler{})
 	SetupConfigurationRoutes(group, &ConfigurationHandler{})
 	SetupDashboardRoutes(group, &DashboardHandler{})
+	// Mounted on /dashboard/sla from outside the dashboard group.
+	SetupSLARoutes(group, &SLAHandler{})
+	SetupInboundEmailRoutes(group, &InboundEmailHandler{})
+	SetupWebhookRoutes(group, &WebhookHandler{})
+	// Mounts /deals, /pipeline-stages and two /dashboard/deals reports.
+	SetupDealRoutes(group, &DealHandler{})
+	SetupAccountRoutes(group, &AccountHandler{})
+	SetupDuplicateRoutes(group, &DuplicateHandler{})
+	SetupCustomFieldRoutes(group, &CustomFieldHandler{})
+	SetupSearchRoutes(group, &SearchHandler{})
+	SetupSavedViewRoutes(group, &SavedViewHandler{})
+	SetupImportRoutes(group, &ImportHandler{})
 	SetupBulkStatusRoutes(group, &BulkHandler{})
+	SetupBulkRoutes(group, &BulkHandler{})
+	SetupBulkOperationRoutes(group, &BulkHandler{})
 	SetupAEORoutes(group, &AEOHandler{})
+	// PUT /users/:id/role sits beside the user group's PUT /users/:id.
+	SetupRoleRoutes(group, &RoleHandler{})
 	SetupFormRoutes(group, &FormHandler{})
 	// The forms module registers two groups on the same mount point from two
 	// different files: the CRM routes above and the unauthenticated ones here.
@@ -46,6 +62,10 @@ func TestAllRouteSetupsCoexist(t *testing.T) {
 		"/api/v1/leads/bulk/status",
 		"/api/v1/tickets/bulk/status",
 		"/api/v1/tasks/bulk/status",
+		// Static merge segments registered next to /leads/:id and
+		// /customers/:id.
+		"/api/v1/leads/merge",
+		"/api/v1/customers/merge",
 		"/api/v1/labels",
 		// Static segment registered next to /aeo/prompts/:id.
 		"/api/v1/aeo/prompts/generate",
@@ -88,6 +108,19 @@ func TestAllRouteSetupsCoexist(t *testing.T) {
 		{http.MethodGet, "/api/v1/aeo/dashboard"},
 		{http.MethodGet, "/api/v1/aeo/citations"},
 		{http.MethodGet, "/api/v1/aeo/providers"},
+		{http.MethodGet, "/api/v1/dashboard/sla/by-priority"},
+		{http.MethodPut, "/api/v1/sla-policies/high"},
+		// Static /webhooks/events next to /webhooks/:id.
+		{http.MethodGet, "/api/v1/webhooks/events"},
+		{http.MethodPost, "/api/v1/webhooks/1/deliveries/2/redeliver"},
+		{http.MethodPut, "/api/v1/deals/1/stage"},
+		{http.MethodGet, "/api/v1/deals/1/stage-history"},
+		{http.MethodGet, "/api/v1/dashboard/deals/forecast"},
+		{http.MethodDelete, "/api/v1/pipeline-stages/1"},
+		{http.MethodGet, "/api/v1/accounts/1/summary"},
+		{http.MethodDelete, "/api/v1/accounts/1"},
+		{http.MethodGet, "/api/v1/leads/1/duplicates"},
+		{http.MethodPut, "/api/v1/custom-fields/1"},
 		{http.MethodGet, "/api/v1/aeo/profile"},
 		{http.MethodPut, "/api/v1/aeo/profile"},
 		// Forms: the public key parameter and the CRM id parameter share a
@@ -101,6 +134,24 @@ func TestAllRouteSetupsCoexist(t *testing.T) {
 		{http.MethodDelete, "/api/v1/forms/123"},
 		{http.MethodGet, "/api/v1/forms/123/submissions"},
 		{http.MethodGet, "/api/v1/forms/submissions/5"},
+		{http.MethodGet, "/api/v1/forms/123/submissions/export"},
+		// Static /export next to /:id on every exported list.
+		{http.MethodGet, "/api/v1/users/export"},
+		{http.MethodGet, "/api/v1/leads/export"},
+		{http.MethodGet, "/api/v1/tickets/export"},
+		{http.MethodGet, "/api/v1/tasks/export"},
+		{http.MethodPost, "/api/v1/imports/1/dry-run"},
+		// Static /bulk/users next to /bulk/:resource, on every verb.
+		{http.MethodPost, "/api/v1/bulk/users/create"},
+		{http.MethodPut, "/api/v1/bulk/users/update"},
+		{http.MethodDelete, "/api/v1/bulk/users/delete"},
+		{http.MethodPost, "/api/v1/bulk/leads/action"},
+		{http.MethodPut, "/api/v1/bulk/tasks/update"},
+		{http.MethodGet, "/api/v1/bulk-operations"},
+		{http.MethodGet, "/api/v1/bulk-operations/1"},
+		{http.MethodPost, "/api/v1/bulk-operations/1/cancel"},
+		{http.MethodPost, "/api/v1/bulk-operations/1/revert"},
+		{http.MethodGet, "/api/v1/imports/1/failed-rows"},
 	} {
 		req := httptest.NewRequest(route.method, route.path, nil)
 		w := httptest.NewRecorder()
@@ -0,0 +1,328 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/middleware"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// savedViewParam is the list query parameter that runs a saved view.
+const savedViewParam = "view_id"
+
+// savedViewContextKey holds the saved view a list request runs, for
+// parseListFilter and listSort.
+const savedViewContextKey = "saved_view"
+
+type SavedViewHandler struct {
+	savedViewService service.SavedViewService
+}
+
+func NewSavedViewHandler(savedViewService service.SavedViewService) *SavedViewHandler {
+	return &SavedViewHandler{savedViewService: savedViewService}
+}
+
+// CreateSavedViewRequest is the body of POST /saved-views.
+type CreateSavedViewRequest struct {
+	EntityType string `json:"entity_type" binding:"required,oneof=lead customer ticket task"`
+	Name       string `json:"name" binding:"required,max=100"`
+	// Filter is a structured filter in the grammar of the list's ?filter=.
+	Filter    string `json:"filter,omitempty"`
+	SortBy    string `json:"sort_by,omitempty" binding:"omitempty,max=100"`
+	SortOrder string `json:"sort_order,omitempty" binding:"omitempty,oneof=asc desc"`
+	// Columns are the columns a client shows, in order; the API stores them
+	// for the client and does not interpret them.
+	Columns        []string        `json:"columns,omitempty"`
+	SharedWithRole models.UserRole `json:"shared_with_role,omitempty" binding:"omitempty,oneof=admin sales support customer"`
+}
+
+// UpdateSavedViewRequest is the body of PUT /saved-views/:id. Omitted fields
+// are left alone; an empty shared_with_role makes the view private again.
+type UpdateSavedViewRequest struct {
+	Name           *string          `json:"name,omitempty" binding:"omitempty,max=100"`
+	Filter         *string          `json:"filter,omitempty"`
+	SortBy         *string          `json:"sort_by,omitempty" binding:"omitempty,max=100"`
+	SortOrder      *string          `json:"sort_order,omitempty" binding:"omitempty,oneof=asc desc"`
+	Columns        []string         `json:"columns,omitempty"`
+	SharedWithRole *models.UserRole `json:"shared_with_role,omitempty"`
+}
+
+// List godoc
+// @Summary List saved views
+// @Description The caller's own saved views and those shared with their role, of one entity type or of all of them, ordered by entity type and name.
+// @Tags saved-views
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param entity_type query string false "Only the views of this entity type" Enums(lead, customer, ticket, task)
+// @Success 200 {object} utils.APIResponse{data=[]models.SavedView} "Saved views retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Unknown entity type"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /saved-views [get]
+func (h *SavedViewHandler) List(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "SavedViewHandler.List")
+
+	views, err := h.savedViewService.List(c.Query("entity_type"), c.GetUint("user_id"), models.UserRole(c.GetString("user_role")))
+	if err != nil {
+		respondSavedViewError(c, logger, err)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, views)
+	utils.RespondSuccess(c, http.StatusOK, views)
+}
+
+// Get godoc
+// @Summary Get a saved view
+// @Description One saved view the caller owns, that is shared with their role, or any view with saved_views:manage.
+// @Tags saved-views
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Saved view ID"
+// @Success 200 {object} utils.APIResponse{data=models.SavedView} "Saved view retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid saved view ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Saved view not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /saved-views/{id} [get]
+func (h *SavedViewHandler) Get(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "SavedViewHandler.Get")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid saved view ID")
+		return
+	}
+
+	view, err := h.savedViewService.Get(uint(id), c.GetUint("user_id"), models.UserRole(c.GetString("user_role")), middleware.Permissions(c))
+	if err != nil {
+		respondSavedViewError(c, logger, err)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, view)
+	utils.RespondSuccess(c, http.StatusOK, view)
+}
+
+// Create godoc
+// @Summary Create a saved view
+// @Description Save a preset of the lead, customer, ticket or task list, owned by the caller. The filter is checked against the list's filter grammar and the sort against its sortable columns (or cf.<name> for a custom field). A view shared with a role is visible to, and runnable by, every user of that role; anyone may share with their own role, saved_views:manage with any role. Names are unique among the caller's views of an entity type.
+// @Tags saved-views
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param request body CreateSavedViewRequest true "Saved view"
+// @Success 201 {object} utils.APIResponse{data=models.SavedView} "Saved view created successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid view, taken name or role the caller cannot share with"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /saved-views [post]
+func (h *SavedViewHandler) Create(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "SavedViewHandler.Create")
+
+	var req CreateSavedViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	view := &models.SavedView{
+		OwnerID:        c.GetUint("user_id"),
+		EntityType:     req.EntityType,
+		Name:           req.Name,
+		Filter:         req.Filter,
+		SortBy:         req.SortBy,
+		SortOrder:      req.SortOrder,
+		Columns:        req.Columns,
+		SharedWithRole: req.SharedWithRole,
+	}
+	if err := h.savedViewService.Create(view, models.UserRole(c.GetString("user_role")), middleware.Permissions(c)); err != nil {
+		respondSavedViewError(c, logger, err)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusCreated, view)
+	utils.RespondSuccess(c, http.StatusCreated, view)
+}
+
+// Update godoc
+// @Summary Update a saved view
+// @Description Change a saved view. Only its owner and holders of saved_views:manage can; other users it is shared with get 403. The entity type is fixed. A view keeps the role a manager shared it with when its owner edits it.
+// @Tags saved-views
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Saved view ID"
+// @Param request body UpdateSavedViewRequest true "Saved view changes"
+// @Success 200 {object} utils.APIResponse{data=models.SavedView} "Saved view updated successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid saved view ID or view"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - not the owner of the view"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Saved view not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /saved-views/{id} [put]
+func (h *SavedViewHandler) Update(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "SavedViewHandler.Update")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid saved view ID")
+		return
+	}
+
+	var req UpdateSavedViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	role := models.UserRole(c.GetString("user_role"))
+	permissions := middleware.Permissions(c)
+	view, err := h.savedViewService.Get(uint(id), userID, role, permissions)
+	if err != nil {
+		respondSavedViewError(c, logger, err)
+		return
+	}
+
+	if req.Name != nil {
+		view.Name = *req.Name
+	}
+	if req.Filter != nil {
+		view.Filter = *req.Filter
+	}
+	if req.SortBy != nil {
+		view.SortBy = *req.SortBy
+	}
+	if req.SortOrder != nil {
+		view.SortOrder = *req.SortOrder
+	}
+	if req.Columns != nil {
+		view.Columns = req.Columns
+	}
+	if req.SharedWithRole != nil {
+		view.SharedWithRole = *req.SharedWithRole
+	}
+
+	view, err = h.savedViewService.Update(view, userID, role, permissions)
+	if err != nil {
+		respondSavedViewError(c, logger, err)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, view)
+	utils.RespondSuccess(c, http.StatusOK, view)
+}
+
+// Delete godoc
+// @Summary Delete a saved view
+// @Description Delete a saved view. Only its owner and holders of saved_views:manage can; other users it is shared with get 403.
+// @Tags saved-views
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Saved view ID"
+// @Success 204 "Saved view deleted"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid saved view ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - not the owner of the view"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Saved view not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /saved-views/{id} [delete]
+func (h *SavedViewHandler) Delete(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "SavedViewHandler.Delete")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid saved view ID")
+		return
+	}
+
+	if err := h.savedViewService.Delete(uint(id), c.GetUint("user_id"), models.UserRole(c.GetString("user_role")), middleware.Permissions(c)); err != nil {
+		respondSavedViewError(c, logger, err)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusNoContent, nil)
+	c.Status(http.StatusNoContent)
+}
+
+func respondSavedViewError(c *gin.Context, logger *logrus.Entry, err error) {
+	switch {
+	case errors.Is(err, apperrors.ErrValidation):
+		logger.WithError(err).Warn("Invalid saved view request")
+		utils.RespondBadRequest(c, err.Error())
+	case errors.Is(err, apperrors.ErrForbidden):
+		logger.WithError(err).Warn("Saved view change refused")
+		utils.RespondForbidden(c, err.Error())
+	case apperrors.IsNotFound(err):
+		logger.WithError(err).Warn("Saved view not found")
+		utils.RespondNotFound(c, "Saved view not found")
+	default:
+		logger.WithError(err).Error("Saved view operation failed")
+		utils.RespondInternalError(c)
+	}
+}
+
+// savedViews is embedded by the handlers of the lists that have saved views,
+// and runs the view a list request names with ?view_id=. Like
+// customFieldFilters it is optional: without the service the parameter is
+// ignored and the lists behave as before.
+type savedViews struct {
+	savedViewService service.SavedViewService
+}
+
+// SetSavedViewService wires saved views into the handler.
+func (v *savedViews) SetSavedViewService(savedViewService service.SavedViewService) {
+	v.savedViewService = savedViewService
+}
+
+// applySavedView resolves the view a list request of entityType runs, if any,
+// for parseListFilter and listSort to apply. It answers the request and
+// returns false when the view cannot be run by the caller.
+func (v *savedViews) applySavedView(c *gin.Context, logger *logrus.Entry, entityType string) bool {
+	raw := c.Query(savedViewParam)
+	if v.savedViewService == nil || raw == "" {
+		return true
+	}
+	id, err := strconv.ParseUint(raw, 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid saved view ID")
+		return false
+	}
+
+	view, err := v.savedViewService.Resolve(uint(id), entityType, c.GetUint("user_id"), models.UserRole(c.GetString("user_role")), middleware.Permissions(c))
+	if err != nil {
+		respondSavedViewError(c, logger, err)
+		return false
+	}
+	logger.WithField("saved_view_id", view.ID).Debug("Running saved view")
+	c.Set(savedViewContextKey, view)
+	return true
+}
+
+// runningSavedView is the saved view applySavedView resolved for the request,
+// or nil.
+func runningSavedView(c *gin.Context) *models.SavedView {
+	if value, ok := c.Get(savedViewContextKey); ok {
+		if view, ok := value.(*models.SavedView); ok {
+			return view
+		}
+	}
+	return nil
+}
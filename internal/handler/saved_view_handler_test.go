@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	servicemocks "github.com/florinel-chis/gophercrm/internal/service/mocks"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var _ service.SavedViewService = (*mocks.SavedViewService)(nil)
+
+type SavedViewHandlerTestSuite struct {
+	suite.Suite
+	mockService     *mocks.SavedViewService
+	mockLeadService *servicemocks.LeadService
+	handler         *SavedViewHandler
+	leadHandler     *LeadHandler
+}
+
+func (suite *SavedViewHandlerTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *SavedViewHandlerTestSuite) SetupTest() {
+	suite.mockService = new(mocks.SavedViewService)
+	suite.mockLeadService = new(servicemocks.LeadService)
+	suite.handler = NewSavedViewHandler(suite.mockService)
+	suite.leadHandler = NewLeadHandler(suite.mockLeadService)
+	suite.leadHandler.SetSavedViewService(suite.mockService)
+}
+
+func (suite *SavedViewHandlerTestSuite) TearDownTest() {
+	suite.mockService.AssertExpectations(suite.T())
+	suite.mockLeadService.AssertExpectations(suite.T())
+}
+
+func (suite *SavedViewHandlerTestSuite) do(role models.UserRole, userID uint, method, path string, body interface{}) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Set("user_role", string(role))
+		c.Next()
+	})
+	SetupSavedViewRoutes(router.Group(""), suite.handler)
+	router.GET("/leads", suite.leadHandler.List)
+
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// hotLeads is a sales manager's view published to the whole sales role.
+func hotLeads() *models.SavedView {
+	return &models.SavedView{ID: 7, OwnerID: 1, EntityType: models.AuditEntityLead, Name: "My hot leads this week",
+		Filter: "classification eq hot_lead and created_at gte -7d", SortBy: "created_at", SortOrder: "desc",
+		SharedWithRole: models.RoleSales}
+}
+
+func (suite *SavedViewHandlerTestSuite) TestCreate_OwnedByCaller() {
+	suite.mockService.On("Create", mock.MatchedBy(func(v *models.SavedView) bool {
+		return v.OwnerID == 5 && v.EntityType == models.AuditEntityLead && v.SharedWithRole == models.RoleSales &&
+			len(v.Columns) == 2
+	}), models.RoleSales, models.BuiltInPermissions(models.RoleSales)).Return(nil)
+
+	w := suite.do(models.RoleSales, 5, http.MethodPost, "/saved-views", gin.H{
+		"entity_type": "lead", "name": "Hot", "filter": "classification eq hot_lead",
+		"columns": []string{"email", "company"}, "shared_with_role": "sales",
+	})
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+}
+
+func (suite *SavedViewHandlerTestSuite) TestCreate_Invalid() {
+	suite.mockService.On("Create", mock.Anything, models.RoleSales, models.BuiltInPermissions(models.RoleSales)).
+		Return(fmt.Errorf("invalid filter: %w", apperrors.ErrValidation))
+	w := suite.do(models.RoleSales, 5, http.MethodPost, "/saved-views", gin.H{"entity_type": "lead", "name": "x", "filter": "status"})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "invalid filter")
+}
+
+func (suite *SavedViewHandlerTestSuite) TestUpdate_KeepsOmittedFields() {
+	suite.mockService.On("Get", uint(7), uint(1), models.RoleSales, models.BuiltInPermissions(models.RoleSales)).Return(hotLeads(), nil)
+	suite.mockService.On("Update", mock.MatchedBy(func(v *models.SavedView) bool {
+		return v.Name == "Renamed" && v.Filter == hotLeads().Filter && v.SharedWithRole == ""
+	}), uint(1), models.RoleSales, models.BuiltInPermissions(models.RoleSales)).Return(hotLeads(), nil)
+
+	w := suite.do(models.RoleSales, 1, http.MethodPut, "/saved-views/7", gin.H{"name": "Renamed", "shared_with_role": ""})
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *SavedViewHandlerTestSuite) TestUpdate_NotOwner() {
+	suite.mockService.On("Get", uint(7), uint(2), models.RoleSales, models.BuiltInPermissions(models.RoleSales)).Return(hotLeads(), nil)
+	suite.mockService.On("Update", mock.Anything, uint(2), models.RoleSales, models.BuiltInPermissions(models.RoleSales)).
+		Return(nil, fmt.Errorf("only the owner of a saved view can change it: %w", apperrors.ErrForbidden))
+
+	w := suite.do(models.RoleSales, 2, http.MethodPut, "/saved-views/7", gin.H{"name": "Mine now"})
+
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *SavedViewHandlerTestSuite) TestDelete() {
+	suite.mockService.On("Delete", uint(7), uint(1), models.RoleSales, models.BuiltInPermissions(models.RoleSales)).Return(nil)
+	w := suite.do(models.RoleSales, 1, http.MethodDelete, "/saved-views/7", nil)
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+
+	suite.mockService.On("Delete", uint(8), uint(1), models.RoleSales, models.BuiltInPermissions(models.RoleSales)).Return(fmt.Errorf("saved view 8: %w", apperrors.ErrNotFound))
+	w = suite.do(models.RoleSales, 1, http.MethodDelete, "/saved-views/8", nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *SavedViewHandlerTestSuite) TestList_RunsView() {
+	suite.mockService.On("Resolve", uint(7), models.AuditEntityLead, uint(2), models.RoleSales, models.BuiltInPermissions(models.RoleSales)).Return(hotLeads(), nil)
+	suite.mockLeadService.On("ListFiltered", mock.MatchedBy(func(f models.LeadFilter) bool {
+		return f.OwnerID == 2 && f.Where != nil && !f.Where.Or && len(f.Where.Group) == 2 &&
+			f.SortBy == "created_at" && f.SortOrder == "desc"
+	}), 0, 20).Return([]models.Lead{}, int64(0), nil)
+
+	w := suite.do(models.RoleSales, 2, http.MethodGet, "/leads?view_id=7", nil)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code, "the view runs within the caller's own leads")
+}
+
+func (suite *SavedViewHandlerTestSuite) TestList_ViewCombinesWithRequest() {
+	suite.mockService.On("Resolve", uint(7), models.AuditEntityLead, uint(1), models.RoleAdmin, models.BuiltInPermissions(models.RoleAdmin)).Return(hotLeads(), nil)
+	suite.mockLeadService.On("ListFiltered", mock.MatchedBy(func(f models.LeadFilter) bool {
+		// The view's two conditions and the request's one must all hold.
+		return f.OwnerID == 0 && len(f.Where.Group) == 2 && len(f.Where.Group[0].Group) == 2 &&
+			f.Where.Group[1].Condition != nil && f.SortBy == "email" && f.SortOrder == "asc"
+	}), 0, 20).Return([]models.Lead{}, int64(0), nil)
+
+	w := suite.do(models.RoleAdmin, 1, http.MethodGet,
+		"/leads?view_id=7&sort_by=email&filter="+url.QueryEscape("source eq webinar"), nil)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *SavedViewHandlerTestSuite) TestList_ViewErrors() {
+	w := suite.do(models.RoleSales, 2, http.MethodGet, "/leads?view_id=abc", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	suite.mockService.On("Resolve", uint(8), models.AuditEntityLead, uint(2), models.RoleSales, models.BuiltInPermissions(models.RoleSales)).
+		Return(nil, fmt.Errorf("saved view 8: %w", apperrors.ErrNotFound))
+	w = suite.do(models.RoleSales, 2, http.MethodGet, "/leads?view_id=8", nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	suite.mockService.On("Resolve", uint(9), models.AuditEntityLead, uint(2), models.RoleSales, models.BuiltInPermissions(models.RoleSales)).
+		Return(nil, fmt.Errorf("saved view 9 is a task view, not a lead view: %w", apperrors.ErrValidation))
+	w = suite.do(models.RoleSales, 2, http.MethodGet, "/leads?view_id=9", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func TestSavedViewHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(SavedViewHandlerTestSuite))
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/middleware"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type SearchHandler struct {
+	searchService service.SearchService
+}
+
+func NewSearchHandler(searchService service.SearchService) *SearchHandler {
+	return &SearchHandler{searchService: searchService}
+}
+
+// Search godoc
+// @Summary Search across CRM records
+// @Description Full-text search of leads (name, email, phone, company, notes), customers (the same), tickets (title, description, resolution), tasks (title, description) and form submissions (email, submitted values). Every word of q must appear in a record, as a word or the start of one; hits are ranked by relevance, best first. Each entity type only yields the records its own list shows the caller: without leads:read:any or tasks:read:any only their own leads or tasks, and nothing of an entity type their permissions cannot list (leads:read, customers:read, tickets:list, tasks:read, forms:read).
+// @Tags search
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param q query string true "Search words (max 200 characters, 10 words)"
+// @Param types query string false "Comma-separated entity types to search (lead, customer, ticket, task, form_submission); all by default"
+// @Param limit query int false "Maximum number of hits (max 100)" default(20)
+// @Success 200 {object} utils.APIResponse{data=[]models.SearchHit} "Search hits"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid search query or entity type"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /search [get]
+func (h *SearchHandler) Search(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "SearchHandler.Search")
+
+	_, limit := utils.ParseOffsetLimit(c)
+	var entityTypes []string
+	for _, entity := range strings.Split(c.Query("types"), ",") {
+		if entity = strings.TrimSpace(entity); entity != "" {
+			entityTypes = append(entityTypes, entity)
+		}
+	}
+
+	hits, err := h.searchService.Search(c.Query("q"), entityTypes,
+		c.GetUint("user_id"), middleware.Permissions(c), limit)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrValidation) {
+			logger.WithError(err).Warn("Invalid search")
+			utils.RespondBadRequest(c, err.Error())
+			return
+		}
+		logger.WithError(err).Error("Failed to search")
+		utils.RespondInternalError(c)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, hits)
+	utils.RespondSuccess(c, http.StatusOK, hits)
+}
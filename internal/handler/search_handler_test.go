@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+var _ service.SearchService = (*mocks.SearchService)(nil)
+
+type SearchHandlerTestSuite struct {
+	suite.Suite
+	mockService *mocks.SearchService
+	handler     *SearchHandler
+}
+
+func (suite *SearchHandlerTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *SearchHandlerTestSuite) SetupTest() {
+	suite.mockService = new(mocks.SearchService)
+	suite.handler = NewSearchHandler(suite.mockService)
+}
+
+func (suite *SearchHandlerTestSuite) TearDownTest() {
+	suite.mockService.AssertExpectations(suite.T())
+}
+
+func (suite *SearchHandlerTestSuite) do(role models.UserRole, userID uint, path string) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Set("user_role", string(role))
+		c.Next()
+	})
+	SetupSearchRoutes(router.Group(""), suite.handler)
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (suite *SearchHandlerTestSuite) TestSearch_Success() {
+	hits := []models.SearchHit{{EntityType: models.AuditEntityTicket, EntityID: 3, Title: "Engine overheats", Subtitle: "open", Score: 1.5}}
+	suite.mockService.On("Search", "engine overheat", []string{"ticket", "task"}, uint(5), models.BuiltInPermissions(models.RoleSupport), 10).
+		Return(hits, nil)
+
+	w := suite.do(models.RoleSupport, 5, "/search?q="+url.QueryEscape("engine overheat")+"&types=ticket,%20task,&limit=10")
+
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	var response struct {
+		Data []models.SearchHit `json:"data"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), hits, response.Data)
+}
+
+func (suite *SearchHandlerTestSuite) TestSearch_AllTypesByDefault() {
+	suite.mockService.On("Search", "acme", []string(nil), uint(1), models.BuiltInPermissions(models.RoleAdmin), 20).Return([]models.SearchHit{}, nil)
+
+	w := suite.do(models.RoleAdmin, 1, "/search?q=acme")
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+// TestSearch_CustomRolePermissions passes the permissions LoadPermissions
+// resolved for a custom role, not those of its base role.
+func (suite *SearchHandlerTestSuite) TestSearch_CustomRolePermissions() {
+	auditor := models.NewPermissionSet(models.PermLeadsReadAny, models.PermAuditRead)
+	suite.mockService.On("Search", "acme", []string(nil), uint(4), auditor, 20).Return([]models.SearchHit{}, nil)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(4))
+		c.Set("user_role", string(models.RoleSupport))
+		c.Set("permissions", auditor)
+		c.Next()
+	})
+	SetupSearchRoutes(router.Group(""), suite.handler)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search?q=acme", nil))
+
+	assert.Equal(suite.T(), http.StatusOK, rec.Code)
+}
+
+func (suite *SearchHandlerTestSuite) TestSearch_InvalidQuery() {
+	suite.mockService.On("Search", "", mock.Anything, uint(1), models.BuiltInPermissions(models.RoleAdmin), 20).
+		Return(nil, fmt.Errorf("a search query needs at least one letter or digit: %w", apperrors.ErrValidation))
+
+	w := suite.do(models.RoleAdmin, 1, "/search")
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "at least one letter or digit")
+}
+
+func (suite *SearchHandlerTestSuite) TestSearch_Failure() {
+	suite.mockService.On("Search", "acme", mock.Anything, uint(1), models.BuiltInPermissions(models.RoleAdmin), 20).
+		Return(nil, fmt.Errorf("search leads: no such table: leads_fts"))
+
+	w := suite.do(models.RoleAdmin, 1, "/search?q=acme")
+
+	assert.Equal(suite.T(), http.StatusInternalServerError, w.Code)
+	assert.NotContains(suite.T(), w.Body.String(), "leads_fts")
+}
+
+func TestSearchHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(SearchHandlerTestSuite))
+}
@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// SessionHandler lists the logins of a user, each with the device and address
+// it was made from, and ends them: a session ended here can no longer refresh,
+// and its access tokens stop working at once.
+type SessionHandler struct {
+	authService service.AuthService
+}
+
+func NewSessionHandler(authService service.AuthService) *SessionHandler {
+	return &SessionHandler{authService: authService}
+}
+
+// clientInfo describes the client of the request, for the session it starts
+// or uses.
+func clientInfo(c *gin.Context) service.ClientInfo {
+	return service.ClientInfo{UserAgent: c.Request.UserAgent(), IPAddress: c.ClientIP()}
+}
+
+// currentAccessToken returns the claims of the access token the request was
+// authenticated with; nil for a request made with an API key.
+func currentAccessToken(c *gin.Context) *service.AccessTokenClaims {
+	value, _ := c.Get("access_token")
+	token, _ := value.(*service.AccessTokenClaims)
+	return token
+}
+
+// List godoc
+// @Summary List the caller's sessions
+// @Description Every live login of the caller — one per login, kept through refresh-token rotation — with the device and address it was last used from and when. The session the request was made in is marked current.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.APIResponse{data=[]models.Session} "Sessions, most recently used first"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /auth/sessions [get]
+func (h *SessionHandler) List(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "SessionHandler.List")
+
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		utils.RespondUnauthorized(c, "Unauthorized")
+		return
+	}
+
+	var currentSessionID uint
+	if token := currentAccessToken(c); token != nil {
+		currentSessionID = token.SessionID
+	}
+	sessions, err := h.authService.ListSessions(userID, currentSessionID)
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, gin.H{"count": len(sessions)})
+	utils.RespondSuccess(c, http.StatusOK, sessions)
+}
+
+// Revoke godoc
+// @Summary End one of the caller's sessions
+// @Description Ends the session: its refresh token is revoked and its access tokens are refused from now on, by every instance within JWT_REVOCATION_CACHE_SECONDS. Ending the current session logs the caller out.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Session ID"
+// @Success 200 {object} utils.APIResponse{data=MessageResponse} "Session ended"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid session ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "No such live session of the caller"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /auth/sessions/{id} [delete]
+func (h *SessionHandler) Revoke(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "SessionHandler.Revoke")
+
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		utils.RespondUnauthorized(c, "Unauthorized")
+		return
+	}
+	h.revoke(c, logger, userID, c.Param("id"))
+}
+
+// ListForUser godoc
+// @Summary List a user's sessions
+// @Description Every live login of the user, with the device and address it was last used from. Requires users:update, which only admins hold by default.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} utils.APIResponse{data=[]models.Session} "Sessions, most recently used first"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid user ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - users:update permission required"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /users/{id}/sessions [get]
+func (h *SessionHandler) ListForUser(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "SessionHandler.ListForUser")
+
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid user ID")
+		return
+	}
+
+	var currentSessionID uint
+	if token := currentAccessToken(c); token != nil && uint(userID) == c.GetUint("user_id") {
+		currentSessionID = token.SessionID
+	}
+	sessions, err := h.authService.ListSessions(uint(userID), currentSessionID)
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, gin.H{"count": len(sessions)})
+	utils.RespondSuccess(c, http.StatusOK, sessions)
+}
+
+// RevokeForUser godoc
+// @Summary End a user's session
+// @Description Ends one session of the user, e.g. one logged in from a stolen device: its refresh token is revoked and its access tokens are refused from now on. Requires users:update, which only admins hold by default.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Param session_id path int true "Session ID"
+// @Success 200 {object} utils.APIResponse{data=MessageResponse} "Session ended"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid user or session ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - users:update permission required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "No such live session of the user"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /users/{id}/sessions/{session_id} [delete]
+func (h *SessionHandler) RevokeForUser(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "SessionHandler.RevokeForUser")
+
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid user ID")
+		return
+	}
+	h.revoke(c, logger, uint(userID), c.Param("session_id"))
+}
+
+func (h *SessionHandler) revoke(c *gin.Context, logger *logrus.Entry, userID uint, param string) {
+	sessionID, err := strconv.ParseUint(param, 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid session ID")
+		return
+	}
+
+	if err := h.authService.RevokeSession(userID, uint(sessionID)); err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	response := MessageResponse{Message: "Session ended"}
+	utils.LogHandlerResponse(logger, http.StatusOK, response)
+	utils.RespondSuccess(c, http.StatusOK, response)
+}
+
+func (h *SessionHandler) respondError(c *gin.Context, logger *logrus.Entry, err error) {
+	if errors.Is(err, service.ErrSessionNotFound) {
+		utils.RespondNotFound(c, "Session not found")
+		return
+	}
+	logger.WithError(err).Error("Session operation failed")
+	utils.RespondInternalError(c)
+}
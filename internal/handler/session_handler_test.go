@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSessionTestRouter simulates the auth middleware: the caller is user 42,
+// authenticated with an access token of session 9.
+func newSessionTestRouter(authService service.AuthService) *gin.Engine {
+	utils.InitLogger(&config.LoggingConfig{Level: "error", Format: "json"})
+	gin.SetMode(gin.TestMode)
+	h := NewSessionHandler(authService)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(42))
+		c.Set("access_token", &service.AccessTokenClaims{TokenID: "jti-42", SessionID: 9})
+		c.Next()
+	})
+	router.GET("/auth/sessions", h.List)
+	router.DELETE("/auth/sessions/:id", h.Revoke)
+	router.GET("/users/:id/sessions", h.ListForUser)
+	router.DELETE("/users/:id/sessions/:session_id", h.RevokeForUser)
+	return router
+}
+
+func TestSessionHandler_List(t *testing.T) {
+	authService := new(MockAuthService)
+	router := newSessionTestRouter(authService)
+	authService.On("ListSessions", uint(42), uint(9)).Return([]models.Session{
+		{ID: 9, UserID: 42, Device: "Firefox on Linux", IPAddress: "192.0.2.1", LastUsedAt: time.Now(), Current: true},
+		{ID: 7, UserID: 42, Device: "Safari on iOS", IPAddress: "198.51.100.2", LastUsedAt: time.Now().Add(-time.Hour)},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/sessions", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data []map[string]any `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 2)
+	assert.Equal(t, "Firefox on Linux", resp.Data[0]["device"])
+	assert.Equal(t, true, resp.Data[0]["current"])
+	assert.NotContains(t, resp.Data[0], "user_id")
+	authService.AssertExpectations(t)
+}
+
+func TestSessionHandler_Revoke(t *testing.T) {
+	authService := new(MockAuthService)
+	router := newSessionTestRouter(authService)
+	authService.On("RevokeSession", uint(42), uint(7)).Return(nil)
+	authService.On("RevokeSession", uint(42), uint(8)).Return(service.ErrSessionNotFound)
+	authService.On("RevokeSession", uint(42), uint(6)).Return(errors.New("db down"))
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/auth/sessions/7", http.StatusOK},
+		{"/auth/sessions/8", http.StatusNotFound},
+		{"/auth/sessions/6", http.StatusInternalServerError},
+		{"/auth/sessions/abc", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, tt.path, nil))
+		assert.Equal(t, tt.want, w.Code, tt.path)
+	}
+	authService.AssertExpectations(t)
+}
+
+func TestSessionHandler_ForUser(t *testing.T) {
+	authService := new(MockAuthService)
+	router := newSessionTestRouter(authService)
+	// Another user's session is never the caller's current one.
+	authService.On("ListSessions", uint(5), uint(0)).Return([]models.Session{{ID: 9, UserID: 5}}, nil)
+	authService.On("RevokeSession", uint(5), uint(9)).Return(nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/5/sessions", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users/5/sessions/9", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/x/sessions", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	authService.AssertExpectations(t)
+}
@@ -0,0 +1,273 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultSLAReportWindow is how far back an SLA report looks when the caller
+// gives no from.
+const defaultSLAReportWindow = 30 * 24 * time.Hour
+
+type SLAHandler struct {
+	auditTrail
+	slaService service.SLAService
+}
+
+func NewSLAHandler(slaService service.SLAService) *SLAHandler {
+	return &SLAHandler{slaService: slaService}
+}
+
+// SaveSLAPolicyRequest is the body of PUT /sla-policies/:priority. The
+// calendar fields are optional and default to UTC, 09:00-17:00, Monday to
+// Friday; they only take effect with business_hours_only.
+type SaveSLAPolicyRequest struct {
+	FirstResponseMinutes int    `json:"first_response_minutes" binding:"required,min=1"`
+	ResolutionMinutes    int    `json:"resolution_minutes" binding:"required,min=1"`
+	BusinessHoursOnly    bool   `json:"business_hours_only"`
+	Timezone             string `json:"timezone,omitempty" binding:"omitempty,max=64"`
+	BusinessDayStart     string `json:"business_day_start,omitempty" binding:"omitempty,len=5"`
+	BusinessDayEnd       string `json:"business_day_end,omitempty" binding:"omitempty,len=5"`
+	BusinessDays         []int  `json:"business_days,omitempty" binding:"omitempty,max=7,dive,min=0,max=6"`
+}
+
+// ListPolicies godoc
+// @Summary List SLA policies
+// @Description Every SLA policy, one per ticket priority at most. A priority without a policy has no deadlines. Available to the admin, sales and support roles.
+// @Tags sla
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.APIResponse{data=[]models.SLAPolicy} "SLA policies retrieved successfully"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin, sales or support role required"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /sla-policies [get]
+func (h *SLAHandler) ListPolicies(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "SLAHandler.ListPolicies")
+
+	policies, err := h.slaService.ListPolicies()
+	if err != nil {
+		logger.WithError(err).Error("Failed to list SLA policies")
+		utils.RespondInternalError(c)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, policies)
+	utils.RespondSuccess(c, http.StatusOK, policies)
+}
+
+// SavePolicy godoc
+// @Summary Create or replace an SLA policy
+// @Description Set the first-response and resolution targets, in minutes, for one ticket priority (admin role only). With business_hours_only the clock runs only inside the policy's calendar — business_days (0 is Sunday, 6 is Saturday) between business_day_start and business_day_end (HH:MM) in timezone (an IANA name) — otherwise around the clock. The resolution target cannot be shorter than the first-response target. The policy applies to tickets opened, or moved to this priority, from now on; existing deadlines are left alone.
+// @Tags sla
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param priority path string true "Ticket priority" Enums(low, medium, high, urgent)
+// @Param request body SaveSLAPolicyRequest true "SLA policy"
+// @Success 200 {object} utils.APIResponse{data=models.SLAPolicy} "SLA policy saved"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid priority, targets or calendar"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /sla-policies/{priority} [put]
+func (h *SLAHandler) SavePolicy(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "SLAHandler.SavePolicy")
+
+	var req SaveSLAPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	policy := &models.SLAPolicy{
+		Priority:             models.TicketPriority(c.Param("priority")),
+		FirstResponseMinutes: req.FirstResponseMinutes,
+		ResolutionMinutes:    req.ResolutionMinutes,
+		BusinessHoursOnly:    req.BusinessHoursOnly,
+		Timezone:             req.Timezone,
+		BusinessDayStart:     req.BusinessDayStart,
+		BusinessDayEnd:       req.BusinessDayEnd,
+		BusinessDays:         req.BusinessDays,
+	}
+	existing := h.auditedPolicy(policy.Priority)
+	before := h.auditState(existing)
+
+	if err := h.slaService.SavePolicy(policy); err != nil {
+		if errors.Is(err, apperrors.ErrValidation) {
+			logger.WithError(err).Warn("Invalid SLA policy")
+			utils.RespondBadRequest(c, err.Error())
+			return
+		}
+		logger.WithError(err).Error("Failed to save SLA policy")
+		utils.RespondInternalError(c)
+		return
+	}
+
+	action := models.AuditActionCreate
+	if existing != nil {
+		action = models.AuditActionUpdate
+	}
+	h.recordAudit(c, models.AuditEntitySLAPolicy, policy.ID, action, before, policy)
+
+	utils.LogHandlerResponse(logger, http.StatusOK, policy)
+	utils.RespondSuccess(c, http.StatusOK, policy)
+}
+
+// auditedPolicy loads the priority's current policy for the audit trail: the
+// before-state of a write, and the id of a delete. It reads nothing, and
+// returns nil, when the trail is off.
+func (h *SLAHandler) auditedPolicy(priority models.TicketPriority) *models.SLAPolicy {
+	if h.auditService == nil {
+		return nil
+	}
+	policies, err := h.slaService.ListPolicies()
+	if err != nil {
+		return nil
+	}
+	for i := range policies {
+		if policies[i].Priority == priority {
+			return &policies[i]
+		}
+	}
+	return nil
+}
+
+// DeletePolicy godoc
+// @Summary Delete an SLA policy
+// @Description Remove the SLA policy of one ticket priority (admin role only). Tickets opened at that priority from now on carry no deadlines; existing deadlines are left alone.
+// @Tags sla
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param priority path string true "Ticket priority" Enums(low, medium, high, urgent)
+// @Success 204 "SLA policy deleted"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "The priority has no SLA policy"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /sla-policies/{priority} [delete]
+func (h *SLAHandler) DeletePolicy(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "SLAHandler.DeletePolicy")
+
+	priority := models.TicketPriority(c.Param("priority"))
+	existing := h.auditedPolicy(priority)
+	before := h.auditState(existing)
+
+	if err := h.slaService.DeletePolicy(priority); err != nil {
+		if apperrors.IsNotFound(err) {
+			utils.RespondNotFound(c, "SLA policy not found")
+			return
+		}
+		logger.WithError(err).Error("Failed to delete SLA policy")
+		utils.RespondInternalError(c)
+		return
+	}
+
+	if existing != nil {
+		h.recordAudit(c, models.AuditEntitySLAPolicy, existing.ID, models.AuditActionDelete, before, nil)
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusNoContent, nil)
+	c.Status(http.StatusNoContent)
+}
+
+// GetAttainmentByPriority godoc
+// @Summary SLA attainment per priority
+// @Description How the tickets opened in the window fared against their SLA deadlines, one row per priority, most urgent first. Only tickets that carry a deadline are counted. For each target a ticket is met (reached by the deadline), breached (reached late, or still unreached past the deadline) or pending (unreached, deadline still ahead); attainment_rate is the met share of the met and breached tickets as a percentage, null while none is decided. The window defaults to the last 30 days. System-wide; restricted to the admin, sales and support roles.
+// @Tags dashboard
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param from query string false "Only tickets opened at or after this RFC3339 timestamp (default 30 days ago)"
+// @Param to query string false "Only tickets opened before this RFC3339 timestamp (default now)"
+// @Success 200 {object} utils.APIResponse{data=[]models.SLAAttainment} "SLA attainment retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid window"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin, sales or support role required"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /dashboard/sla/by-priority [get]
+func (h *SLAHandler) GetAttainmentByPriority(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "SLAHandler.GetAttainmentByPriority")
+	h.respondAttainment(c, logger, h.slaService.AttainmentByPriority)
+}
+
+// GetAttainmentByAssignee godoc
+// @Summary SLA attainment per assignee
+// @Description The same report as /dashboard/sla/by-priority with one row per assignee, ordered by name, and the unassigned tickets last. assignee_id is absent on the unassigned row.
+// @Tags dashboard
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param from query string false "Only tickets opened at or after this RFC3339 timestamp (default 30 days ago)"
+// @Param to query string false "Only tickets opened before this RFC3339 timestamp (default now)"
+// @Success 200 {object} utils.APIResponse{data=[]models.SLAAttainment} "SLA attainment retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid window"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin, sales or support role required"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /dashboard/sla/by-assignee [get]
+func (h *SLAHandler) GetAttainmentByAssignee(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "SLAHandler.GetAttainmentByAssignee")
+	h.respondAttainment(c, logger, h.slaService.AttainmentByAssignee)
+}
+
+func (h *SLAHandler) respondAttainment(c *gin.Context, logger *logrus.Entry, report func(from, to time.Time) ([]models.SLAAttainment, error)) {
+	from, to, ok := parseSLAReportWindow(c)
+	if !ok {
+		return
+	}
+
+	rows, err := report(from, to)
+	if err != nil {
+		logger.WithError(err).Error("Failed to build SLA report")
+		utils.RespondInternalError(c)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, rows)
+	utils.RespondSuccess(c, http.StatusOK, rows)
+}
+
+// parseSLAReportWindow reads the report's half-open [from, to) window; from
+// defaults to 30 days before to, and to to now. A malformed or empty window is
+// rejected rather than replaced with the default, so a typo cannot pass for a
+// report on the wrong period.
+func parseSLAReportWindow(c *gin.Context) (time.Time, time.Time, bool) {
+	var from, to time.Time
+	for name, target := range map[string]*time.Time{"from": &from, "to": &to} {
+		if raw := c.Query(name); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				utils.RespondBadRequest(c, "Invalid "+name+": expected an RFC3339 timestamp")
+				return time.Time{}, time.Time{}, false
+			}
+			*target = parsed.UTC()
+		}
+	}
+	if to.IsZero() {
+		to = time.Now().UTC()
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultSLAReportWindow)
+	}
+	if !from.Before(to) {
+		utils.RespondBadRequest(c, "from must be before to")
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
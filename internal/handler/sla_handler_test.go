@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var _ service.SLAService = (*mocks.SLAService)(nil)
+
+type SLAHandlerTestSuite struct {
+	suite.Suite
+	mockSLAService *mocks.SLAService
+	mockAudit      *mocks.AuditService
+	handler        *SLAHandler
+}
+
+func (suite *SLAHandlerTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *SLAHandlerTestSuite) SetupTest() {
+	suite.mockSLAService = new(mocks.SLAService)
+	suite.mockAudit = new(mocks.AuditService)
+	suite.handler = NewSLAHandler(suite.mockSLAService)
+}
+
+func (suite *SLAHandlerTestSuite) TearDownTest() {
+	suite.mockSLAService.AssertExpectations(suite.T())
+	suite.mockAudit.AssertExpectations(suite.T())
+}
+
+func (suite *SLAHandlerTestSuite) do(role models.UserRole, method, path string, body interface{}) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		c.Set("user_role", string(role))
+		c.Next()
+		if len(c.Errors) > 0 && c.Errors[0].Type == gin.ErrorTypeBind {
+			utils.RespondValidationError(c, c.Errors[0].Error())
+		}
+	})
+	SetupSLARoutes(router.Group(""), suite.handler)
+
+	reader := bytes.NewReader(nil)
+	if body != nil {
+		payload, _ := json.Marshal(body)
+		reader = bytes.NewReader(payload)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (suite *SLAHandlerTestSuite) TestListPolicies_StaffOnly() {
+	suite.mockSLAService.On("ListPolicies").Return([]models.SLAPolicy{{Priority: models.TicketPriorityHigh}}, nil)
+
+	assert.Equal(suite.T(), http.StatusOK, suite.do(models.RoleSupport, http.MethodGet, "/sla-policies", nil).Code)
+	assert.Equal(suite.T(), http.StatusForbidden, suite.do(models.RoleCustomer, http.MethodGet, "/sla-policies", nil).Code)
+}
+
+func (suite *SLAHandlerTestSuite) TestSavePolicy_AdminOnly() {
+	body := map[string]interface{}{"first_response_minutes": 60, "resolution_minutes": 480}
+
+	w := suite.do(models.RoleSupport, http.MethodPut, "/sla-policies/high", body)
+
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *SLAHandlerTestSuite) TestSavePolicy_CreatesAndAudits() {
+	suite.handler.SetAuditService(suite.mockAudit)
+	suite.mockSLAService.On("ListPolicies").Return([]models.SLAPolicy{}, nil)
+	suite.mockSLAService.On("SavePolicy", mock.MatchedBy(func(p *models.SLAPolicy) bool {
+		return p.Priority == models.TicketPriorityHigh && p.FirstResponseMinutes == 60 &&
+			p.ResolutionMinutes == 480 && p.BusinessHoursOnly && p.Timezone == "Europe/Bucharest"
+	})).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.SLAPolicy).ID = 3
+	})
+	suite.mockAudit.On("Record", mock.Anything, models.AuditEntitySLAPolicy, uint(3), models.AuditActionCreate,
+		mock.Anything, mock.AnythingOfType("*models.SLAPolicy")).Return(nil)
+
+	w := suite.do(models.RoleAdmin, http.MethodPut, "/sla-policies/high", map[string]interface{}{
+		"first_response_minutes": 60, "resolution_minutes": 480,
+		"business_hours_only": true, "timezone": "Europe/Bucharest",
+	})
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *SLAHandlerTestSuite) TestSavePolicy_ValidationErrors() {
+	w := suite.do(models.RoleAdmin, http.MethodPut, "/sla-policies/high", map[string]interface{}{"first_response_minutes": 60})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	suite.mockSLAService.On("SavePolicy", mock.Anything).Return(apperrors.ErrValidation)
+	w = suite.do(models.RoleAdmin, http.MethodPut, "/sla-policies/critical", map[string]interface{}{
+		"first_response_minutes": 60, "resolution_minutes": 480,
+	})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *SLAHandlerTestSuite) TestDeletePolicy() {
+	suite.mockSLAService.On("DeletePolicy", models.TicketPriorityLow).Return(nil).Once()
+	assert.Equal(suite.T(), http.StatusNoContent, suite.do(models.RoleAdmin, http.MethodDelete, "/sla-policies/low", nil).Code)
+
+	suite.mockSLAService.On("DeletePolicy", models.TicketPriorityLow).Return(apperrors.ErrNotFound).Once()
+	assert.Equal(suite.T(), http.StatusNotFound, suite.do(models.RoleAdmin, http.MethodDelete, "/sla-policies/low", nil).Code)
+}
+
+func (suite *SLAHandlerTestSuite) TestAttainment_DefaultWindowIsThirtyDays() {
+	suite.mockSLAService.On("AttainmentByPriority", mock.Anything, mock.Anything).Return([]models.SLAAttainment{}, nil).
+		Run(func(args mock.Arguments) {
+			from, to := args.Get(0).(time.Time), args.Get(1).(time.Time)
+			assert.Equal(suite.T(), 30*24*time.Hour, to.Sub(from))
+			assert.WithinDuration(suite.T(), time.Now(), to, time.Minute)
+		})
+
+	w := suite.do(models.RoleSales, http.MethodGet, "/dashboard/sla/by-priority", nil)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *SLAHandlerTestSuite) TestAttainment_ExplicitWindow() {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	suite.mockSLAService.On("AttainmentByAssignee", from, to).Return([]models.SLAAttainment{}, nil)
+
+	w := suite.do(models.RoleAdmin, http.MethodGet, "/dashboard/sla/by-assignee?from=2026-01-01T00:00:00Z&to=2026-02-01T00:00:00Z", nil)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *SLAHandlerTestSuite) TestAttainment_InvalidWindow() {
+	for _, query := range []string{"?from=yesterday", "?from=2026-02-01T00:00:00Z&to=2026-01-01T00:00:00Z"} {
+		w := suite.do(models.RoleAdmin, http.MethodGet, "/dashboard/sla/by-priority"+query, nil)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestSLAHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(SLAHandlerTestSuite))
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/florinel-chis/gophercrm/internal/oidc"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// SSOHandler serves the single sign-on login. The frontend starts it, sends
+// the browser to the identity provider, and hands the provider's redirect
+// back to Callback; no cookie is involved, the session handle returned by
+// Start binds the redirect to the browser that started it.
+type SSOHandler struct {
+	ssoService service.SSOService
+}
+
+func NewSSOHandler(ssoService service.SSOService) *SSOHandler {
+	return &SSOHandler{ssoService: ssoService}
+}
+
+// SSOConfigResponse tells the login page whether to offer single sign-on.
+type SSOConfigResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+// SSOCallbackRequest carries the provider's redirect: the code and state of
+// its query string, and the session handle of POST /auth/sso/start.
+type SSOCallbackRequest struct {
+	Session string `json:"session" binding:"required"`
+	State   string `json:"state" binding:"required"`
+	Code    string `json:"code" binding:"required"`
+}
+
+// Config godoc
+// @Summary Report whether single sign-on is available
+// @Description Public. Lets the login page decide whether to offer signing in with the company identity provider.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=SSOConfigResponse} "Whether single sign-on is configured"
+// @Router /auth/sso [get]
+func (h *SSOHandler) Config(c *gin.Context) {
+	utils.RespondSuccess(c, http.StatusOK, SSOConfigResponse{Enabled: h.ssoService.Enabled()})
+}
+
+// Start godoc
+// @Summary Start a single sign-on login
+// @Description Public. Returns the identity provider URL to send the browser to, and a session handle the frontend keeps (e.g. in sessionStorage) and presents with the provider's redirect to POST /auth/sso/callback. The attempt expires after 10 minutes. The flow is the OpenID Connect authorization-code flow with PKCE.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=models.SSOStart} "Authorization URL and session handle"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Single sign-on is not configured"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Rate limit exceeded (10 requests per minute per IP)"
+// @Failure 503 {object} utils.APIResponse{error=utils.APIError} "The identity provider is unreachable"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /auth/sso/start [post]
+func (h *SSOHandler) Start(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "SSOHandler.Start")
+
+	start, err := h.ssoService.Start(c.Request.Context())
+	if err != nil {
+		h.respondError(c, err, "Single sign-on start failed")
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, "single sign-on started")
+	utils.RespondSuccess(c, http.StatusOK, start)
+}
+
+// Callback godoc
+// @Summary Complete a single sign-on login
+// @Description Public. Redeems the code the identity provider redirected with and returns the same tokens as POST /auth/login. The user is the one linked to the provider account, else the one with the same verified email, else — with just-in-time provisioning on — a new account. The role is set from the provider's claims at every sign-on. Single sign-on does not ask for the local second factor.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body SSOCallbackRequest true "Session handle, state and code"
+// @Success 200 {object} utils.APIResponse{data=AuthResponse} "Authenticated; JWT, refresh token and user returned"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Malformed body"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unknown, expired or already used attempt, state mismatch, or the provider rejected the code or issued an invalid ID token"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "The account may not sign in: domain not allowed, email not verified, no role mapped, no account without provisioning, or account disabled"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Single sign-on is not configured"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Rate limit exceeded (10 requests per minute per IP)"
+// @Failure 503 {object} utils.APIResponse{error=utils.APIError} "The identity provider is unreachable"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /auth/sso/callback [post]
+func (h *SSOHandler) Callback(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "SSOHandler.Callback")
+
+	var req SSOCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	tokens, err := h.ssoService.Complete(c.Request.Context(), req.Session, req.State, req.Code, clientInfo(c))
+	if err != nil {
+		h.respondError(c, err, "Single sign-on failed")
+		return
+	}
+
+	response := AuthResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		User:         tokens.User,
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, "single sign-on login succeeded")
+	utils.RespondSuccess(c, http.StatusOK, response)
+}
+
+func (h *SSOHandler) respondError(c *gin.Context, err error, message string) {
+	logger := utils.Logger.WithError(err)
+	switch {
+	case errors.Is(err, service.ErrSSODisabled):
+		utils.RespondNotFound(c, "Single sign-on is not configured")
+	case errors.Is(err, service.ErrSSOAccountNotAllowed):
+		logger.Warn(message)
+		utils.RespondForbidden(c, "This account is not allowed to sign in with single sign-on")
+	case errors.Is(err, service.ErrInvalidSSOState),
+		errors.Is(err, oidc.ErrExchangeFailed),
+		errors.Is(err, oidc.ErrInvalidIDToken):
+		logger.Warn(message)
+		utils.RespondUnauthorized(c, "Single sign-on failed; please try again")
+	case errors.Is(err, oidc.ErrProviderUnavailable):
+		logger.Error(message)
+		utils.RespondError(c, http.StatusServiceUnavailable, "SSO_PROVIDER_UNAVAILABLE",
+			"The identity provider is unavailable", nil)
+	default:
+		logger.Error(message)
+		utils.RespondInternalError(c)
+	}
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/oidc"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockSSOService struct {
+	mock.Mock
+}
+
+func (m *MockSSOService) Enabled() bool {
+	return m.Called().Bool(0)
+}
+
+func (m *MockSSOService) Start(ctx context.Context) (*models.SSOStart, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SSOStart), args.Error(1)
+}
+
+func (m *MockSSOService) Complete(ctx context.Context, session, state, code string, client service.ClientInfo) (*service.AuthTokens, error) {
+	args := m.Called(ctx, session, state, code, client)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.AuthTokens), args.Error(1)
+}
+
+func newSSOTestRouter(sso service.SSOService) *gin.Engine {
+	utils.InitLogger(&config.LoggingConfig{Level: "error", Format: "json"})
+	gin.SetMode(gin.TestMode)
+	h := NewSSOHandler(sso)
+	router := gin.New()
+	router.GET("/auth/sso", h.Config)
+	router.POST("/auth/sso/start", h.Start)
+	router.POST("/auth/sso/callback", h.Callback)
+	return router
+}
+
+func TestSSOHandler_StartAndCallback(t *testing.T) {
+	sso := new(MockSSOService)
+	router := newSSOTestRouter(sso)
+	sso.On("Enabled").Return(true)
+	sso.On("Start", mock.Anything).Return(&models.SSOStart{AuthorizationURL: "https://idp/authorize?x=1", Session: "sess"}, nil)
+	// httptest requests come from 192.0.2.1.
+	client := service.ClientInfo{UserAgent: "Mozilla/5.0 Firefox/127.0", IPAddress: "192.0.2.1"}
+	sso.On("Complete", mock.Anything, "sess", "st", "code", client).
+		Return(&service.AuthTokens{AccessToken: "jwt", RefreshToken: "refresh", User: &models.User{Email: "a@example.com"}}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/sso", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"enabled":true`)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/auth/sso/start", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var response utils.APIResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	data := response.Data.(map[string]interface{})
+	assert.Equal(t, "https://idp/authorize?x=1", data["authorization_url"])
+	assert.Equal(t, "sess", data["session"])
+
+	body, _ := json.Marshal(map[string]string{"session": "sess", "state": "st", "code": "code"})
+	req := httptest.NewRequest(http.MethodPost, "/auth/sso/callback", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", client.UserAgent)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	data = response.Data.(map[string]interface{})
+	assert.Equal(t, "jwt", data["token"])
+	assert.Equal(t, "refresh", data["refresh_token"])
+	sso.AssertExpectations(t)
+}
+
+func TestSSOHandler_ErrorMapping(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+	}{
+		{service.ErrSSODisabled, http.StatusNotFound},
+		{service.ErrInvalidSSOState, http.StatusUnauthorized},
+		{fmt.Errorf("%w: invalid_grant", oidc.ErrExchangeFailed), http.StatusUnauthorized},
+		{fmt.Errorf("%w: nonce mismatch", oidc.ErrInvalidIDToken), http.StatusUnauthorized},
+		{fmt.Errorf("%w: domain not allowed", service.ErrSSOAccountNotAllowed), http.StatusForbidden},
+		{fmt.Errorf("%w: connection refused", oidc.ErrProviderUnavailable), http.StatusServiceUnavailable},
+		{fmt.Errorf("database is gone"), http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		sso := new(MockSSOService)
+		router := newSSOTestRouter(sso)
+		sso.On("Complete", mock.Anything, "s", "st", "c", mock.Anything).Return(nil, tc.err)
+
+		body, _ := json.Marshal(map[string]string{"session": "s", "state": "st", "code": "c"})
+		req := httptest.NewRequest(http.MethodPost, "/auth/sso/callback", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tc.status, w.Code, tc.err.Error())
+	}
+
+	sso := new(MockSSOService)
+	sso.On("Start", mock.Anything).Return(nil, service.ErrSSODisabled)
+	w := httptest.NewRecorder()
+	newSSOTestRouter(sso).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/auth/sso/start", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/middleware"
 	"github.com/florinel-chis/gophercrm/internal/models"
 	"github.com/florinel-chis/gophercrm/internal/service"
 	"github.com/florinel-chis/gophercrm/internal/utils"
@@ -14,6 +15,9 @@ import (
 )
 
 type TaskHandler struct {
+	auditTrail
+	customFieldFilters
+	savedViews
 	taskService service.TaskService
 }
 
@@ -35,6 +39,8 @@ type CreateTaskRequest struct {
 	// single request cannot turn a few client bytes into an arbitrarily large
 	// IN clause.
 	LabelIDs []uint `json:"label_ids,omitempty" binding:"omitempty,max=100,dive,gt=0"`
+	// CustomFields holds the values of the task custom fields, by name.
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
 }
 
 type UpdateTaskRequest struct {
@@ -51,6 +57,8 @@ type UpdateTaskRequest struct {
 	// empty array — replaces it wholesale. When present it is capped at 100 ids,
 	// exactly as on create.
 	LabelIDs *[]uint `json:"label_ids,omitempty" binding:"omitempty,max=100,dive,gt=0"`
+	// CustomFields sets the fields it names; a null value clears one.
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
 }
 
 // Create godoc
@@ -74,10 +82,9 @@ func (h *TaskHandler) Create(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "TaskHandler.Create")
 
 	currentUserID := c.GetUint("user_id")
-	currentUserRole := c.GetString("user_role")
 
 	// Role-based access control
-	if currentUserRole != string(models.RoleAdmin) && currentUserRole != string(models.RoleSupport) && currentUserRole != string(models.RoleSales) {
+	if !middleware.HasPermission(c, models.PermTasksCreate) {
 		utils.RespondForbidden(c, "Only admin, support, and sales users can create tasks")
 		return
 	}
@@ -89,7 +96,7 @@ func (h *TaskHandler) Create(c *gin.Context) {
 	}
 
 	// Non-admin users can only assign tasks to themselves or other users in same hierarchy
-	if currentUserRole != string(models.RoleAdmin) {
+	if !middleware.HasPermission(c, models.PermTasksAssign) {
 		if req.AssignedToID != currentUserID {
 			utils.RespondForbidden(c, "You can only assign tasks to yourself")
 			return
@@ -105,6 +112,7 @@ func (h *TaskHandler) Create(c *gin.Context) {
 		LeadID:       req.LeadID,
 		CustomerID:   req.CustomerID,
 		Status:       models.TaskStatusPending,
+		CustomFields: requestCustomFields(req.CustomFields),
 	}
 
 	if err := h.taskService.CreateWithLabels(task, req.LabelIDs); err != nil {
@@ -119,7 +127,8 @@ func (h *TaskHandler) Create(c *gin.Context) {
 			// payload-level rejections here.
 			utils.RespondError(c, http.StatusBadRequest, apperrors.CodeInvalidReference, err.Error(), nil)
 		} else if errors.Is(err, apperrors.ErrInactiveUser) ||
-			errors.Is(err, apperrors.ErrTaskLeadCustomerConflict) {
+			errors.Is(err, apperrors.ErrTaskLeadCustomerConflict) ||
+			errors.Is(err, apperrors.ErrValidation) {
 			utils.RespondBadRequest(c, err.Error())
 		} else {
 			utils.RespondInternalError(c)
@@ -127,6 +136,8 @@ func (h *TaskHandler) Create(c *gin.Context) {
 		return
 	}
 
+	h.recordAudit(c, models.AuditEntityTask, task.ID, models.AuditActionCreate, nil, task)
+
 	utils.LogHandlerResponse(logger, http.StatusCreated, task)
 	utils.RespondSuccess(c, http.StatusCreated, task)
 }
@@ -170,11 +181,9 @@ func (h *TaskHandler) Get(c *gin.Context) {
 
 	// Check permissions
 	currentUserID := c.GetUint("user_id")
-	currentUserRole := c.GetString("user_role")
 
-	// Admin can view all tasks
-	// Non-admin users can only view tasks assigned to them
-	if currentUserRole != string(models.RoleAdmin) && task.AssignedToID != currentUserID {
+	// Without tasks:read:any users can only view tasks assigned to them
+	if !middleware.HasPermission(c, models.PermTasksReadAny) && task.AssignedToID != currentUserID {
 		utils.RespondForbidden(c, "You can only view tasks assigned to you")
 		return
 	}
@@ -197,16 +206,26 @@ func (h *TaskHandler) Get(c *gin.Context) {
 // @Param sort_order query string false "Sort direction; anything else falls back to asc" Enums(asc, desc) default(asc)
 // @Param search query string false "Free-text search across task fields; admin only, takes precedence over sort_by, and is itself overridden by label_id"
 // @Param label_id query int false "Only tasks carrying this label; combinable with sort_by/sort_order for every role"
+// @Param cf.<name> query string false "Only tasks whose custom field <name> holds this value; repeat to require several values. sort_by=cf.<name> sorts by the field. With any custom field parameter, search, label_id and sorting all apply together, for every role"
+// @Param filter query string false "Structured filter, e.g. status ne completed and due_date lt today. Fields: id, title, status, priority, due_date, assigned_to_id, lead_id, customer_id, created_at, updated_at. Like a custom field parameter, it makes search, label_id and sorting apply together, for every role"
+// @Param view_id query int false "Run this saved view: its filter applies together with filter, and its sort unless sort_by is given"
+// @Param cursor query string false "Page by keyset instead of offset: empty for the first page, then the meta.next_cursor of the previous one, with the same sort_by and sort_order. Not combinable with a custom field sort"
 // @Success 200 {object} utils.APIResponse{data=object{tasks=[]models.Task,total=int},meta=utils.APIMeta} "Tasks retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid filter, unknown custom field or invalid custom field value"
 // @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Saved view not found"
 // @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
 // @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
 // @Router /tasks [get]
 func (h *TaskHandler) List(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "TaskHandler.List")
 
+	if !h.applySavedView(c, logger, models.AuditEntityTask) {
+		return
+	}
+
 	currentUserID := c.GetUint("user_id")
-	currentUserRole := c.GetString("user_role")
+	readAny := middleware.HasPermission(c, models.PermTasksReadAny)
 
 	// Support both page-based and offset-based pagination
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "0"))
@@ -218,19 +237,9 @@ func (h *TaskHandler) List(c *gin.Context) {
 	}
 
 	// Parse and validate sort parameters
-	sortBy := c.Query("sort_by")
-	sortOrder := c.DefaultQuery("sort_order", "asc")
-
-	allowedSortColumns := map[string]bool{
-		"created_at": true,
-		"updated_at": true,
-		"title":      true,
-		"status":     true,
-		"priority":   true,
-		"due_date":   true,
-	}
+	sortBy, sortOrder := listSort(c)
 
-	if !allowedSortColumns[sortBy] {
+	if !taskSortColumns[sortBy] {
 		sortBy = ""
 	}
 	if sortOrder != "asc" && sortOrder != "desc" {
@@ -250,16 +259,46 @@ func (h *TaskHandler) List(c *gin.Context) {
 		}
 	}
 
+	customFields, err := h.customFieldQuery(c, models.AuditEntityTask)
+	if err != nil {
+		respondCustomFieldQueryError(c, logger, err)
+		return
+	}
+	where, ok := parseListFilter(c, logger, "tasks")
+	if !ok {
+		return
+	}
+	cursor, ok := parseListCursor(c, logger, "tasks", sortBy, sortOrder, customFields)
+	if !ok {
+		return
+	}
+	fetchOffset, fetchLimit := cursorWindow(cursor, offset, limit)
+
 	var tasks []models.Task
 	var total int64
-	var err error
 
-	// Admin can list all tasks, non-admin users can only list their own tasks
-	if labelID != 0 && currentUserRole != string(models.RoleAdmin) {
+	// Users who may read any task list all tasks, everyone else their own
+	if !customFields.Empty() || where != nil || cursor != nil {
+		// Filters and custom field sorts compose with every other parameter,
+		// within the caller's own tasks unless they may read any task
+		filter := models.TaskFilter{
+			Search:       search,
+			LabelID:      labelID,
+			SortBy:       sortBy,
+			SortOrder:    sortOrder,
+			CustomFields: customFields,
+			Where:        where,
+			Cursor:       cursor,
+		}
+		if !readAny {
+			filter.AssignedToID = currentUserID
+		}
+		tasks, total, err = h.taskService.ListFiltered(filter, fetchOffset, fetchLimit)
+	} else if labelID != 0 && !readAny {
 		tasks, total, err = h.taskService.ListByLabelForAssignee(currentUserID, labelID, offset, limit, sortBy, sortOrder)
 	} else if labelID != 0 {
 		tasks, total, err = h.taskService.ListByLabel(labelID, offset, limit, sortBy, sortOrder)
-	} else if currentUserRole != string(models.RoleAdmin) {
+	} else if !readAny {
 		tasks, total, err = h.taskService.GetByAssignee(currentUserID, offset, limit)
 	} else if search != "" {
 		tasks, total, err = h.taskService.Search(search, offset, limit, sortBy, sortOrder)
@@ -274,6 +313,14 @@ func (h *TaskHandler) List(c *gin.Context) {
 		utils.RespondInternalError(c)
 		return
 	}
+	var next string
+	if cursor != nil {
+		if tasks, next, err = cursorPage(cursor, tasks, limit); err != nil {
+			logger.WithError(err).Error("Failed to encode the next cursor")
+			utils.RespondInternalError(c)
+			return
+		}
+	}
 
 	meta := &utils.APIMeta{
 		RequestID:  c.GetString("request_id"),
@@ -282,12 +329,124 @@ func (h *TaskHandler) List(c *gin.Context) {
 		Total:      total,
 		TotalPages: (total + int64(limit) - 1) / int64(limit),
 	}
+	if cursor != nil {
+		meta = cursorMeta(c, limit, total, next)
+	}
 
 	responseData := gin.H{"tasks": tasks, "total": total}
 	utils.LogHandlerResponse(logger, http.StatusOK, responseData)
 	utils.RespondSuccessWithMeta(c, http.StatusOK, responseData, meta)
 }
 
+// taskSortColumns is the sort allowlist shared by the list and export
+// endpoints.
+var taskSortColumns = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"title":      true,
+	"status":     true,
+	"priority":   true,
+	"due_date":   true,
+}
+
+// taskExportColumns are the columns of the task export, in the order a
+// download without the columns parameter writes them.
+var taskExportColumns = []exportColumn[models.Task]{
+	{"id", func(t *models.Task) interface{} { return t.ID }},
+	{"title", func(t *models.Task) interface{} { return t.Title }},
+	{"description", func(t *models.Task) interface{} { return t.Description }},
+	{"status", func(t *models.Task) interface{} { return string(t.Status) }},
+	{"priority", func(t *models.Task) interface{} { return string(t.Priority) }},
+	{"due_date", func(t *models.Task) interface{} { return exportOptional(t.DueDate) }},
+	{"assigned_to_id", func(t *models.Task) interface{} { return t.AssignedToID }},
+	{"lead_id", func(t *models.Task) interface{} { return exportOptional(t.LeadID) }},
+	{"customer_id", func(t *models.Task) interface{} { return exportOptional(t.CustomerID) }},
+	{"created_at", func(t *models.Task) interface{} { return t.CreatedAt }},
+	{"updated_at", func(t *models.Task) interface{} { return t.UpdatedAt }},
+}
+
+// Export godoc
+// @Summary Export tasks as CSV, XLSX or NDJSON
+// @Description Download every task matching the list parameters as a file (admin role only). There is no pagination: every matching row is included, streamed in batches. The response is the raw file, not the utils.APIResponse envelope; errors before the file starts are still reported through the envelope, and a failure after it has started drops the connection.
+// @Description
+// @Description Columns, in order: id, title, description, status, priority, due_date, assigned_to_id, lead_id, customer_id, created_at, updated_at, then one cf.<name> column per task custom field. columns picks and orders a subset. Timestamps are RFC3339 and a null is an empty cell. In CSV and XLSX a text cell a spreadsheet would run as a formula is prefixed with an apostrophe; NDJSON keeps every value as it is, with its JSON type.
+// @Tags tasks
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/x-ndjson
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param format query string false "File format" Enums(csv, xlsx, ndjson) default(csv)
+// @Param columns query string false "Comma-separated columns to write, in this order; all of them by default"
+// @Param search query string false "Search across task fields"
+// @Param label_id query int false "Only tasks carrying this label"
+// @Param sort_by query string false "Sort column; ignored unless one of the allowed values" Enums(created_at, updated_at, title, status, priority, due_date)
+// @Param sort_order query string false "Sort direction" Enums(asc, desc) default(asc)
+// @Param cf.<name> query string false "Only tasks whose custom field <name> holds this value"
+// @Param filter query string false "Structured filter, as on the list endpoint"
+// @Param view_id query int false "Export what this saved view shows"
+// @Success 200 {string} string "File of tasks (raw body, not the API envelope)"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid format, column, filter or custom field, or a custom field sort"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Saved view not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /tasks/export [get]
+func (h *TaskHandler) Export(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "TaskHandler.Export")
+
+	// Repeats the route's permission guard, as the customer export does.
+	if !middleware.HasPermission(c, models.PermTasksExport) {
+		utils.RespondForbidden(c, "Only administrators can export tasks")
+		return
+	}
+	if !h.applySavedView(c, logger, models.AuditEntityTask) {
+		return
+	}
+
+	sortBy, sortOrder := exportSort(c, taskSortColumns)
+	// Malformed label ids are no filter, as on the list.
+	var labelID uint
+	if parsed, err := strconv.ParseUint(c.Query("label_id"), 10, 32); err == nil && parsed > 0 {
+		labelID = uint(parsed)
+	}
+	customFields, err := h.customFieldQuery(c, models.AuditEntityTask)
+	if err != nil {
+		respondCustomFieldQueryError(c, logger, err)
+		return
+	}
+	if customFields.Sort != nil {
+		utils.RespondBadRequest(c, "an export cannot be sorted by a custom field")
+		return
+	}
+	where, ok := parseListFilter(c, logger, "tasks")
+	if !ok {
+		return
+	}
+	filter := models.TaskFilter{
+		Search:       c.Query("search"),
+		LabelID:      labelID,
+		SortBy:       sortBy,
+		SortOrder:    sortOrder,
+		CustomFields: customFields,
+		Where:        where,
+	}
+
+	definitions, err := h.customFieldDefinitions(models.AuditEntityTask)
+	if err != nil {
+		logger.WithError(err).Error("Failed to load task custom fields for export")
+		utils.RespondInternalError(c)
+		return
+	}
+	columns := append(append([]exportColumn[models.Task]{}, taskExportColumns...),
+		customFieldColumns(definitions, func(t *models.Task) map[string]interface{} { return t.CustomFields })...)
+
+	writeExport(c, logger, "tasks", columns, func(fn func([]models.Task) error) error {
+		return h.taskService.StreamExport(filter, fn)
+	})
+}
+
 // ListMyTasks godoc
 // @Summary List tasks assigned to the current user
 // @Description List the tasks assigned to the authenticated user. Available to every authenticated role, including admins, who also see only their own tasks here. Sorting and search are not supported on this endpoint.
@@ -379,7 +538,7 @@ func (h *TaskHandler) GetUpcoming(c *gin.Context) {
 
 	var tasks []models.Task
 	var err error
-	if c.GetString("user_role") == string(models.RoleAdmin) {
+	if middleware.HasPermission(c, models.PermTasksReadAny) {
 		tasks, err = h.taskService.GetDueWithin(from, to, upcomingTasksMaxResults)
 	} else {
 		tasks, err = h.taskService.GetDueWithinByAssignee(c.GetUint("user_id"), from, to, upcomingTasksMaxResults)
@@ -419,7 +578,6 @@ func (h *TaskHandler) Update(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "TaskHandler.Update")
 
 	currentUserID := c.GetUint("user_id")
-	currentUserRole := c.GetString("user_role")
 
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -449,11 +607,13 @@ func (h *TaskHandler) Update(c *gin.Context) {
 	// Check permissions
 	// Admin can update any task
 	// Non-admin users can only update tasks assigned to them
-	if currentUserRole != string(models.RoleAdmin) && task.AssignedToID != currentUserID {
+	if !middleware.HasPermission(c, models.PermTasksUpdateAny) && task.AssignedToID != currentUserID {
 		utils.RespondForbidden(c, "You can only update tasks assigned to you")
 		return
 	}
 
+	before := h.auditState(task)
+
 	// Apply updates
 	if req.Title != "" {
 		task.Title = req.Title
@@ -473,7 +633,7 @@ func (h *TaskHandler) Update(c *gin.Context) {
 	// Only an actual change of assignee counts as a reassignment; clients routinely
 	// echo back the current assigned_to_id when editing other fields.
 	if req.AssignedToID != 0 && req.AssignedToID != task.AssignedToID {
-		if currentUserRole != string(models.RoleAdmin) {
+		if !middleware.HasPermission(c, models.PermTasksAssign) {
 			utils.RespondForbidden(c, "Only admins can reassign tasks")
 			return
 		}
@@ -485,6 +645,9 @@ func (h *TaskHandler) Update(c *gin.Context) {
 	if req.CustomerID != nil {
 		task.CustomerID = req.CustomerID
 	}
+	if req.CustomFields != nil {
+		task.CustomFields = req.CustomFields
+	}
 
 	if err := h.taskService.UpdateWithLabels(task, req.LabelIDs); err != nil {
 		logger.WithError(err).Error("Failed to update task")
@@ -496,7 +659,8 @@ func (h *TaskHandler) Update(c *gin.Context) {
 			utils.RespondError(c, http.StatusBadRequest, apperrors.CodeInvalidReference, err.Error(), nil)
 		} else if errors.Is(err, apperrors.ErrInactiveUser) ||
 			errors.Is(err, apperrors.ErrTaskLeadCustomerConflict) ||
-			errors.Is(err, apperrors.ErrCompletedTaskModify) {
+			errors.Is(err, apperrors.ErrCompletedTaskModify) ||
+			errors.Is(err, apperrors.ErrValidation) {
 			utils.RespondBadRequest(c, err.Error())
 		} else {
 			utils.RespondInternalError(c)
@@ -504,6 +668,8 @@ func (h *TaskHandler) Update(c *gin.Context) {
 		return
 	}
 
+	h.recordAudit(c, models.AuditEntityTask, task.ID, models.AuditActionUpdate, before, task)
+
 	utils.LogHandlerResponse(logger, http.StatusOK, task)
 	utils.RespondSuccess(c, http.StatusOK, task)
 }
@@ -527,10 +693,8 @@ func (h *TaskHandler) Update(c *gin.Context) {
 func (h *TaskHandler) Delete(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "TaskHandler.Delete")
 
-	currentUserRole := c.GetString("user_role")
-
 	// Only admin users can delete tasks
-	if currentUserRole != string(models.RoleAdmin) {
+	if !middleware.HasPermission(c, models.PermTasksDelete) {
 		utils.RespondForbidden(c, "Only administrators can delete tasks")
 		return
 	}
@@ -541,6 +705,7 @@ func (h *TaskHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	before := h.auditLoad(func() (interface{}, error) { return h.taskService.GetByID(uint(id)) })
 	if err := h.taskService.Delete(uint(id)); err != nil {
 		if apperrors.IsNotFound(err) {
 			logger.WithError(err).Warn("Task not found")
@@ -552,6 +717,8 @@ func (h *TaskHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	h.recordAudit(c, models.AuditEntityTask, uint(id), models.AuditActionDelete, before, nil)
+
 	utils.LogHandlerResponse(logger, http.StatusNoContent, nil)
 	c.Status(http.StatusNoContent)
 }
\ No newline at end of file
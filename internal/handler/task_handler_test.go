@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"archive/zip"
 	"bytes"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"net/http"
@@ -81,6 +83,19 @@ func (m *MockTaskService) Search(query string, offset, limit int, sortBy, sortOr
 	return args.Get(0).([]models.Task), args.Get(1).(int64), args.Error(2)
 }
 
+func (m *MockTaskService) ListFiltered(filter models.TaskFilter, offset, limit int) ([]models.Task, int64, error) {
+	args := m.Called(filter, offset, limit)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]models.Task), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockTaskService) StreamExport(filter models.TaskFilter, fn func([]models.Task) error) error {
+	args := m.Called(filter, fn)
+	return args.Error(0)
+}
+
 func (m *MockTaskService) GetPendingCount() (int64, error) {
 	args := m.Called()
 	return args.Get(0).(int64), args.Error(1)
@@ -1072,4 +1087,107 @@ func (suite *TaskHandlerTestSuite) TestGetUpcoming_ServiceErrorIsInternalError()
 	rec := suite.getUpcoming(models.RoleAdmin, 1, "")
 
 	assert.Equal(suite.T(), http.StatusInternalServerError, rec.Code)
-}
\ No newline at end of file
+}
+// --- Cursor pagination --------------------------------------------------------
+
+func (suite *TaskHandlerTestSuite) TestListTasks_CursorPages() {
+	due := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	page := make([]models.Task, 3)
+	for i := range page {
+		page[i] = models.Task{Title: fmt.Sprintf("Task %d", i), DueDate: &due}
+		page[i].ID = uint(i + 1)
+	}
+
+	// The first page is read with one row more than it shows.
+	suite.mockService.On("ListFiltered", mock.MatchedBy(func(f models.TaskFilter) bool {
+		return f.Cursor != nil && f.Cursor.After == nil && f.Cursor.SortBy == "due_date" && !f.Cursor.Desc
+	}), 0, 3).Return(page, int64(5), nil).Once()
+
+	req, _ := http.NewRequest("GET", "/tasks?cursor=&limit=2&offset=40&sort_by=due_date", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var response struct {
+		Data struct {
+			Tasks []models.Task `json:"tasks"`
+		} `json:"data"`
+		Meta utils.APIMeta `json:"meta"`
+	}
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(suite.T(), response.Data.Tasks, 2)
+	assert.Equal(suite.T(), int64(5), response.Meta.Total)
+	assert.Zero(suite.T(), response.Meta.Page, "a cursor has no page number")
+	suite.Require().NotEmpty(response.Meta.NextCursor)
+
+	// The next page starts after the last task shown, not the one held back.
+	suite.mockService.On("ListFiltered", mock.MatchedBy(func(f models.TaskFilter) bool {
+		return f.Cursor != nil && f.Cursor.After != nil && f.Cursor.After.ID == 2 &&
+			due.Equal(f.Cursor.After.Value.(time.Time))
+	}), 0, 3).Return(page[2:], int64(5), nil).Once()
+
+	req, _ = http.NewRequest("GET", "/tasks?limit=2&sort_by=due_date&cursor="+response.Meta.NextCursor, nil)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	suite.Require().Equal(http.StatusOK, w.Code)
+	response.Meta = utils.APIMeta{}
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(suite.T(), response.Data.Tasks, 1)
+	assert.Empty(suite.T(), response.Meta.NextCursor, "the last page has no next cursor")
+}
+
+func (suite *TaskHandlerTestSuite) TestListTasks_InvalidCursor() {
+	task := models.Task{Title: "Task"}
+	task.ID = 9
+	cursor, err := utils.EncodeCursor(&models.ListCursor{SortBy: "title"}, &task)
+	suite.Require().NoError(err)
+
+	for _, query := range []string{
+		"cursor=not-a-cursor",
+		"cursor=" + cursor + "&sort_by=priority",
+		"cursor=" + cursor + "&sort_by=title&sort_order=desc",
+	} {
+		req, _ := http.NewRequest("GET", "/tasks?"+query, nil)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, query)
+	}
+	suite.mockService.AssertNotCalled(suite.T(), "ListFiltered", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TaskHandlerTestSuite) TestExportTasks_XLSX() {
+	due := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	task := models.Task{Title: "Call back", Status: models.TaskStatusPending, DueDate: &due, AssignedToID: 3}
+	task.ID = 8
+
+	suite.mockService.On("StreamExport", mock.MatchedBy(func(f models.TaskFilter) bool {
+		return f.LabelID == 5 && f.SortBy == "due_date" && f.SortOrder == "asc" && f.AssignedToID == 0
+	}), mock.Anything).Run(func(args mock.Arguments) {
+		_ = args.Get(1).(func([]models.Task) error)([]models.Task{task})
+	}).Return(nil)
+
+	req, _ := http.NewRequest("GET", "/tasks/export?format=xlsx&label_id=5&sort_by=due_date&columns=id,title,due_date", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	suite.Require().Equal(http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", w.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), "attachment; filename=tasks-export.xlsx", w.Header().Get("Content-Disposition"))
+
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	suite.Require().NoError(err)
+	var sheet xlsxSheet
+	for _, file := range archive.File {
+		if file.Name == "xl/worksheets/sheet1.xml" {
+			part, err := file.Open()
+			suite.Require().NoError(err)
+			suite.Require().NoError(xml.NewDecoder(part).Decode(&sheet))
+		}
+	}
+	suite.Require().Len(sheet.Rows, 2)
+	assert.Equal(suite.T(), "8", sheet.Rows[1].Cells[0].V)
+	assert.Equal(suite.T(), "Call back", sheet.Rows[1].Cells[1].Inline)
+	assert.Equal(suite.T(), "2026-05-01T09:00:00Z", sheet.Rows[1].Cells[2].Inline)
+}
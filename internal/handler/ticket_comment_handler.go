@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/middleware"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type TicketCommentHandler struct {
+	commentService  service.TicketCommentService
+	ticketService   service.TicketService
+	customerService service.CustomerService
+}
+
+// NewTicketCommentHandler builds the ticket thread endpoints. The ticket and
+// customer services are used to scope the caller to the tickets they may see,
+// by the same rules as GET /tickets/:id.
+func NewTicketCommentHandler(commentService service.TicketCommentService, ticketService service.TicketService, customerService service.CustomerService) *TicketCommentHandler {
+	return &TicketCommentHandler{
+		commentService:  commentService,
+		ticketService:   ticketService,
+		customerService: customerService,
+	}
+}
+
+type CreateTicketCommentRequest struct {
+	Body       string                         `json:"body" binding:"required"`
+	Visibility models.TicketCommentVisibility `json:"visibility,omitempty" binding:"omitempty,oneof=public internal"`
+}
+
+// List godoc
+// @Summary List a ticket's conversation thread
+// @Description The comments, internal notes and status changes of one ticket, oldest first. Status-change entries (kind "status_change") are recorded automatically whenever the ticket moves between open, in_progress, resolved and closed, carry from_status and to_status, and have no author. Access follows GET /tickets/{id}: admin and sales users may read any ticket's thread; support users only the threads of tickets assigned to them; customer users only the threads of their own tickets, and they see the public entries only. The response data is an object with a "comments" array and a "total" count, alongside pagination metadata.
+// @Tags tickets
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Ticket ID"
+// @Param offset query int false "Result offset" default(0)
+// @Param limit query int false "Page size, capped at 100" default(20)
+// @Success 200 {object} utils.APIResponse{data=object{comments=[]models.TicketComment,total=int},meta=utils.APIMeta} "Comments retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid ticket ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - the ticket is outside the caller's scope"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Ticket not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /tickets/{id}/comments [get]
+func (h *TicketCommentHandler) List(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "TicketCommentHandler.List")
+
+	ticket, ok := h.authorizeTicket(c, logger)
+	if !ok {
+		return
+	}
+
+	includeInternal := middleware.HasPermission(c, models.PermTicketsInternalNotes)
+	offset, limit := utils.ParseOffsetLimit(c)
+
+	comments, total, err := h.commentService.List(ticket.ID, includeInternal, offset, limit)
+	if err != nil {
+		logger.WithError(err).Error("Failed to list ticket comments")
+		utils.RespondInternalError(c)
+		return
+	}
+
+	meta := &utils.APIMeta{
+		RequestID:  c.GetString("request_id"),
+		Page:       (offset / limit) + 1,
+		PerPage:    limit,
+		Total:      total,
+		TotalPages: (total + int64(limit) - 1) / int64(limit),
+	}
+
+	responseData := gin.H{"comments": comments, "total": total}
+	utils.LogHandlerResponse(logger, http.StatusOK, responseData)
+	utils.RespondSuccessWithMeta(c, http.StatusOK, responseData, meta)
+}
+
+// Create godoc
+// @Summary Add a comment to a ticket
+// @Description Append a comment to a ticket's thread. visibility defaults to public; an internal note is visible to staff only. Admin users may comment on any ticket and support users on the tickets assigned to them. Customer users may comment on their own tickets, and only publicly. Sales users are read-only on tickets and are rejected. The body is trimmed and must be between 1 and 10000 characters.
+// @Tags tickets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Ticket ID"
+// @Param request body CreateTicketCommentRequest true "Comment"
+// @Success 201 {object} utils.APIResponse{data=models.TicketComment} "Comment added successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid ticket ID or invalid request data"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - sales users cannot comment, customers cannot post internal notes, or the ticket is outside the caller's scope"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Ticket not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /tickets/{id}/comments [post]
+func (h *TicketCommentHandler) Create(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "TicketCommentHandler.Create")
+
+	// Commenting takes its own permission: sales users read tickets only
+	if !middleware.HasPermission(c, models.PermTicketsComment) {
+		utils.RespondForbidden(c, "Insufficient permissions to comment on tickets")
+		return
+	}
+
+	var req CreateTicketCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	if req.Visibility == models.TicketCommentInternal && !middleware.HasPermission(c, models.PermTicketsInternalNotes) {
+		utils.RespondForbidden(c, "Customers can only post public comments")
+		return
+	}
+
+	ticket, ok := h.authorizeTicket(c, logger)
+	if !ok {
+		return
+	}
+
+	comment, err := h.commentService.AddComment(ticket.ID, c.GetUint("user_id"), req.Body, req.Visibility)
+	if err != nil {
+		switch {
+		case errors.Is(err, apperrors.ErrValidation):
+			utils.RespondBadRequest(c, err.Error())
+		case apperrors.IsNotFound(err):
+			utils.RespondNotFound(c, "Ticket not found")
+		default:
+			logger.WithError(err).Error("Failed to add ticket comment")
+			utils.RespondInternalError(c)
+		}
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusCreated, comment)
+	utils.RespondSuccess(c, http.StatusCreated, comment)
+}
+
+// authorizeTicket loads the ticket named by the path and checks the caller may
+// see it, by the rules of GET /tickets/:id, answering the request itself when
+// not.
+func (h *TicketCommentHandler) authorizeTicket(c *gin.Context, logger *logrus.Entry) (*models.Ticket, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid ticket ID")
+		return nil, false
+	}
+
+	ticket, err := h.ticketService.GetByID(uint(id))
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			logger.WithError(err).Warn("Ticket not found")
+			utils.RespondNotFound(c, "Ticket not found")
+		} else {
+			logger.WithError(err).Error("Failed to get ticket")
+			utils.RespondInternalError(c)
+		}
+		return nil, false
+	}
+
+	if message, ok := canReadTicket(c, h.customerService, ticket); !ok {
+		utils.RespondForbidden(c, message)
+		return nil, false
+	}
+
+	return ticket, true
+}
@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var _ service.TicketCommentService = (*mocks.TicketCommentService)(nil)
+
+type TicketCommentHandlerTestSuite struct {
+	suite.Suite
+	mockCommentService  *mocks.TicketCommentService
+	mockTicketService   *mocks.TicketService
+	mockCustomerService *mocks.CustomerService
+	handler             *TicketCommentHandler
+}
+
+func (suite *TicketCommentHandlerTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *TicketCommentHandlerTestSuite) SetupTest() {
+	suite.mockCommentService = new(mocks.TicketCommentService)
+	suite.mockTicketService = new(mocks.TicketService)
+	suite.mockCustomerService = new(mocks.CustomerService)
+	suite.handler = NewTicketCommentHandler(suite.mockCommentService, suite.mockTicketService, suite.mockCustomerService)
+}
+
+func (suite *TicketCommentHandlerTestSuite) TearDownTest() {
+	suite.mockCommentService.AssertExpectations(suite.T())
+	suite.mockTicketService.AssertExpectations(suite.T())
+	suite.mockCustomerService.AssertExpectations(suite.T())
+}
+
+func (suite *TicketCommentHandlerTestSuite) newRouter(userID uint, role models.UserRole) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Set("user_role", string(role))
+		c.Next()
+		if len(c.Errors) > 0 && c.Errors[0].Type == gin.ErrorTypeBind {
+			utils.RespondValidationError(c, c.Errors[0].Error())
+		}
+	})
+	SetupTicketCommentRoutes(router.Group(""), suite.handler)
+	return router
+}
+
+func (suite *TicketCommentHandlerTestSuite) do(router *gin.Engine, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	if body != nil {
+		payload, _ := json.Marshal(body)
+		reader = bytes.NewReader(payload)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func commentTicket(customerID uint, assigneeID *uint) *models.Ticket {
+	ticket := &models.Ticket{Title: "Printer on fire", CustomerID: customerID, AssignedToID: assigneeID}
+	ticket.ID = 5
+	return ticket
+}
+
+func (suite *TicketCommentHandlerTestSuite) TestList_StaffSeeInternalEntries() {
+	router := suite.newRouter(1, models.RoleSales)
+	suite.mockTicketService.On("GetByID", uint(5)).Return(commentTicket(3, nil), nil)
+	comments := []models.TicketComment{
+		{TicketID: 5, Kind: models.TicketCommentKindComment, Visibility: models.TicketCommentInternal, Body: "Customer is VIP"},
+		{TicketID: 5, Kind: models.TicketCommentKindStatusChange, Visibility: models.TicketCommentPublic,
+			FromStatus: models.TicketStatusOpen, ToStatus: models.TicketStatusInProgress},
+	}
+	suite.mockCommentService.On("List", uint(5), true, 0, 20).Return(comments, int64(2), nil)
+
+	rec := suite.do(router, http.MethodGet, "/tickets/5/comments", nil)
+
+	assert.Equal(suite.T(), http.StatusOK, rec.Code)
+	assert.Contains(suite.T(), rec.Body.String(), "Customer is VIP")
+	assert.Contains(suite.T(), rec.Body.String(), `"to_status":"in_progress"`)
+}
+
+func (suite *TicketCommentHandlerTestSuite) TestList_CustomerSeesPublicEntriesOfOwnTicket() {
+	router := suite.newRouter(10, models.RoleCustomer)
+	customer := &models.Customer{}
+	customer.ID = 3
+	suite.mockTicketService.On("GetByID", uint(5)).Return(commentTicket(3, nil), nil)
+	suite.mockCustomerService.On("GetByUserID", uint(10)).Return(customer, nil)
+	suite.mockCommentService.On("List", uint(5), false, 0, 20).Return([]models.TicketComment{}, int64(0), nil)
+
+	rec := suite.do(router, http.MethodGet, "/tickets/5/comments", nil)
+
+	assert.Equal(suite.T(), http.StatusOK, rec.Code)
+}
+
+func (suite *TicketCommentHandlerTestSuite) TestList_CustomerCannotReadAnotherCustomersTicket() {
+	router := suite.newRouter(10, models.RoleCustomer)
+	customer := &models.Customer{}
+	customer.ID = 4
+	suite.mockTicketService.On("GetByID", uint(5)).Return(commentTicket(3, nil), nil)
+	suite.mockCustomerService.On("GetByUserID", uint(10)).Return(customer, nil)
+
+	rec := suite.do(router, http.MethodGet, "/tickets/5/comments", nil)
+
+	assert.Equal(suite.T(), http.StatusForbidden, rec.Code)
+}
+
+func (suite *TicketCommentHandlerTestSuite) TestList_SupportLimitedToAssignedTickets() {
+	router := suite.newRouter(8, models.RoleSupport)
+	other := uint(9)
+	suite.mockTicketService.On("GetByID", uint(5)).Return(commentTicket(3, &other), nil)
+	suite.mockCustomerService.On("GetByUserID", uint(8)).
+		Return(nil, fmt.Errorf("customer for user 8: %w", apperrors.ErrNotFound))
+
+	rec := suite.do(router, http.MethodGet, "/tickets/5/comments", nil)
+
+	assert.Equal(suite.T(), http.StatusForbidden, rec.Code)
+}
+
+func (suite *TicketCommentHandlerTestSuite) TestList_TicketNotFound() {
+	router := suite.newRouter(1, models.RoleAdmin)
+	suite.mockTicketService.On("GetByID", uint(5)).
+		Return(nil, fmt.Errorf("ticket 5 not found: %w", apperrors.ErrNotFound))
+
+	rec := suite.do(router, http.MethodGet, "/tickets/5/comments", nil)
+
+	assert.Equal(suite.T(), http.StatusNotFound, rec.Code)
+}
+
+func (suite *TicketCommentHandlerTestSuite) TestCreate_SupportAddsInternalNote() {
+	router := suite.newRouter(8, models.RoleSupport)
+	assignee := uint(8)
+	suite.mockTicketService.On("GetByID", uint(5)).Return(commentTicket(3, &assignee), nil)
+	created := &models.TicketComment{TicketID: 5, Body: "Checked the logs", Visibility: models.TicketCommentInternal, AuthorID: &assignee}
+	suite.mockCommentService.On("AddComment", uint(5), uint(8), "Checked the logs", models.TicketCommentInternal).Return(created, nil)
+
+	rec := suite.do(router, http.MethodPost, "/tickets/5/comments",
+		CreateTicketCommentRequest{Body: "Checked the logs", Visibility: models.TicketCommentInternal})
+
+	assert.Equal(suite.T(), http.StatusCreated, rec.Code)
+	assert.Contains(suite.T(), rec.Body.String(), `"visibility":"internal"`)
+}
+
+func (suite *TicketCommentHandlerTestSuite) TestCreate_CustomerRepliesOnOwnTicket() {
+	router := suite.newRouter(10, models.RoleCustomer)
+	customer := &models.Customer{}
+	customer.ID = 3
+	suite.mockTicketService.On("GetByID", uint(5)).Return(commentTicket(3, nil), nil)
+	suite.mockCustomerService.On("GetByUserID", uint(10)).Return(customer, nil)
+	suite.mockCommentService.On("AddComment", uint(5), uint(10), "Still broken", models.TicketCommentVisibility("")).
+		Return(&models.TicketComment{TicketID: 5, Body: "Still broken", Visibility: models.TicketCommentPublic}, nil)
+
+	rec := suite.do(router, http.MethodPost, "/tickets/5/comments", CreateTicketCommentRequest{Body: "Still broken"})
+
+	assert.Equal(suite.T(), http.StatusCreated, rec.Code)
+}
+
+func (suite *TicketCommentHandlerTestSuite) TestCreate_CustomerCannotPostInternalNote() {
+	router := suite.newRouter(10, models.RoleCustomer)
+
+	rec := suite.do(router, http.MethodPost, "/tickets/5/comments",
+		CreateTicketCommentRequest{Body: "psst", Visibility: models.TicketCommentInternal})
+
+	assert.Equal(suite.T(), http.StatusForbidden, rec.Code)
+}
+
+func (suite *TicketCommentHandlerTestSuite) TestCreate_SalesIsReadOnly() {
+	router := suite.newRouter(2, models.RoleSales)
+
+	rec := suite.do(router, http.MethodPost, "/tickets/5/comments", CreateTicketCommentRequest{Body: "hello"})
+
+	assert.Equal(suite.T(), http.StatusForbidden, rec.Code)
+}
+
+func (suite *TicketCommentHandlerTestSuite) TestCreate_InvalidVisibility() {
+	router := suite.newRouter(1, models.RoleAdmin)
+
+	rec := suite.do(router, http.MethodPost, "/tickets/5/comments", map[string]string{"body": "x", "visibility": "secret"})
+
+	assert.Equal(suite.T(), http.StatusBadRequest, rec.Code)
+}
+
+func (suite *TicketCommentHandlerTestSuite) TestCreate_BlankBodyRejectedByService() {
+	router := suite.newRouter(1, models.RoleAdmin)
+	suite.mockTicketService.On("GetByID", uint(5)).Return(commentTicket(3, nil), nil)
+	suite.mockCommentService.On("AddComment", uint(5), uint(1), "   ", mock.Anything).
+		Return(nil, fmt.Errorf("comment body is required: %w", apperrors.ErrValidation))
+
+	rec := suite.do(router, http.MethodPost, "/tickets/5/comments", CreateTicketCommentRequest{Body: "   "})
+
+	assert.Equal(suite.T(), http.StatusBadRequest, rec.Code)
+}
+
+func TestTicketCommentHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(TicketCommentHandlerTestSuite))
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/middleware"
 	"github.com/florinel-chis/gophercrm/internal/models"
 	"github.com/florinel-chis/gophercrm/internal/service"
 	"github.com/florinel-chis/gophercrm/internal/utils"
@@ -13,6 +14,9 @@ import (
 )
 
 type TicketHandler struct {
+	auditTrail
+	customFieldFilters
+	savedViews
 	ticketService   service.TicketService
 	customerService service.CustomerService
 }
@@ -31,6 +35,7 @@ type CreateTicketRequest struct {
 	Priority     models.TicketPriority   `json:"priority,omitempty" binding:"omitempty,oneof=low medium high urgent"`
 	CustomerID   uint                    `json:"customer_id" binding:"required"`
 	AssignedToID *uint                   `json:"assigned_to_id,omitempty"`
+	CustomFields map[string]interface{}  `json:"custom_fields,omitempty"`
 }
 
 type UpdateTicketRequest struct {
@@ -40,11 +45,13 @@ type UpdateTicketRequest struct {
 	Priority     models.TicketPriority   `json:"priority,omitempty" binding:"omitempty,oneof=low medium high urgent"`
 	AssignedToID *uint                   `json:"assigned_to_id,omitempty"`
 	Resolution   string                  `json:"resolution,omitempty"`
+	// CustomFields sets the fields it names; a null value clears one.
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
 }
 
 // Create godoc
 // @Summary Create a new ticket
-// @Description Create a support ticket. Only support and admin users may create tickets; sales and customer users are rejected. When assigned_to_id is omitted the ticket is assigned to the caller. The assignee, if given, must exist and hold the support or admin role.
+// @Description Create a support ticket. Only support and admin users may create tickets; sales and customer users are rejected. When assigned_to_id is omitted the ticket is assigned to the caller. The assignee, if given, must exist and hold a role, built-in or custom, with the tickets:update permission (of the built-in roles, support and admin).
 // @Tags tickets
 // @Accept json
 // @Produce json
@@ -63,10 +70,9 @@ func (h *TicketHandler) Create(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "TicketHandler.Create")
 
 	currentUserID := c.GetUint("user_id")
-	currentUserRole := c.GetString("user_role")
 	
 	// Only support and admin users can create tickets
-	if currentUserRole != string(models.RoleSupport) && currentUserRole != string(models.RoleAdmin) {
+	if !middleware.HasPermission(c, models.PermTicketsCreate) {
 		utils.RespondForbidden(c, "Only support and admin users can create tickets")
 		return
 	}
@@ -84,6 +90,7 @@ func (h *TicketHandler) Create(c *gin.Context) {
 		CustomerID:   req.CustomerID,
 		AssignedToID: req.AssignedToID,
 		Status:       models.TicketStatusOpen,
+		CustomFields: requestCustomFields(req.CustomFields),
 	}
 
 	// If no assignee specified, assign to current user
@@ -95,7 +102,8 @@ func (h *TicketHandler) Create(c *gin.Context) {
 		logger.WithError(err).Error("Failed to create ticket")
 		if errors.Is(err, apperrors.ErrCustomerNotFound) {
 			utils.RespondNotFound(c, "customer not found")
-		} else if errors.Is(err, apperrors.ErrAssigneeNotFound) || errors.Is(err, apperrors.ErrInvalidAssigneeRole) {
+		} else if errors.Is(err, apperrors.ErrAssigneeNotFound) || errors.Is(err, apperrors.ErrInvalidAssigneeRole) ||
+			errors.Is(err, apperrors.ErrValidation) {
 			utils.RespondBadRequest(c, err.Error())
 		} else {
 			utils.RespondInternalError(c)
@@ -103,6 +111,8 @@ func (h *TicketHandler) Create(c *gin.Context) {
 		return
 	}
 
+	h.recordAudit(c, models.AuditEntityTicket, ticket.ID, models.AuditActionCreate, nil, ticket)
+
 	utils.LogHandlerResponse(logger, http.StatusCreated, ticket)
 	utils.RespondSuccess(c, http.StatusCreated, ticket)
 }
@@ -120,20 +130,27 @@ func (h *TicketHandler) Create(c *gin.Context) {
 // @Param sort_by query string false "Sort column; ignored unless one of the allowed values" Enums(created_at, updated_at, title, status, priority)
 // @Param sort_order query string false "Sort direction; anything else falls back to asc" Enums(asc, desc) default(asc)
 // @Param search query string false "Free-text search across ticket fields; takes precedence over the plain sorted listing"
+// @Param cf.<name> query string false "Only tickets whose custom field <name> holds this value; repeat to require several values. sort_by=cf.<name> sorts by the field"
+// @Param filter query string false "Structured filter, e.g. (priority eq high or priority eq urgent) and status ne closed and assigned_to_id eq null. Fields: id, title, status, priority, customer_id, assigned_to_id, first_response_due_at, resolution_due_at, first_responded_at, resolved_at, first_response_breached, resolution_breached, created_at, updated_at"
+// @Param view_id query int false "Run this saved view: its filter applies together with filter, and its sort unless sort_by is given"
+// @Param cursor query string false "Page by keyset instead of offset: empty for the first page, then the meta.next_cursor of the previous one, with the same sort_by and sort_order. Not combinable with a custom field sort"
 // @Success 200 {object} utils.APIResponse{data=object,meta=utils.APIMeta} "Tickets retrieved successfully"
 // @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid filter, unknown custom field or invalid custom field value"
 // @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - customers cannot list all tickets"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Saved view not found"
 // @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
 // @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
 // @Router /tickets [get]
 func (h *TicketHandler) List(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "TicketHandler.List")
 
-	currentUserRole := c.GetString("user_role")
+	if !middleware.HasPermission(c, models.PermTicketsList) {
+		utils.RespondForbidden(c, "Insufficient permissions to list tickets")
+		return
+	}
 
-	// Customer users cannot list all tickets
-	if currentUserRole == string(models.RoleCustomer) {
-		utils.RespondForbidden(c, "Customers cannot list all tickets")
+	if !h.applySavedView(c, logger, models.AuditEntityTicket) {
 		return
 	}
 
@@ -147,18 +164,9 @@ func (h *TicketHandler) List(c *gin.Context) {
 	}
 
 	// Parse and validate sort parameters
-	sortBy := c.Query("sort_by")
-	sortOrder := c.DefaultQuery("sort_order", "asc")
+	sortBy, sortOrder := listSort(c)
 
-	allowedSortColumns := map[string]bool{
-		"created_at": true,
-		"updated_at": true,
-		"title":      true,
-		"status":     true,
-		"priority":   true,
-	}
-
-	if !allowedSortColumns[sortBy] {
+	if !ticketSortColumns[sortBy] {
 		sortBy = ""
 	}
 	if sortOrder != "asc" && sortOrder != "desc" {
@@ -167,11 +175,34 @@ func (h *TicketHandler) List(c *gin.Context) {
 
 	search := c.Query("search")
 
+	customFields, err := h.customFieldQuery(c, models.AuditEntityTicket)
+	if err != nil {
+		respondCustomFieldQueryError(c, logger, err)
+		return
+	}
+	where, ok := parseListFilter(c, logger, "tickets")
+	if !ok {
+		return
+	}
+	cursor, ok := parseListCursor(c, logger, "tickets", sortBy, sortOrder, customFields)
+	if !ok {
+		return
+	}
+	fetchOffset, fetchLimit := cursorWindow(cursor, offset, limit)
+
 	var tickets []models.Ticket
 	var total int64
-	var err error
 
-	if search != "" {
+	if !customFields.Empty() || where != nil || cursor != nil {
+		tickets, total, err = h.ticketService.ListFiltered(models.TicketFilter{
+			Search:       search,
+			SortBy:       sortBy,
+			SortOrder:    sortOrder,
+			CustomFields: customFields,
+			Where:        where,
+			Cursor:       cursor,
+		}, fetchOffset, fetchLimit)
+	} else if search != "" {
 		tickets, total, err = h.ticketService.Search(search, offset, limit, sortBy, sortOrder)
 	} else if sortBy != "" {
 		tickets, total, err = h.ticketService.ListSorted(offset, limit, sortBy, sortOrder)
@@ -184,6 +215,14 @@ func (h *TicketHandler) List(c *gin.Context) {
 		utils.RespondInternalError(c)
 		return
 	}
+	var next string
+	if cursor != nil {
+		if tickets, next, err = cursorPage(cursor, tickets, limit); err != nil {
+			logger.WithError(err).Error("Failed to encode the next cursor")
+			utils.RespondInternalError(c)
+			return
+		}
+	}
 
 	meta := &utils.APIMeta{
 		RequestID:  c.GetString("request_id"),
@@ -192,12 +231,121 @@ func (h *TicketHandler) List(c *gin.Context) {
 		Total:      total,
 		TotalPages: (total + int64(limit) - 1) / int64(limit),
 	}
+	if cursor != nil {
+		meta = cursorMeta(c, limit, total, next)
+	}
 
 	responseData := gin.H{"tickets": tickets, "total": total}
 	utils.LogHandlerResponse(logger, http.StatusOK, responseData)
 	utils.RespondSuccessWithMeta(c, http.StatusOK, responseData, meta)
 }
 
+// ticketSortColumns is the sort allowlist shared by the list and export
+// endpoints.
+var ticketSortColumns = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"title":      true,
+	"status":     true,
+	"priority":   true,
+}
+
+// ticketExportColumns are the columns of the ticket export, in the order a
+// download without the columns parameter writes them.
+var ticketExportColumns = []exportColumn[models.Ticket]{
+	{"id", func(t *models.Ticket) interface{} { return t.ID }},
+	{"title", func(t *models.Ticket) interface{} { return t.Title }},
+	{"description", func(t *models.Ticket) interface{} { return t.Description }},
+	{"status", func(t *models.Ticket) interface{} { return string(t.Status) }},
+	{"priority", func(t *models.Ticket) interface{} { return string(t.Priority) }},
+	{"customer_id", func(t *models.Ticket) interface{} { return t.CustomerID }},
+	{"assigned_to_id", func(t *models.Ticket) interface{} { return exportOptional(t.AssignedToID) }},
+	{"resolution", func(t *models.Ticket) interface{} { return t.Resolution }},
+	{"first_response_due_at", func(t *models.Ticket) interface{} { return exportOptional(t.FirstResponseDueAt) }},
+	{"resolution_due_at", func(t *models.Ticket) interface{} { return exportOptional(t.ResolutionDueAt) }},
+	{"first_responded_at", func(t *models.Ticket) interface{} { return exportOptional(t.FirstRespondedAt) }},
+	{"resolved_at", func(t *models.Ticket) interface{} { return exportOptional(t.ResolvedAt) }},
+	{"first_response_breached", func(t *models.Ticket) interface{} { return t.FirstResponseBreached }},
+	{"resolution_breached", func(t *models.Ticket) interface{} { return t.ResolutionBreached }},
+	{"created_at", func(t *models.Ticket) interface{} { return t.CreatedAt }},
+	{"updated_at", func(t *models.Ticket) interface{} { return t.UpdatedAt }},
+}
+
+// Export godoc
+// @Summary Export tickets as CSV, XLSX or NDJSON
+// @Description Download every ticket matching the list parameters as a file (admin role only). There is no pagination: every matching row is included, streamed in batches. The response is the raw file, not the utils.APIResponse envelope; errors before the file starts are still reported through the envelope, and a failure after it has started drops the connection.
+// @Description
+// @Description Columns, in order: id, title, description, status, priority, customer_id, assigned_to_id, resolution, first_response_due_at, resolution_due_at, first_responded_at, resolved_at, first_response_breached, resolution_breached, created_at, updated_at, then one cf.<name> column per ticket custom field. columns picks and orders a subset. Timestamps are RFC3339 and a null is an empty cell. In CSV and XLSX a text cell a spreadsheet would run as a formula is prefixed with an apostrophe; NDJSON keeps every value as it is, with its JSON type.
+// @Tags tickets
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/x-ndjson
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param format query string false "File format" Enums(csv, xlsx, ndjson) default(csv)
+// @Param columns query string false "Comma-separated columns to write, in this order; all of them by default"
+// @Param search query string false "Search across ticket fields"
+// @Param sort_by query string false "Sort column; ignored unless one of the allowed values" Enums(created_at, updated_at, title, status, priority)
+// @Param sort_order query string false "Sort direction" Enums(asc, desc) default(asc)
+// @Param cf.<name> query string false "Only tickets whose custom field <name> holds this value"
+// @Param filter query string false "Structured filter, as on the list endpoint"
+// @Param view_id query int false "Export what this saved view shows"
+// @Success 200 {string} string "File of tickets (raw body, not the API envelope)"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid format, column, filter or custom field, or a custom field sort"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Saved view not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /tickets/export [get]
+func (h *TicketHandler) Export(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "TicketHandler.Export")
+
+	// Repeats the route's permission guard, as the customer export does.
+	if !middleware.HasPermission(c, models.PermTicketsExport) {
+		utils.RespondForbidden(c, "Only administrators can export tickets")
+		return
+	}
+	if !h.applySavedView(c, logger, models.AuditEntityTicket) {
+		return
+	}
+
+	sortBy, sortOrder := exportSort(c, ticketSortColumns)
+	customFields, err := h.customFieldQuery(c, models.AuditEntityTicket)
+	if err != nil {
+		respondCustomFieldQueryError(c, logger, err)
+		return
+	}
+	if customFields.Sort != nil {
+		utils.RespondBadRequest(c, "an export cannot be sorted by a custom field")
+		return
+	}
+	where, ok := parseListFilter(c, logger, "tickets")
+	if !ok {
+		return
+	}
+	filter := models.TicketFilter{
+		Search:       c.Query("search"),
+		SortBy:       sortBy,
+		SortOrder:    sortOrder,
+		CustomFields: customFields,
+		Where:        where,
+	}
+
+	definitions, err := h.customFieldDefinitions(models.AuditEntityTicket)
+	if err != nil {
+		logger.WithError(err).Error("Failed to load ticket custom fields for export")
+		utils.RespondInternalError(c)
+		return
+	}
+	columns := append(append([]exportColumn[models.Ticket]{}, ticketExportColumns...),
+		customFieldColumns(definitions, func(t *models.Ticket) map[string]interface{} { return t.CustomFields })...)
+
+	writeExport(c, logger, "tickets", columns, func(fn func([]models.Ticket) error) error {
+		return h.ticketService.StreamExport(filter, fn)
+	})
+}
+
 // ListByCustomer godoc
 // @Summary List tickets for a customer
 // @Description List the tickets belonging to one customer. Admin, sales and support users may query any customer; a customer user may only query the customer record linked to their own account. The response data is an object with a "tickets" array and a "total" count, alongside pagination metadata.
@@ -224,18 +372,11 @@ func (h *TicketHandler) ListByCustomer(c *gin.Context) {
 		return
 	}
 
-	// Customer users may only list tickets belonging to their own customer record.
-	currentUserID := c.GetUint("user_id")
-	if c.GetString("user_role") == string(models.RoleCustomer) {
-		if h.customerService == nil {
-			utils.RespondForbidden(c, "Customers can only view their own tickets")
-			return
-		}
-		customer, err := h.customerService.GetByUserID(currentUserID)
-		if err != nil || customer == nil || customer.ID != uint(customerID) {
-			utils.RespondForbidden(c, "Customers can only view their own tickets")
-			return
-		}
+	// Without tickets:list users may only list the tickets of their own
+	// customer record.
+	if !middleware.HasPermission(c, models.PermTicketsList) && !h.ownCustomer(c, uint(customerID)) {
+		utils.RespondForbidden(c, "Customers can only view their own tickets")
+		return
 	}
 
 	offset, limit := utils.ParseOffsetLimit(c)
@@ -279,10 +420,9 @@ func (h *TicketHandler) ListMyTickets(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "TicketHandler.ListMyTickets")
 	
 	currentUserID := c.GetUint("user_id")
-	currentUserRole := c.GetString("user_role")
-	
-	// Only support and admin users can have tickets assigned
-	if currentUserRole == string(models.RoleCustomer) {
+
+	// Only staff who work the ticket queue can have tickets assigned
+	if !middleware.HasPermission(c, models.PermTicketsList) {
 		utils.RespondForbidden(c, "Customers cannot have tickets assigned to them")
 		return
 	}
@@ -346,35 +486,8 @@ func (h *TicketHandler) Get(c *gin.Context) {
 		return
 	}
 
-	// Check permissions
-	currentUserID := c.GetUint("user_id")
-	currentUserRole := c.GetString("user_role")
-	
-	// Customer users can only view tickets for their associated customer record
-	if currentUserRole == string(models.RoleCustomer) {
-		if h.customerService == nil {
-			utils.RespondForbidden(c, "Customers can only view their own tickets")
-			return
-		}
-		customer, err := h.customerService.GetByUserID(currentUserID)
-		if err != nil || customer == nil {
-			utils.RespondForbidden(c, "Customers can only view their own tickets")
-			return
-		}
-		if ticket.CustomerID != customer.ID {
-			utils.RespondForbidden(c, "Customers can only view their own tickets")
-			return
-		}
-		// Customer is associated with this ticket - allow access
-		utils.LogHandlerResponse(logger, http.StatusOK, ticket)
-		utils.RespondSuccess(c, http.StatusOK, ticket)
-		return
-	}
-	
-	// Support users can only view assigned tickets
-	if currentUserRole == string(models.RoleSupport) && 
-		(ticket.AssignedToID == nil || *ticket.AssignedToID != currentUserID) {
-		utils.RespondForbidden(c, "You can only view tickets assigned to you")
+	if message, ok := canReadTicket(c, h.customerService, ticket); !ok {
+		utils.RespondForbidden(c, message)
 		return
 	}
 
@@ -384,7 +497,7 @@ func (h *TicketHandler) Get(c *gin.Context) {
 
 // Update godoc
 // @Summary Update a ticket
-// @Description Update a ticket. Customer and sales users are rejected outright — the sales role is read-only on tickets; support users may only update tickets assigned to them; admin users may update any ticket. Only non-empty fields are applied. A closed ticket cannot be moved back to another status, and a new assignee must exist and hold the support or admin role.
+// @Description Update a ticket. Customer and sales users are rejected outright — the sales role is read-only on tickets; support users may only update tickets assigned to them; admin users may update any ticket. Only non-empty fields are applied. A closed ticket cannot be moved back to another status, and a new assignee must exist and hold a role with the tickets:update permission. Changing the assignee takes the tickets:assign permission, which the support and admin roles hold.
 // @Tags tickets
 // @Accept json
 // @Produce json
@@ -395,7 +508,7 @@ func (h *TicketHandler) Get(c *gin.Context) {
 // @Success 200 {object} utils.APIResponse{data=models.Ticket} "Ticket updated successfully"
 // @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid ticket ID, invalid request data, attempt to reopen a closed ticket, unknown assignee, or assignee is not a support/admin user"
 // @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
-// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - customers and sales users cannot update tickets (sales is read-only) and support users only their own assignments"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - customers and sales users cannot update tickets (sales is read-only), support users only their own assignments, and reassigning takes tickets:assign"
 // @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Ticket not found"
 // @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
 // @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
@@ -404,17 +517,10 @@ func (h *TicketHandler) Update(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "TicketHandler.Update")
 	
 	currentUserID := c.GetUint("user_id")
-	currentUserRole := c.GetString("user_role")
-	
-	// Customers cannot update tickets
-	if currentUserRole == string(models.RoleCustomer) {
-		utils.RespondForbidden(c, "Customers cannot update tickets")
-		return
-	}
 
-	// Sales users are read-only on tickets
-	if currentUserRole == string(models.RoleSales) {
-		utils.RespondForbidden(c, "Sales users cannot update tickets")
+	// Customers, and sales users who are read-only on tickets, cannot update them
+	if !middleware.HasPermission(c, models.PermTicketsUpdate) {
+		utils.RespondForbidden(c, "Insufficient permissions to update tickets")
 		return
 	}
 
@@ -444,12 +550,20 @@ func (h *TicketHandler) Update(c *gin.Context) {
 	}
 
 	// Check permissions
-	if currentUserRole == string(models.RoleSupport) && 
-		(ticket.AssignedToID == nil || *ticket.AssignedToID != currentUserID) {
+	if !middleware.HasPermission(c, models.PermTicketsUpdateAny) && !assignedTo(ticket, currentUserID) {
 		utils.RespondForbidden(c, "You can only update tickets assigned to you")
 		return
 	}
 
+	// Reassigning a ticket takes its own permission
+	if req.AssignedToID != nil && !sameAssignee(ticket.AssignedToID, *req.AssignedToID) &&
+		!middleware.HasPermission(c, models.PermTicketsAssign) {
+		utils.RespondForbidden(c, "Insufficient permissions to assign tickets")
+		return
+	}
+
+	before := h.auditState(ticket)
+
 	// Apply updates
 	if req.Title != "" {
 		ticket.Title = req.Title
@@ -469,12 +583,16 @@ func (h *TicketHandler) Update(c *gin.Context) {
 	if req.Resolution != "" {
 		ticket.Resolution = req.Resolution
 	}
+	if req.CustomFields != nil {
+		ticket.CustomFields = req.CustomFields
+	}
 
 	if err := h.ticketService.Update(ticket); err != nil {
 		logger.WithError(err).Error("Failed to update ticket")
 		if errors.Is(err, apperrors.ErrClosedTicketReopen) ||
 			errors.Is(err, apperrors.ErrAssigneeNotFound) ||
-			errors.Is(err, apperrors.ErrInvalidAssigneeRole) {
+			errors.Is(err, apperrors.ErrInvalidAssigneeRole) ||
+			errors.Is(err, apperrors.ErrValidation) {
 			utils.RespondBadRequest(c, err.Error())
 		} else {
 			utils.RespondInternalError(c)
@@ -482,6 +600,8 @@ func (h *TicketHandler) Update(c *gin.Context) {
 		return
 	}
 
+	h.recordAudit(c, models.AuditEntityTicket, ticket.ID, models.AuditActionUpdate, before, ticket)
+
 	utils.LogHandlerResponse(logger, http.StatusOK, ticket)
 	utils.RespondSuccess(c, http.StatusOK, ticket)
 }
@@ -505,10 +625,8 @@ func (h *TicketHandler) Update(c *gin.Context) {
 func (h *TicketHandler) Delete(c *gin.Context) {
 	logger := utils.LogHandlerStart(c, "TicketHandler.Delete")
 	
-	currentUserRole := c.GetString("user_role")
-	
 	// Only admin users can delete tickets
-	if currentUserRole != string(models.RoleAdmin) {
+	if !middleware.HasPermission(c, models.PermTicketsDelete) {
 		utils.RespondForbidden(c, "Only administrators can delete tickets")
 		return
 	}
@@ -519,6 +637,7 @@ func (h *TicketHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	before := h.auditLoad(func() (interface{}, error) { return h.ticketService.GetByID(uint(id)) })
 	if err := h.ticketService.Delete(uint(id)); err != nil {
 		if apperrors.IsNotFound(err) {
 			logger.WithError(err).Warn("Ticket not found")
@@ -530,6 +649,61 @@ func (h *TicketHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	h.recordAudit(c, models.AuditEntityTicket, uint(id), models.AuditActionDelete, before, nil)
+
 	utils.LogHandlerResponse(logger, http.StatusNoContent, nil)
 	c.Status(http.StatusNoContent)
-}
\ No newline at end of file
+}
+
+// sameAssignee reports whether a ticket is already assigned to userID.
+func sameAssignee(assignedToID *uint, userID uint) bool {
+	return assignedToID != nil && *assignedToID == userID
+}
+
+// assignedTo reports whether ticket is assigned to userID.
+func assignedTo(ticket *models.Ticket, userID uint) bool {
+	return sameAssignee(ticket.AssignedToID, userID)
+}
+
+// ownCustomer reports whether customerID is the customer record of the caller.
+func (h *TicketHandler) ownCustomer(c *gin.Context, customerID uint) bool {
+	customer := customerRecord(h.customerService, c.GetUint("user_id"))
+	return customer != nil && customer.ID == customerID
+}
+
+// canReadTicket reports whether the caller may read ticket, and why not when
+// they may not: tickets:read:any reads every ticket, tickets:read:own the ones
+// assigned to the caller and the ones raised by their customer record.
+func canReadTicket(c *gin.Context, customers service.CustomerService, ticket *models.Ticket) (string, bool) {
+	if middleware.HasPermission(c, models.PermTicketsReadAny) {
+		return "", true
+	}
+	if !middleware.HasPermission(c, models.PermTicketsReadOwn) {
+		return "Insufficient permissions to view tickets", false
+	}
+	userID := c.GetUint("user_id")
+	if assignedTo(ticket, userID) {
+		return "", true
+	}
+	customer := customerRecord(customers, userID)
+	if customer == nil {
+		return "You can only view tickets assigned to you", false
+	}
+	if ticket.CustomerID != customer.ID {
+		return "Customers can only view their own tickets", false
+	}
+	return "", true
+}
+
+// customerRecord is the customer record linked to a user, or nil when there
+// is none or it cannot be looked up.
+func customerRecord(customers service.CustomerService, userID uint) *models.Customer {
+	if customers == nil {
+		return nil
+	}
+	customer, err := customers.GetByUserID(userID)
+	if err != nil {
+		return nil
+	}
+	return customer
+}
@@ -450,7 +450,7 @@ func (suite *TicketHandlerTestSuite) TestUpdate_Sales_Forbidden() {
 	assert.NoError(suite.T(), err)
 	assert.False(suite.T(), response.Success)
 	if assert.NotNil(suite.T(), response.Error) {
-		assert.Equal(suite.T(), "Sales users cannot update tickets", response.Error.Message)
+		assert.Equal(suite.T(), "Insufficient permissions to update tickets", response.Error.Message)
 	}
 }
 
@@ -0,0 +1,315 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// TwoFactorHandler serves a user's own two-factor settings, and the reset an
+// administrator performs for a user who lost their authenticator.
+type TwoFactorHandler struct {
+	auditTrail
+	twoFactorService service.TwoFactorService
+}
+
+func NewTwoFactorHandler(twoFactorService service.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{twoFactorService: twoFactorService}
+}
+
+// TwoFactorCodeRequest carries a six-digit TOTP code, or a recovery code
+// where the endpoint accepts one.
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// DisableTwoFactorRequest is the body of POST /auth/2fa/disable. Both the
+// password and a code are required, so neither a stolen session nor a stolen
+// phone alone can switch the second factor off.
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// RecoveryCodesResponse lists recovery codes, shown only in the response
+// that generated them.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// twoFactorUser returns the caller, who must have logged in with a password.
+// An API key is not a login, so it may not change the second factor of its
+// owner.
+func twoFactorUser(c *gin.Context) (uint, bool) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		utils.RespondUnauthorized(c, "Unauthorized")
+		return 0, false
+	}
+	if c.GetUint("api_key_id") != 0 {
+		utils.RespondForbidden(c, "Two-factor settings cannot be managed with an API key")
+		return 0, false
+	}
+	return userID, true
+}
+
+// Status godoc
+// @Summary Get the caller's two-factor status
+// @Description Whether two-factor authentication is enabled, whether the caller's role requires it, and how many unused recovery codes remain.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=models.TwoFactorStatus} "Two-factor status"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Called with an API key"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /auth/2fa [get]
+func (h *TwoFactorHandler) Status(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "TwoFactorHandler.Status")
+
+	userID, ok := twoFactorUser(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.twoFactorService.Status(userID)
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, status)
+	utils.RespondSuccess(c, http.StatusOK, status)
+}
+
+// Setup godoc
+// @Summary Start enrolling an authenticator
+// @Description Generates a TOTP secret (RFC 6238: SHA1, six digits, 30 seconds) and returns it with its otpauth:// URI, usually shown as a QR code. Two-factor authentication is enabled only once POST /auth/2fa/verify confirms a code of the new authenticator; calling setup again before that replaces the secret.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=models.TwoFactorSetup} "Secret and otpauth URI"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Called with an API key"
+// @Failure 409 {object} utils.APIResponse{error=utils.APIError} "Two-factor authentication is already enabled"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /auth/2fa/setup [post]
+func (h *TwoFactorHandler) Setup(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "TwoFactorHandler.Setup")
+
+	userID, ok := twoFactorUser(c)
+	if !ok {
+		return
+	}
+
+	setup, err := h.twoFactorService.Setup(userID)
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	// The secret is deliberately not logged.
+	utils.LogHandlerResponse(logger, http.StatusOK, "two-factor setup started")
+	utils.RespondSuccess(c, http.StatusOK, setup)
+}
+
+// Verify godoc
+// @Summary Confirm the authenticator and enable two-factor authentication
+// @Description Checks a code of the authenticator enrolled through POST /auth/2fa/setup and enables two-factor authentication. The response holds ten single-use recovery codes, each of which stands in for a code once; they are stored only as hashes and never shown again.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body TwoFactorCodeRequest true "Code of the new authenticator"
+// @Success 200 {object} utils.APIResponse{data=RecoveryCodesResponse} "Enabled; recovery codes returned"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Malformed body, invalid code, or setup not started"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Called with an API key"
+// @Failure 409 {object} utils.APIResponse{error=utils.APIError} "Two-factor authentication is already enabled"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /auth/2fa/verify [post]
+func (h *TwoFactorHandler) Verify(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "TwoFactorHandler.Verify")
+
+	userID, ok := twoFactorUser(c)
+	if !ok {
+		return
+	}
+
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	codes, err := h.twoFactorService.Verify(userID, req.Code)
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityUser, userID, models.AuditActionUpdate,
+		map[string]interface{}{"two_factor_enabled": false}, map[string]interface{}{"two_factor_enabled": true})
+
+	utils.LogHandlerResponse(logger, http.StatusOK, "two-factor authentication enabled")
+	utils.RespondSuccess(c, http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// Disable godoc
+// @Summary Disable two-factor authentication
+// @Description Turns two-factor authentication off and deletes the recovery codes. Takes the current password and a code (a TOTP code or a recovery code). Refused when the caller's role requires two-factor authentication.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body DisableTwoFactorRequest true "Password and code"
+// @Success 200 {object} utils.APIResponse{data=MessageResponse} "Disabled"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Malformed body, wrong password, invalid code, or two-factor authentication not enabled"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Called with an API key, or the caller's role requires two-factor authentication"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /auth/2fa/disable [post]
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "TwoFactorHandler.Disable")
+
+	userID, ok := twoFactorUser(c)
+	if !ok {
+		return
+	}
+
+	var req DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	if err := h.twoFactorService.Disable(userID, req.Password, req.Code); err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityUser, userID, models.AuditActionUpdate,
+		map[string]interface{}{"two_factor_enabled": true}, map[string]interface{}{"two_factor_enabled": false})
+
+	response := MessageResponse{Message: "Two-factor authentication disabled"}
+	utils.LogHandlerResponse(logger, http.StatusOK, response)
+	utils.RespondSuccess(c, http.StatusOK, response)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Replace the recovery codes
+// @Description Checks a code (a TOTP code or a recovery code) and replaces every recovery code with ten new ones, returned only in this response.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body TwoFactorCodeRequest true "Code"
+// @Success 200 {object} utils.APIResponse{data=RecoveryCodesResponse} "New recovery codes"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Malformed body, invalid code, or two-factor authentication not enabled"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Called with an API key"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /auth/2fa/recovery-codes [post]
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "TwoFactorHandler.RegenerateRecoveryCodes")
+
+	userID, ok := twoFactorUser(c)
+	if !ok {
+		return
+	}
+
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(userID, req.Code)
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, "recovery codes regenerated")
+	utils.RespondSuccess(c, http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// Reset godoc
+// @Summary Reset a user's two-factor authentication
+// @Description Turns a user's two-factor authentication off and deletes their recovery codes, for a user who lost both. If their role requires two-factor authentication they enroll again at their next login. Requires users:update, which only admins hold by default.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} utils.APIResponse{data=MessageResponse} "Reset"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid user ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - users:update permission required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "User not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /users/{id}/2fa [delete]
+func (h *TwoFactorHandler) Reset(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "TwoFactorHandler.Reset")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid user ID")
+		return
+	}
+
+	before := h.auditLoad(func() (interface{}, error) {
+		status, err := h.twoFactorService.Status(uint(id))
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"two_factor_enabled": status.Enabled}, nil
+	})
+	if err := h.twoFactorService.Reset(uint(id)); err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityUser, uint(id), models.AuditActionUpdate,
+		before, map[string]interface{}{"two_factor_enabled": false})
+
+	response := MessageResponse{Message: "Two-factor authentication reset"}
+	utils.LogHandlerResponse(logger, http.StatusOK, response)
+	utils.RespondSuccess(c, http.StatusOK, response)
+}
+
+func (h *TwoFactorHandler) respondError(c *gin.Context, logger *logrus.Entry, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		logger.WithError(err).Warn("Invalid two-factor code")
+		utils.RespondBadRequest(c, "Invalid two-factor code")
+	case errors.Is(err, service.ErrInvalidCurrentPassword):
+		logger.WithError(err).Warn("Wrong password")
+		utils.RespondBadRequest(c, "The password is incorrect")
+	case errors.Is(err, service.ErrTwoFactorNotEnabled):
+		utils.RespondBadRequest(c, "Two-factor authentication is not enabled")
+	case errors.Is(err, service.ErrTwoFactorEnabled):
+		utils.RespondConflict(c, "Two-factor authentication is already enabled")
+	case errors.Is(err, apperrors.ErrForbidden):
+		logger.WithError(err).Warn("Two-factor change refused")
+		utils.RespondForbidden(c, "Your role requires two-factor authentication")
+	case apperrors.IsNotFound(err):
+		utils.RespondNotFound(c, "User not found")
+	default:
+		logger.WithError(err).Error("Two-factor operation failed")
+		utils.RespondInternalError(c)
+	}
+}
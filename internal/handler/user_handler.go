@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/middleware"
 	"github.com/florinel-chis/gophercrm/internal/models"
 	"github.com/florinel-chis/gophercrm/internal/service"
 	"github.com/florinel-chis/gophercrm/internal/utils"
@@ -13,6 +14,7 @@ import (
 )
 
 type UserHandler struct {
+	auditTrail
 	userService service.UserService
 }
 
@@ -73,6 +75,11 @@ func (h *UserHandler) Create(c *gin.Context) {
 		return
 	}
 
+	if !canGrantRole(c, req.Role) {
+		utils.RespondForbidden(c, "You cannot grant a role with permissions you do not hold")
+		return
+	}
+
 	user := &models.User{
 		Email:     req.Email,
 		FirstName: req.FirstName,
@@ -91,6 +98,8 @@ func (h *UserHandler) Create(c *gin.Context) {
 		return
 	}
 
+	h.recordAudit(c, models.AuditEntityUser, user.ID, models.AuditActionCreate, nil, user)
+
 	utils.LogHandlerResponse(logger, http.StatusCreated, user)
 	utils.RespondSuccess(c, http.StatusCreated, user)
 }
@@ -108,7 +117,10 @@ func (h *UserHandler) Create(c *gin.Context) {
 // @Param sort_by query string false "Sort column; ignored unless one of the allowed values" Enums(created_at, updated_at, email, first_name, last_name, role)
 // @Param sort_order query string false "Sort direction; anything else falls back to asc" Enums(asc, desc) default(asc)
 // @Param search query string false "Free-text search across user fields; takes precedence over sort_by"
+// @Param filter query string false "Structured filter, e.g. role eq sales and is_active eq true and last_login_at lt -90d; with a filter, search and sort_by apply together. Fields: id, email, first_name, last_name, role, is_active, last_login_at, created_at, updated_at"
+// @Param cursor query string false "Page by keyset instead of offset: empty for the first page, then the meta.next_cursor of the previous one, with the same sort_by and sort_order. Not combinable with a custom field sort"
 // @Success 200 {object} utils.APIResponse{data=[]models.User,meta=utils.APIMeta} "Users retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid filter"
 // @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
 // @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
 // @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
@@ -130,16 +142,7 @@ func (h *UserHandler) List(c *gin.Context) {
 	sortBy := c.Query("sort_by")
 	sortOrder := c.DefaultQuery("sort_order", "asc")
 
-	allowedSortColumns := map[string]bool{
-		"created_at": true,
-		"updated_at": true,
-		"email":      true,
-		"first_name": true,
-		"last_name":  true,
-		"role":       true,
-	}
-
-	if !allowedSortColumns[sortBy] {
+	if !userSortColumns[sortBy] {
 		sortBy = ""
 	}
 	if sortOrder != "asc" && sortOrder != "desc" {
@@ -148,11 +151,29 @@ func (h *UserHandler) List(c *gin.Context) {
 
 	search := c.Query("search")
 
+	where, ok := parseListFilter(c, logger, "users")
+	if !ok {
+		return
+	}
+	cursor, ok := parseListCursor(c, logger, "users", sortBy, sortOrder, models.CustomFieldQuery{})
+	if !ok {
+		return
+	}
+	fetchOffset, fetchLimit := cursorWindow(cursor, offset, limit)
+
 	var users []models.User
 	var total int64
 	var err error
 
-	if search != "" {
+	if where != nil || cursor != nil {
+		users, total, err = h.userService.ListFiltered(models.UserFilter{
+			Search:    search,
+			SortBy:    sortBy,
+			SortOrder: sortOrder,
+			Where:     where,
+			Cursor:    cursor,
+		}, fetchOffset, fetchLimit)
+	} else if search != "" {
 		users, total, err = h.userService.Search(search, offset, limit, sortBy, sortOrder)
 	} else if sortBy != "" {
 		users, total, err = h.userService.ListSorted(offset, limit, sortBy, sortOrder)
@@ -165,6 +186,14 @@ func (h *UserHandler) List(c *gin.Context) {
 		utils.RespondInternalError(c)
 		return
 	}
+	var next string
+	if cursor != nil {
+		if users, next, err = cursorPage(cursor, users, limit); err != nil {
+			logger.WithError(err).Error("Failed to encode the next cursor")
+			utils.RespondInternalError(c)
+			return
+		}
+	}
 
 	meta := &utils.APIMeta{
 		RequestID:  c.GetString("request_id"),
@@ -173,11 +202,90 @@ func (h *UserHandler) List(c *gin.Context) {
 		Total:      total,
 		TotalPages: (total + int64(limit) - 1) / int64(limit),
 	}
+	if cursor != nil {
+		meta = cursorMeta(c, limit, total, next)
+	}
 
 	utils.LogHandlerResponse(logger, http.StatusOK, gin.H{"users": users, "total": total})
 	utils.RespondSuccessWithMeta(c, http.StatusOK, users, meta)
 }
 
+// userSortColumns is the sort allowlist shared by the list and export
+// endpoints.
+var userSortColumns = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"email":      true,
+	"first_name": true,
+	"last_name":  true,
+	"role":       true,
+}
+
+// userExportColumns are the columns of the user export, in the order a
+// download without the columns parameter writes them. Credentials and lockout
+// counters are not among them.
+var userExportColumns = []exportColumn[models.User]{
+	{"id", func(u *models.User) interface{} { return u.ID }},
+	{"email", func(u *models.User) interface{} { return u.Email }},
+	{"first_name", func(u *models.User) interface{} { return u.FirstName }},
+	{"last_name", func(u *models.User) interface{} { return u.LastName }},
+	{"role", func(u *models.User) interface{} { return string(u.Role) }},
+	{"is_active", func(u *models.User) interface{} { return u.IsActive }},
+	{"last_login_at", func(u *models.User) interface{} { return exportOptional(u.LastLoginAt) }},
+	{"created_at", func(u *models.User) interface{} { return u.CreatedAt }},
+	{"updated_at", func(u *models.User) interface{} { return u.UpdatedAt }},
+}
+
+// Export godoc
+// @Summary Export users as CSV, XLSX or NDJSON
+// @Description Download every user account matching the list parameters as a file (admin role only). There is no pagination: every matching row is included, streamed in batches. The response is the raw file, not the utils.APIResponse envelope; errors before the file starts are still reported through the envelope, and a failure after it has started drops the connection.
+// @Description
+// @Description Columns, in order: id, email, first_name, last_name, role, is_active, last_login_at, created_at, updated_at. columns picks and orders a subset. Timestamps are RFC3339 and a null is an empty cell. In CSV and XLSX a text cell a spreadsheet would run as a formula is prefixed with an apostrophe; NDJSON keeps every value as it is, with its JSON type.
+// @Tags users
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/x-ndjson
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param format query string false "File format" Enums(csv, xlsx, ndjson) default(csv)
+// @Param columns query string false "Comma-separated columns to write, in this order; all of them by default"
+// @Param search query string false "Search across name and email"
+// @Param sort_by query string false "Sort column; ignored unless one of the allowed values" Enums(created_at, updated_at, email, first_name, last_name, role)
+// @Param sort_order query string false "Sort direction" Enums(asc, desc) default(asc)
+// @Param filter query string false "Structured filter, as on the list endpoint"
+// @Success 200 {string} string "File of users (raw body, not the API envelope)"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid format, column or filter"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /users/export [get]
+func (h *UserHandler) Export(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "UserHandler.Export")
+
+	// Repeats the route's permission guard, as the customer export does.
+	if !middleware.HasPermission(c, models.PermUsersExport) {
+		utils.RespondForbidden(c, "Only administrators can export users")
+		return
+	}
+
+	sortBy, sortOrder := exportSort(c, userSortColumns)
+	where, ok := parseListFilter(c, logger, "users")
+	if !ok {
+		return
+	}
+	filter := models.UserFilter{
+		Search:    c.Query("search"),
+		SortBy:    sortBy,
+		SortOrder: sortOrder,
+		Where:     where,
+	}
+
+	writeExport(c, logger, "users", userExportColumns, func(fn func([]models.User) error) error {
+		return h.userService.StreamExport(filter, fn)
+	})
+}
+
 // Get godoc
 // @Summary Get a user by ID
 // @Description Retrieve a single user. Any authenticated user may fetch their own record; fetching another user's record requires the admin role.
@@ -204,9 +312,8 @@ func (h *UserHandler) Get(c *gin.Context) {
 
 	// Check permissions - users can only view themselves unless admin
 	currentUserID := c.GetUint("user_id")
-	currentUserRole := c.GetString("user_role")
 	
-	if uint(id) != currentUserID && currentUserRole != string(models.RoleAdmin) {
+	if uint(id) != currentUserID && !middleware.HasPermission(c, models.PermUsersRead) {
 		utils.RespondForbidden(c, "You can only view your own profile")
 		return
 	}
@@ -252,9 +359,9 @@ func (h *UserHandler) Update(c *gin.Context) {
 
 	// Check permissions - users can only update themselves unless admin
 	currentUserID := c.GetUint("user_id")
-	currentUserRole := c.GetString("user_role")
+	canUpdateUsers := middleware.HasPermission(c, models.PermUsersUpdate)
 	
-	if uint(id) != currentUserID && currentUserRole != string(models.RoleAdmin) {
+	if uint(id) != currentUserID && !canUpdateUsers {
 		utils.RespondForbidden(c, "You can only update your own profile")
 		return
 	}
@@ -278,8 +385,12 @@ func (h *UserHandler) Update(c *gin.Context) {
 	}
 	
 	// Only admins can update role and active status
-	if currentUserRole == string(models.RoleAdmin) {
+	if canUpdateUsers {
 		if req.Role != "" {
+			if !canGrantRole(c, req.Role) {
+				utils.RespondForbidden(c, "You cannot grant a role with permissions you do not hold")
+				return
+			}
 			updates["role"] = req.Role
 		}
 		if req.IsActive != nil {
@@ -287,6 +398,7 @@ func (h *UserHandler) Update(c *gin.Context) {
 		}
 	}
 
+	before := h.auditLoad(func() (interface{}, error) { return h.userService.GetByID(uint(id)) })
 	user, err := h.userService.Update(uint(id), updates)
 	if err != nil {
 		if errors.Is(err, apperrors.ErrDuplicateEmail) {
@@ -306,6 +418,8 @@ func (h *UserHandler) Update(c *gin.Context) {
 		return
 	}
 
+	h.recordAudit(c, models.AuditEntityUser, user.ID, models.AuditActionUpdate, before, user)
+
 	utils.LogHandlerResponse(logger, http.StatusOK, user)
 	utils.RespondSuccess(c, http.StatusOK, user)
 }
@@ -336,8 +450,7 @@ func (h *UserHandler) Delete(c *gin.Context) {
 	}
 
 	// Only admins can delete users
-	currentUserRole := c.GetString("user_role")
-	if currentUserRole != string(models.RoleAdmin) {
+	if !middleware.HasPermission(c, models.PermUsersDelete) {
 		utils.RespondForbidden(c, "Only administrators can delete users")
 		return
 	}
@@ -363,6 +476,10 @@ func (h *UserHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	// The erasure has already scrubbed this user's earlier events; the delete
+	// event deliberately carries no state so it cannot quote them again.
+	h.recordAudit(c, models.AuditEntityUser, uint(id), models.AuditActionDelete, nil, nil)
+
 	utils.LogHandlerResponse(logger, http.StatusNoContent, nil)
 	c.Status(http.StatusNoContent)
 }
@@ -445,6 +562,7 @@ func (h *UserHandler) UpdateMe(c *gin.Context) {
 		updates["password"] = req.Password
 	}
 
+	before := h.auditLoad(func() (interface{}, error) { return h.userService.GetByID(userID) })
 	user, err := h.userService.Update(userID, updates)
 	if err != nil {
 		if errors.Is(err, apperrors.ErrDuplicateEmail) {
@@ -464,6 +582,14 @@ func (h *UserHandler) UpdateMe(c *gin.Context) {
 		return
 	}
 
+	h.recordAudit(c, models.AuditEntityUser, user.ID, models.AuditActionUpdate, before, user)
+
 	utils.LogHandlerResponse(logger, http.StatusOK, user)
 	utils.RespondSuccess(c, http.StatusOK, user)
-}
\ No newline at end of file
+}
+
+// canGrantRole reports whether the caller holds every permission of a built-in
+// role, so that managing users never hands out more than the caller has.
+func canGrantRole(c *gin.Context, role models.UserRole) bool {
+	return canGrant(c, role, models.BuiltInPermissions(role))
+}
@@ -2,11 +2,14 @@ package handler
 
 import (
 	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/florinel-chis/gophercrm/internal/config"
@@ -148,6 +151,32 @@ func (suite *UserHandlerTestSuite) TestList_Success() {
 	assert.Equal(suite.T(), int64(2), response.Meta.Total)
 }
 
+func (suite *UserHandlerTestSuite) TestList_Filter() {
+	suite.router.GET("/users", suite.handler.List)
+
+	suite.mockService.On("ListFiltered", mock.MatchedBy(func(f models.UserFilter) bool {
+		return f.Where != nil && f.Where.Condition != nil && f.Where.Condition.Field == "role"
+	}), 0, 20).Return([]models.User{{BaseModel: models.BaseModel{ID: 2}, Email: "sales@example.com"}}, int64(1), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/users?filter="+url.QueryEscape("role in (sales, support)"), nil)
+	rec := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(rec, req)
+
+	assert.Equal(suite.T(), http.StatusOK, rec.Code)
+}
+
+func (suite *UserHandlerTestSuite) TestList_InvalidFilter() {
+	suite.router.GET("/users", suite.handler.List)
+
+	req := httptest.NewRequest(http.MethodGet, "/users?filter="+url.QueryEscape("password startswith a"), nil)
+	rec := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(rec, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, rec.Code)
+}
+
 func (suite *UserHandlerTestSuite) TestGet_Success() {
 	suite.router.GET("/users/:id", suite.handler.Get)
 	
@@ -565,6 +594,31 @@ func (suite *UserHandlerTestSuite) TestList_SearchWithSort() {
 	assert.Equal(suite.T(), http.StatusOK, rec.Code)
 }
 
+// The user export writes the account columns and nothing of the credentials.
+func (suite *UserHandlerTestSuite) TestExport_Success() {
+	suite.router.GET("/users/export", suite.handler.Export)
+
+	user := models.User{BaseModel: models.BaseModel{ID: 2}, Email: "ada@example.com", FirstName: "Ada",
+		LastName: "Lovelace", Role: models.RoleSales, IsActive: true, Password: "$2a$10$hash"}
+	suite.mockService.On("StreamExport", models.UserFilter{Search: "ada"}, mock.Anything).Run(func(args mock.Arguments) {
+		_ = args.Get(1).(func([]models.User) error)([]models.User{user})
+	}).Return(nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/export?search=ada&sort_by=password", nil)
+	rec := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(rec, req)
+
+	assert.Equal(suite.T(), http.StatusOK, rec.Code)
+	records, err := csv.NewReader(strings.NewReader(rec.Body.String())).ReadAll()
+	suite.Require().NoError(err)
+	suite.Require().Len(records, 2)
+	assert.Equal(suite.T(), []string{"id", "email", "first_name", "last_name", "role", "is_active",
+		"last_login_at", "created_at", "updated_at"}, records[0])
+	assert.Equal(suite.T(), []string{"2", "ada@example.com", "Ada", "Lovelace", "sales", "true", ""}, records[1][:7])
+	assert.NotContains(suite.T(), rec.Body.String(), "$2a$")
+}
+
 func TestUserHandlerTestSuite(t *testing.T) {
 	suite.Run(t, new(UserHandlerTestSuite))
 }
\ No newline at end of file
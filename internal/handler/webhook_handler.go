@@ -0,0 +1,410 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type WebhookHandler struct {
+	auditTrail
+	webhookService service.WebhookService
+}
+
+func NewWebhookHandler(webhookService service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+// WebhookRequest is the body of both create and update: an update replaces
+// the whole subscription except its secret.
+type WebhookRequest struct {
+	Name   string                `json:"name" binding:"required"`
+	URL    string                `json:"url" binding:"required"`
+	Events []models.WebhookEvent `json:"events" binding:"required"`
+	// Active defaults to true; send false to pause the subscription.
+	Active *bool `json:"active"`
+}
+
+func (r *WebhookRequest) subscription() *models.WebhookSubscription {
+	active := true
+	if r.Active != nil {
+		active = *r.Active
+	}
+	return &models.WebhookSubscription{Name: r.Name, URL: r.URL, Events: r.Events, Active: active}
+}
+
+// WebhookSecretResponse carries a signing secret, which is only ever shown in
+// the response that created it.
+type WebhookSecretResponse struct {
+	Secret  string                      `json:"secret"`
+	Webhook *models.WebhookSubscription `json:"webhook"`
+}
+
+// Events godoc
+// @Summary List webhook events
+// @Description The events a webhook subscription can listen for (admin role only).
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.APIResponse{data=[]string} "Webhook events retrieved successfully"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Router /webhooks/events [get]
+func (h *WebhookHandler) Events(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "WebhookHandler.Events")
+
+	utils.LogHandlerResponse(logger, http.StatusOK, models.WebhookEvents)
+	utils.RespondSuccess(c, http.StatusOK, models.WebhookEvents)
+}
+
+// List godoc
+// @Summary List webhook subscriptions
+// @Description One page of the webhook subscriptions, oldest first (admin role only). Signing secrets are never included.
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param offset query int false "Offset for pagination" default(0)
+// @Param limit query int false "Limit for pagination" default(20)
+// @Success 200 {object} utils.APIResponse{data=object{webhooks=[]models.WebhookSubscription,total=int}} "Webhooks retrieved successfully"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /webhooks [get]
+func (h *WebhookHandler) List(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "WebhookHandler.List")
+
+	offset, limit := utils.ParseOffsetLimit(c)
+	subs, total, err := h.webhookService.List(offset, limit)
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	meta := &utils.APIMeta{
+		RequestID:  c.GetString("request_id"),
+		Page:       (offset / limit) + 1,
+		PerPage:    limit,
+		Total:      total,
+		TotalPages: (total + int64(limit) - 1) / int64(limit),
+	}
+
+	responseData := gin.H{"webhooks": subs, "total": total}
+	utils.LogHandlerResponse(logger, http.StatusOK, responseData)
+	utils.RespondSuccessWithMeta(c, http.StatusOK, responseData, meta)
+}
+
+// Create godoc
+// @Summary Create a webhook subscription
+// @Description Subscribe an HTTP(S) endpoint to a list of events (admin role only). Every delivery is a JSON POST signed with HMAC-SHA256 in the X-GopherCRM-Signature header. The signing secret is returned only in this response; it is stored encrypted and can be replaced with the rotate-secret endpoint, but never read back.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param request body WebhookRequest true "Webhook subscription"
+// @Success 201 {object} utils.APIResponse{data=WebhookSecretResponse} "Webhook created; contains the signing secret"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid name, URL or event list"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /webhooks [post]
+func (h *WebhookHandler) Create(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "WebhookHandler.Create")
+
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	sub := req.subscription()
+	sub.CreatedByID = c.GetUint("user_id")
+	secret, err := h.webhookService.Create(sub)
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityWebhook, sub.ID, models.AuditActionCreate, nil, sub)
+
+	utils.LogHandlerResponse(logger, http.StatusCreated, sub)
+	utils.RespondSuccess(c, http.StatusCreated, WebhookSecretResponse{Secret: secret, Webhook: sub})
+}
+
+// Get godoc
+// @Summary Get a webhook subscription
+// @Description One webhook subscription, without its signing secret (admin role only).
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} utils.APIResponse{data=models.WebhookSubscription} "Webhook retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid webhook ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Webhook not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /webhooks/{id} [get]
+func (h *WebhookHandler) Get(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "WebhookHandler.Get")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid webhook ID")
+		return
+	}
+
+	sub, err := h.webhookService.GetByID(uint(id))
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, sub)
+	utils.RespondSuccess(c, http.StatusOK, sub)
+}
+
+// Update godoc
+// @Summary Update a webhook subscription
+// @Description Replace a subscription's name, URL, events and active flag (admin role only). The signing secret is kept. Pausing a subscription (active false) stops new events being queued for it; deliveries already queued wait until it is active again.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Webhook ID"
+// @Param request body WebhookRequest true "Webhook subscription"
+// @Success 200 {object} utils.APIResponse{data=models.WebhookSubscription} "Webhook updated successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid webhook ID, name, URL or event list"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Webhook not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /webhooks/{id} [put]
+func (h *WebhookHandler) Update(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "WebhookHandler.Update")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid webhook ID")
+		return
+	}
+
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	before := h.auditLoad(func() (interface{}, error) { return h.webhookService.GetByID(uint(id)) })
+	sub := req.subscription()
+	if err := h.webhookService.Update(uint(id), sub); err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityWebhook, sub.ID, models.AuditActionUpdate, before, sub)
+
+	utils.LogHandlerResponse(logger, http.StatusOK, sub)
+	utils.RespondSuccess(c, http.StatusOK, sub)
+}
+
+// Delete godoc
+// @Summary Delete a webhook subscription
+// @Description Remove a subscription together with its delivery history, including deliveries still queued (admin role only).
+// @Tags webhooks
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Webhook ID"
+// @Success 204 "Webhook deleted"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid webhook ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Webhook not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "WebhookHandler.Delete")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid webhook ID")
+		return
+	}
+
+	before := h.auditLoad(func() (interface{}, error) { return h.webhookService.GetByID(uint(id)) })
+	if err := h.webhookService.Delete(uint(id)); err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	h.recordAudit(c, models.AuditEntityWebhook, uint(id), models.AuditActionDelete, before, nil)
+
+	utils.LogHandlerResponse(logger, http.StatusNoContent, nil)
+	c.Status(http.StatusNoContent)
+}
+
+// RotateSecret godoc
+// @Summary Rotate a webhook's signing secret
+// @Description Replace the signing secret and return the new one, shown only in this response (admin role only). Every delivery from now on, retries of earlier events included, is signed with the new secret.
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} utils.APIResponse{data=WebhookSecretResponse} "Secret rotated; contains the new signing secret"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid webhook ID"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Webhook not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /webhooks/{id}/rotate-secret [post]
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "WebhookHandler.RotateSecret")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid webhook ID")
+		return
+	}
+
+	before := h.auditLoad(func() (interface{}, error) { return h.webhookService.GetByID(uint(id)) })
+	secret, err := h.webhookService.RotateSecret(uint(id))
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+	sub, err := h.webhookService.GetByID(uint(id))
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	// The secret itself never reaches the trail; the rotation shows up as the
+	// subscription's updated_at moving.
+	h.recordAudit(c, models.AuditEntityWebhook, sub.ID, models.AuditActionUpdate, before, sub)
+
+	utils.LogHandlerResponse(logger, http.StatusOK, sub)
+	utils.RespondSuccess(c, http.StatusOK, WebhookSecretResponse{Secret: secret, Webhook: sub})
+}
+
+// ListDeliveries godoc
+// @Summary List a webhook's deliveries
+// @Description One page of the events queued for a subscription, newest first, with the outcome of their last attempt (admin role only). Filter with status=failed to review the dead letters. Payloads of a record that was erased read "[erased]".
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Webhook ID"
+// @Param status query string false "Delivery status" Enums(pending, delivered, failed)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Param limit query int false "Limit for pagination" default(20)
+// @Success 200 {object} utils.APIResponse{data=object{deliveries=[]models.WebhookDelivery,total=int}} "Deliveries retrieved successfully"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid webhook ID or unknown status"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Webhook not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "WebhookHandler.ListDeliveries")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid webhook ID")
+		return
+	}
+
+	offset, limit := utils.ParseOffsetLimit(c)
+	status := models.WebhookDeliveryStatus(c.Query("status"))
+	deliveries, total, err := h.webhookService.ListDeliveries(uint(id), status, offset, limit)
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	meta := &utils.APIMeta{
+		RequestID:  c.GetString("request_id"),
+		Page:       (offset / limit) + 1,
+		PerPage:    limit,
+		Total:      total,
+		TotalPages: (total + int64(limit) - 1) / int64(limit),
+	}
+
+	responseData := gin.H{"deliveries": deliveries, "total": total}
+	utils.LogHandlerResponse(logger, http.StatusOK, responseData)
+	utils.RespondSuccessWithMeta(c, http.StatusOK, responseData, meta)
+}
+
+// Redeliver godoc
+// @Summary Redeliver a webhook event
+// @Description Queue a delivery again with a fresh set of attempts, typically a dead-lettered one once its endpoint is fixed (admin role only). It goes out on the delivery worker's next pass, with the same event id and payload as before. A delivery whose record was erased cannot be sent again.
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Webhook ID"
+// @Param deliveryId path int true "Delivery ID"
+// @Success 200 {object} utils.APIResponse{data=models.WebhookDelivery} "Delivery queued"
+// @Failure 400 {object} utils.APIResponse{error=utils.APIError} "Invalid ID, or the payload was erased"
+// @Failure 401 {object} utils.APIResponse{error=utils.APIError} "Unauthorized"
+// @Failure 403 {object} utils.APIResponse{error=utils.APIError} "Forbidden - Admin role required"
+// @Failure 404 {object} utils.APIResponse{error=utils.APIError} "Delivery not found"
+// @Failure 429 {object} utils.APIResponse{error=utils.APIError} "Too many requests - rate limit exceeded"
+// @Failure 500 {object} utils.APIResponse{error=utils.APIError} "Internal server error"
+// @Router /webhooks/{id}/deliveries/{deliveryId}/redeliver [post]
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	logger := utils.LogHandlerStart(c, "WebhookHandler.Redeliver")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid webhook ID")
+		return
+	}
+	deliveryID, err := strconv.ParseUint(c.Param("deliveryId"), 10, 32)
+	if err != nil {
+		utils.RespondBadRequest(c, "Invalid delivery ID")
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(uint(id), uint(deliveryID))
+	if err != nil {
+		h.respondError(c, logger, err)
+		return
+	}
+
+	utils.LogHandlerResponse(logger, http.StatusOK, delivery)
+	utils.RespondSuccess(c, http.StatusOK, delivery)
+}
+
+func (h *WebhookHandler) respondError(c *gin.Context, logger *logrus.Entry, err error) {
+	switch {
+	case errors.Is(err, apperrors.ErrValidation):
+		logger.WithError(err).Warn("Invalid webhook request")
+		utils.RespondBadRequest(c, err.Error())
+	case apperrors.IsNotFound(err):
+		logger.WithError(err).Warn("Webhook not found")
+		utils.RespondNotFound(c, "Webhook not found")
+	default:
+		logger.WithError(err).Error("Webhook operation failed")
+		utils.RespondInternalError(c)
+	}
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+var _ service.WebhookService = (*mocks.WebhookService)(nil)
+
+type WebhookHandlerTestSuite struct {
+	suite.Suite
+	mockService *mocks.WebhookService
+	mockAudit   *mocks.AuditService
+	handler     *WebhookHandler
+}
+
+func (suite *WebhookHandlerTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *WebhookHandlerTestSuite) SetupTest() {
+	suite.mockService = new(mocks.WebhookService)
+	suite.mockAudit = new(mocks.AuditService)
+	suite.handler = NewWebhookHandler(suite.mockService)
+}
+
+func (suite *WebhookHandlerTestSuite) TearDownTest() {
+	suite.mockService.AssertExpectations(suite.T())
+	suite.mockAudit.AssertExpectations(suite.T())
+}
+
+func (suite *WebhookHandlerTestSuite) do(role models.UserRole, method, path string, body interface{}) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		c.Set("user_role", string(role))
+		c.Next()
+	})
+	SetupWebhookRoutes(router.Group(""), suite.handler)
+
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (suite *WebhookHandlerTestSuite) TestRoutes_AdminOnly() {
+	for _, role := range []models.UserRole{models.RoleSales, models.RoleSupport, models.RoleCustomer} {
+		w := suite.do(role, http.MethodGet, "/webhooks", nil)
+		assert.Equal(suite.T(), http.StatusForbidden, w.Code, role)
+	}
+}
+
+func (suite *WebhookHandlerTestSuite) TestCreate_ReturnsTheSecretOnce() {
+	suite.handler.SetAuditService(suite.mockAudit)
+	suite.mockService.On("Create", mock.MatchedBy(func(sub *models.WebhookSubscription) bool {
+		return sub.Active && sub.CreatedByID == 1 && sub.URL == "https://billing.example.com/hooks"
+	})).Run(func(args mock.Arguments) {
+		sub := args.Get(0).(*models.WebhookSubscription)
+		sub.ID = 4
+		sub.SecretSealed = "enc:v1:sealed"
+	}).Return("whsec_abc", nil)
+	suite.mockAudit.On("Record", mock.Anything, models.AuditEntityWebhook, uint(4), models.AuditActionCreate,
+		mock.Anything, mock.MatchedBy(func(after *models.WebhookSubscription) bool {
+			snapshot := service.AuditSnapshot(after)
+			_, hasSecret := snapshot["secret"]
+			return snapshot["name"] == "Billing" && !hasSecret
+		})).Return(nil)
+
+	w := suite.do(models.RoleAdmin, http.MethodPost, "/webhooks", gin.H{
+		"name": "Billing", "url": "https://billing.example.com/hooks", "events": []string{"lead.created"},
+	})
+
+	require.Equal(suite.T(), http.StatusCreated, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"secret":"whsec_abc"`)
+	assert.NotContains(suite.T(), w.Body.String(), "enc:v1:sealed")
+}
+
+func (suite *WebhookHandlerTestSuite) TestCreate_InvalidSubscription() {
+	suite.mockService.On("Create", mock.Anything).Return("", fmt.Errorf("unknown event: %w", apperrors.ErrValidation))
+
+	w := suite.do(models.RoleAdmin, http.MethodPost, "/webhooks", gin.H{
+		"name": "Billing", "url": "https://billing.example.com/hooks", "events": []string{"lead.exploded"},
+	})
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *WebhookHandlerTestSuite) TestUpdate_CanPause() {
+	suite.mockService.On("Update", uint(4), mock.MatchedBy(func(sub *models.WebhookSubscription) bool {
+		return !sub.Active
+	})).Return(nil)
+
+	w := suite.do(models.RoleAdmin, http.MethodPut, "/webhooks/4", gin.H{
+		"name": "Billing", "url": "https://billing.example.com/hooks", "events": []string{"lead.created"}, "active": false,
+	})
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *WebhookHandlerTestSuite) TestRotateSecret() {
+	suite.mockService.On("RotateSecret", uint(4)).Return("whsec_new", nil)
+	suite.mockService.On("GetByID", uint(4)).Return(&models.WebhookSubscription{ID: 4, Name: "Billing"}, nil)
+
+	w := suite.do(models.RoleAdmin, http.MethodPost, "/webhooks/4/rotate-secret", nil)
+
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"secret":"whsec_new"`)
+}
+
+func (suite *WebhookHandlerTestSuite) TestListDeliveries_FiltersByStatus() {
+	deliveries := []models.WebhookDelivery{{ID: 9, SubscriptionID: 4, Status: models.WebhookDeliveryFailed}}
+	suite.mockService.On("ListDeliveries", uint(4), models.WebhookDeliveryFailed, 0, 20).Return(deliveries, int64(1), nil)
+
+	w := suite.do(models.RoleAdmin, http.MethodGet, "/webhooks/4/deliveries?status=failed", nil)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var response utils.APIResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), int64(1), response.Meta.Total)
+}
+
+func (suite *WebhookHandlerTestSuite) TestRedeliver_ErasedPayload() {
+	suite.mockService.On("Redeliver", uint(4), uint(9)).
+		Return(nil, fmt.Errorf("erased: %w", apperrors.ErrValidation))
+
+	w := suite.do(models.RoleAdmin, http.MethodPost, "/webhooks/4/deliveries/9/redeliver", nil)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *WebhookHandlerTestSuite) TestDelete_NotFound() {
+	suite.mockService.On("Delete", uint(9)).Return(fmt.Errorf("missing: %w", apperrors.ErrNotFound))
+
+	w := suite.do(models.RoleAdmin, http.MethodDelete, "/webhooks/9", nil)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *WebhookHandlerTestSuite) TestEvents() {
+	w := suite.do(models.RoleAdmin, http.MethodGet, "/webhooks/events", nil)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "ticket.resolved")
+}
+
+func TestWebhookHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(WebhookHandlerTestSuite))
+}
@@ -0,0 +1,203 @@
+package inbound
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// PollerName identifies the Maildir poller goroutine in logs.
+const PollerName = "inbound-email-poller"
+
+// MessageHandler acts on one parsed message. An error means the message could
+// not be handled right now (the database is down, say) and is retried on the
+// next poll; a message the handler decided to drop or quarantine is handled
+// and returns nil.
+type MessageHandler interface {
+	HandleMessage(msg *Message) error
+}
+
+// Maildir info suffixes given to handled messages as they move to cur/: Seen
+// for a processed message, Flagged and Seen for one the gateway could not
+// read, so an operator can find it with any mail client.
+const (
+	processedInfo = ":2,S"
+	rejectedInfo  = ":2,FS"
+)
+
+// Maildir is a mail directory in the qmail layout: a delivery is written to
+// tmp/ and renamed into new/, where the poller picks it up; handled messages
+// are moved to cur/. Any MTA or MDA that delivers to a Maildir can feed it.
+type Maildir struct {
+	dir string
+}
+
+// OpenMaildir opens the Maildir at dir, creating tmp/, new/ and cur/ when
+// they are missing.
+func OpenMaildir(dir string) (*Maildir, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			return nil, fmt.Errorf("preparing maildir %s: %w", dir, err)
+		}
+	}
+	return &Maildir{dir: dir}, nil
+}
+
+// Deliver writes a message into new/ the way an MDA does, through tmp/ so the
+// poller never sees half a file. It returns the message's file name.
+func (m *Maildir) Deliver(raw []byte) (string, error) {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	hostname, _ := os.Hostname()
+	hostname = strings.NewReplacer("/", "_", ":", "_").Replace(hostname)
+	name := fmt.Sprintf("%d.%s.%s", time.Now().UnixNano(), hex.EncodeToString(suffix), hostname)
+
+	tmp := filepath.Join(m.dir, "tmp", name)
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, filepath.Join(m.dir, "new", name)); err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	return name, nil
+}
+
+// Poll hands every message waiting in new/ to handler, oldest first, and
+// returns how many it moved to cur/. A message that cannot be read or parsed,
+// or is larger than MaxMessageBytes, is moved to cur/ flagged. A handler error
+// stops the poll and leaves that message and the rest in new/ for the next one.
+func (m *Maildir) Poll(handler MessageHandler) (int, error) {
+	entries, err := os.ReadDir(filepath.Join(m.dir, "new"))
+	if err != nil {
+		return 0, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && !strings.HasPrefix(entry.Name(), ".") {
+			names = append(names, entry.Name())
+		}
+	}
+	// Delivery names start with the delivery time, so this is arrival order.
+	sort.Strings(names)
+
+	handled := 0
+	for _, name := range names {
+		msg, err := m.read(name)
+		if err != nil {
+			logPoller().WithField("file", name).WithError(err).Warn("Unreadable inbound message flagged")
+			if err := m.moveToCur(name, rejectedInfo); err != nil {
+				return handled, err
+			}
+			handled++
+			continue
+		}
+		if err := handler.HandleMessage(msg); err != nil {
+			return handled, fmt.Errorf("handling %s: %w", name, err)
+		}
+		if err := m.moveToCur(name, processedInfo); err != nil {
+			return handled, err
+		}
+		handled++
+	}
+	return handled, nil
+}
+
+func (m *Maildir) read(name string) (*Message, error) {
+	file, err := os.Open(filepath.Join(m.dir, "new", name))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(file, MaxMessageBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) > MaxMessageBytes {
+		return nil, fmt.Errorf("message exceeds %d bytes", MaxMessageBytes)
+	}
+	return Parse(bytes.NewReader(raw))
+}
+
+func (m *Maildir) moveToCur(name, info string) error {
+	return os.Rename(filepath.Join(m.dir, "new", name), filepath.Join(m.dir, "cur", name+info))
+}
+
+// StartPoller launches the Maildir poller and returns immediately. It polls
+// once at start, so mail that arrived while the process was down is handled
+// without waiting a full interval, then once per interval. The goroutine exits
+// when ctx is cancelled.
+func StartPoller(ctx context.Context, maildir *Maildir, handler MessageHandler, interval time.Duration) {
+	if maildir == nil || handler == nil {
+		logPoller().Warn("Inbound email poller not started: no maildir or handler")
+		return
+	}
+	if interval <= 0 {
+		logPoller().WithField("interval", interval.String()).Warn("Inbound email poller not started: interval must be positive")
+		return
+	}
+	go pollLoop(ctx, maildir, handler, interval)
+}
+
+func pollLoop(ctx context.Context, maildir *Maildir, handler MessageHandler, interval time.Duration) {
+	logPoller().WithFields(logrus.Fields{
+		"maildir":  maildir.dir,
+		"interval": interval.String(),
+	}).Info("Inbound email poller started")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		poll(maildir, handler)
+
+		select {
+		case <-ctx.Done():
+			logPoller().Info("Inbound email poller stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll runs one pass. Every outcome — including a panic inside the handler —
+// is contained here so the loop always survives to the next tick.
+func poll(maildir *Maildir, handler MessageHandler) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			logPoller().WithField("panic", recovered).Error("Inbound email poll panicked")
+		}
+	}()
+
+	handled, err := maildir.Poll(handler)
+	switch {
+	case err != nil:
+		logPoller().WithField("handled", handled).WithField("error", err.Error()).Error("Inbound email poll failed")
+	case handled > 0:
+		logPoller().WithField("handled", handled).Info("Inbound email handled")
+	default:
+		logPoller().Debug("Inbound email poll found nothing new")
+	}
+}
+
+func logPoller() *logrus.Entry {
+	base := utils.Logger
+	if base == nil {
+		base = logrus.StandardLogger()
+	}
+	return base.WithField("worker", PollerName)
+}
@@ -0,0 +1,181 @@
+package inbound
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingHandler collects the messages it is handed and can be scripted to
+// fail or panic.
+type recordingHandler struct {
+	mu       sync.Mutex
+	subjects []string
+	err      error
+	panics   bool
+	called   chan struct{}
+}
+
+func newRecordingHandler() *recordingHandler {
+	return &recordingHandler{called: make(chan struct{}, 16)}
+}
+
+func (h *recordingHandler) HandleMessage(msg *Message) error {
+	h.mu.Lock()
+	h.subjects = append(h.subjects, msg.Subject)
+	err, shouldPanic := h.err, h.panics
+	h.mu.Unlock()
+
+	select {
+	case h.called <- struct{}{}:
+	default:
+	}
+	if shouldPanic {
+		panic("handler exploded")
+	}
+	return err
+}
+
+func (h *recordingHandler) handled() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.subjects...)
+}
+
+func testMail(subject string) []byte {
+	return []byte("From: a@example.com\r\nSubject: " + subject + "\r\n\r\nbody\r\n")
+}
+
+func dirNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestMaildir_PollHandsOverInArrivalOrder(t *testing.T) {
+	root := t.TempDir()
+	maildir, err := OpenMaildir(root)
+	require.NoError(t, err)
+
+	first, err := maildir.Deliver(testMail("first"))
+	require.NoError(t, err)
+	_, err = maildir.Deliver(testMail("second"))
+	require.NoError(t, err)
+
+	handler := newRecordingHandler()
+	handled, err := maildir.Poll(handler)
+	require.NoError(t, err)
+	assert.Equal(t, 2, handled)
+	assert.Equal(t, []string{"first", "second"}, handler.handled())
+
+	assert.Empty(t, dirNames(t, filepath.Join(root, "new")))
+	assert.Empty(t, dirNames(t, filepath.Join(root, "tmp")))
+	assert.Contains(t, dirNames(t, filepath.Join(root, "cur")), first+":2,S")
+
+	handled, err = maildir.Poll(handler)
+	require.NoError(t, err)
+	assert.Zero(t, handled, "a handled message is not handed over twice")
+}
+
+func TestMaildir_UnparseableMessageFlagged(t *testing.T) {
+	root := t.TempDir()
+	maildir, err := OpenMaildir(root)
+	require.NoError(t, err)
+
+	junk, err := maildir.Deliver([]byte("Subject: no sender\r\n\r\nbody\r\n"))
+	require.NoError(t, err)
+	_, err = maildir.Deliver(testMail("fine"))
+	require.NoError(t, err)
+
+	handler := newRecordingHandler()
+	handled, err := maildir.Poll(handler)
+	require.NoError(t, err)
+	assert.Equal(t, 2, handled)
+	assert.Equal(t, []string{"fine"}, handler.handled(), "the broken message never reaches the handler")
+	assert.Contains(t, dirNames(t, filepath.Join(root, "cur")), junk+":2,FS")
+}
+
+func TestMaildir_HandlerErrorLeavesMessageForNextPoll(t *testing.T) {
+	root := t.TempDir()
+	maildir, err := OpenMaildir(root)
+	require.NoError(t, err)
+	_, err = maildir.Deliver(testMail("first"))
+	require.NoError(t, err)
+	_, err = maildir.Deliver(testMail("second"))
+	require.NoError(t, err)
+
+	handler := newRecordingHandler()
+	handler.err = errors.New("database down")
+	handled, err := maildir.Poll(handler)
+	assert.Error(t, err)
+	assert.Zero(t, handled)
+	assert.Equal(t, []string{"first"}, handler.handled(), "the poll stops at the first failure")
+	assert.Len(t, dirNames(t, filepath.Join(root, "new")), 2)
+
+	handler.err = nil
+	handled, err = maildir.Poll(handler)
+	require.NoError(t, err)
+	assert.Equal(t, 2, handled)
+}
+
+func TestMaildir_IgnoresHiddenFiles(t *testing.T) {
+	root := t.TempDir()
+	maildir, err := OpenMaildir(root)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "new", ".lock"), []byte("x"), 0o600))
+
+	handled, err := maildir.Poll(newRecordingHandler())
+	require.NoError(t, err)
+	assert.Zero(t, handled)
+}
+
+func TestStartPoller_PollsAtStartAndSurvivesPanics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	maildir, err := OpenMaildir(t.TempDir())
+	require.NoError(t, err)
+	_, err = maildir.Deliver(testMail("boom"))
+	require.NoError(t, err)
+
+	handler := newRecordingHandler()
+	handler.panics = true
+	StartPoller(ctx, maildir, handler, 10*time.Millisecond)
+
+	// The message stays in new/ after the panic, so each tick retries it.
+	for i := 0; i < 2; i++ {
+		select {
+		case <-handler.called:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("poller called the handler %d times, want 2", i)
+		}
+	}
+	assert.Equal(t, "boom", handler.handled()[0])
+}
+
+func TestStartPoller_RefusesBadArguments(t *testing.T) {
+	maildir, err := OpenMaildir(t.TempDir())
+	require.NoError(t, err)
+	_, err = maildir.Deliver(testMail("never"))
+	require.NoError(t, err)
+
+	handler := newRecordingHandler()
+	StartPoller(context.Background(), nil, handler, time.Millisecond)
+	StartPoller(context.Background(), maildir, nil, time.Millisecond)
+	StartPoller(context.Background(), maildir, handler, 0)
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, handler.handled())
+}
@@ -0,0 +1,302 @@
+// Package inbound is the email side of the helpdesk: it parses the MIME
+// messages customers send, reads them from the Maildir the mail server
+// delivers into, and recognises the ticket a reply belongs to. What happens to
+// a message is decided by the service layer through MessageHandler.
+package inbound
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxMessageBytes caps the size of a message the gateway will read. Support
+// mail with a screenshot attached fits comfortably; anything larger is left
+// for a human rather than loaded into memory.
+const MaxMessageBytes = 10 << 20
+
+// maxMultipartDepth bounds how deeply nested multiparts are followed.
+const maxMultipartDepth = 8
+
+// ErrNoSender is returned for a message without a usable From address.
+var ErrNoSender = errors.New("message has no sender address")
+
+// Message is the part of an email the gateway acts on.
+type Message struct {
+	// MessageID is the Message-ID without its angle brackets; empty when the
+	// message has none.
+	MessageID string
+	// References lists the message ids this one answers: In-Reply-To first,
+	// then References from newest to oldest, without duplicates.
+	References []string
+	// FromAddress is lower-cased; FromName is the decoded display name.
+	FromAddress string
+	FromName    string
+	Subject     string
+	// Date is the Date header, or the zero time when it is missing or garbled.
+	Date time.Time
+	// Body is the plain-text content: the text/plain part when there is one,
+	// otherwise the text/html part reduced to text.
+	Body string
+	// AutoGenerated is set on auto-replies and bulk mail (RFC 3834
+	// Auto-Submitted, Precedence: bulk/junk/list), which must never open a
+	// ticket or be answered.
+	AutoGenerated bool
+}
+
+var wordDecoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+// Parse reads one RFC 5322 message.
+func Parse(r io.Reader) (*Message, error) {
+	raw, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading message: %w", err)
+	}
+	header := raw.Header
+
+	parser := &mail.AddressParser{WordDecoder: wordDecoder}
+	from, err := parser.Parse(header.Get("From"))
+	if err != nil || from.Address == "" {
+		return nil, ErrNoSender
+	}
+
+	subject, err := wordDecoder.DecodeHeader(header.Get("Subject"))
+	if err != nil {
+		subject = header.Get("Subject")
+	}
+
+	msg := &Message{
+		MessageID:     firstMessageID(header.Get("Message-ID")),
+		References:    references(header),
+		FromAddress:   strings.ToLower(from.Address),
+		FromName:      strings.TrimSpace(from.Name),
+		Subject:       strings.TrimSpace(subject),
+		AutoGenerated: isAutoGenerated(header),
+	}
+	if date, err := header.Date(); err == nil {
+		msg.Date = date
+	}
+
+	body, _, err := textBody(textproto.MIMEHeader(header), raw.Body, 0)
+	if err != nil {
+		return nil, fmt.Errorf("reading body: %w", err)
+	}
+	msg.Body = strings.TrimSpace(normalizeNewlines(body))
+	return msg, nil
+}
+
+var messageIDPattern = regexp.MustCompile(`<([^<>\s]+)>`)
+
+// messageIDs extracts every <id> from a header value.
+func messageIDs(value string) []string {
+	matches := messageIDPattern.FindAllStringSubmatch(value, -1)
+	ids := make([]string, 0, len(matches))
+	for _, match := range matches {
+		ids = append(ids, match[1])
+	}
+	return ids
+}
+
+func firstMessageID(value string) string {
+	if ids := messageIDs(value); len(ids) > 0 {
+		return ids[0]
+	}
+	return strings.Trim(strings.TrimSpace(value), "<>")
+}
+
+func references(header mail.Header) []string {
+	seen := map[string]bool{}
+	var ids []string
+	add := func(id string) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, id := range messageIDs(header.Get("In-Reply-To")) {
+		add(id)
+	}
+	// References runs oldest to newest; the nearest ancestor is the likeliest
+	// to be on file, so it is tried first.
+	refs := messageIDs(header.Get("References"))
+	for i := len(refs) - 1; i >= 0; i-- {
+		add(refs[i])
+	}
+	return ids
+}
+
+func isAutoGenerated(header mail.Header) bool {
+	if value := strings.ToLower(strings.TrimSpace(header.Get("Auto-Submitted"))); value != "" && value != "no" {
+		return true
+	}
+	switch strings.ToLower(strings.TrimSpace(header.Get("Precedence"))) {
+	case "bulk", "junk", "list", "auto_reply":
+		return true
+	}
+	return header.Get("X-Autoreply") != "" || header.Get("X-Autorespond") != ""
+}
+
+// Body kinds, in order of preference.
+const (
+	bodyNone = iota
+	bodyHTML
+	bodyPlain
+)
+
+// textBody returns the best text found in one MIME entity: text/plain wins
+// over text/html, and attachments are skipped.
+func textBody(header textproto.MIMEHeader, body io.Reader, depth int) (string, int, error) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		// RFC 2045: a missing or unreadable Content-Type means plain text.
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if depth >= maxMultipartDepth || params["boundary"] == "" {
+			return "", bodyNone, nil
+		}
+		return multipartBody(multipart.NewReader(body, params["boundary"]), depth)
+	}
+
+	if disposition, _, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil && disposition == "attachment" {
+		return "", bodyNone, nil
+	}
+	kind := bodyNone
+	switch mediaType {
+	case "text/plain":
+		kind = bodyPlain
+	case "text/html":
+		kind = bodyHTML
+	default:
+		return "", bodyNone, nil
+	}
+
+	decoded, err := io.ReadAll(transferDecoder(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return "", bodyNone, err
+	}
+	text, err := decodeCharset(params["charset"], decoded)
+	if err != nil {
+		return "", bodyNone, err
+	}
+	if kind == bodyHTML {
+		text = htmlToText(text)
+	}
+	return text, kind, nil
+}
+
+func multipartBody(reader *multipart.Reader, depth int) (string, int, error) {
+	best, bestKind := "", bodyNone
+	for {
+		// NextRawPart leaves Content-Transfer-Encoding alone, so every part
+		// is decoded by the same code whether or not it is nested.
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			return best, bestKind, nil
+		}
+		if err != nil {
+			return "", bodyNone, err
+		}
+		text, kind, err := textBody(part.Header, part, depth+1)
+		if err != nil {
+			return "", bodyNone, err
+		}
+		if kind > bestKind {
+			best, bestKind = text, kind
+		}
+	}
+}
+
+func transferDecoder(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	case "base64":
+		// The standard decoder skips the line breaks base64 bodies carry.
+		return base64.NewDecoder(base64.StdEncoding, body)
+	default:
+		return body
+	}
+}
+
+// decodeCharset converts a body to UTF-8. Only the charsets mail clients
+// actually send to a support address are known; under an unknown one the bytes
+// that are not UTF-8 become U+FFFD, so the message still gets through with
+// the odd character lost.
+func decodeCharset(charset string, body []byte) (string, error) {
+	reader, err := charsetReader(charset, bytes.NewReader(body))
+	if err != nil {
+		return strings.ToValidUTF8(string(body), string(utf8.RuneError)), nil
+	}
+	decoded, err := io.ReadAll(reader)
+	return string(decoded), err
+}
+
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(strings.TrimSpace(charset)) {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return input, nil
+	case "iso-8859-1", "latin1", "windows-1252", "cp1252":
+		// Windows-1252 is Latin-1 with typographic quotes and dashes in
+		// 0x80-0x9F, where Latin-1 has unused control codes, so one table
+		// serves both.
+		raw, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		runes := make([]rune, len(raw))
+		for i, b := range raw {
+			runes[i] = rune(b)
+			if b >= 0x80 && b < 0xA0 && cp1252[b-0x80] != 0 {
+				runes[i] = cp1252[b-0x80]
+			}
+		}
+		return strings.NewReader(string(runes)), nil
+	}
+	return nil, fmt.Errorf("unsupported charset %q", charset)
+}
+
+// cp1252 maps the Windows-1252 bytes 0x80-0x9F; zero marks an unassigned byte.
+var cp1252 = [32]rune{
+	'€', 0, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0, 'Ž', 0,
+	0, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0, 'ž', 'Ÿ',
+}
+
+var (
+	htmlDropPattern  = regexp.MustCompile(`(?is)<(script|style|head)\b.*?</(script|style|head)>`)
+	htmlBreakPattern = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|li|tr|h[1-6])>`)
+	htmlTagPattern   = regexp.MustCompile(`(?s)<[^>]*>`)
+	blankRunPattern  = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlToText reduces an HTML body to readable text. It is not a renderer; it
+// only has to keep the words and the paragraph breaks of an email.
+func htmlToText(body string) string {
+	body = htmlDropPattern.ReplaceAllString(body, "")
+	body = htmlBreakPattern.ReplaceAllString(body, "\n")
+	body = htmlTagPattern.ReplaceAllString(body, "")
+	body = html.UnescapeString(body)
+
+	lines := strings.Split(normalizeNewlines(body), "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return blankRunPattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+}
+
+func normalizeNewlines(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\r", "\n")
+}
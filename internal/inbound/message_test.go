@@ -0,0 +1,137 @@
+package inbound
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parse(t *testing.T, raw string) *Message {
+	t.Helper()
+	msg, err := Parse(strings.NewReader(strings.ReplaceAll(raw, "\n", "\r\n")))
+	require.NoError(t, err)
+	return msg
+}
+
+func TestParse_PlainMessage(t *testing.T) {
+	msg := parse(t, `From: "Agnes Nutter" <Agnes@Example.com>
+To: support@example.com
+Subject: Printer on fire
+Message-ID: <abc123@mail.example.com>
+Date: Mon, 2 Mar 2026 10:00:00 +0000
+
+Hello,
+
+it is on fire again.
+`)
+
+	assert.Equal(t, "abc123@mail.example.com", msg.MessageID)
+	assert.Equal(t, "agnes@example.com", msg.FromAddress, "addresses are lower-cased")
+	assert.Equal(t, "Agnes Nutter", msg.FromName)
+	assert.Equal(t, "Printer on fire", msg.Subject)
+	assert.Equal(t, 2026, msg.Date.Year())
+	assert.Equal(t, "Hello,\n\nit is on fire again.", msg.Body)
+	assert.False(t, msg.AutoGenerated)
+	assert.Empty(t, msg.References)
+}
+
+func TestParse_RequiresASender(t *testing.T) {
+	_, err := Parse(strings.NewReader("Subject: hi\r\n\r\nbody\r\n"))
+	assert.ErrorIs(t, err, ErrNoSender)
+}
+
+func TestParse_ReferencesNearestFirstWithoutDuplicates(t *testing.T) {
+	msg := parse(t, `From: a@example.com
+In-Reply-To: <three@x>
+References: <one@x> <two@x>
+ <three@x>
+
+body
+`)
+	assert.Equal(t, []string{"three@x", "two@x", "one@x"}, msg.References)
+}
+
+func TestParse_EncodedHeadersAndQuotedPrintable(t *testing.T) {
+	msg := parse(t, `From: =?UTF-8?Q?Ren=C3=A9e?= <renee@example.com>
+Subject: =?UTF-8?B?Q2Fmw6kgb3JkZXI=?=
+Content-Type: text/plain; charset=utf-8
+Content-Transfer-Encoding: quoted-printable
+
+Un caf=C3=A9, s'il vous pla=
+=C3=AEt.
+`)
+	assert.Equal(t, "Renée", msg.FromName)
+	assert.Equal(t, "Café order", msg.Subject)
+	assert.Equal(t, "Un café, s'il vous plaît.", msg.Body)
+}
+
+func TestParse_Windows1252Body(t *testing.T) {
+	raw := "From: a@example.com\r\nContent-Type: text/plain; charset=windows-1252\r\n\r\n\x93quoted\x94 \xe9t\xe9\r\n"
+	msg, err := Parse(strings.NewReader(raw))
+	require.NoError(t, err)
+	assert.Equal(t, "“quoted” été", msg.Body)
+}
+
+func TestParse_UnknownCharsetStillDelivered(t *testing.T) {
+	raw := "From: a@example.com\r\nContent-Type: text/plain; charset=x-klingon\r\n\r\nqapla\xff\r\n"
+	msg, err := Parse(strings.NewReader(raw))
+	require.NoError(t, err)
+	assert.Equal(t, "qapla�", msg.Body)
+}
+
+func TestParse_MultipartPrefersPlainTextAndSkipsAttachments(t *testing.T) {
+	msg := parse(t, `From: a@example.com
+MIME-Version: 1.0
+Content-Type: multipart/mixed; boundary="outer"
+
+--outer
+Content-Type: multipart/alternative; boundary="inner"
+
+--inner
+Content-Type: text/html; charset=utf-8
+
+<p>HTML version</p>
+--inner
+Content-Type: text/plain; charset=utf-8
+Content-Transfer-Encoding: base64
+
+UGxhaW4gdmVyc2lvbg==
+--inner--
+--outer
+Content-Type: text/plain
+Content-Disposition: attachment; filename="log.txt"
+
+attachment text
+--outer--
+`)
+	assert.Equal(t, "Plain version", msg.Body)
+}
+
+func TestParse_HTMLOnlyBodyReducedToText(t *testing.T) {
+	msg := parse(t, `From: a@example.com
+Content-Type: text/html; charset=utf-8
+
+<html><head><style>p { color: red }</style></head>
+<body><p>First &amp; foremost</p><p>Second<br>line</p></body></html>
+`)
+	assert.Equal(t, "First & foremost\nSecond\nline", msg.Body)
+}
+
+func TestParse_AutoGenerated(t *testing.T) {
+	cases := map[string]string{
+		"auto-submitted": "Auto-Submitted: auto-replied",
+		"precedence":     "Precedence: bulk",
+		"x-autoreply":    "X-Autoreply: yes",
+	}
+	for name, header := range cases {
+		t.Run(name, func(t *testing.T) {
+			msg := parse(t, "From: a@example.com\n"+header+"\n\nOut of office\n")
+			assert.True(t, msg.AutoGenerated)
+		})
+	}
+
+	msg := parse(t, "From: a@example.com\nAuto-Submitted: no\n\nhello\n")
+	assert.False(t, msg.AutoGenerated, "Auto-Submitted: no is a human")
+}
@@ -0,0 +1,92 @@
+package inbound
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// TicketToken is the marker a subject carries to thread a message onto a
+// ticket, e.g. "Printer on fire [Ticket #42-3f9c…]": the ticket number, for
+// people, and its reply token, which is what makes the reply trusted. Agents
+// writing to a customer from their own mail client put it in the subject; the
+// customer's reply keeps it.
+func TicketToken(ticketID uint, replyToken string) string {
+	return fmt.Sprintf("[Ticket #%d-%s]", ticketID, replyToken)
+}
+
+// ticketTokenPattern also matches the bare "[Ticket #N]" of older subjects,
+// so that CleanSubject strips it; it threads nothing.
+var ticketTokenPattern = regexp.MustCompile(`(?i)\[\s*ticket\s*#\s*(\d+)(?:\s*-\s*([0-9a-z]+))?\s*\]`)
+
+// TicketFromSubject returns the ticket and reply token named by the subject's
+// token. A token without its reply token names nothing.
+func TicketFromSubject(subject string) (uint, string, bool) {
+	match := ticketTokenPattern.FindStringSubmatch(subject)
+	if match == nil || match[2] == "" {
+		return 0, "", false
+	}
+	id, err := strconv.ParseUint(match[1], 10, 32)
+	if err != nil || id == 0 {
+		return 0, "", false
+	}
+	return uint(id), strings.ToLower(match[2]), true
+}
+
+// TicketTokensIn returns the reply tokens text carries for the ticket, such
+// as those in the quoted history of a reply whose subject lost its token.
+func TicketTokensIn(text string, ticketID uint) []string {
+	var tokens []string
+	for _, match := range ticketTokenPattern.FindAllStringSubmatch(text, -1) {
+		id, err := strconv.ParseUint(match[1], 10, 32)
+		if err == nil && uint(id) == ticketID && match[2] != "" {
+			tokens = append(tokens, strings.ToLower(match[2]))
+		}
+	}
+	return tokens
+}
+
+// replyPrefixPattern matches the stacked "Re:", "Fwd:" and localised
+// equivalents (AW, SV, WG, ...) clients put in front of a subject.
+var replyPrefixPattern = regexp.MustCompile(`(?i)^\s*((re|fw|fwd|aw|sv|wg|vs|antw)\s*(\[\d+\])?\s*:\s*)+`)
+
+// CleanSubject strips reply prefixes and ticket tokens, leaving the subject a
+// new ticket is titled with.
+func CleanSubject(subject string) string {
+	subject = ticketTokenPattern.ReplaceAllString(subject, "")
+	subject = replyPrefixPattern.ReplaceAllString(subject, "")
+	return strings.Join(strings.Fields(subject), " ")
+}
+
+var (
+	// attributionPattern matches the line clients put above a quoted reply,
+	// "On Mon, 2 Mar 2026 at 10:00, Agnes <agnes@example.com> wrote:".
+	attributionPattern = regexp.MustCompile(`(?i)^\s*on\s.+\swrote:\s*$`)
+	// separatorPattern matches Outlook's separators above a quoted reply.
+	separatorPattern = regexp.MustCompile(`^\s*(-{2,}\s*original message\s*-{2,}|_{10,})\s*$`)
+)
+
+// StripQuotedReply removes the quoted history from a reply, keeping what the
+// sender actually wrote: everything after an attribution line or an Outlook
+// separator goes, and so do the "> " quoted lines. When nothing would be left
+// — a bare forward, say — the body is returned whole rather than emptied.
+func StripQuotedReply(body string) string {
+	lines := strings.Split(body, "\n")
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		trimmed := strings.ToLower(strings.TrimSpace(line))
+		if attributionPattern.MatchString(line) || separatorPattern.MatchString(trimmed) {
+			break
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), ">") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	stripped := strings.TrimSpace(strings.Join(kept, "\n"))
+	if stripped == "" {
+		return strings.TrimSpace(body)
+	}
+	return stripped
+}
@@ -0,0 +1,54 @@
+package inbound
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTicketFromSubject(t *testing.T) {
+	id, token, ok := TicketFromSubject("Re: Printer on fire " + TicketToken(42, "3f9c0a6d"))
+	assert.True(t, ok)
+	assert.Equal(t, uint(42), id)
+	assert.Equal(t, "3f9c0a6d", token)
+
+	id, token, ok = TicketFromSubject("AW: [ticket # 7 - 3F9C0A6D] still broken")
+	assert.True(t, ok, "the token is matched loosely")
+	assert.Equal(t, uint(7), id)
+	assert.Equal(t, "3f9c0a6d", token)
+
+	for _, subject := range []string{"Printer on fire", "[Ticket #42]", "[Ticket #0-3f9c]", "[Ticket #abc-3f9c]", "Ticket #5-3f9c"} {
+		_, _, ok := TicketFromSubject(subject)
+		assert.False(t, ok, subject)
+	}
+}
+
+func TestTicketTokensIn(t *testing.T) {
+	body := "Still broken.\n\nOn Mon, Support wrote:\n> Subject: " + TicketToken(42, "3F9C0A6D") +
+		"\n> See also [Ticket #7-aaaa] and [Ticket #42]"
+	assert.Equal(t, []string{"3f9c0a6d"}, TicketTokensIn(body, 42))
+	assert.Equal(t, []string{"aaaa"}, TicketTokensIn(body, 7))
+	assert.Empty(t, TicketTokensIn(body, 43))
+}
+
+func TestCleanSubject(t *testing.T) {
+	assert.Equal(t, "Printer on fire", CleanSubject("Re: RE: Fwd: Printer   on fire [Ticket #42-3f9c0a6d]"))
+	assert.Equal(t, "Printer on fire", CleanSubject("Re: Printer on fire [Ticket #42]"))
+	assert.Equal(t, "Drucker", CleanSubject("AW: WG: Drucker"))
+	assert.Equal(t, "Regarding the invoice", CleanSubject("Regarding the invoice"))
+	assert.Equal(t, "", CleanSubject("Re: "))
+}
+
+func TestStripQuotedReply(t *testing.T) {
+	body := "Still broken, sorry.\n\nOn Mon, 2 Mar 2026 at 10:00, Support <support@example.com> wrote:\n> Have you tried turning it off?\n> Regards"
+	assert.Equal(t, "Still broken, sorry.", StripQuotedReply(body))
+
+	outlook := "Thanks!\n\n-----Original Message-----\nFrom: Support\nSubject: Re: help"
+	assert.Equal(t, "Thanks!", StripQuotedReply(outlook))
+
+	inline := "> question one\nanswer one\n> question two\nanswer two"
+	assert.Equal(t, "answer one\nanswer two", StripQuotedReply(inline))
+
+	onlyQuote := "> everything is quoted"
+	assert.Equal(t, "> everything is quoted", StripQuotedReply(onlyQuote), "an all-quote body is kept whole")
+}
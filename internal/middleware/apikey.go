@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// API key restrictions
+//
+// Auth holds a request made with an API key to what the key was minted with:
+// the networks it may come from, a rate of its own and its scopes. A scope
+// names an entity, and the entity of a request is found in its route: the
+// first segment of the path that stands for one, or for the bulk endpoints the
+// resource they are called on. A read is a GET or a HEAD; everything else is
+// a write. A route that stands for no entity is reachable only with a * scope.
+
+// apiKeyScopeSegments maps the path segments that stand for an entity to it.
+var apiKeyScopeSegments = map[string]string{
+	"users":           "users",
+	"roles":           "roles",
+	"permissions":     "roles",
+	"leads":           "leads",
+	"customers":       "customers",
+	"tickets":         "tickets",
+	"tasks":           "tasks",
+	"labels":          "labels",
+	"deals":           "deals",
+	"pipeline-stages": "deals",
+	"accounts":        "accounts",
+	"forms":           "forms",
+	"aeo":             "aeo",
+	"imports":         "imports",
+	"dashboard":       "dashboard",
+	"search":          "search",
+	"saved-views":     "saved_views",
+	"sla-policies":    "sla",
+	"inbound-emails":  "inbound_emails",
+	"custom-fields":   "custom_fields",
+	"configurations":  "configurations",
+	"webhooks":        "webhooks",
+	"audit":           "audit",
+	"bulk-operations": "bulk_operations",
+	"api-keys":        "api_keys",
+	"auth":            "auth",
+}
+
+// apiKeyScopeEntity returns the entity the matched route stands for, or "".
+func apiKeyScopeEntity(c *gin.Context) string {
+	for _, segment := range strings.Split(c.FullPath(), "/") {
+		if segment == ":resource" {
+			segment = c.Param("resource")
+		}
+		if entity, ok := apiKeyScopeSegments[segment]; ok {
+			return entity
+		}
+	}
+	return ""
+}
+
+// enforceAPIKeyRestrictions answers the request itself and returns false when
+// the key may not make it.
+func enforceAPIKeyRestrictions(c *gin.Context, apiKey *models.APIKey, limiters *apiKeyLimiters) bool {
+	logger := utils.GetLogger(c).WithField("api_key_id", apiKey.ID)
+
+	if !apiKey.AllowsIP(c.ClientIP()) {
+		logger.WithField("client_ip", c.ClientIP()).Warn("API key used from an address it is not allowed from")
+		utils.RespondForbidden(c, "This API key may not be used from your address")
+		return false
+	}
+
+	if !limiters.allow(apiKey.ID, apiKey.RateLimitPerMinute) {
+		logger.Warn("API key rate limit exceeded")
+		utils.RespondError(c, http.StatusTooManyRequests,
+			utils.ErrCodeTooManyRequests,
+			"Too many requests for this API key. Please try again later.",
+			gin.H{
+				"retry_after": "60s",
+			})
+		return false
+	}
+
+	method := c.Request.Method
+	write := method != http.MethodGet && method != http.MethodHead
+	if !apiKey.AllowsEntity(apiKeyScopeEntity(c), write) {
+		logger.WithField("route", c.FullPath()).Warn("API key scopes do not cover the request")
+		utils.RespondForbidden(c, "This API key's scopes do not allow this request")
+		return false
+	}
+
+	return true
+}
+
+// apiKeyLimiter is the rate limiter of one key, and the limit it was made for.
+type apiKeyLimiter struct {
+	limiter   *rate.Limiter
+	perMinute int
+	lastSeen  time.Time
+}
+
+// apiKeyLimiters holds the rate limiter of every key with a rate limit of its
+// own. A key's limiter is replaced when its limit no longer matches, and
+// dropped once the key has been idle for a while.
+type apiKeyLimiters struct {
+	mu       sync.Mutex
+	limiters map[uint]*apiKeyLimiter
+	swept    time.Time
+}
+
+// apiKeyLimiterIdle is how long a key's limiter outlives its last request. It
+// is past the minute a limiter takes to refill, so dropping it forgets nothing.
+const apiKeyLimiterIdle = 5 * time.Minute
+
+func newAPIKeyLimiters() *apiKeyLimiters {
+	return &apiKeyLimiters{limiters: make(map[uint]*apiKeyLimiter), swept: time.Now()}
+}
+
+// allow takes one request from the key's allowance: perMinute requests a
+// minute, all of which may come at once. A perMinute of zero allows every
+// request.
+func (l *apiKeyLimiters) allow(keyID uint, perMinute int) bool {
+	if perMinute <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.swept) > apiKeyLimiterIdle {
+		for id, entry := range l.limiters {
+			if now.Sub(entry.lastSeen) > apiKeyLimiterIdle {
+				delete(l.limiters, id)
+			}
+		}
+		l.swept = now
+	}
+
+	entry, ok := l.limiters[keyID]
+	if !ok || entry.perMinute != perMinute {
+		entry = &apiKeyLimiter{
+			limiter:   rate.NewLimiter(rate.Limit(float64(perMinute)/60), perMinute),
+			perMinute: perMinute,
+		}
+		l.limiters[keyID] = entry
+	}
+	entry.lastSeen = now
+	return entry.limiter.AllowN(now, 1)
+}
+
+// LogAPIKeyUsage records every request made with an API key in the key's
+// usage log, once it has been answered. It is mounted before Auth, so the
+// requests Auth turns away for the key's restrictions are recorded with the
+// status they were refused with. A failure to record is logged and does not
+// fail the request.
+func LogAPIKeyUsage(apiKeyService service.APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		apiKeyID := c.GetUint("api_key_id")
+		if apiKeyID == 0 {
+			return
+		}
+
+		usage := &models.APIKeyUsage{
+			APIKeyID:  apiKeyID,
+			Method:    c.Request.Method,
+			Endpoint:  truncate(c.Request.URL.Path, 255),
+			Status:    c.Writer.Status(),
+			IPAddress: c.ClientIP(),
+		}
+		if err := apiKeyService.RecordUsage(usage); err != nil {
+			utils.GetLogger(c).WithError(err).WithField("api_key_id", apiKeyID).
+				Warn("Failed to record API key usage")
+		}
+	}
+}
+
+// truncate cuts s to at most n bytes.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
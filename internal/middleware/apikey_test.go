@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/service/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// setupAPIKeyTestRouter mounts Auth, behind LogAPIKeyUsage when apiKeys is set,
+// over a few routes standing for the leads and the bulk endpoints.
+func setupAPIKeyTestRouter(authSvc *MockAuthService, apiKeys *mocks.APIKeyService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	if apiKeys != nil {
+		r.Use(LogAPIKeyUsage(apiKeys))
+	}
+	r.Use(Auth(authSvc))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/api/v1/leads", ok)
+	r.POST("/api/v1/leads", ok)
+	r.DELETE("/api/v1/leads/:id", ok)
+	r.GET("/api/v1/pipeline-stages", ok)
+	r.POST("/api/v1/bulk/:resource/update", ok)
+	r.GET("/api/v1/health", ok)
+	return r
+}
+
+func mockAPIKeyAuth(restrictions models.APIKeyRestrictions) *MockAuthService {
+	mockAuth := new(MockAuthService)
+	user := &models.User{BaseModel: models.BaseModel{ID: 7}, Role: models.RoleSales}
+	apiKey := &models.APIKey{BaseModel: models.BaseModel{ID: 3}, UserID: 7, APIKeyRestrictions: restrictions}
+	mockAuth.On("AuthenticateAPIKey", "gcrm_scoped").Return(user, apiKey, nil)
+	return mockAuth
+}
+
+func apiKeyRequest(router *gin.Engine, method, path, remoteAddr string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "ApiKey gcrm_scoped")
+	if remoteAddr != "" {
+		req.RemoteAddr = remoteAddr
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuth_APIKeyScopes(t *testing.T) {
+	router := setupAPIKeyTestRouter(mockAPIKeyAuth(models.APIKeyRestrictions{
+		Scopes: []models.APIKeyScope{"leads:read", "deals:write"},
+	}), nil)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   int
+	}{
+		{"read within a read scope", http.MethodGet, "/api/v1/leads", http.StatusOK},
+		{"write within a read scope", http.MethodPost, "/api/v1/leads", http.StatusForbidden},
+		{"delete within a read scope", http.MethodDelete, "/api/v1/leads/5", http.StatusForbidden},
+		{"neighbour of a write scope", http.MethodGet, "/api/v1/pipeline-stages", http.StatusOK},
+		{"bulk call on a read scope", http.MethodPost, "/api/v1/bulk/leads/update", http.StatusForbidden},
+		{"bulk call on a write scope", http.MethodPost, "/api/v1/bulk/deals/update", http.StatusOK},
+		{"route standing for no entity", http.MethodGet, "/api/v1/health", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := apiKeyRequest(router, tt.method, tt.path, "")
+			assert.Equal(t, tt.want, w.Code)
+			if tt.want == http.StatusForbidden {
+				assert.Contains(t, w.Body.String(), "scopes do not allow this request")
+			}
+		})
+	}
+}
+
+func TestAuth_APIKeyWildcardScope(t *testing.T) {
+	router := setupAPIKeyTestRouter(mockAPIKeyAuth(models.APIKeyRestrictions{
+		Scopes: []models.APIKeyScope{"*:read"},
+	}), nil)
+
+	assert.Equal(t, http.StatusOK, apiKeyRequest(router, http.MethodGet, "/api/v1/health", "").Code)
+	assert.Equal(t, http.StatusOK, apiKeyRequest(router, http.MethodGet, "/api/v1/leads", "").Code)
+	assert.Equal(t, http.StatusForbidden, apiKeyRequest(router, http.MethodPost, "/api/v1/leads", "").Code)
+}
+
+func TestAuth_APIKeyAllowedCIDRs(t *testing.T) {
+	router := setupAPIKeyTestRouter(mockAPIKeyAuth(models.APIKeyRestrictions{
+		Scopes:       []models.APIKeyScope{models.APIKeyScopeFullAccess},
+		AllowedCIDRs: []string{"10.1.0.0/16", "2001:db8::/32"},
+	}), nil)
+
+	assert.Equal(t, http.StatusOK, apiKeyRequest(router, http.MethodGet, "/api/v1/leads", "10.1.2.3:4000").Code)
+	assert.Equal(t, http.StatusOK, apiKeyRequest(router, http.MethodGet, "/api/v1/leads", "[2001:db8::1]:4000").Code)
+
+	w := apiKeyRequest(router, http.MethodGet, "/api/v1/leads", "192.168.1.1:4000")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "may not be used from your address")
+}
+
+func TestAuth_APIKeyRateLimit(t *testing.T) {
+	router := setupAPIKeyTestRouter(mockAPIKeyAuth(models.APIKeyRestrictions{
+		Scopes:             []models.APIKeyScope{models.APIKeyScopeFullAccess},
+		RateLimitPerMinute: 2,
+	}), nil)
+
+	assert.Equal(t, http.StatusOK, apiKeyRequest(router, http.MethodGet, "/api/v1/leads", "").Code)
+	assert.Equal(t, http.StatusOK, apiKeyRequest(router, http.MethodGet, "/api/v1/leads", "").Code)
+
+	w := apiKeyRequest(router, http.MethodGet, "/api/v1/leads", "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), "Too many requests for this API key")
+}
+
+func TestAPIKeyLimiters_ReplacedWhenLimitChanges(t *testing.T) {
+	limiters := newAPIKeyLimiters()
+
+	assert.True(t, limiters.allow(1, 1))
+	assert.False(t, limiters.allow(1, 1))
+	assert.True(t, limiters.allow(1, 5), "a raised limit takes effect at once")
+	assert.True(t, limiters.allow(2, 0), "zero sets no limit")
+}
+
+func TestLogAPIKeyUsage_RecordsRequests(t *testing.T) {
+	apiKeys := new(mocks.APIKeyService)
+	apiKeys.On("RecordUsage", mock.MatchedBy(func(u *models.APIKeyUsage) bool {
+		return u.APIKeyID == 3 && u.Method == http.MethodGet && u.Endpoint == "/api/v1/leads" &&
+			u.Status == http.StatusOK && u.IPAddress == "10.0.0.9"
+	})).Return(nil).Once()
+	apiKeys.On("RecordUsage", mock.MatchedBy(func(u *models.APIKeyUsage) bool {
+		return u.APIKeyID == 3 && u.Method == http.MethodPost && u.Status == http.StatusForbidden
+	})).Return(errors.New("database is down")).Once()
+
+	router := setupAPIKeyTestRouter(mockAPIKeyAuth(models.APIKeyRestrictions{
+		Scopes: []models.APIKeyScope{"leads:read"},
+	}), apiKeys)
+
+	assert.Equal(t, http.StatusOK, apiKeyRequest(router, http.MethodGet, "/api/v1/leads", "10.0.0.9:4000").Code)
+	// A refused request is recorded, and a failure to record it changes nothing.
+	assert.Equal(t, http.StatusForbidden, apiKeyRequest(router, http.MethodPost, "/api/v1/leads", "10.0.0.9:4000").Code)
+	apiKeys.AssertExpectations(t)
+}
+
+func TestLogAPIKeyUsage_IgnoresBearerRequests(t *testing.T) {
+	mockAuth := new(MockAuthService)
+	mockAuth.On("AuthenticateToken", "jwt").Return(&models.User{BaseModel: models.BaseModel{ID: 1}, Role: models.RoleAdmin}, nil, nil)
+	apiKeys := new(mocks.APIKeyService)
+
+	router := setupAPIKeyTestRouter(mockAuth, apiKeys)
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/leads", nil)
+	req.Header.Set("Authorization", "Bearer jwt")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	apiKeys.AssertNotCalled(t, "RecordUsage", mock.Anything)
+}
@@ -9,20 +9,28 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// Auth authenticates the request by its bearer token or API key. A request
+// made with an API key must also pass the key's restrictions: the address it
+// comes from, the key's own rate limit and the key's scopes, checked in that
+// order.
 func Auth(authService service.AuthService) gin.HandlerFunc {
+	limiters := newAPIKeyLimiters()
+
 	return func(c *gin.Context) {
 		var user *models.User
+		var apiKey *models.APIKey
+		var accessToken *service.AccessTokenClaims
 		var err error
 
 		// Check for Bearer token
 		authHeader := c.GetHeader("Authorization")
 		if strings.HasPrefix(authHeader, "Bearer ") {
 			token := strings.TrimPrefix(authHeader, "Bearer ")
-			user, err = authService.ValidateToken(token)
+			user, accessToken, err = authService.AuthenticateToken(token)
 		} else if strings.HasPrefix(authHeader, "ApiKey ") {
 			// Check for API Key
-			apiKey := strings.TrimPrefix(authHeader, "ApiKey ")
-			user, err = authService.ValidateAPIKey(apiKey)
+			rawKey := strings.TrimPrefix(authHeader, "ApiKey ")
+			user, apiKey, err = authService.AuthenticateAPIKey(rawKey)
 		} else {
 			utils.RespondUnauthorized(c, "Missing or invalid authorization header")
 			c.Abort()
@@ -39,6 +47,20 @@ func Auth(authService service.AuthService) gin.HandlerFunc {
 		c.Set("user", user)
 		c.Set("user_id", user.ID)
 		c.Set("user_role", string(user.Role))
+		if accessToken != nil {
+			// Lets logout revoke the token itself, and the session listing
+			// mark the session the request was made in.
+			c.Set("access_token", accessToken)
+		}
+		if apiKey != nil {
+			// Lets the audit trail attribute the request to the key as well
+			// as to its owner, and the usage log record what the key did.
+			c.Set("api_key_id", apiKey.ID)
+			if !enforceAPIKeyRestrictions(c, apiKey, limiters) {
+				c.Abort()
+				return
+			}
+		}
 
 		c.Next()
 	}
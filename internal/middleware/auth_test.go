@@ -23,8 +23,40 @@ func (m *MockAuthService) Login(email, password string) (string, error) {
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockAuthService) LoginWithTokens(email, password string) (*service.AuthTokens, error) {
-	args := m.Called(email, password)
+func (m *MockAuthService) LoginWithTokens(email, password string, client service.ClientInfo) (*service.AuthTokens, error) {
+	args := m.Called(email, password, client)
+	if t := args.Get(0); t != nil {
+		return t.(*service.AuthTokens), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAuthService) CompleteTwoFactorLogin(challenge, code string, client service.ClientInfo) (*service.AuthTokens, error) {
+	args := m.Called(challenge, code, client)
+	if t := args.Get(0); t != nil {
+		return t.(*service.AuthTokens), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAuthService) BeginTwoFactorSetup(challenge string) (*models.TwoFactorSetup, error) {
+	args := m.Called(challenge)
+	if s := args.Get(0); s != nil {
+		return s.(*models.TwoFactorSetup), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAuthService) LoginRegistered(user *models.User, client service.ClientInfo) (*service.AuthTokens, error) {
+	args := m.Called(user, client)
+	if t := args.Get(0); t != nil {
+		return t.(*service.AuthTokens), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAuthService) LoginWithSSO(user *models.User, client service.ClientInfo) (*service.AuthTokens, error) {
+	args := m.Called(user, client)
 	if t := args.Get(0); t != nil {
 		return t.(*service.AuthTokens), args.Error(1)
 	}
@@ -39,6 +71,13 @@ func (m *MockAuthService) ValidateToken(token string) (*models.User, error) {
 	return nil, args.Error(1)
 }
 
+func (m *MockAuthService) AuthenticateToken(token string) (*models.User, *service.AccessTokenClaims, error) {
+	args := m.Called(token)
+	user, _ := args.Get(0).(*models.User)
+	claims, _ := args.Get(1).(*service.AccessTokenClaims)
+	return user, claims, args.Error(2)
+}
+
 func (m *MockAuthService) ValidateAPIKey(key string) (*models.User, error) {
 	args := m.Called(key)
 	if u := args.Get(0); u != nil {
@@ -47,6 +86,13 @@ func (m *MockAuthService) ValidateAPIKey(key string) (*models.User, error) {
 	return nil, args.Error(1)
 }
 
+func (m *MockAuthService) AuthenticateAPIKey(key string) (*models.User, *models.APIKey, error) {
+	args := m.Called(key)
+	user, _ := args.Get(0).(*models.User)
+	apiKey, _ := args.Get(1).(*models.APIKey)
+	return user, apiKey, args.Error(2)
+}
+
 func (m *MockAuthService) GenerateJWT(user *models.User) (string, error) {
 	args := m.Called(user)
 	return args.String(0), args.Error(1)
@@ -60,8 +106,8 @@ func (m *MockAuthService) GenerateTokens(user *models.User) (*service.AuthTokens
 	return nil, args.Error(1)
 }
 
-func (m *MockAuthService) RefreshAccessToken(refreshToken string) (*service.AuthTokens, error) {
-	args := m.Called(refreshToken)
+func (m *MockAuthService) RefreshAccessToken(refreshToken string, client service.ClientInfo) (*service.AuthTokens, error) {
+	args := m.Called(refreshToken, client)
 	if t := args.Get(0); t != nil {
 		return t.(*service.AuthTokens), args.Error(1)
 	}
@@ -73,8 +119,19 @@ func (m *MockAuthService) InvalidateRefreshToken(refreshToken string) error {
 	return args.Error(0)
 }
 
-func (m *MockAuthService) Logout(userID uint, refreshToken string) error {
-	args := m.Called(userID, refreshToken)
+func (m *MockAuthService) Logout(userID uint, refreshToken string, current *service.AccessTokenClaims) error {
+	args := m.Called(userID, refreshToken, current)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ListSessions(userID, currentSessionID uint) ([]models.Session, error) {
+	args := m.Called(userID, currentSessionID)
+	sessions, _ := args.Get(0).([]models.Session)
+	return sessions, args.Error(1)
+}
+
+func (m *MockAuthService) RevokeSession(userID, sessionID uint) error {
+	args := m.Called(userID, sessionID)
 	return args.Error(0)
 }
 
@@ -110,7 +167,8 @@ func setupAuthTestRouter(authSvc *MockAuthService) *gin.Engine {
 	r.GET("/protected", func(c *gin.Context) {
 		userID, _ := c.Get("user_id")
 		role, _ := c.Get("user_role")
-		c.JSON(http.StatusOK, gin.H{"user_id": userID, "role": role})
+		apiKeyID, _ := c.Get("api_key_id")
+		c.JSON(http.StatusOK, gin.H{"user_id": userID, "role": role, "api_key_id": apiKeyID})
 	})
 	return r
 }
@@ -122,7 +180,7 @@ func TestAuth_ValidBearerToken(t *testing.T) {
 		Email:     "test@example.com",
 		Role:      models.RoleAdmin,
 	}
-	mockAuth.On("ValidateToken", "valid-jwt-token").Return(testUser, nil)
+	mockAuth.On("AuthenticateToken", "valid-jwt-token").Return(testUser, nil, nil)
 
 	router := setupAuthTestRouter(mockAuth)
 
@@ -139,7 +197,7 @@ func TestAuth_ValidBearerToken(t *testing.T) {
 
 func TestAuth_InvalidBearerToken(t *testing.T) {
 	mockAuth := new(MockAuthService)
-	mockAuth.On("ValidateToken", "bad-token").Return(nil, errors.New("invalid token"))
+	mockAuth.On("AuthenticateToken", "bad-token").Return(nil, nil, errors.New("invalid token"))
 
 	router := setupAuthTestRouter(mockAuth)
 
@@ -155,7 +213,7 @@ func TestAuth_InvalidBearerToken(t *testing.T) {
 
 func TestAuth_ExpiredBearerToken(t *testing.T) {
 	mockAuth := new(MockAuthService)
-	mockAuth.On("ValidateToken", "expired-token").Return(nil, errors.New("token expired"))
+	mockAuth.On("AuthenticateToken", "expired-token").Return(nil, nil, errors.New("token expired"))
 
 	router := setupAuthTestRouter(mockAuth)
 
@@ -212,7 +270,12 @@ func TestAuth_ValidAPIKey(t *testing.T) {
 		Email:     "api@example.com",
 		Role:      models.RoleSales,
 	}
-	mockAuth.On("ValidateAPIKey", "gcrm_testapikey123").Return(testUser, nil)
+	apiKey := &models.APIKey{
+		BaseModel:          models.BaseModel{ID: 3},
+		UserID:             7,
+		APIKeyRestrictions: models.APIKeyRestrictions{Scopes: []models.APIKeyScope{models.APIKeyScopeFullAccess}},
+	}
+	mockAuth.On("AuthenticateAPIKey", "gcrm_testapikey123").Return(testUser, apiKey, nil)
 
 	router := setupAuthTestRouter(mockAuth)
 
@@ -224,12 +287,13 @@ func TestAuth_ValidAPIKey(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), `"user_id":7`)
 	assert.Contains(t, w.Body.String(), `"role":"sales"`)
+	assert.Contains(t, w.Body.String(), `"api_key_id":3`)
 	mockAuth.AssertExpectations(t)
 }
 
 func TestAuth_InvalidAPIKey(t *testing.T) {
 	mockAuth := new(MockAuthService)
-	mockAuth.On("ValidateAPIKey", "invalid-key").Return(nil, errors.New("api key not found"))
+	mockAuth.On("AuthenticateAPIKey", "invalid-key").Return(nil, nil, errors.New("api key not found"))
 
 	router := setupAuthTestRouter(mockAuth)
 
@@ -250,7 +314,8 @@ func TestAuth_SetsUserContext(t *testing.T) {
 		Email:     "ctx@example.com",
 		Role:      models.RoleSupport,
 	}
-	mockAuth.On("ValidateToken", "ctx-token").Return(testUser, nil)
+	claims := &service.AccessTokenClaims{TokenID: "jti-1", SessionID: 7}
+	mockAuth.On("AuthenticateToken", "ctx-token").Return(testUser, claims, nil)
 
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
@@ -268,6 +333,10 @@ func TestAuth_SetsUserContext(t *testing.T) {
 		assert.True(t, exists)
 		assert.Equal(t, "support", role)
 
+		token, exists := c.Get("access_token")
+		assert.True(t, exists)
+		assert.Equal(t, claims, token)
+
 		c.Status(http.StatusOK)
 	})
 
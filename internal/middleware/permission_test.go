@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func permissionRouter(roleService *mocks.RoleService, role string, permission models.Permission) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_role", role)
+		c.Next()
+	})
+	if roleService != nil {
+		r.Use(LoadPermissions(roleService))
+	}
+	r.GET("/guarded", RequirePermission(permission), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("user_role"))
+	})
+	return r
+}
+
+func serve(r *gin.Engine) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/guarded", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequirePermission_BuiltInRoleWithoutLoadPermissions(t *testing.T) {
+	assert.Equal(t, http.StatusOK, serve(permissionRouter(nil, "admin", models.PermRolesManage)).Code)
+	assert.Equal(t, http.StatusOK, serve(permissionRouter(nil, "sales", models.PermLeadsRead)).Code)
+
+	w := serve(permissionRouter(nil, "sales", models.PermLeadsExport))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "Insufficient permissions")
+}
+
+func TestLoadPermissions_CustomRole(t *testing.T) {
+	roleService := new(mocks.RoleService)
+	roleService.On("Resolve", models.UserRole("auditor")).
+		Return(models.RoleSupport, models.NewPermissionSet(models.PermAuditRead), nil)
+
+	w := serve(permissionRouter(roleService, "auditor", models.PermAuditRead))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "support", w.Body.String(), "the base role replaces the custom one for the record rules")
+
+	w = serve(permissionRouter(roleService, "auditor", models.PermTicketsCreate))
+	assert.Equal(t, http.StatusForbidden, w.Code, "the base role's permissions are not inherited")
+	roleService.AssertExpectations(t)
+}
+
+func TestLoadPermissions_DeletedRole(t *testing.T) {
+	roleService := new(mocks.RoleService)
+	roleService.On("Resolve", models.UserRole("ghost")).
+		Return(models.UserRole(""), nil, fmt.Errorf("role %q: %w", "ghost", apperrors.ErrNotFound))
+
+	w := serve(permissionRouter(roleService, "ghost", models.PermAuditRead))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "Your role no longer exists")
+	roleService.AssertExpectations(t)
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"fmt"
+	"net/http"
 	"runtime"
 
 	"github.com/florinel-chis/gophercrm/internal/utils"
@@ -13,6 +14,15 @@ func Recovery() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if err := recover(); err != nil {
+				// A handler that has already begun its response, such as a
+				// streamed export failing halfway, aborts it with
+				// http.ErrAbortHandler so the client sees a broken connection
+				// rather than a short body that looks complete. net/http drops
+				// the connection without logging it.
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+
 				// Get stack trace
 				buf := make([]byte, 1024)
 				n := runtime.Stack(buf, false)
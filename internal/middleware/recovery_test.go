@@ -79,3 +79,18 @@ func TestRecovery_PanicWithIntegerValue(t *testing.T) {
 
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
+
+func TestRecovery_AbortHandlerPropagates(t *testing.T) {
+	r := setupRecoveryRouter()
+	r.GET("/abort", func(c *gin.Context) {
+		c.String(http.StatusOK, "half a file")
+		panic(http.ErrAbortHandler)
+	})
+
+	req, _ := http.NewRequest("GET", "/abort", nil)
+	w := httptest.NewRecorder()
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() { r.ServeHTTP(w, req) },
+		"net/http must see the abort to drop the connection")
+	assert.Equal(t, "half a file", w.Body.String(), "and nothing is appended to the body")
+}
@@ -64,9 +64,9 @@ func (_m *AEORepository) DeletePrompt(id uint) error {
 	return ret.Error(0)
 }
 
-// ListPrompts provides a mock function with given fields: activeOnly, offset, limit, sortBy, sortOrder
-func (_m *AEORepository) ListPrompts(activeOnly bool, offset int, limit int, sortBy string, sortOrder string) ([]models.AEOPrompt, error) {
-	ret := _m.Called(activeOnly, offset, limit, sortBy, sortOrder)
+// ListPrompts provides a mock function with given fields: filter, offset, limit, sortBy, sortOrder
+func (_m *AEORepository) ListPrompts(filter models.AEOPromptFilter, offset int, limit int, sortBy string, sortOrder string) ([]models.AEOPrompt, error) {
+	ret := _m.Called(filter, offset, limit, sortBy, sortOrder)
 
 	var r0 []models.AEOPrompt
 	if ret.Get(0) != nil {
@@ -75,9 +75,9 @@ func (_m *AEORepository) ListPrompts(activeOnly bool, offset int, limit int, sor
 	return r0, ret.Error(1)
 }
 
-// CountPrompts provides a mock function with given fields: activeOnly
-func (_m *AEORepository) CountPrompts(activeOnly bool) (int64, error) {
-	ret := _m.Called(activeOnly)
+// CountPrompts provides a mock function with given fields: filter
+func (_m *AEORepository) CountPrompts(filter models.AEOPromptFilter) (int64, error) {
+	ret := _m.Called(filter)
 
 	var r0 int64
 	if ret.Get(0) != nil {
@@ -41,9 +41,9 @@ func (_m *AEOService) SaveProfile(profile *models.AEOProfile) (*models.AEOProfil
 	return r0, ret.Error(1)
 }
 
-// ListPrompts provides a mock function with given fields: from, to, activeOnly, offset, limit, sortBy, sortOrder
-func (_m *AEOService) ListPrompts(from time.Time, to time.Time, activeOnly bool, offset int, limit int, sortBy string, sortOrder string) ([]models.AEOPrompt, int64, error) {
-	ret := _m.Called(from, to, activeOnly, offset, limit, sortBy, sortOrder)
+// ListPrompts provides a mock function with given fields: from, to, filter, offset, limit, sortBy, sortOrder
+func (_m *AEOService) ListPrompts(from time.Time, to time.Time, filter models.AEOPromptFilter, offset int, limit int, sortBy string, sortOrder string) ([]models.AEOPrompt, int64, error) {
+	ret := _m.Called(from, to, filter, offset, limit, sortBy, sortOrder)
 
 	var r0 []models.AEOPrompt
 	if ret.Get(0) != nil {
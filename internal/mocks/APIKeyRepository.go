@@ -33,6 +33,24 @@ func (_m *APIKeyRepository) Create(apiKey *models.APIKey) error {
 	return r0
 }
 
+// CreateUsage provides a mock function with given fields: usage
+func (_m *APIKeyRepository) CreateUsage(usage *models.APIKeyUsage) error {
+	ret := _m.Called(usage)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateUsage")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.APIKeyUsage) error); ok {
+		r0 = rf(usage)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Delete provides a mock function with given fields: id
 func (_m *APIKeyRepository) Delete(id uint) error {
 	ret := _m.Called(id)
@@ -141,6 +159,21 @@ func (_m *APIKeyRepository) GetByUserID(userID uint) ([]models.APIKey, error) {
 	return r0, r1
 }
 
+// ListUsage provides a mock function with given fields: apiKeyID, offset, limit
+func (_m *APIKeyRepository) ListUsage(apiKeyID uint, offset int, limit int) ([]models.APIKeyUsage, int64, error) {
+	ret := _m.Called(apiKeyID, offset, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListUsage")
+	}
+
+	var r0 []models.APIKeyUsage
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.APIKeyUsage)
+	}
+	return r0, ret.Get(1).(int64), ret.Error(2)
+}
+
 // Update provides a mock function with given fields: apiKey
 func (_m *APIKeyRepository) Update(apiKey *models.APIKey) error {
 	ret := _m.Called(apiKey)
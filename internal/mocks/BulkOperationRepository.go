@@ -7,6 +7,8 @@ import (
 	repository "github.com/florinel-chis/gophercrm/internal/repository"
 	mock "github.com/stretchr/testify/mock"
 	gorm "gorm.io/gorm"
+
+	time "time"
 )
 
 // BulkOperationRepository is an autogenerated mock type for the BulkOperationRepository type
@@ -14,6 +16,56 @@ type BulkOperationRepository struct {
 	mock.Mock
 }
 
+// Cancel provides a mock function with given fields: id, finishedAt
+func (_m *BulkOperationRepository) Cancel(id uint, finishedAt time.Time) (bool, error) {
+	ret := _m.Called(id, finishedAt)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uint, time.Time) (bool, error)); ok {
+		return rf(id, finishedAt)
+	}
+	if rf, ok := ret.Get(0).(func(uint, time.Time) bool); ok {
+		r0 = rf(id, finishedAt)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(uint, time.Time) error); ok {
+		r1 = rf(id, finishedAt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ClaimNextQueued provides a mock function with given fields: startedAt
+func (_m *BulkOperationRepository) ClaimNextQueued(startedAt time.Time) (*models.BulkOperation, error) {
+	ret := _m.Called(startedAt)
+
+	var r0 *models.BulkOperation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(time.Time) (*models.BulkOperation, error)); ok {
+		return rf(startedAt)
+	}
+	if rf, ok := ret.Get(0).(func(time.Time) *models.BulkOperation); ok {
+		r0 = rf(startedAt)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.BulkOperation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(startedAt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Count provides a mock function with no fields
 func (_m *BulkOperationRepository) Count() (int64, error) {
 	ret := _m.Called()
@@ -90,6 +142,20 @@ func (_m *BulkOperationRepository) CreateItem(item *models.BulkOperationItem) er
 	return r0
 }
 
+// CreateItems provides a mock function with given fields: items
+func (_m *BulkOperationRepository) CreateItems(items []models.BulkOperationItem) error {
+	ret := _m.Called(items)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]models.BulkOperationItem) error); ok {
+		r0 = rf(items)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Delete provides a mock function with given fields: id
 func (_m *BulkOperationRepository) Delete(id uint) error {
 	ret := _m.Called(id)
@@ -104,6 +170,20 @@ func (_m *BulkOperationRepository) Delete(id uint) error {
 	return r0
 }
 
+// Finish provides a mock function with given fields: id, status, successCount, failureCount, finishedAt
+func (_m *BulkOperationRepository) Finish(id uint, status models.BulkOperationStatus, successCount int, failureCount int, finishedAt time.Time) error {
+	ret := _m.Called(id, status, successCount, failureCount, finishedAt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, models.BulkOperationStatus, int, int, time.Time) error); ok {
+		r0 = rf(id, status, successCount, failureCount, finishedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetByID provides a mock function with given fields: id
 func (_m *BulkOperationRepository) GetByID(id uint) (*models.BulkOperation, error) {
 	ret := _m.Called(id)
@@ -182,6 +262,32 @@ func (_m *BulkOperationRepository) GetByUserID(userID uint, offset int, limit in
 	return r0, r1
 }
 
+// GetFailedItems provides a mock function with given fields: operationID
+func (_m *BulkOperationRepository) GetFailedItems(operationID uint) ([]models.BulkOperationItem, error) {
+	ret := _m.Called(operationID)
+
+	var r0 []models.BulkOperationItem
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uint) ([]models.BulkOperationItem, error)); ok {
+		return rf(operationID)
+	}
+	if rf, ok := ret.Get(0).(func(uint) []models.BulkOperationItem); ok {
+		r0 = rf(operationID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.BulkOperationItem)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(uint) error); ok {
+		r1 = rf(operationID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetItemsByOperationID provides a mock function with given fields: operationID
 func (_m *BulkOperationRepository) GetItemsByOperationID(operationID uint) ([]models.BulkOperationItem, error) {
 	ret := _m.Called(operationID)
@@ -208,6 +314,32 @@ func (_m *BulkOperationRepository) GetItemsByOperationID(operationID uint) ([]mo
 	return r0, r1
 }
 
+// GetSnapshotItems provides a mock function with given fields: operationID
+func (_m *BulkOperationRepository) GetSnapshotItems(operationID uint) ([]models.BulkOperationItem, error) {
+	ret := _m.Called(operationID)
+
+	var r0 []models.BulkOperationItem
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uint) ([]models.BulkOperationItem, error)); ok {
+		return rf(operationID)
+	}
+	if rf, ok := ret.Get(0).(func(uint) []models.BulkOperationItem); ok {
+		r0 = rf(operationID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.BulkOperationItem)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(uint) error); ok {
+		r1 = rf(operationID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // List provides a mock function with given fields: offset, limit
 func (_m *BulkOperationRepository) List(offset int, limit int) ([]models.BulkOperation, error) {
 	ret := _m.Called(offset, limit)
@@ -234,6 +366,54 @@ func (_m *BulkOperationRepository) List(offset int, limit int) ([]models.BulkOpe
 	return r0, r1
 }
 
+// MarkInterruptedFailed provides a mock function with given fields: finishedAt
+func (_m *BulkOperationRepository) MarkInterruptedFailed(finishedAt time.Time) (int64, error) {
+	ret := _m.Called(finishedAt)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(time.Time) (int64, error)); ok {
+		return rf(finishedAt)
+	}
+	if rf, ok := ret.Get(0).(func(time.Time) int64); ok {
+		r0 = rf(finishedAt)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(finishedAt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkReverted provides a mock function with given fields: id, revertedAt
+func (_m *BulkOperationRepository) MarkReverted(id uint, revertedAt time.Time) (bool, error) {
+	ret := _m.Called(id, revertedAt)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uint, time.Time) (bool, error)); ok {
+		return rf(id, revertedAt)
+	}
+	if rf, ok := ret.Get(0).(func(uint, time.Time) bool); ok {
+		r0 = rf(id, revertedAt)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(uint, time.Time) error); ok {
+		r1 = rf(id, revertedAt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Update provides a mock function with given fields: operation
 func (_m *BulkOperationRepository) Update(operation *models.BulkOperation) error {
 	ret := _m.Called(operation)
@@ -248,6 +428,20 @@ func (_m *BulkOperationRepository) Update(operation *models.BulkOperation) error
 	return r0
 }
 
+// UpdateCounts provides a mock function with given fields: id, successCount, failureCount
+func (_m *BulkOperationRepository) UpdateCounts(id uint, successCount int, failureCount int) error {
+	ret := _m.Called(id, successCount, failureCount)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, int, int) error); ok {
+		r0 = rf(id, successCount, failureCount)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateItem provides a mock function with given fields: item
 func (_m *BulkOperationRepository) UpdateItem(item *models.BulkOperationItem) error {
 	ret := _m.Called(item)
@@ -262,6 +456,20 @@ func (_m *BulkOperationRepository) UpdateItem(item *models.BulkOperationItem) er
 	return r0
 }
 
+// UpdateProgress provides a mock function with given fields: id, status, successCount, failureCount
+func (_m *BulkOperationRepository) UpdateProgress(id uint, status models.BulkOperationStatus, successCount int, failureCount int) error {
+	ret := _m.Called(id, status, successCount, failureCount)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, models.BulkOperationStatus, int, int) error); ok {
+		r0 = rf(id, status, successCount, failureCount)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateStatus provides a mock function with given fields: id, status
 func (_m *BulkOperationRepository) UpdateStatus(id uint, status models.BulkOperationStatus) error {
 	ret := _m.Called(id, status)
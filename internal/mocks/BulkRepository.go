@@ -400,6 +400,32 @@ func (_m *BulkRepository) GetLeadsByIDs(ids []uint) ([]models.Lead, error) {
 	return r0, r1
 }
 
+// GetRowStates provides a mock function with given fields: resourceType, ids
+func (_m *BulkRepository) GetRowStates(resourceType string, ids []uint) (map[uint]models.BulkRowState, error) {
+	ret := _m.Called(resourceType, ids)
+
+	var r0 map[uint]models.BulkRowState
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, []uint) (map[uint]models.BulkRowState, error)); ok {
+		return rf(resourceType, ids)
+	}
+	if rf, ok := ret.Get(0).(func(string, []uint) map[uint]models.BulkRowState); ok {
+		r0 = rf(resourceType, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[uint]models.BulkRowState)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, []uint) error); ok {
+		r1 = rf(resourceType, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetTasksByIDs provides a mock function with given fields: ids
 func (_m *BulkRepository) GetTasksByIDs(ids []uint) ([]models.Task, error) {
 	ret := _m.Called(ids)
@@ -452,6 +478,20 @@ func (_m *BulkRepository) GetTicketsByIDs(ids []uint) ([]models.Ticket, error) {
 	return r0, r1
 }
 
+// RestoreRow provides a mock function with given fields: resourceType, id, snapshot
+func (_m *BulkRepository) RestoreRow(resourceType string, id uint, snapshot *models.BulkItemSnapshot) error {
+	ret := _m.Called(resourceType, id, snapshot)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, uint, *models.BulkItemSnapshot) error); ok {
+		r0 = rf(resourceType, id, snapshot)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetLeadStatus provides a mock function with given fields: ids, status
 func (_m *BulkRepository) SetLeadStatus(ids []uint, status models.LeadStatus) error {
 	ret := _m.Called(ids, status)
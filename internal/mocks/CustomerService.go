@@ -145,6 +145,24 @@ func (_m *CustomerService) List(offset int, limit int) ([]models.Customer, int64
 	return r0, r1, r2
 }
 
+// StreamExport provides a mock function with given fields: filter, fn
+func (_m *CustomerService) StreamExport(filter models.CustomerFilter, fn func([]models.Customer) error) error {
+	ret := _m.Called(filter, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamExport")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.CustomerFilter, func([]models.Customer) error) error); ok {
+		r0 = rf(filter, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: customer
 func (_m *CustomerService) Update(customer *models.Customer) error {
 	ret := _m.Called(customer)
@@ -279,29 +297,29 @@ func NewCustomerService(t interface {
 	return mock
 }
 
-// ExportAll provides a mock function with given fields: search, sortBy, sortOrder
-func (_m *CustomerService) ExportAll(search string, sortBy string, sortOrder string) ([]models.Customer, error) {
-	ret := _m.Called(search, sortBy, sortOrder)
+// Assign provides a mock function with given fields: customerID, userID
+func (_m *CustomerService) Assign(customerID uint, userID uint) (*models.Customer, error) {
+	ret := _m.Called(customerID, userID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ExportAll")
+		panic("no return value specified for Assign")
 	}
 
-	var r0 []models.Customer
+	var r0 *models.Customer
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string, string) ([]models.Customer, error)); ok {
-		return rf(search, sortBy, sortOrder)
+	if rf, ok := ret.Get(0).(func(uint, uint) (*models.Customer, error)); ok {
+		return rf(customerID, userID)
 	}
-	if rf, ok := ret.Get(0).(func(string, string, string) []models.Customer); ok {
-		r0 = rf(search, sortBy, sortOrder)
+	if rf, ok := ret.Get(0).(func(uint, uint) *models.Customer); ok {
+		r0 = rf(customerID, userID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]models.Customer)
+			r0 = ret.Get(0).(*models.Customer)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
-		r1 = rf(search, sortBy, sortOrder)
+	if rf, ok := ret.Get(1).(func(uint, uint) error); ok {
+		r1 = rf(customerID, userID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -309,32 +327,39 @@ func (_m *CustomerService) ExportAll(search string, sortBy string, sortOrder str
 	return r0, r1
 }
 
-// Assign provides a mock function with given fields: customerID, userID
-func (_m *CustomerService) Assign(customerID uint, userID uint) (*models.Customer, error) {
-	ret := _m.Called(customerID, userID)
+// ListFiltered provides a mock function with given fields: filter, offset, limit
+func (_m *CustomerService) ListFiltered(filter models.CustomerFilter, offset int, limit int) ([]models.Customer, int64, error) {
+	ret := _m.Called(filter, offset, limit)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Assign")
+		panic("no return value specified for ListFiltered")
 	}
 
-	var r0 *models.Customer
-	var r1 error
-	if rf, ok := ret.Get(0).(func(uint, uint) (*models.Customer, error)); ok {
-		return rf(customerID, userID)
+	var r0 []models.Customer
+	var r1 int64
+	var r2 error
+	if rf, ok := ret.Get(0).(func(models.CustomerFilter, int, int) ([]models.Customer, int64, error)); ok {
+		return rf(filter, offset, limit)
 	}
-	if rf, ok := ret.Get(0).(func(uint, uint) *models.Customer); ok {
-		r0 = rf(customerID, userID)
+	if rf, ok := ret.Get(0).(func(models.CustomerFilter, int, int) []models.Customer); ok {
+		r0 = rf(filter, offset, limit)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*models.Customer)
+			r0 = ret.Get(0).([]models.Customer)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(uint, uint) error); ok {
-		r1 = rf(customerID, userID)
+	if rf, ok := ret.Get(1).(func(models.CustomerFilter, int, int) int64); ok {
+		r1 = rf(filter, offset, limit)
 	} else {
-		r1 = ret.Error(1)
+		r1 = ret.Get(1).(int64)
 	}
 
-	return r0, r1
+	if rf, ok := ret.Get(2).(func(models.CustomerFilter, int, int) error); ok {
+		r2 = rf(filter, offset, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
@@ -222,6 +222,24 @@ func (_m *TicketService) List(offset int, limit int) ([]models.Ticket, int64, er
 	return r0, r1, r2
 }
 
+// StreamExport provides a mock function with given fields: filter, fn
+func (_m *TicketService) StreamExport(filter models.TicketFilter, fn func([]models.Ticket) error) error {
+	ret := _m.Called(filter, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamExport")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.TicketFilter, func([]models.Ticket) error) error); ok {
+		r0 = rf(filter, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: ticket
 func (_m *TicketService) Update(ticket *models.Ticket) error {
 	ret := _m.Called(ticket)
@@ -355,3 +373,40 @@ func NewTicketService(t interface {
 
 	return mock
 }
+
+// ListFiltered provides a mock function with given fields: filter, offset, limit
+func (_m *TicketService) ListFiltered(filter models.TicketFilter, offset int, limit int) ([]models.Ticket, int64, error) {
+	ret := _m.Called(filter, offset, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListFiltered")
+	}
+
+	var r0 []models.Ticket
+	var r1 int64
+	var r2 error
+	if rf, ok := ret.Get(0).(func(models.TicketFilter, int, int) ([]models.Ticket, int64, error)); ok {
+		return rf(filter, offset, limit)
+	}
+	if rf, ok := ret.Get(0).(func(models.TicketFilter, int, int) []models.Ticket); ok {
+		r0 = rf(filter, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Ticket)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(models.TicketFilter, int, int) int64); ok {
+		r1 = rf(filter, offset, limit)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if rf, ok := ret.Get(2).(func(models.TicketFilter, int, int) error); ok {
+		r2 = rf(filter, offset, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
@@ -202,6 +202,24 @@ func (_m *UserRepository) List(offset int, limit int) ([]models.User, error) {
 	return r0, r1
 }
 
+// StreamFiltered provides a mock function with given fields: filter, fn
+func (_m *UserRepository) StreamFiltered(filter models.UserFilter, fn func([]models.User) error) error {
+	ret := _m.Called(filter, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamFiltered")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.UserFilter, func([]models.User) error) error); ok {
+		r0 = rf(filter, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: user
 func (_m *UserRepository) Update(user *models.User) error {
 	ret := _m.Called(user)
@@ -270,6 +288,16 @@ func (_m *UserRepository) Search(query string, offset int, limit int, sortBy str
 	return r0, r1
 }
 
+// ListFiltered provides a mock function with given fields: filter, offset, limit
+func (_m *UserRepository) ListFiltered(filter models.UserFilter, offset int, limit int) ([]models.User, int64, error) {
+	ret := _m.Called(filter, offset, limit)
+	var r0 []models.User
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.User)
+	}
+	return r0, ret.Get(1).(int64), ret.Error(2)
+}
+
 // CountSearch provides a mock function with given fields: query
 func (_m *UserRepository) CountSearch(query string) (int64, error) {
 	ret := _m.Called(query)
@@ -305,6 +305,24 @@ func (_m *CustomerRepository) ListWithPreloads(offset int, limit int, preloads .
 	return r0, r1
 }
 
+// StreamFiltered provides a mock function with given fields: filter, fn
+func (_m *CustomerRepository) StreamFiltered(filter models.CustomerFilter, fn func([]models.Customer) error) error {
+	ret := _m.Called(filter, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamFiltered")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.CustomerFilter, func([]models.Customer) error) error); ok {
+		r0 = rf(filter, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: customer
 func (_m *CustomerRepository) Update(customer *models.Customer) error {
 	ret := _m.Called(customer)
@@ -348,6 +366,16 @@ func (_m *CustomerRepository) CountSearch(query string) (int64, error) {
 	return ret.Get(0).(int64), ret.Error(1)
 }
 
+// ListFiltered provides a mock function with given fields: filter, offset, limit, preloads
+func (_m *CustomerRepository) ListFiltered(filter models.CustomerFilter, offset int, limit int, preloads ...string) ([]models.Customer, int64, error) {
+	ret := _m.Called(filter, offset, limit, preloads)
+	var r0 []models.Customer
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.Customer)
+	}
+	return r0, ret.Get(1).(int64), ret.Error(2)
+}
+
 func (_m *CustomerRepository) WithTx(tx *gorm.DB) repository.CustomerRepository {
 	_m.Called(tx)
 	return _m
@@ -364,33 +392,3 @@ func NewCustomerRepository(t interface {
 
 	return mock
 }
-
-// ListAllForExport provides a mock function with given fields: search, sortBy, sortOrder
-func (_m *CustomerRepository) ListAllForExport(search string, sortBy string, sortOrder string) ([]models.Customer, error) {
-	ret := _m.Called(search, sortBy, sortOrder)
-
-	if len(ret) == 0 {
-		panic("no return value specified for ListAllForExport")
-	}
-
-	var r0 []models.Customer
-	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string, string) ([]models.Customer, error)); ok {
-		return rf(search, sortBy, sortOrder)
-	}
-	if rf, ok := ret.Get(0).(func(string, string, string) []models.Customer); ok {
-		r0 = rf(search, sortBy, sortOrder)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]models.Customer)
-		}
-	}
-
-	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
-		r1 = rf(search, sortBy, sortOrder)
-	} else {
-		r1 = ret.Error(1)
-	}
-
-	return r0, r1
-}
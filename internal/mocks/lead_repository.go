@@ -367,6 +367,16 @@ func (_m *LeadRepository) CountSearch(query string) (int64, error) {
 	return r0, r1
 }
 
+// ListFiltered provides a mock function with given fields: filter, offset, limit, preloads
+func (_m *LeadRepository) ListFiltered(filter models.LeadFilter, offset int, limit int, preloads ...string) ([]models.Lead, int64, error) {
+	ret := _m.Called(filter, offset, limit, preloads)
+	var r0 []models.Lead
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.Lead)
+	}
+	return r0, ret.Get(1).(int64), ret.Error(2)
+}
+
 // ListSortedWithPreloads provides a mock function with given fields: offset, limit, sortBy, sortOrder, preloads
 func (_m *LeadRepository) ListSortedWithPreloads(offset int, limit int, sortBy string, sortOrder string, preloads ...string) ([]models.Lead, error) {
 	_va := make([]interface{}, len(preloads))
@@ -404,6 +414,24 @@ func (_m *LeadRepository) ListSortedWithPreloads(offset int, limit int, sortBy s
 	return r0, r1
 }
 
+// StreamFiltered provides a mock function with given fields: filter, fn
+func (_m *LeadRepository) StreamFiltered(filter models.LeadFilter, fn func([]models.Lead) error) error {
+	ret := _m.Called(filter, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamFiltered")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.LeadFilter, func([]models.Lead) error) error); ok {
+		r0 = rf(filter, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: lead
 func (_m *LeadRepository) Update(lead *models.Lead) error {
 	ret := _m.Called(lead)
@@ -340,6 +340,24 @@ func (_m *TaskRepository) ListWithPreloads(offset int, limit int, preloads ...st
 	return r0, r1
 }
 
+// StreamFiltered provides a mock function with given fields: filter, fn
+func (_m *TaskRepository) StreamFiltered(filter models.TaskFilter, fn func([]models.Task) error) error {
+	ret := _m.Called(filter, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamFiltered")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.TaskFilter, func([]models.Task) error) error); ok {
+		r0 = rf(filter, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: task
 func (_m *TaskRepository) Update(task *models.Task) error {
 	ret := _m.Called(task)
@@ -383,6 +401,16 @@ func (_m *TaskRepository) CountSearch(query string) (int64, error) {
 	return ret.Get(0).(int64), ret.Error(1)
 }
 
+// ListFiltered provides a mock function with given fields: filter, offset, limit, preloads
+func (_m *TaskRepository) ListFiltered(filter models.TaskFilter, offset int, limit int, preloads ...string) ([]models.Task, int64, error) {
+	ret := _m.Called(filter, offset, limit, preloads)
+	var r0 []models.Task
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.Task)
+	}
+	return r0, ret.Get(1).(int64), ret.Error(2)
+}
+
 // CountByStatus provides a mock function with no fields
 func (_m *TaskRepository) CountByStatus() (map[string]int64, error) {
 	ret := _m.Called()
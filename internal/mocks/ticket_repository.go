@@ -4,6 +4,8 @@
 package mocks
 
 import (
+	"time"
+
 	models "github.com/florinel-chis/gophercrm/internal/models"
 	repository "github.com/florinel-chis/gophercrm/internal/repository"
 	mock "github.com/stretchr/testify/mock"
@@ -433,6 +435,24 @@ func (_m *TicketRepository) ListWithPreloads(offset int, limit int, preloads ...
 	return r0, r1
 }
 
+// StreamFiltered provides a mock function with given fields: filter, fn
+func (_m *TicketRepository) StreamFiltered(filter models.TicketFilter, fn func([]models.Ticket) error) error {
+	ret := _m.Called(filter, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamFiltered")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.TicketFilter, func([]models.Ticket) error) error); ok {
+		r0 = rf(filter, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: ticket
 func (_m *TicketRepository) Update(ticket *models.Ticket) error {
 	ret := _m.Called(ticket)
@@ -476,6 +496,16 @@ func (_m *TicketRepository) CountSearch(query string) (int64, error) {
 	return ret.Get(0).(int64), ret.Error(1)
 }
 
+// ListFiltered provides a mock function with given fields: filter, offset, limit, preloads
+func (_m *TicketRepository) ListFiltered(filter models.TicketFilter, offset int, limit int, preloads ...string) ([]models.Ticket, int64, error) {
+	ret := _m.Called(filter, offset, limit, preloads)
+	var r0 []models.Ticket
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.Ticket)
+	}
+	return r0, ret.Get(1).(int64), ret.Error(2)
+}
+
 // CountByPriority provides a mock function with no fields
 func (_m *TicketRepository) CountByPriority() (map[string]int64, error) {
 	ret := _m.Called()
@@ -509,6 +539,17 @@ func (_m *TicketRepository) ListRecentlyResolved(limit int) ([]models.Ticket, er
 	return r0, ret.Error(1)
 }
 
+// MarkFirstResponse provides a mock function with given fields: id, at
+func (_m *TicketRepository) MarkFirstResponse(id uint, at time.Time) error {
+	ret := _m.Called(id, at)
+	return ret.Error(0)
+}
+
+func (_m *TicketRepository) BackfillReplyTokens() (int64, error) {
+	ret := _m.Called()
+	return ret.Get(0).(int64), ret.Error(1)
+}
+
 func (_m *TicketRepository) WithTx(tx *gorm.DB) repository.TicketRepository {
 	_m.Called(tx)
 	return _m
@@ -0,0 +1,160 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// AccountSize is the headcount band of an organization.
+type AccountSize string
+
+const (
+	AccountSizeMicro      AccountSize = "1-10"
+	AccountSizeSmall      AccountSize = "11-50"
+	AccountSizeMedium     AccountSize = "51-200"
+	AccountSizeLarge      AccountSize = "201-1000"
+	AccountSizeEnterprise AccountSize = "1001-5000"
+	AccountSizeGlobal     AccountSize = "5000+"
+)
+
+// AccountSizes lists the valid sizes, smallest first.
+var AccountSizes = []AccountSize{
+	AccountSizeMicro, AccountSizeSmall, AccountSizeMedium,
+	AccountSizeLarge, AccountSizeEnterprise, AccountSizeGlobal,
+}
+
+// IsValid reports whether s is one of AccountSizes. The empty size is valid:
+// it means the headcount is unknown.
+func (s AccountSize) IsValid() bool {
+	if s == "" {
+		return true
+	}
+	for _, size := range AccountSizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
+
+// Account is an organization. Leads and customers point at it through their
+// AccountID, and the account views roll up their tickets, tasks and deals.
+//
+// NameKey is the name reduced by AccountNameKey. It is how "Acme", "ACME Inc."
+// and "Acme Inc" are recognised as one organization, both when a new account
+// is created and when contacts are linked by their company text.
+type Account struct {
+	BaseModel
+	Name       string      `gorm:"not null;type:varchar(200)" json:"name"`
+	NameKey    string      `gorm:"not null;type:varchar(200);index" json:"-"`
+	Domain     string      `gorm:"type:varchar(255);index" json:"domain"`
+	Industry   string      `gorm:"type:varchar(100)" json:"industry"`
+	Size       AccountSize `gorm:"type:varchar(20)" json:"size"`
+	Phone      string      `gorm:"type:varchar(50)" json:"phone"`
+	Address    string      `gorm:"type:varchar(255)" json:"address"`
+	City       string      `gorm:"type:varchar(100)" json:"city"`
+	State      string      `gorm:"type:varchar(100)" json:"state"`
+	Country    string      `gorm:"type:varchar(100)" json:"country"`
+	PostalCode string      `gorm:"type:varchar(20)" json:"postal_code"`
+	Notes      string      `gorm:"type:text" json:"notes"`
+	OwnerID    *uint       `json:"owner_id,omitempty"`
+	Owner      *User       `gorm:"foreignKey:OwnerID" json:"owner,omitempty"`
+}
+
+// legalSuffixes are the company-form words AccountNameKey drops from the end
+// of a name.
+var legalSuffixes = map[string]bool{
+	"inc": true, "incorporated": true, "llc": true, "llp": true, "lp": true,
+	"ltd": true, "limited": true, "corp": true, "corporation": true,
+	"co": true, "company": true, "plc": true, "gmbh": true, "ag": true,
+	"sa": true, "sas": true, "sarl": true, "srl": true, "bv": true, "nv": true,
+	"pty": true, "pte": true, "oy": true, "ab": true, "as": true, "spa": true,
+}
+
+// AccountNameKey reduces a company name to the key organizations are matched
+// on: lower case, punctuation dropped, whitespace collapsed and trailing legal
+// forms removed, so "ACME, Inc." and "Acme" both become "acme". A name that is
+// nothing but a legal form ("Ltd.") keeps it rather than reducing to nothing.
+// It returns "" only for a name with no letters or digits.
+func AccountNameKey(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '&'
+	})
+	// Dotted forms such as "S.A." or "B.V." arrive as single letters.
+	joined := make([]string, 0, len(words))
+	for _, word := range words {
+		if n := len(joined); n > 0 && len(word) == 1 && len(joined[n-1]) == 1 && unicode.IsLetter(rune(word[0])) {
+			joined[n-1] += word
+			continue
+		}
+		joined = append(joined, word)
+	}
+	words = joined
+
+	end := len(words)
+	for end > 1 && legalSuffixes[words[end-1]] {
+		end--
+	}
+	return strings.Join(words[:end], " ")
+}
+
+// AccountFilter narrows an account list. Search matches the name, domain and
+// industry.
+type AccountFilter struct {
+	Search   string
+	OwnerID  uint
+	Industry string
+	// Cursor pages the list by keyset instead of by offset, nil for none.
+	Cursor *ListCursor
+}
+
+// AccountScope narrows an account's rollup to what the caller may see. A
+// non-zero LeadOwnerID or DealOwnerID limits leads or deals to the ones that
+// user owns, and a non-zero TaskAssigneeID tasks to the ones assigned to them;
+// SkipLeads and SkipDeals leave leads or deals out entirely, for callers that
+// cannot read them at all.
+type AccountScope struct {
+	LeadOwnerID    uint
+	DealOwnerID    uint
+	TaskAssigneeID uint
+	SkipLeads      bool
+	SkipDeals      bool
+}
+
+// AccountSummary rolls up everything attached to an account. Leads and Deals
+// are nil when the scope skipped them.
+type AccountSummary struct {
+	AccountID   uint                `json:"account_id"`
+	Leads       *int64              `json:"leads,omitempty"`
+	Customers   int64               `json:"customers"`
+	Tickets     int64               `json:"tickets"`
+	OpenTickets int64               `json:"open_tickets"`
+	Tasks       int64               `json:"tasks"`
+	OpenTasks   int64               `json:"open_tasks"`
+	Deals       *AccountDealSummary `json:"deals,omitempty"`
+}
+
+// AccountDealSummary counts an account's deals by outcome and totals its open
+// pipeline. Amounts are never converted, so the pipeline has one row per
+// currency.
+type AccountDealSummary struct {
+	Open     int64                  `json:"open"`
+	Won      int64                  `json:"won"`
+	Lost     int64                  `json:"lost"`
+	Pipeline []AccountPipelineValue `json:"pipeline"`
+}
+
+// AccountPipelineValue is the open pipeline of an account in one currency.
+type AccountPipelineValue struct {
+	Currency      string  `json:"currency"`
+	Deals         int64   `json:"deals"`
+	Value         float64 `json:"value"`
+	WeightedValue float64 `json:"weighted_value"`
+}
+
+// AccountBackfill reports what linking contacts by their company text did.
+type AccountBackfill struct {
+	AccountsCreated int `json:"accounts_created"`
+	LeadsLinked     int `json:"leads_linked"`
+	CustomersLinked int `json:"customers_linked"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountNameKey(t *testing.T) {
+	for name, want := range map[string]string{
+		"Acme":                    "acme",
+		"ACME Inc.":               "acme",
+		"Acme, Inc":               "acme",
+		"  acme   corp  ltd ":     "acme",
+		"Müller GmbH":             "müller",
+		"Renault S.A.":            "renault",
+		"Philips N.V.":            "philips",
+		"Johnson & Johnson":       "johnson & johnson",
+		"The Company Store Inc.":  "the company store",
+		"Ltd.":                    "ltd",
+		"Acme Holdings Co., Ltd.": "acme holdings",
+		"3M Company":              "3m",
+		"--":                      "",
+		"":                        "",
+	} {
+		assert.Equal(t, want, AccountNameKey(name), name)
+	}
+}
+
+func TestAccountSize_IsValid(t *testing.T) {
+	for _, size := range AccountSizes {
+		assert.True(t, size.IsValid(), size)
+	}
+	assert.True(t, AccountSize("").IsValid(), "an unknown size is allowed")
+	assert.False(t, AccountSize("10-20").IsValid())
+}
@@ -0,0 +1,103 @@
+package models
+
+import "time"
+
+// The activity feed is the human-readable counterpart of the audit trail: one
+// row per thing worth showing on the dashboard — a lead created, a ticket
+// reassigned, a task completed — written by the entity services at the moment
+// the change is committed. Where the audit trail answers "who changed which
+// field", the feed answers "what has been happening on my records".
+//
+// Every event carries the user the record belonged to when it happened (the
+// lead's owner, the ticket's or task's assignee, the customer's account
+// manager), which is who the entry names. The feed is scoped and filtered by
+// the record's current owner instead, looked up when it is read: a sales user
+// sees the activity on the records they own now, history from before they
+// took them over included, and not everything in the CRM. The acting
+// user is deliberately not stored here; the services are also driven by bulk
+// operations and background jobs with no caller, and the audit trail already
+// attributes every write to its actor.
+//
+// Rows are append-only, like the audit trail's. The one rewrite is the erasure
+// scrub in repository/erasure.go, which blanks the description of a lead's or
+// customer's events since it quotes the person's name.
+
+// ActivityType names what happened. The value is entity-prefixed so the feed
+// can be rendered without looking at EntityType.
+type ActivityType string
+
+const (
+	ActivityLeadCreated       ActivityType = "lead_created"
+	ActivityLeadUpdated       ActivityType = "lead_updated"
+	ActivityLeadStatusChanged ActivityType = "lead_status_changed"
+	ActivityLeadReassigned    ActivityType = "lead_reassigned"
+	ActivityLeadConverted     ActivityType = "lead_converted"
+	ActivityLeadMerged        ActivityType = "lead_merged"
+	ActivityLeadDeleted       ActivityType = "lead_deleted"
+
+	ActivityCustomerCreated    ActivityType = "customer_created"
+	ActivityCustomerUpdated    ActivityType = "customer_updated"
+	ActivityCustomerReassigned ActivityType = "customer_reassigned"
+	ActivityCustomerMerged     ActivityType = "customer_merged"
+	ActivityCustomerDeleted    ActivityType = "customer_deleted"
+
+	ActivityTicketCreated       ActivityType = "ticket_created"
+	ActivityTicketUpdated       ActivityType = "ticket_updated"
+	ActivityTicketStatusChanged ActivityType = "ticket_status_changed"
+	ActivityTicketResolved      ActivityType = "ticket_resolved"
+	ActivityTicketReassigned    ActivityType = "ticket_reassigned"
+	ActivityTicketCommented     ActivityType = "ticket_commented"
+	ActivityTicketSLABreached   ActivityType = "ticket_sla_breached"
+	ActivityTicketDeleted       ActivityType = "ticket_deleted"
+
+	ActivityTaskCreated       ActivityType = "task_created"
+	ActivityTaskUpdated       ActivityType = "task_updated"
+	ActivityTaskStatusChanged ActivityType = "task_status_changed"
+	ActivityTaskCompleted     ActivityType = "task_completed"
+	ActivityTaskReassigned    ActivityType = "task_reassigned"
+	ActivityTaskDeleted       ActivityType = "task_deleted"
+
+	ActivityDealCreated      ActivityType = "deal_created"
+	ActivityDealUpdated      ActivityType = "deal_updated"
+	ActivityDealStageChanged ActivityType = "deal_stage_changed"
+	ActivityDealWon          ActivityType = "deal_won"
+	ActivityDealLost         ActivityType = "deal_lost"
+	ActivityDealReassigned   ActivityType = "deal_reassigned"
+	ActivityDealDeleted      ActivityType = "deal_deleted"
+
+	ActivityAccountCreated    ActivityType = "account_created"
+	ActivityAccountUpdated    ActivityType = "account_updated"
+	ActivityAccountReassigned ActivityType = "account_reassigned"
+	ActivityAccountDeleted    ActivityType = "account_deleted"
+)
+
+// ActivityEvent is one entry of the activity feed. EntityType uses the
+// models.AuditEntity* names, so an entry links straight to the record's audit
+// history.
+type ActivityEvent struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	EntityType string       `gorm:"not null;type:varchar(50);index:idx_activity_events_entity" json:"entity_type"`
+	EntityID   uint         `gorm:"not null;index:idx_activity_events_entity" json:"entity_id"`
+	Type       ActivityType `gorm:"not null;type:varchar(50)" json:"type"`
+
+	Title       string `gorm:"not null;type:varchar(200)" json:"title"`
+	Description string `gorm:"type:text" json:"description"`
+
+	// OwnerID is the user the record belonged to when the event happened, nil
+	// for a record nobody owned, such as an unassigned ticket. It only decides
+	// who sees the event when the record itself is gone.
+	OwnerID *uint `gorm:"index" json:"owner_id,omitempty"`
+	Owner   *User `gorm:"foreignKey:OwnerID" json:"owner,omitempty"`
+}
+
+// ActivityFilter narrows the feed. Zero values mean "any". OwnerID matches
+// the events about the records that user owns now. BeforeID is the keyset
+// cursor: only events older than that id are returned.
+type ActivityFilter struct {
+	EntityType string
+	EntityID   uint
+	OwnerID    uint
+	BeforeID   uint
+}
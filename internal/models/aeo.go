@@ -91,6 +91,15 @@ type AEOPrompt struct {
 	LastRunAt    *time.Time `gorm:"-" json:"last_run_at,omitempty"`
 }
 
+// AEOPromptFilter narrows a prompt list.
+type AEOPromptFilter struct {
+	ActiveOnly bool
+	// Where is the structured filter of the request, nil for none.
+	Where *FilterExpr
+	// Cursor pages the list by keyset instead of by offset, nil for none.
+	Cursor *ListCursor
+}
+
 func (AEOPrompt) TableName() string {
 	return "aeo_prompts"
 }
@@ -1,15 +1,308 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
 
+	"gorm.io/gorm"
+)
+
+// APIKey is a credential an integration presents instead of a session. It acts
+// as its owner, but only within its scopes: a request the key's scopes do not
+// cover is refused before the owner's role is even consulted, so a key never
+// does more than both the key and its owner allow. A key may also be held to a
+// list of networks and to a request rate of its own.
+//
+// The scopes and networks are persisted as serialized JSON in TEXT columns with
+// a `gorm:"-"` decoded twin, the convention the role and form models use.
 type APIKey struct {
 	BaseModel
-	Name        string     `gorm:"not null;type:varchar(100)" json:"name"`
-	KeyHash     string     `gorm:"uniqueIndex;not null;type:varchar(64)" json:"-"`
-	Prefix      string     `gorm:"not null;type:varchar(8)" json:"prefix"`
-	UserID      uint       `json:"user_id"`
-	User        User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
-	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
-	IsActive    bool       `gorm:"default:true" json:"is_active"`
-}
\ No newline at end of file
+	Name       string     `gorm:"not null;type:varchar(100)" json:"name"`
+	KeyHash    string     `gorm:"uniqueIndex;not null;type:varchar(64)" json:"-"`
+	Prefix     string     `gorm:"not null;type:varchar(8)" json:"prefix"`
+	UserID     uint       `json:"user_id"`
+	User       User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	IsActive   bool       `gorm:"default:true" json:"is_active"`
+	APIKeyRestrictions
+}
+
+// APIKeyRestrictions is what a key is held to. They are set when the key is
+// minted and cannot be changed afterwards; a key that needs others is replaced.
+type APIKeyRestrictions struct {
+	Scopes     []APIKeyScope `gorm:"-" json:"scopes"`
+	ScopesJSON string        `gorm:"column:scopes;type:text" json:"-"`
+	// AllowedCIDRs lists the networks the key may be used from. Empty allows
+	// every address.
+	AllowedCIDRs     []string `gorm:"-" json:"allowed_cidrs"`
+	AllowedCIDRsJSON string   `gorm:"column:allowed_cidrs;type:text" json:"-"`
+	// RateLimitPerMinute caps the requests the key may make in a minute, on
+	// top of the limits every caller is under. Zero sets no cap of its own.
+	RateLimitPerMinute int `gorm:"not null;default:0" json:"rate_limit_per_minute"`
+}
+
+// APIKeyScope grants a key access to one entity, as entity:read or
+// entity:write. Write includes read. The entity * stands for every entity.
+type APIKeyScope string
+
+// The access levels of a scope.
+const (
+	APIKeyAccessRead  = "read"
+	APIKeyAccessWrite = "write"
+)
+
+// APIKeyScopeAll is the entity of the scopes that cover every entity.
+const APIKeyScopeAll = "*"
+
+// APIKeyScopeFullAccess is the scope of the keys minted before keys had
+// scopes: it lets the key do whatever its owner can.
+const APIKeyScopeFullAccess APIKeyScope = APIKeyScopeAll + ":" + APIKeyAccessWrite
+
+// MaxAPIKeyRateLimitPerMinute bounds RateLimitPerMinute; a higher cap would
+// never bite under the limits every caller is already under.
+const MaxAPIKeyRateLimitPerMinute = 10000
+
+// APIKeyScopeEntities are the entities a scope can name. Each stands for the
+// endpoints under the matching path, and a few take in their neighbours:
+// roles the permission registry, deals the pipeline stages, sla the SLA
+// policies and auth the logout and password change.
+var APIKeyScopeEntities = []string{
+	"users", "roles",
+	"leads", "customers", "tickets", "tasks", "labels",
+	"deals", "accounts", "forms", "aeo", "imports",
+	"dashboard", "search", "saved_views", "sla", "inbound_emails",
+	"custom_fields", "configurations", "webhooks", "audit",
+	"bulk_operations", "api_keys", "auth",
+}
+
+// Entity returns the entity the scope names.
+func (s APIKeyScope) Entity() string {
+	entity, _, _ := strings.Cut(string(s), ":")
+	return entity
+}
+
+// Access returns the access level of the scope.
+func (s APIKeyScope) Access() string {
+	_, access, _ := strings.Cut(string(s), ":")
+	return access
+}
+
+// IsValid reports whether s names a known entity, or *, and an access level.
+func (s APIKeyScope) IsValid() bool {
+	entity, access, ok := strings.Cut(string(s), ":")
+	if !ok || (access != APIKeyAccessRead && access != APIKeyAccessWrite) {
+		return false
+	}
+	if entity == APIKeyScopeAll {
+		return true
+	}
+	for _, known := range APIKeyScopeEntities {
+		if entity == known {
+			return true
+		}
+	}
+	return false
+}
+
+// BeforeSave serializes the scopes and networks into their TEXT columns.
+func (k *APIKey) BeforeSave(tx *gorm.DB) error {
+	scopes := k.Scopes
+	if scopes == nil {
+		scopes = []APIKeyScope{}
+	}
+	encoded, err := json.Marshal(scopes)
+	if err != nil {
+		return fmt.Errorf("api key scopes: %w", err)
+	}
+	k.ScopesJSON = string(encoded)
+
+	cidrs := k.AllowedCIDRs
+	if cidrs == nil {
+		cidrs = []string{}
+	}
+	encoded, err = json.Marshal(cidrs)
+	if err != nil {
+		return fmt.Errorf("api key networks: %w", err)
+	}
+	k.AllowedCIDRsJSON = string(encoded)
+	return nil
+}
+
+// AfterFind restores the scopes and networks. A key stored before keys had
+// scopes has no scopes column and keeps the full access it was minted with. A
+// scopes column that cannot be decoded grants nothing, and a networks column
+// that cannot be decoded allows no address, so a damaged row locks the key
+// rather than opening it.
+func (k *APIKey) AfterFind(tx *gorm.DB) error {
+	switch {
+	case k.ScopesJSON == "":
+		k.Scopes = []APIKeyScope{APIKeyScopeFullAccess}
+	case json.Unmarshal([]byte(k.ScopesJSON), &k.Scopes) != nil:
+		k.Scopes = []APIKeyScope{}
+	}
+
+	k.AllowedCIDRs = []string{}
+	if k.AllowedCIDRsJSON != "" {
+		if err := json.Unmarshal([]byte(k.AllowedCIDRsJSON), &k.AllowedCIDRs); err != nil {
+			// The raw column is no network, so it matches no address.
+			k.AllowedCIDRs = []string{k.AllowedCIDRsJSON}
+		}
+	}
+	return nil
+}
+
+// ErrInvalidAPIKey is wrapped by every error APIKeyRestrictions.Validate
+// returns.
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// Validate checks what a new key is held to: at least one scope,
+// each valid and listed once, networks in CIDR notation, and a rate limit
+// between zero and MaxAPIKeyRateLimitPerMinute. A bare address is accepted as
+// a network of one and stored as such.
+func (k *APIKeyRestrictions) Validate() error {
+	if len(k.Scopes) == 0 {
+		return fmt.Errorf("%w: at least one scope is required", ErrInvalidAPIKey)
+	}
+	seen := make(map[APIKeyScope]bool, len(k.Scopes))
+	for _, scope := range k.Scopes {
+		if !scope.IsValid() {
+			return fmt.Errorf("%w: unknown scope %q; a scope is entity:read or entity:write", ErrInvalidAPIKey, scope)
+		}
+		if seen[scope] {
+			return fmt.Errorf("%w: scope %q is listed twice", ErrInvalidAPIKey, scope)
+		}
+		seen[scope] = true
+	}
+
+	for i, cidr := range k.AllowedCIDRs {
+		network, err := parseAllowedNetwork(cidr)
+		if err != nil {
+			return fmt.Errorf("%w: %q is not an address or a CIDR network", ErrInvalidAPIKey, cidr)
+		}
+		k.AllowedCIDRs[i] = network.String()
+	}
+
+	if k.RateLimitPerMinute < 0 || k.RateLimitPerMinute > MaxAPIKeyRateLimitPerMinute {
+		return fmt.Errorf("%w: rate_limit_per_minute must be between 0 and %d", ErrInvalidAPIKey, MaxAPIKeyRateLimitPerMinute)
+	}
+	return nil
+}
+
+// parseAllowedNetwork reads a CIDR network, or a bare address as the network
+// holding only that address.
+func parseAllowedNetwork(value string) (*net.IPNet, error) {
+	value = strings.TrimSpace(value)
+	if !strings.Contains(value, "/") {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid address %q", value)
+		}
+		bits := 128
+		if ip.To4() != nil {
+			ip = ip.To4()
+			bits = 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, network, err := net.ParseCIDR(value)
+	return network, err
+}
+
+// AllowsEntity reports whether the key's scopes let it read, or with write
+// set, change the entity. The empty entity, standing for none, is covered only
+// by the * scopes.
+func (k *APIKeyRestrictions) AllowsEntity(entity string, write bool) bool {
+	for _, scope := range k.Scopes {
+		if scope.Entity() != APIKeyScopeAll && (entity == "" || scope.Entity() != entity) {
+			continue
+		}
+		if scope.Access() == APIKeyAccessWrite || (!write && scope.Access() == APIKeyAccessRead) {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers reports whether other grants nothing k does not: each of its scopes
+// is one k allows, each of its networks lies within one of k's, and its rate
+// limit is no looser. A key may only hand out what it holds itself.
+func (k *APIKeyRestrictions) Covers(other APIKeyRestrictions) bool {
+	for _, scope := range other.Scopes {
+		entity := scope.Entity()
+		if entity == APIKeyScopeAll {
+			entity = ""
+		}
+		if !k.AllowsEntity(entity, scope.Access() == APIKeyAccessWrite) {
+			return false
+		}
+	}
+
+	if len(k.AllowedCIDRs) > 0 {
+		if len(other.AllowedCIDRs) == 0 {
+			return false
+		}
+		for _, cidr := range other.AllowedCIDRs {
+			network, err := parseAllowedNetwork(cidr)
+			if err != nil || !k.containsNetwork(network) {
+				return false
+			}
+		}
+	}
+
+	if k.RateLimitPerMinute > 0 {
+		return other.RateLimitPerMinute > 0 && other.RateLimitPerMinute <= k.RateLimitPerMinute
+	}
+	return true
+}
+
+// containsNetwork reports whether network lies wholly within one of the key's.
+func (k *APIKeyRestrictions) containsNetwork(network *net.IPNet) bool {
+	ones, bits := network.Mask.Size()
+	for _, cidr := range k.AllowedCIDRs {
+		allowed, err := parseAllowedNetwork(cidr)
+		if err != nil {
+			continue
+		}
+		allowedOnes, allowedBits := allowed.Mask.Size()
+		if allowedBits == bits && allowedOnes <= ones && allowed.Contains(network.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsIP reports whether the key may be used from ip.
+func (k *APIKeyRestrictions) AllowsIP(ip string) bool {
+	if len(k.AllowedCIDRs) == 0 {
+		return true
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, cidr := range k.AllowedCIDRs {
+		network, err := parseAllowedNetwork(cidr)
+		if err == nil && network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyUsage records one request made with an API key: where it went, how it
+// was answered and where it came from. Requests the key's restrictions turned
+// away are recorded too. Rows are append-only, like the audit trail.
+type APIKeyUsage struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	APIKeyID  uint      `gorm:"not null;index:idx_api_key_usage_key_time,priority:1" json:"api_key_id"`
+	Method    string    `gorm:"not null;type:varchar(10)" json:"method"`
+	Endpoint  string    `gorm:"not null;type:varchar(255)" json:"endpoint"`
+	Status    int       `gorm:"not null" json:"status"`
+	IPAddress string    `gorm:"type:varchar(45)" json:"ip_address"`
+	CreatedAt time.Time `gorm:"index:idx_api_key_usage_key_time,priority:2" json:"created_at"`
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyRestrictionsValidate(t *testing.T) {
+	tests := []struct {
+		name         string
+		restrictions APIKeyRestrictions
+		wantErr      bool
+	}{
+		{"scoped", APIKeyRestrictions{Scopes: []APIKeyScope{"leads:read", "*:read"}}, false},
+		{"no scopes", APIKeyRestrictions{}, true},
+		{"unknown entity", APIKeyRestrictions{Scopes: []APIKeyScope{"invoices:read"}}, true},
+		{"unknown access", APIKeyRestrictions{Scopes: []APIKeyScope{"leads:delete"}}, true},
+		{"no access", APIKeyRestrictions{Scopes: []APIKeyScope{"leads"}}, true},
+		{"duplicate scope", APIKeyRestrictions{Scopes: []APIKeyScope{"leads:read", "leads:read"}}, true},
+		{"networks", APIKeyRestrictions{Scopes: []APIKeyScope{"leads:read"}, AllowedCIDRs: []string{"10.0.0.0/8", "::1"}}, false},
+		{"bad network", APIKeyRestrictions{Scopes: []APIKeyScope{"leads:read"}, AllowedCIDRs: []string{"10.0.0.0/33"}}, true},
+		{"negative rate", APIKeyRestrictions{Scopes: []APIKeyScope{"leads:read"}, RateLimitPerMinute: -1}, true},
+		{"rate over max", APIKeyRestrictions{Scopes: []APIKeyScope{"leads:read"}, RateLimitPerMinute: MaxAPIKeyRateLimitPerMinute + 1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.restrictions.Validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidAPIKey)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAPIKeyRestrictionsValidateNormalizesNetworks(t *testing.T) {
+	restrictions := APIKeyRestrictions{
+		Scopes:       []APIKeyScope{"leads:read"},
+		AllowedCIDRs: []string{"192.168.1.17/24", "203.0.113.7", "2001:db8::1"},
+	}
+
+	require.NoError(t, restrictions.Validate())
+	assert.Equal(t, []string{"192.168.1.0/24", "203.0.113.7/32", "2001:db8::1/128"}, restrictions.AllowedCIDRs)
+}
+
+func TestAPIKeyRestrictionsAllowsEntity(t *testing.T) {
+	restrictions := APIKeyRestrictions{Scopes: []APIKeyScope{"leads:read", "tickets:write"}}
+
+	assert.True(t, restrictions.AllowsEntity("leads", false))
+	assert.False(t, restrictions.AllowsEntity("leads", true))
+	assert.True(t, restrictions.AllowsEntity("tickets", false), "write includes read")
+	assert.True(t, restrictions.AllowsEntity("tickets", true))
+	assert.False(t, restrictions.AllowsEntity("customers", false))
+	assert.False(t, restrictions.AllowsEntity("", false))
+
+	readAll := APIKeyRestrictions{Scopes: []APIKeyScope{"*:read"}}
+	assert.True(t, readAll.AllowsEntity("customers", false))
+	assert.True(t, readAll.AllowsEntity("", false))
+	assert.False(t, readAll.AllowsEntity("customers", true))
+}
+
+func TestAPIKeyRestrictionsCovers(t *testing.T) {
+	key := APIKeyRestrictions{
+		Scopes:             []APIKeyScope{"leads:write", "api_keys:write"},
+		AllowedCIDRs:       []string{"10.0.0.0/8"},
+		RateLimitPerMinute: 60,
+	}
+	within := APIKeyRestrictions{Scopes: []APIKeyScope{"leads:read"}, AllowedCIDRs: []string{"10.1.0.0/16", "10.2.3.4"}, RateLimitPerMinute: 30}
+	assert.True(t, key.Covers(within))
+
+	for name, broader := range map[string]APIKeyRestrictions{
+		"other entity":  {Scopes: []APIKeyScope{"tickets:read"}, AllowedCIDRs: within.AllowedCIDRs, RateLimitPerMinute: 30},
+		"every entity":  {Scopes: []APIKeyScope{"*:read"}, AllowedCIDRs: within.AllowedCIDRs, RateLimitPerMinute: 30},
+		"no networks":   {Scopes: within.Scopes, RateLimitPerMinute: 30},
+		"wider network": {Scopes: within.Scopes, AllowedCIDRs: []string{"10.0.0.0/7"}, RateLimitPerMinute: 30},
+		"other network": {Scopes: within.Scopes, AllowedCIDRs: []string{"192.168.0.0/16"}, RateLimitPerMinute: 30},
+		"no rate limit": {Scopes: within.Scopes, AllowedCIDRs: within.AllowedCIDRs},
+		"higher rate":   {Scopes: within.Scopes, AllowedCIDRs: within.AllowedCIDRs, RateLimitPerMinute: 61},
+	} {
+		assert.False(t, key.Covers(broader), name)
+	}
+
+	unrestricted := APIKeyRestrictions{Scopes: []APIKeyScope{"*:write"}}
+	assert.True(t, unrestricted.Covers(key), "a key with every scope and no limits covers anything")
+}
+
+func TestAPIKeyRestrictionsAllowsIP(t *testing.T) {
+	assert.True(t, (&APIKeyRestrictions{}).AllowsIP("198.51.100.1"), "no networks allows every address")
+
+	restrictions := APIKeyRestrictions{AllowedCIDRs: []string{"10.0.0.0/8", "2001:db8::/32"}}
+	assert.True(t, restrictions.AllowsIP("10.20.30.40"))
+	assert.True(t, restrictions.AllowsIP("2001:db8::abcd"))
+	assert.False(t, restrictions.AllowsIP("11.0.0.1"))
+	assert.False(t, restrictions.AllowsIP("not-an-ip"))
+}
+
+func TestAPIKeyAfterFind(t *testing.T) {
+	legacy := APIKey{}
+	require.NoError(t, legacy.AfterFind(nil))
+	assert.Equal(t, []APIKeyScope{APIKeyScopeFullAccess}, legacy.Scopes, "a key from before scopes keeps full access")
+	assert.True(t, legacy.AllowsIP("198.51.100.1"))
+
+	stored := APIKey{APIKeyRestrictions: APIKeyRestrictions{Scopes: []APIKeyScope{"leads:read"}, AllowedCIDRs: []string{"10.0.0.0/8"}}}
+	require.NoError(t, stored.BeforeSave(nil))
+	restored := APIKey{APIKeyRestrictions: APIKeyRestrictions{ScopesJSON: stored.ScopesJSON, AllowedCIDRsJSON: stored.AllowedCIDRsJSON}}
+	require.NoError(t, restored.AfterFind(nil))
+	assert.Equal(t, stored.Scopes, restored.Scopes)
+	assert.Equal(t, stored.AllowedCIDRs, restored.AllowedCIDRs)
+
+	damaged := APIKey{APIKeyRestrictions: APIKeyRestrictions{ScopesJSON: "{", AllowedCIDRsJSON: "{"}}
+	require.NoError(t, damaged.AfterFind(nil))
+	assert.False(t, damaged.AllowsEntity("leads", false), "damaged scopes grant nothing")
+	assert.False(t, damaged.AllowsIP("10.0.0.1"), "damaged networks allow no address")
+}
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// The audit trail records who changed what across the CRM entities: one row per
+// create, update or delete, carrying the actor and a field-level before/after
+// diff of the entity's own columns.
+//
+// Rows are append-only. There is no UpdatedAt and no DeletedAt: an audit entry
+// that can be edited or hidden is not an audit entry. The one sanctioned
+// rewrite is the erasure scrub in repository/erasure.go, which blanks the
+// personal data a diff quoted so the trail never undoes an anonymisation.
+//
+// The diff is persisted as serialized JSON in a TEXT column with a `gorm:"-"`
+// decoded twin, the same convention the form and AEO models use, so MySQL 8 and
+// SQLite store and read it identically.
+
+// AuditAction is what happened to the entity.
+type AuditAction string
+
+const (
+	AuditActionCreate AuditAction = "create"
+	AuditActionUpdate AuditAction = "update"
+	AuditActionDelete AuditAction = "delete"
+)
+
+// AuditActorType says what kind of credential performed the change. A change
+// made through an API key is attributed to the key AND to the user owning it,
+// so an integration's writes can be told apart from the owner's own.
+type AuditActorType string
+
+const (
+	AuditActorUser   AuditActorType = "user"
+	AuditActorAPIKey AuditActorType = "api_key"
+	AuditActorSystem AuditActorType = "system"
+)
+
+// The entity types the audit trail covers. They double as the path segment of
+// the per-entity history endpoints, minus the plural.
+const (
+	AuditEntityUser          = "user"
+	AuditEntityLead          = "lead"
+	AuditEntityCustomer      = "customer"
+	AuditEntityTicket        = "ticket"
+	AuditEntityTask          = "task"
+	AuditEntityLabel         = "label"
+	AuditEntityForm          = "form"
+	AuditEntityConfiguration = "configuration"
+	AuditEntitySLAPolicy     = "sla_policy"
+	AuditEntityInboundEmail  = "inbound_email"
+	AuditEntityWebhook       = "webhook"
+	AuditEntityDeal          = "deal"
+	AuditEntityPipelineStage = "pipeline_stage"
+	AuditEntityAccount       = "account"
+	AuditEntityCustomField   = "custom_field"
+	AuditEntityRole          = "role"
+)
+
+// AuditErasedValue replaces a personal-data value inside a stored diff once
+// the person it described has been erased. The field name stays, so the trail
+// still shows THAT the field changed, just no longer what it said.
+const AuditErasedValue = "[erased]"
+
+// AuditRedactedValue stands in for a secret written to a sensitive
+// configuration entry. The trail records that the secret was changed, never
+// what it was changed to.
+const AuditRedactedValue = "[redacted]"
+
+// AuditActor identifies who performed a change. UserID is nil only for the
+// system actor (background workers, migrations).
+type AuditActor struct {
+	Type     AuditActorType
+	UserID   *uint
+	APIKeyID *uint
+}
+
+// AuditChange is one field of a diff. A create has no Before and a delete has
+// no After; both marshal as null.
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditEvent is one recorded change.
+type AuditEvent struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	ActorType     AuditActorType `gorm:"not null;type:varchar(20)" json:"actor_type"`
+	ActorUserID   *uint          `gorm:"index" json:"actor_user_id,omitempty"`
+	ActorAPIKeyID *uint          `json:"actor_api_key_id,omitempty"`
+
+	EntityType string      `gorm:"not null;type:varchar(50);index:idx_audit_events_entity" json:"entity_type"`
+	EntityID   uint        `gorm:"not null;index:idx_audit_events_entity" json:"entity_id"`
+	Action     AuditAction `gorm:"not null;type:varchar(20)" json:"action"`
+
+	Changes     map[string]AuditChange `gorm:"-" json:"changes"`
+	ChangesJSON string                 `gorm:"column:changes;type:text" json:"-"`
+}
+
+// BeforeSave serializes the diff into its TEXT column.
+func (e *AuditEvent) BeforeSave(tx *gorm.DB) error {
+	changes := e.Changes
+	if changes == nil {
+		changes = map[string]AuditChange{}
+	}
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("audit event changes: %w", err)
+	}
+	e.ChangesJSON = string(encoded)
+	return nil
+}
+
+// AfterFind restores the diff and guarantees it marshals as `{}` rather than
+// `null`. A row whose column cannot be decoded yields an empty diff instead of
+// failing the whole listing.
+func (e *AuditEvent) AfterFind(tx *gorm.DB) error {
+	e.Changes = DecodeAuditChanges(e.ChangesJSON)
+	return nil
+}
+
+// DecodeAuditChanges decodes a stored diff column. It is exported for the
+// erasure scrub, which rewrites the column without loading the model.
+func DecodeAuditChanges(raw string) map[string]AuditChange {
+	changes := map[string]AuditChange{}
+	if raw == "" {
+		return changes
+	}
+	if err := json.Unmarshal([]byte(raw), &changes); err != nil {
+		return map[string]AuditChange{}
+	}
+	return changes
+}
+
+// AuditFilter narrows the admin audit listing. Zero values mean "any"; the
+// time window is half-open, From inclusive and To exclusive.
+type AuditFilter struct {
+	EntityType  string
+	EntityID    uint
+	ActorUserID uint
+	Action      AuditAction
+	From        *time.Time
+	To          *time.Time
+	// Cursor pages the list by keyset instead of by offset, nil for none.
+	Cursor *ListCursor
+}
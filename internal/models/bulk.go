@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // BulkOperationStatus represents the status of a bulk operation
 type BulkOperationStatus string
@@ -11,6 +14,7 @@ const (
 	BulkOperationStatusCompleted  BulkOperationStatus = "completed"
 	BulkOperationStatusFailed     BulkOperationStatus = "failed"
 	BulkOperationStatusPartial    BulkOperationStatus = "partial"
+	BulkOperationStatusCancelled  BulkOperationStatus = "cancelled"
 )
 
 // Short aliases for BulkOperationStatus used by services
@@ -20,6 +24,7 @@ var (
 	StatusCompleted = BulkOperationStatusCompleted
 	StatusFailed    = BulkOperationStatusFailed
 	StatusPartial   = BulkOperationStatusPartial
+	StatusCancelled = BulkOperationStatusCancelled
 )
 
 // BulkOperationType represents the type of bulk operation
@@ -49,6 +54,11 @@ const MaxBulkItems = 1000
 // transaction, and the cap bounds how long that transaction can hold its locks.
 const MaxBulkStatusItems = 100
 
+// BulkChunkSize is how many items of a queued bulk operation run together.
+// Each chunk is its own transaction: progress is recorded and cancellation is
+// honoured between chunks, and a chunk is the most a failure can roll back.
+const BulkChunkSize = 100
+
 // BulkOperation represents a bulk operation record
 type BulkOperation struct {
 	BaseModel
@@ -61,7 +71,17 @@ type BulkOperation struct {
 	TotalItems    int                 `gorm:"not null" json:"total_items"`
 	SuccessCount  int                 `gorm:"default:0" json:"success_count"`
 	FailureCount  int                 `gorm:"default:0" json:"failure_count"`
-	Items         []BulkOperationItem `gorm:"foreignKey:OperationID" json:"items,omitempty"`
+	// Request is the queued request as JSON, which the worker that claims the
+	// operation runs. It is dropped once the operation finishes, as it can
+	// carry whatever the items did, passwords included. Operations recorded by
+	// other means, such as imports, leave it empty and are never claimed.
+	Request    string              `gorm:"type:longtext" json:"-"`
+	StartedAt  *time.Time          `json:"started_at,omitempty"`
+	FinishedAt *time.Time          `json:"finished_at,omitempty"`
+	// RevertedAt is when the operation was reverted. An operation is
+	// reverted at most once.
+	RevertedAt *time.Time          `json:"reverted_at,omitempty"`
+	Items      []BulkOperationItem `gorm:"foreignKey:OperationID" json:"items,omitempty"`
 }
 
 // BulkOperationItem represents an individual item within a bulk operation
@@ -74,6 +94,43 @@ type BulkOperationItem struct {
 	Data        string              `gorm:"type:text" json:"data,omitempty"`
 }
 
+// BulkRowState is one row as the undo of bulk operations sees it: the JSON
+// value of each of its columns by column name, and of its custom field map
+// under "custom_fields". Columns the API never shows, such as a password
+// hash, are left out.
+type BulkRowState map[string]json.RawMessage
+
+// BulkItemSnapshot is the Data of a completed item of a queued bulk update,
+// delete or action: what it takes to put the row back. Before holds the prior
+// value of every column the operation changed and After the value it left,
+// along with updated_at and deleted_at, so that a row changed since can be
+// told apart. An erased row keeps neither, as an erasure leaves nothing to
+// restore; nor does one the operation changed in a way that cannot be
+// undone, which says why in Irreversible.
+type BulkItemSnapshot struct {
+	Before       BulkRowState `json:"before,omitempty"`
+	After        BulkRowState `json:"after,omitempty"`
+	Erased       bool         `json:"erased,omitempty"`
+	Irreversible string       `json:"irreversible,omitempty"`
+}
+
+// BulkRevertRefusal is a record a revert left as it was, and why: "changed"
+// when it was changed after the operation, "erased" when it went through a
+// GDPR erasure, "irreversible" when the operation changed something it keeps
+// no copy of, "failed" when putting it back failed.
+type BulkRevertRefusal struct {
+	ResourceID uint   `json:"resource_id"`
+	Reason     string `json:"reason"`
+	Message    string `json:"message,omitempty"`
+}
+
+// BulkRevertResult is the outcome of reverting a bulk operation.
+type BulkRevertResult struct {
+	OperationID uint                `json:"operation_id"`
+	Reverted    int                 `json:"reverted"`
+	Refused     []BulkRevertRefusal `json:"refused"`
+}
+
 // BulkUpdateItem represents an item to be updated in a bulk operation
 type BulkUpdateItem struct {
 	ID      uint                   `json:"id"`
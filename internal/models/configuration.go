@@ -311,6 +311,19 @@ func DefaultConfigurations() []Configuration {
 			IsReadOnly:   false,
 			ValidValues:  `[1, 8, 24, 48, 72, 168]`,
 		},
+		// Roles listed here must log in with a second factor; a user of such a
+		// role who has none enrolls at their next login. Role names are not
+		// restricted to the built-ins, since roles are configurable.
+		{
+			Key:          "security.two_factor.required_roles",
+			Value:        "[]",
+			Type:         ConfigTypeArray,
+			Category:     CategorySecurity,
+			Description:  "Roles whose users must use two-factor authentication",
+			DefaultValue: "[]",
+			IsSystem:     true,
+			IsReadOnly:   false,
+		},
 		{
 			Key:          "tickets.auto_assign_support",
 			Value:        "true",
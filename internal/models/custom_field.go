@@ -0,0 +1,425 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// Custom fields are admin-defined attributes of leads, customers, tickets and
+// tasks. A definition says what the field is called, which entity carries it
+// and what kind of value it holds; the values themselves live one row per
+// record and field (one per chosen option for a multi-select) in
+// custom_field_values, never as columns of the entity, so adding a field is a
+// data change rather than a migration.
+//
+// On the entities the values surface as the `gorm:"-"` CustomFields map, keyed
+// by field name. The GORM plugin in repository/custom_field_plugin.go fills it
+// on every query and writes it back on every create and update, so a record
+// reads and saves the same way whether or not it has custom fields.
+
+// CustomFieldType is the kind of value a custom field holds.
+type CustomFieldType string
+
+const (
+	CustomFieldText        CustomFieldType = "text"
+	CustomFieldNumber      CustomFieldType = "number"
+	CustomFieldDate        CustomFieldType = "date"
+	CustomFieldSelect      CustomFieldType = "select"
+	CustomFieldMultiSelect CustomFieldType = "multi_select"
+	CustomFieldBoolean     CustomFieldType = "boolean"
+)
+
+// CustomFieldDateLayout is the only accepted date format. Dates carry no time
+// of day, so there is no time zone to get wrong.
+const CustomFieldDateLayout = "2006-01-02"
+
+// CustomFieldMaxDefinitions caps the fields per entity type and, like
+// FormMaxFields, doubles as the cap on the options of a select field.
+const CustomFieldMaxDefinitions = 50
+
+// CustomFieldMaxLength is the longest text value, and the width of the value
+// column.
+const CustomFieldMaxLength = 1000
+
+// ErrInvalidCustomFieldDefinition marks every error ValidateDefinition returns
+// and ErrInvalidCustomFieldValue every error Encode returns. The service layer
+// wraps both in its validation sentinel.
+var (
+	ErrInvalidCustomFieldDefinition = errors.New("invalid custom field definition")
+	ErrInvalidCustomFieldValue      = errors.New("invalid custom field value")
+)
+
+// CustomFieldEntityTypes are the entity types that can carry custom fields.
+var CustomFieldEntityTypes = []string{AuditEntityLead, AuditEntityCustomer, AuditEntityTicket, AuditEntityTask}
+
+// IsCustomFieldEntity reports whether entityType can carry custom fields.
+func IsCustomFieldEntity(entityType string) bool {
+	for _, candidate := range CustomFieldEntityTypes {
+		if candidate == entityType {
+			return true
+		}
+	}
+	return false
+}
+
+// CustomFieldDefinition is one admin-defined field of an entity type. Its name
+// is the key of the value in the entity's custom_fields and is unique per
+// entity type; it cannot be changed once values have been stored under it.
+type CustomFieldDefinition struct {
+	ID         uint            `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	EntityType string          `gorm:"not null;type:varchar(20);uniqueIndex:idx_custom_field_name" json:"entity_type"`
+	Name       string          `gorm:"not null;type:varchar(50);uniqueIndex:idx_custom_field_name" json:"name"`
+	Label      string          `gorm:"not null;type:varchar(100)" json:"label"`
+	Type       CustomFieldType `gorm:"not null;type:varchar(20)" json:"type"`
+
+	// Required carries no `default` tag for the same reason Form.CreateLead
+	// does not: GORM would store the default over an explicit false.
+	Required bool `gorm:"not null" json:"required"`
+
+	Options     []string `gorm:"-" json:"options,omitempty"`
+	OptionsJSON string   `gorm:"column:options;type:text" json:"-"`
+
+	Position int `gorm:"not null;default:0" json:"position"`
+}
+
+// BeforeSave serializes the options into their TEXT column.
+func (d *CustomFieldDefinition) BeforeSave(tx *gorm.DB) error {
+	encoded, err := encodeJSONSlice(d.Options)
+	if err != nil {
+		return fmt.Errorf("custom field options: %w", err)
+	}
+	d.OptionsJSON = encoded
+	return nil
+}
+
+// AfterFind restores the options from their TEXT column.
+func (d *CustomFieldDefinition) AfterFind(tx *gorm.DB) error {
+	d.Options = decodeJSONSlice[string](d.OptionsJSON)
+	return nil
+}
+
+// ValidateDefinition checks a definition before it is stored and normalises
+// what it can: the name and options are trimmed and a missing label defaults to
+// the name.
+//
+// Every error wraps ErrInvalidCustomFieldDefinition.
+func (d *CustomFieldDefinition) ValidateDefinition() error {
+	if !IsCustomFieldEntity(d.EntityType) {
+		return customFieldDefinitionError("entity type must be one of %s", strings.Join(CustomFieldEntityTypes, ", "))
+	}
+
+	d.Name = strings.TrimSpace(d.Name)
+	if !formFieldNamePattern.MatchString(d.Name) {
+		return customFieldDefinitionError("field %q: name must start with a lowercase letter and contain only lowercase letters, digits and underscores", d.Name)
+	}
+
+	d.Label = strings.TrimSpace(d.Label)
+	if d.Label == "" {
+		d.Label = d.Name
+	}
+	if utf8.RuneCountInString(d.Label) > 100 {
+		return customFieldDefinitionError("field %q: the label cannot be longer than 100 characters", d.Name)
+	}
+
+	switch d.Type {
+	case CustomFieldText, CustomFieldNumber, CustomFieldDate, CustomFieldBoolean:
+		if len(d.Options) > 0 {
+			return customFieldDefinitionError("field %q: only a select or multi_select field can have options", d.Name)
+		}
+	case CustomFieldSelect, CustomFieldMultiSelect:
+		return d.validateOptions()
+	default:
+		return customFieldDefinitionError("field %q: unknown field type %q", d.Name, d.Type)
+	}
+	return nil
+}
+
+func (d *CustomFieldDefinition) validateOptions() error {
+	if len(d.Options) == 0 {
+		return customFieldDefinitionError("field %q: a %s field needs at least one option", d.Name, d.Type)
+	}
+	if len(d.Options) > CustomFieldMaxDefinitions {
+		return customFieldDefinitionError("field %q: a %s field cannot have more than %d options", d.Name, d.Type, CustomFieldMaxDefinitions)
+	}
+	seen := make(map[string]bool, len(d.Options))
+	for i, option := range d.Options {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			return customFieldDefinitionError("field %q: options cannot be empty", d.Name)
+		}
+		if utf8.RuneCountInString(option) > 100 {
+			return customFieldDefinitionError("field %q: option %q is longer than 100 characters", d.Name, option)
+		}
+		if seen[option] {
+			return customFieldDefinitionError("field %q: duplicate option %q", d.Name, option)
+		}
+		seen[option] = true
+		d.Options[i] = option
+	}
+	return nil
+}
+
+func customFieldDefinitionError(format string, args ...any) error {
+	return fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), ErrInvalidCustomFieldDefinition)
+}
+
+func customFieldValueError(name, format string, args ...any) error {
+	return fmt.Errorf("custom field %q: %s: %w", name, fmt.Sprintf(format, args...), ErrInvalidCustomFieldValue)
+}
+
+// hasOption reports whether option is one of the field's options.
+func (d *CustomFieldDefinition) hasOption(option string) bool {
+	for _, candidate := range d.Options {
+		if candidate == option {
+			return true
+		}
+	}
+	return false
+}
+
+// Encode turns a value as it arrives in a JSON payload (or a query string, for
+// the filters) into its stored form: one string per row, canonical for the
+// field's type so that equal values compare equal in SQL. nil, and an empty
+// string or list, clear the field and encode to no rows.
+//
+// Numbers and booleans are also accepted as strings, which is how they arrive
+// from query strings and CSV files.
+//
+// Every error wraps ErrInvalidCustomFieldValue.
+func (d *CustomFieldDefinition) Encode(value interface{}) ([]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if text, ok := value.(string); ok && d.Type != CustomFieldText {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return nil, nil
+		}
+		value = text
+	}
+
+	switch d.Type {
+	case CustomFieldText:
+		text, ok := value.(string)
+		if !ok {
+			return nil, customFieldValueError(d.Name, "expected a string")
+		}
+		if text == "" {
+			return nil, nil
+		}
+		if utf8.RuneCountInString(text) > CustomFieldMaxLength {
+			return nil, customFieldValueError(d.Name, "cannot be longer than %d characters", CustomFieldMaxLength)
+		}
+		return []string{text}, nil
+
+	case CustomFieldNumber:
+		number, ok := customFieldNumber(value)
+		if !ok {
+			return nil, customFieldValueError(d.Name, "expected a number")
+		}
+		return []string{strconv.FormatFloat(number, 'f', -1, 64)}, nil
+
+	case CustomFieldDate:
+		text, ok := value.(string)
+		if !ok {
+			return nil, customFieldValueError(d.Name, "expected a date")
+		}
+		date, err := time.Parse(CustomFieldDateLayout, text)
+		if err != nil {
+			return nil, customFieldValueError(d.Name, "expected a date formatted YYYY-MM-DD")
+		}
+		return []string{date.Format(CustomFieldDateLayout)}, nil
+
+	case CustomFieldBoolean:
+		var flag bool
+		switch v := value.(type) {
+		case bool:
+			flag = v
+		case string:
+			parsed, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, customFieldValueError(d.Name, "expected true or false")
+			}
+			flag = parsed
+		default:
+			return nil, customFieldValueError(d.Name, "expected true or false")
+		}
+		return []string{strconv.FormatBool(flag)}, nil
+
+	case CustomFieldSelect:
+		option, ok := value.(string)
+		if !ok {
+			return nil, customFieldValueError(d.Name, "expected one of its options")
+		}
+		if !d.hasOption(option) {
+			return nil, customFieldValueError(d.Name, "%q is not one of its options", option)
+		}
+		return []string{option}, nil
+
+	case CustomFieldMultiSelect:
+		return d.encodeOptions(value)
+	}
+	return nil, customFieldValueError(d.Name, "unknown field type %q", d.Type)
+}
+
+// encodeOptions encodes a multi-select value: a list of options, or a single
+// option on its own. The rows follow the order of the definition's options, so
+// a value reads back the same however it was written.
+func (d *CustomFieldDefinition) encodeOptions(value interface{}) ([]string, error) {
+	var chosen []string
+	switch v := value.(type) {
+	case string:
+		chosen = []string{v}
+	case []string:
+		chosen = v
+	case []interface{}:
+		for _, element := range v {
+			option, ok := element.(string)
+			if !ok {
+				return nil, customFieldValueError(d.Name, "expected a list of its options")
+			}
+			chosen = append(chosen, option)
+		}
+	default:
+		return nil, customFieldValueError(d.Name, "expected a list of its options")
+	}
+
+	picked := make(map[string]bool, len(chosen))
+	for _, option := range chosen {
+		if !d.hasOption(option) {
+			return nil, customFieldValueError(d.Name, "%q is not one of its options", option)
+		}
+		picked[option] = true
+	}
+	var encoded []string
+	for _, option := range d.Options {
+		if picked[option] {
+			encoded = append(encoded, option)
+		}
+	}
+	return encoded, nil
+}
+
+func customFieldNumber(value interface{}) (float64, bool) {
+	var number float64
+	switch v := value.(type) {
+	case float64:
+		number = v
+	case float32:
+		number = float64(v)
+	case int:
+		number = float64(v)
+	case int64:
+		number = float64(v)
+	case uint:
+		number = float64(v)
+	case string:
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, false
+		}
+		number = parsed
+	default:
+		return 0, false
+	}
+	if math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, false
+	}
+	return number, true
+}
+
+// Decode turns stored rows back into the value an API client sees: a float64
+// for a number, a bool for a boolean, a list for a multi-select and a string
+// otherwise. No rows decode to nil.
+func (d *CustomFieldDefinition) Decode(stored []string) interface{} {
+	if len(stored) == 0 {
+		return nil
+	}
+	switch d.Type {
+	case CustomFieldMultiSelect:
+		return append([]string(nil), stored...)
+	case CustomFieldNumber:
+		if number, err := strconv.ParseFloat(stored[0], 64); err == nil {
+			return number
+		}
+	case CustomFieldBoolean:
+		if flag, err := strconv.ParseBool(stored[0]); err == nil {
+			return flag
+		}
+	}
+	return stored[0]
+}
+
+// CustomFieldValue is one stored value of one record. Number mirrors Value for
+// number fields so that sorting can compare numerically in SQL; it is nil for
+// every other type.
+type CustomFieldValue struct {
+	ID       uint     `gorm:"primarykey" json:"id"`
+	FieldID  uint     `gorm:"not null;index:idx_custom_field_values_field" json:"field_id"`
+	EntityID uint     `gorm:"not null;index:idx_custom_field_values_field;index" json:"entity_id"`
+	Value    string   `gorm:"not null;type:varchar(1000)" json:"value"`
+	Number   *float64 `json:"number,omitempty"`
+}
+
+// CustomFieldFilter keeps the records whose field holds Value, in its encoded
+// form. For a multi-select that means Value is among the chosen options.
+type CustomFieldFilter struct {
+	FieldID uint
+	Value   string
+}
+
+// CustomFieldSort orders a list by one field. Numeric compares the number
+// mirror rather than the text; records without a value sort as NULL does on
+// the database.
+type CustomFieldSort struct {
+	FieldID uint
+	Numeric bool
+	Desc    bool
+}
+
+// CustomFieldQuery is the custom field part of a list request: every filter
+// must hold, and Sort, when set, replaces the column sort.
+type CustomFieldQuery struct {
+	Filters []CustomFieldFilter
+	Sort    *CustomFieldSort
+}
+
+// Empty reports whether the query neither filters nor sorts.
+func (q CustomFieldQuery) Empty() bool {
+	return len(q.Filters) == 0 && q.Sort == nil
+}
+
+// CustomFieldRecord is an entity that carries custom fields. The plugin works
+// through it so it never has to know the concrete types.
+type CustomFieldRecord interface {
+	CustomFieldEntity() string
+	CustomFieldRecordID() uint
+	CustomFieldValues() map[string]interface{}
+	SetCustomFieldValues(values map[string]interface{})
+}
+
+func (l *Lead) CustomFieldEntity() string                              { return AuditEntityLead }
+func (l *Lead) CustomFieldRecordID() uint                              { return l.ID }
+func (l *Lead) CustomFieldValues() map[string]interface{}              { return l.CustomFields }
+func (l *Lead) SetCustomFieldValues(values map[string]interface{})     { l.CustomFields = values }
+func (c *Customer) CustomFieldEntity() string                          { return AuditEntityCustomer }
+func (c *Customer) CustomFieldRecordID() uint                          { return c.ID }
+func (c *Customer) CustomFieldValues() map[string]interface{}          { return c.CustomFields }
+func (c *Customer) SetCustomFieldValues(values map[string]interface{}) { c.CustomFields = values }
+func (t *Ticket) CustomFieldEntity() string                            { return AuditEntityTicket }
+func (t *Ticket) CustomFieldRecordID() uint                            { return t.ID }
+func (t *Ticket) CustomFieldValues() map[string]interface{}            { return t.CustomFields }
+func (t *Ticket) SetCustomFieldValues(values map[string]interface{})   { t.CustomFields = values }
+func (t *Task) CustomFieldEntity() string                              { return AuditEntityTask }
+func (t *Task) CustomFieldRecordID() uint                              { return t.ID }
+func (t *Task) CustomFieldValues() map[string]interface{}              { return t.CustomFields }
+func (t *Task) SetCustomFieldValues(values map[string]interface{})     { t.CustomFields = values }
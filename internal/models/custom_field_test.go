@@ -0,0 +1,133 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomFieldValidateDefinition(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition CustomFieldDefinition
+		wantErr    string
+	}{
+		{
+			name:       "text field",
+			definition: CustomFieldDefinition{EntityType: AuditEntityLead, Name: "region", Type: CustomFieldText},
+		},
+		{
+			name:       "select field",
+			definition: CustomFieldDefinition{EntityType: AuditEntityTicket, Name: "product", Type: CustomFieldSelect, Options: []string{"crm", "erp"}},
+		},
+		{
+			name:       "unknown entity",
+			definition: CustomFieldDefinition{EntityType: AuditEntityUser, Name: "region", Type: CustomFieldText},
+			wantErr:    "entity type must be one of",
+		},
+		{
+			name:       "invalid name",
+			definition: CustomFieldDefinition{EntityType: AuditEntityLead, Name: "Region", Type: CustomFieldText},
+			wantErr:    "name must start with a lowercase letter",
+		},
+		{
+			name:       "unknown type",
+			definition: CustomFieldDefinition{EntityType: AuditEntityLead, Name: "region", Type: "color"},
+			wantErr:    "unknown field type",
+		},
+		{
+			name:       "options on a text field",
+			definition: CustomFieldDefinition{EntityType: AuditEntityLead, Name: "region", Type: CustomFieldText, Options: []string{"emea"}},
+			wantErr:    "only a select or multi_select field can have options",
+		},
+		{
+			name:       "select without options",
+			definition: CustomFieldDefinition{EntityType: AuditEntityLead, Name: "region", Type: CustomFieldSelect},
+			wantErr:    "needs at least one option",
+		},
+		{
+			name:       "duplicate option",
+			definition: CustomFieldDefinition{EntityType: AuditEntityLead, Name: "region", Type: CustomFieldMultiSelect, Options: []string{"emea", " emea "}},
+			wantErr:    `duplicate option "emea"`,
+		},
+		{
+			name:       "long label",
+			definition: CustomFieldDefinition{EntityType: AuditEntityLead, Name: "region", Label: strings.Repeat("x", 101), Type: CustomFieldText},
+			wantErr:    "label cannot be longer than 100 characters",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			definition := tt.definition
+			err := definition.ValidateDefinition()
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			assert.True(t, errors.Is(err, ErrInvalidCustomFieldDefinition))
+		})
+	}
+}
+
+func TestCustomFieldValidateDefinition_Normalises(t *testing.T) {
+	definition := CustomFieldDefinition{
+		EntityType: AuditEntityCustomer, Name: " tier ", Type: CustomFieldSelect, Options: []string{" gold", "silver "},
+	}
+	require.NoError(t, definition.ValidateDefinition())
+	assert.Equal(t, "tier", definition.Name)
+	assert.Equal(t, "tier", definition.Label, "the label defaults to the name")
+	assert.Equal(t, []string{"gold", "silver"}, definition.Options)
+}
+
+func TestCustomFieldEncodeDecode(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition CustomFieldDefinition
+		value      interface{}
+		want       []string
+		decoded    interface{}
+		wantErr    string
+	}{
+		{name: "text", definition: CustomFieldDefinition{Name: "f", Type: CustomFieldText}, value: "EMEA", want: []string{"EMEA"}, decoded: "EMEA"},
+		{name: "nil clears", definition: CustomFieldDefinition{Name: "f", Type: CustomFieldText}, value: nil},
+		{name: "text too long", definition: CustomFieldDefinition{Name: "f", Type: CustomFieldText}, value: strings.Repeat("x", CustomFieldMaxLength+1), wantErr: "cannot be longer"},
+		{name: "number from JSON", definition: CustomFieldDefinition{Name: "f", Type: CustomFieldNumber}, value: 12.50, want: []string{"12.5"}, decoded: 12.5},
+		{name: "number from query string", definition: CustomFieldDefinition{Name: "f", Type: CustomFieldNumber}, value: " 007 ", want: []string{"7"}, decoded: 7.0},
+		{name: "not a number", definition: CustomFieldDefinition{Name: "f", Type: CustomFieldNumber}, value: "seven", wantErr: "expected a number"},
+		{name: "date", definition: CustomFieldDefinition{Name: "f", Type: CustomFieldDate}, value: "2026-02-01", want: []string{"2026-02-01"}, decoded: "2026-02-01"},
+		{name: "bad date", definition: CustomFieldDefinition{Name: "f", Type: CustomFieldDate}, value: "01/02/2026", wantErr: "YYYY-MM-DD"},
+		{name: "boolean", definition: CustomFieldDefinition{Name: "f", Type: CustomFieldBoolean}, value: "1", want: []string{"true"}, decoded: true},
+		{name: "bad boolean", definition: CustomFieldDefinition{Name: "f", Type: CustomFieldBoolean}, value: 3.0, wantErr: "expected true or false"},
+		{name: "select", definition: CustomFieldDefinition{Name: "f", Type: CustomFieldSelect, Options: []string{"a", "b"}}, value: "b", want: []string{"b"}, decoded: "b"},
+		{name: "unknown option", definition: CustomFieldDefinition{Name: "f", Type: CustomFieldSelect, Options: []string{"a", "b"}}, value: "c", wantErr: `"c" is not one of its options`},
+		{
+			name:       "multi select in option order",
+			definition: CustomFieldDefinition{Name: "f", Type: CustomFieldMultiSelect, Options: []string{"a", "b", "c"}},
+			value:      []interface{}{"c", "a", "c"},
+			want:       []string{"a", "c"},
+			decoded:    []string{"a", "c"},
+		},
+		{name: "empty multi select clears", definition: CustomFieldDefinition{Name: "f", Type: CustomFieldMultiSelect, Options: []string{"a"}}, value: []interface{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := tt.definition.Encode(tt.value)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.True(t, errors.Is(err, ErrInvalidCustomFieldValue))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, encoded)
+			assert.Equal(t, tt.decoded, tt.definition.Decode(encoded))
+		})
+	}
+}
@@ -6,7 +6,11 @@ type Customer struct {
 	LastName     string   `gorm:"not null;type:varchar(100)" json:"last_name"`
 	Email        string   `gorm:"uniqueIndex;not null;type:varchar(255)" json:"email"`
 	Phone        string   `gorm:"type:varchar(50)" json:"phone"`
+	EmailKey     string   `gorm:"index;type:varchar(255)" json:"-"`
+	PhoneKey     string   `gorm:"index;type:varchar(20)" json:"-"`
 	Company      string   `gorm:"type:varchar(200)" json:"company"`
+	AccountID    *uint    `gorm:"index" json:"account_id,omitempty"`
+	Account      *Account `gorm:"foreignKey:AccountID" json:"account,omitempty"`
 	Position     string   `gorm:"type:varchar(100)" json:"position"`
 	Address      string   `gorm:"type:varchar(255)" json:"address"`
 	City         string   `gorm:"type:varchar(100)" json:"city"`
@@ -30,4 +34,18 @@ type Customer struct {
 
 
 	Tickets      []Ticket `gorm:"foreignKey:CustomerID" json:"tickets,omitempty"`
-}
\ No newline at end of file
+	CustomFields map[string]interface{} `gorm:"-" json:"custom_fields,omitempty"`
+}
+
+// CustomerFilter narrows a customer list. Search matches the name, email,
+// company, phone and notes.
+type CustomerFilter struct {
+	Search       string
+	SortBy       string
+	SortOrder    string
+	CustomFields CustomFieldQuery
+	// Where is the structured filter of the request, nil for none.
+	Where        *FilterExpr
+	// Cursor pages the list by keyset instead of by offset, nil for none.
+	Cursor       *ListCursor
+}
@@ -44,17 +44,35 @@ func InitDatabase(cfg *config.DatabaseConfig) error {
 func MigrateDatabase() error {
 	return DB.AutoMigrate(
 		&User{},
+		&Account{},
 		&Lead{},
 		&Customer{},
 		&Ticket{},
+		&TicketComment{},
+		&SLAPolicy{},
+		&InboundEmail{},
+		&WebhookSubscription{},
+		&WebhookDelivery{},
+		&PipelineStage{},
+		&Deal{},
+		&DealStageChange{},
 		&Label{},
 		&Task{},
+		&CustomFieldDefinition{},
+		&CustomFieldValue{},
 		&APIKey{},
+		&APIKeyUsage{},
 		&Configuration{},
 		&RefreshToken{},
+		&Session{},
+		&RevokedToken{},
+		&SigningKey{},
 		&PasswordResetToken{},
+		&TwoFactorRecoveryCode{},
+		&SSOLoginState{},
 		&BulkOperation{},
 		&BulkOperationItem{},
+		&Import{},
 		&AEOProfile{},
 		&AEOPrompt{},
 		&AEORun{},
@@ -63,5 +81,9 @@ func MigrateDatabase() error {
 		&Form{},
 		&FormSubmission{},
 		&FormConfirmationToken{},
+		&AuditEvent{},
+		&ActivityEvent{},
+		&SavedView{},
+		&Role{},
 	)
 }
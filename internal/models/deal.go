@@ -0,0 +1,142 @@
+package models
+
+import "time"
+
+// A deal is one sale in progress: an amount of money a customer may spend,
+// moving through the pipeline stages towards won or lost. It belongs to a
+// customer and, when the sale started as a lead, remembers that lead too.
+//
+// The stages are configuration, not records, and admins manage them through
+// the API. A stage carries the default win probability of the deals that
+// enter it, and its outcome says whether reaching it closes the deal. Every
+// stage a deal enters is written to its history, with the stage names copied
+// so renaming a stage later does not rewrite what happened.
+//
+// Amounts are kept in the deal's own currency and never converted; the
+// pipeline and forecast reports therefore always cover one currency at a time.
+
+// DealOutcome says whether a stage is part of the open pipeline or closes the
+// deals that reach it.
+type DealOutcome string
+
+const (
+	DealOutcomeOpen DealOutcome = "open"
+	DealOutcomeWon  DealOutcome = "won"
+	DealOutcomeLost DealOutcome = "lost"
+)
+
+// PipelineStage is one column of the sales pipeline. Stages are listed by
+// Position, then by id.
+type PipelineStage struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Name     string `gorm:"uniqueIndex;not null;type:varchar(100)" json:"name"`
+	Position int    `gorm:"not null;default:0" json:"position"`
+	// Probability is the win probability, in percent, a deal takes when it
+	// enters the stage. Won stages always use 100 and lost stages 0.
+	Probability int         `gorm:"not null;default:0" json:"probability"`
+	Outcome     DealOutcome `gorm:"not null;default:'open';type:varchar(10)" json:"outcome"`
+}
+
+// DefaultPipelineStages seed an empty pipeline, in order.
+var DefaultPipelineStages = []PipelineStage{
+	{Name: "Prospecting", Position: 1, Probability: 10, Outcome: DealOutcomeOpen},
+	{Name: "Qualification", Position: 2, Probability: 25, Outcome: DealOutcomeOpen},
+	{Name: "Proposal", Position: 3, Probability: 50, Outcome: DealOutcomeOpen},
+	{Name: "Negotiation", Position: 4, Probability: 75, Outcome: DealOutcomeOpen},
+	{Name: "Closed Won", Position: 5, Probability: 100, Outcome: DealOutcomeWon},
+	{Name: "Closed Lost", Position: 6, Probability: 0, Outcome: DealOutcomeLost},
+}
+
+type Deal struct {
+	BaseModel
+	Title      string    `gorm:"not null;type:varchar(200)" json:"title"`
+	CustomerID uint      `gorm:"not null;index" json:"customer_id"`
+	Customer   *Customer `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
+	// LeadID is the lead the sale started from, if any.
+	LeadID *uint `gorm:"index" json:"lead_id,omitempty"`
+	Lead   *Lead `gorm:"foreignKey:LeadID" json:"lead,omitempty"`
+
+	Amount   float64 `gorm:"not null;type:decimal(15,2)" json:"amount"`
+	Currency string  `gorm:"not null;type:varchar(3)" json:"currency"`
+	// ExpectedCloseDate places the deal in the forecast; a deal without one
+	// only shows in the pipeline.
+	ExpectedCloseDate *time.Time `gorm:"index" json:"expected_close_date,omitempty"`
+	// Probability is the win probability in percent, 0 to 100.
+	Probability int `gorm:"not null;default:0" json:"probability"`
+
+	OwnerID uint           `gorm:"not null;index" json:"owner_id"`
+	Owner   *User          `gorm:"foreignKey:OwnerID" json:"owner,omitempty"`
+	StageID uint           `gorm:"not null;index" json:"stage_id"`
+	Stage   *PipelineStage `gorm:"foreignKey:StageID" json:"stage,omitempty"`
+	// ClosedAt is when the deal entered a won or lost stage; it is cleared
+	// when the deal is moved back into the open pipeline.
+	ClosedAt *time.Time `json:"closed_at,omitempty"`
+	Notes    string     `gorm:"type:text" json:"notes"`
+}
+
+// WeightedAmount is the amount discounted by the win probability.
+func (d *Deal) WeightedAmount() float64 {
+	return d.Amount * float64(d.Probability) / 100
+}
+
+// DealStageChange is one entry of a deal's stage history. The first entry of
+// every deal has no FromStage: it records the stage the deal was created in.
+type DealStageChange struct {
+	ID            uint      `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time `gorm:"index" json:"created_at"`
+	DealID        uint      `gorm:"not null;index" json:"deal_id"`
+	FromStageID   *uint     `json:"from_stage_id,omitempty"`
+	FromStageName string    `gorm:"type:varchar(100)" json:"from_stage_name,omitempty"`
+	ToStageID     uint      `gorm:"not null" json:"to_stage_id"`
+	ToStageName   string    `gorm:"not null;type:varchar(100)" json:"to_stage_name"`
+	// Probability is the deal's win probability after the move.
+	Probability int   `gorm:"not null;default:0" json:"probability"`
+	ChangedByID *uint `json:"changed_by_id,omitempty"`
+	ChangedBy   *User `gorm:"foreignKey:ChangedByID" json:"changed_by,omitempty"`
+}
+
+// DealFilter narrows a deal list. Zero values mean "any".
+type DealFilter struct {
+	OwnerID    uint
+	CustomerID uint
+	StageID    uint
+	Outcome    DealOutcome
+	// Cursor pages the list by keyset instead of by offset, nil for none.
+	Cursor *ListCursor
+}
+
+// DealReportFilter selects the deals a pipeline or forecast report covers.
+// Currency is required; the expected-close window is half-open and only
+// applies when set.
+type DealReportFilter struct {
+	Currency  string
+	OwnerID   uint
+	CloseFrom *time.Time
+	CloseTo   *time.Time
+}
+
+// DealPipelineRow is one stage of the pipeline report.
+type DealPipelineRow struct {
+	StageID       uint        `json:"stage_id"`
+	StageName     string      `json:"stage_name"`
+	Outcome       DealOutcome `json:"outcome"`
+	Deals         int64       `json:"deals"`
+	Value         float64     `json:"value"`
+	WeightedValue float64     `json:"weighted_value"`
+}
+
+// DealForecastRow is one cell of the forecast: the deals of one owner in one
+// stage expected to close in one month.
+type DealForecastRow struct {
+	Month         string  `json:"month"`
+	StageID       uint    `json:"stage_id"`
+	StageName     string  `json:"stage_name"`
+	OwnerID       uint    `json:"owner_id"`
+	OwnerName     string  `json:"owner_name"`
+	Deals         int64   `json:"deals"`
+	Value         float64 `json:"value"`
+	WeightedValue float64 `json:"weighted_value"`
+}
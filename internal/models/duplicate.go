@@ -0,0 +1,187 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// NormalizeEmail reduces an address to the key duplicates are matched on:
+// trimmed and lower-cased, without a "+tag" on the local part. Gmail ignores
+// dots in the local part and answers to googlemail.com as well, so both are
+// folded for it.
+func NormalizeEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return email
+	}
+	local, domain := email[:at], email[at+1:]
+	if plus := strings.Index(local, "+"); plus > 0 {
+		local = local[:plus]
+	}
+	if domain == "googlemail.com" {
+		domain = "gmail.com"
+	}
+	if domain == "gmail.com" {
+		local = strings.ReplaceAll(local, ".", "")
+	}
+	return local + "@" + domain
+}
+
+// phoneKeyDigits is how many trailing digits of a number are compared. Ten
+// covers a full North American number and the national part of most others,
+// so "+1 (555) 123-4567" and "555.123.4567" meet, as do "+44 20 7946 0958"
+// and "020 7946 0958".
+const phoneKeyDigits = 10
+
+// NormalizePhone reduces a phone number to the key duplicates are matched on:
+// its last ten digits, ignoring formatting and the country prefix. A number
+// with fewer than seven digits is too short to mean anything and has no key.
+func NormalizePhone(phone string) string {
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	key := digits.String()
+	if len(key) < 7 {
+		return ""
+	}
+	if len(key) > phoneKeyDigits {
+		key = key[len(key)-phoneKeyDigits:]
+	}
+	return key
+}
+
+// NormalizeName lower-cases a name and strips everything but letters and
+// digits from each word, for comparing the names of two people.
+func NormalizeName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// syncMatchKeys keeps the email_key and phone_key columns of a lead or
+// customer in step with its email and phone. It is called from their
+// BeforeSave hooks, which run for struct writes (Create, Save) and for map
+// updates alike. A struct write recomputes both keys from the struct; a map
+// update only touches the key whose source column is in the map, through
+// SetColumn, since the hook's receiver is then not the row being written. A
+// map that sets a key itself, as erasure does, is left alone.
+func syncMatchKeys(tx *gorm.DB, email, phone string, emailKey, phoneKey *string) {
+	updates, ok := tx.Statement.Dest.(map[string]interface{})
+	if !ok {
+		*emailKey, *phoneKey = NormalizeEmail(email), NormalizePhone(phone)
+		return
+	}
+	syncMatchKey(tx, updates, "email", "email_key", NormalizeEmail)
+	syncMatchKey(tx, updates, "phone", "phone_key", NormalizePhone)
+}
+
+func syncMatchKey(tx *gorm.DB, updates map[string]interface{}, column, keyColumn string, normalize func(string) string) {
+	if _, set := updates[keyColumn]; set {
+		return
+	}
+	if value, ok := updates[column].(string); ok {
+		tx.Statement.SetColumn(keyColumn, normalize(value))
+	}
+}
+
+// BeforeSave maintains the lead's match keys.
+func (l *Lead) BeforeSave(tx *gorm.DB) error {
+	syncMatchKeys(tx, l.Email, l.Phone, &l.EmailKey, &l.PhoneKey)
+	return nil
+}
+
+// BeforeSave maintains the customer's match keys.
+func (c *Customer) BeforeSave(tx *gorm.DB) error {
+	syncMatchKeys(tx, c.Email, c.Phone, &c.EmailKey, &c.PhoneKey)
+	return nil
+}
+
+// DuplicateReason is one signal that two records describe the same person.
+type DuplicateReason string
+
+const (
+	DuplicateReasonEmail DuplicateReason = "email"
+	DuplicateReasonPhone DuplicateReason = "phone"
+	// DuplicateReasonNameCompany is a name close enough to be a variant
+	// spelling ("Jon Smith", "Smith, John") at the same company.
+	DuplicateReasonNameCompany DuplicateReason = "name_company"
+)
+
+// DuplicateProbe is what the candidate search looks for: rows sharing either
+// key, the account, or the last name of the record being checked. The
+// exclusions keep the record itself out, and the customer a lead was converted
+// into, which is the same person by design. A non-zero LeadOwnerID keeps lead
+// candidates to that owner's leads.
+type DuplicateProbe struct {
+	EmailKey          string
+	PhoneKey          string
+	LastName          string
+	AccountID         *uint
+	ExcludeLeadID     uint
+	ExcludeCustomerID uint
+	LeadOwnerID       uint
+}
+
+// DuplicateCandidate is a lead or customer that may describe the same person
+// as the record it was found for. Score is the sum of the weights of its
+// reasons, out of 100.
+type DuplicateCandidate struct {
+	EntityType string            `json:"entity_type"`
+	ID         uint              `json:"id"`
+	FirstName  string            `json:"first_name"`
+	LastName   string            `json:"last_name"`
+	Email      string            `json:"email"`
+	Phone      string            `json:"phone"`
+	Company    string            `json:"company"`
+	Score      int               `json:"score"`
+	Reasons    []DuplicateReason `json:"reasons"`
+}
+
+// MergeSide names the record a merged field takes its value from.
+type MergeSide string
+
+const (
+	MergeSideSurvivor MergeSide = "survivor"
+	MergeSideMerged   MergeSide = "merged"
+)
+
+// MergeRequest folds the record MergedID into SurvivorID. Fields names, per
+// field, which side's value the survivor keeps; a field left out keeps the
+// survivor's value, or takes the merged record's when the survivor has none.
+type MergeRequest struct {
+	SurvivorID uint
+	MergedID   uint
+	Fields     map[string]MergeSide
+}
+
+// MergeCounts reports how many records a merge re-pointed from the merged
+// record to the survivor.
+type MergeCounts struct {
+	Tasks           int64 `json:"tasks"`
+	Tickets         int64 `json:"tickets"`
+	Deals           int64 `json:"deals"`
+	FormSubmissions int64 `json:"form_submissions"`
+	InboundEmails   int64 `json:"inbound_emails"`
+	Leads           int64 `json:"leads"`
+}
+
+// LeadMerge is the outcome of merging two leads.
+type LeadMerge struct {
+	Lead     *Lead       `json:"lead"`
+	MergedID uint        `json:"merged_id"`
+	Moved    MergeCounts `json:"moved"`
+}
+
+// CustomerMerge is the outcome of merging two customers.
+type CustomerMerge struct {
+	Customer *Customer   `json:"customer"`
+	MergedID uint        `json:"merged_id"`
+	Moved    MergeCounts `json:"moved"`
+}
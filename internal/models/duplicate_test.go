@@ -0,0 +1,44 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	for email, want := range map[string]string{
+		"Ada@Example.com":                 "ada@example.com",
+		"  ada+crm@example.com ":          "ada@example.com",
+		"a.da+news@gmail.com":             "ada@gmail.com",
+		"A.Da@GoogleMail.com":             "ada@gmail.com",
+		"a.da@example.com":                "a.da@example.com",
+		"+tag@example.com":                "+tag@example.com",
+		"not an address":                  "not an address",
+		"deleted-ab12@anonymized.invalid": "deleted-ab12@anonymized.invalid",
+		"":                                "",
+	} {
+		assert.Equal(t, want, NormalizeEmail(email), email)
+	}
+}
+
+func TestNormalizePhone(t *testing.T) {
+	for phone, want := range map[string]string{
+		"+1 (555) 123-4567": "5551234567",
+		"555.123.4567":      "5551234567",
+		"+44 20 7946 0958":  "2079460958",
+		"020 7946 0958":     "2079460958",
+		"0044 20 7946 0958": "2079460958",
+		"123-456":           "",
+		"ext. 42":           "",
+		"":                  "",
+	} {
+		assert.Equal(t, want, NormalizePhone(phone), phone)
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	assert.Equal(t, "smith john", NormalizeName(" Smith,  John "))
+	assert.Equal(t, "o brien", NormalizeName("O'Brien"))
+	assert.Equal(t, "", NormalizeName("--"))
+}
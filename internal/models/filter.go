@@ -0,0 +1,36 @@
+package models
+
+// FilterOperator compares a field with the values of a FilterCondition.
+type FilterOperator string
+
+const (
+	FilterEq         FilterOperator = "eq"
+	FilterNe         FilterOperator = "ne"
+	FilterGt         FilterOperator = "gt"
+	FilterGte        FilterOperator = "gte"
+	FilterLt         FilterOperator = "lt"
+	FilterLte        FilterOperator = "lte"
+	FilterIn         FilterOperator = "in"
+	FilterNotIn      FilterOperator = "nin"
+	FilterContains   FilterOperator = "contains"
+	FilterStartsWith FilterOperator = "startswith"
+)
+
+// FilterCondition compares one column with its values, already converted to
+// the column's Go type: a string, an int64, a bool or a time.Time. Values is
+// empty for a comparison with null (eq and ne only), and holds more than one
+// value only for in and nin.
+type FilterCondition struct {
+	Field    string
+	Operator FilterOperator
+	Values   []interface{}
+}
+
+// FilterExpr is a parsed filter query, as produced by utils.ParseFilter: either
+// a single condition, or a group of expressions that must all hold — or, when
+// Or is set, at least one of them.
+type FilterExpr struct {
+	Condition *FilterCondition
+	Or        bool
+	Group     []FilterExpr
+}
@@ -368,6 +368,16 @@ type FormSubmission struct {
 	ConfirmedAt *time.Time           `json:"confirmed_at"`
 }
 
+// FormSubmissionFilter narrows the submission list of a form; an empty Status
+// means any.
+type FormSubmissionFilter struct {
+	Status string
+	// Where is the structured filter of the request, nil for none.
+	Where *FilterExpr
+	// Cursor pages the list by keyset instead of by offset, nil for none.
+	Cursor *ListCursor
+}
+
 // BeforeSave serializes the submitted values into their TEXT column.
 func (s *FormSubmission) BeforeSave(tx *gorm.DB) error {
 	encoded, err := encodeJSONStringMap(s.Data)
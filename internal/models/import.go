@@ -0,0 +1,171 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// An import loads a CSV file into leads or customers in three steps. The file
+// is uploaded first and stored as it is; the caller then maps its columns to
+// entity fields and dry-runs the mapping, which validates every row and
+// reports the errors without writing anything; finally the import is started
+// with that mapping and runs in the background, tracked by a BulkOperation
+// whose items record the rows that failed, so they can be downloaded, fixed
+// and imported again.
+
+// Limits of an import. The file is held in memory while it is parsed, so its
+// size is capped; the row cap keeps a single background import to a bounded
+// run.
+const (
+	ImportMaxFileSize = 20 << 20
+	ImportMaxRows     = 50000
+	// ImportPreviewRows is how many rows an upload shows back.
+	ImportPreviewRows = 5
+	// ImportMaxReportedErrors caps the row errors a dry run lists; the counts
+	// still cover every row.
+	ImportMaxReportedErrors = 1000
+)
+
+// ImportCustomFieldPrefix marks a mapping to a custom field, as in cf.budget.
+const ImportCustomFieldPrefix = "cf."
+
+// ImportMultiValueSeparator separates the options of a multi-select custom
+// field in a cell, the way exports write them.
+const ImportMultiValueSeparator = ";"
+
+// ImportEntityResources maps the entity types that can be imported to the
+// resource type of their bulk operation.
+var ImportEntityResources = map[string]string{
+	AuditEntityLead:     "leads",
+	AuditEntityCustomer: "customers",
+}
+
+// ImportFields are the fields a column of each entity type can be mapped to,
+// besides its custom fields. Every one of them takes the cell as text, except
+// the enums, which take one of their values.
+var ImportFields = map[string][]string{
+	AuditEntityLead: {"first_name", "last_name", "email", "phone", "company", "position", "source",
+		"status", "classification", "external_id", "notes", "created_at"},
+	AuditEntityCustomer: {"first_name", "last_name", "email", "phone", "company", "position", "address",
+		"city", "state", "country", "postal_code", "notes"},
+}
+
+// ImportRequiredFields must be mapped, and filled on every row, for either
+// entity type.
+var ImportRequiredFields = []string{"first_name", "last_name", "email"}
+
+// ImportEnumValues are the values of the fields that take one of a fixed set.
+var ImportEnumValues = map[string][]string{
+	"status": {string(LeadStatusNew), string(LeadStatusContacted), string(LeadStatusQualified),
+		string(LeadStatusUnqualified), string(LeadStatusConverted)},
+	"classification": {string(LeadClassificationUnclassified), string(LeadClassificationTest),
+		string(LeadClassificationSpam), string(LeadClassificationLead), string(LeadClassificationHotLead)},
+}
+
+// ImportColumnMapping maps one column of the file to a field. Values, when
+// given, replaces a cell's value before it is checked — "Hot" to "hot_lead",
+// say; a value it does not list passes through unchanged.
+type ImportColumnMapping struct {
+	Column string            `json:"column"`
+	Field  string            `json:"field"`
+	Values map[string]string `json:"values,omitempty"`
+}
+
+// ImportOptions is how a file is imported: the mapping of its columns, and
+// for leads the owner of every lead created.
+type ImportOptions struct {
+	Mapping []ImportColumnMapping `json:"mapping"`
+	OwnerID *uint                 `json:"owner_id,omitempty"`
+}
+
+// Import is an uploaded CSV file and, once started, the import of its rows.
+// Its progress and outcome are those of its bulk operation.
+type Import struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	UserID     uint      `gorm:"not null;index" json:"user_id"`
+	EntityType string    `gorm:"not null;type:varchar(20)" json:"entity_type"`
+	FileName   string    `gorm:"type:varchar(255)" json:"file_name"`
+	// Delimiter separates the fields of a row: a comma, a semicolon or a tab.
+	Delimiter string `gorm:"not null;type:varchar(1)" json:"delimiter"`
+	RowCount  int    `gorm:"not null" json:"row_count"`
+	// Content is the file as it was uploaded. It is emptied when the import
+	// is started, as the rows have been read from it by then.
+	Content string `gorm:"type:longtext" json:"-"`
+
+	Columns     []string `gorm:"-" json:"columns"`
+	ColumnsJSON string   `gorm:"column:columns;type:text" json:"-"`
+
+	// Mapping and OwnerID are the options the import was started with.
+	Mapping     []ImportColumnMapping `gorm:"-" json:"mapping,omitempty"`
+	MappingJSON string                `gorm:"column:mapping;type:text" json:"-"`
+	OwnerID     *uint                 `json:"owner_id,omitempty"`
+
+	// OperationID is set when the import is started, and only then.
+	OperationID *uint          `gorm:"index" json:"operation_id,omitempty"`
+	Operation   *BulkOperation `gorm:"foreignKey:OperationID" json:"operation,omitempty"`
+
+	// Preview and SuggestedMapping are filled on upload only: the first rows
+	// of the file, and a mapping of the columns whose names match a field.
+	Preview          [][]string            `gorm:"-" json:"preview,omitempty"`
+	SuggestedMapping []ImportColumnMapping `gorm:"-" json:"suggested_mapping,omitempty"`
+}
+
+// BeforeSave serializes the columns and the mapping into their TEXT columns.
+func (i *Import) BeforeSave(tx *gorm.DB) error {
+	columns, err := encodeJSONSlice(i.Columns)
+	if err != nil {
+		return fmt.Errorf("import columns: %w", err)
+	}
+	mapping, err := encodeJSONSlice(i.Mapping)
+	if err != nil {
+		return fmt.Errorf("import mapping: %w", err)
+	}
+	i.ColumnsJSON, i.MappingJSON = columns, mapping
+	return nil
+}
+
+// AfterFind restores the columns and the mapping.
+func (i *Import) AfterFind(tx *gorm.DB) error {
+	i.Columns = decodeJSONSlice[string](i.ColumnsJSON)
+	if i.MappingJSON != "" {
+		i.Mapping = decodeJSONSlice[ImportColumnMapping](i.MappingJSON)
+	}
+	return nil
+}
+
+// VisibleTo reports whether the user may see the import: they uploaded it, or
+// their permissions include imports:manage.
+func (i *Import) VisibleTo(userID uint, permissions PermissionSet) bool {
+	return i.UserID == userID || permissions.Allows(PermImportsManage)
+}
+
+// ImportRowError is what is wrong with one row of a file. Row is its row
+// number as a spreadsheet shows it, the header being row 1; Column is the
+// column at fault, when there is one.
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportDryRun is the outcome of validating every row of a file against a
+// mapping. Errors lists at most ImportMaxReportedErrors of them.
+type ImportDryRun struct {
+	TotalRows   int              `json:"total_rows"`
+	ValidRows   int              `json:"valid_rows"`
+	InvalidRows int              `json:"invalid_rows"`
+	Errors      []ImportRowError `json:"errors"`
+}
+
+// ImportFailedRow is the Data of the bulk operation item of a row an import
+// could not create: its row number and its cells as they were in the file.
+// Error is what was wrong with it, which the item holds in its own column.
+type ImportFailedRow struct {
+	Row    int      `json:"row"`
+	Values []string `json:"values"`
+	Error  string   `json:"error,omitempty"`
+}
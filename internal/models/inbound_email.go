@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// InboundEmailStatus records what the email gateway did with a message.
+type InboundEmailStatus string
+
+const (
+	// InboundEmailTicketCreated: a known customer wrote and a ticket was opened.
+	InboundEmailTicketCreated InboundEmailStatus = "ticket_created"
+	// InboundEmailReplyAdded: the message was threaded onto an existing ticket.
+	InboundEmailReplyAdded InboundEmailStatus = "reply_added"
+	// InboundEmailLeadCaptured: an unknown sender was turned into a lead, or
+	// noted on the lead that already has their address.
+	InboundEmailLeadCaptured InboundEmailStatus = "lead_captured"
+	// InboundEmailQuarantined: an unknown sender was held for review.
+	InboundEmailQuarantined InboundEmailStatus = "quarantined"
+	// InboundEmailIgnored: an auto-reply or bulk message, dropped so that an
+	// out-of-office answer cannot open a ticket or start a mail loop.
+	InboundEmailIgnored InboundEmailStatus = "ignored"
+)
+
+// IsValid reports whether s is one of the known statuses.
+func (s InboundEmailStatus) IsValid() bool {
+	switch s {
+	case InboundEmailTicketCreated, InboundEmailReplyAdded, InboundEmailLeadCaptured,
+		InboundEmailQuarantined, InboundEmailIgnored:
+		return true
+	}
+	return false
+}
+
+// Unknown-sender policies of the email gateway.
+const (
+	InboundUnknownSenderQuarantine = "quarantine"
+	InboundUnknownSenderLead       = "lead"
+)
+
+// InboundEmail is the gateway's log of one received message. It is what makes
+// redelivery harmless (a Message-ID already on file is skipped) and what lets a
+// reply find its ticket through In-Reply-To.
+//
+// The sender, subject and body are kept only on quarantined messages, which
+// exist nowhere else and have to be reviewable. A message that became a ticket,
+// a reply or a lead note lives on in that record; the log keeps just the link,
+// so erasing the customer or lead leaves no copy of their mail behind.
+type InboundEmail struct {
+	ID         uint               `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time          `json:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+	MessageID  string             `gorm:"type:varchar(255);index" json:"message_id"`
+	Status     InboundEmailStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	ReceivedAt time.Time          `gorm:"not null" json:"received_at"`
+	TicketID   *uint              `gorm:"index" json:"ticket_id,omitempty"`
+	CustomerID *uint              `gorm:"index" json:"customer_id,omitempty"`
+	LeadID     *uint              `gorm:"index" json:"lead_id,omitempty"`
+
+	FromAddress string `gorm:"type:varchar(255)" json:"from_address,omitempty"`
+	FromName    string `gorm:"type:varchar(200)" json:"from_name,omitempty"`
+	Subject     string `gorm:"type:varchar(255)" json:"subject,omitempty"`
+	Body        string `gorm:"type:text" json:"body,omitempty"`
+}
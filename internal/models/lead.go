@@ -26,7 +26,11 @@ type Lead struct {
 	LastName       string             `gorm:"not null;type:varchar(100)" json:"last_name"`
 	Email          string             `gorm:"not null;type:varchar(255)" json:"email"`
 	Phone          string             `gorm:"type:varchar(50)" json:"phone"`
+	EmailKey       string             `gorm:"index;type:varchar(255)" json:"-"`
+	PhoneKey       string             `gorm:"index;type:varchar(20)" json:"-"`
 	Company        string             `gorm:"type:varchar(200)" json:"company"`
+	AccountID      *uint              `gorm:"index" json:"account_id,omitempty"`
+	Account        *Account           `gorm:"foreignKey:AccountID" json:"account,omitempty"`
 	Position       string             `gorm:"type:varchar(100)" json:"position"`
 	Source         string             `gorm:"type:varchar(100)" json:"source"`
 	Status         LeadStatus         `gorm:"not null;default:'new';type:varchar(20)" json:"status"`
@@ -37,4 +41,20 @@ type Lead struct {
 	Owner          User               `gorm:"foreignKey:OwnerID" json:"owner,omitempty"`
 	CustomerID     *uint              `json:"customer_id,omitempty"`
 	Customer       *Customer          `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
-}
\ No newline at end of file
+	CustomFields   map[string]interface{} `gorm:"-" json:"custom_fields,omitempty"`
+}
+
+// LeadFilter narrows a lead list. Search matches the name, email, company,
+// phone and notes; a zero OwnerID and an empty Classification mean any.
+type LeadFilter struct {
+	Search         string
+	OwnerID        uint
+	Classification LeadClassification
+	SortBy         string
+	SortOrder      string
+	CustomFields   CustomFieldQuery
+	// Where is the structured filter of the request, nil for none.
+	Where          *FilterExpr
+	// Cursor pages the list by keyset instead of by offset, nil for none.
+	Cursor         *ListCursor
+}
@@ -0,0 +1,23 @@
+package models
+
+// ListCursor pages a list by keyset rather than by offset: the next page is
+// the rows that sort after the last row of the previous one, by the sort
+// column and then by id, so it costs the same however deep the page is and
+// neither skips nor repeats rows when rows are added or removed between
+// requests.
+type ListCursor struct {
+	// SortBy is the sort column, empty for id.
+	SortBy string
+	Desc   bool
+	// After is the position of the last row of the previous page, nil for
+	// the first page.
+	After *CursorPosition
+}
+
+// CursorPosition is one row's place in a keyset-paged list: its sort column
+// value, already converted to the column's Go type as in FilterCondition (nil
+// for a null), and its id, which breaks ties between equal values.
+type CursorPosition struct {
+	Value interface{}
+	ID    uint
+}
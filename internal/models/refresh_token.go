@@ -10,4 +10,7 @@ type RefreshToken struct {
 	TokenHash string    `gorm:"not null;uniqueIndex;type:varchar(255)" json:"-"`
 	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
 	Revoked   bool      `gorm:"column:is_revoked;default:false" json:"revoked"`
+	// SessionID is the login the token descends from; zero for tokens issued
+	// before sessions were recorded.
+	SessionID uint `gorm:"index" json:"session_id"`
 }
@@ -0,0 +1,468 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Permission names one thing a role may do, as resource:action. Where a role
+// may be held to its own records the permission comes in two scopes,
+// resource:action:own and resource:action:any; the handler decides which
+// records are the caller's own.
+type Permission string
+
+const (
+	PermUsersCreate Permission = "users:create"
+	PermUsersRead   Permission = "users:read"
+	PermUsersUpdate Permission = "users:update"
+	PermUsersDelete Permission = "users:delete"
+	PermUsersExport Permission = "users:export"
+
+	PermRolesManage Permission = "roles:manage"
+
+	PermLeadsCreate    Permission = "leads:create"
+	PermLeadsReadOwn   Permission = "leads:read:own"
+	PermLeadsReadAny   Permission = "leads:read:any"
+	PermLeadsUpdateOwn Permission = "leads:update:own"
+	PermLeadsUpdateAny Permission = "leads:update:any"
+	PermLeadsDeleteOwn Permission = "leads:delete:own"
+	PermLeadsDeleteAny Permission = "leads:delete:any"
+	PermLeadsAssign    Permission = "leads:assign"
+	PermLeadsExport    Permission = "leads:export"
+
+	PermCustomersCreate Permission = "customers:create"
+	PermCustomersRead   Permission = "customers:read"
+	PermCustomersUpdate Permission = "customers:update"
+	PermCustomersDelete Permission = "customers:delete"
+	PermCustomersAssign Permission = "customers:assign"
+	PermCustomersExport Permission = "customers:export"
+
+	PermTicketsCreate        Permission = "tickets:create"
+	PermTicketsList          Permission = "tickets:list"
+	PermTicketsReadOwn       Permission = "tickets:read:own"
+	PermTicketsReadAny       Permission = "tickets:read:any"
+	PermTicketsUpdateOwn     Permission = "tickets:update:own"
+	PermTicketsUpdateAny     Permission = "tickets:update:any"
+	PermTicketsDelete        Permission = "tickets:delete"
+	PermTicketsAssign        Permission = "tickets:assign"
+	PermTicketsComment       Permission = "tickets:comment"
+	PermTicketsInternalNotes Permission = "tickets:internal_notes"
+	PermTicketsExport        Permission = "tickets:export"
+
+	PermTasksCreate    Permission = "tasks:create"
+	PermTasksReadOwn   Permission = "tasks:read:own"
+	PermTasksReadAny   Permission = "tasks:read:any"
+	PermTasksUpdateOwn Permission = "tasks:update:own"
+	PermTasksUpdateAny Permission = "tasks:update:any"
+	PermTasksDelete    Permission = "tasks:delete"
+	PermTasksAssign    Permission = "tasks:assign"
+	PermTasksExport    Permission = "tasks:export"
+
+	PermLabelsWrite  Permission = "labels:write"
+	PermLabelsDelete Permission = "labels:delete"
+
+	PermDealsReadOwn   Permission = "deals:read:own"
+	PermDealsReadAny   Permission = "deals:read:any"
+	PermDealsWriteOwn  Permission = "deals:write:own"
+	PermDealsWriteAny  Permission = "deals:write:any"
+	PermDealsAssign    Permission = "deals:assign"
+	PermPipelineRead   Permission = "pipeline:read"
+	PermPipelineManage Permission = "pipeline:manage"
+
+	PermAccountsRead   Permission = "accounts:read"
+	PermAccountsWrite  Permission = "accounts:write"
+	PermAccountsAssign Permission = "accounts:assign"
+	PermAccountsDelete Permission = "accounts:delete"
+
+	PermFormsRead    Permission = "forms:read"
+	PermFormsWrite   Permission = "forms:write"
+	PermFormsPublish Permission = "forms:publish"
+	PermFormsDelete  Permission = "forms:delete"
+	PermFormsExport  Permission = "forms:export"
+
+	PermAEORead   Permission = "aeo:read"
+	PermAEOWrite  Permission = "aeo:write"
+	PermAEODelete Permission = "aeo:delete"
+
+	PermImportsRun    Permission = "imports:run"
+	PermImportsManage Permission = "imports:manage"
+
+	PermBulkOperationsManage Permission = "bulk_operations:manage"
+	PermSavedViewsManage     Permission = "saved_views:manage"
+
+	PermDashboardRead Permission = "dashboard:read"
+
+	PermSLARead   Permission = "sla:read"
+	PermSLAManage Permission = "sla:manage"
+
+	PermInboundEmailsRead    Permission = "inbound_emails:read"
+	PermInboundEmailsRelease Permission = "inbound_emails:release"
+	PermInboundEmailsDelete  Permission = "inbound_emails:delete"
+
+	PermCustomFieldsRead   Permission = "custom_fields:read"
+	PermCustomFieldsManage Permission = "custom_fields:manage"
+
+	PermConfigurationsRead   Permission = "configurations:read"
+	PermConfigurationsUpdate Permission = "configurations:update"
+
+	PermWebhooksManage Permission = "webhooks:manage"
+	PermAuditRead      Permission = "audit:read"
+)
+
+// Either scope of the scoped permissions, for the routes that admit both and
+// leave the handler to hold the caller to their own records. No role holds
+// them as such.
+const (
+	PermLeadsRead     Permission = "leads:read"
+	PermLeadsUpdate   Permission = "leads:update"
+	PermLeadsDelete   Permission = "leads:delete"
+	PermTicketsRead   Permission = "tickets:read"
+	PermTicketsUpdate Permission = "tickets:update"
+	PermTasksRead     Permission = "tasks:read"
+	PermTasksUpdate   Permission = "tasks:update"
+	PermDealsRead     Permission = "deals:read"
+	PermDealsWrite    Permission = "deals:write"
+)
+
+// The scopes of a scoped permission.
+const (
+	PermissionScopeOwn = "own"
+	PermissionScopeAny = "any"
+)
+
+// PermissionDefinition describes one permission of the registry.
+type PermissionDefinition struct {
+	Name        Permission `json:"name"`
+	Description string     `json:"description"`
+}
+
+// PermissionRegistry is every permission a role can hold, in the order the
+// API lists them.
+var PermissionRegistry = []PermissionDefinition{
+	{PermUsersCreate, "Create users"},
+	{PermUsersRead, "List users and view other users' profiles"},
+	{PermUsersUpdate, "Update other users' profiles, status and role"},
+	{PermUsersDelete, "Delete (erase) users"},
+	{PermUsersExport, "Export users"},
+	{PermRolesManage, "Define custom roles and assign roles to users"},
+
+	{PermLeadsCreate, "Create leads"},
+	{PermLeadsReadOwn, "View the leads you own"},
+	{PermLeadsReadAny, "View every lead"},
+	{PermLeadsUpdateOwn, "Update and convert the leads you own"},
+	{PermLeadsUpdateAny, "Update and convert every lead"},
+	{PermLeadsDeleteOwn, "Delete and merge the leads you own"},
+	{PermLeadsDeleteAny, "Delete and merge every lead"},
+	{PermLeadsAssign, "Create leads for, and reassign leads to, other users"},
+	{PermLeadsExport, "Export leads"},
+
+	{PermCustomersCreate, "Create customers"},
+	{PermCustomersRead, "View customers"},
+	{PermCustomersUpdate, "Update customers in bulk"},
+	{PermCustomersDelete, "Delete (erase) and merge customers"},
+	{PermCustomersAssign, "Assign customers to staff"},
+	{PermCustomersExport, "Export customers"},
+
+	{PermTicketsCreate, "Create tickets in bulk"},
+	{PermTicketsList, "List and search every ticket, and any customer's tickets"},
+	{PermTicketsReadOwn, "View the tickets assigned to you or raised by your customer record, and their threads"},
+	{PermTicketsReadAny, "View every ticket and its thread"},
+	{PermTicketsUpdateOwn, "Update the tickets assigned to you"},
+	{PermTicketsUpdateAny, "Update every ticket"},
+	{PermTicketsDelete, "Delete tickets in bulk"},
+	{PermTicketsAssign, "Assign tickets"},
+	{PermTicketsComment, "Comment on the tickets you can view"},
+	{PermTicketsInternalNotes, "Read and post the internal notes of tickets"},
+	{PermTicketsExport, "Export tickets"},
+
+	{PermTasksCreate, "Create tasks in bulk"},
+	{PermTasksReadOwn, "View the tasks assigned to you"},
+	{PermTasksReadAny, "View every task"},
+	{PermTasksUpdateOwn, "Update the tasks assigned to you in bulk"},
+	{PermTasksUpdateAny, "Update every task in bulk"},
+	{PermTasksDelete, "Delete tasks in bulk"},
+	{PermTasksAssign, "Reassign tasks in bulk"},
+	{PermTasksExport, "Export tasks"},
+
+	{PermLabelsWrite, "Create and edit task labels"},
+	{PermLabelsDelete, "Delete task labels"},
+
+	{PermDealsReadOwn, "View your own deals and report on them"},
+	{PermDealsReadAny, "View every deal and report on every owner's"},
+	{PermDealsWriteOwn, "Create, update, move and delete your own deals"},
+	{PermDealsWriteAny, "Update, move and delete every deal"},
+	{PermDealsAssign, "Create deals for, and reassign deals to, other users"},
+	{PermPipelineRead, "View the pipeline stages"},
+	{PermPipelineManage, "Create, update and delete pipeline stages"},
+
+	{PermAccountsRead, "View accounts and their customers, tickets and tasks"},
+	{PermAccountsWrite, "Create and update accounts"},
+	{PermAccountsAssign, "Create accounts for, and reassign accounts to, other users"},
+	{PermAccountsDelete, "Delete accounts"},
+
+	{PermFormsRead, "View forms and their submissions"},
+	{PermFormsWrite, "Create and edit forms"},
+	{PermFormsPublish, "Publish and unpublish forms"},
+	{PermFormsDelete, "Delete forms"},
+	{PermFormsExport, "Export form submissions"},
+
+	{PermAEORead, "View the AEO reports"},
+	{PermAEOWrite, "Change the AEO profile and prompts, and start runs"},
+	{PermAEODelete, "Delete AEO prompts"},
+
+	{PermImportsRun, "Import leads and customers from CSV"},
+	{PermImportsManage, "View and run every user's imports"},
+
+	{PermBulkOperationsManage, "View, cancel and revert every user's bulk operations"},
+	{PermSavedViewsManage, "See, change and delete every saved view, and share views with any role"},
+
+	{PermDashboardRead, "View the dashboard reports"},
+
+	{PermSLARead, "View the SLA policies"},
+	{PermSLAManage, "Change the SLA policies"},
+
+	{PermInboundEmailsRead, "View the inbound email log"},
+	{PermInboundEmailsRelease, "Release quarantined inbound emails"},
+	{PermInboundEmailsDelete, "Discard inbound emails"},
+
+	{PermCustomFieldsRead, "View the custom field definitions"},
+	{PermCustomFieldsManage, "Define, change and delete custom fields"},
+
+	{PermConfigurationsRead, "View the system configuration"},
+	{PermConfigurationsUpdate, "Change the system configuration"},
+
+	{PermWebhooksManage, "Manage webhook subscriptions and deliveries"},
+	{PermAuditRead, "Read the audit trail and the whole activity feed"},
+}
+
+// IsValid reports whether p is in the registry.
+func (p Permission) IsValid() bool {
+	for _, known := range PermissionRegistry {
+		if p == known.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionSet is the set of permissions a role holds.
+type PermissionSet map[Permission]bool
+
+// NewPermissionSet returns the set of permissions.
+func NewPermissionSet(permissions ...Permission) PermissionSet {
+	set := make(PermissionSet, len(permissions))
+	for _, permission := range permissions {
+		set[permission] = true
+	}
+	return set
+}
+
+// Allows reports whether the set grants p. The any scope of a permission
+// grants its own scope as well, and a permission named without a scope is
+// granted by either: leads:read is held by a role that may read its own
+// leads, or every lead.
+func (s PermissionSet) Allows(p Permission) bool {
+	if s[p] {
+		return true
+	}
+	name := string(p)
+	if base, ok := strings.CutSuffix(name, ":"+PermissionScopeOwn); ok {
+		return s[Permission(base+":"+PermissionScopeAny)]
+	}
+	if strings.Count(name, ":") == 1 {
+		return s[Permission(name+":"+PermissionScopeOwn)] || s[Permission(name+":"+PermissionScopeAny)]
+	}
+	return false
+}
+
+// Covers reports whether the set grants every permission of other.
+func (s PermissionSet) Covers(other PermissionSet) bool {
+	for permission := range other {
+		if !s.Allows(permission) {
+			return false
+		}
+	}
+	return true
+}
+
+// List returns the permissions of the set in registry order.
+func (s PermissionSet) List() []Permission {
+	permissions := make([]Permission, 0, len(s))
+	for _, definition := range PermissionRegistry {
+		if s[definition.Name] {
+			permissions = append(permissions, definition.Name)
+		}
+	}
+	return permissions
+}
+
+// builtInPermissions express what the four fixed roles could do before roles
+// were configurable. Staff are the admin, sales and support roles.
+var builtInPermissions = map[UserRole][]Permission{
+	RoleAdmin: allPermissions(),
+	RoleSales: {
+		PermLeadsCreate, PermLeadsReadOwn, PermLeadsUpdateOwn, PermLeadsDeleteOwn,
+		PermCustomersCreate, PermCustomersRead, PermCustomersUpdate, PermCustomersAssign,
+		PermTicketsList, PermTicketsReadAny, PermTicketsInternalNotes,
+		PermTasksCreate, PermTasksReadOwn, PermTasksUpdateOwn,
+		PermLabelsWrite,
+		PermDealsReadOwn, PermDealsWriteOwn, PermPipelineRead,
+		PermAccountsRead, PermAccountsWrite,
+		PermFormsRead, PermFormsWrite, PermFormsPublish,
+		PermAEORead, PermAEOWrite,
+		PermImportsRun,
+		PermDashboardRead, PermSLARead, PermCustomFieldsRead,
+	},
+	RoleSupport: {
+		PermCustomersRead,
+		PermTicketsCreate, PermTicketsList, PermTicketsReadOwn, PermTicketsUpdateOwn, PermTicketsAssign,
+		PermTicketsComment, PermTicketsInternalNotes,
+		PermTasksCreate, PermTasksReadOwn, PermTasksUpdateOwn,
+		PermLabelsWrite,
+		PermPipelineRead,
+		PermAccountsRead,
+		PermFormsRead,
+		PermAEORead,
+		PermDashboardRead, PermSLARead, PermCustomFieldsRead,
+		PermInboundEmailsRead, PermInboundEmailsRelease,
+	},
+	RoleCustomer: {
+		PermTicketsReadOwn, PermTicketsComment,
+		PermTasksReadOwn, PermTasksUpdateOwn,
+	},
+}
+
+func allPermissions() []Permission {
+	permissions := make([]Permission, 0, len(PermissionRegistry))
+	for _, definition := range PermissionRegistry {
+		permissions = append(permissions, definition.Name)
+	}
+	return permissions
+}
+
+// IsBuiltIn reports whether r is one of the four built-in roles.
+func (r UserRole) IsBuiltIn() bool {
+	_, ok := builtInPermissions[r]
+	return ok
+}
+
+// BuiltInPermissions returns the permissions of a built-in role, and an empty
+// set for any other role.
+func BuiltInPermissions(role UserRole) PermissionSet {
+	return NewPermissionSet(builtInPermissions[role]...)
+}
+
+// builtInDescriptions describe the built-in roles.
+var builtInDescriptions = map[UserRole]string{
+	RoleAdmin:    "Full access",
+	RoleSales:    "Leads, customers, deals and accounts; their own leads only",
+	RoleSupport:  "Tickets assigned to them and the inbound email quarantine",
+	RoleCustomer: "Their own tickets and the tasks assigned to them",
+}
+
+// BuiltInRoles returns the four built-in roles, as they are stored.
+func BuiltInRoles() []Role {
+	roles := make([]Role, 0, len(builtInPermissions))
+	for _, name := range []UserRole{RoleAdmin, RoleSales, RoleSupport, RoleCustomer} {
+		roles = append(roles, Role{
+			Name:        string(name),
+			Description: builtInDescriptions[name],
+			BaseRole:    name,
+			BuiltIn:     true,
+			Permissions: append([]Permission(nil), builtInPermissions[name]...),
+		})
+	}
+	return roles
+}
+
+// Role maps a name users are given to the permissions they hold.
+//
+// The four built-in roles are stored alongside the custom ones, and refreshed
+// from the code on every start; they cannot be changed. A custom role is based
+// on one of them. Its permissions decide both which endpoints its users reach
+// and which records they see and change there; the base role only decides
+// which saved views shared with a role reach its users.
+type Role struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Name is what users.role holds. It is fixed once the role is created.
+	Name        string   `gorm:"uniqueIndex;not null;type:varchar(20)" json:"name"`
+	Description string   `gorm:"type:varchar(255)" json:"description"`
+	BaseRole    UserRole `gorm:"not null;type:varchar(20)" json:"base_role"`
+	BuiltIn     bool     `gorm:"not null;default:false" json:"built_in"`
+
+	Permissions     []Permission `gorm:"-" json:"permissions"`
+	PermissionsJSON string       `gorm:"column:permissions;type:text" json:"-"`
+}
+
+// BeforeSave serializes the permission list into its TEXT column.
+func (r *Role) BeforeSave(tx *gorm.DB) error {
+	permissions := r.Permissions
+	if permissions == nil {
+		permissions = []Permission{}
+	}
+	encoded, err := json.Marshal(permissions)
+	if err != nil {
+		return fmt.Errorf("role permissions: %w", err)
+	}
+	r.PermissionsJSON = string(encoded)
+	return nil
+}
+
+// AfterFind restores the permission list. A column that cannot be decoded
+// yields no permissions, so a damaged row grants nothing rather than
+// everything.
+func (r *Role) AfterFind(tx *gorm.DB) error {
+	r.Permissions = []Permission{}
+	if r.PermissionsJSON != "" {
+		if err := json.Unmarshal([]byte(r.PermissionsJSON), &r.Permissions); err != nil {
+			r.Permissions = []Permission{}
+		}
+	}
+	return nil
+}
+
+// PermissionSet returns the permissions of the role as a set.
+func (r *Role) PermissionSet() PermissionSet {
+	return NewPermissionSet(r.Permissions...)
+}
+
+// ErrInvalidRole is wrapped by every error ValidateRole returns.
+var ErrInvalidRole = errors.New("invalid role")
+
+// roleNamePattern is the shape of a role name: it is stored in users.role and
+// appears in paths, so it is kept to a lowercase slug.
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,19}$`)
+
+// ValidateRole checks a custom role: a slug name no built-in role uses, a
+// built-in base role, and permissions from the registry, each listed once.
+func (r *Role) ValidateRole() error {
+	if !roleNamePattern.MatchString(r.Name) {
+		return fmt.Errorf("%w: the name must be 2 to 20 lowercase letters, digits, dashes or underscores, starting with a letter", ErrInvalidRole)
+	}
+	if UserRole(r.Name).IsBuiltIn() {
+		return fmt.Errorf("%w: %q is a built-in role", ErrInvalidRole, r.Name)
+	}
+	if !r.BaseRole.IsBuiltIn() {
+		return fmt.Errorf("%w: the base role must be admin, sales, support or customer", ErrInvalidRole)
+	}
+	seen := make(map[Permission]bool, len(r.Permissions))
+	for _, permission := range r.Permissions {
+		if !permission.IsValid() {
+			return fmt.Errorf("%w: unknown permission %q", ErrInvalidRole, permission)
+		}
+		if seen[permission] {
+			return fmt.Errorf("%w: permission %q is listed twice", ErrInvalidRole, permission)
+		}
+		seen[permission] = true
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermissionSetAllows(t *testing.T) {
+	set := NewPermissionSet(PermLeadsReadAny, PermLeadsUpdateOwn, PermTicketsCreate)
+
+	assert.True(t, set.Allows(PermLeadsReadAny))
+	assert.True(t, set.Allows(PermLeadsReadOwn), "the any scope includes the own scope")
+	assert.True(t, set.Allows(PermLeadsRead), "an unscoped permission is granted by either scope")
+	assert.True(t, set.Allows(PermLeadsUpdate))
+	assert.False(t, set.Allows(PermLeadsUpdateAny), "the own scope does not include the any scope")
+	assert.False(t, set.Allows(PermLeadsDelete))
+	assert.True(t, set.Allows(PermTicketsCreate))
+	assert.False(t, set.Allows(PermTicketsDelete))
+}
+
+func TestPermissionSetCovers(t *testing.T) {
+	admin := BuiltInPermissions(RoleAdmin)
+	sales := BuiltInPermissions(RoleSales)
+
+	assert.True(t, admin.Covers(sales))
+	assert.False(t, sales.Covers(admin))
+	assert.True(t, NewPermissionSet(PermLeadsReadAny).Covers(NewPermissionSet(PermLeadsReadOwn)))
+	assert.True(t, sales.Covers(NewPermissionSet()))
+}
+
+func TestBuiltInPermissions(t *testing.T) {
+	for _, definition := range PermissionRegistry {
+		assert.True(t, BuiltInPermissions(RoleAdmin).Allows(definition.Name), "admin holds %s", definition.Name)
+	}
+
+	sales := BuiltInPermissions(RoleSales)
+	assert.True(t, sales.Allows(PermLeadsReadOwn))
+	assert.False(t, sales.Allows(PermLeadsReadAny))
+	assert.False(t, sales.Allows(PermRolesManage))
+
+	support := BuiltInPermissions(RoleSupport)
+	assert.True(t, support.Allows(PermTicketsAssign))
+	assert.False(t, support.Allows(PermLeadsRead))
+
+	assert.Empty(t, BuiltInPermissions("auditor"), "an unknown role holds nothing")
+}
+
+func TestBuiltInRoles(t *testing.T) {
+	roles := BuiltInRoles()
+	require.Len(t, roles, 4)
+	for _, role := range roles {
+		assert.True(t, role.BuiltIn)
+		assert.Equal(t, UserRole(role.Name), role.BaseRole)
+		assert.Equal(t, BuiltInPermissions(role.BaseRole), role.PermissionSet())
+	}
+}
+
+func TestRoleValidateRole(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    Role
+		wantErr string
+	}{
+		{
+			name: "custom role",
+			role: Role{Name: "sales-lead", BaseRole: RoleSales, Permissions: []Permission{PermLeadsReadAny, PermLeadsAssign}},
+		},
+		{
+			name: "no permissions",
+			role: Role{Name: "viewer", BaseRole: RoleCustomer},
+		},
+		{
+			name:    "invalid name",
+			role:    Role{Name: "Sales Lead", BaseRole: RoleSales},
+			wantErr: "the name must be",
+		},
+		{
+			name:    "built-in name",
+			role:    Role{Name: "support", BaseRole: RoleSupport},
+			wantErr: "is a built-in role",
+		},
+		{
+			name:    "custom base role",
+			role:    Role{Name: "auditor", BaseRole: "sales-lead"},
+			wantErr: "the base role must be",
+		},
+		{
+			name:    "unknown permission",
+			role:    Role{Name: "auditor", BaseRole: RoleCustomer, Permissions: []Permission{"leads:fly"}},
+			wantErr: "unknown permission",
+		},
+		{
+			name:    "unscoped form of a scoped permission",
+			role:    Role{Name: "auditor", BaseRole: RoleCustomer, Permissions: []Permission{PermLeadsRead}},
+			wantErr: "unknown permission",
+		},
+		{
+			name:    "duplicate permission",
+			role:    Role{Name: "auditor", BaseRole: RoleCustomer, Permissions: []Permission{PermAuditRead, PermAuditRead}},
+			wantErr: "listed twice",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.role.ValidateRole()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, ErrInvalidRole))
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
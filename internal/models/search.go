@@ -0,0 +1,50 @@
+package models
+
+// SearchEntityFormSubmission is the entity type of a form submission among the
+// search hits. The other searchable types share their names with the audit
+// trail: lead, customer, ticket and task.
+const SearchEntityFormSubmission = "form_submission"
+
+// SearchEntities are the entity types the global search covers, in the order
+// hits of equal score are listed.
+var SearchEntities = []string{
+	AuditEntityLead, AuditEntityCustomer, AuditEntityTicket, AuditEntityTask, SearchEntityFormSubmission,
+}
+
+// IsSearchEntity reports whether entityType is covered by the global search.
+func IsSearchEntity(entityType string) bool {
+	for _, entity := range SearchEntities {
+		if entity == entityType {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchScope is one entity type a search covers. With a UserID the hits are
+// limited to the records that user owns (leads) or is assigned (tickets and
+// tasks), the same records their List shows them.
+type SearchScope struct {
+	Entity string
+	UserID uint
+}
+
+// SearchQuery is a global search: every term must appear in a record, as a
+// word or the start of one, for it to be a hit. Terms are lowercase letters
+// and digits only. Entity types without a scope are not searched.
+type SearchQuery struct {
+	Terms  []string
+	Scopes []SearchScope
+	Limit  int
+}
+
+// SearchHit is one record matching a search. Score is the relevance the
+// database gives it, higher is better; it orders the hits of one search and
+// means nothing across searches.
+type SearchHit struct {
+	EntityType string  `json:"entity_type"`
+	EntityID   uint    `json:"entity_id"`
+	Title      string  `json:"title"`
+	Subtitle   string  `json:"subtitle,omitempty"`
+	Score      float64 `json:"score"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Session is one login: the family of refresh tokens that starts when the
+// user logs in and continues through every rotation. Its access tokens carry
+// its ID, so revoking the session ends them too, before they expire.
+type Session struct {
+	ID     uint `gorm:"primarykey" json:"id"`
+	UserID uint `gorm:"not null;index" json:"-"`
+	// Device is a readable summary of UserAgent, e.g. "Firefox on Windows".
+	Device     string     `gorm:"type:varchar(100)" json:"device"`
+	UserAgent  string     `gorm:"type:varchar(512)" json:"user_agent"`
+	IPAddress  string     `gorm:"type:varchar(45)" json:"ip_address"`
+	LastUsedAt time.Time  `gorm:"not null" json:"last_used_at"`
+	ExpiresAt  time.Time  `gorm:"not null;index" json:"expires_at"`
+	RevokedAt  *time.Time `gorm:"index" json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	// Current marks the session the listing was requested from.
+	Current bool `gorm:"-" json:"current"`
+}
+
+// RevokedToken is an access token revoked before its expiry, by its jti. The
+// row is useless once the token has expired and is swept after that.
+type RevokedToken struct {
+	ID        uint      `gorm:"primarykey"`
+	JTI       string    `gorm:"column:jti;not null;uniqueIndex;type:varchar(64)"`
+	UserID    uint      `gorm:"not null;index"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time
+}
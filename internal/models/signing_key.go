@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// The algorithms a signing key can use.
+const (
+	SigningAlgorithmRS256 = "RS256"
+	SigningAlgorithmEdDSA = "EdDSA"
+)
+
+// SigningKey is a key pair that signs access tokens, named in their kid
+// header. A key is published in the JWKS from the moment it is generated, so
+// other services can fetch it before any token is signed with it; it signs
+// once activated, the most recently activated key signing; and it stops
+// verifying when it is retired.
+type SigningKey struct {
+	ID uint `gorm:"primarykey" json:"id"`
+	// KID is the RFC 7638 thumbprint of the public key.
+	KID       string `gorm:"column:kid;uniqueIndex;type:varchar(64);not null" json:"kid"`
+	Algorithm string `gorm:"type:varchar(16);not null" json:"algorithm"`
+	// PublicKey is PEM-encoded PKIX.
+	PublicKey string `gorm:"type:text;not null" json:"public_key"`
+	// PrivateKey is PEM-encoded PKCS #8, sealed with API_KEY_SECRET.
+	PrivateKey  string     `gorm:"type:text;not null" json:"-"`
+	ActivatedAt *time.Time `json:"activated_at,omitempty"`
+	RetiredAt   *time.Time `gorm:"index" json:"retired_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Published reports whether the key is in the JWKS and verifies tokens.
+func (k *SigningKey) Published() bool {
+	return k.RetiredAt == nil
+}
+
+// JWK is a public key in the JSON Web Key format (RFC 7517): an RSA key has
+// n and e, an Ed25519 key crv and x.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// JWKS is the set of public keys that verify access tokens, served at
+// /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// An SLA policy sets the first-response and resolution targets for one ticket
+// priority. A ticket whose priority has no policy carries no deadlines and is
+// left out of SLA reporting.
+//
+// Targets are measured either around the clock or on a business-hours
+// calendar: the policy's working days, between BusinessDayStart and
+// BusinessDayEnd, in Timezone. The calendar belongs to the policy rather than
+// to the deployment so an urgent tier can run 24x7 while the others keep
+// office hours.
+//
+// Policies are configuration, not records: there is no DeletedAt, and deleting
+// one removes the row so the priority can be given a new policy later.
+//
+// The working days are persisted as serialized JSON in a TEXT column with a
+// `gorm:"-"` decoded twin, the same convention the audit and form models use.
+
+// SLATarget names one of the two deadlines a policy sets.
+type SLATarget string
+
+const (
+	SLATargetFirstResponse SLATarget = "first_response"
+	SLATargetResolution    SLATarget = "resolution"
+)
+
+// Calendar defaults applied when a policy asks for business hours without
+// spelling the calendar out.
+const (
+	DefaultSLATimezone         = "UTC"
+	DefaultSLABusinessDayStart = "09:00"
+	DefaultSLABusinessDayEnd   = "17:00"
+)
+
+// DefaultSLABusinessDays is Monday to Friday, as time.Weekday values.
+var DefaultSLABusinessDays = []int{1, 2, 3, 4, 5}
+
+// SLAPolicy is the SLA for one ticket priority.
+type SLAPolicy struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Priority             TicketPriority `gorm:"uniqueIndex;not null;type:varchar(20)" json:"priority"`
+	FirstResponseMinutes int            `gorm:"not null" json:"first_response_minutes"`
+	ResolutionMinutes    int            `gorm:"not null" json:"resolution_minutes"`
+
+	// BusinessHoursOnly stops the clock outside the calendar below. When it is
+	// false the calendar fields are kept but not used.
+	BusinessHoursOnly bool   `gorm:"not null" json:"business_hours_only"`
+	Timezone          string `gorm:"not null;type:varchar(64)" json:"timezone"`
+	BusinessDayStart  string `gorm:"not null;type:varchar(5)" json:"business_day_start"`
+	BusinessDayEnd    string `gorm:"not null;type:varchar(5)" json:"business_day_end"`
+	// BusinessDays are time.Weekday values: 0 is Sunday, 6 is Saturday.
+	BusinessDays     []int  `gorm:"-" json:"business_days"`
+	BusinessDaysJSON string `gorm:"column:business_days;type:text" json:"-"`
+}
+
+// BeforeSave serializes the working days into their TEXT column.
+func (p *SLAPolicy) BeforeSave(tx *gorm.DB) error {
+	days := p.BusinessDays
+	if days == nil {
+		days = []int{}
+	}
+	encoded, err := json.Marshal(days)
+	if err != nil {
+		return fmt.Errorf("sla policy business days: %w", err)
+	}
+	p.BusinessDaysJSON = string(encoded)
+	return nil
+}
+
+// AfterFind restores the working days. A column that cannot be decoded yields
+// no working days, which the calendar reports as a misconfiguration instead of
+// silently falling back to a default.
+func (p *SLAPolicy) AfterFind(tx *gorm.DB) error {
+	p.BusinessDays = []int{}
+	if p.BusinessDaysJSON != "" {
+		if err := json.Unmarshal([]byte(p.BusinessDaysJSON), &p.BusinessDays); err != nil {
+			p.BusinessDays = []int{}
+		}
+	}
+	return nil
+}
+
+// SLABreach is one deadline the breach worker has just flagged as missed.
+type SLABreach struct {
+	Ticket Ticket
+	Target SLATarget
+}
+
+// SLATargetStats counts how one target fared across a group of tickets. A
+// ticket that met its deadline or missed it is decided; one still inside its
+// window is pending. AttainmentRate is the share of decided tickets that met
+// the target, as a percentage, and is null while nothing has been decided.
+type SLATargetStats struct {
+	Met            int64    `json:"met"`
+	Breached       int64    `json:"breached"`
+	Pending        int64    `json:"pending"`
+	AttainmentRate *float64 `json:"attainment_rate"`
+}
+
+// SLAAttainment is one row of an SLA report: a priority, or an assignee, with
+// how its tickets fared against both targets.
+type SLAAttainment struct {
+	Priority      TicketPriority `json:"priority,omitempty"`
+	AssigneeID    *uint          `json:"assignee_id,omitempty"`
+	AssigneeName  string         `json:"assignee_name,omitempty"`
+	Tickets       int64          `json:"tickets"`
+	FirstResponse SLATargetStats `json:"first_response"`
+	Resolution    SLATargetStats `json:"resolution"`
+}
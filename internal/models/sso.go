@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// SSOLoginState is a single sign-on attempt in progress: what the provider's
+// redirect is checked against and what redeeming its code needs. The browser
+// that started the attempt holds a session handle of which only the HMAC is
+// stored; the row is deleted when the attempt completes, and left to expire
+// when it is abandoned.
+type SSOLoginState struct {
+	ID           uint      `gorm:"primarykey"`
+	SessionHash  string    `gorm:"not null;uniqueIndex;type:varchar(64)"`
+	State        string    `gorm:"not null;type:varchar(64)"`
+	Nonce        string    `gorm:"not null;type:varchar(64)"`
+	CodeVerifier string    `gorm:"not null;type:varchar(128)"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreatedAt    time.Time
+}
+
+// SSOStart is what the frontend needs to send the browser to the provider:
+// the URL, and the session handle it must present with the provider's
+// redirect.
+type SSOStart struct {
+	AuthorizationURL string `json:"authorization_url"`
+	Session          string `json:"session"`
+}
@@ -32,4 +32,21 @@ type Task struct {
 	// Labels is the ad-hoc grouping of the task. The join table carries no
 	// payload of its own, so the whole relationship lives in `task_labels`.
 	Labels []Label `gorm:"many2many:task_labels" json:"labels,omitempty"`
-}
\ No newline at end of file
+
+	CustomFields map[string]interface{} `gorm:"-" json:"custom_fields,omitempty"`
+}
+
+// TaskFilter narrows a task list. Search matches the title and description; a
+// zero AssignedToID or LabelID means any.
+type TaskFilter struct {
+	Search       string
+	AssignedToID uint
+	LabelID      uint
+	SortBy       string
+	SortOrder    string
+	CustomFields CustomFieldQuery
+	// Where is the structured filter of the request, nil for none.
+	Where        *FilterExpr
+	// Cursor pages the list by keyset instead of by offset, nil for none.
+	Cursor       *ListCursor
+}
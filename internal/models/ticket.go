@@ -1,5 +1,13 @@
 package models
 
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"gorm.io/gorm"
+)
+
 type TicketStatus string
 type TicketPriority string
 
@@ -26,4 +34,84 @@ type Ticket struct {
 	AssignedToID *uint          `json:"assigned_to_id,omitempty"`
 	AssignedTo   *User          `gorm:"foreignKey:AssignedToID" json:"assigned_to,omitempty"`
 	Resolution   string         `gorm:"type:text" json:"resolution"`
-}
\ No newline at end of file
+	// ReplyToken is the secret half of the ticket's subject token; a reply
+	// threads onto the ticket only when it carries it. See inbound.TicketToken.
+	ReplyToken string `gorm:"type:varchar(64);index" json:"reply_token"`
+
+	// SLA tracking. The due dates come from the SLA policy for the ticket's
+	// priority and are nil when it has none. FirstRespondedAt is the first
+	// public reply by anyone but the customer, or the resolution if that came
+	// first; ResolvedAt is when the ticket last reached resolved or closed.
+	// The breached flags are set by the SLA breach worker.
+	FirstResponseDueAt    *time.Time `gorm:"index" json:"first_response_due_at"`
+	ResolutionDueAt       *time.Time `gorm:"index" json:"resolution_due_at"`
+	FirstRespondedAt      *time.Time `json:"first_responded_at"`
+	ResolvedAt            *time.Time `json:"resolved_at"`
+	FirstResponseBreached bool       `gorm:"not null;default:false" json:"first_response_breached"`
+	ResolutionBreached    bool       `gorm:"not null;default:false" json:"resolution_breached"`
+
+	CustomFields map[string]interface{} `gorm:"-" json:"custom_fields,omitempty"`
+}
+
+// NewReplyToken returns a fresh random reply token.
+func NewReplyToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// BeforeCreate gives every new ticket its reply token, however it is created.
+func (t *Ticket) BeforeCreate(tx *gorm.DB) error {
+	if t.ReplyToken != "" {
+		return nil
+	}
+	token, err := NewReplyToken()
+	if err != nil {
+		return err
+	}
+	t.ReplyToken = token
+	return nil
+}
+
+// IsResolved reports whether the status ends the resolution clock.
+func (s TicketStatus) IsResolved() bool {
+	return s == TicketStatusResolved || s == TicketStatusClosed
+}
+
+// TrackResolution updates the SLA timestamps for a move from previous to the
+// ticket's current status at now. Resolving stamps ResolvedAt, and also the
+// first response if nobody had replied, since a resolution answers the
+// customer. Reopening a resolved ticket clears ResolvedAt so the resolution
+// clock runs again against the original deadline. It reports whether it
+// changed anything.
+func (t *Ticket) TrackResolution(previous TicketStatus, now time.Time) bool {
+	switch {
+	case t.Status.IsResolved() && !previous.IsResolved():
+		if t.ResolvedAt == nil {
+			t.ResolvedAt = &now
+		}
+		if t.FirstRespondedAt == nil {
+			t.FirstRespondedAt = &now
+		}
+		return true
+	case !t.Status.IsResolved() && previous.IsResolved() && t.ResolvedAt != nil:
+		t.ResolvedAt = nil
+		return true
+	}
+	return false
+}
+
+// TicketFilter narrows a ticket list. Search matches the title and
+// description.
+type TicketFilter struct {
+	Search       string
+	SortBy       string
+	SortOrder    string
+	CustomFields CustomFieldQuery
+	// Where is the structured filter of the request, nil for none.
+	Where        *FilterExpr
+	// Cursor pages the list by keyset instead of by offset, nil for none.
+	Cursor       *ListCursor
+}
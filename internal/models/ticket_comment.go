@@ -0,0 +1,53 @@
+package models
+
+// A ticket's conversation thread: the comments exchanged with the customer,
+// the internal notes the support team keeps alongside them, and an entry for
+// every status change, in the order they happened.
+
+// TicketCommentKind says whether an entry was written by someone in the app,
+// received from the customer by email, or recorded by the ticket service when
+// the ticket moved between statuses.
+type TicketCommentKind string
+
+const (
+	TicketCommentKindComment      TicketCommentKind = "comment"
+	TicketCommentKindEmail        TicketCommentKind = "email"
+	TicketCommentKindStatusChange TicketCommentKind = "status_change"
+)
+
+// TicketCommentVisibility decides who may read an entry. Customers see only
+// the public entries on their own tickets; staff see everything.
+type TicketCommentVisibility string
+
+const (
+	TicketCommentPublic   TicketCommentVisibility = "public"
+	TicketCommentInternal TicketCommentVisibility = "internal"
+)
+
+// MaxTicketCommentLength caps a comment body. It is generous for a message and
+// still keeps a single request from writing megabytes into the thread.
+const MaxTicketCommentLength = 10000
+
+type TicketComment struct {
+	BaseModel
+	TicketID   uint                    `gorm:"not null;index" json:"ticket_id"`
+	Kind       TicketCommentKind       `gorm:"not null;default:'comment';type:varchar(20)" json:"kind"`
+	Visibility TicketCommentVisibility `gorm:"not null;default:'public';type:varchar(20)" json:"visibility"`
+	Body       string                  `gorm:"type:text" json:"body"`
+
+	// AuthorID is nil on status-change entries: they are recorded by the ticket
+	// service, which does not know the caller. The audit trail attributes the
+	// update itself. On email entries it is the customer's login, and nil when
+	// the customer has none.
+	AuthorID *uint `gorm:"index" json:"author_id,omitempty"`
+	Author   *User `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
+
+	// FromStatus and ToStatus are set on status-change entries only.
+	FromStatus TicketStatus `gorm:"type:varchar(20)" json:"from_status,omitempty"`
+	ToStatus   TicketStatus `gorm:"type:varchar(20)" json:"to_status,omitempty"`
+}
+
+// IsValid reports whether v is one of the known visibilities.
+func (v TicketCommentVisibility) IsValid() bool {
+	return v == TicketCommentPublic || v == TicketCommentInternal
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// TwoFactorRecoveryCode is one of the single-use codes that stand in for a
+// TOTP code when the authenticator is lost. Only the HMAC of the code is
+// stored, like the session tokens; the codes themselves are shown once, when
+// they are generated. A spent code keeps its row with UsedAt set, and a new
+// set replaces every row of the user.
+type TwoFactorRecoveryCode struct {
+	ID        uint       `gorm:"primarykey" json:"-"`
+	UserID    uint       `gorm:"not null;index" json:"-"`
+	CodeHash  string     `gorm:"not null;uniqueIndex;type:varchar(64)" json:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TwoFactorStatus is what a user can see of their own two-factor setup.
+type TwoFactorStatus struct {
+	Enabled bool `json:"enabled"`
+	// Required is set when the user's role must use two-factor
+	// authentication; such a user cannot turn it off.
+	Required bool `json:"required"`
+	// RecoveryCodesRemaining counts the unspent recovery codes.
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorSetup is a pending TOTP enrollment: the secret, and the otpauth URI
+// an authenticator app enrolls it from. It takes effect once a code it
+// generates is verified.
+type TwoFactorSetup struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
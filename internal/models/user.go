@@ -26,12 +26,36 @@ type User struct {
 	LastLoginAt         *time.Time `json:"last_login_at,omitempty"`
 	FailedLoginAttempts int        `gorm:"default:0" json:"-"`
 	LockedUntil         *time.Time `json:"locked_until,omitempty"`
+	// TwoFactorEnabled is set once a TOTP secret has been confirmed with a
+	// code. TOTPSecret is sealed with utils.SecretBox; it is stored before
+	// that confirmation too, as the pending secret of an enrollment.
+	// TOTPLastStep is the time step of the last code accepted, so that no
+	// code is accepted twice.
+	TwoFactorEnabled bool   `gorm:"default:false" json:"two_factor_enabled"`
+	TOTPSecret       string `gorm:"column:totp_secret;type:text" json:"-"`
+	TOTPLastStep     int64  `gorm:"column:totp_last_step;default:0" json:"-"`
+	// SSOIssuer and SSOSubject link the user to an account at the single
+	// sign-on provider: the issuer URL and the provider's stable subject ID.
+	// Both are empty for a user who has never signed in through it.
+	SSOIssuer  string `gorm:"column:sso_issuer;type:varchar(255);index:idx_users_sso" json:"-"`
+	SSOSubject string `gorm:"column:sso_subject;type:varchar(255);index:idx_users_sso" json:"-"`
 	
 	Leads     []Lead     `gorm:"foreignKey:OwnerID" json:"-"`
 	Tasks     []Task     `gorm:"foreignKey:AssignedToID" json:"-"`
 	APIKeys   []APIKey   `gorm:"foreignKey:UserID" json:"-"`
 }
 
+// UserFilter narrows a user list. Search matches the email and name.
+type UserFilter struct {
+	Search    string
+	SortBy    string
+	SortOrder string
+	// Where is the structured filter of the request, nil for none.
+	Where *FilterExpr
+	// Cursor pages the list by keyset instead of by offset, nil for none.
+	Cursor *ListCursor
+}
+
 func (u *User) FullName() string {
 	return u.FirstName + " " + u.LastName
 }
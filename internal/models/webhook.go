@@ -0,0 +1,166 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookEvent names a change a webhook subscription can ask to be told about.
+type WebhookEvent string
+
+const (
+	WebhookLeadCreated   WebhookEvent = "lead.created"
+	WebhookLeadUpdated   WebhookEvent = "lead.updated"
+	WebhookLeadConverted WebhookEvent = "lead.converted"
+	WebhookLeadDeleted   WebhookEvent = "lead.deleted"
+
+	WebhookCustomerCreated WebhookEvent = "customer.created"
+	WebhookCustomerUpdated WebhookEvent = "customer.updated"
+	WebhookCustomerDeleted WebhookEvent = "customer.deleted"
+
+	WebhookTicketCreated WebhookEvent = "ticket.created"
+	WebhookTicketUpdated WebhookEvent = "ticket.updated"
+	// WebhookTicketResolved fires, next to ticket.updated, when an update
+	// moves a ticket to resolved or closed.
+	WebhookTicketResolved WebhookEvent = "ticket.resolved"
+	WebhookTicketDeleted  WebhookEvent = "ticket.deleted"
+
+	// WebhookFormSubmissionReceived fires for a submission that needs no
+	// confirmation; WebhookFormSubmissionConfirmed for a double-opt-in one
+	// once its address is confirmed. Spam and pending submissions never fire.
+	WebhookFormSubmissionReceived  WebhookEvent = "form.submission.received"
+	WebhookFormSubmissionConfirmed WebhookEvent = "form.submission.confirmed"
+)
+
+// WebhookEvents is the catalogue of events, in the order the API lists them.
+var WebhookEvents = []WebhookEvent{
+	WebhookLeadCreated, WebhookLeadUpdated, WebhookLeadConverted, WebhookLeadDeleted,
+	WebhookCustomerCreated, WebhookCustomerUpdated, WebhookCustomerDeleted,
+	WebhookTicketCreated, WebhookTicketUpdated, WebhookTicketResolved, WebhookTicketDeleted,
+	WebhookFormSubmissionReceived, WebhookFormSubmissionConfirmed,
+}
+
+// IsValid reports whether e is in the catalogue.
+func (e WebhookEvent) IsValid() bool {
+	for _, known := range WebhookEvents {
+		if e == known {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEntityFormSubmission is the entity type of the form submission
+// events. The other events use the audit trail's entity types.
+const WebhookEntityFormSubmission = "form_submission"
+
+// WebhookSubscription is an endpoint that receives the events it lists.
+//
+// The signing secret is stored sealed (utils.SecretBox) and never serialised:
+// it is shown once, when the subscription is created or the secret rotated.
+type WebhookSubscription struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Name string `gorm:"not null;type:varchar(100)" json:"name"`
+	URL  string `gorm:"not null;type:varchar(2048)" json:"url"`
+	// Active pauses the subscription when false: no deliveries are queued for
+	// it, and those already queued wait until it is active again.
+	Active bool `gorm:"not null" json:"active"`
+
+	Events     []WebhookEvent `gorm:"-" json:"events"`
+	EventsJSON string         `gorm:"column:events;type:text" json:"-"`
+
+	SecretSealed string `gorm:"column:secret;not null;type:varchar(255)" json:"-"`
+	CreatedByID  uint   `json:"created_by_id"`
+}
+
+// BeforeSave serializes the event list into its TEXT column.
+func (s *WebhookSubscription) BeforeSave(tx *gorm.DB) error {
+	events := s.Events
+	if events == nil {
+		events = []WebhookEvent{}
+	}
+	encoded, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("webhook subscription events: %w", err)
+	}
+	s.EventsJSON = string(encoded)
+	return nil
+}
+
+// AfterFind restores the event list. A column that cannot be decoded yields
+// no events, so a damaged row receives nothing rather than everything.
+func (s *WebhookSubscription) AfterFind(tx *gorm.DB) error {
+	s.Events = []WebhookEvent{}
+	if s.EventsJSON != "" {
+		if err := json.Unmarshal([]byte(s.EventsJSON), &s.Events); err != nil {
+			s.Events = []WebhookEvent{}
+		}
+	}
+	return nil
+}
+
+// Wants reports whether the subscription lists event.
+func (s *WebhookSubscription) Wants(event WebhookEvent) bool {
+	for _, wanted := range s.Events {
+		if wanted == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus is where a delivery stands in the queue.
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryPending: queued, or waiting for its next attempt.
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliveryDelivered: the endpoint answered with a 2xx status.
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	// WebhookDeliveryFailed: dead-lettered after its last attempt failed. It
+	// stays until an admin redelivers or the subscription is deleted.
+	WebhookDeliveryFailed WebhookDeliveryStatus = "failed"
+)
+
+// IsValid reports whether s is one of the known statuses.
+func (s WebhookDeliveryStatus) IsValid() bool {
+	switch s {
+	case WebhookDeliveryPending, WebhookDeliveryDelivered, WebhookDeliveryFailed:
+		return true
+	}
+	return false
+}
+
+// WebhookDelivery is one event queued for one subscription. The payload is
+// rendered when the event happens, so a retry sends exactly what the first
+// attempt did, with the same event id for the receiver to deduplicate on.
+type WebhookDelivery struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	SubscriptionID uint         `gorm:"not null;index" json:"subscription_id"`
+	EventID        string       `gorm:"not null;type:varchar(36);index" json:"event_id"`
+	Event          WebhookEvent `gorm:"not null;type:varchar(50)" json:"event"`
+	// EntityType and EntityID name the record the event is about, so erasing
+	// that record can find its deliveries.
+	EntityType string `gorm:"not null;type:varchar(50);index:idx_webhook_delivery_entity" json:"entity_type"`
+	EntityID   uint   `gorm:"not null;index:idx_webhook_delivery_entity" json:"entity_id"`
+	Payload    string `gorm:"not null;type:text" json:"payload"`
+
+	Status        WebhookDeliveryStatus `gorm:"not null;type:varchar(20);index" json:"status"`
+	Attempts      int                   `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time             `gorm:"not null;index" json:"next_attempt_at"`
+	LastAttemptAt *time.Time            `json:"last_attempt_at,omitempty"`
+	// ResponseStatus is the HTTP status of the last attempt; 0 when it got no
+	// response at all.
+	ResponseStatus int        `json:"response_status"`
+	LastError      string     `gorm:"type:varchar(500)" json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
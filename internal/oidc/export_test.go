@@ -0,0 +1,9 @@
+package oidc
+
+import "time"
+
+// SetClock replaces the provider's clock, so tests can move past the key
+// refresh interval.
+func SetClock(p *Provider, now func() time.Time) {
+	p.now = now
+}
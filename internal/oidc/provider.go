@@ -0,0 +1,354 @@
+// Package oidc is a minimal OpenID Connect relying party: it reads the
+// provider's discovery document and signing keys, builds the authorization
+// request of the authorization-code flow with PKCE, redeems the code and
+// verifies the ID token that comes back.
+package oidc
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// requestTimeout bounds every call to the provider. A sign-in waits on these
+// calls, so a provider that hangs must not hold the request open.
+const requestTimeout = 10 * time.Second
+
+// maxResponseBytes caps how much of a provider response is read; discovery
+// documents, key sets and token responses are a few kilobytes.
+const maxResponseBytes = 1 << 20
+
+// clockSkew is how far the provider's clock may be off from ours when the
+// token's time claims are checked.
+const clockSkew = time.Minute
+
+// keyRefreshInterval limits how often an unknown key ID makes the key set be
+// fetched again, so tokens with made-up key IDs cannot hammer the provider.
+const keyRefreshInterval = time.Minute
+
+// signingMethods are the ID token algorithms accepted. "none" and the HMAC
+// family are deliberately absent: an ID token must be signed with one of the
+// provider's published keys.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+var (
+	// ErrProviderUnavailable means the provider could not be reached or
+	// answered with something unusable.
+	ErrProviderUnavailable = errors.New("identity provider unavailable")
+	// ErrExchangeFailed means the provider refused to redeem the code.
+	ErrExchangeFailed = errors.New("authorization code was not accepted")
+	// ErrInvalidIDToken means the ID token failed verification.
+	ErrInvalidIDToken = errors.New("invalid ID token")
+)
+
+// Config identifies this application to the provider.
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// Scopes are requested in addition to "openid".
+	Scopes []string
+}
+
+// IDToken is a verified ID token.
+type IDToken struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+	Name          string
+	// Claims holds every claim of the token, for lookups such as the role
+	// claim that have no field of their own.
+	Claims map[string]interface{}
+}
+
+// Values returns the string values of a claim, which may hold a single
+// string or a list. Anything else yields nothing.
+func (t *IDToken) Values(claim string) []string {
+	switch v := t.Claims[claim].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+// Provider talks to one OpenID Connect provider. The discovery document and
+// the key set are fetched on first use and cached, so the application starts
+// even while the provider is down. It is safe for concurrent use.
+type Provider struct {
+	config Config
+	client *http.Client
+	now    func() time.Time
+
+	mu            sync.Mutex
+	metadata      *metadata
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+// Option customises a provider at construction time.
+type Option func(*Provider)
+
+// WithHTTPClient overrides the HTTP client, including its timeout.
+func WithHTTPClient(client *http.Client) Option {
+	return func(p *Provider) {
+		if client != nil {
+			p.client = client
+		}
+	}
+}
+
+// NewProvider returns a provider for the given configuration.
+func NewProvider(config Config, opts ...Option) *Provider {
+	config.Issuer = strings.TrimRight(config.Issuer, "/")
+	p := &Provider{
+		config: config,
+		client: &http.Client{Timeout: requestTimeout},
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// metadata is the part of the discovery document this package uses.
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// discover returns the cached discovery document, fetching it when there is
+// none yet. A failed fetch is not cached, so the next sign-in tries again.
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	var doc metadata
+	if err := p.getJSON(ctx, p.config.Issuer+"/.well-known/openid-configuration", &doc); err != nil {
+		return nil, err
+	}
+	// The issuer in the document must be the one configured, or tokens from
+	// another tenant of the same provider could be accepted.
+	if strings.TrimRight(doc.Issuer, "/") != p.config.Issuer {
+		return nil, fmt.Errorf("%w: discovery document names issuer %q", ErrProviderUnavailable, doc.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: discovery document is missing endpoints", ErrProviderUnavailable)
+	}
+	p.metadata = &doc
+	return p.metadata, nil
+}
+
+// AuthCodeURL returns the provider URL the browser is sent to. state and
+// nonce are echoed back in the redirect and the ID token; challenge is the
+// PKCE S256 challenge of the verifier later passed to Exchange.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, challenge string) (string, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	scopes := []string{"openid"}
+	for _, scope := range p.config.Scopes {
+		if scope != "openid" {
+			scopes = append(scopes, scope)
+		}
+	}
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientID)
+	query.Set("redirect_uri", p.config.RedirectURL)
+	query.Set("scope", strings.Join(scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", challenge)
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(doc.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return doc.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange redeems an authorization code with its PKCE verifier and returns
+// the verified ID token, which must carry nonce.
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*IDToken, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("code_verifier", verifier)
+	form.Set("client_id", p.config.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, doc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var payload struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	decodeErr := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&payload)
+	switch {
+	case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized:
+		// The token endpoint answers an invalid code, verifier or client
+		// with 400/401 and an OAuth error.
+		return nil, fmt.Errorf("%w: %s %s", ErrExchangeFailed, payload.Error, payload.ErrorDescription)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%w: token endpoint returned status %d", ErrProviderUnavailable, resp.StatusCode)
+	case decodeErr != nil:
+		return nil, fmt.Errorf("%w: decode token response: %v", ErrProviderUnavailable, decodeErr)
+	case payload.IDToken == "":
+		return nil, fmt.Errorf("%w: token response has no id_token", ErrExchangeFailed)
+	}
+
+	return p.Verify(ctx, payload.IDToken, nonce)
+}
+
+// idTokenClaims are the registered claims plus the OpenID ones used here.
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce           string `json:"nonce"`
+	AuthorizedParty string `json:"azp"`
+}
+
+// Verify checks an ID token's signature, issuer, audience, lifetime and
+// nonce, and returns its claims.
+func (p *Provider) Verify(ctx context.Context, raw, nonce string) (*IDToken, error) {
+	if _, err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	var claims idTokenClaims
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(p.config.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+		jwt.WithTimeFunc(p.now),
+	)
+	var keyErr error
+	_, err := parser.ParseWithClaims(raw, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := p.key(ctx, kid)
+		keyErr = err
+		return key, err
+	})
+	if err != nil {
+		if errors.Is(keyErr, ErrProviderUnavailable) {
+			return nil, keyErr
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: no subject", ErrInvalidIDToken)
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 || nonce == "" {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.config.ClientID {
+		return nil, fmt.Errorf("%w: token was issued to %q", ErrInvalidIDToken, claims.AuthorizedParty)
+	}
+
+	// The registered claims are checked above; the rest are read from a
+	// plain map so callers can look up any claim by name.
+	all, err := payloadClaims(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	token := &IDToken{Subject: claims.Subject, Claims: all}
+	token.Email, _ = all["email"].(string)
+	token.GivenName, _ = all["given_name"].(string)
+	token.FamilyName, _ = all["family_name"].(string)
+	token.Name, _ = all["name"].(string)
+	switch verified := all["email_verified"].(type) {
+	case bool:
+		token.EmailVerified = verified
+	case string:
+		// Some providers send the flag as a string.
+		token.EmailVerified = verified == "true"
+	}
+	return token, nil
+}
+
+// payloadClaims decodes the payload of a token whose signature has already
+// been verified.
+func payloadClaims(raw string) (map[string]interface{}, error) {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(raw, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// getJSON fetches a provider document.
+func (p *Provider) getJSON(ctx context.Context, target string, into interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s returned status %d", ErrProviderUnavailable, target, resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(into); err != nil {
+		return fmt.Errorf("%w: decode %s: %v", ErrProviderUnavailable, target, err)
+	}
+	return nil
+}
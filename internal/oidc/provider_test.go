@@ -0,0 +1,194 @@
+package oidc_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/oidc"
+	"github.com/florinel-chis/gophercrm/internal/oidc/oidctest"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const redirectURL = "http://localhost:5173/auth/sso/callback"
+
+// signIn runs the authorization-code flow against the mock provider and
+// returns what Exchange makes of it.
+func signIn(t *testing.T, idp *oidctest.Server, provider *oidc.Provider, identity oidctest.Identity) (*oidc.IDToken, error) {
+	t.Helper()
+	verifier, err := oidc.RandomString()
+	require.NoError(t, err)
+	authURL, err := provider.AuthCodeURL(context.Background(), "state-1", "nonce-1", oidc.CodeChallenge(verifier))
+	require.NoError(t, err)
+	code, state, err := idp.Authorize(authURL, identity)
+	require.NoError(t, err)
+	assert.Equal(t, "state-1", state)
+	return provider.Exchange(context.Background(), code, verifier, "nonce-1")
+}
+
+func TestProvider_AuthorizationCodeFlow(t *testing.T) {
+	idp := oidctest.NewServer("crm", "s3cret")
+	defer idp.Close()
+	provider := oidc.NewProvider(idp.Config(redirectURL))
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "st", "nc", "challenge")
+	require.NoError(t, err)
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "openid email profile", u.Query().Get("scope"))
+	assert.Equal(t, redirectURL, u.Query().Get("redirect_uri"))
+	assert.Equal(t, "S256", u.Query().Get("code_challenge_method"))
+
+	token, err := signIn(t, idp, provider, oidctest.Identity{
+		Subject: "u-1", Email: "ada@example.com", EmailVerified: true, GivenName: "Ada", FamilyName: "Lovelace",
+		Claims: map[string]interface{}{"groups": []string{"crm-admins", "staff"}, "department": "eng"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "u-1", token.Subject)
+	assert.Equal(t, "ada@example.com", token.Email)
+	assert.True(t, token.EmailVerified)
+	assert.Equal(t, "Ada", token.GivenName)
+	assert.Equal(t, []string{"crm-admins", "staff"}, token.Values("groups"))
+	assert.Equal(t, []string{"eng"}, token.Values("department"))
+	assert.Empty(t, token.Values("missing"))
+}
+
+func TestProvider_ExchangeRejectsWrongVerifier(t *testing.T) {
+	idp := oidctest.NewServer("crm", "s3cret")
+	defer idp.Close()
+	provider := oidc.NewProvider(idp.Config(redirectURL))
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "st", "nc", oidc.CodeChallenge("the-real-verifier"))
+	require.NoError(t, err)
+	code, _, err := idp.Authorize(authURL, oidctest.Identity{Subject: "u-1"})
+	require.NoError(t, err)
+
+	_, err = provider.Exchange(context.Background(), code, "another-verifier", "nc")
+	assert.ErrorIs(t, err, oidc.ErrExchangeFailed)
+}
+
+func TestProvider_ExchangeRejectsWrongClientSecret(t *testing.T) {
+	idp := oidctest.NewServer("crm", "s3cret")
+	defer idp.Close()
+	config := idp.Config(redirectURL)
+	config.ClientSecret = "wrong"
+	provider := oidc.NewProvider(config)
+
+	_, err := signIn(t, idp, provider, oidctest.Identity{Subject: "u-1"})
+	assert.ErrorIs(t, err, oidc.ErrExchangeFailed)
+}
+
+func TestProvider_VerifyChecksClaims(t *testing.T) {
+	idp := oidctest.NewServer("crm", "s3cret")
+	defer idp.Close()
+	provider := oidc.NewProvider(idp.Config(redirectURL))
+	now := time.Now()
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{"iss": idp.URL, "aud": "crm", "sub": "u-1", "nonce": "n",
+			"iat": now.Unix(), "exp": now.Add(time.Minute).Unix()}
+	}
+
+	_, err := provider.Verify(context.Background(), idp.SignIDToken(valid()), "n")
+	require.NoError(t, err)
+
+	cases := map[string]func(jwt.MapClaims){
+		"wrong issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"wrong audience": func(c jwt.MapClaims) { c["aud"] = "another-client" },
+		"expired":        func(c jwt.MapClaims) { c["exp"] = now.Add(-time.Hour).Unix() },
+		"no expiry":      func(c jwt.MapClaims) { delete(c, "exp") },
+		"no subject":     func(c jwt.MapClaims) { delete(c, "sub") },
+		"wrong nonce":    func(c jwt.MapClaims) { c["nonce"] = "other" },
+		"foreign azp":    func(c jwt.MapClaims) { c["aud"] = []string{"crm", "other"}; c["azp"] = "other" },
+	}
+	for name, mutate := range cases {
+		claims := valid()
+		mutate(claims)
+		_, err := provider.Verify(context.Background(), idp.SignIDToken(claims), "n")
+		assert.ErrorIs(t, err, oidc.ErrInvalidIDToken, name)
+	}
+}
+
+func TestProvider_VerifyRejectsUnsignedAndHMACTokens(t *testing.T) {
+	idp := oidctest.NewServer("crm", "s3cret")
+	defer idp.Close()
+	provider := oidc.NewProvider(idp.Config(redirectURL))
+	claims := jwt.MapClaims{"iss": idp.URL, "aud": "crm", "sub": "u-1", "nonce": "n",
+		"exp": time.Now().Add(time.Minute).Unix()}
+
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	_, err = provider.Verify(context.Background(), none, "n")
+	assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+
+	// An HMAC token keyed with the client secret is not an ID token this
+	// relying party accepts.
+	hmac, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("s3cret"))
+	require.NoError(t, err)
+	_, err = provider.Verify(context.Background(), hmac, "n")
+	assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+}
+
+func TestProvider_FollowsKeyRotation(t *testing.T) {
+	idp := oidctest.NewServer("crm", "s3cret")
+	defer idp.Close()
+	provider := oidc.NewProvider(idp.Config(redirectURL))
+	now := time.Now()
+	oidc.SetClock(provider, func() time.Time { return now })
+	identity := oidctest.Identity{Subject: "u-1"}
+
+	_, err := signIn(t, idp, provider, identity)
+	require.NoError(t, err)
+
+	// Unknown key IDs refetch the set at most once per interval...
+	idp.RotateKey()
+	_, err = signIn(t, idp, provider, identity)
+	assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+
+	// ...after which the new key is picked up.
+	now = now.Add(2 * time.Minute)
+	_, err = signIn(t, idp, provider, identity)
+	assert.NoError(t, err)
+}
+
+func TestProvider_UnreachableProvider(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	provider := oidc.NewProvider(oidc.Config{Issuer: server.URL, ClientID: "crm"})
+
+	_, err := provider.AuthCodeURL(context.Background(), "s", "n", "c")
+	assert.ErrorIs(t, err, oidc.ErrProviderUnavailable)
+}
+
+func TestProvider_DiscoveryIssuerMustMatch(t *testing.T) {
+	idp := oidctest.NewServer("crm", "s3cret")
+	defer idp.Close()
+	config := idp.Config(redirectURL)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Serve the real provider's document under another issuer path.
+		http.Redirect(w, r, idp.URL+"/.well-known/openid-configuration", http.StatusFound)
+	}))
+	defer server.Close()
+	config.Issuer = server.URL
+
+	_, err := oidc.NewProvider(config).AuthCodeURL(context.Background(), "s", "n", "c")
+	assert.ErrorIs(t, err, oidc.ErrProviderUnavailable)
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// BASE64URL(SHA256(verifier)), without padding.
+	assert.Equal(t, "e8FyxagPuFsoKuiC4u6ZYUdYNAxrDKd_2MXQWqAWYX0",
+		oidc.CodeChallenge("dBjftJeZ4CVP-mJ0kXrMFbQBqOCT2-EoFh6Xa4L-2Rk"))
+
+	a, err := oidc.RandomString()
+	require.NoError(t, err)
+	b, err := oidc.RandomString()
+	require.NoError(t, err)
+	assert.Len(t, a, 43)
+	assert.NotEqual(t, a, b)
+}
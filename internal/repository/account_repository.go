@@ -0,0 +1,339 @@
+package repository
+
+import (
+	"strings"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type accountRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountRepository(db *gorm.DB) AccountRepository {
+	return &accountRepository{db: db}
+}
+
+func (r *accountRepository) WithTx(tx *gorm.DB) AccountRepository {
+	return &accountRepository{db: tx}
+}
+
+func (r *accountRepository) Create(account *models.Account) error {
+	return r.db.Omit(clause.Associations).Create(account).Error
+}
+
+func (r *accountRepository) GetByID(id uint) (*models.Account, error) {
+	var account models.Account
+	if err := r.db.Preload("Owner").First(&account, id).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *accountRepository) GetByNameKey(key string) (*models.Account, error) {
+	var account models.Account
+	if err := r.db.Where("name_key = ?", key).Order("id ASC").First(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *accountRepository) Update(account *models.Account) error {
+	return r.db.Omit(clause.Associations).Save(account).Error
+}
+
+// Delete leaves the contacts' company text alone, so unlinking loses nothing
+// they said about themselves.
+func (r *accountRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&models.Lead{}, &models.Customer{}} {
+			if err := tx.Model(model).Where("account_id = ?", id).Update("account_id", nil).Error; err != nil {
+				return err
+			}
+		}
+		result := tx.Delete(&models.Account{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+func (r *accountRepository) List(filter models.AccountFilter, offset, limit int) ([]models.Account, int64, error) {
+	query := r.db.Model(&models.Account{})
+	if filter.Search != "" {
+		pattern := "%" + filter.Search + "%"
+		query = query.Where("name LIKE ? OR domain LIKE ? OR industry LIKE ?", pattern, pattern, pattern)
+	}
+	if filter.OwnerID != 0 {
+		query = query.Where("owner_id = ?", filter.OwnerID)
+	}
+	if filter.Industry != "" {
+		query = query.Where("industry = ?", filter.Industry)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Cursor != nil {
+		var err error
+		if query, err = seekCursor(query, "accounts", filter.Cursor); err != nil {
+			return nil, 0, err
+		}
+	} else {
+		query = query.Order("name ASC, id ASC")
+	}
+	accounts := []models.Account{}
+	err := query.Preload("Owner").Offset(offset).Limit(limit).Find(&accounts).Error
+	return accounts, total, err
+}
+
+// accountLeadIDs and accountCustomerIDs are the subqueries every rollup is
+// built on. Both carry gorm's soft-delete scope.
+func (r *accountRepository) accountLeadIDs(accountID uint) *gorm.DB {
+	return r.db.Model(&models.Lead{}).Select("id").Where("account_id = ?", accountID)
+}
+
+func (r *accountRepository) accountCustomerIDs(accountID uint) *gorm.DB {
+	return r.db.Model(&models.Customer{}).Select("id").Where("account_id = ?", accountID)
+}
+
+func (r *accountRepository) leadsQuery(accountID, ownerID uint) *gorm.DB {
+	query := r.db.Model(&models.Lead{}).Where("account_id = ?", accountID)
+	if ownerID != 0 {
+		query = query.Where("owner_id = ?", ownerID)
+	}
+	return query
+}
+
+func (r *accountRepository) ticketsQuery(accountID uint) *gorm.DB {
+	return r.db.Model(&models.Ticket{}).Where("customer_id IN (?)", r.accountCustomerIDs(accountID))
+}
+
+func (r *accountRepository) tasksQuery(accountID, assigneeID uint) *gorm.DB {
+	query := r.db.Model(&models.Task{}).Where(
+		r.db.Where("lead_id IN (?)", r.accountLeadIDs(accountID)).
+			Or("customer_id IN (?)", r.accountCustomerIDs(accountID)))
+	if assigneeID != 0 {
+		query = query.Where("assigned_to_id = ?", assigneeID)
+	}
+	return query
+}
+
+func (r *accountRepository) dealsQuery(accountID, ownerID uint) *gorm.DB {
+	query := r.db.Model(&models.Deal{}).Where("customer_id IN (?)", r.accountCustomerIDs(accountID))
+	if ownerID != 0 {
+		query = query.Where("owner_id = ?", ownerID)
+	}
+	return query
+}
+
+func (r *accountRepository) ListLeads(accountID, ownerID uint, offset, limit int) ([]models.Lead, int64, error) {
+	query := r.leadsQuery(accountID, ownerID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	leads := []models.Lead{}
+	err := query.Preload("Owner").Order("id DESC").Offset(offset).Limit(limit).Find(&leads).Error
+	return leads, total, err
+}
+
+func (r *accountRepository) ListCustomers(accountID uint, offset, limit int) ([]models.Customer, int64, error) {
+	query := r.db.Model(&models.Customer{}).Where("account_id = ?", accountID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	customers := []models.Customer{}
+	err := query.Preload("AssignedTo").Order("id DESC").Offset(offset).Limit(limit).Find(&customers).Error
+	return customers, total, err
+}
+
+func (r *accountRepository) ListTickets(accountID uint, offset, limit int) ([]models.Ticket, int64, error) {
+	query := r.ticketsQuery(accountID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	tickets := []models.Ticket{}
+	err := query.Preload("Customer").Preload("AssignedTo").Order("id DESC").Offset(offset).Limit(limit).Find(&tickets).Error
+	return tickets, total, err
+}
+
+func (r *accountRepository) ListTasks(accountID, assigneeID uint, offset, limit int) ([]models.Task, int64, error) {
+	query := r.tasksQuery(accountID, assigneeID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	tasks := []models.Task{}
+	err := query.Preload("AssignedTo").Preload("Lead").Preload("Customer").
+		Order("id DESC").Offset(offset).Limit(limit).Find(&tasks).Error
+	return tasks, total, err
+}
+
+func (r *accountRepository) ListDeals(accountID, ownerID uint, offset, limit int) ([]models.Deal, int64, error) {
+	query := r.dealsQuery(accountID, ownerID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	deals := []models.Deal{}
+	err := query.Preload("Customer").Preload("Owner").Preload("Stage").
+		Order("id DESC").Offset(offset).Limit(limit).Find(&deals).Error
+	return deals, total, err
+}
+
+func (r *accountRepository) AllDeals(accountID, ownerID uint) ([]models.Deal, error) {
+	deals := []models.Deal{}
+	err := r.dealsQuery(accountID, ownerID).Preload("Stage").Order("id ASC").Find(&deals).Error
+	return deals, err
+}
+
+func (r *accountRepository) Summarize(accountID uint, scope models.AccountScope) (*models.AccountSummary, error) {
+	summary := &models.AccountSummary{AccountID: accountID}
+	openTickets := []models.TicketStatus{models.TicketStatusOpen, models.TicketStatusInProgress}
+	openTasks := []models.TaskStatus{models.TaskStatusPending, models.TaskStatusInProgress}
+
+	type rollupCount struct {
+		query  *gorm.DB
+		target *int64
+	}
+	counts := []rollupCount{
+		{r.db.Model(&models.Customer{}).Where("account_id = ?", accountID), &summary.Customers},
+		{r.ticketsQuery(accountID), &summary.Tickets},
+		{r.ticketsQuery(accountID).Where("status IN ?", openTickets), &summary.OpenTickets},
+		{r.tasksQuery(accountID, scope.TaskAssigneeID), &summary.Tasks},
+		{r.tasksQuery(accountID, scope.TaskAssigneeID).Where("status IN ?", openTasks), &summary.OpenTasks},
+	}
+	if !scope.SkipLeads {
+		summary.Leads = new(int64)
+		counts = append(counts, rollupCount{r.leadsQuery(accountID, scope.LeadOwnerID), summary.Leads})
+	}
+	for _, count := range counts {
+		if err := count.query.Count(count.target).Error; err != nil {
+			return nil, err
+		}
+	}
+	return summary, nil
+}
+
+// companyContact is a lead or customer waiting to be linked by its company.
+type companyContact struct {
+	ID      uint
+	Company string
+}
+
+// companyCluster gathers the contacts whose company text reduces to one key,
+// and counts each spelling so the account can take the most common one.
+type companyCluster struct {
+	leadIDs     []uint
+	customerIDs []uint
+	spellings   map[string]int
+	firstSeen   []string
+}
+
+func (c *companyCluster) add(company string) {
+	if c.spellings[company] == 0 {
+		c.firstSeen = append(c.firstSeen, company)
+	}
+	c.spellings[company]++
+}
+
+// name is the most common spelling, the earliest one on a tie.
+func (c *companyCluster) name() string {
+	best := c.firstSeen[0]
+	for _, spelling := range c.firstSeen[1:] {
+		if c.spellings[spelling] > c.spellings[best] {
+			best = spelling
+		}
+	}
+	return best
+}
+
+func (r *accountRepository) BackfillFromCompanies() (*models.AccountBackfill, error) {
+	result := &models.AccountBackfill{}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Unscoped().Model(&models.Account{}).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return nil
+		}
+
+		var leads, customers []companyContact
+		if err := tx.Model(&models.Lead{}).Select("id, company").
+			Where("account_id IS NULL AND company <> ''").Order("id ASC").Find(&leads).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Customer{}).Select("id, company").
+			Where("account_id IS NULL AND company <> ''").Order("id ASC").Find(&customers).Error; err != nil {
+			return err
+		}
+
+		clusters := map[string]*companyCluster{}
+		var keys []string
+		cluster := func(company string) *companyCluster {
+			company = strings.Join(strings.Fields(company), " ")
+			key := models.AccountNameKey(company)
+			if key == "" {
+				return nil
+			}
+			c, ok := clusters[key]
+			if !ok {
+				c = &companyCluster{spellings: map[string]int{}}
+				clusters[key] = c
+				keys = append(keys, key)
+			}
+			c.add(company)
+			return c
+		}
+		for _, lead := range leads {
+			if c := cluster(lead.Company); c != nil {
+				c.leadIDs = append(c.leadIDs, lead.ID)
+			}
+		}
+		for _, customer := range customers {
+			if c := cluster(customer.Company); c != nil {
+				c.customerIDs = append(c.customerIDs, customer.ID)
+			}
+		}
+
+		for _, key := range keys {
+			c := clusters[key]
+			account := &models.Account{Name: c.name(), NameKey: key}
+			if err := tx.Create(account).Error; err != nil {
+				return err
+			}
+			result.AccountsCreated++
+			if len(c.leadIDs) > 0 {
+				if err := tx.Model(&models.Lead{}).Where("id IN ?", c.leadIDs).
+					Update("account_id", account.ID).Error; err != nil {
+					return err
+				}
+				result.LeadsLinked += len(c.leadIDs)
+			}
+			if len(c.customerIDs) > 0 {
+				if err := tx.Model(&models.Customer{}).Where("id IN ?", c.customerIDs).
+					Update("account_id", account.ID).Error; err != nil {
+					return err
+				}
+				result.CustomersLinked += len(c.customerIDs)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
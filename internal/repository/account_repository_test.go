@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+func setupAccountDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	require.NoError(t, err)
+
+	tables := []interface{}{
+		&models.Account{}, &models.User{}, &models.Lead{}, &models.Customer{}, &models.Ticket{}, &models.Task{},
+		&models.PipelineStage{}, &models.Deal{},
+	}
+	require.NoError(t, db.Migrator().DropTable(tables...))
+	require.NoError(t, db.AutoMigrate(tables...))
+
+	t.Cleanup(func() {
+		sqlDB, err := db.DB()
+		if err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func createAccountLead(t *testing.T, db *gorm.DB, company string, ownerID uint, accountID *uint) *models.Lead {
+	t.Helper()
+	lead := &models.Lead{
+		FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com",
+		Company: company, OwnerID: ownerID, AccountID: accountID,
+	}
+	require.NoError(t, db.Omit(clause.Associations).Create(lead).Error)
+	return lead
+}
+
+func createAccountCustomer(t *testing.T, db *gorm.DB, email, company string, accountID *uint) *models.Customer {
+	t.Helper()
+	customer := &models.Customer{FirstName: "Grace", LastName: "Hopper", Email: email, Company: company, AccountID: accountID}
+	require.NoError(t, db.Omit(clause.Associations).Create(customer).Error)
+	return customer
+}
+
+func TestAccountRepository_BackfillClustersCompanySpellings(t *testing.T) {
+	db := setupAccountDB(t)
+	repo := NewAccountRepository(db)
+
+	acme := []*models.Lead{
+		createAccountLead(t, db, "ACME Inc.", 1, nil),
+		createAccountLead(t, db, "Acme", 1, nil),
+	}
+	createAccountLead(t, db, "", 1, nil)
+	globex := createAccountLead(t, db, "Globex Corporation", 1, nil)
+	acmeCustomer := createAccountCustomer(t, db, "c1@example.com", " Acme   Inc ", nil)
+	acmeCustomer2 := createAccountCustomer(t, db, "c2@example.com", "acme", nil)
+	createAccountCustomer(t, db, "c3@example.com", "Acme", nil)
+
+	result, err := repo.BackfillFromCompanies()
+	require.NoError(t, err)
+	assert.Equal(t, &models.AccountBackfill{AccountsCreated: 2, LeadsLinked: 3, CustomersLinked: 3}, result)
+
+	account, err := repo.GetByNameKey("acme")
+	require.NoError(t, err)
+	assert.Equal(t, "Acme", account.Name, "the most common spelling names the account")
+	assert.Nil(t, account.OwnerID)
+
+	for _, id := range []uint{acme[0].ID, acme[1].ID} {
+		var lead models.Lead
+		require.NoError(t, db.First(&lead, id).Error)
+		require.NotNil(t, lead.AccountID)
+		assert.Equal(t, account.ID, *lead.AccountID)
+	}
+	for _, id := range []uint{acmeCustomer.ID, acmeCustomer2.ID} {
+		var customer models.Customer
+		require.NoError(t, db.First(&customer, id).Error)
+		require.NotNil(t, customer.AccountID)
+		assert.Equal(t, account.ID, *customer.AccountID)
+		assert.NotEqual(t, "Acme", customer.Company, "the company text is kept as written")
+	}
+
+	var globexLead models.Lead
+	require.NoError(t, db.First(&globexLead, globex.ID).Error)
+	globexAccount, err := repo.GetByNameKey("globex")
+	require.NoError(t, err)
+	assert.Equal(t, "Globex Corporation", globexAccount.Name)
+	assert.Equal(t, globexAccount.ID, *globexLead.AccountID)
+
+	createAccountLead(t, db, "Initech", 1, nil)
+	result, err = repo.BackfillFromCompanies()
+	require.NoError(t, err)
+	assert.Equal(t, &models.AccountBackfill{}, result, "the backfill only runs while there are no accounts")
+}
+
+func TestAccountRepository_SummarizeAndRollups(t *testing.T) {
+	db := setupAccountDB(t)
+	repo := NewAccountRepository(db)
+
+	account := &models.Account{Name: "Acme", NameKey: "acme"}
+	require.NoError(t, repo.Create(account))
+	other := &models.Account{Name: "Globex", NameKey: "globex"}
+	require.NoError(t, repo.Create(other))
+
+	mine := createAccountLead(t, db, "Acme", 7, &account.ID)
+	createAccountLead(t, db, "Acme", 8, &account.ID)
+	createAccountLead(t, db, "Globex", 7, &other.ID)
+	customer := createAccountCustomer(t, db, "c4@example.com", "Acme", &account.ID)
+	outsider := createAccountCustomer(t, db, "c5@example.com", "Globex", &other.ID)
+
+	for _, ticket := range []models.Ticket{
+		{Title: "Broken", CustomerID: customer.ID, Status: models.TicketStatusOpen},
+		{Title: "Fixed", CustomerID: customer.ID, Status: models.TicketStatusClosed},
+		{Title: "Elsewhere", CustomerID: outsider.ID, Status: models.TicketStatusOpen},
+	} {
+		require.NoError(t, db.Omit(clause.Associations).Create(&ticket).Error)
+	}
+	for _, task := range []models.Task{
+		{Title: "Call", LeadID: &mine.ID, AssignedToID: 7, Status: models.TaskStatusPending},
+		{Title: "Visit", CustomerID: &customer.ID, AssignedToID: 8, Status: models.TaskStatusCompleted},
+		{Title: "Elsewhere", CustomerID: &outsider.ID, AssignedToID: 7, Status: models.TaskStatusPending},
+	} {
+		require.NoError(t, db.Omit(clause.Associations).Create(&task).Error)
+	}
+	for _, deal := range []models.Deal{
+		{Title: "Renewal", CustomerID: customer.ID, OwnerID: 7, StageID: 1, Currency: "EUR"},
+		{Title: "Upsell", CustomerID: customer.ID, OwnerID: 8, StageID: 1, Currency: "EUR"},
+		{Title: "Elsewhere", CustomerID: outsider.ID, OwnerID: 7, StageID: 1, Currency: "EUR"},
+	} {
+		require.NoError(t, db.Omit(clause.Associations).Create(&deal).Error)
+	}
+
+	summary, err := repo.Summarize(account.ID, models.AccountScope{})
+	require.NoError(t, err)
+	require.NotNil(t, summary.Leads)
+	assert.Equal(t, int64(2), *summary.Leads)
+	assert.Equal(t, int64(1), summary.Customers)
+	assert.Equal(t, int64(2), summary.Tickets)
+	assert.Equal(t, int64(1), summary.OpenTickets)
+	assert.Equal(t, int64(2), summary.Tasks)
+	assert.Equal(t, int64(1), summary.OpenTasks)
+
+	summary, err = repo.Summarize(account.ID, models.AccountScope{LeadOwnerID: 7, DealOwnerID: 7, TaskAssigneeID: 7})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), *summary.Leads, "a sales user counts their own leads")
+	assert.Equal(t, int64(1), summary.Tasks)
+	assert.Equal(t, int64(2), summary.Tickets, "tickets are not scoped")
+
+	summary, err = repo.Summarize(account.ID, models.AccountScope{TaskAssigneeID: 7, SkipLeads: true, SkipDeals: true})
+	require.NoError(t, err)
+	assert.Nil(t, summary.Leads)
+
+	deals, total, err := repo.ListDeals(account.ID, 7, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, deals, 1)
+	assert.Equal(t, "Renewal", deals[0].Title)
+
+	all, err := repo.AllDeals(account.ID, 0)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	tickets, total, err := repo.ListTickets(account.ID, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, "Fixed", tickets[0].Title, "newest first")
+}
+
+func TestAccountRepository_DeleteUnlinksContacts(t *testing.T) {
+	db := setupAccountDB(t)
+	repo := NewAccountRepository(db)
+
+	account := &models.Account{Name: "Acme", NameKey: "acme"}
+	require.NoError(t, repo.Create(account))
+	lead := createAccountLead(t, db, "Acme Inc.", 7, &account.ID)
+	customer := createAccountCustomer(t, db, "c6@example.com", "Acme", &account.ID)
+
+	require.NoError(t, repo.Delete(account.ID))
+
+	var storedLead models.Lead
+	require.NoError(t, db.First(&storedLead, lead.ID).Error)
+	assert.Nil(t, storedLead.AccountID)
+	assert.Equal(t, "Acme Inc.", storedLead.Company)
+	var storedCustomer models.Customer
+	require.NoError(t, db.First(&storedCustomer, customer.ID).Error)
+	assert.Nil(t, storedCustomer.AccountID)
+
+	_, err := repo.GetByID(account.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, repo.Delete(account.ID), gorm.ErrRecordNotFound)
+}
+
+// Accounts are listed by name; two of the same name are told apart by id, on
+// either side of a cursor.
+func TestAccountRepository_ListByCursor(t *testing.T) {
+	db := setupAccountDB(t)
+	repo := NewAccountRepository(db)
+
+	names := []string{"Globex", "Acme", "Initech", "Acme"}
+	ids := make([]uint, len(names))
+	for i, name := range names {
+		account := &models.Account{Name: name, NameKey: strings.ToLower(name)}
+		require.NoError(t, repo.Create(account))
+		ids[i] = account.ID
+	}
+
+	cursor := &models.ListCursor{SortBy: "name"}
+	first, total, err := repo.List(models.AccountFilter{Cursor: cursor}, 0, 2)
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, total)
+	require.Len(t, first, 2)
+	assert.Equal(t, []uint{ids[1], ids[3]}, []uint{first[0].ID, first[1].ID})
+
+	raw, err := utils.EncodeCursor(cursor, &first[0])
+	require.NoError(t, err)
+	cursor, err = utils.DecodeCursor("accounts", raw, "name", false)
+	require.NoError(t, err)
+	rest, _, err := repo.List(models.AccountFilter{Cursor: cursor}, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, rest, 3)
+	assert.Equal(t, []uint{ids[3], ids[0], ids[2]}, []uint{rest[0].ID, rest[1].ID, rest[2].ID})
+}
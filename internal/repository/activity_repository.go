@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"gorm.io/gorm"
+)
+
+type activityRepository struct {
+	db *gorm.DB
+}
+
+func NewActivityRepository(db *gorm.DB) ActivityRepository {
+	return &activityRepository{db: db}
+}
+
+func (r *activityRepository) WithTx(tx *gorm.DB) ActivityRepository {
+	return &activityRepository{db: tx}
+}
+
+func (r *activityRepository) Create(event *models.ActivityEvent) error {
+	return r.db.Create(event).Error
+}
+
+// activityOwnerColumns are the table and owner column of each entity that
+// writes to the feed: the lead's and deal's owner, the customer's account
+// manager, the ticket's and task's assignee, the account's owner.
+var activityOwnerColumns = []struct{ entityType, table, column string }{
+	{models.AuditEntityLead, "leads", "owner_id"},
+	{models.AuditEntityCustomer, "customers", "assigned_to_id"},
+	{models.AuditEntityTicket, "tickets", "assigned_to_id"},
+	{models.AuditEntityTask, "tasks", "assigned_to_id"},
+	{models.AuditEntityDeal, "deals", "owner_id"},
+	{models.AuditEntityAccount, "accounts", "owner_id"},
+}
+
+// ownedActivityCondition matches the events about the records ownerID owns
+// now, whoever owned them when the event happened: reassigning a record hands
+// its history over with it, by whichever path it was reassigned. Deleted
+// records count, as they keep their row. Only an event whose record is not
+// there at all falls back to the owner it was stored with.
+func ownedActivityCondition(db *gorm.DB, ownerID uint) *gorm.DB {
+	condition := db.Where("1 = 0")
+	known := make([]string, 0, len(activityOwnerColumns))
+	for _, owner := range activityOwnerColumns {
+		known = append(known, owner.entityType)
+		record := fmt.Sprintf("SELECT 1 FROM %s WHERE %s.id = activity_events.entity_id", owner.table, owner.table)
+		condition = condition.
+			Or(fmt.Sprintf("entity_type = ? AND EXISTS (%s AND %s.%s = ?)", record, owner.table, owner.column),
+				owner.entityType, ownerID).
+			Or(fmt.Sprintf("entity_type = ? AND owner_id = ? AND NOT EXISTS (%s)", record),
+				owner.entityType, ownerID)
+	}
+	return condition.Or("entity_type NOT IN ? AND owner_id = ?", known, ownerID)
+}
+
+// List pages by id rather than by offset: ids grow with insertion, so "older
+// than the last id seen" stays correct while new events keep arriving at the
+// head of the feed, where an offset would shift and repeat entries.
+func (r *activityRepository) List(filter models.ActivityFilter, limit int) ([]models.ActivityEvent, error) {
+	query := r.db.Model(&models.ActivityEvent{}).Preload("Owner")
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.OwnerID != 0 {
+		query = query.Where(ownedActivityCondition(r.db, filter.OwnerID))
+	}
+	if filter.BeforeID != 0 {
+		query = query.Where("id < ?", filter.BeforeID)
+	}
+
+	events := []models.ActivityEvent{}
+	if err := query.Order("id DESC").Limit(limit).Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupActivityDB gives each test a private activity_events table on the
+// shared in-memory DSN, the same way the audit tests do.
+func setupActivityDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	require.NoError(t, err)
+
+	// The feed resolves owners against the records' own tables.
+	records := []interface{}{&models.Lead{}, &models.Customer{}, &models.Ticket{}, &models.Task{}, &models.Deal{}, &models.Account{}}
+	require.NoError(t, db.Migrator().DropTable(append(records, &models.ActivityEvent{}, &models.User{})...))
+	require.NoError(t, db.AutoMigrate(append([]interface{}{&models.User{}, &models.ActivityEvent{}}, records...)...))
+
+	t.Cleanup(func() {
+		sqlDB, err := db.DB()
+		if err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func recordTestActivity(t *testing.T, repo ActivityRepository, entityType string, entityID uint, ownerID *uint) *models.ActivityEvent {
+	t.Helper()
+	event := &models.ActivityEvent{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Type:       models.ActivityType(entityType + "_updated"),
+		Title:      "Updated",
+		OwnerID:    ownerID,
+	}
+	require.NoError(t, repo.Create(event))
+	return event
+}
+
+func TestActivityRepository_ListFiltersNewestFirst(t *testing.T) {
+	db := setupActivityDB(t)
+	repo := NewActivityRepository(db)
+
+	owner := &models.User{Email: "owner@example.com", Password: "x", FirstName: "Olive", Role: models.RoleSales}
+	require.NoError(t, db.Create(owner).Error)
+
+	first := recordTestActivity(t, repo, models.AuditEntityLead, 1, &owner.ID)
+	recordTestActivity(t, repo, models.AuditEntityTicket, 2, nil)
+	third := recordTestActivity(t, repo, models.AuditEntityLead, 1, &owner.ID)
+	recordTestActivity(t, repo, models.AuditEntityLead, 3, nil)
+
+	events, err := repo.List(models.ActivityFilter{}, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+	assert.Greater(t, events[0].ID, events[3].ID)
+
+	events, err = repo.List(models.ActivityFilter{EntityType: models.AuditEntityLead, EntityID: 1}, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, third.ID, events[0].ID)
+	assert.Equal(t, first.ID, events[1].ID)
+
+	events, err = repo.List(models.ActivityFilter{OwnerID: owner.ID}, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.NotNil(t, events[0].Owner)
+	assert.Equal(t, "owner@example.com", events[0].Owner.Email)
+}
+
+// TestActivityRepository_ListFollowsTheCurrentOwner: an owner's feed holds
+// the history of the records they own now, not of the ones they used to.
+func TestActivityRepository_ListFollowsTheCurrentOwner(t *testing.T) {
+	db := setupActivityDB(t)
+	repo := NewActivityRepository(db)
+
+	var users []*models.User
+	for _, email := range []string{"former@example.com", "current@example.com"} {
+		user := &models.User{Email: email, Password: "x", FirstName: "Sam", Role: models.RoleSales}
+		require.NoError(t, db.Create(user).Error)
+		users = append(users, user)
+	}
+	former, current := users[0], users[1]
+
+	lead := &models.Lead{FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", OwnerID: former.ID}
+	require.NoError(t, db.Create(lead).Error)
+	ticket := &models.Ticket{Title: "Printer", Description: "On fire", CustomerID: 1, AssignedToID: &former.ID}
+	require.NoError(t, db.Create(ticket).Error)
+	task := &models.Task{Title: "Call back", AssignedToID: former.ID}
+	require.NoError(t, db.Create(task).Error)
+
+	leadEvent := recordTestActivity(t, repo, models.AuditEntityLead, lead.ID, &former.ID)
+	recordTestActivity(t, repo, models.AuditEntityTicket, ticket.ID, &former.ID)
+	taskEvent := recordTestActivity(t, repo, models.AuditEntityTask, task.ID, &former.ID)
+
+	// The lead and the task change hands, the ticket goes back to the queue,
+	// and the task is then deleted.
+	require.NoError(t, db.Model(lead).UpdateColumn("owner_id", current.ID).Error)
+	require.NoError(t, db.Model(ticket).UpdateColumn("assigned_to_id", nil).Error)
+	require.NoError(t, db.Model(task).UpdateColumn("assigned_to_id", current.ID).Error)
+	require.NoError(t, db.Delete(task).Error)
+
+	events, err := repo.List(models.ActivityFilter{OwnerID: former.ID}, 10)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	events, err = repo.List(models.ActivityFilter{OwnerID: current.ID}, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, taskEvent.ID, events[0].ID)
+	assert.Equal(t, leadEvent.ID, events[1].ID)
+	require.NotNil(t, events[1].Owner)
+	assert.Equal(t, "former@example.com", events[1].Owner.Email, "the entry still names who owned the record then")
+}
+
+func TestActivityRepository_ListPagesByID(t *testing.T) {
+	db := setupActivityDB(t)
+	repo := NewActivityRepository(db)
+
+	var ids []uint
+	for i := uint(1); i <= 5; i++ {
+		ids = append(ids, recordTestActivity(t, repo, models.AuditEntityTask, i, nil).ID)
+	}
+
+	page, err := repo.List(models.ActivityFilter{}, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, ids[4], page[0].ID)
+	assert.Equal(t, ids[3], page[1].ID)
+
+	// A new event at the head of the feed does not shift the next page.
+	recordTestActivity(t, repo, models.AuditEntityTask, 6, nil)
+
+	page, err = repo.List(models.ActivityFilter{BeforeID: page[1].ID}, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, ids[2], page[0].ID)
+	assert.Equal(t, ids[1], page[1].ID)
+}
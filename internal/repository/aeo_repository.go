@@ -14,7 +14,9 @@ import (
 //
 // These are deliberately local to this file rather than folded into
 // utils.AllowedSortColumns: that map is shared state nobody owns, and the AEO
-// tables are the only consumers of these two column sets. validateAEOSort below
+// tables are the only consumers of these two column sets. The one exception is
+// the cursor of the prompt list, which seeks through seekCursor and so needs
+// the prompt columns in utils.AllowedSortColumns too. validateAEOSort below
 // reproduces utils.ValidateSort's semantics exactly, so the guarantee is the
 // same one the rest of the repository layer relies on — no ORDER BY fragment is
 // ever built from a string that did not come out of an allowlist.
@@ -144,34 +146,51 @@ func (r *aeoRepository) DeletePrompt(id uint) error {
 	return nil
 }
 
-func (r *aeoRepository) ListPrompts(activeOnly bool, offset, limit int, sortBy, sortOrder string) ([]models.AEOPrompt, error) {
+func (r *aeoRepository) ListPrompts(filter models.AEOPromptFilter, offset, limit int, sortBy, sortOrder string) ([]models.AEOPrompt, error) {
 	column, order, err := validateAEOSort(aeoPromptSortColumns, sortBy, sortOrder)
 	if err != nil {
 		return nil, err
 	}
 
-	prompts := []models.AEOPrompt{}
-	query := r.db.Model(&models.AEOPrompt{})
-	if activeOnly {
-		query = query.Where("is_active = ?", true)
+	query, err := r.filteredPrompts(filter)
+	if err != nil {
+		return nil, err
 	}
 
-	err = query.Order(aeoOrderClause(column, order)).
-		Offset(offset).Limit(limit).Find(&prompts).Error
+	if filter.Cursor != nil {
+		if query, err = seekCursor(query, "aeo_prompts", filter.Cursor); err != nil {
+			return nil, err
+		}
+	} else {
+		query = query.Order(aeoOrderClause(column, order))
+	}
+
+	prompts := []models.AEOPrompt{}
+	err = query.Offset(offset).Limit(limit).Find(&prompts).Error
 	return prompts, err
 }
 
-func (r *aeoRepository) CountPrompts(activeOnly bool) (int64, error) {
-	query := r.db.Model(&models.AEOPrompt{})
-	if activeOnly {
-		query = query.Where("is_active = ?", true)
+func (r *aeoRepository) CountPrompts(filter models.AEOPromptFilter) (int64, error) {
+	query, err := r.filteredPrompts(filter)
+	if err != nil {
+		return 0, err
 	}
 
 	var count int64
-	err := query.Count(&count).Error
+	err = query.Count(&count).Error
 	return count, err
 }
 
+// filteredPrompts is the prompt query ListPrompts pages through and
+// CountPrompts counts, so the two always agree.
+func (r *aeoRepository) filteredPrompts(filter models.AEOPromptFilter) (*gorm.DB, error) {
+	query := r.db.Model(&models.AEOPrompt{})
+	if filter.ActiveOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	return whereFilter(query, "aeo_prompts", filter.Where)
+}
+
 // ListActivePrompts returns every active prompt, oldest first. This is the run
 // engine's input, so it is deliberately unpaginated — the service caps the
 // active set at 100.
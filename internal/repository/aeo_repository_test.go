@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/sqlite"
@@ -169,33 +170,33 @@ func TestAEORepository_ListPrompts_FilterSortAndPaginate(t *testing.T) {
 	bravo := makeAEOPrompt(t, db, "Bravo question", true)
 	charlie := makeAEOPrompt(t, db, "Charlie question", false)
 
-	active, err := repo.ListPrompts(true, 0, 20, "text", "asc")
+	active, err := repo.ListPrompts(models.AEOPromptFilter{ActiveOnly: true}, 0, 20, "text", "asc")
 	require.NoError(t, err)
 	require.Len(t, active, 2)
 	assert.Equal(t, alpha.ID, active[0].ID)
 	assert.Equal(t, bravo.ID, active[1].ID)
 
-	all, err := repo.ListPrompts(false, 0, 20, "text", "desc")
+	all, err := repo.ListPrompts(models.AEOPromptFilter{}, 0, 20, "text", "desc")
 	require.NoError(t, err)
 	require.Len(t, all, 3)
 	assert.Equal(t, charlie.ID, all[0].ID)
 
-	page, err := repo.ListPrompts(false, 1, 1, "text", "asc")
+	page, err := repo.ListPrompts(models.AEOPromptFilter{}, 1, 1, "text", "asc")
 	require.NoError(t, err)
 	require.Len(t, page, 1)
 	assert.Equal(t, bravo.ID, page[0].ID, "offset 1 limit 1 is the second row of the sorted set")
 
 	// Sorting by the tie-breaker column itself must not emit "id asc, id asc".
-	byID, err := repo.ListPrompts(false, 0, 20, "id", "desc")
+	byID, err := repo.ListPrompts(models.AEOPromptFilter{}, 0, 20, "id", "desc")
 	require.NoError(t, err)
 	require.Len(t, byID, 3)
 	assert.Equal(t, charlie.ID, byID[0].ID)
 
-	total, err := repo.CountPrompts(false)
+	total, err := repo.CountPrompts(models.AEOPromptFilter{})
 	require.NoError(t, err)
 	assert.Equal(t, int64(3), total)
 
-	activeTotal, err := repo.CountPrompts(true)
+	activeTotal, err := repo.CountPrompts(models.AEOPromptFilter{ActiveOnly: true})
 	require.NoError(t, err)
 	assert.Equal(t, int64(2), activeTotal)
 }
@@ -210,16 +211,40 @@ func TestAEORepository_ListPrompts_DefaultsToNewestFirst(t *testing.T) {
 	stampTimes(t, db, &older, base, base)
 	stampTimes(t, db, &newer, base.Add(time.Hour), base.Add(time.Hour))
 
-	prompts, err := repo.ListPrompts(false, 0, 20, "", "")
+	prompts, err := repo.ListPrompts(models.AEOPromptFilter{}, 0, 20, "", "")
 	require.NoError(t, err)
 	require.Len(t, prompts, 2)
 	assert.Equal(t, newer.ID, prompts[0].ID, "an empty sortBy means created_at desc, like utils.ValidateSort")
 }
 
+func TestAEORepository_ListPrompts_ByCursor(t *testing.T) {
+	db := setupAEOTestDB(t)
+	repo := NewAEORepository(db)
+
+	charlie := makeAEOPrompt(t, db, "Charlie question", true)
+	alpha := makeAEOPrompt(t, db, "Alpha question", true)
+	bravo := makeAEOPrompt(t, db, "Bravo question", false)
+
+	cursor := &models.ListCursor{SortBy: "text"}
+	first, err := repo.ListPrompts(models.AEOPromptFilter{Cursor: cursor}, 0, 2, "text", "asc")
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	assert.Equal(t, []uint{alpha.ID, bravo.ID}, []uint{first[0].ID, first[1].ID})
+
+	raw, err := utils.EncodeCursor(cursor, &first[1])
+	require.NoError(t, err)
+	cursor, err = utils.DecodeCursor("aeo_prompts", raw, "text", false)
+	require.NoError(t, err)
+	rest, err := repo.ListPrompts(models.AEOPromptFilter{Cursor: cursor}, 0, 2, "text", "asc")
+	require.NoError(t, err)
+	require.Len(t, rest, 1)
+	assert.Equal(t, charlie.ID, rest[0].ID, "the page after the cursor")
+}
+
 func TestAEORepository_ListPrompts_RejectsUnknownSortColumn(t *testing.T) {
 	repo := NewAEORepository(setupAEOTestDB(t))
 
-	prompts, err := repo.ListPrompts(false, 0, 20, "text); DROP TABLE aeo_prompts;--", "asc")
+	prompts, err := repo.ListPrompts(models.AEOPromptFilter{}, 0, 20, "text); DROP TABLE aeo_prompts;--", "asc")
 
 	assert.Nil(t, prompts)
 	require.Error(t, err)
@@ -977,7 +1002,7 @@ func TestAEORepository_WithTx(t *testing.T) {
 	})
 	require.Error(t, err)
 
-	count, err := repo.CountPrompts(false)
+	count, err := repo.CountPrompts(models.AEOPromptFilter{})
 	require.NoError(t, err)
 	assert.Equal(t, int64(0), count, "WithTx has to bind the repository to the caller's transaction")
 
@@ -985,7 +1010,7 @@ func TestAEORepository_WithTx(t *testing.T) {
 		return repo.WithTx(tx).CreatePrompt(&models.AEOPrompt{Text: "Committed", IsActive: true})
 	}))
 
-	count, err = repo.CountPrompts(false)
+	count, err = repo.CountPrompts(models.AEOPromptFilter{})
 	require.NoError(t, err)
 	assert.Equal(t, int64(1), count)
 }
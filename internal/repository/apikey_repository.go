@@ -56,6 +56,22 @@ func (r *apiKeyRepository) UpdateLastUsed(id uint) error {
 	return r.db.Model(&models.APIKey{}).Where("id = ?", id).Update("last_used_at", &now).Error
 }
 
+func (r *apiKeyRepository) CreateUsage(usage *models.APIKeyUsage) error {
+	return r.db.Create(usage).Error
+}
+
+func (r *apiKeyRepository) ListUsage(apiKeyID uint, offset, limit int) ([]models.APIKeyUsage, int64, error) {
+	var usage []models.APIKeyUsage
+	var total int64
+
+	query := r.db.Model(&models.APIKeyUsage{}).Where("api_key_id = ?", apiKeyID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&usage).Error
+	return usage, total, err
+}
+
 func (r *apiKeyRepository) WithTx(tx *gorm.DB) APIKeyRepository {
 	return &apiKeyRepository{db: tx}
 }
\ No newline at end of file
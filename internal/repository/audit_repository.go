@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"gorm.io/gorm"
+)
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) WithTx(tx *gorm.DB) AuditRepository {
+	return &auditRepository{db: tx}
+}
+
+func (r *auditRepository) Create(event *models.AuditEvent) error {
+	return r.db.Create(event).Error
+}
+
+func (r *auditRepository) List(filter models.AuditFilter, offset, limit int) ([]models.AuditEvent, int64, error) {
+	query := r.db.Model(&models.AuditEvent{})
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.ActorUserID != 0 {
+		query = query.Where("actor_user_id = ?", filter.ActorUserID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	return r.page(query, filter.Cursor, offset, limit)
+}
+
+func (r *auditRepository) ListByEntity(entityType string, entityID uint, offset, limit int) ([]models.AuditEvent, int64, error) {
+	query := r.db.Model(&models.AuditEvent{}).
+		Where("entity_type = ? AND entity_id = ?", entityType, entityID)
+	return r.page(query, nil, offset, limit)
+}
+
+// page counts the filtered query and reads one page of it newest first. The id
+// tie-breaker keeps the order stable between events written in the same
+// second, which is the common case for a cascade. A non-nil cursor reads the
+// page after it instead, in the same order.
+func (r *auditRepository) page(query *gorm.DB, cursor *models.ListCursor, offset, limit int) ([]models.AuditEvent, int64, error) {
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if cursor != nil {
+		var err error
+		if query, err = seekCursor(query, "audit_events", cursor); err != nil {
+			return nil, 0, err
+		}
+	} else {
+		query = query.Order("created_at DESC").Order("id DESC")
+	}
+	events := []models.AuditEvent{}
+	err := query.Offset(offset).Limit(limit).Find(&events).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupAuditDB gives each test a private audit_events table on the shared
+// in-memory DSN, the same way the label tests do.
+func setupAuditDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, db.Migrator().DropTable(&models.AuditEvent{}))
+	require.NoError(t, db.AutoMigrate(&models.AuditEvent{}))
+
+	t.Cleanup(func() {
+		sqlDB, err := db.DB()
+		if err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func recordTestAuditEvent(t *testing.T, repo AuditRepository, entityType string, entityID uint, action models.AuditAction, actorID uint) *models.AuditEvent {
+	t.Helper()
+	event := &models.AuditEvent{
+		ActorType:   models.AuditActorUser,
+		ActorUserID: &actorID,
+		EntityType:  entityType,
+		EntityID:    entityID,
+		Action:      action,
+		Changes: map[string]models.AuditChange{
+			"status": {Before: "new", After: "contacted"},
+		},
+	}
+	require.NoError(t, repo.Create(event))
+	return event
+}
+
+func TestAuditRepository_CreateRoundTripsChanges(t *testing.T) {
+	db := setupAuditDB(t)
+	repo := NewAuditRepository(db)
+
+	recordTestAuditEvent(t, repo, models.AuditEntityLead, 7, models.AuditActionUpdate, 1)
+
+	events, total, err := repo.ListByEntity(models.AuditEntityLead, 7, 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), total)
+	require.Len(t, events, 1)
+	assert.Equal(t, models.AuditChange{Before: "new", After: "contacted"}, events[0].Changes["status"])
+	require.NotNil(t, events[0].ActorUserID)
+	assert.Equal(t, uint(1), *events[0].ActorUserID)
+}
+
+func TestAuditRepository_ListByEntityIsScopedAndNewestFirst(t *testing.T) {
+	db := setupAuditDB(t)
+	repo := NewAuditRepository(db)
+
+	first := recordTestAuditEvent(t, repo, models.AuditEntityLead, 7, models.AuditActionCreate, 1)
+	second := recordTestAuditEvent(t, repo, models.AuditEntityLead, 7, models.AuditActionUpdate, 1)
+	recordTestAuditEvent(t, repo, models.AuditEntityLead, 8, models.AuditActionUpdate, 1)
+	// Same id, different entity type: must not leak into the lead's history.
+	recordTestAuditEvent(t, repo, models.AuditEntityCustomer, 7, models.AuditActionUpdate, 1)
+
+	events, total, err := repo.ListByEntity(models.AuditEntityLead, 7, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, events, 2)
+	assert.Equal(t, second.ID, events[0].ID)
+	assert.Equal(t, first.ID, events[1].ID)
+}
+
+func TestAuditRepository_ListAppliesFilters(t *testing.T) {
+	db := setupAuditDB(t)
+	repo := NewAuditRepository(db)
+
+	recordTestAuditEvent(t, repo, models.AuditEntityLead, 1, models.AuditActionCreate, 1)
+	recordTestAuditEvent(t, repo, models.AuditEntityLead, 1, models.AuditActionUpdate, 2)
+	recordTestAuditEvent(t, repo, models.AuditEntityTicket, 3, models.AuditActionDelete, 2)
+
+	_, total, err := repo.List(models.AuditFilter{}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+
+	_, total, err = repo.List(models.AuditFilter{ActorUserID: 2}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+
+	events, total, err := repo.List(models.AuditFilter{EntityType: models.AuditEntityLead, Action: models.AuditActionUpdate}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, events, 1)
+	assert.Equal(t, uint(2), *events[0].ActorUserID)
+
+	future := time.Now().Add(time.Hour)
+	_, total, err = repo.List(models.AuditFilter{From: &future}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+
+	_, total, err = repo.List(models.AuditFilter{To: &future}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+}
+
+func TestAuditRepository_ListPaginatesWithFullTotal(t *testing.T) {
+	db := setupAuditDB(t)
+	repo := NewAuditRepository(db)
+
+	for i := 0; i < 5; i++ {
+		recordTestAuditEvent(t, repo, models.AuditEntityTask, 1, models.AuditActionUpdate, 1)
+	}
+
+	events, total, err := repo.List(models.AuditFilter{}, 2, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	assert.Len(t, events, 2)
+}
@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"errors"
+	"time"
+
 	"github.com/florinel-chis/gophercrm/internal/models"
 	"gorm.io/gorm"
 )
@@ -94,8 +97,153 @@ func (r *bulkOperationRepository) UpdateItem(item *models.BulkOperationItem) err
 
 func (r *bulkOperationRepository) GetItemsByOperationID(operationID uint) ([]models.BulkOperationItem, error) {
 	var items []models.BulkOperationItem
-	err := r.db.Where("bulk_operation_id = ?", operationID).
+	err := r.db.Where("operation_id = ?", operationID).
 		Order("created_at ASC").
 		Find(&items).Error
 	return items, err
-}
\ No newline at end of file
+}
+
+func (r *bulkOperationRepository) CreateItems(items []models.BulkOperationItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(&items, 100).Error
+}
+
+func (r *bulkOperationRepository) UpdateProgress(id uint, status models.BulkOperationStatus, successCount, failureCount int) error {
+	return r.db.Model(&models.BulkOperation{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":        status,
+			"success_count": successCount,
+			"failure_count": failureCount,
+		}).Error
+}
+
+func (r *bulkOperationRepository) GetFailedItems(operationID uint) ([]models.BulkOperationItem, error) {
+	var items []models.BulkOperationItem
+	err := r.db.Where("operation_id = ? AND status = ?", operationID, models.StatusFailed).
+		Order("id ASC").
+		Find(&items).Error
+	return items, err
+}
+
+// ClaimNextQueued picks the oldest pending queued operation and claims it with
+// a conditional UPDATE. A worker that loses the race for a row looks again, so
+// the loop ends once a claim succeeds or nothing is left to claim.
+func (r *bulkOperationRepository) ClaimNextQueued(startedAt time.Time) (*models.BulkOperation, error) {
+	for {
+		var operation models.BulkOperation
+		err := r.db.Where("status = ? AND request <> ''", models.StatusPending).
+			Order("id ASC").
+			First(&operation).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result := r.db.Model(&models.BulkOperation{}).
+			Where("id = ? AND status = ?", operation.ID, models.StatusPending).
+			Updates(map[string]interface{}{
+				"status":     models.StatusProcessing,
+				"started_at": startedAt,
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			operation.Status = models.StatusProcessing
+			operation.StartedAt = &startedAt
+			return &operation, nil
+		}
+	}
+}
+
+func (r *bulkOperationRepository) UpdateCounts(id uint, successCount, failureCount int) error {
+	return r.db.Model(&models.BulkOperation{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"success_count": successCount,
+			"failure_count": failureCount,
+		}).Error
+}
+
+func (r *bulkOperationRepository) Finish(id uint, status models.BulkOperationStatus, successCount, failureCount int, finishedAt time.Time) error {
+	return r.db.Model(&models.BulkOperation{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":        gorm.Expr("CASE WHEN status = ? THEN ? ELSE status END", models.StatusProcessing, status),
+			"success_count": successCount,
+			"failure_count": failureCount,
+			"finished_at":   finishedAt,
+			"request":       "",
+		}).Error
+}
+
+// Cancel is two conditional UPDATEs rather than one so that each can be
+// decided on the status alone: an operation a worker claims between them is
+// caught by the second. The worker of a running operation already holds its
+// request, so the request is dropped either way.
+func (r *bulkOperationRepository) Cancel(id uint, finishedAt time.Time) (bool, error) {
+	result := r.db.Model(&models.BulkOperation{}).
+		Where("id = ? AND status = ? AND request <> ''", id, models.StatusPending).
+		Updates(map[string]interface{}{
+			"status":      models.StatusCancelled,
+			"finished_at": finishedAt,
+			"request":     "",
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return true, nil
+	}
+
+	result = r.db.Model(&models.BulkOperation{}).
+		Where("id = ? AND status = ? AND request <> ''", id, models.StatusProcessing).
+		Updates(map[string]interface{}{
+			"status":  models.StatusCancelled,
+			"request": "",
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *bulkOperationRepository) MarkInterruptedFailed(finishedAt time.Time) (int64, error) {
+	result := r.db.Model(&models.BulkOperation{}).
+		Where("status = ? AND request <> ''", models.StatusProcessing).
+		Updates(map[string]interface{}{
+			"status":      models.StatusFailed,
+			"finished_at": finishedAt,
+			"request":     "",
+		})
+	return result.RowsAffected, result.Error
+}
+
+func (r *bulkOperationRepository) GetSnapshotItems(operationID uint) ([]models.BulkOperationItem, error) {
+	var items []models.BulkOperationItem
+	err := r.db.Where("operation_id = ? AND status = ? AND data <> ''", operationID, models.StatusCompleted).
+		Order("id ASC").
+		Find(&items).Error
+	return items, err
+}
+
+// MarkReverted is conditional on reverted_at, so that of two reverts racing
+// for one operation only one goes ahead.
+func (r *bulkOperationRepository) MarkReverted(id uint, revertedAt time.Time) (bool, error) {
+	var marked bool
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.BulkOperation{}).
+			Where("id = ? AND reverted_at IS NULL", id).
+			Update("reverted_at", revertedAt)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		marked = true
+		return tx.Model(&models.BulkOperationItem{}).
+			Where("operation_id = ? AND data <> ''", id).
+			Update("data", "").Error
+	})
+	return marked, err
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
 	"github.com/florinel-chis/gophercrm/internal/models"
 	"gorm.io/gorm"
 )
@@ -41,6 +42,30 @@ func bulkCreate[T any](db *gorm.DB, entities []T, tableName string) ([]T, []erro
 	return entities, nil
 }
 
+// splitCustomFields takes the custom_fields entry out of the column map of a
+// bulk update. It is not a column: the values are written through the record
+// once its columns are updated, which validates them as a single update would.
+// The map is returned as is when it has no such entry.
+func splitCustomFields(updates map[string]interface{}) (map[string]interface{}, map[string]interface{}, error) {
+	raw, ok := updates["custom_fields"]
+	if !ok {
+		return updates, nil, nil
+	}
+	var customFields map[string]interface{}
+	if raw != nil {
+		if customFields, ok = raw.(map[string]interface{}); !ok {
+			return nil, nil, fmt.Errorf("custom_fields must be an object: %w", apperrors.ErrValidation)
+		}
+	}
+	columns := make(map[string]interface{}, len(updates))
+	for column, value := range updates {
+		if column != "custom_fields" {
+			columns[column] = value
+		}
+	}
+	return columns, customFields, nil
+}
+
 // Helper function to handle bulk deletes of entities that hold no personal
 // data of their own — tasks and tickets. They carry a title, a description and
 // foreign keys to the people involved, and those people are erased through
@@ -180,7 +205,10 @@ func (r *bulkRepository) BulkUpdateLeads(updates []models.BulkUpdateItem) ([]mod
 	
 	for _, update := range updates {
 		lead := models.Lead{}
-		err := r.db.Model(&lead).Where("id = ?", update.ID).Updates(update.Updates).Error
+		columns, customFields, err := splitCustomFields(update.Updates)
+		if err == nil && len(columns) > 0 {
+			err = r.db.Model(&lead).Where("id = ?", update.ID).Updates(columns).Error
+		}
 		if err != nil {
 			errors = append(errors, fmt.Errorf("failed to update lead with ID %d: %w", update.ID, err))
 			continue
@@ -192,6 +220,14 @@ func (r *bulkRepository) BulkUpdateLeads(updates []models.BulkUpdateItem) ([]mod
 			errors = append(errors, fmt.Errorf("failed to fetch updated lead with ID %d: %w", update.ID, err))
 			continue
 		}
+
+		if customFields != nil {
+			lead.CustomFields = customFields
+			if err := writeCustomFields(r.db, []models.CustomFieldRecord{&lead}, false); err != nil {
+				errors = append(errors, fmt.Errorf("failed to update custom fields of lead with ID %d: %w", update.ID, err))
+				continue
+			}
+		}
 		
 		leads = append(leads, lead)
 	}
@@ -223,7 +259,10 @@ func (r *bulkRepository) BulkUpdateCustomers(updates []models.BulkUpdateItem) ([
 	
 	for _, update := range updates {
 		customer := models.Customer{}
-		err := r.db.Model(&customer).Where("id = ?", update.ID).Updates(update.Updates).Error
+		columns, customFields, err := splitCustomFields(update.Updates)
+		if err == nil && len(columns) > 0 {
+			err = r.db.Model(&customer).Where("id = ?", update.ID).Updates(columns).Error
+		}
 		if err != nil {
 			errors = append(errors, fmt.Errorf("failed to update customer with ID %d: %w", update.ID, err))
 			continue
@@ -235,6 +274,14 @@ func (r *bulkRepository) BulkUpdateCustomers(updates []models.BulkUpdateItem) ([
 			errors = append(errors, fmt.Errorf("failed to fetch updated customer with ID %d: %w", update.ID, err))
 			continue
 		}
+
+		if customFields != nil {
+			customer.CustomFields = customFields
+			if err := writeCustomFields(r.db, []models.CustomFieldRecord{&customer}, false); err != nil {
+				errors = append(errors, fmt.Errorf("failed to update custom fields of customer with ID %d: %w", update.ID, err))
+				continue
+			}
+		}
 		
 		customers = append(customers, customer)
 	}
@@ -263,7 +310,10 @@ func (r *bulkRepository) BulkUpdateTasks(updates []models.BulkUpdateItem) ([]mod
 	
 	for _, update := range updates {
 		task := models.Task{}
-		err := r.db.Model(&task).Where("id = ?", update.ID).Updates(update.Updates).Error
+		columns, customFields, err := splitCustomFields(update.Updates)
+		if err == nil && len(columns) > 0 {
+			err = r.db.Model(&task).Where("id = ?", update.ID).Updates(columns).Error
+		}
 		if err != nil {
 			errors = append(errors, fmt.Errorf("failed to update task with ID %d: %w", update.ID, err))
 			continue
@@ -275,6 +325,14 @@ func (r *bulkRepository) BulkUpdateTasks(updates []models.BulkUpdateItem) ([]mod
 			errors = append(errors, fmt.Errorf("failed to fetch updated task with ID %d: %w", update.ID, err))
 			continue
 		}
+
+		if customFields != nil {
+			task.CustomFields = customFields
+			if err := writeCustomFields(r.db, []models.CustomFieldRecord{&task}, false); err != nil {
+				errors = append(errors, fmt.Errorf("failed to update custom fields of task with ID %d: %w", update.ID, err))
+				continue
+			}
+		}
 		
 		tasks = append(tasks, task)
 	}
@@ -300,7 +358,10 @@ func (r *bulkRepository) BulkUpdateTickets(updates []models.BulkUpdateItem) ([]m
 	
 	for _, update := range updates {
 		ticket := models.Ticket{}
-		err := r.db.Model(&ticket).Where("id = ?", update.ID).Updates(update.Updates).Error
+		columns, customFields, err := splitCustomFields(update.Updates)
+		if err == nil && len(columns) > 0 {
+			err = r.db.Model(&ticket).Where("id = ?", update.ID).Updates(columns).Error
+		}
 		if err != nil {
 			errors = append(errors, fmt.Errorf("failed to update ticket with ID %d: %w", update.ID, err))
 			continue
@@ -312,6 +373,14 @@ func (r *bulkRepository) BulkUpdateTickets(updates []models.BulkUpdateItem) ([]m
 			errors = append(errors, fmt.Errorf("failed to fetch updated ticket with ID %d: %w", update.ID, err))
 			continue
 		}
+
+		if customFields != nil {
+			ticket.CustomFields = customFields
+			if err := writeCustomFields(r.db, []models.CustomFieldRecord{&ticket}, false); err != nil {
+				errors = append(errors, fmt.Errorf("failed to update custom fields of ticket with ID %d: %w", update.ID, err))
+				continue
+			}
+		}
 		
 		tickets = append(tickets, ticket)
 	}
@@ -382,8 +451,55 @@ func (r *bulkRepository) SetLeadStatus(ids []uint, status models.LeadStatus) err
 	return setStatus(r.db, &models.Lead{}, ids, status)
 }
 
+// SetTicketStatus also appends a status-change entry to the thread of every
+// ticket it moves, as TicketService.Update does for a single ticket, so a bulk
+// update does not leave a gap in the ticket's history, and keeps the SLA
+// resolution timestamps in step with the new status. All of it shares one
+// transaction.
 func (r *bulkRepository) SetTicketStatus(ids []uint, status models.TicketStatus) error {
-	return setStatus(r.db, &models.Ticket{}, ids, status)
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var moving []models.Ticket
+		err := tx.Select("id", "status", "resolved_at", "first_responded_at").
+			Where("id IN ? AND status <> ?", ids, status).Find(&moving).Error
+		if err != nil {
+			return err
+		}
+		if err := setStatus(tx, &models.Ticket{}, ids, status); err != nil {
+			return err
+		}
+		if len(moving) == 0 {
+			return nil
+		}
+
+		now := tx.NowFunc()
+		entries := make([]models.TicketComment, 0, len(moving))
+		for _, ticket := range moving {
+			previous := ticket.Status
+			entries = append(entries, models.TicketComment{
+				TicketID:   ticket.ID,
+				Kind:       models.TicketCommentKindStatusChange,
+				Visibility: models.TicketCommentPublic,
+				Body:       fmt.Sprintf("Status changed from %s to %s", previous, status),
+				FromStatus: previous,
+				ToStatus:   status,
+			})
+
+			ticket.Status = status
+			if ticket.TrackResolution(previous, now) {
+				err := tx.Model(&models.Ticket{}).Where("id = ?", ticket.ID).UpdateColumns(map[string]interface{}{
+					"resolved_at":        ticket.ResolvedAt,
+					"first_responded_at": ticket.FirstRespondedAt,
+				}).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Create(&entries).Error
+	})
 }
 
 func (r *bulkRepository) SetTaskStatus(ids []uint, status models.TaskStatus) error {
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Undo of queued bulk operations
+//
+// The worker reads the rows of every chunk before and after it runs, and keeps
+// what SnapshotChange makes of the two on the chunk's items. A row is read as
+// a models.BulkRowState: its columns as JSON, whatever their type, so that the
+// state survives being stored as text and two states compare byte for byte.
+// RestoreRow turns the stored JSON back into values of each column's type
+// through the model's schema.
+
+// secretColumns are compared but never kept. Their state is a digest, so a
+// change to one is noticed, and the change cannot be undone.
+var secretColumns = map[string]bool{"password": true}
+
+// untrackedColumns are never restored: the primary key and creation time do
+// not change, and updated_at moves with every write, the restore included.
+var untrackedColumns = map[string]bool{"id": true, "created_at": true, "updated_at": true}
+
+// bulkRowModel returns an empty slice of the model of resourceType.
+func bulkRowModel(resourceType string) (interface{}, error) {
+	switch resourceType {
+	case "users":
+		return &[]models.User{}, nil
+	case "leads":
+		return &[]models.Lead{}, nil
+	case "customers":
+		return &[]models.Customer{}, nil
+	case "tasks":
+		return &[]models.Task{}, nil
+	case "tickets":
+		return &[]models.Ticket{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported resource type: %s: %w", resourceType, apperrors.ErrValidation)
+	}
+}
+
+func (r *bulkRepository) rowSchema(rows interface{}) (*schema.Schema, error) {
+	statement := &gorm.Statement{DB: r.db}
+	if err := statement.Parse(rows); err != nil {
+		return nil, err
+	}
+	return statement.Schema, nil
+}
+
+func (r *bulkRepository) GetRowStates(resourceType string, ids []uint) (map[uint]models.BulkRowState, error) {
+	states := make(map[uint]models.BulkRowState, len(ids))
+	if len(ids) == 0 {
+		return states, nil
+	}
+	rows, err := bulkRowModel(resourceType)
+	if err != nil {
+		return nil, err
+	}
+	rowSchema, err := r.rowSchema(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.db.Unscoped().Where("id IN ?", ids).Find(rows).Error; err != nil {
+		return nil, err
+	}
+
+	slice := reflect.ValueOf(rows).Elem()
+	var unloaded []models.CustomFieldRecord
+	for i := 0; i < slice.Len(); i++ {
+		if record, ok := slice.Index(i).Addr().Interface().(models.CustomFieldRecord); ok && record.CustomFieldValues() == nil {
+			unloaded = append(unloaded, record)
+		}
+	}
+	// A handle without the custom field plugin has not loaded them.
+	if len(unloaded) > 0 {
+		if err := loadCustomFieldValues(r.db, unloaded); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := 0; i < slice.Len(); i++ {
+		id, state, err := rowState(rowSchema, slice.Index(i).Addr())
+		if err != nil {
+			return nil, err
+		}
+		states[id] = state
+	}
+	return states, nil
+}
+
+// rowState reads the state of the row row points at.
+func rowState(rowSchema *schema.Schema, row reflect.Value) (uint, models.BulkRowState, error) {
+	state := models.BulkRowState{}
+	var id uint
+	for _, field := range rowSchema.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		value, _ := field.ValueOf(context.Background(), row.Elem())
+		if field.DBName == "id" {
+			id, _ = value.(uint)
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return 0, nil, fmt.Errorf("reading %s: %w", field.DBName, err)
+		}
+		if secretColumns[field.DBName] {
+			digest := sha256.Sum256(raw)
+			raw, _ = json.Marshal(hex.EncodeToString(digest[:]))
+		}
+		state[field.DBName] = raw
+	}
+	if record, ok := row.Interface().(models.CustomFieldRecord); ok {
+		raw, err := json.Marshal(record.CustomFieldValues())
+		if err != nil {
+			return 0, nil, fmt.Errorf("reading custom fields: %w", err)
+		}
+		state["custom_fields"] = raw
+	}
+	return id, state, nil
+}
+
+// SnapshotChange compares the states GetRowStates read of a row before and
+// after an operation. It returns nil when nothing that can be restored changed.
+func SnapshotChange(before, after models.BulkRowState) *models.BulkItemSnapshot {
+	snapshot := &models.BulkItemSnapshot{Before: models.BulkRowState{}, After: models.BulkRowState{}}
+	for column, value := range after {
+		if untrackedColumns[column] || bytes.Equal(before[column], value) {
+			continue
+		}
+		if secretColumns[column] {
+			return &models.BulkItemSnapshot{Irreversible: fmt.Sprintf("the %s was changed", column)}
+		}
+		snapshot.Before[column] = before[column]
+		snapshot.After[column] = value
+	}
+	if len(snapshot.Before) == 0 {
+		return nil
+	}
+	snapshot.After["updated_at"] = after["updated_at"]
+	snapshot.After["deleted_at"] = after["deleted_at"]
+	return snapshot
+}
+
+// RestoreRow puts the columns and custom fields of one row back as snapshot
+// found them, in a transaction of its own. A row whose state is no longer the
+// one the operation left is not touched: apperrors.ErrBulkRowChanged.
+func (r *bulkRepository) RestoreRow(resourceType string, id uint, snapshot *models.BulkItemSnapshot) error {
+	return runIsolated(r.db, func(tx *gorm.DB) error {
+		txRepo := &bulkRepository{db: tx}
+		states, err := txRepo.GetRowStates(resourceType, []uint{id})
+		if err != nil {
+			return err
+		}
+		current, ok := states[id]
+		if !ok {
+			return fmt.Errorf("%s %d: %w", resourceType, id, apperrors.ErrBulkRowChanged)
+		}
+		for column, value := range snapshot.After {
+			if !bytes.Equal(current[column], value) {
+				return fmt.Errorf("%s %d: %w", resourceType, id, apperrors.ErrBulkRowChanged)
+			}
+		}
+
+		rows, _ := bulkRowModel(resourceType)
+		rowSchema, err := txRepo.rowSchema(rows)
+		if err != nil {
+			return err
+		}
+		columns := make(map[string]interface{}, len(snapshot.Before))
+		for column, raw := range snapshot.Before {
+			if column == "custom_fields" {
+				continue
+			}
+			field := rowSchema.LookUpField(column)
+			if field == nil || secretColumns[column] || untrackedColumns[column] {
+				return fmt.Errorf("cannot restore column %q of %s", column, resourceType)
+			}
+			value := reflect.New(field.FieldType)
+			if err := json.Unmarshal(raw, value.Interface()); err != nil {
+				return fmt.Errorf("restoring %s: %w", column, err)
+			}
+			columns[column] = value.Elem().Interface()
+		}
+		model := reflect.New(rowSchema.ModelType).Interface()
+		if len(columns) > 0 {
+			if err := tx.Unscoped().Model(model).Where("id = ?", id).Updates(columns).Error; err != nil {
+				return err
+			}
+		}
+
+		raw, ok := snapshot.Before["custom_fields"]
+		if !ok {
+			return nil
+		}
+		record, ok := model.(models.CustomFieldRecord)
+		if !ok {
+			return fmt.Errorf("%s carry no custom fields", resourceType)
+		}
+		if err := tx.Unscoped().First(model, id).Error; err != nil {
+			return err
+		}
+		var values map[string]interface{}
+		if err := json.Unmarshal(raw, &values); err != nil {
+			return fmt.Errorf("restoring custom fields: %w", err)
+		}
+		record.SetCustomFieldValues(values)
+		return writeCustomFields(tx, []models.CustomFieldRecord{record}, false)
+	})
+}
@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"gorm.io/gorm"
+)
+
+// CustomFieldPlugin keeps the CustomFields map of every entity that carries
+// custom fields (models.CustomFieldRecord) in step with custom_field_values,
+// wherever the entity is read or written: a repository method, a preload, a
+// bulk operation or a merge all see and save the values without knowing they
+// exist.
+//
+// After a query it loads the values of every record the statement returned, in
+// one round trip per statement, and sets a map holding every defined field of
+// the entity — nil for the ones the record has no value for. After a create or
+// an update of a record (never of a bare column map) it validates and writes
+// the map the record carries, then reloads it. A nil map leaves the values
+// alone, which is what every internal write that does not deal in custom fields
+// relies on; so required fields are enforced only on writes that send a map.
+// An update skips the fields whose value did not change, so saving a record
+// exactly as it was loaded never fails validation.
+//
+// Every statement the plugin issues runs on the statement's own connection, so
+// inside a transaction it is part of the transaction, and an error it adds
+// rolls the transaction back like any other.
+//
+// It is registered once on the application's database handle; a handle
+// without it, such as most test databases, simply never touches the values.
+type CustomFieldPlugin struct{}
+
+// Name identifies the plugin to gorm.
+func (CustomFieldPlugin) Name() string {
+	return "gophercrm:custom_fields"
+}
+
+// Initialize registers the callbacks.
+func (CustomFieldPlugin) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().After("gorm:query").
+		Register("gophercrm:load_custom_fields", loadCustomFields); err != nil {
+		return err
+	}
+	// Both writes run before the commit of gorm's default transaction, so that
+	// an invalid value rolls the insert or update back with it.
+	if err := db.Callback().Create().After("gorm:create").Before("gorm:commit_or_rollback_transaction").
+		Register("gophercrm:create_custom_fields", func(db *gorm.DB) { saveCustomFields(db, true) }); err != nil {
+		return err
+	}
+	return db.Callback().Update().After("gorm:update").Before("gorm:commit_or_rollback_transaction").
+		Register("gophercrm:update_custom_fields", func(db *gorm.DB) { saveCustomFields(db, false) })
+}
+
+// customFieldRecords returns the records a statement read or wrote, or nil
+// when its model carries no custom fields or its destination is not made of
+// that model (a count, a pluck, a scan into another struct).
+func customFieldRecords(db *gorm.DB) []models.CustomFieldRecord {
+	if db.Statement.Schema == nil {
+		return nil
+	}
+	if _, ok := reflect.New(db.Statement.Schema.ModelType).Interface().(models.CustomFieldRecord); !ok {
+		return nil
+	}
+
+	var records []models.CustomFieldRecord
+	collect := func(value reflect.Value) {
+		value = reflect.Indirect(value)
+		if value.Kind() != reflect.Struct || !value.CanAddr() {
+			return
+		}
+		if record, ok := value.Addr().Interface().(models.CustomFieldRecord); ok && record.CustomFieldRecordID() != 0 {
+			records = append(records, record)
+		}
+	}
+
+	value := db.Statement.ReflectValue
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			collect(value.Index(i))
+		}
+	case reflect.Struct:
+		collect(value)
+	}
+	return records
+}
+
+func loadCustomFields(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	records := customFieldRecords(db)
+	if len(records) == 0 {
+		return
+	}
+	if err := loadCustomFieldValues(db.Session(&gorm.Session{NewDB: true}), records); err != nil {
+		_ = db.AddError(fmt.Errorf("loading custom fields: %w", err))
+	}
+}
+
+// loadCustomFieldValues sets the custom field map of records, which must all be
+// of the same entity type.
+func loadCustomFieldValues(tx *gorm.DB, records []models.CustomFieldRecord) error {
+	definitions, err := customFieldDefinitions(tx, records[0].CustomFieldEntity())
+	if err != nil {
+		return err
+	}
+	if len(definitions) == 0 {
+		for _, record := range records {
+			record.SetCustomFieldValues(map[string]interface{}{})
+		}
+		return nil
+	}
+
+	stored, err := storedCustomFieldValues(tx, definitions, records)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		values := make(map[string]interface{}, len(definitions))
+		for _, definition := range definitions {
+			values[definition.Name] = definition.Decode(stored[record.CustomFieldRecordID()][definition.ID])
+		}
+		record.SetCustomFieldValues(values)
+	}
+	return nil
+}
+
+func customFieldDefinitions(tx *gorm.DB, entityType string) ([]models.CustomFieldDefinition, error) {
+	var definitions []models.CustomFieldDefinition
+	err := tx.Where("entity_type = ?", entityType).Order("position ASC, id ASC").Find(&definitions).Error
+	return definitions, err
+}
+
+// storedCustomFieldValues returns the stored rows of the records, by record id
+// and field id, in insertion order.
+func storedCustomFieldValues(tx *gorm.DB, definitions []models.CustomFieldDefinition, records []models.CustomFieldRecord) (map[uint]map[uint][]string, error) {
+	fieldIDs := make([]uint, len(definitions))
+	for i, definition := range definitions {
+		fieldIDs[i] = definition.ID
+	}
+	entityIDs := make([]uint, len(records))
+	for i, record := range records {
+		entityIDs[i] = record.CustomFieldRecordID()
+	}
+
+	var rows []models.CustomFieldValue
+	err := tx.Where("field_id IN ? AND entity_id IN ?", fieldIDs, entityIDs).Order("id ASC").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[uint]map[uint][]string, len(records))
+	for _, row := range rows {
+		if stored[row.EntityID] == nil {
+			stored[row.EntityID] = map[uint][]string{}
+		}
+		stored[row.EntityID][row.FieldID] = append(stored[row.EntityID][row.FieldID], row.Value)
+	}
+	return stored, nil
+}
+
+func saveCustomFields(db *gorm.DB, creating bool) {
+	if db.Error != nil {
+		return
+	}
+	// A column map (Updates, UpdateColumn) carries no custom fields, even when
+	// the model it is applied to happens to hold a loaded map.
+	switch db.Statement.Dest.(type) {
+	case map[string]interface{}, *map[string]interface{}, []map[string]interface{}:
+		return
+	}
+
+	var pending []models.CustomFieldRecord
+	for _, record := range customFieldRecords(db) {
+		values := record.CustomFieldValues()
+		if values == nil || (!creating && len(values) == 0) {
+			continue
+		}
+		pending = append(pending, record)
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	if err := writeCustomFields(db.Session(&gorm.Session{NewDB: true}), pending, creating); err != nil {
+		_ = db.AddError(err)
+	}
+}
+
+// writeCustomFields validates and writes the custom field maps of records of
+// one entity type, then reloads them. The plugin calls it for the records a
+// statement wrote; the bulk updates call it directly, since their column
+// updates carry no record.
+func writeCustomFields(tx *gorm.DB, records []models.CustomFieldRecord, creating bool) error {
+	definitions, err := customFieldDefinitions(tx, records[0].CustomFieldEntity())
+	if err != nil {
+		return fmt.Errorf("saving custom fields: %w", err)
+	}
+	for _, record := range records {
+		if err := writeCustomFieldValues(tx, definitions, record, creating); err != nil {
+			return err
+		}
+	}
+	if err := loadCustomFieldValues(tx, records); err != nil {
+		return fmt.Errorf("reloading custom fields: %w", err)
+	}
+	return nil
+}
+
+// writeCustomFieldValues validates the map of one record and replaces the
+// stored rows of every field whose value changed. Validation errors wrap
+// apperrors.ErrValidation.
+func writeCustomFieldValues(tx *gorm.DB, definitions []models.CustomFieldDefinition, record models.CustomFieldRecord, creating bool) error {
+	values := record.CustomFieldValues()
+	known := make(map[string]bool, len(definitions))
+	for _, definition := range definitions {
+		known[definition.Name] = true
+	}
+	for name := range values {
+		if !known[name] {
+			return fmt.Errorf("unknown custom field %q for %s: %w", name, record.CustomFieldEntity(), apperrors.ErrValidation)
+		}
+	}
+
+	var stored map[uint][]string
+	if !creating {
+		all, err := storedCustomFieldValues(tx, definitions, []models.CustomFieldRecord{record})
+		if err != nil {
+			return fmt.Errorf("saving custom fields: %w", err)
+		}
+		stored = all[record.CustomFieldRecordID()]
+	}
+
+	for i := range definitions {
+		definition := &definitions[i]
+		value, given := values[definition.Name]
+		if !given {
+			if creating && definition.Required {
+				return fmt.Errorf("custom field %q is required: %w", definition.Name, apperrors.ErrValidation)
+			}
+			continue
+		}
+		encoded, err := definition.Encode(value)
+		if err != nil {
+			return fmt.Errorf("%w: %w", err, apperrors.ErrValidation)
+		}
+		// Checked before the required flag, so that a record which predates a
+		// field being made required can still be saved as it was loaded.
+		if !creating && reflect.DeepEqual(definition.Decode(stored[definition.ID]), definition.Decode(encoded)) {
+			continue
+		}
+		if len(encoded) == 0 && definition.Required {
+			return fmt.Errorf("custom field %q is required: %w", definition.Name, apperrors.ErrValidation)
+		}
+		if err := replaceCustomFieldValue(tx, definition, record.CustomFieldRecordID(), encoded); err != nil {
+			return fmt.Errorf("saving custom field %q: %w", definition.Name, err)
+		}
+	}
+	return nil
+}
+
+func replaceCustomFieldValue(tx *gorm.DB, definition *models.CustomFieldDefinition, entityID uint, encoded []string) error {
+	if err := tx.Where("field_id = ? AND entity_id = ?", definition.ID, entityID).
+		Delete(&models.CustomFieldValue{}).Error; err != nil {
+		return err
+	}
+	if len(encoded) == 0 {
+		return nil
+	}
+	rows := make([]models.CustomFieldValue, len(encoded))
+	for i, value := range encoded {
+		rows[i] = models.CustomFieldValue{FieldID: definition.ID, EntityID: entityID, Value: value}
+		if definition.Type == models.CustomFieldNumber {
+			if number, err := strconv.ParseFloat(value, 64); err == nil {
+				rows[i].Number = &number
+			}
+		}
+	}
+	return tx.Create(&rows).Error
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"fmt"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type customFieldRepository struct {
+	db *gorm.DB
+}
+
+func NewCustomFieldRepository(db *gorm.DB) CustomFieldRepository {
+	return &customFieldRepository{db: db}
+}
+
+func (r *customFieldRepository) WithTx(tx *gorm.DB) CustomFieldRepository {
+	return &customFieldRepository{db: tx}
+}
+
+func (r *customFieldRepository) ListDefinitions(entityType string) ([]models.CustomFieldDefinition, error) {
+	definitions := []models.CustomFieldDefinition{}
+	query := r.db.Order("entity_type ASC, position ASC, id ASC")
+	if entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	err := query.Find(&definitions).Error
+	return definitions, err
+}
+
+func (r *customFieldRepository) GetDefinition(id uint) (*models.CustomFieldDefinition, error) {
+	var definition models.CustomFieldDefinition
+	if err := r.db.First(&definition, id).Error; err != nil {
+		return nil, err
+	}
+	return &definition, nil
+}
+
+func (r *customFieldRepository) CountDefinitions(entityType string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.CustomFieldDefinition{}).Where("entity_type = ?", entityType).Count(&count).Error
+	return count, err
+}
+
+func (r *customFieldRepository) CreateDefinition(definition *models.CustomFieldDefinition) error {
+	if err := r.db.Create(definition).Error; err != nil {
+		if isDuplicateKeyError(err) {
+			return fmt.Errorf("a %s custom field named %q already exists: %w", definition.EntityType, definition.Name, apperrors.ErrValidation)
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *customFieldRepository) UpdateDefinition(definition *models.CustomFieldDefinition) error {
+	return r.db.Save(definition).Error
+}
+
+// DeleteDefinition is a hard delete: a definition nobody can see again has no
+// business keeping its values, and dropping both frees the name for reuse.
+func (r *customFieldRepository) DeleteDefinition(id uint) error {
+	return runInTransaction(r.db, func(tx *gorm.DB) error {
+		if err := tx.Where("field_id = ?", id).Delete(&models.CustomFieldValue{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.CustomFieldDefinition{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// whereCustomFields adds one correlated EXISTS per filter, so several filters
+// on one multi-select field require every option rather than any of them.
+func whereCustomFields(query *gorm.DB, table string, filters []models.CustomFieldFilter) *gorm.DB {
+	for _, filter := range filters {
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM custom_field_values WHERE custom_field_values.field_id = ? AND custom_field_values.entity_id = "+table+".id AND custom_field_values.value = ?)",
+			filter.FieldID, filter.Value,
+		)
+	}
+	return query
+}
+
+// orderByCustomField orders by the value one field holds, with the id as tie
+// breaker so that pages are stable across records without a value. It is
+// applied after the count, which has no use for an ORDER BY. table is always
+// the repository's own table name, never input.
+func orderByCustomField(query *gorm.DB, table string, sort *models.CustomFieldSort) *gorm.DB {
+	column := "value"
+	if sort.Numeric {
+		column = "number"
+	}
+	direction := sortDirection(sort.Desc)
+	return query.Order(clause.OrderBy{Expression: clause.Expr{
+		SQL: "(SELECT MIN(custom_field_values." + column + ") FROM custom_field_values" +
+			" WHERE custom_field_values.field_id = ? AND custom_field_values.entity_id = " + table + ".id) " +
+			direction + ", " + table + ".id " + direction,
+		Vars:               []interface{}{sort.FieldID},
+		WithoutParentheses: true,
+	}})
+}
+
+func sortDirection(desc bool) string {
+	if desc {
+		return "DESC"
+	}
+	return "ASC"
+}
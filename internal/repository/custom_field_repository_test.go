@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupCustomFieldDB opens a private database with the plugin registered, the
+// way cmd/main.go registers it on the application's.
+func setupCustomFieldDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Customer{}, &models.Task{}, &models.Label{},
+		&models.AuditEvent{}, &models.ActivityEvent{}, &models.WebhookDelivery{},
+		&models.CustomFieldDefinition{}, &models.CustomFieldValue{}, &models.BulkOperation{}, &models.BulkOperationItem{}, &models.Import{}))
+	require.NoError(t, db.Use(CustomFieldPlugin{}))
+	return db
+}
+
+func createCustomFieldDefinition(t *testing.T, repo CustomFieldRepository, definition models.CustomFieldDefinition) models.CustomFieldDefinition {
+	t.Helper()
+	require.NoError(t, definition.ValidateDefinition())
+	require.NoError(t, repo.CreateDefinition(&definition))
+	return definition
+}
+
+func createCustomFieldCustomer(t *testing.T, db *gorm.DB, email string, values map[string]interface{}) *models.Customer {
+	t.Helper()
+	customer := &models.Customer{FirstName: "Ada", LastName: "Lovelace", Email: email, CustomFields: values}
+	require.NoError(t, NewCustomerRepository(db).Create(customer))
+	return customer
+}
+
+func TestCustomFieldPlugin_SavesAndLoadsValues(t *testing.T) {
+	db := setupCustomFieldDB(t)
+	fields := NewCustomFieldRepository(db)
+	createCustomFieldDefinition(t, fields, models.CustomFieldDefinition{EntityType: models.AuditEntityCustomer, Name: "tier", Type: models.CustomFieldSelect, Options: []string{"gold", "silver"}})
+	createCustomFieldDefinition(t, fields, models.CustomFieldDefinition{EntityType: models.AuditEntityCustomer, Name: "seats", Type: models.CustomFieldNumber})
+	createCustomFieldDefinition(t, fields, models.CustomFieldDefinition{EntityType: models.AuditEntityCustomer, Name: "markets", Type: models.CustomFieldMultiSelect, Options: []string{"emea", "apac", "amer"}})
+
+	customer := createCustomFieldCustomer(t, db, "ada@example.com", map[string]interface{}{
+		"tier": "gold", "seats": 12.0, "markets": []interface{}{"amer", "emea"},
+	})
+	assert.Equal(t, []string{"emea", "amer"}, customer.CustomFields["markets"], "the saved record carries the stored form")
+
+	repo := NewCustomerRepository(db)
+	loaded, err := repo.GetByID(customer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"tier": "gold", "seats": 12.0, "markets": []string{"emea", "amer"}}, loaded.CustomFields)
+
+	// A save that sends only some fields leaves the others alone; nil clears.
+	loaded.CustomFields = map[string]interface{}{"seats": nil}
+	require.NoError(t, repo.Update(loaded))
+	reloaded, err := repo.GetByID(customer.ID)
+	require.NoError(t, err)
+	assert.Nil(t, reloaded.CustomFields["seats"])
+	assert.Equal(t, "gold", reloaded.CustomFields["tier"])
+
+	// A column-map update never touches the values.
+	require.NoError(t, db.Model(&models.Customer{}).Where("id = ?", customer.ID).Updates(map[string]interface{}{"notes": "vip"}).Error)
+	listed, err := repo.List(0, 10)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, "gold", listed[0].CustomFields["tier"], "lists load the values too")
+}
+
+func TestCustomFieldPlugin_ValidatesWrites(t *testing.T) {
+	db := setupCustomFieldDB(t)
+	fields := NewCustomFieldRepository(db)
+	createCustomFieldDefinition(t, fields, models.CustomFieldDefinition{EntityType: models.AuditEntityCustomer, Name: "tier", Type: models.CustomFieldSelect, Options: []string{"gold"}, Required: true})
+	repo := NewCustomerRepository(db)
+
+	err := repo.Create(&models.Customer{FirstName: "A", LastName: "B", Email: "a@example.com", CustomFields: map[string]interface{}{}})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, apperrors.ErrValidation), "a required field is enforced on create")
+
+	err = repo.Create(&models.Customer{FirstName: "A", LastName: "B", Email: "a@example.com", CustomFields: map[string]interface{}{"tier": "gold", "colour": "red"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown custom field "colour"`)
+
+	err = repo.Create(&models.Customer{FirstName: "A", LastName: "B", Email: "a@example.com", CustomFields: map[string]interface{}{"tier": "bronze"}})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, apperrors.ErrValidation))
+
+	var count int64
+	require.NoError(t, db.Model(&models.Customer{}).Count(&count).Error)
+	assert.Zero(t, count, "a rejected value rolls the insert back")
+
+	// Internal writes that carry no map are left alone.
+	require.NoError(t, repo.Create(&models.Customer{FirstName: "A", LastName: "B", Email: "b@example.com"}))
+}
+
+func TestCustomFieldPlugin_RequiredFieldAddedLater(t *testing.T) {
+	db := setupCustomFieldDB(t)
+	fields := NewCustomFieldRepository(db)
+	customer := createCustomFieldCustomer(t, db, "ada@example.com", map[string]interface{}{})
+	createCustomFieldDefinition(t, fields, models.CustomFieldDefinition{EntityType: models.AuditEntityCustomer, Name: "tier", Type: models.CustomFieldText, Required: true})
+
+	repo := NewCustomerRepository(db)
+	loaded, err := repo.GetByID(customer.ID)
+	require.NoError(t, err)
+	loaded.Notes = "edited"
+	require.NoError(t, repo.Update(loaded), "a record saved as it was loaded is not held to a newer rule")
+}
+
+func TestCustomFieldRepository_FiltersAndSorts(t *testing.T) {
+	db := setupCustomFieldDB(t)
+	fields := NewCustomFieldRepository(db)
+	tier := createCustomFieldDefinition(t, fields, models.CustomFieldDefinition{EntityType: models.AuditEntityCustomer, Name: "tier", Type: models.CustomFieldText})
+	seats := createCustomFieldDefinition(t, fields, models.CustomFieldDefinition{EntityType: models.AuditEntityCustomer, Name: "seats", Type: models.CustomFieldNumber})
+	markets := createCustomFieldDefinition(t, fields, models.CustomFieldDefinition{EntityType: models.AuditEntityCustomer, Name: "markets", Type: models.CustomFieldMultiSelect, Options: []string{"emea", "apac"}})
+
+	a := createCustomFieldCustomer(t, db, "a@example.com", map[string]interface{}{"tier": "gold", "seats": 9, "markets": []string{"emea", "apac"}})
+	b := createCustomFieldCustomer(t, db, "b@example.com", map[string]interface{}{"tier": "gold", "seats": 10, "markets": []string{"emea"}})
+	c := createCustomFieldCustomer(t, db, "c@example.com", map[string]interface{}{"tier": "silver"})
+
+	repo := NewCustomerRepository(db)
+	ids := func(customers []models.Customer) []uint {
+		out := make([]uint, len(customers))
+		for i, customer := range customers {
+			out[i] = customer.ID
+		}
+		return out
+	}
+
+	customers, total, err := repo.ListFiltered(models.CustomerFilter{CustomFields: models.CustomFieldQuery{
+		Filters: []models.CustomFieldFilter{{FieldID: tier.ID, Value: "gold"}},
+	}}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.ElementsMatch(t, []uint{a.ID, b.ID}, ids(customers))
+
+	customers, _, err = repo.ListFiltered(models.CustomerFilter{CustomFields: models.CustomFieldQuery{
+		Filters: []models.CustomFieldFilter{{FieldID: markets.ID, Value: "emea"}, {FieldID: markets.ID, Value: "apac"}},
+	}}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{a.ID}, ids(customers), "repeated filters require every value")
+
+	// Numbers sort numerically (9 before 10), records without a value first.
+	customers, _, err = repo.ListFiltered(models.CustomerFilter{CustomFields: models.CustomFieldQuery{
+		Sort: &models.CustomFieldSort{FieldID: seats.ID, Numeric: true},
+	}}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{c.ID, a.ID, b.ID}, ids(customers))
+
+	customers, total, err = repo.ListFiltered(models.CustomerFilter{Search: "b@example", CustomFields: models.CustomFieldQuery{
+		Sort: &models.CustomFieldSort{FieldID: tier.ID, Desc: true},
+	}}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, []uint{b.ID}, ids(customers))
+}
+
+func TestCustomFieldRepository_DeleteDefinitionDropsItsValues(t *testing.T) {
+	db := setupCustomFieldDB(t)
+	fields := NewCustomFieldRepository(db)
+	tier := createCustomFieldDefinition(t, fields, models.CustomFieldDefinition{EntityType: models.AuditEntityCustomer, Name: "tier", Type: models.CustomFieldText})
+	createCustomFieldCustomer(t, db, "a@example.com", map[string]interface{}{"tier": "gold"})
+
+	err := fields.CreateDefinition(&models.CustomFieldDefinition{EntityType: models.AuditEntityCustomer, Name: "tier", Label: "tier", Type: models.CustomFieldText})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, apperrors.ErrValidation), "names are unique per entity type")
+
+	require.NoError(t, fields.DeleteDefinition(tier.ID))
+	var values int64
+	require.NoError(t, db.Model(&models.CustomFieldValue{}).Count(&values).Error)
+	assert.Zero(t, values)
+	assert.ErrorIs(t, fields.DeleteDefinition(tier.ID), gorm.ErrRecordNotFound)
+}
+
+func TestCustomFieldBulkUpdateAndErasure(t *testing.T) {
+	db := setupCustomFieldDB(t)
+	fields := NewCustomFieldRepository(db)
+	createCustomFieldDefinition(t, fields, models.CustomFieldDefinition{EntityType: models.AuditEntityCustomer, Name: "tier", Type: models.CustomFieldText})
+	createCustomFieldDefinition(t, fields, models.CustomFieldDefinition{EntityType: models.AuditEntityTask, Name: "tier", Type: models.CustomFieldText})
+	customer := createCustomFieldCustomer(t, db, "a@example.com", map[string]interface{}{"tier": "gold"})
+	task := &models.Task{Title: "Call", AssignedToID: 1, CustomFields: map[string]interface{}{"tier": "task"}}
+	require.NoError(t, NewTaskRepository(db).Create(task))
+
+	updated, errs := NewBulkRepository(db).BulkUpdateCustomers([]models.BulkUpdateItem{
+		{ID: customer.ID, Updates: map[string]interface{}{"notes": "vip", "custom_fields": map[string]interface{}{"tier": "platinum"}}},
+		{ID: customer.ID, Updates: map[string]interface{}{"custom_fields": map[string]interface{}{"colour": "red"}}},
+	})
+	require.Len(t, updated, 1)
+	assert.Equal(t, "vip", updated[0].Notes)
+	assert.Equal(t, "platinum", updated[0].CustomFields["tier"])
+	require.Len(t, errs, 1)
+	assert.True(t, errors.Is(errs[0], apperrors.ErrValidation))
+
+	// Erasing the customer drops its values, and only its own: the task's
+	// field shares the name and the record id, but not the entity type.
+	require.NoError(t, NewCustomerRepository(db).Delete(customer.ID))
+	var values []models.CustomFieldValue
+	require.NoError(t, db.Find(&values).Error)
+	require.Len(t, values, 1)
+	assert.Equal(t, "task", values[0].Value)
+}
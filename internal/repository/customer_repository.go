@@ -85,8 +85,9 @@ func (r *customerRepository) Update(customer *models.Customer) error {
 // Delete erases a customer under the GDPR right to erasure (Article 17).
 //
 // As with users, this is NOT deactivation: it is irreversible. Every
-// personal-data field on the customer — names, email, phone, company, position,
-// the full postal address and the free-text notes — is overwritten in place,
+// personal-data field on the customer — names, email, phone, company (and the
+// account link that says the same thing), position, the full postal address and
+// the free-text notes — is overwritten in place,
 // and only then is the row soft-deleted, in the SAME transaction, so a crash
 // cannot leave a live anonymised record behind.
 //
@@ -113,7 +114,10 @@ func (r *customerRepository) Delete(id uint) error {
 			"first_name":  "",
 			"last_name":   "",
 			"phone":       "",
+			"email_key":   "",
+			"phone_key":   "",
 			"company":     "",
+			"account_id":  nil,
 			"position":    "",
 			"address":     "",
 			"city":        "",
@@ -122,6 +126,8 @@ func (r *customerRepository) Delete(id uint) error {
 			"postal_code": "",
 			"notes":       "",
 		},
+		AuditEntity:  models.AuditEntityCustomer,
+		CustomFields: true,
 	})
 }
 
@@ -197,72 +203,70 @@ func (r *customerRepository) Search(query string, offset, limit int, sortBy, sor
 	return customers, err
 }
 
-// exportBatchSize is how many customers are read per round trip by
-// ListAllForExport. Big enough that a full export is a handful of queries,
-// small enough that no single result set has to be held in the driver at once.
-const exportBatchSize = 500
+func (r *customerRepository) CountSearch(query string) (int64, error) {
+	var count int64
+	searchPattern := "%" + query + "%"
+	err := r.db.Model(&models.Customer{}).Where(
+		"first_name LIKE ? OR last_name LIKE ? OR email LIKE ? OR company LIKE ? OR phone LIKE ? OR notes LIKE ?",
+		searchPattern, searchPattern, searchPattern, searchPattern, searchPattern, searchPattern,
+	).Count(&count).Error
+	return count, err
+}
 
-// ListAllForExport returns every customer matching the optional search term,
-// with no pagination at all — the export is a whole-book download, and a page
-// boundary in the middle of it would produce a file that looks complete and is
-// not.
-//
-// Rows are read in batches rather than in one unbounded query so the driver
-// never has to materialise the entire table in a single result set. The
-// accumulated slice is still held in memory, which is the deliberate trade: this
-// deployment's customer table is small, and the alternative (streaming rows
-// straight into the HTTP response) would mean a mid-stream failure arriving
-// after a 200 and half a file.
-//
-// FindInBatches drives an ordered keyset scan on the primary key by itself, so
-// this is portable across MySQL and SQLite without any driver-specific SQL.
-//
-// Soft-deleted rows are excluded by GORM's own scope: an erased customer must
-// never reappear in an export.
-//
-// sortBy goes through the same allowlist as every other sorted query. An
-// unrecognised column is an error, never interpolated.
-func (r *customerRepository) ListAllForExport(search, sortBy, sortOrder string) ([]models.Customer, error) {
-	db := r.db.Model(&models.Customer{})
+func (r *customerRepository) ListFiltered(filter models.CustomerFilter, offset, limit int, preloads ...string) ([]models.Customer, int64, error) {
+	query, err := r.filteredQuery(filter)
+	if err != nil {
+		return nil, 0, err
+	}
 
-	if search != "" {
-		searchPattern := "%" + search + "%"
-		db = db.Where(
-			"first_name LIKE ? OR last_name LIKE ? OR email LIKE ? OR company LIKE ? OR phone LIKE ? OR notes LIKE ?",
-			searchPattern, searchPattern, searchPattern, searchPattern, searchPattern, searchPattern,
-		)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
 	}
 
-	if sortBy != "" {
-		orderClause, err := utils.SafeOrderClause("customers", sortBy, sortOrder)
+	for _, preload := range preloads {
+		query = query.Preload(preload)
+	}
+	if filter.Cursor != nil {
+		query, err = seekCursor(query, "customers", filter.Cursor)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
+		}
+	} else if filter.CustomFields.Sort != nil {
+		query = orderByCustomField(query, "customers", filter.CustomFields.Sort)
+	} else if filter.SortBy != "" {
+		orderClause, err := utils.SafeOrderClause("customers", filter.SortBy, filter.SortOrder)
+		if err != nil {
+			return nil, 0, err
 		}
 		if orderClause != "" {
-			db = db.Order(orderClause)
+			query = query.Order(orderClause)
 		}
 	}
+	customers := []models.Customer{}
+	err = query.Offset(offset).Limit(limit).Find(&customers).Error
+	return customers, total, err
+}
 
-	customers := make([]models.Customer, 0)
-	var batch []models.Customer
-	result := db.FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
-		customers = append(customers, batch...)
-		return nil
-	})
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	return customers, nil
+// StreamFiltered hands every customer matching filter to fn, a batch at a time, in
+// the order of its sort with the id breaking ties.
+func (r *customerRepository) StreamFiltered(filter models.CustomerFilter, fn func([]models.Customer) error) error {
+	return streamFiltered(func() (*gorm.DB, error) { return r.filteredQuery(filter) }, "customers", filter.SortBy, filter.SortOrder, fn)
 }
 
-func (r *customerRepository) CountSearch(query string) (int64, error) {
-	var count int64
-	searchPattern := "%" + query + "%"
-	err := r.db.Model(&models.Customer{}).Where(
-		"first_name LIKE ? OR last_name LIKE ? OR email LIKE ? OR company LIKE ? OR phone LIKE ? OR notes LIKE ?",
-		searchPattern, searchPattern, searchPattern, searchPattern, searchPattern, searchPattern,
-	).Count(&count).Error
-	return count, err
+// filteredQuery narrows the customers to those matching every condition of
+// filter, for ListFiltered and StreamFiltered.
+func (r *customerRepository) filteredQuery(filter models.CustomerFilter) (*gorm.DB, error) {
+	query := r.db.Model(&models.Customer{})
+	if filter.Search != "" {
+		searchPattern := "%" + filter.Search + "%"
+		query = query.Where(
+			"first_name LIKE ? OR last_name LIKE ? OR email LIKE ? OR company LIKE ? OR phone LIKE ? OR notes LIKE ?",
+			searchPattern, searchPattern, searchPattern, searchPattern, searchPattern, searchPattern,
+		)
+	}
+	query = whereCustomFields(query, "customers", filter.CustomFields.Filters)
+	return whereFilter(query, "customers", filter.Where)
 }
 
 func (r *customerRepository) Count() (int64, error) {
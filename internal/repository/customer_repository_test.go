@@ -22,8 +22,10 @@ func setupCustomerExportDB(t *testing.T) *gorm.DB {
 	// A private schema per test: the shared-cache DSN above is reused, so the
 	// tables are dropped and recreated rather than accumulating rows between
 	// tests.
-	require.NoError(t, db.Migrator().DropTable(&models.Customer{}, &models.User{}))
-	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Customer{}))
+	require.NoError(t, db.Migrator().DropTable(&models.AuditEvent{}, &models.ActivityEvent{}, &models.WebhookDelivery{},
+		&models.CustomFieldDefinition{}, &models.CustomFieldValue{}, &models.BulkOperation{}, &models.BulkOperationItem{}, &models.Import{}, &models.Customer{}, &models.User{}))
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Customer{}, &models.AuditEvent{}, &models.ActivityEvent{}, &models.WebhookDelivery{},
+		&models.CustomFieldDefinition{}, &models.CustomFieldValue{}, &models.BulkOperation{}, &models.BulkOperationItem{}, &models.Import{}))
 
 	t.Cleanup(func() {
 		sqlDB, err := db.DB()
@@ -34,12 +36,27 @@ func setupCustomerExportDB(t *testing.T) *gorm.DB {
 	return db
 }
 
+// exportCustomers collects what StreamFiltered hands over, failing on a
+// batch larger than the export is meant to hold in memory.
+func exportCustomers(repo CustomerRepository, search, sortBy, sortOrder string) ([]models.Customer, error) {
+	customers := []models.Customer{}
+	filter := models.CustomerFilter{Search: search, SortBy: sortBy, SortOrder: sortOrder}
+	err := repo.StreamFiltered(filter, func(batch []models.Customer) error {
+		if len(batch) > exportBatchSize {
+			return fmt.Errorf("batch of %d customers", len(batch))
+		}
+		customers = append(customers, batch...)
+		return nil
+	})
+	return customers, err
+}
+
 // The export exists to produce the WHOLE book of customers, so the one thing it
 // must never do is silently stop at a page boundary. The row count here is
 // comfortably past the 20/100 limits the list endpoints work in and past the
 // repository's own batch size, so a batching bug that dropped or repeated a
 // batch shows up as a wrong count rather than as a plausible-looking file.
-func TestListAllForExport_ReturnsEveryRowRegardlessOfPageSize(t *testing.T) {
+func TestStreamFiltered_ReturnsEveryRowRegardlessOfPageSize(t *testing.T) {
 	db := setupCustomerExportDB(t)
 	repo := NewCustomerRepository(db)
 
@@ -52,7 +69,7 @@ func TestListAllForExport_ReturnsEveryRowRegardlessOfPageSize(t *testing.T) {
 		}).Error)
 	}
 
-	customers, err := repo.ListAllForExport("", "", "")
+	customers, err := exportCustomers(repo, "", "", "")
 	require.NoError(t, err)
 	assert.Len(t, customers, total)
 
@@ -70,7 +87,7 @@ func TestListAllForExport_ReturnsEveryRowRegardlessOfPageSize(t *testing.T) {
 
 // An erased customer has already exercised its right to be forgotten. It must
 // not reappear in an export.
-func TestListAllForExport_ExcludesSoftDeletedRows(t *testing.T) {
+func TestStreamFiltered_ExcludesSoftDeletedRows(t *testing.T) {
 	db := setupCustomerExportDB(t)
 	repo := NewCustomerRepository(db)
 
@@ -80,26 +97,26 @@ func TestListAllForExport_ExcludesSoftDeletedRows(t *testing.T) {
 	require.NoError(t, db.Create(erased).Error)
 	require.NoError(t, repo.Delete(erased.ID))
 
-	customers, err := repo.ListAllForExport("", "", "")
+	customers, err := exportCustomers(repo, "", "", "")
 	require.NoError(t, err)
 	require.Len(t, customers, 1)
 	assert.Equal(t, live.ID, customers[0].ID)
 }
 
-func TestListAllForExport_HonoursSearch(t *testing.T) {
+func TestStreamFiltered_HonoursSearch(t *testing.T) {
 	db := setupCustomerExportDB(t)
 	repo := NewCustomerRepository(db)
 
 	require.NoError(t, db.Create(&models.Customer{FirstName: "John", LastName: "Doe", Email: "john@acme.test", Company: "Acme Corp"}).Error)
 	require.NoError(t, db.Create(&models.Customer{FirstName: "Jane", LastName: "Smith", Email: "jane@globex.test", Company: "Globex"}).Error)
 
-	customers, err := repo.ListAllForExport("acme", "", "")
+	customers, err := exportCustomers(repo, "acme", "", "")
 	require.NoError(t, err)
 	require.Len(t, customers, 1)
 	assert.Equal(t, "john@acme.test", customers[0].Email)
 }
 
-func TestListAllForExport_HonoursSortOrder(t *testing.T) {
+func TestStreamFiltered_HonoursSortOrder(t *testing.T) {
 	db := setupCustomerExportDB(t)
 	repo := NewCustomerRepository(db)
 
@@ -107,7 +124,7 @@ func TestListAllForExport_HonoursSortOrder(t *testing.T) {
 		require.NoError(t, db.Create(&models.Customer{FirstName: "X", LastName: "Y", Email: email}).Error)
 	}
 
-	customers, err := repo.ListAllForExport("", "email", "desc")
+	customers, err := exportCustomers(repo, "", "email", "desc")
 	require.NoError(t, err)
 	require.Len(t, customers, 3)
 	assert.Equal(t, []string{"c@example.com", "b@example.com", "a@example.com"},
@@ -116,16 +133,65 @@ func TestListAllForExport_HonoursSortOrder(t *testing.T) {
 
 // The sort column is the SQL-injection surface. An unvalidated column must be
 // refused rather than interpolated.
-func TestListAllForExport_RejectsUnknownSortColumn(t *testing.T) {
+func TestStreamFiltered_RejectsUnknownSortColumn(t *testing.T) {
 	db := setupCustomerExportDB(t)
 	repo := NewCustomerRepository(db)
 
 	require.NoError(t, db.Create(&models.Customer{FirstName: "X", LastName: "Y", Email: "x@example.com"}).Error)
 
-	_, err := repo.ListAllForExport("", "email; DROP TABLE customers", "asc")
+	_, err := exportCustomers(repo, "", "email; DROP TABLE customers", "asc")
 	assert.Error(t, err)
 
 	var count int64
 	require.NoError(t, db.Model(&models.Customer{}).Count(&count).Error)
 	assert.Equal(t, int64(1), count, "the customers table must still be there")
 }
+
+// A sort column with far fewer distinct values than rows puts every batch
+// boundary inside a run of equal values. The id breaks those ties, so each
+// row is still exported exactly once and in order.
+func TestStreamFiltered_SortedAcrossBatches(t *testing.T) {
+	db := setupCustomerExportDB(t)
+	repo := NewCustomerRepository(db)
+
+	const total = 1250
+	for i := 0; i < total; i++ {
+		require.NoError(t, db.Create(&models.Customer{
+			FirstName: "Sorted",
+			LastName:  "Exportable",
+			Email:     fmt.Sprintf("sorted%04d@example.com", i),
+			Company:   fmt.Sprintf("Company %d", i%3),
+		}).Error)
+	}
+
+	customers, err := exportCustomers(repo, "", "company", "desc")
+	require.NoError(t, err)
+	require.Len(t, customers, total)
+
+	seen := map[uint]bool{}
+	for i, c := range customers {
+		require.False(t, seen[c.ID], "customer %d exported twice", c.ID)
+		seen[c.ID] = true
+		if i > 0 {
+			prev := customers[i-1]
+			require.True(t, prev.Company > c.Company || (prev.Company == c.Company && prev.ID > c.ID),
+				"row %d out of order", i)
+		}
+	}
+}
+
+func TestStreamFiltered_StopsOnCallbackError(t *testing.T) {
+	db := setupCustomerExportDB(t)
+	repo := NewCustomerRepository(db)
+	for i := 0; i < exportBatchSize+1; i++ {
+		require.NoError(t, db.Create(&models.Customer{FirstName: "X", LastName: "Y", Email: fmt.Sprintf("stop%04d@example.com", i)}).Error)
+	}
+
+	calls := 0
+	err := repo.StreamFiltered(models.CustomerFilter{}, func([]models.Customer) error {
+		calls++
+		return fmt.Errorf("client went away")
+	})
+	assert.EqualError(t, err, "client went away")
+	assert.Equal(t, 1, calls, "no batch is read after the callback fails")
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"fmt"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type dealRepository struct {
+	db *gorm.DB
+}
+
+func NewDealRepository(db *gorm.DB) DealRepository {
+	return &dealRepository{db: db}
+}
+
+func (r *dealRepository) WithTx(tx *gorm.DB) DealRepository {
+	return &dealRepository{db: tx}
+}
+
+func (r *dealRepository) ListStages() ([]models.PipelineStage, error) {
+	stages := []models.PipelineStage{}
+	err := r.db.Order("position ASC, id ASC").Find(&stages).Error
+	return stages, err
+}
+
+func (r *dealRepository) GetStage(id uint) (*models.PipelineStage, error) {
+	var stage models.PipelineStage
+	if err := r.db.First(&stage, id).Error; err != nil {
+		return nil, err
+	}
+	return &stage, nil
+}
+
+// CreateStage and UpdateStage report a name another stage already has as
+// apperrors.ErrValidation; the unique index on the name is the only check.
+func (r *dealRepository) CreateStage(stage *models.PipelineStage) error {
+	return stageNameError(stage, r.db.Create(stage).Error)
+}
+
+func (r *dealRepository) UpdateStage(stage *models.PipelineStage) error {
+	return stageNameError(stage, r.db.Save(stage).Error)
+}
+
+func stageNameError(stage *models.PipelineStage, err error) error {
+	if isDuplicateKeyError(err) {
+		return fmt.Errorf("a pipeline stage named %q already exists: %w", stage.Name, apperrors.ErrValidation)
+	}
+	return err
+}
+
+func (r *dealRepository) DeleteStage(id uint) error {
+	result := r.db.Delete(&models.PipelineStage{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *dealRepository) CountDealsInStage(stageID uint) (int64, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.Deal{}).Where("stage_id = ?", stageID).Count(&count).Error
+	return count, err
+}
+
+func (r *dealRepository) InitializeDefaultStages() error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.PipelineStage{}).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		stages := append([]models.PipelineStage(nil), models.DefaultPipelineStages...)
+		return tx.Create(&stages).Error
+	})
+}
+
+// Create inserts the deal without its preloaded associations, so a customer or
+// owner attached to it is never written back.
+func (r *dealRepository) Create(deal *models.Deal, entry *models.DealStageChange) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(deal).Error; err != nil {
+			return err
+		}
+		entry.DealID = deal.ID
+		return tx.Omit(clause.Associations).Create(entry).Error
+	})
+}
+
+func (r *dealRepository) GetByID(id uint) (*models.Deal, error) {
+	var deal models.Deal
+	err := r.db.Preload("Customer").Preload("Owner").Preload("Stage").First(&deal, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &deal, nil
+}
+
+func (r *dealRepository) Update(deal *models.Deal, entry *models.DealStageChange) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(deal).Error; err != nil {
+			return err
+		}
+		if entry == nil {
+			return nil
+		}
+		entry.DealID = deal.ID
+		return tx.Omit(clause.Associations).Create(entry).Error
+	})
+}
+
+func (r *dealRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.Deal{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *dealRepository) List(filter models.DealFilter, offset, limit int) ([]models.Deal, int64, error) {
+	query := r.db.Model(&models.Deal{})
+	if filter.OwnerID != 0 {
+		query = query.Where("deals.owner_id = ?", filter.OwnerID)
+	}
+	if filter.CustomerID != 0 {
+		query = query.Where("deals.customer_id = ?", filter.CustomerID)
+	}
+	if filter.StageID != 0 {
+		query = query.Where("deals.stage_id = ?", filter.StageID)
+	}
+	if filter.Outcome != "" {
+		query = query.Joins("JOIN pipeline_stages ON pipeline_stages.id = deals.stage_id").
+			Where("pipeline_stages.outcome = ?", filter.Outcome)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Cursor != nil {
+		var err error
+		if query, err = seekCursor(query, "deals", filter.Cursor); err != nil {
+			return nil, 0, err
+		}
+	} else {
+		query = query.Order("deals.id DESC")
+	}
+	deals := []models.Deal{}
+	err := query.Preload("Customer").Preload("Owner").Preload("Stage").
+		Offset(offset).Limit(limit).Find(&deals).Error
+	return deals, total, err
+}
+
+func (r *dealRepository) StageHistory(dealID uint) ([]models.DealStageChange, error) {
+	history := []models.DealStageChange{}
+	err := r.db.Preload("ChangedBy").Where("deal_id = ?", dealID).Order("id ASC").Find(&history).Error
+	return history, err
+}
+
+func (r *dealRepository) ListForReport(filter models.DealReportFilter) ([]models.Deal, error) {
+	query := r.db.Preload("Owner").Preload("Stage").Where("currency = ?", filter.Currency)
+	if filter.OwnerID != 0 {
+		query = query.Where("owner_id = ?", filter.OwnerID)
+	}
+	if filter.CloseFrom != nil {
+		query = query.Where("expected_close_date >= ?", *filter.CloseFrom)
+	}
+	if filter.CloseTo != nil {
+		query = query.Where("expected_close_date < ?", *filter.CloseTo)
+	}
+
+	deals := []models.Deal{}
+	err := query.Order("id ASC").Find(&deals).Error
+	return deals, err
+}
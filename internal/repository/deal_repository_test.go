@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupDealDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	require.NoError(t, err)
+
+	tables := []interface{}{&models.DealStageChange{}, &models.Deal{}, &models.PipelineStage{}, &models.Customer{}, &models.User{}}
+	require.NoError(t, db.Migrator().DropTable(tables...))
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Customer{}, &models.PipelineStage{}, &models.Deal{}, &models.DealStageChange{}))
+
+	t.Cleanup(func() {
+		sqlDB, err := db.DB()
+		if err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// seedDealRepository seeds the default stages and returns them by name.
+func seedDealRepository(t *testing.T, repo DealRepository) map[string]models.PipelineStage {
+	t.Helper()
+	require.NoError(t, repo.InitializeDefaultStages())
+	stages, err := repo.ListStages()
+	require.NoError(t, err)
+	byName := map[string]models.PipelineStage{}
+	for _, stage := range stages {
+		byName[stage.Name] = stage
+	}
+	return byName
+}
+
+func createDeal(t *testing.T, repo DealRepository, stage models.PipelineStage, ownerID uint, currency string, closes *time.Time) *models.Deal {
+	t.Helper()
+	deal := &models.Deal{
+		Title: "Renewal", CustomerID: 1, Amount: 1000, Currency: currency, ExpectedCloseDate: closes,
+		Probability: stage.Probability, OwnerID: ownerID, StageID: stage.ID,
+	}
+	require.NoError(t, repo.Create(deal, &models.DealStageChange{ToStageID: stage.ID, ToStageName: stage.Name}))
+	return deal
+}
+
+func TestDealRepository_InitializeDefaultStagesSeedsOnce(t *testing.T) {
+	db := setupDealDB(t)
+	repo := NewDealRepository(db)
+
+	require.NoError(t, repo.InitializeDefaultStages())
+	stages, err := repo.ListStages()
+	require.NoError(t, err)
+	require.Len(t, stages, len(models.DefaultPipelineStages))
+	assert.Equal(t, "Prospecting", stages[0].Name)
+	assert.Equal(t, models.DealOutcomeLost, stages[len(stages)-1].Outcome)
+
+	require.NoError(t, repo.DeleteStage(stages[0].ID))
+	require.NoError(t, repo.InitializeDefaultStages())
+	stages, err = repo.ListStages()
+	require.NoError(t, err)
+	assert.Len(t, stages, len(models.DefaultPipelineStages)-1, "a configured pipeline is left alone")
+}
+
+func TestDealRepository_CreateAndUpdateWriteTheStageHistory(t *testing.T) {
+	db := setupDealDB(t)
+	repo := NewDealRepository(db)
+	stages := seedDealRepository(t, repo)
+
+	deal := createDeal(t, repo, stages["Prospecting"], 7, "USD", nil)
+
+	proposal := stages["Proposal"]
+	deal.StageID = proposal.ID
+	deal.Probability = proposal.Probability
+	from := stages["Prospecting"].ID
+	require.NoError(t, repo.Update(deal, &models.DealStageChange{
+		FromStageID: &from, FromStageName: "Prospecting", ToStageID: proposal.ID, ToStageName: "Proposal", Probability: 50,
+	}))
+	deal.Notes = "Sent the quote"
+	require.NoError(t, repo.Update(deal, nil))
+
+	history, err := repo.StageHistory(deal.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Nil(t, history[0].FromStageID)
+	assert.Equal(t, "Prospecting", history[0].ToStageName)
+	assert.Equal(t, "Proposal", history[1].ToStageName)
+
+	stored, err := repo.GetByID(deal.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Sent the quote", stored.Notes)
+	require.NotNil(t, stored.Stage)
+	assert.Equal(t, "Proposal", stored.Stage.Name)
+}
+
+func TestDealRepository_ListFiltersByOutcomeAndOwner(t *testing.T) {
+	db := setupDealDB(t)
+	repo := NewDealRepository(db)
+	stages := seedDealRepository(t, repo)
+
+	createDeal(t, repo, stages["Proposal"], 7, "USD", nil)
+	won := createDeal(t, repo, stages["Closed Won"], 7, "USD", nil)
+	createDeal(t, repo, stages["Closed Won"], 8, "USD", nil)
+
+	deals, total, err := repo.List(models.DealFilter{OwnerID: 7, Outcome: models.DealOutcomeWon}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, deals, 1)
+	assert.Equal(t, won.ID, deals[0].ID)
+}
+
+func TestDealRepository_CountDealsInStageIncludesDeletedDeals(t *testing.T) {
+	db := setupDealDB(t)
+	repo := NewDealRepository(db)
+	stages := seedDealRepository(t, repo)
+
+	deal := createDeal(t, repo, stages["Negotiation"], 7, "USD", nil)
+	require.NoError(t, repo.Delete(deal.ID))
+
+	count, err := repo.CountDealsInStage(stages["Negotiation"].ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	assert.ErrorIs(t, repo.Delete(deal.ID), gorm.ErrRecordNotFound)
+}
+
+func TestDealRepository_ListForReportFiltersCurrencyAndCloseWindow(t *testing.T) {
+	db := setupDealDB(t)
+	repo := NewDealRepository(db)
+	stages := seedDealRepository(t, repo)
+
+	may := time.Date(2026, 5, 20, 0, 0, 0, 0, time.UTC)
+	june := time.Date(2026, 6, 2, 0, 0, 0, 0, time.UTC)
+	inWindow := createDeal(t, repo, stages["Proposal"], 7, "USD", &may)
+	createDeal(t, repo, stages["Proposal"], 7, "USD", &june)
+	createDeal(t, repo, stages["Proposal"], 7, "EUR", &may)
+	createDeal(t, repo, stages["Proposal"], 7, "USD", nil)
+
+	from := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	deals, err := repo.ListForReport(models.DealReportFilter{Currency: "USD", CloseFrom: &from, CloseTo: &to})
+	require.NoError(t, err)
+	require.Len(t, deals, 1)
+	assert.Equal(t, inWindow.ID, deals[0].ID)
+	require.NotNil(t, deals[0].Stage)
+
+	deals, err = repo.ListForReport(models.DealReportFilter{Currency: "USD"})
+	require.NoError(t, err)
+	assert.Len(t, deals, 3, "without a window deals with no close date are included")
+}
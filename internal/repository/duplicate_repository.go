@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"strings"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"gorm.io/gorm"
+)
+
+// matchKeyBatch is how many rows BackfillMatchKeys reads at a time.
+const matchKeyBatch = 500
+
+type duplicateRepository struct {
+	db *gorm.DB
+}
+
+func NewDuplicateRepository(db *gorm.DB) DuplicateRepository {
+	return &duplicateRepository{db: db}
+}
+
+func (r *duplicateRepository) WithTx(tx *gorm.DB) DuplicateRepository {
+	return &duplicateRepository{db: tx}
+}
+
+// matchCondition ORs together every signal the probe carries. The last name is
+// also compared with the first name, which catches a person entered with the
+// two swapped. ok is false when the probe carries nothing to match on.
+func matchCondition(probe models.DuplicateProbe) (condition string, args []interface{}, ok bool) {
+	var clauses []string
+	if probe.EmailKey != "" {
+		clauses = append(clauses, "email_key = ?")
+		args = append(args, probe.EmailKey)
+	}
+	if probe.PhoneKey != "" {
+		clauses = append(clauses, "phone_key = ?")
+		args = append(args, probe.PhoneKey)
+	}
+	if probe.AccountID != nil {
+		clauses = append(clauses, "account_id = ?")
+		args = append(args, *probe.AccountID)
+	}
+	if lastName := strings.ToLower(strings.TrimSpace(probe.LastName)); lastName != "" {
+		clauses = append(clauses, "LOWER(last_name) = ?", "LOWER(first_name) = ?")
+		args = append(args, lastName, lastName)
+	}
+	if len(clauses) == 0 {
+		return "", nil, false
+	}
+	return "(" + strings.Join(clauses, " OR ") + ")", args, true
+}
+
+func (r *duplicateRepository) FindLeads(probe models.DuplicateProbe, limit int) ([]models.Lead, error) {
+	condition, args, ok := matchCondition(probe)
+	if !ok {
+		return nil, nil
+	}
+	query := r.db.Where(condition, args...)
+	if probe.ExcludeLeadID != 0 {
+		query = query.Where("id <> ?", probe.ExcludeLeadID)
+	}
+	if probe.LeadOwnerID != 0 {
+		query = query.Where("owner_id = ?", probe.LeadOwnerID)
+	}
+	var leads []models.Lead
+	err := query.Order("id ASC").Limit(limit).Find(&leads).Error
+	return leads, err
+}
+
+func (r *duplicateRepository) FindCustomers(probe models.DuplicateProbe, limit int) ([]models.Customer, error) {
+	condition, args, ok := matchCondition(probe)
+	if !ok {
+		return nil, nil
+	}
+	query := r.db.Where(condition, args...)
+	if probe.ExcludeCustomerID != 0 {
+		query = query.Where("id <> ?", probe.ExcludeCustomerID)
+	}
+	var customers []models.Customer
+	err := query.Order("id ASC").Limit(limit).Find(&customers).Error
+	return customers, err
+}
+
+// repoint moves one foreign key from one record to another. UpdateColumn keeps
+// the referencing rows' updated_at and hooks out of it: only the reference
+// changes, not the record.
+func repoint(tx *gorm.DB, model interface{}, column string, fromID, toID uint) (int64, error) {
+	result := tx.Unscoped().Model(model).Where(column+" = ?", fromID).UpdateColumn(column, toID)
+	return result.RowsAffected, result.Error
+}
+
+func (r *duplicateRepository) RepointLead(fromID, toID uint) (models.MergeCounts, error) {
+	var counts models.MergeCounts
+	err := runInTransaction(r.db, func(tx *gorm.DB) error {
+		var err error
+		if counts.Tasks, err = repoint(tx, &models.Task{}, "lead_id", fromID, toID); err != nil {
+			return err
+		}
+		if counts.FormSubmissions, err = repoint(tx, &models.FormSubmission{}, "lead_id", fromID, toID); err != nil {
+			return err
+		}
+		if counts.InboundEmails, err = repoint(tx, &models.InboundEmail{}, "lead_id", fromID, toID); err != nil {
+			return err
+		}
+		counts.Deals, err = repoint(tx, &models.Deal{}, "lead_id", fromID, toID)
+		return err
+	})
+	return counts, err
+}
+
+func (r *duplicateRepository) RepointCustomer(fromID, toID uint) (models.MergeCounts, error) {
+	var counts models.MergeCounts
+	err := runInTransaction(r.db, func(tx *gorm.DB) error {
+		var err error
+		if counts.Tasks, err = repoint(tx, &models.Task{}, "customer_id", fromID, toID); err != nil {
+			return err
+		}
+		if counts.Tickets, err = repoint(tx, &models.Ticket{}, "customer_id", fromID, toID); err != nil {
+			return err
+		}
+		if counts.Deals, err = repoint(tx, &models.Deal{}, "customer_id", fromID, toID); err != nil {
+			return err
+		}
+		if counts.InboundEmails, err = repoint(tx, &models.InboundEmail{}, "customer_id", fromID, toID); err != nil {
+			return err
+		}
+		counts.Leads, err = repoint(tx, &models.Lead{}, "customer_id", fromID, toID)
+		return err
+	})
+	return counts, err
+}
+
+func (r *duplicateRepository) EraseLead(id uint) error {
+	return eraseRecord(r.db, id, leadErasurePlan())
+}
+
+func (r *duplicateRepository) EraseCustomer(id uint) error {
+	return NewCustomerRepository(r.db).Delete(id)
+}
+
+func (r *duplicateRepository) BackfillMatchKeys() (int64, error) {
+	leads, err := r.backfillMatchKeys(func() interface{} { return &models.Lead{} })
+	if err != nil {
+		return leads, err
+	}
+	customers, err := r.backfillMatchKeys(func() interface{} { return &models.Customer{} })
+	return leads + customers, err
+}
+
+// backfillMatchKeys walks the rows of one table that have an email but no
+// email key, in id order so a row whose email normalizes to nothing is passed
+// over rather than read again.
+func (r *duplicateRepository) backfillMatchKeys(newModel func() interface{}) (int64, error) {
+	type contact struct {
+		ID    uint
+		Email string
+		Phone string
+	}
+	var filled int64
+	var lastID uint
+	for {
+		var batch []contact
+		err := r.db.Model(newModel()).Select("id", "email", "phone").
+			Where("email_key = '' AND email <> '' AND id > ?", lastID).
+			Order("id ASC").Limit(matchKeyBatch).Find(&batch).Error
+		if err != nil {
+			return filled, err
+		}
+		for _, row := range batch {
+			err := r.db.Model(newModel()).Where("id = ?", row.ID).UpdateColumns(map[string]interface{}{
+				"email_key": models.NormalizeEmail(row.Email),
+				"phone_key": models.NormalizePhone(row.Phone),
+			}).Error
+			if err != nil {
+				return filled, err
+			}
+			filled++
+			lastID = row.ID
+		}
+		if len(batch) < matchKeyBatch {
+			return filled, nil
+		}
+	}
+}
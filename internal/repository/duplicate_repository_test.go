@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+func setupDuplicateDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	require.NoError(t, err)
+
+	tables := []interface{}{
+		&models.User{}, &models.Account{}, &models.Lead{}, &models.Customer{}, &models.Ticket{}, &models.Task{},
+		&models.PipelineStage{}, &models.Deal{}, &models.InboundEmail{}, &models.Form{}, &models.FormSubmission{},
+		&models.FormConfirmationToken{}, &models.WebhookDelivery{}, &models.AuditEvent{}, &models.ActivityEvent{},
+		&models.CustomFieldDefinition{}, &models.CustomFieldValue{}, &models.BulkOperation{}, &models.BulkOperationItem{}, &models.Import{},
+	}
+	require.NoError(t, db.Migrator().DropTable(tables...))
+	require.NoError(t, db.AutoMigrate(tables...))
+
+	t.Cleanup(func() {
+		sqlDB, err := db.DB()
+		if err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func createDuplicateLead(t *testing.T, db *gorm.DB, lead models.Lead) *models.Lead {
+	t.Helper()
+	if lead.FirstName == "" {
+		lead.FirstName = "Ada"
+	}
+	if lead.LastName == "" {
+		lead.LastName = "Lovelace"
+	}
+	require.NoError(t, db.Omit(clause.Associations).Create(&lead).Error)
+	return &lead
+}
+
+func TestDuplicateRepository_MatchKeysFollowEveryWrite(t *testing.T) {
+	db := setupDuplicateDB(t)
+
+	lead := createDuplicateLead(t, db, models.Lead{Email: "A.Da+crm@GMail.com", Phone: "+1 (555) 123-4567", OwnerID: 1})
+	var stored models.Lead
+	require.NoError(t, db.First(&stored, lead.ID).Error)
+	assert.Equal(t, "ada@gmail.com", stored.EmailKey)
+	assert.Equal(t, "5551234567", stored.PhoneKey)
+
+	// A map update only recomputes the key of the column it writes.
+	require.NoError(t, db.Model(&models.Lead{}).Where("id = ?", lead.ID).
+		Updates(map[string]interface{}{"phone": "555 765 4321"}).Error)
+	require.NoError(t, db.First(&stored, lead.ID).Error)
+	assert.Equal(t, "ada@gmail.com", stored.EmailKey)
+	assert.Equal(t, "5557654321", stored.PhoneKey)
+
+	require.NoError(t, db.Model(&models.Lead{}).Where("id = ?", lead.ID).Update("email", "ada@example.com").Error)
+	require.NoError(t, db.First(&stored, lead.ID).Error)
+	assert.Equal(t, "ada@example.com", stored.EmailKey)
+
+	stored.Email = "Ada.Lovelace@Example.com"
+	require.NoError(t, NewLeadRepository(db).Update(&stored))
+	require.NoError(t, db.First(&stored, lead.ID).Error)
+	assert.Equal(t, "ada.lovelace@example.com", stored.EmailKey)
+
+	// Erasure blanks the keys along with the columns they are derived from.
+	require.NoError(t, NewDuplicateRepository(db).EraseLead(lead.ID))
+	require.NoError(t, db.Unscoped().First(&stored, lead.ID).Error)
+	assert.Empty(t, stored.EmailKey)
+	assert.Empty(t, stored.PhoneKey)
+}
+
+func TestDuplicateRepository_FindLeadsPrefilters(t *testing.T) {
+	db := setupDuplicateDB(t)
+	repo := NewDuplicateRepository(db)
+	account := uint(9)
+
+	probe := createDuplicateLead(t, db, models.Lead{Email: "ada@example.com", Phone: "555-123-4567", OwnerID: 1})
+	byEmail := createDuplicateLead(t, db, models.Lead{FirstName: "Someone", LastName: "Else", Email: "ADA+x@example.com", OwnerID: 1})
+	byPhone := createDuplicateLead(t, db, models.Lead{FirstName: "Someone", LastName: "Else", Email: "x@example.com", Phone: "+1 555 123 4567", OwnerID: 1})
+	swapped := createDuplicateLead(t, db, models.Lead{FirstName: "Lovelace", LastName: "Ada", Email: "y@example.com", OwnerID: 1})
+	byAccount := createDuplicateLead(t, db, models.Lead{FirstName: "Someone", LastName: "Else", Email: "z@example.com", AccountID: &account, OwnerID: 1})
+	createDuplicateLead(t, db, models.Lead{FirstName: "Grace", LastName: "Hopper", Email: "grace@example.com", OwnerID: 1})
+	otherOwner := createDuplicateLead(t, db, models.Lead{Email: "ada@example.com", OwnerID: 2})
+
+	leads, err := repo.FindLeads(models.DuplicateProbe{
+		EmailKey: "ada@example.com", PhoneKey: "5551234567", LastName: "Lovelace", AccountID: &account,
+		ExcludeLeadID: probe.ID, LeadOwnerID: 1,
+	}, 10)
+	require.NoError(t, err)
+	var ids []uint
+	for _, lead := range leads {
+		ids = append(ids, lead.ID)
+	}
+	assert.Equal(t, []uint{byEmail.ID, byPhone.ID, swapped.ID, byAccount.ID}, ids)
+
+	leads, err = repo.FindLeads(models.DuplicateProbe{EmailKey: "ada@example.com", ExcludeLeadID: probe.ID}, 10)
+	require.NoError(t, err)
+	require.Len(t, leads, 2, "without an owner every owner's leads are searched")
+	assert.Equal(t, otherOwner.ID, leads[1].ID)
+
+	leads, err = repo.FindLeads(models.DuplicateProbe{}, 10)
+	require.NoError(t, err)
+	assert.Empty(t, leads, "an empty probe matches nothing")
+}
+
+func TestDuplicateRepository_RepointAndEraseCustomer(t *testing.T) {
+	db := setupDuplicateDB(t)
+	repo := NewDuplicateRepository(db)
+
+	survivor := createAccountCustomer(t, db, "ada@example.com", "Acme", nil)
+	merged := createAccountCustomer(t, db, "ada@old.example.com", "Acme", nil)
+	convertedLead := createDuplicateLead(t, db, models.Lead{Email: "ada@old.example.com", OwnerID: 1, CustomerID: &merged.ID})
+	require.NoError(t, db.Omit(clause.Associations).Create(&models.Ticket{Title: "Broken", CustomerID: merged.ID}).Error)
+	require.NoError(t, db.Omit(clause.Associations).Create(&models.Task{Title: "Call", CustomerID: &merged.ID}).Error)
+	require.NoError(t, db.Omit(clause.Associations).Create(&models.Deal{Title: "Renewal", CustomerID: merged.ID, StageID: 1, Currency: "EUR"}).Error)
+	deletedTask := &models.Task{Title: "Old", CustomerID: &merged.ID}
+	require.NoError(t, db.Omit(clause.Associations).Create(deletedTask).Error)
+	require.NoError(t, db.Delete(deletedTask).Error)
+
+	var counts models.MergeCounts
+	err := db.Transaction(func(tx *gorm.DB) error {
+		txRepo := repo.WithTx(tx)
+		var err error
+		if counts, err = txRepo.RepointCustomer(merged.ID, survivor.ID); err != nil {
+			return err
+		}
+		return txRepo.EraseCustomer(merged.ID)
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, models.MergeCounts{Tasks: 2, Tickets: 1, Deals: 1, Leads: 1}, counts)
+
+	var lead models.Lead
+	require.NoError(t, db.First(&lead, convertedLead.ID).Error, "the converted lead is not erased with the merged customer")
+	require.NotNil(t, lead.CustomerID)
+	assert.Equal(t, survivor.ID, *lead.CustomerID)
+	assert.Equal(t, "ada@old.example.com", lead.Email)
+
+	var ticket models.Ticket
+	require.NoError(t, db.First(&ticket).Error)
+	assert.Equal(t, survivor.ID, ticket.CustomerID)
+
+	var erased models.Customer
+	require.NoError(t, db.Unscoped().First(&erased, merged.ID).Error)
+	assert.True(t, erased.DeletedAt.Valid)
+	assert.NotEqual(t, "ada@old.example.com", erased.Email)
+}
+
+func TestDuplicateRepository_RepointLeadMovesSubmissionsBeforeErasure(t *testing.T) {
+	db := setupDuplicateDB(t)
+	repo := NewDuplicateRepository(db)
+
+	survivor := createDuplicateLead(t, db, models.Lead{Email: "ada@example.com", OwnerID: 1})
+	merged := createDuplicateLead(t, db, models.Lead{Email: "ada+form@example.com", OwnerID: 1})
+	submission := &models.FormSubmission{FormID: 1, LeadID: &merged.ID, Email: "ada+form@example.com"}
+	require.NoError(t, db.Omit(clause.Associations).Create(submission).Error)
+	require.NoError(t, db.Omit(clause.Associations).Create(&models.Task{Title: "Call", LeadID: &merged.ID}).Error)
+
+	counts, err := repo.RepointLead(merged.ID, survivor.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.MergeCounts{Tasks: 1, FormSubmissions: 1}, counts)
+	require.NoError(t, repo.EraseLead(merged.ID))
+
+	var stored models.FormSubmission
+	require.NoError(t, db.First(&stored, submission.ID).Error)
+	require.NotNil(t, stored.LeadID)
+	assert.Equal(t, survivor.ID, *stored.LeadID)
+	assert.Equal(t, "ada+form@example.com", stored.Email, "the survivor's submission is not scrubbed")
+}
+
+func TestDuplicateRepository_BackfillMatchKeys(t *testing.T) {
+	db := setupDuplicateDB(t)
+	repo := NewDuplicateRepository(db)
+
+	lead := createDuplicateLead(t, db, models.Lead{Email: "Ada@Example.com", Phone: "555 123 4567", OwnerID: 1})
+	customer := createAccountCustomer(t, db, "Grace+x@Example.com", "", nil)
+	for _, model := range []interface{}{&models.Lead{}, &models.Customer{}} {
+		require.NoError(t, db.Session(&gorm.Session{AllowGlobalUpdate: true}).Model(model).
+			UpdateColumns(map[string]interface{}{"email_key": "", "phone_key": ""}).Error)
+	}
+
+	filled, err := repo.BackfillMatchKeys()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), filled)
+
+	var storedLead models.Lead
+	require.NoError(t, db.First(&storedLead, lead.ID).Error)
+	assert.Equal(t, "ada@example.com", storedLead.EmailKey)
+	assert.Equal(t, "5551234567", storedLead.PhoneKey)
+	var storedCustomer models.Customer
+	require.NoError(t, db.First(&storedCustomer, customer.ID).Error)
+	assert.Equal(t, "grace@example.com", storedCustomer.EmailKey)
+
+	filled, err = repo.BackfillMatchKeys()
+	require.NoError(t, err)
+	assert.Zero(t, filled)
+}
@@ -32,8 +32,10 @@ package repository
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/florinel-chis/gophercrm/internal/models"
 	"gorm.io/gorm"
@@ -97,6 +99,38 @@ type erasurePlan struct {
 	// data that lives outside the row itself — credentials, above all — which
 	// must not outlive the person either.
 	AfterScrub func(tx *gorm.DB, id uint) error
+
+	// AuditEntity is the entity type the audit trail records this row under
+	// (models.AuditEntity*). When set, every personal-data column of the plan
+	// is also blanked inside the stored diffs of the row's history — a trail
+	// that still quoted the old email would undo the erasure it records. The
+	// descriptions of the row's activity feed entries are blanked likewise,
+	// and so are the payloads of its webhook deliveries and the snapshots bulk
+	// operations kept of it. For an entity that can be imported, the files
+	// and the failed rows of imports that quote the row's email go too.
+	AuditEntity string
+
+	// CustomFields, when set, hard-deletes the row's custom field values in
+	// the same transaction. What a custom field holds is up to whoever
+	// defined it, so the values are treated as personal data wholesale, and
+	// the custom_fields entry of the audit diffs is blanked with the columns.
+	// It needs AuditEntity, which is also the entity type of the definitions.
+	CustomFields bool
+}
+
+// personalColumns returns every column the plan treats as personal data.
+func (p erasurePlan) personalColumns() []string {
+	columns := make([]string, 0, len(p.Scrub)+1)
+	for column := range p.Scrub {
+		columns = append(columns, column)
+	}
+	if p.EmailColumn != "" {
+		columns = append(columns, p.EmailColumn)
+	}
+	if p.CustomFields {
+		columns = append(columns, "custom_fields")
+	}
+	return columns
 }
 
 // newModel returns a fresh zero pointer of the plan's entity type. Every
@@ -122,6 +156,16 @@ func eraseRecord(db *gorm.DB, id uint, plan erasurePlan) error {
 			erased[column] = value
 		}
 
+		// The address the row held, read before the scrub overwrites it, is
+		// how the imports that carried the person are found.
+		var emails []string
+		if plan.EmailColumn != "" && plan.AuditEntity != "" {
+			if err := tx.Unscoped().Model(plan.newModel()).Where("id = ?", id).
+				Pluck(plan.EmailColumn, &emails).Error; err != nil {
+				return err
+			}
+		}
+
 		if plan.EmailColumn != "" {
 			placeholder, err := newAnonymizedEmail()
 			if err != nil {
@@ -143,6 +187,32 @@ func eraseRecord(db *gorm.DB, id uint, plan erasurePlan) error {
 			}
 		}
 
+		if plan.CustomFields {
+			if err := purgeCustomFieldValues(tx, plan.AuditEntity, []uint{id}); err != nil {
+				return err
+			}
+		}
+
+		if plan.AuditEntity != "" {
+			if err := scrubAuditTrail(tx, plan.AuditEntity, id, plan.personalColumns()); err != nil {
+				return err
+			}
+			if err := scrubActivityFeed(tx, plan.AuditEntity, id); err != nil {
+				return err
+			}
+			if err := scrubWebhookDeliveries(tx, plan.AuditEntity, []uint{id}); err != nil {
+				return err
+			}
+			if err := scrubBulkSnapshots(tx, plan.AuditEntity, id); err != nil {
+				return err
+			}
+			for _, email := range emails {
+				if err := scrubImports(tx, plan.AuditEntity, email); err != nil {
+					return err
+				}
+			}
+		}
+
 		return tx.Delete(plan.newModel(), id).Error
 	})
 }
@@ -217,15 +287,24 @@ func inTransaction(db *gorm.DB) bool {
 // is a security hole, and the tokens themselves are linked to the person.
 //
 // The delete is unconditional and any error propagates, which rolls the erasure
-// back. Both tables belong to the schema and are created by auto-migration, so
+// back. The tables belong to the schema and are created by auto-migration, so
 // a failure here is a real failure — it is never evidence that the deployment
 // simply has no such table. Probing for the table first and skipping it would
 // be worse than useless: a probe that cannot distinguish "absent" from "the
 // query failed" turns a transient database error into a silently skipped purge,
 // and the transaction would still commit, leaving an anonymised user whose
 // credentials still work.
+//
+// The usage log of the user's API keys goes first: it records the addresses
+// the keys were used from, and it would be orphaned by the keys' deletion.
+// The sessions record addresses too, of the logins.
 func purgeCredentials(tx *gorm.DB, userID uint) error {
-	credentialModels := []interface{}{&models.APIKey{}, &models.RefreshToken{}, &models.PasswordResetToken{}}
+	keyIDs := tx.Unscoped().Model(&models.APIKey{}).Select("id").Where("user_id = ?", userID)
+	if err := tx.Where("api_key_id IN (?)", keyIDs).Delete(&models.APIKeyUsage{}).Error; err != nil {
+		return fmt.Errorf("purging the API key usage of user %d: %w", userID, err)
+	}
+	credentialModels := []interface{}{&models.APIKey{}, &models.RefreshToken{}, &models.PasswordResetToken{},
+		&models.TwoFactorRecoveryCode{}, &models.Session{}, &models.RevokedToken{}}
 	for _, model := range credentialModels {
 		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(model).Error; err != nil {
 			return fmt.Errorf("purging credentials of user %d: %w", userID, err)
@@ -233,3 +312,167 @@ func purgeCredentials(tx *gorm.DB, userID uint) error {
 	}
 	return nil
 }
+
+// purgeCustomFieldValues hard-deletes every custom field value the records
+// of one entity type hold. As with purgeCredentials, any error rolls the
+// erasure back.
+func purgeCustomFieldValues(tx *gorm.DB, entityType string, ids []uint) error {
+	fieldIDs := tx.Model(&models.CustomFieldDefinition{}).Select("id").Where("entity_type = ?", entityType)
+	if err := tx.Where("entity_id IN ? AND field_id IN (?)", ids, fieldIDs).
+		Delete(&models.CustomFieldValue{}).Error; err != nil {
+		return fmt.Errorf("purging the custom fields of %s %v: %w", entityType, ids, err)
+	}
+	return nil
+}
+
+// scrubAuditTrail blanks the personal-data fields inside the stored diffs of
+// one entity's audit history. The events themselves survive — who changed the
+// record, when, and which fields they touched is business history — but every
+// before and after value of a personal column becomes models.AuditErasedValue.
+//
+// Like purgeCredentials, the rewrite is unconditional and any error rolls the
+// erasure back: the audit table is part of the schema, and an erasure that
+// commits while the trail still quotes the person is not an erasure.
+func scrubAuditTrail(tx *gorm.DB, entityType string, entityID uint, columns []string) error {
+	type storedDiff struct {
+		ID      uint
+		Changes string
+	}
+	var rows []storedDiff
+	err := tx.Model(&models.AuditEvent{}).Select("id", "changes").
+		Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Scan(&rows).Error
+	if err != nil {
+		return fmt.Errorf("reading the audit trail of %s %d: %w", entityType, entityID, err)
+	}
+
+	for _, row := range rows {
+		changes := models.DecodeAuditChanges(row.Changes)
+		scrubbed := false
+		for _, column := range columns {
+			change, ok := changes[column]
+			if !ok {
+				continue
+			}
+			if change.Before != nil {
+				change.Before = models.AuditErasedValue
+			}
+			if change.After != nil {
+				change.After = models.AuditErasedValue
+			}
+			changes[column] = change
+			scrubbed = true
+		}
+		if !scrubbed {
+			continue
+		}
+
+		encoded, err := json.Marshal(changes)
+		if err != nil {
+			return fmt.Errorf("encoding the scrubbed audit event %d: %w", row.ID, err)
+		}
+		// UpdateColumn on the table rather than the model: audit rows have no
+		// updated_at to bump, and going around BeforeSave keeps the hook from
+		// re-encoding an empty decoded twin over the value written here.
+		if err := tx.Table("audit_events").Where("id = ?", row.ID).
+			UpdateColumn("changes", string(encoded)).Error; err != nil {
+			return fmt.Errorf("scrubbing audit event %d: %w", row.ID, err)
+		}
+	}
+	return nil
+}
+
+// scrubActivityFeed blanks the description of every activity event about one
+// entity. A lead's or customer's entries describe the person by name and
+// company; the entries stay, so the feed still shows that something happened
+// to the record, but the description becomes models.AuditErasedValue. It is
+// unconditional for the same reason scrubAuditTrail is.
+func scrubActivityFeed(tx *gorm.DB, entityType string, entityID uint) error {
+	err := tx.Table("activity_events").
+		Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		UpdateColumn("description", models.AuditErasedValue).Error
+	if err != nil {
+		return fmt.Errorf("scrubbing the activity feed of %s %d: %w", entityType, entityID, err)
+	}
+	return nil
+}
+
+// scrubWebhookDeliveries replaces the payload of every webhook delivery about
+// the given entities with models.AuditErasedValue. A payload is a snapshot of
+// the record taken when the event happened, so it quotes the person verbatim.
+// Deliveries still waiting to go out are dead-lettered rather than sent: the
+// receiver is told about the erasure by the entity's deleted event instead.
+// It is unconditional for the same reason scrubAuditTrail is.
+func scrubWebhookDeliveries(tx *gorm.DB, entityType string, entityIDs []uint) error {
+	if len(entityIDs) == 0 {
+		return nil
+	}
+	deliveries := tx.Table("webhook_deliveries").Where("entity_type = ? AND entity_id IN ?", entityType, entityIDs)
+	if err := deliveries.Session(&gorm.Session{}).
+		Where("status = ?", models.WebhookDeliveryPending).
+		Updates(map[string]interface{}{
+			"status":     models.WebhookDeliveryFailed,
+			"last_error": webhookErasedError,
+		}).Error; err != nil {
+		return fmt.Errorf("dead-lettering the webhook deliveries of %s: %w", entityType, err)
+	}
+	if err := deliveries.Session(&gorm.Session{}).
+		UpdateColumn("payload", models.AuditErasedValue).Error; err != nil {
+		return fmt.Errorf("scrubbing the webhook deliveries of %s: %w", entityType, err)
+	}
+	return nil
+}
+
+// scrubBulkSnapshots replaces the snapshot every bulk operation kept of one
+// entity, for its undo, with bulkSnapshotErased. A snapshot holds the values
+// the operation overwrote, and an erased record must never be put back from
+// it, so a revert refuses the record instead. The bulk resources are named
+// after the plural of the audit entity type. It is unconditional for the same
+// reason scrubAuditTrail is.
+func scrubBulkSnapshots(tx *gorm.DB, entityType string, entityID uint) error {
+	operations := tx.Unscoped().Model(&models.BulkOperation{}).Select("id").Where("resource_type = ?", entityType+"s")
+	err := tx.Unscoped().Model(&models.BulkOperationItem{}).
+		Where("resource_id = ? AND status = ? AND data <> '' AND operation_id IN (?)", entityID, models.StatusCompleted, operations).
+		UpdateColumn("data", bulkSnapshotErased).Error
+	if err != nil {
+		return fmt.Errorf("scrubbing the bulk operation snapshots of %s %d: %w", entityType, entityID, err)
+	}
+	return nil
+}
+
+// scrubImports removes the person with the given email from the imports of
+// their entity type. An import keeps its file until it is started, and the
+// cells of every row it could not create for as long as it exists, so an
+// unstarted file that quotes the address loses its content — it can no longer
+// be started — and the failed rows that quote it are deleted, which leaves
+// them out of the failed rows download. The email is all that ties a row of
+// a file to a record: a row that was imported is the record itself, and is
+// erased as such. It is unconditional for the same reason scrubAuditTrail is.
+func scrubImports(tx *gorm.DB, entityType, email string) error {
+	if _, ok := models.ImportEntityResources[entityType]; !ok || email == "" {
+		return nil
+	}
+	// INSTR rather than LIKE, so an underscore in the address is not a
+	// wildcard; both engines have it.
+	needle := strings.ToLower(email)
+	imports := tx.Model(&models.Import{}).Where("entity_type = ?", entityType)
+	if err := imports.Session(&gorm.Session{}).
+		Where("INSTR(LOWER(content), ?) > 0", needle).
+		UpdateColumn("content", "").Error; err != nil {
+		return fmt.Errorf("scrubbing the import files of a %s: %w", entityType, err)
+	}
+	operations := imports.Session(&gorm.Session{}).Select("operation_id").Where("operation_id IS NOT NULL")
+	if err := tx.Unscoped().
+		Where("operation_id IN (?) AND status = ?", operations, models.StatusFailed).
+		Where("INSTR(LOWER(data), ?) > 0 OR INSTR(LOWER(error), ?) > 0", needle, needle).
+		Delete(&models.BulkOperationItem{}).Error; err != nil {
+		return fmt.Errorf("deleting the failed import rows of a %s: %w", entityType, err)
+	}
+	return nil
+}
+
+// bulkSnapshotErased is the models.BulkItemSnapshot of an erased record.
+const bulkSnapshotErased = `{"erased":true}`
+
+// webhookErasedError is the last error of a delivery the erasure stopped.
+const webhookErasedError = "record erased before delivery"
@@ -36,6 +36,8 @@ import (
 // A lead carries the same categories as a customer — the names, the email, the
 // phone number, the employer and job title, and the free-text notes, which are
 // the highest-risk field because unstructured personal data accumulates there.
+// The employer is held twice, as the company text and as the account link, and
+// both go.
 //
 // ExternalID is scrubbed too. It is the lead's identifier in whatever system
 // the lead was imported from; an identifier that still singles the person out
@@ -59,12 +61,17 @@ func leadErasurePlan() erasurePlan {
 			"first_name":  "",
 			"last_name":   "",
 			"phone":       "",
+			"email_key":   "",
+			"phone_key":   "",
 			"company":     "",
+			"account_id":  nil,
 			"position":    "",
 			"notes":       "",
 			"external_id": "",
 		},
-		AfterScrub: scrubLeadFormSubmissions,
+		AfterScrub:   scrubLeadFormSubmissions,
+		AuditEntity:  models.AuditEntityLead,
+		CustomFields: true,
 	}
 }
 
@@ -79,7 +86,8 @@ func leadErasurePlan() erasurePlan {
 // and before the soft delete. The submission keeps the lead's placeholder
 // email rather than a blank so the two rows stay visibly linked without either
 // identifying anybody. Confirmation tokens are hard-deleted outright: they
-// exist only to prove control of an address the erasure just destroyed.
+// exist only to prove control of an address the erasure just destroyed. The
+// webhook deliveries that carried a submission lose their payload.
 //
 // Submissions with no lead_id (spam, never-confirmed opt-ins, forms configured
 // not to create leads) are outside any lead's erasure and are deliberately not
@@ -116,6 +124,9 @@ func scrubLeadFormSubmissions(tx *gorm.DB, leadID uint) error {
 		Delete(&models.FormConfirmationToken{}).Error; err != nil {
 		return fmt.Errorf("purging confirmation tokens of lead %d: %w", leadID, err)
 	}
+	if err := scrubWebhookDeliveries(tx, models.WebhookEntityFormSubmission, submissionIDs); err != nil {
+		return err
+	}
 	return nil
 }
 
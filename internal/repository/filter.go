@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"gorm.io/gorm"
+)
+
+// whereFilter narrows query by the structured filter of a list request; a nil
+// filter leaves it alone. It is applied before the count, so the total is
+// the total of the filtered list. table is always the repository's own table
+// name, never input.
+func whereFilter(query *gorm.DB, table string, expr *models.FilterExpr) (*gorm.DB, error) {
+	if expr == nil {
+		return query, nil
+	}
+	where, vars, err := utils.SafeWhereClause(table, expr)
+	if err != nil {
+		return nil, err
+	}
+	return query.Where(where, vars...), nil
+}
@@ -195,24 +195,32 @@ func (r *formRepository) GetSubmissionByID(id uint) (*models.FormSubmission, err
 }
 
 // ListSubmissions returns one page of a form's submissions, newest first, plus
-// the total matching the same filter.
-func (r *formRepository) ListSubmissions(formID uint, offset, limit int, status string) ([]models.FormSubmission, int64, error) {
-	filtered := func() *gorm.DB {
-		query := r.db.Model(&models.FormSubmission{}).Where("form_id = ?", formID)
-		if status != "" {
-			query = query.Where("status = ?", status)
-		}
-		return query
+// the total matching the same filter. A page with a cursor starts after it
+// rather than at offset.
+func (r *formRepository) ListSubmissions(formID uint, filter models.FormSubmissionFilter, offset, limit int) ([]models.FormSubmission, int64, error) {
+	query, err := r.submissionsQuery(formID, filter)
+	if err != nil {
+		return nil, 0, err
 	}
-
 	var total int64
-	if err := filtered().Count(&total).Error; err != nil {
+	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
+	query, err = r.submissionsQuery(formID, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	if filter.Cursor != nil {
+		query, err = seekCursor(query, "form_submissions", filter.Cursor)
+		if err != nil {
+			return nil, 0, err
+		}
+	} else {
+		query = query.Order("`created_at` desc, `id` desc")
+	}
 	submissions := []models.FormSubmission{}
-	err := filtered().
-		Order("`created_at` desc, `id` desc").
+	err = query.
 		Offset(offset).
 		Limit(limit).
 		Find(&submissions).Error
@@ -222,6 +230,22 @@ func (r *formRepository) ListSubmissions(formID uint, offset, limit int, status
 	return submissions, total, nil
 }
 
+// StreamSubmissions hands every submission of a form matching filter to fn,
+// newest first, a bounded batch at a time, for exports.
+func (r *formRepository) StreamSubmissions(formID uint, filter models.FormSubmissionFilter, fn func([]models.FormSubmission) error) error {
+	return streamFiltered(func() (*gorm.DB, error) { return r.submissionsQuery(formID, filter) },
+		"form_submissions", "created_at", "desc", fn)
+}
+
+// submissionsQuery selects the submissions of a form that filter matches.
+func (r *formRepository) submissionsQuery(formID uint, filter models.FormSubmissionFilter) (*gorm.DB, error) {
+	query := r.db.Model(&models.FormSubmission{}).Where("form_id = ?", formID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	return whereFilter(query, "form_submissions", filter.Where)
+}
+
 func (r *formRepository) UpdateSubmission(sub *models.FormSubmission) error {
 	return r.db.Save(sub).Error
 }
@@ -2,10 +2,12 @@ package repository
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/sqlite"
@@ -248,20 +250,94 @@ func TestFormRepositoryListSubmissions(t *testing.T) {
 	newest := makeSubmission(t, db, form.ID, "new@example.com", models.FormSubmissionReceived)
 	makeSubmission(t, db, other.ID, "elsewhere@example.com", models.FormSubmissionReceived)
 
-	all, total, err := repo.ListSubmissions(form.ID, 0, 20, "")
+	all, total, err := repo.ListSubmissions(form.ID, models.FormSubmissionFilter{}, 0, 20)
 	require.NoError(t, err)
 	assert.Len(t, all, 3)
 	assert.EqualValues(t, 3, total)
 	assert.Equal(t, newest.ID, all[0].ID, "newest first")
 	assert.Equal(t, oldest.ID, all[len(all)-1].ID)
 
-	spam, total, err := repo.ListSubmissions(form.ID, 0, 20, string(models.FormSubmissionSpam))
+	spam, total, err := repo.ListSubmissions(form.ID, models.FormSubmissionFilter{Status: string(models.FormSubmissionSpam)}, 0, 20)
 	require.NoError(t, err)
 	require.Len(t, spam, 1)
 	assert.EqualValues(t, 1, total)
 	assert.Equal(t, "spam@example.com", spam[0].Email)
 }
 
+// Submissions received together share a created_at; a cursor page boundary
+// between them still neither repeats nor skips one.
+func TestFormRepositoryListSubmissionsByCursor(t *testing.T) {
+	db := setupFormTestDB(t)
+	repo := NewFormRepository(db)
+
+	form := makeForm(t, db, "Contact", "pub-contact", models.FormStatusPublished)
+	ids := make([]uint, 5)
+	for i := range ids {
+		ids[i] = makeSubmission(t, db, form.ID, fmt.Sprintf("visitor%d@example.com", i), models.FormSubmissionReceived).ID
+	}
+	created := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	require.NoError(t, db.Model(&models.FormSubmission{}).Where("id IN ?", ids[:3]).UpdateColumn("created_at", created).Error)
+
+	var listed []uint
+	cursor := &models.ListCursor{SortBy: "created_at", Desc: true}
+	for {
+		page, total, err := repo.ListSubmissions(form.ID, models.FormSubmissionFilter{Cursor: cursor}, 0, 3)
+		require.NoError(t, err)
+		assert.EqualValues(t, 5, total, "the total is of the whole list on every page")
+		if len(page) < 3 {
+			for _, sub := range page {
+				listed = append(listed, sub.ID)
+			}
+			break
+		}
+		for _, sub := range page[:2] {
+			listed = append(listed, sub.ID)
+		}
+		raw, err := utils.EncodeCursor(cursor, &page[1])
+		require.NoError(t, err)
+		cursor, err = utils.DecodeCursor("form_submissions", raw, "created_at", true)
+		require.NoError(t, err)
+		require.Less(t, len(listed), 5, "paging does not end")
+	}
+	assert.Equal(t, []uint{ids[4], ids[3], ids[2], ids[1], ids[0]}, listed)
+}
+
+// The export reads the whole list in batches. Every submission of the form
+// shares one created_at here, so only the id orders them and every batch
+// boundary falls between equal values.
+func TestFormRepositoryStreamSubmissions(t *testing.T) {
+	db := setupFormTestDB(t)
+	repo := NewFormRepository(db)
+
+	form := makeForm(t, db, "Contact", "pub-contact", models.FormStatusPublished)
+	other := makeForm(t, db, "Other", "pub-other", models.FormStatusPublished)
+	makeSubmission(t, db, other.ID, "elsewhere@example.com", models.FormSubmissionReceived)
+	makeSubmission(t, db, form.ID, "spam@example.com", models.FormSubmissionSpam)
+
+	const total = exportBatchSize + 3
+	for i := 0; i < total; i++ {
+		makeSubmission(t, db, form.ID, fmt.Sprintf("visitor%03d@example.com", i), models.FormSubmissionReceived)
+	}
+	created := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	require.NoError(t, db.Model(&models.FormSubmission{}).Where("1 = 1").UpdateColumn("created_at", created).Error)
+
+	var streamed []models.FormSubmission
+	batches := 0
+	err := repo.StreamSubmissions(form.ID, models.FormSubmissionFilter{Status: string(models.FormSubmissionReceived)},
+		func(batch []models.FormSubmission) error {
+			batches++
+			streamed = append(streamed, batch...)
+			return nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, 2, batches)
+	require.Len(t, streamed, total)
+	for i := 1; i < len(streamed); i++ {
+		require.Greater(t, streamed[i-1].ID, streamed[i].ID, "newest first, by id on a tie")
+	}
+	assert.Equal(t, "visitor000@example.com", streamed[total-1].Data["email"], "the values come back decoded")
+}
+
 // Used, expired and unknown tokens must be indistinguishable to the caller.
 func TestFormRepositoryConfirmationTokenLookupOnlySpendable(t *testing.T) {
 	db := setupFormTestDB(t)
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"fmt"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"gorm.io/gorm"
+)
+
+type importRepository struct {
+	db *gorm.DB
+}
+
+func NewImportRepository(db *gorm.DB) ImportRepository {
+	return &importRepository{db: db}
+}
+
+func (r *importRepository) Create(imp *models.Import) error {
+	return r.db.Create(imp).Error
+}
+
+func (r *importRepository) GetByID(id uint) (*models.Import, error) {
+	var imp models.Import
+	if err := r.db.Preload("Operation").First(&imp, id).Error; err != nil {
+		return nil, err
+	}
+	return &imp, nil
+}
+
+func (r *importRepository) List(userID uint, offset, limit int) ([]models.Import, int64, error) {
+	query := r.db.Model(&models.Import{})
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	imports := []models.Import{}
+	err := query.Omit("content").Preload("Operation").
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&imports).Error
+	return imports, total, err
+}
+
+func (r *importRepository) Start(imp *models.Import, operation *models.BulkOperation) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(operation).Error; err != nil {
+			return err
+		}
+		mapping := &models.Import{Mapping: imp.Mapping}
+		if err := mapping.BeforeSave(tx); err != nil {
+			return err
+		}
+		// The claim is conditional, so of two concurrent starts exactly one
+		// records its operation; the other's is rolled back with it.
+		// The rows are read from the file before it is started and never
+		// again, so the file is dropped with the claim rather than kept for
+		// however the import ends.
+		result := tx.Model(&models.Import{}).
+			Where("id = ? AND operation_id IS NULL", imp.ID).
+			UpdateColumns(map[string]interface{}{
+				"mapping":      mapping.MappingJSON,
+				"owner_id":     imp.OwnerID,
+				"operation_id": operation.ID,
+				"content":      "",
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("import %d: %w", imp.ID, apperrors.ErrImportStarted)
+		}
+		imp.OperationID = &operation.ID
+		imp.Operation = operation
+		imp.Content = ""
+		return nil
+	})
+}
+
+func (r *importRepository) ListUnfinished() ([]models.Import, error) {
+	var imports []models.Import
+	err := r.db.Omit("content").Preload("Operation").
+		Joins("JOIN bulk_operations ON bulk_operations.id = imports.operation_id").
+		Where("bulk_operations.status IN ?", []models.BulkOperationStatus{models.StatusPending, models.StatusProcessing}).
+		Order("imports.id ASC").
+		Find(&imports).Error
+	return imports, err
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"gorm.io/gorm"
+)
+
+type inboundEmailRepository struct {
+	db *gorm.DB
+}
+
+func NewInboundEmailRepository(db *gorm.DB) InboundEmailRepository {
+	return &inboundEmailRepository{db: db}
+}
+
+func (r *inboundEmailRepository) WithTx(tx *gorm.DB) InboundEmailRepository {
+	return &inboundEmailRepository{db: tx}
+}
+
+func (r *inboundEmailRepository) Create(email *models.InboundEmail) error {
+	return r.db.Create(email).Error
+}
+
+func (r *inboundEmailRepository) GetByID(id uint) (*models.InboundEmail, error) {
+	var email models.InboundEmail
+	if err := r.db.First(&email, id).Error; err != nil {
+		return nil, err
+	}
+	return &email, nil
+}
+
+func (r *inboundEmailRepository) Update(email *models.InboundEmail) error {
+	return r.db.Save(email).Error
+}
+
+func (r *inboundEmailRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.InboundEmail{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *inboundEmailRepository) List(status models.InboundEmailStatus, offset, limit int) ([]models.InboundEmail, int64, error) {
+	query := r.db.Model(&models.InboundEmail{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	emails := []models.InboundEmail{}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&emails).Error
+	return emails, total, err
+}
+
+func (r *inboundEmailRepository) ExistsByMessageID(messageID string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.InboundEmail{}).Where("message_id = ?", messageID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *inboundEmailRepository) TicketIDForMessages(messageIDs []string) (uint, error) {
+	if len(messageIDs) == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	var email models.InboundEmail
+	err := r.db.Where("message_id IN ? AND ticket_id IS NOT NULL", messageIDs).
+		Order("id DESC").
+		First(&email).Error
+	if err != nil {
+		return 0, err
+	}
+	return *email.TicketID, nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupInboundEmailDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, db.Migrator().DropTable(&models.InboundEmail{}))
+	require.NoError(t, db.AutoMigrate(&models.InboundEmail{}))
+
+	t.Cleanup(func() {
+		sqlDB, err := db.DB()
+		if err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func logInboundEmail(t *testing.T, repo InboundEmailRepository, messageID string, status models.InboundEmailStatus, ticketID *uint) *models.InboundEmail {
+	t.Helper()
+	email := &models.InboundEmail{MessageID: messageID, Status: status, ReceivedAt: time.Now().UTC(), TicketID: ticketID}
+	require.NoError(t, repo.Create(email))
+	return email
+}
+
+func TestInboundEmailRepository_TicketIDForMessagesPrefersNewest(t *testing.T) {
+	db := setupInboundEmailDB(t)
+	repo := NewInboundEmailRepository(db)
+
+	older, newer := uint(3), uint(4)
+	logInboundEmail(t, repo, "a@x", models.InboundEmailTicketCreated, &older)
+	logInboundEmail(t, repo, "b@x", models.InboundEmailReplyAdded, &newer)
+	logInboundEmail(t, repo, "c@x", models.InboundEmailQuarantined, nil)
+
+	id, err := repo.TicketIDForMessages([]string{"a@x", "b@x", "c@x"})
+	require.NoError(t, err)
+	assert.Equal(t, uint(4), id)
+
+	_, err = repo.TicketIDForMessages([]string{"c@x", "unknown@x"})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "a message without a ticket threads nothing")
+
+	_, err = repo.TicketIDForMessages(nil)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestInboundEmailRepository_ExistsListAndDelete(t *testing.T) {
+	db := setupInboundEmailDB(t)
+	repo := NewInboundEmailRepository(db)
+
+	first := logInboundEmail(t, repo, "a@x", models.InboundEmailQuarantined, nil)
+	logInboundEmail(t, repo, "b@x", models.InboundEmailIgnored, nil)
+	third := logInboundEmail(t, repo, "c@x", models.InboundEmailQuarantined, nil)
+
+	exists, err := repo.ExistsByMessageID("a@x")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = repo.ExistsByMessageID("z@x")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	emails, total, err := repo.List(models.InboundEmailQuarantined, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, emails, 2)
+	assert.Equal(t, third.ID, emails[0].ID, "newest first")
+
+	_, total, err = repo.List("", 0, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+
+	require.NoError(t, repo.Delete(first.ID))
+	assert.ErrorIs(t, repo.Delete(first.ID), gorm.ErrRecordNotFound)
+	_, err = repo.GetByID(first.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
@@ -37,6 +37,13 @@ type UserRepository interface {
 	ListSorted(offset, limit int, sortBy, sortOrder string) ([]models.User, error)
 	Search(query string, offset, limit int, sortBy, sortOrder string) ([]models.User, error)
 	CountSearch(query string) (int64, error)
+	// ListFiltered returns one page of the users matching every condition of
+	// filter, plus the total number of matching users. A cursor replaces the
+	// offset, which is then ignored.
+	ListFiltered(filter models.UserFilter, offset, limit int) ([]models.User, int64, error)
+	// StreamFiltered hands every user matching filter to fn with no
+	// pagination, a bounded batch at a time, for exports.
+	StreamFiltered(filter models.UserFilter, fn func([]models.User) error) error
 	Count() (int64, error)
 	UpdateLastLogin(id uint) error
 	WithTx(tx *gorm.DB) UserRepository
@@ -57,6 +64,13 @@ type LeadRepository interface {
 	ListSortedWithPreloads(offset, limit int, sortBy, sortOrder string, preloads ...string) ([]models.Lead, error)
 	Search(query string, offset, limit int, sortBy, sortOrder string, preloads ...string) ([]models.Lead, error)
 	CountSearch(query string) (int64, error)
+	// ListFiltered returns one page of the leads matching every condition of
+	// the filter, and how many match in all. A custom field sort replaces
+	// the column sort, and a cursor the offset, which is then ignored.
+	ListFiltered(filter models.LeadFilter, offset, limit int, preloads ...string) ([]models.Lead, int64, error)
+	// StreamFiltered hands every lead matching filter to fn with no
+	// pagination, a bounded batch at a time, for exports.
+	StreamFiltered(filter models.LeadFilter, fn func([]models.Lead) error) error
 	Count() (int64, error)
 	CountByClassification(classification models.LeadClassification) (int64, error)
 	CountByOwnerID(ownerID uint) (int64, error)
@@ -92,11 +106,14 @@ type CustomerRepository interface {
 	ListWithPreloads(offset, limit int, preloads ...string) ([]models.Customer, error)
 	ListSortedWithPreloads(offset, limit int, sortBy, sortOrder string, preloads ...string) ([]models.Customer, error)
 	Search(query string, offset, limit int, sortBy, sortOrder string, preloads ...string) ([]models.Customer, error)
-	// ListAllForExport returns every matching customer with no pagination, read
-	// in batches. Backs the CSV export, where a page boundary would silently
-	// truncate the file.
-	ListAllForExport(search, sortBy, sortOrder string) ([]models.Customer, error)
 	CountSearch(query string) (int64, error)
+	// ListFiltered returns one page of the customers matching every condition of
+	// the filter, and how many match in all. A custom field sort replaces
+	// the column sort, and a cursor the offset, which is then ignored.
+	ListFiltered(filter models.CustomerFilter, offset, limit int, preloads ...string) ([]models.Customer, int64, error)
+	// StreamFiltered hands every customer matching filter to fn with no
+	// pagination, a bounded batch at a time, for exports.
+	StreamFiltered(filter models.CustomerFilter, fn func([]models.Customer) error) error
 	Count() (int64, error)
 	WithTx(tx *gorm.DB) CustomerRepository
 }
@@ -116,6 +133,13 @@ type TicketRepository interface {
 	ListSortedWithPreloads(offset, limit int, sortBy, sortOrder string, preloads ...string) ([]models.Ticket, error)
 	Search(query string, offset, limit int, sortBy, sortOrder string, preloads ...string) ([]models.Ticket, error)
 	CountSearch(query string) (int64, error)
+	// ListFiltered returns one page of the tickets matching every condition of
+	// the filter, and how many match in all. A custom field sort replaces
+	// the column sort, and a cursor the offset, which is then ignored.
+	ListFiltered(filter models.TicketFilter, offset, limit int, preloads ...string) ([]models.Ticket, int64, error)
+	// StreamFiltered hands every ticket matching filter to fn with no
+	// pagination, a bounded batch at a time, for exports.
+	StreamFiltered(filter models.TicketFilter, fn func([]models.Ticket) error) error
 	Count() (int64, error)
 	CountByCustomerID(customerID uint) (int64, error)
 	CountByAssignedToID(assignedToID uint) (int64, error)
@@ -124,9 +148,25 @@ type TicketRepository interface {
 	CountByPriority() (map[string]int64, error)
 	ListRecent(limit int) ([]models.Ticket, error)
 	ListRecentlyResolved(limit int) ([]models.Ticket, error)
+	// MarkFirstResponse stamps the ticket's first response at at, unless it
+	// already has one.
+	MarkFirstResponse(id uint, at time.Time) error
+	// BackfillReplyTokens gives the tickets stored before reply tokens
+	// existed one each and returns how many it filled.
+	BackfillReplyTokens() (int64, error)
 	WithTx(tx *gorm.DB) TicketRepository
 }
 
+// TicketCommentRepository stores ticket conversation threads. Entries are
+// never edited; a thread only grows.
+type TicketCommentRepository interface {
+	Create(comment *models.TicketComment) error
+	// ListByTicket returns one page of a ticket's thread, oldest first, with
+	// authors preloaded. Internal entries are left out unless includeInternal.
+	ListByTicket(ticketID uint, includeInternal bool, offset, limit int) ([]models.TicketComment, int64, error)
+	WithTx(tx *gorm.DB) TicketCommentRepository
+}
+
 type TaskRepository interface {
 	Create(task *models.Task) error
 	GetByID(id uint) (*models.Task, error)
@@ -140,6 +180,13 @@ type TaskRepository interface {
 	ListSortedWithPreloads(offset, limit int, sortBy, sortOrder string, preloads ...string) ([]models.Task, error)
 	Search(query string, offset, limit int, sortBy, sortOrder string, preloads ...string) ([]models.Task, error)
 	CountSearch(query string) (int64, error)
+	// ListFiltered returns one page of the tasks matching every condition of
+	// the filter, and how many match in all. A custom field sort replaces
+	// the column sort, and a cursor the offset, which is then ignored.
+	ListFiltered(filter models.TaskFilter, offset, limit int, preloads ...string) ([]models.Task, int64, error)
+	// StreamFiltered hands every task matching filter to fn with no
+	// pagination, a bounded batch at a time, for exports.
+	StreamFiltered(filter models.TaskFilter, fn func([]models.Task) error) error
 	Count() (int64, error)
 	CountByAssignedToID(assignedToID uint) (int64, error)
 	CountPending() (int64, error)
@@ -188,6 +235,10 @@ type APIKeyRepository interface {
 	Update(apiKey *models.APIKey) error
 	Delete(id uint) error
 	UpdateLastUsed(id uint) error
+	// CreateUsage appends one request to a key's usage log.
+	CreateUsage(usage *models.APIKeyUsage) error
+	// ListUsage pages through a key's usage log, newest first.
+	ListUsage(apiKeyID uint, offset, limit int) ([]models.APIKeyUsage, int64, error)
 	WithTx(tx *gorm.DB) APIKeyRepository
 }
 
@@ -218,6 +269,84 @@ type PasswordResetTokenRepository interface {
 	WithTx(tx *gorm.DB) PasswordResetTokenRepository
 }
 
+// TwoFactorRepository keeps the two-factor state of users: the TOTP columns of
+// the user row and the recovery codes. The writes that guard against a code
+// being accepted twice are conditional updates, so they hold under concurrent
+// logins as well.
+type TwoFactorRepository interface {
+	// SavePendingSecret stores the sealed secret of an enrollment that has not
+	// been confirmed yet. It does not touch an enabled user.
+	SavePendingSecret(userID uint, sealedSecret string) error
+	// Enable turns two-factor authentication on with the pending secret,
+	// records step as the last one used and replaces the recovery codes, in
+	// one transaction.
+	Enable(userID uint, step int64, codeHashes []string) error
+	// Disable clears the secret and deletes the recovery codes.
+	Disable(userID uint) error
+	// ClaimStep records step as the user's last used TOTP step. It reports
+	// false, writing nothing, when that step or a later one was already used.
+	ClaimStep(userID uint, step int64) (bool, error)
+	// ConsumeRecoveryCode spends an unspent recovery code of the user. It
+	// reports false when the user has no such code.
+	ConsumeRecoveryCode(userID uint, codeHash string) (bool, error)
+	ReplaceRecoveryCodes(userID uint, codeHashes []string) error
+	CountRecoveryCodes(userID uint) (int64, error)
+}
+
+// SSORepository keeps single sign-on attempts in progress and the link
+// between users and their accounts at the provider.
+type SSORepository interface {
+	// CreateLoginState stores an attempt, deleting the expired ones on the way.
+	CreateLoginState(state *models.SSOLoginState) error
+	// ConsumeLoginState deletes and returns the unexpired attempt with the
+	// given session hash, so each attempt completes at most once.
+	ConsumeLoginState(sessionHash string) (*models.SSOLoginState, error)
+	// GetUserBySubject finds the user linked to a provider account.
+	GetUserBySubject(issuer, subject string) (*models.User, error)
+	// LinkUser links a user to a provider account.
+	LinkUser(userID uint, issuer, subject string) error
+}
+
+// SessionRepository keeps the logins of users and the access tokens revoked
+// before their expiry. Revoked sessions and revoked tokens together make up
+// the revocation list access tokens are checked against.
+type SessionRepository interface {
+	Create(session *models.Session) error
+	// GetByID returns the session whether or not it is revoked or expired.
+	GetByID(id uint) (*models.Session, error)
+	// ListActive returns the user's sessions that are neither revoked nor
+	// expired, most recently used first.
+	ListActive(userID uint) ([]models.Session, error)
+	// Touch records a use of a session from ipAddress and moves its expiry
+	// along with its newest refresh token. A revoked session is a not-found.
+	Touch(id uint, ipAddress string, expiresAt time.Time) error
+	// Revoke ends a session and revokes its refresh tokens.
+	Revoke(id uint) error
+	// RevokeAllForUser ends every session of the user and revokes their
+	// refresh tokens.
+	RevokeAllForUser(userID uint) error
+	// RevokeToken adds an access token to the revocation list, sweeping the
+	// expired entries on the way. A token already on the list is ignored.
+	RevokeToken(token *models.RevokedToken) error
+	// Revocations returns the jtis of the revoked tokens that have not expired
+	// yet, and the IDs of the sessions revoked after since.
+	Revocations(since time.Time) (tokenIDs []string, sessionIDs []uint, err error)
+}
+
+// SigningKeyRepository keeps the key pairs that sign access tokens, retired
+// ones included.
+type SigningKeyRepository interface {
+	Create(key *models.SigningKey) error
+	// List returns every key, the oldest first.
+	List() ([]models.SigningKey, error)
+	GetByKID(kid string) (*models.SigningKey, error)
+	// Activate makes a published key the one that signs, from at. A retired
+	// key is a not-found.
+	Activate(kid string, at time.Time) error
+	// Retire withdraws a published key. A retired key is a not-found.
+	Retire(kid string, at time.Time) error
+}
+
 type BulkOperationRepository interface {
 	Create(operation *models.BulkOperation) error
 	GetByID(id uint) (*models.BulkOperation, error)
@@ -232,6 +361,37 @@ type BulkOperationRepository interface {
 	CreateItem(item *models.BulkOperationItem) error
 	UpdateItem(item *models.BulkOperationItem) error
 	GetItemsByOperationID(operationID uint) ([]models.BulkOperationItem, error)
+	// CreateItems inserts the items of an operation in batches.
+	CreateItems(items []models.BulkOperationItem) error
+	// UpdateProgress sets the status and counters of an operation, and
+	// nothing else of it.
+	UpdateProgress(id uint, status models.BulkOperationStatus, successCount, failureCount int) error
+	// GetFailedItems returns the failed items of an operation in the order
+	// they were recorded.
+	GetFailedItems(operationID uint) ([]models.BulkOperationItem, error)
+	// ClaimNextQueued moves the oldest pending operation that carries a queued
+	// request to processing, stamped startedAt, and returns it; nil when none
+	// is queued. The claim is conditional, so no two workers run one operation.
+	ClaimNextQueued(startedAt time.Time) (*models.BulkOperation, error)
+	// UpdateCounts sets the counters of an operation and leaves its status
+	// alone, so that a cancellation in the meantime is not overwritten.
+	UpdateCounts(id uint, successCount, failureCount int) error
+	// Finish records the final counters and finishedAt, and moves the
+	// operation to status if it is still processing. One cancelled meanwhile
+	// stays cancelled. The queued request is dropped.
+	Finish(id uint, status models.BulkOperationStatus, successCount, failureCount int, finishedAt time.Time) error
+	// Cancel moves a queued operation that is pending or processing to
+	// cancelled and reports whether it did. A pending one is finished at once.
+	Cancel(id uint, finishedAt time.Time) (bool, error)
+	// MarkInterruptedFailed fails every queued operation still processing and
+	// returns how many it failed.
+	MarkInterruptedFailed(finishedAt time.Time) (int64, error)
+	// GetSnapshotItems returns the completed items of an operation that carry
+	// a snapshot, in the order they were recorded.
+	GetSnapshotItems(operationID uint) ([]models.BulkOperationItem, error)
+	// MarkReverted stamps revertedAt on an operation not reverted yet, drops
+	// the snapshots of its items and reports whether it did.
+	MarkReverted(id uint, revertedAt time.Time) (bool, error)
 	WithTx(tx *gorm.DB) BulkOperationRepository
 }
 
@@ -272,6 +432,12 @@ type BulkRepository interface {
 	SetTicketStatus(ids []uint, status models.TicketStatus) error
 	SetTaskStatus(ids []uint, status models.TaskStatus) error
 
+	// Undo of queued bulk operations. GetRowStates reads the rows that exist
+	// among ids, deleted or not. RestoreRow puts one back as a snapshot found
+	// it, or returns apperrors.ErrBulkRowChanged when it has changed since.
+	GetRowStates(resourceType string, ids []uint) (map[uint]models.BulkRowState, error)
+	RestoreRow(resourceType string, id uint, snapshot *models.BulkItemSnapshot) error
+
 	WithTx(tx *gorm.DB) BulkRepository
 }
 
@@ -297,8 +463,8 @@ type AEORepository interface {
 	// DeletePrompt soft-deletes the prompt and reports gorm.ErrRecordNotFound
 	// when no row matched.
 	DeletePrompt(id uint) error
-	ListPrompts(activeOnly bool, offset, limit int, sortBy, sortOrder string) ([]models.AEOPrompt, error)
-	CountPrompts(activeOnly bool) (int64, error)
+	ListPrompts(filter models.AEOPromptFilter, offset, limit int, sortBy, sortOrder string) ([]models.AEOPrompt, error)
+	CountPrompts(filter models.AEOPromptFilter) (int64, error)
 	// ListActivePrompts returns every active prompt, unpaginated: it is the run
 	// engine's input and the service caps the active set.
 	ListActivePrompts() ([]models.AEOPrompt, error)
@@ -386,8 +552,11 @@ type FormRepository interface {
 	CreateSubmission(sub *models.FormSubmission) error
 	GetSubmissionByID(id uint) (*models.FormSubmission, error)
 	// ListSubmissions returns one page of a form's submissions newest first,
-	// plus the total matching the same status filter.
-	ListSubmissions(formID uint, offset, limit int, status string) ([]models.FormSubmission, int64, error)
+	// plus the total matching the same filter.
+	ListSubmissions(formID uint, filter models.FormSubmissionFilter, offset, limit int) ([]models.FormSubmission, int64, error)
+	// StreamSubmissions hands every submission of a form matching filter to
+	// fn, newest first, a bounded batch at a time, for exports.
+	StreamSubmissions(formID uint, filter models.FormSubmissionFilter, fn func([]models.FormSubmission) error) error
 	UpdateSubmission(sub *models.FormSubmission) error
 
 	CreateConfirmationToken(t *models.FormConfirmationToken) error
@@ -403,3 +572,274 @@ type FormRepository interface {
 
 	WithTx(tx *gorm.DB) FormRepository
 }
+
+// AuditRepository stores the append-only audit trail. There is no Update and
+// no Delete: the only rewrite an audit row ever sees is the erasure scrub,
+// which lives with the rest of the erasure machinery in erasure.go.
+type AuditRepository interface {
+	Create(event *models.AuditEvent) error
+	// List returns one page of events matching the filter, newest first, plus
+	// the total matching the same filter.
+	List(filter models.AuditFilter, offset, limit int) ([]models.AuditEvent, int64, error)
+	// ListByEntity returns one entity's history, newest first, plus its total.
+	ListByEntity(entityType string, entityID uint, offset, limit int) ([]models.AuditEvent, int64, error)
+	WithTx(tx *gorm.DB) AuditRepository
+}
+
+// SLARepository stores the SLA policies and runs the ticket queries behind
+// breach flagging and SLA reporting.
+type SLARepository interface {
+	ListPolicies() ([]models.SLAPolicy, error)
+	GetPolicy(priority models.TicketPriority) (*models.SLAPolicy, error)
+	// SavePolicy creates or replaces the policy for policy.Priority.
+	SavePolicy(policy *models.SLAPolicy) error
+	DeletePolicy(priority models.TicketPriority) error
+	// FlagBreaches flags every missed deadline not flagged yet and returns
+	// them, with the ticket as it was read.
+	FlagBreaches(now time.Time) ([]models.SLABreach, error)
+	ListForReport(from, to time.Time) ([]models.Ticket, error)
+	WithTx(tx *gorm.DB) SLARepository
+}
+
+// InboundEmailRepository stores the email gateway's log of received messages.
+type InboundEmailRepository interface {
+	Create(email *models.InboundEmail) error
+	GetByID(id uint) (*models.InboundEmail, error)
+	Update(email *models.InboundEmail) error
+	// Delete removes the row for good; gorm.ErrRecordNotFound when there is
+	// none.
+	Delete(id uint) error
+	// List returns one page, newest first; an empty status lists them all.
+	List(status models.InboundEmailStatus, offset, limit int) ([]models.InboundEmail, int64, error)
+	ExistsByMessageID(messageID string) (bool, error)
+	// TicketIDForMessages returns the ticket of the newest logged message
+	// among messageIDs that has one, or gorm.ErrRecordNotFound.
+	TicketIDForMessages(messageIDs []string) (uint, error)
+	WithTx(tx *gorm.DB) InboundEmailRepository
+}
+
+// WebhookRepository stores the webhook subscriptions and their delivery
+// queue.
+type WebhookRepository interface {
+	CreateSubscription(sub *models.WebhookSubscription) error
+	GetSubscription(id uint) (*models.WebhookSubscription, error)
+	UpdateSubscription(sub *models.WebhookSubscription) error
+	// DeleteSubscription removes a subscription and its deliveries for good;
+	// gorm.ErrRecordNotFound when there is none.
+	DeleteSubscription(id uint) error
+	ListSubscriptions(offset, limit int) ([]models.WebhookSubscription, int64, error)
+	// ActiveSubscriptions returns every active subscription. The events are
+	// a JSON column, so matching them against an event is the caller's job.
+	ActiveSubscriptions() ([]models.WebhookSubscription, error)
+
+	CreateDeliveries(deliveries []models.WebhookDelivery) error
+	// GetDelivery returns a delivery of the given subscription, or
+	// gorm.ErrRecordNotFound.
+	GetDelivery(subscriptionID, id uint) (*models.WebhookDelivery, error)
+	UpdateDelivery(delivery *models.WebhookDelivery) error
+	// ListDeliveries returns one page, newest first; an empty status lists
+	// them all.
+	ListDeliveries(subscriptionID uint, status models.WebhookDeliveryStatus, offset, limit int) ([]models.WebhookDelivery, int64, error)
+	// ClaimDue leases up to limit pending deliveries of active subscriptions
+	// that are due at now, oldest first, by moving their next attempt to
+	// leaseUntil. A delivery claimed by one worker is therefore not claimed
+	// by another, and one whose worker died becomes due again when the lease
+	// runs out.
+	ClaimDue(now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error)
+	WithTx(tx *gorm.DB) WebhookRepository
+}
+
+// DealRepository stores the pipeline stages, the deals and their stage
+// history.
+type DealRepository interface {
+	// ListStages returns every stage in pipeline order.
+	ListStages() ([]models.PipelineStage, error)
+	GetStage(id uint) (*models.PipelineStage, error)
+	CreateStage(stage *models.PipelineStage) error
+	UpdateStage(stage *models.PipelineStage) error
+	// DeleteStage removes a stage for good; gorm.ErrRecordNotFound when there
+	// is none.
+	DeleteStage(id uint) error
+	// CountDealsInStage counts the deals in a stage, deleted ones included,
+	// since their history still points at it.
+	CountDealsInStage(stageID uint) (int64, error)
+	// InitializeDefaultStages seeds models.DefaultPipelineStages into an
+	// empty pipeline and does nothing otherwise.
+	InitializeDefaultStages() error
+
+	// Create inserts the deal and the first entry of its stage history.
+	Create(deal *models.Deal, entry *models.DealStageChange) error
+	// GetByID returns the deal with its customer, owner and stage preloaded.
+	GetByID(id uint) (*models.Deal, error)
+	// Update saves the deal, and appends entry to its history when entry is
+	// not nil, in one transaction.
+	Update(deal *models.Deal, entry *models.DealStageChange) error
+	// Delete soft-deletes the deal; gorm.ErrRecordNotFound when there is none.
+	Delete(id uint) error
+	// List returns one page, newest first, with customer, owner and stage
+	// preloaded.
+	List(filter models.DealFilter, offset, limit int) ([]models.Deal, int64, error)
+	// StageHistory returns the deal's stage changes, oldest first.
+	StageHistory(dealID uint) ([]models.DealStageChange, error)
+	// ListForReport returns the deals a report covers, with their owners and
+	// stages preloaded.
+	ListForReport(filter models.DealReportFilter) ([]models.Deal, error)
+	WithTx(tx *gorm.DB) DealRepository
+}
+
+// AccountRepository stores the accounts and answers the rollups over the
+// leads and customers linked to them. The rollups see only live records:
+// tickets and tasks through the account's live customers and leads, deals
+// through its live customers.
+type AccountRepository interface {
+	Create(account *models.Account) error
+	// GetByID returns the account with its owner preloaded.
+	GetByID(id uint) (*models.Account, error)
+	// GetByNameKey returns the live account with that models.AccountNameKey.
+	GetByNameKey(key string) (*models.Account, error)
+	Update(account *models.Account) error
+	// Delete unlinks the account's leads and customers and soft-deletes it in
+	// one transaction; gorm.ErrRecordNotFound when there is none.
+	Delete(id uint) error
+	// List returns one page ordered by name, with owners preloaded.
+	List(filter models.AccountFilter, offset, limit int) ([]models.Account, int64, error)
+
+	// ListLeads and ListDeals return only the records ownerID owns when it is
+	// not zero; ListTasks only the tasks assigned to assigneeID.
+	ListLeads(accountID, ownerID uint, offset, limit int) ([]models.Lead, int64, error)
+	ListCustomers(accountID uint, offset, limit int) ([]models.Customer, int64, error)
+	ListTickets(accountID uint, offset, limit int) ([]models.Ticket, int64, error)
+	ListTasks(accountID, assigneeID uint, offset, limit int) ([]models.Task, int64, error)
+	ListDeals(accountID, ownerID uint, offset, limit int) ([]models.Deal, int64, error)
+	// Summarize fills the counts of an account summary. Deals are left to
+	// the caller, which totals the ones AllDeals returns.
+	Summarize(accountID uint, scope models.AccountScope) (*models.AccountSummary, error)
+	// AllDeals returns every deal of the account, with stages preloaded.
+	AllDeals(accountID, ownerID uint) ([]models.Deal, error)
+
+	// BackfillFromCompanies clusters the company text of the leads and
+	// customers that have no account by models.AccountNameKey, creates an
+	// account for each cluster and links its contacts, in one transaction. It
+	// is the one-off migration from free-text companies, so it does nothing
+	// once any account exists, deleted ones included.
+	BackfillFromCompanies() (*models.AccountBackfill, error)
+	WithTx(tx *gorm.DB) AccountRepository
+}
+
+// ActivityRepository stores the append-only activity feed. Like the audit
+// trail it has no Update and no Delete; the erasure scrub in erasure.go is the
+// only rewrite.
+type ActivityRepository interface {
+	Create(event *models.ActivityEvent) error
+	// List returns up to limit events matching the filter, newest first, with
+	// their owners preloaded.
+	List(filter models.ActivityFilter, limit int) ([]models.ActivityEvent, error)
+	WithTx(tx *gorm.DB) ActivityRepository
+}
+
+// DuplicateRepository backs duplicate detection and merging of leads and
+// customers. The finders are a coarse prefilter: they return every live row
+// sharing a match key, the account or the last name with the probe, and the
+// service scores what comes back.
+type DuplicateRepository interface {
+	FindLeads(probe models.DuplicateProbe, limit int) ([]models.Lead, error)
+	FindCustomers(probe models.DuplicateProbe, limit int) ([]models.Customer, error)
+
+	// RepointLead moves everything that references lead fromID to lead toID:
+	// tasks, form submissions, inbound emails and deals, soft-deleted ones
+	// included. RepointCustomer does the same for a customer's tasks, tickets,
+	// deals and inbound emails, and for the leads converted into it.
+	RepointLead(fromID, toID uint) (models.MergeCounts, error)
+	RepointCustomer(fromID, toID uint) (models.MergeCounts, error)
+
+	// EraseLead and EraseCustomer erase the record a merge folded away. They
+	// do not follow the conversion link the way a deletion does: by then the
+	// link belongs to the survivor.
+	EraseLead(id uint) error
+	EraseCustomer(id uint) error
+
+	// BackfillMatchKeys fills the match keys of the leads and customers that
+	// were stored before the keys existed and returns how many it filled.
+	BackfillMatchKeys() (int64, error)
+	WithTx(tx *gorm.DB) DuplicateRepository
+}
+
+// CustomFieldRepository stores the custom field definitions. The values are
+// read and written by CustomFieldPlugin alongside the records they belong to.
+type CustomFieldRepository interface {
+	// ListDefinitions returns the definitions of one entity type, or of all
+	// of them when entityType is empty, in display order.
+	ListDefinitions(entityType string) ([]models.CustomFieldDefinition, error)
+	GetDefinition(id uint) (*models.CustomFieldDefinition, error)
+	CountDefinitions(entityType string) (int64, error)
+	// CreateDefinition reports a name already taken for the entity type as
+	// apperrors.ErrValidation.
+	CreateDefinition(definition *models.CustomFieldDefinition) error
+	UpdateDefinition(definition *models.CustomFieldDefinition) error
+	// DeleteDefinition hard-deletes the definition and every value stored
+	// under it; gorm.ErrRecordNotFound when there is none.
+	DeleteDefinition(id uint) error
+	WithTx(tx *gorm.DB) CustomFieldRepository
+}
+
+// SearchRepository runs the global search on the full-text indexes created by
+// EnsureSearchIndexes. Soft-deleted records are never hits.
+type SearchRepository interface {
+	// Search returns at most query.Limit hits, best first, across the
+	// entity types of query.Scopes.
+	Search(query models.SearchQuery) ([]models.SearchHit, error)
+}
+
+// SavedViewRepository stores the saved views of the entity lists.
+type SavedViewRepository interface {
+	// ListVisible returns the views of entityType, or of every entity type
+	// when entityType is empty, that userID owns or that are shared with
+	// role, ordered by entity type and name.
+	ListVisible(entityType string, userID uint, role models.UserRole) ([]models.SavedView, error)
+	GetByID(id uint) (*models.SavedView, error)
+	// Create and Update report a name the owner already gave another view of
+	// the entity type as apperrors.ErrValidation.
+	Create(view *models.SavedView) error
+	Update(view *models.SavedView) error
+	// Delete hard-deletes the view; gorm.ErrRecordNotFound when there is none.
+	Delete(id uint) error
+}
+
+// RoleRepository stores the built-in and custom roles.
+type RoleRepository interface {
+	// List returns every role, the built-in ones first, then by name.
+	List() ([]models.Role, error)
+	// GetByName returns the role named name; gorm.ErrRecordNotFound when
+	// there is none.
+	GetByName(name string) (*models.Role, error)
+	// Create reports a taken name as apperrors.ErrValidation.
+	Create(role *models.Role) error
+	Update(role *models.Role) error
+	// Delete deletes a role no user holds, apperrors.ErrRoleInUse otherwise;
+	// gorm.ErrRecordNotFound when there is none.
+	Delete(name string) error
+	// AssignUser gives the user the role named name and returns the user;
+	// gorm.ErrRecordNotFound when there is no such user.
+	AssignUser(userID uint, name string) (*models.User, error)
+	// InitializeBuiltInRoles stores models.BuiltInRoles, replacing whatever
+	// an earlier version stored of them.
+	InitializeBuiltInRoles() error
+}
+
+// ImportRepository stores uploaded import files.
+type ImportRepository interface {
+	Create(imp *models.Import) error
+	// GetByID returns the import with its file content and its bulk
+	// operation, if it has been started.
+	GetByID(id uint) (*models.Import, error)
+	// List returns the imports of userID, or of every user when userID is 0,
+	// newest first, with their bulk operations but without their content.
+	List(userID uint, offset, limit int) ([]models.Import, int64, error)
+	// Start creates the bulk operation of an import and records it, with the
+	// import's Mapping and OwnerID, in one transaction. An import that already
+	// has an operation is apperrors.ErrImportStarted.
+	Start(imp *models.Import, operation *models.BulkOperation) error
+	// ListUnfinished returns the imports whose operation is still pending or
+	// processing, with their operations.
+	ListUnfinished() ([]models.Import, error)
+}
@@ -209,6 +209,68 @@ func (r *leadRepository) CountSearch(query string) (int64, error) {
 	return count, err
 }
 
+func (r *leadRepository) ListFiltered(filter models.LeadFilter, offset, limit int, preloads ...string) ([]models.Lead, int64, error) {
+	query, err := r.filteredQuery(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	for _, preload := range preloads {
+		query = query.Preload(preload)
+	}
+	if filter.Cursor != nil {
+		query, err = seekCursor(query, "leads", filter.Cursor)
+		if err != nil {
+			return nil, 0, err
+		}
+	} else if filter.CustomFields.Sort != nil {
+		query = orderByCustomField(query, "leads", filter.CustomFields.Sort)
+	} else if filter.SortBy != "" {
+		orderClause, err := utils.SafeOrderClause("leads", filter.SortBy, filter.SortOrder)
+		if err != nil {
+			return nil, 0, err
+		}
+		if orderClause != "" {
+			query = query.Order(orderClause)
+		}
+	}
+	leads := []models.Lead{}
+	err = query.Offset(offset).Limit(limit).Find(&leads).Error
+	return leads, total, err
+}
+
+// StreamFiltered hands every lead matching filter to fn, a batch at a time, in
+// the order of its sort with the id breaking ties.
+func (r *leadRepository) StreamFiltered(filter models.LeadFilter, fn func([]models.Lead) error) error {
+	return streamFiltered(func() (*gorm.DB, error) { return r.filteredQuery(filter) }, "leads", filter.SortBy, filter.SortOrder, fn)
+}
+
+// filteredQuery narrows the leads to those matching every condition of
+// filter, for ListFiltered and StreamFiltered.
+func (r *leadRepository) filteredQuery(filter models.LeadFilter) (*gorm.DB, error) {
+	query := r.db.Model(&models.Lead{})
+	if filter.Search != "" {
+		searchPattern := "%" + filter.Search + "%"
+		query = query.Where(
+			"first_name LIKE ? OR last_name LIKE ? OR email LIKE ? OR company LIKE ? OR phone LIKE ? OR notes LIKE ?",
+			searchPattern, searchPattern, searchPattern, searchPattern, searchPattern, searchPattern,
+		)
+	}
+	if filter.OwnerID != 0 {
+		query = query.Where("owner_id = ?", filter.OwnerID)
+	}
+	if filter.Classification != "" {
+		query = query.Where("classification = ?", filter.Classification)
+	}
+	query = whereCustomFields(query, "leads", filter.CustomFields.Filters)
+	return whereFilter(query, "leads", filter.Where)
+}
+
 func (r *leadRepository) Count() (int64, error) {
 	var count int64
 	err := r.db.Model(&models.Lead{}).Count(&count).Error
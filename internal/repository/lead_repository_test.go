@@ -1,10 +1,12 @@
 package repository
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
@@ -87,3 +89,39 @@ func TestLeadRepository_GetLatestByEmail_UsesTheTransaction(t *testing.T) {
 		return nil
 	}))
 }
+
+func TestLeadRepository_ListFiltered_StructuredFilter(t *testing.T) {
+	db := setupAnalyticsTestDB(t)
+	repo := NewLeadRepository(db)
+	owner := makeUser(t, db, "owner@example.com")
+	other := makeUser(t, db, "other@example.com")
+
+	ada := makeLead(t, db, "Ada", "ada@example.com", owner.ID)
+	grace := makeLead(t, db, "Grace", "grace@example.org", owner.ID)
+	makeLead(t, db, "Linus", "linus@example.com", other.ID)
+	require.NoError(t, db.Model(&grace).Update("status", models.LeadStatusQualified).Error)
+	stampTimes(t, db, &ada, time.Now().Add(-72*time.Hour), time.Now().Add(-72*time.Hour))
+
+	list := func(raw string, filter models.LeadFilter) ([]models.Lead, int64) {
+		t.Helper()
+		where, err := utils.ParseFilter("leads", raw)
+		require.NoError(t, err)
+		filter.Where = where
+		leads, total, err := repo.ListFiltered(filter, 0, 10)
+		require.NoError(t, err)
+		return leads, total
+	}
+
+	leads, total := list(`status eq qualified or email contains ".com" and created_at lt -1d`, models.LeadFilter{SortBy: "first_name", SortOrder: "asc"})
+	assert.Equal(t, int64(2), total, "the count is the count of the filtered list")
+	require.Len(t, leads, 2)
+	assert.Equal(t, "Ada", leads[0].FirstName)
+	assert.Equal(t, "Grace", leads[1].FirstName)
+
+	// The filter narrows the scoping a non-admin gets; it never widens it.
+	_, total = list(fmt.Sprintf("owner_id eq %d", other.ID), models.LeadFilter{OwnerID: owner.ID})
+	assert.Equal(t, int64(0), total)
+
+	_, total = list("status ne qualified and customer_id eq null", models.LeadFilter{OwnerID: owner.ID})
+	assert.Equal(t, int64(1), total)
+}
@@ -198,6 +198,24 @@ func (_m *CustomerRepository) List(offset int, limit int) ([]models.Customer, er
 	return r0, r1
 }
 
+// StreamFiltered provides a mock function with given fields: filter, fn
+func (_m *CustomerRepository) StreamFiltered(filter models.CustomerFilter, fn func([]models.Customer) error) error {
+	ret := _m.Called(filter, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamFiltered")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.CustomerFilter, func([]models.Customer) error) error); ok {
+		r0 = rf(filter, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: customer
 func (_m *CustomerRepository) Update(customer *models.Customer) error {
 	ret := _m.Called(customer)
@@ -212,6 +212,24 @@ func (_m *LeadRepository) CountByOwnerID(ownerID uint) (int64, error) {
 	return r0, r1
 }
 
+// StreamFiltered provides a mock function with given fields: filter, fn
+func (_m *LeadRepository) StreamFiltered(filter models.LeadFilter, fn func([]models.Lead) error) error {
+	ret := _m.Called(filter, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamFiltered")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.LeadFilter, func([]models.Lead) error) error); ok {
+		r0 = rf(filter, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: lead
 func (_m *LeadRepository) Update(lead *models.Lead) error {
 	ret := _m.Called(lead)
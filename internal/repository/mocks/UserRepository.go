@@ -201,6 +201,24 @@ func (_m *UserRepository) List(offset int, limit int) ([]models.User, error) {
 	return r0, r1
 }
 
+// StreamFiltered provides a mock function with given fields: filter, fn
+func (_m *UserRepository) StreamFiltered(filter models.UserFilter, fn func([]models.User) error) error {
+	ret := _m.Called(filter, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamFiltered")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.UserFilter, func([]models.User) error) error); ok {
+		r0 = rf(filter, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: user
 func (_m *UserRepository) Update(user *models.User) error {
 	ret := _m.Called(user)
@@ -260,6 +278,15 @@ func (_m *UserRepository) CountSearch(query string) (int64, error) {
 	return ret.Get(0).(int64), ret.Error(1)
 }
 
+func (_m *UserRepository) ListFiltered(filter models.UserFilter, offset int, limit int) ([]models.User, int64, error) {
+	ret := _m.Called(filter, offset, limit)
+	var r0 []models.User
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.User)
+	}
+	return r0, ret.Get(1).(int64), ret.Error(2)
+}
+
 func (_m *UserRepository) WithTx(tx *gorm.DB) repository.UserRepository {
 	_m.Called(tx)
 	return _m
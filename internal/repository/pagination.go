@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"gorm.io/gorm"
+)
+
+// seekCursor orders query by the sort of cursor, with the id breaking ties,
+// and narrows it to the rows after the cursor's position. It replaces the
+// ORDER BY and OFFSET of an offset page, and is applied after the count so
+// the total is still the total of the whole list. table is always the
+// repository's own table name, never input, and query must carry the model.
+//
+// A nullable column sorts its nulls last ascending and first descending, the
+// same on MySQL and SQLite, so that the order is the exact reverse of itself
+// and a position can be a null.
+func seekCursor(query *gorm.DB, table string, cursor *models.ListCursor) (*gorm.DB, error) {
+	column := cursor.SortBy
+	if column == "" {
+		column = "id"
+	}
+	if !utils.AllowedSortColumns[table][column] {
+		return nil, fmt.Errorf("invalid sort column %q for %s", column, table)
+	}
+	dir, cmp := "ASC", ">"
+	if cursor.Desc {
+		dir, cmp = "DESC", "<"
+	}
+	id := table + ".id"
+
+	if column == "id" {
+		query = query.Order(id + " " + dir)
+		if cursor.After != nil {
+			query = query.Where(id+" "+cmp+" ?", cursor.After.ID)
+		}
+		return query, nil
+	}
+
+	col := table + "." + column
+	nullable, err := nullableColumn(query, column)
+	if err != nil {
+		return nil, err
+	}
+	if nullable {
+		query = query.Order(col + " IS NULL " + dir)
+	}
+	query = query.Order(col + " " + dir).Order(id + " " + dir)
+
+	after := cursor.After
+	switch {
+	case after == nil:
+	case after.Value == nil && cursor.Desc:
+		// The nulls came first; the rest of them and every value follow.
+		query = query.Where(col+" IS NOT NULL OR "+id+" < ?", after.ID)
+	case after.Value == nil:
+		query = query.Where(col+" IS NULL AND "+id+" > ?", after.ID)
+	case nullable && !cursor.Desc:
+		query = query.Where(col+" IS NULL OR "+col+" > ? OR ("+col+" = ? AND "+id+" > ?)",
+			after.Value, after.Value, after.ID)
+	default:
+		query = query.Where(col+" "+cmp+" ? OR ("+col+" = ? AND "+id+" "+cmp+" ?)",
+			after.Value, after.Value, after.ID)
+	}
+	return query, nil
+}
+
+// nullableColumn reports whether the model of query holds column in a
+// pointer field.
+func nullableColumn(query *gorm.DB, column string) (bool, error) {
+	stmt := &gorm.Statement{DB: query}
+	if err := stmt.Parse(query.Statement.Model); err != nil {
+		return false, err
+	}
+	field := stmt.Schema.LookUpField(column)
+	if field == nil {
+		return false, fmt.Errorf("%s has no column %q", stmt.Schema.Table, column)
+	}
+	return field.FieldType.Kind() == reflect.Ptr, nil
+}
+
+// exportBatchSize is how many rows streamFiltered reads per round trip, and
+// so how many an export holds in memory at once.
+const exportBatchSize = 500
+
+// streamFiltered hands every row of the list filtered builds to fn, a batch
+// at a time, with no pagination at all — an export is a whole-list download,
+// and a page boundary in the middle of it would produce a file that looks
+// complete and is not.
+//
+// Each batch is read by keyset, after the last row of the one before in the
+// order of sortBy with the id breaking ties, so memory stays at one batch
+// however large the table is, every row comes exactly once even when the sort
+// column has duplicates, and no query holds a connection for as long as a slow
+// client takes to download the file. A row created or deleted while an export
+// runs may or may not be in it; nothing is read twice or skipped. Soft-deleted
+// rows stay out by GORM's own scope: an erased person must never reappear in
+// an export.
+//
+// filtered builds a fresh query of the list for each batch. fn returning an
+// error stops the stream with that error.
+func streamFiltered[T any](filtered func() (*gorm.DB, error), table, sortBy, sortOrder string, fn func([]T) error) error {
+	cursor := &models.ListCursor{SortBy: sortBy, Desc: sortOrder == "desc"}
+	for {
+		query, err := filtered()
+		if err != nil {
+			return err
+		}
+		if query, err = seekCursor(query, table, cursor); err != nil {
+			return err
+		}
+
+		batch := make([]T, 0, exportBatchSize)
+		if err := query.Limit(exportBatchSize).Find(&batch).Error; err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if len(batch) < exportBatchSize {
+			return nil
+		}
+		if cursor.After, err = utils.RowPosition(sortBy, &batch[len(batch)-1]); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pageTasks reads the whole task list page by page through cursors, the way
+// a client following next_cursor does, and returns the ids in order.
+func pageTasks(t *testing.T, repo TaskRepository, sortBy string, desc bool, pageSize int) []uint {
+	t.Helper()
+	ids := []uint{}
+	raw := ""
+	for {
+		cursor := &models.ListCursor{SortBy: sortBy, Desc: desc}
+		if raw != "" {
+			var err error
+			cursor, err = utils.DecodeCursor("tasks", raw, sortBy, desc)
+			require.NoError(t, err)
+		}
+		tasks, total, err := repo.ListFiltered(models.TaskFilter{Cursor: cursor}, 0, pageSize+1)
+		require.NoError(t, err)
+		require.Equal(t, int64(7), total, "the total is of the whole list on every page")
+
+		if len(tasks) <= pageSize {
+			for _, task := range tasks {
+				ids = append(ids, task.ID)
+			}
+			return ids
+		}
+		for _, task := range tasks[:pageSize] {
+			ids = append(ids, task.ID)
+		}
+		raw, err = utils.EncodeCursor(cursor, &tasks[pageSize-1])
+		require.NoError(t, err)
+		require.Less(t, len(ids), 7, "paging does not end")
+	}
+}
+
+// Pages follow the same order as one query over the whole list, however the
+// page boundaries fall between equal values and nulls: every task comes once.
+func TestSeekCursor_PagesThroughTiesAndNulls(t *testing.T) {
+	db := setupAnalyticsTestDB(t)
+	repo := NewTaskRepository(db)
+	assignee := makeUser(t, db, "assignee@example.com")
+
+	// Sub-second precision makes the cursor's time round-trip matter.
+	soon := time.Date(2026, 5, 1, 9, 0, 0, 123456789, time.UTC)
+	later := soon.Add(48 * time.Hour)
+	dueDates := []*time.Time{&later, nil, &soon, &later, nil, &soon, &soon}
+	ids := make([]uint, len(dueDates))
+	for i, due := range dueDates {
+		task := models.Task{Title: fmt.Sprintf("Task %d", i), AssignedToID: assignee.ID, DueDate: due}
+		require.NoError(t, db.Create(&task).Error)
+		ids[i] = task.ID
+	}
+
+	// Ascending: soon, then later, then the nulls, ties by id.
+	ascending := []uint{ids[2], ids[5], ids[6], ids[0], ids[3], ids[1], ids[4]}
+	descending := make([]uint, len(ascending))
+	for i, id := range ascending {
+		descending[len(ascending)-1-i] = id
+	}
+
+	for _, pageSize := range []int{1, 2, 3, 7} {
+		assert.Equal(t, ascending, pageTasks(t, repo, "due_date", false, pageSize), "asc, pages of %d", pageSize)
+		assert.Equal(t, descending, pageTasks(t, repo, "due_date", true, pageSize), "desc, pages of %d", pageSize)
+	}
+	assert.Equal(t, ids, pageTasks(t, repo, "", false, 3), "no sort pages by id")
+	assert.Equal(t, []uint{ids[6], ids[5], ids[4], ids[3], ids[2], ids[1], ids[0]},
+		pageTasks(t, repo, "title", true, 2), "a column without nulls")
+}
+
+func TestSeekCursor_RejectsUnknownColumn(t *testing.T) {
+	db := setupAnalyticsTestDB(t)
+	repo := NewTaskRepository(db)
+
+	_, _, err := repo.ListFiltered(models.TaskFilter{Cursor: &models.ListCursor{SortBy: "title; DROP TABLE tasks"}}, 0, 10)
+	assert.Error(t, err)
+}
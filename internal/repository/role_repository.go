@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"fmt"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"gorm.io/gorm"
+)
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+func (r *roleRepository) List() ([]models.Role, error) {
+	roles := []models.Role{}
+	err := r.db.Order("built_in DESC, name ASC").Find(&roles).Error
+	return roles, err
+}
+
+func (r *roleRepository) GetByName(name string) (*models.Role, error) {
+	var role models.Role
+	if err := r.db.Where("name = ?", name).First(&role).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *roleRepository) Create(role *models.Role) error {
+	err := r.db.Create(role).Error
+	if isDuplicateKeyError(err) {
+		return fmt.Errorf("a role named %q already exists: %w", role.Name, apperrors.ErrValidation)
+	}
+	return err
+}
+
+func (r *roleRepository) Update(role *models.Role) error {
+	return r.db.Save(role).Error
+}
+
+func (r *roleRepository) Delete(name string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Soft-deleted users count: restoring one must not leave it holding
+		// a role that no longer exists.
+		var holders int64
+		if err := tx.Unscoped().Model(&models.User{}).Where("role = ?", name).Count(&holders).Error; err != nil {
+			return err
+		}
+		if holders > 0 {
+			return fmt.Errorf("role %q is held by %d users: %w", name, holders, apperrors.ErrRoleInUse)
+		}
+		result := tx.Where("name = ?", name).Delete(&models.Role{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+func (r *roleRepository) AssignUser(userID uint, name string) (*models.User, error) {
+	var user models.User
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Update("role", name).Error; err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *roleRepository) InitializeBuiltInRoles() error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, role := range models.BuiltInRoles() {
+			var existing models.Role
+			err := tx.Where("name = ?", role.Name).Limit(1).Find(&existing).Error
+			if err != nil {
+				return err
+			}
+			if existing.ID != 0 {
+				role.ID = existing.ID
+				role.CreatedAt = existing.CreatedAt
+			}
+			if err := tx.Save(&role).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupRoleDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Role{}))
+	return db
+}
+
+func TestRoleRepository_InitializeBuiltInRoles(t *testing.T) {
+	db := setupRoleDB(t)
+	repo := NewRoleRepository(db)
+
+	require.NoError(t, repo.InitializeBuiltInRoles())
+	// A stale copy of a built-in role, as an earlier version stored it, is
+	// brought back in line with the code on the next start.
+	require.NoError(t, db.Model(&models.Role{}).Where("name = ?", "sales").Update("permissions", `["leads:create"]`).Error)
+	require.NoError(t, repo.InitializeBuiltInRoles())
+
+	roles, err := repo.List()
+	require.NoError(t, err)
+	require.Len(t, roles, 4, "seeding twice stores each role once")
+	for _, role := range roles {
+		assert.True(t, role.BuiltIn)
+		assert.Equal(t, models.UserRole(role.Name), role.BaseRole)
+		assert.Equal(t, models.BuiltInPermissions(role.BaseRole), role.PermissionSet(), role.Name)
+	}
+}
+
+func TestRoleRepository_CreateListAndAssign(t *testing.T) {
+	db := setupRoleDB(t)
+	repo := NewRoleRepository(db)
+	require.NoError(t, repo.InitializeBuiltInRoles())
+
+	auditor := &models.Role{Name: "auditor", BaseRole: models.RoleAdmin, Permissions: []models.Permission{models.PermAuditRead}}
+	require.NoError(t, repo.Create(auditor))
+	err := repo.Create(&models.Role{Name: "auditor", BaseRole: models.RoleSupport})
+	assert.ErrorIs(t, err, apperrors.ErrValidation, "names are unique")
+
+	roles, err := repo.List()
+	require.NoError(t, err)
+	require.Len(t, roles, 5)
+	assert.Equal(t, "auditor", roles[4].Name, "custom roles follow the built-in ones")
+
+	stored, err := repo.GetByName("auditor")
+	require.NoError(t, err)
+	assert.Equal(t, []models.Permission{models.PermAuditRead}, stored.Permissions)
+
+	user := &models.User{Email: "a@example.com", Password: "hashed", FirstName: "A", LastName: "B", Role: models.RoleSupport, IsActive: true}
+	require.NoError(t, db.Create(user).Error)
+	assigned, err := repo.AssignUser(user.ID, "auditor")
+	require.NoError(t, err)
+	assert.Equal(t, models.UserRole("auditor"), assigned.Role)
+
+	_, err = repo.AssignUser(999, "auditor")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+
+	// A role cannot go while anyone, even a deleted user, still holds it.
+	require.NoError(t, db.Delete(user).Error)
+	assert.ErrorIs(t, repo.Delete("auditor"), apperrors.ErrRoleInUse)
+	require.NoError(t, db.Unscoped().Model(&models.User{}).Where("id = ?", user.ID).Update("role", models.RoleSupport).Error)
+	require.NoError(t, repo.Delete("auditor"))
+	assert.True(t, errors.Is(repo.Delete("auditor"), gorm.ErrRecordNotFound))
+}
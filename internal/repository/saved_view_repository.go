@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"fmt"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"gorm.io/gorm"
+)
+
+type savedViewRepository struct {
+	db *gorm.DB
+}
+
+func NewSavedViewRepository(db *gorm.DB) SavedViewRepository {
+	return &savedViewRepository{db: db}
+}
+
+func (r *savedViewRepository) ListVisible(entityType string, userID uint, role models.UserRole) ([]models.SavedView, error) {
+	views := []models.SavedView{}
+	query := r.db.Where("owner_id = ? OR shared_with_role = ?", userID, role).
+		Order("entity_type ASC, name ASC, id ASC")
+	if entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	err := query.Find(&views).Error
+	return views, err
+}
+
+func (r *savedViewRepository) GetByID(id uint) (*models.SavedView, error) {
+	var view models.SavedView
+	if err := r.db.First(&view, id).Error; err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+func (r *savedViewRepository) Create(view *models.SavedView) error {
+	return r.duplicateName(view, r.db.Create(view).Error)
+}
+
+func (r *savedViewRepository) Update(view *models.SavedView) error {
+	return r.duplicateName(view, r.db.Save(view).Error)
+}
+
+func (r *savedViewRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.SavedView{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *savedViewRepository) duplicateName(view *models.SavedView, err error) error {
+	if isDuplicateKeyError(err) {
+		return fmt.Errorf("you already have a %s view named %q: %w", view.EntityType, view.Name, apperrors.ErrValidation)
+	}
+	return err
+}
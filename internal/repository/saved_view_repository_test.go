@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupSavedViewDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	require.NoError(t, db.AutoMigrate(&models.SavedView{}))
+	return db
+}
+
+func TestSavedViewRepository_ListVisible(t *testing.T) {
+	repo := NewSavedViewRepository(setupSavedViewDB(t))
+	for _, view := range []models.SavedView{
+		{OwnerID: 1, EntityType: models.AuditEntityLead, Name: "Mine"},
+		{OwnerID: 1, EntityType: models.AuditEntityTask, Name: "My tasks"},
+		{OwnerID: 2, EntityType: models.AuditEntityLead, Name: "Hot leads this week", SharedWithRole: models.RoleSales},
+		{OwnerID: 2, EntityType: models.AuditEntityLead, Name: "Private"},
+		{OwnerID: 3, EntityType: models.AuditEntityLead, Name: "For support", SharedWithRole: models.RoleSupport},
+	} {
+		require.NoError(t, repo.Create(&view))
+	}
+
+	views, err := repo.ListVisible(models.AuditEntityLead, 1, models.RoleSales)
+	require.NoError(t, err)
+	names := make([]string, len(views))
+	for i, view := range views {
+		names[i] = view.Name
+	}
+	assert.Equal(t, []string{"Hot leads this week", "Mine"}, names, "own and shared with the role, by name")
+
+	views, err = repo.ListVisible("", 1, models.RoleSales)
+	require.NoError(t, err)
+	assert.Len(t, views, 3, "every entity type")
+}
+
+func TestSavedViewRepository_RoundTrip(t *testing.T) {
+	repo := NewSavedViewRepository(setupSavedViewDB(t))
+	view := &models.SavedView{OwnerID: 1, EntityType: models.AuditEntityLead, Name: "Hot",
+		Filter: "classification eq hot_lead", Columns: []string{"email", "cf.region"}}
+	require.NoError(t, repo.Create(view))
+
+	stored, err := repo.GetByID(view.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"email", "cf.region"}, stored.Columns)
+
+	stored.Columns = nil
+	require.NoError(t, repo.Update(stored))
+	stored, err = repo.GetByID(view.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, stored.Columns, "no columns marshal as []")
+	assert.Empty(t, stored.Columns)
+
+	require.NoError(t, repo.Delete(view.ID))
+	_, err = repo.GetByID(view.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, repo.Delete(view.ID), gorm.ErrRecordNotFound)
+}
+
+func TestSavedViewRepository_DuplicateName(t *testing.T) {
+	repo := NewSavedViewRepository(setupSavedViewDB(t))
+	require.NoError(t, repo.Create(&models.SavedView{OwnerID: 1, EntityType: models.AuditEntityLead, Name: "Hot"}))
+
+	err := repo.Create(&models.SavedView{OwnerID: 1, EntityType: models.AuditEntityLead, Name: "Hot"})
+	assert.True(t, errors.Is(err, apperrors.ErrValidation), "got %v", err)
+
+	assert.NoError(t, repo.Create(&models.SavedView{OwnerID: 2, EntityType: models.AuditEntityLead, Name: "Hot"}),
+		"names are per owner")
+	assert.NoError(t, repo.Create(&models.SavedView{OwnerID: 1, EntityType: models.AuditEntityTask, Name: "Hot"}),
+		"and per entity type")
+}
@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"gorm.io/gorm"
+)
+
+// searchSource is the full-text index of one searchable table. columns is the
+// indexed column list, in index order: MySQL only answers a MATCH on exactly
+// the columns of a FULLTEXT index. A hit's title is its title columns joined
+// by spaces, its subtitle the subtitle column.
+type searchSource struct {
+	entity   string
+	table    string
+	columns  []string
+	title    []string
+	subtitle string
+	// scope is the column a SearchScope.UserID is compared with, empty for
+	// an entity every role that can search it sees in full.
+	scope string
+}
+
+var searchSources = []searchSource{
+	{
+		entity: models.AuditEntityLead, table: "leads",
+		columns: []string{"first_name", "last_name", "email", "phone", "company", "notes"},
+		title:   []string{"first_name", "last_name"}, subtitle: "company", scope: "owner_id",
+	},
+	{
+		entity: models.AuditEntityCustomer, table: "customers",
+		columns: []string{"first_name", "last_name", "email", "phone", "company", "notes"},
+		title:   []string{"first_name", "last_name"}, subtitle: "company",
+	},
+	{
+		entity: models.AuditEntityTicket, table: "tickets",
+		columns: []string{"title", "description", "resolution"},
+		title:   []string{"title"}, subtitle: "status", scope: "assigned_to_id",
+	},
+	{
+		entity: models.AuditEntityTask, table: "tasks",
+		columns: []string{"title", "description"},
+		title:   []string{"title"}, subtitle: "status", scope: "assigned_to_id",
+	},
+	{
+		entity: models.SearchEntityFormSubmission, table: "form_submissions",
+		columns: []string{"email", "data"},
+		title:   []string{"email"}, subtitle: "status",
+	},
+}
+
+func findSearchSource(entity string) (searchSource, bool) {
+	for _, source := range searchSources {
+		if source.entity == entity {
+			return source, true
+		}
+	}
+	return searchSource{}, false
+}
+
+// EnsureSearchIndexes creates the full-text indexes the global search reads,
+// once the tables exist. On MySQL each searchable table gets a FULLTEXT index,
+// which InnoDB keeps current on its own. SQLite, which only the tests run on,
+// gets an FTS5 table per searchable table, kept current by triggers and
+// filled from the rows already stored when it is created; the driver must be
+// built with the sqlite_fts5 tag for FTS5 to exist.
+func EnsureSearchIndexes(db *gorm.DB) error {
+	switch db.Dialector.Name() {
+	case "mysql":
+		for _, source := range searchSources {
+			index := "idx_" + source.table + "_search"
+			if db.Migrator().HasIndex(source.table, index) {
+				continue
+			}
+			statement := fmt.Sprintf("ALTER TABLE %s ADD FULLTEXT INDEX %s (%s)",
+				source.table, index, strings.Join(source.columns, ", "))
+			if err := db.Exec(statement).Error; err != nil {
+				return fmt.Errorf("full-text index of %s: %w", source.table, err)
+			}
+		}
+		return nil
+	case "sqlite":
+		for _, source := range searchSources {
+			if err := ensureFTS5Table(db, source); err != nil {
+				return fmt.Errorf("full-text index of %s: %w", source.table, err)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("full-text search is not supported on %s", db.Dialector.Name())
+	}
+}
+
+func ensureFTS5Table(db *gorm.DB, source searchSource) error {
+	fts := source.table + "_fts"
+	columns := strings.Join(source.columns, ", ")
+	insert := fmt.Sprintf("INSERT INTO %s(rowid, %s) VALUES (new.id, %s);", fts, columns, qualify("new", source.columns))
+	remove := fmt.Sprintf("INSERT INTO %s(%s, rowid, %s) VALUES ('delete', old.id, %s);",
+		fts, fts, columns, qualify("old", source.columns))
+
+	created := !db.Migrator().HasTable(fts)
+	statements := []string{
+		fmt.Sprintf("CREATE VIRTUAL TABLE IF NOT EXISTS %s USING fts5(%s, content='%s', content_rowid='id')",
+			fts, columns, source.table),
+		fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %s_ai AFTER INSERT ON %s BEGIN %s END", fts, source.table, insert),
+		fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %s_ad AFTER DELETE ON %s BEGIN %s END", fts, source.table, remove),
+		fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %s_au AFTER UPDATE ON %s BEGIN %s %s END", fts, source.table, remove, insert),
+	}
+	if created {
+		statements = append(statements, fmt.Sprintf("INSERT INTO %s(%s) VALUES ('rebuild')", fts, fts))
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type searchRepository struct {
+	db *gorm.DB
+}
+
+func NewSearchRepository(db *gorm.DB) SearchRepository {
+	return &searchRepository{db: db}
+}
+
+func (r *searchRepository) Search(query models.SearchQuery) ([]models.SearchHit, error) {
+	hits := []models.SearchHit{}
+	if len(query.Terms) == 0 || query.Limit <= 0 {
+		return hits, nil
+	}
+	for _, scope := range query.Scopes {
+		source, ok := findSearchSource(scope.Entity)
+		if !ok {
+			return nil, fmt.Errorf("entity type %q is not searchable", scope.Entity)
+		}
+		if scope.UserID != 0 && source.scope == "" {
+			return nil, fmt.Errorf("entity type %q has no per-user scope", scope.Entity)
+		}
+		sourceHits, err := r.searchSource(source, scope.UserID, query.Terms, query.Limit)
+		if err != nil {
+			return nil, fmt.Errorf("search %s: %w", source.table, err)
+		}
+		hits = append(hits, sourceHits...)
+	}
+
+	// Each source returned its best hits; the overall best are the best of
+	// those. The sort is stable so hits of equal score keep the source order.
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if len(hits) > query.Limit {
+		hits = hits[:query.Limit]
+	}
+	return hits, nil
+}
+
+func (r *searchRepository) searchSource(source searchSource, userID uint, terms []string, limit int) ([]models.SearchHit, error) {
+	display := append(append([]string{}, source.title...), source.subtitle)
+
+	var statement string
+	var vars []interface{}
+	switch r.db.Dialector.Name() {
+	case "mysql":
+		// Boolean mode: +term* requires every term, each as a word or a
+		// word prefix. A prefix is never dropped as too short or a stopword.
+		against := make([]string, len(terms))
+		for i, term := range terms {
+			against[i] = "+" + term + "*"
+		}
+		match := fmt.Sprintf("MATCH (%s) AGAINST (? IN BOOLEAN MODE)", qualify("t", source.columns))
+		statement = fmt.Sprintf("SELECT t.id, %s AS score, %s FROM %s t WHERE %s AND t.deleted_at IS NULL",
+			match, qualify("t", display), source.table, match)
+		vars = []interface{}{strings.Join(against, " "), strings.Join(against, " ")}
+	case "sqlite":
+		// bm25 is lower for better matches, so its negation ranks like
+		// MySQL's relevance.
+		phrases := make([]string, len(terms))
+		for i, term := range terms {
+			phrases[i] = `"` + term + `"*`
+		}
+		fts := source.table + "_fts"
+		statement = fmt.Sprintf("SELECT t.id, -bm25(%s) AS score, %s FROM %s JOIN %s t ON t.id = %s.rowid WHERE %s MATCH ? AND t.deleted_at IS NULL",
+			fts, qualify("t", display), fts, source.table, fts, fts)
+		vars = []interface{}{strings.Join(phrases, " ")}
+	default:
+		return nil, fmt.Errorf("full-text search is not supported on %s", r.db.Dialector.Name())
+	}
+	if userID != 0 {
+		statement += " AND t." + source.scope + " = ?"
+		vars = append(vars, userID)
+	}
+	statement += " ORDER BY score DESC, t.id DESC LIMIT ?"
+	vars = append(vars, limit)
+
+	rows, err := r.db.Raw(statement, vars...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := []models.SearchHit{}
+	for rows.Next() {
+		hit := models.SearchHit{EntityType: source.entity}
+		texts := make([]sql.NullString, len(display))
+		dest := []interface{}{&hit.EntityID, &hit.Score}
+		for i := range texts {
+			dest = append(dest, &texts[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		title := make([]string, 0, len(source.title))
+		for _, text := range texts[:len(source.title)] {
+			if text.String != "" {
+				title = append(title, text.String)
+			}
+		}
+		hit.Title = strings.Join(title, " ")
+		hit.Subtitle = texts[len(texts)-1].String
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+func qualify(table string, columns []string) string {
+	qualified := make([]string, len(columns))
+	for i, column := range columns {
+		qualified[i] = table + "." + column
+	}
+	return strings.Join(qualified, ", ")
+}
@@ -0,0 +1,197 @@
+//go:build sqlite_fts5
+
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The search indexes are FTS5 tables on SQLite, and FTS5 only exists when the
+// driver is built with the sqlite_fts5 tag, so these tests are built with it
+// too (make test sets it). Without the tag search_repository_nofts5_test.go reports them
+// skipped instead.
+
+// setupSearchDB opens a private in-memory database with the search indexes.
+func setupSearchDB(t *testing.T, seed func(db *gorm.DB)) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	if err := db.Exec("CREATE VIRTUAL TABLE fts5_probe USING fts5(x)").Error; err != nil {
+		t.Fatal("SQLite driver built without FTS5 despite the sqlite_fts5 tag")
+	}
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Lead{}, &models.Customer{}, &models.Ticket{},
+		&models.Task{}, &models.Form{}, &models.FormSubmission{},
+		// Erasing a lead also scrubs these.
+		&models.AuditEvent{}, &models.ActivityEvent{}, &models.WebhookDelivery{},
+		&models.CustomFieldDefinition{}, &models.CustomFieldValue{}, &models.BulkOperation{}, &models.BulkOperationItem{}, &models.Import{}))
+	if seed != nil {
+		seed(db)
+	}
+	require.NoError(t, EnsureSearchIndexes(db))
+	// A second run finds everything in place.
+	require.NoError(t, EnsureSearchIndexes(db))
+	return db
+}
+
+func searchTitles(hits []models.SearchHit) []string {
+	titles := make([]string, len(hits))
+	for i, hit := range hits {
+		titles[i] = hit.EntityType + ":" + hit.Title
+	}
+	return titles
+}
+
+func allSearchScopes() []models.SearchScope {
+	scopes := make([]models.SearchScope, len(models.SearchEntities))
+	for i, entity := range models.SearchEntities {
+		scopes[i] = models.SearchScope{Entity: entity}
+	}
+	return scopes
+}
+
+func TestSearchRepository_AcrossEntities(t *testing.T) {
+	db := setupSearchDB(t, nil)
+	repo := NewSearchRepository(db)
+	owner := makeUser(t, db, "owner@example.com")
+
+	lead := makeLead(t, db, "Ada", "ada@lovelace.dev", owner.ID)
+	require.NoError(t, db.Model(&lead).Update("company", "Analytical Engines").Error)
+	require.NoError(t, db.Create(&models.Customer{FirstName: "Charles", LastName: "Babbage", Email: "charles@example.com",
+		Company: "Difference Engines"}).Error)
+	require.NoError(t, db.Create(&models.Ticket{Title: "Engine overheats", Description: "The analytical engine stalls",
+		CustomerID: 1}).Error)
+	require.NoError(t, db.Create(&models.Task{Title: "Call back", Description: "About the engines order",
+		AssignedToID: owner.ID}).Error)
+	require.NoError(t, db.Create(&models.FormSubmission{FormID: 1, Email: "grace@example.com",
+		Data: map[string]string{"message": "Quote for two engines"}, Status: models.FormSubmissionReceived}).Error)
+	makeLead(t, db, "Grace", "grace@example.com", owner.ID)
+
+	hits, err := repo.Search(models.SearchQuery{Terms: []string{"engine"}, Scopes: allSearchScopes(), Limit: 20})
+
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"lead:Ada Tester", "customer:Charles Babbage", "ticket:Engine overheats", "task:Call back",
+		"form_submission:grace@example.com",
+	}, searchTitles(hits), "engine is the start of engines: every record mentioning either is a hit")
+	assert.Equal(t, "ticket:Engine overheats", searchTitles(hits)[0], "two mentions in a short text rank first")
+	for i := 1; i < len(hits); i++ {
+		assert.GreaterOrEqual(t, hits[i-1].Score, hits[i].Score)
+	}
+
+	hits, err = repo.Search(models.SearchQuery{Terms: []string{"analytical", "engine"}, Scopes: allSearchScopes(), Limit: 20})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"lead:Ada Tester", "ticket:Engine overheats"}, searchTitles(hits),
+		"every term must match")
+	for _, hit := range hits {
+		if hit.EntityType == models.AuditEntityLead {
+			assert.Equal(t, lead.ID, hit.EntityID)
+			assert.Equal(t, "Analytical Engines", hit.Subtitle)
+		}
+	}
+
+	hits, err = repo.Search(models.SearchQuery{Terms: []string{"engine"}, Scopes: allSearchScopes(), Limit: 2})
+	require.NoError(t, err)
+	assert.Len(t, hits, 2)
+}
+
+func TestSearchRepository_Scopes(t *testing.T) {
+	db := setupSearchDB(t, nil)
+	repo := NewSearchRepository(db)
+	owner := makeUser(t, db, "owner@example.com")
+	other := makeUser(t, db, "other@example.com")
+
+	makeLead(t, db, "Mine", "acme-mine@example.com", owner.ID)
+	makeLead(t, db, "Theirs", "acme-theirs@example.com", other.ID)
+	require.NoError(t, db.Create(&models.Task{Title: "Acme renewal", AssignedToID: other.ID}).Error)
+
+	hits, err := repo.Search(models.SearchQuery{Terms: []string{"acme"}, Limit: 20, Scopes: []models.SearchScope{
+		{Entity: models.AuditEntityLead, UserID: owner.ID}, {Entity: models.AuditEntityTask, UserID: owner.ID},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lead:Mine Tester"}, searchTitles(hits))
+
+	hits, err = repo.Search(models.SearchQuery{Terms: []string{"acme"}, Limit: 20, Scopes: []models.SearchScope{
+		{Entity: models.AuditEntityTicket},
+	}})
+	require.NoError(t, err)
+	assert.Empty(t, hits, "entity types without a scope are not searched")
+
+	_, err = repo.Search(models.SearchQuery{Terms: []string{"acme"}, Limit: 20, Scopes: []models.SearchScope{
+		{Entity: models.AuditEntityCustomer, UserID: owner.ID},
+	}})
+	assert.Error(t, err, "customers have no per-user scope")
+}
+
+func TestSearchRepository_FollowsWrites(t *testing.T) {
+	db := setupSearchDB(t, nil)
+	repo := NewSearchRepository(db)
+	owner := makeUser(t, db, "owner@example.com")
+	search := func(term string) []string {
+		t.Helper()
+		hits, err := repo.Search(models.SearchQuery{Terms: []string{term}, Scopes: allSearchScopes(), Limit: 20})
+		require.NoError(t, err)
+		return searchTitles(hits)
+	}
+
+	lead := makeLead(t, db, "Ada", "ada@example.com", owner.ID)
+	require.NoError(t, db.Model(&lead).Update("notes", "prefers zeppelins").Error)
+	assert.Equal(t, []string{"lead:Ada Tester"}, search("zeppelin"))
+
+	require.NoError(t, db.Model(&lead).Update("notes", "prefers airships").Error)
+	assert.Empty(t, search("zeppelin"), "the old text leaves the index on update")
+	assert.Equal(t, []string{"lead:Ada Tester"}, search("airship"))
+
+	gone := makeLead(t, db, "Gone", "gone@example.com", owner.ID)
+	require.NoError(t, db.Delete(&gone).Error)
+	assert.Empty(t, search("gone"), "soft-deleted records are never hits")
+
+	// Erasure overwrites the person's data, and the index with it.
+	require.NoError(t, NewLeadRepository(db).Delete(lead.ID))
+	var indexed int64
+	require.NoError(t, db.Raw("SELECT COUNT(*) FROM leads_fts WHERE leads_fts MATCH ?", "ada").Scan(&indexed).Error)
+	assert.Zero(t, indexed)
+}
+
+func TestSearchRepository_IndexesExistingRows(t *testing.T) {
+	db := setupSearchDB(t, func(db *gorm.DB) {
+		owner := makeUser(t, db, "owner@example.com")
+		makeLead(t, db, "Early", "early@example.com", owner.ID)
+	})
+
+	hits, err := NewSearchRepository(db).Search(models.SearchQuery{
+		Terms: []string{"early"}, Scopes: allSearchScopes(), Limit: 20,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lead:Early Tester"}, searchTitles(hits))
+}
+
+func TestSearchRepository_TermsAreNotSyntax(t *testing.T) {
+	db := setupSearchDB(t, nil)
+	owner := makeUser(t, db, "owner@example.com")
+	makeLead(t, db, "Near", "near@example.com", owner.ID)
+
+	// FTS5 keywords are matched as words, never read as operators.
+	for _, term := range []string{"near", "and", "not", strings.Repeat("x", 64)} {
+		_, err := NewSearchRepository(db).Search(models.SearchQuery{
+			Terms: []string{term}, Scopes: allSearchScopes(), Limit: 20,
+		})
+		assert.NoError(t, err, term)
+	}
+}
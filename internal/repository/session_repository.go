@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(session *models.Session) error {
+	return r.db.Create(session).Error
+}
+
+func (r *sessionRepository) GetByID(id uint) (*models.Session, error) {
+	var session models.Session
+	if err := r.db.First(&session, id).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) ListActive(userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC, id DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *sessionRepository) Touch(id uint, ipAddress string, expiresAt time.Time) error {
+	result := r.db.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"ip_address":   ipAddress,
+			"last_used_at": time.Now(),
+			"expires_at":   expiresAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *sessionRepository) Revoke(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Session{}).Where("id = ? AND revoked_at IS NULL", id).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).Where("session_id = ? AND is_revoked = ?", id, false).
+			Update("is_revoked", true).Error
+	})
+}
+
+func (r *sessionRepository) RevokeAllForUser(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).Where("user_id = ? AND is_revoked = ?", userID, false).
+			Update("is_revoked", true).Error
+	})
+}
+
+func (r *sessionRepository) RevokeToken(token *models.RevokedToken) error {
+	if err := r.db.Where("expires_at < ?", time.Now()).Delete(&models.RevokedToken{}).Error; err != nil {
+		return err
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(token).Error
+}
+
+func (r *sessionRepository) Revocations(since time.Time) ([]string, []uint, error) {
+	var tokenIDs []string
+	if err := r.db.Model(&models.RevokedToken{}).Where("expires_at > ?", time.Now()).
+		Pluck("jti", &tokenIDs).Error; err != nil {
+		return nil, nil, err
+	}
+	var sessionIDs []uint
+	if err := r.db.Model(&models.Session{}).Where("revoked_at > ?", since).
+		Pluck("id", &sessionIDs).Error; err != nil {
+		return nil, nil, err
+	}
+	return tokenIDs, sessionIDs, nil
+}
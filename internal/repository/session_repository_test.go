@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupSessionDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.RefreshToken{}, &models.Session{}, &models.RevokedToken{}))
+	return db
+}
+
+func createTestSession(t *testing.T, repo SessionRepository, userID uint, lastUsed time.Time) *models.Session {
+	t.Helper()
+	session := &models.Session{UserID: userID, Device: "Firefox on Linux", IPAddress: "192.0.2.1",
+		LastUsedAt: lastUsed, ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, repo.Create(session))
+	return session
+}
+
+func TestSessionRepository_ListActive(t *testing.T) {
+	db := setupSessionDB(t)
+	repo := NewSessionRepository(db)
+	now := time.Now()
+
+	older := createTestSession(t, repo, 1, now.Add(-time.Hour))
+	newer := createTestSession(t, repo, 1, now)
+	revoked := createTestSession(t, repo, 1, now)
+	require.NoError(t, repo.Revoke(revoked.ID))
+	expired := createTestSession(t, repo, 1, now)
+	require.NoError(t, db.Model(expired).Update("expires_at", now.Add(-time.Minute)).Error)
+	createTestSession(t, repo, 2, now)
+
+	sessions, err := repo.ListActive(1)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, newer.ID, sessions[0].ID, "most recently used first")
+	assert.Equal(t, older.ID, sessions[1].ID)
+}
+
+func TestSessionRepository_RevokeEndsOnlyThatSession(t *testing.T) {
+	db := setupSessionDB(t)
+	repo := NewSessionRepository(db)
+	stolen := createTestSession(t, repo, 1, time.Now())
+	kept := createTestSession(t, repo, 1, time.Now())
+	expires := time.Now().Add(time.Hour)
+	require.NoError(t, db.Create(&models.RefreshToken{UserID: 1, SessionID: stolen.ID, TokenHash: "a", ExpiresAt: expires}).Error)
+	require.NoError(t, db.Create(&models.RefreshToken{UserID: 1, SessionID: kept.ID, TokenHash: "b", ExpiresAt: expires}).Error)
+
+	require.NoError(t, repo.Revoke(stolen.ID))
+
+	var revoked []string
+	require.NoError(t, db.Model(&models.RefreshToken{}).Where("is_revoked = ?", true).Pluck("token_hash", &revoked).Error)
+	assert.Equal(t, []string{"a"}, revoked)
+
+	err := repo.Touch(stolen.ID, "198.51.100.7", expires)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "a revoked session cannot be used")
+
+	require.NoError(t, repo.Touch(kept.ID, "198.51.100.7", expires))
+	touched, err := repo.GetByID(kept.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "198.51.100.7", touched.IPAddress)
+}
+
+func TestSessionRepository_RevokeAllForUser(t *testing.T) {
+	db := setupSessionDB(t)
+	repo := NewSessionRepository(db)
+	createTestSession(t, repo, 1, time.Now())
+	createTestSession(t, repo, 1, time.Now())
+	other := createTestSession(t, repo, 2, time.Now())
+
+	require.NoError(t, repo.RevokeAllForUser(1))
+
+	sessions, err := repo.ListActive(1)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+	sessions, err = repo.ListActive(2)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, other.ID, sessions[0].ID)
+}
+
+func TestSessionRepository_Revocations(t *testing.T) {
+	db := setupSessionDB(t)
+	repo := NewSessionRepository(db)
+
+	require.NoError(t, repo.RevokeToken(&models.RevokedToken{JTI: "live", UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}))
+	require.NoError(t, repo.RevokeToken(&models.RevokedToken{JTI: "live", UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}),
+		"revoking a token twice is not an error")
+	require.NoError(t, db.Create(&models.RevokedToken{JTI: "dead", UserID: 1, ExpiresAt: time.Now().Add(-time.Minute)}).Error)
+
+	old := createTestSession(t, repo, 1, time.Now())
+	require.NoError(t, repo.Revoke(old.ID))
+	require.NoError(t, db.Model(old).Update("revoked_at", time.Now().Add(-2*time.Hour)).Error)
+	recent := createTestSession(t, repo, 1, time.Now())
+	require.NoError(t, repo.Revoke(recent.ID))
+
+	tokenIDs, sessionIDs, err := repo.Revocations(time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"live"}, tokenIDs, "an expired token needs no revocation")
+	assert.Equal(t, []uint{recent.ID}, sessionIDs, "a session revoked before the window has no live tokens")
+
+	require.NoError(t, repo.RevokeToken(&models.RevokedToken{JTI: "other", UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}))
+	var count int64
+	require.NoError(t, db.Model(&models.RevokedToken{}).Where("jti = ?", "dead").Count(&count).Error)
+	assert.Zero(t, count, "revoking a token sweeps the expired ones")
+}
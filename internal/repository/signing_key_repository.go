@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"gorm.io/gorm"
+)
+
+type signingKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewSigningKeyRepository(db *gorm.DB) SigningKeyRepository {
+	return &signingKeyRepository{db: db}
+}
+
+func (r *signingKeyRepository) Create(key *models.SigningKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *signingKeyRepository) List() ([]models.SigningKey, error) {
+	var keys []models.SigningKey
+	err := r.db.Order("id ASC").Find(&keys).Error
+	return keys, err
+}
+
+func (r *signingKeyRepository) GetByKID(kid string) (*models.SigningKey, error) {
+	var key models.SigningKey
+	if err := r.db.Where("kid = ?", kid).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *signingKeyRepository) Activate(kid string, at time.Time) error {
+	return r.updatePublished(kid, "activated_at", at)
+}
+
+func (r *signingKeyRepository) Retire(kid string, at time.Time) error {
+	return r.updatePublished(kid, "retired_at", at)
+}
+
+func (r *signingKeyRepository) updatePublished(kid, column string, at time.Time) error {
+	result := r.db.Model(&models.SigningKey{}).
+		Where("kid = ? AND retired_at IS NULL", kid).
+		Update(column, at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
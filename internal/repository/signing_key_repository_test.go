@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestSigningKeyRepository_Lifecycle(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.SigningKey{}))
+	repo := NewSigningKeyRepository(db)
+
+	for _, kid := range []string{"first", "second"} {
+		require.NoError(t, repo.Create(&models.SigningKey{KID: kid, Algorithm: models.SigningAlgorithmRS256,
+			PublicKey: "public", PrivateKey: "sealed"}))
+	}
+	assert.Error(t, repo.Create(&models.SigningKey{KID: "first", Algorithm: models.SigningAlgorithmRS256,
+		PublicKey: "public", PrivateKey: "sealed"}), "a kid is unique")
+
+	now := time.Now()
+	require.NoError(t, repo.Activate("first", now))
+	require.NoError(t, repo.Retire("first", now))
+	assert.ErrorIs(t, repo.Activate("first", now), gorm.ErrRecordNotFound, "a retired key cannot sign again")
+	assert.ErrorIs(t, repo.Retire("first", now), gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, repo.Retire("unknown", now), gorm.ErrRecordNotFound)
+
+	keys, err := repo.List()
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "first", keys[0].KID, "oldest first")
+	assert.NotNil(t, keys[0].ActivatedAt)
+	assert.NotNil(t, keys[0].RetiredAt)
+	assert.Nil(t, keys[1].ActivatedAt)
+
+	key, err := repo.GetByKID("second")
+	require.NoError(t, err)
+	assert.Equal(t, "sealed", key.PrivateKey)
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"gorm.io/gorm"
+)
+
+type slaRepository struct {
+	db *gorm.DB
+}
+
+func NewSLARepository(db *gorm.DB) SLARepository {
+	return &slaRepository{db: db}
+}
+
+func (r *slaRepository) WithTx(tx *gorm.DB) SLARepository {
+	return &slaRepository{db: tx}
+}
+
+func (r *slaRepository) ListPolicies() ([]models.SLAPolicy, error) {
+	policies := []models.SLAPolicy{}
+	err := r.db.Order("id ASC").Find(&policies).Error
+	return policies, err
+}
+
+func (r *slaRepository) GetPolicy(priority models.TicketPriority) (*models.SLAPolicy, error) {
+	var policy models.SLAPolicy
+	if err := r.db.Where("priority = ?", priority).First(&policy).Error; err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// SavePolicy creates the priority's policy or replaces the one it has. The
+// replacement keeps the row's id and creation time.
+func (r *slaRepository) SavePolicy(policy *models.SLAPolicy) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.SLAPolicy
+		err := tx.Where("priority = ?", policy.Priority).First(&existing).Error
+		switch {
+		case err == nil:
+			policy.ID = existing.ID
+			policy.CreatedAt = existing.CreatedAt
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+		return tx.Save(policy).Error
+	})
+}
+
+// DeletePolicy removes the priority's policy. A priority without one is
+// reported as gorm.ErrRecordNotFound.
+func (r *slaRepository) DeletePolicy(priority models.TicketPriority) error {
+	result := r.db.Where("priority = ?", priority).Delete(&models.SLAPolicy{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// slaDeadline names the columns behind one SLA target.
+type slaDeadline struct {
+	target  models.SLATarget
+	due     string
+	reached string
+	flag    string
+}
+
+var slaDeadlines = []slaDeadline{
+	{models.SLATargetFirstResponse, "first_response_due_at", "first_responded_at", "first_response_breached"},
+	{models.SLATargetResolution, "resolution_due_at", "resolved_at", "resolution_breached"},
+}
+
+// FlagBreaches sets the breached flag on every deadline that has been missed
+// and is not flagged yet, and returns what it flagged. A deadline is missed
+// when it passed before the target was reached, or has passed and the target
+// is still unreached at now — so a ticket resolved late is caught as well as
+// one still open. Both targets are flagged in one transaction.
+//
+// The flags are written with UpdateColumn so a sweep does not touch
+// updated_at: flagging is bookkeeping, not an edit of the ticket.
+func (r *slaRepository) FlagBreaches(now time.Time) ([]models.SLABreach, error) {
+	breaches := []models.SLABreach{}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, deadline := range slaDeadlines {
+			missed := fmt.Sprintf("%s = ? AND %s IS NOT NULL AND %s < COALESCE(%s, ?)",
+				deadline.flag, deadline.due, deadline.due, deadline.reached)
+
+			var tickets []models.Ticket
+			if err := tx.Where(missed, false, now).Find(&tickets).Error; err != nil {
+				return err
+			}
+			if len(tickets) == 0 {
+				continue
+			}
+
+			ids := make([]uint, len(tickets))
+			for i, ticket := range tickets {
+				ids[i] = ticket.ID
+			}
+			err := tx.Model(&models.Ticket{}).Where("id IN ?", ids).
+				UpdateColumn(deadline.flag, true).Error
+			if err != nil {
+				return err
+			}
+			for _, ticket := range tickets {
+				breaches = append(breaches, models.SLABreach{Ticket: ticket, Target: deadline.target})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return breaches, nil
+}
+
+// ListForReport returns the tickets opened in [from, to) that carry at least
+// one SLA deadline, with their assignees preloaded.
+func (r *slaRepository) ListForReport(from, to time.Time) ([]models.Ticket, error) {
+	tickets := []models.Ticket{}
+	err := r.db.Preload("AssignedTo").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Where("first_response_due_at IS NOT NULL OR resolution_due_at IS NOT NULL").
+		Order("id ASC").
+		Find(&tickets).Error
+	return tickets, err
+}
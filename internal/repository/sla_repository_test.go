@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+func setupSLADB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, db.Migrator().DropTable(&models.SLAPolicy{}, &models.Ticket{}, &models.User{}))
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Ticket{}, &models.SLAPolicy{}))
+
+	t.Cleanup(func() {
+		sqlDB, err := db.DB()
+		if err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func createSLATicket(t *testing.T, db *gorm.DB, ticket *models.Ticket) *models.Ticket {
+	t.Helper()
+	if ticket.Status == "" {
+		ticket.Status = models.TicketStatusOpen
+	}
+	if ticket.Priority == "" {
+		ticket.Priority = models.TicketPriorityHigh
+	}
+	require.NoError(t, db.Omit(clause.Associations).Create(ticket).Error)
+	return ticket
+}
+
+func TestSLARepository_SavePolicyReplacesByPriority(t *testing.T) {
+	db := setupSLADB(t)
+	repo := NewSLARepository(db)
+
+	first := &models.SLAPolicy{Priority: models.TicketPriorityHigh, FirstResponseMinutes: 60, ResolutionMinutes: 480,
+		Timezone: "UTC", BusinessDayStart: "09:00", BusinessDayEnd: "17:00", BusinessDays: []int{1, 2, 3}}
+	require.NoError(t, repo.SavePolicy(first))
+
+	replacement := &models.SLAPolicy{Priority: models.TicketPriorityHigh, FirstResponseMinutes: 30, ResolutionMinutes: 240,
+		Timezone: "Europe/Bucharest", BusinessDayStart: "08:00", BusinessDayEnd: "16:00", BusinessDays: []int{1, 2, 3, 4, 5}}
+	require.NoError(t, repo.SavePolicy(replacement))
+	assert.Equal(t, first.ID, replacement.ID)
+
+	policies, err := repo.ListPolicies()
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	assert.Equal(t, 30, policies[0].FirstResponseMinutes)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, policies[0].BusinessDays)
+
+	got, err := repo.GetPolicy(models.TicketPriorityHigh)
+	require.NoError(t, err)
+	assert.Equal(t, "Europe/Bucharest", got.Timezone)
+
+	require.NoError(t, repo.DeletePolicy(models.TicketPriorityHigh))
+	assert.ErrorIs(t, repo.DeletePolicy(models.TicketPriorityHigh), gorm.ErrRecordNotFound)
+	_, err = repo.GetPolicy(models.TicketPriorityHigh)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestSLARepository_FlagBreaches(t *testing.T) {
+	db := setupSLADB(t)
+	repo := NewSLARepository(db)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	early, late := past.Add(-time.Minute), past.Add(time.Minute)
+
+	unanswered := createSLATicket(t, db, &models.Ticket{Title: "unanswered", FirstResponseDueAt: &past, ResolutionDueAt: &future})
+	answeredLate := createSLATicket(t, db, &models.Ticket{Title: "answered late", FirstResponseDueAt: &past, FirstRespondedAt: &late})
+	createSLATicket(t, db, &models.Ticket{Title: "answered in time", FirstResponseDueAt: &past, FirstRespondedAt: &early})
+	createSLATicket(t, db, &models.Ticket{Title: "already flagged", FirstResponseDueAt: &past, FirstResponseBreached: true})
+	createSLATicket(t, db, &models.Ticket{Title: "no policy"})
+
+	breaches, err := repo.FlagBreaches(now)
+	require.NoError(t, err)
+	flagged := map[uint]models.SLATarget{}
+	for _, breach := range breaches {
+		flagged[breach.Ticket.ID] = breach.Target
+	}
+	assert.Equal(t, map[uint]models.SLATarget{
+		unanswered.ID:   models.SLATargetFirstResponse,
+		answeredLate.ID: models.SLATargetFirstResponse,
+	}, flagged)
+
+	var reloaded models.Ticket
+	require.NoError(t, db.First(&reloaded, unanswered.ID).Error)
+	assert.True(t, reloaded.FirstResponseBreached)
+	assert.False(t, reloaded.ResolutionBreached)
+	assert.Equal(t, unanswered.UpdatedAt.Unix(), reloaded.UpdatedAt.Unix(), "a sweep is not an edit")
+
+	// A second sweep finds nothing new.
+	breaches, err = repo.FlagBreaches(now)
+	require.NoError(t, err)
+	assert.Empty(t, breaches)
+
+	// Once the resolution deadline passes too, only that target is flagged.
+	breaches, err = repo.FlagBreaches(future.Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, breaches, 1)
+	assert.Equal(t, unanswered.ID, breaches[0].Ticket.ID)
+	assert.Equal(t, models.SLATargetResolution, breaches[0].Target)
+}
+
+func TestSLARepository_ListForReport(t *testing.T) {
+	db := setupSLADB(t)
+	repo := NewSLARepository(db)
+
+	assignee := &models.User{Email: "agent@example.com", Password: "x", FirstName: "Agnes", Role: models.RoleSupport}
+	require.NoError(t, db.Create(assignee).Error)
+
+	due := time.Now().UTC()
+	inWindow := createSLATicket(t, db, &models.Ticket{Title: "in window", ResolutionDueAt: &due, AssignedToID: &assignee.ID})
+	createSLATicket(t, db, &models.Ticket{Title: "no deadlines"})
+	old := createSLATicket(t, db, &models.Ticket{Title: "too old", ResolutionDueAt: &due})
+	require.NoError(t, db.Model(old).UpdateColumn("created_at", due.Add(-48*time.Hour)).Error)
+
+	tickets, err := repo.ListForReport(due.Add(-24*time.Hour), due.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, tickets, 1)
+	assert.Equal(t, inWindow.ID, tickets[0].ID)
+	require.NotNil(t, tickets[0].AssignedTo)
+	assert.Equal(t, "agent@example.com", tickets[0].AssignedTo.Email)
+}
+
+func TestTicketRepository_MarkFirstResponseKeepsTheEarliest(t *testing.T) {
+	db := setupSLADB(t)
+	repo := NewTicketRepository(db)
+	ticket := createSLATicket(t, db, &models.Ticket{Title: "reply"})
+
+	first := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, repo.MarkFirstResponse(ticket.ID, first))
+	require.NoError(t, repo.MarkFirstResponse(ticket.ID, first.Add(time.Hour)))
+
+	var reloaded models.Ticket
+	require.NoError(t, db.First(&reloaded, ticket.ID).Error)
+	require.NotNil(t, reloaded.FirstRespondedAt)
+	assert.True(t, first.Equal(*reloaded.FirstRespondedAt))
+}
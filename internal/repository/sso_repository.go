@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"gorm.io/gorm"
+)
+
+type ssoRepository struct {
+	db *gorm.DB
+}
+
+func NewSSORepository(db *gorm.DB) SSORepository {
+	return &ssoRepository{db: db}
+}
+
+func (r *ssoRepository) CreateLoginState(state *models.SSOLoginState) error {
+	if err := r.db.Where("expires_at < ?", time.Now()).Delete(&models.SSOLoginState{}).Error; err != nil {
+		return err
+	}
+	return r.db.Create(state).Error
+}
+
+func (r *ssoRepository) ConsumeLoginState(sessionHash string) (*models.SSOLoginState, error) {
+	var state models.SSOLoginState
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("session_hash = ?", sessionHash).First(&state).Error; err != nil {
+			return err
+		}
+		// The delete decides between concurrent callbacks with the same
+		// session: only the one that removes the row may use it.
+		result := tx.Delete(&models.SSOLoginState{}, state.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(state.ExpiresAt) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &state, nil
+}
+
+func (r *ssoRepository) GetUserBySubject(issuer, subject string) (*models.User, error) {
+	var user models.User
+	if err := r.db.Where("sso_issuer = ? AND sso_subject = ?", issuer, subject).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *ssoRepository) LinkUser(userID uint, issuer, subject string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{"sso_issuer": issuer, "sso_subject": subject}).Error
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupSSODB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.SSOLoginState{}))
+	return db
+}
+
+func TestSSORepository_LoginStateIsConsumedOnce(t *testing.T) {
+	db := setupSSODB(t)
+	repo := NewSSORepository(db)
+
+	require.NoError(t, repo.CreateLoginState(&models.SSOLoginState{SessionHash: "h1", State: "s", Nonce: "n",
+		CodeVerifier: "v", ExpiresAt: time.Now().Add(time.Minute)}))
+
+	state, err := repo.ConsumeLoginState("h1")
+	require.NoError(t, err)
+	assert.Equal(t, "v", state.CodeVerifier)
+
+	_, err = repo.ConsumeLoginState("h1")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestSSORepository_ExpiredLoginStates(t *testing.T) {
+	db := setupSSODB(t)
+	repo := NewSSORepository(db)
+
+	require.NoError(t, repo.CreateLoginState(&models.SSOLoginState{SessionHash: "old", State: "s", Nonce: "n",
+		CodeVerifier: "v", ExpiresAt: time.Now().Add(-time.Minute)}))
+	_, err := repo.ConsumeLoginState("old")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "an expired attempt cannot complete")
+
+	require.NoError(t, repo.CreateLoginState(&models.SSOLoginState{SessionHash: "stale", State: "s", Nonce: "n",
+		CodeVerifier: "v", ExpiresAt: time.Now().Add(-time.Minute)}))
+	require.NoError(t, repo.CreateLoginState(&models.SSOLoginState{SessionHash: "new", State: "s", Nonce: "n",
+		CodeVerifier: "v", ExpiresAt: time.Now().Add(time.Minute)}))
+	var count int64
+	require.NoError(t, db.Model(&models.SSOLoginState{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count, "creating an attempt sweeps the expired ones")
+}
+
+func TestSSORepository_LinkAndFindUser(t *testing.T) {
+	db := setupSSODB(t)
+	repo := NewSSORepository(db)
+	user := &models.User{Email: "sso@example.com", Password: "hash", FirstName: "S", LastName: "O",
+		Role: models.RoleSales, IsActive: true}
+	require.NoError(t, db.Create(user).Error)
+
+	_, err := repo.GetUserBySubject("https://idp", "sub-1")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	require.NoError(t, repo.LinkUser(user.ID, "https://idp", "sub-1"))
+	found, err := repo.GetUserBySubject("https://idp", "sub-1")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+
+	_, err = repo.GetUserBySubject("https://other-idp", "sub-1")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "subjects are scoped to their issuer")
+}
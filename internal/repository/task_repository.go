@@ -164,6 +164,68 @@ func (r *taskRepository) CountSearch(query string) (int64, error) {
 	return count, err
 }
 
+func (r *taskRepository) ListFiltered(filter models.TaskFilter, offset, limit int, preloads ...string) ([]models.Task, int64, error) {
+	query, err := r.filteredQuery(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	for _, preload := range preloads {
+		query = query.Preload(preload)
+	}
+	if filter.Cursor != nil {
+		query, err = seekCursor(query, "tasks", filter.Cursor)
+		if err != nil {
+			return nil, 0, err
+		}
+	} else if filter.CustomFields.Sort != nil {
+		query = orderByCustomField(query, "tasks", filter.CustomFields.Sort)
+	} else if filter.SortBy != "" {
+		orderClause, err := utils.SafeOrderClause("tasks", filter.SortBy, filter.SortOrder)
+		if err != nil {
+			return nil, 0, err
+		}
+		if orderClause != "" {
+			query = query.Order(orderClause)
+		}
+	}
+	tasks := []models.Task{}
+	err = query.Offset(offset).Limit(limit).Find(&tasks).Error
+	return tasks, total, err
+}
+
+// StreamFiltered hands every task matching filter to fn, a batch at a time, in
+// the order of its sort with the id breaking ties.
+func (r *taskRepository) StreamFiltered(filter models.TaskFilter, fn func([]models.Task) error) error {
+	return streamFiltered(func() (*gorm.DB, error) { return r.filteredQuery(filter) }, "tasks", filter.SortBy, filter.SortOrder, fn)
+}
+
+// filteredQuery narrows the tasks to those matching every condition of
+// filter, for ListFiltered and StreamFiltered.
+func (r *taskRepository) filteredQuery(filter models.TaskFilter) (*gorm.DB, error) {
+	query := r.db.Model(&models.Task{})
+	if filter.Search != "" {
+		searchPattern := "%" + filter.Search + "%"
+		query = query.Where(
+			"title LIKE ? OR description LIKE ?",
+			searchPattern, searchPattern,
+		)
+	}
+	if filter.AssignedToID != 0 {
+		query = query.Where("assigned_to_id = ?", filter.AssignedToID)
+	}
+	if filter.LabelID != 0 {
+		query = query.Where("id IN (SELECT task_id FROM task_labels WHERE label_id = ?)", filter.LabelID)
+	}
+	query = whereCustomFields(query, "tasks", filter.CustomFields.Filters)
+	return whereFilter(query, "tasks", filter.Where)
+}
+
 // CountByStatus returns the number of live tasks per status. Statuses with no
 // rows are absent from the map; the caller supplies the full label set.
 func (r *taskRepository) CountByStatus() (map[string]int64, error) {
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"gorm.io/gorm"
+)
+
+type ticketCommentRepository struct {
+	db *gorm.DB
+}
+
+func NewTicketCommentRepository(db *gorm.DB) TicketCommentRepository {
+	return &ticketCommentRepository{db: db}
+}
+
+func (r *ticketCommentRepository) WithTx(tx *gorm.DB) TicketCommentRepository {
+	return &ticketCommentRepository{db: tx}
+}
+
+func (r *ticketCommentRepository) Create(comment *models.TicketComment) error {
+	return r.db.Create(comment).Error
+}
+
+// ListByTicket orders by id rather than created_at: two entries written in the
+// same second — a status change and the comment explaining it — must still come
+// back in the order they were written.
+func (r *ticketCommentRepository) ListByTicket(ticketID uint, includeInternal bool, offset, limit int) ([]models.TicketComment, int64, error) {
+	query := r.db.Model(&models.TicketComment{}).Where("ticket_id = ?", ticketID)
+	if !includeInternal {
+		query = query.Where("visibility = ?", models.TicketCommentPublic)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	comments := []models.TicketComment{}
+	err := query.Preload("Author").Order("id ASC").Offset(offset).Limit(limit).Find(&comments).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return comments, total, nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupTicketCommentDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, db.Migrator().DropTable(&models.TicketComment{}, &models.User{}))
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.TicketComment{}))
+
+	t.Cleanup(func() {
+		sqlDB, err := db.DB()
+		if err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestTicketCommentRepository_ListByTicketIsOldestFirstAndHonoursVisibility(t *testing.T) {
+	db := setupTicketCommentDB(t)
+	repo := NewTicketCommentRepository(db)
+
+	author := &models.User{Email: "agent@example.com", Password: "x", FirstName: "Agnes", Role: models.RoleSupport}
+	require.NoError(t, db.Create(author).Error)
+
+	entries := []*models.TicketComment{
+		{TicketID: 1, Kind: models.TicketCommentKindComment, Visibility: models.TicketCommentPublic, Body: "first", AuthorID: &author.ID},
+		{TicketID: 1, Kind: models.TicketCommentKindComment, Visibility: models.TicketCommentInternal, Body: "note", AuthorID: &author.ID},
+		{TicketID: 2, Kind: models.TicketCommentKindComment, Visibility: models.TicketCommentPublic, Body: "other ticket"},
+		{TicketID: 1, Kind: models.TicketCommentKindStatusChange, Visibility: models.TicketCommentPublic,
+			FromStatus: models.TicketStatusOpen, ToStatus: models.TicketStatusResolved},
+	}
+	for _, entry := range entries {
+		require.NoError(t, repo.Create(entry))
+	}
+
+	all, total, err := repo.ListByTicket(1, true, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, all, 3)
+	assert.Equal(t, []string{"first", "note", ""}, []string{all[0].Body, all[1].Body, all[2].Body})
+	require.NotNil(t, all[0].Author)
+	assert.Equal(t, "agent@example.com", all[0].Author.Email)
+	assert.Nil(t, all[2].Author)
+
+	public, total, err := repo.ListByTicket(1, false, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, public, 2)
+	assert.Equal(t, models.TicketCommentKindStatusChange, public[1].Kind)
+
+	page, total, err := repo.ListByTicket(1, true, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, page, 1)
+	assert.Equal(t, "note", page[0].Body)
+}
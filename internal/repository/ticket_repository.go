@@ -1,11 +1,16 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/florinel-chis/gophercrm/internal/models"
 	"github.com/florinel-chis/gophercrm/internal/utils"
 	"gorm.io/gorm"
 )
 
+// replyTokenBatch is how many tickets BackfillReplyTokens reads at a time.
+const replyTokenBatch = 500
+
 type ticketRepository struct {
 	db *gorm.DB
 }
@@ -173,6 +178,62 @@ func (r *ticketRepository) CountSearch(query string) (int64, error) {
 	return count, err
 }
 
+func (r *ticketRepository) ListFiltered(filter models.TicketFilter, offset, limit int, preloads ...string) ([]models.Ticket, int64, error) {
+	query, err := r.filteredQuery(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	for _, preload := range preloads {
+		query = query.Preload(preload)
+	}
+	if filter.Cursor != nil {
+		query, err = seekCursor(query, "tickets", filter.Cursor)
+		if err != nil {
+			return nil, 0, err
+		}
+	} else if filter.CustomFields.Sort != nil {
+		query = orderByCustomField(query, "tickets", filter.CustomFields.Sort)
+	} else if filter.SortBy != "" {
+		orderClause, err := utils.SafeOrderClause("tickets", filter.SortBy, filter.SortOrder)
+		if err != nil {
+			return nil, 0, err
+		}
+		if orderClause != "" {
+			query = query.Order(orderClause)
+		}
+	}
+	tickets := []models.Ticket{}
+	err = query.Offset(offset).Limit(limit).Find(&tickets).Error
+	return tickets, total, err
+}
+
+// StreamFiltered hands every ticket matching filter to fn, a batch at a time, in
+// the order of its sort with the id breaking ties.
+func (r *ticketRepository) StreamFiltered(filter models.TicketFilter, fn func([]models.Ticket) error) error {
+	return streamFiltered(func() (*gorm.DB, error) { return r.filteredQuery(filter) }, "tickets", filter.SortBy, filter.SortOrder, fn)
+}
+
+// filteredQuery narrows the tickets to those matching every condition of
+// filter, for ListFiltered and StreamFiltered.
+func (r *ticketRepository) filteredQuery(filter models.TicketFilter) (*gorm.DB, error) {
+	query := r.db.Model(&models.Ticket{})
+	if filter.Search != "" {
+		searchPattern := "%" + filter.Search + "%"
+		query = query.Where(
+			"title LIKE ? OR description LIKE ? OR resolution LIKE ?",
+			searchPattern, searchPattern, searchPattern,
+		)
+	}
+	query = whereCustomFields(query, "tickets", filter.CustomFields.Filters)
+	return whereFilter(query, "tickets", filter.Where)
+}
+
 // CountByPriority returns the number of live tickets per priority. Priorities
 // with no rows are absent from the map; the caller supplies the full label set.
 func (r *ticketRepository) CountByPriority() (map[string]int64, error) {
@@ -190,21 +251,61 @@ func (r *ticketRepository) ListRecent(limit int) ([]models.Ticket, error) {
 }
 
 // ListRecentlyResolved returns tickets that have left the open states, most
-// recently touched first.
+// recently resolved first.
 //
 // Both "resolved" and "closed" count as resolutions, mirroring CountOpen, which
-// treats open and in_progress as the open half. There is no resolved_at column,
-// so updated_at stands in for the moment of resolution.
+// treats open and in_progress as the open half. Tickets resolved before
+// resolved_at was recorded have none, so updated_at stands in for them.
 func (r *ticketRepository) ListRecentlyResolved(limit int) ([]models.Ticket, error) {
 	tickets := []models.Ticket{}
 	err := r.db.Preload("Customer").Preload("AssignedTo").
 		Where("status IN ?", []string{string(models.TicketStatusResolved), string(models.TicketStatusClosed)}).
-		Order("updated_at DESC, id DESC").
+		Order("COALESCE(resolved_at, updated_at) DESC, id DESC").
 		Limit(limit).
 		Find(&tickets).Error
 	return tickets, err
 }
 
+// MarkFirstResponse only ever fills an empty first_responded_at, so two replies
+// racing each other cannot move the first response later.
+func (r *ticketRepository) MarkFirstResponse(id uint, at time.Time) error {
+	return r.db.Model(&models.Ticket{}).
+		Where("id = ? AND first_responded_at IS NULL", id).
+		UpdateColumn("first_responded_at", at).Error
+}
+
+// BackfillReplyTokens walks the tickets without a reply token in id order,
+// deleted ones included since a revert can bring them back.
+func (r *ticketRepository) BackfillReplyTokens() (int64, error) {
+	var filled int64
+	var lastID uint
+	for {
+		var ids []uint
+		err := r.db.Unscoped().Model(&models.Ticket{}).
+			Where("(reply_token = '' OR reply_token IS NULL) AND id > ?", lastID).
+			Order("id ASC").Limit(replyTokenBatch).Pluck("id", &ids).Error
+		if err != nil {
+			return filled, err
+		}
+		for _, id := range ids {
+			token, err := models.NewReplyToken()
+			if err != nil {
+				return filled, err
+			}
+			err = r.db.Unscoped().Model(&models.Ticket{}).Where("id = ?", id).
+				UpdateColumn("reply_token", token).Error
+			if err != nil {
+				return filled, err
+			}
+			filled++
+			lastID = id
+		}
+		if len(ids) < replyTokenBatch {
+			return filled, nil
+		}
+	}
+}
+
 func (r *ticketRepository) WithTx(tx *gorm.DB) TicketRepository {
 	return &ticketRepository{db: tx}
 }
\ No newline at end of file
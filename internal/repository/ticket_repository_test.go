@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupTicketDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Ticket{}))
+
+	t.Cleanup(func() {
+		sqlDB, err := db.DB()
+		if err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestTicketRepository_CreateGivesEachTicketItsReplyToken(t *testing.T) {
+	db := setupTicketDB(t)
+	repo := NewTicketRepository(db)
+
+	first := &models.Ticket{Title: "Printer", Description: "On fire", CustomerID: 1}
+	second := &models.Ticket{Title: "Scanner", Description: "Jammed", CustomerID: 1}
+	require.NoError(t, repo.Create(first))
+	require.NoError(t, repo.Create(second))
+
+	assert.Len(t, first.ReplyToken, 32)
+	assert.NotEqual(t, first.ReplyToken, second.ReplyToken)
+}
+
+func TestTicketRepository_BackfillReplyTokens(t *testing.T) {
+	db := setupTicketDB(t)
+	repo := NewTicketRepository(db)
+
+	open := &models.Ticket{Title: "Printer", Description: "On fire", CustomerID: 1}
+	deleted := &models.Ticket{Title: "Scanner", Description: "Jammed", CustomerID: 1}
+	require.NoError(t, repo.Create(open))
+	require.NoError(t, repo.Create(deleted))
+	require.NoError(t, repo.Delete(deleted.ID))
+	require.NoError(t, db.Session(&gorm.Session{AllowGlobalUpdate: true}).Unscoped().
+		Model(&models.Ticket{}).UpdateColumn("reply_token", "").Error)
+
+	filled, err := repo.BackfillReplyTokens()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), filled, "deleted tickets too, as a revert can bring them back")
+
+	var tickets []models.Ticket
+	require.NoError(t, db.Unscoped().Order("id").Find(&tickets).Error)
+	require.Len(t, tickets, 2)
+	assert.Len(t, tickets[0].ReplyToken, 32)
+	assert.Len(t, tickets[1].ReplyToken, 32)
+	assert.NotEqual(t, tickets[0].ReplyToken, tickets[1].ReplyToken)
+
+	filled, err = repo.BackfillReplyTokens()
+	require.NoError(t, err)
+	assert.Zero(t, filled)
+}
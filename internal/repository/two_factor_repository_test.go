@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupTwoFactorDB(t *testing.T) (*gorm.DB, *models.User) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.TwoFactorRecoveryCode{}))
+
+	user := &models.User{Email: "2fa@example.com", Password: "hash", FirstName: "Two", LastName: "Factor",
+		Role: models.RoleAdmin, IsActive: true}
+	require.NoError(t, db.Create(user).Error)
+	return db, user
+}
+
+func TestTwoFactorRepository_EnableAndDisable(t *testing.T) {
+	db, user := setupTwoFactorDB(t)
+	repo := NewTwoFactorRepository(db)
+
+	require.NoError(t, repo.SavePendingSecret(user.ID, "sealed-1"))
+	require.NoError(t, repo.Enable(user.ID, 100, []string{"h1", "h2", "h3"}))
+
+	var stored models.User
+	require.NoError(t, db.First(&stored, user.ID).Error)
+	assert.True(t, stored.TwoFactorEnabled)
+	assert.Equal(t, "sealed-1", stored.TOTPSecret)
+	assert.Equal(t, int64(100), stored.TOTPLastStep)
+
+	count, err := repo.CountRecoveryCodes(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	// An enabled secret is not replaced by a new setup.
+	require.NoError(t, repo.SavePendingSecret(user.ID, "sealed-2"))
+	require.NoError(t, db.First(&stored, user.ID).Error)
+	assert.Equal(t, "sealed-1", stored.TOTPSecret)
+
+	require.NoError(t, repo.Disable(user.ID))
+	require.NoError(t, db.First(&stored, user.ID).Error)
+	assert.False(t, stored.TwoFactorEnabled)
+	assert.Empty(t, stored.TOTPSecret)
+	count, err = repo.CountRecoveryCodes(user.ID)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestTwoFactorRepository_ClaimStepOnlyMovesForward(t *testing.T) {
+	db, user := setupTwoFactorDB(t)
+	repo := NewTwoFactorRepository(db)
+
+	claimed, err := repo.ClaimStep(user.ID, 10)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	claimed, err = repo.ClaimStep(user.ID, 10)
+	require.NoError(t, err)
+	assert.False(t, claimed, "a step is claimed once")
+
+	claimed, err = repo.ClaimStep(user.ID, 9)
+	require.NoError(t, err)
+	assert.False(t, claimed, "an older step cannot be claimed after a newer one")
+}
+
+func TestTwoFactorRepository_RecoveryCodesAreSingleUse(t *testing.T) {
+	db, user := setupTwoFactorDB(t)
+	repo := NewTwoFactorRepository(db)
+	require.NoError(t, repo.Enable(user.ID, 1, []string{"h1", "h2"}))
+
+	consumed, err := repo.ConsumeRecoveryCode(user.ID, "h1")
+	require.NoError(t, err)
+	assert.True(t, consumed)
+
+	consumed, err = repo.ConsumeRecoveryCode(user.ID, "h1")
+	require.NoError(t, err)
+	assert.False(t, consumed)
+
+	consumed, err = repo.ConsumeRecoveryCode(user.ID+1, "h2")
+	require.NoError(t, err)
+	assert.False(t, consumed, "another user's code is not accepted")
+
+	count, err := repo.CountRecoveryCodes(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	require.NoError(t, repo.ReplaceRecoveryCodes(user.ID, []string{"h3", "h4", "h5"}))
+	consumed, err = repo.ConsumeRecoveryCode(user.ID, "h2")
+	require.NoError(t, err)
+	assert.False(t, consumed, "replaced codes stop working")
+	count, err = repo.CountRecoveryCodes(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+}
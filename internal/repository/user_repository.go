@@ -108,7 +108,9 @@ func (r *userRepository) Delete(id uint) error {
 		EmailColumn: "email",
 		// The NOT NULL name columns are blanked rather than nulled. The login
 		// bookkeeping goes too: a last-login timestamp and a lock-out window are
-		// behavioural data about the person, not business records.
+		// behavioural data about the person, not business records. So does the
+		// TOTP secret, a credential like the password, and the link to the
+		// person's account at the single sign-on provider.
 		Scrub: map[string]interface{}{
 			"password":              unusablePasswordHash,
 			"first_name":            "",
@@ -117,8 +119,14 @@ func (r *userRepository) Delete(id uint) error {
 			"last_login_at":         nil,
 			"failed_login_attempts": 0,
 			"locked_until":          nil,
+			"two_factor_enabled":    false,
+			"totp_secret":           "",
+			"totp_last_step":        0,
+			"sso_issuer":            "",
+			"sso_subject":           "",
 		},
-		AfterScrub: purgeCredentials,
+		AfterScrub:  purgeCredentials,
+		AuditEntity: models.AuditEntityUser,
 	})
 }
 
@@ -186,6 +194,56 @@ func (r *userRepository) CountSearch(query string) (int64, error) {
 	return count, err
 }
 
+func (r *userRepository) ListFiltered(filter models.UserFilter, offset, limit int) ([]models.User, int64, error) {
+	query, err := r.filteredQuery(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Cursor != nil {
+		query, err = seekCursor(query, "users", filter.Cursor)
+		if err != nil {
+			return nil, 0, err
+		}
+	} else if filter.SortBy != "" {
+		orderClause, err := utils.SafeOrderClause("users", filter.SortBy, filter.SortOrder)
+		if err != nil {
+			return nil, 0, err
+		}
+		if orderClause != "" {
+			query = query.Order(orderClause)
+		}
+	}
+	users := []models.User{}
+	err = query.Offset(offset).Limit(limit).Find(&users).Error
+	return users, total, err
+}
+
+// StreamFiltered hands every user matching filter to fn, a batch at a time, in
+// the order of its sort with the id breaking ties.
+func (r *userRepository) StreamFiltered(filter models.UserFilter, fn func([]models.User) error) error {
+	return streamFiltered(func() (*gorm.DB, error) { return r.filteredQuery(filter) }, "users", filter.SortBy, filter.SortOrder, fn)
+}
+
+// filteredQuery narrows the users to those matching every condition of
+// filter, for ListFiltered and StreamFiltered.
+func (r *userRepository) filteredQuery(filter models.UserFilter) (*gorm.DB, error) {
+	query := r.db.Model(&models.User{})
+	if filter.Search != "" {
+		searchPattern := "%" + filter.Search + "%"
+		query = query.Where(
+			"email LIKE ? OR first_name LIKE ? OR last_name LIKE ?",
+			searchPattern, searchPattern, searchPattern,
+		)
+	}
+	return whereFilter(query, "users", filter.Where)
+}
+
 func (r *userRepository) WithTx(tx *gorm.DB) UserRepository {
 	return &userRepository{db: tx}
 }
\ No newline at end of file
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"gorm.io/gorm"
+)
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) WithTx(tx *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: tx}
+}
+
+func (r *webhookRepository) CreateSubscription(sub *models.WebhookSubscription) error {
+	return r.db.Create(sub).Error
+}
+
+func (r *webhookRepository) GetSubscription(id uint) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	if err := r.db.First(&sub, id).Error; err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r *webhookRepository) UpdateSubscription(sub *models.WebhookSubscription) error {
+	return r.db.Save(sub).Error
+}
+
+func (r *webhookRepository) DeleteSubscription(id uint) error {
+	return runInTransaction(r.db, func(tx *gorm.DB) error {
+		if err := tx.Where("subscription_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.WebhookSubscription{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+func (r *webhookRepository) ListSubscriptions(offset, limit int) ([]models.WebhookSubscription, int64, error) {
+	var total int64
+	if err := r.db.Model(&models.WebhookSubscription{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	subs := []models.WebhookSubscription{}
+	err := r.db.Order("id ASC").Offset(offset).Limit(limit).Find(&subs).Error
+	return subs, total, err
+}
+
+func (r *webhookRepository) ActiveSubscriptions() ([]models.WebhookSubscription, error) {
+	subs := []models.WebhookSubscription{}
+	err := r.db.Where("active = ?", true).Order("id ASC").Find(&subs).Error
+	return subs, err
+}
+
+func (r *webhookRepository) CreateDeliveries(deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.Create(&deliveries).Error
+}
+
+func (r *webhookRepository) GetDelivery(subscriptionID, id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.db.Where("subscription_id = ?", subscriptionID).First(&delivery, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *webhookRepository) UpdateDelivery(delivery *models.WebhookDelivery) error {
+	return r.db.Save(delivery).Error
+}
+
+func (r *webhookRepository) ListDeliveries(subscriptionID uint, status models.WebhookDeliveryStatus, offset, limit int) ([]models.WebhookDelivery, int64, error) {
+	query := r.db.Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	deliveries := []models.WebhookDelivery{}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+func (r *webhookRepository) ClaimDue(now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error) {
+	active := r.db.Model(&models.WebhookSubscription{}).Select("id").Where("active = ?", true)
+
+	var candidates []uint
+	err := r.db.Model(&models.WebhookDelivery{}).
+		Where("status = ? AND next_attempt_at <= ? AND subscription_id IN (?)", models.WebhookDeliveryPending, now, active).
+		Order("next_attempt_at ASC, id ASC").
+		Limit(limit).
+		Pluck("id", &candidates).Error
+	if err != nil || len(candidates) == 0 {
+		return nil, err
+	}
+
+	// Each claim is conditional on the row still being due, so of two
+	// workers racing for a delivery exactly one sees a row affected.
+	claimed := make([]uint, 0, len(candidates))
+	for _, id := range candidates {
+		result := r.db.Model(&models.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at <= ?", id, models.WebhookDeliveryPending, now).
+			Update("next_attempt_at", leaseUntil)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			claimed = append(claimed, id)
+		}
+	}
+	if len(claimed) == 0 {
+		return nil, nil
+	}
+
+	deliveries := []models.WebhookDelivery{}
+	err = r.db.Where("id IN ?", claimed).Order("id ASC").Find(&deliveries).Error
+	return deliveries, err
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupWebhookDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, db.Migrator().DropTable(&models.WebhookDelivery{}, &models.WebhookSubscription{}))
+	require.NoError(t, db.AutoMigrate(&models.WebhookSubscription{}, &models.WebhookDelivery{}))
+
+	t.Cleanup(func() {
+		sqlDB, err := db.DB()
+		if err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func createWebhookSubscription(t *testing.T, repo WebhookRepository, active bool) *models.WebhookSubscription {
+	t.Helper()
+	sub := &models.WebhookSubscription{
+		Name:         "Billing",
+		URL:          "https://billing.example.com/hooks",
+		Active:       active,
+		Events:       []models.WebhookEvent{models.WebhookLeadCreated, models.WebhookTicketResolved},
+		SecretSealed: "enc:v1:sealed",
+	}
+	require.NoError(t, repo.CreateSubscription(sub))
+	return sub
+}
+
+func queueWebhookDelivery(t *testing.T, repo WebhookRepository, subscriptionID uint, status models.WebhookDeliveryStatus, due time.Time) models.WebhookDelivery {
+	t.Helper()
+	deliveries := []models.WebhookDelivery{{
+		SubscriptionID: subscriptionID,
+		EventID:        "evt",
+		Event:          models.WebhookLeadCreated,
+		EntityType:     models.AuditEntityLead,
+		EntityID:       1,
+		Payload:        `{}`,
+		Status:         status,
+		NextAttemptAt:  due,
+	}}
+	require.NoError(t, repo.CreateDeliveries(deliveries))
+	return deliveries[0]
+}
+
+func TestWebhookRepository_SubscriptionEventsRoundTrip(t *testing.T) {
+	db := setupWebhookDB(t)
+	repo := NewWebhookRepository(db)
+
+	sub := createWebhookSubscription(t, repo, true)
+	createWebhookSubscription(t, repo, false)
+
+	stored, err := repo.GetSubscription(sub.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.WebhookEvent{models.WebhookLeadCreated, models.WebhookTicketResolved}, stored.Events)
+	assert.True(t, stored.Wants(models.WebhookTicketResolved))
+	assert.False(t, stored.Wants(models.WebhookLeadDeleted))
+
+	active, err := repo.ActiveSubscriptions()
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, sub.ID, active[0].ID)
+
+	all, total, err := repo.ListSubscriptions(0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, all, 2)
+}
+
+func TestWebhookRepository_ClaimDueLeasesDueDeliveriesOnce(t *testing.T) {
+	db := setupWebhookDB(t)
+	repo := NewWebhookRepository(db)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	sub := createWebhookSubscription(t, repo, true)
+	paused := createWebhookSubscription(t, repo, false)
+	due := queueWebhookDelivery(t, repo, sub.ID, models.WebhookDeliveryPending, now.Add(-time.Minute))
+	queueWebhookDelivery(t, repo, sub.ID, models.WebhookDeliveryPending, now.Add(time.Hour))
+	queueWebhookDelivery(t, repo, sub.ID, models.WebhookDeliveryFailed, now.Add(-time.Hour))
+	queueWebhookDelivery(t, repo, paused.ID, models.WebhookDeliveryPending, now.Add(-time.Hour))
+
+	claimed, err := repo.ClaimDue(now, now.Add(5*time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1, "only the due, pending delivery of an active subscription is claimed")
+	assert.Equal(t, due.ID, claimed[0].ID)
+	assert.WithinDuration(t, now.Add(5*time.Minute), claimed[0].NextAttemptAt, time.Second)
+
+	again, err := repo.ClaimDue(now, now.Add(5*time.Minute), 10)
+	require.NoError(t, err)
+	assert.Empty(t, again, "a leased delivery is not claimed twice")
+
+	expired, err := repo.ClaimDue(now.Add(10*time.Minute), now.Add(15*time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	assert.Equal(t, due.ID, expired[0].ID, "an expired lease makes the delivery due again")
+}
+
+func TestWebhookRepository_DeliveriesAndDelete(t *testing.T) {
+	db := setupWebhookDB(t)
+	repo := NewWebhookRepository(db)
+	now := time.Now().UTC()
+
+	sub := createWebhookSubscription(t, repo, true)
+	other := createWebhookSubscription(t, repo, true)
+	first := queueWebhookDelivery(t, repo, sub.ID, models.WebhookDeliveryFailed, now)
+	second := queueWebhookDelivery(t, repo, sub.ID, models.WebhookDeliveryDelivered, now)
+	queueWebhookDelivery(t, repo, other.ID, models.WebhookDeliveryFailed, now)
+
+	failed, total, err := repo.ListDeliveries(sub.ID, models.WebhookDeliveryFailed, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, failed, 1)
+	assert.Equal(t, first.ID, failed[0].ID)
+
+	all, _, err := repo.ListDeliveries(sub.ID, "", 0, 10)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, second.ID, all[0].ID, "newest first")
+
+	_, err = repo.GetDelivery(other.ID, first.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "a delivery is only found under its own subscription")
+
+	require.NoError(t, repo.DeleteSubscription(sub.ID))
+	assert.ErrorIs(t, repo.DeleteSubscription(sub.ID), gorm.ErrRecordNotFound)
+
+	var remaining int64
+	require.NoError(t, db.Model(&models.WebhookDelivery{}).Count(&remaining).Error)
+	assert.Equal(t, int64(1), remaining, "the deliveries go with their subscription")
+}
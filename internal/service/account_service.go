@@ -0,0 +1,411 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+)
+
+// AccountService manages the organizations leads and customers belong to,
+// and the views that roll up everything attached to one.
+type AccountService interface {
+	// Create adds an account. A name that matches another account's once
+	// reduced by models.AccountNameKey is refused with
+	// apperrors.ErrDuplicateAccount; any other invalid field with
+	// apperrors.ErrValidation.
+	Create(account *models.Account) error
+	GetByID(id uint) (*models.Account, error)
+	Update(account *models.Account) (*models.Account, error)
+	// Delete unlinks the account's leads and customers, which keep their
+	// company text, and deletes the account.
+	Delete(id uint) error
+	List(filter models.AccountFilter, offset, limit int) ([]models.Account, int64, error)
+
+	// Summary counts the account's contacts, tickets, tasks and deals, and
+	// totals its open pipeline, within the caller's scope.
+	Summary(id uint, scope models.AccountScope) (*models.AccountSummary, error)
+	// The rollups list one kind of record of the account, newest first. A
+	// non-zero ownerID keeps only the leads or deals that user owns, and a
+	// non-zero assigneeID only the tasks assigned to them.
+	ListLeads(id, ownerID uint, offset, limit int) ([]models.Lead, int64, error)
+	ListCustomers(id uint, offset, limit int) ([]models.Customer, int64, error)
+	ListTickets(id uint, offset, limit int) ([]models.Ticket, int64, error)
+	ListTasks(id, assigneeID uint, offset, limit int) ([]models.Task, int64, error)
+	ListDeals(id, ownerID uint, offset, limit int) ([]models.Deal, int64, error)
+}
+
+type accountService struct {
+	repo     repository.AccountRepository
+	userRepo repository.UserRepository
+	activityFeed
+}
+
+func NewAccountService(repo repository.AccountRepository, userRepo repository.UserRepository, opts ...ActivityOption) AccountService {
+	s := &accountService{repo: repo, userRepo: userRepo}
+	s.applyActivityOptions(opts)
+	return s
+}
+
+func (s *accountService) Create(account *models.Account) error {
+	logger := utils.LogServiceCall(utils.Logger.WithField("account_name", account.Name), "AccountService", "Create")
+
+	if err := s.validateAccount(account); err != nil {
+		logger.WithError(err).Warn("Invalid account")
+		return err
+	}
+	if err := s.repo.Create(account); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+
+	logger.WithField("account_id", account.ID).Info("Account created")
+	s.recordActivity(models.AuditEntityAccount, account.ID, models.ActivityAccountCreated, account.OwnerID,
+		"New account", account.Name+" was added as an account")
+	return nil
+}
+
+func (s *accountService) GetByID(id uint) (*models.Account, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("account_id", id), "AccountService", "GetByID")
+
+	account, err := s.repo.GetByID(id)
+	if err != nil {
+		if isNotFound(err) {
+			logger.Warn("Account not found")
+			return nil, fmt.Errorf("account %d: %w", id, apperrors.ErrNotFound)
+		}
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	return account, nil
+}
+
+func (s *accountService) Update(account *models.Account) (*models.Account, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("account_id", account.ID), "AccountService", "Update")
+
+	existing, err := s.GetByID(account.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateAccount(account); err != nil {
+		logger.WithError(err).Warn("Invalid account")
+		return nil, err
+	}
+	account.CreatedAt = existing.CreatedAt
+
+	if err := s.repo.Update(account); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+
+	logger.Info("Account updated")
+	if !sameOwner(account.OwnerID, existing.OwnerID) {
+		s.recordActivity(models.AuditEntityAccount, account.ID, models.ActivityAccountReassigned, account.OwnerID,
+			"Account reassigned", account.Name+" was reassigned")
+	} else {
+		s.recordActivity(models.AuditEntityAccount, account.ID, models.ActivityAccountUpdated, account.OwnerID,
+			"Account updated", account.Name+" was updated")
+	}
+	return s.GetByID(account.ID)
+}
+
+// validateAccount checks and normalizes an account's fields, derives its name
+// key and refuses a name another account already answers to.
+func (s *accountService) validateAccount(account *models.Account) error {
+	account.Name = strings.Join(strings.Fields(account.Name), " ")
+	if account.Name == "" {
+		return fmt.Errorf("account name is required: %w", apperrors.ErrValidation)
+	}
+	if len(account.Name) > 200 {
+		return fmt.Errorf("account name cannot be longer than 200 characters: %w", apperrors.ErrValidation)
+	}
+	account.NameKey = models.AccountNameKey(account.Name)
+	if account.NameKey == "" {
+		return fmt.Errorf("account name must contain a letter or digit: %w", apperrors.ErrValidation)
+	}
+
+	domain, err := normalizeDomain(account.Domain)
+	if err != nil {
+		return err
+	}
+	account.Domain = domain
+	account.Industry = strings.TrimSpace(account.Industry)
+	if !account.Size.IsValid() {
+		return fmt.Errorf("account size %q is not one of %v: %w", account.Size, models.AccountSizes, apperrors.ErrValidation)
+	}
+
+	if account.OwnerID != nil {
+		owner, err := s.userRepo.GetByID(*account.OwnerID)
+		if err != nil {
+			if isNotFound(err) {
+				return fmt.Errorf("owner %d does not exist: %w", *account.OwnerID, apperrors.ErrValidation)
+			}
+			return err
+		}
+		if !owner.IsActive {
+			return fmt.Errorf("owner %d is inactive: %w", *account.OwnerID, apperrors.ErrValidation)
+		}
+	}
+
+	existing, err := s.repo.GetByNameKey(account.NameKey)
+	switch {
+	case err == nil && existing.ID != account.ID:
+		return fmt.Errorf("account %d is already named %q: %w", existing.ID, existing.Name, apperrors.ErrDuplicateAccount)
+	case err != nil && !isNotFound(err):
+		return err
+	}
+	return nil
+}
+
+// normalizeDomain reduces what people paste as a company's domain — a URL, a
+// "www." host, mixed case — to the bare lower-case host name.
+func normalizeDomain(domain string) (string, error) {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if domain == "" {
+		return "", nil
+	}
+	if i := strings.Index(domain, "://"); i >= 0 {
+		domain = domain[i+3:]
+	}
+	if i := strings.IndexAny(domain, "/?#"); i >= 0 {
+		domain = domain[:i]
+	}
+	if i := strings.LastIndex(domain, ":"); i >= 0 {
+		domain = domain[:i]
+	}
+	domain = strings.TrimSuffix(strings.TrimPrefix(domain, "www."), ".")
+
+	valid := strings.Contains(domain, ".") && !strings.HasPrefix(domain, ".") && len(domain) <= 255
+	for _, r := range domain {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '.') {
+			valid = false
+		}
+	}
+	if !valid {
+		return "", fmt.Errorf("%q is not a domain name: %w", domain, apperrors.ErrValidation)
+	}
+	return domain, nil
+}
+
+func (s *accountService) Delete(id uint) error {
+	logger := utils.LogServiceCall(utils.Logger.WithField("account_id", id), "AccountService", "Delete")
+
+	account, err := s.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		if isNotFound(err) {
+			return fmt.Errorf("account %d: %w", id, apperrors.ErrNotFound)
+		}
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+
+	logger.Info("Account deleted")
+	s.recordActivity(models.AuditEntityAccount, id, models.ActivityAccountDeleted, account.OwnerID,
+		"Account deleted", account.Name+" was deleted")
+	return nil
+}
+
+func (s *accountService) List(filter models.AccountFilter, offset, limit int) ([]models.Account, int64, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("search", filter.Search), "AccountService", "List")
+
+	accounts, total, err := s.repo.List(filter, offset, limit)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, 0, err
+	}
+	return accounts, total, nil
+}
+
+func (s *accountService) Summary(id uint, scope models.AccountScope) (*models.AccountSummary, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("account_id", id), "AccountService", "Summary")
+
+	if _, err := s.GetByID(id); err != nil {
+		return nil, err
+	}
+	summary, err := s.repo.Summarize(id, scope)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	if scope.SkipDeals {
+		return summary, nil
+	}
+
+	deals, err := s.repo.AllDeals(id, scope.DealOwnerID)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	summary.Deals = summarizeAccountDeals(deals)
+	return summary, nil
+}
+
+// summarizeAccountDeals counts deals by the outcome of their stage and totals
+// the open ones per currency, currencies in alphabetical order.
+func summarizeAccountDeals(deals []models.Deal) *models.AccountDealSummary {
+	summary := &models.AccountDealSummary{Pipeline: []models.AccountPipelineValue{}}
+	pipeline := map[string]*models.AccountPipelineValue{}
+	for i := range deals {
+		deal := &deals[i]
+		outcome := models.DealOutcomeOpen
+		if deal.Stage != nil {
+			outcome = deal.Stage.Outcome
+		}
+		switch outcome {
+		case models.DealOutcomeWon:
+			summary.Won++
+			continue
+		case models.DealOutcomeLost:
+			summary.Lost++
+			continue
+		}
+		summary.Open++
+		row, ok := pipeline[deal.Currency]
+		if !ok {
+			row = &models.AccountPipelineValue{Currency: deal.Currency}
+			pipeline[deal.Currency] = row
+		}
+		row.Deals++
+		row.Value += deal.Amount
+		row.WeightedValue += deal.WeightedAmount()
+	}
+	for _, row := range pipeline {
+		row.Value = roundCents(row.Value)
+		row.WeightedValue = roundCents(row.WeightedValue)
+		summary.Pipeline = append(summary.Pipeline, *row)
+	}
+	sort.Slice(summary.Pipeline, func(i, j int) bool {
+		return summary.Pipeline[i].Currency < summary.Pipeline[j].Currency
+	})
+	return summary
+}
+
+func (s *accountService) ListLeads(id, ownerID uint, offset, limit int) ([]models.Lead, int64, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("account_id", id), "AccountService", "ListLeads")
+
+	if _, err := s.GetByID(id); err != nil {
+		return nil, 0, err
+	}
+	leads, total, err := s.repo.ListLeads(id, ownerID, offset, limit)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, 0, err
+	}
+	return leads, total, nil
+}
+
+func (s *accountService) ListCustomers(id uint, offset, limit int) ([]models.Customer, int64, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("account_id", id), "AccountService", "ListCustomers")
+
+	if _, err := s.GetByID(id); err != nil {
+		return nil, 0, err
+	}
+	customers, total, err := s.repo.ListCustomers(id, offset, limit)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, 0, err
+	}
+	return customers, total, nil
+}
+
+func (s *accountService) ListTickets(id uint, offset, limit int) ([]models.Ticket, int64, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("account_id", id), "AccountService", "ListTickets")
+
+	if _, err := s.GetByID(id); err != nil {
+		return nil, 0, err
+	}
+	tickets, total, err := s.repo.ListTickets(id, offset, limit)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, 0, err
+	}
+	return tickets, total, nil
+}
+
+func (s *accountService) ListTasks(id, assigneeID uint, offset, limit int) ([]models.Task, int64, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("account_id", id), "AccountService", "ListTasks")
+
+	if _, err := s.GetByID(id); err != nil {
+		return nil, 0, err
+	}
+	tasks, total, err := s.repo.ListTasks(id, assigneeID, offset, limit)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, 0, err
+	}
+	return tasks, total, nil
+}
+
+func (s *accountService) ListDeals(id, ownerID uint, offset, limit int) ([]models.Deal, int64, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("account_id", id), "AccountService", "ListDeals")
+
+	if _, err := s.GetByID(id); err != nil {
+		return nil, 0, err
+	}
+	deals, total, err := s.repo.ListDeals(id, ownerID, offset, limit)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, 0, err
+	}
+	return deals, total, nil
+}
+
+// accountLinks is embedded by the lead and customer services, which link
+// their records to accounts. A nil repository takes every link as given and
+// matches no company, which is what the unit tests that predate accounts
+// expect.
+type accountLinks struct {
+	accounts repository.AccountRepository
+}
+
+// checkAccount verifies the account a lead or customer is being linked to.
+// Zero unlinks, so it comes back as nil. Without an account repository the
+// link is taken as given.
+func (f *accountLinks) checkAccount(accountID *uint) (*uint, error) {
+	if accountID == nil || *accountID == 0 {
+		return nil, nil
+	}
+	if f.accounts == nil {
+		return accountID, nil
+	}
+	if _, err := f.accounts.GetByID(*accountID); err != nil {
+		if isNotFound(err) {
+			return nil, fmt.Errorf("account %d does not exist: %w", *accountID, apperrors.ErrValidation)
+		}
+		return nil, err
+	}
+	return accountID, nil
+}
+
+// matchAccount finds the account a new lead or customer's company text names,
+// if there is one. A failed lookup only means the record starts unlinked.
+func (f *accountLinks) matchAccount(company string) *uint {
+	key := models.AccountNameKey(company)
+	if f.accounts == nil || key == "" {
+		return nil
+	}
+	account, err := f.accounts.GetByNameKey(key)
+	if err != nil {
+		if !isNotFound(err) {
+			utils.Logger.WithError(err).Warn("Failed to match company to an account")
+		}
+		return nil
+	}
+	return &account.ID
+}
+
+// linkNewContact settles the account of a lead or customer being created: the
+// one it names, or else the one its company matches.
+func (f *accountLinks) linkNewContact(accountID *uint, company string) (*uint, error) {
+	if accountID != nil && *accountID != 0 {
+		return f.checkAccount(accountID)
+	}
+	return f.matchAccount(company), nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type AccountServiceTestSuite struct {
+	suite.Suite
+	mockRepo     *mocks.AccountRepository
+	mockUserRepo *mocks.UserRepository
+	mockActivity *mocks.ActivityService
+	service      AccountService
+}
+
+func (suite *AccountServiceTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+}
+
+func (suite *AccountServiceTestSuite) SetupTest() {
+	suite.mockRepo = new(mocks.AccountRepository)
+	suite.mockUserRepo = new(mocks.UserRepository)
+	suite.mockActivity = new(mocks.ActivityService)
+	suite.service = NewAccountService(suite.mockRepo, suite.mockUserRepo, WithActivityFeed(suite.mockActivity))
+}
+
+func (suite *AccountServiceTestSuite) TearDownTest() {
+	suite.mockRepo.AssertExpectations(suite.T())
+	suite.mockUserRepo.AssertExpectations(suite.T())
+	suite.mockActivity.AssertExpectations(suite.T())
+}
+
+func (suite *AccountServiceTestSuite) expectActivity(activity models.ActivityType) {
+	suite.mockActivity.On("Record", mock.MatchedBy(func(e *models.ActivityEvent) bool {
+		return e.Type == activity && e.EntityType == models.AuditEntityAccount
+	})).Return(nil).Once()
+}
+
+func (suite *AccountServiceTestSuite) TestCreate_NormalizesTheNameAndDomain() {
+	suite.mockUserRepo.On("GetByID", uint(7)).Return(&models.User{BaseModel: models.BaseModel{ID: 7}, IsActive: true}, nil)
+	suite.mockRepo.On("GetByNameKey", "acme").Return(nil, gorm.ErrRecordNotFound)
+	suite.mockRepo.On("Create", mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Account).ID = 4
+	}).Return(nil)
+	suite.expectActivity(models.ActivityAccountCreated)
+
+	owner := uint(7)
+	account := &models.Account{Name: "  ACME   Inc. ", Domain: "https://www.Acme.com:443/about", OwnerID: &owner}
+	err := suite.service.Create(account)
+
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "ACME Inc.", account.Name)
+	assert.Equal(suite.T(), "acme", account.NameKey)
+	assert.Equal(suite.T(), "acme.com", account.Domain)
+}
+
+func (suite *AccountServiceTestSuite) TestCreate_RefusesADuplicateName() {
+	suite.mockRepo.On("GetByNameKey", "acme").Return(&models.Account{BaseModel: models.BaseModel{ID: 2}, Name: "Acme"}, nil)
+
+	err := suite.service.Create(&models.Account{Name: "Acme, Ltd."})
+
+	assert.ErrorIs(suite.T(), err, apperrors.ErrDuplicateAccount)
+	suite.mockRepo.AssertNotCalled(suite.T(), "Create", mock.Anything)
+}
+
+func (suite *AccountServiceTestSuite) TestCreate_RejectsInvalidFields() {
+	inactive := uint(9)
+	suite.mockUserRepo.On("GetByID", inactive).Return(&models.User{BaseModel: models.BaseModel{ID: 9}}, nil)
+
+	for name, account := range map[string]*models.Account{
+		"no name":        {Name: "   "},
+		"only symbols":   {Name: "--"},
+		"bad domain":     {Name: "Acme", Domain: "not a domain"},
+		"bad size":       {Name: "Acme", Size: "lots"},
+		"inactive owner": {Name: "Acme", OwnerID: &inactive},
+	} {
+		err := suite.service.Create(account)
+		assert.ErrorIs(suite.T(), err, apperrors.ErrValidation, name)
+	}
+}
+
+func (suite *AccountServiceTestSuite) TestUpdate_KeepsItsOwnNameAndRecordsReassignment() {
+	owner := uint(7)
+	existing := &models.Account{BaseModel: models.BaseModel{ID: 4}, Name: "Acme", NameKey: "acme"}
+	suite.mockRepo.On("GetByID", uint(4)).Return(existing, nil)
+	suite.mockUserRepo.On("GetByID", owner).Return(&models.User{BaseModel: models.BaseModel{ID: 7}, IsActive: true}, nil)
+	suite.mockRepo.On("GetByNameKey", "acme").Return(existing, nil)
+	suite.mockRepo.On("Update", mock.Anything).Return(nil)
+	suite.expectActivity(models.ActivityAccountReassigned)
+
+	_, err := suite.service.Update(&models.Account{BaseModel: models.BaseModel{ID: 4}, Name: "Acme Inc", OwnerID: &owner})
+
+	require.NoError(suite.T(), err)
+}
+
+func (suite *AccountServiceTestSuite) TestGetByID_NotFound() {
+	suite.mockRepo.On("GetByID", uint(4)).Return(nil, gorm.ErrRecordNotFound)
+
+	_, err := suite.service.GetByID(4)
+
+	assert.True(suite.T(), apperrors.IsNotFound(err))
+}
+
+func (suite *AccountServiceTestSuite) TestSummary_TotalsTheOpenPipelinePerCurrency() {
+	open := &models.PipelineStage{Outcome: models.DealOutcomeOpen}
+	won := &models.PipelineStage{Outcome: models.DealOutcomeWon}
+	suite.mockRepo.On("GetByID", uint(4)).Return(&models.Account{BaseModel: models.BaseModel{ID: 4}}, nil)
+	suite.mockRepo.On("Summarize", uint(4), models.AccountScope{LeadOwnerID: 7, DealOwnerID: 7, TaskAssigneeID: 7}).Return(&models.AccountSummary{AccountID: 4}, nil)
+	suite.mockRepo.On("AllDeals", uint(4), uint(7)).Return([]models.Deal{
+		{Amount: 1000, Currency: "USD", Probability: 50, Stage: open},
+		{Amount: 100.004, Currency: "EUR", Probability: 10, Stage: open},
+		{Amount: 200.004, Currency: "EUR", Probability: 25, Stage: open},
+		{Amount: 5000, Currency: "EUR", Probability: 100, Stage: won},
+	}, nil)
+
+	summary, err := suite.service.Summary(4, models.AccountScope{LeadOwnerID: 7, DealOwnerID: 7, TaskAssigneeID: 7})
+
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), summary.Deals)
+	assert.Equal(suite.T(), int64(3), summary.Deals.Open)
+	assert.Equal(suite.T(), int64(1), summary.Deals.Won)
+	assert.Equal(suite.T(), []models.AccountPipelineValue{
+		{Currency: "EUR", Deals: 2, Value: 300.01, WeightedValue: 60},
+		{Currency: "USD", Deals: 1, Value: 1000, WeightedValue: 500},
+	}, summary.Deals.Pipeline)
+}
+
+func (suite *AccountServiceTestSuite) TestSummary_SkipDealsLeavesDealsOut() {
+	scope := models.AccountScope{TaskAssigneeID: 7, SkipLeads: true, SkipDeals: true}
+	suite.mockRepo.On("GetByID", uint(4)).Return(&models.Account{BaseModel: models.BaseModel{ID: 4}}, nil)
+	suite.mockRepo.On("Summarize", uint(4), scope).Return(&models.AccountSummary{AccountID: 4}, nil)
+
+	summary, err := suite.service.Summary(4, scope)
+
+	require.NoError(suite.T(), err)
+	assert.Nil(suite.T(), summary.Deals)
+	suite.mockRepo.AssertNotCalled(suite.T(), "AllDeals", mock.Anything, mock.Anything)
+}
+
+func TestAccountServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(AccountServiceTestSuite))
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+)
+
+// ActivityService records and reads the activity feed.
+type ActivityService interface {
+	// Record stores one event. The entity services call it after the change
+	// has been committed, so they log a failure rather than return it.
+	Record(event *models.ActivityEvent) error
+	// Feed returns up to limit events matching the filter, newest first, and
+	// the cursor of the next page ("" on the last one). cursor is the value a
+	// previous call returned, or "" for the first page; a cursor that did not
+	// come from Feed is rejected with apperrors.ErrValidation.
+	Feed(filter models.ActivityFilter, cursor string, limit int) ([]models.ActivityEvent, string, error)
+}
+
+type activityService struct {
+	activityRepo repository.ActivityRepository
+}
+
+func NewActivityService(activityRepo repository.ActivityRepository) ActivityService {
+	return &activityService{activityRepo: activityRepo}
+}
+
+func (s *activityService) Record(event *models.ActivityEvent) error {
+	logger := utils.LogServiceCall(utils.Logger.WithFields(map[string]interface{}{
+		"entity_type": event.EntityType,
+		"entity_id":   event.EntityID,
+		"type":        event.Type,
+	}), "ActivityService", "Record")
+
+	if err := s.activityRepo.Create(event); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+	return nil
+}
+
+func (s *activityService) Feed(filter models.ActivityFilter, cursor string, limit int) ([]models.ActivityEvent, string, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithFields(map[string]interface{}{
+		"entity_type": filter.EntityType,
+		"owner_id":    filter.OwnerID,
+		"limit":       limit,
+	}), "ActivityService", "Feed")
+
+	if cursor != "" {
+		beforeID, err := decodeActivityCursor(cursor)
+		if err != nil {
+			logger.WithError(err).Warn("Invalid activity cursor")
+			return nil, "", err
+		}
+		filter.BeforeID = beforeID
+	}
+
+	// One row more than the page tells whether there is a next page without a
+	// second query.
+	events, err := s.activityRepo.List(filter, limit+1)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, "", err
+	}
+
+	next := ""
+	if len(events) > limit {
+		events = events[:limit]
+		next = encodeActivityCursor(events[limit-1].ID)
+	}
+	return events, next, nil
+}
+
+// The cursor is the id of the last event of the page, wrapped so clients treat
+// it as opaque rather than doing arithmetic on it.
+func encodeActivityCursor(id uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(id), 10)))
+}
+
+func decodeActivityCursor(cursor string) (uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid activity cursor: %w", apperrors.ErrValidation)
+	}
+	id, err := strconv.ParseUint(string(raw), 10, 32)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid activity cursor: %w", apperrors.ErrValidation)
+	}
+	return uint(id), nil
+}
+
+// ActivityOption wires the activity feed into an entity service at
+// construction time. A service built without one records nothing, which is
+// what every unit test that predates the feed expects.
+type ActivityOption func(*activityFeed)
+
+// EntityOption is an option of the entity services that both write to the
+// feed and publish webhooks: an ActivityOption or a WebhookOption.
+type EntityOption interface {
+	applyEntityOption(feed *activityFeed, events *webhookEvents)
+}
+
+func (o ActivityOption) applyEntityOption(feed *activityFeed, _ *webhookEvents) { o(feed) }
+
+// applyEntityOptions hands each option the half of the service it configures.
+func applyEntityOptions(feed *activityFeed, events *webhookEvents, opts []EntityOption) {
+	for _, opt := range opts {
+		opt.applyEntityOption(feed, events)
+	}
+}
+
+// WithActivityFeed makes the service write its changes to the activity feed.
+func WithActivityFeed(activity ActivityService) ActivityOption {
+	return func(f *activityFeed) { f.activity = activity }
+}
+
+// activityFeed is embedded by the entity services that write to the feed.
+type activityFeed struct {
+	activity ActivityService
+}
+
+func (f *activityFeed) applyActivityOptions(opts []ActivityOption) {
+	for _, opt := range opts {
+		opt(f)
+	}
+}
+
+// recordActivity stores one event about a change that has already been
+// committed, so a failure is logged, not returned.
+func (f *activityFeed) recordActivity(entityType string, entityID uint, activityType models.ActivityType, ownerID *uint, title, description string) {
+	if f.activity == nil {
+		return
+	}
+	event := &models.ActivityEvent{
+		EntityType:  entityType,
+		EntityID:    entityID,
+		Type:        activityType,
+		Title:       title,
+		Description: description,
+		OwnerID:     ownerID,
+	}
+	if err := f.activity.Record(event); err != nil {
+		utils.Logger.WithError(err).
+			WithField("entity_type", entityType).
+			WithField("entity_id", entityID).
+			WithField("type", activityType).
+			Warn("Failed to record activity event")
+	}
+}
+
+// activityOwner turns a non-nullable owner column into the event's nullable
+// one; 0 is "nobody".
+func activityOwner(id uint) *uint {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+// sameOwner compares two nullable owner columns.
+func sameOwner(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// describePerson renders a lead or customer as "First Last (Company)",
+// degrading gracefully when either half is missing.
+func describePerson(firstName, lastName, company, fallback string) string {
+	name := firstName
+	if lastName != "" {
+		if name != "" {
+			name += " "
+		}
+		name += lastName
+	}
+	if name == "" {
+		name = fallback
+	}
+	if company != "" {
+		return name + " (" + company + ")"
+	}
+	return name
+}
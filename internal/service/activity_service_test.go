@@ -0,0 +1,129 @@
+package service
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ActivityServiceTestSuite struct {
+	suite.Suite
+	mockRepo *mocks.ActivityRepository
+	service  ActivityService
+}
+
+func (suite *ActivityServiceTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+}
+
+func (suite *ActivityServiceTestSuite) SetupTest() {
+	suite.mockRepo = new(mocks.ActivityRepository)
+	suite.service = NewActivityService(suite.mockRepo)
+}
+
+func (suite *ActivityServiceTestSuite) TearDownTest() {
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+func activityEvents(ids ...uint) []models.ActivityEvent {
+	events := make([]models.ActivityEvent, len(ids))
+	for i, id := range ids {
+		events[i] = models.ActivityEvent{ID: id}
+	}
+	return events
+}
+
+func (suite *ActivityServiceTestSuite) TestFeed_FullPageHasNextCursor() {
+	filter := models.ActivityFilter{OwnerID: 7}
+	suite.mockRepo.On("List", filter, 3).Return(activityEvents(9, 8, 5), nil)
+
+	events, next, err := suite.service.Feed(filter, "", 2)
+
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), events, 2)
+	assert.NotEmpty(suite.T(), next)
+
+	// The cursor resumes strictly after the last event returned.
+	suite.mockRepo.On("List", models.ActivityFilter{OwnerID: 7, BeforeID: 8}, 3).Return(activityEvents(5), nil)
+
+	events, next, err = suite.service.Feed(filter, next, 2)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), uint(5), events[0].ID)
+	assert.Empty(suite.T(), next)
+}
+
+func (suite *ActivityServiceTestSuite) TestFeed_InvalidCursorIsValidationError() {
+	for _, cursor := range []string{"not base64!", base64.RawURLEncoding.EncodeToString([]byte("abc")), base64.RawURLEncoding.EncodeToString([]byte("0"))} {
+		_, _, err := suite.service.Feed(models.ActivityFilter{}, cursor, 10)
+		assert.ErrorIs(suite.T(), err, apperrors.ErrValidation, cursor)
+	}
+}
+
+func (suite *ActivityServiceTestSuite) TestFeed_RepositoryError() {
+	suite.mockRepo.On("List", models.ActivityFilter{}, 11).Return(nil, assert.AnError)
+
+	_, _, err := suite.service.Feed(models.ActivityFilter{}, "", 10)
+
+	assert.ErrorIs(suite.T(), err, assert.AnError)
+}
+
+func TestActivityServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(ActivityServiceTestSuite))
+}
+
+// The entity services describe an update by what it changed; a status change
+// and a reassignment in one update are two entries.
+func TestLeadService_UpdateRecordsStatusChangeAndReassignment(t *testing.T) {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+	leadRepo := new(mocks.LeadRepository)
+	activity := new(mocks.ActivityService)
+	svc := NewLeadService(leadRepo, new(mocks.CustomerRepository), nil, nil, WithActivityFeed(activity))
+
+	lead := &models.Lead{FirstName: "Ada", LastName: "Lovelace", Status: models.LeadStatusNew, OwnerID: 1}
+	lead.ID = 4
+	leadRepo.On("GetByID", uint(4)).Return(lead, nil)
+	leadRepo.On("Update", mock.AnythingOfType("*models.Lead")).Return(nil)
+
+	var recorded []*models.ActivityEvent
+	activity.On("Record", mock.AnythingOfType("*models.ActivityEvent")).Return(nil).Run(func(args mock.Arguments) {
+		recorded = append(recorded, args.Get(0).(*models.ActivityEvent))
+	})
+
+	_, err := svc.Update(4, map[string]interface{}{"status": models.LeadStatusContacted, "owner_id": uint(2)})
+
+	assert.NoError(t, err)
+	if assert.Len(t, recorded, 2) {
+		assert.Equal(t, models.ActivityLeadStatusChanged, recorded[0].Type)
+		assert.Equal(t, "Ada Lovelace moved from new to contacted", recorded[0].Description)
+		assert.Equal(t, models.ActivityLeadReassigned, recorded[1].Type)
+		assert.Equal(t, uint(2), *recorded[1].OwnerID)
+		assert.Equal(t, models.AuditEntityLead, recorded[1].EntityType)
+		assert.Equal(t, uint(4), recorded[1].EntityID)
+	}
+}
+
+// A feed that cannot be written must not fail a change that is already
+// committed.
+func TestLeadService_ActivityFailureDoesNotFailCreate(t *testing.T) {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+	leadRepo := new(mocks.LeadRepository)
+	activity := new(mocks.ActivityService)
+	svc := NewLeadService(leadRepo, new(mocks.CustomerRepository), nil, nil, WithActivityFeed(activity))
+
+	leadRepo.On("Create", mock.AnythingOfType("*models.Lead")).Return(nil)
+	activity.On("Record", mock.AnythingOfType("*models.ActivityEvent")).Return(assert.AnError)
+
+	err := svc.Create(&models.Lead{FirstName: "Ada", Email: "ada@example.com", OwnerID: 1})
+
+	assert.NoError(t, err)
+	activity.AssertExpectations(t)
+}
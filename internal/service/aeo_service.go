@@ -246,10 +246,10 @@ func (s *aeoService) SaveProfile(profile *models.AEOProfile) (*models.AEOProfile
 
 // ---------------------------------------------------------------- prompts ---
 
-func (s *aeoService) ListPrompts(from, to time.Time, activeOnly bool, offset, limit int, sortBy, sortOrder string) ([]models.AEOPrompt, int64, error) {
+func (s *aeoService) ListPrompts(from, to time.Time, filter models.AEOPromptFilter, offset, limit int, sortBy, sortOrder string) ([]models.AEOPrompt, int64, error) {
 	logger := utils.LogServiceCall(utils.Logger.WithField("entity", "aeo_prompt"), "AEOService", "ListPrompts")
 
-	prompts, err := s.repo.ListPrompts(activeOnly, offset, limit, sortBy, sortOrder)
+	prompts, err := s.repo.ListPrompts(filter, offset, limit, sortBy, sortOrder)
 	if err != nil {
 		utils.LogServiceResponse(logger, err)
 		return nil, 0, err
@@ -258,7 +258,7 @@ func (s *aeoService) ListPrompts(from, to time.Time, activeOnly bool, offset, li
 		prompts = []models.AEOPrompt{}
 	}
 
-	total, err := s.repo.CountPrompts(activeOnly)
+	total, err := s.repo.CountPrompts(filter)
 	if err != nil {
 		utils.LogServiceResponse(logger, err)
 		return nil, 0, err
@@ -332,7 +332,7 @@ func (s *aeoService) CreatePrompts(texts []string, createdByID uint) ([]models.A
 		}
 		txRepo := s.repo.WithTx(tx)
 
-		active, err := txRepo.CountPrompts(true)
+		active, err := txRepo.CountPrompts(models.AEOPromptFilter{ActiveOnly: true})
 		if err != nil {
 			return err
 		}
@@ -400,7 +400,7 @@ func (s *aeoService) UpdatePrompt(id uint, text *string, isActive *bool) (*model
 	if isActive != nil {
 		// Reactivating counts against the cap; deactivating never can.
 		if *isActive && !prompt.IsActive {
-			active, err := s.repo.CountPrompts(true)
+			active, err := s.repo.CountPrompts(models.AEOPromptFilter{ActiveOnly: true})
 			if err != nil {
 				utils.LogServiceResponse(logger, err)
 				return nil, err
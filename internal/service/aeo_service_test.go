@@ -190,15 +190,15 @@ func (suite *AEOServiceTestSuite) TestListPrompts_DecoratesWindowMetrics() {
 	second := models.AEOPrompt{Text: "Best CRM for support teams?"}
 	second.ID = 2
 
-	suite.mockRepo.On("ListPrompts", true, 0, 20, "created_at", "desc").
+	suite.mockRepo.On("ListPrompts", models.AEOPromptFilter{ActiveOnly: true}, 0, 20, "created_at", "desc").
 		Return([]models.AEOPrompt{first, second}, nil)
-	suite.mockRepo.On("CountPrompts", true).Return(int64(2), nil)
+	suite.mockRepo.On("CountPrompts", models.AEOPromptFilter{ActiveOnly: true}).Return(int64(2), nil)
 	suite.mockRepo.On("PromptVisibility", from, to, []uint{1, 2}).
 		Return(map[uint]models.AEOPromptVisibility{
 			1: {PromptID: 1, Answers: 3, Mentions: 2, LastRunAt: &lastRun},
 		}, nil)
 
-	prompts, total, err := suite.service.ListPrompts(from, to, true, 0, 20, "created_at", "desc")
+	prompts, total, err := suite.service.ListPrompts(from, to, models.AEOPromptFilter{ActiveOnly: true}, 0, 20, "created_at", "desc")
 
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), int64(2), total)
@@ -216,10 +216,10 @@ func (suite *AEOServiceTestSuite) TestListPrompts_EmptyPageSkipsMetricsQuery() {
 	from := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
 	to := from.AddDate(0, 0, 7)
 
-	suite.mockRepo.On("ListPrompts", false, 0, 20, "", "").Return(nil, nil)
-	suite.mockRepo.On("CountPrompts", false).Return(int64(0), nil)
+	suite.mockRepo.On("ListPrompts", models.AEOPromptFilter{}, 0, 20, "", "").Return(nil, nil)
+	suite.mockRepo.On("CountPrompts", models.AEOPromptFilter{}).Return(int64(0), nil)
 
-	prompts, total, err := suite.service.ListPrompts(from, to, false, 0, 20, "", "")
+	prompts, total, err := suite.service.ListPrompts(from, to, models.AEOPromptFilter{}, 0, 20, "", "")
 
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), int64(0), total)
@@ -230,7 +230,7 @@ func (suite *AEOServiceTestSuite) TestListPrompts_EmptyPageSkipsMetricsQuery() {
 
 func (suite *AEOServiceTestSuite) TestCreatePrompts_Success() {
 	suite.mockRepo.On("WithTx", mock.Anything).Return(suite.mockRepo)
-	suite.mockRepo.On("CountPrompts", true).Return(int64(5), nil)
+	suite.mockRepo.On("CountPrompts", models.AEOPromptFilter{ActiveOnly: true}).Return(int64(5), nil)
 	suite.mockRepo.On("ExistsByTextInsensitive", "Which CRM for SMBs?", uint(0)).Return(false, nil)
 	suite.mockRepo.On("ExistsByTextInsensitive", "Best CRM for support?", uint(0)).Return(false, nil)
 	suite.mockRepo.On("CreatePrompt", mock.AnythingOfType("*models.AEOPrompt")).Return(nil).
@@ -250,7 +250,7 @@ func (suite *AEOServiceTestSuite) TestCreatePrompts_Success() {
 
 func (suite *AEOServiceTestSuite) TestCreatePrompts_AnonymousCreatorLeavesOwnerNil() {
 	suite.mockRepo.On("WithTx", mock.Anything).Return(suite.mockRepo)
-	suite.mockRepo.On("CountPrompts", true).Return(int64(0), nil)
+	suite.mockRepo.On("CountPrompts", models.AEOPromptFilter{ActiveOnly: true}).Return(int64(0), nil)
 	suite.mockRepo.On("ExistsByTextInsensitive", "Which CRM?", uint(0)).Return(false, nil)
 	suite.mockRepo.On("CreatePrompt", mock.AnythingOfType("*models.AEOPrompt")).Return(nil)
 
@@ -274,7 +274,7 @@ func (suite *AEOServiceTestSuite) TestCreatePrompts_RejectsDuplicateWithinBatch(
 
 func (suite *AEOServiceTestSuite) TestCreatePrompts_RejectsExistingText() {
 	suite.mockRepo.On("WithTx", mock.Anything).Return(suite.mockRepo)
-	suite.mockRepo.On("CountPrompts", true).Return(int64(1), nil)
+	suite.mockRepo.On("CountPrompts", models.AEOPromptFilter{ActiveOnly: true}).Return(int64(1), nil)
 	suite.mockRepo.On("ExistsByTextInsensitive", "Which CRM?", uint(0)).Return(true, nil)
 
 	created, err := suite.service.CreatePrompts([]string{"Which CRM?"}, 1)
@@ -286,7 +286,7 @@ func (suite *AEOServiceTestSuite) TestCreatePrompts_RejectsExistingText() {
 
 func (suite *AEOServiceTestSuite) TestCreatePrompts_EnforcesActivePromptCap() {
 	suite.mockRepo.On("WithTx", mock.Anything).Return(suite.mockRepo)
-	suite.mockRepo.On("CountPrompts", true).Return(int64(99), nil)
+	suite.mockRepo.On("CountPrompts", models.AEOPromptFilter{ActiveOnly: true}).Return(int64(99), nil)
 
 	created, err := suite.service.CreatePrompts([]string{"One more?", "And another?"}, 1)
 
@@ -298,7 +298,7 @@ func (suite *AEOServiceTestSuite) TestCreatePrompts_EnforcesActivePromptCap() {
 // The cap is a ceiling, not a barrier: filling the last slot exactly is fine.
 func (suite *AEOServiceTestSuite) TestCreatePrompts_AllowsFillingTheLastSlot() {
 	suite.mockRepo.On("WithTx", mock.Anything).Return(suite.mockRepo)
-	suite.mockRepo.On("CountPrompts", true).Return(int64(99), nil)
+	suite.mockRepo.On("CountPrompts", models.AEOPromptFilter{ActiveOnly: true}).Return(int64(99), nil)
 	suite.mockRepo.On("ExistsByTextInsensitive", "One more?", uint(0)).Return(false, nil)
 	suite.mockRepo.On("CreatePrompt", mock.AnythingOfType("*models.AEOPrompt")).Return(nil)
 
@@ -371,7 +371,7 @@ func (suite *AEOServiceTestSuite) TestUpdatePrompt_ReactivationHitsCap() {
 	active := true
 
 	suite.mockRepo.On("GetPromptByID", uint(4)).Return(&existing, nil)
-	suite.mockRepo.On("CountPrompts", true).Return(int64(100), nil)
+	suite.mockRepo.On("CountPrompts", models.AEOPromptFilter{ActiveOnly: true}).Return(int64(100), nil)
 
 	updated, err := suite.service.UpdatePrompt(4, nil, &active)
 
@@ -22,6 +22,19 @@ func NewAPIKeyService(apiKeyRepo repository.APIKeyRepository, apiKeySecret strin
 }
 
 func (s *apiKeyService) Generate(userID uint, name string, expiresAt *time.Time) (string, *models.APIKey, error) {
+	return s.generate(userID, name, expiresAt, models.APIKeyRestrictions{
+		Scopes: []models.APIKeyScope{models.APIKeyScopeFullAccess},
+	})
+}
+
+func (s *apiKeyService) GenerateRestricted(userID uint, name string, expiresAt *time.Time, restrictions models.APIKeyRestrictions) (string, *models.APIKey, error) {
+	if err := restrictions.Validate(); err != nil {
+		return "", nil, fmt.Errorf("%w: %w", err, apperrors.ErrValidation)
+	}
+	return s.generate(userID, name, expiresAt, restrictions)
+}
+
+func (s *apiKeyService) generate(userID uint, name string, expiresAt *time.Time, restrictions models.APIKeyRestrictions) (string, *models.APIKey, error) {
 	// Generate random key
 	keyBytes := make([]byte, 32)
 	if _, err := rand.Read(keyBytes); err != nil {
@@ -37,6 +50,8 @@ func (s *apiKeyService) Generate(userID uint, name string, expiresAt *time.Time)
 		Prefix:    prefix,
 		UserID:    userID,
 		ExpiresAt: expiresAt,
+
+		APIKeyRestrictions: restrictions,
 	}
 
 	if err := s.apiKeyRepo.Create(apiKey); err != nil {
@@ -127,3 +142,21 @@ func (s *apiKeyService) List(userID uint) ([]models.APIKey, error) {
 	return s.apiKeyRepo.GetByUserID(userID)
 }
 
+func (s *apiKeyService) ListUsage(id uint, userID uint, anyKey bool, offset, limit int) ([]models.APIKeyUsage, int64, error) {
+	if anyKey {
+		if _, err := s.apiKeyRepo.GetByID(id); err != nil {
+			if isNotFound(err) {
+				return nil, 0, fmt.Errorf("api key %d not found: %w", id, apperrors.ErrNotFound)
+			}
+			return nil, 0, err
+		}
+	} else if _, err := s.ownedKey(id, userID); err != nil {
+		return nil, 0, err
+	}
+
+	return s.apiKeyRepo.ListUsage(id, offset, limit)
+}
+
+func (s *apiKeyService) RecordUsage(usage *models.APIKeyUsage) error {
+	return s.apiKeyRepo.CreateUsage(usage)
+}
@@ -429,4 +429,106 @@ func TestAPIKeyService_List(t *testing.T) {
 	assert.Equal(t, expectedKeys, keys)
 	
 	mockAPIKeyRepo.AssertExpectations(t)
-}
\ No newline at end of file
+}
+func TestAPIKeyService_Generate_FullAccess(t *testing.T) {
+	mockAPIKeyRepo := new(MockAPIKeyRepository)
+	apiKeyService := NewAPIKeyService(mockAPIKeyRepo, "test-api-key-secret")
+
+	mockAPIKeyRepo.On("Create", mock.Anything).Return(nil)
+
+	_, apiKey, err := apiKeyService.Generate(1, "Programmatic Key", nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.APIKeyScope{models.APIKeyScopeFullAccess}, apiKey.Scopes)
+	assert.Empty(t, apiKey.AllowedCIDRs)
+	assert.Zero(t, apiKey.RateLimitPerMinute)
+}
+
+func TestAPIKeyService_GenerateRestricted(t *testing.T) {
+	t.Run("stores the restrictions, networks normalized", func(t *testing.T) {
+		mockAPIKeyRepo := new(MockAPIKeyRepository)
+		apiKeyService := NewAPIKeyService(mockAPIKeyRepo, "test-api-key-secret")
+
+		mockAPIKeyRepo.On("Create", mock.MatchedBy(func(k *models.APIKey) bool {
+			return len(k.Scopes) == 2 && k.RateLimitPerMinute == 60
+		})).Return(nil)
+
+		key, apiKey, err := apiKeyService.GenerateRestricted(1, "Scoped Key", nil, models.APIKeyRestrictions{
+			Scopes:             []models.APIKeyScope{"leads:read", "tickets:write"},
+			AllowedCIDRs:       []string{"203.0.113.7", "10.0.0.0/8"},
+			RateLimitPerMinute: 60,
+		})
+
+		assert.NoError(t, err)
+		assert.Contains(t, key, "gcrm_")
+		assert.Equal(t, []string{"203.0.113.7/32", "10.0.0.0/8"}, apiKey.AllowedCIDRs)
+		mockAPIKeyRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects invalid restrictions", func(t *testing.T) {
+		mockAPIKeyRepo := new(MockAPIKeyRepository)
+		apiKeyService := NewAPIKeyService(mockAPIKeyRepo, "test-api-key-secret")
+
+		_, apiKey, err := apiKeyService.GenerateRestricted(1, "Bad Key", nil, models.APIKeyRestrictions{
+			Scopes: []models.APIKeyScope{"leads:delete"},
+		})
+
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+		assert.ErrorIs(t, err, models.ErrInvalidAPIKey)
+		assert.Nil(t, apiKey)
+		mockAPIKeyRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+}
+
+func TestAPIKeyService_ListUsage(t *testing.T) {
+	usage := []models.APIKeyUsage{{ID: 1, APIKeyID: 5, Method: "GET", Endpoint: "/api/v1/leads", Status: 200}}
+
+	t.Run("owner reads their key's log", func(t *testing.T) {
+		mockAPIKeyRepo := new(MockAPIKeyRepository)
+		apiKeyService := NewAPIKeyService(mockAPIKeyRepo, "test-api-key-secret")
+
+		mockAPIKeyRepo.On("GetByID", uint(5)).Return(&models.APIKey{BaseModel: models.BaseModel{ID: 5}, UserID: 1}, nil)
+		mockAPIKeyRepo.On("ListUsage", uint(5), 0, 20).Return(usage, int64(1), nil)
+
+		rows, total, err := apiKeyService.ListUsage(5, 1, false, 0, 20)
+
+		assert.NoError(t, err)
+		assert.Equal(t, usage, rows)
+		assert.Equal(t, int64(1), total)
+	})
+
+	t.Run("another user's key is forbidden", func(t *testing.T) {
+		mockAPIKeyRepo := new(MockAPIKeyRepository)
+		apiKeyService := NewAPIKeyService(mockAPIKeyRepo, "test-api-key-secret")
+
+		mockAPIKeyRepo.On("GetByID", uint(5)).Return(&models.APIKey{BaseModel: models.BaseModel{ID: 5}, UserID: 2}, nil)
+
+		_, _, err := apiKeyService.ListUsage(5, 1, false, 0, 20)
+
+		assert.ErrorIs(t, err, apperrors.ErrForbidden)
+		mockAPIKeyRepo.AssertNotCalled(t, "ListUsage", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("anyKey reads another user's key", func(t *testing.T) {
+		mockAPIKeyRepo := new(MockAPIKeyRepository)
+		apiKeyService := NewAPIKeyService(mockAPIKeyRepo, "test-api-key-secret")
+
+		mockAPIKeyRepo.On("GetByID", uint(5)).Return(&models.APIKey{BaseModel: models.BaseModel{ID: 5}, UserID: 2}, nil)
+		mockAPIKeyRepo.On("ListUsage", uint(5), 0, 20).Return(usage, int64(1), nil)
+
+		_, _, err := apiKeyService.ListUsage(5, 1, true, 0, 20)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		mockAPIKeyRepo := new(MockAPIKeyRepository)
+		apiKeyService := NewAPIKeyService(mockAPIKeyRepo, "test-api-key-secret")
+
+		mockAPIKeyRepo.On("GetByID", uint(9)).Return(nil, gorm.ErrRecordNotFound)
+
+		_, _, err := apiKeyService.ListUsage(9, 1, true, 0, 20)
+
+		assert.ErrorIs(t, err, apperrors.ErrNotFound)
+	})
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+)
+
+// auditIgnoredFields are the bookkeeping columns every entity carries, plus the
+// derived counts some responses include. They change without the actor touching
+// them, so diffing them would add noise to every event without saying anything
+// about what the actor did.
+var auditIgnoredFields = map[string]bool{
+	"id":         true,
+	"created_at": true,
+	"updated_at": true,
+	"deleted_at": true,
+	"task_count": true,
+}
+
+// auditOwnObjects are the object-valued fields that belong to the entity
+// itself rather than naming another record. The custom field values are one
+// object so that the erasure scrub can blank them by a single name.
+var auditOwnObjects = map[string]bool{
+	"custom_fields": true,
+}
+
+// AuditService records and reads the audit trail.
+//
+// Record is called after the change it describes has been committed, so it is
+// deliberately unable to undo that change: callers log a failure to record and
+// carry on rather than reporting a successful write as failed.
+type AuditService interface {
+	// Record diffs before against after and stores the result. before is nil
+	// for a create and after is nil for a delete. Either may be the entity
+	// itself or a snapshot taken with AuditSnapshot; only the entity's own
+	// scalar fields are compared, never its preloaded associations. An update
+	// that changed nothing is not recorded. A delete may pass nil for both
+	// states: erasing a person must not copy their data into a fresh event.
+	Record(actor models.AuditActor, entityType string, entityID uint, action models.AuditAction, before, after interface{}) error
+	// List returns one page of the whole trail, newest first.
+	List(filter models.AuditFilter, offset, limit int) ([]models.AuditEvent, int64, error)
+	// History returns one page of a single entity's trail, newest first.
+	History(entityType string, entityID uint, offset, limit int) ([]models.AuditEvent, int64, error)
+}
+
+type auditService struct {
+	auditRepo repository.AuditRepository
+}
+
+func NewAuditService(auditRepo repository.AuditRepository) AuditService {
+	return &auditService{auditRepo: auditRepo}
+}
+
+// auditRecorder is embedded by the services whose writes happen outside a
+// request: queued bulk operations, imports and reverts run for the user who
+// started them, and are attributed to that user; single sign-on, public forms,
+// inbound email and the SLA breach worker write for nobody in particular, and
+// are attributed to the system. A service built without an
+// audit service records nothing, which is what their unit tests expect.
+type auditRecorder struct {
+	auditService AuditService
+}
+
+// recordAudit stores one audit event about a change that has already been
+// committed, so a failure is logged, not returned.
+func (a *auditRecorder) recordAudit(actor models.AuditActor, entityType string, entityID uint, action models.AuditAction, before, after interface{}) {
+	if a.auditService == nil {
+		return
+	}
+	if err := a.auditService.Record(actor, entityType, entityID, action, before, after); err != nil {
+		utils.Logger.WithError(err).
+			WithField("entity_type", entityType).
+			WithField("entity_id", entityID).
+			WithField("action", action).
+			Warn("Failed to record audit event")
+	}
+}
+
+// systemAuditActor attributes a change to the system: what arrives from
+// outside without a signed-in user — public forms, inbound email, the identity
+// provider's claims — and what the background workers decide.
+var systemAuditActor = models.AuditActor{Type: models.AuditActorSystem}
+
+// userAuditActor attributes a change to the user it was made for.
+func userAuditActor(userID uint) models.AuditActor {
+	if userID == 0 {
+		return systemAuditActor
+	}
+	return models.AuditActor{Type: models.AuditActorUser, UserID: &userID}
+}
+
+func (s *auditService) Record(actor models.AuditActor, entityType string, entityID uint, action models.AuditAction, before, after interface{}) error {
+	logger := utils.LogServiceCall(utils.Logger.WithFields(map[string]interface{}{
+		"entity_type": entityType,
+		"entity_id":   entityID,
+		"action":      action,
+	}), "AuditService", "Record")
+
+	beforeState, afterState := AuditSnapshot(before), AuditSnapshot(after)
+	if beforeState == nil && afterState == nil && action != models.AuditActionDelete {
+		err := fmt.Errorf("audit %s event has neither a before nor an after state", action)
+		logger.WithError(err).Error("Failed to record audit event")
+		return err
+	}
+	changes := diffAuditStates(beforeState, afterState)
+	if action == models.AuditActionUpdate && len(changes) == 0 {
+		return nil
+	}
+
+	event := &models.AuditEvent{
+		ActorType:     actor.Type,
+		ActorUserID:   actor.UserID,
+		ActorAPIKeyID: actor.APIKeyID,
+		EntityType:    entityType,
+		EntityID:      entityID,
+		Action:        action,
+		Changes:       changes,
+	}
+	if event.ActorType == "" {
+		event.ActorType = models.AuditActorSystem
+	}
+
+	if err := s.auditRepo.Create(event); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+	return nil
+}
+
+func (s *auditService) List(filter models.AuditFilter, offset, limit int) ([]models.AuditEvent, int64, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithFields(map[string]interface{}{
+		"entity_type": filter.EntityType,
+		"offset":      offset,
+		"limit":       limit,
+	}), "AuditService", "List")
+
+	events, total, err := s.auditRepo.List(filter, offset, limit)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+func (s *auditService) History(entityType string, entityID uint, offset, limit int) ([]models.AuditEvent, int64, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithFields(map[string]interface{}{
+		"entity_type": entityType,
+		"entity_id":   entityID,
+	}), "AuditService", "History")
+
+	events, total, err := s.auditRepo.ListByEntity(entityType, entityID, offset, limit)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+// AuditSnapshot freezes the auditable state of an entity: its JSON
+// representation as a flat map of its own fields. Handlers take one BEFORE
+// mutating a record they loaded, because the update paths modify that same
+// struct in place and a diff against it afterwards would compare the record
+// with itself.
+//
+// The JSON form is used on purpose. Every field an API client can see is in it
+// and nothing else is: a password hash or an encrypted secret is tagged
+// `json:"-"` and so can never land in the trail.
+//
+// Associations are dropped. A nested object — the preloaded owner of a lead,
+// the customer of a ticket — and a list of nested records with ids, such as a
+// task's labels, describe OTHER rows; their own trails record their changes.
+// A list of plain values or of id-less objects (a form's field definitions)
+// is the entity's own data and is kept, as are the objects in auditOwnObjects.
+func AuditSnapshot(entity interface{}) map[string]interface{} {
+	if entity == nil {
+		return nil
+	}
+	if snapshot, ok := entity.(map[string]interface{}); ok {
+		return snapshot
+	}
+	value := reflect.ValueOf(entity)
+	if value.Kind() == reflect.Ptr && value.IsNil() {
+		return nil
+	}
+
+	encoded, err := json.Marshal(entity)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil
+	}
+
+	for name, field := range fields {
+		if auditIgnoredFields[name] || (isAuditAssociation(field) && !auditOwnObjects[name]) {
+			delete(fields, name)
+		}
+	}
+	return fields
+}
+
+// isAuditAssociation reports whether a decoded JSON value is a related record
+// rather than a field of the entity itself.
+func isAuditAssociation(value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return true
+	case []interface{}:
+		for _, element := range v {
+			if object, ok := element.(map[string]interface{}); ok {
+				if _, hasID := object["id"]; hasID {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// diffAuditStates returns the fields whose value differs between the two
+// snapshots. A nil snapshot is an absent record, so a create lists every field
+// with a null before and a delete every field with a null after.
+func diffAuditStates(before, after map[string]interface{}) map[string]models.AuditChange {
+	changes := map[string]models.AuditChange{}
+	for name, newValue := range after {
+		oldValue, existed := before[name]
+		if existed && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		changes[name] = models.AuditChange{Before: oldValue, After: newValue}
+	}
+	for name, oldValue := range before {
+		if _, stillThere := after[name]; !stillThere {
+			changes[name] = models.AuditChange{Before: oldValue, After: nil}
+		}
+	}
+
+	// A field that is null on both sides of a create or delete carries no
+	// information.
+	for name, change := range changes {
+		if change.Before == nil && change.After == nil {
+			delete(changes, name)
+		}
+	}
+	return changes
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type AuditServiceTestSuite struct {
+	suite.Suite
+	mockRepo *mocks.AuditRepository
+	service  AuditService
+	actor    models.AuditActor
+}
+
+func (suite *AuditServiceTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+}
+
+func (suite *AuditServiceTestSuite) SetupTest() {
+	suite.mockRepo = new(mocks.AuditRepository)
+	suite.service = NewAuditService(suite.mockRepo)
+	userID := uint(3)
+	suite.actor = models.AuditActor{Type: models.AuditActorUser, UserID: &userID}
+}
+
+func (suite *AuditServiceTestSuite) TearDownTest() {
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+// captureEvent makes the mocked Create hand back what the service stored.
+func (suite *AuditServiceTestSuite) captureEvent() *models.AuditEvent {
+	stored := &models.AuditEvent{}
+	suite.mockRepo.On("Create", mock.AnythingOfType("*models.AuditEvent")).Return(nil).Run(func(args mock.Arguments) {
+		*stored = *args.Get(0).(*models.AuditEvent)
+	})
+	return stored
+}
+
+func (suite *AuditServiceTestSuite) TestRecord_UpdateStoresOnlyChangedFields() {
+	stored := suite.captureEvent()
+	before := &models.Lead{FirstName: "Ada", LastName: "Lovelace", Status: models.LeadStatusNew}
+	before.ID = 9
+	snapshot := AuditSnapshot(before)
+	before.Status = models.LeadStatusContacted
+
+	err := suite.service.Record(suite.actor, models.AuditEntityLead, 9, models.AuditActionUpdate, snapshot, before)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), map[string]models.AuditChange{
+		"status": {Before: string(models.LeadStatusNew), After: string(models.LeadStatusContacted)},
+	}, stored.Changes)
+	assert.Equal(suite.T(), models.AuditActorUser, stored.ActorType)
+	assert.Equal(suite.T(), uint(3), *stored.ActorUserID)
+	assert.Equal(suite.T(), uint(9), stored.EntityID)
+}
+
+func (suite *AuditServiceTestSuite) TestRecord_UpdateWithoutChangesIsNotStored() {
+	lead := &models.Lead{FirstName: "Ada"}
+
+	err := suite.service.Record(suite.actor, models.AuditEntityLead, 9, models.AuditActionUpdate, AuditSnapshot(lead), lead)
+
+	assert.NoError(suite.T(), err)
+	suite.mockRepo.AssertNotCalled(suite.T(), "Create", mock.Anything)
+}
+
+func (suite *AuditServiceTestSuite) TestRecord_CreateListsFieldsWithNullBefore() {
+	stored := suite.captureEvent()
+	label := &models.Label{Name: "Urgent", Color: "#FF0000"}
+
+	err := suite.service.Record(suite.actor, models.AuditEntityLabel, 1, models.AuditActionCreate, nil, label)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), models.AuditChange{Before: nil, After: "Urgent"}, stored.Changes["name"])
+	// Derived counts and bookkeeping columns are not the actor's doing.
+	assert.NotContains(suite.T(), stored.Changes, "task_count")
+	assert.NotContains(suite.T(), stored.Changes, "created_at")
+}
+
+// The password hash is tagged json:"-", so it cannot reach the trail even
+// though it is a column of the user.
+func (suite *AuditServiceTestSuite) TestRecord_NeverStoresHiddenFields() {
+	stored := suite.captureEvent()
+	user := &models.User{Email: "ada@example.com", Password: "$2a$10$hash", Role: models.RoleSales}
+
+	err := suite.service.Record(suite.actor, models.AuditEntityUser, 1, models.AuditActionCreate, nil, user)
+
+	assert.NoError(suite.T(), err)
+	assert.Contains(suite.T(), stored.Changes, "email")
+	for _, change := range stored.Changes {
+		assert.NotEqual(suite.T(), "$2a$10$hash", change.After)
+	}
+}
+
+// A preloaded association describes another row and has its own trail.
+func (suite *AuditServiceTestSuite) TestRecord_IgnoresAssociations() {
+	stored := suite.captureEvent()
+	task := &models.Task{
+		Title:      "Call back",
+		AssignedTo: models.User{Email: "owner@example.com"},
+		Labels:     []models.Label{{BaseModel: models.BaseModel{ID: 1}, Name: "Urgent"}},
+	}
+
+	err := suite.service.Record(suite.actor, models.AuditEntityTask, 1, models.AuditActionCreate, nil, task)
+
+	assert.NoError(suite.T(), err)
+	assert.Contains(suite.T(), stored.Changes, "title")
+	assert.NotContains(suite.T(), stored.Changes, "assigned_to")
+	assert.NotContains(suite.T(), stored.Changes, "labels")
+}
+
+func (suite *AuditServiceTestSuite) TestRecord_DeleteWithoutStateIsStored() {
+	stored := suite.captureEvent()
+
+	err := suite.service.Record(models.AuditActor{}, models.AuditEntityCustomer, 4, models.AuditActionDelete, nil, nil)
+
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), stored.Changes)
+	assert.Equal(suite.T(), models.AuditActionDelete, stored.Action)
+	assert.Equal(suite.T(), models.AuditActorSystem, stored.ActorType)
+}
+
+func (suite *AuditServiceTestSuite) TestRecord_UpdateWithoutStateIsRejected() {
+	err := suite.service.Record(suite.actor, models.AuditEntityCustomer, 4, models.AuditActionUpdate, nil, nil)
+
+	assert.Error(suite.T(), err)
+	suite.mockRepo.AssertNotCalled(suite.T(), "Create", mock.Anything)
+}
+
+func (suite *AuditServiceTestSuite) TestRecord_PropagatesRepositoryError() {
+	suite.mockRepo.On("Create", mock.Anything).Return(errors.New("db down"))
+
+	err := suite.service.Record(suite.actor, models.AuditEntityLabel, 1, models.AuditActionCreate, nil, &models.Label{Name: "x"})
+
+	assert.EqualError(suite.T(), err, "db down")
+}
+
+func (suite *AuditServiceTestSuite) TestHistory_DelegatesToRepository() {
+	events := []models.AuditEvent{{ID: 1}}
+	suite.mockRepo.On("ListByEntity", models.AuditEntityTicket, uint(5), 0, 20).Return(events, int64(1), nil)
+
+	got, total, err := suite.service.History(models.AuditEntityTicket, 5, 0, 20)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), events, got)
+	assert.Equal(suite.T(), int64(1), total)
+}
+
+func TestAuditServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(AuditServiceTestSuite))
+}
@@ -38,6 +38,12 @@ var (
 	// ErrInvalidResetToken covers every rejection of a password reset token:
 	// unknown, expired, already used, or owned by an inactive/erased user.
 	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+	// ErrTokenRevoked rejects an access token that was revoked, or whose
+	// session was, before it expired.
+	ErrTokenRevoked = errors.New("access token has been revoked")
+	// ErrSessionNotFound means the user has no live session with the ID:
+	// unknown, another user's, revoked or expired.
+	ErrSessionNotFound = errors.New("session not found")
 )
 
 // resetTokenTTL is the fixed lifetime of a password reset token.
@@ -55,6 +61,62 @@ type authService struct {
 	jwtConfig        config.JWTConfig
 	apiKeySecret     string
 	appBaseURL       string
+	// twoFactor is nil on an instance built without WithTwoFactor. Such an
+	// instance fails closed: a user with two-factor authentication enabled
+	// cannot log in through it at all.
+	twoFactor TwoFactorService
+	// ssoDomains are the email domains that must sign in through the
+	// identity provider; see WithSSOEnforcement.
+	ssoDomains []string
+	// sessionRepo and revocations are nil on an instance built without
+	// WithSessionStore, which records no sessions and revokes no access
+	// tokens.
+	sessionRepo repository.SessionRepository
+	revocations *revocationList
+	// signingKeys is nil on an instance built without WithSigningKeys, which
+	// signs and verifies access tokens with JWT_SECRET alone.
+	signingKeys SigningKeyService
+}
+
+// AuthOption configures the optional parts of NewAuthServiceWithSessions.
+type AuthOption func(*authService)
+
+// WithTwoFactor enables the two-step login: a user with two-factor
+// authentication enabled, or of a role that requires it, gets a challenge
+// from LoginWithTokens instead of tokens.
+func WithTwoFactor(twoFactor TwoFactorService) AuthOption {
+	return func(s *authService) {
+		s.twoFactor = twoFactor
+	}
+}
+
+// WithSSOEnforcement disables password login and password reset for emails
+// in the given lower-case domains, whose users sign in through the identity
+// provider instead.
+func WithSSOEnforcement(domains []string) AuthOption {
+	return func(s *authService) {
+		s.ssoDomains = domains
+	}
+}
+
+// WithSessionStore records every login as a session, and checks access
+// tokens against the revocation list so that logging out or revoking a
+// session ends its access tokens at once rather than at their expiry.
+func WithSessionStore(sessionRepo repository.SessionRepository) AuthOption {
+	return func(s *authService) {
+		s.sessionRepo = sessionRepo
+		s.revocations = newRevocationList(sessionRepo,
+			time.Duration(s.jwtConfig.RevocationCacheSeconds)*time.Second, s.accessTokenTTL())
+	}
+}
+
+// WithSigningKeys signs access tokens with the active key pair, once one is
+// activated, and verifies them with the published ones. Tokens signed with
+// JWT_SECRET are accepted for as long as SecretAccepted says.
+func WithSigningKeys(signingKeys SigningKeyService) AuthOption {
+	return func(s *authService) {
+		s.signingKeys = signingKeys
+	}
 }
 
 func NewAuthService(userRepo repository.UserRepository, apiKeyRepo repository.APIKeyRepository, jwtConfig config.JWTConfig, apiKeySecret ...string) AuthService {
@@ -84,8 +146,9 @@ func NewAuthServiceWithSessions(
 	jwtConfig config.JWTConfig,
 	appBaseURL string,
 	apiKeySecret string,
+	opts ...AuthOption,
 ) AuthService {
-	return &authService{
+	s := &authService{
 		userRepo:         userRepo,
 		apiKeyRepo:       apiKeyRepo,
 		refreshTokenRepo: refreshTokenRepo,
@@ -95,6 +158,10 @@ func NewAuthServiceWithSessions(
 		apiKeySecret:     apiKeySecret,
 		appBaseURL:       strings.TrimRight(appBaseURL, "/"),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // MaxFailedLoginAttempts is the number of failed attempts before an account is locked.
@@ -103,20 +170,40 @@ const MaxFailedLoginAttempts = 5
 // AccountLockDuration is how long an account stays locked after too many failed attempts.
 const AccountLockDuration = 15 * time.Minute
 
+// TwoFactorChallengeTTL is how long the second step of a login may take.
+const TwoFactorChallengeTTL = 5 * time.Minute
+
+// twoFactorChallengePurpose marks a challenge JWT, which must never pass as
+// an access token (nor an access token as a challenge).
+const twoFactorChallengePurpose = "two_factor"
+
+// Login issues a bare access token. It has no second step, so an account that
+// needs a second factor is refused with ErrTwoFactorRequired.
 func (s *authService) Login(email, password string) (string, error) {
 	user, err := s.authenticate(email, password)
 	if err != nil {
 		return "", err
 	}
+	if s.needsSecondFactor(user) {
+		return "", ErrTwoFactorRequired
+	}
+	s.recordLogin(user)
 	return s.GenerateJWT(user)
 }
 
 // authenticate verifies credentials and account state, maintaining the
 // constant-time and anti-enumeration behaviour: unknown email, wrong password,
 // locked account and deactivated account are indistinguishable to the caller.
+// The login is recorded by recordLogin once every factor has been checked.
 func (s *authService) authenticate(email, password string) (*models.User, error) {
 	logger := utils.LogServiceCall(utils.Logger.WithField("email", email), "AuthService", "Login")
 
+	// Decided by the domain alone, so it discloses nothing about the account.
+	if s.ssoRequired(email) {
+		logger.Warn("Login refused - the domain signs in with single sign-on")
+		return nil, ErrSSORequired
+	}
+
 	// Pre-computed dummy hash for timing attack prevention
 	// This is a bcrypt hash of "dummy-password-for-timing-attack-prevention"
 	const dummyHash = "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"
@@ -151,16 +238,7 @@ func (s *authService) authenticate(email, password string) (*models.User, error)
 	// Check password result
 	if bcryptErr != nil {
 		logger.WithField("user_id", user.ID).Warn("Login failed - invalid password")
-		// Increment failed login attempts
-		user.FailedLoginAttempts++
-		if user.FailedLoginAttempts >= MaxFailedLoginAttempts {
-			lockUntil := time.Now().Add(AccountLockDuration)
-			user.LockedUntil = &lockUntil
-			logger.WithField("user_id", user.ID).Warn("Account locked due to too many failed login attempts")
-		}
-		if updateErr := s.userRepo.Update(user); updateErr != nil {
-			logger.WithError(updateErr).Warn("Failed to update failed login attempts")
-		}
+		s.recordFailedAttempt(user)
 		return nil, errors.New("invalid credentials")
 	}
 
@@ -170,6 +248,30 @@ func (s *authService) authenticate(email, password string) (*models.User, error)
 		return nil, errors.New("invalid credentials") // Use same error message
 	}
 
+	return user, nil
+}
+
+// recordFailedAttempt counts a wrong password or second factor towards the
+// account lockout.
+func (s *authService) recordFailedAttempt(user *models.User) {
+	logger := utils.Logger.WithField("user_id", user.ID)
+	user.FailedLoginAttempts++
+	if user.FailedLoginAttempts >= MaxFailedLoginAttempts {
+		lockUntil := time.Now().Add(AccountLockDuration)
+		user.LockedUntil = &lockUntil
+		logger.Warn("Account locked due to too many failed login attempts")
+	}
+	if updateErr := s.userRepo.Update(user); updateErr != nil {
+		logger.WithError(updateErr).Warn("Failed to update failed login attempts")
+	}
+}
+
+// recordLogin resets the lockout counter and stamps the last login. It runs
+// only after the last factor: resetting the counter on a right password alone
+// would let whoever holds it guess second factors without ever being locked.
+func (s *authService) recordLogin(user *models.User) {
+	logger := utils.Logger.WithField("user_id", user.ID)
+
 	// Reset failed login attempts on successful login
 	if user.FailedLoginAttempts > 0 || user.LockedUntil != nil {
 		user.FailedLoginAttempts = 0
@@ -184,41 +286,91 @@ func (s *authService) authenticate(email, password string) (*models.User, error)
 		logger.WithError(err).Warn("Failed to update last login time")
 	}
 
-	logger.WithFields(map[string]interface{}{
-		"user_id": user.ID,
-		"role":    user.Role,
-	}).Info("User logged in successfully")
+	logger.WithField("role", user.Role).Info("User logged in successfully")
+}
 
-	return user, nil
+// ssoRequired reports whether the email must sign in through the identity
+// provider rather than with a password.
+func (s *authService) ssoRequired(email string) bool {
+	return len(s.ssoDomains) > 0 && emailInDomains(email, s.ssoDomains)
+}
+
+// needsSecondFactor reports whether the user's login takes a second step.
+func (s *authService) needsSecondFactor(user *models.User) bool {
+	if user.TwoFactorEnabled {
+		return true
+	}
+	return s.twoFactor != nil && s.twoFactor.Required(user)
 }
 
 func (s *authService) ValidateToken(tokenString string) (*models.User, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(s.jwtConfig.Secret), nil
-	})
+	user, _, err := s.AuthenticateToken(tokenString)
+	return user, err
+}
 
+func (s *authService) AuthenticateToken(tokenString string) (*models.User, *AccessTokenClaims, error) {
+	token, err := jwt.Parse(tokenString, s.accessTokenKey)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		// Safe type assertion with validation
-		userIDFloat, ok := claims["user_id"].(float64)
-		if !ok {
-			return nil, errors.New("invalid user_id in token claims")
-		}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, nil, errors.New("invalid token")
+	}
+
+	// A two-factor challenge is signed with the same key, and proves only
+	// the password.
+	if _, ok := claims["purpose"]; ok {
+		return nil, nil, errors.New("invalid token")
+	}
+
+	// Safe type assertion with validation
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return nil, nil, errors.New("invalid user_id in token claims")
+	}
 
-		userID := uint(userIDFloat)
-		return s.userRepo.GetByID(userID)
+	accessClaims := &AccessTokenClaims{}
+	accessClaims.TokenID, _ = claims["jti"].(string)
+	if sid, ok := claims["sid"].(float64); ok {
+		accessClaims.SessionID = uint(sid)
+	}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		accessClaims.ExpiresAt = exp.Time
 	}
 
-	return nil, errors.New("invalid token")
+	// A token without a jti predates revocation. It is accepted until it
+	// expires, so that an upgrade logs nobody out, but it cannot be revoked:
+	// only one that expires within an access token's lifetime is, which
+	// bounds how long such tokens live on after the upgrade.
+	if accessClaims.TokenID == "" {
+		if accessClaims.ExpiresAt.IsZero() || accessClaims.ExpiresAt.After(time.Now().Add(s.accessTokenTTL())) {
+			return nil, nil, errors.New("invalid jti in token claims")
+		}
+	} else if s.revocations != nil {
+		revoked, err := s.revocations.revoked(accessClaims.TokenID, accessClaims.SessionID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to check token revocation: %w", err)
+		}
+		if revoked {
+			return nil, nil, ErrTokenRevoked
+		}
+	}
+
+	user, err := s.userRepo.GetByID(uint(userIDFloat))
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, accessClaims, nil
 }
 
 func (s *authService) ValidateAPIKey(key string) (*models.User, error) {
+	user, _, err := s.AuthenticateAPIKey(key)
+	return user, err
+}
+
+func (s *authService) AuthenticateAPIKey(key string) (*models.User, *models.APIKey, error) {
 	// Try HMAC-SHA256 hash first (new format)
 	hmacHash := utils.HashAPIKeyHMAC(key, s.apiKeySecret)
 	apiKey, err := s.apiKeyRepo.GetByKeyHash(hmacHash)
@@ -227,18 +379,18 @@ func (s *authService) ValidateAPIKey(key string) (*models.User, error) {
 		legacyHash := utils.HashAPIKey(key)
 		apiKey, err = s.apiKeyRepo.GetByKeyHash(legacyHash)
 		if err != nil {
-			return nil, errors.New("invalid API key")
+			return nil, nil, errors.New("invalid API key")
 		}
 	}
 
 	// Check if API key is active (not revoked)
 	if !apiKey.IsActive {
-		return nil, errors.New("API key has been revoked")
+		return nil, nil, errors.New("API key has been revoked")
 	}
 
 	// Check expiration
 	if apiKey.ExpiresAt != nil && apiKey.ExpiresAt.Before(time.Now()) {
-		return nil, errors.New("API key expired")
+		return nil, nil, errors.New("API key expired")
 	}
 
 	// Defence in depth: a credential must never outlive its owner.
@@ -256,12 +408,12 @@ func (s *authService) ValidateAPIKey(key string) (*models.User, error) {
 	if err != nil {
 		utils.Logger.WithField("api_key_id", apiKey.ID).WithField("user_id", apiKey.UserID).
 			Warn("Rejected API key whose owner no longer exists")
-		return nil, errors.New("invalid API key")
+		return nil, nil, errors.New("invalid API key")
 	}
 	if !user.IsActive {
 		utils.Logger.WithField("api_key_id", apiKey.ID).WithField("user_id", apiKey.UserID).
 			Warn("Rejected API key of an inactive user")
-		return nil, errors.New("API key owner is not active")
+		return nil, nil, errors.New("API key owner is not active")
 	}
 
 	// Update last used timestamp (best effort - don't fail validation if this fails)
@@ -270,51 +422,272 @@ func (s *authService) ValidateAPIKey(key string) (*models.User, error) {
 		utils.Logger.WithError(err).WithField("api_key_id", apiKey.ID).Warn("Failed to update API key last used timestamp")
 	}
 
-	return user, nil
+	return user, apiKey, nil
+}
+
+// accessTokenKey resolves the key that verifies an access token: the
+// published key its kid names, or JWT_SECRET for a token without a kid while
+// those are still accepted.
+func (s *authService) accessTokenKey(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Header["kid"]; ok {
+		if s.signingKeys == nil {
+			return nil, errors.New("unknown signing key")
+		}
+		return s.signingKeys.VerificationKey(token)
+	}
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, errors.New("unexpected signing method")
+	}
+	if s.signingKeys != nil {
+		accepted, err := s.signingKeys.SecretAccepted()
+		if err != nil {
+			return nil, err
+		}
+		if !accepted {
+			return nil, errors.New("tokens signed with the secret are no longer accepted")
+		}
+	}
+	return []byte(s.jwtConfig.Secret), nil
 }
 
 func (s *authService) GenerateJWT(user *models.User) (string, error) {
+	return s.generateAccessToken(user, 0)
+}
+
+// generateAccessToken signs an access token of the session with the given
+// ID, or of no session for zero. Every token has a jti of its own, by which
+// it can be revoked.
+func (s *authService) generateAccessToken(user *models.User, sessionID uint) (string, error) {
+	tokenID, err := generateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"user_id": user.ID,
 		"email":   user.Email,
 		"role":    user.Role,
-		"exp":     time.Now().Add(time.Hour * time.Duration(s.jwtConfig.ExpiryHours)).Unix(),
+		"jti":     tokenID,
+		"iat":     now.Unix(),
+		"exp":     now.Add(s.accessTokenTTL()).Unix(),
+	}
+	if sessionID != 0 {
+		claims["sid"] = sessionID
 	}
 
+	if s.signingKeys != nil {
+		signed, err := s.signingKeys.Sign(claims)
+		if !errors.Is(err, ErrNoSigningKey) {
+			return signed, err
+		}
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(s.jwtConfig.Secret))
 }
 
-func (s *authService) LoginWithTokens(email, password string) (*AuthTokens, error) {
+// accessTokenTTL is the lifetime of an access token, JWT_EXPIRY_HOURS.
+func (s *authService) accessTokenTTL() time.Duration {
+	return time.Hour * time.Duration(s.jwtConfig.ExpiryHours)
+}
+
+func (s *authService) LoginWithTokens(email, password string, client ClientInfo) (*AuthTokens, error) {
 	user, err := s.authenticate(email, password)
 	if err != nil {
 		return nil, err
 	}
+	return s.passedPassword(user, client)
+}
 
-	accessToken, err := s.GenerateJWT(user)
-	if err != nil {
-		return nil, err
+// LoginRegistered logs in a user who has just registered with a password.
+// Registration proves the password but not a second factor, so a user whose
+// role requires one gets a setup challenge, exactly as at login.
+func (s *authService) LoginRegistered(user *models.User, client ClientInfo) (*AuthTokens, error) {
+	return s.passedPassword(user, client)
+}
+
+// passedPassword takes a user whose password has been checked to the second
+// step of the login if they need one, and into a session otherwise.
+func (s *authService) passedPassword(user *models.User, client ClientInfo) (*AuthTokens, error) {
+	if s.needsSecondFactor(user) {
+		if s.twoFactor == nil {
+			utils.Logger.WithField("user_id", user.ID).
+				Warn("Login refused - two-factor authentication is enabled but not wired")
+			return nil, ErrTwoFactorRequired
+		}
+		challenge, err := s.generateTwoFactorChallenge(user)
+		if err != nil {
+			return nil, err
+		}
+		utils.Logger.WithField("user_id", user.ID).Info("Password accepted, second factor pending")
+		return &AuthTokens{
+			TwoFactorChallenge:     challenge,
+			TwoFactorSetupRequired: !user.TwoFactorEnabled,
+		}, nil
+	}
+
+	s.recordLogin(user)
+	return s.startSession(user, client)
+}
+
+// LoginWithSSO logs in a user the identity provider has authenticated. The
+// provider stands in for both factors, so neither the password nor the local
+// second factor is asked for, and the lockout, which guards the password,
+// does not apply.
+func (s *authService) LoginWithSSO(user *models.User, client ClientInfo) (*AuthTokens, error) {
+	if !user.IsActive {
+		return nil, ErrSSOAccountNotAllowed
 	}
+	s.recordLogin(user)
+	return s.startSession(user, client)
+}
 
-	tokens := &AuthTokens{AccessToken: accessToken, User: user}
+// startSession issues the access token, and a refresh token when the session
+// store is wired, of a user who has just logged in from client.
+func (s *authService) startSession(user *models.User, client ClientInfo) (*AuthTokens, error) {
+	tokens := &AuthTokens{User: user}
 
 	// Refresh tokens are issued only when the session store is wired. If
 	// persisting one fails, the login still succeeds with a JWT-only session:
 	// the response contract marks refresh_token as optional, and locking a
 	// user out because the token table hiccuped would be the worse trade.
+	var sessionID uint
 	if s.refreshTokenRepo != nil {
-		refreshToken, err := s.issueRefreshToken(user.ID)
+		session, err := s.recordSession(user.ID, client)
+		if err == nil {
+			sessionID = session.ID
+			tokens.RefreshToken, err = s.issueRefreshToken(user.ID, sessionID)
+		}
 		if err != nil {
 			utils.Logger.WithError(err).WithField("user_id", user.ID).
 				Warn("Failed to issue refresh token at login; continuing with access token only")
-		} else {
-			tokens.RefreshToken = refreshToken
+			sessionID = 0
+		}
+	}
+
+	accessToken, err := s.generateAccessToken(user, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	tokens.AccessToken = accessToken
+	return tokens, nil
+}
+
+// generateTwoFactorChallenge signs the proof that the user passed the first
+// step of the login. It is stateless: the second step is bounded by the
+// challenge's lifetime and by the lockout counter, which wrong codes feed.
+// It is always signed with JWT_SECRET: only this service reads challenges,
+// and a verifier holding just the published keys cannot mistake one for an
+// access token.
+func (s *authService) generateTwoFactorChallenge(user *models.User) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub":     strconv.FormatUint(uint64(user.ID), 10),
+		"purpose": twoFactorChallengePurpose,
+		"setup":   !user.TwoFactorEnabled,
+		"iat":     now.Unix(),
+		"exp":     now.Add(TwoFactorChallengeTTL).Unix(),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.jwtConfig.Secret))
+}
+
+// parseTwoFactorChallenge returns the user a challenge was issued to, and
+// whether it was issued for enrollment. The account must still be able to
+// log in; every rejection is ErrInvalidTwoFactorChallenge, bar the lockout.
+func (s *authService) parseTwoFactorChallenge(challenge string) (*models.User, bool, error) {
+	if s.twoFactor == nil || challenge == "" {
+		return nil, false, ErrInvalidTwoFactorChallenge
+	}
+
+	token, err := jwt.Parse(challenge, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return []byte(s.jwtConfig.Secret), nil
+	})
+	if err != nil || !token.Valid {
+		return nil, false, ErrInvalidTwoFactorChallenge
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != twoFactorChallengePurpose {
+		return nil, false, ErrInvalidTwoFactorChallenge
+	}
+	subject, _ := claims["sub"].(string)
+	userID, err := strconv.ParseUint(subject, 10, 64)
+	if err != nil {
+		return nil, false, ErrInvalidTwoFactorChallenge
+	}
+	setup, _ := claims["setup"].(bool)
+
+	user, err := s.userRepo.GetByID(uint(userID))
+	if err != nil || !user.IsActive {
+		return nil, false, ErrInvalidTwoFactorChallenge
+	}
+	if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		utils.Logger.WithField("user_id", user.ID).Warn("Second factor refused - account locked")
+		return nil, false, errors.New("account is locked due to too many failed login attempts, please try again later")
+	}
+	return user, setup, nil
+}
+
+// CompleteTwoFactorLogin checks the second factor against the account as it
+// is now, not as it was when the challenge was issued: a user who enrolled in
+// the meantime must present a code, and one whose second factor was reset and
+// is no longer required must log in again.
+func (s *authService) CompleteTwoFactorLogin(challenge, code string, client ClientInfo) (*AuthTokens, error) {
+	user, setup, err := s.parseTwoFactorChallenge(challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	var recoveryCodes []string
+	switch {
+	case user.TwoFactorEnabled:
+		err = s.twoFactor.CheckCode(user, code)
+	case setup:
+		recoveryCodes, err = s.twoFactor.Verify(user.ID, code)
+	default:
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+	if err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			utils.Logger.WithField("user_id", user.ID).Warn("Login failed - invalid second factor")
+			s.recordFailedAttempt(user)
+		}
+		return nil, err
+	}
+
+	if recoveryCodes != nil {
+		// Enrollment changed the row under us; saving the stale copy in
+		// recordLogin would switch two-factor authentication off again.
+		if user, err = s.userRepo.GetByID(user.ID); err != nil {
+			return nil, fmt.Errorf("failed to reload user: %w", err)
 		}
 	}
 
+	s.recordLogin(user)
+	tokens, err := s.startSession(user, client)
+	if err != nil {
+		return nil, err
+	}
+	tokens.RecoveryCodes = recoveryCodes
 	return tokens, nil
 }
 
+// BeginTwoFactorSetup starts the enrollment of a user who holds a setup
+// challenge. The challenge stays valid for the CompleteTwoFactorLogin that
+// confirms the enrollment.
+func (s *authService) BeginTwoFactorSetup(challenge string) (*models.TwoFactorSetup, error) {
+	user, setup, err := s.parseTwoFactorChallenge(challenge)
+	if err != nil {
+		return nil, err
+	}
+	if !setup || user.TwoFactorEnabled {
+		return nil, ErrInvalidTwoFactorChallenge
+	}
+	return s.twoFactor.Setup(user.ID)
+}
+
 func (s *authService) GenerateTokens(user *models.User) (*AuthTokens, error) {
 	accessToken, err := s.GenerateJWT(user)
 	if err != nil {
@@ -350,24 +723,20 @@ func generateOpaqueToken() (string, error) {
 	return base64.RawURLEncoding.EncodeToString(buf), nil
 }
 
-// issueRefreshToken mints, hashes and persists a refresh token for the user,
-// returning the raw value for the client. Lifetime comes from
-// REFRESH_TOKEN_EXPIRY_DAYS (config JWT.RefreshTokenDays).
-func (s *authService) issueRefreshToken(userID uint) (string, error) {
+// issueRefreshToken mints, hashes and persists a refresh token of the
+// session for the user, returning the raw value for the client. Lifetime
+// comes from REFRESH_TOKEN_EXPIRY_DAYS (config JWT.RefreshTokenDays).
+func (s *authService) issueRefreshToken(userID, sessionID uint) (string, error) {
 	raw, err := generateOpaqueToken()
 	if err != nil {
 		return "", err
 	}
 
-	days := s.jwtConfig.RefreshTokenDays
-	if days <= 0 {
-		days = 30
-	}
-
 	token := &models.RefreshToken{
 		UserID:    userID,
+		SessionID: sessionID,
 		TokenHash: hashOpaqueToken(raw, s.sessionTokenSecret()),
-		ExpiresAt: time.Now().Add(time.Duration(days) * 24 * time.Hour),
+		ExpiresAt: time.Now().Add(s.refreshTokenTTL()),
 	}
 	if err := s.refreshTokenRepo.Create(token); err != nil {
 		return "", fmt.Errorf("failed to store refresh token: %w", err)
@@ -375,11 +744,21 @@ func (s *authService) issueRefreshToken(userID uint) (string, error) {
 	return raw, nil
 }
 
+// refreshTokenTTL is the lifetime of a refresh token, and so the time a
+// session lasts without use.
+func (s *authService) refreshTokenTTL() time.Duration {
+	days := s.jwtConfig.RefreshTokenDays
+	if days <= 0 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
 // RefreshAccessToken validates the presented refresh token and rotates it:
 // the presented token is revoked and a fresh JWT + refresh token are issued.
 // Every rejection maps to ErrInvalidRefreshToken so callers cannot learn
 // whether the token was unknown, revoked, expired, or orphaned.
-func (s *authService) RefreshAccessToken(refreshToken string) (*AuthTokens, error) {
+func (s *authService) RefreshAccessToken(refreshToken string, client ClientInfo) (*AuthTokens, error) {
 	if s.refreshTokenRepo == nil || refreshToken == "" {
 		return nil, fmt.Errorf("refresh not available: %w", ErrInvalidRefreshToken)
 	}
@@ -421,12 +800,17 @@ func (s *authService) RefreshAccessToken(refreshToken string) (*AuthTokens, erro
 		return nil, fmt.Errorf("failed to revoke refresh token during rotation: %w", err)
 	}
 
-	newRefreshToken, err := s.issueRefreshToken(user.ID)
+	sessionID, err := s.continueSession(stored, client)
 	if err != nil {
 		return nil, err
 	}
 
-	accessToken, err := s.GenerateJWT(user)
+	newRefreshToken, err := s.issueRefreshToken(user.ID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.generateAccessToken(user, sessionID)
 	if err != nil {
 		return nil, err
 	}
@@ -451,7 +835,10 @@ func (s *authService) InvalidateRefreshToken(refreshToken string) error {
 // authenticated caller cannot use logout to revoke someone else's session.
 // Unknown or foreign tokens are ignored: logout is idempotent and discloses
 // nothing about token validity.
-func (s *authService) Logout(userID uint, refreshToken string) error {
+func (s *authService) Logout(userID uint, refreshToken string, current *AccessTokenClaims) error {
+	if err := s.revokeAccessToken(userID, current); err != nil {
+		return err
+	}
 	if s.refreshTokenRepo == nil {
 		return nil
 	}
@@ -460,6 +847,12 @@ func (s *authService) Logout(userID uint, refreshToken string) error {
 		if err := s.refreshTokenRepo.RevokeAllForUser(userID); err != nil {
 			return fmt.Errorf("failed to revoke sessions: %w", err)
 		}
+		if s.sessionRepo != nil {
+			if err := s.sessionRepo.RevokeAllForUser(userID); err != nil {
+				return fmt.Errorf("failed to revoke sessions: %w", err)
+			}
+			s.revocations.invalidate()
+		}
 		utils.Logger.WithField("user_id", userID).Info("All sessions revoked at logout")
 		return nil
 	}
@@ -477,6 +870,13 @@ func (s *authService) Logout(userID uint, refreshToken string) error {
 			Warn("Logout presented a refresh token belonging to another user; ignoring")
 		return nil
 	}
+	if stored.SessionID != 0 && s.sessionRepo != nil {
+		if err := s.sessionRepo.Revoke(stored.SessionID); err != nil {
+			return fmt.Errorf("failed to revoke session: %w", err)
+		}
+		s.revocations.invalidate()
+		return nil
+	}
 	if err := s.refreshTokenRepo.RevokeByTokenHash(hash); err != nil {
 		return fmt.Errorf("failed to revoke session: %w", err)
 	}
@@ -528,6 +928,14 @@ func (s *authService) revokeAllSessionsBestEffort(userID uint, reason string) {
 		utils.Logger.WithError(err).WithField("user_id", userID).WithField("reason", reason).
 			Error("Failed to revoke refresh tokens after credential change")
 	}
+	if s.sessionRepo == nil {
+		return
+	}
+	if err := s.sessionRepo.RevokeAllForUser(userID); err != nil {
+		utils.Logger.WithError(err).WithField("user_id", userID).WithField("reason", reason).
+			Error("Failed to revoke sessions after credential change")
+	}
+	s.revocations.invalidate()
 }
 
 // RequestPasswordReset starts the reset flow. It NEVER discloses whether the
@@ -555,6 +963,10 @@ func (s *authService) RequestPasswordReset(email string) error {
 		logger.WithField("user_id", user.ID).Info("Password reset requested for inactive account; ignored")
 		return nil
 	}
+	if s.ssoRequired(user.Email) {
+		logger.WithField("user_id", user.ID).Info("Password reset requested for a single sign-on account; ignored")
+		return nil
+	}
 
 	// Only the newest link should work; stale outstanding tokens are spent.
 	if err := s.resetTokenRepo.InvalidateAllForUser(user.ID); err != nil {
@@ -617,7 +1029,7 @@ func (s *authService) ConfirmPasswordReset(token, newPassword string) error {
 	}
 
 	user, err := s.userRepo.GetByID(stored.UserID)
-	if err != nil || !user.IsActive {
+	if err != nil || !user.IsActive || s.ssoRequired(user.Email) {
 		return fmt.Errorf("reset token rejected: %w", ErrInvalidResetToken)
 	}
 
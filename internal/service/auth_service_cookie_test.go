@@ -37,7 +37,7 @@ func TestAuthService_LoginWithTokens(t *testing.T) {
 		mockUserRepo.On("GetByEmail", "test@example.com").Return(activeUser, nil)
 		mockUserRepo.On("UpdateLastLogin", uint(1)).Return(nil)
 
-		tokens, err := authService.LoginWithTokens("test@example.com", "password123")
+		tokens, err := authService.LoginWithTokens("test@example.com", "password123", ClientInfo{})
 
 		assert.NoError(t, err)
 		assert.NotNil(t, tokens)
@@ -54,7 +54,7 @@ func TestAuthService_LoginWithTokens(t *testing.T) {
 		mockUserRepo.On("GetByEmail", "test@example.com").Return(activeUser, nil)
 		mockUserRepo.On("Update", mock.AnythingOfType("*models.User")).Return(nil)
 
-		tokens, err := authService.LoginWithTokens("test@example.com", "wrongpassword")
+		tokens, err := authService.LoginWithTokens("test@example.com", "wrongpassword", ClientInfo{})
 
 		assert.Error(t, err)
 		assert.Nil(t, tokens)
@@ -76,7 +76,7 @@ func TestAuthService_LoginWithTokens(t *testing.T) {
 
 		mockUserRepo.On("GetByEmail", "inactive@example.com").Return(inactiveUser, nil)
 
-		tokens, err := authService.LoginWithTokens("inactive@example.com", "password123")
+		tokens, err := authService.LoginWithTokens("inactive@example.com", "password123", ClientInfo{})
 
 		assert.Error(t, err)
 		assert.Nil(t, tokens)
@@ -98,7 +98,7 @@ func TestAuthService_RefreshAccessToken(t *testing.T) {
 		mockAPIKeyRepo := &mocks.APIKeyRepository{}
 		authService := NewAuthService(mockUserRepo, mockAPIKeyRepo, jwtConfig)
 
-		tokens, err := authService.RefreshAccessToken("some-refresh-token")
+		tokens, err := authService.RefreshAccessToken("some-refresh-token", ClientInfo{})
 
 		assert.Error(t, err)
 		assert.Nil(t, tokens)
@@ -91,6 +91,16 @@ func (m *MockUserRepository) CountSearch(query string) (int64, error) {
 	return args.Get(0).(int64), args.Error(1)
 }
 
+func (m *MockUserRepository) ListFiltered(filter models.UserFilter, offset, limit int) ([]models.User, int64, error) {
+	args := m.Called(filter, offset, limit)
+	return args.Get(0).([]models.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepository) StreamFiltered(filter models.UserFilter, fn func([]models.User) error) error {
+	args := m.Called(filter, fn)
+	return args.Error(0)
+}
+
 func (m *MockUserRepository) WithTx(tx *gorm.DB) repository.UserRepository {
 	return m
 }
@@ -140,6 +150,19 @@ func (m *MockAPIKeyRepository) UpdateLastUsed(id uint) error {
 	return args.Error(0)
 }
 
+func (m *MockAPIKeyRepository) CreateUsage(usage *models.APIKeyUsage) error {
+	args := m.Called(usage)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) ListUsage(apiKeyID uint, offset, limit int) ([]models.APIKeyUsage, int64, error) {
+	args := m.Called(apiKeyID, offset, limit)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]models.APIKeyUsage), args.Get(1).(int64), args.Error(2)
+}
+
 func (m *MockAPIKeyRepository) WithTx(tx *gorm.DB) repository.APIKeyRepository {
 	return m
 }
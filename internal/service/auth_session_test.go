@@ -97,7 +97,7 @@ func TestAuthService_RefreshAccessToken_RotatesToken(t *testing.T) {
 		return rt.UserID == 1 && rt.TokenHash != hash && rt.TokenHash != ""
 	})).Return(nil)
 
-	tokens, err := d.svc.RefreshAccessToken(raw)
+	tokens, err := d.svc.RefreshAccessToken(raw, ClientInfo{})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, tokens)
@@ -126,7 +126,7 @@ func TestAuthService_RefreshAccessToken_UnknownRevokedOrExpired(t *testing.T) {
 	hash := hashOpaqueToken("dead-token", sessionTestSecret)
 	d.refreshRepo.On("GetByTokenHash", hash).Return(nil, gorm.ErrRecordNotFound)
 
-	tokens, err := d.svc.RefreshAccessToken("dead-token")
+	tokens, err := d.svc.RefreshAccessToken("dead-token", ClientInfo{})
 
 	assert.Nil(t, tokens)
 	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
@@ -150,7 +150,7 @@ func TestAuthService_RefreshAccessToken_InactiveOwnerRejectedAndRevoked(t *testi
 	d.userRepo.On("GetByID", uint(2)).Return(inactive, nil)
 	d.refreshRepo.On("RevokeByTokenHash", hash).Return(nil)
 
-	tokens, err := d.svc.RefreshAccessToken(raw)
+	tokens, err := d.svc.RefreshAccessToken(raw, ClientInfo{})
 
 	assert.Nil(t, tokens)
 	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
@@ -171,7 +171,7 @@ func TestAuthService_LoginWithTokens_IssuesRefreshToken(t *testing.T) {
 		return rt.UserID == 1
 	})).Return(nil)
 
-	tokens, err := d.svc.LoginWithTokens(user.Email, "Password123!")
+	tokens, err := d.svc.LoginWithTokens(user.Email, "Password123!", ClientInfo{})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, tokens)
@@ -187,7 +187,7 @@ func TestAuthService_Logout(t *testing.T) {
 		d := newSessionAuthService(t)
 		d.refreshRepo.On("RevokeAllForUser", uint(42)).Return(nil)
 
-		err := d.svc.Logout(42, "")
+		err := d.svc.Logout(42, "", nil)
 
 		assert.NoError(t, err)
 		d.refreshRepo.AssertCalled(t, "RevokeAllForUser", uint(42))
@@ -201,7 +201,7 @@ func TestAuthService_Logout(t *testing.T) {
 		d.refreshRepo.On("GetByTokenHash", hash).Return(stored, nil)
 		d.refreshRepo.On("RevokeByTokenHash", hash).Return(nil)
 
-		err := d.svc.Logout(42, raw)
+		err := d.svc.Logout(42, raw, nil)
 
 		assert.NoError(t, err)
 		d.refreshRepo.AssertCalled(t, "RevokeByTokenHash", hash)
@@ -214,7 +214,7 @@ func TestAuthService_Logout(t *testing.T) {
 		stored := &models.RefreshToken{UserID: 7, TokenHash: hash, ExpiresAt: time.Now().Add(time.Hour)}
 		d.refreshRepo.On("GetByTokenHash", hash).Return(stored, nil)
 
-		err := d.svc.Logout(42, raw)
+		err := d.svc.Logout(42, raw, nil)
 
 		assert.NoError(t, err)
 		d.refreshRepo.AssertNotCalled(t, "RevokeByTokenHash", mock.Anything)
@@ -225,7 +225,7 @@ func TestAuthService_Logout(t *testing.T) {
 		hash := hashOpaqueToken("gone", sessionTestSecret)
 		d.refreshRepo.On("GetByTokenHash", hash).Return(nil, gorm.ErrRecordNotFound)
 
-		err := d.svc.Logout(42, "gone")
+		err := d.svc.Logout(42, "gone", nil)
 
 		assert.NoError(t, err)
 	})
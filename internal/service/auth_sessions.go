@@ -0,0 +1,129 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+)
+
+// maxUserAgentLength is the size of the session's user_agent column.
+const maxUserAgentLength = 512
+
+// recordSession stores a new session of the user, started from client. On an
+// instance without the session store it returns a session with ID zero.
+func (s *authService) recordSession(userID uint, client ClientInfo) (*models.Session, error) {
+	if s.sessionRepo == nil {
+		return &models.Session{UserID: userID}, nil
+	}
+	userAgent := client.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	now := time.Now()
+	session := &models.Session{
+		UserID:     userID,
+		Device:     utils.DescribeUserAgent(client.UserAgent),
+		UserAgent:  userAgent,
+		IPAddress:  client.IPAddress,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(s.refreshTokenTTL()),
+	}
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, fmt.Errorf("failed to store session: %w", err)
+	}
+	return session, nil
+}
+
+// continueSession records the use of the presented refresh token's session
+// by a rotation from client, and returns the session its successor belongs
+// to. A token that predates sessions starts one.
+func (s *authService) continueSession(stored *models.RefreshToken, client ClientInfo) (uint, error) {
+	if s.sessionRepo == nil {
+		return stored.SessionID, nil
+	}
+	logger := utils.Logger.WithField("user_id", stored.UserID)
+
+	if stored.SessionID == 0 {
+		session, err := s.recordSession(stored.UserID, client)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to start a session for a refresh token issued before sessions")
+			return 0, nil
+		}
+		return session.ID, nil
+	}
+
+	err := s.sessionRepo.Touch(stored.SessionID, client.IPAddress, time.Now().Add(s.refreshTokenTTL()))
+	switch {
+	case err == nil:
+	case isNotFound(err):
+		// Revoked while the rotation was under way.
+		return 0, fmt.Errorf("session revoked: %w", ErrInvalidRefreshToken)
+	default:
+		// The last use is shown to the user but guards nothing.
+		logger.WithError(err).WithField("session_id", stored.SessionID).Warn("Failed to record session use")
+	}
+	return stored.SessionID, nil
+}
+
+// revokeAccessToken puts the access token the request was made with on the
+// revocation list. A nil token, as of a request made with an API key, a
+// token without a jti, which predates revocation and runs out on its own, and
+// an instance without the session store are no-ops.
+func (s *authService) revokeAccessToken(userID uint, token *AccessTokenClaims) error {
+	if token == nil || token.TokenID == "" || s.sessionRepo == nil {
+		return nil
+	}
+	expiresAt := token.ExpiresAt
+	if expiresAt.IsZero() {
+		expiresAt = time.Now().Add(s.accessTokenTTL())
+	}
+	if err := s.sessionRepo.RevokeToken(&models.RevokedToken{
+		JTI:       token.TokenID,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return fmt.Errorf("failed to revoke access token: %w", err)
+	}
+	s.revocations.invalidate()
+	return nil
+}
+
+func (s *authService) ListSessions(userID, currentSessionID uint) ([]models.Session, error) {
+	if s.sessionRepo == nil {
+		return []models.Session{}, nil
+	}
+	sessions, err := s.sessionRepo.ListActive(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	for i := range sessions {
+		sessions[i].Current = currentSessionID != 0 && sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+func (s *authService) RevokeSession(userID, sessionID uint) error {
+	if s.sessionRepo == nil {
+		return ErrSessionNotFound
+	}
+	session, err := s.sessionRepo.GetByID(sessionID)
+	if err != nil {
+		if isNotFound(err) {
+			return ErrSessionNotFound
+		}
+		return fmt.Errorf("failed to load session: %w", err)
+	}
+	// Another user's session reads as a missing one, so the endpoint cannot
+	// be used to probe for session IDs.
+	if session.UserID != userID || session.RevokedAt != nil || session.ExpiresAt.Before(time.Now()) {
+		return ErrSessionNotFound
+	}
+	if err := s.sessionRepo.Revoke(sessionID); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	s.revocations.invalidate()
+	utils.Logger.WithField("user_id", userID).WithField("session_id", sessionID).Info("Session revoked")
+	return nil
+}
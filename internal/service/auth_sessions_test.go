@@ -0,0 +1,247 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const (
+	sessionsTestSecret   = "sessions-test-secret"
+	sessionsTestPassword = "Str0ng!Passw0rd"
+)
+
+var (
+	laptop = ClientInfo{
+		UserAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:127.0) Gecko/20100101 Firefox/127.0",
+		IPAddress: "192.0.2.10",
+	}
+	phone = ClientInfo{
+		UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1",
+		IPAddress: "198.51.100.20",
+	}
+)
+
+// AuthSessionsSuite runs logins, rotations and revocations against real
+// repositories on an in-memory SQLite database.
+type AuthSessionsSuite struct {
+	suite.Suite
+	db   *gorm.DB
+	user *models.User
+	auth AuthService
+}
+
+func TestAuthSessionsSuite(t *testing.T) {
+	suite.Run(t, new(AuthSessionsSuite))
+}
+
+func (s *AuthSessionsSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "error", Format: "json"})
+}
+
+func (s *AuthSessionsSuite) SetupTest() {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	s.Require().NoError(err)
+	s.Require().NoError(db.AutoMigrate(&models.User{}, &models.RefreshToken{}, &models.Session{}, &models.RevokedToken{}))
+	s.db = db
+
+	s.user = s.createUser("lin@example.com")
+	s.auth = s.newAuthService()
+}
+
+func (s *AuthSessionsSuite) newAuthService() AuthService {
+	return NewAuthServiceWithSessions(repository.NewUserRepository(s.db), nil,
+		repository.NewRefreshTokenRepository(s.db), nil, nil,
+		config.JWTConfig{Secret: sessionsTestSecret, ExpiryHours: 1, RefreshTokenDays: 30, RevocationCacheSeconds: 30},
+		"http://localhost:5173", sessionsTestSecret, WithSessionStore(repository.NewSessionRepository(s.db)))
+}
+
+func (s *AuthSessionsSuite) createUser(email string) *models.User {
+	user := &models.User{Email: email, FirstName: "Lin", LastName: "Ko", Role: models.RoleSales, IsActive: true}
+	s.Require().NoError(user.SetPassword(sessionsTestPassword))
+	s.Require().NoError(s.db.Create(user).Error)
+	return user
+}
+
+func (s *AuthSessionsSuite) login(client ClientInfo) *AuthTokens {
+	tokens, err := s.auth.LoginWithTokens(s.user.Email, sessionsTestPassword, client)
+	s.Require().NoError(err)
+	s.Require().NotEmpty(tokens.RefreshToken)
+	return tokens
+}
+
+func (s *AuthSessionsSuite) claims(tokens *AuthTokens) *AccessTokenClaims {
+	_, claims, err := s.auth.AuthenticateToken(tokens.AccessToken)
+	s.Require().NoError(err)
+	return claims
+}
+
+func (s *AuthSessionsSuite) TestLoginRecordsSession() {
+	tokens := s.login(laptop)
+	claims := s.claims(tokens)
+	s.NotEmpty(claims.TokenID)
+	s.NotZero(claims.SessionID)
+	s.WithinDuration(time.Now().Add(time.Hour), claims.ExpiresAt, time.Minute)
+
+	s.login(phone)
+	sessions, err := s.auth.ListSessions(s.user.ID, claims.SessionID)
+	s.Require().NoError(err)
+	s.Require().Len(sessions, 2)
+	var current *models.Session
+	for i := range sessions {
+		if sessions[i].Current {
+			s.Nil(current, "only one session is current")
+			current = &sessions[i]
+		}
+	}
+	s.Require().NotNil(current)
+	s.Equal(claims.SessionID, current.ID)
+	s.Equal("Firefox on Linux", current.Device)
+	s.Equal(laptop.IPAddress, current.IPAddress)
+}
+
+func (s *AuthSessionsSuite) TestRefreshContinuesTheSession() {
+	tokens := s.login(laptop)
+	sessionID := s.claims(tokens).SessionID
+
+	moved := ClientInfo{UserAgent: laptop.UserAgent, IPAddress: "203.0.113.5"}
+	refreshed, err := s.auth.RefreshAccessToken(tokens.RefreshToken, moved)
+	s.Require().NoError(err)
+	s.Equal(sessionID, s.claims(refreshed).SessionID)
+	s.NotEqual(s.claims(tokens).TokenID, s.claims(refreshed).TokenID)
+
+	sessions, err := s.auth.ListSessions(s.user.ID, 0)
+	s.Require().NoError(err)
+	s.Require().Len(sessions, 1, "rotation does not start another session")
+	s.Equal(moved.IPAddress, sessions[0].IPAddress)
+}
+
+func (s *AuthSessionsSuite) TestRevokeSessionEndsItsTokensAtOnce() {
+	stolen := s.login(phone)
+	kept := s.login(laptop)
+	// Both tokens have been checked, so the revocation list is cached.
+	stolenSession := s.claims(stolen).SessionID
+	s.claims(kept)
+
+	s.Require().NoError(s.auth.RevokeSession(s.user.ID, stolenSession))
+
+	_, _, err := s.auth.AuthenticateToken(stolen.AccessToken)
+	s.ErrorIs(err, ErrTokenRevoked)
+	_, err = s.auth.RefreshAccessToken(stolen.RefreshToken, phone)
+	s.ErrorIs(err, ErrInvalidRefreshToken)
+
+	_, _, err = s.auth.AuthenticateToken(kept.AccessToken)
+	s.NoError(err, "the other session is untouched")
+	sessions, err := s.auth.ListSessions(s.user.ID, 0)
+	s.Require().NoError(err)
+	s.Len(sessions, 1)
+
+	s.ErrorIs(s.auth.RevokeSession(s.user.ID, stolenSession), ErrSessionNotFound, "already revoked")
+}
+
+func (s *AuthSessionsSuite) TestRevokeSessionOfAnotherUser() {
+	tokens := s.login(laptop)
+	other := s.createUser("someone@example.com")
+
+	err := s.auth.RevokeSession(other.ID, s.claims(tokens).SessionID)
+	s.ErrorIs(err, ErrSessionNotFound)
+	_, _, err = s.auth.AuthenticateToken(tokens.AccessToken)
+	s.NoError(err)
+}
+
+func (s *AuthSessionsSuite) TestLogout() {
+	current := s.login(laptop)
+	other := s.login(phone)
+
+	s.Require().NoError(s.auth.Logout(s.user.ID, current.RefreshToken, s.claims(current)))
+	_, _, err := s.auth.AuthenticateToken(current.AccessToken)
+	s.ErrorIs(err, ErrTokenRevoked)
+	_, _, err = s.auth.AuthenticateToken(other.AccessToken)
+	s.NoError(err, "logging out with a refresh token ends only its session")
+
+	s.Require().NoError(s.auth.Logout(s.user.ID, "", s.claims(other)))
+	_, _, err = s.auth.AuthenticateToken(other.AccessToken)
+	s.ErrorIs(err, ErrTokenRevoked)
+	sessions, err := s.auth.ListSessions(s.user.ID, 0)
+	s.Require().NoError(err)
+	s.Empty(sessions)
+}
+
+func (s *AuthSessionsSuite) TestLogoutRevokesATokenOutsideAnySession() {
+	token, err := s.auth.GenerateJWT(s.user)
+	s.Require().NoError(err)
+	_, claims, err := s.auth.AuthenticateToken(token)
+	s.Require().NoError(err)
+	s.Zero(claims.SessionID)
+
+	s.Require().NoError(s.auth.Logout(s.user.ID, "", claims))
+	_, _, err = s.auth.AuthenticateToken(token)
+	s.ErrorIs(err, ErrTokenRevoked)
+}
+
+func (s *AuthSessionsSuite) TestChangePasswordEndsEverySession() {
+	tokens := s.login(laptop)
+
+	s.Require().NoError(s.auth.ChangePassword(s.user.ID, sessionsTestPassword, "An0ther!Passw0rd"))
+	_, _, err := s.auth.AuthenticateToken(tokens.AccessToken)
+	s.ErrorIs(err, ErrTokenRevoked)
+}
+
+func (s *AuthSessionsSuite) TestRevocationThroughAnotherInstance() {
+	tokens := s.login(laptop)
+	other := s.newAuthService()
+	now := time.Now()
+	other.(*authService).revocations.now = func() time.Time { return now }
+	_, _, err := other.AuthenticateToken(tokens.AccessToken)
+	s.Require().NoError(err)
+
+	s.Require().NoError(s.auth.RevokeSession(s.user.ID, s.claims(tokens).SessionID))
+
+	_, _, err = other.AuthenticateToken(tokens.AccessToken)
+	s.NoError(err, "the other instance's copy of the list is still fresh")
+	now = now.Add(31 * time.Second)
+	_, _, err = other.AuthenticateToken(tokens.AccessToken)
+	s.ErrorIs(err, ErrTokenRevoked)
+}
+
+// A token issued before access tokens carried a jti keeps working until it
+// expires, so that upgrading logs nobody out.
+func (s *AuthSessionsSuite) TestTokenWithoutJTIIsAcceptedUntilItExpires() {
+	legacy := func(claims jwt.MapClaims) string {
+		claims["user_id"] = s.user.ID
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(sessionsTestSecret))
+		s.Require().NoError(err)
+		return token
+	}
+	token := legacy(jwt.MapClaims{"exp": time.Now().Add(30 * time.Minute).Unix()})
+
+	user, claims, err := s.auth.AuthenticateToken(token)
+	s.Require().NoError(err)
+	s.Equal(s.user.ID, user.ID)
+	s.Empty(claims.TokenID)
+
+	// Logging out with it revokes nothing by an empty jti, which would
+	// refuse every other such token.
+	s.Require().NoError(s.auth.Logout(s.user.ID, "", claims))
+	var revoked int64
+	s.Require().NoError(s.db.Model(&models.RevokedToken{}).Count(&revoked).Error)
+	s.Zero(revoked)
+	_, err = s.auth.ValidateToken(legacy(jwt.MapClaims{"exp": time.Now().Add(time.Minute).Unix()}))
+	s.NoError(err)
+
+	_, err = s.auth.ValidateToken(legacy(jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()}))
+	s.Error(err, "expired")
+	_, err = s.auth.ValidateToken(legacy(jwt.MapClaims{}))
+	s.Error(err, "no expiry")
+	_, err = s.auth.ValidateToken(legacy(jwt.MapClaims{"exp": time.Now().Add(2 * time.Hour).Unix()}))
+	s.Error(err, "outlives any token this service issued")
+}
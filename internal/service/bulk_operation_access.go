@@ -0,0 +1,326 @@
+package service
+
+import (
+	"fmt"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+)
+
+// Access to queued bulk operations.
+//
+// A bulk request may do nothing its items could not do one at a time through
+// the single-record endpoints. Before a request is queued, the caller's
+// permissions are checked against the resource and operation, and every record
+// the request names is checked against the same per-record rules the bulk
+// status updates apply: without the any scope of the permission, users only
+// touch the leads they own and the tickets and tasks assigned to them; only
+// leads:assign and tasks:assign give a lead or a task to someone else; a
+// completed task's status is fixed and a closed ticket cannot be reopened. A
+// request naming even one record the caller may not change is refused in full,
+// and the error names those records.
+//
+// Records that do not exist are not refused here; they fail as items when the
+// operation runs, as they always have. The check is made when the operation is
+// queued, which is when the caller is there to be told.
+
+// bulkPermissions lists, per resource and operation, the permission the
+// single-record endpoints require. Actions amount to updates.
+var bulkPermissions = map[string]map[models.BulkOperationType]models.Permission{
+	"users": {
+		models.BulkCreate: models.PermUsersCreate,
+		models.BulkUpdate: models.PermUsersUpdate,
+		models.BulkDelete: models.PermUsersDelete,
+		models.BulkAction: models.PermUsersUpdate,
+	},
+	"leads": {
+		models.BulkCreate: models.PermLeadsCreate,
+		models.BulkUpdate: models.PermLeadsUpdate,
+		models.BulkDelete: models.PermLeadsDelete,
+		models.BulkAction: models.PermLeadsUpdate,
+	},
+	"customers": {
+		models.BulkCreate: models.PermCustomersCreate,
+		models.BulkUpdate: models.PermCustomersUpdate,
+		models.BulkDelete: models.PermCustomersDelete,
+		models.BulkAction: models.PermCustomersUpdate,
+	},
+	"tasks": {
+		models.BulkCreate: models.PermTasksCreate,
+		models.BulkUpdate: models.PermTasksUpdate,
+		models.BulkDelete: models.PermTasksDelete,
+		models.BulkAction: models.PermTasksUpdate,
+	},
+	"tickets": {
+		models.BulkCreate: models.PermTicketsCreate,
+		models.BulkUpdate: models.PermTicketsUpdate,
+		models.BulkDelete: models.PermTicketsDelete,
+		models.BulkAction: models.PermTicketsUpdate,
+	},
+}
+
+// BulkPermission returns the permission operationType on resourceType
+// requires, and false for a resource or operation that has none.
+func BulkPermission(resourceType string, operationType models.BulkOperationType) (models.Permission, bool) {
+	permission, ok := bulkPermissions[resourceType][operationType]
+	return permission, ok
+}
+
+// BulkAllowed reports whether permissions allow operationType on resourceType
+// at all. Handlers check it before reading the body, and the service again
+// before it queues or reverts.
+func BulkAllowed(resourceType string, operationType models.BulkOperationType, permissions models.PermissionSet) bool {
+	permission, ok := BulkPermission(resourceType, operationType)
+	return ok && permissions.Allows(permission)
+}
+
+// bulkScope is what a queued request asks of the records it names, in the
+// terms the access rules are written in: the records it creates, or the
+// records it changes or deletes and the fields it sets on each.
+type bulkScope struct {
+	operationType models.BulkOperationType
+	created       []map[string]interface{}
+	ids           []uint
+	changes       map[uint]map[string]interface{}
+}
+
+// verb names what the request does to its records, for messages.
+func (scope bulkScope) verb() string {
+	switch scope.operationType {
+	case models.BulkCreate:
+		return "create"
+	case models.BulkDelete:
+		return "delete"
+	default:
+		return "update"
+	}
+}
+
+func createScope(request *models.BulkCreateRequest) bulkScope {
+	return bulkScope{operationType: models.BulkCreate, created: request.Items}
+}
+
+func updateScope(request *models.BulkUpdateRequest) bulkScope {
+	scope := bulkScope{operationType: models.BulkUpdate, changes: make(map[uint]map[string]interface{}, len(request.Items))}
+	for _, item := range request.Items {
+		scope.ids = append(scope.ids, item.ID)
+		scope.changes[item.ID] = item.Updates
+	}
+	return scope
+}
+
+func deleteScope(request *models.BulkDeleteRequest) bulkScope {
+	return bulkScope{operationType: models.BulkDelete, ids: request.IDs}
+}
+
+// actionScope translates an action into the fields it sets, so that an
+// action is held to the rules of the update it amounts to.
+func actionScope(resourceType string, request *models.BulkActionRequest) bulkScope {
+	fields := map[string]interface{}{}
+	params := request.Parameters
+	switch {
+	case resourceType == "leads" && request.Action == models.LeadBulkActionTypes.Assign:
+		fields["owner_id"] = params["owner_id"]
+	case resourceType == "leads" && request.Action == models.LeadBulkActionTypes.Convert:
+		fields["status"] = string(models.LeadStatusConverted)
+	case resourceType == "tasks" && request.Action == models.TaskBulkActionTypes.Assign,
+		resourceType == "tickets" && request.Action == models.TicketBulkActionTypes.Assign:
+		fields["assigned_to_id"] = params["assigned_to_id"]
+	case resourceType == "users" && request.Action == models.UserBulkActionTypes.ChangeRole:
+		fields["role"] = params["role"]
+	case request.Action == "update_status":
+		fields["status"] = params["status"]
+	}
+
+	scope := bulkScope{operationType: models.BulkAction, ids: request.IDs, changes: make(map[uint]map[string]interface{}, len(request.IDs))}
+	for _, id := range request.IDs {
+		scope.changes[id] = fields
+	}
+	return scope
+}
+
+// authorizeQueued refuses a request that does anything the caller could not
+// do one record at a time.
+func (s *bulkOperationService) authorizeQueued(actorID uint, permissions models.PermissionSet, resourceType string, scope bulkScope) error {
+	if !BulkAllowed(resourceType, scope.operationType, permissions) {
+		return bulkStatusForbidden(fmt.Sprintf("Your role cannot %s %s in bulk", scope.verb(), resourceType), nil)
+	}
+
+	switch resourceType {
+	case "leads":
+		return s.authorizeQueuedLeads(actorID, permissions, scope)
+	case "tasks":
+		return s.authorizeQueuedTasks(actorID, permissions, scope)
+	case "tickets":
+		return s.authorizeQueuedTickets(actorID, permissions, scope)
+	case "users":
+		return authorizeQueuedUsers(permissions, scope)
+	default:
+		// Customers have no per-record rules beyond the permission.
+		return nil
+	}
+}
+
+// authorizeQueuedUsers refuses a request that sets a role without
+// roles:manage, as PUT /users/{id}/role would.
+func authorizeQueuedUsers(permissions models.PermissionSet, scope bulkScope) error {
+	if permissions.Allows(models.PermRolesManage) {
+		return nil
+	}
+	fields := append([]map[string]interface{}{}, scope.created...)
+	for _, changes := range scope.changes {
+		fields = append(fields, changes)
+	}
+	for _, item := range fields {
+		if _, ok := item["role"]; ok {
+			return bulkStatusForbidden("Only users who manage roles can set roles in bulk", nil)
+		}
+	}
+	return nil
+}
+
+func (s *bulkOperationService) authorizeQueuedLeads(actorID uint, permissions models.PermissionSet, scope bulkScope) error {
+	canAssign := permissions.Allows(models.PermLeadsAssign)
+	if scope.operationType == models.BulkCreate {
+		if canAssign {
+			return nil
+		}
+		return refuseForeignOwners(scope.created, "owner_id", actorID, "You can only assign leads to yourself")
+	}
+	anyLead := permissions.Allows(models.PermLeadsUpdateAny)
+	if scope.operationType == models.BulkDelete {
+		anyLead = permissions.Allows(models.PermLeadsDeleteAny)
+	}
+	if anyLead && canAssign {
+		return nil
+	}
+
+	leads, err := s.bulkRepo.GetLeadsByIDs(scope.ids)
+	if err != nil {
+		return err
+	}
+	notOwned := make([]uint, 0)
+	reassigned := make([]uint, 0)
+	for _, lead := range leads {
+		if !anyLead && lead.OwnerID != actorID {
+			notOwned = append(notOwned, lead.ID)
+			continue
+		}
+		if owner, ok := scope.changes[lead.ID]["owner_id"]; ok && !canAssign && !sameID(owner, lead.OwnerID) {
+			reassigned = append(reassigned, lead.ID)
+		}
+	}
+	if len(notOwned) > 0 {
+		return bulkStatusForbidden(fmt.Sprintf("You can only %s your own leads", scope.verb()), notOwned)
+	}
+	if len(reassigned) > 0 {
+		return bulkStatusForbidden("Only administrators can reassign leads", reassigned)
+	}
+	return nil
+}
+
+func (s *bulkOperationService) authorizeQueuedTasks(actorID uint, permissions models.PermissionSet, scope bulkScope) error {
+	anyTask := permissions.Allows(models.PermTasksUpdateAny)
+	canAssign := permissions.Allows(models.PermTasksAssign)
+	switch scope.operationType {
+	case models.BulkCreate:
+		if canAssign {
+			return nil
+		}
+		return refuseForeignOwners(scope.created, "assigned_to_id", actorID, "You can only assign tasks to yourself")
+	case models.BulkDelete:
+		return nil
+	}
+
+	tasks, err := s.bulkRepo.GetTasksByIDs(scope.ids)
+	if err != nil {
+		return err
+	}
+	notAssigned := make([]uint, 0)
+	reassigned := make([]uint, 0)
+	completed := make([]uint, 0)
+	for _, task := range tasks {
+		changes := scope.changes[task.ID]
+		if !anyTask && task.AssignedToID != actorID {
+			notAssigned = append(notAssigned, task.ID)
+			continue
+		}
+		if assignee, ok := changes["assigned_to_id"]; ok && !canAssign && !sameID(assignee, task.AssignedToID) {
+			reassigned = append(reassigned, task.ID)
+		}
+		if status, ok := changes["status"]; ok && task.Status == models.TaskStatusCompleted && status != string(models.TaskStatusCompleted) {
+			completed = append(completed, task.ID)
+		}
+	}
+	if len(notAssigned) > 0 {
+		return bulkStatusForbidden("You can only update tasks assigned to you", notAssigned)
+	}
+	if len(reassigned) > 0 {
+		return bulkStatusForbidden("Only admins can reassign tasks", reassigned)
+	}
+	if len(completed) > 0 {
+		return bulkCompletedTasks(completed)
+	}
+	return nil
+}
+
+func (s *bulkOperationService) authorizeQueuedTickets(actorID uint, permissions models.PermissionSet, scope bulkScope) error {
+	if scope.operationType == models.BulkCreate || scope.operationType == models.BulkDelete {
+		return nil
+	}
+
+	tickets, err := s.bulkRepo.GetTicketsByIDs(scope.ids)
+	if err != nil {
+		return err
+	}
+	anyTicket := permissions.Allows(models.PermTicketsUpdateAny)
+	notAssigned := make([]uint, 0)
+	closed := make([]uint, 0)
+	for _, ticket := range tickets {
+		if !anyTicket && (ticket.AssignedToID == nil || *ticket.AssignedToID != actorID) {
+			notAssigned = append(notAssigned, ticket.ID)
+			continue
+		}
+		if status, ok := scope.changes[ticket.ID]["status"]; ok && ticket.Status == models.TicketStatusClosed && status != string(models.TicketStatusClosed) {
+			closed = append(closed, ticket.ID)
+		}
+	}
+	if len(notAssigned) > 0 {
+		return bulkStatusForbidden("You can only update tickets assigned to you", notAssigned)
+	}
+	if len(closed) > 0 {
+		return bulkClosedTickets(closed)
+	}
+	return nil
+}
+
+// refuseForeignOwners refuses records to be created for someone other than
+// the caller. A record that names no one is created for the caller.
+func refuseForeignOwners(items []map[string]interface{}, field string, actorID uint, message string) error {
+	foreign := make([]int, 0)
+	for i, item := range items {
+		if owner, ok := item[field]; ok && owner != nil && !sameID(owner, actorID) && !sameID(owner, 0) {
+			foreign = append(foreign, i)
+		}
+	}
+	if len(foreign) > 0 {
+		return apperrors.Wrap(apperrors.ErrForbidden, apperrors.CodeInsufficientPermissions, message).
+			WithDetail("forbidden_items", foreign)
+	}
+	return nil
+}
+
+// sameID compares an ID decoded from a JSON request, which arrives as a
+// float64, with a stored one.
+func sameID(value interface{}, id uint) bool {
+	switch v := value.(type) {
+	case float64:
+		return v == float64(id)
+	case int:
+		return v >= 0 && uint(v) == id
+	case uint:
+		return v == id
+	default:
+		return false
+	}
+}
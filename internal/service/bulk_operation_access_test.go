@@ -0,0 +1,207 @@
+package service
+
+import (
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+)
+
+func (s *BulkOperationPersistenceSuite) staffUser(role models.UserRole) uint {
+	user := &models.User{
+		Email:     string(role) + "@example.com",
+		Password:  "hashed",
+		FirstName: "Bulk",
+		LastName:  string(role),
+		Role:      role,
+		IsActive:  true,
+	}
+	s.Require().NoError(s.db.Create(user).Error)
+	return user.ID
+}
+
+func (s *BulkOperationPersistenceSuite) leadOwnedBy(ownerID uint) uint {
+	lead := &models.Lead{FirstName: "Bulk", LastName: "Lead", Email: "lead@example.com", Status: models.LeadStatusNew, OwnerID: ownerID}
+	s.Require().NoError(s.db.Create(lead).Error)
+	return lead.ID
+}
+
+func (s *BulkOperationPersistenceSuite) taskAssignedTo(assigneeID uint, status models.TaskStatus) uint {
+	task := &models.Task{Title: "Bulk task", Status: status, Priority: models.TaskPriorityMedium, AssignedToID: assigneeID}
+	s.Require().NoError(s.db.Create(task).Error)
+	return task.ID
+}
+
+func (s *BulkOperationPersistenceSuite) ticketAssignedTo(assigneeID uint, status models.TicketStatus) uint {
+	ticket := &models.Ticket{Title: "Bulk ticket", Description: "Broken", Status: status, Priority: models.TicketPriorityMedium, AssignedToID: &assigneeID}
+	s.Require().NoError(s.db.Create(ticket).Error)
+	return ticket.ID
+}
+
+// requireRefusal checks err is refused with sentinel and names ids under key.
+func (s *BulkOperationPersistenceSuite) requireRefusal(err error, sentinel error, key string, ids interface{}) {
+	s.Require().ErrorIs(err, sentinel)
+	appErr, ok := apperrors.AsAppError(err)
+	s.Require().True(ok, "the refusal names the records it is about")
+	s.Equal(ids, appErr.Details[key])
+}
+
+func (s *BulkOperationPersistenceSuite) TestQueue_SalesOnlyTheirOwnLeads() {
+	salesID := s.staffUser(models.RoleSales)
+	own := s.leadOwnedBy(salesID)
+	other := s.leadOwnedBy(s.actorID)
+
+	_, err := s.service.QueueBulkDelete(salesID, models.BuiltInPermissions(models.RoleSales), "leads", &models.BulkDeleteRequest{IDs: []uint{own, other}})
+	s.requireRefusal(err, apperrors.ErrForbidden, "forbidden_ids", []uint{other})
+
+	_, err = s.service.QueueBulkAction(salesID, models.BuiltInPermissions(models.RoleSales), "leads", &models.BulkActionRequest{
+		IDs:        []uint{own},
+		Action:     models.LeadBulkActionTypes.Assign,
+		Parameters: map[string]interface{}{"owner_id": float64(s.actorID)},
+	})
+	s.requireRefusal(err, apperrors.ErrForbidden, "forbidden_ids", []uint{own})
+
+	_, err = s.service.QueueBulkCreate(salesID, models.BuiltInPermissions(models.RoleSales), "leads", &models.BulkCreateRequest{Items: []map[string]interface{}{
+		{"first_name": "Mine"},
+		{"first_name": "Theirs", "owner_id": float64(s.actorID)},
+	}})
+	s.requireRefusal(err, apperrors.ErrForbidden, "forbidden_items", []int{1})
+	s.Equal(int64(0), s.count(&models.BulkOperation{}), "a refused request is never queued")
+
+	// Their own leads, and an ID that does not exist, which fails when it runs.
+	_, err = s.service.QueueBulkUpdate(salesID, models.BuiltInPermissions(models.RoleSales), "leads", &models.BulkUpdateRequest{Items: []models.BulkUpdateItem{
+		{ID: own, Updates: map[string]interface{}{"owner_id": float64(salesID), "status": "contacted"}},
+		{ID: 999, Updates: map[string]interface{}{"status": "contacted"}},
+	}})
+	s.NoError(err)
+
+	// An admin may reassign anyone's.
+	_, err = s.service.QueueBulkAction(s.actorID, models.BuiltInPermissions(models.RoleAdmin), "leads", &models.BulkActionRequest{
+		IDs:        []uint{own, other},
+		Action:     models.LeadBulkActionTypes.Assign,
+		Parameters: map[string]interface{}{"owner_id": float64(salesID)},
+	})
+	s.NoError(err)
+}
+
+func (s *BulkOperationPersistenceSuite) TestQueue_TasksAssignedToTheCaller() {
+	supportID := s.staffUser(models.RoleSupport)
+	mine := s.taskAssignedTo(supportID, models.TaskStatusPending)
+	done := s.taskAssignedTo(supportID, models.TaskStatusCompleted)
+	theirs := s.taskAssignedTo(s.actorID, models.TaskStatusPending)
+
+	_, err := s.service.QueueBulkUpdate(supportID, models.BuiltInPermissions(models.RoleSupport), "tasks", &models.BulkUpdateRequest{Items: []models.BulkUpdateItem{
+		{ID: mine, Updates: map[string]interface{}{"priority": "high"}},
+		{ID: theirs, Updates: map[string]interface{}{"priority": "high"}},
+	}})
+	s.requireRefusal(err, apperrors.ErrForbidden, "forbidden_ids", []uint{theirs})
+
+	_, err = s.service.QueueBulkUpdate(supportID, models.BuiltInPermissions(models.RoleSupport), "tasks", &models.BulkUpdateRequest{Items: []models.BulkUpdateItem{
+		{ID: mine, Updates: map[string]interface{}{"assigned_to_id": float64(s.actorID)}},
+	}})
+	s.requireRefusal(err, apperrors.ErrForbidden, "forbidden_ids", []uint{mine})
+
+	// A completed task's status is fixed for admins too.
+	_, err = s.service.QueueBulkAction(s.actorID, models.BuiltInPermissions(models.RoleAdmin), "tasks", &models.BulkActionRequest{
+		IDs:        []uint{mine, done},
+		Action:     models.TaskBulkActionTypes.UpdateStatus,
+		Parameters: map[string]interface{}{"status": "in_progress"},
+	})
+	s.requireRefusal(err, apperrors.ErrCompletedTaskModify, "completed_ids", []uint{done})
+
+	_, err = s.service.QueueBulkDelete(supportID, models.BuiltInPermissions(models.RoleSupport), "tasks", &models.BulkDeleteRequest{IDs: []uint{mine}})
+	s.ErrorIs(err, apperrors.ErrForbidden, "only an admin deletes tasks")
+
+	_, err = s.service.QueueBulkCreate(supportID, models.BuiltInPermissions(models.RoleSupport), "tasks", &models.BulkCreateRequest{Items: []map[string]interface{}{
+		{"title": "For someone else", "assigned_to_id": float64(s.actorID)},
+	}})
+	s.requireRefusal(err, apperrors.ErrForbidden, "forbidden_items", []int{0})
+
+	_, err = s.service.QueueBulkUpdate(supportID, models.BuiltInPermissions(models.RoleSupport), "tasks", &models.BulkUpdateRequest{Items: []models.BulkUpdateItem{
+		{ID: mine, Updates: map[string]interface{}{"priority": "high", "assigned_to_id": float64(supportID)}},
+	}})
+	s.NoError(err)
+}
+
+func (s *BulkOperationPersistenceSuite) TestQueue_TicketsAssignedToSupport() {
+	supportID := s.staffUser(models.RoleSupport)
+	mine := s.ticketAssignedTo(supportID, models.TicketStatusOpen)
+	closed := s.ticketAssignedTo(supportID, models.TicketStatusClosed)
+	theirs := s.ticketAssignedTo(s.actorID, models.TicketStatusOpen)
+
+	_, err := s.service.QueueBulkAction(supportID, models.BuiltInPermissions(models.RoleSupport), "tickets", &models.BulkActionRequest{
+		IDs:        []uint{mine, theirs},
+		Action:     models.TicketBulkActionTypes.UpdatePriority,
+		Parameters: map[string]interface{}{"priority": "high"},
+	})
+	s.requireRefusal(err, apperrors.ErrForbidden, "forbidden_ids", []uint{theirs})
+
+	_, err = s.service.QueueBulkUpdate(supportID, models.BuiltInPermissions(models.RoleSupport), "tickets", &models.BulkUpdateRequest{Items: []models.BulkUpdateItem{
+		{ID: closed, Updates: map[string]interface{}{"status": "open"}},
+	}})
+	s.requireRefusal(err, apperrors.ErrClosedTicketReopen, "closed_ids", []uint{closed})
+
+	_, err = s.service.QueueBulkUpdate(s.actorID, models.BuiltInPermissions(models.RoleSales), "tickets", &models.BulkUpdateRequest{Items: []models.BulkUpdateItem{
+		{ID: theirs, Updates: map[string]interface{}{"priority": "high"}},
+	}})
+	s.ErrorIs(err, apperrors.ErrForbidden, "sales users are read-only on tickets")
+
+	_, err = s.service.QueueBulkCreate(supportID+1, models.BuiltInPermissions(models.RoleCustomer), "users", &models.BulkCreateRequest{Items: []map[string]interface{}{userItem(1)}})
+	s.ErrorIs(err, apperrors.ErrForbidden, "only an admin changes users")
+}
+
+// TestQueue_UsersRoleNeedsRolesManage refuses setting a role in bulk to a
+// caller who may change users but not roles.
+func (s *BulkOperationPersistenceSuite) TestQueue_UsersRoleNeedsRolesManage() {
+	userAdmin := models.NewPermissionSet(models.PermUsersCreate, models.PermUsersUpdate)
+
+	_, err := s.service.QueueBulkCreate(s.actorID, userAdmin, "users", &models.BulkCreateRequest{Items: []map[string]interface{}{userItem(1)}})
+	s.ErrorIs(err, apperrors.ErrForbidden)
+
+	_, err = s.service.QueueBulkAction(s.actorID, userAdmin, "users", &models.BulkActionRequest{
+		IDs: []uint{s.actorID}, Action: models.UserBulkActionTypes.ChangeRole, Parameters: map[string]interface{}{"role": "admin"},
+	})
+	s.ErrorIs(err, apperrors.ErrForbidden)
+
+	roleless := userItem(2)
+	delete(roleless, "role")
+	_, err = s.service.QueueBulkCreate(s.actorID, userAdmin, "users", &models.BulkCreateRequest{Items: []map[string]interface{}{roleless}})
+	s.NoError(err, "creating users without a role takes no roles:manage")
+}
+
+// TestQueue_CustomRoleDecidedByPermissions holds a custom role to its
+// permissions, not to the built-in role it is based on.
+func (s *BulkOperationPersistenceSuite) TestQueue_CustomRoleDecidedByPermissions() {
+	leadID := s.staffUser(models.RoleSupport)
+	theirs := s.ticketAssignedTo(s.actorID, models.TicketStatusOpen)
+	teamLead := models.NewPermissionSet(models.PermTicketsRead, models.PermTicketsUpdateAny)
+
+	_, err := s.service.QueueBulkUpdate(leadID, teamLead, "tickets", &models.BulkUpdateRequest{Items: []models.BulkUpdateItem{
+		{ID: theirs, Updates: map[string]interface{}{"priority": "high"}},
+	}})
+	s.NoError(err, "tickets:update:any reaches tickets assigned to someone else")
+
+	_, err = s.service.QueueBulkUpdate(leadID, models.NewPermissionSet(models.PermTicketsUpdateOwn), "tickets", &models.BulkUpdateRequest{Items: []models.BulkUpdateItem{
+		{ID: theirs, Updates: map[string]interface{}{"priority": "high"}},
+	}})
+	s.requireRefusal(err, apperrors.ErrForbidden, "forbidden_ids", []uint{theirs})
+}
+
+// TestQueuedAction_AcceptsParamsAlias runs an action whose parameters arrive
+// under "params", the documented alias the engine itself does not read.
+func (s *BulkOperationPersistenceSuite) TestQueuedAction_AcceptsParamsAlias() {
+	lead := s.leadOwnedBy(s.actorID)
+
+	queued, err := s.service.QueueBulkAction(s.actorID, models.BuiltInPermissions(models.RoleAdmin), "leads", &models.BulkActionRequest{
+		IDs:    []uint{lead},
+		Action: models.LeadBulkActionTypes.UpdateStatus,
+		Params: map[string]interface{}{"status": "contacted"},
+	})
+	s.Require().NoError(err)
+
+	_, err = s.service.RunNextQueued()
+	s.Require().NoError(err)
+
+	s.Equal(models.StatusCompleted, s.operation(queued.ID).Status)
+	var updated models.Lead
+	s.Require().NoError(s.db.First(&updated, lead).Error)
+	s.Equal(models.LeadStatusContacted, updated.Status)
+}
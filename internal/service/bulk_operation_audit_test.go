@@ -0,0 +1,64 @@
+package service
+
+import (
+	"github.com/florinel-chis/gophercrm/internal/models"
+)
+
+func (s *BulkOperationPersistenceSuite) auditEvents(entityType string) []models.AuditEvent {
+	var events []models.AuditEvent
+	s.Require().NoError(s.db.Where("entity_type = ?", entityType).Order("id").Find(&events).Error)
+	return events
+}
+
+// TestQueued_AuditsEveryChangedRow runs a queued action, delete and erasure
+// and reverts the first two as another user: every row changed is audited,
+// attributed to whoever queued or reverted the change.
+func (s *BulkOperationPersistenceSuite) TestQueued_AuditsEveryChangedRow() {
+	first := s.taskAssignedTo(s.actorID, models.TaskStatusPending)
+	second := s.taskAssignedTo(s.actorID, models.TaskStatusPending)
+	admin := models.BuiltInPermissions(models.RoleAdmin)
+
+	action := s.runQueuedOperation(s.service.QueueBulkAction(s.actorID, admin, "tasks", &models.BulkActionRequest{
+		IDs:        []uint{first, 999},
+		Action:     models.TaskBulkActionTypes.UpdatePriority,
+		Parameters: map[string]interface{}{"priority": "high"},
+	}))
+	deleted := s.runQueuedOperation(s.service.QueueBulkDelete(s.actorID, admin, "tasks", &models.BulkDeleteRequest{IDs: []uint{second}}))
+
+	events := s.auditEvents(models.AuditEntityTask)
+	s.Require().Len(events, 2, "only the rows that changed")
+	s.Equal(first, events[0].EntityID)
+	s.Equal(models.AuditActionUpdate, events[0].Action)
+	s.Equal(models.AuditChange{Before: "medium", After: "high"}, events[0].Changes["priority"])
+	s.Equal(second, events[1].EntityID)
+	s.Equal(models.AuditActionDelete, events[1].Action)
+	s.Equal("Bulk task", events[1].Changes["title"].Before)
+	for _, event := range events {
+		s.Equal(models.AuditActorUser, event.ActorType)
+		s.Equal(s.actorID, *event.ActorUserID)
+	}
+
+	reverter := s.staffUser(models.RoleAdmin)
+	_, err := s.service.RevertBulkOperation(deleted.ID, reverter, admin)
+	s.Require().NoError(err)
+	_, err = s.service.RevertBulkOperation(action.ID, reverter, admin)
+	s.Require().NoError(err)
+
+	events = s.auditEvents(models.AuditEntityTask)[2:]
+	s.Require().Len(events, 2)
+	s.Equal(second, events[0].EntityID)
+	s.Equal(models.AuditActionCreate, events[0].Action, "a deleted row put back is created again")
+	s.Equal(first, events[1].EntityID)
+	s.Equal(models.AuditActionUpdate, events[1].Action)
+	s.Equal(models.AuditChange{Before: "high", After: "medium"}, events[1].Changes["priority"])
+	for _, event := range events {
+		s.Equal(reverter, *event.ActorUserID)
+	}
+
+	lead := s.leadOwnedBy(s.actorID)
+	s.runQueuedOperation(s.service.QueueBulkDelete(s.actorID, admin, "leads", &models.BulkDeleteRequest{IDs: []uint{lead}}))
+	erased := s.auditEvents(models.AuditEntityLead)
+	s.Require().Len(erased, 1)
+	s.Equal(models.AuditActionDelete, erased[0].Action)
+	s.Empty(erased[0].Changes, "an erasure copies nothing of the person")
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"testing"
 
+	"github.com/florinel-chis/gophercrm/internal/config"
 	"github.com/florinel-chis/gophercrm/internal/models"
 	"github.com/florinel-chis/gophercrm/internal/repository"
 	"github.com/florinel-chis/gophercrm/internal/utils"
@@ -31,47 +32,50 @@ func TestBulkOperationPersistenceSuite(t *testing.T) {
 	suite.Run(t, new(BulkOperationPersistenceSuite))
 }
 
+// SetupSuite gives the queued operations, which log through utils.Logger, a
+// logger to log to.
+func (s *BulkOperationPersistenceSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "error", Format: "json"})
+}
+
 func (s *BulkOperationPersistenceSuite) SetupTest() {
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
 		Logger: gormlogger.Discard,
 	})
 	s.Require().NoError(err)
 
-	// api_keys and refresh_tokens are part of the schema under test even though
-	// no test here creates one: bulk-deleting a user is a GDPR erasure and the
-	// erasure purges their credentials unconditionally. A schema without those
-	// tables would make every user deletion fail.
+	// api_keys, refresh_tokens, audit_events and activity_events are part of
+	// the schema under test even though no test here creates one: bulk-deleting
+	// a user is a GDPR erasure and the erasure purges their credentials and
+	// scrubs their audit trail and activity feed unconditionally. A schema
+	// without those tables would make every user deletion fail.
 	s.Require().NoError(db.AutoMigrate(
 		&models.User{},
 		&models.Lead{},
 		&models.Customer{},
 		&models.Ticket{},
 		&models.Task{},
-		&models.APIKey{},
+		&models.APIKey{}, &models.APIKeyUsage{},
 		&models.RefreshToken{},
 		&models.PasswordResetToken{},
+		&models.TwoFactorRecoveryCode{},
+		&models.Session{},
+		&models.RevokedToken{},
 		&models.BulkOperation{},
 		&models.BulkOperationItem{},
+		&models.Import{},
 		&models.Form{},
 		&models.FormSubmission{},
+		&models.AuditEvent{},
+		&models.ActivityEvent{},
+		&models.WebhookDelivery{},
 		&models.FormConfirmationToken{},
+		&models.CustomFieldDefinition{},
+		&models.CustomFieldValue{},
 	))
 	s.db = db
 
-	logger := logrus.New()
-	logger.SetOutput(io.Discard)
-
-	s.service = NewBulkOperationService(
-		repository.NewBulkOperationRepository(db),
-		repository.NewBulkRepository(db),
-		repository.NewUserRepository(db),
-		repository.NewLeadRepository(db),
-		repository.NewCustomerRepository(db),
-		repository.NewTaskRepository(db),
-		repository.NewTicketRepository(db),
-		utils.NewTransactionManager(db),
-		logger,
-	)
+	s.service = s.newService()
 
 	// The user performing the bulk operations.
 	actor := &models.User{
@@ -86,6 +90,27 @@ func (s *BulkOperationPersistenceSuite) SetupTest() {
 	s.actorID = actor.ID
 }
 
+// newService builds the service under test on the suite's database.
+func (s *BulkOperationPersistenceSuite) newService(opts ...EntityOption) BulkOperationService {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	return NewBulkOperationService(
+		repository.NewBulkOperationRepository(s.db),
+		repository.NewBulkRepository(s.db),
+		repository.NewUserRepository(s.db),
+		repository.NewLeadRepository(s.db),
+		repository.NewCustomerRepository(s.db),
+		repository.NewTaskRepository(s.db),
+		repository.NewTicketRepository(s.db),
+		utils.NewTransactionManager(s.db),
+		NewAuditService(repository.NewAuditRepository(s.db)),
+		logger,
+		config.BulkConfig{RevertWindowHours: 24},
+		opts...,
+	)
+}
+
 func (s *BulkOperationPersistenceSuite) count(model interface{}) int64 {
 	var n int64
 	s.Require().NoError(s.db.Model(model).Count(&n).Error)
@@ -423,7 +448,7 @@ func (s *BulkOperationPersistenceSuite) TestBulkDeleteCustomers_ErasesTheOrigina
 
 	leadService := NewLeadService(
 		repository.NewLeadRepository(s.db),
-		repository.NewCustomerRepository(s.db),
+		repository.NewCustomerRepository(s.db), nil,
 		utils.NewTransactionManager(s.db),
 	)
 	customer, err := leadService.ConvertToCustomer(lead.ID, &models.Customer{})
@@ -457,7 +482,7 @@ func (s *BulkOperationPersistenceSuite) TestBulkDeleteLeads_ErasesTheConvertedCu
 
 	leadService := NewLeadService(
 		repository.NewLeadRepository(s.db),
-		repository.NewCustomerRepository(s.db),
+		repository.NewCustomerRepository(s.db), nil,
 		utils.NewTransactionManager(s.db),
 	)
 	customer, err := leadService.ConvertToCustomer(lead.ID, &models.Customer{})
@@ -0,0 +1,429 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+)
+
+// bulkResourceActions lists the actions each resource accepts, so that an
+// unknown action is refused when it is queued rather than when it runs.
+var bulkResourceActions = map[string][]string{
+	"users": {
+		models.UserBulkActionTypes.Activate,
+		models.UserBulkActionTypes.Deactivate,
+		models.UserBulkActionTypes.ChangeRole,
+	},
+	"leads": {
+		models.LeadBulkActionTypes.UpdateStatus,
+		models.LeadBulkActionTypes.Assign,
+		models.LeadBulkActionTypes.Convert,
+		models.LeadBulkActionTypes.UpdateSource,
+	},
+	"customers": {
+		models.CustomerBulkActionTypes.Activate,
+		models.CustomerBulkActionTypes.Deactivate,
+		models.CustomerBulkActionTypes.UpdateType,
+		models.CustomerBulkActionTypes.Assign,
+	},
+	"tasks": {
+		models.TaskBulkActionTypes.UpdateStatus,
+		models.TaskBulkActionTypes.Assign,
+		models.TaskBulkActionTypes.UpdatePriority,
+		models.TaskBulkActionTypes.SetDueDate,
+	},
+	"tickets": {
+		models.TicketBulkActionTypes.UpdateStatus,
+		models.TicketBulkActionTypes.Assign,
+		models.TicketBulkActionTypes.UpdatePriority,
+		models.TicketBulkActionTypes.UpdateCategory,
+	},
+}
+
+func (s *bulkOperationService) QueueBulkCreate(userID uint, permissions models.PermissionSet, resourceType string, request *models.BulkCreateRequest) (*models.BulkOperation, error) {
+	return s.queue(userID, permissions, resourceType, createScope(request), len(request.Items), request)
+}
+
+func (s *bulkOperationService) QueueBulkUpdate(userID uint, permissions models.PermissionSet, resourceType string, request *models.BulkUpdateRequest) (*models.BulkOperation, error) {
+	return s.queue(userID, permissions, resourceType, updateScope(request), len(request.Items), request)
+}
+
+func (s *bulkOperationService) QueueBulkDelete(userID uint, permissions models.PermissionSet, resourceType string, request *models.BulkDeleteRequest) (*models.BulkOperation, error) {
+	return s.queue(userID, permissions, resourceType, deleteScope(request), len(request.IDs), request)
+}
+
+func (s *bulkOperationService) QueueBulkAction(userID uint, permissions models.PermissionSet, resourceType string, request *models.BulkActionRequest) (*models.BulkOperation, error) {
+	if actions, ok := bulkResourceActions[resourceType]; ok && !containsString(actions, request.Action) {
+		return nil, fmt.Errorf("unsupported %s action %q: %w", resourceType, request.Action, apperrors.ErrValidation)
+	}
+	// The engine reads Parameters; params is the documented alias.
+	if request.Parameters == nil {
+		request.Parameters, request.Params = request.Params, nil
+	}
+	return s.queue(userID, permissions, resourceType, actionScope(resourceType, request), len(request.IDs), request)
+}
+
+// queue checks request against what the caller may do, records it on a
+// pending operation and wakes a worker for it.
+func (s *bulkOperationService) queue(userID uint, permissions models.PermissionSet, resourceType string, scope bulkScope, itemCount int, request interface{}) (*models.BulkOperation, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("entity", "bulk_operation"), "BulkOperationService", "Queue")
+
+	if _, ok := bulkResourceActions[resourceType]; !ok {
+		return nil, fmt.Errorf("unsupported resource type: %s: %w", resourceType, apperrors.ErrValidation)
+	}
+	if err := s.validateBulkRequest(itemCount); err != nil {
+		return nil, fmt.Errorf("%v: %w", err, apperrors.ErrValidation)
+	}
+	if err := s.authorizeQueued(userID, permissions, resourceType, scope); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", err, apperrors.ErrValidation)
+	}
+
+	operation := &models.BulkOperation{
+		UserID:        userID,
+		ResourceType:  resourceType,
+		Type:          scope.operationType,
+		OperationType: scope.operationType,
+		Status:        models.StatusPending,
+		TotalItems:    itemCount,
+		Request:       string(payload),
+	}
+	if err := s.bulkOperationRepo.Create(operation); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, fmt.Errorf("failed to create bulk operation: %w", err)
+	}
+	s.signalQueued()
+	return operation, nil
+}
+
+func (s *bulkOperationService) CancelBulkOperation(id uint, userID uint, permissions models.PermissionSet) (*models.BulkOperation, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("bulk_operation_id", id), "BulkOperationService", "CancelBulkOperation")
+
+	operation, err := s.bulkOperationRepo.GetByID(id)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		if isNotFound(err) {
+			return nil, fmt.Errorf("bulk operation %d: %w", id, apperrors.ErrNotFound)
+		}
+		return nil, err
+	}
+	if operation.UserID != userID && !permissions.Allows(models.PermBulkOperationsManage) {
+		return nil, fmt.Errorf("you can only cancel your own bulk operations: %w", apperrors.ErrForbidden)
+	}
+
+	cancelled, err := s.bulkOperationRepo.Cancel(id, time.Now().UTC())
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	if operation, err = s.bulkOperationRepo.GetByID(id); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	if !cancelled {
+		if operation.Status == models.StatusPending || operation.Status == models.StatusProcessing {
+			return nil, fmt.Errorf("bulk operation %d was not queued and cannot be cancelled: %w", id, apperrors.ErrValidation)
+		}
+		return nil, fmt.Errorf("bulk operation %d is %s: %w", id, operation.Status, apperrors.ErrBulkOperationFinished)
+	}
+	return operation, nil
+}
+
+func (s *bulkOperationService) Queued() <-chan struct{} {
+	return s.queued
+}
+
+// signalQueued wakes one idle worker, if one is not already due to wake.
+func (s *bulkOperationService) signalQueued() {
+	select {
+	case s.queued <- struct{}{}:
+	default:
+	}
+}
+
+// ReconcileInterrupted fails the queued operations still processing at boot.
+// Their workers died with the previous process, and a chunk that process
+// committed may not have made it into the counters, so running the rest again
+// could apply it twice. Failing them leaves the counters as recorded and says
+// the rest never ran; pending operations were never started and simply wait
+// for a worker.
+func (s *bulkOperationService) ReconcileInterrupted() (int64, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("entity", "bulk_operation"), "BulkOperationService", "ReconcileInterrupted")
+
+	failed, err := s.bulkOperationRepo.MarkInterruptedFailed(time.Now().UTC())
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return 0, err
+	}
+	return failed, nil
+}
+
+func (s *bulkOperationService) RunNextQueued() (bool, error) {
+	operation, err := s.bulkOperationRepo.ClaimNextQueued(time.Now().UTC())
+	if err != nil || operation == nil {
+		return false, err
+	}
+	// More may be queued behind this one; let another idle worker look.
+	s.signalQueued()
+	s.runQueued(operation)
+	return true, nil
+}
+
+// bulkChunk is one slice of a queued operation, run by the synchronous engine
+// as if it were a request of its own.
+type bulkChunk struct {
+	offset int
+	size   int
+	// ids are the records the chunk changes or deletes; none for a create.
+	ids []uint
+	run func(engine *bulkOperationService) (*models.BulkResponse, error)
+}
+
+// bulkProgress is what a queued operation has done so far.
+type bulkProgress struct {
+	success int
+	failure int
+}
+
+// runQueued runs a claimed operation chunk by chunk, recording progress after
+// each and checking for cancellation before the next. It always finishes the
+// operation, even if the engine panics.
+func (s *bulkOperationService) runQueued(operation *models.BulkOperation) {
+	logger := utils.Logger.WithFields(map[string]interface{}{
+		"bulk_operation_id": operation.ID,
+		"resource_type":     operation.ResourceType,
+		"type":              operation.Type,
+	})
+	logger.Info("Bulk operation started")
+
+	var progress bulkProgress
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			logger.WithField("panic", recovered).Error("Bulk operation panicked")
+			s.failRemaining(operation, &progress, "the operation stopped unexpectedly")
+		}
+		s.finishQueued(operation, progress)
+		logger.WithFields(map[string]interface{}{
+			"success_count": progress.success,
+			"failure_count": progress.failure,
+		}).Info("Bulk operation finished")
+	}()
+
+	chunks, err := queuedChunks(operation)
+	if err != nil {
+		s.failRemaining(operation, &progress, fmt.Sprintf("the queued request is unreadable: %v", err))
+		return
+	}
+
+	engine := s.forOperation(operation)
+	for _, chunk := range chunks {
+		if s.isCancelled(operation.ID) {
+			logger.Info("Bulk operation cancelled")
+			return
+		}
+
+		before := s.readChunkRows(operation, chunk)
+		response, err := chunk.run(engine)
+		if err != nil {
+			s.failRemaining(operation, &progress, err.Error())
+			return
+		}
+		var after map[uint]models.BulkRowState
+		if before != nil {
+			after = s.readChunkRows(operation, chunk)
+		}
+		s.auditChunk(operation, chunk, before, after)
+		s.recordChunk(operation, &progress, chunk, response, s.snapshotChunk(operation, chunk, before, after))
+	}
+}
+
+// recordChunk adds the outcome of one chunk to the counters and records its
+// errors as failed items, along with the snapshots of the rows it changed.
+// Success is what the engine reports; everything else in the chunk failed,
+// which for an all-or-nothing create is the whole chunk.
+func (s *bulkOperationService) recordChunk(operation *models.BulkOperation, progress *bulkProgress, chunk bulkChunk, response *models.BulkResponse, snapshots []models.BulkOperationItem) {
+	success := response.SuccessItems
+	if success > chunk.size {
+		success = chunk.size
+	}
+	progress.success += success
+	progress.failure += chunk.size - success
+
+	itemErrs, _ := response.Errors.([]models.BulkItemError)
+	items := make([]models.BulkOperationItem, 0, len(itemErrs)+len(snapshots))
+	for _, itemErr := range itemErrs {
+		message := itemErr.Message
+		if itemErr.Code == "VALIDATION_ERROR" {
+			message = fmt.Sprintf("item %d: %s", chunk.offset+itemErr.Index, message)
+		}
+		items = append(items, models.BulkOperationItem{
+			OperationID: operation.ID,
+			Status:      models.StatusFailed,
+			Error:       message,
+		})
+	}
+	s.saveProgress(operation, *progress, append(items, snapshots...))
+}
+
+// failRemaining counts every item not yet run as failed, for the one reason.
+func (s *bulkOperationService) failRemaining(operation *models.BulkOperation, progress *bulkProgress, reason string) {
+	remaining := operation.TotalItems - progress.success - progress.failure
+	if remaining <= 0 {
+		return
+	}
+	progress.failure += remaining
+	s.saveProgress(operation, *progress, []models.BulkOperationItem{{
+		OperationID: operation.ID,
+		Status:      models.StatusFailed,
+		Error:       fmt.Sprintf("%d items were not processed: %s", remaining, reason),
+	}})
+}
+
+func (s *bulkOperationService) saveProgress(operation *models.BulkOperation, progress bulkProgress, items []models.BulkOperationItem) {
+	logger := utils.Logger.WithField("bulk_operation_id", operation.ID)
+	if err := s.bulkOperationRepo.CreateItems(items); err != nil {
+		logger.WithError(err).Error("Failed to record bulk operation items")
+	}
+	if err := s.bulkOperationRepo.UpdateCounts(operation.ID, progress.success, progress.failure); err != nil {
+		logger.WithError(err).Error("Failed to record bulk operation progress")
+	}
+}
+
+func (s *bulkOperationService) finishQueued(operation *models.BulkOperation, progress bulkProgress) {
+	status := models.StatusCompleted
+	switch {
+	case progress.success == 0:
+		status = models.StatusFailed
+	case progress.success < operation.TotalItems:
+		status = models.StatusPartial
+	}
+	if err := s.bulkOperationRepo.Finish(operation.ID, status, progress.success, progress.failure, time.Now().UTC()); err != nil {
+		utils.Logger.WithField("bulk_operation_id", operation.ID).WithError(err).Error("Failed to finish bulk operation")
+	}
+}
+
+func (s *bulkOperationService) isCancelled(id uint) bool {
+	operation, err := s.bulkOperationRepo.GetByID(id)
+	if err != nil {
+		utils.Logger.WithField("bulk_operation_id", id).WithError(err).Warn("Failed to check bulk operation for cancellation")
+		return false
+	}
+	return operation.Status == models.StatusCancelled
+}
+
+// forOperation returns the engine bound to a queued operation: every chunk it
+// runs belongs to that operation instead of recording one of its own, and the
+// operation's status is left to the worker.
+func (s *bulkOperationService) forOperation(operation *models.BulkOperation) *bulkOperationService {
+	engine := *s
+	engine.bulkOperationRepo = queuedOperationRepository{
+		BulkOperationRepository: s.bulkOperationRepo,
+		operation:               operation,
+	}
+	return &engine
+}
+
+// queuedOperationRepository is the operation repository the engine sees while
+// it runs a chunk. The engine records an operation per call and sets its
+// status when done; a queued operation already exists and its status is the
+// worker's to set, so both are absorbed.
+type queuedOperationRepository struct {
+	repository.BulkOperationRepository
+	operation *models.BulkOperation
+}
+
+func (r queuedOperationRepository) Create(operation *models.BulkOperation) error {
+	operation.ID = r.operation.ID
+	return nil
+}
+
+func (r queuedOperationRepository) UpdateStatus(id uint, status models.BulkOperationStatus) error {
+	return nil
+}
+
+// queuedChunks decodes the queued request of operation and splits it into
+// chunks of models.BulkChunkSize.
+func queuedChunks(operation *models.BulkOperation) ([]bulkChunk, error) {
+	userID, resourceType := operation.UserID, operation.ResourceType
+	decode := func(request interface{}) error {
+		return json.Unmarshal([]byte(operation.Request), request)
+	}
+
+	switch operation.Type {
+	case models.BulkCreate:
+		var request models.BulkCreateRequest
+		if err := decode(&request); err != nil {
+			return nil, err
+		}
+		return splitBulkChunks(len(request.Items), nil, func(from, to int) func(*bulkOperationService) (*models.BulkResponse, error) {
+			return func(engine *bulkOperationService) (*models.BulkResponse, error) {
+				return engine.ProcessBulkCreate(userID, resourceType, &models.BulkCreateRequest{Items: request.Items[from:to]})
+			}
+		}), nil
+	case models.BulkUpdate:
+		var request models.BulkUpdateRequest
+		if err := decode(&request); err != nil {
+			return nil, err
+		}
+		ids := make([]uint, len(request.Items))
+		for i, item := range request.Items {
+			ids[i] = item.ID
+		}
+		return splitBulkChunks(len(request.Items), ids, func(from, to int) func(*bulkOperationService) (*models.BulkResponse, error) {
+			return func(engine *bulkOperationService) (*models.BulkResponse, error) {
+				return engine.ProcessBulkUpdate(userID, resourceType, &models.BulkUpdateRequest{Items: request.Items[from:to]})
+			}
+		}), nil
+	case models.BulkDelete:
+		var request models.BulkDeleteRequest
+		if err := decode(&request); err != nil {
+			return nil, err
+		}
+		return splitBulkChunks(len(request.IDs), request.IDs, func(from, to int) func(*bulkOperationService) (*models.BulkResponse, error) {
+			return func(engine *bulkOperationService) (*models.BulkResponse, error) {
+				return engine.ProcessBulkDelete(userID, resourceType, &models.BulkDeleteRequest{IDs: request.IDs[from:to]})
+			}
+		}), nil
+	case models.BulkAction:
+		var request models.BulkActionRequest
+		if err := decode(&request); err != nil {
+			return nil, err
+		}
+		return splitBulkChunks(len(request.IDs), request.IDs, func(from, to int) func(*bulkOperationService) (*models.BulkResponse, error) {
+			return func(engine *bulkOperationService) (*models.BulkResponse, error) {
+				chunk := request
+				chunk.IDs = request.IDs[from:to]
+				return engine.ProcessBulkAction(userID, resourceType, &chunk)
+			}
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown operation type %q", operation.Type)
+	}
+}
+
+// splitBulkChunks splits total items into chunks; ids, when given, are the
+// records the items change, one per item.
+func splitBulkChunks(total int, ids []uint, chunk func(from, to int) func(*bulkOperationService) (*models.BulkResponse, error)) []bulkChunk {
+	chunks := make([]bulkChunk, 0, (total+models.BulkChunkSize-1)/models.BulkChunkSize)
+	for from := 0; from < total; from += models.BulkChunkSize {
+		to := from + models.BulkChunkSize
+		if to > total {
+			to = total
+		}
+		next := bulkChunk{offset: from, size: to - from, run: chunk(from, to)}
+		if ids != nil {
+			next.ids = ids[from:to]
+		}
+		chunks = append(chunks, next)
+	}
+	return chunks
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"time"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"gorm.io/gorm"
+)
+
+func (s *BulkOperationPersistenceSuite) operation(id uint) *models.BulkOperation {
+	var operation models.BulkOperation
+	s.Require().NoError(s.db.First(&operation, id).Error)
+	return &operation
+}
+
+func (s *BulkOperationPersistenceSuite) failedItems(id uint) []models.BulkOperationItem {
+	items, err := repository.NewBulkOperationRepository(s.db).GetFailedItems(id)
+	s.Require().NoError(err)
+	return items
+}
+
+// TestQueuedCreate_RunsChunkByChunk queues 250 users with a duplicate in the
+// second chunk. Nothing is written when the operation is queued; when it runs,
+// the second chunk rolls back on its own while the first and third commit, and
+// the counters say exactly that.
+func (s *BulkOperationPersistenceSuite) TestQueuedCreate_RunsChunkByChunk() {
+	items := make([]map[string]interface{}, 0, 250)
+	for i := 0; i < 250; i++ {
+		items = append(items, userItem(i))
+	}
+	items[120]["email"] = items[5]["email"]
+
+	queued, err := s.service.QueueBulkCreate(s.actorID, models.BuiltInPermissions(models.RoleAdmin), "users", &models.BulkCreateRequest{Items: items})
+	s.Require().NoError(err)
+	s.Equal(models.StatusPending, queued.Status)
+	s.Equal(models.BulkCreate, queued.Type)
+	s.Equal(250, queued.TotalItems)
+	s.Equal(int64(1), s.count(&models.User{}), "queueing must not run anything")
+
+	ran, err := s.service.RunNextQueued()
+	s.Require().NoError(err)
+	s.True(ran)
+
+	operation := s.operation(queued.ID)
+	s.Equal(models.StatusPartial, operation.Status)
+	s.Equal(150, operation.SuccessCount)
+	s.Equal(100, operation.FailureCount)
+	s.NotNil(operation.StartedAt)
+	s.NotNil(operation.FinishedAt)
+	s.Empty(operation.Request, "the queued request is dropped once the operation finishes")
+	s.Equal(int64(151), s.count(&models.User{}))
+	s.NotEmpty(s.failedItems(queued.ID))
+
+	ran, err = s.service.RunNextQueued()
+	s.Require().NoError(err)
+	s.False(ran, "a finished operation is never claimed again")
+}
+
+func (s *BulkOperationPersistenceSuite) TestQueue_RefusesInvalidRequests() {
+	_, err := s.service.QueueBulkCreate(s.actorID, models.BuiltInPermissions(models.RoleAdmin), "deals", &models.BulkCreateRequest{Items: []map[string]interface{}{{}}})
+	s.ErrorIs(err, apperrors.ErrValidation)
+
+	_, err = s.service.QueueBulkDelete(s.actorID, models.BuiltInPermissions(models.RoleAdmin), "leads", &models.BulkDeleteRequest{})
+	s.ErrorIs(err, apperrors.ErrValidation)
+
+	_, err = s.service.QueueBulkDelete(s.actorID, models.BuiltInPermissions(models.RoleAdmin), "leads", &models.BulkDeleteRequest{IDs: make([]uint, models.MaxBulkItems+1)})
+	s.ErrorIs(err, apperrors.ErrValidation)
+
+	_, err = s.service.QueueBulkAction(s.actorID, models.BuiltInPermissions(models.RoleAdmin), "leads", &models.BulkActionRequest{IDs: []uint{1}, Action: "explode"})
+	s.ErrorIs(err, apperrors.ErrValidation)
+
+	s.Equal(int64(0), s.count(&models.BulkOperation{}))
+}
+
+// TestQueuedAction_EngineErrorFailsTheRest covers an error that is not about
+// any one item: every item not yet run is failed, for that reason.
+func (s *BulkOperationPersistenceSuite) TestQueuedAction_EngineErrorFailsTheRest() {
+	queued, err := s.service.QueueBulkAction(s.actorID, models.BuiltInPermissions(models.RoleAdmin), "leads", &models.BulkActionRequest{
+		IDs:    []uint{1, 2, 3},
+		Action: models.LeadBulkActionTypes.UpdateStatus,
+	})
+	s.Require().NoError(err)
+
+	_, err = s.service.RunNextQueued()
+	s.Require().NoError(err)
+
+	operation := s.operation(queued.ID)
+	s.Equal(models.StatusFailed, operation.Status)
+	s.Equal(0, operation.SuccessCount)
+	s.Equal(3, operation.FailureCount)
+	failed := s.failedItems(queued.ID)
+	s.Require().Len(failed, 1)
+	s.Equal("3 items were not processed: missing required parameter: status", failed[0].Error)
+}
+
+func (s *BulkOperationPersistenceSuite) TestCancel_QueuedOperationNeverRuns() {
+	first, err := s.service.QueueBulkCreate(s.actorID, models.BuiltInPermissions(models.RoleAdmin), "users", &models.BulkCreateRequest{Items: []map[string]interface{}{userItem(1)}})
+	s.Require().NoError(err)
+	second, err := s.service.QueueBulkCreate(s.actorID, models.BuiltInPermissions(models.RoleAdmin), "users", &models.BulkCreateRequest{Items: []map[string]interface{}{userItem(2)}})
+	s.Require().NoError(err)
+
+	cancelled, err := s.service.CancelBulkOperation(first.ID, s.actorID, models.BuiltInPermissions(models.RoleSales))
+	s.Require().NoError(err)
+	s.Equal(models.StatusCancelled, cancelled.Status)
+	s.NotNil(cancelled.FinishedAt)
+
+	ran, err := s.service.RunNextQueued()
+	s.Require().NoError(err)
+	s.True(ran)
+	s.Equal(models.StatusCompleted, s.operation(second.ID).Status)
+	s.Equal(models.StatusCancelled, s.operation(first.ID).Status)
+	s.Equal(int64(2), s.count(&models.User{}), "only the second operation's user is created")
+
+	_, err = s.service.CancelBulkOperation(first.ID, s.actorID, models.BuiltInPermissions(models.RoleSales))
+	s.ErrorIs(err, apperrors.ErrBulkOperationFinished)
+	_, err = s.service.CancelBulkOperation(second.ID, s.actorID, models.BuiltInPermissions(models.RoleSales))
+	s.ErrorIs(err, apperrors.ErrBulkOperationFinished)
+}
+
+func (s *BulkOperationPersistenceSuite) TestCancel_Refusals() {
+	queued, err := s.service.QueueBulkCreate(s.actorID, models.BuiltInPermissions(models.RoleAdmin), "users", &models.BulkCreateRequest{Items: []map[string]interface{}{userItem(1)}})
+	s.Require().NoError(err)
+
+	_, err = s.service.CancelBulkOperation(queued.ID, s.actorID+1, models.BuiltInPermissions(models.RoleSales))
+	s.ErrorIs(err, apperrors.ErrForbidden)
+
+	_, err = s.service.CancelBulkOperation(queued.ID+100, s.actorID, models.BuiltInPermissions(models.RoleAdmin))
+	s.True(apperrors.IsNotFound(err))
+
+	// An operation recorded by other means, such as an import, has no queued
+	// request and runs elsewhere; it cannot be cancelled from here.
+	recorded, err := s.service.CreateBulkOperation(s.actorID, "leads", models.BulkCreate, 1)
+	s.Require().NoError(err)
+	_, err = s.service.CancelBulkOperation(recorded.ID, s.actorID, models.BuiltInPermissions(models.RoleAdmin))
+	s.ErrorIs(err, apperrors.ErrValidation)
+
+	// An admin may cancel anyone's.
+	cancelled, err := s.service.CancelBulkOperation(queued.ID, s.actorID+1, models.BuiltInPermissions(models.RoleAdmin))
+	s.Require().NoError(err)
+	s.Equal(models.StatusCancelled, cancelled.Status)
+}
+
+// TestCancel_StopsARunningOperationBetweenChunks marks the operation
+// cancelled while its first chunk is being written. That chunk completes; the
+// next two never run, and the operation stays cancelled with the counters of
+// what was done.
+func (s *BulkOperationPersistenceSuite) TestCancel_StopsARunningOperationBetweenChunks() {
+	items := make([]map[string]interface{}, 0, 250)
+	for i := 0; i < 250; i++ {
+		items = append(items, userItem(i))
+	}
+	queued, err := s.service.QueueBulkCreate(s.actorID, models.BuiltInPermissions(models.RoleAdmin), "users", &models.BulkCreateRequest{Items: items})
+	s.Require().NoError(err)
+
+	cancelledOnce := false
+	s.Require().NoError(s.db.Callback().Create().After("gorm:create").Register("test:cancel_bulk", func(tx *gorm.DB) {
+		if cancelledOnce || tx.Statement.Table != "users" {
+			return
+		}
+		cancelledOnce = true
+		// Through the chunk's own transaction: an in-memory database is
+		// private to its connection, and the transaction holds it.
+		s.Require().NoError(tx.Session(&gorm.Session{NewDB: true}).Model(&models.BulkOperation{}).
+			Where("id = ?", queued.ID).Update("status", models.StatusCancelled).Error)
+	}))
+
+	_, err = s.service.RunNextQueued()
+	s.Require().NoError(err)
+
+	operation := s.operation(queued.ID)
+	s.Equal(models.StatusCancelled, operation.Status)
+	s.Equal(100, operation.SuccessCount)
+	s.Equal(0, operation.FailureCount)
+	s.NotNil(operation.FinishedAt)
+	s.Equal(int64(101), s.count(&models.User{}))
+}
+
+// TestReconcileInterrupted fails the queued operation a previous process was
+// running and leaves the rest alone: a pending one is still to run, and one
+// that was never queued is not the workers' to judge.
+func (s *BulkOperationPersistenceSuite) TestReconcileInterrupted() {
+	repo := repository.NewBulkOperationRepository(s.db)
+
+	interrupted, err := s.service.QueueBulkCreate(s.actorID, models.BuiltInPermissions(models.RoleAdmin), "users", &models.BulkCreateRequest{Items: []map[string]interface{}{userItem(1)}})
+	s.Require().NoError(err)
+	claimed, err := repo.ClaimNextQueued(time.Now().UTC())
+	s.Require().NoError(err)
+	s.Require().NotNil(claimed)
+	s.Equal(interrupted.ID, claimed.ID)
+
+	pending, err := s.service.QueueBulkCreate(s.actorID, models.BuiltInPermissions(models.RoleAdmin), "users", &models.BulkCreateRequest{Items: []map[string]interface{}{userItem(2)}})
+	s.Require().NoError(err)
+	recorded, err := s.service.CreateBulkOperation(s.actorID, "leads", models.BulkCreate, 1)
+	s.Require().NoError(err)
+	s.Require().NoError(repo.UpdateStatus(recorded.ID, models.StatusProcessing))
+
+	failed, err := s.service.ReconcileInterrupted()
+	s.Require().NoError(err)
+	s.Equal(int64(1), failed)
+
+	s.Equal(models.StatusFailed, s.operation(interrupted.ID).Status)
+	s.NotNil(s.operation(interrupted.ID).FinishedAt)
+	s.Equal(models.StatusPending, s.operation(pending.ID).Status)
+	s.Equal(models.StatusProcessing, s.operation(recorded.ID).Status)
+
+	ran, err := s.service.RunNextQueued()
+	s.Require().NoError(err)
+	s.True(ran)
+	s.Equal(models.StatusCompleted, s.operation(pending.ID).Status)
+}
+
+// TestQueued_WakesAWorker checks the signal the workers wait on.
+func (s *BulkOperationPersistenceSuite) TestQueued_WakesAWorker() {
+	_, err := s.service.QueueBulkDelete(s.actorID, models.BuiltInPermissions(models.RoleAdmin), "leads", &models.BulkDeleteRequest{IDs: []uint{1}})
+	s.Require().NoError(err)
+
+	select {
+	case <-s.service.Queued():
+	default:
+		s.Fail("queueing an operation did not wake a worker")
+	}
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+)
+
+// Undo of queued bulk operations.
+//
+// Every chunk of a queued update, delete or action reads the rows it names
+// before and after it runs, and records a completed item for each row it
+// changed, holding what it takes to put the row back (models.BulkItemSnapshot).
+// A revert puts back every row that is still as the operation left it, newest
+// item first, and names the rows it refused: changed since, erased, or changed
+// in a way nothing was kept of. A row is put back on its own, so one refusal
+// does not hold up the rest.
+//
+// A delete of users, leads or customers is a GDPR erasure. Its snapshot keeps
+// nothing of the row, and the revert refuses it; erasing a record later
+// likewise replaces every snapshot kept of it.
+
+// erasingDeletes are the resources whose delete is an erasure.
+var erasingDeletes = map[string]bool{"users": true, "leads": true, "customers": true}
+
+// readChunkRows reads the rows a chunk names. A read that fails leaves the
+// chunk without snapshots: it still runs, and cannot be reverted.
+func (s *bulkOperationService) readChunkRows(operation *models.BulkOperation, chunk bulkChunk) map[uint]models.BulkRowState {
+	if len(chunk.ids) == 0 {
+		return nil
+	}
+	states, err := s.bulkRepo.GetRowStates(operation.ResourceType, chunk.ids)
+	if err != nil {
+		utils.Logger.WithField("bulk_operation_id", operation.ID).WithError(err).Warn("Failed to snapshot bulk operation rows")
+		return nil
+	}
+	return states
+}
+
+// snapshotChunk compares the rows of a chunk before and after it ran and
+// returns a completed item for each one it changed.
+func (s *bulkOperationService) snapshotChunk(operation *models.BulkOperation, chunk bulkChunk, before, after map[uint]models.BulkRowState) []models.BulkOperationItem {
+	if before == nil || after == nil {
+		return nil
+	}
+
+	items := make([]models.BulkOperationItem, 0, len(before))
+	seen := make(map[uint]bool, len(chunk.ids))
+	for _, id := range chunk.ids {
+		prior, ok := before[id]
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		snapshot := repository.SnapshotChange(prior, after[id])
+		if snapshot == nil {
+			continue
+		}
+		if operation.Type == models.BulkDelete && erasingDeletes[operation.ResourceType] {
+			snapshot = &models.BulkItemSnapshot{Erased: true}
+		}
+		data, err := json.Marshal(snapshot)
+		if err != nil {
+			continue
+		}
+		items = append(items, models.BulkOperationItem{
+			OperationID: operation.ID,
+			ResourceID:  id,
+			Status:      models.StatusCompleted,
+			Data:        string(data),
+		})
+	}
+	return items
+}
+
+func (s *bulkOperationService) RevertBulkOperation(id uint, userID uint, permissions models.PermissionSet) (*models.BulkRevertResult, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("bulk_operation_id", id), "BulkOperationService", "RevertBulkOperation")
+
+	operation, err := s.bulkOperationRepo.GetByID(id)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		if isNotFound(err) {
+			return nil, fmt.Errorf("bulk operation %d: %w", id, apperrors.ErrNotFound)
+		}
+		return nil, err
+	}
+	if operation.UserID != userID && !permissions.Allows(models.PermBulkOperationsManage) {
+		return nil, fmt.Errorf("you can only revert your own bulk operations: %w", apperrors.ErrForbidden)
+	}
+	if !BulkAllowed(operation.ResourceType, operation.Type, permissions) {
+		verb := bulkScope{operationType: operation.Type}.verb()
+		return nil, fmt.Errorf("your role cannot %s %s in bulk: %w", verb, operation.ResourceType, apperrors.ErrForbidden)
+	}
+	if operation.Type == models.BulkCreate {
+		return nil, fmt.Errorf("bulk operation %d is a create, which cannot be reverted: %w", id, apperrors.ErrValidation)
+	}
+	if operation.RevertedAt != nil {
+		return nil, fmt.Errorf("bulk operation %d: %w", id, apperrors.ErrBulkOperationReverted)
+	}
+	if operation.FinishedAt == nil {
+		return nil, fmt.Errorf("bulk operation %d has not finished: %w", id, apperrors.ErrValidation)
+	}
+	now := time.Now().UTC()
+	if now.Sub(*operation.FinishedAt) > s.revertWindow {
+		return nil, fmt.Errorf("bulk operation %d finished more than %s ago: %w", id, s.revertWindow, apperrors.ErrBulkRevertExpired)
+	}
+
+	items, err := s.bulkOperationRepo.GetSnapshotItems(id)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	// Marked first, so that of two reverts only one puts anything back.
+	marked, err := s.bulkOperationRepo.MarkReverted(id, now)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	if !marked {
+		return nil, fmt.Errorf("bulk operation %d: %w", id, apperrors.ErrBulkOperationReverted)
+	}
+
+	reverter := s.bulkReverter(userID)
+	result := &models.BulkRevertResult{OperationID: id, Refused: []models.BulkRevertRefusal{}}
+	for i := len(items) - 1; i >= 0; i-- {
+		item := items[i]
+		if refusal := s.revertItem(operation, reverter, item); refusal != nil {
+			result.Refused = append(result.Refused, *refusal)
+			continue
+		}
+		result.Reverted++
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"reverted": result.Reverted,
+		"refused":  len(result.Refused),
+	}).Info("Bulk operation reverted")
+	return result, nil
+}
+
+// revertItem puts back the row of one item, or says why it did not.
+func (s *bulkOperationService) revertItem(operation *models.BulkOperation, reverter bulkReverter, item models.BulkOperationItem) *models.BulkRevertRefusal {
+	refuse := func(reason, message string) *models.BulkRevertRefusal {
+		return &models.BulkRevertRefusal{ResourceID: item.ResourceID, Reason: reason, Message: message}
+	}
+
+	var snapshot models.BulkItemSnapshot
+	if err := json.Unmarshal([]byte(item.Data), &snapshot); err != nil {
+		return refuse("failed", "the snapshot is unreadable")
+	}
+	switch {
+	case snapshot.Erased:
+		return refuse("erased", "the record was erased")
+	case snapshot.Irreversible != "":
+		return refuse("irreversible", snapshot.Irreversible)
+	}
+
+	err := s.bulkRepo.RestoreRow(operation.ResourceType, item.ResourceID, &snapshot)
+	switch {
+	case errors.Is(err, apperrors.ErrBulkRowChanged):
+		return refuse("changed", "the record was changed after the operation")
+	case err != nil:
+		return refuse("failed", err.Error())
+	}
+	s.recordRestore(operation, reverter, item.ResourceID, &snapshot)
+	return nil
+}
+
+// bulkReverter is the user reverting an operation, to whom every row it puts
+// back is attributed.
+type bulkReverter struct {
+	userID uint
+	name   string
+}
+
+func (s *bulkOperationService) bulkReverter(userID uint) bulkReverter {
+	reverter := bulkReverter{userID: userID, name: fmt.Sprintf("user #%d", userID)}
+	if user, err := s.userRepo.GetByID(userID); err == nil {
+		reverter.name = user.FullName()
+	}
+	return reverter
+}
+
+// recordRestore tells the audit trail, the activity feed and the webhook
+// subscribers about a row a revert put back, as the entity services do for
+// the changes they make. The row is read again once restored; what it held
+// before is that state with the columns the operation had left, which
+// RestoreRow checked were still there. A deleted row put back is created
+// again.
+func (s *bulkOperationService) recordRestore(operation *models.BulkOperation, reverter bulkReverter, id uint, snapshot *models.BulkItemSnapshot) {
+	if s.auditService == nil && s.activity == nil && s.webhooks == nil {
+		return
+	}
+	states, err := s.bulkRepo.GetRowStates(operation.ResourceType, []uint{id})
+	restored, ok := states[id]
+	if err != nil || !ok {
+		utils.Logger.WithField("bulk_operation_id", operation.ID).WithField("resource_id", id).
+			WithError(err).Warn("Failed to read a reverted row")
+		return
+	}
+	record := decodeRowState(operation.ResourceType, restored)
+	undeleted := operation.Type == models.BulkDelete
+
+	actor := userAuditActor(reverter.userID)
+	entityType := bulkAuditEntities[operation.ResourceType]
+	if undeleted {
+		s.recordAudit(actor, entityType, id, models.AuditActionCreate, nil, record)
+	} else {
+		left := make(models.BulkRowState, len(restored))
+		for column, value := range restored {
+			left[column] = value
+		}
+		for column, value := range snapshot.After {
+			left[column] = value
+		}
+		s.recordAudit(actor, entityType, id, models.AuditActionUpdate, decodeRowState(operation.ResourceType, left), record)
+	}
+
+	how := fmt.Sprintf("was put back by %s, reverting bulk operation #%d", reverter.name, operation.ID)
+	title := "reverted"
+	if undeleted {
+		title = "restored"
+	}
+	event := func(created, updated models.WebhookEvent) models.WebhookEvent {
+		if undeleted {
+			return created
+		}
+		return updated
+	}
+	switch record := record.(type) {
+	case *models.Lead:
+		s.recordActivity(models.AuditEntityLead, id, models.ActivityLeadUpdated, activityOwner(record.OwnerID),
+			"Lead "+title, describeLead(record)+" "+how)
+		s.publishWebhook(event(models.WebhookLeadCreated, models.WebhookLeadUpdated), models.AuditEntityLead, id, leadWebhookData(record))
+	case *models.Customer:
+		s.recordActivity(models.AuditEntityCustomer, id, models.ActivityCustomerUpdated, record.AssignedToID,
+			"Customer "+title, describeCustomer(record)+" "+how)
+		s.publishWebhook(event(models.WebhookCustomerCreated, models.WebhookCustomerUpdated), models.AuditEntityCustomer, id, customerWebhookData(record))
+	case *models.Ticket:
+		s.recordActivity(models.AuditEntityTicket, id, models.ActivityTicketUpdated, record.AssignedToID,
+			"Ticket "+title, record.Title+" "+how)
+		s.publishWebhook(event(models.WebhookTicketCreated, models.WebhookTicketUpdated), models.AuditEntityTicket, id, ticketWebhookData(record))
+	case *models.Task:
+		s.recordActivity(models.AuditEntityTask, id, models.ActivityTaskUpdated, activityOwner(record.AssignedToID),
+			"Task "+title, record.Title+" "+how)
+	}
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+// runQueuedOperation runs the one queued operation and returns it finished.
+func (s *BulkOperationPersistenceSuite) runQueuedOperation(queued *models.BulkOperation, err error) *models.BulkOperation {
+	s.Require().NoError(err)
+	ran, err := s.service.RunNextQueued()
+	s.Require().NoError(err)
+	s.Require().True(ran)
+	return s.operation(queued.ID)
+}
+
+func (s *BulkOperationPersistenceSuite) snapshotItems(id uint) []models.BulkOperationItem {
+	items, err := repository.NewBulkOperationRepository(s.db).GetSnapshotItems(id)
+	s.Require().NoError(err)
+	return items
+}
+
+func (s *BulkOperationPersistenceSuite) TestRevert_PutsBackAnActionAndADelete() {
+	first := s.taskAssignedTo(s.actorID, models.TaskStatusPending)
+	second := s.taskAssignedTo(s.actorID, models.TaskStatusPending)
+	third := s.taskAssignedTo(s.actorID, models.TaskStatusPending)
+
+	action := s.runQueuedOperation(s.service.QueueBulkAction(s.actorID, models.BuiltInPermissions(models.RoleAdmin), "tasks", &models.BulkActionRequest{
+		IDs:        []uint{first, second, 999},
+		Action:     models.TaskBulkActionTypes.UpdatePriority,
+		Parameters: map[string]interface{}{"priority": "high"},
+	}))
+	items := s.snapshotItems(action.ID)
+	s.Require().Len(items, 2, "one snapshot per row the action changed")
+	var snapshot models.BulkItemSnapshot
+	s.Require().NoError(json.Unmarshal([]byte(items[0].Data), &snapshot))
+	s.Equal(first, items[0].ResourceID)
+	s.JSONEq(`"medium"`, string(snapshot.Before["priority"]))
+
+	deleted := s.runQueuedOperation(s.service.QueueBulkDelete(s.actorID, models.BuiltInPermissions(models.RoleAdmin), "tasks", &models.BulkDeleteRequest{IDs: []uint{third}}))
+
+	result, err := s.service.RevertBulkOperation(deleted.ID, s.actorID, models.BuiltInPermissions(models.RoleAdmin))
+	s.Require().NoError(err)
+	s.Equal(1, result.Reverted)
+	s.Empty(result.Refused)
+
+	result, err = s.service.RevertBulkOperation(action.ID, s.actorID, models.BuiltInPermissions(models.RoleAdmin))
+	s.Require().NoError(err)
+	s.Equal(2, result.Reverted)
+	s.Empty(result.Refused)
+
+	var tasks []models.Task
+	s.Require().NoError(s.db.Order("id").Find(&tasks, []uint{first, second, third}).Error)
+	s.Require().Len(tasks, 3, "the deleted task is back")
+	for _, task := range tasks {
+		s.Equal(models.TaskPriorityMedium, task.Priority)
+	}
+	s.NotNil(s.operation(action.ID).RevertedAt)
+	s.Empty(s.snapshotItems(action.ID), "the snapshots go once they are used")
+}
+
+// TestRevert_AnnouncesEveryRowItPutsBack reverts an action and a delete of
+// tickets as another user: each row put back reaches the activity feed and
+// the webhook subscribers, in the reverting user's name.
+func (s *BulkOperationPersistenceSuite) TestRevert_AnnouncesEveryRowItPutsBack() {
+	webhooks := new(mocks.WebhookService)
+	defer webhooks.AssertExpectations(s.T())
+	s.service = s.newService(WithActivityFeed(NewActivityService(repository.NewActivityRepository(s.db))), WithWebhooks(webhooks))
+	admin := models.BuiltInPermissions(models.RoleAdmin)
+
+	assignee := s.staffUser(models.RoleSupport)
+	changed := s.ticketAssignedTo(assignee, models.TicketStatusOpen)
+	deleted := s.ticketAssignedTo(assignee, models.TicketStatusOpen)
+	action := s.runQueuedOperation(s.service.QueueBulkAction(s.actorID, admin, "tickets", &models.BulkActionRequest{
+		IDs:        []uint{changed},
+		Action:     models.TicketBulkActionTypes.UpdatePriority,
+		Parameters: map[string]interface{}{"priority": "high"},
+	}))
+	deletion := s.runQueuedOperation(s.service.QueueBulkDelete(s.actorID, admin, "tickets", &models.BulkDeleteRequest{IDs: []uint{deleted}}))
+
+	webhooks.On("Publish", models.WebhookTicketUpdated, models.AuditEntityTicket, changed, mock.MatchedBy(func(data interface{}) bool {
+		return data.(map[string]interface{})["priority"] == string(models.TicketPriorityMedium)
+	})).Return(nil).Once()
+	webhooks.On("Publish", models.WebhookTicketCreated, models.AuditEntityTicket, deleted, mock.Anything).Return(nil).Once()
+
+	reverter := s.staffUser(models.RoleAdmin)
+	_, err := s.service.RevertBulkOperation(action.ID, reverter, admin)
+	s.Require().NoError(err)
+	_, err = s.service.RevertBulkOperation(deletion.ID, reverter, admin)
+	s.Require().NoError(err)
+
+	var events []models.ActivityEvent
+	s.Require().NoError(s.db.Order("id").Find(&events).Error)
+	s.Require().Len(events, 2)
+	s.Equal(changed, events[0].EntityID)
+	s.Equal("Ticket reverted", events[0].Title)
+	s.Equal(fmt.Sprintf("Bulk ticket was put back by Bulk admin, reverting bulk operation #%d", action.ID), events[0].Description)
+	s.Equal(deleted, events[1].EntityID)
+	s.Equal("Ticket restored", events[1].Title)
+	for _, event := range events {
+		s.Equal(models.ActivityTicketUpdated, event.Type)
+		s.Equal(assignee, *event.OwnerID)
+	}
+}
+
+// TestRevert_LeavesChangedAndErasedRecords reverts an update after one of its
+// leads was changed and another erased: only the untouched one is put back.
+func (s *BulkOperationPersistenceSuite) TestRevert_LeavesChangedAndErasedRecords() {
+	s.Require().NoError(s.db.Create(&models.CustomFieldDefinition{
+		EntityType: models.AuditEntityLead, Name: "region", Label: "Region", Type: models.CustomFieldText,
+	}).Error)
+	untouched := s.leadOwnedBy(s.actorID)
+	changed := s.leadOwnedBy(s.actorID)
+	erased := s.leadOwnedBy(s.actorID)
+
+	items := make([]models.BulkUpdateItem, 0, 3)
+	for _, id := range []uint{untouched, changed, erased} {
+		items = append(items, models.BulkUpdateItem{ID: id, Updates: map[string]interface{}{
+			"status":        "contacted",
+			"custom_fields": map[string]interface{}{"region": "EMEA"},
+		}})
+	}
+	update := s.runQueuedOperation(s.service.QueueBulkUpdate(s.actorID, models.BuiltInPermissions(models.RoleAdmin), "leads", &models.BulkUpdateRequest{Items: items}))
+	s.Require().Len(s.snapshotItems(update.ID), 3)
+
+	s.Require().NoError(s.db.Model(&models.Lead{}).Where("id = ?", changed).Update("status", models.LeadStatusQualified).Error)
+	s.Require().NoError(repository.NewLeadRepository(s.db).Delete(erased))
+
+	result, err := s.service.RevertBulkOperation(update.ID, s.actorID, models.BuiltInPermissions(models.RoleAdmin))
+	s.Require().NoError(err)
+	s.Equal(1, result.Reverted)
+	s.ElementsMatch([]models.BulkRevertRefusal{
+		{ResourceID: changed, Reason: "changed", Message: "the record was changed after the operation"},
+		{ResourceID: erased, Reason: "erased", Message: "the record was erased"},
+	}, result.Refused)
+
+	var lead models.Lead
+	s.Require().NoError(s.db.First(&lead, untouched).Error)
+	s.Equal(models.LeadStatusNew, lead.Status)
+	var values int64
+	s.Require().NoError(s.db.Model(&models.CustomFieldValue{}).Where("entity_id = ?", untouched).Count(&values).Error)
+	s.Zero(values, "the custom field is back to having no value")
+	var later models.Lead
+	s.Require().NoError(s.db.First(&later, changed).Error)
+	s.Equal(models.LeadStatusQualified, later.Status, "a later change is never overwritten")
+}
+
+func (s *BulkOperationPersistenceSuite) TestRevert_Refusals() {
+	salesID := s.staffUser(models.RoleSales)
+	lead := s.leadOwnedBy(s.actorID)
+
+	created := s.runQueuedOperation(s.service.QueueBulkCreate(s.actorID, models.BuiltInPermissions(models.RoleAdmin), "leads", &models.BulkCreateRequest{
+		Items: []map[string]interface{}{{"first_name": "New", "last_name": "Lead", "email": "new@example.com"}},
+	}))
+	_, err := s.service.RevertBulkOperation(created.ID, s.actorID, models.BuiltInPermissions(models.RoleAdmin))
+	s.ErrorIs(err, apperrors.ErrValidation, "a create is not reverted")
+
+	update := s.runQueuedOperation(s.service.QueueBulkUpdate(s.actorID, models.BuiltInPermissions(models.RoleAdmin), "leads", &models.BulkUpdateRequest{
+		Items: []models.BulkUpdateItem{{ID: lead, Updates: map[string]interface{}{"source": "import"}}},
+	}))
+	_, err = s.service.RevertBulkOperation(update.ID, salesID, models.BuiltInPermissions(models.RoleSales))
+	s.ErrorIs(err, apperrors.ErrForbidden, "only whoever ran it, or an admin")
+
+	_, err = s.service.RevertBulkOperation(404, s.actorID, models.BuiltInPermissions(models.RoleAdmin))
+	s.ErrorIs(err, apperrors.ErrNotFound)
+
+	finished := time.Now().UTC().Add(-25 * time.Hour)
+	s.Require().NoError(s.db.Model(&models.BulkOperation{}).Where("id = ?", update.ID).Update("finished_at", finished).Error)
+	_, err = s.service.RevertBulkOperation(update.ID, s.actorID, models.BuiltInPermissions(models.RoleAdmin))
+	s.ErrorIs(err, apperrors.ErrBulkRevertExpired)
+
+	erasure := s.runQueuedOperation(s.service.QueueBulkDelete(s.actorID, models.BuiltInPermissions(models.RoleAdmin), "leads", &models.BulkDeleteRequest{IDs: []uint{lead}}))
+	result, err := s.service.RevertBulkOperation(erasure.ID, s.actorID, models.BuiltInPermissions(models.RoleAdmin))
+	s.Require().NoError(err)
+	s.Equal(0, result.Reverted)
+	s.Equal([]models.BulkRevertRefusal{{ResourceID: lead, Reason: "erased", Message: "the record was erased"}}, result.Refused)
+
+	_, err = s.service.RevertBulkOperation(erasure.ID, s.actorID, models.BuiltInPermissions(models.RoleAdmin))
+	s.ErrorIs(err, apperrors.ErrBulkOperationReverted)
+}
+
+// TestRevert_PasswordChangeIsIrreversible keeps password hashes out of the
+// snapshots: a user whose password the operation changed is left alone.
+func (s *BulkOperationPersistenceSuite) TestRevert_PasswordChangeIsIrreversible() {
+	userID := s.staffUser(models.RoleSupport)
+
+	update := s.runQueuedOperation(s.service.QueueBulkUpdate(s.actorID, models.BuiltInPermissions(models.RoleAdmin), "users", &models.BulkUpdateRequest{
+		Items: []models.BulkUpdateItem{{ID: userID, Updates: map[string]interface{}{"password": "rehashed", "first_name": "Renamed"}}},
+	}))
+	items := s.snapshotItems(update.ID)
+	s.Require().Len(items, 1)
+	s.NotContains(items[0].Data, "hashed")
+
+	result, err := s.service.RevertBulkOperation(update.ID, s.actorID, models.BuiltInPermissions(models.RoleAdmin))
+	s.Require().NoError(err)
+	s.Equal([]models.BulkRevertRefusal{{ResourceID: userID, Reason: "irreversible", Message: "the password was changed"}}, result.Refused)
+}
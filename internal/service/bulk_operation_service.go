@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/florinel-chis/gophercrm/internal/config"
 	"github.com/florinel-chis/gophercrm/internal/models"
 	"github.com/florinel-chis/gophercrm/internal/repository"
 	"github.com/florinel-chis/gophercrm/internal/utils"
@@ -51,6 +52,15 @@ type bulkOperationService struct {
 	ticketRepo        repository.TicketRepository
 	transactionMgr    repository.TransactionManager
 	logger            *logrus.Logger
+	auditRecorder
+	activityFeed
+	webhookEvents
+	// revertWindow is how long after it finishes a queued update, delete or
+	// action can be reverted.
+	revertWindow time.Duration
+	// queued wakes a worker when an operation is queued. It holds one
+	// signal: a worker that wakes runs every queued operation it can claim.
+	queued chan struct{}
 }
 
 func NewBulkOperationService(
@@ -62,9 +72,12 @@ func NewBulkOperationService(
 	taskRepo repository.TaskRepository,
 	ticketRepo repository.TicketRepository,
 	transactionMgr repository.TransactionManager,
+	auditService AuditService,
 	logger *logrus.Logger,
+	cfg config.BulkConfig,
+	opts ...EntityOption,
 ) BulkOperationService {
-	return &bulkOperationService{
+	s := &bulkOperationService{
 		bulkOperationRepo: bulkOperationRepo,
 		bulkRepo:          bulkRepo,
 		userRepo:          userRepo,
@@ -74,7 +87,12 @@ func NewBulkOperationService(
 		ticketRepo:        ticketRepo,
 		transactionMgr:    transactionMgr,
 		logger:            logger,
+		auditRecorder:     auditRecorder{auditService: auditService},
+		revertWindow:      time.Duration(cfg.RevertWindowHours) * time.Hour,
+		queued:            make(chan struct{}, 1),
 	}
+	applyEntityOptions(&s.activityFeed, &s.webhookEvents, opts)
+	return s
 }
 
 // Bulk operation management
@@ -563,6 +581,7 @@ func (s *bulkOperationService) BulkCreateLeads(request *models.BulkCreateRequest
 			})
 			continue
 		}
+		requireCustomFields(&lead)
 
 		// Set defaults and owner
 		if lead.Status == "" {
@@ -785,6 +804,7 @@ func (s *bulkOperationService) BulkCreateCustomers(request *models.BulkCreateReq
 			})
 			continue
 		}
+		requireCustomFields(&customer)
 
 		// Set defaults - customer doesn't have type field in current model
 		// customer.IsActive = true // Customer doesn't have IsActive field
@@ -1002,6 +1022,7 @@ func (s *bulkOperationService) BulkCreateTasks(request *models.BulkCreateRequest
 			})
 			continue
 		}
+		requireCustomFields(&task)
 
 		// Set defaults
 		if task.Status == "" {
@@ -1228,6 +1249,7 @@ func (s *bulkOperationService) BulkCreateTickets(request *models.BulkCreateReque
 			})
 			continue
 		}
+		requireCustomFields(&ticket)
 
 		// Set defaults
 		if ticket.Status == "" {
@@ -1477,6 +1499,14 @@ func (s *bulkOperationService) validateBulkRequest(itemCount int) error {
 	return nil
 }
 
+// requireCustomFields gives a record whose item sent no custom fields an empty
+// map, so that it is checked for required fields the way a single create is.
+func requireCustomFields(record models.CustomFieldRecord) {
+	if record.CustomFieldValues() == nil {
+		record.SetCustomFieldValues(map[string]interface{}{})
+	}
+}
+
 func (s *bulkOperationService) convertMapToModel(data map[string]interface{}, model interface{}) error {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
@@ -3,6 +3,7 @@ package service
 import (
 	"testing"
 
+	"github.com/florinel-chis/gophercrm/internal/config"
 	"github.com/florinel-chis/gophercrm/internal/models"
 	"github.com/florinel-chis/gophercrm/internal/mocks"
 	"github.com/sirupsen/logrus"
@@ -32,7 +33,9 @@ func TestBulkOperationService_ValidateBulkRequest(t *testing.T) {
 		mockTaskRepo,
 		mockTicketRepo,
 		mockTransactionMgr,
+		nil,
 		logger,
+		config.BulkConfig{},
 	)
 
 	bulkService := service.(*bulkOperationService)
@@ -103,7 +106,9 @@ func TestBulkOperationService_CreateBulkOperation(t *testing.T) {
 		mockTaskRepo,
 		mockTicketRepo,
 		mockTransactionMgr,
+		nil,
 		logger,
+		config.BulkConfig{},
 	)
 
 	// Set up expectations
@@ -159,7 +164,9 @@ func TestBulkOperationService_ProcessBulkCreate_InvalidResourceType(t *testing.T
 		mockTaskRepo,
 		mockTicketRepo,
 		mockTransactionMgr,
+		nil,
 		logger,
+		config.BulkConfig{},
 	)
 
 	request := &models.BulkCreateRequest{
@@ -199,7 +206,9 @@ func TestBulkOperationService_ConvertMapToModel(t *testing.T) {
 		mockTaskRepo,
 		mockTicketRepo,
 		mockTransactionMgr,
+		nil,
 		logger,
+		config.BulkConfig{},
 	)
 
 	bulkService := service.(*bulkOperationService)
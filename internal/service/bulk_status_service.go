@@ -43,6 +43,16 @@ func bulkStatusNotFound(resource string, ids []uint) error {
 		fmt.Sprintf("One or more %s were not found", resource)).WithDetail("missing_ids", ids)
 }
 
+func bulkClosedTickets(ids []uint) error {
+	return apperrors.Wrap(apperrors.ErrClosedTicketReopen, apperrors.CodeInvalidStatusTransition,
+		"Cannot reopen closed tickets").WithDetail("closed_ids", ids)
+}
+
+func bulkCompletedTasks(ids []uint) error {
+	return apperrors.Wrap(apperrors.ErrCompletedTaskModify, apperrors.CodeInvalidStatusTransition,
+		"Cannot change the status of completed tasks").WithDetail("completed_ids", ids)
+}
+
 func bulkStatusInvalidInput(message string) *apperrors.AppError {
 	return apperrors.New(apperrors.CodeInvalidInput, message)
 }
@@ -154,10 +164,10 @@ func (s *bulkOperationService) recordBulkStatusOutcome(operationID uint, status
 
 // BulkSetLeadStatus sets one status on every listed lead.
 //
-// Admins may update any lead; sales users only leads they own, and a batch
-// containing even one lead they do not own is refused in full, naming those
-// leads. Support and customer users have no access to leads at all.
-func (s *bulkOperationService) BulkSetLeadStatus(actorID uint, actorRole models.UserRole, ids []uint, status models.LeadStatus) (*models.BulkStatusUpdateResult, error) {
+// It takes leads:update. Without leads:update:any only leads the caller owns
+// may be updated, and a batch containing even one lead they do not own is
+// refused in full, naming those leads.
+func (s *bulkOperationService) BulkSetLeadStatus(actorID uint, permissions models.PermissionSet, ids []uint, status models.LeadStatus) (*models.BulkStatusUpdateResult, error) {
 	unique, err := normalizeBulkStatusIDs(ids)
 	if err != nil {
 		return nil, err
@@ -165,9 +175,10 @@ func (s *bulkOperationService) BulkSetLeadStatus(actorID uint, actorRole models.
 	if !isValidLeadStatus(status) {
 		return nil, bulkStatusInvalidInput(fmt.Sprintf("Invalid lead status: %s", status))
 	}
-	if actorRole != models.RoleAdmin && actorRole != models.RoleSales {
-		return nil, bulkStatusForbidden("Only sales and admin users can update leads", nil)
+	if !permissions.Allows(models.PermLeadsUpdate) {
+		return nil, bulkStatusForbidden("Insufficient permissions to update leads", nil)
 	}
+	anyLead := permissions.Allows(models.PermLeadsUpdateAny)
 
 	return s.runBulkStatusUpdate(actorID, "leads", unique, func(txRepo repository.BulkRepository) error {
 		leads, err := txRepo.GetLeadsByIDs(unique)
@@ -179,7 +190,7 @@ func (s *bulkOperationService) BulkSetLeadStatus(actorID uint, actorRole models.
 		notOwned := make([]uint, 0)
 		for _, lead := range leads {
 			found[lead.ID] = struct{}{}
-			if actorRole == models.RoleSales && lead.OwnerID != actorID {
+			if !anyLead && lead.OwnerID != actorID {
 				notOwned = append(notOwned, lead.ID)
 			}
 		}
@@ -197,12 +208,11 @@ func (s *bulkOperationService) BulkSetLeadStatus(actorID uint, actorRole models.
 
 // BulkSetTicketStatus sets one status on every listed ticket.
 //
-// Admins may update any ticket, support users only tickets assigned to them.
-// Sales users are read-only on tickets and customers cannot update them at all,
-// so both are refused outright. A closed ticket cannot be reopened here either:
-// the single-record update refuses that transition, and the bulk endpoint must
-// not be a way around it.
-func (s *bulkOperationService) BulkSetTicketStatus(actorID uint, actorRole models.UserRole, ids []uint, status models.TicketStatus) (*models.BulkStatusUpdateResult, error) {
+// It takes tickets:update, and without tickets:update:any only tickets
+// assigned to the caller may be updated. A closed ticket cannot be reopened
+// here either: the single-record update refuses that transition, and the bulk
+// endpoint must not be a way around it.
+func (s *bulkOperationService) BulkSetTicketStatus(actorID uint, permissions models.PermissionSet, ids []uint, status models.TicketStatus) (*models.BulkStatusUpdateResult, error) {
 	unique, err := normalizeBulkStatusIDs(ids)
 	if err != nil {
 		return nil, err
@@ -210,15 +220,10 @@ func (s *bulkOperationService) BulkSetTicketStatus(actorID uint, actorRole model
 	if !isValidTicketStatus(status) {
 		return nil, bulkStatusInvalidInput(fmt.Sprintf("Invalid ticket status: %s", status))
 	}
-	switch actorRole {
-	case models.RoleAdmin, models.RoleSupport:
-	case models.RoleSales:
-		return nil, bulkStatusForbidden("Sales users cannot update tickets", nil)
-	case models.RoleCustomer:
-		return nil, bulkStatusForbidden("Customers cannot update tickets", nil)
-	default:
-		return nil, bulkStatusForbidden("Your role cannot update tickets", nil)
+	if !permissions.Allows(models.PermTicketsUpdate) {
+		return nil, bulkStatusForbidden("Insufficient permissions to update tickets", nil)
 	}
+	anyTicket := permissions.Allows(models.PermTicketsUpdateAny)
 
 	return s.runBulkStatusUpdate(actorID, "tickets", unique, func(txRepo repository.BulkRepository) error {
 		tickets, err := txRepo.GetTicketsByIDs(unique)
@@ -231,8 +236,7 @@ func (s *bulkOperationService) BulkSetTicketStatus(actorID uint, actorRole model
 		closed := make([]uint, 0)
 		for _, ticket := range tickets {
 			found[ticket.ID] = struct{}{}
-			if actorRole == models.RoleSupport &&
-				(ticket.AssignedToID == nil || *ticket.AssignedToID != actorID) {
+			if !anyTicket && (ticket.AssignedToID == nil || *ticket.AssignedToID != actorID) {
 				notAssigned = append(notAssigned, ticket.ID)
 			}
 			if ticket.Status == models.TicketStatusClosed && status != models.TicketStatusClosed {
@@ -247,8 +251,7 @@ func (s *bulkOperationService) BulkSetTicketStatus(actorID uint, actorRole model
 			return bulkStatusForbidden("You can only update tickets assigned to you", notAssigned)
 		}
 		if len(closed) > 0 {
-			return apperrors.Wrap(apperrors.ErrClosedTicketReopen, apperrors.CodeInvalidStatusTransition,
-				"Cannot reopen closed tickets").WithDetail("closed_ids", closed)
+			return bulkClosedTickets(closed)
 		}
 
 		return txRepo.SetTicketStatus(unique, status)
@@ -257,10 +260,10 @@ func (s *bulkOperationService) BulkSetTicketStatus(actorID uint, actorRole model
 
 // BulkSetTaskStatus sets one status on every listed task.
 //
-// Admins may update any task, everyone else only tasks assigned to them. A
-// completed task's status is final for admins too, exactly as it is through the
+// Without tasks:update:any only tasks assigned to the caller may be updated. A
+// completed task's status is final for everyone, exactly as it is through the
 // single-record update.
-func (s *bulkOperationService) BulkSetTaskStatus(actorID uint, actorRole models.UserRole, ids []uint, status models.TaskStatus) (*models.BulkStatusUpdateResult, error) {
+func (s *bulkOperationService) BulkSetTaskStatus(actorID uint, permissions models.PermissionSet, ids []uint, status models.TaskStatus) (*models.BulkStatusUpdateResult, error) {
 	unique, err := normalizeBulkStatusIDs(ids)
 	if err != nil {
 		return nil, err
@@ -269,6 +272,8 @@ func (s *bulkOperationService) BulkSetTaskStatus(actorID uint, actorRole models.
 		return nil, bulkStatusInvalidInput(fmt.Sprintf("Invalid task status: %s", status))
 	}
 
+	anyTask := permissions.Allows(models.PermTasksUpdateAny)
+
 	return s.runBulkStatusUpdate(actorID, "tasks", unique, func(txRepo repository.BulkRepository) error {
 		tasks, err := txRepo.GetTasksByIDs(unique)
 		if err != nil {
@@ -280,7 +285,7 @@ func (s *bulkOperationService) BulkSetTaskStatus(actorID uint, actorRole models.
 		completed := make([]uint, 0)
 		for _, task := range tasks {
 			found[task.ID] = struct{}{}
-			if actorRole != models.RoleAdmin && task.AssignedToID != actorID {
+			if !anyTask && task.AssignedToID != actorID {
 				notAssigned = append(notAssigned, task.ID)
 			}
 			if task.Status == models.TaskStatusCompleted && status != models.TaskStatusCompleted {
@@ -295,8 +300,7 @@ func (s *bulkOperationService) BulkSetTaskStatus(actorID uint, actorRole models.
 			return bulkStatusForbidden("You can only update tasks assigned to you", notAssigned)
 		}
 		if len(completed) > 0 {
-			return apperrors.Wrap(apperrors.ErrCompletedTaskModify, apperrors.CodeInvalidStatusTransition,
-				"Cannot change the status of completed tasks").WithDetail("completed_ids", completed)
+			return bulkCompletedTasks(completed)
 		}
 
 		return txRepo.SetTaskStatus(unique, status)
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+)
+
+// CustomFieldService manages the admin-defined custom fields of leads,
+// customers, tickets and tasks, and turns the custom field parameters of a
+// list request into a query the repositories understand. The values
+// themselves travel with the records, through repository.CustomFieldPlugin.
+type CustomFieldService interface {
+	// ListDefinitions returns the definitions of one entity type, or of every
+	// entity type when entityType is empty.
+	ListDefinitions(entityType string) ([]models.CustomFieldDefinition, error)
+	GetDefinition(id uint) (*models.CustomFieldDefinition, error)
+	// CreateDefinition validates the definition with ValidateDefinition and
+	// refuses a taken name or a full entity type with apperrors.ErrValidation.
+	CreateDefinition(definition *models.CustomFieldDefinition) error
+	// UpdateDefinition changes the label, required flag, options and position.
+	// The entity type, name and type are fixed once values may exist under
+	// them. Values stored under an option that is removed stay as they are.
+	UpdateDefinition(definition *models.CustomFieldDefinition) (*models.CustomFieldDefinition, error)
+	// DeleteDefinition deletes the definition and every value stored under it.
+	DeleteDefinition(id uint) error
+
+	// ResolveQuery maps field names to definitions of entityType. Every value
+	// of filters must be held by a record for it to match; sortField, when not
+	// empty, names the field to order by. An unknown field, a value the field
+	// could never hold and a sort on a multi_select field are
+	// apperrors.ErrValidation.
+	ResolveQuery(entityType string, filters map[string][]string, sortField, sortOrder string) (models.CustomFieldQuery, error)
+}
+
+type customFieldService struct {
+	repo repository.CustomFieldRepository
+}
+
+func NewCustomFieldService(repo repository.CustomFieldRepository) CustomFieldService {
+	return &customFieldService{repo: repo}
+}
+
+func (s *customFieldService) ListDefinitions(entityType string) ([]models.CustomFieldDefinition, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("entity_type", entityType), "CustomFieldService", "ListDefinitions")
+
+	if entityType != "" && !models.IsCustomFieldEntity(entityType) {
+		logger.Warn("Unknown custom field entity type")
+		return nil, fmt.Errorf("entity type %q has no custom fields: %w", entityType, apperrors.ErrValidation)
+	}
+	definitions, err := s.repo.ListDefinitions(entityType)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	return definitions, nil
+}
+
+func (s *customFieldService) GetDefinition(id uint) (*models.CustomFieldDefinition, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("custom_field_id", id), "CustomFieldService", "GetDefinition")
+
+	definition, err := s.repo.GetDefinition(id)
+	if err != nil {
+		if isNotFound(err) {
+			logger.Warn("Custom field not found")
+			return nil, fmt.Errorf("custom field %d: %w", id, apperrors.ErrNotFound)
+		}
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	return definition, nil
+}
+
+func (s *customFieldService) CreateDefinition(definition *models.CustomFieldDefinition) error {
+	logger := utils.LogServiceCall(utils.Logger.WithField("name", definition.Name), "CustomFieldService", "CreateDefinition")
+
+	// ValidateDefinition wraps the models package's own sentinel, which
+	// becomes the application-wide one here.
+	if err := definition.ValidateDefinition(); err != nil {
+		logger.WithError(err).Warn("Invalid custom field")
+		return fmt.Errorf("%w: %w", err, apperrors.ErrValidation)
+	}
+	count, err := s.repo.CountDefinitions(definition.EntityType)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+	if count >= models.CustomFieldMaxDefinitions {
+		logger.Warn("Too many custom fields")
+		return fmt.Errorf("a %s cannot have more than %d custom fields: %w",
+			definition.EntityType, models.CustomFieldMaxDefinitions, apperrors.ErrValidation)
+	}
+
+	if err := s.repo.CreateDefinition(definition); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+	logger.WithField("custom_field_id", definition.ID).Info("Custom field created")
+	return nil
+}
+
+func (s *customFieldService) UpdateDefinition(definition *models.CustomFieldDefinition) (*models.CustomFieldDefinition, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("custom_field_id", definition.ID), "CustomFieldService", "UpdateDefinition")
+
+	existing, err := s.GetDefinition(definition.ID)
+	if err != nil {
+		return nil, err
+	}
+	if definition.EntityType != existing.EntityType || definition.Name != existing.Name || definition.Type != existing.Type {
+		logger.Warn("Attempt to change a fixed custom field attribute")
+		return nil, fmt.Errorf("the entity type, name and type of a custom field cannot be changed: %w", apperrors.ErrValidation)
+	}
+	if err := definition.ValidateDefinition(); err != nil {
+		logger.WithError(err).Warn("Invalid custom field")
+		return nil, fmt.Errorf("%w: %w", err, apperrors.ErrValidation)
+	}
+	definition.CreatedAt = existing.CreatedAt
+
+	if err := s.repo.UpdateDefinition(definition); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	logger.Info("Custom field updated")
+	return definition, nil
+}
+
+func (s *customFieldService) DeleteDefinition(id uint) error {
+	logger := utils.LogServiceCall(utils.Logger.WithField("custom_field_id", id), "CustomFieldService", "DeleteDefinition")
+
+	if err := s.repo.DeleteDefinition(id); err != nil {
+		if isNotFound(err) {
+			logger.Warn("Custom field not found")
+			return fmt.Errorf("custom field %d: %w", id, apperrors.ErrNotFound)
+		}
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+	logger.Info("Custom field deleted")
+	return nil
+}
+
+func (s *customFieldService) ResolveQuery(entityType string, filters map[string][]string, sortField, sortOrder string) (models.CustomFieldQuery, error) {
+	var query models.CustomFieldQuery
+	if len(filters) == 0 && sortField == "" {
+		return query, nil
+	}
+	logger := utils.LogServiceCall(utils.Logger.WithField("entity_type", entityType), "CustomFieldService", "ResolveQuery")
+
+	definitions, err := s.repo.ListDefinitions(entityType)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return query, err
+	}
+	byName := make(map[string]*models.CustomFieldDefinition, len(definitions))
+	for i := range definitions {
+		byName[definitions[i].Name] = &definitions[i]
+	}
+
+	// Sorted so that the same request always builds the same SQL.
+	names := make([]string, 0, len(filters))
+	for name := range filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		definition, ok := byName[name]
+		if !ok {
+			return query, fmt.Errorf("unknown custom field %q for %s: %w", name, entityType, apperrors.ErrValidation)
+		}
+		for _, value := range filters[name] {
+			encoded, err := definition.Encode(value)
+			if err != nil {
+				return query, fmt.Errorf("%w: %w", err, apperrors.ErrValidation)
+			}
+			if len(encoded) != 1 {
+				return query, fmt.Errorf("custom field filter %q needs a single value: %w", name, apperrors.ErrValidation)
+			}
+			query.Filters = append(query.Filters, models.CustomFieldFilter{FieldID: definition.ID, Value: encoded[0]})
+		}
+	}
+
+	if sortField != "" {
+		definition, ok := byName[sortField]
+		if !ok {
+			return query, fmt.Errorf("unknown custom field %q for %s: %w", sortField, entityType, apperrors.ErrValidation)
+		}
+		if definition.Type == models.CustomFieldMultiSelect {
+			return query, fmt.Errorf("cannot sort by the multi_select field %q: %w", sortField, apperrors.ErrValidation)
+		}
+		query.Sort = &models.CustomFieldSort{
+			FieldID: definition.ID,
+			Numeric: definition.Type == models.CustomFieldNumber,
+			Desc:    sortOrder == "desc",
+		}
+	}
+	return query, nil
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type CustomFieldServiceTestSuite struct {
+	suite.Suite
+	mockRepo *mocks.CustomFieldRepository
+	service  CustomFieldService
+}
+
+func (suite *CustomFieldServiceTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+}
+
+func (suite *CustomFieldServiceTestSuite) SetupTest() {
+	suite.mockRepo = new(mocks.CustomFieldRepository)
+	suite.service = NewCustomFieldService(suite.mockRepo)
+}
+
+func (suite *CustomFieldServiceTestSuite) TearDownTest() {
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+func (suite *CustomFieldServiceTestSuite) definitions() []models.CustomFieldDefinition {
+	return []models.CustomFieldDefinition{
+		{ID: 1, EntityType: models.AuditEntityLead, Name: "region", Type: models.CustomFieldSelect, Options: []string{"emea", "apac"}},
+		{ID: 2, EntityType: models.AuditEntityLead, Name: "seats", Type: models.CustomFieldNumber},
+		{ID: 3, EntityType: models.AuditEntityLead, Name: "markets", Type: models.CustomFieldMultiSelect, Options: []string{"smb", "enterprise"}},
+	}
+}
+
+func (suite *CustomFieldServiceTestSuite) TestCreateDefinition_Success() {
+	definition := &models.CustomFieldDefinition{EntityType: models.AuditEntityLead, Name: " region ", Type: models.CustomFieldText}
+
+	suite.mockRepo.On("CountDefinitions", models.AuditEntityLead).Return(int64(3), nil)
+	suite.mockRepo.On("CreateDefinition", mock.MatchedBy(func(d *models.CustomFieldDefinition) bool {
+		return d.Name == "region" && d.Label == "region"
+	})).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.CustomFieldDefinition).ID = 4
+	})
+
+	err := suite.service.CreateDefinition(definition)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), uint(4), definition.ID)
+}
+
+func (suite *CustomFieldServiceTestSuite) TestCreateDefinition_InvalidDefinition() {
+	err := suite.service.CreateDefinition(&models.CustomFieldDefinition{EntityType: models.AuditEntityLead, Name: "region", Type: models.CustomFieldSelect})
+
+	assert.True(suite.T(), errors.Is(err, apperrors.ErrValidation))
+	assert.True(suite.T(), errors.Is(err, models.ErrInvalidCustomFieldDefinition))
+	suite.mockRepo.AssertNotCalled(suite.T(), "CreateDefinition", mock.Anything)
+}
+
+func (suite *CustomFieldServiceTestSuite) TestCreateDefinition_TooManyFields() {
+	suite.mockRepo.On("CountDefinitions", models.AuditEntityTask).Return(int64(models.CustomFieldMaxDefinitions), nil)
+
+	err := suite.service.CreateDefinition(&models.CustomFieldDefinition{EntityType: models.AuditEntityTask, Name: "region", Type: models.CustomFieldText})
+	assert.True(suite.T(), errors.Is(err, apperrors.ErrValidation))
+	assert.Contains(suite.T(), err.Error(), "cannot have more than")
+	suite.mockRepo.AssertNotCalled(suite.T(), "CreateDefinition", mock.Anything)
+}
+
+func (suite *CustomFieldServiceTestSuite) TestUpdateDefinition_RefusesFixedAttributes() {
+	existing := suite.definitions()[0]
+	suite.mockRepo.On("GetDefinition", uint(1)).Return(&existing, nil)
+
+	changed := existing
+	changed.Type = models.CustomFieldText
+	changed.Options = nil
+	_, err := suite.service.UpdateDefinition(&changed)
+
+	assert.True(suite.T(), errors.Is(err, apperrors.ErrValidation))
+	suite.mockRepo.AssertNotCalled(suite.T(), "UpdateDefinition", mock.Anything)
+}
+
+func (suite *CustomFieldServiceTestSuite) TestUpdateDefinition_Success() {
+	existing := suite.definitions()[0]
+	suite.mockRepo.On("GetDefinition", uint(1)).Return(&existing, nil)
+	suite.mockRepo.On("UpdateDefinition", mock.AnythingOfType("*models.CustomFieldDefinition")).Return(nil)
+
+	changed := existing
+	changed.Options = []string{"emea", "apac", "amer"}
+	changed.Required = true
+	updated, err := suite.service.UpdateDefinition(&changed)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"emea", "apac", "amer"}, updated.Options)
+}
+
+func (suite *CustomFieldServiceTestSuite) TestGetDefinition_NotFound() {
+	suite.mockRepo.On("GetDefinition", uint(9)).Return(nil, gorm.ErrRecordNotFound)
+
+	_, err := suite.service.GetDefinition(9)
+	assert.True(suite.T(), apperrors.IsNotFound(err))
+}
+
+func (suite *CustomFieldServiceTestSuite) TestListDefinitions_UnknownEntity() {
+	_, err := suite.service.ListDefinitions("deal")
+	assert.True(suite.T(), errors.Is(err, apperrors.ErrValidation))
+}
+
+func (suite *CustomFieldServiceTestSuite) TestResolveQuery_NoCustomFields() {
+	query, err := suite.service.ResolveQuery(models.AuditEntityLead, map[string][]string{}, "", "asc")
+
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), query.Empty())
+	suite.mockRepo.AssertNotCalled(suite.T(), "ListDefinitions", mock.Anything)
+}
+
+func (suite *CustomFieldServiceTestSuite) TestResolveQuery_FiltersAndSort() {
+	suite.mockRepo.On("ListDefinitions", models.AuditEntityLead).Return(suite.definitions(), nil)
+
+	query, err := suite.service.ResolveQuery(models.AuditEntityLead,
+		map[string][]string{"region": {"emea"}, "markets": {"smb", "enterprise"}}, "seats", "desc")
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []models.CustomFieldFilter{
+		{FieldID: 3, Value: "smb"}, {FieldID: 3, Value: "enterprise"}, {FieldID: 1, Value: "emea"},
+	}, query.Filters)
+	assert.Equal(suite.T(), &models.CustomFieldSort{FieldID: 2, Numeric: true, Desc: true}, query.Sort)
+}
+
+func (suite *CustomFieldServiceTestSuite) TestResolveQuery_Invalid() {
+	tests := []struct {
+		name      string
+		filters   map[string][]string
+		sortField string
+		wantErr   string
+	}{
+		{name: "unknown filter", filters: map[string][]string{"colour": {"red"}}, wantErr: `unknown custom field "colour"`},
+		{name: "unknown option", filters: map[string][]string{"region": {"amer"}}, wantErr: "is not one of its options"},
+		{name: "not a number", filters: map[string][]string{"seats": {"many"}}, wantErr: "expected a number"},
+		{name: "unknown sort", sortField: "colour", wantErr: `unknown custom field "colour"`},
+		{name: "multi_select sort", sortField: "markets", wantErr: "cannot sort by the multi_select field"},
+	}
+
+	suite.mockRepo.On("ListDefinitions", models.AuditEntityLead).Return(suite.definitions(), nil)
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			_, err := suite.service.ResolveQuery(models.AuditEntityLead, tt.filters, tt.sortField, "asc")
+			suite.Require().Error(err)
+			assert.Contains(suite.T(), err.Error(), tt.wantErr)
+			assert.True(suite.T(), errors.Is(err, apperrors.ErrValidation))
+		})
+	}
+}
+
+func TestCustomFieldServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(CustomFieldServiceTestSuite))
+}
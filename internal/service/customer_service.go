@@ -12,13 +12,28 @@ import (
 type customerService struct {
 	customerRepo repository.CustomerRepository
 	userRepo     repository.UserRepository
+	userPermissions
+	accountLinks
+	activityFeed
+	webhookEvents
 }
 
-func NewCustomerService(customerRepo repository.CustomerRepository, userRepo repository.UserRepository) CustomerService {
-	return &customerService{
-		customerRepo: customerRepo,
-		userRepo:     userRepo,
+// NewCustomerService builds the customer service. roleService resolves the
+// role of an account manager, who must be allowed to update customers.
+func NewCustomerService(customerRepo repository.CustomerRepository, userRepo repository.UserRepository, roleService RoleService, accountRepo repository.AccountRepository, opts ...EntityOption) CustomerService {
+	s := &customerService{
+		customerRepo:    customerRepo,
+		userRepo:        userRepo,
+		userPermissions: userPermissions{roles: roleService},
+		accountLinks:    accountLinks{accounts: accountRepo},
 	}
+	applyEntityOptions(&s.activityFeed, &s.webhookEvents, opts)
+	return s
+}
+
+// describeCustomer names a customer in the feed.
+func describeCustomer(customer *models.Customer) string {
+	return describePerson(customer.FirstName, customer.LastName, customer.Company, "Unnamed customer")
 }
 
 func (s *customerService) Create(customer *models.Customer) error {
@@ -33,6 +48,13 @@ func (s *customerService) Create(customer *models.Customer) error {
 		logger.Warn("Attempted to create customer with duplicate email")
 		return fmt.Errorf("customer with this email already exists: %w", apperrors.ErrDuplicateEmail)
 	}
+
+	accountID, err := s.linkNewContact(customer.AccountID, customer.Company)
+	if err != nil {
+		logger.WithError(err).Warn("Invalid customer account")
+		return err
+	}
+	customer.AccountID = accountID
 	
 	if err := s.customerRepo.Create(customer); err != nil {
 		logger.WithError(err).Error("Failed to create customer")
@@ -40,6 +62,9 @@ func (s *customerService) Create(customer *models.Customer) error {
 	}
 	
 	logger.WithField("customer_id", customer.ID).Info("Customer created successfully")
+	s.recordActivity(models.AuditEntityCustomer, customer.ID, models.ActivityCustomerCreated, customer.AssignedToID,
+		"New customer", describeCustomer(customer)+" was added as a customer")
+	s.publishWebhook(models.WebhookCustomerCreated, models.AuditEntityCustomer, customer.ID, customerWebhookData(customer))
 	return nil
 }
 
@@ -91,6 +116,13 @@ func (s *customerService) Update(customer *models.Customer) error {
 			return fmt.Errorf("customer with this email already exists: %w", apperrors.ErrDuplicateEmail)
 		}
 	}
+
+	accountID, err := s.checkAccount(customer.AccountID)
+	if err != nil {
+		logger.WithError(err).Warn("Invalid customer account")
+		return err
+	}
+	customer.AccountID, customer.Account = accountID, nil
 	
 	if err := s.customerRepo.Update(customer); err != nil {
 		logger.WithError(err).Error("Failed to update customer")
@@ -98,6 +130,9 @@ func (s *customerService) Update(customer *models.Customer) error {
 	}
 	
 	logger.Info("Customer updated successfully")
+	s.recordActivity(models.AuditEntityCustomer, customer.ID, models.ActivityCustomerUpdated, customer.AssignedToID,
+		"Customer updated", describeCustomer(customer)+" was updated")
+	s.publishWebhook(models.WebhookCustomerUpdated, models.AuditEntityCustomer, customer.ID, customerWebhookData(customer))
 	return nil
 }
 
@@ -119,7 +154,8 @@ func (s *customerService) Delete(id uint) error {
 	// the delete both match by primary key, and zero matched rows is not an
 	// error in SQL. Reported as the not-found sentinel so the caller can tell
 	// "there was nobody to erase" from "the erasure failed".
-	if _, err := s.customerRepo.GetByID(id); err != nil {
+	customer, err := s.customerRepo.GetByID(id)
+	if err != nil {
 		if isNotFound(err) {
 			logger.WithError(err).Warn("Customer not found")
 			return fmt.Errorf("customer %d not found: %w", id, apperrors.ErrNotFound)
@@ -134,6 +170,10 @@ func (s *customerService) Delete(id uint) error {
 	}
 
 	logger.Info("Customer erased successfully")
+	// The entry must not name the person it records the erasure of.
+	s.recordActivity(models.AuditEntityCustomer, id, models.ActivityCustomerDeleted, customer.AssignedToID,
+		"Customer erased", "A customer was erased")
+	s.publishWebhook(models.WebhookCustomerDeleted, models.AuditEntityCustomer, id, deletedWebhookData(id))
 	return nil
 }
 
@@ -206,31 +246,55 @@ func (s *customerService) Search(query string, offset, limit int, sortBy, sortOr
 	return customers, total, nil
 }
 
-func (s *customerService) GetCount() (int64, error) {
-	return s.customerRepo.Count()
+// ListFiltered returns one page of the customers matching the filter, custom
+// field conditions included.
+func (s *customerService) ListFiltered(filter models.CustomerFilter, offset, limit int) ([]models.Customer, int64, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithFields(map[string]interface{}{
+		"search": filter.Search,
+		"offset": offset,
+		"limit":  limit,
+	}), "CustomerService", "ListFiltered")
+
+	customers, total, err := s.customerRepo.ListFiltered(filter, offset, limit)
+	if err != nil {
+		logger.WithError(err).Error("Failed to list filtered customers")
+		return nil, 0, err
+	}
+
+	logger.WithField("total", total).Info("Filtered customer list completed")
+	return customers, total, nil
 }
 
-// ExportAll returns every customer matching the optional search term, unpaginated.
+// StreamExport hands every customer matching the filter to fn, unpaginated, a batch
+// at a time as it is read.
 //
-// The caller is expected to be the admin-only CSV export: this is a bulk read of
-// personal data, so it is deliberately a distinct method rather than "List with
-// a very large limit", and the audit log below records that a full export was
-// taken.
-func (s *customerService) ExportAll(search, sortBy, sortOrder string) ([]models.Customer, error) {
+// The caller is expected to be an admin-only export: this is a bulk read, so
+// it is deliberately a distinct method rather than "ListFiltered with a very
+// large limit", and the log below records that a full export was taken — or
+// that one stopped part of the way through.
+func (s *customerService) StreamExport(filter models.CustomerFilter, fn func([]models.Customer) error) error {
 	logger := utils.LogServiceCall(utils.Logger.WithFields(map[string]interface{}{
-		"search":     search,
-		"sort_by":    sortBy,
-		"sort_order": sortOrder,
-	}), "CustomerService", "ExportAll")
-
-	customers, err := s.customerRepo.ListAllForExport(search, sortBy, sortOrder)
+		"search":     filter.Search,
+		"sort_by":    filter.SortBy,
+		"sort_order": filter.SortOrder,
+	}), "CustomerService", "StreamExport")
+
+	count := 0
+	err := s.customerRepo.StreamFiltered(filter, func(batch []models.Customer) error {
+		count += len(batch)
+		return fn(batch)
+	})
 	if err != nil {
-		logger.WithError(err).Error("Failed to export customers")
-		return nil, err
+		logger.WithError(err).WithField("count", count).Error("Failed to export customers")
+		return err
 	}
 
-	logger.WithField("count", len(customers)).Info("Customer export produced")
-	return customers, nil
+	logger.WithField("count", count).Info("Customer export produced")
+	return nil
+}
+
+func (s *customerService) GetCount() (int64, error) {
+	return s.customerRepo.Count()
 }
 
 // Assign sets the staff account that owns a customer relationship.
@@ -270,9 +334,14 @@ func (s *customerService) Assign(customerID, userID uint) (*models.Customer, err
 		return nil, fmt.Errorf("cannot assign customer to inactive user: %w", apperrors.ErrInactiveUser)
 	}
 
-	if assignee.Role != models.RoleSales && assignee.Role != models.RoleAdmin {
+	allowed, err := s.userAllows(assignee, models.PermCustomersUpdate)
+	if err != nil {
+		logger.WithError(err).Error("Failed to resolve the assignee's role")
+		return nil, err
+	}
+	if !allowed {
 		logger.WithField("assignee_role", assignee.Role).Warn("Invalid assignee role")
-		return nil, fmt.Errorf("customers can only be assigned to sales or admin users: %w", apperrors.ErrInvalidCustomerAssignee)
+		return nil, fmt.Errorf("customers can only be assigned to users whose role may update customers: %w", apperrors.ErrInvalidCustomerAssignee)
 	}
 
 	customer.AssignedToID = &userID
@@ -283,5 +352,8 @@ func (s *customerService) Assign(customerID, userID uint) (*models.Customer, err
 	}
 
 	logger.Info("Customer assigned successfully")
+	s.recordActivity(models.AuditEntityCustomer, customer.ID, models.ActivityCustomerReassigned, customer.AssignedToID,
+		"Customer reassigned", describeCustomer(customer)+" was assigned a new account manager")
+	s.publishWebhook(models.WebhookCustomerUpdated, models.AuditEntityCustomer, customer.ID, customerWebhookData(customer))
 	return customer, nil
 }
\ No newline at end of file
@@ -35,7 +35,7 @@ func (suite *CustomerServiceTestSuite) SetupSuite() {
 func (suite *CustomerServiceTestSuite) SetupTest() {
 	suite.mockRepo = new(mocks.CustomerRepository)
 	suite.mockUserRepo = new(mocks.UserRepository)
-	suite.service = NewCustomerService(suite.mockRepo, suite.mockUserRepo)
+	suite.service = NewCustomerService(suite.mockRepo, suite.mockUserRepo, nil, nil)
 }
 
 func (suite *CustomerServiceTestSuite) TearDownTest() {
@@ -287,35 +287,55 @@ func (suite *CustomerServiceTestSuite) TestList_RepoError() {
 func TestCustomerServiceTestSuite(t *testing.T) {
 	suite.Run(t, new(CustomerServiceTestSuite))
 }
-// --- ExportAll ---------------------------------------------------------------
+// --- StreamExport ------------------------------------------------------------
+
+// streamBatches makes the repository mock hand each batch to the export's
+// callback, stopping at the first error like the real one.
+func streamBatches(batches ...[]models.Customer) func(mock.Arguments) {
+	return func(args mock.Arguments) {
+		fn := args.Get(1).(func([]models.Customer) error)
+		for _, batch := range batches {
+			if fn(batch) != nil {
+				return
+			}
+		}
+	}
+}
 
-func (suite *CustomerServiceTestSuite) TestExportAll_Success() {
-	expected := []models.Customer{
+func (suite *CustomerServiceTestSuite) TestStreamExport_Success() {
+	first := []models.Customer{
 		{BaseModel: models.BaseModel{ID: 1}, FirstName: "John", LastName: "Doe", Email: "john@example.com"},
 		{BaseModel: models.BaseModel{ID: 2}, FirstName: "Jane", LastName: "Smith", Email: "jane@example.com"},
 	}
+	second := []models.Customer{{BaseModel: models.BaseModel{ID: 3}, FirstName: "Max", LastName: "Mustermann"}}
 
-	suite.mockRepo.On("ListAllForExport", "", "", "").Return(expected, nil)
+	suite.mockRepo.On("StreamFiltered", models.CustomerFilter{}, mock.Anything).Run(streamBatches(first, second)).Return(nil)
 
-	customers, err := suite.service.ExportAll("", "", "")
+	var exported []models.Customer
+	err := suite.service.StreamExport(models.CustomerFilter{}, func(batch []models.Customer) error {
+		exported = append(exported, batch...)
+		return nil
+	})
 	assert.NoError(suite.T(), err)
-	assert.Len(suite.T(), customers, 2)
+	assert.Len(suite.T(), exported, 3)
 }
 
-func (suite *CustomerServiceTestSuite) TestExportAll_PassesFiltersThrough() {
-	suite.mockRepo.On("ListAllForExport", "acme", "email", "desc").Return([]models.Customer{}, nil)
+func (suite *CustomerServiceTestSuite) TestStreamExport_PassesFiltersThrough() {
+	filter := models.CustomerFilter{Search: "acme", SortBy: "email", SortOrder: "desc"}
+	suite.mockRepo.On("StreamFiltered", filter, mock.Anything).Return(nil)
 
-	customers, err := suite.service.ExportAll("acme", "email", "desc")
+	err := suite.service.StreamExport(filter, func([]models.Customer) error {
+		suite.Fail("no customer matched")
+		return nil
+	})
 	assert.NoError(suite.T(), err)
-	assert.Empty(suite.T(), customers)
 }
 
-func (suite *CustomerServiceTestSuite) TestExportAll_RepoError() {
-	suite.mockRepo.On("ListAllForExport", "", "", "").Return(nil, errors.New("database error"))
+func (suite *CustomerServiceTestSuite) TestStreamExport_RepoError() {
+	suite.mockRepo.On("StreamFiltered", models.CustomerFilter{}, mock.Anything).Return(errors.New("database error"))
 
-	customers, err := suite.service.ExportAll("", "", "")
+	err := suite.service.StreamExport(models.CustomerFilter{}, func([]models.Customer) error { return nil })
 	assert.Error(suite.T(), err)
-	assert.Nil(suite.T(), customers)
 }
 
 // --- Assign ------------------------------------------------------------------
@@ -433,6 +453,30 @@ func (suite *CustomerServiceTestSuite) TestAssign_SupportRoleAssigneeRejected()
 	assert.True(suite.T(), errors.Is(err, apperrors.ErrInvalidCustomerAssignee))
 }
 
+// TestAssign_CustomRoleAssignee: a custom role may hold customers when it may
+// update them, and not otherwise, whatever built-in role it is based on.
+func (suite *CustomerServiceTestSuite) TestAssign_CustomRoleAssignee() {
+	roles := new(mocks.RoleService)
+	roles.On("Resolve", models.UserRole("key_accounts")).
+		Return(models.RoleSupport, models.NewPermissionSet(models.PermCustomersRead, models.PermCustomersUpdate), nil)
+	roles.On("Resolve", models.UserRole("analyst")).
+		Return(models.RoleSales, models.NewPermissionSet(models.PermCustomersRead), nil)
+	service := NewCustomerService(suite.mockRepo, suite.mockUserRepo, roles, nil)
+
+	suite.mockRepo.On("GetByID", uint(1)).Return(&models.Customer{BaseModel: models.BaseModel{ID: 1}, Email: "john@example.com"}, nil)
+	suite.mockUserRepo.On("GetByID", uint(7)).Return(&models.User{BaseModel: models.BaseModel{ID: 7}, Role: "key_accounts", IsActive: true}, nil)
+	suite.mockUserRepo.On("GetByID", uint(8)).Return(&models.User{BaseModel: models.BaseModel{ID: 8}, Role: "analyst", IsActive: true}, nil)
+	suite.mockRepo.On("Update", mock.AnythingOfType("*models.Customer")).Return(nil).Once()
+
+	updated, err := service.Assign(1, 7)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), uint(7), *updated.AssignedToID)
+
+	_, err = service.Assign(1, 8)
+	assert.True(suite.T(), errors.Is(err, apperrors.ErrInvalidCustomerAssignee))
+	roles.AssertExpectations(suite.T())
+}
+
 func (suite *CustomerServiceTestSuite) TestAssign_PersistFailureIsReported() {
 	customer := &models.Customer{BaseModel: models.BaseModel{ID: 1}, Email: "john@example.com"}
 	assignee := &models.User{BaseModel: models.BaseModel{ID: 7}, Role: models.RoleSales, IsActive: true}
@@ -0,0 +1,648 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+)
+
+// DealService manages the sales pipeline: its stages, the deals moving
+// through them, and the pipeline and forecast reports.
+//
+// A deal's probability follows its stage. Entering a stage sets it to the
+// stage's default unless the caller gives one; a won stage always means 100
+// and a lost stage 0. Reaching a won or lost stage closes the deal, and moving
+// it back into the open pipeline reopens it.
+type DealService interface {
+	ListStages() ([]models.PipelineStage, error)
+	GetStage(id uint) (*models.PipelineStage, error)
+	// CreateStage adds a stage. The outcome defaults to open; an invalid
+	// stage is rejected with apperrors.ErrValidation.
+	CreateStage(stage *models.PipelineStage) error
+	// UpdateStage replaces the stage's settings. The outcome of a stage that
+	// holds deals cannot change, since it would leave them wrongly closed or
+	// open.
+	UpdateStage(id uint, stage *models.PipelineStage) (*models.PipelineStage, error)
+	// DeleteStage removes a stage no deal has ever been in; one still
+	// referenced is refused with apperrors.ErrValidation.
+	DeleteStage(id uint) error
+
+	// Create adds a deal in deal.StageID, or in the first open stage when it
+	// is zero, and starts its stage history. probability overrides the
+	// stage's default when given.
+	Create(deal *models.Deal, probability *int, actorID uint) error
+	GetByID(id uint) (*models.Deal, error)
+	// Update saves everything but the stage, which only MoveToStage changes.
+	Update(deal *models.Deal) (*models.Deal, error)
+	// MoveToStage moves the deal to another stage and records the move.
+	MoveToStage(id, stageID uint, probability *int, actorID uint) (*models.Deal, error)
+	Delete(id uint) error
+	List(filter models.DealFilter, offset, limit int) ([]models.Deal, int64, error)
+	StageHistory(id uint) ([]models.DealStageChange, error)
+
+	// Pipeline reports count, value and weighted value per stage, every stage
+	// present in pipeline order. An empty filter.Currency means the default.
+	Pipeline(filter models.DealReportFilter) ([]models.DealPipelineRow, error)
+	// Forecast reports the open and won deals with an expected close date,
+	// per month of that date, stage and owner.
+	Forecast(filter models.DealReportFilter) ([]models.DealForecastRow, error)
+	// DefaultCurrency is the currency deals and reports get when none is
+	// given.
+	DefaultCurrency() string
+}
+
+type dealService struct {
+	repo         repository.DealRepository
+	customerRepo repository.CustomerRepository
+	leadRepo     repository.LeadRepository
+	userRepo     repository.UserRepository
+	cfg          config.DealConfig
+	activityFeed
+}
+
+func NewDealService(
+	repo repository.DealRepository,
+	customerRepo repository.CustomerRepository,
+	leadRepo repository.LeadRepository,
+	userRepo repository.UserRepository,
+	cfg config.DealConfig,
+	opts ...ActivityOption,
+) DealService {
+	if cfg.DefaultCurrency == "" {
+		cfg.DefaultCurrency = "USD"
+	}
+	s := &dealService{repo: repo, customerRepo: customerRepo, leadRepo: leadRepo, userRepo: userRepo, cfg: cfg}
+	s.applyActivityOptions(opts)
+	return s
+}
+
+func (s *dealService) DefaultCurrency() string {
+	return s.cfg.DefaultCurrency
+}
+
+func (s *dealService) ListStages() ([]models.PipelineStage, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("entity", "pipeline_stage"), "DealService", "ListStages")
+
+	stages, err := s.repo.ListStages()
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	return stages, nil
+}
+
+func (s *dealService) GetStage(id uint) (*models.PipelineStage, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("stage_id", id), "DealService", "GetStage")
+
+	stage, err := s.getStage(id)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	return stage, nil
+}
+
+func (s *dealService) CreateStage(stage *models.PipelineStage) error {
+	logger := utils.LogServiceCall(utils.Logger.WithField("stage_name", stage.Name), "DealService", "CreateStage")
+
+	if err := normalizePipelineStage(stage); err != nil {
+		logger.WithError(err).Warn("Invalid pipeline stage")
+		return err
+	}
+	if err := s.repo.CreateStage(stage); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+
+	logger.WithField("stage_id", stage.ID).Info("Pipeline stage created")
+	return nil
+}
+
+func (s *dealService) UpdateStage(id uint, stage *models.PipelineStage) (*models.PipelineStage, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("stage_id", id), "DealService", "UpdateStage")
+
+	existing, err := s.getStage(id)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	if err := normalizePipelineStage(stage); err != nil {
+		logger.WithError(err).Warn("Invalid pipeline stage")
+		return nil, err
+	}
+	if stage.Outcome != existing.Outcome {
+		inUse, err := s.repo.CountDealsInStage(id)
+		if err != nil {
+			utils.LogServiceResponse(logger, err)
+			return nil, err
+		}
+		if inUse > 0 {
+			logger.WithField("deals", inUse).Warn("Refusing to change the outcome of a stage in use")
+			return nil, fmt.Errorf("the outcome of a stage that holds deals cannot change: %w", apperrors.ErrValidation)
+		}
+	}
+
+	existing.Name = stage.Name
+	existing.Position = stage.Position
+	existing.Probability = stage.Probability
+	existing.Outcome = stage.Outcome
+	if err := s.repo.UpdateStage(existing); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+
+	logger.Info("Pipeline stage updated")
+	return existing, nil
+}
+
+func (s *dealService) DeleteStage(id uint) error {
+	logger := utils.LogServiceCall(utils.Logger.WithField("stage_id", id), "DealService", "DeleteStage")
+
+	inUse, err := s.repo.CountDealsInStage(id)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+	if inUse > 0 {
+		logger.WithField("deals", inUse).Warn("Refusing to delete a stage in use")
+		return fmt.Errorf("pipeline stage %d still holds %d deals: %w", id, inUse, apperrors.ErrValidation)
+	}
+	if err := s.repo.DeleteStage(id); err != nil {
+		if isNotFound(err) {
+			logger.Warn("Pipeline stage not found")
+			return fmt.Errorf("pipeline stage %d: %w", id, apperrors.ErrNotFound)
+		}
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+
+	logger.Info("Pipeline stage deleted")
+	return nil
+}
+
+// normalizePipelineStage validates a stage and pins the probability of the
+// won and lost stages.
+func normalizePipelineStage(stage *models.PipelineStage) error {
+	stage.Name = strings.TrimSpace(stage.Name)
+	if stage.Name == "" || len(stage.Name) > 100 {
+		return fmt.Errorf("stage name must be 1 to 100 characters: %w", apperrors.ErrValidation)
+	}
+	if stage.Outcome == "" {
+		stage.Outcome = models.DealOutcomeOpen
+	}
+	switch stage.Outcome {
+	case models.DealOutcomeOpen:
+		if err := validateProbability(stage.Probability); err != nil {
+			return err
+		}
+	case models.DealOutcomeWon:
+		stage.Probability = 100
+	case models.DealOutcomeLost:
+		stage.Probability = 0
+	default:
+		return fmt.Errorf("unknown stage outcome %q: %w", stage.Outcome, apperrors.ErrValidation)
+	}
+	return nil
+}
+
+func validateProbability(probability int) error {
+	if probability < 0 || probability > 100 {
+		return fmt.Errorf("probability must be between 0 and 100: %w", apperrors.ErrValidation)
+	}
+	return nil
+}
+
+func (s *dealService) getStage(id uint) (*models.PipelineStage, error) {
+	stage, err := s.repo.GetStage(id)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, fmt.Errorf("pipeline stage %d: %w", id, apperrors.ErrNotFound)
+		}
+		return nil, err
+	}
+	return stage, nil
+}
+
+// firstOpenStage is where a deal created without a stage starts.
+func (s *dealService) firstOpenStage() (*models.PipelineStage, error) {
+	stages, err := s.repo.ListStages()
+	if err != nil {
+		return nil, err
+	}
+	for i := range stages {
+		if stages[i].Outcome == models.DealOutcomeOpen {
+			return &stages[i], nil
+		}
+	}
+	return nil, fmt.Errorf("the pipeline has no open stage: %w", apperrors.ErrValidation)
+}
+
+func (s *dealService) Create(deal *models.Deal, probability *int, actorID uint) error {
+	logger := utils.LogServiceCall(utils.Logger.WithField("deal_title", deal.Title), "DealService", "Create")
+
+	var stage *models.PipelineStage
+	var err error
+	if deal.StageID == 0 {
+		stage, err = s.firstOpenStage()
+	} else {
+		stage, err = s.repo.GetStage(deal.StageID)
+		if isNotFound(err) {
+			err = fmt.Errorf("pipeline stage %d does not exist: %w", deal.StageID, apperrors.ErrValidation)
+		}
+	}
+	if err != nil {
+		logger.WithError(err).Warn("Cannot resolve the deal's stage")
+		return err
+	}
+
+	if err := s.validateDeal(deal); err != nil {
+		logger.WithError(err).Warn("Invalid deal")
+		return err
+	}
+	if err := enterStage(deal, stage, probability); err != nil {
+		logger.WithError(err).Warn("Invalid deal probability")
+		return err
+	}
+
+	entry := &models.DealStageChange{
+		ToStageID:   stage.ID,
+		ToStageName: stage.Name,
+		Probability: deal.Probability,
+		ChangedByID: activityOwner(actorID),
+	}
+	if err := s.repo.Create(deal, entry); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+
+	logger.WithField("deal_id", deal.ID).Info("Deal created")
+	s.recordActivity(models.AuditEntityDeal, deal.ID, models.ActivityDealCreated, activityOwner(deal.OwnerID),
+		"New deal", fmt.Sprintf("%s in %s", deal.Title, stage.Name))
+	return nil
+}
+
+func (s *dealService) GetByID(id uint) (*models.Deal, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("deal_id", id), "DealService", "GetByID")
+
+	deal, err := s.repo.GetByID(id)
+	if err != nil {
+		if isNotFound(err) {
+			logger.Warn("Deal not found")
+			return nil, fmt.Errorf("deal %d: %w", id, apperrors.ErrNotFound)
+		}
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	return deal, nil
+}
+
+func (s *dealService) Update(deal *models.Deal) (*models.Deal, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("deal_id", deal.ID), "DealService", "Update")
+
+	existing, err := s.GetByID(deal.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateDeal(deal); err != nil {
+		logger.WithError(err).Warn("Invalid deal")
+		return nil, err
+	}
+	if err := validateProbability(deal.Probability); err != nil {
+		logger.WithError(err).Warn("Invalid deal probability")
+		return nil, err
+	}
+
+	// The stage, and with it the closing date, only moves through
+	// MoveToStage; a closed deal keeps the probability its outcome implies.
+	deal.StageID = existing.StageID
+	deal.ClosedAt = existing.ClosedAt
+	deal.CreatedAt = existing.CreatedAt
+	if existing.Stage != nil {
+		switch existing.Stage.Outcome {
+		case models.DealOutcomeWon:
+			deal.Probability = 100
+		case models.DealOutcomeLost:
+			deal.Probability = 0
+		}
+	}
+
+	if err := s.repo.Update(deal, nil); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+
+	logger.Info("Deal updated")
+	if deal.OwnerID != existing.OwnerID {
+		s.recordActivity(models.AuditEntityDeal, deal.ID, models.ActivityDealReassigned, activityOwner(deal.OwnerID),
+			"Deal reassigned", deal.Title+" was reassigned")
+	} else {
+		s.recordActivity(models.AuditEntityDeal, deal.ID, models.ActivityDealUpdated, activityOwner(deal.OwnerID),
+			"Deal updated", deal.Title+" was updated")
+	}
+	return s.GetByID(deal.ID)
+}
+
+func (s *dealService) MoveToStage(id, stageID uint, probability *int, actorID uint) (*models.Deal, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithFields(map[string]interface{}{
+		"deal_id":  id,
+		"stage_id": stageID,
+	}), "DealService", "MoveToStage")
+
+	deal, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	stage, err := s.repo.GetStage(stageID)
+	if err != nil {
+		if isNotFound(err) {
+			err = fmt.Errorf("pipeline stage %d does not exist: %w", stageID, apperrors.ErrValidation)
+		}
+		logger.WithError(err).Warn("Cannot resolve the target stage")
+		return nil, err
+	}
+	if stage.ID == deal.StageID && probability == nil {
+		return deal, nil
+	}
+
+	previous, fromStageID := deal.Stage, deal.StageID
+	entry := &models.DealStageChange{
+		FromStageID: &fromStageID,
+		ToStageID:   stage.ID,
+		ToStageName: stage.Name,
+		ChangedByID: activityOwner(actorID),
+	}
+	if previous != nil {
+		entry.FromStageName = previous.Name
+	}
+	if err := enterStage(deal, stage, probability); err != nil {
+		logger.WithError(err).Warn("Invalid deal probability")
+		return nil, err
+	}
+	entry.Probability = deal.Probability
+
+	if err := s.repo.Update(deal, entry); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+
+	logger.Info("Deal moved to another stage")
+	switch stage.Outcome {
+	case models.DealOutcomeWon:
+		s.recordActivity(models.AuditEntityDeal, deal.ID, models.ActivityDealWon, activityOwner(deal.OwnerID),
+			"Deal won", deal.Title)
+	case models.DealOutcomeLost:
+		s.recordActivity(models.AuditEntityDeal, deal.ID, models.ActivityDealLost, activityOwner(deal.OwnerID),
+			"Deal lost", deal.Title)
+	default:
+		s.recordActivity(models.AuditEntityDeal, deal.ID, models.ActivityDealStageChanged, activityOwner(deal.OwnerID),
+			"Deal stage changed", fmt.Sprintf("%s moved from %s to %s", deal.Title, entry.FromStageName, stage.Name))
+	}
+	return s.GetByID(deal.ID)
+}
+
+// enterStage puts the deal in the stage, taking the stage's probability
+// unless one is given and the stage is still open, and closes or reopens it.
+func enterStage(deal *models.Deal, stage *models.PipelineStage, probability *int) error {
+	wasClosed := deal.ClosedAt != nil
+	deal.StageID = stage.ID
+	deal.Stage = stage
+
+	switch stage.Outcome {
+	case models.DealOutcomeWon, models.DealOutcomeLost:
+		deal.Probability = stage.Probability
+		if !wasClosed {
+			now := time.Now().UTC()
+			deal.ClosedAt = &now
+		}
+		return nil
+	}
+
+	deal.ClosedAt = nil
+	deal.Probability = stage.Probability
+	if probability != nil {
+		if err := validateProbability(*probability); err != nil {
+			return err
+		}
+		deal.Probability = *probability
+	}
+	return nil
+}
+
+// validateDeal checks a deal's own fields and the records it points at, and
+// normalizes its currency.
+func (s *dealService) validateDeal(deal *models.Deal) error {
+	deal.Title = strings.TrimSpace(deal.Title)
+	if deal.Title == "" {
+		return fmt.Errorf("deal title is required: %w", apperrors.ErrValidation)
+	}
+	if deal.Amount < 0 || math.IsNaN(deal.Amount) || math.IsInf(deal.Amount, 0) {
+		return fmt.Errorf("deal amount cannot be negative: %w", apperrors.ErrValidation)
+	}
+	deal.Amount = roundCents(deal.Amount)
+
+	currency, err := s.currency(deal.Currency)
+	if err != nil {
+		return err
+	}
+	deal.Currency = currency
+
+	if _, err := s.customerRepo.GetByID(deal.CustomerID); err != nil {
+		if isNotFound(err) {
+			return fmt.Errorf("customer %d does not exist: %w", deal.CustomerID, apperrors.ErrValidation)
+		}
+		return err
+	}
+	if deal.LeadID != nil {
+		if _, err := s.leadRepo.GetByID(*deal.LeadID); err != nil {
+			if isNotFound(err) {
+				return fmt.Errorf("lead %d does not exist: %w", *deal.LeadID, apperrors.ErrValidation)
+			}
+			return err
+		}
+	}
+	owner, err := s.userRepo.GetByID(deal.OwnerID)
+	if err != nil {
+		if isNotFound(err) {
+			return fmt.Errorf("owner %d does not exist: %w", deal.OwnerID, apperrors.ErrValidation)
+		}
+		return err
+	}
+	if !owner.IsActive {
+		return fmt.Errorf("owner %d is inactive: %w", deal.OwnerID, apperrors.ErrValidation)
+	}
+	return nil
+}
+
+// currency upper-cases a currency code, defaulting an empty one.
+func (s *dealService) currency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return s.cfg.DefaultCurrency, nil
+	}
+	if len(code) != 3 || strings.IndexFunc(code, func(r rune) bool { return r < 'A' || r > 'Z' }) >= 0 {
+		return "", fmt.Errorf("currency must be a three-letter ISO 4217 code: %w", apperrors.ErrValidation)
+	}
+	return code, nil
+}
+
+func (s *dealService) Delete(id uint) error {
+	logger := utils.LogServiceCall(utils.Logger.WithField("deal_id", id), "DealService", "Delete")
+
+	deal, err := s.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		if isNotFound(err) {
+			return fmt.Errorf("deal %d: %w", id, apperrors.ErrNotFound)
+		}
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+
+	logger.Info("Deal deleted")
+	s.recordActivity(models.AuditEntityDeal, id, models.ActivityDealDeleted, activityOwner(deal.OwnerID),
+		"Deal deleted", deal.Title+" was deleted")
+	return nil
+}
+
+func (s *dealService) List(filter models.DealFilter, offset, limit int) ([]models.Deal, int64, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("owner_id", filter.OwnerID), "DealService", "List")
+
+	deals, total, err := s.repo.List(filter, offset, limit)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, 0, err
+	}
+	return deals, total, nil
+}
+
+func (s *dealService) StageHistory(id uint) ([]models.DealStageChange, error) {
+	if _, err := s.GetByID(id); err != nil {
+		return nil, err
+	}
+	return s.repo.StageHistory(id)
+}
+
+func (s *dealService) Pipeline(filter models.DealReportFilter) ([]models.DealPipelineRow, error) {
+	deals, stages, err := s.reportData(&filter)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]models.DealPipelineRow, len(stages))
+	index := make(map[uint]int, len(stages))
+	for i, stage := range stages {
+		rows[i] = models.DealPipelineRow{StageID: stage.ID, StageName: stage.Name, Outcome: stage.Outcome}
+		index[stage.ID] = i
+	}
+	for i := range deals {
+		deal := &deals[i]
+		at, ok := index[deal.StageID]
+		if !ok {
+			continue
+		}
+		row := &rows[at]
+		row.Deals++
+		row.Value += deal.Amount
+		row.WeightedValue += deal.WeightedAmount()
+	}
+	for i := range rows {
+		rows[i].Value = roundCents(rows[i].Value)
+		rows[i].WeightedValue = roundCents(rows[i].WeightedValue)
+	}
+	return rows, nil
+}
+
+func (s *dealService) Forecast(filter models.DealReportFilter) ([]models.DealForecastRow, error) {
+	deals, stages, err := s.reportData(&filter)
+	if err != nil {
+		return nil, err
+	}
+	position := make(map[uint]int, len(stages))
+	for i, stage := range stages {
+		position[stage.ID] = i
+	}
+
+	type cell struct {
+		month   string
+		stageID uint
+		ownerID uint
+	}
+	groups := map[cell]*models.DealForecastRow{}
+	for i := range deals {
+		deal := &deals[i]
+		if deal.ExpectedCloseDate == nil || deal.Stage == nil || deal.Stage.Outcome == models.DealOutcomeLost {
+			continue
+		}
+		key := cell{deal.ExpectedCloseDate.UTC().Format("2006-01"), deal.StageID, deal.OwnerID}
+		row, ok := groups[key]
+		if !ok {
+			row = &models.DealForecastRow{Month: key.month, StageID: deal.StageID, StageName: deal.Stage.Name, OwnerID: deal.OwnerID}
+			if owner := deal.Owner; owner != nil {
+				row.OwnerName = describePerson(owner.FirstName, owner.LastName, "", owner.Email)
+			}
+			groups[key] = row
+		}
+		row.Deals++
+		row.Value += deal.Amount
+		row.WeightedValue += deal.WeightedAmount()
+	}
+
+	rows := make([]models.DealForecastRow, 0, len(groups))
+	for _, row := range groups {
+		row.Value = roundCents(row.Value)
+		row.WeightedValue = roundCents(row.WeightedValue)
+		rows = append(rows, *row)
+	}
+	// By month, then in pipeline order, then by owner name.
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Month != rows[j].Month {
+			return rows[i].Month < rows[j].Month
+		}
+		if rows[i].StageID != rows[j].StageID {
+			return position[rows[i].StageID] < position[rows[j].StageID]
+		}
+		if rows[i].OwnerName != rows[j].OwnerName {
+			return rows[i].OwnerName < rows[j].OwnerName
+		}
+		return rows[i].OwnerID < rows[j].OwnerID
+	})
+	return rows, nil
+}
+
+// reportData defaults the filter's currency and loads the deals and stages a
+// report is built from.
+func (s *dealService) reportData(filter *models.DealReportFilter) ([]models.Deal, []models.PipelineStage, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("owner_id", filter.OwnerID), "DealService", "Report")
+
+	currency, err := s.currency(filter.Currency)
+	if err != nil {
+		return nil, nil, err
+	}
+	filter.Currency = currency
+
+	stages, err := s.repo.ListStages()
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, nil, err
+	}
+	deals, err := s.repo.ListForReport(*filter)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, nil, err
+	}
+	return deals, stages, nil
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type DealServiceTestSuite struct {
+	suite.Suite
+	mockRepo         *mocks.DealRepository
+	mockCustomerRepo *mocks.CustomerRepository
+	mockLeadRepo     *mocks.LeadRepository
+	mockUserRepo     *mocks.UserRepository
+	mockActivity     *mocks.ActivityService
+	service          DealService
+}
+
+var (
+	testProposalStage = models.PipelineStage{ID: 3, Name: "Proposal", Position: 3, Probability: 50, Outcome: models.DealOutcomeOpen}
+	testWonStage      = models.PipelineStage{ID: 5, Name: "Closed Won", Position: 5, Probability: 100, Outcome: models.DealOutcomeWon}
+	testLostStage     = models.PipelineStage{ID: 6, Name: "Closed Lost", Position: 6, Probability: 0, Outcome: models.DealOutcomeLost}
+)
+
+func (suite *DealServiceTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+}
+
+func (suite *DealServiceTestSuite) SetupTest() {
+	suite.mockRepo = new(mocks.DealRepository)
+	suite.mockCustomerRepo = new(mocks.CustomerRepository)
+	suite.mockLeadRepo = new(mocks.LeadRepository)
+	suite.mockUserRepo = new(mocks.UserRepository)
+	suite.mockActivity = new(mocks.ActivityService)
+	suite.service = NewDealService(suite.mockRepo, suite.mockCustomerRepo, suite.mockLeadRepo, suite.mockUserRepo,
+		config.DealConfig{DefaultCurrency: "EUR"}, WithActivityFeed(suite.mockActivity))
+}
+
+func (suite *DealServiceTestSuite) TearDownTest() {
+	suite.mockRepo.AssertExpectations(suite.T())
+	suite.mockCustomerRepo.AssertExpectations(suite.T())
+	suite.mockUserRepo.AssertExpectations(suite.T())
+	suite.mockActivity.AssertExpectations(suite.T())
+}
+
+func (suite *DealServiceTestSuite) expectValidReferences() {
+	suite.mockCustomerRepo.On("GetByID", uint(2)).Return(&models.Customer{BaseModel: models.BaseModel{ID: 2}}, nil)
+	suite.mockUserRepo.On("GetByID", uint(7)).Return(&models.User{BaseModel: models.BaseModel{ID: 7}, IsActive: true}, nil)
+}
+
+func (suite *DealServiceTestSuite) expectActivity(activity models.ActivityType) {
+	suite.mockActivity.On("Record", mock.MatchedBy(func(e *models.ActivityEvent) bool {
+		return e.Type == activity && e.EntityType == models.AuditEntityDeal
+	})).Return(nil).Once()
+}
+
+func (suite *DealServiceTestSuite) TestCreate_StartsInTheFirstOpenStageWithItsProbability() {
+	suite.expectValidReferences()
+	suite.mockRepo.On("ListStages").Return([]models.PipelineStage{testProposalStage, testWonStage}, nil)
+	suite.mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(entry *models.DealStageChange) bool {
+		return entry.FromStageID == nil && entry.ToStageName == "Proposal" && *entry.ChangedByID == 7
+	})).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Deal).ID = 11
+	}).Return(nil)
+	suite.expectActivity(models.ActivityDealCreated)
+
+	deal := &models.Deal{Title: " Renewal ", CustomerID: 2, OwnerID: 7, Amount: 1200.555}
+	err := suite.service.Create(deal, nil, 7)
+
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Renewal", deal.Title)
+	assert.Equal(suite.T(), testProposalStage.ID, deal.StageID)
+	assert.Equal(suite.T(), 50, deal.Probability)
+	assert.Equal(suite.T(), "EUR", deal.Currency)
+	assert.Equal(suite.T(), 1200.56, deal.Amount)
+	assert.Nil(suite.T(), deal.ClosedAt)
+}
+
+func (suite *DealServiceTestSuite) TestCreate_ExplicitProbabilityWins() {
+	suite.expectValidReferences()
+	suite.mockRepo.On("GetStage", testProposalStage.ID).Return(&testProposalStage, nil)
+	suite.mockRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	suite.expectActivity(models.ActivityDealCreated)
+
+	probability := 35
+	deal := &models.Deal{Title: "Renewal", CustomerID: 2, OwnerID: 7, Currency: "usd", StageID: testProposalStage.ID}
+	require.NoError(suite.T(), suite.service.Create(deal, &probability, 7))
+
+	assert.Equal(suite.T(), 35, deal.Probability)
+	assert.Equal(suite.T(), "USD", deal.Currency)
+}
+
+func (suite *DealServiceTestSuite) TestCreate_ValidationErrors() {
+	suite.mockRepo.On("GetStage", testProposalStage.ID).Return(&testProposalStage, nil)
+	suite.mockCustomerRepo.On("GetByID", uint(2)).Return(&models.Customer{}, nil).Maybe()
+	suite.mockCustomerRepo.On("GetByID", uint(404)).Return(nil, gorm.ErrRecordNotFound).Maybe()
+	suite.mockUserRepo.On("GetByID", uint(7)).Return(&models.User{IsActive: true}, nil).Maybe()
+	suite.mockUserRepo.On("GetByID", uint(8)).Return(&models.User{IsActive: false}, nil).Maybe()
+
+	cases := map[string]func(d *models.Deal){
+		"blank title":       func(d *models.Deal) { d.Title = "  " },
+		"negative amount":   func(d *models.Deal) { d.Amount = -1 },
+		"bad currency":      func(d *models.Deal) { d.Currency = "EURO" },
+		"unknown customer":  func(d *models.Deal) { d.CustomerID = 404 },
+		"inactive owner":    func(d *models.Deal) { d.OwnerID = 8 },
+		"probability > 100": func(d *models.Deal) {},
+	}
+	for name, mutate := range cases {
+		deal := &models.Deal{Title: "Renewal", CustomerID: 2, OwnerID: 7, StageID: testProposalStage.ID}
+		mutate(deal)
+		var probability *int
+		if name == "probability > 100" {
+			tooHigh := 101
+			probability = &tooHigh
+		}
+		err := suite.service.Create(deal, probability, 7)
+		assert.ErrorIs(suite.T(), err, apperrors.ErrValidation, name)
+	}
+	suite.mockRepo.AssertNotCalled(suite.T(), "Create", mock.Anything, mock.Anything)
+}
+
+func (suite *DealServiceTestSuite) TestMoveToStage_WinningClosesAndPinsProbability() {
+	proposal := testProposalStage
+	suite.mockRepo.On("GetByID", uint(11)).Return(&models.Deal{
+		BaseModel: models.BaseModel{ID: 11}, Title: "Renewal", OwnerID: 7, StageID: proposal.ID, Stage: &proposal, Probability: 60,
+	}, nil)
+	suite.mockRepo.On("GetStage", testWonStage.ID).Return(&testWonStage, nil)
+	suite.mockRepo.On("Update", mock.MatchedBy(func(d *models.Deal) bool {
+		return d.StageID == testWonStage.ID && d.Probability == 100 && d.ClosedAt != nil
+	}), mock.MatchedBy(func(entry *models.DealStageChange) bool {
+		return *entry.FromStageID == proposal.ID && entry.FromStageName == "Proposal" &&
+			entry.ToStageName == "Closed Won" && entry.Probability == 100
+	})).Return(nil)
+	suite.expectActivity(models.ActivityDealWon)
+
+	probability := 20
+	_, err := suite.service.MoveToStage(11, testWonStage.ID, &probability, 9)
+
+	require.NoError(suite.T(), err)
+}
+
+func (suite *DealServiceTestSuite) TestMoveToStage_ReopeningClearsClosedAt() {
+	lost := testLostStage
+	closed := time.Now().Add(-time.Hour)
+	suite.mockRepo.On("GetByID", uint(11)).Return(&models.Deal{
+		BaseModel: models.BaseModel{ID: 11}, Title: "Renewal", OwnerID: 7, StageID: lost.ID, Stage: &lost, ClosedAt: &closed,
+	}, nil)
+	suite.mockRepo.On("GetStage", testProposalStage.ID).Return(&testProposalStage, nil)
+	suite.mockRepo.On("Update", mock.MatchedBy(func(d *models.Deal) bool {
+		return d.ClosedAt == nil && d.Probability == 50
+	}), mock.Anything).Return(nil)
+	suite.expectActivity(models.ActivityDealStageChanged)
+
+	_, err := suite.service.MoveToStage(11, testProposalStage.ID, nil, 9)
+
+	require.NoError(suite.T(), err)
+}
+
+func (suite *DealServiceTestSuite) TestUpdate_KeepsTheStageAndRecordsReassignment() {
+	won := testWonStage
+	suite.mockRepo.On("GetByID", uint(11)).Return(&models.Deal{
+		BaseModel: models.BaseModel{ID: 11}, Title: "Renewal", CustomerID: 2, OwnerID: 6, StageID: won.ID, Stage: &won, Probability: 100,
+	}, nil)
+	suite.expectValidReferences()
+	suite.mockRepo.On("Update", mock.MatchedBy(func(d *models.Deal) bool {
+		return d.StageID == won.ID && d.Probability == 100 && d.OwnerID == 7
+	}), (*models.DealStageChange)(nil)).Return(nil)
+	suite.expectActivity(models.ActivityDealReassigned)
+
+	_, err := suite.service.Update(&models.Deal{
+		BaseModel: models.BaseModel{ID: 11}, Title: "Renewal", CustomerID: 2, OwnerID: 7, StageID: testProposalStage.ID, Probability: 40,
+	})
+
+	require.NoError(suite.T(), err)
+}
+
+func (suite *DealServiceTestSuite) TestDeleteStage_RefusedWhileReferenced() {
+	suite.mockRepo.On("CountDealsInStage", uint(3)).Return(int64(2), nil)
+
+	err := suite.service.DeleteStage(3)
+
+	assert.ErrorIs(suite.T(), err, apperrors.ErrValidation)
+	suite.mockRepo.AssertNotCalled(suite.T(), "DeleteStage", mock.Anything)
+}
+
+func (suite *DealServiceTestSuite) TestUpdateStage_OutcomeLockedWhileInUse() {
+	stage := testProposalStage
+	suite.mockRepo.On("GetStage", uint(3)).Return(&stage, nil)
+	suite.mockRepo.On("CountDealsInStage", uint(3)).Return(int64(1), nil)
+
+	_, err := suite.service.UpdateStage(3, &models.PipelineStage{Name: "Proposal", Outcome: models.DealOutcomeWon})
+
+	assert.ErrorIs(suite.T(), err, apperrors.ErrValidation)
+}
+
+func (suite *DealServiceTestSuite) TestCreateStage_PinsWonProbability() {
+	suite.mockRepo.On("CreateStage", mock.Anything).Return(nil)
+
+	stage := &models.PipelineStage{Name: "Signed", Probability: 40, Outcome: models.DealOutcomeWon}
+	require.NoError(suite.T(), suite.service.CreateStage(stage))
+
+	assert.Equal(suite.T(), 100, stage.Probability)
+}
+
+func (suite *DealServiceTestSuite) TestPipeline_EveryStageInOrderWithWeightedValue() {
+	proposal, won := testProposalStage, testWonStage
+	suite.mockRepo.On("ListStages").Return([]models.PipelineStage{proposal, won, testLostStage}, nil)
+	suite.mockRepo.On("ListForReport", models.DealReportFilter{Currency: "EUR"}).Return([]models.Deal{
+		{Amount: 1000, Probability: 50, StageID: proposal.ID, Stage: &proposal},
+		{Amount: 333.33, Probability: 25, StageID: proposal.ID, Stage: &proposal},
+		{Amount: 500, Probability: 100, StageID: won.ID, Stage: &won},
+	}, nil)
+
+	rows, err := suite.service.Pipeline(models.DealReportFilter{})
+
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), rows, 3)
+	assert.Equal(suite.T(), models.DealPipelineRow{
+		StageID: proposal.ID, StageName: "Proposal", Outcome: models.DealOutcomeOpen,
+		Deals: 2, Value: 1333.33, WeightedValue: 583.33,
+	}, rows[0])
+	assert.Equal(suite.T(), int64(1), rows[1].Deals)
+	assert.Equal(suite.T(), int64(0), rows[2].Deals)
+}
+
+func (suite *DealServiceTestSuite) TestForecast_GroupsByMonthStageAndOwner() {
+	proposal, won, lost := testProposalStage, testWonStage, testLostStage
+	ana := &models.User{BaseModel: models.BaseModel{ID: 7}, FirstName: "Ana", LastName: "Lopez"}
+	ben := &models.User{BaseModel: models.BaseModel{ID: 8}, FirstName: "Ben", LastName: "Ode"}
+	may := time.Date(2026, 5, 3, 0, 0, 0, 0, time.UTC)
+	late := time.Date(2026, 5, 30, 0, 0, 0, 0, time.UTC)
+	june := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	suite.mockRepo.On("ListStages").Return([]models.PipelineStage{proposal, won, lost}, nil)
+	suite.mockRepo.On("ListForReport", models.DealReportFilter{Currency: "USD", OwnerID: 0}).Return([]models.Deal{
+		{Amount: 1000, Probability: 50, StageID: proposal.ID, Stage: &proposal, OwnerID: 8, Owner: ben, ExpectedCloseDate: &may},
+		{Amount: 1000, Probability: 30, StageID: proposal.ID, Stage: &proposal, OwnerID: 7, Owner: ana, ExpectedCloseDate: &may},
+		{Amount: 200, Probability: 50, StageID: proposal.ID, Stage: &proposal, OwnerID: 7, Owner: ana, ExpectedCloseDate: &late},
+		{Amount: 400, Probability: 100, StageID: won.ID, Stage: &won, OwnerID: 7, Owner: ana, ExpectedCloseDate: &may},
+		{Amount: 900, Probability: 0, StageID: lost.ID, Stage: &lost, OwnerID: 7, Owner: ana, ExpectedCloseDate: &may},
+		{Amount: 100, Probability: 50, StageID: proposal.ID, Stage: &proposal, OwnerID: 7, Owner: ana, ExpectedCloseDate: &june},
+		{Amount: 100, Probability: 50, StageID: proposal.ID, Stage: &proposal, OwnerID: 7, Owner: ana},
+	}, nil)
+
+	rows, err := suite.service.Forecast(models.DealReportFilter{Currency: "usd"})
+
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), rows, 4)
+	assert.Equal(suite.T(), models.DealForecastRow{
+		Month: "2026-05", StageID: proposal.ID, StageName: "Proposal", OwnerID: 7, OwnerName: "Ana Lopez",
+		Deals: 2, Value: 1200, WeightedValue: 400,
+	}, rows[0])
+	assert.Equal(suite.T(), "Ben Ode", rows[1].OwnerName)
+	assert.Equal(suite.T(), "Closed Won", rows[2].StageName)
+	assert.Equal(suite.T(), "2026-06", rows[3].Month)
+}
+
+func (suite *DealServiceTestSuite) TestForecast_RejectsAMalformedCurrency() {
+	_, err := suite.service.Forecast(models.DealReportFilter{Currency: "dollars"})
+
+	assert.ErrorIs(suite.T(), err, apperrors.ErrValidation)
+}
+
+func TestDealServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(DealServiceTestSuite))
+}
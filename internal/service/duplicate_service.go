@@ -0,0 +1,507 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+)
+
+// Candidate scoring. An email match is close to proof, a shared phone number
+// is strong but can be a switchboard, and a similar name at the same company
+// only suggests a variant spelling; together they add up to 100.
+const (
+	duplicateEmailWeight = 50
+	duplicatePhoneWeight = 30
+	duplicateNameWeight  = 20
+
+	// duplicateNameSimilarity is how close two normalized full names must
+	// be, as 1 - edit distance / length, to count as the same name.
+	duplicateNameSimilarity = 0.8
+
+	// duplicateScanLimit caps how many prefiltered rows per table are scored.
+	duplicateScanLimit = 200
+)
+
+// DuplicateService finds leads and customers that may describe the same
+// person, and merges two records into one.
+type DuplicateService interface {
+	// FindForLead scores the live leads and customers against lead, which
+	// need not be stored yet, and returns up to limit candidates, best first.
+	// A non-zero ownerID keeps lead candidates to that user's leads. The
+	// customer the lead was converted into is never a candidate.
+	FindForLead(lead *models.Lead, ownerID uint, limit int) ([]models.DuplicateCandidate, error)
+	// LeadDuplicates loads the lead and runs FindForLead for it; a non-zero
+	// ownerID that does not own the lead is refused with apperrors.ErrForbidden.
+	LeadDuplicates(leadID, ownerID uint, limit int) ([]models.DuplicateCandidate, error)
+
+	// MergeLeads folds one lead into another in one transaction: the survivor
+	// takes the chosen field values, everything that referenced the merged
+	// lead is re-pointed to it, and the merged lead is erased. A non-zero
+	// ownerID must own both leads. Two leads converted into different
+	// customers are refused with apperrors.ErrValidation; merge the customers
+	// first.
+	MergeLeads(req models.MergeRequest, ownerID uint) (*models.LeadMerge, error)
+	// MergeCustomers does the same for two customers, and also moves the
+	// leads converted into the merged customer. The survivor takes the merged
+	// customer's portal login when it has none; two logins are refused with
+	// apperrors.ErrValidation.
+	MergeCustomers(req models.MergeRequest) (*models.CustomerMerge, error)
+}
+
+type duplicateService struct {
+	repo         repository.DuplicateRepository
+	leadRepo     repository.LeadRepository
+	customerRepo repository.CustomerRepository
+	txManager    *utils.TransactionManager
+	activityFeed
+	webhookEvents
+}
+
+func NewDuplicateService(repo repository.DuplicateRepository, leadRepo repository.LeadRepository, customerRepo repository.CustomerRepository, txManager *utils.TransactionManager, opts ...EntityOption) DuplicateService {
+	s := &duplicateService{
+		repo:         repo,
+		leadRepo:     leadRepo,
+		customerRepo: customerRepo,
+		txManager:    txManager,
+	}
+	applyEntityOptions(&s.activityFeed, &s.webhookEvents, opts)
+	return s
+}
+
+// duplicatePerson is the part of a lead or customer the scoring compares.
+type duplicatePerson struct {
+	firstName, lastName string
+	company             string
+	accountID           *uint
+	emailKey, phoneKey  string
+}
+
+func leadPerson(lead *models.Lead) duplicatePerson {
+	return duplicatePerson{
+		firstName: lead.FirstName, lastName: lead.LastName,
+		company: lead.Company, accountID: lead.AccountID,
+		emailKey: models.NormalizeEmail(lead.Email), phoneKey: models.NormalizePhone(lead.Phone),
+	}
+}
+
+func customerPerson(customer *models.Customer) duplicatePerson {
+	return duplicatePerson{
+		firstName: customer.FirstName, lastName: customer.LastName,
+		company: customer.Company, accountID: customer.AccountID,
+		emailKey: models.NormalizeEmail(customer.Email), phoneKey: models.NormalizePhone(customer.Phone),
+	}
+}
+
+// score compares a candidate with the probed person and returns the weight of
+// every signal they share.
+func (p duplicatePerson) score(candidate duplicatePerson) (int, []models.DuplicateReason) {
+	score := 0
+	var reasons []models.DuplicateReason
+	if p.emailKey != "" && p.emailKey == candidate.emailKey {
+		score += duplicateEmailWeight
+		reasons = append(reasons, models.DuplicateReasonEmail)
+	}
+	if p.phoneKey != "" && p.phoneKey == candidate.phoneKey {
+		score += duplicatePhoneWeight
+		reasons = append(reasons, models.DuplicateReasonPhone)
+	}
+	if p.sameCompany(candidate) && p.similarName(candidate) {
+		score += duplicateNameWeight
+		reasons = append(reasons, models.DuplicateReasonNameCompany)
+	}
+	return score, reasons
+}
+
+func (p duplicatePerson) sameCompany(other duplicatePerson) bool {
+	if p.accountID != nil && other.accountID != nil {
+		return *p.accountID == *other.accountID
+	}
+	key := models.AccountNameKey(p.company)
+	return key != "" && key == models.AccountNameKey(other.company)
+}
+
+// similarName compares the full names both ways round, so "Smith John" is the
+// same name as "John Smith".
+func (p duplicatePerson) similarName(other duplicatePerson) bool {
+	name := models.NormalizeName(p.firstName + " " + p.lastName)
+	if name == "" {
+		return false
+	}
+	for _, candidate := range []string{
+		models.NormalizeName(other.firstName + " " + other.lastName),
+		models.NormalizeName(other.lastName + " " + other.firstName),
+	} {
+		if nameSimilarity(name, candidate) >= duplicateNameSimilarity {
+			return true
+		}
+	}
+	return false
+}
+
+// nameSimilarity is 1 minus the edit distance between a and b over the length
+// of the longer one.
+func nameSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 0
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func (s *duplicateService) FindForLead(lead *models.Lead, ownerID uint, limit int) ([]models.DuplicateCandidate, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("lead_id", lead.ID), "DuplicateService", "FindForLead")
+
+	person := leadPerson(lead)
+	probe := models.DuplicateProbe{
+		EmailKey:      person.emailKey,
+		PhoneKey:      person.phoneKey,
+		LastName:      lead.LastName,
+		AccountID:     lead.AccountID,
+		ExcludeLeadID: lead.ID,
+		LeadOwnerID:   ownerID,
+	}
+	if lead.CustomerID != nil {
+		probe.ExcludeCustomerID = *lead.CustomerID
+	}
+
+	leads, err := s.repo.FindLeads(probe, duplicateScanLimit)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	customers, err := s.repo.FindCustomers(probe, duplicateScanLimit)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+
+	candidates := []models.DuplicateCandidate{}
+	for i := range leads {
+		candidate := &leads[i]
+		score, reasons := person.score(leadPerson(candidate))
+		if score == 0 {
+			continue
+		}
+		candidates = append(candidates, models.DuplicateCandidate{
+			EntityType: models.AuditEntityLead, ID: candidate.ID,
+			FirstName: candidate.FirstName, LastName: candidate.LastName,
+			Email: candidate.Email, Phone: candidate.Phone, Company: candidate.Company,
+			Score: score, Reasons: reasons,
+		})
+	}
+	for i := range customers {
+		candidate := &customers[i]
+		score, reasons := person.score(customerPerson(candidate))
+		if score == 0 {
+			continue
+		}
+		candidates = append(candidates, models.DuplicateCandidate{
+			EntityType: models.AuditEntityCustomer, ID: candidate.ID,
+			FirstName: candidate.FirstName, LastName: candidate.LastName,
+			Email: candidate.Email, Phone: candidate.Phone, Company: candidate.Company,
+			Score: score, Reasons: reasons,
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates, nil
+}
+
+func (s *duplicateService) LeadDuplicates(leadID, ownerID uint, limit int) ([]models.DuplicateCandidate, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("lead_id", leadID), "DuplicateService", "LeadDuplicates")
+
+	lead, err := s.leadRepo.GetByID(leadID)
+	if err != nil {
+		if isNotFound(err) {
+			err = fmt.Errorf("lead %d: %w", leadID, apperrors.ErrNotFound)
+		}
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	if ownerID != 0 && lead.OwnerID != ownerID {
+		return nil, fmt.Errorf("lead %d belongs to another user: %w", leadID, apperrors.ErrForbidden)
+	}
+	return s.FindForLead(lead, ownerID, limit)
+}
+
+// pickMerged settles one field of a merge: the survivor keeps its value unless
+// the merged record's was chosen, or no side was chosen and the survivor's is
+// blank.
+func pickMerged[T comparable](side models.MergeSide, survivor *T, merged T) {
+	var zero T
+	if side == models.MergeSideMerged || (side == "" && *survivor == zero) {
+		*survivor = merged
+	}
+}
+
+// mergeCustomFields fills the custom fields the survivor has no value for
+// with the merged record's values. Custom fields are never chosen field by
+// field: a value only one side holds is kept, and where both hold one the
+// survivor's wins.
+func mergeCustomFields(survivor, merged map[string]interface{}) {
+	if survivor == nil {
+		return
+	}
+	for name, value := range merged {
+		if value != nil && survivor[name] == nil {
+			survivor[name] = value
+		}
+	}
+}
+
+// leadMergeFields and customerMergeFields are the fields a merge request may
+// choose a side for, by JSON name.
+var leadMergeFields = map[string]func(side models.MergeSide, survivor, merged *models.Lead){
+	"first_name":     func(side models.MergeSide, s, m *models.Lead) { pickMerged(side, &s.FirstName, m.FirstName) },
+	"last_name":      func(side models.MergeSide, s, m *models.Lead) { pickMerged(side, &s.LastName, m.LastName) },
+	"email":          func(side models.MergeSide, s, m *models.Lead) { pickMerged(side, &s.Email, m.Email) },
+	"phone":          func(side models.MergeSide, s, m *models.Lead) { pickMerged(side, &s.Phone, m.Phone) },
+	"company":        func(side models.MergeSide, s, m *models.Lead) { pickMerged(side, &s.Company, m.Company) },
+	"account_id":     func(side models.MergeSide, s, m *models.Lead) { pickMerged(side, &s.AccountID, m.AccountID) },
+	"position":       func(side models.MergeSide, s, m *models.Lead) { pickMerged(side, &s.Position, m.Position) },
+	"source":         func(side models.MergeSide, s, m *models.Lead) { pickMerged(side, &s.Source, m.Source) },
+	"status":         func(side models.MergeSide, s, m *models.Lead) { pickMerged(side, &s.Status, m.Status) },
+	"classification": func(side models.MergeSide, s, m *models.Lead) { pickMerged(side, &s.Classification, m.Classification) },
+	"external_id":    func(side models.MergeSide, s, m *models.Lead) { pickMerged(side, &s.ExternalID, m.ExternalID) },
+	"notes":          func(side models.MergeSide, s, m *models.Lead) { pickMerged(side, &s.Notes, m.Notes) },
+	"owner_id":       func(side models.MergeSide, s, m *models.Lead) { pickMerged(side, &s.OwnerID, m.OwnerID) },
+}
+
+var customerMergeFields = map[string]func(side models.MergeSide, survivor, merged *models.Customer){
+	"first_name":     func(side models.MergeSide, s, m *models.Customer) { pickMerged(side, &s.FirstName, m.FirstName) },
+	"last_name":      func(side models.MergeSide, s, m *models.Customer) { pickMerged(side, &s.LastName, m.LastName) },
+	"email":          func(side models.MergeSide, s, m *models.Customer) { pickMerged(side, &s.Email, m.Email) },
+	"phone":          func(side models.MergeSide, s, m *models.Customer) { pickMerged(side, &s.Phone, m.Phone) },
+	"company":        func(side models.MergeSide, s, m *models.Customer) { pickMerged(side, &s.Company, m.Company) },
+	"account_id":     func(side models.MergeSide, s, m *models.Customer) { pickMerged(side, &s.AccountID, m.AccountID) },
+	"position":       func(side models.MergeSide, s, m *models.Customer) { pickMerged(side, &s.Position, m.Position) },
+	"address":        func(side models.MergeSide, s, m *models.Customer) { pickMerged(side, &s.Address, m.Address) },
+	"city":           func(side models.MergeSide, s, m *models.Customer) { pickMerged(side, &s.City, m.City) },
+	"state":          func(side models.MergeSide, s, m *models.Customer) { pickMerged(side, &s.State, m.State) },
+	"country":        func(side models.MergeSide, s, m *models.Customer) { pickMerged(side, &s.Country, m.Country) },
+	"postal_code":    func(side models.MergeSide, s, m *models.Customer) { pickMerged(side, &s.PostalCode, m.PostalCode) },
+	"notes":          func(side models.MergeSide, s, m *models.Customer) { pickMerged(side, &s.Notes, m.Notes) },
+	"assigned_to_id": func(side models.MergeSide, s, m *models.Customer) { pickMerged(side, &s.AssignedToID, m.AssignedToID) },
+}
+
+// validateMergeRequest checks the two ids and every field choice against the
+// fields the entity allows.
+func validateMergeRequest[F any](req models.MergeRequest, fields map[string]F) error {
+	if req.SurvivorID == 0 || req.MergedID == 0 {
+		return fmt.Errorf("survivor_id and merged_id are required: %w", apperrors.ErrValidation)
+	}
+	if req.SurvivorID == req.MergedID {
+		return fmt.Errorf("a record cannot be merged into itself: %w", apperrors.ErrValidation)
+	}
+	for field, side := range req.Fields {
+		if _, ok := fields[field]; !ok {
+			return fmt.Errorf("field %q cannot be merged: %w", field, apperrors.ErrValidation)
+		}
+		if side != models.MergeSideSurvivor && side != models.MergeSideMerged {
+			return fmt.Errorf("field %q must take the survivor's or the merged record's value: %w", field, apperrors.ErrValidation)
+		}
+	}
+	return nil
+}
+
+func (s *duplicateService) MergeLeads(req models.MergeRequest, ownerID uint) (*models.LeadMerge, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithFields(map[string]interface{}{
+		"survivor_id": req.SurvivorID,
+		"merged_id":   req.MergedID,
+	}), "DuplicateService", "MergeLeads")
+
+	if err := validateMergeRequest(req, leadMergeFields); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+
+	var result *models.LeadMerge
+	err := s.txManager.WithTransactionAndRetry(context.Background(), func(ctx context.Context) error {
+		tx, ok := utils.GetTxFromContext(ctx)
+		if !ok {
+			return utils.ErrNoTransaction
+		}
+		txLeadRepo := s.leadRepo.WithTx(tx)
+		txRepo := s.repo.WithTx(tx)
+
+		survivor, err := loadMergeLead(txLeadRepo, req.SurvivorID, ownerID)
+		if err != nil {
+			return err
+		}
+		merged, err := loadMergeLead(txLeadRepo, req.MergedID, ownerID)
+		if err != nil {
+			return err
+		}
+		if survivor.CustomerID != nil && merged.CustomerID != nil && *survivor.CustomerID != *merged.CustomerID {
+			return fmt.Errorf("leads %d and %d were converted into different customers, merge the customers first: %w",
+				survivor.ID, merged.ID, apperrors.ErrValidation)
+		}
+
+		for field, pick := range leadMergeFields {
+			pick(req.Fields[field], survivor, merged)
+		}
+		mergeCustomFields(survivor.CustomFields, merged.CustomFields)
+		if survivor.CustomerID == nil {
+			survivor.CustomerID = merged.CustomerID
+		}
+		if survivor.CustomerID != nil {
+			survivor.Status = models.LeadStatusConverted
+		}
+
+		moved, err := txRepo.RepointLead(merged.ID, survivor.ID)
+		if err != nil {
+			return err
+		}
+		if err := txRepo.EraseLead(merged.ID); err != nil {
+			return err
+		}
+		if err := txLeadRepo.Update(survivor); err != nil {
+			return err
+		}
+		result = &models.LeadMerge{Lead: survivor, MergedID: merged.ID, Moved: moved}
+		return nil
+	}, 3)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+
+	lead := result.Lead
+	s.recordActivity(models.AuditEntityLead, lead.ID, models.ActivityLeadMerged, activityOwner(lead.OwnerID),
+		"Leads merged", fmt.Sprintf("Lead %d was merged into %s", result.MergedID, describeLead(lead)))
+	s.publishWebhook(models.WebhookLeadUpdated, models.AuditEntityLead, lead.ID, leadWebhookData(lead))
+	s.publishWebhook(models.WebhookLeadDeleted, models.AuditEntityLead, result.MergedID, deletedWebhookData(result.MergedID))
+	return result, nil
+}
+
+// loadMergeLead loads one side of a lead merge, as the owner when ownerID is
+// not zero.
+func loadMergeLead(leadRepo repository.LeadRepository, id, ownerID uint) (*models.Lead, error) {
+	lead, err := leadRepo.GetByID(id)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, fmt.Errorf("lead %d: %w", id, apperrors.ErrNotFound)
+		}
+		return nil, err
+	}
+	if ownerID != 0 && lead.OwnerID != ownerID {
+		return nil, fmt.Errorf("lead %d belongs to another user: %w", id, apperrors.ErrForbidden)
+	}
+	return lead, nil
+}
+
+func (s *duplicateService) MergeCustomers(req models.MergeRequest) (*models.CustomerMerge, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithFields(map[string]interface{}{
+		"survivor_id": req.SurvivorID,
+		"merged_id":   req.MergedID,
+	}), "DuplicateService", "MergeCustomers")
+
+	if err := validateMergeRequest(req, customerMergeFields); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+
+	var result *models.CustomerMerge
+	err := s.txManager.WithTransactionAndRetry(context.Background(), func(ctx context.Context) error {
+		tx, ok := utils.GetTxFromContext(ctx)
+		if !ok {
+			return utils.ErrNoTransaction
+		}
+		txCustomerRepo := s.customerRepo.WithTx(tx)
+		txRepo := s.repo.WithTx(tx)
+
+		survivor, err := loadMergeCustomer(txCustomerRepo, req.SurvivorID)
+		if err != nil {
+			return err
+		}
+		merged, err := loadMergeCustomer(txCustomerRepo, req.MergedID)
+		if err != nil {
+			return err
+		}
+		if survivor.UserID != nil && merged.UserID != nil && *survivor.UserID != *merged.UserID {
+			return fmt.Errorf("customers %d and %d both have a portal login: %w",
+				survivor.ID, merged.ID, apperrors.ErrValidation)
+		}
+
+		for field, pick := range customerMergeFields {
+			pick(req.Fields[field], survivor, merged)
+		}
+		mergeCustomFields(survivor.CustomFields, merged.CustomFields)
+		if survivor.UserID == nil {
+			survivor.UserID = merged.UserID
+		}
+		survivor.User = nil
+
+		moved, err := txRepo.RepointCustomer(merged.ID, survivor.ID)
+		if err != nil {
+			return err
+		}
+		// The merged customer is erased before the survivor is saved: the
+		// unique index on the email covers it until then, and the survivor
+		// may be taking that very address.
+		if err := txRepo.EraseCustomer(merged.ID); err != nil {
+			return err
+		}
+		if err := txCustomerRepo.Update(survivor); err != nil {
+			return err
+		}
+		result = &models.CustomerMerge{Customer: survivor, MergedID: merged.ID, Moved: moved}
+		return nil
+	}, 3)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+
+	customer := result.Customer
+	s.recordActivity(models.AuditEntityCustomer, customer.ID, models.ActivityCustomerMerged, customer.AssignedToID,
+		"Customers merged", fmt.Sprintf("Customer %d was merged into %s", result.MergedID, describeCustomer(customer)))
+	s.publishWebhook(models.WebhookCustomerUpdated, models.AuditEntityCustomer, customer.ID, customerWebhookData(customer))
+	s.publishWebhook(models.WebhookCustomerDeleted, models.AuditEntityCustomer, result.MergedID, deletedWebhookData(result.MergedID))
+	return result, nil
+}
+
+func loadMergeCustomer(customerRepo repository.CustomerRepository, id uint) (*models.Customer, error) {
+	customer, err := customerRepo.GetByID(id)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, fmt.Errorf("customer %d: %w", id, apperrors.ErrNotFound)
+		}
+		return nil, err
+	}
+	return customer, nil
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type DuplicateServiceTestSuite struct {
+	suite.Suite
+	mockRepo         *mocks.DuplicateRepository
+	mockLeadRepo     *mocks.LeadRepository
+	mockCustomerRepo *mocks.CustomerRepository
+	mockActivity     *mocks.ActivityService
+	txManager        *utils.TransactionManager
+	service          DuplicateService
+	calls            []string
+}
+
+func (suite *DuplicateServiceTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	suite.Require().NoError(err)
+	suite.txManager = utils.NewTransactionManager(db)
+}
+
+func (suite *DuplicateServiceTestSuite) SetupTest() {
+	suite.mockRepo = new(mocks.DuplicateRepository)
+	suite.mockLeadRepo = new(mocks.LeadRepository)
+	suite.mockCustomerRepo = new(mocks.CustomerRepository)
+	suite.mockActivity = new(mocks.ActivityService)
+	suite.calls = nil
+	suite.service = NewDuplicateService(suite.mockRepo, suite.mockLeadRepo, suite.mockCustomerRepo, suite.txManager,
+		WithActivityFeed(suite.mockActivity))
+
+	suite.mockRepo.On("WithTx", mock.Anything).Return(suite.mockRepo).Maybe()
+	suite.mockLeadRepo.On("WithTx", mock.Anything).Return(suite.mockLeadRepo).Maybe()
+	suite.mockCustomerRepo.On("WithTx", mock.Anything).Return(suite.mockCustomerRepo).Maybe()
+}
+
+func (suite *DuplicateServiceTestSuite) TearDownTest() {
+	suite.mockRepo.AssertExpectations(suite.T())
+	suite.mockLeadRepo.AssertExpectations(suite.T())
+	suite.mockCustomerRepo.AssertExpectations(suite.T())
+	suite.mockActivity.AssertExpectations(suite.T())
+}
+
+// track records the order of the merge's writes.
+func (suite *DuplicateServiceTestSuite) track(name string) func(mock.Arguments) {
+	return func(mock.Arguments) { suite.calls = append(suite.calls, name) }
+}
+
+func (suite *DuplicateServiceTestSuite) TestFindForLead_ScoresAndRanksCandidates() {
+	account := uint(9)
+	lead := &models.Lead{
+		BaseModel: models.BaseModel{ID: 1}, FirstName: "John", LastName: "Lovelace",
+		Email: "J.Lovelace+web@gmail.com", Phone: "+1 555 123 4567", Company: "Acme Inc.", AccountID: &account,
+	}
+	suite.mockRepo.On("FindLeads", models.DuplicateProbe{
+		EmailKey: "jlovelace@gmail.com", PhoneKey: "5551234567", LastName: "Lovelace", AccountID: &account,
+		ExcludeLeadID: 1, LeadOwnerID: 7,
+	}, duplicateScanLimit).Return([]models.Lead{
+		{BaseModel: models.BaseModel{ID: 2}, FirstName: "Jon", LastName: "Lovelace", Email: "jlovelace@googlemail.com", AccountID: &account},
+		{BaseModel: models.BaseModel{ID: 3}, FirstName: "Grace", LastName: "Hopper", Email: "grace@example.com", AccountID: &account},
+		{BaseModel: models.BaseModel{ID: 4}, FirstName: "Lovelace", LastName: "John", Email: "other@example.com", Company: "ACME"},
+	}, nil)
+	suite.mockRepo.On("FindCustomers", mock.Anything, duplicateScanLimit).Return([]models.Customer{
+		{BaseModel: models.BaseModel{ID: 5}, FirstName: "Someone", LastName: "Else", Email: "x@example.com", Phone: "555.123.4567"},
+	}, nil)
+
+	candidates, err := suite.service.FindForLead(lead, 7, 10)
+
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), candidates, 3, "a shared account alone is no reason")
+	assert.Equal(suite.T(), uint(2), candidates[0].ID)
+	assert.Equal(suite.T(), 70, candidates[0].Score)
+	assert.Equal(suite.T(), []models.DuplicateReason{models.DuplicateReasonEmail, models.DuplicateReasonNameCompany}, candidates[0].Reasons)
+	assert.Equal(suite.T(), models.AuditEntityCustomer, candidates[1].EntityType)
+	assert.Equal(suite.T(), 30, candidates[1].Score)
+	assert.Equal(suite.T(), uint(4), candidates[2].ID, "a swapped name at the same company by name matches")
+	assert.Equal(suite.T(), 20, candidates[2].Score)
+}
+
+func (suite *DuplicateServiceTestSuite) TestLeadDuplicates_OthersLeadIsForbidden() {
+	suite.mockLeadRepo.On("GetByID", uint(1)).Return(&models.Lead{BaseModel: models.BaseModel{ID: 1}, OwnerID: 8}, nil)
+
+	_, err := suite.service.LeadDuplicates(1, 7, 10)
+
+	assert.ErrorIs(suite.T(), err, apperrors.ErrForbidden)
+}
+
+func (suite *DuplicateServiceTestSuite) TestMergeLeads_PicksFieldsAndKeepsTheConversionLink() {
+	customerID := uint(40)
+	suite.mockLeadRepo.On("GetByID", uint(1)).Return(&models.Lead{
+		BaseModel: models.BaseModel{ID: 1}, FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com",
+		Status: models.LeadStatusContacted, Notes: "Keep me", OwnerID: 7,
+	}, nil)
+	suite.mockLeadRepo.On("GetByID", uint(2)).Return(&models.Lead{
+		BaseModel: models.BaseModel{ID: 2}, FirstName: "Augusta", LastName: "Lovelace", Email: "ada@old.example.com",
+		Phone: "555 123 4567", Status: models.LeadStatusConverted, Notes: "Drop me", OwnerID: 7, CustomerID: &customerID,
+	}, nil)
+	suite.mockRepo.On("RepointLead", uint(2), uint(1)).Run(suite.track("repoint")).
+		Return(models.MergeCounts{Tasks: 2, FormSubmissions: 1}, nil)
+	suite.mockRepo.On("EraseLead", uint(2)).Run(suite.track("erase")).Return(nil)
+	suite.mockLeadRepo.On("Update", mock.MatchedBy(func(l *models.Lead) bool {
+		return l.ID == 1 && l.FirstName == "Augusta" && l.Email == "ada@example.com" && l.Phone == "555 123 4567" &&
+			l.Notes == "Keep me" && l.CustomerID != nil && *l.CustomerID == customerID &&
+			l.Status == models.LeadStatusConverted
+	})).Run(suite.track("update")).Return(nil)
+	suite.mockActivity.On("Record", mock.MatchedBy(func(e *models.ActivityEvent) bool {
+		return e.Type == models.ActivityLeadMerged && e.EntityID == 1
+	})).Return(nil)
+
+	result, err := suite.service.MergeLeads(models.MergeRequest{
+		SurvivorID: 1, MergedID: 2,
+		Fields: map[string]models.MergeSide{"first_name": models.MergeSideMerged, "notes": models.MergeSideSurvivor},
+	}, 7)
+
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), uint(2), result.MergedID)
+	assert.Equal(suite.T(), int64(2), result.Moved.Tasks)
+	assert.Equal(suite.T(), []string{"repoint", "erase", "update"}, suite.calls)
+}
+
+func (suite *DuplicateServiceTestSuite) TestMergeLeads_Refusals() {
+	first, second := uint(40), uint(41)
+	suite.mockLeadRepo.On("GetByID", uint(1)).Return(&models.Lead{BaseModel: models.BaseModel{ID: 1}, OwnerID: 7, CustomerID: &first}, nil)
+	suite.mockLeadRepo.On("GetByID", uint(2)).Return(&models.Lead{BaseModel: models.BaseModel{ID: 2}, OwnerID: 7, CustomerID: &second}, nil)
+	suite.mockLeadRepo.On("GetByID", uint(3)).Return(&models.Lead{BaseModel: models.BaseModel{ID: 3}, OwnerID: 8}, nil)
+
+	_, err := suite.service.MergeLeads(models.MergeRequest{SurvivorID: 1, MergedID: 2}, 7)
+	assert.ErrorIs(suite.T(), err, apperrors.ErrValidation, "converted into different customers")
+
+	_, err = suite.service.MergeLeads(models.MergeRequest{SurvivorID: 1, MergedID: 3}, 7)
+	assert.ErrorIs(suite.T(), err, apperrors.ErrForbidden)
+
+	for name, req := range map[string]models.MergeRequest{
+		"itself":        {SurvivorID: 1, MergedID: 1},
+		"no survivor":   {MergedID: 1},
+		"unknown field": {SurvivorID: 1, MergedID: 2, Fields: map[string]models.MergeSide{"customer_id": models.MergeSideMerged}},
+		"unknown side":  {SurvivorID: 1, MergedID: 2, Fields: map[string]models.MergeSide{"email": "both"}},
+	} {
+		_, err := suite.service.MergeLeads(req, 0)
+		assert.ErrorIs(suite.T(), err, apperrors.ErrValidation, name)
+	}
+	suite.mockRepo.AssertNotCalled(suite.T(), "RepointLead", mock.Anything, mock.Anything)
+}
+
+func (suite *DuplicateServiceTestSuite) TestMergeCustomers_MovesThePortalLogin() {
+	login := uint(12)
+	suite.mockCustomerRepo.On("GetByID", uint(1)).Return(&models.Customer{
+		BaseModel: models.BaseModel{ID: 1}, FirstName: "Ada", Email: "ada@example.com",
+	}, nil)
+	suite.mockCustomerRepo.On("GetByID", uint(2)).Return(&models.Customer{
+		BaseModel: models.BaseModel{ID: 2}, FirstName: "Ada", Email: "ada@old.example.com", City: "London", UserID: &login,
+	}, nil)
+	suite.mockRepo.On("RepointCustomer", uint(2), uint(1)).Run(suite.track("repoint")).Return(models.MergeCounts{Leads: 1}, nil)
+	suite.mockRepo.On("EraseCustomer", uint(2)).Run(suite.track("erase")).Return(nil)
+	suite.mockCustomerRepo.On("Update", mock.MatchedBy(func(c *models.Customer) bool {
+		return c.Email == "ada@old.example.com" && c.City == "London" && c.UserID != nil && *c.UserID == login
+	})).Run(suite.track("update")).Return(nil)
+	suite.mockActivity.On("Record", mock.MatchedBy(func(e *models.ActivityEvent) bool {
+		return e.Type == models.ActivityCustomerMerged && e.EntityID == 1
+	})).Return(nil)
+
+	result, err := suite.service.MergeCustomers(models.MergeRequest{
+		SurvivorID: 1, MergedID: 2, Fields: map[string]models.MergeSide{"email": models.MergeSideMerged},
+	})
+
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(1), result.Moved.Leads)
+	assert.Equal(suite.T(), []string{"repoint", "erase", "update"}, suite.calls,
+		"the merged customer frees its email before the survivor takes it")
+}
+
+func (suite *DuplicateServiceTestSuite) TestMergeCustomers_TwoPortalLogins() {
+	first, second := uint(12), uint(13)
+	suite.mockCustomerRepo.On("GetByID", uint(1)).Return(&models.Customer{BaseModel: models.BaseModel{ID: 1}, UserID: &first}, nil)
+	suite.mockCustomerRepo.On("GetByID", uint(2)).Return(&models.Customer{BaseModel: models.BaseModel{ID: 2}, UserID: &second}, nil)
+
+	_, err := suite.service.MergeCustomers(models.MergeRequest{SurvivorID: 1, MergedID: 2})
+
+	assert.ErrorIs(suite.T(), err, apperrors.ErrValidation)
+}
+
+func (suite *DuplicateServiceTestSuite) TestMergeCustomers_NotFound() {
+	suite.mockCustomerRepo.On("GetByID", uint(1)).Return(nil, gorm.ErrRecordNotFound)
+
+	_, err := suite.service.MergeCustomers(models.MergeRequest{SurvivorID: 1, MergedID: 2})
+
+	assert.True(suite.T(), apperrors.IsNotFound(err))
+}
+
+func TestDuplicateServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(DuplicateServiceTestSuite))
+}
@@ -200,19 +200,27 @@ type formService struct {
 	// verifier is nil whenever the server has no reCAPTCHA key pair, which is
 	// what makes a form's captcha_enabled flag a no-op instead of a wall.
 	verifier *forms.RecaptchaVerifier
+	userPermissions
+	webhookEvents
+	auditRecorder
 }
 
 // NewFormService wires the forms module. apiPrefix is the mount point of the
 // API (e.g. "/api/v1"), which together with cfg.PublicBaseURL yields the
-// confirmation link mailed to visitors.
+// confirmation link mailed to visitors. roleService resolves the role of a
+// form's lead owner, who must be allowed to update leads. The leads the public
+// submissions create or add to are audited as the system's doing.
 func NewFormService(
 	repo repository.FormRepository,
 	leadRepo repository.LeadRepository,
 	userRepo repository.UserRepository,
+	roleService RoleService,
+	auditService AuditService,
 	m mailer.Mailer,
 	txManager *utils.TransactionManager,
 	cfg config.FormsConfig,
 	apiPrefix string,
+	opts ...WebhookOption,
 ) FormService {
 	s := &formService{
 		repo:      repo,
@@ -223,11 +231,14 @@ func NewFormService(
 		cfg:       cfg,
 		confirmURL: strings.TrimRight(cfg.PublicBaseURL, "/") +
 			"/" + strings.Trim(apiPrefix, "/") + "/forms/public/confirm",
-		tokenSecret: formTokenSecret(),
+		tokenSecret:     formTokenSecret(),
+		userPermissions: userPermissions{roles: roleService},
+		auditRecorder:   auditRecorder{auditService: auditService},
 	}
 	if cfg.RecaptchaActive() {
 		s.verifier = forms.NewRecaptchaVerifier(cfg.RecaptchaSecret, cfg.RecaptchaMinScore)
 	}
+	s.applyWebhookOptions(opts)
 	return s
 }
 
@@ -372,11 +383,18 @@ func (s *formService) Delete(id uint) error {
 	return nil
 }
 
-func (s *formService) ListSubmissions(formID uint, offset, limit int, status string) ([]models.FormSubmission, int64, error) {
+func (s *formService) ListSubmissions(formID uint, filter models.FormSubmissionFilter, offset, limit int) ([]models.FormSubmission, int64, error) {
 	if _, err := s.GetByID(formID); err != nil {
 		return nil, 0, err
 	}
-	return s.repo.ListSubmissions(formID, offset, limit, status)
+	return s.repo.ListSubmissions(formID, filter, offset, limit)
+}
+
+func (s *formService) StreamSubmissions(formID uint, filter models.FormSubmissionFilter, fn func([]models.FormSubmission) error) error {
+	if _, err := s.GetByID(formID); err != nil {
+		return err
+	}
+	return s.repo.StreamSubmissions(formID, filter, fn)
 }
 
 func (s *formService) GetSubmission(id uint) (*models.FormSubmission, error) {
@@ -419,7 +437,7 @@ func (s *formService) prepare(form *models.Form) error {
 }
 
 // checkLeadOwner makes sure created leads land with someone who can work them:
-// an existing, active user holding a role that owns leads.
+// an existing, active user whose role, built-in or custom, may update leads.
 func (s *formService) checkLeadOwner(form *models.Form) error {
 	if form.DefaultOwnerID == 0 {
 		return nil
@@ -435,8 +453,12 @@ func (s *formService) checkLeadOwner(form *models.Form) error {
 	if !owner.IsActive {
 		return FieldErrors{"default_owner_id": "the lead owner is deactivated"}
 	}
-	if owner.Role != models.RoleAdmin && owner.Role != models.RoleSales {
-		return FieldErrors{"default_owner_id": "leads can only be owned by an admin or sales user"}
+	allowed, err := s.userAllows(owner, models.PermLeadsUpdate)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return FieldErrors{"default_owner_id": "leads can only be owned by a user whose role may update leads"}
 	}
 	return nil
 }
@@ -782,6 +804,7 @@ func (s *formService) newSubmission(form *models.Form, values map[string]string,
 // submission's link to it are written in one transaction so a submission can
 // never point at a lead that was rolled back.
 func (s *formService) storeReceivedSubmission(form *models.Form, submission *models.FormSubmission) error {
+	var change *submissionLeadChange
 	err := s.txManager.WithTransaction(context.Background(), func(ctx context.Context) error {
 		tx, ok := utils.GetTxFromContext(ctx)
 		if !ok {
@@ -795,17 +818,22 @@ func (s *formService) storeReceivedSubmission(form *models.Form, submission *mod
 		if !form.CreateLead {
 			return nil
 		}
-		if err := s.applySubmissionLead(s.leadRepo.WithTx(tx), form, submission); err != nil {
+		applied, err := s.applySubmissionLead(s.leadRepo.WithTx(tx), form, submission)
+		if err != nil {
 			return err
 		}
+		change = applied
 		return txFormRepo.UpdateSubmission(submission)
 	})
 	if err != nil {
 		return err
 	}
 
+	s.auditSubmissionLead(change)
 	s.notify(form, submission)
 	s.sendFollowUpMail(form, submission)
+	s.publishWebhook(models.WebhookFormSubmissionReceived, models.WebhookEntityFormSubmission, submission.ID,
+		formSubmissionWebhookData(submission))
 	return nil
 }
 
@@ -908,15 +936,18 @@ func (s *formService) ConfirmSubmission(rawToken string) error {
 	submission.Status = models.FormSubmissionConfirmed
 	submission.ConfirmedAt = &confirmedAt
 
+	var change *submissionLeadChange
 	err = s.txManager.WithTransaction(context.Background(), func(ctx context.Context) error {
 		tx, ok := utils.GetTxFromContext(ctx)
 		if !ok {
 			return utils.ErrNoTransaction
 		}
 		if form.CreateLead {
-			if err := s.applySubmissionLead(s.leadRepo.WithTx(tx), form, submission); err != nil {
+			applied, err := s.applySubmissionLead(s.leadRepo.WithTx(tx), form, submission)
+			if err != nil {
 				return err
 			}
+			change = applied
 		}
 		return s.repo.WithTx(tx).UpdateSubmission(submission)
 	})
@@ -925,36 +956,59 @@ func (s *formService) ConfirmSubmission(rawToken string) error {
 		return err
 	}
 
+	s.auditSubmissionLead(change)
 	s.notify(form, submission)
 	s.sendFollowUpMail(form, submission)
+	s.publishWebhook(models.WebhookFormSubmissionConfirmed, models.WebhookEntityFormSubmission, submission.ID,
+		formSubmissionWebhookData(submission))
 
 	logger.WithField("submission_id", submission.ID).Info("Form submission confirmed")
 	return nil
 }
 
+// submissionLeadChange is what applySubmissionLead did to a lead, audited once
+// its transaction has committed.
+type submissionLeadChange struct {
+	leadID uint
+	action models.AuditAction
+	before map[string]interface{}
+	after  *models.Lead
+}
+
+// auditSubmissionLead records the change a submission made to its lead; nil
+// is a submission that made none.
+func (s *formService) auditSubmissionLead(change *submissionLeadChange) {
+	if change == nil {
+		return
+	}
+	s.recordAudit(systemAuditActor, models.AuditEntityLead, change.leadID, change.action, change.before, change.after)
+}
+
 // applySubmissionLead links the submission to a lead: the newest live lead with
 // the same address gains a note recording this submission, and when there is
 // none a lead is created from the mapped fields. Both repositories are
-// transaction-scoped by the caller.
-func (s *formService) applySubmissionLead(leadRepo repository.LeadRepository, form *models.Form, submission *models.FormSubmission) error {
+// transaction-scoped by the caller, which audits the returned change once the
+// transaction has committed.
+func (s *formService) applySubmissionLead(leadRepo repository.LeadRepository, form *models.Form, submission *models.FormSubmission) (*submissionLeadChange, error) {
 	notes := submissionNotes(form, submission)
 
 	existing, err := leadRepo.GetLatestByEmail(submission.Email)
 	if err != nil && !apperrors.IsNotFound(err) {
-		return err
+		return nil, err
 	}
 
 	if err == nil && existing != nil {
+		before := AuditSnapshot(existing)
 		if strings.TrimSpace(existing.Notes) == "" {
 			existing.Notes = strings.TrimLeft(notes, "\n")
 		} else {
 			existing.Notes = strings.TrimRight(existing.Notes, "\n") + notes
 		}
 		if err := leadRepo.Update(existing); err != nil {
-			return err
+			return nil, err
 		}
 		submission.LeadID = &existing.ID
-		return nil
+		return &submissionLeadChange{leadID: existing.ID, action: models.AuditActionUpdate, before: before, after: existing}, nil
 	}
 
 	values := submission.Data
@@ -991,11 +1045,11 @@ func (s *formService) applySubmissionLead(leadRepo repository.LeadRepository, fo
 		Notes:     strings.TrimLeft(notes, "\n"),
 	}
 	if err := leadRepo.Create(lead); err != nil {
-		return err
+		return nil, err
 	}
 
 	submission.LeadID = &lead.ID
-	return nil
+	return &submissionLeadChange{leadID: lead.ID, action: models.AuditActionCreate, after: lead}, nil
 }
 
 // submissionNotes renders the note block appended to the lead: a dated header
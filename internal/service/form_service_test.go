@@ -122,6 +122,8 @@ func newFormFixture(t *testing.T, cfg config.FormsConfig) *formFixture {
 		&models.Form{},
 		&models.FormSubmission{},
 		&models.FormConfirmationToken{},
+		&models.Role{},
+		&models.AuditEvent{},
 	))
 
 	f := &formFixture{
@@ -132,7 +134,8 @@ func newFormFixture(t *testing.T, cfg config.FormsConfig) *formFixture {
 		mailer:   &fakeFormMailer{},
 	}
 	f.owner = f.createUser(t, "owner@example.com", models.RoleSales, true)
-	f.service = NewFormService(f.repo, f.leadRepo, f.userRepo, f.mailer,
+	f.service = NewFormService(f.repo, f.leadRepo, f.userRepo, NewRoleService(repository.NewRoleRepository(db)),
+		NewAuditService(repository.NewAuditRepository(db)), f.mailer,
 		utils.NewTransactionManager(db), cfg, formTestAPIPrefix)
 	return f
 }
@@ -219,7 +222,7 @@ func submissionMeta() SubmissionMeta {
 
 func (f *formFixture) submissions(t *testing.T, formID uint) []models.FormSubmission {
 	t.Helper()
-	list, _, err := f.repo.ListSubmissions(formID, 0, 100, "")
+	list, _, err := f.repo.ListSubmissions(formID, models.FormSubmissionFilter{}, 0, 100)
 	require.NoError(t, err)
 	return list
 }
@@ -356,6 +359,29 @@ func TestFormServiceCreateRejectsUnusableLeadOwner(t *testing.T) {
 	}
 }
 
+// TestFormServiceLeadOwnerByCustomRole: a custom role may own form leads when
+// it may update leads, whatever built-in role it is based on.
+func TestFormServiceLeadOwnerByCustomRole(t *testing.T) {
+	f := newDefaultFormFixture(t)
+	require.NoError(t, f.db.Create(&models.Role{Name: "field_sales", BaseRole: models.RoleSales,
+		Permissions: []models.Permission{models.PermLeadsCreate, models.PermLeadsReadOwn, models.PermLeadsUpdateOwn}}).Error)
+	require.NoError(t, f.db.Create(&models.Role{Name: "lead_viewer", BaseRole: models.RoleSales,
+		Permissions: []models.Permission{models.PermLeadsReadAny}}).Error)
+	fieldSales := f.createUser(t, "field@example.com", "field_sales", true)
+	viewer := f.createUser(t, "viewer@example.com", "lead_viewer", true)
+
+	form := f.newForm()
+	form.DefaultOwnerID = fieldSales.ID
+	require.NoError(t, f.service.Create(form, f.owner.ID))
+
+	form = f.newForm()
+	form.DefaultOwnerID = viewer.ID
+	err := f.service.Create(form, f.owner.ID)
+	var fieldErrors FieldErrors
+	require.ErrorAs(t, err, &fieldErrors)
+	assert.Contains(t, fieldErrors, "default_owner_id")
+}
+
 func TestFormServiceUpdateKeepsPublicIdentity(t *testing.T) {
 	f := newDefaultFormFixture(t)
 	form := f.publish(t, f.newForm())
@@ -388,7 +414,10 @@ func TestFormServiceMissingFormIsNotFound(t *testing.T) {
 	_, err = f.service.GetSubmission(404)
 	assert.True(t, apperrors.IsNotFound(err))
 
-	_, _, err = f.service.ListSubmissions(404, 0, 20, "")
+	_, _, err = f.service.ListSubmissions(404, models.FormSubmissionFilter{}, 0, 20)
+	assert.True(t, apperrors.IsNotFound(err))
+
+	err = f.service.StreamSubmissions(404, models.FormSubmissionFilter{}, func([]models.FormSubmission) error { return nil })
 	assert.True(t, apperrors.IsNotFound(err))
 }
 
@@ -866,6 +895,19 @@ func TestFormServiceSubmitDeduplicatesLeadsByAddress(t *testing.T) {
 		require.NotNil(t, submission.LeadID)
 		assert.Equal(t, leads[0].ID, *submission.LeadID)
 	}
+
+	// The visitor is nobody signed in, so the lead's trail is the system's.
+	var events []models.AuditEvent
+	require.NoError(t, f.db.Order("id").Find(&events).Error)
+	require.Len(t, events, 2)
+	assert.Equal(t, models.AuditActionCreate, events[0].Action)
+	assert.Equal(t, models.AuditActionUpdate, events[1].Action)
+	for _, event := range events {
+		assert.Equal(t, models.AuditEntityLead, event.EntityType)
+		assert.Equal(t, leads[0].ID, event.EntityID)
+		assert.Equal(t, models.AuditActorSystem, event.ActorType)
+	}
+	assert.Contains(t, events[1].Changes, "notes")
 }
 
 func TestFormServiceSubmitWithoutLeadCreation(t *testing.T) {
@@ -0,0 +1,855 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// ImportService loads CSV files into leads and customers: an upload stores
+// the file, a dry run checks every row against a column mapping without
+// writing, and a start imports the rows in the background. Rows are created
+// one at a time through the lead and customer services, exactly as the API
+// creates them, so a row that fails is reported on its own and the rest are
+// still imported.
+//
+// An import is visible to the user who uploaded it and to holders of
+// imports:manage; one the caller cannot see is apperrors.ErrNotFound.
+type ImportService interface {
+	// Upload parses and stores a file of entityType, with fields separated by
+	// delimiter (",", ";" or "\t"; empty for a comma). The import it returns
+	// carries a preview of the first rows and a suggested mapping.
+	Upload(userID uint, entityType, fileName string, content []byte, delimiter string) (*models.Import, error)
+	Get(id, userID uint, permissions models.PermissionSet) (*models.Import, error)
+	// List returns the caller's imports, or every import with imports:manage,
+	// newest first.
+	List(userID uint, permissions models.PermissionSet, offset, limit int) ([]models.Import, int64, error)
+	// DryRun validates every row of the file against options and reports the
+	// rows that would fail. Nothing is written.
+	DryRun(id, userID uint, permissions models.PermissionSet, options models.ImportOptions) (*models.ImportDryRun, error)
+	// Start checks options, records them with a new bulk operation and
+	// imports the rows in the background. An import is started once.
+	Start(id, userID uint, permissions models.PermissionSet, options models.ImportOptions) (*models.Import, error)
+	// FailedRows returns the rows a started import could not create, as they
+	// were in the file.
+	FailedRows(id, userID uint, permissions models.PermissionSet) (*models.Import, []models.ImportFailedRow, error)
+	// ReconcileUnfinished fails the imports a previous process left running
+	// and returns how many there were.
+	ReconcileUnfinished() (int, error)
+}
+
+type importService struct {
+	importRepo        repository.ImportRepository
+	bulkOperationRepo repository.BulkOperationRepository
+	customFieldRepo   repository.CustomFieldRepository
+	userRepo          repository.UserRepository
+	customerRepo      repository.CustomerRepository
+	leadService       LeadService
+	customerService   CustomerService
+	auditRecorder
+	// runner runs a started import; in the background, outside tests.
+	runner func(job func())
+}
+
+func NewImportService(
+	importRepo repository.ImportRepository,
+	bulkOperationRepo repository.BulkOperationRepository,
+	customFieldRepo repository.CustomFieldRepository,
+	userRepo repository.UserRepository,
+	customerRepo repository.CustomerRepository,
+	leadService LeadService,
+	customerService CustomerService,
+	auditService AuditService,
+) ImportService {
+	return &importService{
+		importRepo:        importRepo,
+		bulkOperationRepo: bulkOperationRepo,
+		customFieldRepo:   customFieldRepo,
+		userRepo:          userRepo,
+		customerRepo:      customerRepo,
+		leadService:       leadService,
+		customerService:   customerService,
+		auditRecorder:     auditRecorder{auditService: auditService},
+		runner:            func(job func()) { go job() },
+	}
+}
+
+// importBatchSize is how many rows an import creates between two progress
+// updates of its bulk operation.
+const importBatchSize = 100
+
+// importDelimiters are the accepted field separators, by how a request names
+// them.
+var importDelimiters = map[string]string{"": ",", ",": ",", ";": ";", "\t": "\t", "tab": "\t"}
+
+// importEmailPattern is the address check of the form fields: something, an
+// @, and a host with a dot.
+var importEmailPattern = regexp.MustCompile(`^[^\s@]+@[^\s@]+\.[^\s@]+$`)
+
+// importFieldLengths are the widths of the text columns a cell is written to.
+// A longer value would be refused by the database, so the dry run reports it.
+var importFieldLengths = map[string]int{
+	"first_name": 100, "last_name": 100, "email": 255, "phone": 50, "company": 200, "position": 100,
+	"source": 100, "external_id": 255, "address": 255, "city": 100, "state": 100, "country": 100,
+	"postal_code": 20,
+}
+
+// importTimeLayouts are the formats a created_at cell may take, those of the
+// created_at a single lead create accepts.
+var importTimeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05Z", "2006-01-02 15:04:05", "2006-01-02"}
+
+// importFieldAliases are the usual other names of a field in exports of other
+// CRMs and spreadsheets, for the suggested mapping.
+var importFieldAliases = map[string]string{
+	"firstname": "first_name", "given_name": "first_name", "forename": "first_name",
+	"lastname": "last_name", "surname": "last_name", "family_name": "last_name",
+	"e_mail": "email", "email_address": "email", "e_mail_address": "email", "mail": "email",
+	"phone_number": "phone", "telephone": "phone", "mobile": "phone",
+	"company_name": "company", "organization": "company", "organisation": "company",
+	"title": "position", "job_title": "position", "role": "position",
+	"lead_source": "source", "lead_status": "status", "rating": "classification",
+	"street": "address", "street_address": "address", "province": "state", "region": "state",
+	"zip": "postal_code", "zip_code": "postal_code", "postcode": "postal_code",
+	"comments": "notes", "description": "notes", "created": "created_at", "created_date": "created_at",
+}
+
+// importRow is one record of a file and its row number.
+type importRow struct {
+	number int
+	values []string
+}
+
+// importColumn is a mapped column, ready to read cells with.
+type importColumn struct {
+	index      int
+	name       string
+	field      string
+	values     map[string]string
+	definition *models.CustomFieldDefinition
+}
+
+// importPlan is a mapping checked against a file: what each row is read as,
+// who owns the leads, and who the records are created for.
+type importPlan struct {
+	entityType string
+	columns    []importColumn
+	ownerID    uint
+	userID     uint
+}
+
+func (s *importService) Upload(userID uint, entityType, fileName string, content []byte, delimiter string) (*models.Import, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("user_id", userID), "ImportService", "Upload")
+
+	if _, ok := models.ImportEntityResources[entityType]; !ok {
+		logger.Warn("Unknown import entity type")
+		return nil, fmt.Errorf("%q cannot be imported; import leads or customers: %w", entityType, apperrors.ErrValidation)
+	}
+	separator, ok := importDelimiters[delimiter]
+	if !ok {
+		return nil, fmt.Errorf("the delimiter must be a comma, a semicolon or a tab: %w", apperrors.ErrValidation)
+	}
+	if len(content) > models.ImportMaxFileSize {
+		return nil, fmt.Errorf("the file is larger than %d MB: %w", models.ImportMaxFileSize>>20, apperrors.ErrValidation)
+	}
+	columns, rows, err := parseImportFile(content, separator)
+	if err != nil {
+		logger.WithError(err).Warn("Unreadable import file")
+		return nil, err
+	}
+
+	imp := &models.Import{
+		UserID:     userID,
+		EntityType: entityType,
+		FileName:   fileName,
+		Delimiter:  separator,
+		RowCount:   len(rows),
+		Content:    string(content),
+		Columns:    columns,
+	}
+	if err := s.importRepo.Create(imp); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+
+	for _, row := range rows[:min(len(rows), models.ImportPreviewRows)] {
+		imp.Preview = append(imp.Preview, row.values)
+	}
+	definitions, err := s.customFieldRepo.ListDefinitions(entityType)
+	if err != nil {
+		logger.WithError(err).Warn("Custom fields unavailable for the suggested mapping")
+	}
+	imp.SuggestedMapping = suggestImportMapping(entityType, columns, definitions)
+
+	logger.WithField("import_id", imp.ID).WithField("rows", imp.RowCount).Info("Import file uploaded")
+	return imp, nil
+}
+
+func (s *importService) Get(id, userID uint, permissions models.PermissionSet) (*models.Import, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("import_id", id), "ImportService", "Get")
+
+	imp, err := s.importRepo.GetByID(id)
+	if err != nil {
+		if isNotFound(err) {
+			logger.Warn("Import not found")
+			return nil, fmt.Errorf("import %d: %w", id, apperrors.ErrNotFound)
+		}
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	if !imp.VisibleTo(userID, permissions) {
+		logger.WithField("user_id", userID).Warn("Import not visible to user")
+		return nil, fmt.Errorf("import %d: %w", id, apperrors.ErrNotFound)
+	}
+	return imp, nil
+}
+
+func (s *importService) List(userID uint, permissions models.PermissionSet, offset, limit int) ([]models.Import, int64, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("user_id", userID), "ImportService", "List")
+
+	owner := userID
+	if permissions.Allows(models.PermImportsManage) {
+		owner = 0
+	}
+	imports, total, err := s.importRepo.List(owner, offset, limit)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, 0, err
+	}
+	return imports, total, nil
+}
+
+func (s *importService) DryRun(id, userID uint, permissions models.PermissionSet, options models.ImportOptions) (*models.ImportDryRun, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("import_id", id), "ImportService", "DryRun")
+
+	imp, plan, rows, err := s.prepare(id, userID, permissions, options)
+	if err != nil {
+		logger.WithError(err).Warn("Import cannot be dry-run")
+		return nil, err
+	}
+
+	result := &models.ImportDryRun{TotalRows: len(rows), Errors: []models.ImportRowError{}}
+	// The first row of each customer email, for the duplicates within the
+	// file, which a customer create would refuse one by one.
+	emails := map[string]int{}
+	for _, row := range rows {
+		rowErrors := plan.check(row)
+		if len(rowErrors) == 0 && imp.EntityType == models.AuditEntityCustomer {
+			rowErrors = s.checkCustomerEmail(plan, row, emails)
+		}
+		if len(rowErrors) == 0 {
+			result.ValidRows++
+			continue
+		}
+		result.InvalidRows++
+		for _, rowError := range rowErrors {
+			if len(result.Errors) < models.ImportMaxReportedErrors {
+				result.Errors = append(result.Errors, rowError)
+			}
+		}
+	}
+
+	logger.WithFields(logrus.Fields{"valid": result.ValidRows, "invalid": result.InvalidRows}).Info("Import dry run finished")
+	return result, nil
+}
+
+// checkCustomerEmail reports a customer row whose email an earlier row of the
+// file or an existing customer already has.
+func (s *importService) checkCustomerEmail(plan *importPlan, row importRow, emails map[string]int) []models.ImportRowError {
+	column := plan.column("email")
+	email := plan.cell(column, row)
+	key := strings.ToLower(email)
+	if first, ok := emails[key]; ok {
+		return []models.ImportRowError{{Row: row.number, Column: column.name,
+			Message: fmt.Sprintf("%s is already the email of row %d", email, first)}}
+	}
+	emails[key] = row.number
+	if existing, err := s.customerRepo.GetByEmailUnscoped(email); err == nil && existing != nil {
+		return []models.ImportRowError{{Row: row.number, Column: column.name,
+			Message: fmt.Sprintf("a customer with the email %s already exists", email)}}
+	}
+	return nil
+}
+
+func (s *importService) Start(id, userID uint, permissions models.PermissionSet, options models.ImportOptions) (*models.Import, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("import_id", id), "ImportService", "Start")
+
+	imp, plan, rows, err := s.prepare(id, userID, permissions, options)
+	if err != nil {
+		logger.WithError(err).Warn("Import cannot be started")
+		return nil, err
+	}
+
+	imp.Mapping = options.Mapping
+	imp.OwnerID = nil
+	if plan.ownerID != 0 {
+		imp.OwnerID = &plan.ownerID
+	}
+	operation := &models.BulkOperation{
+		UserID:       userID,
+		ResourceType: models.ImportEntityResources[imp.EntityType],
+		Type:         models.BulkCreate,
+		Status:       models.StatusPending,
+		TotalItems:   len(rows),
+	}
+	if err := s.importRepo.Start(imp, operation); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+
+	logger.WithField("operation_id", operation.ID).WithField("rows", len(rows)).Info("Import started")
+	s.runner(func() { s.run(imp.ID, operation.ID, plan, rows) })
+	return imp, nil
+}
+
+// prepare loads an import that has not been started yet and checks options
+// against its file.
+func (s *importService) prepare(id, userID uint, permissions models.PermissionSet, options models.ImportOptions) (*models.Import, *importPlan, []importRow, error) {
+	imp, err := s.Get(id, userID, permissions)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if imp.OperationID != nil {
+		return nil, nil, nil, fmt.Errorf("import %d: %w", id, apperrors.ErrImportStarted)
+	}
+	_, rows, err := parseImportFile([]byte(imp.Content), imp.Delimiter)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	plan, err := s.plan(imp, options, userID, permissions)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return imp, plan, rows, nil
+}
+
+// plan checks a mapping against the columns of the file and the fields of
+// its entity type, and settles the owner of the leads: the user named, for a
+// caller with leads:assign, and the caller otherwise.
+func (s *importService) plan(imp *models.Import, options models.ImportOptions, userID uint, permissions models.PermissionSet) (*importPlan, error) {
+	if len(options.Mapping) == 0 {
+		return nil, fmt.Errorf("the mapping maps no column: %w", apperrors.ErrValidation)
+	}
+	definitions, err := s.customFieldRepo.ListDefinitions(imp.EntityType)
+	if err != nil {
+		return nil, err
+	}
+	definitionsByName := make(map[string]*models.CustomFieldDefinition, len(definitions))
+	for i := range definitions {
+		definitionsByName[definitions[i].Name] = &definitions[i]
+	}
+	fields := map[string]bool{}
+	for _, field := range models.ImportFields[imp.EntityType] {
+		fields[field] = true
+	}
+	indexes := make(map[string]int, len(imp.Columns))
+	for i, column := range imp.Columns {
+		indexes[column] = i
+	}
+
+	plan := &importPlan{entityType: imp.EntityType, userID: userID}
+	mapped := map[string]bool{}
+	for _, mapping := range options.Mapping {
+		index, ok := indexes[mapping.Column]
+		if !ok {
+			return nil, fmt.Errorf("column %q is not in the file: %w", mapping.Column, apperrors.ErrValidation)
+		}
+		field := strings.TrimSpace(mapping.Field)
+		column := importColumn{index: index, name: mapping.Column, field: field, values: mapping.Values}
+		if name, ok := strings.CutPrefix(field, models.ImportCustomFieldPrefix); ok {
+			if column.definition = definitionsByName[name]; column.definition == nil {
+				return nil, fmt.Errorf("%q is not a custom field of %ss: %w", name, imp.EntityType, apperrors.ErrValidation)
+			}
+		} else if !fields[field] {
+			return nil, fmt.Errorf("%q is not a field a %s import can fill: %w", field, imp.EntityType, apperrors.ErrValidation)
+		}
+		if mapped[field] {
+			return nil, fmt.Errorf("field %q is mapped twice: %w", field, apperrors.ErrValidation)
+		}
+		mapped[field] = true
+		plan.columns = append(plan.columns, column)
+	}
+	for _, field := range models.ImportRequiredFields {
+		if !mapped[field] {
+			return nil, fmt.Errorf("field %q must be mapped: %w", field, apperrors.ErrValidation)
+		}
+	}
+	for _, definition := range definitions {
+		if definition.Required && !mapped[models.ImportCustomFieldPrefix+definition.Name] {
+			return nil, fmt.Errorf("custom field %q is required and must be mapped: %w", definition.Name, apperrors.ErrValidation)
+		}
+	}
+
+	if imp.EntityType != models.AuditEntityLead {
+		if options.OwnerID != nil {
+			return nil, fmt.Errorf("owner_id only applies to lead imports: %w", apperrors.ErrValidation)
+		}
+		return plan, nil
+	}
+	switch {
+	case !permissions.Allows(models.PermLeadsAssign):
+		if options.OwnerID != nil && *options.OwnerID != userID {
+			return nil, fmt.Errorf("you can only import leads you own: %w", apperrors.ErrForbidden)
+		}
+		plan.ownerID = userID
+	case options.OwnerID == nil:
+		return nil, fmt.Errorf("owner_id is required for users who assign leads: %w", apperrors.ErrValidation)
+	default:
+		owner, err := s.userRepo.GetByID(*options.OwnerID)
+		if err != nil {
+			if isNotFound(err) {
+				return nil, fmt.Errorf("owner %d does not exist: %w", *options.OwnerID, apperrors.ErrValidation)
+			}
+			return nil, err
+		}
+		if !owner.IsActive {
+			return nil, fmt.Errorf("owner %d is not active: %w", *options.OwnerID, apperrors.ErrValidation)
+		}
+		plan.ownerID = owner.ID
+	}
+	return plan, nil
+}
+
+// run imports the rows of a started import, recording each failed row as an
+// item of its operation and the counters after every batch.
+func (s *importService) run(importID, operationID uint, plan *importPlan, rows []importRow) {
+	logger := utils.Logger.WithFields(logrus.Fields{"import_id": importID, "operation_id": operationID})
+	succeeded, failed := 0, 0
+	// The customer emails of the file so far, as the dry run tracks them.
+	emails := map[string]int{}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			logger.WithField("panic", recovered).Error("Import panicked")
+			s.finish(logger, operationID, models.StatusFailed, succeeded, failed)
+		}
+	}()
+
+	if err := s.bulkOperationRepo.UpdateProgress(operationID, models.StatusProcessing, 0, 0); err != nil {
+		logger.WithError(err).Error("Failed to mark the import as processing")
+	}
+	for start := 0; start < len(rows); start += importBatchSize {
+		batch := rows[start:min(start+importBatchSize, len(rows))]
+		items := make([]models.BulkOperationItem, 0, len(batch))
+		for _, row := range batch {
+			item := s.importRow(logger, plan, row, emails)
+			item.OperationID = operationID
+			if item.Status == models.StatusFailed {
+				failed++
+			} else {
+				succeeded++
+			}
+			items = append(items, item)
+		}
+		if err := s.bulkOperationRepo.CreateItems(items); err != nil {
+			logger.WithError(err).Error("Failed to record import rows")
+			s.finish(logger, operationID, models.StatusFailed, succeeded, failed)
+			return
+		}
+		if err := s.bulkOperationRepo.UpdateProgress(operationID, models.StatusProcessing, succeeded, failed); err != nil {
+			logger.WithError(err).Warn("Failed to record import progress")
+		}
+	}
+
+	status := models.StatusCompleted
+	switch {
+	case failed > 0 && succeeded == 0:
+		status = models.StatusFailed
+	case failed > 0:
+		status = models.StatusPartial
+	}
+	s.finish(logger, operationID, status, succeeded, failed)
+}
+
+func (s *importService) finish(logger *logrus.Entry, operationID uint, status models.BulkOperationStatus, succeeded, failed int) {
+	if err := s.bulkOperationRepo.UpdateProgress(operationID, status, succeeded, failed); err != nil {
+		logger.WithError(err).Error("Failed to record the outcome of the import")
+		return
+	}
+	logger.WithFields(logrus.Fields{"status": status, "succeeded": succeeded, "failed": failed}).Info("Import finished")
+}
+
+// importRow creates the record of one row and returns its item: the new
+// record's id, or the row and what is wrong with it. A row is checked exactly
+// as the dry run checks it, so the two agree on which rows fail.
+func (s *importService) importRow(logger *logrus.Entry, plan *importPlan, row importRow, emails map[string]int) models.BulkOperationItem {
+	rowErrors := plan.check(row)
+	if len(rowErrors) == 0 && plan.entityType == models.AuditEntityCustomer {
+		rowErrors = s.checkCustomerEmail(plan, row, emails)
+	}
+	if len(rowErrors) == 0 {
+		id, err := s.create(plan, row)
+		if err == nil {
+			return models.BulkOperationItem{ResourceID: id, Status: models.StatusCompleted}
+		}
+		message := "the record could not be saved"
+		if errors.Is(err, apperrors.ErrValidation) || errors.Is(err, apperrors.ErrDuplicateEmail) {
+			message = err.Error()
+		} else {
+			logger.WithError(err).WithField("row", row.number).Error("Failed to import row")
+		}
+		rowErrors = []models.ImportRowError{{Row: row.number, Message: message}}
+	}
+
+	messages := make([]string, len(rowErrors))
+	for i, rowError := range rowErrors {
+		messages[i] = rowError.Message
+		if rowError.Column != "" {
+			messages[i] = rowError.Column + ": " + rowError.Message
+		}
+	}
+	data, _ := json.Marshal(models.ImportFailedRow{Row: row.number, Values: row.values})
+	return models.BulkOperationItem{
+		Status: models.StatusFailed,
+		Error:  strings.Join(messages, "; "),
+		Data:   string(data),
+	}
+}
+
+// create creates the record of a row that passed plan.check.
+func (s *importService) create(plan *importPlan, row importRow) (uint, error) {
+	values, customFields := plan.read(row)
+	switch plan.entityType {
+	case models.AuditEntityLead:
+		lead := &models.Lead{
+			FirstName:      values["first_name"],
+			LastName:       values["last_name"],
+			Email:          values["email"],
+			Phone:          values["phone"],
+			Company:        values["company"],
+			Position:       values["position"],
+			Source:         values["source"],
+			Status:         models.LeadStatus(values["status"]),
+			Classification: models.LeadClassification(values["classification"]),
+			ExternalID:     values["external_id"],
+			Notes:          values["notes"],
+			OwnerID:        plan.ownerID,
+			CustomFields:   customFields,
+		}
+		if raw := values["created_at"]; raw != "" {
+			lead.CreatedAt, _ = parseImportTime(raw)
+		}
+		if err := s.leadService.Create(lead); err != nil {
+			return 0, err
+		}
+		s.recordAudit(userAuditActor(plan.userID), models.AuditEntityLead, lead.ID, models.AuditActionCreate, nil, lead)
+		return lead.ID, nil
+	default:
+		customer := &models.Customer{
+			FirstName:    values["first_name"],
+			LastName:     values["last_name"],
+			Email:        values["email"],
+			Phone:        values["phone"],
+			Company:      values["company"],
+			Position:     values["position"],
+			Address:      values["address"],
+			City:         values["city"],
+			State:        values["state"],
+			Country:      values["country"],
+			PostalCode:   values["postal_code"],
+			Notes:        values["notes"],
+			CustomFields: customFields,
+		}
+		if err := s.customerService.Create(customer); err != nil {
+			return 0, err
+		}
+		s.recordAudit(userAuditActor(plan.userID), models.AuditEntityCustomer, customer.ID, models.AuditActionCreate, nil, customer)
+		return customer.ID, nil
+	}
+}
+
+func (s *importService) FailedRows(id, userID uint, permissions models.PermissionSet) (*models.Import, []models.ImportFailedRow, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("import_id", id), "ImportService", "FailedRows")
+
+	imp, err := s.Get(id, userID, permissions)
+	if err != nil {
+		return nil, nil, err
+	}
+	if imp.OperationID == nil {
+		logger.Warn("Import not started")
+		return nil, nil, fmt.Errorf("import %d has not been started: %w", id, apperrors.ErrValidation)
+	}
+	items, err := s.bulkOperationRepo.GetFailedItems(*imp.OperationID)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, nil, err
+	}
+	rows := make([]models.ImportFailedRow, 0, len(items))
+	for _, item := range items {
+		var row models.ImportFailedRow
+		if err := json.Unmarshal([]byte(item.Data), &row); err != nil {
+			logger.WithError(err).WithField("item_id", item.ID).Warn("Unreadable failed import row")
+			continue
+		}
+		row.Error = item.Error
+		rows = append(rows, row)
+	}
+	return imp, rows, nil
+}
+
+func (s *importService) ReconcileUnfinished() (int, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("entity", "import"), "ImportService", "ReconcileUnfinished")
+
+	imports, err := s.importRepo.ListUnfinished()
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return 0, err
+	}
+	for _, imp := range imports {
+		operation := imp.Operation
+		if err := s.bulkOperationRepo.UpdateProgress(operation.ID, models.StatusFailed, operation.SuccessCount, operation.FailureCount); err != nil {
+			utils.LogServiceResponse(logger, err)
+			return 0, err
+		}
+	}
+	return len(imports), nil
+}
+
+// parseImportFile reads the header and the rows of a file. A row whose cells
+// are all blank is skipped, the way a spreadsheet's trailing rows are; row
+// numbers still count it.
+func parseImportFile(content []byte, delimiter string) ([]string, []importRow, error) {
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(content) {
+		return nil, nil, fmt.Errorf("the file is not UTF-8 text; save it as CSV UTF-8: %w", apperrors.ErrValidation)
+	}
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.Comma, _ = utf8.DecodeRuneInString(delimiter)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("the file is empty: %w", apperrors.ErrValidation)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("the file is not valid CSV: %v: %w", err, apperrors.ErrValidation)
+	}
+	seen := make(map[string]bool, len(header))
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+		switch {
+		case header[i] == "":
+			return nil, nil, fmt.Errorf("column %d of the header has no name: %w", i+1, apperrors.ErrValidation)
+		case seen[header[i]]:
+			return nil, nil, fmt.Errorf("the header names column %q twice: %w", header[i], apperrors.ErrValidation)
+		}
+		seen[header[i]] = true
+	}
+
+	var rows []importRow
+	for number := 2; ; number++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("the file is not valid CSV: %v: %w", err, apperrors.ErrValidation)
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		if len(rows) == models.ImportMaxRows {
+			return nil, nil, fmt.Errorf("the file has more than %d rows: %w", models.ImportMaxRows, apperrors.ErrValidation)
+		}
+		rows = append(rows, importRow{number: number, values: record})
+	}
+	if len(rows) == 0 {
+		return nil, nil, fmt.Errorf("the file has no rows below its header: %w", apperrors.ErrValidation)
+	}
+	return header, rows, nil
+}
+
+// suggestImportMapping maps each column whose name, once normalized, is a
+// field of the entity type, a usual alias of one, or the name or label of a
+// custom field.
+func suggestImportMapping(entityType string, columns []string, definitions []models.CustomFieldDefinition) []models.ImportColumnMapping {
+	fields := map[string]string{}
+	for _, definition := range definitions {
+		fields[normalizeImportName(definition.Label)] = models.ImportCustomFieldPrefix + definition.Name
+		fields[normalizeImportName(definition.Name)] = models.ImportCustomFieldPrefix + definition.Name
+	}
+	for alias, field := range importFieldAliases {
+		fields[alias] = field
+	}
+	for _, field := range models.ImportFields[entityType] {
+		fields[field] = field
+	}
+	importable := map[string]bool{}
+	for _, field := range models.ImportFields[entityType] {
+		importable[field] = true
+	}
+
+	suggested := []models.ImportColumnMapping{}
+	taken := map[string]bool{}
+	for _, column := range columns {
+		field, ok := fields[normalizeImportName(column)]
+		if !ok || taken[field] || (!importable[field] && !strings.HasPrefix(field, models.ImportCustomFieldPrefix)) {
+			continue
+		}
+		taken[field] = true
+		suggested = append(suggested, models.ImportColumnMapping{Column: column, Field: field})
+	}
+	return suggested
+}
+
+// normalizeImportName lowercases a column name and joins its words with
+// underscores: "E-mail Address" is e_mail_address.
+func normalizeImportName(name string) string {
+	var normalized strings.Builder
+	pending := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			if pending && normalized.Len() > 0 {
+				normalized.WriteByte('_')
+			}
+			pending = false
+			normalized.WriteRune(r)
+			continue
+		}
+		pending = true
+	}
+	return normalized.String()
+}
+
+func parseImportTime(raw string) (time.Time, error) {
+	for _, layout := range importTimeLayouts {
+		if parsed, err := time.Parse(layout, raw); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date; use YYYY-MM-DD or RFC 3339", raw)
+}
+
+// column returns the mapped column of field; check guarantees the required
+// fields have one.
+func (p *importPlan) column(field string) *importColumn {
+	for i := range p.columns {
+		if p.columns[i].field == field {
+			return &p.columns[i]
+		}
+	}
+	return nil
+}
+
+// cell returns the value of a column in a row: trimmed, through the column's
+// value replacements, and for an enum normalized to its lowercase form.
+func (p *importPlan) cell(column *importColumn, row importRow) string {
+	value := strings.TrimSpace(row.values[column.index])
+	if replacement, ok := column.values[value]; ok {
+		value = strings.TrimSpace(replacement)
+	}
+	if _, ok := models.ImportEnumValues[column.field]; ok {
+		value = normalizeImportName(value)
+	}
+	return value
+}
+
+// read returns the field values and the custom field values of a row, empty
+// cells left out.
+func (p *importPlan) read(row importRow) (map[string]string, map[string]interface{}) {
+	values := map[string]string{}
+	customFields := map[string]interface{}{}
+	for i := range p.columns {
+		column := &p.columns[i]
+		value := p.cell(column, row)
+		if value == "" {
+			continue
+		}
+		if column.definition == nil {
+			values[column.field] = value
+			continue
+		}
+		if column.definition.Type == models.CustomFieldMultiSelect {
+			options := strings.Split(value, models.ImportMultiValueSeparator)
+			for j := range options {
+				options[j] = strings.TrimSpace(options[j])
+			}
+			customFields[column.definition.Name] = options
+		} else {
+			customFields[column.definition.Name] = value
+		}
+	}
+	return values, customFields
+}
+
+// check returns everything wrong with a row, without touching the database.
+func (p *importPlan) check(row importRow) []models.ImportRowError {
+	width := 0
+	for _, column := range p.columns {
+		width = max(width, column.index+1)
+	}
+	if len(row.values) < width {
+		return []models.ImportRowError{{Row: row.number,
+			Message: fmt.Sprintf("the row has %d fields, fewer than the columns it is mapped from", len(row.values))}}
+	}
+
+	var rowErrors []models.ImportRowError
+	fail := func(column *importColumn, format string, args ...any) {
+		rowErrors = append(rowErrors, models.ImportRowError{Row: row.number, Column: column.name,
+			Message: fmt.Sprintf(format, args...)})
+	}
+	values, customFields := p.read(row)
+	for i := range p.columns {
+		column := &p.columns[i]
+		if column.definition != nil {
+			if value, ok := customFields[column.definition.Name]; ok {
+				if _, err := column.definition.Encode(value); err != nil {
+					fail(column, "%v", err)
+				}
+			}
+			continue
+		}
+		value, ok := values[column.field]
+		if !ok {
+			continue
+		}
+		if limit, ok := importFieldLengths[column.field]; ok && utf8.RuneCountInString(value) > limit {
+			fail(column, "%s cannot be longer than %d characters", column.field, limit)
+		}
+		switch column.field {
+		case "email":
+			if !importEmailPattern.MatchString(value) {
+				fail(column, "%q is not a valid email address", value)
+			}
+		case "created_at":
+			if _, err := parseImportTime(value); err != nil {
+				fail(column, "%v", err)
+			}
+		}
+		if allowed, ok := models.ImportEnumValues[column.field]; ok && !containsString(allowed, value) {
+			fail(column, "%q is not a %s; expected one of %s", value, column.field, strings.Join(allowed, ", "))
+		}
+	}
+	for _, field := range models.ImportRequiredFields {
+		if values[field] == "" {
+			fail(p.column(field), "%s is required", field)
+		}
+	}
+	for i := range p.columns {
+		definition := p.columns[i].definition
+		if definition != nil && definition.Required && customFields[definition.Name] == nil {
+			fail(&p.columns[i], "custom field %s is required", definition.Name)
+		}
+	}
+	return rowErrors
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,414 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// ImportServiceSuite runs imports end to end against an in-memory SQLite
+// database, through the real lead and customer services, with the background
+// run made synchronous so a started import has finished when Start returns.
+type ImportServiceSuite struct {
+	suite.Suite
+	db      *gorm.DB
+	service *importService
+	admin   *models.User
+	sales   *models.User
+	other   *models.User
+	// jobs holds the runs of started imports instead of running them, when
+	// a test sets deferRuns.
+	deferRuns bool
+	jobs      []func()
+}
+
+func TestImportServiceSuite(t *testing.T) {
+	suite.Run(t, new(ImportServiceSuite))
+}
+
+func (s *ImportServiceSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "error", Format: "json"})
+}
+
+func (s *ImportServiceSuite) SetupTest() {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	s.Require().NoError(err)
+	sqlDB, err := db.DB()
+	s.Require().NoError(err)
+	sqlDB.SetMaxOpenConns(1)
+	s.T().Cleanup(func() { _ = sqlDB.Close() })
+
+	s.Require().NoError(db.AutoMigrate(
+		&models.User{},
+		&models.Lead{},
+		&models.Customer{},
+		&models.BulkOperation{},
+		&models.BulkOperationItem{},
+		&models.Import{},
+		&models.AuditEvent{},
+		&models.CustomFieldDefinition{},
+		&models.CustomFieldValue{},
+	))
+	s.Require().NoError(db.Use(repository.CustomFieldPlugin{}))
+	s.db = db
+
+	customerRepo := repository.NewCustomerRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	s.service = NewImportService(
+		repository.NewImportRepository(db),
+		repository.NewBulkOperationRepository(db),
+		repository.NewCustomFieldRepository(db),
+		userRepo,
+		customerRepo,
+		NewLeadService(repository.NewLeadRepository(db), customerRepo, nil, nil),
+		NewCustomerService(customerRepo, userRepo, nil, nil),
+		NewAuditService(repository.NewAuditRepository(db)),
+	).(*importService)
+	s.deferRuns, s.jobs = false, nil
+	s.service.runner = func(job func()) {
+		if s.deferRuns {
+			s.jobs = append(s.jobs, job)
+			return
+		}
+		job()
+	}
+
+	s.admin = s.createUser("admin@example.com", models.RoleAdmin, true)
+	s.sales = s.createUser("sales@example.com", models.RoleSales, true)
+	s.other = s.createUser("other@example.com", models.RoleSales, true)
+}
+
+func (s *ImportServiceSuite) createUser(email string, role models.UserRole, active bool) *models.User {
+	user := &models.User{Email: email, Password: "hashed", FirstName: "Test", LastName: "User", Role: role, IsActive: active}
+	s.Require().NoError(s.db.Create(user).Error)
+	if !active {
+		s.Require().NoError(s.db.Model(user).Update("is_active", false).Error)
+	}
+	return user
+}
+
+func (s *ImportServiceSuite) count(model interface{}) int64 {
+	var n int64
+	s.Require().NoError(s.db.Model(model).Count(&n).Error)
+	return n
+}
+
+// leadFile is a spreadsheet export of four leads, saved with a byte order
+// mark and semicolons the way Excel does in much of Europe. Row 4 is blank,
+// row 5 has a bad email and no last name, row 6 a rating no mapping covers.
+const leadFile = "\xef\xbb\xbfFirst Name;Last Name;E-mail Address;Rating;Company\n" +
+	"Ada;Lovelace;ada@example.com;Hot;Analytical Engines\n" +
+	"Grace;Hopper;grace@example.com;Warm;Navy\n" +
+	";;;;\n" +
+	"Alan;;not-an-email;Hot;\n" +
+	"Edsger;Dijkstra;edsger@example.com;Lukewarm;TU Eindhoven\n"
+
+func leadMapping() []models.ImportColumnMapping {
+	return []models.ImportColumnMapping{
+		{Column: "First Name", Field: "first_name"},
+		{Column: "Last Name", Field: "last_name"},
+		{Column: "E-mail Address", Field: "email"},
+		{Column: "Rating", Field: "classification", Values: map[string]string{"Hot": "hot_lead", "Warm": "lead"}},
+		{Column: "Company", Field: "company"},
+	}
+}
+
+func (s *ImportServiceSuite) upload(userID uint, entityType, content, delimiter string) *models.Import {
+	imp, err := s.service.Upload(userID, entityType, "file.csv", []byte(content), delimiter)
+	s.Require().NoError(err)
+	return imp
+}
+
+func (s *ImportServiceSuite) TestUpload_ParsesAndSuggestsMapping() {
+	imp := s.upload(s.sales.ID, models.AuditEntityLead, leadFile, ";")
+
+	s.Equal([]string{"First Name", "Last Name", "E-mail Address", "Rating", "Company"}, imp.Columns)
+	s.Equal(4, imp.RowCount, "the blank row is skipped")
+	s.Len(imp.Preview, 4)
+	s.Equal("Ada", imp.Preview[0][0], "the byte order mark is not part of the first cell")
+	s.Equal([]models.ImportColumnMapping{
+		{Column: "First Name", Field: "first_name"},
+		{Column: "Last Name", Field: "last_name"},
+		{Column: "E-mail Address", Field: "email"},
+		{Column: "Rating", Field: "classification"},
+		{Column: "Company", Field: "company"},
+	}, imp.SuggestedMapping)
+
+	stored, err := s.service.Get(imp.ID, s.sales.ID, models.BuiltInPermissions(models.RoleSales))
+	s.Require().NoError(err)
+	s.Equal(imp.Columns, stored.Columns)
+	s.Nil(stored.OperationID)
+}
+
+func (s *ImportServiceSuite) TestUpload_RejectsUnreadableFiles() {
+	for name, tc := range map[string]struct {
+		entityType, content, delimiter string
+	}{
+		"unknown entity":    {"ticket", "a\n1\n", ""},
+		"unknown delimiter": {"lead", "a\n1\n", "|"},
+		"empty":             {"lead", "", ""},
+		"header only":       {"lead", "email\n", ""},
+		"unnamed column":    {"lead", "email,\n1,2\n", ""},
+		"duplicate column":  {"lead", "email,Email ,email\n1,2,3\n", ""},
+		"not utf-8":         {"lead", "name\nJos\xe9\n", ""},
+		"broken quoting":    {"lead", "name\n\"unterminated\n", ""},
+	} {
+		_, err := s.service.Upload(s.sales.ID, tc.entityType, "f.csv", []byte(tc.content), tc.delimiter)
+		s.ErrorIs(err, apperrors.ErrValidation, name)
+	}
+	s.Zero(s.count(&models.Import{}))
+}
+
+func (s *ImportServiceSuite) TestVisibility() {
+	imp := s.upload(s.sales.ID, models.AuditEntityLead, leadFile, ";")
+
+	_, err := s.service.Get(imp.ID, s.other.ID, models.BuiltInPermissions(models.RoleSales))
+	s.ErrorIs(err, apperrors.ErrNotFound)
+	_, err = s.service.Get(imp.ID, s.admin.ID, models.BuiltInPermissions(models.RoleAdmin))
+	s.NoError(err)
+
+	mine, total, err := s.service.List(s.other.ID, models.BuiltInPermissions(models.RoleSales), 0, 20)
+	s.Require().NoError(err)
+	s.Empty(mine)
+	s.Zero(total)
+	all, total, err := s.service.List(s.admin.ID, models.BuiltInPermissions(models.RoleAdmin), 0, 20)
+	s.Require().NoError(err)
+	s.Equal(int64(1), total)
+	s.Empty(all[0].Content, "lists leave the file out")
+}
+
+func (s *ImportServiceSuite) TestDryRun_ReportsRowErrorsWithoutWriting() {
+	imp := s.upload(s.sales.ID, models.AuditEntityLead, leadFile, ";")
+
+	result, err := s.service.DryRun(imp.ID, s.sales.ID, models.BuiltInPermissions(models.RoleSales), models.ImportOptions{Mapping: leadMapping()})
+	s.Require().NoError(err)
+
+	s.Equal(4, result.TotalRows)
+	s.Equal(2, result.ValidRows)
+	s.Equal(2, result.InvalidRows)
+	s.Require().Len(result.Errors, 3)
+	s.Equal(models.ImportRowError{Row: 5, Column: "E-mail Address", Message: `"not-an-email" is not a valid email address`}, result.Errors[0],
+		"row numbers count the header and the blank row")
+	s.Equal(models.ImportRowError{Row: 5, Column: "Last Name", Message: "last_name is required"}, result.Errors[1])
+	s.Equal(6, result.Errors[2].Row)
+	s.Contains(result.Errors[2].Message, `"lukewarm" is not a classification`)
+
+	s.Zero(s.count(&models.Lead{}))
+	s.Zero(s.count(&models.BulkOperation{}))
+}
+
+func (s *ImportServiceSuite) TestDryRun_RejectsBadMappings() {
+	imp := s.upload(s.sales.ID, models.AuditEntityLead, leadFile, ";")
+
+	for name, mapping := range map[string][]models.ImportColumnMapping{
+		"no mapping":       nil,
+		"unknown column":   append(leadMapping(), models.ImportColumnMapping{Column: "Phone", Field: "phone"}),
+		"unknown field":    append(leadMapping()[:4], models.ImportColumnMapping{Column: "Company", Field: "owner_id"}),
+		"customer field":   append(leadMapping()[:4], models.ImportColumnMapping{Column: "Company", Field: "city"}),
+		"unknown cf":       append(leadMapping()[:4], models.ImportColumnMapping{Column: "Company", Field: "cf.budget"}),
+		"mapped twice":     append(leadMapping()[:4], models.ImportColumnMapping{Column: "Company", Field: "first_name"}),
+		"required missing": leadMapping()[1:],
+	} {
+		_, err := s.service.DryRun(imp.ID, s.sales.ID, models.BuiltInPermissions(models.RoleSales), models.ImportOptions{Mapping: mapping})
+		s.ErrorIs(err, apperrors.ErrValidation, name)
+	}
+}
+
+func (s *ImportServiceSuite) TestOwnerRules() {
+	imp := s.upload(s.sales.ID, models.AuditEntityLead, leadFile, ";")
+	options := func(ownerID *uint) models.ImportOptions {
+		return models.ImportOptions{Mapping: leadMapping(), OwnerID: ownerID}
+	}
+
+	_, err := s.service.DryRun(imp.ID, s.sales.ID, models.BuiltInPermissions(models.RoleSales), options(&s.other.ID))
+	s.ErrorIs(err, apperrors.ErrForbidden, "a sales user imports only their own leads")
+	_, err = s.service.DryRun(imp.ID, s.sales.ID, models.BuiltInPermissions(models.RoleSales), options(&s.sales.ID))
+	s.NoError(err)
+
+	_, err = s.service.DryRun(imp.ID, s.admin.ID, models.BuiltInPermissions(models.RoleAdmin), options(nil))
+	s.ErrorIs(err, apperrors.ErrValidation, "an admin names the owner")
+	missing := uint(999)
+	_, err = s.service.DryRun(imp.ID, s.admin.ID, models.BuiltInPermissions(models.RoleAdmin), options(&missing))
+	s.ErrorIs(err, apperrors.ErrValidation)
+	inactive := s.createUser("gone@example.com", models.RoleSales, false)
+	_, err = s.service.DryRun(imp.ID, s.admin.ID, models.BuiltInPermissions(models.RoleAdmin), options(&inactive.ID))
+	s.ErrorIs(err, apperrors.ErrValidation)
+	_, err = s.service.DryRun(imp.ID, s.admin.ID, models.BuiltInPermissions(models.RoleAdmin), options(&s.other.ID))
+	s.NoError(err)
+
+	customers := s.upload(s.sales.ID, models.AuditEntityCustomer, "first_name,last_name,email\nA,B,a@example.com\n", "")
+	_, err = s.service.DryRun(customers.ID, s.sales.ID, models.BuiltInPermissions(models.RoleSales), models.ImportOptions{
+		Mapping: customers.SuggestedMapping, OwnerID: &s.sales.ID,
+	})
+	s.ErrorIs(err, apperrors.ErrValidation, "customers have no owner")
+}
+
+func (s *ImportServiceSuite) TestStart_ImportsValidRowsAndRecordsFailures() {
+	imp := s.upload(s.sales.ID, models.AuditEntityLead, leadFile, ";")
+	mapping := leadMapping()
+	mapping[3].Values["Lukewarm"] = "unclassified"
+
+	started, err := s.service.Start(imp.ID, s.sales.ID, models.BuiltInPermissions(models.RoleSales), models.ImportOptions{Mapping: mapping})
+	s.Require().NoError(err)
+	s.Require().NotNil(started.OperationID)
+
+	var leads []models.Lead
+	s.Require().NoError(s.db.Order("id").Find(&leads).Error)
+	s.Require().Len(leads, 3)
+	s.Equal("ada@example.com", leads[0].Email)
+	s.Equal(models.LeadClassificationHotLead, leads[0].Classification)
+	s.Equal(models.LeadStatusNew, leads[0].Status)
+	s.Equal(s.sales.ID, leads[0].OwnerID)
+	s.Equal(models.LeadClassificationUnclassified, leads[2].Classification)
+
+	var events []models.AuditEvent
+	s.Require().NoError(s.db.Order("id").Find(&events).Error)
+	s.Require().Len(events, 3, "every created lead is audited")
+	for i, event := range events {
+		s.Equal(leads[i].ID, event.EntityID)
+		s.Equal(models.AuditActionCreate, event.Action)
+		s.Equal(s.sales.ID, *event.ActorUserID, "as created by the user who started the import")
+	}
+
+	imp, err = s.service.Get(imp.ID, s.sales.ID, models.BuiltInPermissions(models.RoleSales))
+	s.Require().NoError(err)
+	s.Equal(models.StatusPartial, imp.Operation.Status)
+	s.Equal(models.BulkCreate, imp.Operation.Type)
+	s.Equal("leads", imp.Operation.ResourceType)
+	s.Equal(4, imp.Operation.TotalItems)
+	s.Equal(3, imp.Operation.SuccessCount)
+	s.Equal(1, imp.Operation.FailureCount)
+	s.Equal(mapping, imp.Mapping)
+	s.Equal(s.sales.ID, *imp.OwnerID)
+	s.Empty(imp.Content, "the file is not kept once its rows have been read")
+
+	_, failed, err := s.service.FailedRows(imp.ID, s.sales.ID, models.BuiltInPermissions(models.RoleSales))
+	s.Require().NoError(err)
+	s.Require().Len(failed, 1)
+	s.Equal(5, failed[0].Row)
+	s.Equal([]string{"Alan", "", "not-an-email", "Hot", ""}, failed[0].Values)
+	s.Equal(`E-mail Address: "not-an-email" is not a valid email address; Last Name: last_name is required`, failed[0].Error)
+
+	// Started once: a second start, or a dry run, is refused and creates
+	// nothing.
+	_, err = s.service.Start(imp.ID, s.sales.ID, models.BuiltInPermissions(models.RoleSales), models.ImportOptions{Mapping: mapping})
+	s.ErrorIs(err, apperrors.ErrImportStarted)
+	_, err = s.service.DryRun(imp.ID, s.sales.ID, models.BuiltInPermissions(models.RoleSales), models.ImportOptions{Mapping: mapping})
+	s.ErrorIs(err, apperrors.ErrImportStarted)
+	s.Equal(int64(3), s.count(&models.Lead{}))
+	s.Equal(int64(1), s.count(&models.BulkOperation{}))
+}
+
+func (s *ImportServiceSuite) TestStart_ConcurrentStartLosesTheClaim() {
+	imp := s.upload(s.sales.ID, models.AuditEntityLead, leadFile, ";")
+	// Both requests loaded the import before either recorded its operation.
+	loaded, err := s.service.importRepo.GetByID(imp.ID)
+	s.Require().NoError(err)
+
+	_, err = s.service.Start(imp.ID, s.sales.ID, models.BuiltInPermissions(models.RoleSales), models.ImportOptions{Mapping: leadMapping()})
+	s.Require().NoError(err)
+	err = s.service.importRepo.Start(loaded, &models.BulkOperation{UserID: s.sales.ID, Type: models.BulkCreate,
+		ResourceType: "leads", Status: models.StatusPending})
+	s.ErrorIs(err, apperrors.ErrImportStarted)
+	s.Equal(int64(1), s.count(&models.BulkOperation{}), "the loser's operation is rolled back")
+}
+
+func (s *ImportServiceSuite) TestCustomers_DuplicateEmailsAndCustomFields() {
+	fields := repository.NewCustomFieldRepository(s.db)
+	tier := models.CustomFieldDefinition{EntityType: models.AuditEntityCustomer, Name: "tier", Label: "Tier",
+		Type: models.CustomFieldSelect, Options: []string{"gold", "silver"}}
+	s.Require().NoError(fields.CreateDefinition(&tier))
+	markets := models.CustomFieldDefinition{EntityType: models.AuditEntityCustomer, Name: "markets", Label: "Markets",
+		Type: models.CustomFieldMultiSelect, Options: []string{"emea", "apac", "amer"}}
+	s.Require().NoError(fields.CreateDefinition(&markets))
+	s.Require().NoError(s.db.Create(&models.Customer{FirstName: "Old", LastName: "Customer", Email: "taken@example.com"}).Error)
+
+	imp := s.upload(s.admin.ID, models.AuditEntityCustomer, "first_name,last_name,email,City,Tier,Markets\n"+
+		"Ada,Lovelace,ada@example.com,London,gold,emea; amer\n"+
+		"Ada,Twice,ADA@example.com,London,,\n"+
+		"Old,Again,taken@example.com,Paris,,\n"+
+		"Grace,Hopper,grace@example.com,Arlington,bronze,\n", "")
+	s.Equal([]models.ImportColumnMapping{
+		{Column: "first_name", Field: "first_name"},
+		{Column: "last_name", Field: "last_name"},
+		{Column: "email", Field: "email"},
+		{Column: "City", Field: "city"},
+		{Column: "Tier", Field: "cf.tier"},
+		{Column: "Markets", Field: "cf.markets"},
+	}, imp.SuggestedMapping)
+	options := models.ImportOptions{Mapping: imp.SuggestedMapping}
+
+	result, err := s.service.DryRun(imp.ID, s.admin.ID, models.BuiltInPermissions(models.RoleAdmin), options)
+	s.Require().NoError(err)
+	s.Equal(1, result.ValidRows)
+	s.Require().Len(result.Errors, 3)
+	s.Equal("ADA@example.com is already the email of row 2", result.Errors[0].Message)
+	s.Equal("a customer with the email taken@example.com already exists", result.Errors[1].Message)
+	s.Equal("Tier", result.Errors[2].Column)
+
+	_, err = s.service.Start(imp.ID, s.admin.ID, models.BuiltInPermissions(models.RoleAdmin), options)
+	s.Require().NoError(err)
+	customer, err := repository.NewCustomerRepository(s.db).GetByEmail("ada@example.com")
+	s.Require().NoError(err)
+	s.Equal("London", customer.City)
+	s.Equal("gold", customer.CustomFields["tier"])
+	s.Equal([]string{"emea", "amer"}, customer.CustomFields["markets"])
+
+	imp, err = s.service.Get(imp.ID, s.admin.ID, models.BuiltInPermissions(models.RoleAdmin))
+	s.Require().NoError(err)
+	s.Equal(models.StatusPartial, imp.Operation.Status)
+	s.Equal(1, imp.Operation.SuccessCount)
+	s.Equal(3, imp.Operation.FailureCount)
+	_, failed, err := s.service.FailedRows(imp.ID, s.admin.ID, models.BuiltInPermissions(models.RoleAdmin))
+	s.Require().NoError(err)
+	s.Require().Len(failed, 3)
+	s.Equal("email: ADA@example.com is already the email of row 2", failed[0].Error,
+		"the run refuses what the dry run reported, though the emails differ in case")
+	s.Equal("email: a customer with the email taken@example.com already exists", failed[1].Error)
+}
+
+func (s *ImportServiceSuite) TestFailedRows_NotStarted() {
+	imp := s.upload(s.sales.ID, models.AuditEntityLead, leadFile, ";")
+	_, _, err := s.service.FailedRows(imp.ID, s.sales.ID, models.BuiltInPermissions(models.RoleSales))
+	s.ErrorIs(err, apperrors.ErrValidation)
+}
+
+func (s *ImportServiceSuite) TestReconcileUnfinished() {
+	s.deferRuns = true
+	running := s.upload(s.sales.ID, models.AuditEntityLead, leadFile, ";")
+	_, err := s.service.Start(running.ID, s.sales.ID, models.BuiltInPermissions(models.RoleSales), models.ImportOptions{Mapping: leadMapping()})
+	s.Require().NoError(err)
+	s.upload(s.sales.ID, models.AuditEntityLead, leadFile, ";")
+
+	reconciled, err := s.service.ReconcileUnfinished()
+	s.Require().NoError(err)
+	s.Equal(1, reconciled, "only a started import can be unfinished")
+
+	running, err = s.service.Get(running.ID, s.sales.ID, models.BuiltInPermissions(models.RoleSales))
+	s.Require().NoError(err)
+	s.Equal(models.StatusFailed, running.Operation.Status)
+	reconciled, err = s.service.ReconcileUnfinished()
+	s.Require().NoError(err)
+	s.Zero(reconciled)
+}
+
+func (s *ImportServiceSuite) TestParseImportFile_RowCap() {
+	var file strings.Builder
+	file.WriteString("email\n")
+	for i := 0; i <= models.ImportMaxRows; i++ {
+		file.WriteString("a@example.com\n")
+	}
+	_, _, err := parseImportFile([]byte(file.String()), ",")
+	s.True(errors.Is(err, apperrors.ErrValidation))
+	s.Contains(err.Error(), "more than")
+}
@@ -0,0 +1,462 @@
+package service
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/inbound"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// inboundNoSubject titles a ticket opened from a message without a subject.
+	inboundNoSubject = "(no subject)"
+	// inboundEmptyBody stands in for a message without any text, since both a
+	// ticket description and a comment body are required.
+	inboundEmptyBody = "(empty message)"
+	// inboundTruncatedMarker ends a body clipped to MaxTicketCommentLength.
+	inboundTruncatedMarker = "\n\n[Message truncated]"
+	// inboundLeadSource is the source given to leads captured from email.
+	inboundLeadSource = "email"
+
+	// Column widths of inbound_emails and tickets.title.
+	inboundAddressMaxLength = 255
+	inboundNameMaxLength    = 200
+	inboundSubjectMaxLength = 255
+	ticketTitleMaxLength    = 255
+)
+
+// InboundEmailService turns the mail customers send to the support address
+// into tickets and replies, and keeps the log that makes delivery idempotent.
+//
+// A message is routed as follows:
+//   - an auto-reply or bulk message is ignored;
+//   - a message naming a ticket, through its "[Ticket #N-token]" subject
+//     token (see inbound.TicketToken) or by answering a message already on
+//     file, becomes a reply on that ticket, provided the token is the
+//     ticket's, it comes from the ticket's customer and the ticket is not
+//     closed;
+//   - otherwise a message from a customer opens a ticket;
+//   - a message from anybody else is quarantined, or captured as a lead when
+//     the gateway is configured to.
+//
+// Only failures worth retrying — the database being unreachable — are
+// returned from HandleMessage; everything a message itself can get wrong is
+// decided there and then, so one bad message never blocks the queue.
+type InboundEmailService interface {
+	inbound.MessageHandler
+	// List returns one page of the log, newest first; an empty status lists
+	// every entry.
+	List(status models.InboundEmailStatus, offset, limit int) ([]models.InboundEmail, int64, error)
+	GetByID(id uint) (*models.InboundEmail, error)
+	// Release routes a quarantined message again, typically after its sender
+	// was added as a customer. A message that is not quarantined, or whose
+	// sender is still unknown to a gateway that does not capture leads, is
+	// rejected with apperrors.ErrValidation.
+	Release(id uint) (*models.InboundEmail, error)
+	// Delete discards a log entry, and with it a quarantined message.
+	Delete(id uint) error
+}
+
+type inboundEmailService struct {
+	repo           repository.InboundEmailRepository
+	customerRepo   repository.CustomerRepository
+	ticketRepo     repository.TicketRepository
+	leadRepo       repository.LeadRepository
+	ticketService  TicketService
+	commentService TicketCommentService
+	cfg            config.InboundEmailConfig
+	activityFeed
+	auditRecorder
+}
+
+// NewInboundEmailService wires the gateway. The tickets and leads a message
+// opens or adds to are audited as the system's doing.
+func NewInboundEmailService(
+	repo repository.InboundEmailRepository,
+	customerRepo repository.CustomerRepository,
+	ticketRepo repository.TicketRepository,
+	leadRepo repository.LeadRepository,
+	ticketService TicketService,
+	commentService TicketCommentService,
+	auditService AuditService,
+	cfg config.InboundEmailConfig,
+	opts ...ActivityOption,
+) InboundEmailService {
+	s := &inboundEmailService{
+		repo:           repo,
+		customerRepo:   customerRepo,
+		ticketRepo:     ticketRepo,
+		leadRepo:       leadRepo,
+		ticketService:  ticketService,
+		commentService: commentService,
+		cfg:            cfg,
+		auditRecorder:  auditRecorder{auditService: auditService},
+	}
+	s.applyActivityOptions(opts)
+	return s
+}
+
+func (s *inboundEmailService) HandleMessage(msg *inbound.Message) error {
+	logger := utils.LogServiceCall(utils.Logger.WithFields(map[string]interface{}{
+		"message_id": msg.MessageID,
+		"from":       msg.FromAddress,
+	}), "InboundEmailService", "HandleMessage")
+
+	if msg.MessageID != "" {
+		seen, err := s.repo.ExistsByMessageID(truncate(msg.MessageID, inboundAddressMaxLength))
+		if err != nil {
+			utils.LogServiceResponse(logger, err)
+			return err
+		}
+		if seen {
+			logger.Info("Inbound email already handled, skipped")
+			return nil
+		}
+	}
+
+	entry := &models.InboundEmail{
+		MessageID:  truncate(msg.MessageID, inboundAddressMaxLength),
+		ReceivedAt: time.Now().UTC(),
+	}
+	if msg.AutoGenerated {
+		entry.Status = models.InboundEmailIgnored
+	} else if err := s.route(msg, entry); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+	if entry.Status == models.InboundEmailQuarantined {
+		keepQuarantined(entry, msg)
+	}
+
+	if err := s.repo.Create(entry); err != nil {
+		if entry.TicketID != nil || entry.LeadID != nil {
+			// The ticket, reply or lead exists already. Returning the error
+			// would handle the message a second time on the next poll, so
+			// the missing log entry — which only costs redelivery protection
+			// for this one message — is logged instead.
+			logger.WithError(err).Error("Failed to log inbound email; it was handled")
+			return nil
+		}
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"inbound_email_id": entry.ID,
+		"status":           entry.Status,
+	}).Info("Inbound email handled")
+	return nil
+}
+
+// route decides what becomes of msg and records the outcome on entry. It
+// never quarantines by writing anything: a message whose sender is unknown
+// only gets the status.
+func (s *inboundEmailService) route(msg *inbound.Message, entry *models.InboundEmail) error {
+	ticket, err := s.threadTicket(msg)
+	if err != nil {
+		return err
+	}
+	if ticket != nil {
+		body := clipEmailBody(inbound.StripQuotedReply(msg.Body))
+		if _, err := s.commentService.AddEmailReply(ticket.ID, body); err != nil {
+			return err
+		}
+		entry.Status = models.InboundEmailReplyAdded
+		entry.TicketID = &ticket.ID
+		entry.CustomerID = &ticket.CustomerID
+		return nil
+	}
+
+	customer, err := s.customerRepo.GetByEmail(msg.FromAddress)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	if err == nil {
+		ticket := &models.Ticket{
+			Title:       emailTicketTitle(msg.Subject),
+			Description: clipEmailBody(msg.Body),
+			CustomerID:  customer.ID,
+		}
+		if err := s.ticketService.Create(ticket); err != nil {
+			return err
+		}
+		s.recordAudit(systemAuditActor, models.AuditEntityTicket, ticket.ID, models.AuditActionCreate, nil, ticket)
+		entry.Status = models.InboundEmailTicketCreated
+		entry.TicketID = &ticket.ID
+		entry.CustomerID = &customer.ID
+		return nil
+	}
+
+	if !s.cfg.CapturesLeads() {
+		entry.Status = models.InboundEmailQuarantined
+		return nil
+	}
+	lead, err := s.captureLead(msg)
+	if err != nil {
+		return err
+	}
+	entry.Status = models.InboundEmailLeadCaptured
+	entry.LeadID = &lead.ID
+	return nil
+}
+
+// threadTicket returns the ticket msg answers, or nil when it answers none it
+// may be added to. Anybody can type a ticket number into a subject line and
+// forge a From header, so a subject token threads only with the ticket's
+// reply token, and even then only a message from the ticket's customer. The
+// In-Reply-To and References headers are the sender's to write just the same:
+// a message they tie to a ticket threads only when its body quotes the
+// ticket's token as well.
+func (s *inboundEmailService) threadTicket(msg *inbound.Message) (*models.Ticket, error) {
+	ticketID, replyToken, ok := inbound.TicketFromSubject(msg.Subject)
+	if !ok {
+		if len(msg.References) == 0 {
+			return nil, nil
+		}
+		id, err := s.repo.TicketIDForMessages(msg.References)
+		if err != nil {
+			if isNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		ticketID = id
+	}
+
+	ticket, err := s.ticketRepo.GetByID(ticketID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if ok && !sameReplyToken(ticket.ReplyToken, replyToken) {
+		return nil, nil
+	}
+	if !ok && !quotesReplyToken(ticket, msg.Body) {
+		return nil, nil
+	}
+	if !strings.EqualFold(ticket.Customer.Email, msg.FromAddress) {
+		return nil, nil
+	}
+	if ticket.Status == models.TicketStatusClosed {
+		// A closed ticket is final; writing again about it is a new request.
+		return nil, nil
+	}
+	return ticket, nil
+}
+
+// sameReplyToken compares a subject's reply token with the ticket's in
+// constant time. A ticket without a token is matched by none.
+func sameReplyToken(ticketToken, subjectToken string) bool {
+	return ticketToken != "" && subtle.ConstantTimeCompare([]byte(ticketToken), []byte(subjectToken)) == 1
+}
+
+// quotesReplyToken reports whether body carries the ticket's token with its
+// reply token, as the quoted history of an answer to the agent's mail does.
+func quotesReplyToken(ticket *models.Ticket, body string) bool {
+	for _, token := range inbound.TicketTokensIn(body, ticket.ID) {
+		if sameReplyToken(ticket.ReplyToken, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// captureLead files an unknown sender's message on the newest lead with
+// their address, or on a new lead owned by the configured owner, the way a
+// form submission is.
+func (s *inboundEmailService) captureLead(msg *inbound.Message) (*models.Lead, error) {
+	notes := emailLeadNotes(msg)
+
+	existing, err := s.leadRepo.GetLatestByEmail(msg.FromAddress)
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+	if err == nil && existing != nil {
+		before := AuditSnapshot(existing)
+		if strings.TrimSpace(existing.Notes) == "" {
+			existing.Notes = strings.TrimLeft(notes, "\n")
+		} else {
+			existing.Notes = strings.TrimRight(existing.Notes, "\n") + notes
+		}
+		if err := s.leadRepo.Update(existing); err != nil {
+			return nil, err
+		}
+		s.recordAudit(systemAuditActor, models.AuditEntityLead, existing.ID, models.AuditActionUpdate, before, existing)
+		return existing, nil
+	}
+
+	firstName, lastName := "", ""
+	if parts := strings.Fields(msg.FromName); len(parts) > 0 {
+		firstName = truncate(parts[0], leadNameMaxLength)
+		lastName = truncate(strings.Join(parts[1:], " "), leadNameMaxLength)
+	}
+	if firstName == "" {
+		firstName = "Email"
+	}
+	if lastName == "" {
+		lastName = truncate(emailLocalPart(msg.FromAddress), leadNameMaxLength)
+	}
+
+	lead := &models.Lead{
+		FirstName: firstName,
+		LastName:  lastName,
+		Email:     truncate(msg.FromAddress, inboundAddressMaxLength),
+		Source:    inboundLeadSource,
+		Status:    models.LeadStatusNew,
+		OwnerID:   s.cfg.LeadOwnerID,
+		Notes:     strings.TrimLeft(notes, "\n"),
+	}
+	if err := s.leadRepo.Create(lead); err != nil {
+		return nil, err
+	}
+	s.recordAudit(systemAuditActor, models.AuditEntityLead, lead.ID, models.AuditActionCreate, nil, lead)
+	ownerID := lead.OwnerID
+	s.recordActivity(models.AuditEntityLead, lead.ID, models.ActivityLeadCreated, &ownerID,
+		"New lead from email", lead.FirstName+" "+lead.LastName)
+	return lead, nil
+}
+
+func (s *inboundEmailService) List(status models.InboundEmailStatus, offset, limit int) ([]models.InboundEmail, int64, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithFields(map[string]interface{}{
+		"status": status,
+		"offset": offset,
+		"limit":  limit,
+	}), "InboundEmailService", "List")
+
+	if status != "" && !status.IsValid() {
+		return nil, 0, fmt.Errorf("unknown inbound email status %q: %w", status, apperrors.ErrValidation)
+	}
+	emails, total, err := s.repo.List(status, offset, limit)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, 0, err
+	}
+	return emails, total, nil
+}
+
+func (s *inboundEmailService) GetByID(id uint) (*models.InboundEmail, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("inbound_email_id", id), "InboundEmailService", "GetByID")
+
+	email, err := s.repo.GetByID(id)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, fmt.Errorf("inbound email %d not found: %w", id, apperrors.ErrNotFound)
+		}
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	return email, nil
+}
+
+func (s *inboundEmailService) Release(id uint) (*models.InboundEmail, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("inbound_email_id", id), "InboundEmailService", "Release")
+
+	entry, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Status != models.InboundEmailQuarantined {
+		return nil, fmt.Errorf("inbound email %d is not quarantined: %w", id, apperrors.ErrValidation)
+	}
+
+	// The quarantined copy is all that is left of the message; its headers
+	// were not kept, so a reply can only find its ticket by subject token.
+	msg := &inbound.Message{
+		MessageID:   entry.MessageID,
+		FromAddress: entry.FromAddress,
+		FromName:    entry.FromName,
+		Subject:     entry.Subject,
+		Body:        entry.Body,
+	}
+	if err := s.route(msg, entry); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	if entry.Status == models.InboundEmailQuarantined {
+		return nil, fmt.Errorf("sender %s is not a customer: %w", entry.FromAddress, apperrors.ErrValidation)
+	}
+
+	// The message now lives on in the ticket or lead it became.
+	entry.FromAddress, entry.FromName, entry.Subject, entry.Body = "", "", "", ""
+	if err := s.repo.Update(entry); err != nil {
+		logger.WithError(err).Error("Failed to update released inbound email")
+		return nil, err
+	}
+
+	logger.WithField("status", entry.Status).Info("Quarantined inbound email released")
+	return entry, nil
+}
+
+func (s *inboundEmailService) Delete(id uint) error {
+	logger := utils.LogServiceCall(utils.Logger.WithField("inbound_email_id", id), "InboundEmailService", "Delete")
+
+	if err := s.repo.Delete(id); err != nil {
+		if isNotFound(err) {
+			return fmt.Errorf("inbound email %d not found: %w", id, apperrors.ErrNotFound)
+		}
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+	logger.Info("Inbound email deleted")
+	return nil
+}
+
+// keepQuarantined copies what a reviewer needs onto a quarantined entry.
+func keepQuarantined(entry *models.InboundEmail, msg *inbound.Message) {
+	entry.FromAddress = truncate(msg.FromAddress, inboundAddressMaxLength)
+	entry.FromName = truncate(msg.FromName, inboundNameMaxLength)
+	entry.Subject = truncate(msg.Subject, inboundSubjectMaxLength)
+	entry.Body = clipEmailBody(msg.Body)
+}
+
+func emailTicketTitle(subject string) string {
+	title := inbound.CleanSubject(subject)
+	if title == "" {
+		title = inboundNoSubject
+	}
+	return truncate(title, ticketTitleMaxLength)
+}
+
+// clipEmailBody makes a body fit a ticket comment, which is limited in bytes,
+// cutting on a character boundary and saying so.
+func clipEmailBody(body string) string {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return inboundEmptyBody
+	}
+	if len(body) <= models.MaxTicketCommentLength {
+		return body
+	}
+	cut := models.MaxTicketCommentLength - len(inboundTruncatedMarker)
+	for cut > 0 && !utf8.RuneStart(body[cut]) {
+		cut--
+	}
+	return strings.TrimSpace(body[:cut]) + inboundTruncatedMarker
+}
+
+// emailLeadNotes renders the note block appended to a lead for one message.
+func emailLeadNotes(msg *inbound.Message) string {
+	var b strings.Builder
+	b.WriteString("\n\n--- Email: ")
+	subject := strings.TrimSpace(msg.Subject)
+	if subject == "" {
+		subject = inboundNoSubject
+	}
+	b.WriteString(subject)
+	b.WriteString(" (")
+	b.WriteString(time.Now().Format("2006-01-02"))
+	b.WriteString(") ---\n")
+	b.WriteString(clipEmailBody(msg.Body))
+	return b.String()
+}
@@ -0,0 +1,391 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/inbound"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type InboundEmailServiceTestSuite struct {
+	suite.Suite
+	mockRepo         *mocks.InboundEmailRepository
+	mockCustomerRepo *mocks.CustomerRepository
+	mockTicketRepo   *mocks.TicketRepository
+	mockLeadRepo     *mocks.LeadRepository
+	mockTickets      *mocks.TicketService
+	mockComments     *mocks.TicketCommentService
+	mockActivity     *mocks.ActivityService
+	service          InboundEmailService
+}
+
+func (suite *InboundEmailServiceTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+}
+
+func (suite *InboundEmailServiceTestSuite) SetupTest() {
+	suite.mockRepo = new(mocks.InboundEmailRepository)
+	suite.mockCustomerRepo = new(mocks.CustomerRepository)
+	suite.mockTicketRepo = new(mocks.TicketRepository)
+	suite.mockLeadRepo = new(mocks.LeadRepository)
+	suite.mockTickets = new(mocks.TicketService)
+	suite.mockComments = new(mocks.TicketCommentService)
+	suite.mockActivity = new(mocks.ActivityService)
+	suite.service = suite.newService(config.InboundEmailConfig{UnknownSender: "quarantine"})
+}
+
+func (suite *InboundEmailServiceTestSuite) newService(cfg config.InboundEmailConfig) InboundEmailService {
+	return NewInboundEmailService(suite.mockRepo, suite.mockCustomerRepo, suite.mockTicketRepo, suite.mockLeadRepo,
+		suite.mockTickets, suite.mockComments, nil, cfg, WithActivityFeed(suite.mockActivity))
+}
+
+func (suite *InboundEmailServiceTestSuite) TearDownTest() {
+	suite.mockRepo.AssertExpectations(suite.T())
+	suite.mockCustomerRepo.AssertExpectations(suite.T())
+	suite.mockTicketRepo.AssertExpectations(suite.T())
+	suite.mockLeadRepo.AssertExpectations(suite.T())
+	suite.mockTickets.AssertExpectations(suite.T())
+	suite.mockComments.AssertExpectations(suite.T())
+	suite.mockActivity.AssertExpectations(suite.T())
+}
+
+// testReplyToken is the reply token of every customerTicket.
+const testReplyToken = "3f9c0a6d1e2b4c5a8f7e6d5c4b3a2910"
+
+func customerTicket(id uint, email string, status models.TicketStatus) *models.Ticket {
+	ticket := &models.Ticket{Title: "Printer on fire", Status: status, CustomerID: 3, ReplyToken: testReplyToken}
+	ticket.ID = id
+	ticket.Customer.ID = 3
+	ticket.Customer.Email = email
+	return ticket
+}
+
+func (suite *InboundEmailServiceTestSuite) TestHandleMessage_KnownCustomerOpensTicket() {
+	msg := &inbound.Message{MessageID: "m1@x", FromAddress: "agnes@example.com", Subject: "Fwd: Printer on fire", Body: "It is on fire."}
+	customer := &models.Customer{Email: "agnes@example.com"}
+	customer.ID = 3
+
+	suite.mockRepo.On("ExistsByMessageID", "m1@x").Return(false, nil)
+	suite.mockCustomerRepo.On("GetByEmail", "agnes@example.com").Return(customer, nil)
+	suite.mockTickets.On("Create", mock.MatchedBy(func(t *models.Ticket) bool {
+		return t.Title == "Printer on fire" && t.Description == "It is on fire." && t.CustomerID == 3
+	})).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Ticket).ID = 11
+	}).Return(nil)
+	suite.mockRepo.On("Create", mock.MatchedBy(func(e *models.InboundEmail) bool {
+		return e.Status == models.InboundEmailTicketCreated && e.MessageID == "m1@x" &&
+			*e.TicketID == 11 && *e.CustomerID == 3 && e.Body == "" && e.FromAddress == ""
+	})).Return(nil)
+
+	err := suite.service.HandleMessage(msg)
+
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *InboundEmailServiceTestSuite) TestHandleMessage_SubjectTokenThreadsReply() {
+	msg := &inbound.Message{
+		FromAddress: "agnes@example.com",
+		Subject:     "Re: Printer on fire " + inbound.TicketToken(11, testReplyToken),
+		Body:        "Still burning.\n\nOn Mon, 2 Mar 2026, Support <s@x> wrote:\n> try water",
+	}
+	suite.mockTicketRepo.On("GetByID", uint(11)).Return(customerTicket(11, "Agnes@Example.com", models.TicketStatusResolved), nil)
+	suite.mockComments.On("AddEmailReply", uint(11), "Still burning.").Return(&models.TicketComment{}, nil)
+	suite.mockRepo.On("Create", mock.MatchedBy(func(e *models.InboundEmail) bool {
+		return e.Status == models.InboundEmailReplyAdded && *e.TicketID == 11 && e.MessageID == ""
+	})).Return(nil)
+
+	err := suite.service.HandleMessage(msg)
+
+	assert.NoError(suite.T(), err)
+	suite.mockRepo.AssertNotCalled(suite.T(), "ExistsByMessageID", mock.Anything)
+}
+
+func (suite *InboundEmailServiceTestSuite) TestHandleMessage_InReplyToThreadsReply() {
+	// The subject lost its token, but the quoted answer still carries it.
+	body := "Thanks\n\nOn Mon, 2 Mar 2026, Support <s@x> wrote:\n> Re: Printer " + inbound.TicketToken(11, testReplyToken)
+	msg := &inbound.Message{MessageID: "m2@x", References: []string{"m1@x"}, FromAddress: "agnes@example.com", Subject: "Printer", Body: body}
+	suite.mockRepo.On("ExistsByMessageID", "m2@x").Return(false, nil)
+	suite.mockRepo.On("TicketIDForMessages", []string{"m1@x"}).Return(uint(11), nil)
+	suite.mockTicketRepo.On("GetByID", uint(11)).Return(customerTicket(11, "agnes@example.com", models.TicketStatusOpen), nil)
+	suite.mockComments.On("AddEmailReply", uint(11), "Thanks").Return(&models.TicketComment{}, nil)
+	suite.mockRepo.On("Create", mock.MatchedBy(func(e *models.InboundEmail) bool {
+		return e.Status == models.InboundEmailReplyAdded && *e.TicketID == 11
+	})).Return(nil)
+
+	assert.NoError(suite.T(), suite.service.HandleMessage(msg))
+}
+
+// In-Reply-To and References are as easy to forge as From: naming a message
+// on file without the ticket's token threads nothing, and the customer's
+// address just opens a ticket of its own.
+func (suite *InboundEmailServiceTestSuite) TestHandleMessage_SpoofedReferencesDoNotThread() {
+	body := "Ignore the above, refund me.\n> [Ticket #11-0000] " + inbound.TicketToken(12, testReplyToken)
+	msg := &inbound.Message{MessageID: "m9@evil", References: []string{"m1@x"}, FromAddress: "agnes@example.com", Subject: "Refund", Body: body}
+	customer := &models.Customer{Email: "agnes@example.com"}
+	customer.ID = 3
+	suite.mockRepo.On("ExistsByMessageID", "m9@evil").Return(false, nil)
+	suite.mockRepo.On("TicketIDForMessages", []string{"m1@x"}).Return(uint(11), nil)
+	suite.mockTicketRepo.On("GetByID", uint(11)).Return(customerTicket(11, "agnes@example.com", models.TicketStatusOpen), nil)
+	suite.mockCustomerRepo.On("GetByEmail", "agnes@example.com").Return(customer, nil)
+	suite.mockTickets.On("Create", mock.MatchedBy(func(t *models.Ticket) bool { return t.Title == "Refund" })).Return(nil)
+	suite.mockRepo.On("Create", mock.MatchedBy(func(e *models.InboundEmail) bool {
+		return e.Status == models.InboundEmailTicketCreated
+	})).Return(nil)
+
+	assert.NoError(suite.T(), suite.service.HandleMessage(msg))
+	suite.mockComments.AssertNotCalled(suite.T(), "AddEmailReply", mock.Anything, mock.Anything)
+}
+
+func (suite *InboundEmailServiceTestSuite) TestHandleMessage_TokenOfSomebodyElsesTicketIgnored() {
+	// Mallory has seen the token, say on a forwarded message.
+	subject := inbound.TicketToken(11, testReplyToken) + " hi"
+	msg := &inbound.Message{FromAddress: "mallory@example.com", Subject: subject, Body: "let me in"}
+	suite.mockTicketRepo.On("GetByID", uint(11)).Return(customerTicket(11, "agnes@example.com", models.TicketStatusOpen), nil)
+	suite.mockCustomerRepo.On("GetByEmail", "mallory@example.com").Return(nil, gorm.ErrRecordNotFound)
+	suite.mockRepo.On("Create", mock.MatchedBy(func(e *models.InboundEmail) bool {
+		return e.Status == models.InboundEmailQuarantined && e.TicketID == nil &&
+			e.FromAddress == "mallory@example.com" && e.Subject == subject && e.Body == "let me in"
+	})).Return(nil)
+
+	assert.NoError(suite.T(), suite.service.HandleMessage(msg))
+	suite.mockComments.AssertNotCalled(suite.T(), "AddEmailReply", mock.Anything, mock.Anything)
+}
+
+func (suite *InboundEmailServiceTestSuite) TestHandleMessage_ClosedTicketOpensNewOne() {
+	msg := &inbound.Message{FromAddress: "agnes@example.com", Subject: "Re: Printer " + inbound.TicketToken(11, testReplyToken), Body: "Again"}
+	customer := &models.Customer{Email: "agnes@example.com"}
+	customer.ID = 3
+	suite.mockTicketRepo.On("GetByID", uint(11)).Return(customerTicket(11, "agnes@example.com", models.TicketStatusClosed), nil)
+	suite.mockCustomerRepo.On("GetByEmail", "agnes@example.com").Return(customer, nil)
+	suite.mockTickets.On("Create", mock.MatchedBy(func(t *models.Ticket) bool { return t.Title == "Printer" })).Return(nil)
+	suite.mockRepo.On("Create", mock.MatchedBy(func(e *models.InboundEmail) bool {
+		return e.Status == models.InboundEmailTicketCreated
+	})).Return(nil)
+
+	assert.NoError(suite.T(), suite.service.HandleMessage(msg))
+}
+
+// TestHandleMessage_TokenWithoutReplyTokenIgnored: a ticket number alone, or
+// with a reply token that is not the ticket's, threads nothing, even from the
+// ticket's customer — a From header is easily forged.
+func (suite *InboundEmailServiceTestSuite) TestHandleMessage_TokenWithoutReplyTokenIgnored() {
+	customer := &models.Customer{Email: "agnes@example.com"}
+	customer.ID = 3
+	suite.mockTicketRepo.On("GetByID", uint(11)).Return(customerTicket(11, "agnes@example.com", models.TicketStatusOpen), nil)
+	suite.mockCustomerRepo.On("GetByEmail", "agnes@example.com").Return(customer, nil)
+	suite.mockTickets.On("Create", mock.MatchedBy(func(t *models.Ticket) bool { return t.Title == "Printer" })).Return(nil)
+	suite.mockRepo.On("Create", mock.MatchedBy(func(e *models.InboundEmail) bool {
+		return e.Status == models.InboundEmailTicketCreated
+	})).Return(nil)
+
+	for _, subject := range []string{"Re: Printer [Ticket #11]", "Re: Printer " + inbound.TicketToken(11, "0badc0ffee")} {
+		assert.NoError(suite.T(), suite.service.HandleMessage(&inbound.Message{FromAddress: "agnes@example.com", Subject: subject, Body: "Again"}))
+	}
+	suite.mockTicketRepo.AssertNumberOfCalls(suite.T(), "GetByID", 1)
+	suite.mockTickets.AssertNumberOfCalls(suite.T(), "Create", 2)
+	suite.mockComments.AssertNotCalled(suite.T(), "AddEmailReply", mock.Anything, mock.Anything)
+}
+
+func (suite *InboundEmailServiceTestSuite) TestHandleMessage_DuplicateSkipped() {
+	suite.mockRepo.On("ExistsByMessageID", "m1@x").Return(true, nil)
+
+	err := suite.service.HandleMessage(&inbound.Message{MessageID: "m1@x", FromAddress: "agnes@example.com"})
+
+	assert.NoError(suite.T(), err)
+	suite.mockRepo.AssertNotCalled(suite.T(), "Create", mock.Anything)
+}
+
+func (suite *InboundEmailServiceTestSuite) TestHandleMessage_AutoReplyIgnored() {
+	suite.mockRepo.On("Create", mock.MatchedBy(func(e *models.InboundEmail) bool {
+		return e.Status == models.InboundEmailIgnored && e.Body == ""
+	})).Return(nil)
+
+	err := suite.service.HandleMessage(&inbound.Message{FromAddress: "agnes@example.com", Body: "Out of office", AutoGenerated: true})
+
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *InboundEmailServiceTestSuite) TestHandleMessage_UnknownSenderBecomesLead() {
+	service := suite.newService(config.InboundEmailConfig{UnknownSender: "lead", LeadOwnerID: 5})
+	msg := &inbound.Message{FromAddress: "prospect@example.com", FromName: "Anathema Device", Subject: "Pricing?", Body: "How much?"}
+
+	suite.mockCustomerRepo.On("GetByEmail", "prospect@example.com").Return(nil, gorm.ErrRecordNotFound)
+	suite.mockLeadRepo.On("GetLatestByEmail", "prospect@example.com").Return(nil, gorm.ErrRecordNotFound)
+	suite.mockLeadRepo.On("Create", mock.MatchedBy(func(l *models.Lead) bool {
+		return l.FirstName == "Anathema" && l.LastName == "Device" && l.Source == "email" && l.OwnerID == 5 &&
+			strings.Contains(l.Notes, "--- Email: Pricing?") && strings.HasSuffix(l.Notes, "How much?")
+	})).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Lead).ID = 21
+	}).Return(nil)
+	suite.mockActivity.On("Record", mock.MatchedBy(func(e *models.ActivityEvent) bool {
+		return e.Type == models.ActivityLeadCreated && e.EntityID == 21
+	})).Return(nil)
+	suite.mockRepo.On("Create", mock.MatchedBy(func(e *models.InboundEmail) bool {
+		return e.Status == models.InboundEmailLeadCaptured && *e.LeadID == 21 && e.Body == ""
+	})).Return(nil)
+
+	assert.NoError(suite.T(), service.HandleMessage(msg))
+}
+
+// TestHandleMessage_AuditedAsTheSystem: nobody signed in opened the ticket or
+// the lead, so the audit trail has them as the system's.
+func (suite *InboundEmailServiceTestSuite) TestHandleMessage_AuditedAsTheSystem() {
+	audit := new(mocks.AuditService)
+	defer audit.AssertExpectations(suite.T())
+	service := NewInboundEmailService(suite.mockRepo, suite.mockCustomerRepo, suite.mockTicketRepo, suite.mockLeadRepo,
+		suite.mockTickets, suite.mockComments, audit, config.InboundEmailConfig{UnknownSender: "lead", LeadOwnerID: 5})
+	customer := &models.Customer{Email: "agnes@example.com"}
+	customer.ID = 3
+	system := models.AuditActor{Type: models.AuditActorSystem}
+
+	suite.mockCustomerRepo.On("GetByEmail", "agnes@example.com").Return(customer, nil)
+	suite.mockTickets.On("Create", mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Ticket).ID = 11
+	}).Return(nil)
+	audit.On("Record", system, models.AuditEntityTicket, uint(11), models.AuditActionCreate, nil,
+		mock.AnythingOfType("*models.Ticket")).Return(nil).Once()
+	suite.mockCustomerRepo.On("GetByEmail", "prospect@example.com").Return(nil, gorm.ErrRecordNotFound)
+	suite.mockLeadRepo.On("GetLatestByEmail", "prospect@example.com").Return(nil, gorm.ErrRecordNotFound)
+	suite.mockLeadRepo.On("Create", mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Lead).ID = 21
+	}).Return(nil)
+	audit.On("Record", system, models.AuditEntityLead, uint(21), models.AuditActionCreate, nil,
+		mock.AnythingOfType("*models.Lead")).Return(nil).Once()
+	suite.mockRepo.On("Create", mock.Anything).Return(nil)
+
+	assert.NoError(suite.T(), service.HandleMessage(&inbound.Message{FromAddress: "agnes@example.com", Body: "Hi"}))
+	assert.NoError(suite.T(), service.HandleMessage(&inbound.Message{FromAddress: "prospect@example.com", Body: "Hi"}))
+}
+
+func (suite *InboundEmailServiceTestSuite) TestHandleMessage_UnknownSenderNotedOnExistingLead() {
+	service := suite.newService(config.InboundEmailConfig{UnknownSender: "lead", LeadOwnerID: 5})
+	existing := &models.Lead{Email: "prospect@example.com", Notes: "Met at the fair."}
+	existing.ID = 21
+
+	suite.mockCustomerRepo.On("GetByEmail", "prospect@example.com").Return(nil, gorm.ErrRecordNotFound)
+	suite.mockLeadRepo.On("GetLatestByEmail", "prospect@example.com").Return(existing, nil)
+	suite.mockLeadRepo.On("Update", mock.MatchedBy(func(l *models.Lead) bool {
+		return strings.HasPrefix(l.Notes, "Met at the fair.\n\n--- Email: (no subject)")
+	})).Return(nil)
+	suite.mockRepo.On("Create", mock.MatchedBy(func(e *models.InboundEmail) bool {
+		return e.Status == models.InboundEmailLeadCaptured && *e.LeadID == 21
+	})).Return(nil)
+
+	assert.NoError(suite.T(), service.HandleMessage(&inbound.Message{FromAddress: "prospect@example.com", Body: "Hello again"}))
+}
+
+func (suite *InboundEmailServiceTestSuite) TestHandleMessage_LeadPolicyWithoutOwnerQuarantines() {
+	service := suite.newService(config.InboundEmailConfig{UnknownSender: "lead"})
+	suite.mockCustomerRepo.On("GetByEmail", "prospect@example.com").Return(nil, gorm.ErrRecordNotFound)
+	suite.mockRepo.On("Create", mock.MatchedBy(func(e *models.InboundEmail) bool {
+		return e.Status == models.InboundEmailQuarantined
+	})).Return(nil)
+
+	assert.NoError(suite.T(), service.HandleMessage(&inbound.Message{FromAddress: "prospect@example.com", Body: "Hi"}))
+	suite.mockLeadRepo.AssertNotCalled(suite.T(), "Create", mock.Anything)
+}
+
+func (suite *InboundEmailServiceTestSuite) TestHandleMessage_InfrastructureErrorIsRetried() {
+	suite.mockCustomerRepo.On("GetByEmail", "agnes@example.com").Return(nil, errors.New("connection refused"))
+
+	err := suite.service.HandleMessage(&inbound.Message{FromAddress: "agnes@example.com", Body: "Hi"})
+
+	assert.Error(suite.T(), err)
+	suite.mockRepo.AssertNotCalled(suite.T(), "Create", mock.Anything)
+}
+
+func (suite *InboundEmailServiceTestSuite) TestHandleMessage_LogFailureAfterTicketIsNotRetried() {
+	customer := &models.Customer{Email: "agnes@example.com"}
+	customer.ID = 3
+	suite.mockCustomerRepo.On("GetByEmail", "agnes@example.com").Return(customer, nil)
+	suite.mockTickets.On("Create", mock.Anything).Return(nil)
+	suite.mockRepo.On("Create", mock.Anything).Return(errors.New("connection reset"))
+
+	err := suite.service.HandleMessage(&inbound.Message{FromAddress: "agnes@example.com", Body: "Hi"})
+
+	assert.NoError(suite.T(), err, "retrying would open the ticket twice")
+}
+
+func (suite *InboundEmailServiceTestSuite) TestRelease_OpensTicketAndDropsCopy() {
+	entry := &models.InboundEmail{
+		ID: 8, Status: models.InboundEmailQuarantined,
+		FromAddress: "agnes@example.com", Subject: "Help", Body: "Please",
+	}
+	customer := &models.Customer{Email: "agnes@example.com"}
+	customer.ID = 3
+	suite.mockRepo.On("GetByID", uint(8)).Return(entry, nil)
+	suite.mockCustomerRepo.On("GetByEmail", "agnes@example.com").Return(customer, nil)
+	suite.mockTickets.On("Create", mock.MatchedBy(func(t *models.Ticket) bool {
+		return t.Title == "Help" && t.Description == "Please"
+	})).Return(nil)
+	suite.mockRepo.On("Update", mock.MatchedBy(func(e *models.InboundEmail) bool {
+		return e.Status == models.InboundEmailTicketCreated && e.FromAddress == "" && e.Body == ""
+	})).Return(nil)
+
+	released, err := suite.service.Release(8)
+
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), models.InboundEmailTicketCreated, released.Status)
+}
+
+func (suite *InboundEmailServiceTestSuite) TestRelease_StillUnknownSenderRejected() {
+	entry := &models.InboundEmail{ID: 8, Status: models.InboundEmailQuarantined, FromAddress: "stranger@example.com"}
+	suite.mockRepo.On("GetByID", uint(8)).Return(entry, nil)
+	suite.mockCustomerRepo.On("GetByEmail", "stranger@example.com").Return(nil, gorm.ErrRecordNotFound)
+
+	_, err := suite.service.Release(8)
+
+	assert.ErrorIs(suite.T(), err, apperrors.ErrValidation)
+	suite.mockRepo.AssertNotCalled(suite.T(), "Update", mock.Anything)
+}
+
+func (suite *InboundEmailServiceTestSuite) TestRelease_OnlyQuarantined() {
+	suite.mockRepo.On("GetByID", uint(8)).Return(&models.InboundEmail{ID: 8, Status: models.InboundEmailIgnored}, nil)
+
+	_, err := suite.service.Release(8)
+
+	assert.ErrorIs(suite.T(), err, apperrors.ErrValidation)
+}
+
+func (suite *InboundEmailServiceTestSuite) TestList_UnknownStatusRejected() {
+	_, _, err := suite.service.List("bounced", 0, 20)
+
+	assert.ErrorIs(suite.T(), err, apperrors.ErrValidation)
+}
+
+func (suite *InboundEmailServiceTestSuite) TestDelete_NotFound() {
+	suite.mockRepo.On("Delete", uint(8)).Return(gorm.ErrRecordNotFound)
+
+	err := suite.service.Delete(8)
+
+	assert.ErrorIs(suite.T(), err, apperrors.ErrNotFound)
+}
+
+func TestInboundEmailServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(InboundEmailServiceTestSuite))
+}
+
+func TestClipEmailBody(t *testing.T) {
+	assert.Equal(t, inboundEmptyBody, clipEmailBody("  \n "))
+
+	long := strings.Repeat("é", models.MaxTicketCommentLength)
+	clipped := clipEmailBody(long)
+	assert.LessOrEqual(t, len(clipped), models.MaxTicketCommentLength, "a comment is limited in bytes")
+	assert.True(t, strings.HasSuffix(clipped, inboundTruncatedMarker))
+	assert.True(t, utf8ValidPrefix(clipped), "the cut falls on a character boundary")
+}
+
+func utf8ValidPrefix(s string) bool {
+	return strings.ToValidUTF8(s, "?") == s
+}
@@ -15,20 +15,68 @@ type AuthTokens struct {
 	// the raw opaque value — it is returned to the client once and only its
 	// hash is persisted; it must never be logged.
 	User *models.User
+	// TwoFactorChallenge is set instead of the tokens and the user when the
+	// password was right but the account needs a second factor. The login
+	// completes with CompleteTwoFactorLogin; with TwoFactorSetupRequired the
+	// user has no authenticator yet and must enroll one first.
+	TwoFactorChallenge     string
+	TwoFactorSetupRequired bool
+	// RecoveryCodes are returned once, by the login that enrolled the user.
+	RecoveryCodes []string
+}
+
+// ClientInfo describes the client a session is started or used from. It is
+// what GET /auth/sessions shows of each session.
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// AccessTokenClaims identifies a validated access token: its jti, and the
+// session it was issued to, zero for a token issued outside any session.
+type AccessTokenClaims struct {
+	TokenID   string
+	SessionID uint
+	ExpiresAt time.Time
 }
 
 type AuthService interface {
 	Login(email, password string) (string, error)
-	LoginWithTokens(email, password string) (*AuthTokens, error)
+	LoginWithTokens(email, password string, client ClientInfo) (*AuthTokens, error)
+	// CompleteTwoFactorLogin finishes a login that LoginWithTokens answered
+	// with a challenge, given a TOTP or recovery code.
+	CompleteTwoFactorLogin(challenge, code string, client ClientInfo) (*AuthTokens, error)
+	// BeginTwoFactorSetup enrolls the user of a setup challenge, whose role
+	// requires a second factor they do not have yet.
+	BeginTwoFactorSetup(challenge string) (*models.TwoFactorSetup, error)
+	// LoginRegistered logs in a user who has just registered, answering with
+	// a setup challenge when their role requires a second factor.
+	LoginRegistered(user *models.User, client ClientInfo) (*AuthTokens, error)
+	// LoginWithSSO starts a session for a user the identity provider has
+	// authenticated.
+	LoginWithSSO(user *models.User, client ClientInfo) (*AuthTokens, error)
 	ValidateToken(token string) (*models.User, error)
+	// AuthenticateToken is ValidateToken that also returns the token's claims,
+	// for callers that act on the session the request was made in.
+	AuthenticateToken(token string) (*models.User, *AccessTokenClaims, error)
 	ValidateAPIKey(key string) (*models.User, error)
+	// AuthenticateAPIKey is ValidateAPIKey that also returns the key itself,
+	// for callers that attribute the request to the key as well as its owner.
+	AuthenticateAPIKey(key string) (*models.User, *models.APIKey, error)
 	GenerateJWT(user *models.User) (string, error)
 	GenerateTokens(user *models.User) (*AuthTokens, error)
-	RefreshAccessToken(refreshToken string) (*AuthTokens, error)
+	RefreshAccessToken(refreshToken string, client ClientInfo) (*AuthTokens, error)
 	InvalidateRefreshToken(refreshToken string) error
-	// Logout revokes the given refresh token if it belongs to the user, or all
-	// of the user's refresh tokens when refreshToken is empty. Idempotent.
-	Logout(userID uint, refreshToken string) error
+	// Logout revokes the session of the given refresh token if it belongs to
+	// the user, or all of the user's sessions when refreshToken is empty, and
+	// the access token the request was made with, if any. Idempotent.
+	Logout(userID uint, refreshToken string, current *AccessTokenClaims) error
+	// ListSessions returns the user's live sessions, the one with ID
+	// currentSessionID marked as current.
+	ListSessions(userID, currentSessionID uint) ([]models.Session, error)
+	// RevokeSession ends one of the user's sessions: its refresh token stops
+	// working, and so do its access tokens.
+	RevokeSession(userID, sessionID uint) error
 	ChangePassword(userID uint, currentPassword, newPassword string) error
 	// RequestPasswordReset never discloses whether the account exists; mail
 	// and lookup failures are swallowed by design (anti-enumeration).
@@ -47,6 +95,10 @@ type UserService interface {
 	List(offset, limit int) ([]models.User, int64, error)
 	ListSorted(offset, limit int, sortBy, sortOrder string) ([]models.User, int64, error)
 	Search(query string, offset, limit int, sortBy, sortOrder string) ([]models.User, int64, error)
+	ListFiltered(filter models.UserFilter, offset, limit int) ([]models.User, int64, error)
+	// StreamExport hands every user matching the filter to fn, unpaginated
+	// and a bounded batch at a time, for the admin-only exports.
+	StreamExport(filter models.UserFilter, fn func([]models.User) error) error
 }
 
 type LeadService interface {
@@ -60,6 +112,12 @@ type LeadService interface {
 	List(offset, limit int) ([]models.Lead, int64, error)
 	ListSorted(offset, limit int, sortBy, sortOrder string) ([]models.Lead, int64, error)
 	Search(query string, offset, limit int, sortBy, sortOrder string) ([]models.Lead, int64, error)
+	// ListFiltered applies the search, scoping and sort of the filter together
+	// with its custom field conditions.
+	ListFiltered(filter models.LeadFilter, offset, limit int) ([]models.Lead, int64, error)
+	// StreamExport hands every lead matching the filter to fn, unpaginated
+	// and a bounded batch at a time, for the admin-only exports.
+	StreamExport(filter models.LeadFilter, fn func([]models.Lead) error) error
 	ConvertToCustomer(leadID uint, customerData *models.Customer) (*models.Customer, error)
 	GetCount() (int64, error)
 	GetCountByClassification(classification models.LeadClassification) (int64, error)
@@ -83,9 +141,12 @@ type CustomerService interface {
 	List(offset, limit int) ([]models.Customer, int64, error)
 	ListSorted(offset, limit int, sortBy, sortOrder string) ([]models.Customer, int64, error)
 	Search(query string, offset, limit int, sortBy, sortOrder string) ([]models.Customer, int64, error)
-	// ExportAll returns every matching customer, unpaginated, for the admin-only
-	// CSV export.
-	ExportAll(search, sortBy, sortOrder string) ([]models.Customer, error)
+	// ListFiltered applies the search, scoping and sort of the filter together
+	// with its custom field conditions.
+	ListFiltered(filter models.CustomerFilter, offset, limit int) ([]models.Customer, int64, error)
+	// StreamExport hands every customer matching the filter to fn, unpaginated
+	// and a bounded batch at a time, for the admin-only exports.
+	StreamExport(filter models.CustomerFilter, fn func([]models.Customer) error) error
 	// Assign sets the staff account owning the customer relationship. The
 	// assignee must exist, be active, and hold the admin or sales role.
 	Assign(customerID, userID uint) (*models.Customer, error)
@@ -102,6 +163,12 @@ type TicketService interface {
 	List(offset, limit int) ([]models.Ticket, int64, error)
 	ListSorted(offset, limit int, sortBy, sortOrder string) ([]models.Ticket, int64, error)
 	Search(query string, offset, limit int, sortBy, sortOrder string) ([]models.Ticket, int64, error)
+	// ListFiltered applies the search, scoping and sort of the filter together
+	// with its custom field conditions.
+	ListFiltered(filter models.TicketFilter, offset, limit int) ([]models.Ticket, int64, error)
+	// StreamExport hands every ticket matching the filter to fn, unpaginated
+	// and a bounded batch at a time, for the admin-only exports.
+	StreamExport(filter models.TicketFilter, fn func([]models.Ticket) error) error
 	GetOpenCount() (int64, error)
 	// Dashboard analytics.
 	GetPriorityCounts() (map[string]int64, error)
@@ -118,6 +185,12 @@ type TaskService interface {
 	List(offset, limit int) ([]models.Task, int64, error)
 	ListSorted(offset, limit int, sortBy, sortOrder string) ([]models.Task, int64, error)
 	Search(query string, offset, limit int, sortBy, sortOrder string) ([]models.Task, int64, error)
+	// ListFiltered applies the search, scoping and sort of the filter together
+	// with its custom field conditions.
+	ListFiltered(filter models.TaskFilter, offset, limit int) ([]models.Task, int64, error)
+	// StreamExport hands every task matching the filter to fn, unpaginated
+	// and a bounded batch at a time, for the admin-only exports.
+	StreamExport(filter models.TaskFilter, fn func([]models.Task) error) error
 	GetPendingCount() (int64, error)
 	// Dashboard analytics. The ByAssignee variants exist so the handlers can
 	// narrow non-admin callers to their own assignments without the scoping
@@ -157,9 +230,13 @@ type LabelService interface {
 }
 
 type APIKeyService interface {
-	// Generate mints a key for userID. A non-nil expiresAt is stored on the key
-	// and enforced at authentication time by AuthService.ValidateAPIKey.
+	// Generate mints a key for userID with the full access of its owner. A
+	// non-nil expiresAt is stored on the key and enforced at authentication
+	// time by AuthService.ValidateAPIKey.
 	Generate(userID uint, name string, expiresAt *time.Time) (string, *models.APIKey, error)
+	// GenerateRestricted is Generate for a key held to restrictions. Invalid
+	// restrictions are apperrors.ErrValidation.
+	GenerateRestricted(userID uint, name string, expiresAt *time.Time, restrictions models.APIKeyRestrictions) (string, *models.APIKey, error)
 	GetByUser(userID uint) ([]models.APIKey, error)
 	// GetByID is owner-scoped: a key belonging to another user yields
 	// apperrors.ErrForbidden, a missing one apperrors.ErrNotFound.
@@ -168,6 +245,11 @@ type APIKeyService interface {
 	Update(id uint, userID uint, name *string, isActive *bool) (*models.APIKey, error)
 	Revoke(id uint, userID uint) error
 	List(userID uint) ([]models.APIKey, error)
+	// ListUsage pages through a key's request log, newest first. It is
+	// owner-scoped like GetByID unless anyKey is set.
+	ListUsage(id uint, userID uint, anyKey bool, offset, limit int) ([]models.APIKeyUsage, int64, error)
+	// RecordUsage appends one request to its key's log.
+	RecordUsage(usage *models.APIKeyUsage) error
 }
 
 type BulkOperationService interface {
@@ -184,6 +266,35 @@ type BulkOperationService interface {
 	ProcessBulkUpdate(userID uint, resourceType string, request *models.BulkUpdateRequest) (*models.BulkResponse, error)
 	ProcessBulkDelete(userID uint, resourceType string, request *models.BulkDeleteRequest) (*models.BulkResponse, error)
 	ProcessBulkAction(userID uint, resourceType string, request *models.BulkActionRequest) (*models.BulkResponse, error)
+
+	// Queued bulk operations. Each Queue method validates the request, refuses
+	// it if permissions do not allow one of its changes through the
+	// single-record endpoints, records it on a pending operation and returns that operation
+	// at once; a worker
+	// started by bulk.StartWorkers then runs it in chunks of
+	// models.BulkChunkSize, keeping its counters current as it goes.
+	QueueBulkCreate(userID uint, permissions models.PermissionSet, resourceType string, request *models.BulkCreateRequest) (*models.BulkOperation, error)
+	QueueBulkUpdate(userID uint, permissions models.PermissionSet, resourceType string, request *models.BulkUpdateRequest) (*models.BulkOperation, error)
+	QueueBulkDelete(userID uint, permissions models.PermissionSet, resourceType string, request *models.BulkDeleteRequest) (*models.BulkOperation, error)
+	QueueBulkAction(userID uint, permissions models.PermissionSet, resourceType string, request *models.BulkActionRequest) (*models.BulkOperation, error)
+	// CancelBulkOperation stops a queued operation before its next chunk. Only
+	// its owner or a holder of bulk_operations:manage may; one that has
+	// already finished yields apperrors.ErrBulkOperationFinished.
+	CancelBulkOperation(id uint, userID uint, permissions models.PermissionSet) (*models.BulkOperation, error)
+	// RevertBulkOperation puts back the rows a finished queued update, delete
+	// or action changed, within the revert window, and names the rows it left
+	// alone. Only whoever ran it, or a holder of bulk_operations:manage, may
+	// revert it, and only once: apperrors.ErrBulkOperationReverted, or
+	// apperrors.ErrBulkRevertExpired once the window has passed.
+	RevertBulkOperation(id uint, userID uint, permissions models.PermissionSet) (*models.BulkRevertResult, error)
+	// RunNextQueued claims the oldest queued operation and runs it to the end.
+	// It reports whether there was one to run.
+	RunNextQueued() (bool, error)
+	// Queued receives whenever an operation is queued.
+	Queued() <-chan struct{}
+	// ReconcileInterrupted fails the operations a previous process left
+	// running and returns how many. Pending ones are left for the workers.
+	ReconcileInterrupted() (int64, error)
 	
 	// User bulk operations
 	BulkCreateUsers(request *models.BulkCreateRequest, currentUserID uint) (*models.BulkResponse, error)
@@ -211,9 +322,9 @@ type BulkOperationService interface {
 	
 	// Bulk status updates. All-or-nothing, authorized per record against the
 	// same rules the single-record update applies.
-	BulkSetLeadStatus(actorID uint, actorRole models.UserRole, ids []uint, status models.LeadStatus) (*models.BulkStatusUpdateResult, error)
-	BulkSetTicketStatus(actorID uint, actorRole models.UserRole, ids []uint, status models.TicketStatus) (*models.BulkStatusUpdateResult, error)
-	BulkSetTaskStatus(actorID uint, actorRole models.UserRole, ids []uint, status models.TaskStatus) (*models.BulkStatusUpdateResult, error)
+	BulkSetLeadStatus(actorID uint, permissions models.PermissionSet, ids []uint, status models.LeadStatus) (*models.BulkStatusUpdateResult, error)
+	BulkSetTicketStatus(actorID uint, permissions models.PermissionSet, ids []uint, status models.TicketStatus) (*models.BulkStatusUpdateResult, error)
+	BulkSetTaskStatus(actorID uint, permissions models.PermissionSet, ids []uint, status models.TaskStatus) (*models.BulkStatusUpdateResult, error)
 
 	// Ticket bulk operations
 	BulkCreateTickets(request *models.BulkCreateRequest, currentUserID uint) (*models.BulkResponse, error)
@@ -249,7 +360,7 @@ type AEOService interface {
 	// ListPrompts returns a page of prompts, each decorated with the answer
 	// count, mention count, visibility percentage and last-run timestamp for
 	// the [from, to) window.
-	ListPrompts(from, to time.Time, activeOnly bool, offset, limit int, sortBy, sortOrder string) ([]models.AEOPrompt, int64, error)
+	ListPrompts(from, to time.Time, filter models.AEOPromptFilter, offset, limit int, sortBy, sortOrder string) ([]models.AEOPrompt, int64, error)
 	// CreatePrompts is all-or-nothing: one duplicate or one prompt over the
 	// active-prompt cap saves none of them.
 	CreatePrompts(texts []string, createdByID uint) ([]models.AEOPrompt, error)
@@ -309,7 +420,11 @@ type FormService interface {
 	// row.
 	Update(id uint, form *models.Form) error
 	Delete(id uint) error
-	ListSubmissions(formID uint, offset, limit int, status string) ([]models.FormSubmission, int64, error)
+	ListSubmissions(formID uint, filter models.FormSubmissionFilter, offset, limit int) ([]models.FormSubmission, int64, error)
+	// StreamSubmissions hands every submission of a form matching filter to
+	// fn, unpaginated and newest first, for the admin-only export. An unknown
+	// form is ErrNotFound.
+	StreamSubmissions(formID uint, filter models.FormSubmissionFilter, fn func([]models.FormSubmission) error) error
 	GetSubmission(id uint) (*models.FormSubmission, error)
 
 	// PublicDefinition returns what a visitor's browser needs to render a
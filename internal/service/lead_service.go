@@ -15,14 +15,20 @@ type leadService struct {
 	leadRepo     repository.LeadRepository
 	customerRepo repository.CustomerRepository
 	txManager    *utils.TransactionManager
+	accountLinks
+	activityFeed
+	webhookEvents
 }
 
-func NewLeadService(leadRepo repository.LeadRepository, customerRepo repository.CustomerRepository, txManager *utils.TransactionManager) LeadService {
-	return &leadService{
+func NewLeadService(leadRepo repository.LeadRepository, customerRepo repository.CustomerRepository, accountRepo repository.AccountRepository, txManager *utils.TransactionManager, opts ...EntityOption) LeadService {
+	s := &leadService{
 		leadRepo:     leadRepo,
 		customerRepo: customerRepo,
 		txManager:    txManager,
+		accountLinks: accountLinks{accounts: accountRepo},
 	}
+	applyEntityOptions(&s.activityFeed, &s.webhookEvents, opts)
+	return s
 }
 
 func (s *leadService) Create(lead *models.Lead) error {
@@ -32,14 +38,24 @@ func (s *leadService) Create(lead *models.Lead) error {
 	if lead.Status == "" {
 		lead.Status = models.LeadStatusNew
 	}
+
+	accountID, err := s.linkNewContact(lead.AccountID, lead.Company)
+	if err != nil {
+		logger.WithError(err).Warn("Invalid lead account")
+		return err
+	}
+	lead.AccountID = accountID
 	
-	err := s.leadRepo.Create(lead)
+	err = s.leadRepo.Create(lead)
 	if err != nil {
 		logger.WithError(err).Error("Failed to create lead")
 		return err
 	}
 	
 	logger.WithField("lead_id", lead.ID).Info("Lead created successfully")
+	s.recordActivity(models.AuditEntityLead, lead.ID, models.ActivityLeadCreated, activityOwner(lead.OwnerID),
+		"New lead", describeLead(lead)+" was added as a lead")
+	s.publishWebhook(models.WebhookLeadCreated, models.AuditEntityLead, lead.ID, leadWebhookData(lead))
 	return nil
 }
 
@@ -98,6 +114,7 @@ func (s *leadService) Update(id uint, updates map[string]interface{}) (*models.L
 		logger.WithError(err).Error("Lead not found")
 		return nil, err
 	}
+	previousStatus, previousOwnerID := lead.Status, lead.OwnerID
 
 	// Apply updates
 	if firstName, ok := updates["first_name"].(string); ok {
@@ -136,6 +153,17 @@ func (s *leadService) Update(id uint, updates map[string]interface{}) (*models.L
 	if ownerID, ok := updates["owner_id"].(uint); ok {
 		lead.OwnerID = ownerID
 	}
+	if accountID, ok := updates["account_id"].(uint); ok {
+		linked, err := s.checkAccount(&accountID)
+		if err != nil {
+			logger.WithError(err).Warn("Invalid lead account")
+			return nil, err
+		}
+		lead.AccountID, lead.Account = linked, nil
+	}
+	if customFields, ok := updates["custom_fields"].(map[string]interface{}); ok {
+		lead.CustomFields = customFields
+	}
 
 	if err := s.leadRepo.Update(lead); err != nil {
 		logger.WithError(err).Error("Failed to update lead")
@@ -143,9 +171,49 @@ func (s *leadService) Update(id uint, updates map[string]interface{}) (*models.L
 	}
 
 	logger.Info("Lead updated successfully")
+	s.recordLeadUpdate(lead, previousStatus, previousOwnerID)
+	s.publishWebhook(models.WebhookLeadUpdated, models.AuditEntityLead, lead.ID, leadWebhookData(lead))
 	return lead, nil
 }
 
+// recordLeadUpdate describes an update by what it changed: a status change and
+// a reassignment each get their own entry, and anything else is a plain edit.
+func (s *leadService) recordLeadUpdate(lead *models.Lead, previousStatus models.LeadStatus, previousOwnerID uint) {
+	name := describeLead(lead)
+	changed := false
+	if lead.Status != previousStatus {
+		s.recordActivity(models.AuditEntityLead, lead.ID, models.ActivityLeadStatusChanged, activityOwner(lead.OwnerID),
+			"Lead status changed", fmt.Sprintf("%s moved from %s to %s", name, previousStatus, lead.Status))
+		changed = true
+	}
+	if lead.OwnerID != previousOwnerID {
+		s.recordActivity(models.AuditEntityLead, lead.ID, models.ActivityLeadReassigned, activityOwner(lead.OwnerID),
+			"Lead reassigned", name+" was reassigned")
+		changed = true
+	}
+	if !changed {
+		s.recordActivity(models.AuditEntityLead, lead.ID, models.ActivityLeadUpdated, activityOwner(lead.OwnerID),
+			"Lead updated", name+" was updated")
+	}
+}
+
+// publishConversion tells subscribers which customer the lead became. lead is
+// the copy read before the conversion, so it is brought up to date first.
+func (s *leadService) publishConversion(lead *models.Lead, customer *models.Customer) {
+	converted := *lead
+	converted.Status = models.LeadStatusConverted
+	converted.CustomerID = &customer.ID
+	s.publishWebhook(models.WebhookLeadConverted, models.AuditEntityLead, lead.ID, map[string]interface{}{
+		"lead":     leadWebhookData(&converted),
+		"customer": customerWebhookData(customer),
+	})
+}
+
+// describeLead names a lead in the feed.
+func describeLead(lead *models.Lead) string {
+	return describePerson(lead.FirstName, lead.LastName, lead.Company, "Unnamed lead")
+}
+
 // Delete erases the lead's personal data and then soft-deletes the row. It is a
 // GDPR Article 17 erasure and it is IRREVERSIBLE: the email becomes a
 // non-routable placeholder and the names, phone, company, position, external id
@@ -163,7 +231,8 @@ func (s *leadService) Delete(id uint) error {
 	// the soft delete both match by primary key, and zero matched rows is not an
 	// error in SQL. Reported as the not-found sentinel so the caller can tell
 	// "there was nobody to erase" from "the erasure failed".
-	if _, err := s.leadRepo.GetByID(id); err != nil {
+	lead, err := s.leadRepo.GetByID(id)
+	if err != nil {
 		if isNotFound(err) {
 			logger.WithError(err).Warn("Lead not found")
 			return fmt.Errorf("lead %d not found: %w", id, apperrors.ErrNotFound)
@@ -178,6 +247,10 @@ func (s *leadService) Delete(id uint) error {
 	}
 
 	logger.Info("Lead erased successfully")
+	// The entry must not name the person it records the erasure of.
+	s.recordActivity(models.AuditEntityLead, id, models.ActivityLeadDeleted, activityOwner(lead.OwnerID),
+		"Lead erased", "A lead was erased")
+	s.publishWebhook(models.WebhookLeadDeleted, models.AuditEntityLead, id, deletedWebhookData(id))
 	return nil
 }
 
@@ -251,6 +324,53 @@ func (s *leadService) Search(query string, offset, limit int, sortBy, sortOrder
 	return leads, total, nil
 }
 
+// ListFiltered returns one page of the leads matching the filter, custom
+// field conditions included.
+func (s *leadService) ListFiltered(filter models.LeadFilter, offset, limit int) ([]models.Lead, int64, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithFields(map[string]interface{}{
+		"search": filter.Search,
+		"offset": offset,
+		"limit":  limit,
+	}), "LeadService", "ListFiltered")
+
+	leads, total, err := s.leadRepo.ListFiltered(filter, offset, limit, "Owner")
+	if err != nil {
+		logger.WithError(err).Error("Failed to list filtered leads")
+		return nil, 0, err
+	}
+
+	logger.WithField("total", total).Info("Filtered lead list completed")
+	return leads, total, nil
+}
+
+// StreamExport hands every lead matching the filter to fn, unpaginated, a batch
+// at a time as it is read.
+//
+// The caller is expected to be an admin-only export: this is a bulk read, so
+// it is deliberately a distinct method rather than "ListFiltered with a very
+// large limit", and the log below records that a full export was taken — or
+// that one stopped part of the way through.
+func (s *leadService) StreamExport(filter models.LeadFilter, fn func([]models.Lead) error) error {
+	logger := utils.LogServiceCall(utils.Logger.WithFields(map[string]interface{}{
+		"search":     filter.Search,
+		"sort_by":    filter.SortBy,
+		"sort_order": filter.SortOrder,
+	}), "LeadService", "StreamExport")
+
+	count := 0
+	err := s.leadRepo.StreamFiltered(filter, func(batch []models.Lead) error {
+		count += len(batch)
+		return fn(batch)
+	})
+	if err != nil {
+		logger.WithError(err).WithField("count", count).Error("Failed to export leads")
+		return err
+	}
+
+	logger.WithField("count", count).Info("Lead export produced")
+	return nil
+}
+
 // ConvertToCustomer promotes a lead to a customer.
 //
 // The lead's personal data is COPIED into the new customer and the lead row is
@@ -315,6 +435,9 @@ func (s *leadService) ConvertToCustomer(leadID uint, customerData *models.Custom
 		if customerData.Company == "" {
 			customerData.Company = txLead.Company
 		}
+		if customerData.AccountID == nil {
+			customerData.AccountID = txLead.AccountID
+		}
 
 		// Create customer within transaction
 		if err := txCustomerRepo.Create(customerData); err != nil {
@@ -341,6 +464,9 @@ func (s *leadService) ConvertToCustomer(leadID uint, customerData *models.Custom
 		"customer_id": convertedCustomer.ID,
 		"lead_email":  lead.Email,
 	}).Info("Lead converted to customer successfully")
+	s.recordActivity(models.AuditEntityLead, lead.ID, models.ActivityLeadConverted, activityOwner(lead.OwnerID),
+		"Lead converted", describeLead(lead)+" was converted to a customer")
+	s.publishConversion(lead, convertedCustomer)
 	
 	return convertedCustomer, nil
 }
@@ -45,7 +45,7 @@ func (suite *LeadServiceTestSuite) SetupSuite() {
 func (suite *LeadServiceTestSuite) SetupTest() {
 	suite.mockLeadRepo = new(mocks.LeadRepository)
 	suite.mockCustomerRepo = new(mocks.CustomerRepository)
-	suite.leadService = NewLeadService(suite.mockLeadRepo, suite.mockCustomerRepo, suite.txManager)
+	suite.leadService = NewLeadService(suite.mockLeadRepo, suite.mockCustomerRepo, nil, suite.txManager)
 }
 
 func (suite *LeadServiceTestSuite) TearDownTest() {
@@ -96,6 +96,31 @@ func (suite *LeadServiceTestSuite) TestCreate_WithCustomStatus() {
 	assert.Equal(suite.T(), models.LeadStatusContacted, lead.Status)
 }
 
+func (suite *LeadServiceTestSuite) TestCreate_LinksTheAccountItsCompanyNames() {
+	accounts := new(mocks.AccountRepository)
+	defer accounts.AssertExpectations(suite.T())
+	leadService := NewLeadService(suite.mockLeadRepo, suite.mockCustomerRepo, accounts, suite.txManager)
+	accounts.On("GetByNameKey", "acme").Return(&models.Account{BaseModel: models.BaseModel{ID: 4}}, nil)
+	suite.mockLeadRepo.On("Create", mock.MatchedBy(func(l *models.Lead) bool {
+		return l.AccountID != nil && *l.AccountID == 4
+	})).Return(nil)
+
+	err := leadService.Create(&models.Lead{FirstName: "John", LastName: "Doe", Email: "john@example.com", Company: "ACME Inc.", OwnerID: 1})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *LeadServiceTestSuite) TestCreate_UnknownAccount() {
+	accounts := new(mocks.AccountRepository)
+	defer accounts.AssertExpectations(suite.T())
+	leadService := NewLeadService(suite.mockLeadRepo, suite.mockCustomerRepo, accounts, suite.txManager)
+	accounts.On("GetByID", uint(4)).Return(nil, gorm.ErrRecordNotFound)
+
+	accountID := uint(4)
+	err := leadService.Create(&models.Lead{FirstName: "John", LastName: "Doe", Email: "john@example.com", OwnerID: 1, AccountID: &accountID})
+	assert.ErrorIs(suite.T(), err, apperrors.ErrValidation)
+	suite.mockLeadRepo.AssertNotCalled(suite.T(), "Create", mock.Anything)
+}
+
 func (suite *LeadServiceTestSuite) TestCreate_Error() {
 	lead := &models.Lead{
 		FirstName: "John",
@@ -49,6 +49,21 @@ func (_m *APIKeyService) Generate(userID uint, name string, expiresAt *time.Time
 	return r0, r1, r2
 }
 
+// GenerateRestricted provides a mock function with given fields: userID, name, expiresAt, restrictions
+func (_m *APIKeyService) GenerateRestricted(userID uint, name string, expiresAt *time.Time, restrictions models.APIKeyRestrictions) (string, *models.APIKey, error) {
+	ret := _m.Called(userID, name, expiresAt, restrictions)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GenerateRestricted")
+	}
+
+	var r1 *models.APIKey
+	if ret.Get(1) != nil {
+		r1 = ret.Get(1).(*models.APIKey)
+	}
+	return ret.String(0), r1, ret.Error(2)
+}
+
 // GetByID provides a mock function with given fields: id, userID
 func (_m *APIKeyService) GetByID(id uint, userID uint) (*models.APIKey, error) {
 	ret := _m.Called(id, userID)
@@ -187,6 +202,32 @@ func (_m *APIKeyService) List(userID uint) ([]models.APIKey, error) {
 	return r0, r1
 }
 
+// ListUsage provides a mock function with given fields: id, userID, anyKey, offset, limit
+func (_m *APIKeyService) ListUsage(id uint, userID uint, anyKey bool, offset int, limit int) ([]models.APIKeyUsage, int64, error) {
+	ret := _m.Called(id, userID, anyKey, offset, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListUsage")
+	}
+
+	var r0 []models.APIKeyUsage
+	if ret.Get(0) != nil {
+		r0 = ret.Get(0).([]models.APIKeyUsage)
+	}
+	return r0, ret.Get(1).(int64), ret.Error(2)
+}
+
+// RecordUsage provides a mock function with given fields: usage
+func (_m *APIKeyService) RecordUsage(usage *models.APIKeyUsage) error {
+	ret := _m.Called(usage)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordUsage")
+	}
+
+	return ret.Error(0)
+}
+
 // NewAPIKeyService creates a new instance of APIKeyService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 func NewAPIKeyService(t interface {
 	mock.TestingT
@@ -436,6 +436,24 @@ func (_m *LeadService) Search(query string, offset int, limit int, sortBy string
 	return r0, r1, r2
 }
 
+// StreamExport provides a mock function with given fields: filter, fn
+func (_m *LeadService) StreamExport(filter models.LeadFilter, fn func([]models.Lead) error) error {
+	ret := _m.Called(filter, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamExport")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.LeadFilter, func([]models.Lead) error) error); ok {
+		r0 = rf(filter, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: id, updates
 func (_m *LeadService) Update(id uint, updates map[string]interface{}) (*models.Lead, error) {
 	ret := _m.Called(id, updates)
@@ -479,3 +497,40 @@ func NewLeadService(t interface {
 
 	return mock
 }
+
+// ListFiltered provides a mock function with given fields: filter, offset, limit
+func (_m *LeadService) ListFiltered(filter models.LeadFilter, offset int, limit int) ([]models.Lead, int64, error) {
+	ret := _m.Called(filter, offset, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListFiltered")
+	}
+
+	var r0 []models.Lead
+	var r1 int64
+	var r2 error
+	if rf, ok := ret.Get(0).(func(models.LeadFilter, int, int) ([]models.Lead, int64, error)); ok {
+		return rf(filter, offset, limit)
+	}
+	if rf, ok := ret.Get(0).(func(models.LeadFilter, int, int) []models.Lead); ok {
+		r0 = rf(filter, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Lead)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(models.LeadFilter, int, int) int64); ok {
+		r1 = rf(filter, offset, limit)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if rf, ok := ret.Get(2).(func(models.LeadFilter, int, int) error); ok {
+		r2 = rf(filter, offset, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
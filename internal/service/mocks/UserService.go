@@ -145,6 +145,24 @@ func (_m *UserService) Register(user *models.User, password string) error {
 	return r0
 }
 
+// StreamExport provides a mock function with given fields: filter, fn
+func (_m *UserService) StreamExport(filter models.UserFilter, fn func([]models.User) error) error {
+	ret := _m.Called(filter, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamExport")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.UserFilter, func([]models.User) error) error); ok {
+		r0 = rf(filter, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: id, updates
 func (_m *UserService) Update(id uint, updates map[string]interface{}) (*models.User, error) {
 	ret := _m.Called(id, updates)
@@ -212,6 +230,43 @@ func (_m *UserService) ListSorted(offset int, limit int, sortBy string, sortOrde
 	return r0, r1, r2
 }
 
+// ListFiltered provides a mock function with given fields: filter, offset, limit
+func (_m *UserService) ListFiltered(filter models.UserFilter, offset int, limit int) ([]models.User, int64, error) {
+	ret := _m.Called(filter, offset, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListFiltered")
+	}
+
+	var r0 []models.User
+	var r1 int64
+	var r2 error
+	if rf, ok := ret.Get(0).(func(models.UserFilter, int, int) ([]models.User, int64, error)); ok {
+		return rf(filter, offset, limit)
+	}
+	if rf, ok := ret.Get(0).(func(models.UserFilter, int, int) []models.User); ok {
+		r0 = rf(filter, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(models.UserFilter, int, int) int64); ok {
+		r1 = rf(filter, offset, limit)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if rf, ok := ret.Get(2).(func(models.UserFilter, int, int) error); ok {
+		r2 = rf(filter, offset, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // Search provides a mock function with given fields: query, offset, limit, sortBy, sortOrder
 func (_m *UserService) Search(query string, offset int, limit int, sortBy string, sortOrder string) ([]models.User, int64, error) {
 	ret := _m.Called(query, offset, limit, sortBy, sortOrder)
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+)
+
+// RoleService manages the roles users are given, and resolves the role of an
+// authenticated user into what it may do. The permissions of the four
+// built-in roles live in the code (models.BuiltInPermissions); admins define
+// custom roles on top of them.
+type RoleService interface {
+	// List returns every role, the built-in ones first.
+	List() ([]models.Role, error)
+	Get(name string) (*models.Role, error)
+	// Create validates the role with ValidateRole and refuses a taken name
+	// with apperrors.ErrValidation.
+	Create(role *models.Role) error
+	// Update changes the description, base role and permissions of a custom
+	// role; its name is fixed. The built-in roles are apperrors.ErrBuiltInRole.
+	Update(role *models.Role) (*models.Role, error)
+	// Delete deletes a custom role no user holds: apperrors.ErrRoleInUse
+	// otherwise, and apperrors.ErrBuiltInRole for a built-in one.
+	Delete(name string) error
+	// AssignUser gives the user a built-in or custom role. A role that does
+	// not exist is apperrors.ErrValidation.
+	AssignUser(userID uint, name string) (*models.User, error)
+	// Resolve returns the base role and the permissions of the role a user
+	// holds. A built-in role is resolved without reading the store; a role
+	// that no longer exists is apperrors.ErrNotFound.
+	Resolve(role models.UserRole) (models.UserRole, models.PermissionSet, error)
+}
+
+type roleService struct {
+	repo repository.RoleRepository
+}
+
+func NewRoleService(repo repository.RoleRepository) RoleService {
+	return &roleService{repo: repo}
+}
+
+func (s *roleService) List() ([]models.Role, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("entity", "role"), "RoleService", "List")
+
+	roles, err := s.repo.List()
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (s *roleService) Get(name string) (*models.Role, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("role", name), "RoleService", "Get")
+
+	role, err := s.repo.GetByName(name)
+	if err != nil {
+		if isNotFound(err) {
+			logger.Warn("Role not found")
+			return nil, fmt.Errorf("role %q: %w", name, apperrors.ErrNotFound)
+		}
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	return role, nil
+}
+
+func (s *roleService) Create(role *models.Role) error {
+	logger := utils.LogServiceCall(utils.Logger.WithField("role", role.Name), "RoleService", "Create")
+
+	role.ID = 0
+	role.BuiltIn = false
+	if err := role.ValidateRole(); err != nil {
+		logger.WithError(err).Warn("Invalid role")
+		return fmt.Errorf("%w: %w", err, apperrors.ErrValidation)
+	}
+	if err := s.repo.Create(role); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+	logger.Info("Role created")
+	return nil
+}
+
+func (s *roleService) Update(role *models.Role) (*models.Role, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("role", role.Name), "RoleService", "Update")
+
+	existing, err := s.Get(role.Name)
+	if err != nil {
+		return nil, err
+	}
+	if existing.BuiltIn {
+		logger.Warn("Attempt to change a built-in role")
+		return nil, fmt.Errorf("role %q: %w", role.Name, apperrors.ErrBuiltInRole)
+	}
+	role.ID = existing.ID
+	role.CreatedAt = existing.CreatedAt
+	role.BuiltIn = false
+	if err := role.ValidateRole(); err != nil {
+		logger.WithError(err).Warn("Invalid role")
+		return nil, fmt.Errorf("%w: %w", err, apperrors.ErrValidation)
+	}
+	if err := s.repo.Update(role); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	logger.Info("Role updated")
+	return role, nil
+}
+
+func (s *roleService) Delete(name string) error {
+	logger := utils.LogServiceCall(utils.Logger.WithField("role", name), "RoleService", "Delete")
+
+	if models.UserRole(name).IsBuiltIn() {
+		logger.Warn("Attempt to delete a built-in role")
+		return fmt.Errorf("role %q: %w", name, apperrors.ErrBuiltInRole)
+	}
+	if err := s.repo.Delete(name); err != nil {
+		if isNotFound(err) {
+			logger.Warn("Role not found")
+			return fmt.Errorf("role %q: %w", name, apperrors.ErrNotFound)
+		}
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+	logger.Info("Role deleted")
+	return nil
+}
+
+func (s *roleService) AssignUser(userID uint, name string) (*models.User, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("user_id", userID).WithField("role", name), "RoleService", "AssignUser")
+
+	if !models.UserRole(name).IsBuiltIn() {
+		if _, err := s.Get(name); err != nil {
+			if errors.Is(err, apperrors.ErrNotFound) {
+				return nil, fmt.Errorf("there is no role named %q: %w", name, apperrors.ErrValidation)
+			}
+			return nil, err
+		}
+	}
+	user, err := s.repo.AssignUser(userID, name)
+	if err != nil {
+		if isNotFound(err) {
+			logger.Warn("User not found")
+			return nil, fmt.Errorf("user %d: %w", userID, apperrors.ErrNotFound)
+		}
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	logger.Info("Role assigned")
+	return user, nil
+}
+
+// userPermissions is embedded by the services that hand records to users and
+// must check the user may work them: whoever their role, built-in or custom.
+type userPermissions struct {
+	roles RoleService
+}
+
+// userAllows reports whether the role user holds grants p. Without a role
+// service only the built-in roles are known, and a custom role grants
+// nothing; so does a role that no longer exists.
+func (u *userPermissions) userAllows(user *models.User, p models.Permission) (bool, error) {
+	if u.roles == nil {
+		return models.BuiltInPermissions(user.Role).Allows(p), nil
+	}
+	_, permissions, err := u.roles.Resolve(user.Role)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return permissions.Allows(p), nil
+}
+
+func (s *roleService) Resolve(role models.UserRole) (models.UserRole, models.PermissionSet, error) {
+	if role.IsBuiltIn() {
+		return role, models.BuiltInPermissions(role), nil
+	}
+	custom, err := s.repo.GetByName(string(role))
+	if err != nil {
+		if isNotFound(err) {
+			return "", nil, fmt.Errorf("role %q: %w", role, apperrors.ErrNotFound)
+		}
+		return "", nil, err
+	}
+	return custom.BaseRole, custom.PermissionSet(), nil
+}
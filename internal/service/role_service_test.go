@@ -0,0 +1,124 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type RoleServiceTestSuite struct {
+	suite.Suite
+	mockRepo *mocks.RoleRepository
+	service  RoleService
+}
+
+func (suite *RoleServiceTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+}
+
+func (suite *RoleServiceTestSuite) SetupTest() {
+	suite.mockRepo = new(mocks.RoleRepository)
+	suite.service = NewRoleService(suite.mockRepo)
+}
+
+func (suite *RoleServiceTestSuite) TearDownTest() {
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+func (suite *RoleServiceTestSuite) TestCreate_Success() {
+	role := &models.Role{ID: 9, Name: "sales-lead", BaseRole: models.RoleSales, BuiltIn: true,
+		Permissions: []models.Permission{models.PermLeadsReadAny}}
+
+	suite.mockRepo.On("Create", mock.MatchedBy(func(r *models.Role) bool {
+		return r.ID == 0 && !r.BuiltIn
+	})).Return(nil)
+
+	err := suite.service.Create(role)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *RoleServiceTestSuite) TestCreate_InvalidRole() {
+	err := suite.service.Create(&models.Role{Name: "admin", BaseRole: models.RoleAdmin})
+	assert.True(suite.T(), errors.Is(err, apperrors.ErrValidation))
+	assert.True(suite.T(), errors.Is(err, models.ErrInvalidRole))
+}
+
+func (suite *RoleServiceTestSuite) TestUpdate_BuiltInRole() {
+	suite.mockRepo.On("GetByName", "sales").Return(&models.Role{ID: 2, Name: "sales", BaseRole: models.RoleSales, BuiltIn: true}, nil)
+
+	_, err := suite.service.Update(&models.Role{Name: "sales", BaseRole: models.RoleSales})
+	assert.True(suite.T(), errors.Is(err, apperrors.ErrBuiltInRole))
+}
+
+func (suite *RoleServiceTestSuite) TestUpdate_Success() {
+	existing := &models.Role{ID: 7, Name: "auditor", BaseRole: models.RoleCustomer}
+	suite.mockRepo.On("GetByName", "auditor").Return(existing, nil)
+	suite.mockRepo.On("Update", mock.MatchedBy(func(r *models.Role) bool {
+		return r.ID == 7 && r.BaseRole == models.RoleSupport && len(r.Permissions) == 1
+	})).Return(nil)
+
+	updated, err := suite.service.Update(&models.Role{Name: "auditor", BaseRole: models.RoleSupport,
+		Permissions: []models.Permission{models.PermAuditRead}})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), uint(7), updated.ID)
+}
+
+func (suite *RoleServiceTestSuite) TestDelete() {
+	err := suite.service.Delete("admin")
+	assert.True(suite.T(), errors.Is(err, apperrors.ErrBuiltInRole), "built-in roles never reach the store")
+
+	suite.mockRepo.On("Delete", "auditor").Return(apperrors.ErrRoleInUse).Once()
+	err = suite.service.Delete("auditor")
+	assert.True(suite.T(), errors.Is(err, apperrors.ErrRoleInUse))
+
+	suite.mockRepo.On("Delete", "ghost").Return(gorm.ErrRecordNotFound).Once()
+	err = suite.service.Delete("ghost")
+	assert.True(suite.T(), errors.Is(err, apperrors.ErrNotFound))
+}
+
+func (suite *RoleServiceTestSuite) TestAssignUser() {
+	suite.mockRepo.On("GetByName", "ghost").Return(nil, gorm.ErrRecordNotFound).Once()
+	_, err := suite.service.AssignUser(3, "ghost")
+	assert.True(suite.T(), errors.Is(err, apperrors.ErrValidation), "an unknown role is the request's fault")
+
+	suite.mockRepo.On("AssignUser", uint(3), "support").Return(&models.User{BaseModel: models.BaseModel{ID: 3}, Role: models.RoleSupport}, nil).Once()
+	user, err := suite.service.AssignUser(3, "support")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), models.RoleSupport, user.Role)
+
+	suite.mockRepo.On("AssignUser", uint(4), "support").Return(nil, gorm.ErrRecordNotFound).Once()
+	_, err = suite.service.AssignUser(4, "support")
+	assert.True(suite.T(), errors.Is(err, apperrors.ErrNotFound))
+}
+
+func (suite *RoleServiceTestSuite) TestResolve() {
+	base, permissions, err := suite.service.Resolve(models.RoleSupport)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), models.RoleSupport, base)
+	assert.True(suite.T(), permissions.Allows(models.PermTicketsAssign))
+
+	suite.mockRepo.On("GetByName", "auditor").Return(&models.Role{Name: "auditor", BaseRole: models.RoleCustomer,
+		Permissions: []models.Permission{models.PermAuditRead}}, nil).Once()
+	base, permissions, err = suite.service.Resolve("auditor")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), models.RoleCustomer, base)
+	assert.True(suite.T(), permissions.Allows(models.PermAuditRead))
+	assert.False(suite.T(), permissions.Allows(models.PermTasksUpdateOwn), "a custom role holds only its own permissions")
+
+	suite.mockRepo.On("GetByName", "ghost").Return(nil, gorm.ErrRecordNotFound).Once()
+	_, _, err = suite.service.Resolve("ghost")
+	assert.True(suite.T(), errors.Is(err, apperrors.ErrNotFound))
+}
+
+func TestRoleServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(RoleServiceTestSuite))
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+)
+
+// customFieldSortPrefix marks a sort on a custom field, as in ?sort_by=cf.x.
+const customFieldSortPrefix = "cf."
+
+// SavedViewService manages the saved views of the lead, customer, ticket and
+// task lists. A view is visible to its owner, to every user of the role it is
+// shared with, and to holders of saved_views:manage; a view the caller cannot
+// see is reported as
+// apperrors.ErrNotFound, never as forbidden, so that its existence does not
+// leak.
+type SavedViewService interface {
+	// List returns the caller's own views and those shared with their role,
+	// of one entity type or of every entity type when entityType is empty.
+	List(entityType string, userID uint, role models.UserRole) ([]models.SavedView, error)
+	Get(id, userID uint, role models.UserRole, permissions models.PermissionSet) (*models.SavedView, error)
+	// Create validates the view and saves it as owned by view.OwnerID. Only
+	// saved_views:manage shares a view with a role other than the caller's.
+	Create(view *models.SavedView, role models.UserRole, permissions models.PermissionSet) error
+	// Update saves changes to a view its owner or a holder of
+	// saved_views:manage made; anyone else who can see it gets
+	// apperrors.ErrForbidden. The owner and entity type are fixed.
+	Update(view *models.SavedView, userID uint, role models.UserRole, permissions models.PermissionSet) (*models.SavedView, error)
+	// Delete deletes a view; only its owner and holders of saved_views:manage
+	// may.
+	Delete(id, userID uint, role models.UserRole, permissions models.PermissionSet) error
+
+	// Resolve returns the view a list of entityType is run with. A view of
+	// another entity type is apperrors.ErrValidation.
+	Resolve(id uint, entityType string, userID uint, role models.UserRole, permissions models.PermissionSet) (*models.SavedView, error)
+}
+
+type savedViewService struct {
+	repo repository.SavedViewRepository
+}
+
+func NewSavedViewService(repo repository.SavedViewRepository) SavedViewService {
+	return &savedViewService{repo: repo}
+}
+
+func (s *savedViewService) List(entityType string, userID uint, role models.UserRole) ([]models.SavedView, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("user_id", userID), "SavedViewService", "List")
+
+	if _, ok := models.SavedViewEntityTables[entityType]; entityType != "" && !ok {
+		logger.Warn("Unknown saved view entity type")
+		return nil, fmt.Errorf("entity type %q has no saved views: %w", entityType, apperrors.ErrValidation)
+	}
+	views, err := s.repo.ListVisible(entityType, userID, role)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	return views, nil
+}
+
+func (s *savedViewService) Get(id, userID uint, role models.UserRole, permissions models.PermissionSet) (*models.SavedView, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("saved_view_id", id), "SavedViewService", "Get")
+
+	view, err := s.repo.GetByID(id)
+	if err != nil {
+		if isNotFound(err) {
+			logger.Warn("Saved view not found")
+			return nil, fmt.Errorf("saved view %d: %w", id, apperrors.ErrNotFound)
+		}
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	if !view.VisibleTo(userID, role, permissions) {
+		logger.WithField("user_id", userID).Warn("Saved view not visible to user")
+		return nil, fmt.Errorf("saved view %d: %w", id, apperrors.ErrNotFound)
+	}
+	return view, nil
+}
+
+func (s *savedViewService) Create(view *models.SavedView, role models.UserRole, permissions models.PermissionSet) error {
+	logger := utils.LogServiceCall(utils.Logger.WithField("user_id", view.OwnerID), "SavedViewService", "Create")
+
+	if err := validateSavedView(view); err != nil {
+		logger.WithError(err).Warn("Invalid saved view")
+		return err
+	}
+	if err := validateSharing(view.SharedWithRole, role, permissions); err != nil {
+		logger.WithError(err).Warn("Invalid saved view sharing")
+		return err
+	}
+	if err := s.repo.Create(view); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+	logger.WithField("saved_view_id", view.ID).Info("Saved view created")
+	return nil
+}
+
+func (s *savedViewService) Update(view *models.SavedView, userID uint, role models.UserRole, permissions models.PermissionSet) (*models.SavedView, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("saved_view_id", view.ID), "SavedViewService", "Update")
+
+	existing, err := s.Get(view.ID, userID, role, permissions)
+	if err != nil {
+		return nil, err
+	}
+	if !existing.EditableBy(userID, permissions) {
+		logger.WithField("user_id", userID).Warn("Attempt to change another user's saved view")
+		return nil, fmt.Errorf("only the owner of a saved view can change it: %w", apperrors.ErrForbidden)
+	}
+	if view.OwnerID != existing.OwnerID || view.EntityType != existing.EntityType {
+		logger.Warn("Attempt to change a fixed saved view attribute")
+		return nil, fmt.Errorf("the owner and entity type of a saved view cannot be changed: %w", apperrors.ErrValidation)
+	}
+	if err := validateSavedView(view); err != nil {
+		logger.WithError(err).Warn("Invalid saved view")
+		return nil, err
+	}
+	// A view keeps the sharing a manager gave it when its owner edits it.
+	if view.SharedWithRole != existing.SharedWithRole {
+		if err := validateSharing(view.SharedWithRole, role, permissions); err != nil {
+			logger.WithError(err).Warn("Invalid saved view sharing")
+			return nil, err
+		}
+	}
+	view.CreatedAt = existing.CreatedAt
+
+	if err := s.repo.Update(view); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	logger.Info("Saved view updated")
+	return view, nil
+}
+
+func (s *savedViewService) Delete(id, userID uint, role models.UserRole, permissions models.PermissionSet) error {
+	logger := utils.LogServiceCall(utils.Logger.WithField("saved_view_id", id), "SavedViewService", "Delete")
+
+	existing, err := s.Get(id, userID, role, permissions)
+	if err != nil {
+		return err
+	}
+	if !existing.EditableBy(userID, permissions) {
+		logger.WithField("user_id", userID).Warn("Attempt to delete another user's saved view")
+		return fmt.Errorf("only the owner of a saved view can delete it: %w", apperrors.ErrForbidden)
+	}
+	if err := s.repo.Delete(id); err != nil {
+		if isNotFound(err) {
+			return fmt.Errorf("saved view %d: %w", id, apperrors.ErrNotFound)
+		}
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+	logger.Info("Saved view deleted")
+	return nil
+}
+
+func (s *savedViewService) Resolve(id uint, entityType string, userID uint, role models.UserRole, permissions models.PermissionSet) (*models.SavedView, error) {
+	view, err := s.Get(id, userID, role, permissions)
+	if err != nil {
+		return nil, err
+	}
+	if view.EntityType != entityType {
+		return nil, fmt.Errorf("saved view %d is a %s view, not a %s view: %w", id, view.EntityType, entityType, apperrors.ErrValidation)
+	}
+	return view, nil
+}
+
+// validateSavedView checks a view against the entity it lists, so that a
+// saved view always runs: its filter parses and its sort is a sortable column
+// or a custom field. It normalises the sort order and trims the name. Custom
+// field sorts are only checked when the view runs, since the field may be
+// deleted after the view is saved anyway.
+func validateSavedView(view *models.SavedView) error {
+	table, ok := models.SavedViewEntityTables[view.EntityType]
+	if !ok {
+		return fmt.Errorf("entity type %q has no saved views: %w", view.EntityType, apperrors.ErrValidation)
+	}
+	view.Name = strings.TrimSpace(view.Name)
+	if view.Name == "" {
+		return fmt.Errorf("a saved view needs a name: %w", apperrors.ErrValidation)
+	}
+	if _, err := utils.ParseFilter(table, view.Filter); err != nil {
+		return err
+	}
+
+	switch view.SortOrder {
+	case "":
+		if view.SortBy != "" {
+			view.SortOrder = "asc"
+		}
+	case "asc", "desc":
+	default:
+		return fmt.Errorf("sort order must be asc or desc: %w", apperrors.ErrValidation)
+	}
+	if field, ok := strings.CutPrefix(view.SortBy, customFieldSortPrefix); ok {
+		if field == "" {
+			return fmt.Errorf("a custom field sort needs a field name: %w", apperrors.ErrValidation)
+		}
+	} else if view.SortBy != "" {
+		if _, _, err := utils.ValidateSort(table, view.SortBy, view.SortOrder); err != nil {
+			return fmt.Errorf("%w: %w", err, apperrors.ErrValidation)
+		}
+	}
+
+	if len(view.Columns) > models.SavedViewMaxColumns {
+		return fmt.Errorf("a saved view cannot show more than %d columns: %w", models.SavedViewMaxColumns, apperrors.ErrValidation)
+	}
+	seen := make(map[string]bool, len(view.Columns))
+	for _, column := range view.Columns {
+		if strings.TrimSpace(column) == "" || len(column) > 100 {
+			return fmt.Errorf("column names must be between 1 and 100 characters: %w", apperrors.ErrValidation)
+		}
+		if seen[column] {
+			return fmt.Errorf("column %q is listed twice: %w", column, apperrors.ErrValidation)
+		}
+		seen[column] = true
+	}
+
+	return nil
+}
+
+// validateSharing checks that a user of role may share a view with
+// sharedWith: anyone with their own role, saved_views:manage with any role.
+func validateSharing(sharedWith, role models.UserRole, permissions models.PermissionSet) error {
+	switch sharedWith {
+	case "", role:
+		return nil
+	case models.RoleAdmin, models.RoleSales, models.RoleSupport, models.RoleCustomer:
+		if !permissions.Allows(models.PermSavedViewsManage) {
+			return fmt.Errorf("you can only share a view with your own role: %w", apperrors.ErrValidation)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown role %q: %w", sharedWith, apperrors.ErrValidation)
+	}
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type SavedViewServiceTestSuite struct {
+	suite.Suite
+	mockRepo *mocks.SavedViewRepository
+	service  SavedViewService
+}
+
+func (suite *SavedViewServiceTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+}
+
+func (suite *SavedViewServiceTestSuite) SetupTest() {
+	suite.mockRepo = new(mocks.SavedViewRepository)
+	suite.service = NewSavedViewService(suite.mockRepo)
+}
+
+func (suite *SavedViewServiceTestSuite) TearDownTest() {
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+// sharedView is a sales manager's view published to the whole sales role.
+func (suite *SavedViewServiceTestSuite) sharedView() *models.SavedView {
+	return &models.SavedView{ID: 7, OwnerID: 1, EntityType: models.AuditEntityLead, Name: "My hot leads this week",
+		Filter: "classification eq hot_lead and created_at gte -7d", SharedWithRole: models.RoleSales}
+}
+
+func (suite *SavedViewServiceTestSuite) TestCreate_Success() {
+	view := &models.SavedView{OwnerID: 1, EntityType: models.AuditEntityLead, Name: " Hot ",
+		Filter: "classification eq hot_lead", SortBy: "created_at", SharedWithRole: models.RoleSales}
+
+	suite.mockRepo.On("Create", mock.MatchedBy(func(v *models.SavedView) bool {
+		return v.Name == "Hot" && v.SortOrder == "asc"
+	})).Return(nil)
+
+	assert.NoError(suite.T(), suite.service.Create(view, models.RoleSales, models.BuiltInPermissions(models.RoleSales)))
+}
+
+func (suite *SavedViewServiceTestSuite) TestCreate_Invalid() {
+	for name, view := range map[string]models.SavedView{
+		"unknown entity":        {EntityType: "deal", Name: "x"},
+		"blank name":            {EntityType: models.AuditEntityLead, Name: "  "},
+		"bad filter":            {EntityType: models.AuditEntityLead, Name: "x", Filter: "status eq"},
+		"unfilterable field":    {EntityType: models.AuditEntityLead, Name: "x", Filter: "notes eq secret"},
+		"unsortable column":     {EntityType: models.AuditEntityLead, Name: "x", SortBy: "notes"},
+		"bad sort order":        {EntityType: models.AuditEntityLead, Name: "x", SortBy: "email", SortOrder: "up"},
+		"empty custom field":    {EntityType: models.AuditEntityLead, Name: "x", SortBy: "cf."},
+		"duplicate column":      {EntityType: models.AuditEntityLead, Name: "x", Columns: []string{"email", "email"}},
+		"shared with other":     {EntityType: models.AuditEntityLead, Name: "x", SharedWithRole: models.RoleSupport},
+		"shared with bad role":  {EntityType: models.AuditEntityLead, Name: "x", SharedWithRole: "everyone"},
+		"ticket filter on lead": {EntityType: models.AuditEntityLead, Name: "x", Filter: "priority eq high"},
+	} {
+		view := view
+		err := suite.service.Create(&view, models.RoleSales, models.BuiltInPermissions(models.RoleSales))
+		assert.True(suite.T(), errors.Is(err, apperrors.ErrValidation), "%s: got %v", name, err)
+	}
+	suite.mockRepo.AssertNotCalled(suite.T(), "Create", mock.Anything)
+}
+
+func (suite *SavedViewServiceTestSuite) TestCreate_AdminSharesWithAnyRole() {
+	view := &models.SavedView{OwnerID: 9, EntityType: models.AuditEntityTicket, Name: "Urgent",
+		SortBy: "cf.region", SortOrder: "desc", SharedWithRole: models.RoleSupport}
+	suite.mockRepo.On("Create", view).Return(nil)
+
+	assert.NoError(suite.T(), suite.service.Create(view, models.RoleAdmin, models.BuiltInPermissions(models.RoleAdmin)))
+}
+
+func (suite *SavedViewServiceTestSuite) TestGet_Visibility() {
+	suite.mockRepo.On("GetByID", uint(7)).Return(suite.sharedView(), nil)
+
+	_, err := suite.service.Get(7, 2, models.RoleSales, models.BuiltInPermissions(models.RoleSales))
+	assert.NoError(suite.T(), err, "shared with the caller's role")
+	_, err = suite.service.Get(7, 3, models.RoleAdmin, models.BuiltInPermissions(models.RoleAdmin))
+	assert.NoError(suite.T(), err, "admins see every view")
+	_, err = suite.service.Get(7, 4, models.RoleSupport, models.BuiltInPermissions(models.RoleSupport))
+	assert.True(suite.T(), errors.Is(err, apperrors.ErrNotFound), "invisible views do not exist")
+	_, err = suite.service.Get(7, 4, models.RoleSupport, models.NewPermissionSet(models.PermSavedViewsManage))
+	assert.NoError(suite.T(), err, "saved_views:manage sees every view whatever the base role")
+}
+
+func (suite *SavedViewServiceTestSuite) TestGet_NotFound() {
+	suite.mockRepo.On("GetByID", uint(8)).Return(nil, gorm.ErrRecordNotFound)
+
+	_, err := suite.service.Get(8, 1, models.RoleSales, models.BuiltInPermissions(models.RoleSales))
+	assert.True(suite.T(), errors.Is(err, apperrors.ErrNotFound))
+}
+
+func (suite *SavedViewServiceTestSuite) TestUpdate_OnlyOwnerOrAdmin() {
+	suite.mockRepo.On("GetByID", uint(7)).Return(suite.sharedView(), nil)
+
+	changed := suite.sharedView()
+	changed.Name = "Renamed"
+	_, err := suite.service.Update(changed, 2, models.RoleSales, models.BuiltInPermissions(models.RoleSales))
+	assert.True(suite.T(), errors.Is(err, apperrors.ErrForbidden))
+
+	suite.mockRepo.On("Update", changed).Return(nil)
+	_, err = suite.service.Update(changed, 3, models.RoleAdmin, models.BuiltInPermissions(models.RoleAdmin))
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *SavedViewServiceTestSuite) TestUpdate_OwnerKeepsAdminSharing() {
+	existing := suite.sharedView()
+	existing.SharedWithRole = models.RoleSupport
+	suite.mockRepo.On("GetByID", uint(7)).Return(existing, nil)
+
+	changed := *existing
+	changed.Name = "Renamed"
+	suite.mockRepo.On("Update", &changed).Return(nil)
+	_, err := suite.service.Update(&changed, 1, models.RoleSales, models.BuiltInPermissions(models.RoleSales))
+	assert.NoError(suite.T(), err, "the owner may edit a view an admin shared with another role")
+
+	resharing := *existing
+	resharing.SharedWithRole = models.RoleCustomer
+	_, err = suite.service.Update(&resharing, 1, models.RoleSales, models.BuiltInPermissions(models.RoleSales))
+	assert.True(suite.T(), errors.Is(err, apperrors.ErrValidation), "but not share it elsewhere")
+}
+
+func (suite *SavedViewServiceTestSuite) TestUpdate_FixedAttributes() {
+	suite.mockRepo.On("GetByID", uint(7)).Return(suite.sharedView(), nil)
+
+	changed := suite.sharedView()
+	changed.EntityType = models.AuditEntityTask
+	_, err := suite.service.Update(changed, 1, models.RoleSales, models.BuiltInPermissions(models.RoleSales))
+	assert.True(suite.T(), errors.Is(err, apperrors.ErrValidation))
+	suite.mockRepo.AssertNotCalled(suite.T(), "Update", mock.Anything)
+}
+
+func (suite *SavedViewServiceTestSuite) TestDelete() {
+	suite.mockRepo.On("GetByID", uint(7)).Return(suite.sharedView(), nil)
+
+	err := suite.service.Delete(7, 2, models.RoleSales, models.BuiltInPermissions(models.RoleSales))
+	assert.True(suite.T(), errors.Is(err, apperrors.ErrForbidden))
+
+	suite.mockRepo.On("Delete", uint(7)).Return(nil)
+	assert.NoError(suite.T(), suite.service.Delete(7, 1, models.RoleSales, models.BuiltInPermissions(models.RoleSales)))
+}
+
+func (suite *SavedViewServiceTestSuite) TestResolve() {
+	suite.mockRepo.On("GetByID", uint(7)).Return(suite.sharedView(), nil)
+
+	view, err := suite.service.Resolve(7, models.AuditEntityLead, 2, models.RoleSales, models.BuiltInPermissions(models.RoleSales))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), uint(7), view.ID)
+
+	_, err = suite.service.Resolve(7, models.AuditEntityTask, 2, models.RoleSales, models.BuiltInPermissions(models.RoleSales))
+	assert.True(suite.T(), errors.Is(err, apperrors.ErrValidation), "a lead view cannot run on tasks")
+}
+
+func (suite *SavedViewServiceTestSuite) TestList_UnknownEntity() {
+	_, err := suite.service.List("deal", 1, models.RoleSales)
+	assert.True(suite.T(), errors.Is(err, apperrors.ErrValidation))
+}
+
+func TestSavedViewServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(SavedViewServiceTestSuite))
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+)
+
+// Limits on a search query.
+const (
+	MaxSearchQueryLength = 200
+	MaxSearchTerms       = 10
+)
+
+// SearchService is the global search across leads, customers, tickets, tasks
+// and form submissions.
+type SearchService interface {
+	// Search returns the best hits for q among entityTypes, or among every
+	// searchable type when entityTypes is empty. A user only finds the
+	// records the List of each entity shows them: an entity type their
+	// permissions cannot list is skipped, not refused. An empty or oversized query and an
+	// unknown entity type are apperrors.ErrValidation.
+	Search(q string, entityTypes []string, userID uint, permissions models.PermissionSet, limit int) ([]models.SearchHit, error)
+}
+
+type searchService struct {
+	repo repository.SearchRepository
+}
+
+func NewSearchService(repo repository.SearchRepository) SearchService {
+	return &searchService{repo: repo}
+}
+
+func (s *searchService) Search(q string, entityTypes []string, userID uint, permissions models.PermissionSet, limit int) ([]models.SearchHit, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("user_id", userID), "SearchService", "Search")
+
+	terms, err := searchTerms(q)
+	if err != nil {
+		logger.WithError(err).Warn("Invalid search query")
+		return nil, err
+	}
+	if len(entityTypes) == 0 {
+		entityTypes = models.SearchEntities
+	}
+	query := models.SearchQuery{Terms: terms, Limit: limit}
+	for _, entity := range entityTypes {
+		if !models.IsSearchEntity(entity) {
+			logger.WithField("entity_type", entity).Warn("Unknown search entity type")
+			return nil, fmt.Errorf("entity type %q is not searchable: %w", entity, apperrors.ErrValidation)
+		}
+		if scope, visible := searchScope(entity, userID, permissions); visible {
+			query.Scopes = append(query.Scopes, scope)
+		}
+	}
+	if len(query.Scopes) == 0 {
+		return []models.SearchHit{}, nil
+	}
+
+	hits, err := s.repo.Search(query)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	return hits, nil
+}
+
+// searchScope mirrors the permission checks of each entity's List: leads and
+// tasks are limited to the caller's own without their read:any permission,
+// tickets take tickets:list, and an entity type the caller cannot list at all
+// is left out.
+func searchScope(entity string, userID uint, permissions models.PermissionSet) (models.SearchScope, bool) {
+	switch entity {
+	case models.AuditEntityLead:
+		return ownedSearchScope(entity, userID, permissions, models.PermLeadsRead, models.PermLeadsReadAny)
+	case models.AuditEntityTask:
+		return ownedSearchScope(entity, userID, permissions, models.PermTasksRead, models.PermTasksReadAny)
+	case models.AuditEntityCustomer:
+		return models.SearchScope{Entity: entity}, permissions.Allows(models.PermCustomersRead)
+	case models.AuditEntityTicket:
+		return models.SearchScope{Entity: entity}, permissions.Allows(models.PermTicketsList)
+	case models.SearchEntityFormSubmission:
+		return models.SearchScope{Entity: entity}, permissions.Allows(models.PermFormsRead)
+	}
+	return models.SearchScope{}, false
+}
+
+// ownedSearchScope scopes an entity whose List shows every record with
+// readAny and only the caller's own with read alone.
+func ownedSearchScope(entity string, userID uint, permissions models.PermissionSet, read, readAny models.Permission) (models.SearchScope, bool) {
+	switch {
+	case permissions.Allows(readAny):
+		return models.SearchScope{Entity: entity}, true
+	case permissions.Allows(read):
+		return models.SearchScope{Entity: entity, UserID: userID}, true
+	}
+	return models.SearchScope{}, false
+}
+
+// searchTerms splits a search query into the distinct lowercase runs of
+// letters and digits the full-text indexes are made of; everything else,
+// including the operators of either database's query syntax, only separates
+// terms.
+func searchTerms(q string) ([]string, error) {
+	if len(q) > MaxSearchQueryLength {
+		return nil, fmt.Errorf("a search query cannot be longer than %d characters: %w", MaxSearchQueryLength, apperrors.ErrValidation)
+	}
+	var terms []string
+	seen := map[string]bool{}
+	for _, term := range strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("a search query needs at least one letter or digit: %w", apperrors.ErrValidation)
+	}
+	if len(terms) > MaxSearchTerms {
+		return nil, fmt.Errorf("a search query cannot have more than %d words: %w", MaxSearchTerms, apperrors.ErrValidation)
+	}
+	return terms, nil
+}
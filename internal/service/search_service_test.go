@@ -0,0 +1,137 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type SearchServiceTestSuite struct {
+	suite.Suite
+	mockRepo *mocks.SearchRepository
+	service  SearchService
+}
+
+func (suite *SearchServiceTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+}
+
+func (suite *SearchServiceTestSuite) SetupTest() {
+	suite.mockRepo = new(mocks.SearchRepository)
+	suite.service = NewSearchService(suite.mockRepo)
+}
+
+func (suite *SearchServiceTestSuite) TearDownTest() {
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+func (suite *SearchServiceTestSuite) TestSearch_TermsAndLimit() {
+	hits := []models.SearchHit{{EntityType: models.AuditEntityLead, EntityID: 4, Title: "Ada Lovelace", Score: 2.5}}
+	suite.mockRepo.On("Search", mock.MatchedBy(func(q models.SearchQuery) bool {
+		return assert.ObjectsAreEqual([]string{"ada", "lovelace", "dev"}, q.Terms) && q.Limit == 15
+	})).Return(hits, nil)
+
+	result, err := suite.service.Search(`"Ada" +lovelace* ada@lovelace.dev`, nil, 1, models.BuiltInPermissions(models.RoleAdmin), 15)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), hits, result)
+}
+
+func (suite *SearchServiceTestSuite) TestSearch_ScopesFollowListScoping() {
+	tests := []struct {
+		role models.UserRole
+		want []models.SearchScope
+	}{
+		{models.RoleAdmin, []models.SearchScope{
+			{Entity: "lead"}, {Entity: "customer"}, {Entity: "ticket"}, {Entity: "task"}, {Entity: "form_submission"},
+		}},
+		{models.RoleSales, []models.SearchScope{
+			{Entity: "lead", UserID: 7}, {Entity: "customer"}, {Entity: "ticket"}, {Entity: "task", UserID: 7},
+			{Entity: "form_submission"},
+		}},
+		{models.RoleSupport, []models.SearchScope{
+			{Entity: "customer"}, {Entity: "ticket"}, {Entity: "task", UserID: 7}, {Entity: "form_submission"},
+		}},
+		{models.RoleCustomer, []models.SearchScope{{Entity: "task", UserID: 7}}},
+	}
+
+	for _, tt := range tests {
+		suite.Run(string(tt.role), func() {
+			suite.mockRepo.On("Search", models.SearchQuery{Terms: []string{"acme"}, Scopes: tt.want, Limit: 20}).
+				Return([]models.SearchHit{}, nil).Once()
+
+			_, err := suite.service.Search("acme", nil, 7, models.BuiltInPermissions(tt.role), 20)
+			assert.NoError(suite.T(), err)
+		})
+	}
+}
+
+// TestSearch_CustomRole scopes a custom role by its own permissions, whatever
+// built-in role it is based on: every lead with leads:read:any, only the
+// tasks assigned to the caller with tasks:read:own, and nothing of the types
+// it cannot list.
+func (suite *SearchServiceTestSuite) TestSearch_CustomRole() {
+	leadAuditor := models.NewPermissionSet(models.PermLeadsReadAny, models.PermTasksReadOwn, models.PermTicketsReadOwn)
+	suite.mockRepo.On("Search", models.SearchQuery{
+		Terms: []string{"acme"}, Scopes: []models.SearchScope{{Entity: "lead"}, {Entity: "task", UserID: 7}}, Limit: 20,
+	}).Return([]models.SearchHit{}, nil)
+
+	_, err := suite.service.Search("acme", nil, 7, leadAuditor, 20)
+	assert.NoError(suite.T(), err)
+
+	hits, err := suite.service.Search("acme", []string{"customer", "ticket", "form_submission"}, 7, leadAuditor, 20)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), hits, "types the role cannot list are left out")
+}
+
+func (suite *SearchServiceTestSuite) TestSearch_EntityTypes() {
+	suite.mockRepo.On("Search", models.SearchQuery{
+		Terms: []string{"acme"}, Scopes: []models.SearchScope{{Entity: "ticket"}}, Limit: 20,
+	}).Return([]models.SearchHit{}, nil)
+
+	_, err := suite.service.Search("acme", []string{"ticket"}, 7, models.BuiltInPermissions(models.RoleSupport), 20)
+	assert.NoError(suite.T(), err)
+
+	hits, err := suite.service.Search("acme", []string{"lead"}, 7, models.BuiltInPermissions(models.RoleSupport), 20)
+	assert.NoError(suite.T(), err, "a type the permissions cannot list is skipped")
+	assert.Empty(suite.T(), hits)
+
+	_, err = suite.service.Search("acme", []string{"deal"}, 7, models.BuiltInPermissions(models.RoleAdmin), 20)
+	assert.True(suite.T(), errors.Is(err, apperrors.ErrValidation))
+}
+
+func (suite *SearchServiceTestSuite) TestSearch_InvalidQuery() {
+	tests := []struct {
+		name    string
+		q       string
+		wantErr string
+	}{
+		{"empty", "", "at least one letter or digit"},
+		{"only punctuation", ` "*" + - () `, "at least one letter or digit"},
+		{"too long", strings.Repeat("a", MaxSearchQueryLength+1), "cannot be longer than"},
+		{"too many words", "one two three four five six seven eight nine ten eleven", "more than 10 words"},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			_, err := suite.service.Search(tt.q, nil, 1, models.BuiltInPermissions(models.RoleAdmin), 20)
+			suite.Require().Error(err)
+			assert.Contains(suite.T(), err.Error(), tt.wantErr)
+			assert.True(suite.T(), errors.Is(err, apperrors.ErrValidation))
+		})
+	}
+	suite.mockRepo.AssertNotCalled(suite.T(), "Search", mock.Anything)
+}
+
+func TestSearchServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(SearchServiceTestSuite))
+}
@@ -0,0 +1,496 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Sentinel errors of the signing keys, classified with errors.Is().
+var (
+	// ErrSigningKeyNotFound means there is no published key with the kid:
+	// unknown, or retired.
+	ErrSigningKeyNotFound = errors.New("signing key not found")
+	// ErrSigningKeyInUse refuses to retire the key that signs tokens; another
+	// key has to be activated first.
+	ErrSigningKeyInUse             = errors.New("signing key is the active one")
+	ErrUnsupportedSigningAlgorithm = errors.New("unsupported signing algorithm")
+	// ErrNoSigningKey means no key has been activated, and tokens are signed
+	// with JWT_SECRET.
+	ErrNoSigningKey = errors.New("no signing key is active")
+)
+
+// SigningKeySealContext is the SecretBox context the private keys are sealed
+// under. The server and the jwt-keys command must agree on it.
+const SigningKeySealContext = "jwt-signing-key"
+
+// rsaKeyBits is the size of a generated RSA key.
+const rsaKeyBits = 2048
+
+// unknownKeyReloadInterval bounds how often tokens naming a key the cached
+// copy does not have reload the keys, so that made-up kids cannot turn every
+// request into a query.
+const unknownKeyReloadInterval = 5 * time.Second
+
+// SigningKeyService manages the key pairs that sign access tokens, and signs
+// and verifies with them. Rotating keys takes three steps: generate a key,
+// which publishes it in the JWKS; activate it once the services that verify
+// our tokens have fetched it; and retire the previous key once the tokens it
+// signed have expired.
+type SigningKeyService interface {
+	// Generate creates and publishes a key pair of the algorithm, RS256 or
+	// EdDSA. It does not sign anything until activated.
+	Generate(algorithm string) (*models.SigningKey, error)
+	// Activate makes a published key the one that signs tokens.
+	Activate(kid string) (*models.SigningKey, error)
+	// Retire withdraws a key from the JWKS; the tokens it signed stop
+	// verifying. The active key cannot be retired.
+	Retire(kid string) (*models.SigningKey, error)
+	// List returns every key, retired ones included, the oldest first.
+	List() ([]models.SigningKey, error)
+	// JWKS returns the public keys that verify access tokens.
+	JWKS() (*models.JWKS, error)
+	// Sign signs claims with the active key, named in the kid header. It
+	// returns ErrNoSigningKey while no key has been activated.
+	Sign(claims jwt.Claims) (string, error)
+	// VerificationKey is a jwt.Keyfunc for a token with a kid header: the
+	// public key of the published key it names, provided the token uses that
+	// key's algorithm.
+	VerificationKey(token *jwt.Token) (interface{}, error)
+	// SecretAccepted reports whether tokens signed with JWT_SECRET are still
+	// accepted: while no key has been activated, and for one access-token
+	// lifetime after the first was, by which the last of them has expired.
+	SecretAccepted() (bool, error)
+}
+
+type signingKeyService struct {
+	repo repository.SigningKeyRepository
+	// secretBox seals the private keys at rest.
+	secretBox *utils.SecretBox
+	// ttl is how long the copy of the keys is used before it is reloaded.
+	ttl time.Duration
+	// tokenTTL is the lifetime of an access token.
+	tokenTTL time.Duration
+	now      func() time.Time
+
+	mu       sync.RWMutex
+	keys     *keySet
+	loadedAt time.Time
+	stale    bool
+}
+
+// keySet is the in-memory copy of the keys.
+type keySet struct {
+	// signer is nil while no key is active, and when the active key cannot
+	// be opened, which signErr then tells.
+	signer    *activeKey
+	signErr   error
+	verifiers map[string]publishedKey
+	jwks      models.JWKS
+	// secretUntil is when tokens signed with JWT_SECRET stop being accepted;
+	// zero while no key is active.
+	secretUntil time.Time
+}
+
+type activeKey struct {
+	kid    string
+	method jwt.SigningMethod
+	key    crypto.Signer
+}
+
+type publishedKey struct {
+	method jwt.SigningMethod
+	key    crypto.PublicKey
+}
+
+func NewSigningKeyService(repo repository.SigningKeyRepository, secretBox *utils.SecretBox, jwtConfig config.JWTConfig) SigningKeyService {
+	ttl := time.Duration(jwtConfig.KeyCacheSeconds) * time.Second
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	return &signingKeyService{
+		repo:      repo,
+		secretBox: secretBox,
+		ttl:       ttl,
+		tokenTTL:  time.Hour * time.Duration(jwtConfig.ExpiryHours),
+		now:       time.Now,
+		stale:     true,
+	}
+}
+
+// signingMethod returns the method of a supported algorithm, or nil.
+func signingMethod(algorithm string) jwt.SigningMethod {
+	switch algorithm {
+	case models.SigningAlgorithmRS256:
+		return jwt.SigningMethodRS256
+	case models.SigningAlgorithmEdDSA:
+		return jwt.SigningMethodEdDSA
+	}
+	return nil
+}
+
+func (s *signingKeyService) Generate(algorithm string) (*models.SigningKey, error) {
+	var private crypto.Signer
+	switch {
+	case strings.EqualFold(algorithm, models.SigningAlgorithmRS256):
+		algorithm = models.SigningAlgorithmRS256
+		key, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate key: %w", err)
+		}
+		private = key
+	case strings.EqualFold(algorithm, models.SigningAlgorithmEdDSA):
+		algorithm = models.SigningAlgorithmEdDSA
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate key: %w", err)
+		}
+		private = key
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedSigningAlgorithm, algorithm)
+	}
+
+	jwk, err := publicJWK("", algorithm, private.Public())
+	if err != nil {
+		return nil, err
+	}
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %w", err)
+	}
+	sealed, err := s.secretBox.Seal(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})))
+	if err != nil {
+		return nil, fmt.Errorf("failed to seal private key: %w", err)
+	}
+
+	key := &models.SigningKey{
+		KID:        jwkThumbprint(jwk),
+		Algorithm:  algorithm,
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+		PrivateKey: sealed,
+	}
+	if err := s.repo.Create(key); err != nil {
+		return nil, fmt.Errorf("failed to store signing key: %w", err)
+	}
+	s.invalidate()
+	utils.Logger.WithFields(map[string]interface{}{"kid": key.KID, "algorithm": algorithm}).
+		Info("Signing key generated")
+	return key, nil
+}
+
+func (s *signingKeyService) Activate(kid string) (*models.SigningKey, error) {
+	key, err := s.published(kid)
+	if err != nil {
+		return nil, err
+	}
+	// A key that cannot be opened, because API_KEY_SECRET changed since it
+	// was generated, must not become the one every login depends on.
+	if _, err := s.openPrivateKey(key); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Activate(kid, s.now()); err != nil {
+		if isNotFound(err) {
+			return nil, ErrSigningKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to activate signing key: %w", err)
+	}
+	s.invalidate()
+	utils.Logger.WithField("kid", kid).Info("Signing key activated")
+	return s.repo.GetByKID(kid)
+}
+
+func (s *signingKeyService) Retire(kid string) (*models.SigningKey, error) {
+	keys, err := s.repo.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list signing keys: %w", err)
+	}
+	if active := ActiveSigningKey(keys); active != nil && active.KID == kid {
+		return nil, ErrSigningKeyInUse
+	}
+	if err := s.repo.Retire(kid, s.now()); err != nil {
+		if isNotFound(err) {
+			return nil, ErrSigningKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to retire signing key: %w", err)
+	}
+	s.invalidate()
+	utils.Logger.WithField("kid", kid).Info("Signing key retired")
+	return s.repo.GetByKID(kid)
+}
+
+func (s *signingKeyService) List() ([]models.SigningKey, error) {
+	return s.repo.List()
+}
+
+func (s *signingKeyService) JWKS() (*models.JWKS, error) {
+	keys, err := s.current()
+	if err != nil {
+		return nil, err
+	}
+	jwks := models.JWKS{Keys: append([]models.JWK{}, keys.jwks.Keys...)}
+	return &jwks, nil
+}
+
+func (s *signingKeyService) Sign(claims jwt.Claims) (string, error) {
+	keys, err := s.current()
+	if err != nil {
+		return "", err
+	}
+	if keys.signer == nil {
+		if keys.signErr != nil {
+			return "", keys.signErr
+		}
+		return "", ErrNoSigningKey
+	}
+	token := jwt.NewWithClaims(keys.signer.method, claims)
+	token.Header["kid"] = keys.signer.kid
+	return token.SignedString(keys.signer.key)
+}
+
+func (s *signingKeyService) VerificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	keys, err := s.current()
+	if err != nil {
+		return nil, err
+	}
+	key, ok := keys.verifiers[kid]
+	if !ok {
+		// The key may have been generated since the copy was loaded.
+		if keys, err = s.reloadForUnknownKey(); err != nil {
+			return nil, err
+		}
+		if key, ok = keys.verifiers[kid]; !ok {
+			return nil, errors.New("unknown signing key")
+		}
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.key, nil
+}
+
+func (s *signingKeyService) SecretAccepted() (bool, error) {
+	keys, err := s.current()
+	if err != nil {
+		return false, err
+	}
+	return keys.secretUntil.IsZero() || s.now().Before(keys.secretUntil), nil
+}
+
+// published returns the published key with the kid.
+func (s *signingKeyService) published(kid string) (*models.SigningKey, error) {
+	key, err := s.repo.GetByKID(kid)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrSigningKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get signing key: %w", err)
+	}
+	if !key.Published() {
+		return nil, ErrSigningKeyNotFound
+	}
+	return key, nil
+}
+
+// invalidate makes the next use reload the keys, after a change made
+// through this instance.
+func (s *signingKeyService) invalidate() {
+	s.mu.Lock()
+	s.stale = true
+	s.mu.Unlock()
+}
+
+// current returns the copy of the keys, reloading it when it is stale or
+// older than ttl. Concurrent callers wait for the one that reloads.
+func (s *signingKeyService) current() (*keySet, error) {
+	s.mu.RLock()
+	keys, fresh := s.keys, s.isFresh()
+	s.mu.RUnlock()
+	if fresh {
+		return keys, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isFresh() {
+		return s.keys, nil
+	}
+	return s.reload()
+}
+
+// reloadForUnknownKey reloads the keys unless that was done in the last
+// unknownKeyReloadInterval.
+func (s *signingKeyService) reloadForUnknownKey() (*keySet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.keys != nil && s.now().Sub(s.loadedAt) < unknownKeyReloadInterval {
+		return s.keys, nil
+	}
+	return s.reload()
+}
+
+// reload must be called with mu held.
+func (s *signingKeyService) reload() (*keySet, error) {
+	now := s.now()
+	stored, err := s.repo.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load signing keys: %w", err)
+	}
+
+	keys := &keySet{verifiers: make(map[string]publishedKey), jwks: models.JWKS{Keys: []models.JWK{}}}
+	var firstActivation *time.Time
+	for i := range stored {
+		key := &stored[i]
+		if key.ActivatedAt != nil && (firstActivation == nil || key.ActivatedAt.Before(*firstActivation)) {
+			firstActivation = key.ActivatedAt
+		}
+		if !key.Published() {
+			continue
+		}
+		public, jwk, err := parsePublicKey(key)
+		if err != nil {
+			// One unreadable key must not stop the others from verifying.
+			utils.Logger.WithError(err).WithField("kid", key.KID).Error("Signing key skipped")
+			continue
+		}
+		keys.verifiers[key.KID] = publishedKey{method: signingMethod(key.Algorithm), key: public}
+		keys.jwks.Keys = append(keys.jwks.Keys, jwk)
+	}
+
+	if active := ActiveSigningKey(stored); active != nil {
+		if private, err := s.openPrivateKey(active); err != nil {
+			keys.signErr = err
+			utils.Logger.WithError(err).WithField("kid", active.KID).Error("Active signing key cannot be opened")
+		} else {
+			keys.signer = &activeKey{kid: active.KID, method: signingMethod(active.Algorithm), key: private}
+		}
+		// Instances keep signing with the secret until they reload the
+		// keys, and those tokens live for tokenTTL.
+		keys.secretUntil = firstActivation.Add(s.ttl + s.tokenTTL)
+	}
+
+	s.keys, s.loadedAt, s.stale = keys, now, false
+	return keys, nil
+}
+
+// isFresh must be called with mu held.
+func (s *signingKeyService) isFresh() bool {
+	return s.keys != nil && !s.stale && s.now().Sub(s.loadedAt) < s.ttl
+}
+
+// ActiveSigningKey returns the key of keys that signs tokens: the published
+// key activated last, or nil when none is activated.
+func ActiveSigningKey(keys []models.SigningKey) *models.SigningKey {
+	var active *models.SigningKey
+	for i := range keys {
+		key := &keys[i]
+		if !key.Published() || key.ActivatedAt == nil {
+			continue
+		}
+		if active == nil || !key.ActivatedAt.Before(*active.ActivatedAt) {
+			active = key
+		}
+	}
+	return active
+}
+
+func (s *signingKeyService) openPrivateKey(key *models.SigningKey) (crypto.Signer, error) {
+	encoded, err := s.secretBox.Open(key.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open private key %s: %w", key.KID, err)
+	}
+	block, _ := pem.Decode([]byte(encoded))
+	if block == nil {
+		return nil, fmt.Errorf("private key %s is not PEM", key.KID)
+	}
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key %s: %w", key.KID, err)
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("private key %s cannot sign", key.KID)
+	}
+	if _, err := publicJWK(key.KID, key.Algorithm, signer.Public()); err != nil {
+		return nil, err
+	}
+	return signer, nil
+}
+
+// parsePublicKey returns a stored public key and its JWK.
+func parsePublicKey(key *models.SigningKey) (crypto.PublicKey, models.JWK, error) {
+	block, _ := pem.Decode([]byte(key.PublicKey))
+	if block == nil {
+		return nil, models.JWK{}, errors.New("public key is not PEM")
+	}
+	public, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, models.JWK{}, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	jwk, err := publicJWK(key.KID, key.Algorithm, public)
+	if err != nil {
+		return nil, models.JWK{}, err
+	}
+	return public, jwk, nil
+}
+
+// publicJWK returns the JWK of a public key, which must be of the kind the
+// algorithm uses.
+func publicJWK(kid, algorithm string, public crypto.PublicKey) (models.JWK, error) {
+	encode := base64.RawURLEncoding.EncodeToString
+	jwk := models.JWK{KeyID: kid, Use: "sig", Algorithm: algorithm}
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		if algorithm != models.SigningAlgorithmRS256 {
+			break
+		}
+		jwk.KeyType = "RSA"
+		jwk.N = encode(key.N.Bytes())
+		jwk.E = encode(big.NewInt(int64(key.E)).Bytes())
+		return jwk, nil
+	case ed25519.PublicKey:
+		if algorithm != models.SigningAlgorithmEdDSA {
+			break
+		}
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = encode(key)
+		return jwk, nil
+	}
+	return models.JWK{}, fmt.Errorf("%w: %T key for %s", ErrUnsupportedSigningAlgorithm, public, algorithm)
+}
+
+// jwkThumbprint is the RFC 7638 SHA-256 thumbprint of a JWK: the hash of its
+// required members, in lexicographic order and without whitespace.
+func jwkThumbprint(jwk models.JWK) string {
+	var canonical string
+	switch jwk.KeyType {
+	case "RSA":
+		canonical = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, jwk.E, jwk.N)
+	case "OKP":
+		canonical = fmt.Sprintf(`{"crv":%q,"kty":"OKP","x":%q}`, jwk.Curve, jwk.X)
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const signingKeysTestSecret = "signing-keys-test-secret"
+
+var signingKeysTestConfig = config.JWTConfig{Secret: signingKeysTestSecret, ExpiryHours: 1, KeyCacheSeconds: 60}
+
+// SigningKeySuite signs and verifies access tokens through an auth service
+// whose keys live in an in-memory SQLite database.
+type SigningKeySuite struct {
+	suite.Suite
+	db   *gorm.DB
+	user *models.User
+	keys SigningKeyService
+	auth AuthService
+}
+
+func TestSigningKeySuite(t *testing.T) {
+	suite.Run(t, new(SigningKeySuite))
+}
+
+func (s *SigningKeySuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "error", Format: "json"})
+}
+
+func (s *SigningKeySuite) SetupTest() {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	s.Require().NoError(err)
+	s.Require().NoError(db.AutoMigrate(&models.User{}, &models.SigningKey{}))
+	s.db = db
+
+	s.user = &models.User{Email: "kim@example.com", FirstName: "Kim", LastName: "Lee", Role: models.RoleSales, IsActive: true}
+	s.Require().NoError(db.Create(s.user).Error)
+	s.keys, s.auth = s.newInstance("api-key-secret")
+}
+
+// newInstance builds the signing keys and auth service of one server.
+func (s *SigningKeySuite) newInstance(apiKeySecret string) (SigningKeyService, AuthService) {
+	keys := NewSigningKeyService(repository.NewSigningKeyRepository(s.db),
+		utils.NewSecretBox(apiKeySecret, SigningKeySealContext), signingKeysTestConfig)
+	auth := NewAuthServiceWithSessions(repository.NewUserRepository(s.db), nil, nil, nil, nil,
+		signingKeysTestConfig, "", apiKeySecret, WithSigningKeys(keys))
+	return keys, auth
+}
+
+func (s *SigningKeySuite) token() (string, *jwt.Token) {
+	signed, err := s.auth.GenerateJWT(s.user)
+	s.Require().NoError(err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(signed, jwt.MapClaims{})
+	s.Require().NoError(err)
+	return signed, parsed
+}
+
+func (s *SigningKeySuite) generate(algorithm string, activate bool) *models.SigningKey {
+	key, err := s.keys.Generate(algorithm)
+	s.Require().NoError(err)
+	if activate {
+		_, err = s.keys.Activate(key.KID)
+		s.Require().NoError(err)
+	}
+	return key
+}
+
+func (s *SigningKeySuite) TestGenerate() {
+	rsaKey := s.generate("rs256", false)
+	edKey := s.generate(models.SigningAlgorithmEdDSA, false)
+
+	s.Equal(models.SigningAlgorithmRS256, rsaKey.Algorithm)
+	s.Len(rsaKey.KID, 43, "a base64url SHA-256 thumbprint")
+	s.True(utils.IsSealed(rsaKey.PrivateKey))
+	s.NotEqual(rsaKey.KID, edKey.KID)
+
+	jwks, err := s.keys.JWKS()
+	s.Require().NoError(err)
+	s.Require().Len(jwks.Keys, 2, "a key is published before it is activated")
+	s.Equal(models.JWK{KeyType: "RSA", KeyID: rsaKey.KID, Use: "sig", Algorithm: "RS256",
+		N: jwks.Keys[0].N, E: "AQAB"}, jwks.Keys[0])
+	s.Equal("OKP", jwks.Keys[1].KeyType)
+	s.Equal("Ed25519", jwks.Keys[1].Curve)
+	s.Equal(edKey.KID, jwkThumbprint(jwks.Keys[1]))
+
+	_, err = s.keys.Generate("HS256")
+	s.ErrorIs(err, ErrUnsupportedSigningAlgorithm)
+}
+
+func (s *SigningKeySuite) TestThumbprintMatchesRFC7638() {
+	jwk := models.JWK{KeyType: "RSA", E: "AQAB",
+		N: "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw"}
+	s.Equal("NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", jwkThumbprint(jwk))
+}
+
+func (s *SigningKeySuite) TestSecretSignsUntilAKeyIsActivated() {
+	s.generate(models.SigningAlgorithmRS256, false)
+
+	signed, token := s.token()
+	s.Equal("HS256", token.Method.Alg())
+	s.NotContains(token.Header, "kid")
+	_, err := s.auth.ValidateToken(signed)
+	s.NoError(err)
+}
+
+func (s *SigningKeySuite) TestOtherServicesVerifyWithTheJWKS() {
+	key := s.generate(models.SigningAlgorithmRS256, true)
+
+	signed, token := s.token()
+	s.Equal("RS256", token.Method.Alg())
+	s.Equal(key.KID, token.Header["kid"])
+	_, err := s.auth.ValidateToken(signed)
+	s.NoError(err)
+
+	// A verifier that has nothing but the JWKS.
+	jwks, err := s.keys.JWKS()
+	s.Require().NoError(err)
+	s.Require().Len(jwks.Keys, 1)
+	n, err := base64.RawURLEncoding.DecodeString(jwks.Keys[0].N)
+	s.Require().NoError(err)
+	public := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537}
+	verified, err := jwt.Parse(signed, func(*jwt.Token) (interface{}, error) { return public, nil },
+		jwt.WithValidMethods([]string{"RS256"}))
+	s.Require().NoError(err)
+	s.Equal(float64(s.user.ID), verified.Claims.(jwt.MapClaims)["user_id"])
+}
+
+func (s *SigningKeySuite) TestEdDSA() {
+	key := s.generate(models.SigningAlgorithmEdDSA, true)
+
+	signed, token := s.token()
+	s.Equal("EdDSA", token.Method.Alg())
+	s.Equal(key.KID, token.Header["kid"])
+	_, err := s.auth.ValidateToken(signed)
+	s.NoError(err)
+
+	jwks, err := s.keys.JWKS()
+	s.Require().NoError(err)
+	x, err := base64.RawURLEncoding.DecodeString(jwks.Keys[0].X)
+	s.Require().NoError(err)
+	_, err = jwt.Parse(signed, func(*jwt.Token) (interface{}, error) { return ed25519.PublicKey(x), nil })
+	s.NoError(err)
+}
+
+func (s *SigningKeySuite) TestRotation() {
+	old := s.generate(models.SigningAlgorithmRS256, true)
+	signedByOld, _ := s.token()
+
+	next := s.generate(models.SigningAlgorithmEdDSA, false)
+	_, token := s.token()
+	s.Equal(old.KID, token.Header["kid"], "a published key does not sign before it is activated")
+
+	_, err := s.keys.Activate(next.KID)
+	s.Require().NoError(err)
+	signedByNext, token := s.token()
+	s.Equal(next.KID, token.Header["kid"])
+	_, err = s.auth.ValidateToken(signedByOld)
+	s.NoError(err, "both keys verify during the overlap")
+
+	_, err = s.keys.Retire(next.KID)
+	s.ErrorIs(err, ErrSigningKeyInUse)
+	retired, err := s.keys.Retire(old.KID)
+	s.Require().NoError(err)
+	s.NotNil(retired.RetiredAt)
+
+	_, err = s.auth.ValidateToken(signedByOld)
+	s.Error(err, "a retired key verifies nothing")
+	_, err = s.auth.ValidateToken(signedByNext)
+	s.NoError(err)
+	jwks, err := s.keys.JWKS()
+	s.Require().NoError(err)
+	s.Require().Len(jwks.Keys, 1)
+	s.Equal(next.KID, jwks.Keys[0].KeyID)
+
+	_, err = s.keys.Activate(old.KID)
+	s.ErrorIs(err, ErrSigningKeyNotFound)
+	_, err = s.keys.Retire("no-such-key")
+	s.ErrorIs(err, ErrSigningKeyNotFound)
+}
+
+func (s *SigningKeySuite) TestSecretIsRefusedOnceItsTokensExpired() {
+	signedBySecret, _ := s.token()
+	s.generate(models.SigningAlgorithmRS256, true)
+
+	_, err := s.auth.ValidateToken(signedBySecret)
+	s.NoError(err, "tokens signed before the switch stay valid")
+
+	// One access-token lifetime and one cache period later, a token signed
+	// with the secret can only be forged.
+	later := time.Now().Add(time.Hour + time.Minute + time.Second)
+	s.keys.(*signingKeyService).now = func() time.Time { return later }
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": s.user.ID,
+		"jti":     "forged",
+		"exp":     later.Add(time.Hour).Unix(),
+	}).SignedString([]byte(signingKeysTestSecret))
+	s.Require().NoError(err)
+	_, err = s.auth.ValidateToken(forged)
+	s.Error(err)
+}
+
+func (s *SigningKeySuite) TestTokenMustUseItsKeysAlgorithm() {
+	key := s.generate(models.SigningAlgorithmRS256, true)
+
+	// The public key is no secret; it must not pass as an HMAC key.
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": s.user.ID,
+		"jti":     "confused",
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = key.KID
+	signed, err := token.SignedString([]byte(key.PublicKey))
+	s.Require().NoError(err)
+	_, err = s.auth.ValidateToken(signed)
+	s.Error(err)
+}
+
+func (s *SigningKeySuite) TestAnotherInstanceLearnsANewKeyFromItsTokens() {
+	otherKeys, otherAuth := s.newInstance("api-key-secret")
+	_, err := otherKeys.JWKS()
+	s.Require().NoError(err, "the other instance has loaded the keys")
+
+	s.generate(models.SigningAlgorithmRS256, true)
+	signed, _ := s.token()
+
+	later := time.Now().Add(unknownKeyReloadInterval)
+	otherKeys.(*signingKeyService).now = func() time.Time { return later }
+	_, err = otherAuth.ValidateToken(signed)
+	s.NoError(err, "a token naming an unknown key reloads the keys")
+}
+
+func (s *SigningKeySuite) TestKeyThatCannotBeOpenedIsNotActivated() {
+	key := s.generate(models.SigningAlgorithmEdDSA, false)
+	otherKeys, _ := s.newInstance("a-different-api-key-secret")
+
+	_, err := otherKeys.Activate(key.KID)
+	s.ErrorIs(err, utils.ErrSecretUndecryptable)
+	_, token := s.token()
+	s.Equal("HS256", token.Method.Alg())
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/sla"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+)
+
+// MaxSLATargetMinutes caps a target at a year, which is already far beyond any
+// real SLA and keeps the business-hours walk short.
+const MaxSLATargetMinutes = 365 * 24 * 60
+
+// slaPriorityOrder is the order SLA reports list priorities in, most urgent
+// first.
+var slaPriorityOrder = []models.TicketPriority{
+	models.TicketPriorityUrgent,
+	models.TicketPriorityHigh,
+	models.TicketPriorityMedium,
+	models.TicketPriorityLow,
+}
+
+// SLAService manages the per-priority SLA policies, stamps tickets with their
+// deadlines, flags missed deadlines and reports attainment.
+//
+// A policy applies to the tickets opened, or moved to its priority, after it
+// was saved. Changing or deleting a policy leaves the deadlines already stamped
+// on existing tickets alone, so a report never rewrites history.
+type SLAService interface {
+	ListPolicies() ([]models.SLAPolicy, error)
+	// SavePolicy creates or replaces the policy for policy.Priority. Omitted
+	// calendar fields take the defaults (UTC, 09:00-17:00, Monday to
+	// Friday). An invalid policy is rejected with apperrors.ErrValidation.
+	SavePolicy(policy *models.SLAPolicy) error
+	// DeletePolicy removes the priority's policy; apperrors.ErrNotFound when
+	// it has none.
+	DeletePolicy(priority models.TicketPriority) error
+	// ApplyDeadlines stamps the ticket's due dates from the policy for its
+	// priority, measured from its creation time, and clears its breach flags.
+	// A priority without a policy clears the due dates. Nothing is persisted.
+	ApplyDeadlines(ticket *models.Ticket) error
+	// FlagBreaches flags every deadline missed as of now and returns how many
+	// it flagged. The breach worker calls it on every tick.
+	FlagBreaches(now time.Time) (int, error)
+	// AttainmentByPriority and AttainmentByAssignee report how the tickets
+	// opened in [from, to) fared against their deadlines.
+	AttainmentByPriority(from, to time.Time) ([]models.SLAAttainment, error)
+	AttainmentByAssignee(from, to time.Time) ([]models.SLAAttainment, error)
+}
+
+type slaService struct {
+	repo repository.SLARepository
+	activityFeed
+	auditRecorder
+}
+
+// NewSLAService builds the service. The breach flags it sets are audited as
+// the system's doing.
+func NewSLAService(repo repository.SLARepository, auditService AuditService, opts ...ActivityOption) SLAService {
+	s := &slaService{repo: repo, auditRecorder: auditRecorder{auditService: auditService}}
+	s.applyActivityOptions(opts)
+	return s
+}
+
+func (s *slaService) ListPolicies() ([]models.SLAPolicy, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("entity", "sla_policy"), "SLAService", "ListPolicies")
+
+	policies, err := s.repo.ListPolicies()
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	return policies, nil
+}
+
+func (s *slaService) SavePolicy(policy *models.SLAPolicy) error {
+	logger := utils.LogServiceCall(utils.Logger.WithField("priority", policy.Priority), "SLAService", "SavePolicy")
+
+	if err := normalizeSLAPolicy(policy); err != nil {
+		logger.WithError(err).Warn("Invalid SLA policy")
+		return err
+	}
+	if err := s.repo.SavePolicy(policy); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+
+	logger.WithField("policy_id", policy.ID).Info("SLA policy saved")
+	return nil
+}
+
+// normalizeSLAPolicy fills the calendar defaults and validates the result.
+// The calendar is checked even when the policy runs around the clock, so
+// switching business hours on later cannot expose a broken one.
+func normalizeSLAPolicy(policy *models.SLAPolicy) error {
+	if !isTicketPriority(policy.Priority) {
+		return fmt.Errorf("unknown ticket priority %q: %w", policy.Priority, apperrors.ErrValidation)
+	}
+	if policy.FirstResponseMinutes <= 0 || policy.FirstResponseMinutes > MaxSLATargetMinutes {
+		return fmt.Errorf("first_response_minutes must be between 1 and %d: %w", MaxSLATargetMinutes, apperrors.ErrValidation)
+	}
+	if policy.ResolutionMinutes <= 0 || policy.ResolutionMinutes > MaxSLATargetMinutes {
+		return fmt.Errorf("resolution_minutes must be between 1 and %d: %w", MaxSLATargetMinutes, apperrors.ErrValidation)
+	}
+	if policy.ResolutionMinutes < policy.FirstResponseMinutes {
+		return fmt.Errorf("resolution target cannot be shorter than the first-response target: %w", apperrors.ErrValidation)
+	}
+
+	if policy.Timezone == "" {
+		policy.Timezone = models.DefaultSLATimezone
+	}
+	if policy.BusinessDayStart == "" {
+		policy.BusinessDayStart = models.DefaultSLABusinessDayStart
+	}
+	if policy.BusinessDayEnd == "" {
+		policy.BusinessDayEnd = models.DefaultSLABusinessDayEnd
+	}
+	if len(policy.BusinessDays) == 0 {
+		policy.BusinessDays = append([]int(nil), models.DefaultSLABusinessDays...)
+	}
+	if _, err := sla.NewCalendar(policy.Timezone, policy.BusinessDayStart, policy.BusinessDayEnd, policy.BusinessDays); err != nil {
+		return fmt.Errorf("%s: %w", err.Error(), apperrors.ErrValidation)
+	}
+	sort.Ints(policy.BusinessDays)
+	return nil
+}
+
+func isTicketPriority(priority models.TicketPriority) bool {
+	for _, known := range slaPriorityOrder {
+		if priority == known {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *slaService) DeletePolicy(priority models.TicketPriority) error {
+	logger := utils.LogServiceCall(utils.Logger.WithField("priority", priority), "SLAService", "DeletePolicy")
+
+	if err := s.repo.DeletePolicy(priority); err != nil {
+		if isNotFound(err) {
+			logger.Warn("SLA policy not found")
+			return fmt.Errorf("no SLA policy for priority %q: %w", priority, apperrors.ErrNotFound)
+		}
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+
+	logger.Info("SLA policy deleted")
+	return nil
+}
+
+func (s *slaService) ApplyDeadlines(ticket *models.Ticket) error {
+	ticket.FirstResponseDueAt = nil
+	ticket.ResolutionDueAt = nil
+	ticket.FirstResponseBreached = false
+	ticket.ResolutionBreached = false
+
+	policy, err := s.repo.GetPolicy(ticket.Priority)
+	if err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	opened := ticket.CreatedAt
+	if opened.IsZero() {
+		opened = time.Now()
+	}
+	firstResponse, resolution, err := sla.Deadlines(*policy, opened.UTC())
+	if err != nil {
+		return fmt.Errorf("SLA policy for priority %q: %w", policy.Priority, err)
+	}
+	ticket.FirstResponseDueAt = &firstResponse
+	ticket.ResolutionDueAt = &resolution
+	return nil
+}
+
+func (s *slaService) FlagBreaches(now time.Time) (int, error) {
+	breaches, err := s.repo.FlagBreaches(now.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	for _, breach := range breaches {
+		title, flag := "Resolution SLA breached", "resolution_breached"
+		if breach.Target == models.SLATargetFirstResponse {
+			title, flag = "First response SLA breached", "first_response_breached"
+		}
+		utils.Logger.WithFields(map[string]interface{}{
+			"ticket_id": breach.Ticket.ID,
+			"target":    breach.Target,
+		}).Warn(title)
+		s.recordAudit(systemAuditActor, models.AuditEntityTicket, breach.Ticket.ID, models.AuditActionUpdate,
+			map[string]interface{}{flag: false}, map[string]interface{}{flag: true})
+		s.recordActivity(models.AuditEntityTicket, breach.Ticket.ID, models.ActivityTicketSLABreached,
+			breach.Ticket.AssignedToID, title, breach.Ticket.Title)
+	}
+	return len(breaches), nil
+}
+
+func (s *slaService) AttainmentByPriority(from, to time.Time) ([]models.SLAAttainment, error) {
+	tickets, err := s.repo.ListForReport(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	groups := map[models.TicketPriority]*models.SLAAttainment{}
+	for i := range tickets {
+		ticket := &tickets[i]
+		row, ok := groups[ticket.Priority]
+		if !ok {
+			row = &models.SLAAttainment{Priority: ticket.Priority}
+			groups[ticket.Priority] = row
+		}
+		tallySLA(row, ticket, now)
+	}
+
+	rows := []models.SLAAttainment{}
+	for _, priority := range slaPriorityOrder {
+		if row, ok := groups[priority]; ok {
+			rows = append(rows, finishSLARow(row))
+		}
+	}
+	return rows, nil
+}
+
+func (s *slaService) AttainmentByAssignee(from, to time.Time) ([]models.SLAAttainment, error) {
+	tickets, err := s.repo.ListForReport(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	// Key 0 is the unassigned group; user ids start at 1.
+	groups := map[uint]*models.SLAAttainment{}
+	for i := range tickets {
+		ticket := &tickets[i]
+		var key uint
+		if ticket.AssignedToID != nil {
+			key = *ticket.AssignedToID
+		}
+		row, ok := groups[key]
+		if !ok {
+			row = &models.SLAAttainment{AssigneeID: ticket.AssignedToID, AssigneeName: "Unassigned"}
+			if assignee := ticket.AssignedTo; assignee != nil {
+				row.AssigneeName = describePerson(assignee.FirstName, assignee.LastName, "", assignee.Email)
+			}
+			groups[key] = row
+		}
+		tallySLA(row, ticket, now)
+	}
+
+	rows := make([]models.SLAAttainment, 0, len(groups))
+	for _, row := range groups {
+		rows = append(rows, finishSLARow(row))
+	}
+	// Named assignees by name, the unassigned group last.
+	sort.Slice(rows, func(i, j int) bool {
+		if (rows[i].AssigneeID == nil) != (rows[j].AssigneeID == nil) {
+			return rows[j].AssigneeID == nil
+		}
+		if rows[i].AssigneeName != rows[j].AssigneeName {
+			return rows[i].AssigneeName < rows[j].AssigneeName
+		}
+		return rows[i].AssigneeID != nil && *rows[i].AssigneeID < *rows[j].AssigneeID
+	})
+	return rows, nil
+}
+
+// tallySLA adds one ticket to a report row.
+func tallySLA(row *models.SLAAttainment, ticket *models.Ticket, now time.Time) {
+	row.Tickets++
+	tallySLATarget(&row.FirstResponse, ticket.FirstResponseDueAt, ticket.FirstRespondedAt, now)
+	tallySLATarget(&row.Resolution, ticket.ResolutionDueAt, ticket.ResolvedAt, now)
+}
+
+// tallySLATarget decides one target from the timestamps rather than from the
+// breached flags, so a report is exact even between two sweeps of the worker.
+func tallySLATarget(stats *models.SLATargetStats, due, reached *time.Time, now time.Time) {
+	if due == nil {
+		return
+	}
+	switch {
+	case reached != nil && !reached.After(*due):
+		stats.Met++
+	case reached != nil || now.After(*due):
+		stats.Breached++
+	default:
+		stats.Pending++
+	}
+}
+
+func finishSLARow(row *models.SLAAttainment) models.SLAAttainment {
+	row.FirstResponse.AttainmentRate = attainmentRate(row.FirstResponse)
+	row.Resolution.AttainmentRate = attainmentRate(row.Resolution)
+	return *row
+}
+
+// attainmentRate is the met share of the decided tickets as a percentage with
+// one decimal, or nil when none is decided yet.
+func attainmentRate(stats models.SLATargetStats) *float64 {
+	decided := stats.Met + stats.Breached
+	if decided == 0 {
+		return nil
+	}
+	rate := math.Round(float64(stats.Met)/float64(decided)*1000) / 10
+	return &rate
+}
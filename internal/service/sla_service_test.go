@@ -0,0 +1,201 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type SLAServiceTestSuite struct {
+	suite.Suite
+	mockRepo     *mocks.SLARepository
+	mockActivity *mocks.ActivityService
+	mockAudit    *mocks.AuditService
+	service      SLAService
+}
+
+func (suite *SLAServiceTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+}
+
+func (suite *SLAServiceTestSuite) SetupTest() {
+	suite.mockRepo = new(mocks.SLARepository)
+	suite.mockActivity = new(mocks.ActivityService)
+	suite.mockAudit = new(mocks.AuditService)
+	suite.service = NewSLAService(suite.mockRepo, suite.mockAudit, WithActivityFeed(suite.mockActivity))
+}
+
+func (suite *SLAServiceTestSuite) TearDownTest() {
+	suite.mockRepo.AssertExpectations(suite.T())
+	suite.mockActivity.AssertExpectations(suite.T())
+}
+
+func (suite *SLAServiceTestSuite) TestSavePolicy_FillsCalendarDefaults() {
+	policy := &models.SLAPolicy{Priority: models.TicketPriorityHigh, FirstResponseMinutes: 60, ResolutionMinutes: 480}
+	suite.mockRepo.On("SavePolicy", policy).Return(nil)
+
+	err := suite.service.SavePolicy(policy)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "UTC", policy.Timezone)
+	assert.Equal(suite.T(), "09:00", policy.BusinessDayStart)
+	assert.Equal(suite.T(), "17:00", policy.BusinessDayEnd)
+	assert.Equal(suite.T(), []int{1, 2, 3, 4, 5}, policy.BusinessDays)
+}
+
+func (suite *SLAServiceTestSuite) TestSavePolicy_ValidationErrors() {
+	valid := func() models.SLAPolicy {
+		return models.SLAPolicy{Priority: models.TicketPriorityHigh, FirstResponseMinutes: 60, ResolutionMinutes: 480}
+	}
+	cases := map[string]func(p *models.SLAPolicy){
+		"unknown priority":         func(p *models.SLAPolicy) { p.Priority = "critical" },
+		"zero first response":      func(p *models.SLAPolicy) { p.FirstResponseMinutes = 0 },
+		"resolution above the cap": func(p *models.SLAPolicy) { p.ResolutionMinutes = MaxSLATargetMinutes + 1 },
+		"resolution before reply":  func(p *models.SLAPolicy) { p.ResolutionMinutes = 30 },
+		"unknown timezone":         func(p *models.SLAPolicy) { p.Timezone = "Mars/Olympus" },
+		"malformed day start":      func(p *models.SLAPolicy) { p.BusinessDayStart = "9am" },
+		"day ending before start":  func(p *models.SLAPolicy) { p.BusinessDayStart, p.BusinessDayEnd = "17:00", "09:00" },
+		"weekday out of range":     func(p *models.SLAPolicy) { p.BusinessDays = []int{1, 7} },
+	}
+	for name, mutate := range cases {
+		policy := valid()
+		mutate(&policy)
+		err := suite.service.SavePolicy(&policy)
+		assert.ErrorIs(suite.T(), err, apperrors.ErrValidation, name)
+	}
+	suite.mockRepo.AssertNotCalled(suite.T(), "SavePolicy", mock.Anything)
+}
+
+func (suite *SLAServiceTestSuite) TestDeletePolicy_NotFound() {
+	suite.mockRepo.On("DeletePolicy", models.TicketPriorityLow).Return(gorm.ErrRecordNotFound)
+
+	err := suite.service.DeletePolicy(models.TicketPriorityLow)
+
+	assert.ErrorIs(suite.T(), err, apperrors.ErrNotFound)
+}
+
+func (suite *SLAServiceTestSuite) TestApplyDeadlines_FromCreationTime() {
+	opened := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC) // a Monday
+	policy := &models.SLAPolicy{
+		Priority: models.TicketPriorityHigh, FirstResponseMinutes: 60, ResolutionMinutes: 480,
+		BusinessHoursOnly: true, Timezone: "UTC", BusinessDayStart: "09:00", BusinessDayEnd: "17:00",
+		BusinessDays: []int{1, 2, 3, 4, 5},
+	}
+	suite.mockRepo.On("GetPolicy", models.TicketPriorityHigh).Return(policy, nil)
+	ticket := &models.Ticket{Priority: models.TicketPriorityHigh, FirstResponseBreached: true}
+	ticket.CreatedAt = opened
+
+	err := suite.service.ApplyDeadlines(ticket)
+
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), opened.Add(time.Hour), *ticket.FirstResponseDueAt)
+	// Seven hours are left on Monday; the eighth falls on Tuesday morning.
+	assert.Equal(suite.T(), time.Date(2026, 3, 3, 10, 0, 0, 0, time.UTC), *ticket.ResolutionDueAt)
+	assert.False(suite.T(), ticket.FirstResponseBreached)
+}
+
+func (suite *SLAServiceTestSuite) TestApplyDeadlines_NoPolicyClearsDeadlines() {
+	due := time.Now()
+	suite.mockRepo.On("GetPolicy", models.TicketPriorityLow).Return(nil, gorm.ErrRecordNotFound)
+	ticket := &models.Ticket{Priority: models.TicketPriorityLow, FirstResponseDueAt: &due, ResolutionDueAt: &due}
+
+	err := suite.service.ApplyDeadlines(ticket)
+
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), ticket.FirstResponseDueAt)
+	assert.Nil(suite.T(), ticket.ResolutionDueAt)
+}
+
+func (suite *SLAServiceTestSuite) TestFlagBreaches_RecordsActivityAndAudit() {
+	assignee := uint(4)
+	ticket := models.Ticket{Title: "Outage", AssignedToID: &assignee}
+	ticket.ID = 9
+	now := time.Now()
+	suite.mockRepo.On("FlagBreaches", now.UTC()).Return([]models.SLABreach{
+		{Ticket: ticket, Target: models.SLATargetFirstResponse},
+		{Ticket: ticket, Target: models.SLATargetResolution},
+	}, nil)
+	suite.mockActivity.On("Record", mock.MatchedBy(func(e *models.ActivityEvent) bool {
+		return e.Type == models.ActivityTicketSLABreached && e.EntityID == 9 && *e.OwnerID == 4 &&
+			e.Title == "First response SLA breached"
+	})).Return(nil).Once()
+	suite.mockActivity.On("Record", mock.MatchedBy(func(e *models.ActivityEvent) bool {
+		return e.Type == models.ActivityTicketSLABreached && e.Title == "Resolution SLA breached"
+	})).Return(nil).Once()
+	system := models.AuditActor{Type: models.AuditActorSystem}
+	for _, flag := range []string{"first_response_breached", "resolution_breached"} {
+		suite.mockAudit.On("Record", system, models.AuditEntityTicket, uint(9), models.AuditActionUpdate,
+			map[string]interface{}{flag: false}, map[string]interface{}{flag: true}).Return(nil).Once()
+	}
+
+	flagged, err := suite.service.FlagBreaches(now)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, flagged)
+	suite.mockAudit.AssertExpectations(suite.T())
+}
+
+func (suite *SLAServiceTestSuite) TestAttainmentByPriority() {
+	now := time.Now().UTC()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	early, late := past.Add(-time.Minute), past.Add(time.Minute)
+	tickets := []models.Ticket{
+		// Met, then breached by a late resolution.
+		{Priority: models.TicketPriorityHigh, FirstResponseDueAt: &past, FirstRespondedAt: &early, ResolutionDueAt: &past, ResolvedAt: &late},
+		// Breached by an unreached deadline, resolution still pending.
+		{Priority: models.TicketPriorityHigh, FirstResponseDueAt: &past, ResolutionDueAt: &future},
+		{Priority: models.TicketPriorityUrgent, FirstResponseDueAt: &future, ResolutionDueAt: &future},
+	}
+	from, to := now.Add(-24*time.Hour), now
+	suite.mockRepo.On("ListForReport", from, to).Return(tickets, nil)
+
+	rows, err := suite.service.AttainmentByPriority(from, to)
+
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), rows, 2)
+	assert.Equal(suite.T(), models.TicketPriorityUrgent, rows[0].Priority)
+	assert.Nil(suite.T(), rows[0].FirstResponse.AttainmentRate)
+	assert.Equal(suite.T(), int64(1), rows[0].FirstResponse.Pending)
+
+	high := rows[1]
+	assert.Equal(suite.T(), int64(2), high.Tickets)
+	assert.Equal(suite.T(), models.SLATargetStats{Met: 1, Breached: 1, AttainmentRate: high.FirstResponse.AttainmentRate}, high.FirstResponse)
+	assert.Equal(suite.T(), 50.0, *high.FirstResponse.AttainmentRate)
+	assert.Equal(suite.T(), int64(1), high.Resolution.Breached)
+	assert.Equal(suite.T(), int64(1), high.Resolution.Pending)
+	assert.Equal(suite.T(), 0.0, *high.Resolution.AttainmentRate)
+}
+
+func (suite *SLAServiceTestSuite) TestAttainmentByAssignee_UnassignedLast() {
+	due := time.Now().Add(time.Hour)
+	alice := models.User{FirstName: "Alice", LastName: "Ames", Email: "alice@example.com"}
+	alice.ID = 7
+	tickets := []models.Ticket{
+		{FirstResponseDueAt: &due},
+		{FirstResponseDueAt: &due, AssignedToID: &alice.ID, AssignedTo: &alice},
+	}
+	suite.mockRepo.On("ListForReport", mock.Anything, mock.Anything).Return(tickets, nil)
+
+	rows, err := suite.service.AttainmentByAssignee(time.Now().Add(-time.Hour), time.Now())
+
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), rows, 2)
+	assert.Equal(suite.T(), uint(7), *rows[0].AssigneeID)
+	assert.Equal(suite.T(), "Alice Ames", rows[0].AssigneeName)
+	assert.Nil(suite.T(), rows[1].AssigneeID)
+	assert.Equal(suite.T(), "Unassigned", rows[1].AssigneeName)
+}
+
+func TestSLAServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(SLAServiceTestSuite))
+}
@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/oidc"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+)
+
+var (
+	// ErrSSODisabled means no identity provider is configured.
+	ErrSSODisabled = errors.New("single sign-on is not configured")
+	// ErrInvalidSSOState covers every rejected callback that does not match
+	// an attempt in progress: unknown or expired session, state mismatch, or
+	// an attempt that already completed.
+	ErrInvalidSSOState = errors.New("invalid or expired single sign-on attempt")
+	// ErrSSOAccountNotAllowed means the provider vouched for someone who may
+	// not sign in here: an email outside the allowed domains or not verified,
+	// no role for their claims, no account and no provisioning, an account
+	// that cannot be linked, or a deactivated account.
+	ErrSSOAccountNotAllowed = errors.New("account is not allowed to sign in with single sign-on")
+	// ErrSSORequired refuses a password login or reset for an email in a
+	// domain that must sign in through the provider.
+	ErrSSORequired = errors.New("this account signs in with single sign-on")
+)
+
+// SSOLoginTTL is how long the browser may take at the provider between
+// Start and Complete.
+const SSOLoginTTL = 10 * time.Minute
+
+// IdentityProvider is the OpenID Connect provider single sign-on goes
+// through; *oidc.Provider implements it.
+type IdentityProvider interface {
+	AuthCodeURL(ctx context.Context, state, nonce, challenge string) (string, error)
+	Exchange(ctx context.Context, code, verifier, nonce string) (*oidc.IDToken, error)
+}
+
+// SSOService runs the authorization-code flow with PKCE for staff signing in
+// through the company identity provider, and maps who the provider vouches
+// for onto a user: the account linked to their subject, else the account with
+// their verified email, else a new one when provisioning is on.
+type SSOService interface {
+	// Enabled reports whether a provider is configured.
+	Enabled() bool
+	// Start begins an attempt and returns where to send the browser, and the
+	// session handle the callback must present.
+	Start(ctx context.Context) (*models.SSOStart, error)
+	// Complete redeems the provider's redirect and logs the user in from
+	// client. SSO logins skip the local second factor: the provider's own
+	// policy applies.
+	Complete(ctx context.Context, session, state, code string, client ClientInfo) (*AuthTokens, error)
+}
+
+type ssoService struct {
+	provider      IdentityProvider
+	ssoRepo       repository.SSORepository
+	userRepo      repository.UserRepository
+	roleService   RoleService
+	authService   AuthService
+	config        config.OIDCConfig
+	sessionSecret string
+	auditRecorder
+}
+
+// NewSSOService builds the service. provider is nil when single sign-on is
+// not configured, and every call then fails with ErrSSODisabled. The accounts
+// it provisions and the roles it changes from the provider's claims are
+// audited as the system's doing.
+func NewSSOService(
+	provider IdentityProvider,
+	ssoRepo repository.SSORepository,
+	userRepo repository.UserRepository,
+	roleService RoleService,
+	authService AuthService,
+	auditService AuditService,
+	cfg config.OIDCConfig,
+	sessionSecret string,
+) SSOService {
+	return &ssoService{
+		provider:      provider,
+		ssoRepo:       ssoRepo,
+		userRepo:      userRepo,
+		roleService:   roleService,
+		authService:   authService,
+		config:        cfg,
+		sessionSecret: sessionSecret,
+		auditRecorder: auditRecorder{auditService: auditService},
+	}
+}
+
+func (s *ssoService) Enabled() bool {
+	return s.provider != nil
+}
+
+func (s *ssoService) Start(ctx context.Context) (*models.SSOStart, error) {
+	if !s.Enabled() {
+		return nil, ErrSSODisabled
+	}
+
+	session, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	var values [3]string
+	for i := range values {
+		if values[i], err = oidc.RandomString(); err != nil {
+			return nil, err
+		}
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+
+	authURL, err := s.provider.AuthCodeURL(ctx, state, nonce, oidc.CodeChallenge(verifier))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ssoRepo.CreateLoginState(&models.SSOLoginState{
+		SessionHash:  hashOpaqueToken(session, s.sessionSecret),
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(SSOLoginTTL),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to store single sign-on attempt: %w", err)
+	}
+	return &models.SSOStart{AuthorizationURL: authURL, Session: session}, nil
+}
+
+func (s *ssoService) Complete(ctx context.Context, session, state, code string, client ClientInfo) (*AuthTokens, error) {
+	if !s.Enabled() {
+		return nil, ErrSSODisabled
+	}
+	if session == "" || state == "" || code == "" {
+		return nil, ErrInvalidSSOState
+	}
+
+	attempt, err := s.ssoRepo.ConsumeLoginState(hashOpaqueToken(session, s.sessionSecret))
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrInvalidSSOState
+		}
+		return nil, fmt.Errorf("failed to load single sign-on attempt: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(attempt.State), []byte(state)) != 1 {
+		return nil, ErrInvalidSSOState
+	}
+
+	token, err := s.provider.Exchange(ctx, code, attempt.CodeVerifier, attempt.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.resolveUser(token)
+	if err != nil {
+		return nil, err
+	}
+	return s.authService.LoginWithSSO(user, client)
+}
+
+// resolveUser finds, links or provisions the user the provider vouched for,
+// and brings their role in line with the mapping when roles are synced.
+func (s *ssoService) resolveUser(token *oidc.IDToken) (*models.User, error) {
+	logger := utils.Logger.WithField("sso_subject", token.Subject)
+
+	email := strings.TrimSpace(token.Email)
+	if email == "" || !token.EmailVerified {
+		logger.Warn("SSO sign-in refused - no verified email")
+		return nil, fmt.Errorf("%w: no verified email", ErrSSOAccountNotAllowed)
+	}
+	if !s.domainAllowed(email) {
+		logger.WithField("email", email).Warn("SSO sign-in refused - domain not allowed")
+		return nil, fmt.Errorf("%w: domain not allowed", ErrSSOAccountNotAllowed)
+	}
+	role, err := s.mapRole(token)
+	if err != nil {
+		logger.WithError(err).Warn("SSO sign-in refused - no role")
+		return nil, err
+	}
+
+	user, err := s.ssoRepo.GetUserBySubject(s.config.Issuer, token.Subject)
+	switch {
+	case err == nil:
+	case !isNotFound(err):
+		return nil, fmt.Errorf("failed to look up linked user: %w", err)
+	default:
+		if user, err = s.linkOrProvision(token, email, role); err != nil {
+			return nil, err
+		}
+	}
+
+	if !user.IsActive {
+		logger.WithField("user_id", user.ID).Warn("SSO sign-in refused - account disabled")
+		return nil, fmt.Errorf("%w: account disabled", ErrSSOAccountNotAllowed)
+	}
+	if s.config.SyncRoles && user.Role != role {
+		logger.WithFields(map[string]interface{}{"user_id": user.ID, "from": user.Role, "to": role}).
+			Info("Role updated from identity provider claims")
+		before := AuditSnapshot(user)
+		user.Role = role
+		if err := s.userRepo.Update(user); err != nil {
+			return nil, fmt.Errorf("failed to update role: %w", err)
+		}
+		s.recordAudit(systemAuditActor, models.AuditEntityUser, user.ID, models.AuditActionUpdate, before, user)
+	}
+	return user, nil
+}
+
+// linkOrProvision links the account with the token's email, or creates one.
+// An account already linked to another subject of this provider is not
+// taken over, and neither is a customer account: self-service registration
+// does not verify the address, so whoever registered it first would keep its
+// password on what becomes a staff account. Nor is an account whose role the
+// mapping would change: linking by email must not be a way into another
+// role, so such an account is linked only once its role here matches.
+func (s *ssoService) linkOrProvision(token *oidc.IDToken, email string, role models.UserRole) (*models.User, error) {
+	logger := utils.Logger.WithField("sso_subject", token.Subject)
+
+	user, err := s.userRepo.GetByEmailUnscoped(email)
+	switch {
+	case err == nil:
+		if user.DeletedAt.Valid || user.SSOSubject != "" || user.Role == models.RoleCustomer {
+			logger.WithField("user_id", user.ID).Warn("SSO sign-in refused - email belongs to another account")
+			return nil, fmt.Errorf("%w: email belongs to another account", ErrSSOAccountNotAllowed)
+		}
+		if user.Role != role {
+			logger.WithFields(map[string]interface{}{"user_id": user.ID, "role": user.Role, "mapped_role": role}).
+				Warn("SSO sign-in refused - linking would change the account's role")
+			return nil, fmt.Errorf("%w: role differs from the mapped role", ErrSSOAccountNotAllowed)
+		}
+		if err := s.ssoRepo.LinkUser(user.ID, s.config.Issuer, token.Subject); err != nil {
+			return nil, fmt.Errorf("failed to link user: %w", err)
+		}
+		user.SSOIssuer, user.SSOSubject = s.config.Issuer, token.Subject
+		logger.WithField("user_id", user.ID).Info("Existing user linked to identity provider")
+		return user, nil
+	case !isNotFound(err):
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+
+	if !s.config.JITProvisioning {
+		logger.Warn("SSO sign-in refused - no account and provisioning is off")
+		return nil, fmt.Errorf("%w: no account", ErrSSOAccountNotAllowed)
+	}
+
+	firstName, lastName := token.GivenName, token.FamilyName
+	if firstName == "" && lastName == "" {
+		firstName, lastName, _ = strings.Cut(strings.TrimSpace(token.Name), " ")
+	}
+	if firstName == "" {
+		firstName, _, _ = strings.Cut(email, "@")
+	}
+	user = &models.User{
+		Email:      email,
+		FirstName:  firstName,
+		LastName:   lastName,
+		Role:       role,
+		IsActive:   true,
+		SSOIssuer:  s.config.Issuer,
+		SSOSubject: token.Subject,
+	}
+	// The account gets a random password nobody knows; it signs in through
+	// the provider, or sets a password with a reset when that is allowed.
+	password, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := user.SetPassword(password); err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, fmt.Errorf("failed to provision user: %w", err)
+	}
+	logger.WithField("user_id", user.ID).Info("User provisioned from identity provider")
+	s.recordAudit(systemAuditActor, models.AuditEntityUser, user.ID, models.AuditActionCreate, nil, user)
+	return user, nil
+}
+
+// mapRole picks the role for the token's claims: the first mapping whose
+// value the role claim holds, else the default role. The role must exist.
+func (s *ssoService) mapRole(token *oidc.IDToken) (models.UserRole, error) {
+	role := s.config.DefaultRole
+	values := token.Values(s.config.RoleClaim)
+mappings:
+	for _, mapping := range s.config.RoleMapping {
+		for _, value := range values {
+			if value == mapping.Value {
+				role = mapping.Role
+				break mappings
+			}
+		}
+	}
+	if role == "" {
+		return "", fmt.Errorf("%w: no role mapped", ErrSSOAccountNotAllowed)
+	}
+	if _, _, err := s.roleService.Resolve(models.UserRole(role)); err != nil {
+		utils.Logger.WithError(err).WithField("role", role).Error("SSO role mapping names a role that cannot be resolved")
+		return "", fmt.Errorf("%w: role %q is not available", ErrSSOAccountNotAllowed, role)
+	}
+	return models.UserRole(role), nil
+}
+
+func (s *ssoService) domainAllowed(email string) bool {
+	return len(s.config.Domains) == 0 || emailInDomains(email, s.config.Domains)
+}
+
+// emailInDomains reports whether the email's domain is one of domains, which
+// are lower-case.
+func emailInDomains(email string, domains []string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(strings.TrimSpace(email[at+1:]))
+	for _, d := range domains {
+		if domain == d {
+			return true
+		}
+	}
+	return false
+}
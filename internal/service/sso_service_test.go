@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/oidc"
+	"github.com/florinel-chis/gophercrm/internal/oidc/oidctest"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const ssoTestSecret = "sso-test-secret"
+
+// SSOServiceSuite signs in through a mock identity provider, against real
+// repositories on an in-memory SQLite database.
+type SSOServiceSuite struct {
+	suite.Suite
+	db     *gorm.DB
+	idp    *oidctest.Server
+	config config.OIDCConfig
+}
+
+func TestSSOServiceSuite(t *testing.T) {
+	suite.Run(t, new(SSOServiceSuite))
+}
+
+func (s *SSOServiceSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "error", Format: "json"})
+	s.idp = oidctest.NewServer("gophercrm", "client-secret")
+}
+
+func (s *SSOServiceSuite) TearDownSuite() {
+	s.idp.Close()
+}
+
+func (s *SSOServiceSuite) SetupTest() {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	s.Require().NoError(err)
+	s.Require().NoError(db.AutoMigrate(&models.User{}, &models.RefreshToken{}, &models.SSOLoginState{},
+		&models.Role{}, &models.AuditEvent{}))
+	s.db = db
+
+	s.config = config.OIDCConfig{
+		Issuer:          s.idp.URL,
+		ClientID:        "gophercrm",
+		RoleClaim:       "groups",
+		RoleMapping:     []config.OIDCRoleMapping{{Value: "crm-admins", Role: "admin"}, {Value: "crm-sales", Role: "sales"}},
+		Domains:         []string{"example.com"},
+		JITProvisioning: true,
+	}
+}
+
+func (s *SSOServiceSuite) newService(authOpts ...AuthOption) SSOService {
+	userRepo := repository.NewUserRepository(s.db)
+	auth := NewAuthServiceWithSessions(userRepo, nil, repository.NewRefreshTokenRepository(s.db), nil, nil,
+		config.JWTConfig{Secret: ssoTestSecret, ExpiryHours: 1, RefreshTokenDays: 30},
+		"http://localhost:5173", ssoTestSecret, authOpts...)
+	provider := oidc.NewProvider(s.idp.Config("http://localhost:5173/auth/sso/callback"))
+	return NewSSOService(provider, repository.NewSSORepository(s.db), userRepo,
+		NewRoleService(repository.NewRoleRepository(s.db)), auth,
+		NewAuditService(repository.NewAuditRepository(s.db)), s.config, ssoTestSecret)
+}
+
+// signIn runs a whole sign-in as identity.
+func (s *SSOServiceSuite) signIn(sso SSOService, identity oidctest.Identity) (*AuthTokens, error) {
+	start, err := sso.Start(context.Background())
+	s.Require().NoError(err)
+	code, state, err := s.idp.Authorize(start.AuthorizationURL, identity)
+	s.Require().NoError(err)
+	return sso.Complete(context.Background(), start.Session, state, code, ClientInfo{})
+}
+
+func staff(subject, email string, groups ...string) oidctest.Identity {
+	return oidctest.Identity{
+		Subject: subject, Email: email, EmailVerified: true, GivenName: "Grace", FamilyName: "Hopper",
+		Claims: map[string]interface{}{"groups": groups},
+	}
+}
+
+func (s *SSOServiceSuite) createUser(email string, role models.UserRole) *models.User {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("Str0ng!Passw0rd"), bcrypt.MinCost)
+	s.Require().NoError(err)
+	user := &models.User{Email: email, Password: string(hashed), FirstName: "Local", LastName: "User",
+		Role: role, IsActive: true}
+	s.Require().NoError(s.db.Create(user).Error)
+	return user
+}
+
+func (s *SSOServiceSuite) TestProvisionsOnFirstSignInAndSyncsTheRole() {
+	s.config.SyncRoles = true
+	sso := s.newService()
+
+	tokens, err := s.signIn(sso, staff("sub-1", "grace@example.com", "staff", "crm-sales"))
+	s.Require().NoError(err)
+	s.NotEmpty(tokens.AccessToken)
+	s.NotEmpty(tokens.RefreshToken)
+	s.Equal(models.RoleSales, tokens.User.Role)
+
+	var user models.User
+	s.Require().NoError(s.db.Where("email = ?", "grace@example.com").First(&user).Error)
+	s.Equal("Grace", user.FirstName)
+	s.Equal("Hopper", user.LastName)
+	s.Equal(s.idp.URL, user.SSOIssuer)
+	s.Equal("sub-1", user.SSOSubject)
+	s.True(user.IsActive)
+	s.NotNil(user.LastLoginAt)
+
+	// The mapping is applied again at every sign-in; the first match wins.
+	tokens, err = s.signIn(sso, staff("sub-1", "grace@example.com", "crm-sales", "crm-admins"))
+	s.Require().NoError(err)
+	s.Equal(user.ID, tokens.User.ID)
+	s.Equal(models.RoleAdmin, tokens.User.Role)
+
+	var count int64
+	s.Require().NoError(s.db.Model(&models.User{}).Count(&count).Error)
+	s.Equal(int64(1), count)
+
+	// Nobody signed in made either change, so both are the system's.
+	var events []models.AuditEvent
+	s.Require().NoError(s.db.Order("id").Find(&events).Error)
+	s.Require().Len(events, 2)
+	s.Equal(models.AuditActionCreate, events[0].Action)
+	s.Equal(user.ID, events[0].EntityID)
+	s.Equal(models.AuditActorSystem, events[0].ActorType)
+	s.Nil(events[0].ActorUserID)
+	s.Equal(models.AuditActionUpdate, events[1].Action)
+	s.Equal(models.AuditActorSystem, events[1].ActorType)
+	s.Equal(models.AuditChange{Before: string(models.RoleSales), After: string(models.RoleAdmin)}, events[1].Changes["role"])
+}
+
+// Without role sync, the mapping sets the role of the accounts it provisions
+// and nothing else.
+func (s *SSOServiceSuite) TestKeepsTheRoleWithoutSync() {
+	sso := s.newService()
+
+	_, err := s.signIn(sso, staff("sub-1", "grace@example.com", "crm-sales"))
+	s.Require().NoError(err)
+	tokens, err := s.signIn(sso, staff("sub-1", "grace@example.com", "crm-admins"))
+	s.Require().NoError(err)
+	s.Equal(models.RoleSales, tokens.User.Role)
+
+	var events []models.AuditEvent
+	s.Require().NoError(s.db.Find(&events).Error)
+	s.Len(events, 1, "only the provisioning")
+}
+
+func (s *SSOServiceSuite) TestLinksAnExistingStaffAccountByEmail() {
+	existing := s.createUser("ops@example.com", models.RoleSales)
+	existing.TwoFactorEnabled = true
+	s.Require().NoError(s.db.Save(existing).Error)
+	sso := s.newService()
+
+	tokens, err := s.signIn(sso, staff("sub-ops", "ops@example.com", "crm-sales"))
+	s.Require().NoError(err, "the provider stands in for the local second factor")
+	s.Equal(existing.ID, tokens.User.ID)
+	s.Equal(models.RoleSales, tokens.User.Role)
+
+	var linked models.User
+	s.Require().NoError(s.db.First(&linked, existing.ID).Error)
+	s.Equal("sub-ops", linked.SSOSubject)
+
+	// Another subject with the same email cannot take the account over.
+	_, err = s.signIn(sso, staff("sub-impostor", "ops@example.com", "crm-sales"))
+	s.ErrorIs(err, ErrSSOAccountNotAllowed)
+}
+
+// Linking by email is no way into another role, whether or not roles are
+// synced afterwards.
+func (s *SSOServiceSuite) TestDoesNotLinkWhenTheRoleWouldChange() {
+	for _, sync := range []bool{false, true} {
+		s.SetupTest()
+		s.config.SyncRoles = sync
+		existing := s.createUser("ops@example.com", models.RoleSales)
+
+		_, err := s.signIn(s.newService(), staff("sub-ops", "ops@example.com", "crm-admins"))
+		s.ErrorIs(err, ErrSSOAccountNotAllowed, "sync=%v", sync)
+
+		var stored models.User
+		s.Require().NoError(s.db.First(&stored, existing.ID).Error)
+		s.Empty(stored.SSOSubject, "sync=%v", sync)
+		s.Equal(models.RoleSales, stored.Role, "sync=%v", sync)
+	}
+}
+
+func (s *SSOServiceSuite) TestDoesNotLinkSelfRegisteredCustomers() {
+	s.createUser("early@example.com", models.RoleCustomer)
+	_, err := s.signIn(s.newService(), staff("sub-2", "early@example.com", "crm-admins"))
+	s.ErrorIs(err, ErrSSOAccountNotAllowed)
+}
+
+func (s *SSOServiceSuite) TestRefusesAccountsThatMayNotSignIn() {
+	sso := s.newService()
+
+	unverified := staff("sub-3", "grace@example.com", "crm-sales")
+	unverified.EmailVerified = false
+	_, err := s.signIn(sso, unverified)
+	s.ErrorIs(err, ErrSSOAccountNotAllowed, "unverified email")
+
+	_, err = s.signIn(sso, staff("sub-4", "grace@elsewhere.org", "crm-sales"))
+	s.ErrorIs(err, ErrSSOAccountNotAllowed, "domain not allowed")
+
+	_, err = s.signIn(sso, staff("sub-5", "grace@example.com", "marketing"))
+	s.ErrorIs(err, ErrSSOAccountNotAllowed, "no role mapped and no default")
+
+	disabled := s.createUser("gone@example.com", models.RoleSales)
+	s.Require().NoError(s.db.Model(disabled).Update("is_active", false).Error)
+	_, err = s.signIn(sso, staff("sub-6", "gone@example.com", "crm-sales"))
+	s.ErrorIs(err, ErrSSOAccountNotAllowed, "deactivated account")
+
+	var count int64
+	s.Require().NoError(s.db.Model(&models.User{}).Count(&count).Error)
+	s.Equal(int64(1), count, "nobody was provisioned")
+}
+
+func (s *SSOServiceSuite) TestDefaultRoleAndUnknownRoles() {
+	s.config.DefaultRole = "support"
+	tokens, err := s.signIn(s.newService(), staff("sub-7", "new@example.com", "marketing"))
+	s.Require().NoError(err)
+	s.Equal(models.RoleSupport, tokens.User.Role)
+
+	s.config.RoleMapping = []config.OIDCRoleMapping{{Value: "marketing", Role: "marketing-lead"}}
+	_, err = s.signIn(s.newService(), staff("sub-8", "other@example.com", "marketing"))
+	s.ErrorIs(err, ErrSSOAccountNotAllowed, "a mapping to a role that does not exist")
+
+	s.Require().NoError(s.db.Create(&models.Role{Name: "marketing-lead", BaseRole: models.RoleSales}).Error)
+	tokens, err = s.signIn(s.newService(), staff("sub-8", "other@example.com", "marketing"))
+	s.Require().NoError(err, "custom roles can be mapped")
+	s.Equal(models.UserRole("marketing-lead"), tokens.User.Role)
+}
+
+func (s *SSOServiceSuite) TestWithoutProvisioningOnlyExistingUsersSignIn() {
+	s.config.JITProvisioning = false
+	sso := s.newService()
+
+	_, err := s.signIn(sso, staff("sub-9", "new@example.com", "crm-sales"))
+	s.ErrorIs(err, ErrSSOAccountNotAllowed)
+
+	s.createUser("new@example.com", models.RoleSales)
+	_, err = s.signIn(sso, staff("sub-9", "new@example.com", "crm-sales"))
+	s.NoError(err)
+}
+
+func (s *SSOServiceSuite) TestAttemptsCompleteOnce() {
+	sso := s.newService()
+	identity := staff("sub-10", "grace@example.com", "crm-sales")
+	issued := s.idp.IssuedTokens()
+
+	start, err := sso.Start(context.Background())
+	s.Require().NoError(err)
+	code, state, err := s.idp.Authorize(start.AuthorizationURL, identity)
+	s.Require().NoError(err)
+
+	_, err = sso.Complete(context.Background(), "another-browser", state, code, ClientInfo{})
+	s.ErrorIs(err, ErrInvalidSSOState)
+
+	_, err = sso.Complete(context.Background(), start.Session, "forged-state", code, ClientInfo{})
+	s.ErrorIs(err, ErrInvalidSSOState)
+	_, err = sso.Complete(context.Background(), start.Session, state, code, ClientInfo{})
+	s.ErrorIs(err, ErrInvalidSSOState, "a failed callback spends the attempt")
+	s.Equal(issued, s.idp.IssuedTokens(), "the code was never redeemed")
+
+	start, err = sso.Start(context.Background())
+	s.Require().NoError(err)
+	code, state, err = s.idp.Authorize(start.AuthorizationURL, identity)
+	s.Require().NoError(err)
+	_, err = sso.Complete(context.Background(), start.Session, state, code, ClientInfo{})
+	s.Require().NoError(err)
+	_, err = sso.Complete(context.Background(), start.Session, state, code, ClientInfo{})
+	s.ErrorIs(err, ErrInvalidSSOState)
+}
+
+func (s *SSOServiceSuite) TestAuthorizationRequest() {
+	start, err := s.newService().Start(context.Background())
+	s.Require().NoError(err)
+	s.NotEmpty(start.Session)
+
+	u, err := url.Parse(start.AuthorizationURL)
+	s.Require().NoError(err)
+	q := u.Query()
+	s.Equal("S256", q.Get("code_challenge_method"))
+	s.NotEmpty(q.Get("state"))
+	s.NotEmpty(q.Get("nonce"))
+
+	var attempt models.SSOLoginState
+	s.Require().NoError(s.db.First(&attempt).Error)
+	s.NotEqual(start.Session, attempt.SessionHash, "only the hash of the session handle is stored")
+	s.Equal(oidc.CodeChallenge(attempt.CodeVerifier), q.Get("code_challenge"))
+}
+
+func (s *SSOServiceSuite) TestDisabled() {
+	sso := NewSSOService(nil, repository.NewSSORepository(s.db), repository.NewUserRepository(s.db),
+		nil, nil, nil, config.OIDCConfig{}, ssoTestSecret)
+	s.False(sso.Enabled())
+	_, err := sso.Start(context.Background())
+	s.ErrorIs(err, ErrSSODisabled)
+	_, err = sso.Complete(context.Background(), "s", "s", "c", ClientInfo{})
+	s.ErrorIs(err, ErrSSODisabled)
+}
+
+func (s *SSOServiceSuite) TestEnforcementDisablesPasswordLogin() {
+	s.createUser("staff@example.com", models.RoleSales)
+	s.createUser("partner@partner.org", models.RoleSales)
+	auth := NewAuthServiceWithSessions(repository.NewUserRepository(s.db), nil, nil, nil, nil,
+		config.JWTConfig{Secret: ssoTestSecret, ExpiryHours: 1}, "http://localhost:5173", ssoTestSecret,
+		WithSSOEnforcement([]string{"example.com"}))
+
+	_, err := auth.LoginWithTokens("staff@example.com", "Str0ng!Passw0rd", ClientInfo{})
+	s.ErrorIs(err, ErrSSORequired)
+	_, err = auth.LoginWithTokens("Someone@EXAMPLE.com", "whatever", ClientInfo{})
+	s.ErrorIs(err, ErrSSORequired, "decided by the domain, whether or not the account exists")
+	_, err = auth.Login("staff@example.com", "Str0ng!Passw0rd")
+	s.True(errors.Is(err, ErrSSORequired))
+
+	tokens, err := auth.LoginWithTokens("partner@partner.org", "Str0ng!Passw0rd", ClientInfo{})
+	s.Require().NoError(err, "other domains keep their passwords")
+	s.NotEmpty(tokens.AccessToken)
+}
@@ -16,16 +16,19 @@ type taskService struct {
 	leadRepo     repository.LeadRepository
 	customerRepo repository.CustomerRepository
 	labelRepo    repository.LabelRepository
+	activityFeed
 }
 
-func NewTaskService(taskRepo repository.TaskRepository, userRepo repository.UserRepository, leadRepo repository.LeadRepository, customerRepo repository.CustomerRepository, labelRepo repository.LabelRepository) TaskService {
-	return &taskService{
+func NewTaskService(taskRepo repository.TaskRepository, userRepo repository.UserRepository, leadRepo repository.LeadRepository, customerRepo repository.CustomerRepository, labelRepo repository.LabelRepository, opts ...ActivityOption) TaskService {
+	s := &taskService{
 		taskRepo:     taskRepo,
 		userRepo:     userRepo,
 		leadRepo:     leadRepo,
 		customerRepo: customerRepo,
 		labelRepo:    labelRepo,
 	}
+	s.applyActivityOptions(opts)
+	return s
 }
 
 // Create persists a task without touching its label set.
@@ -102,6 +105,8 @@ func (s *taskService) CreateWithLabels(task *models.Task, labelIDs []uint) error
 		"task_id":    task.ID,
 		"task_title": task.Title,
 	}).Info("Task created successfully")
+	s.recordActivity(models.AuditEntityTask, task.ID, models.ActivityTaskCreated, activityOwner(task.AssignedToID),
+		"New task", task.Title)
 
 	return nil
 }
@@ -174,6 +179,7 @@ func (s *taskService) UpdateWithLabels(task *models.Task, labelIDs *[]uint) erro
 		utils.LogServiceResponse(logger, err)
 		return err
 	}
+	previousStatus, previousAssigneeID := existingTask.Status, existingTask.AssignedToID
 
 	// Validate assignee if being changed
 	if task.AssignedToID != existingTask.AssignedToID {
@@ -225,6 +231,7 @@ func (s *taskService) UpdateWithLabels(task *models.Task, labelIDs *[]uint) erro
 			return err
 		}
 		logger.WithField("task_id", task.ID).Info("Task updated successfully")
+		s.recordTaskUpdate(task, previousStatus, previousAssigneeID)
 		return nil
 	}
 
@@ -240,9 +247,36 @@ func (s *taskService) UpdateWithLabels(task *models.Task, labelIDs *[]uint) erro
 	}
 
 	logger.WithField("task_id", task.ID).Info("Task updated successfully")
+	s.recordTaskUpdate(task, previousStatus, previousAssigneeID)
 	return nil
 }
 
+// recordTaskUpdate describes an update by what it changed, with completion
+// singled out the way resolution is for tickets.
+func (s *taskService) recordTaskUpdate(task *models.Task, previousStatus models.TaskStatus, previousAssigneeID uint) {
+	owner := activityOwner(task.AssignedToID)
+	changed := false
+	if task.Status != previousStatus {
+		if task.Status == models.TaskStatusCompleted {
+			s.recordActivity(models.AuditEntityTask, task.ID, models.ActivityTaskCompleted, owner,
+				"Task completed", task.Title)
+		} else {
+			s.recordActivity(models.AuditEntityTask, task.ID, models.ActivityTaskStatusChanged, owner,
+				"Task status changed", fmt.Sprintf("%s moved from %s to %s", task.Title, previousStatus, task.Status))
+		}
+		changed = true
+	}
+	if task.AssignedToID != previousAssigneeID {
+		s.recordActivity(models.AuditEntityTask, task.ID, models.ActivityTaskReassigned, owner,
+			"Task reassigned", task.Title)
+		changed = true
+	}
+	if !changed {
+		s.recordActivity(models.AuditEntityTask, task.ID, models.ActivityTaskUpdated, owner,
+			"Task updated", task.Title)
+	}
+}
+
 // resolveLabels turns a list of label ids into the labels themselves,
 // rejecting the whole request if any id matches nothing. Duplicated ids are
 // collapsed, so sending the same label twice attaches it once instead of
@@ -323,7 +357,7 @@ func (s *taskService) Delete(id uint) error {
 
 	// Check if task exists. Only an absent row is reported as not-found; a failed
 	// lookup is passed through unclassified so it surfaces as a server error.
-	_, err := s.taskRepo.GetByID(id)
+	task, err := s.taskRepo.GetByID(id)
 	if err != nil {
 		if isNotFound(err) {
 			logger.WithError(err).Warn("Task not found")
@@ -340,6 +374,8 @@ func (s *taskService) Delete(id uint) error {
 	}
 
 	logger.WithField("task_id", id).Info("Task deleted successfully")
+	s.recordActivity(models.AuditEntityTask, id, models.ActivityTaskDeleted, activityOwner(task.AssignedToID),
+		"Task deleted", task.Title)
 	return nil
 }
 
@@ -417,6 +453,53 @@ func (s *taskService) Search(query string, offset, limit int, sortBy, sortOrder
 	return tasks, total, nil
 }
 
+// ListFiltered returns one page of the tasks matching the filter, custom
+// field conditions included.
+func (s *taskService) ListFiltered(filter models.TaskFilter, offset, limit int) ([]models.Task, int64, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithFields(map[string]interface{}{
+		"search": filter.Search,
+		"offset": offset,
+		"limit":  limit,
+	}), "TaskService", "ListFiltered")
+
+	tasks, total, err := s.taskRepo.ListFiltered(filter, offset, limit, "AssignedTo", "Labels")
+	if err != nil {
+		logger.WithError(err).Error("Failed to list filtered tasks")
+		return nil, 0, err
+	}
+
+	logger.WithField("total", total).Info("Filtered task list completed")
+	return tasks, total, nil
+}
+
+// StreamExport hands every task matching the filter to fn, unpaginated, a batch
+// at a time as it is read.
+//
+// The caller is expected to be an admin-only export: this is a bulk read, so
+// it is deliberately a distinct method rather than "ListFiltered with a very
+// large limit", and the log below records that a full export was taken — or
+// that one stopped part of the way through.
+func (s *taskService) StreamExport(filter models.TaskFilter, fn func([]models.Task) error) error {
+	logger := utils.LogServiceCall(utils.Logger.WithFields(map[string]interface{}{
+		"search":     filter.Search,
+		"sort_by":    filter.SortBy,
+		"sort_order": filter.SortOrder,
+	}), "TaskService", "StreamExport")
+
+	count := 0
+	err := s.taskRepo.StreamFiltered(filter, func(batch []models.Task) error {
+		count += len(batch)
+		return fn(batch)
+	})
+	if err != nil {
+		logger.WithError(err).WithField("count", count).Error("Failed to export tasks")
+		return err
+	}
+
+	logger.WithField("count", count).Info("Task export produced")
+	return nil
+}
+
 func (s *taskService) GetPendingCount() (int64, error) {
 	return s.taskRepo.CountPending()
 }
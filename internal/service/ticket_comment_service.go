@@ -0,0 +1,173 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+)
+
+// TicketCommentService reads and writes ticket conversation threads. Who may
+// see or write which entries is decided by the caller; the service only keeps
+// internal entries out of a listing that did not ask for them.
+type TicketCommentService interface {
+	// AddComment appends a comment by authorID. An empty visibility means
+	// public. A blank or oversized body, or an unknown visibility, is rejected
+	// with apperrors.ErrValidation; a missing ticket with apperrors.ErrNotFound.
+	AddComment(ticketID, authorID uint, body string, visibility models.TicketCommentVisibility) (*models.TicketComment, error)
+	// AddEmailReply appends an email the ticket's customer sent, as a public
+	// entry of kind email authored by the customer's login when they have
+	// one. It never counts as the first response. The body rules are
+	// AddComment's.
+	AddEmailReply(ticketID uint, body string) (*models.TicketComment, error)
+	// List returns one page of a ticket's thread, oldest first, status-change
+	// entries included. Internal entries are left out unless includeInternal.
+	List(ticketID uint, includeInternal bool, offset, limit int) ([]models.TicketComment, int64, error)
+}
+
+type ticketCommentService struct {
+	commentRepo repository.TicketCommentRepository
+	ticketRepo  repository.TicketRepository
+	activityFeed
+}
+
+func NewTicketCommentService(commentRepo repository.TicketCommentRepository, ticketRepo repository.TicketRepository, opts ...ActivityOption) TicketCommentService {
+	s := &ticketCommentService{
+		commentRepo: commentRepo,
+		ticketRepo:  ticketRepo,
+	}
+	s.applyActivityOptions(opts)
+	return s
+}
+
+func (s *ticketCommentService) AddComment(ticketID, authorID uint, body string, visibility models.TicketCommentVisibility) (*models.TicketComment, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithFields(map[string]interface{}{
+		"ticket_id":  ticketID,
+		"author_id":  authorID,
+		"visibility": visibility,
+	}), "TicketCommentService", "AddComment")
+
+	body, err := normalizeCommentBody(body)
+	if err != nil {
+		return nil, err
+	}
+	if visibility == "" {
+		visibility = models.TicketCommentPublic
+	}
+	if !visibility.IsValid() {
+		return nil, fmt.Errorf("unknown comment visibility %q: %w", visibility, apperrors.ErrValidation)
+	}
+
+	ticket, err := s.ticketRepo.GetByID(ticketID)
+	if err != nil {
+		if isNotFound(err) {
+			logger.WithError(err).Warn("Ticket not found")
+			return nil, fmt.Errorf("ticket %d not found: %w", ticketID, apperrors.ErrNotFound)
+		}
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+
+	comment := &models.TicketComment{
+		TicketID:   ticketID,
+		Kind:       models.TicketCommentKindComment,
+		Visibility: visibility,
+		Body:       body,
+		AuthorID:   &authorID,
+	}
+	if err := s.commentRepo.Create(comment); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+
+	logger.WithField("comment_id", comment.ID).Info("Ticket comment added")
+	if visibility == models.TicketCommentPublic && ticket.FirstRespondedAt == nil && !isTicketCustomer(ticket, authorID) {
+		// The first public reply to the customer stops the first-response
+		// clock. The comment is already stored, so a failure is logged.
+		if err := s.ticketRepo.MarkFirstResponse(ticketID, time.Now().UTC()); err != nil {
+			logger.WithError(err).Warn("Failed to record the ticket's first response")
+		}
+	}
+	title := "Ticket comment"
+	if visibility == models.TicketCommentInternal {
+		title = "Internal note"
+	}
+	s.recordActivity(models.AuditEntityTicket, ticketID, models.ActivityTicketCommented, ticket.AssignedToID,
+		title, ticket.Title)
+	return comment, nil
+}
+
+func (s *ticketCommentService) AddEmailReply(ticketID uint, body string) (*models.TicketComment, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("ticket_id", ticketID), "TicketCommentService", "AddEmailReply")
+
+	body, err := normalizeCommentBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket, err := s.ticketRepo.GetByID(ticketID)
+	if err != nil {
+		if isNotFound(err) {
+			logger.WithError(err).Warn("Ticket not found")
+			return nil, fmt.Errorf("ticket %d not found: %w", ticketID, apperrors.ErrNotFound)
+		}
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+
+	comment := &models.TicketComment{
+		TicketID:   ticketID,
+		Kind:       models.TicketCommentKindEmail,
+		Visibility: models.TicketCommentPublic,
+		Body:       body,
+		AuthorID:   ticket.Customer.UserID,
+	}
+	if err := s.commentRepo.Create(comment); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+
+	logger.WithField("comment_id", comment.ID).Info("Customer email added to ticket")
+	s.recordActivity(models.AuditEntityTicket, ticketID, models.ActivityTicketCommented, ticket.AssignedToID,
+		"Customer email", ticket.Title)
+	return comment, nil
+}
+
+// normalizeCommentBody trims a comment body and checks it is neither blank
+// nor oversized.
+func normalizeCommentBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", fmt.Errorf("comment body is required: %w", apperrors.ErrValidation)
+	}
+	if len(body) > models.MaxTicketCommentLength {
+		return "", fmt.Errorf("comment body exceeds %d characters: %w", models.MaxTicketCommentLength, apperrors.ErrValidation)
+	}
+	return body, nil
+}
+
+// isTicketCustomer reports whether userID is the login of the customer the
+// ticket belongs to. The customer writing on their own ticket is not a response.
+func isTicketCustomer(ticket *models.Ticket, userID uint) bool {
+	return ticket.Customer.UserID != nil && *ticket.Customer.UserID == userID
+}
+
+func (s *ticketCommentService) List(ticketID uint, includeInternal bool, offset, limit int) ([]models.TicketComment, int64, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithFields(map[string]interface{}{
+		"ticket_id":        ticketID,
+		"include_internal": includeInternal,
+		"offset":           offset,
+		"limit":            limit,
+	}), "TicketCommentService", "List")
+
+	comments, total, err := s.commentRepo.ListByTicket(ticketID, includeInternal, offset, limit)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, 0, err
+	}
+	return comments, total, nil
+}
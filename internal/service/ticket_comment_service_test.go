@@ -0,0 +1,147 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type TicketCommentServiceTestSuite struct {
+	suite.Suite
+	mockCommentRepo *mocks.TicketCommentRepository
+	mockTicketRepo  *mocks.TicketRepository
+	mockActivity    *mocks.ActivityService
+	service         TicketCommentService
+}
+
+func (suite *TicketCommentServiceTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+}
+
+func (suite *TicketCommentServiceTestSuite) SetupTest() {
+	suite.mockCommentRepo = new(mocks.TicketCommentRepository)
+	suite.mockTicketRepo = new(mocks.TicketRepository)
+	suite.mockActivity = new(mocks.ActivityService)
+	suite.service = NewTicketCommentService(suite.mockCommentRepo, suite.mockTicketRepo, WithActivityFeed(suite.mockActivity))
+}
+
+func (suite *TicketCommentServiceTestSuite) TearDownTest() {
+	suite.mockCommentRepo.AssertExpectations(suite.T())
+	suite.mockTicketRepo.AssertExpectations(suite.T())
+	suite.mockActivity.AssertExpectations(suite.T())
+}
+
+func (suite *TicketCommentServiceTestSuite) TestAddComment_DefaultsToPublic() {
+	assignee := uint(8)
+	ticket := &models.Ticket{Title: "Printer on fire", AssignedToID: &assignee}
+	ticket.ID = 5
+	suite.mockTicketRepo.On("GetByID", uint(5)).Return(ticket, nil)
+	suite.mockCommentRepo.On("Create", mock.MatchedBy(func(c *models.TicketComment) bool {
+		return c.TicketID == 5 && *c.AuthorID == 10 && c.Body == "Still broken" &&
+			c.Kind == models.TicketCommentKindComment && c.Visibility == models.TicketCommentPublic
+	})).Return(nil)
+	suite.mockActivity.On("Record", mock.MatchedBy(func(e *models.ActivityEvent) bool {
+		return e.Type == models.ActivityTicketCommented && e.EntityID == 5 && *e.OwnerID == 8
+	})).Return(nil)
+	suite.mockTicketRepo.On("MarkFirstResponse", uint(5), mock.AnythingOfType("time.Time")).Return(nil)
+
+	comment, err := suite.service.AddComment(5, 10, "  Still broken\n", "")
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Still broken", comment.Body)
+}
+
+func (suite *TicketCommentServiceTestSuite) TestAddComment_FirstResponseOnlyFromStaffReplies() {
+	customerLogin := uint(10)
+	answered := time.Now().Add(-time.Hour)
+	cases := map[string]struct {
+		ticket     *models.Ticket
+		visibility models.TicketCommentVisibility
+	}{
+		"customer on their own ticket": {
+			ticket:     &models.Ticket{Customer: models.Customer{UserID: &customerLogin}},
+			visibility: models.TicketCommentPublic,
+		},
+		"internal note":     {ticket: &models.Ticket{}, visibility: models.TicketCommentInternal},
+		"already responded": {ticket: &models.Ticket{FirstRespondedAt: &answered}, visibility: models.TicketCommentPublic},
+	}
+	for name, tc := range cases {
+		suite.Run(name, func() {
+			suite.SetupTest()
+			tc.ticket.ID = 5
+			suite.mockTicketRepo.On("GetByID", uint(5)).Return(tc.ticket, nil)
+			suite.mockCommentRepo.On("Create", mock.Anything).Return(nil)
+			suite.mockActivity.On("Record", mock.Anything).Return(nil)
+
+			_, err := suite.service.AddComment(5, 10, "hello", tc.visibility)
+
+			assert.NoError(suite.T(), err)
+			suite.mockTicketRepo.AssertNotCalled(suite.T(), "MarkFirstResponse", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func (suite *TicketCommentServiceTestSuite) TestAddComment_ValidationErrors() {
+	cases := map[string]struct {
+		body       string
+		visibility models.TicketCommentVisibility
+	}{
+		"blank body":         {body: " \t ", visibility: models.TicketCommentPublic},
+		"oversized body":     {body: strings.Repeat("x", models.MaxTicketCommentLength+1)},
+		"unknown visibility": {body: "hello", visibility: "secret"},
+	}
+	for name, tc := range cases {
+		_, err := suite.service.AddComment(5, 10, tc.body, tc.visibility)
+		assert.ErrorIs(suite.T(), err, apperrors.ErrValidation, name)
+	}
+}
+
+func (suite *TicketCommentServiceTestSuite) TestAddComment_TicketNotFound() {
+	suite.mockTicketRepo.On("GetByID", uint(5)).Return(nil, gorm.ErrRecordNotFound)
+
+	_, err := suite.service.AddComment(5, 10, "hello", models.TicketCommentPublic)
+
+	assert.ErrorIs(suite.T(), err, apperrors.ErrNotFound)
+}
+
+func (suite *TicketCommentServiceTestSuite) TestAddEmailReply_AuthoredByCustomerLogin() {
+	customerUser := uint(12)
+	ticket := &models.Ticket{Title: "Printer on fire"}
+	ticket.ID = 5
+	ticket.Customer.UserID = &customerUser
+	suite.mockTicketRepo.On("GetByID", uint(5)).Return(ticket, nil)
+	suite.mockCommentRepo.On("Create", mock.MatchedBy(func(c *models.TicketComment) bool {
+		return c.TicketID == 5 && *c.AuthorID == 12 && c.Body == "Still broken" &&
+			c.Kind == models.TicketCommentKindEmail && c.Visibility == models.TicketCommentPublic
+	})).Return(nil)
+	suite.mockActivity.On("Record", mock.MatchedBy(func(e *models.ActivityEvent) bool {
+		return e.Type == models.ActivityTicketCommented && e.Title == "Customer email"
+	})).Return(nil)
+
+	_, err := suite.service.AddEmailReply(5, "Still broken\n")
+
+	assert.NoError(suite.T(), err)
+	suite.mockTicketRepo.AssertNotCalled(suite.T(), "MarkFirstResponse", mock.Anything, mock.Anything)
+}
+
+func (suite *TicketCommentServiceTestSuite) TestList_PassesVisibilityThrough() {
+	suite.mockCommentRepo.On("ListByTicket", uint(5), false, 0, 20).Return([]models.TicketComment{}, int64(0), nil)
+
+	_, _, err := suite.service.List(5, false, 0, 20)
+
+	assert.NoError(suite.T(), err)
+}
+
+func TestTicketCommentServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(TicketCommentServiceTestSuite))
+}
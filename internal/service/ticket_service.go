@@ -2,6 +2,7 @@ package service
 
 import (
 	"fmt"
+	"time"
 
 	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
 	"github.com/florinel-chis/gophercrm/internal/models"
@@ -13,14 +14,50 @@ type ticketService struct {
 	ticketRepo   repository.TicketRepository
 	customerRepo repository.CustomerRepository
 	userRepo     repository.UserRepository
+	commentRepo  repository.TicketCommentRepository
+	sla          SLAService
+	userPermissions
+	activityFeed
+	webhookEvents
 }
 
-func NewTicketService(ticketRepo repository.TicketRepository, customerRepo repository.CustomerRepository, userRepo repository.UserRepository) TicketService {
-	return &ticketService{
-		ticketRepo:   ticketRepo,
-		customerRepo: customerRepo,
-		userRepo:     userRepo,
+// NewTicketService builds the ticket service. commentRepo receives the
+// status-change entry of every update that moves a ticket between statuses;
+// roleService resolves the role of an assignee, who must be allowed to update
+// tickets.
+func NewTicketService(ticketRepo repository.TicketRepository, customerRepo repository.CustomerRepository, userRepo repository.UserRepository, roleService RoleService, commentRepo repository.TicketCommentRepository, opts ...EntityOption) TicketService {
+	s := &ticketService{
+		ticketRepo:      ticketRepo,
+		customerRepo:    customerRepo,
+		userRepo:        userRepo,
+		commentRepo:     commentRepo,
+		userPermissions: userPermissions{roles: roleService},
 	}
+	applyEntityOptions(&s.activityFeed, &s.webhookEvents, opts)
+	return s
+}
+
+// NewTicketServiceWithSLA is NewTicketService plus SLA deadlines: every ticket
+// it creates, and every ticket whose priority changes, is stamped with the due
+// dates of the SLA policy for its priority. It is the constructor the
+// application must be wired with.
+func NewTicketServiceWithSLA(ticketRepo repository.TicketRepository, customerRepo repository.CustomerRepository, userRepo repository.UserRepository, roleService RoleService, commentRepo repository.TicketCommentRepository, slaService SLAService, opts ...EntityOption) TicketService {
+	s := NewTicketService(ticketRepo, customerRepo, userRepo, roleService, commentRepo, opts...).(*ticketService)
+	s.sla = slaService
+	return s
+}
+
+// checkAssignee makes sure a ticket goes to someone who can work it: a user
+// whose role, built-in or custom, may update tickets.
+func (s *ticketService) checkAssignee(assignee *models.User) error {
+	allowed, err := s.userAllows(assignee, models.PermTicketsUpdate)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("tickets can only be assigned to users whose role may update tickets: %w", apperrors.ErrInvalidAssigneeRole)
+	}
+	return nil
 }
 
 func (s *ticketService) Create(ticket *models.Ticket) error {
@@ -48,10 +85,16 @@ func (s *ticketService) Create(ticket *models.Ticket) error {
 			logger.WithError(err).Warn("Assignee not found")
 			return fmt.Errorf("assignee not found: %w", apperrors.ErrAssigneeNotFound)
 		}
-		// Only support and admin users can be assigned tickets
-		if assignee.Role != models.RoleSupport && assignee.Role != models.RoleAdmin {
-			logger.Warn("Invalid assignee role")
-			return fmt.Errorf("tickets can only be assigned to support or admin users: %w", apperrors.ErrInvalidAssigneeRole)
+		if err := s.checkAssignee(assignee); err != nil {
+			logger.WithError(err).Warn("Invalid assignee role")
+			return err
+		}
+	}
+
+	if s.sla != nil {
+		if err := s.sla.ApplyDeadlines(ticket); err != nil {
+			logger.WithError(err).Error("Failed to apply SLA deadlines")
+			return err
 		}
 	}
 	
@@ -61,6 +104,9 @@ func (s *ticketService) Create(ticket *models.Ticket) error {
 	}
 	
 	logger.WithField("ticket_id", ticket.ID).Info("Ticket created successfully")
+	s.recordActivity(models.AuditEntityTicket, ticket.ID, models.ActivityTicketCreated, ticket.AssignedToID,
+		"New ticket", ticket.Title)
+	s.publishWebhook(models.WebhookTicketCreated, models.AuditEntityTicket, ticket.ID, ticketWebhookData(ticket))
 	return nil
 }
 
@@ -136,6 +182,7 @@ func (s *ticketService) Update(ticket *models.Ticket) error {
 		logger.WithError(err).Warn("Ticket not found")
 		return err
 	}
+	previousStatus, previousAssigneeID := existing.Status, existing.AssignedToID
 	
 	// Validate status transitions
 	if existing.Status == models.TicketStatusClosed && ticket.Status != models.TicketStatusClosed {
@@ -150,11 +197,21 @@ func (s *ticketService) Update(ticket *models.Ticket) error {
 			logger.WithError(err).Warn("Assignee not found")
 			return fmt.Errorf("assignee not found: %w", apperrors.ErrAssigneeNotFound)
 		}
-		if assignee.Role != models.RoleSupport && assignee.Role != models.RoleAdmin {
-			logger.Warn("Invalid assignee role")
-			return fmt.Errorf("tickets can only be assigned to support or admin users: %w", apperrors.ErrInvalidAssigneeRole)
+		if err := s.checkAssignee(assignee); err != nil {
+			logger.WithError(err).Warn("Invalid assignee role")
+			return err
 		}
 	}
+
+	// A new priority brings the deadlines of its own policy, still measured
+	// from when the ticket was opened.
+	if s.sla != nil && ticket.Priority != existing.Priority {
+		if err := s.sla.ApplyDeadlines(ticket); err != nil {
+			logger.WithError(err).Error("Failed to apply SLA deadlines")
+			return err
+		}
+	}
+	ticket.TrackResolution(previousStatus, time.Now().UTC())
 	
 	if err := s.ticketRepo.Update(ticket); err != nil {
 		logger.WithError(err).Error("Failed to update ticket")
@@ -162,14 +219,76 @@ func (s *ticketService) Update(ticket *models.Ticket) error {
 	}
 	
 	logger.Info("Ticket updated successfully")
+	if ticket.Status != previousStatus {
+		s.recordStatusChange(ticket.ID, previousStatus, ticket.Status)
+	}
+	s.recordTicketUpdate(ticket, previousStatus, previousAssigneeID)
+	s.publishTicketUpdate(ticket, previousStatus)
 	return nil
 }
 
+// recordStatusChange appends the status change to the ticket's thread. It is
+// public: the customer is entitled to see their ticket move. The update has
+// already been committed, so a failure is logged, not returned.
+func (s *ticketService) recordStatusChange(ticketID uint, from, to models.TicketStatus) {
+	entry := &models.TicketComment{
+		TicketID:   ticketID,
+		Kind:       models.TicketCommentKindStatusChange,
+		Visibility: models.TicketCommentPublic,
+		Body:       fmt.Sprintf("Status changed from %s to %s", from, to),
+		FromStatus: from,
+		ToStatus:   to,
+	}
+	if err := s.commentRepo.Create(entry); err != nil {
+		utils.Logger.WithError(err).
+			WithField("ticket_id", ticketID).
+			Warn("Failed to record ticket status change")
+	}
+}
+
+// recordTicketUpdate describes an update by what it changed. Resolving or
+// closing a ticket is the status change the dashboard cares most about, so it
+// has its own type.
+func (s *ticketService) recordTicketUpdate(ticket *models.Ticket, previousStatus models.TicketStatus, previousAssigneeID *uint) {
+	changed := false
+	if ticket.Status != previousStatus {
+		if ticket.Status == models.TicketStatusResolved || ticket.Status == models.TicketStatusClosed {
+			s.recordActivity(models.AuditEntityTicket, ticket.ID, models.ActivityTicketResolved, ticket.AssignedToID,
+				"Ticket resolved", ticket.Title)
+		} else {
+			s.recordActivity(models.AuditEntityTicket, ticket.ID, models.ActivityTicketStatusChanged, ticket.AssignedToID,
+				"Ticket status changed", fmt.Sprintf("%s moved from %s to %s", ticket.Title, previousStatus, ticket.Status))
+		}
+		changed = true
+	}
+	if !sameOwner(ticket.AssignedToID, previousAssigneeID) {
+		s.recordActivity(models.AuditEntityTicket, ticket.ID, models.ActivityTicketReassigned, ticket.AssignedToID,
+			"Ticket reassigned", ticket.Title)
+		changed = true
+	}
+	if !changed {
+		s.recordActivity(models.AuditEntityTicket, ticket.ID, models.ActivityTicketUpdated, ticket.AssignedToID,
+			"Ticket updated", ticket.Title)
+	}
+}
+
+// publishTicketUpdate publishes ticket.updated, and ticket.resolved as well when
+// the update resolved or closed the ticket — the same transitions the activity
+// feed calls a resolution.
+func (s *ticketService) publishTicketUpdate(ticket *models.Ticket, previousStatus models.TicketStatus) {
+	data := ticketWebhookData(ticket)
+	s.publishWebhook(models.WebhookTicketUpdated, models.AuditEntityTicket, ticket.ID, data)
+	if ticket.Status != previousStatus &&
+		(ticket.Status == models.TicketStatusResolved || ticket.Status == models.TicketStatusClosed) {
+		s.publishWebhook(models.WebhookTicketResolved, models.AuditEntityTicket, ticket.ID, data)
+	}
+}
+
 func (s *ticketService) Delete(id uint) error {
 	logger := utils.LogServiceCall(utils.Logger.WithField("ticket_id", id), "TicketService", "Delete")
 	
 	// Check if ticket exists
-	_, err := s.ticketRepo.GetByID(id)
+	ticket, err := s.ticketRepo.GetByID(id)
 	if err != nil {
 		if isNotFound(err) {
 			logger.WithError(err).Warn("Ticket not found")
@@ -185,6 +304,9 @@ func (s *ticketService) Delete(id uint) error {
 	}
 	
 	logger.Info("Ticket deleted successfully")
+	s.recordActivity(models.AuditEntityTicket, id, models.ActivityTicketDeleted, ticket.AssignedToID,
+		"Ticket deleted", ticket.Title)
+	s.publishWebhook(models.WebhookTicketDeleted, models.AuditEntityTicket, id, deletedWebhookData(id))
 	return nil
 }
 
@@ -257,6 +379,53 @@ func (s *ticketService) Search(query string, offset, limit int, sortBy, sortOrde
 	return tickets, total, nil
 }
 
+// ListFiltered returns one page of the tickets matching the filter, custom
+// field conditions included.
+func (s *ticketService) ListFiltered(filter models.TicketFilter, offset, limit int) ([]models.Ticket, int64, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithFields(map[string]interface{}{
+		"search": filter.Search,
+		"offset": offset,
+		"limit":  limit,
+	}), "TicketService", "ListFiltered")
+
+	tickets, total, err := s.ticketRepo.ListFiltered(filter, offset, limit, "Customer", "AssignedTo")
+	if err != nil {
+		logger.WithError(err).Error("Failed to list filtered tickets")
+		return nil, 0, err
+	}
+
+	logger.WithField("total", total).Info("Filtered ticket list completed")
+	return tickets, total, nil
+}
+
+// StreamExport hands every ticket matching the filter to fn, unpaginated, a batch
+// at a time as it is read.
+//
+// The caller is expected to be an admin-only export: this is a bulk read, so
+// it is deliberately a distinct method rather than "ListFiltered with a very
+// large limit", and the log below records that a full export was taken — or
+// that one stopped part of the way through.
+func (s *ticketService) StreamExport(filter models.TicketFilter, fn func([]models.Ticket) error) error {
+	logger := utils.LogServiceCall(utils.Logger.WithFields(map[string]interface{}{
+		"search":     filter.Search,
+		"sort_by":    filter.SortBy,
+		"sort_order": filter.SortOrder,
+	}), "TicketService", "StreamExport")
+
+	count := 0
+	err := s.ticketRepo.StreamFiltered(filter, func(batch []models.Ticket) error {
+		count += len(batch)
+		return fn(batch)
+	})
+	if err != nil {
+		logger.WithError(err).WithField("count", count).Error("Failed to export tickets")
+		return err
+	}
+
+	logger.WithField("count", count).Info("Ticket export produced")
+	return nil
+}
+
 func (s *ticketService) GetOpenCount() (int64, error) {
 	return s.ticketRepo.CountOpen()
 }
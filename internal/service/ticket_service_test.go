@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/florinel-chis/gophercrm/internal/config"
 	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
@@ -20,6 +21,7 @@ type TicketServiceTestSuite struct {
 	mockTicketRepo   *mocks.TicketRepository
 	mockCustomerRepo *mocks.CustomerRepository
 	mockUserRepo     *mocks.UserRepository
+	mockCommentRepo  *mocks.TicketCommentRepository
 	service          TicketService
 }
 
@@ -36,13 +38,15 @@ func (suite *TicketServiceTestSuite) SetupTest() {
 	suite.mockTicketRepo = new(mocks.TicketRepository)
 	suite.mockCustomerRepo = new(mocks.CustomerRepository)
 	suite.mockUserRepo = new(mocks.UserRepository)
-	suite.service = NewTicketService(suite.mockTicketRepo, suite.mockCustomerRepo, suite.mockUserRepo)
+	suite.mockCommentRepo = new(mocks.TicketCommentRepository)
+	suite.service = NewTicketService(suite.mockTicketRepo, suite.mockCustomerRepo, suite.mockUserRepo, nil, suite.mockCommentRepo)
 }
 
 func (suite *TicketServiceTestSuite) TearDownTest() {
 	suite.mockTicketRepo.AssertExpectations(suite.T())
 	suite.mockCustomerRepo.AssertExpectations(suite.T())
 	suite.mockUserRepo.AssertExpectations(suite.T())
+	suite.mockCommentRepo.AssertExpectations(suite.T())
 }
 
 func (suite *TicketServiceTestSuite) TestCreate_Success() {
@@ -141,6 +145,28 @@ func (suite *TicketServiceTestSuite) TestCreate_InvalidAssigneeRole() {
 	assert.True(suite.T(), errors.Is(err, apperrors.ErrInvalidAssigneeRole))
 }
 
+// TestCreate_CustomRoleAssignee: a custom role may be assigned tickets when it
+// may update them, and not otherwise, whatever built-in role it is based on.
+func (suite *TicketServiceTestSuite) TestCreate_CustomRoleAssignee() {
+	roles := new(mocks.RoleService)
+	roles.On("Resolve", models.UserRole("tier2")).
+		Return(models.RoleSupport, models.NewPermissionSet(models.PermTicketsReadOwn, models.PermTicketsUpdateOwn), nil)
+	roles.On("Resolve", models.UserRole("auditor")).
+		Return(models.RoleSupport, models.NewPermissionSet(models.PermTicketsReadAny), nil)
+	service := NewTicketService(suite.mockTicketRepo, suite.mockCustomerRepo, suite.mockUserRepo, roles, suite.mockCommentRepo)
+
+	suite.mockCustomerRepo.On("GetByID", uint(1)).Return(&models.Customer{BaseModel: models.BaseModel{ID: 1}}, nil)
+	suite.mockUserRepo.On("GetByID", uint(2)).Return(&models.User{BaseModel: models.BaseModel{ID: 2}, Role: "tier2"}, nil)
+	suite.mockUserRepo.On("GetByID", uint(3)).Return(&models.User{BaseModel: models.BaseModel{ID: 3}, Role: "auditor"}, nil)
+	suite.mockTicketRepo.On("Create", mock.AnythingOfType("*models.Ticket")).Return(nil).Once()
+
+	assert.NoError(suite.T(), service.Create(&models.Ticket{Title: "Printer", Description: "On fire", CustomerID: 1, AssignedToID: uintPtr(2)}))
+
+	err := service.Create(&models.Ticket{Title: "Printer", Description: "On fire", CustomerID: 1, AssignedToID: uintPtr(3)})
+	assert.True(suite.T(), errors.Is(err, apperrors.ErrInvalidAssigneeRole))
+	roles.AssertExpectations(suite.T())
+}
+
 func (suite *TicketServiceTestSuite) TestGetByID_Success() {
 	expectedTicket := &models.Ticket{
 		BaseModel:   models.BaseModel{ID: 1},
@@ -233,6 +259,49 @@ func (suite *TicketServiceTestSuite) TestUpdate_Success() {
 	suite.mockTicketRepo.On("GetByID", uint(1)).Return(existingTicket, nil)
 	suite.mockUserRepo.On("GetByID", uint(3)).Return(newAssignee, nil)
 	suite.mockTicketRepo.On("Update", updatedTicket).Return(nil)
+	suite.mockCommentRepo.On("Create", mock.MatchedBy(func(c *models.TicketComment) bool {
+		return c.TicketID == 1 &&
+			c.Kind == models.TicketCommentKindStatusChange &&
+			c.Visibility == models.TicketCommentPublic &&
+			c.FromStatus == models.TicketStatusOpen &&
+			c.ToStatus == models.TicketStatusInProgress &&
+			c.AuthorID == nil
+	})).Return(nil)
+
+	err := suite.service.Update(updatedTicket)
+	assert.NoError(suite.T(), err)
+}
+
+// An update that leaves the status alone adds nothing to the thread.
+func (suite *TicketServiceTestSuite) TestUpdate_SameStatusRecordsNoStatusChange() {
+	existingTicket := &models.Ticket{
+		BaseModel: models.BaseModel{ID: 1},
+		Title:     "Old Title",
+		Status:    models.TicketStatusOpen,
+	}
+	updatedTicket := &models.Ticket{
+		BaseModel: models.BaseModel{ID: 1},
+		Title:     "New Title",
+		Status:    models.TicketStatusOpen,
+	}
+
+	suite.mockTicketRepo.On("GetByID", uint(1)).Return(existingTicket, nil)
+	suite.mockTicketRepo.On("Update", updatedTicket).Return(nil)
+
+	err := suite.service.Update(updatedTicket)
+	assert.NoError(suite.T(), err)
+	suite.mockCommentRepo.AssertNotCalled(suite.T(), "Create", mock.Anything)
+}
+
+// The update is already committed when the thread is written, so a failed
+// status-change entry must not turn it into an error.
+func (suite *TicketServiceTestSuite) TestUpdate_StatusChangeEntryFailureIsNotAnError() {
+	existingTicket := &models.Ticket{BaseModel: models.BaseModel{ID: 1}, Status: models.TicketStatusOpen}
+	updatedTicket := &models.Ticket{BaseModel: models.BaseModel{ID: 1}, Status: models.TicketStatusResolved}
+
+	suite.mockTicketRepo.On("GetByID", uint(1)).Return(existingTicket, nil)
+	suite.mockTicketRepo.On("Update", updatedTicket).Return(nil)
+	suite.mockCommentRepo.On("Create", mock.AnythingOfType("*models.TicketComment")).Return(assert.AnError)
 
 	err := suite.service.Update(updatedTicket)
 	assert.NoError(suite.T(), err)
@@ -337,6 +406,61 @@ func (suite *TicketServiceTestSuite) TestList_Success() {
 }
 
 // Helper function
+// With an SLA service the ticket is stamped with its deadlines before it is
+// stored.
+func (suite *TicketServiceTestSuite) TestCreate_AppliesSLADeadlines() {
+	slaService := new(mocks.SLAService)
+	service := NewTicketServiceWithSLA(suite.mockTicketRepo, suite.mockCustomerRepo, suite.mockUserRepo, nil, suite.mockCommentRepo, slaService)
+	due := time.Now().Add(time.Hour)
+	ticket := &models.Ticket{Title: "Down", CustomerID: 1, Priority: models.TicketPriorityUrgent}
+
+	suite.mockCustomerRepo.On("GetByID", uint(1)).Return(&models.Customer{BaseModel: models.BaseModel{ID: 1}}, nil)
+	slaService.On("ApplyDeadlines", ticket).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Ticket).FirstResponseDueAt = &due
+	})
+	suite.mockTicketRepo.On("Create", mock.MatchedBy(func(t *models.Ticket) bool {
+		return t.FirstResponseDueAt != nil && t.FirstResponseDueAt.Equal(due)
+	})).Return(nil)
+
+	err := service.Create(ticket)
+	assert.NoError(suite.T(), err)
+	slaService.AssertExpectations(suite.T())
+}
+
+// Only a priority change moves the deadlines.
+func (suite *TicketServiceTestSuite) TestUpdate_PriorityChangeReappliesSLADeadlines() {
+	slaService := new(mocks.SLAService)
+	service := NewTicketServiceWithSLA(suite.mockTicketRepo, suite.mockCustomerRepo, suite.mockUserRepo, nil, suite.mockCommentRepo, slaService)
+	existing := &models.Ticket{BaseModel: models.BaseModel{ID: 1}, Status: models.TicketStatusOpen, Priority: models.TicketPriorityLow}
+	updated := &models.Ticket{BaseModel: models.BaseModel{ID: 1}, Status: models.TicketStatusOpen, Priority: models.TicketPriorityHigh}
+
+	suite.mockTicketRepo.On("GetByID", uint(1)).Return(existing, nil).Once()
+	slaService.On("ApplyDeadlines", updated).Return(nil).Once()
+	suite.mockTicketRepo.On("Update", updated).Return(nil)
+
+	assert.NoError(suite.T(), service.Update(updated))
+
+	unchanged := &models.Ticket{BaseModel: models.BaseModel{ID: 1}, Status: models.TicketStatusOpen, Priority: models.TicketPriorityHigh}
+	suite.mockTicketRepo.On("GetByID", uint(1)).Return(unchanged, nil).Once()
+	assert.NoError(suite.T(), service.Update(updated))
+	slaService.AssertExpectations(suite.T())
+}
+
+// Resolving a ticket stamps when it was resolved and, when nobody had replied
+// yet, counts the resolution as the first response.
+func (suite *TicketServiceTestSuite) TestUpdate_ResolvingStampsResolutionTimes() {
+	existing := &models.Ticket{BaseModel: models.BaseModel{ID: 1}, Status: models.TicketStatusInProgress}
+	updated := &models.Ticket{BaseModel: models.BaseModel{ID: 1}, Status: models.TicketStatusResolved}
+
+	suite.mockTicketRepo.On("GetByID", uint(1)).Return(existing, nil)
+	suite.mockTicketRepo.On("Update", mock.MatchedBy(func(t *models.Ticket) bool {
+		return t.ResolvedAt != nil && t.FirstRespondedAt != nil && t.ResolvedAt.Equal(*t.FirstRespondedAt)
+	})).Return(nil)
+	suite.mockCommentRepo.On("Create", mock.AnythingOfType("*models.TicketComment")).Return(nil)
+
+	assert.NoError(suite.T(), suite.service.Update(updated))
+}
+
 func uintPtr(u uint) *uint {
 	return &u
 }
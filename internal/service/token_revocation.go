@@ -0,0 +1,100 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/repository"
+)
+
+// revocationList is the in-memory copy of the access-token revocation list
+// that every request's token is checked against: the jtis of revoked tokens
+// and the IDs of revoked sessions. The copy is reloaded from the store once
+// it is older than ttl, so a revocation made through another instance takes
+// effect here within ttl. One made through this instance marks the copy
+// stale and takes effect at once.
+type revocationList struct {
+	repo repository.SessionRepository
+	ttl  time.Duration
+	// window is the lifetime of an access token. A session revoked longer
+	// ago than that has no access token left to refuse.
+	window time.Duration
+	now    func() time.Time
+
+	mu       sync.RWMutex
+	tokens   map[string]struct{}
+	sessions map[uint]struct{}
+	loadedAt time.Time
+	stale    bool
+}
+
+func newRevocationList(repo repository.SessionRepository, ttl, window time.Duration) *revocationList {
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	return &revocationList{repo: repo, ttl: ttl, window: window, now: time.Now, stale: true}
+}
+
+// revoked reports whether the token with the jti, or its session, is
+// revoked. sessionID is zero for a token outside any session.
+func (l *revocationList) revoked(tokenID string, sessionID uint) (bool, error) {
+	if err := l.refresh(); err != nil {
+		return false, err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if _, ok := l.tokens[tokenID]; ok {
+		return true, nil
+	}
+	_, ok := l.sessions[sessionID]
+	return ok && sessionID != 0, nil
+}
+
+// invalidate makes the next check reload the list, after a revocation made
+// through this instance. It is a no-op on a nil list, which an instance
+// without the session store has.
+func (l *revocationList) invalidate() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	l.stale = true
+	l.mu.Unlock()
+}
+
+// refresh reloads the list when it is stale or older than ttl. Concurrent
+// callers wait for the one that reloads rather than all querying the store.
+func (l *revocationList) refresh() error {
+	l.mu.RLock()
+	fresh := l.isFresh()
+	l.mu.RUnlock()
+	if fresh {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.isFresh() {
+		return nil
+	}
+	now := l.now()
+	tokenIDs, sessionIDs, err := l.repo.Revocations(now.Add(-l.window))
+	if err != nil {
+		return err
+	}
+	l.tokens = make(map[string]struct{}, len(tokenIDs))
+	for _, id := range tokenIDs {
+		l.tokens[id] = struct{}{}
+	}
+	l.sessions = make(map[uint]struct{}, len(sessionIDs))
+	for _, id := range sessionIDs {
+		l.sessions[id] = struct{}{}
+	}
+	l.loadedAt, l.stale = now, false
+	return nil
+}
+
+// isFresh must be called with mu held.
+func (l *revocationList) isFresh() bool {
+	return !l.stale && l.now().Sub(l.loadedAt) < l.ttl
+}
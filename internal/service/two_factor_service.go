@@ -0,0 +1,379 @@
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Sentinel errors of two-factor authentication, classified with errors.Is().
+var (
+	// ErrInvalidTwoFactorCode covers every rejected code: wrong, expired,
+	// already used, or a recovery code that was never issued or is spent.
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	// ErrInvalidTwoFactorChallenge covers every rejected login challenge:
+	// malformed, expired, or issued to an account that can no longer log in.
+	ErrInvalidTwoFactorChallenge = errors.New("invalid or expired two-factor challenge")
+	// ErrTwoFactorRequired is returned by the logins that cannot ask for a
+	// second factor when the account needs one.
+	ErrTwoFactorRequired = errors.New("two-factor authentication is required")
+	ErrTwoFactorEnabled  = errors.New("two-factor authentication is already enabled")
+	// ErrTwoFactorNotEnabled is also what verifying a code yields before any
+	// setup was started.
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+)
+
+// ConfigTwoFactorRequiredRoles is the configuration entry listing the roles
+// whose users must use two-factor authentication. It is seeded empty by
+// models.DefaultConfigurations.
+const ConfigTwoFactorRequiredRoles = "security.two_factor.required_roles"
+
+// TwoFactorIssuer names the application in authenticator apps.
+const TwoFactorIssuer = "GopherCRM"
+
+// recoveryCodeCount is how many recovery codes a set holds.
+const recoveryCodeCount = 10
+
+// recoveryCodeAlphabet leaves out the characters that read alike (0/o, 1/l/i).
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// TwoFactorService manages TOTP two-factor authentication (RFC 6238): the
+// enrollment of a user's authenticator, the recovery codes that stand in for
+// it, and the check of a code at login.
+type TwoFactorService interface {
+	Status(userID uint) (*models.TwoFactorStatus, error)
+	// Setup starts an enrollment with a new secret. It replaces any earlier
+	// enrollment that was not verified.
+	Setup(userID uint) (*models.TwoFactorSetup, error)
+	// Verify completes the enrollment with a code of the pending secret and
+	// returns the recovery codes, which are never shown again.
+	Verify(userID uint, code string) ([]string, error)
+	// Disable turns two-factor authentication off after checking both the
+	// password and a code. Users of a role that requires it cannot.
+	Disable(userID uint, password, code string) error
+	// RegenerateRecoveryCodes replaces the recovery codes after checking a
+	// code, and returns the new ones.
+	RegenerateRecoveryCodes(userID uint, code string) ([]string, error)
+	// Reset turns a user's two-factor authentication off without a code, for
+	// an administrator helping someone who lost both the authenticator and
+	// the recovery codes.
+	Reset(userID uint) error
+	// Required reports whether the user's role must use two-factor
+	// authentication.
+	Required(user *models.User) bool
+	// CheckCode accepts a TOTP code or a recovery code of a user with
+	// two-factor authentication enabled, and spends it.
+	CheckCode(user *models.User, code string) error
+}
+
+type twoFactorService struct {
+	userRepo      repository.UserRepository
+	twoFactorRepo repository.TwoFactorRepository
+	// secretBox seals the TOTP secrets at rest.
+	secretBox *utils.SecretBox
+	// codeSecret is the HMAC key of the stored recovery codes.
+	codeSecret string
+	// requiredRoles returns the roles that must use two-factor
+	// authentication right now.
+	requiredRoles func() []string
+	now           func() time.Time
+}
+
+func NewTwoFactorService(
+	userRepo repository.UserRepository,
+	twoFactorRepo repository.TwoFactorRepository,
+	secretBox *utils.SecretBox,
+	codeSecret string,
+	requiredRoles func() []string,
+) TwoFactorService {
+	if requiredRoles == nil {
+		requiredRoles = func() []string { return nil }
+	}
+	return &twoFactorService{
+		userRepo:      userRepo,
+		twoFactorRepo: twoFactorRepo,
+		secretBox:     secretBox,
+		codeSecret:    codeSecret,
+		requiredRoles: requiredRoles,
+		now:           time.Now,
+	}
+}
+
+// TwoFactorRequiredRolesFrom reads the roles that must use two-factor
+// authentication from the configuration, on every call, so a change made by
+// an administrator applies from the next login. A lookup that fails requires
+// nothing; it is logged.
+func TwoFactorRequiredRolesFrom(configs ConfigurationService) func() []string {
+	return func() []string {
+		values, err := configs.GetArray(ConfigTwoFactorRequiredRoles)
+		if err != nil {
+			if !errors.Is(err, apperrors.ErrNotFound) {
+				configLogger().WithError(err).Warn("Failed to read the roles that require two-factor authentication")
+			}
+			return nil
+		}
+		roles := make([]string, 0, len(values))
+		for _, value := range values {
+			if role, ok := value.(string); ok {
+				roles = append(roles, role)
+			}
+		}
+		return roles
+	}
+}
+
+func (s *twoFactorService) loadUser(userID uint) (*models.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, fmt.Errorf("user %d not found: %w", userID, apperrors.ErrNotFound)
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *twoFactorService) Status(userID uint) (*models.TwoFactorStatus, error) {
+	user, err := s.loadUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.TwoFactorStatus{Enabled: user.TwoFactorEnabled, Required: s.Required(user)}
+	if user.TwoFactorEnabled {
+		if status.RecoveryCodesRemaining, err = s.twoFactorRepo.CountRecoveryCodes(userID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+func (s *twoFactorService) Setup(userID uint) (*models.TwoFactorSetup, error) {
+	user, err := s.loadUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.secretBox.Seal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to seal the TOTP secret: %w", err)
+	}
+	if err := s.twoFactorRepo.SavePendingSecret(userID, sealed); err != nil {
+		return nil, fmt.Errorf("failed to store the TOTP secret: %w", err)
+	}
+
+	utils.Logger.WithField("user_id", userID).Info("Two-factor enrollment started")
+	return &models.TwoFactorSetup{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(TwoFactorIssuer, user.Email, secret),
+	}, nil
+}
+
+func (s *twoFactorService) Verify(userID uint, code string) ([]string, error) {
+	user, err := s.loadUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	step, err := s.matchTOTP(user, code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := s.newRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactorRepo.Enable(userID, step, hashes); err != nil {
+		return nil, fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+	user.TwoFactorEnabled = true
+	user.TOTPLastStep = step
+
+	utils.Logger.WithField("user_id", userID).Info("Two-factor authentication enabled")
+	return codes, nil
+}
+
+func (s *twoFactorService) Disable(userID uint, password, code string) error {
+	user, err := s.loadUser(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TwoFactorEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if s.Required(user) {
+		return fmt.Errorf("role %s requires two-factor authentication: %w", user.Role, apperrors.ErrForbidden)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return ErrInvalidCurrentPassword
+	}
+	if err := s.CheckCode(user, code); err != nil {
+		return err
+	}
+
+	if err := s.twoFactorRepo.Disable(userID); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+	utils.Logger.WithField("user_id", userID).Info("Two-factor authentication disabled")
+	return nil
+}
+
+func (s *twoFactorService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	user, err := s.loadUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactorEnabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err := s.CheckCode(user, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := s.newRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactorRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, fmt.Errorf("failed to store recovery codes: %w", err)
+	}
+	utils.Logger.WithField("user_id", userID).Info("Two-factor recovery codes regenerated")
+	return codes, nil
+}
+
+func (s *twoFactorService) Reset(userID uint) error {
+	if _, err := s.loadUser(userID); err != nil {
+		return err
+	}
+	if err := s.twoFactorRepo.Disable(userID); err != nil {
+		return fmt.Errorf("failed to reset two-factor authentication: %w", err)
+	}
+	utils.Logger.WithField("user_id", userID).Warn("Two-factor authentication reset by an administrator")
+	return nil
+}
+
+func (s *twoFactorService) Required(user *models.User) bool {
+	for _, role := range s.requiredRoles() {
+		if models.UserRole(role) == user.Role {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckCode tells the two kinds of code apart by their shape: a TOTP code is
+// all digits, and a recovery code never is.
+func (s *twoFactorService) CheckCode(user *models.User, code string) error {
+	if !user.TwoFactorEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if isTOTPCode(code) {
+		step, err := s.matchTOTP(user, code)
+		if err != nil {
+			return err
+		}
+		claimed, err := s.twoFactorRepo.ClaimStep(user.ID, step)
+		if err != nil {
+			return fmt.Errorf("failed to record the TOTP step: %w", err)
+		}
+		if !claimed {
+			utils.Logger.WithField("user_id", user.ID).Warn("Rejected a TOTP code that was already used")
+			return ErrInvalidTwoFactorCode
+		}
+		// Callers may save the user they hold; it must not carry the old step.
+		user.TOTPLastStep = step
+		return nil
+	}
+
+	consumed, err := s.twoFactorRepo.ConsumeRecoveryCode(user.ID, s.hashRecoveryCode(user.ID, code))
+	if err != nil {
+		return fmt.Errorf("failed to spend the recovery code: %w", err)
+	}
+	if !consumed {
+		return ErrInvalidTwoFactorCode
+	}
+	utils.Logger.WithField("user_id", user.ID).Info("Two-factor recovery code used")
+	return nil
+}
+
+// matchTOTP returns the step a code of the user's secret matched. A secret
+// that cannot be opened — the master secret was rotated — matches nothing.
+func (s *twoFactorService) matchTOTP(user *models.User, code string) (int64, error) {
+	secret, err := s.secretBox.Open(user.TOTPSecret)
+	if err != nil || secret == "" {
+		utils.Logger.WithField("user_id", user.ID).Error("Stored TOTP secret cannot be opened")
+		return 0, ErrInvalidTwoFactorCode
+	}
+	step, ok := utils.ValidateTOTP(secret, code, s.now())
+	if !ok || step <= user.TOTPLastStep {
+		return 0, ErrInvalidTwoFactorCode
+	}
+	return step, nil
+}
+
+// newRecoveryCodes generates a set of recovery codes, as shown to the user
+// and as stored.
+func (s *twoFactorService) newRecoveryCodes(userID uint) ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	buf := make([]byte, 10)
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+		}
+		var code strings.Builder
+		for j, b := range buf {
+			if j == 5 {
+				code.WriteByte('-')
+			}
+			code.WriteByte(recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)])
+		}
+		codes[i] = code.String()
+		hashes[i] = s.hashRecoveryCode(userID, codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode derives the stored form of a recovery code. The code is
+// read case-insensitively and without its separator, and is bound to the user
+// so that no two users' codes can share a hash.
+func (s *twoFactorService) hashRecoveryCode(userID uint, code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return hashOpaqueToken(fmt.Sprintf("%d:%s", userID, normalized), s.codeSecret)
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != utils.TOTPDigits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const twoFactorTestPassword = "Str0ng!Passw0rd"
+
+// TwoFactorServiceSuite runs the two-factor service and the two-step login
+// against a real (in-memory SQLite) database, on a clock the tests move, so
+// that the single use of each code is checked where it is enforced.
+type TwoFactorServiceSuite struct {
+	suite.Suite
+	db            *gorm.DB
+	now           time.Time
+	requiredRoles []string
+	twoFactor     TwoFactorService
+	auth          AuthService
+	user          *models.User
+}
+
+func TestTwoFactorServiceSuite(t *testing.T) {
+	suite.Run(t, new(TwoFactorServiceSuite))
+}
+
+func (s *TwoFactorServiceSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "error", Format: "json"})
+}
+
+func (s *TwoFactorServiceSuite) SetupTest() {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	s.Require().NoError(err)
+	s.Require().NoError(db.AutoMigrate(&models.User{}, &models.RefreshToken{}, &models.TwoFactorRecoveryCode{}))
+	s.db = db
+
+	s.now = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.requiredRoles = nil
+	userRepo := repository.NewUserRepository(db)
+	twoFactor := NewTwoFactorService(userRepo, repository.NewTwoFactorRepository(db),
+		utils.NewSecretBox("two-factor-test-secret", "totp-secret"), "two-factor-test-secret",
+		func() []string { return s.requiredRoles })
+	twoFactor.(*twoFactorService).now = func() time.Time { return s.now }
+	s.twoFactor = twoFactor
+	s.auth = NewAuthServiceWithSessions(userRepo, nil, repository.NewRefreshTokenRepository(db), nil, nil,
+		config.JWTConfig{Secret: "two-factor-test-secret", ExpiryHours: 1, RefreshTokenDays: 30},
+		"http://localhost:5173", "two-factor-test-secret", WithTwoFactor(twoFactor))
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(twoFactorTestPassword), bcrypt.MinCost)
+	s.Require().NoError(err)
+	s.user = &models.User{Email: "admin@example.com", Password: string(hashed), FirstName: "Ada", LastName: "Admin",
+		Role: models.RoleAdmin, IsActive: true}
+	s.Require().NoError(db.Create(s.user).Error)
+}
+
+// code returns the current code of secret, after moving the clock on to the
+// next step so that the code has not been used yet.
+func (s *TwoFactorServiceSuite) code(secret string) string {
+	s.now = s.now.Add(utils.TOTPPeriod)
+	code, err := utils.TOTPCode(secret, utils.TOTPStep(s.now))
+	s.Require().NoError(err)
+	return code
+}
+
+// enroll enables two-factor authentication for the suite's user and returns
+// the secret and the recovery codes.
+func (s *TwoFactorServiceSuite) enroll() (string, []string) {
+	setup, err := s.twoFactor.Setup(s.user.ID)
+	s.Require().NoError(err)
+	codes, err := s.twoFactor.Verify(s.user.ID, s.code(setup.Secret))
+	s.Require().NoError(err)
+	return setup.Secret, codes
+}
+
+func (s *TwoFactorServiceSuite) reload() *models.User {
+	var user models.User
+	s.Require().NoError(s.db.First(&user, s.user.ID).Error)
+	return &user
+}
+
+func (s *TwoFactorServiceSuite) TestSetup_SealsTheSecret() {
+	setup, err := s.twoFactor.Setup(s.user.ID)
+	s.Require().NoError(err)
+
+	s.Contains(setup.OTPAuthURI, "otpauth://totp/GopherCRM:admin@example.com?")
+	s.Contains(setup.OTPAuthURI, "secret="+setup.Secret)
+	stored := s.reload()
+	s.False(stored.TwoFactorEnabled, "nothing is enabled before a code is verified")
+	s.NotEmpty(stored.TOTPSecret)
+	s.NotContains(stored.TOTPSecret, setup.Secret, "the secret is stored sealed")
+}
+
+func (s *TwoFactorServiceSuite) TestVerify_EnablesAndReturnsRecoveryCodes() {
+	_, codes := s.enroll()
+
+	s.Len(codes, 10)
+	for _, code := range codes {
+		s.Regexp(`^[a-z2-9]{5}-[a-z2-9]{5}$`, code)
+	}
+	s.True(s.reload().TwoFactorEnabled)
+
+	var stored []models.TwoFactorRecoveryCode
+	s.Require().NoError(s.db.Where("user_id = ?", s.user.ID).Find(&stored).Error)
+	s.Len(stored, 10)
+	for _, row := range stored {
+		for _, code := range codes {
+			s.NotEqual(code, row.CodeHash, "recovery codes are stored hashed")
+		}
+	}
+
+	status, err := s.twoFactor.Status(s.user.ID)
+	s.Require().NoError(err)
+	s.Equal(models.TwoFactorStatus{Enabled: true, RecoveryCodesRemaining: 10}, *status)
+
+	_, err = s.twoFactor.Setup(s.user.ID)
+	s.ErrorIs(err, ErrTwoFactorEnabled)
+}
+
+func (s *TwoFactorServiceSuite) TestVerify_RejectsAWrongCode() {
+	_, err := s.twoFactor.Verify(s.user.ID, "123456")
+	s.ErrorIs(err, ErrTwoFactorNotEnabled, "there is nothing to verify before a setup")
+
+	setup, err := s.twoFactor.Setup(s.user.ID)
+	s.Require().NoError(err)
+	code := s.code(setup.Secret)
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	_, err = s.twoFactor.Verify(s.user.ID, wrong)
+	s.ErrorIs(err, ErrInvalidTwoFactorCode)
+	s.False(s.reload().TwoFactorEnabled)
+}
+
+func (s *TwoFactorServiceSuite) TestCheckCode_EachCodeIsAcceptedOnce() {
+	secret, recovery := s.enroll()
+
+	code := s.code(secret)
+	s.Require().NoError(s.twoFactor.CheckCode(s.reload(), code))
+	s.ErrorIs(s.twoFactor.CheckCode(s.reload(), code), ErrInvalidTwoFactorCode, "a TOTP code cannot be replayed")
+
+	s.Require().NoError(s.twoFactor.CheckCode(s.reload(), strings.ToUpper(strings.ReplaceAll(recovery[0], "-", ""))),
+		"recovery codes are read without case or separator")
+	s.ErrorIs(s.twoFactor.CheckCode(s.reload(), recovery[0]), ErrInvalidTwoFactorCode, "a recovery code is single-use")
+
+	status, err := s.twoFactor.Status(s.user.ID)
+	s.Require().NoError(err)
+	s.Equal(int64(9), status.RecoveryCodesRemaining)
+}
+
+func (s *TwoFactorServiceSuite) TestRegenerateRecoveryCodes_ReplacesTheSet() {
+	secret, old := s.enroll()
+
+	fresh, err := s.twoFactor.RegenerateRecoveryCodes(s.user.ID, s.code(secret))
+	s.Require().NoError(err)
+	s.Len(fresh, 10)
+	s.ErrorIs(s.twoFactor.CheckCode(s.reload(), old[0]), ErrInvalidTwoFactorCode)
+	s.NoError(s.twoFactor.CheckCode(s.reload(), fresh[0]))
+}
+
+func (s *TwoFactorServiceSuite) TestDisable_NeedsPasswordAndCode() {
+	secret, _ := s.enroll()
+
+	s.ErrorIs(s.twoFactor.Disable(s.user.ID, "wrong-password", s.code(secret)), ErrInvalidCurrentPassword)
+	s.ErrorIs(s.twoFactor.Disable(s.user.ID, twoFactorTestPassword, "abcde-fghij"), ErrInvalidTwoFactorCode)
+
+	s.Require().NoError(s.twoFactor.Disable(s.user.ID, twoFactorTestPassword, s.code(secret)))
+	stored := s.reload()
+	s.False(stored.TwoFactorEnabled)
+	s.Empty(stored.TOTPSecret)
+}
+
+func (s *TwoFactorServiceSuite) TestDisable_RefusedWhenTheRoleRequiresIt() {
+	secret, _ := s.enroll()
+	s.requiredRoles = []string{"admin"}
+
+	err := s.twoFactor.Disable(s.user.ID, twoFactorTestPassword, s.code(secret))
+	s.True(errors.Is(err, apperrors.ErrForbidden))
+	s.True(s.reload().TwoFactorEnabled)
+}
+
+func (s *TwoFactorServiceSuite) TestReset() {
+	s.enroll()
+
+	s.Require().NoError(s.twoFactor.Reset(s.user.ID))
+	s.False(s.reload().TwoFactorEnabled)
+	s.True(apperrors.IsNotFound(s.twoFactor.Reset(s.user.ID + 100)))
+}
+
+func (s *TwoFactorServiceSuite) TestLogin_WithoutTwoFactorIssuesTokens() {
+	tokens, err := s.auth.LoginWithTokens(s.user.Email, twoFactorTestPassword, ClientInfo{})
+	s.Require().NoError(err)
+	s.NotEmpty(tokens.AccessToken)
+	s.Empty(tokens.TwoFactorChallenge)
+}
+
+func (s *TwoFactorServiceSuite) TestLogin_TwoSteps() {
+	secret, _ := s.enroll()
+
+	tokens, err := s.auth.LoginWithTokens(s.user.Email, twoFactorTestPassword, ClientInfo{})
+	s.Require().NoError(err)
+	s.Empty(tokens.AccessToken, "no token before the second factor")
+	s.Empty(tokens.RefreshToken)
+	s.Nil(tokens.User)
+	s.False(tokens.TwoFactorSetupRequired)
+	s.Require().NotEmpty(tokens.TwoFactorChallenge)
+
+	_, err = s.auth.ValidateToken(tokens.TwoFactorChallenge)
+	s.Error(err, "a challenge is not an access token")
+
+	completed, err := s.auth.CompleteTwoFactorLogin(tokens.TwoFactorChallenge, s.code(secret), ClientInfo{})
+	s.Require().NoError(err)
+	s.NotEmpty(completed.AccessToken)
+	s.NotEmpty(completed.RefreshToken)
+	s.Equal(s.user.ID, completed.User.ID)
+	s.Nil(completed.RecoveryCodes)
+	s.NotNil(s.reload().LastLoginAt)
+
+	_, err = s.auth.CompleteTwoFactorLogin(completed.AccessToken, s.code(secret), ClientInfo{})
+	s.ErrorIs(err, ErrInvalidTwoFactorChallenge, "an access token is not a challenge")
+
+	_, err = s.auth.Login(s.user.Email, twoFactorTestPassword)
+	s.ErrorIs(err, ErrTwoFactorRequired, "the single-step login cannot ask for a second factor")
+}
+
+func (s *TwoFactorServiceSuite) TestLogin_WrongCodesLockTheAccount() {
+	s.enroll()
+
+	tokens, err := s.auth.LoginWithTokens(s.user.Email, twoFactorTestPassword, ClientInfo{})
+	s.Require().NoError(err)
+	for i := 0; i < MaxFailedLoginAttempts; i++ {
+		_, err = s.auth.CompleteTwoFactorLogin(tokens.TwoFactorChallenge, "zzzzz-zzzzz", ClientInfo{})
+		s.ErrorIs(err, ErrInvalidTwoFactorCode)
+	}
+
+	stored := s.reload()
+	s.Require().NotNil(stored.LockedUntil)
+	s.True(stored.TwoFactorEnabled, "counting failures does not touch the second factor")
+	_, err = s.auth.CompleteTwoFactorLogin(tokens.TwoFactorChallenge, "zzzzz-zzzzz", ClientInfo{})
+	s.Error(err)
+	s.NotErrorIs(err, ErrInvalidTwoFactorCode, "a locked account takes no more codes")
+}
+
+func (s *TwoFactorServiceSuite) TestLogin_RecoveryCode() {
+	_, recovery := s.enroll()
+
+	tokens, err := s.auth.LoginWithTokens(s.user.Email, twoFactorTestPassword, ClientInfo{})
+	s.Require().NoError(err)
+	completed, err := s.auth.CompleteTwoFactorLogin(tokens.TwoFactorChallenge, recovery[3], ClientInfo{})
+	s.Require().NoError(err)
+	s.NotEmpty(completed.AccessToken)
+
+	_, err = s.auth.CompleteTwoFactorLogin(tokens.TwoFactorChallenge, recovery[3], ClientInfo{})
+	s.ErrorIs(err, ErrInvalidTwoFactorCode)
+}
+
+func (s *TwoFactorServiceSuite) TestLogin_RequiredRoleEnrollsFirst() {
+	s.requiredRoles = []string{"admin"}
+
+	tokens, err := s.auth.LoginWithTokens(s.user.Email, twoFactorTestPassword, ClientInfo{})
+	s.Require().NoError(err)
+	s.True(tokens.TwoFactorSetupRequired)
+	s.Empty(tokens.AccessToken)
+
+	_, err = s.auth.CompleteTwoFactorLogin(tokens.TwoFactorChallenge, "123456", ClientInfo{})
+	s.ErrorIs(err, ErrTwoFactorNotEnabled, "the authenticator must be enrolled first")
+
+	setup, err := s.auth.BeginTwoFactorSetup(tokens.TwoFactorChallenge)
+	s.Require().NoError(err)
+	completed, err := s.auth.CompleteTwoFactorLogin(tokens.TwoFactorChallenge, s.code(setup.Secret), ClientInfo{})
+	s.Require().NoError(err)
+	s.NotEmpty(completed.AccessToken)
+	s.Len(completed.RecoveryCodes, 10)
+	s.True(s.reload().TwoFactorEnabled)
+
+	_, err = s.auth.BeginTwoFactorSetup(tokens.TwoFactorChallenge)
+	s.ErrorIs(err, ErrInvalidTwoFactorChallenge, "an enrolled user cannot be enrolled again")
+}
+
+// Registering proves the password, not a second factor: a role that requires
+// one gets no tokens before enrolling.
+func (s *TwoFactorServiceSuite) TestLoginRegistered_RequiredRoleEnrollsFirst() {
+	tokens, err := s.auth.LoginRegistered(s.user, ClientInfo{})
+	s.Require().NoError(err)
+	s.NotEmpty(tokens.AccessToken)
+	s.NotEmpty(tokens.RefreshToken)
+
+	s.requiredRoles = []string{"admin"}
+	tokens, err = s.auth.LoginRegistered(s.user, ClientInfo{})
+	s.Require().NoError(err)
+	s.Empty(tokens.AccessToken)
+	s.Empty(tokens.RefreshToken)
+	s.True(tokens.TwoFactorSetupRequired)
+	s.Require().NotEmpty(tokens.TwoFactorChallenge)
+
+	_, err = s.auth.BeginTwoFactorSetup(tokens.TwoFactorChallenge)
+	s.NoError(err)
+}
+
+func (s *TwoFactorServiceSuite) TestBeginTwoFactorSetup_NeedsASetupChallenge() {
+	s.enroll()
+
+	tokens, err := s.auth.LoginWithTokens(s.user.Email, twoFactorTestPassword, ClientInfo{})
+	s.Require().NoError(err)
+	_, err = s.auth.BeginTwoFactorSetup(tokens.TwoFactorChallenge)
+	s.ErrorIs(err, ErrInvalidTwoFactorChallenge)
+}
+
+func (s *TwoFactorServiceSuite) TestChallenge_Expires() {
+	expired := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":     "1",
+		"purpose": twoFactorChallengePurpose,
+		"exp":     time.Now().Add(-time.Minute).Unix(),
+	})
+	challenge, err := expired.SignedString([]byte("two-factor-test-secret"))
+	s.Require().NoError(err)
+
+	_, err = s.auth.CompleteTwoFactorLogin(challenge, "123456", ClientInfo{})
+	s.ErrorIs(err, ErrInvalidTwoFactorChallenge)
+}
@@ -177,4 +177,14 @@ func (s *userService) Search(query string, offset, limit int, sortBy, sortOrder
 	}
 
 	return users, total, nil
+}
+
+func (s *userService) ListFiltered(filter models.UserFilter, offset, limit int) ([]models.User, int64, error) {
+	return s.userRepo.ListFiltered(filter, offset, limit)
+}
+
+// StreamExport hands every user matching the filter to fn, unpaginated, a batch
+// at a time as it is read, for the admin-only export.
+func (s *userService) StreamExport(filter models.UserFilter, fn func([]models.User) error) error {
+	return s.userRepo.StreamFiltered(filter, fn)
 }
\ No newline at end of file
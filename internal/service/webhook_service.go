@@ -0,0 +1,514 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/florinel-chis/gophercrm/internal/webhook"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// webhookBatchSize is how many deliveries one pass of the worker claims.
+	webhookBatchSize = 20
+	// webhookLastErrorMaxLength is the width of webhook_deliveries.last_error.
+	webhookLastErrorMaxLength = 500
+	// webhookNameMaxLength and webhookURLMaxLength are the widths of the
+	// subscription columns.
+	webhookNameMaxLength = 100
+	webhookURLMaxLength  = 2048
+)
+
+// WebhookPublisher queues an event for the webhook subscriptions that want it.
+// The entity services call it after the change has been committed, so they log
+// a failure rather than return it.
+type WebhookPublisher interface {
+	// Publish renders the event once and queues a delivery of it for every
+	// active subscription listing it. data becomes the body's "data" object;
+	// entityType and entityID name the record it is about, which is how an
+	// erasure finds the payloads quoting that record.
+	Publish(event models.WebhookEvent, entityType string, entityID uint, data interface{}) error
+}
+
+// WebhookOption wires the webhook publisher into an entity service at
+// construction time. A service built without one publishes nothing.
+type WebhookOption func(*webhookEvents)
+
+func (o WebhookOption) applyEntityOption(_ *activityFeed, events *webhookEvents) { o(events) }
+
+// WithWebhooks makes the service publish its changes as webhook events.
+func WithWebhooks(webhooks WebhookPublisher) WebhookOption {
+	return func(w *webhookEvents) { w.webhooks = webhooks }
+}
+
+// webhookEvents is embedded by the entity services that publish webhooks.
+type webhookEvents struct {
+	webhooks WebhookPublisher
+}
+
+func (w *webhookEvents) applyWebhookOptions(opts []WebhookOption) {
+	for _, opt := range opts {
+		opt(w)
+	}
+}
+
+// publishWebhook queues a webhook event about a change that has already been
+// committed, so a failure is logged, not returned.
+func (w *webhookEvents) publishWebhook(event models.WebhookEvent, entityType string, entityID uint, data interface{}) {
+	if w.webhooks == nil {
+		return
+	}
+	if err := w.webhooks.Publish(event, entityType, entityID, data); err != nil {
+		utils.Logger.WithError(err).
+			WithField("event", event).
+			WithField("entity_id", entityID).
+			Warn("Failed to queue webhook event")
+	}
+}
+
+// WebhookService manages the webhook subscriptions and drains their delivery
+// queue.
+//
+// Every event is delivered at least once: a delivery that fails is retried
+// with exponential backoff until it succeeds or runs out of attempts, when it
+// is dead-lettered for an admin to inspect and redeliver. Receivers drop
+// duplicates by the event id in the body.
+type WebhookService interface {
+	WebhookPublisher
+	webhook.Dispatcher
+
+	// Create stores a subscription and returns its signing secret, which is
+	// shown this once.
+	Create(sub *models.WebhookSubscription) (string, error)
+	List(offset, limit int) ([]models.WebhookSubscription, int64, error)
+	GetByID(id uint) (*models.WebhookSubscription, error)
+	// Update replaces the name, URL, events and active flag; the secret is
+	// kept.
+	Update(id uint, sub *models.WebhookSubscription) error
+	// Delete removes a subscription together with its delivery history.
+	Delete(id uint) error
+	// RotateSecret replaces the signing secret and returns the new one.
+	// Deliveries from then on, retries included, are signed with it.
+	RotateSecret(id uint) (string, error)
+
+	// ListDeliveries returns one page of a subscription's deliveries, newest
+	// first; an empty status lists them all.
+	ListDeliveries(subscriptionID uint, status models.WebhookDeliveryStatus, offset, limit int) ([]models.WebhookDelivery, int64, error)
+	// Redeliver queues a delivery again, with a fresh set of attempts, to go
+	// out on the worker's next pass. A delivery whose payload was erased is
+	// rejected with apperrors.ErrValidation.
+	Redeliver(subscriptionID, deliveryID uint) (*models.WebhookDelivery, error)
+}
+
+type webhookService struct {
+	repo      repository.WebhookRepository
+	secretBox *utils.SecretBox
+	sender    *webhook.Sender
+	cfg       config.WebhookConfig
+}
+
+func NewWebhookService(repo repository.WebhookRepository, secretBox *utils.SecretBox, sender *webhook.Sender, cfg config.WebhookConfig) WebhookService {
+	return &webhookService{
+		repo:      repo,
+		secretBox: secretBox,
+		sender:    sender,
+		cfg:       cfg,
+	}
+}
+
+func (s *webhookService) Create(sub *models.WebhookSubscription) (string, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("webhook_name", sub.Name), "WebhookService", "Create")
+
+	if err := prepareWebhookSubscription(sub); err != nil {
+		logger.WithError(err).Warn("Rejected webhook subscription")
+		return "", err
+	}
+	secret, err := s.sealNewSecret(sub)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return "", err
+	}
+	if err := s.repo.CreateSubscription(sub); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return "", err
+	}
+
+	logger.WithField("webhook_id", sub.ID).Info("Webhook subscription created")
+	return secret, nil
+}
+
+func (s *webhookService) List(offset, limit int) ([]models.WebhookSubscription, int64, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithFields(map[string]interface{}{
+		"offset": offset,
+		"limit":  limit,
+	}), "WebhookService", "List")
+
+	subs, total, err := s.repo.ListSubscriptions(offset, limit)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, 0, err
+	}
+	return subs, total, nil
+}
+
+func (s *webhookService) GetByID(id uint) (*models.WebhookSubscription, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("webhook_id", id), "WebhookService", "GetByID")
+
+	sub, err := s.repo.GetSubscription(id)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, fmt.Errorf("webhook %d not found: %w", id, apperrors.ErrNotFound)
+		}
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (s *webhookService) Update(id uint, sub *models.WebhookSubscription) error {
+	logger := utils.LogServiceCall(utils.Logger.WithField("webhook_id", id), "WebhookService", "Update")
+
+	if err := prepareWebhookSubscription(sub); err != nil {
+		logger.WithError(err).Warn("Rejected webhook subscription")
+		return err
+	}
+	existing, err := s.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	sub.ID = existing.ID
+	sub.CreatedAt = existing.CreatedAt
+	sub.CreatedByID = existing.CreatedByID
+	sub.SecretSealed = existing.SecretSealed
+	if err := s.repo.UpdateSubscription(sub); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+
+	logger.Info("Webhook subscription updated")
+	return nil
+}
+
+func (s *webhookService) Delete(id uint) error {
+	logger := utils.LogServiceCall(utils.Logger.WithField("webhook_id", id), "WebhookService", "Delete")
+
+	if err := s.repo.DeleteSubscription(id); err != nil {
+		if isNotFound(err) {
+			return fmt.Errorf("webhook %d not found: %w", id, apperrors.ErrNotFound)
+		}
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+	logger.Info("Webhook subscription deleted")
+	return nil
+}
+
+func (s *webhookService) RotateSecret(id uint) (string, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithField("webhook_id", id), "WebhookService", "RotateSecret")
+
+	sub, err := s.GetByID(id)
+	if err != nil {
+		return "", err
+	}
+	secret, err := s.sealNewSecret(sub)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return "", err
+	}
+	if err := s.repo.UpdateSubscription(sub); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return "", err
+	}
+
+	logger.Info("Webhook signing secret rotated")
+	return secret, nil
+}
+
+func (s *webhookService) ListDeliveries(subscriptionID uint, status models.WebhookDeliveryStatus, offset, limit int) ([]models.WebhookDelivery, int64, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithFields(map[string]interface{}{
+		"webhook_id": subscriptionID,
+		"status":     status,
+		"offset":     offset,
+		"limit":      limit,
+	}), "WebhookService", "ListDeliveries")
+
+	if status != "" && !status.IsValid() {
+		return nil, 0, fmt.Errorf("unknown delivery status %q: %w", status, apperrors.ErrValidation)
+	}
+	if _, err := s.GetByID(subscriptionID); err != nil {
+		return nil, 0, err
+	}
+	deliveries, total, err := s.repo.ListDeliveries(subscriptionID, status, offset, limit)
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+func (s *webhookService) Redeliver(subscriptionID, deliveryID uint) (*models.WebhookDelivery, error) {
+	logger := utils.LogServiceCall(utils.Logger.WithFields(map[string]interface{}{
+		"webhook_id":  subscriptionID,
+		"delivery_id": deliveryID,
+	}), "WebhookService", "Redeliver")
+
+	delivery, err := s.repo.GetDelivery(subscriptionID, deliveryID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, fmt.Errorf("webhook delivery %d not found: %w", deliveryID, apperrors.ErrNotFound)
+		}
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+	if delivery.Payload == models.AuditErasedValue {
+		return nil, fmt.Errorf("webhook delivery %d was erased and cannot be sent: %w", deliveryID, apperrors.ErrValidation)
+	}
+
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now().UTC()
+	delivery.DeliveredAt = nil
+	delivery.LastError = ""
+	if err := s.repo.UpdateDelivery(delivery); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return nil, err
+	}
+
+	logger.Info("Webhook delivery queued again")
+	return delivery, nil
+}
+
+func (s *webhookService) Publish(event models.WebhookEvent, entityType string, entityID uint, data interface{}) error {
+	logger := utils.LogServiceCall(utils.Logger.WithFields(map[string]interface{}{
+		"event":       event,
+		"entity_type": entityType,
+		"entity_id":   entityID,
+	}), "WebhookService", "Publish")
+
+	subs, err := s.repo.ActiveSubscriptions()
+	if err != nil {
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+	var wanting []models.WebhookSubscription
+	for _, sub := range subs {
+		if sub.Wants(event) {
+			wanting = append(wanting, sub)
+		}
+	}
+	if len(wanting) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	envelope := webhook.Envelope{ID: uuid.NewString(), Type: string(event), CreatedAt: now, Data: data}
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("encoding %s event: %w", event, err)
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0, len(wanting))
+	for _, sub := range wanting {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        envelope.ID,
+			Event:          event,
+			EntityType:     entityType,
+			EntityID:       entityID,
+			Payload:        string(body),
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  now,
+		})
+	}
+	if err := s.repo.CreateDeliveries(deliveries); err != nil {
+		utils.LogServiceResponse(logger, err)
+		return err
+	}
+
+	logger.WithField("deliveries", len(deliveries)).Debug("Webhook event queued")
+	return nil
+}
+
+func (s *webhookService) DeliverDue(now time.Time) (int, error) {
+	timeout := time.Duration(s.cfg.TimeoutSeconds) * time.Second
+	// The lease has to outlast the whole batch, which is sent one delivery at
+	// a time, or a slow batch would be claimed a second time by another
+	// replica before it is done.
+	leaseUntil := now.Add(time.Duration(webhookBatchSize)*timeout + time.Minute)
+
+	deliveries, err := s.repo.ClaimDue(now, leaseUntil, webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	subs := map[uint]*models.WebhookSubscription{}
+	attempted := 0
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		sub, ok := subs[delivery.SubscriptionID]
+		if !ok {
+			sub, err = s.repo.GetSubscription(delivery.SubscriptionID)
+			if err != nil {
+				if isNotFound(err) {
+					// Deleted since the claim; its deliveries went with it.
+					continue
+				}
+				return attempted, err
+			}
+			subs[delivery.SubscriptionID] = sub
+		}
+
+		s.attempt(sub, delivery, time.Now().UTC())
+		attempted++
+		if err := s.repo.UpdateDelivery(delivery); err != nil {
+			return attempted, err
+		}
+	}
+	return attempted, nil
+}
+
+// attempt sends one delivery and records the outcome on it: delivered, due
+// again after the backoff, or dead-lettered after the last attempt.
+func (s *webhookService) attempt(sub *models.WebhookSubscription, delivery *models.WebhookDelivery, now time.Time) {
+	status, err := 0, error(nil)
+	secret, openErr := s.secretBox.Open(sub.SecretSealed)
+	if openErr != nil {
+		// Sending unsigned would teach receivers to accept unsigned calls.
+		err = fmt.Errorf("signing secret cannot be read; rotate it")
+	} else {
+		status, err = s.sender.Send(webhook.Request{
+			URL:        sub.URL,
+			Secret:     secret,
+			Event:      string(delivery.Event),
+			DeliveryID: delivery.ID,
+			Body:       []byte(delivery.Payload),
+		})
+	}
+
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = status
+	logger := utils.Logger.WithFields(logrus.Fields{
+		"webhook_id":  sub.ID,
+		"delivery_id": delivery.ID,
+		"event":       delivery.Event,
+		"attempts":    delivery.Attempts,
+	})
+
+	if err == nil {
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+		logger.Debug("Webhook delivered")
+		return
+	}
+
+	delivery.LastError = truncate(err.Error(), webhookLastErrorMaxLength)
+	if delivery.Attempts >= s.cfg.MaxAttempts {
+		delivery.Status = models.WebhookDeliveryFailed
+		logger.WithError(err).Warn("Webhook delivery dead-lettered")
+		return
+	}
+	delivery.NextAttemptAt = now.Add(webhook.Backoff(delivery.Attempts))
+	logger.WithError(err).Info("Webhook delivery failed; will retry")
+}
+
+func (s *webhookService) sealNewSecret(sub *models.WebhookSubscription) (string, error) {
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := s.secretBox.Seal(secret)
+	if err != nil {
+		return "", err
+	}
+	sub.SecretSealed = sealed
+	return secret, nil
+}
+
+// prepareWebhookSubscription validates and normalises what an admin sent.
+func prepareWebhookSubscription(sub *models.WebhookSubscription) error {
+	sub.Name = strings.TrimSpace(sub.Name)
+	sub.URL = strings.TrimSpace(sub.URL)
+	if sub.Name == "" || len(sub.Name) > webhookNameMaxLength {
+		return fmt.Errorf("name must be 1 to %d characters: %w", webhookNameMaxLength, apperrors.ErrValidation)
+	}
+	if len(sub.URL) > webhookURLMaxLength {
+		return fmt.Errorf("url must be at most %d characters: %w", webhookURLMaxLength, apperrors.ErrValidation)
+	}
+	if err := webhook.ValidateURL(sub.URL); err != nil {
+		return fmt.Errorf("%s: %w", err.Error(), apperrors.ErrValidation)
+	}
+
+	if len(sub.Events) == 0 {
+		return fmt.Errorf("at least one event is required: %w", apperrors.ErrValidation)
+	}
+	seen := map[models.WebhookEvent]bool{}
+	events := make([]models.WebhookEvent, 0, len(sub.Events))
+	for _, event := range sub.Events {
+		if !event.IsValid() {
+			return fmt.Errorf("unknown event %q: %w", event, apperrors.ErrValidation)
+		}
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	sub.Events = events
+	return nil
+}
+
+// webhookEntity renders a record as the data of an event: its JSON form minus
+// the named associations. The receiver fetches a related record by the id
+// that stays behind; a preloaded owner or customer would otherwise ship a
+// second person's details to a third-party system.
+func webhookEntity(entity interface{}, associations ...string) map[string]interface{} {
+	encoded, err := json.Marshal(entity)
+	if err != nil {
+		return map[string]interface{}{}
+	}
+	data := map[string]interface{}{}
+	if err := json.Unmarshal(encoded, &data); err != nil {
+		return map[string]interface{}{}
+	}
+	for _, association := range associations {
+		delete(data, association)
+	}
+	return data
+}
+
+// The data of each entity's events, minus its associations.
+func leadWebhookData(lead *models.Lead) map[string]interface{} {
+	return webhookEntity(lead, "owner", "customer")
+}
+
+func customerWebhookData(customer *models.Customer) map[string]interface{} {
+	return webhookEntity(customer, "user", "assigned_to", "tickets")
+}
+
+// ticketWebhookData also leaves out the reply token, which would let the
+// receiver post replies to the ticket as its customer.
+func ticketWebhookData(ticket *models.Ticket) map[string]interface{} {
+	return webhookEntity(ticket, "customer", "assigned_to", "reply_token")
+}
+
+// formSubmissionWebhookData leaves out the visitor's IP address, user agent
+// and referrer, which are kept for spam review and are nobody else's business.
+func formSubmissionWebhookData(submission *models.FormSubmission) map[string]interface{} {
+	return webhookEntity(submission, "ip_address", "user_agent", "referrer")
+}
+
+// deletedWebhookData is the data of a deleted event: only the id, since the
+// record is gone — erased, for a person — by the time it is sent.
+func deletedWebhookData(id uint) map[string]interface{} {
+	return map[string]interface{}{"id": id}
+}
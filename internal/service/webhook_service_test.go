@@ -0,0 +1,364 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/mocks"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/florinel-chis/gophercrm/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// webhookEndpoint is a receiver that records what it was sent and answers with
+// a scripted status.
+type webhookEndpoint struct {
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+	server   *httptest.Server
+}
+
+func newWebhookEndpoint(status int) *webhookEndpoint {
+	e := &webhookEndpoint{status: status}
+	e.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		e.mu.Lock()
+		e.requests = append(e.requests, r)
+		e.bodies = append(e.bodies, body)
+		status := e.status
+		e.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	return e
+}
+
+type WebhookServiceTestSuite struct {
+	suite.Suite
+	mockRepo  *mocks.WebhookRepository
+	secretBox *utils.SecretBox
+	endpoint  *webhookEndpoint
+	service   WebhookService
+}
+
+func (suite *WebhookServiceTestSuite) SetupSuite() {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+}
+
+func (suite *WebhookServiceTestSuite) SetupTest() {
+	suite.mockRepo = new(mocks.WebhookRepository)
+	suite.secretBox = utils.NewSecretBox("test-master-secret", "webhook-secret")
+	suite.endpoint = newWebhookEndpoint(http.StatusOK)
+	suite.service = NewWebhookService(suite.mockRepo, suite.secretBox, webhook.NewSender(time.Second),
+		config.WebhookConfig{MaxAttempts: 3, TimeoutSeconds: 1})
+}
+
+func (suite *WebhookServiceTestSuite) TearDownTest() {
+	suite.endpoint.server.Close()
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+func (suite *WebhookServiceTestSuite) subscription(id uint, secret string, events ...models.WebhookEvent) *models.WebhookSubscription {
+	sealed, err := suite.secretBox.Seal(secret)
+	suite.Require().NoError(err)
+	return &models.WebhookSubscription{
+		ID: id, Name: "Billing", URL: suite.endpoint.server.URL, Active: true, Events: events, SecretSealed: sealed,
+	}
+}
+
+func (suite *WebhookServiceTestSuite) TestCreate_SealsTheSecretItReturns() {
+	sub := &models.WebhookSubscription{
+		Name:   " Billing ",
+		URL:    "https://billing.example.com/hooks",
+		Events: []models.WebhookEvent{models.WebhookLeadCreated, models.WebhookLeadCreated, models.WebhookTicketResolved},
+	}
+	suite.mockRepo.On("CreateSubscription", sub).Return(nil)
+
+	secret, err := suite.service.Create(sub)
+
+	suite.Require().NoError(err)
+	suite.Regexp(`^whsec_[0-9a-f]{64}$`, secret)
+	suite.NotContains(sub.SecretSealed, secret, "only the sealed form is stored")
+	opened, err := suite.secretBox.Open(sub.SecretSealed)
+	suite.Require().NoError(err)
+	suite.Equal(secret, opened)
+	suite.Equal("Billing", sub.Name)
+	suite.Equal([]models.WebhookEvent{models.WebhookLeadCreated, models.WebhookTicketResolved}, sub.Events)
+}
+
+func (suite *WebhookServiceTestSuite) TestCreate_RejectsBadSubscriptions() {
+	cases := map[string]*models.WebhookSubscription{
+		"no name":       {URL: "https://x.example.com", Events: []models.WebhookEvent{models.WebhookLeadCreated}},
+		"bad scheme":    {Name: "x", URL: "ftp://x.example.com", Events: []models.WebhookEvent{models.WebhookLeadCreated}},
+		"no events":     {Name: "x", URL: "https://x.example.com"},
+		"unknown event": {Name: "x", URL: "https://x.example.com", Events: []models.WebhookEvent{"lead.exploded"}},
+	}
+	for name, sub := range cases {
+		_, err := suite.service.Create(sub)
+		suite.ErrorIs(err, apperrors.ErrValidation, name)
+	}
+}
+
+func (suite *WebhookServiceTestSuite) TestUpdate_KeepsTheSecret() {
+	existing := suite.subscription(4, "whsec_old", models.WebhookLeadCreated)
+	existing.CreatedByID = 1
+	suite.mockRepo.On("GetSubscription", uint(4)).Return(existing, nil)
+	suite.mockRepo.On("UpdateSubscription", mock.MatchedBy(func(s *models.WebhookSubscription) bool {
+		return s.ID == 4 && s.SecretSealed == existing.SecretSealed && s.CreatedByID == 1 && !s.Active
+	})).Return(nil)
+
+	err := suite.service.Update(4, &models.WebhookSubscription{
+		Name: "Warehouse", URL: "https://dw.example.com/in", Events: []models.WebhookEvent{models.WebhookTicketCreated},
+	})
+
+	suite.NoError(err)
+}
+
+func (suite *WebhookServiceTestSuite) TestDelete_NotFound() {
+	suite.mockRepo.On("DeleteSubscription", uint(9)).Return(gorm.ErrRecordNotFound)
+
+	err := suite.service.Delete(9)
+
+	suite.ErrorIs(err, apperrors.ErrNotFound)
+}
+
+func (suite *WebhookServiceTestSuite) TestRotateSecret_ReplacesTheSealedSecret() {
+	sub := suite.subscription(4, "whsec_old", models.WebhookLeadCreated)
+	previous := sub.SecretSealed
+	suite.mockRepo.On("GetSubscription", uint(4)).Return(sub, nil)
+	suite.mockRepo.On("UpdateSubscription", sub).Return(nil)
+
+	secret, err := suite.service.RotateSecret(4)
+
+	suite.Require().NoError(err)
+	suite.NotEqual(previous, sub.SecretSealed)
+	opened, err := suite.secretBox.Open(sub.SecretSealed)
+	suite.Require().NoError(err)
+	suite.Equal(secret, opened)
+}
+
+func (suite *WebhookServiceTestSuite) TestPublish_QueuesOneDeliveryPerInterestedSubscription() {
+	wanting := suite.subscription(1, "whsec_a", models.WebhookLeadCreated)
+	other := suite.subscription(2, "whsec_b", models.WebhookTicketCreated)
+	suite.mockRepo.On("ActiveSubscriptions").Return([]models.WebhookSubscription{*wanting, *other}, nil)
+
+	var queued []models.WebhookDelivery
+	suite.mockRepo.On("CreateDeliveries", mock.Anything).Run(func(args mock.Arguments) {
+		queued = args.Get(0).([]models.WebhookDelivery)
+	}).Return(nil)
+
+	err := suite.service.Publish(models.WebhookLeadCreated, models.AuditEntityLead, 7, map[string]interface{}{"id": 7})
+
+	suite.Require().NoError(err)
+	suite.Require().Len(queued, 1)
+	delivery := queued[0]
+	suite.Equal(uint(1), delivery.SubscriptionID)
+	suite.Equal(models.WebhookDeliveryPending, delivery.Status)
+	suite.Equal(models.AuditEntityLead, delivery.EntityType)
+	suite.Equal(uint(7), delivery.EntityID)
+
+	var envelope webhook.Envelope
+	suite.Require().NoError(json.Unmarshal([]byte(delivery.Payload), &envelope))
+	suite.Equal(delivery.EventID, envelope.ID)
+	suite.Equal("lead.created", envelope.Type)
+	suite.Equal(map[string]interface{}{"id": float64(7)}, envelope.Data)
+}
+
+func (suite *WebhookServiceTestSuite) TestPublish_NobodyInterested() {
+	suite.mockRepo.On("ActiveSubscriptions").Return([]models.WebhookSubscription{*suite.subscription(1, "s", models.WebhookTicketCreated)}, nil)
+
+	err := suite.service.Publish(models.WebhookLeadCreated, models.AuditEntityLead, 7, nil)
+
+	suite.NoError(err)
+	suite.mockRepo.AssertNotCalled(suite.T(), "CreateDeliveries", mock.Anything)
+}
+
+func (suite *WebhookServiceTestSuite) TestDeliverDue_SendsSignedPayload() {
+	now := time.Now().UTC()
+	sub := suite.subscription(1, "whsec_signing", models.WebhookLeadCreated)
+	delivery := models.WebhookDelivery{ID: 11, SubscriptionID: 1, Event: models.WebhookLeadCreated,
+		Payload: `{"id":"evt","type":"lead.created"}`, Status: models.WebhookDeliveryPending}
+	suite.mockRepo.On("ClaimDue", now, mock.AnythingOfType("time.Time"), webhookBatchSize).
+		Return([]models.WebhookDelivery{delivery}, nil)
+	suite.mockRepo.On("GetSubscription", uint(1)).Return(sub, nil)
+	suite.mockRepo.On("UpdateDelivery", mock.MatchedBy(func(d *models.WebhookDelivery) bool {
+		return d.Status == models.WebhookDeliveryDelivered && d.Attempts == 1 && d.ResponseStatus == http.StatusOK &&
+			d.DeliveredAt != nil && d.LastError == ""
+	})).Return(nil)
+
+	attempted, err := suite.service.DeliverDue(now)
+
+	suite.Require().NoError(err)
+	suite.Equal(1, attempted)
+	suite.Require().Len(suite.endpoint.requests, 1)
+	request := suite.endpoint.requests[0]
+	suite.Equal("lead.created", request.Header.Get(webhook.EventHeader))
+	suite.Equal("11", request.Header.Get(webhook.DeliveryHeader))
+	suite.Equal(delivery.Payload, string(suite.endpoint.bodies[0]))
+	suite.NoError(webhook.Verify("whsec_signing", request.Header.Get(webhook.SignatureHeader),
+		suite.endpoint.bodies[0], time.Minute, time.Now()))
+}
+
+func (suite *WebhookServiceTestSuite) TestDeliverDue_FailureBacksOffThenDeadLetters() {
+	suite.endpoint.status = http.StatusInternalServerError
+	now := time.Now().UTC()
+	sub := suite.subscription(1, "whsec_signing", models.WebhookLeadCreated)
+	first := models.WebhookDelivery{ID: 11, SubscriptionID: 1, Payload: `{}`, Status: models.WebhookDeliveryPending}
+	last := models.WebhookDelivery{ID: 12, SubscriptionID: 1, Payload: `{}`, Status: models.WebhookDeliveryPending, Attempts: 2}
+	suite.mockRepo.On("ClaimDue", now, mock.AnythingOfType("time.Time"), webhookBatchSize).
+		Return([]models.WebhookDelivery{first, last}, nil)
+	suite.mockRepo.On("GetSubscription", uint(1)).Return(sub, nil).Once()
+
+	suite.mockRepo.On("UpdateDelivery", mock.MatchedBy(func(d *models.WebhookDelivery) bool {
+		return d.ID == 11 && d.Status == models.WebhookDeliveryPending && d.Attempts == 1 &&
+			d.ResponseStatus == http.StatusInternalServerError && d.LastError == "endpoint answered 500" &&
+			d.NextAttemptAt.Sub(*d.LastAttemptAt) == webhook.Backoff(1)
+	})).Return(nil)
+	suite.mockRepo.On("UpdateDelivery", mock.MatchedBy(func(d *models.WebhookDelivery) bool {
+		return d.ID == 12 && d.Status == models.WebhookDeliveryFailed && d.Attempts == 3
+	})).Return(nil)
+
+	attempted, err := suite.service.DeliverDue(now)
+
+	suite.NoError(err)
+	suite.Equal(2, attempted)
+}
+
+func (suite *WebhookServiceTestSuite) TestDeliverDue_UnreadableSecretIsNotSentUnsigned() {
+	now := time.Now().UTC()
+	sub := suite.subscription(1, "whsec_signing", models.WebhookLeadCreated)
+	sub.SecretSealed = "enc:v1:garbage"
+	suite.mockRepo.On("ClaimDue", now, mock.AnythingOfType("time.Time"), webhookBatchSize).
+		Return([]models.WebhookDelivery{{ID: 11, SubscriptionID: 1, Payload: `{}`}}, nil)
+	suite.mockRepo.On("GetSubscription", uint(1)).Return(sub, nil)
+	suite.mockRepo.On("UpdateDelivery", mock.MatchedBy(func(d *models.WebhookDelivery) bool {
+		return d.Attempts == 1 && d.ResponseStatus == 0 && d.LastError == "signing secret cannot be read; rotate it"
+	})).Return(nil)
+
+	_, err := suite.service.DeliverDue(now)
+
+	suite.NoError(err)
+	suite.Empty(suite.endpoint.requests)
+}
+
+func (suite *WebhookServiceTestSuite) TestDeliverDue_ClaimFailure() {
+	now := time.Now().UTC()
+	suite.mockRepo.On("ClaimDue", now, mock.AnythingOfType("time.Time"), webhookBatchSize).
+		Return(nil, errors.New("db down"))
+
+	attempted, err := suite.service.DeliverDue(now)
+
+	suite.Error(err)
+	suite.Zero(attempted)
+}
+
+func (suite *WebhookServiceTestSuite) TestRedeliver_RequeuesWithFreshAttempts() {
+	delivered := time.Now().Add(-time.Hour)
+	delivery := &models.WebhookDelivery{ID: 11, SubscriptionID: 1, Payload: `{}`, Status: models.WebhookDeliveryFailed,
+		Attempts: 3, LastError: "endpoint answered 500", DeliveredAt: &delivered}
+	suite.mockRepo.On("GetDelivery", uint(1), uint(11)).Return(delivery, nil)
+	suite.mockRepo.On("UpdateDelivery", delivery).Return(nil)
+
+	requeued, err := suite.service.Redeliver(1, 11)
+
+	suite.Require().NoError(err)
+	suite.Equal(models.WebhookDeliveryPending, requeued.Status)
+	suite.Zero(requeued.Attempts)
+	suite.Empty(requeued.LastError)
+	suite.Nil(requeued.DeliveredAt)
+	suite.WithinDuration(time.Now(), requeued.NextAttemptAt, time.Minute)
+}
+
+func (suite *WebhookServiceTestSuite) TestRedeliver_RefusesErasedPayload() {
+	suite.mockRepo.On("GetDelivery", uint(1), uint(11)).
+		Return(&models.WebhookDelivery{ID: 11, SubscriptionID: 1, Payload: models.AuditErasedValue}, nil)
+
+	_, err := suite.service.Redeliver(1, 11)
+
+	suite.ErrorIs(err, apperrors.ErrValidation)
+}
+
+func (suite *WebhookServiceTestSuite) TestListDeliveries_UnknownStatus() {
+	_, _, err := suite.service.ListDeliveries(1, "lost", 0, 20)
+
+	suite.ErrorIs(err, apperrors.ErrValidation)
+}
+
+func (suite *WebhookServiceTestSuite) TestWebhookEntityDropsAssociations() {
+	lead := &models.Lead{FirstName: "Ada", Email: "ada@example.com", OwnerID: 2}
+	lead.ID = 7
+	lead.Owner.Email = "owner@example.com"
+
+	data := leadWebhookData(lead)
+
+	suite.Equal("ada@example.com", data["email"])
+	suite.Equal(float64(2), data["owner_id"])
+	suite.NotContains(data, "owner")
+}
+
+func TestWebhookServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(WebhookServiceTestSuite))
+}
+
+// Resolving a ticket is both an update and a resolution; a subscriber to
+// either hears about it.
+func TestTicketService_ResolvingPublishesUpdatedAndResolved(t *testing.T) {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+	ticketRepo := new(mocks.TicketRepository)
+	commentRepo := new(mocks.TicketCommentRepository)
+	webhooks := new(mocks.WebhookService)
+	svc := NewTicketService(ticketRepo, new(mocks.CustomerRepository), new(mocks.UserRepository), nil, commentRepo,
+		WithWebhooks(webhooks))
+
+	existing := &models.Ticket{Title: "Printer on fire", Status: models.TicketStatusInProgress, CustomerID: 3}
+	existing.ID = 5
+	ticketRepo.On("GetByID", uint(5)).Return(existing, nil)
+	ticketRepo.On("Update", mock.AnythingOfType("*models.Ticket")).Return(nil)
+	commentRepo.On("Create", mock.AnythingOfType("*models.TicketComment")).Return(nil)
+
+	var published []models.WebhookEvent
+	webhooks.On("Publish", mock.Anything, models.AuditEntityTicket, uint(5), mock.MatchedBy(func(data interface{}) bool {
+		fields := data.(map[string]interface{})
+		_, hasCustomer := fields["customer"]
+		return fields["status"] == string(models.TicketStatusResolved) && !hasCustomer
+	})).Run(func(args mock.Arguments) {
+		published = append(published, args.Get(0).(models.WebhookEvent))
+	}).Return(nil)
+
+	ticket := &models.Ticket{Title: "Printer on fire", Status: models.TicketStatusResolved, CustomerID: 3}
+	ticket.ID = 5
+	err := svc.Update(ticket)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.WebhookEvent{models.WebhookTicketUpdated, models.WebhookTicketResolved}, published)
+}
+
+// A queue that cannot be written must not fail a change that is already
+// committed.
+func TestLeadService_WebhookFailureDoesNotFailCreate(t *testing.T) {
+	utils.InitLogger(&config.LoggingConfig{Level: "debug", Format: "json"})
+	leadRepo := new(mocks.LeadRepository)
+	webhooks := new(mocks.WebhookService)
+	svc := NewLeadService(leadRepo, new(mocks.CustomerRepository), nil, nil, WithWebhooks(webhooks))
+
+	leadRepo.On("Create", mock.AnythingOfType("*models.Lead")).Return(nil)
+	webhooks.On("Publish", models.WebhookLeadCreated, models.AuditEntityLead, mock.Anything, mock.Anything).Return(assert.AnError)
+
+	err := svc.Create(&models.Lead{FirstName: "Ada", Email: "ada@example.com", OwnerID: 1})
+
+	assert.NoError(t, err)
+	webhooks.AssertExpectations(t)
+}
@@ -0,0 +1,140 @@
+// Package sla computes ticket SLA deadlines and runs the background worker that
+// flags missed ones.
+package sla
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+)
+
+// Calendar says when the SLA clock runs. The zero value is not usable; build
+// one with AlwaysOpen or NewCalendar.
+type Calendar struct {
+	always   bool
+	location *time.Location
+	// start and end are offsets from local midnight.
+	start time.Duration
+	end   time.Duration
+	days  [7]bool
+}
+
+// AlwaysOpen is the around-the-clock calendar.
+func AlwaysOpen() Calendar {
+	return Calendar{always: true}
+}
+
+// NewCalendar builds a business-hours calendar. dayStart and dayEnd are HH:MM
+// wall-clock times in timezone, and days are time.Weekday values. A calendar
+// with no working days, or whose day ends before it starts, is rejected: it
+// would never let a deadline fall due.
+func NewCalendar(timezone, dayStart, dayEnd string, days []int) (Calendar, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return Calendar{}, fmt.Errorf("unknown timezone %q", timezone)
+	}
+	start, err := ParseClock(dayStart)
+	if err != nil {
+		return Calendar{}, err
+	}
+	end, err := ParseClock(dayEnd)
+	if err != nil {
+		return Calendar{}, err
+	}
+	if end <= start {
+		return Calendar{}, fmt.Errorf("business day must end after it starts (%s-%s)", dayStart, dayEnd)
+	}
+
+	calendar := Calendar{location: location, start: start, end: end}
+	working := 0
+	for _, day := range days {
+		if day < 0 || day > 6 {
+			return Calendar{}, fmt.Errorf("business day %d is not a weekday (0 is Sunday, 6 is Saturday)", day)
+		}
+		if !calendar.days[day] {
+			calendar.days[day] = true
+			working++
+		}
+	}
+	if working == 0 {
+		return Calendar{}, fmt.Errorf("a business-hours calendar needs at least one working day")
+	}
+	return calendar, nil
+}
+
+// CalendarFor returns the calendar a policy's deadlines are measured on.
+func CalendarFor(policy models.SLAPolicy) (Calendar, error) {
+	if !policy.BusinessHoursOnly {
+		return AlwaysOpen(), nil
+	}
+	return NewCalendar(policy.Timezone, policy.BusinessDayStart, policy.BusinessDayEnd, policy.BusinessDays)
+}
+
+// ParseClock parses an HH:MM wall-clock time into its offset from midnight.
+// 24:00 is accepted so a business day can run to the end of the day.
+func ParseClock(clock string) (time.Duration, error) {
+	hours, minutes, ok := strings.Cut(clock, ":")
+	if !ok || len(hours) != 2 || len(minutes) != 2 {
+		return 0, fmt.Errorf("time %q is not in HH:MM form", clock)
+	}
+	h, errH := strconv.Atoi(hours)
+	m, errM := strconv.Atoi(minutes)
+	if errH != nil || errM != nil || h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("time %q is not in HH:MM form", clock)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+// Add returns the instant at which d of calendar time has elapsed after from.
+// On a business-hours calendar the clock only runs inside working hours, so a
+// ticket opened on Friday evening with a four-hour target falls due on Monday
+// morning.
+//
+// Day boundaries are built with time.Date rather than by adding 24 hours so a
+// DST transition still lands on the configured wall-clock times.
+func (c Calendar) Add(from time.Time, d time.Duration) time.Time {
+	if c.always || d <= 0 {
+		return from.Add(d)
+	}
+
+	t := from.In(c.location)
+	remaining := d
+	for {
+		midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.location)
+		opens := c.at(midnight, c.start)
+		closes := c.at(midnight, c.end)
+
+		if c.days[t.Weekday()] && t.Before(closes) {
+			if t.Before(opens) {
+				t = opens
+			}
+			available := closes.Sub(t)
+			if remaining <= available {
+				return t.Add(remaining)
+			}
+			remaining -= available
+		}
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, c.location)
+	}
+}
+
+// at is the wall-clock time offset after the given local midnight.
+func (c Calendar) at(midnight time.Time, offset time.Duration) time.Time {
+	return time.Date(midnight.Year(), midnight.Month(), midnight.Day(),
+		int(offset/time.Hour), int(offset%time.Hour/time.Minute), 0, 0, c.location)
+}
+
+// Deadlines returns the first-response and resolution due dates of a ticket
+// opened at opened under policy, in opened's location.
+func Deadlines(policy models.SLAPolicy, opened time.Time) (firstResponse, resolution time.Time, err error) {
+	calendar, err := CalendarFor(policy)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	firstResponse = calendar.Add(opened, time.Duration(policy.FirstResponseMinutes)*time.Minute).In(opened.Location())
+	resolution = calendar.Add(opened, time.Duration(policy.ResolutionMinutes)*time.Minute).In(opened.Location())
+	return firstResponse, resolution, nil
+}
@@ -0,0 +1,148 @@
+package sla
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+)
+
+func weekdayCalendar(t *testing.T) Calendar {
+	t.Helper()
+	calendar, err := NewCalendar("UTC", "09:00", "17:00", []int{1, 2, 3, 4, 5})
+	require.NoError(t, err)
+	return calendar
+}
+
+func TestCalendarAdd_AlwaysOpen(t *testing.T) {
+	from := time.Date(2026, 8, 14, 22, 0, 0, 0, time.UTC) // Friday
+	assert.Equal(t, from.Add(4*time.Hour), AlwaysOpen().Add(from, 4*time.Hour))
+}
+
+func TestCalendarAdd_BusinessHours(t *testing.T) {
+	calendar := weekdayCalendar(t)
+	utc := time.UTC
+
+	tests := []struct {
+		name string
+		from time.Time
+		d    time.Duration
+		want time.Time
+	}{
+		{
+			name: "inside the working day",
+			from: time.Date(2026, 8, 11, 10, 0, 0, 0, utc), // Tuesday
+			d:    2 * time.Hour,
+			want: time.Date(2026, 8, 11, 12, 0, 0, 0, utc),
+		},
+		{
+			name: "before opening starts the clock at opening",
+			from: time.Date(2026, 8, 11, 6, 30, 0, 0, utc),
+			d:    time.Hour,
+			want: time.Date(2026, 8, 11, 10, 0, 0, 0, utc),
+		},
+		{
+			name: "runs over into the next working day",
+			from: time.Date(2026, 8, 11, 16, 0, 0, 0, utc),
+			d:    2 * time.Hour,
+			want: time.Date(2026, 8, 12, 10, 0, 0, 0, utc),
+		},
+		{
+			name: "friday evening falls due on monday",
+			from: time.Date(2026, 8, 14, 18, 0, 0, 0, utc),
+			d:    4 * time.Hour,
+			want: time.Date(2026, 8, 17, 13, 0, 0, 0, utc),
+		},
+		{
+			name: "exactly at closing",
+			from: time.Date(2026, 8, 11, 9, 0, 0, 0, utc),
+			d:    8 * time.Hour,
+			want: time.Date(2026, 8, 11, 17, 0, 0, 0, utc),
+		},
+		{
+			name: "spans several working days",
+			from: time.Date(2026, 8, 13, 9, 0, 0, 0, utc), // Thursday
+			d:    24 * time.Hour,
+			want: time.Date(2026, 8, 17, 17, 0, 0, 0, utc),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, calendar.Add(tt.from, tt.d).UTC())
+		})
+	}
+}
+
+func TestCalendarAdd_KeepsWallClockAcrossDST(t *testing.T) {
+	calendar, err := NewCalendar("Europe/Berlin", "09:00", "17:00", []int{1, 2, 3, 4, 5})
+	if err != nil {
+		t.Skip("zoneinfo database not available")
+	}
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+
+	// Clocks go back on Sunday 2026-10-25; the working day after it still
+	// opens at 09:00 local time.
+	from := time.Date(2026, 10, 23, 16, 0, 0, 0, berlin)
+	got := calendar.Add(from, 2*time.Hour)
+
+	assert.Equal(t, time.Date(2026, 10, 26, 10, 0, 0, 0, berlin), got)
+}
+
+func TestNewCalendar_RejectsUnusableCalendars(t *testing.T) {
+	_, err := NewCalendar("UTC", "17:00", "09:00", []int{1})
+	assert.Error(t, err)
+
+	_, err = NewCalendar("UTC", "09:00", "17:00", nil)
+	assert.Error(t, err)
+
+	_, err = NewCalendar("UTC", "09:00", "17:00", []int{7})
+	assert.Error(t, err)
+
+	_, err = NewCalendar("Not/AZone", "09:00", "17:00", []int{1})
+	assert.Error(t, err)
+}
+
+func TestParseClock(t *testing.T) {
+	got, err := ParseClock("08:30")
+	require.NoError(t, err)
+	assert.Equal(t, 8*time.Hour+30*time.Minute, got)
+
+	got, err = ParseClock("24:00")
+	require.NoError(t, err)
+	assert.Equal(t, 24*time.Hour, got)
+
+	for _, bad := range []string{"8:30", "24:30", "12:60", "noon", ""} {
+		_, err := ParseClock(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestDeadlines(t *testing.T) {
+	policy := models.SLAPolicy{
+		Priority:             models.TicketPriorityHigh,
+		FirstResponseMinutes: 60,
+		ResolutionMinutes:    8 * 60,
+		BusinessHoursOnly:    true,
+		Timezone:             "UTC",
+		BusinessDayStart:     "09:00",
+		BusinessDayEnd:       "17:00",
+		BusinessDays:         []int{1, 2, 3, 4, 5},
+	}
+	opened := time.Date(2026, 8, 11, 15, 0, 0, 0, time.UTC)
+
+	firstResponse, resolution, err := Deadlines(policy, opened)
+
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 8, 11, 16, 0, 0, 0, time.UTC), firstResponse)
+	assert.Equal(t, time.Date(2026, 8, 12, 15, 0, 0, 0, time.UTC), resolution)
+
+	policy.BusinessHoursOnly = false
+	firstResponse, resolution, err = Deadlines(policy, opened)
+	require.NoError(t, err)
+	assert.Equal(t, opened.Add(time.Hour), firstResponse)
+	assert.Equal(t, opened.Add(8*time.Hour), resolution)
+}
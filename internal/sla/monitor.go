@@ -0,0 +1,81 @@
+package sla
+
+import (
+	"context"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// BreachMonitorName identifies the breach worker goroutine in logs.
+const BreachMonitorName = "sla-breach-monitor"
+
+// BreachFlagger is the slice of the SLA service the worker needs. Depending on
+// this instead of service.SLAService keeps internal/sla free of an import
+// cycle (the service already imports this package for the calendar).
+type BreachFlagger interface {
+	FlagBreaches(now time.Time) (int, error)
+}
+
+// StartBreachMonitor launches the breach worker and returns immediately. It
+// sweeps once at start, so breaches that fell due while the process was down
+// are flagged without waiting a full interval, then once per interval. The
+// goroutine exits when ctx is cancelled.
+func StartBreachMonitor(ctx context.Context, flagger BreachFlagger, interval time.Duration) {
+	if flagger == nil {
+		logMonitor().Warn("SLA breach monitor not started: no flagger")
+		return
+	}
+	if interval <= 0 {
+		logMonitor().WithField("interval", interval.String()).Warn("SLA breach monitor not started: interval must be positive")
+		return
+	}
+	go monitorLoop(ctx, flagger, interval)
+}
+
+func monitorLoop(ctx context.Context, flagger BreachFlagger, interval time.Duration) {
+	logMonitor().WithField("interval", interval.String()).Info("SLA breach monitor started")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		sweep(flagger, time.Now())
+
+		select {
+		case <-ctx.Done():
+			logMonitor().Info("SLA breach monitor stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sweep runs one breach pass. Every outcome — including a panic inside the
+// service — is contained here so the loop always survives to the next tick.
+func sweep(flagger BreachFlagger, now time.Time) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			logMonitor().WithField("panic", recovered).Error("SLA breach sweep panicked")
+		}
+	}()
+
+	flagged, err := flagger.FlagBreaches(now)
+	switch {
+	case err != nil:
+		logMonitor().WithField("error", err.Error()).Error("SLA breach sweep failed")
+	case flagged > 0:
+		logMonitor().WithField("breaches", flagged).Info("SLA breaches flagged")
+	default:
+		logMonitor().Debug("SLA breach sweep found nothing new")
+	}
+}
+
+func logMonitor() *logrus.Entry {
+	base := utils.Logger
+	if base == nil {
+		base = logrus.StandardLogger()
+	}
+	return base.WithField("worker", BreachMonitorName)
+}
@@ -0,0 +1,114 @@
+package sla
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeFlagger counts sweeps and can be scripted to fail or panic.
+type fakeFlagger struct {
+	mu     sync.Mutex
+	calls  int
+	err    error
+	panics bool
+	called chan struct{}
+}
+
+func newFakeFlagger() *fakeFlagger {
+	return &fakeFlagger{called: make(chan struct{}, 16)}
+}
+
+func (f *fakeFlagger) FlagBreaches(now time.Time) (int, error) {
+	f.mu.Lock()
+	f.calls++
+	err := f.err
+	shouldPanic := f.panics
+	f.mu.Unlock()
+
+	select {
+	case f.called <- struct{}{}:
+	default:
+	}
+
+	if shouldPanic {
+		panic("service exploded")
+	}
+	return 1, err
+}
+
+func (f *fakeFlagger) waitForCalls(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-f.called:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("breach monitor swept %d times, want %d", i, n)
+		}
+	}
+}
+
+func TestStartBreachMonitor_SweepsAtStartAndOnEveryTick(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	flagger := newFakeFlagger()
+
+	StartBreachMonitor(ctx, flagger, 10*time.Millisecond)
+
+	flagger.waitForCalls(t, 3)
+}
+
+func TestStartBreachMonitor_SurvivesFailuresAndPanics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	flagger := newFakeFlagger()
+	flagger.panics = true
+
+	StartBreachMonitor(ctx, flagger, 10*time.Millisecond)
+	flagger.waitForCalls(t, 2)
+
+	flagger.mu.Lock()
+	flagger.panics = false
+	flagger.err = errors.New("db down")
+	flagger.mu.Unlock()
+
+	flagger.waitForCalls(t, 2)
+}
+
+func TestStartBreachMonitor_StopsWhenContextIsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	flagger := newFakeFlagger()
+
+	StartBreachMonitor(ctx, flagger, 10*time.Millisecond)
+	flagger.waitForCalls(t, 1)
+	cancel()
+
+	// Let an in-flight tick drain, then make sure the loop has gone quiet.
+	time.Sleep(30 * time.Millisecond)
+	flagger.mu.Lock()
+	stoppedAt := flagger.calls
+	flagger.mu.Unlock()
+	time.Sleep(50 * time.Millisecond)
+
+	flagger.mu.Lock()
+	defer flagger.mu.Unlock()
+	assert.Equal(t, stoppedAt, flagger.calls)
+}
+
+func TestStartBreachMonitor_RefusesUnusableSettings(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	flagger := newFakeFlagger()
+
+	StartBreachMonitor(ctx, nil, time.Second)
+	StartBreachMonitor(ctx, flagger, 0)
+
+	time.Sleep(20 * time.Millisecond)
+	flagger.mu.Lock()
+	defer flagger.mu.Unlock()
+	assert.Zero(t, flagger.calls)
+}
@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"gorm.io/gorm/schema"
+)
+
+// A list cursor is the position of the last row of a page — its sort column
+// value and its id — together with the sort it was taken under, as base64url
+// JSON so clients treat it as opaque. The sort travels in the cursor so that
+// a cursor replayed under another sort is rejected instead of silently
+// skipping rows.
+
+// cursorPayload is the encoded form of a cursor.
+type cursorPayload struct {
+	SortBy string      `json:"s,omitempty"`
+	Desc   bool        `json:"d,omitempty"`
+	Value  interface{} `json:"v"`
+	ID     uint        `json:"i"`
+}
+
+var cursorSchemas sync.Map
+
+// EncodeCursor returns the cursor of the page that follows row under the sort
+// of cursor. row is a pointer to the model the list is of.
+func EncodeCursor(cursor *models.ListCursor, row interface{}) (string, error) {
+	position, err := RowPosition(cursor.SortBy, row)
+	if err != nil {
+		return "", err
+	}
+	value := position.Value
+	if t, ok := value.(time.Time); ok {
+		// Full precision and offset, so the row compares equal to itself
+		// when the cursor comes back.
+		value = t.Format(time.RFC3339Nano)
+	}
+	encoded, err := json.Marshal(cursorPayload{SortBy: cursor.SortBy, Desc: cursor.Desc, Value: value, ID: position.ID})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+// RowPosition returns the position of row, a pointer to a model, in a list
+// sorted by sortBy (empty for id): the value of its sort column, nil for a
+// null or when that is the id, and its id.
+func RowPosition(sortBy string, row interface{}) (*models.CursorPosition, error) {
+	s, err := schema.Parse(row, &cursorSchemas, schema.NamingStrategy{})
+	if err != nil {
+		return nil, err
+	}
+	column := cursorColumn(sortBy)
+	field := s.LookUpField(column)
+	if field == nil || s.PrioritizedPrimaryField == nil {
+		return nil, fmt.Errorf("%s has no column %q", s.Table, column)
+	}
+	record := reflect.Indirect(reflect.ValueOf(row))
+	id, _ := s.PrioritizedPrimaryField.ValueOf(context.Background(), record)
+	position := &models.CursorPosition{}
+	switch id := id.(type) {
+	case uint:
+		position.ID = id
+	default:
+		return nil, fmt.Errorf("%s has no uint id", s.Table)
+	}
+	if column != "id" {
+		value, _ := field.ValueOf(context.Background(), record)
+		position.Value = derefValue(value)
+	}
+	return position, nil
+}
+
+// DecodeCursor reads a cursor of the list of table for a request sorted by
+// sortBy (empty for id) in the given direction. The value is converted back
+// to the Go type of its column, the way utils.ParseFilter converts the values
+// it compares with. A cursor that is malformed or was taken under another
+// sort is an ErrValidation.
+func DecodeCursor(table, raw, sortBy string, desc bool) (*models.ListCursor, error) {
+	invalid := fmt.Errorf("invalid cursor: %w", apperrors.ErrValidation)
+
+	encoded, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, invalid
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var payload cursorPayload
+	if err := decoder.Decode(&payload); err != nil || payload.ID == 0 {
+		return nil, invalid
+	}
+	if payload.SortBy != sortBy || payload.Desc != desc {
+		return nil, fmt.Errorf("the cursor belongs to another sort; repeat the sort_by and sort_order it was returned for: %w",
+			apperrors.ErrValidation)
+	}
+
+	column := cursorColumn(sortBy)
+	fieldType, ok := AllowedFilterFields[table][column]
+	if !ok {
+		return nil, invalid
+	}
+	value, err := cursorFieldValue(fieldType, payload.Value)
+	if err != nil || (column == "id" && value != nil) {
+		return nil, invalid
+	}
+	return &models.ListCursor{SortBy: sortBy, Desc: desc, After: &models.CursorPosition{Value: value, ID: payload.ID}}, nil
+}
+
+// cursorColumn is the column a list is sorted by, id when no sort is named.
+func cursorColumn(sortBy string) string {
+	if sortBy == "" {
+		return "id"
+	}
+	return sortBy
+}
+
+// derefValue returns what a pointer column value points to, nil for a null.
+func derefValue(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		return v.Elem().Interface()
+	}
+	return value
+}
+
+// cursorFieldValue converts a decoded JSON value back to the Go type of a
+// column of fieldType.
+func cursorFieldValue(fieldType FilterFieldType, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch fieldType {
+	case FilterInteger:
+		if n, ok := value.(json.Number); ok {
+			return n.Int64()
+		}
+	case FilterBool:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	case FilterTime:
+		if s, ok := value.(string); ok {
+			return time.Parse(time.RFC3339Nano, s)
+		}
+	default:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected cursor value %v", value)
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	created := time.Date(2026, 5, 1, 9, 30, 0, 123456789, time.FixedZone("CEST", 2*60*60))
+	lead := &models.Lead{BaseModel: models.BaseModel{ID: 42, CreatedAt: created}, Email: "ada@example.com"}
+
+	for _, tc := range []struct {
+		sortBy string
+		desc   bool
+		value  interface{}
+	}{
+		{"created_at", true, created},
+		{"email", false, "ada@example.com"},
+		{"", false, nil},
+	} {
+		raw, err := EncodeCursor(&models.ListCursor{SortBy: tc.sortBy, Desc: tc.desc}, lead)
+		require.NoError(t, err)
+
+		cursor, err := DecodeCursor("leads", raw, tc.sortBy, tc.desc)
+		require.NoError(t, err, tc.sortBy)
+		assert.Equal(t, uint(42), cursor.After.ID)
+		if at, ok := tc.value.(time.Time); ok {
+			assert.True(t, at.Equal(cursor.After.Value.(time.Time)), "to the nanosecond")
+			assert.Equal(t, at.Format(time.RFC3339Nano), cursor.After.Value.(time.Time).Format(time.RFC3339Nano),
+				"with its offset")
+		} else {
+			assert.Equal(t, tc.value, cursor.After.Value, tc.sortBy)
+		}
+	}
+}
+
+func TestCursor_NullValue(t *testing.T) {
+	task := &models.Task{BaseModel: models.BaseModel{ID: 7}}
+	raw, err := EncodeCursor(&models.ListCursor{SortBy: "due_date"}, task)
+	require.NoError(t, err)
+
+	cursor, err := DecodeCursor("tasks", raw, "due_date", false)
+	require.NoError(t, err)
+	assert.Nil(t, cursor.After.Value)
+	assert.Equal(t, uint(7), cursor.After.ID)
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	lead := &models.Lead{BaseModel: models.BaseModel{ID: 42}, Email: "ada@example.com"}
+	raw, err := EncodeCursor(&models.ListCursor{SortBy: "email"}, lead)
+	require.NoError(t, err)
+
+	for name, decode := range map[string]func() error{
+		"other column":    func() error { _, err := DecodeCursor("leads", raw, "company", false); return err },
+		"other direction": func() error { _, err := DecodeCursor("leads", raw, "email", true); return err },
+		"not base64":      func() error { _, err := DecodeCursor("leads", "%%%", "email", false); return err },
+		"not json":        func() error { _, err := DecodeCursor("leads", "bm90IGpzb24", "email", false); return err },
+		"wrong type": func() error {
+			_, err := DecodeCursor("leads", "eyJzIjoiaWQiLCJ2IjoieCIsImkiOjF9", "id", false)
+			return err
+		},
+	} {
+		err := decode()
+		assert.True(t, errors.Is(err, apperrors.ErrValidation), "%s: got %v", name, err)
+	}
+}
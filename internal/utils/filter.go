@@ -0,0 +1,588 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+)
+
+// FilterFieldType is the type of a filterable column. It decides which
+// operators the column accepts and how the values compared with it are read.
+type FilterFieldType int
+
+const (
+	FilterString FilterFieldType = iota
+	FilterInteger
+	FilterBool
+	FilterTime
+)
+
+// Limits on a filter query. Every list request can carry one, and each
+// condition becomes a clause of the WHERE, so the size of the SQL a caller can
+// make us build is bounded here.
+const (
+	MaxFilterLength     = 2000
+	MaxFilterConditions = 20
+	MaxFilterDepth      = 5
+	MaxFilterValues     = 50
+	MaxFilterValueLen   = 500
+)
+
+// AllowedFilterFields defines the filterable columns per entity, keyed like
+// AllowedSortColumns by table name. A column is only filterable when every
+// role that can list the entity may see it: nothing secret (password hashes,
+// key hashes, match keys) belongs here.
+var AllowedFilterFields = map[string]map[string]FilterFieldType{
+	"users": {
+		"id": FilterInteger, "email": FilterString, "first_name": FilterString, "last_name": FilterString,
+		"role": FilterString, "is_active": FilterBool, "last_login_at": FilterTime,
+		"created_at": FilterTime, "updated_at": FilterTime,
+	},
+	"leads": {
+		"id": FilterInteger, "first_name": FilterString, "last_name": FilterString, "email": FilterString,
+		"phone": FilterString, "company": FilterString, "position": FilterString, "source": FilterString,
+		"status": FilterString, "classification": FilterString, "owner_id": FilterInteger,
+		"account_id": FilterInteger, "customer_id": FilterInteger,
+		"created_at": FilterTime, "updated_at": FilterTime,
+	},
+	"customers": {
+		"id": FilterInteger, "first_name": FilterString, "last_name": FilterString, "email": FilterString,
+		"phone": FilterString, "company": FilterString, "position": FilterString, "city": FilterString,
+		"state": FilterString, "country": FilterString, "postal_code": FilterString,
+		"account_id": FilterInteger, "assigned_to_id": FilterInteger, "user_id": FilterInteger,
+		"created_at": FilterTime, "updated_at": FilterTime,
+	},
+	"tickets": {
+		"id": FilterInteger, "title": FilterString, "status": FilterString, "priority": FilterString,
+		"customer_id": FilterInteger, "assigned_to_id": FilterInteger,
+		"first_response_due_at": FilterTime, "resolution_due_at": FilterTime,
+		"first_responded_at": FilterTime, "resolved_at": FilterTime,
+		"first_response_breached": FilterBool, "resolution_breached": FilterBool,
+		"created_at": FilterTime, "updated_at": FilterTime,
+	},
+	"tasks": {
+		"id": FilterInteger, "title": FilterString, "status": FilterString, "priority": FilterString,
+		"due_date": FilterTime, "assigned_to_id": FilterInteger, "lead_id": FilterInteger,
+		"customer_id": FilterInteger, "created_at": FilterTime, "updated_at": FilterTime,
+	},
+	"form_submissions": {
+		"id": FilterInteger, "email": FilterString, "status": FilterString, "spam_reason": FilterString,
+		"lead_id": FilterInteger, "ip_address": FilterString, "referrer": FilterString,
+		"confirmed_at": FilterTime, "created_at": FilterTime,
+	},
+	"aeo_prompts": {
+		"id": FilterInteger, "text": FilterString, "is_active": FilterBool, "created_by_id": FilterInteger,
+		"created_at": FilterTime, "updated_at": FilterTime,
+	},
+	// Lists that take no filter parameter are here only for the columns they
+	// are ordered by, which is what their cursors are decoded with.
+	"deals": {
+		"id": FilterInteger, "created_at": FilterTime,
+	},
+	"accounts": {
+		"id": FilterInteger, "name": FilterString, "created_at": FilterTime,
+	},
+	"audit_events": {
+		"id": FilterInteger, "created_at": FilterTime,
+	},
+}
+
+// filterOperators lists the operators each field type accepts.
+var filterOperators = map[FilterFieldType]map[models.FilterOperator]bool{
+	FilterString: {
+		models.FilterEq: true, models.FilterNe: true, models.FilterIn: true, models.FilterNotIn: true,
+		models.FilterContains: true, models.FilterStartsWith: true,
+	},
+	FilterInteger: {
+		models.FilterEq: true, models.FilterNe: true, models.FilterGt: true, models.FilterGte: true,
+		models.FilterLt: true, models.FilterLte: true, models.FilterIn: true, models.FilterNotIn: true,
+	},
+	FilterBool: {
+		models.FilterEq: true, models.FilterNe: true,
+	},
+	FilterTime: {
+		models.FilterEq: true, models.FilterNe: true, models.FilterGt: true, models.FilterGte: true,
+		models.FilterLt: true, models.FilterLte: true,
+	},
+}
+
+// filterNow is the clock relative dates are read against; tests replace it.
+var filterNow = time.Now
+
+var relativeFilterTime = regexp.MustCompile(`^([+-])(\d{1,4})([mhdw])$`)
+
+// ParseFilter parses the filter query of a list request for entity, one of
+// the keys of AllowedFilterFields. The grammar is a sequence of conditions
+// joined by "and" and "or" ("and" binds tighter), grouped with parentheses:
+//
+//	status eq qualified and (source eq webinar or source in (event, referral))
+//	created_at gte -30d and owner_id eq 4
+//	email contains "@example.com" and assigned_to_id ne null
+//
+// A condition is a field, an operator and a value. The operators are eq, ne,
+// gt, gte, lt, lte, in and nin (which take a parenthesised list), contains
+// and startswith (text only, case-insensitive). A value is a bare word or a
+// double-quoted string, in which \" and \\ are escapes; the bare word null
+// compares with eq and ne against a missing value. Times are RFC 3339
+// timestamps, YYYY-MM-DD dates (a whole UTC day: "eq" matches any time on
+// it), "now", "today", or an offset from now such as -30d, -12h or +1w.
+//
+// An empty query is no filter and returns nil. Every error wraps
+// apperrors.ErrValidation.
+func ParseFilter(entity, raw string) (*models.FilterExpr, error) {
+	fields, ok := AllowedFilterFields[entity]
+	if !ok {
+		return nil, fmt.Errorf("unknown entity for filtering: %s", entity)
+	}
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	if len(raw) > MaxFilterLength {
+		return nil, fmt.Errorf("invalid filter: longer than %d characters: %w", MaxFilterLength, apperrors.ErrValidation)
+	}
+
+	tokens, err := tokenizeFilter(raw)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{entity: entity, fields: fields, tokens: tokens, now: filterNow().UTC()}
+	expr, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != filterTokenEOF {
+		return nil, p.errorf(tok, "unexpected %s", tok)
+	}
+	return &expr, nil
+}
+
+// SafeWhereClause builds the WHERE fragment of a parsed filter, with every
+// value bound as a parameter and every column qualified by the entity's
+// table. The fields are checked against AllowedFilterFields again, so a
+// FilterExpr that did not come out of ParseFilter still never puts anything
+// but an allowlisted column name into the SQL.
+//
+// ne and nin also match records where the column is NULL, which is what
+// "is not" means to a caller who never sees the NULL.
+func SafeWhereClause(entity string, expr *models.FilterExpr) (string, []interface{}, error) {
+	fields, ok := AllowedFilterFields[entity]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown entity for filtering: %s", entity)
+	}
+	return buildWhereClause(entity, fields, expr)
+}
+
+func buildWhereClause(table string, fields map[string]FilterFieldType, expr *models.FilterExpr) (string, []interface{}, error) {
+	if expr.Condition == nil {
+		if len(expr.Group) == 0 {
+			return "", nil, fmt.Errorf("empty filter group")
+		}
+		joiner := " AND "
+		if expr.Or {
+			joiner = " OR "
+		}
+		parts := make([]string, len(expr.Group))
+		var vars []interface{}
+		for i := range expr.Group {
+			part, partVars, err := buildWhereClause(table, fields, &expr.Group[i])
+			if err != nil {
+				return "", nil, err
+			}
+			parts[i] = part
+			vars = append(vars, partVars...)
+		}
+		return "(" + strings.Join(parts, joiner) + ")", vars, nil
+	}
+
+	condition := expr.Condition
+	fieldType, ok := fields[condition.Field]
+	if !ok {
+		return "", nil, fmt.Errorf("invalid filter field %q for %s", condition.Field, table)
+	}
+	column := table + "." + condition.Field
+	values := condition.Values
+
+	switch condition.Operator {
+	case models.FilterEq, models.FilterNe:
+		if len(values) == 0 {
+			if condition.Operator == models.FilterEq {
+				return column + " IS NULL", nil, nil
+			}
+			return column + " IS NOT NULL", nil, nil
+		}
+		if len(values) != 1 {
+			break
+		}
+		if condition.Operator == models.FilterEq {
+			return column + " = ?", values, nil
+		}
+		return "(" + column + " <> ? OR " + column + " IS NULL)", values, nil
+	case models.FilterGt, models.FilterGte, models.FilterLt, models.FilterLte:
+		if len(values) != 1 {
+			break
+		}
+		comparison := map[models.FilterOperator]string{
+			models.FilterGt: " > ?", models.FilterGte: " >= ?", models.FilterLt: " < ?", models.FilterLte: " <= ?",
+		}[condition.Operator]
+		return column + comparison, values, nil
+	case models.FilterIn:
+		if len(values) == 0 {
+			break
+		}
+		return column + " IN ?", []interface{}{values}, nil
+	case models.FilterNotIn:
+		if len(values) == 0 {
+			break
+		}
+		return "(" + column + " NOT IN ? OR " + column + " IS NULL)", []interface{}{values}, nil
+	case models.FilterContains, models.FilterStartsWith:
+		text, isText := firstString(values)
+		if fieldType != FilterString || !isText {
+			break
+		}
+		pattern := escapeLike(strings.ToLower(text)) + "%"
+		if condition.Operator == models.FilterContains {
+			pattern = "%" + pattern
+		}
+		// "!" rather than a backslash: MySQL reads a backslash inside a
+		// string literal as an escape of its own, SQLite does not.
+		return "LOWER(" + column + ") LIKE ? ESCAPE '!'", []interface{}{pattern}, nil
+	}
+	return "", nil, fmt.Errorf("invalid filter condition %s %s with %d values", condition.Field, condition.Operator, len(values))
+}
+
+func firstString(values []interface{}) (string, bool) {
+	if len(values) != 1 {
+		return "", false
+	}
+	text, ok := values[0].(string)
+	return text, ok
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(value)
+}
+
+type filterTokenKind int
+
+const (
+	filterTokenEOF filterTokenKind = iota
+	filterTokenWord
+	filterTokenString
+	filterTokenOpen
+	filterTokenClose
+	filterTokenComma
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+	pos  int
+}
+
+func (t filterToken) String() string {
+	switch t.kind {
+	case filterTokenEOF:
+		return "end of filter"
+	case filterTokenString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// is reports whether the token is the bare word keyword, in any case.
+func (t filterToken) is(keyword string) bool {
+	return t.kind == filterTokenWord && strings.EqualFold(t.text, keyword)
+}
+
+func tokenizeFilter(raw string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(raw)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, filterToken{kind: filterTokenOpen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, filterToken{kind: filterTokenClose, text: ")", pos: i})
+			i++
+		case r == ',':
+			tokens = append(tokens, filterToken{kind: filterTokenComma, text: ",", pos: i})
+			i++
+		case r == '"':
+			start := i
+			var text strings.Builder
+			for i++; ; i++ {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("invalid filter at position %d: unterminated string: %w", start+1, apperrors.ErrValidation)
+				}
+				if runes[i] == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\') {
+					i++
+				} else if runes[i] == '"' {
+					i++
+					break
+				}
+				text.WriteRune(runes[i])
+			}
+			tokens = append(tokens, filterToken{kind: filterTokenString, text: text.String(), pos: start})
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune(`(),"`, runes[i]) {
+				i++
+			}
+			tokens = append(tokens, filterToken{kind: filterTokenWord, text: string(runes[start:i]), pos: start})
+		}
+	}
+	return append(tokens, filterToken{kind: filterTokenEOF, pos: len(runes)}), nil
+}
+
+type filterParser struct {
+	entity     string
+	fields     map[string]FilterFieldType
+	tokens     []filterToken
+	pos        int
+	conditions int
+	now        time.Time
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() filterToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != filterTokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *filterParser) errorf(tok filterToken, format string, args ...interface{}) error {
+	return fmt.Errorf("invalid filter at position %d: %s: %w", tok.pos+1, fmt.Sprintf(format, args...), apperrors.ErrValidation)
+}
+
+func (p *filterParser) parseOr(depth int) (models.FilterExpr, error) {
+	return p.parseJoined(depth, "or", true, p.parseAnd)
+}
+
+func (p *filterParser) parseAnd(depth int) (models.FilterExpr, error) {
+	return p.parseJoined(depth, "and", false, p.parsePrimary)
+}
+
+// parseJoined parses operands joined by keyword into one group, flattening
+// operands that are groups of the same kind: a and (b and c) is a and b and c.
+func (p *filterParser) parseJoined(depth int, keyword string, or bool, operand func(int) (models.FilterExpr, error)) (models.FilterExpr, error) {
+	var group []models.FilterExpr
+	for {
+		expr, err := operand(depth)
+		if err != nil {
+			return models.FilterExpr{}, err
+		}
+		if expr.Condition == nil && expr.Or == or {
+			group = append(group, expr.Group...)
+		} else {
+			group = append(group, expr)
+		}
+		if !p.peek().is(keyword) {
+			break
+		}
+		p.next()
+	}
+	if len(group) == 1 {
+		return group[0], nil
+	}
+	return models.FilterExpr{Or: or, Group: group}, nil
+}
+
+func (p *filterParser) parsePrimary(depth int) (models.FilterExpr, error) {
+	if tok := p.peek(); tok.kind == filterTokenOpen {
+		if depth+1 > MaxFilterDepth {
+			return models.FilterExpr{}, p.errorf(tok, "more than %d nested groups", MaxFilterDepth)
+		}
+		p.next()
+		expr, err := p.parseOr(depth + 1)
+		if err != nil {
+			return models.FilterExpr{}, err
+		}
+		if tok := p.next(); tok.kind != filterTokenClose {
+			return models.FilterExpr{}, p.errorf(tok, "expected \")\", found %s", tok)
+		}
+		return expr, nil
+	}
+	return p.parseCondition()
+}
+
+func (p *filterParser) parseCondition() (models.FilterExpr, error) {
+	fieldTok := p.next()
+	if fieldTok.kind != filterTokenWord {
+		return models.FilterExpr{}, p.errorf(fieldTok, "expected a field, found %s", fieldTok)
+	}
+	fieldType, ok := p.fields[fieldTok.text]
+	if !ok {
+		return models.FilterExpr{}, p.errorf(fieldTok, "unknown field %q for %s", fieldTok.text, p.entity)
+	}
+	p.conditions++
+	if p.conditions > MaxFilterConditions {
+		return models.FilterExpr{}, p.errorf(fieldTok, "more than %d conditions", MaxFilterConditions)
+	}
+
+	opTok := p.next()
+	if opTok.kind != filterTokenWord {
+		return models.FilterExpr{}, p.errorf(opTok, "expected an operator after %s, found %s", fieldTok.text, opTok)
+	}
+	op := models.FilterOperator(strings.ToLower(opTok.text))
+	if !filterOperators[fieldType][op] {
+		return models.FilterExpr{}, p.errorf(opTok, "operator %q cannot be used with %s", opTok.text, fieldTok.text)
+	}
+
+	if op == models.FilterIn || op == models.FilterNotIn {
+		values, err := p.parseList(fieldType)
+		if err != nil {
+			return models.FilterExpr{}, err
+		}
+		return models.FilterExpr{Condition: &models.FilterCondition{Field: fieldTok.text, Operator: op, Values: values}}, nil
+	}
+
+	valueTok := p.next()
+	if valueTok.kind != filterTokenWord && valueTok.kind != filterTokenString {
+		return models.FilterExpr{}, p.errorf(valueTok, "expected a value after %s %s, found %s", fieldTok.text, op, valueTok)
+	}
+	if valueTok.is("null") {
+		if op != models.FilterEq && op != models.FilterNe {
+			return models.FilterExpr{}, p.errorf(valueTok, "null can only be compared with eq or ne")
+		}
+		return models.FilterExpr{Condition: &models.FilterCondition{Field: fieldTok.text, Operator: op}}, nil
+	}
+
+	if fieldType == FilterTime {
+		instant, wholeDay, err := p.parseTime(valueTok)
+		if err != nil {
+			return models.FilterExpr{}, err
+		}
+		if wholeDay {
+			return dayFilter(fieldTok.text, op, instant), nil
+		}
+		return models.FilterExpr{Condition: &models.FilterCondition{Field: fieldTok.text, Operator: op, Values: []interface{}{instant}}}, nil
+	}
+
+	value, err := p.parseValue(fieldType, valueTok)
+	if err != nil {
+		return models.FilterExpr{}, err
+	}
+	return models.FilterExpr{Condition: &models.FilterCondition{Field: fieldTok.text, Operator: op, Values: []interface{}{value}}}, nil
+}
+
+func (p *filterParser) parseList(fieldType FilterFieldType) ([]interface{}, error) {
+	if tok := p.next(); tok.kind != filterTokenOpen {
+		return nil, p.errorf(tok, "expected \"(\" to open a list, found %s", tok)
+	}
+	var values []interface{}
+	for {
+		tok := p.next()
+		if tok.kind != filterTokenWord && tok.kind != filterTokenString {
+			return nil, p.errorf(tok, "expected a value, found %s", tok)
+		}
+		value, err := p.parseValue(fieldType, tok)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		if len(values) > MaxFilterValues {
+			return nil, p.errorf(tok, "a list cannot hold more than %d values", MaxFilterValues)
+		}
+
+		tok = p.next()
+		if tok.kind == filterTokenClose {
+			return values, nil
+		}
+		if tok.kind != filterTokenComma {
+			return nil, p.errorf(tok, "expected \",\" or \")\", found %s", tok)
+		}
+	}
+}
+
+func (p *filterParser) parseValue(fieldType FilterFieldType, tok filterToken) (interface{}, error) {
+	if len(tok.text) > MaxFilterValueLen {
+		return nil, p.errorf(tok, "a value cannot be longer than %d characters", MaxFilterValueLen)
+	}
+	switch fieldType {
+	case FilterInteger:
+		n, err := strconv.ParseInt(tok.text, 10, 64)
+		if err != nil {
+			return nil, p.errorf(tok, "expected a whole number, found %s", tok)
+		}
+		return n, nil
+	case FilterBool:
+		b, err := strconv.ParseBool(tok.text)
+		if err != nil {
+			return nil, p.errorf(tok, "expected true or false, found %s", tok)
+		}
+		return b, nil
+	default:
+		return tok.text, nil
+	}
+}
+
+// parseTime reads a time value. wholeDay is set for a date, whose instant is
+// the start of the UTC day.
+func (p *filterParser) parseTime(tok filterToken) (instant time.Time, wholeDay bool, err error) {
+	text := strings.ToLower(tok.text)
+	switch {
+	case text == "now":
+		return p.now, false, nil
+	case text == "today":
+		return p.now.Truncate(24 * time.Hour), true, nil
+	}
+	if match := relativeFilterTime.FindStringSubmatch(text); match != nil {
+		n, _ := strconv.Atoi(match[2])
+		unit := map[string]time.Duration{"m": time.Minute, "h": time.Hour, "d": 24 * time.Hour, "w": 7 * 24 * time.Hour}[match[3]]
+		offset := time.Duration(n) * unit
+		if match[1] == "-" {
+			offset = -offset
+		}
+		return p.now.Add(offset), false, nil
+	}
+	if day, err := time.Parse("2006-01-02", tok.text); err == nil {
+		return day, true, nil
+	}
+	if instant, err := time.Parse(time.RFC3339, tok.text); err == nil {
+		return instant.UTC(), false, nil
+	}
+	return time.Time{}, false, p.errorf(tok, "expected a time (RFC 3339, YYYY-MM-DD, now, today or an offset like -30d), found %s", tok)
+}
+
+// dayFilter compares field with the whole UTC day starting at start: eq
+// matches any time on it, gt anything after it, and so on.
+func dayFilter(field string, op models.FilterOperator, start time.Time) models.FilterExpr {
+	end := start.Add(24 * time.Hour)
+	condition := func(op models.FilterOperator, at time.Time) models.FilterExpr {
+		return models.FilterExpr{Condition: &models.FilterCondition{Field: field, Operator: op, Values: []interface{}{at}}}
+	}
+	switch op {
+	case models.FilterEq:
+		return models.FilterExpr{Group: []models.FilterExpr{condition(models.FilterGte, start), condition(models.FilterLt, end)}}
+	case models.FilterNe:
+		return models.FilterExpr{Or: true, Group: []models.FilterExpr{
+			condition(models.FilterLt, start), condition(models.FilterGte, end),
+			{Condition: &models.FilterCondition{Field: field, Operator: models.FilterEq}},
+		}}
+	case models.FilterGt:
+		return condition(models.FilterGte, end)
+	case models.FilterLte:
+		return condition(models.FilterLt, end)
+	case models.FilterLt:
+		return condition(models.FilterLt, start)
+	default:
+		return condition(models.FilterGte, start)
+	}
+}
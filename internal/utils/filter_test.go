@@ -0,0 +1,219 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cond(field string, op models.FilterOperator, values ...interface{}) models.FilterExpr {
+	return models.FilterExpr{Condition: &models.FilterCondition{Field: field, Operator: op, Values: values}}
+}
+
+func withFilterClock(t *testing.T, now time.Time) {
+	t.Helper()
+	previous := filterNow
+	filterNow = func() time.Time { return now }
+	t.Cleanup(func() { filterNow = previous })
+}
+
+func TestParseFilter_Empty(t *testing.T) {
+	expr, err := ParseFilter("leads", "   ")
+	require.NoError(t, err)
+	assert.Nil(t, expr)
+}
+
+func TestParseFilter_Valid(t *testing.T) {
+	tests := []struct {
+		name   string
+		entity string
+		raw    string
+		want   models.FilterExpr
+	}{
+		{"single condition", "leads", "status eq qualified", cond("status", models.FilterEq, "qualified")},
+		{"operator case", "leads", "status EQ qualified", cond("status", models.FilterEq, "qualified")},
+		{"quoted string", "customers", `company eq "Acme \"Labs\""`, cond("company", models.FilterEq, `Acme "Labs"`)},
+		{"integer", "leads", "owner_id gt 4", cond("owner_id", models.FilterGt, int64(4))},
+		{"bool", "users", "is_active eq false", cond("is_active", models.FilterEq, false)},
+		{"null", "tickets", "assigned_to_id ne null", cond("assigned_to_id", models.FilterNe)},
+		{"in list", "tasks", "priority in (high, \"urgent\")", cond("priority", models.FilterIn, "high", "urgent")},
+		{"nin list", "leads", "owner_id nin (1,2)", cond("owner_id", models.FilterNotIn, int64(1), int64(2))},
+		{"and binds tighter than or", "leads", "status eq new or status eq contacted and source eq web",
+			models.FilterExpr{Or: true, Group: []models.FilterExpr{
+				cond("status", models.FilterEq, "new"),
+				{Group: []models.FilterExpr{cond("status", models.FilterEq, "contacted"), cond("source", models.FilterEq, "web")}},
+			}}},
+		{"parentheses", "leads", "(status eq new or status eq contacted) and source eq web",
+			models.FilterExpr{Group: []models.FilterExpr{
+				{Or: true, Group: []models.FilterExpr{cond("status", models.FilterEq, "new"), cond("status", models.FilterEq, "contacted")}},
+				cond("source", models.FilterEq, "web"),
+			}}},
+		{"nested groups of the same kind flatten", "leads", "status eq new and (source eq web and (owner_id eq 1))",
+			models.FilterExpr{Group: []models.FilterExpr{
+				cond("status", models.FilterEq, "new"), cond("source", models.FilterEq, "web"), cond("owner_id", models.FilterEq, int64(1)),
+			}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := ParseFilter(tt.entity, tt.raw)
+			require.NoError(t, err)
+			require.NotNil(t, expr)
+			assert.Equal(t, tt.want, *expr)
+		})
+	}
+}
+
+func TestParseFilter_Times(t *testing.T) {
+	now := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+	withFilterClock(t, now)
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	nextDay := day.Add(24 * time.Hour)
+	today := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		raw  string
+		want models.FilterExpr
+	}{
+		{"now", "created_at lt now", cond("created_at", models.FilterLt, now)},
+		{"relative days", "created_at gte -30d", cond("created_at", models.FilterGte, now.Add(-30*24*time.Hour))},
+		{"relative weeks ahead", "created_at lte +1w", cond("created_at", models.FilterLte, now.Add(7*24*time.Hour))},
+		{"rfc3339", "created_at gt 2024-03-01T12:00:00+02:00",
+			cond("created_at", models.FilterGt, time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC))},
+		{"date eq is the whole day", "created_at eq 2024-03-01", models.FilterExpr{Group: []models.FilterExpr{
+			cond("created_at", models.FilterGte, day), cond("created_at", models.FilterLt, nextDay),
+		}}},
+		{"date ne also matches null", "created_at ne 2024-03-01", models.FilterExpr{Or: true, Group: []models.FilterExpr{
+			cond("created_at", models.FilterLt, day), cond("created_at", models.FilterGte, nextDay), cond("created_at", models.FilterEq),
+		}}},
+		{"date gt is after the day", "created_at gt 2024-03-01", cond("created_at", models.FilterGte, nextDay)},
+		{"date lte includes the day", "created_at lte 2024-03-01", cond("created_at", models.FilterLt, nextDay)},
+		{"today", "created_at gte today", cond("created_at", models.FilterGte, today)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := ParseFilter("leads", tt.raw)
+			require.NoError(t, err)
+			require.NotNil(t, expr)
+			assert.Equal(t, tt.want, *expr)
+		})
+	}
+}
+
+func TestParseFilter_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		entity  string
+		raw     string
+		wantErr string
+	}{
+		{"unknown field", "leads", "password eq x", `unknown field "password" for leads`},
+		{"secret column", "users", "password eq x", `unknown field "password" for users`},
+		{"operator not allowed for type", "leads", "owner_id contains 4", `operator "contains" cannot be used with owner_id`},
+		{"unknown operator", "leads", "status like new", `operator "like" cannot be used with status`},
+		{"not a number", "leads", "owner_id eq four", "expected a whole number"},
+		{"not a bool", "users", "is_active eq maybe", "expected true or false"},
+		{"not a time", "leads", "created_at gt yesterday", "expected a time"},
+		{"null with gt", "leads", "owner_id gt null", "null can only be compared with eq or ne"},
+		{"missing value", "leads", "status eq", "expected a value after status eq"},
+		{"list without parentheses", "leads", "status in new", `expected "(" to open a list`},
+		{"unterminated string", "leads", `company eq "Acme`, "position 12: unterminated string"},
+		{"unbalanced parentheses", "leads", "(status eq new", `expected ")"`},
+		{"trailing tokens", "leads", "status eq new source eq web", `unexpected "source"`},
+		{"too long", "leads", "status eq " + strings.Repeat("a", MaxFilterLength), "longer than"},
+		{"too many conditions", "leads", strings.Repeat("owner_id eq 1 and ", MaxFilterConditions) + "owner_id eq 1",
+			"more than 20 conditions"},
+		{"too deep", "leads", strings.Repeat("(", MaxFilterDepth+1) + "owner_id eq 1" + strings.Repeat(")", MaxFilterDepth+1),
+			"nested groups"},
+		{"too many values", "leads", "owner_id in (" + strings.Repeat("1,", MaxFilterValues) + "1)", "cannot hold more than"},
+		{"value too long", "leads", "company eq " + strings.Repeat("a", MaxFilterValueLen+1), "cannot be longer than"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseFilter(tt.entity, tt.raw)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			assert.True(t, errors.Is(err, apperrors.ErrValidation))
+		})
+	}
+}
+
+func TestParseFilter_UnknownEntity(t *testing.T) {
+	_, err := ParseFilter("secrets", "id eq 1")
+	assert.Error(t, err)
+}
+
+func TestSafeWhereClause(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		raw      string
+		wantSQL  string
+		wantVars []interface{}
+	}{
+		{"eq", "status eq new", "leads.status = ?", []interface{}{"new"}},
+		{"ne matches null", "status ne new", "(leads.status <> ? OR leads.status IS NULL)", []interface{}{"new"}},
+		{"null", "owner_id eq null", "leads.owner_id IS NULL", nil},
+		{"in binds one slice", "owner_id in (1, 2)", "leads.owner_id IN ?", []interface{}{[]interface{}{int64(1), int64(2)}}},
+		{"nin matches null", "source nin (web)", "(leads.source NOT IN ? OR leads.source IS NULL)",
+			[]interface{}{[]interface{}{"web"}}},
+		{"contains escapes wildcards", `email contains "50%_off!"`, "LOWER(leads.email) LIKE ? ESCAPE '!'",
+			[]interface{}{"%50!%!_off!!%"}},
+		{"startswith lowercases", "company startswith Acme", "LOWER(leads.company) LIKE ? ESCAPE '!'",
+			[]interface{}{"acme%"}},
+		{"groups", "status eq new and (owner_id eq 1 or created_at eq 2024-03-01)",
+			"(leads.status = ? AND (leads.owner_id = ? OR (leads.created_at >= ? AND leads.created_at < ?)))",
+			[]interface{}{"new", int64(1), day, day.Add(24 * time.Hour)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := ParseFilter("leads", tt.raw)
+			require.NoError(t, err)
+			sql, vars, err := SafeWhereClause("leads", expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSQL, sql)
+			assert.Equal(t, tt.wantVars, vars)
+		})
+	}
+}
+
+func TestSafeWhereClause_RejectsUnlistedField(t *testing.T) {
+	expr := cond("1=1; DROP TABLE leads; --", models.FilterEq, "x")
+
+	_, _, err := SafeWhereClause("leads", &expr)
+	assert.Error(t, err)
+
+	group := models.FilterExpr{Group: []models.FilterExpr{cond("status", models.FilterEq, "new"), cond("password", models.FilterEq, "x")}}
+	_, _, err = SafeWhereClause("users", &group)
+	assert.Error(t, err)
+}
+
+func TestSafeWhereClause_RejectsMalformedCondition(t *testing.T) {
+	tests := []struct {
+		name string
+		expr models.FilterExpr
+	}{
+		{"empty group", models.FilterExpr{}},
+		{"gt without value", cond("owner_id", models.FilterGt)},
+		{"empty in", cond("owner_id", models.FilterIn)},
+		{"contains on integer", cond("owner_id", models.FilterContains, "1")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := SafeWhereClause("leads", &tt.expr)
+			assert.Error(t, err)
+		})
+	}
+}
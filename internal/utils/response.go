@@ -29,6 +29,19 @@ type APIMeta struct {
 	PerPage    int    `json:"per_page,omitempty"`
 	Total      int64  `json:"total,omitempty"`
 	TotalPages int64  `json:"total_pages,omitempty"`
+	// NextCursor is set by cursor-paginated endpoints when there is a next
+	// page; it is passed back verbatim as ?cursor=.
+	NextCursor string `json:"next_cursor,omitempty"`
+	// Warnings flag something about a request that succeeded which the
+	// client may want to act on, such as a possible duplicate.
+	Warnings []APIWarning `json:"warnings,omitempty"`
+}
+
+// APIWarning is one warning attached to a successful response.
+type APIWarning struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
 }
 
 // Common error codes
@@ -29,6 +29,24 @@ var AllowedSortColumns = map[string]map[string]bool{
 		"id": true, "title": true, "status": true, "priority": true,
 		"due_date": true, "assigned_to_id": true, "created_at": true, "updated_at": true,
 	},
+	// Submissions are listed newest first with no sort parameter; the entry
+	// lets an export and a cursor seek through them in that order. The same
+	// goes for the fixed orders of deals, accounts and the audit trail.
+	"form_submissions": {
+		"id": true, "created_at": true,
+	},
+	"deals": {
+		"id": true, "created_at": true,
+	},
+	"accounts": {
+		"id": true, "name": true, "created_at": true,
+	},
+	"audit_events": {
+		"id": true, "created_at": true,
+	},
+	"aeo_prompts": {
+		"id": true, "text": true, "is_active": true, "created_at": true, "updated_at": true,
+	},
 }
 
 // ValidateSort checks that sortBy is in the allowlist for the given entity and
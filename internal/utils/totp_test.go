@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfc6238Secret is the SHA1 seed of the RFC 6238 test vectors, base32-encoded.
+var rfc6238Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	// The RFC lists eight-digit codes; a six-digit code is their last six.
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, v := range vectors {
+		code, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(v.unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, v.code, code, "time %d", v.unix)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := TOTPStep(now)
+
+	current, _ := TOTPCode(rfc6238Secret, step)
+	matched, ok := ValidateTOTP(rfc6238Secret, current, now)
+	assert.True(t, ok)
+	assert.Equal(t, step, matched)
+
+	previous, _ := TOTPCode(rfc6238Secret, step-1)
+	matched, ok = ValidateTOTP(rfc6238Secret, previous, now)
+	assert.True(t, ok, "a code from the previous step is still accepted")
+	assert.Equal(t, step-1, matched)
+
+	stale, _ := TOTPCode(rfc6238Secret, step-2)
+	_, ok = ValidateTOTP(rfc6238Secret, stale, now)
+	assert.False(t, ok, "a code two steps old is rejected")
+
+	_, ok = ValidateTOTP(rfc6238Secret, "12345", now)
+	assert.False(t, ok)
+	_, ok = ValidateTOTP("not base32!", current, now)
+	assert.False(t, ok)
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	first, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+	second, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+
+	assert.Len(t, first, 32, "160 bits in unpadded base32")
+	assert.NotEqual(t, first, second)
+	_, err = TOTPCode(first, 1)
+	assert.NoError(t, err)
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("GopherCRM", "alice@example.com", "JBSWY3DPEHPK3PXP")
+
+	parsed, err := url.Parse(uri)
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", parsed.Scheme)
+	assert.Equal(t, "totp", parsed.Host)
+	assert.Equal(t, "/GopherCRM:alice@example.com", parsed.Path)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", parsed.Query().Get("secret"))
+	assert.Equal(t, "GopherCRM", parsed.Query().Get("issuer"))
+	assert.Equal(t, "6", parsed.Query().Get("digits"))
+	assert.Equal(t, "30", parsed.Query().Get("period"))
+}
@@ -0,0 +1,132 @@
+package webhook
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// userAgent identifies deliveries in the receiver's access log.
+const userAgent = "GopherCRM-Webhooks/1.0"
+
+// maxResponseSnippet caps how much of a failed response is kept as the
+// delivery's last error; enough for an error message, not a whole page.
+const maxResponseSnippet = 200
+
+// Backoff bounds: the first retry comes after retryBase, each one after that
+// waits twice as long, up to retryCap.
+const (
+	retryBase = 30 * time.Second
+	retryCap  = 6 * time.Hour
+)
+
+// Envelope is the JSON body of every delivery.
+type Envelope struct {
+	// ID identifies the event and is the same on every attempt and every
+	// redelivery, so a receiver can drop duplicates.
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Request is one delivery attempt.
+type Request struct {
+	URL        string
+	Secret     string
+	Event      string
+	DeliveryID uint
+	Body       []byte
+}
+
+// Sender posts deliveries. It is safe for concurrent use.
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender returns a Sender whose requests give up after timeout. Redirects
+// are not followed: an endpoint that moved is a configuration error to be
+// fixed on the subscription, not something to chase with a signed payload.
+func NewSender(timeout time.Duration) *Sender {
+	return &Sender{client: &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+// Send makes one attempt and returns the response status, 0 when there was no
+// response. Only a 2xx status counts as delivered; anything else is an error.
+func (s *Sender) Send(req Request) (int, error) {
+	httpReq, err := http.NewRequest(http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return 0, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", userAgent)
+	httpReq.Header.Set(EventHeader, req.Event)
+	httpReq.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(req.DeliveryID), 10))
+	httpReq.Header.Set(SignatureHeader, Sign(req.Secret, time.Now(), req.Body))
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseSnippet))
+	// Drain a little more so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		text := strings.TrimSpace(strings.ToValidUTF8(string(snippet), string(utf8.RuneError)))
+		if text == "" {
+			return resp.StatusCode, fmt.Errorf("endpoint answered %d", resp.StatusCode)
+		}
+		return resp.StatusCode, fmt.Errorf("endpoint answered %d: %s", resp.StatusCode, text)
+	}
+	return resp.StatusCode, nil
+}
+
+// Backoff returns how long to wait after the given number of failed attempts.
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		return 0
+	}
+	wait := retryBase
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= retryCap {
+			return retryCap
+		}
+	}
+	return wait
+}
+
+// ValidateURL accepts an absolute http or https URL with a host. Private
+// addresses are allowed on purpose: the billing system and the warehouse the
+// webhooks feed usually live on the internal network.
+func ValidateURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return errors.New("url is not valid")
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return errors.New("url must use http or https")
+	}
+	if parsed.Host == "" {
+		return errors.New("url must name a host")
+	}
+	if parsed.User != nil {
+		return errors.New("url must not carry credentials; the signature authenticates the delivery")
+	}
+	return nil
+}
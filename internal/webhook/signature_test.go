@@ -0,0 +1,51 @@
+package webhook
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign_VerifiesWithTheSameSecret(t *testing.T) {
+	body := []byte(`{"id":"evt","type":"lead.created"}`)
+	now := time.Unix(1767225600, 0)
+
+	header := Sign("whsec_one", now, body)
+
+	assert.True(t, strings.HasPrefix(header, "t=1767225600,v1="))
+	assert.NoError(t, Verify("whsec_one", header, body, 5*time.Minute, now.Add(time.Minute)))
+}
+
+func TestVerify_RejectsTamperingAndReplays(t *testing.T) {
+	body := []byte(`{"id":"evt"}`)
+	now := time.Unix(1767225600, 0)
+	header := Sign("whsec_one", now, body)
+
+	cases := map[string]error{
+		"other secret": Verify("whsec_two", header, body, time.Minute, now),
+		"other body":   Verify("whsec_one", header, []byte(`{"id":"evt2"}`), time.Minute, now),
+		"stale":        Verify("whsec_one", header, body, time.Minute, now.Add(10*time.Minute)),
+		"no mac":       Verify("whsec_one", "t=1767225600", body, time.Minute, now),
+		"garbage":      Verify("whsec_one", "nonsense", body, time.Minute, now),
+		// A timestamp swapped for a fresh one breaks the MAC.
+		"new timestamp": Verify("whsec_one",
+			strings.Replace(header, "t=1767225600", "t=1767226200", 1), body, time.Minute, now.Add(10*time.Minute)),
+	}
+	for name, err := range cases {
+		assert.ErrorIs(t, err, ErrInvalidSignature, name)
+	}
+}
+
+func TestNewSecret_IsPrefixedAndUnique(t *testing.T) {
+	first, err := NewSecret()
+	require.NoError(t, err)
+	second, err := NewSecret()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(first, "whsec_"))
+	assert.Len(t, first, len("whsec_")+64)
+	assert.NotEqual(t, first, second)
+}
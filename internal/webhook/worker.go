@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// WorkerName identifies the delivery worker goroutine in logs.
+const WorkerName = "webhook-delivery-worker"
+
+// Dispatcher is the slice of the webhook service the worker needs. Depending
+// on this instead of service.WebhookService keeps internal/webhook free of an
+// import cycle (the service imports this package to sign and send).
+type Dispatcher interface {
+	// DeliverDue attempts the deliveries due at now and returns how many it
+	// attempted.
+	DeliverDue(now time.Time) (int, error)
+}
+
+// StartWorker launches the delivery worker and returns immediately. It drains
+// the queue once at start, so deliveries that fell due while the process was
+// down go out without waiting a full interval, then once per interval. The
+// goroutine exits when ctx is cancelled.
+func StartWorker(ctx context.Context, dispatcher Dispatcher, interval time.Duration) {
+	if dispatcher == nil {
+		logWorker().Warn("Webhook delivery worker not started: no dispatcher")
+		return
+	}
+	if interval <= 0 {
+		logWorker().WithField("interval", interval.String()).Warn("Webhook delivery worker not started: interval must be positive")
+		return
+	}
+	go workerLoop(ctx, dispatcher, interval)
+}
+
+func workerLoop(ctx context.Context, dispatcher Dispatcher, interval time.Duration) {
+	logWorker().WithField("interval", interval.String()).Info("Webhook delivery worker started")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		drain(dispatcher, time.Now())
+
+		select {
+		case <-ctx.Done():
+			logWorker().Info("Webhook delivery worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain runs one delivery pass. Every outcome — including a panic inside the
+// service — is contained here so the loop always survives to the next tick.
+func drain(dispatcher Dispatcher, now time.Time) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			logWorker().WithField("panic", recovered).Error("Webhook delivery pass panicked")
+		}
+	}()
+
+	attempted, err := dispatcher.DeliverDue(now)
+	switch {
+	case err != nil:
+		logWorker().WithField("attempted", attempted).WithField("error", err.Error()).Error("Webhook delivery pass failed")
+	case attempted > 0:
+		logWorker().WithField("attempted", attempted).Info("Webhook deliveries attempted")
+	default:
+		logWorker().Debug("Webhook delivery pass found nothing due")
+	}
+}
+
+func logWorker() *logrus.Entry {
+	base := utils.Logger
+	if base == nil {
+		base = logrus.StandardLogger()
+	}
+	return base.WithField("worker", WorkerName)
+}
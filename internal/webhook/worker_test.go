@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDispatcher counts passes and can be scripted to fail or panic.
+type fakeDispatcher struct {
+	mu     sync.Mutex
+	calls  int
+	err    error
+	panics bool
+	called chan struct{}
+}
+
+func newFakeDispatcher() *fakeDispatcher {
+	return &fakeDispatcher{called: make(chan struct{}, 16)}
+}
+
+func (f *fakeDispatcher) DeliverDue(now time.Time) (int, error) {
+	f.mu.Lock()
+	f.calls++
+	err := f.err
+	shouldPanic := f.panics
+	f.mu.Unlock()
+
+	select {
+	case f.called <- struct{}{}:
+	default:
+	}
+
+	if shouldPanic {
+		panic("service exploded")
+	}
+	return 1, err
+}
+
+func (f *fakeDispatcher) waitForCalls(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-f.called:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("delivery worker ran %d times, want %d", i, n)
+		}
+	}
+}
+
+func TestStartWorker_DrainsAtStartAndOnEveryTick(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dispatcher := newFakeDispatcher()
+
+	StartWorker(ctx, dispatcher, 10*time.Millisecond)
+
+	dispatcher.waitForCalls(t, 3)
+}
+
+func TestStartWorker_SurvivesFailuresAndPanics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dispatcher := newFakeDispatcher()
+	dispatcher.panics = true
+
+	StartWorker(ctx, dispatcher, 10*time.Millisecond)
+	dispatcher.waitForCalls(t, 2)
+
+	dispatcher.mu.Lock()
+	dispatcher.panics = false
+	dispatcher.err = errors.New("db down")
+	dispatcher.mu.Unlock()
+
+	dispatcher.waitForCalls(t, 2)
+}
+
+func TestStartWorker_RefusesUnusableSettings(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dispatcher := newFakeDispatcher()
+
+	StartWorker(ctx, nil, time.Second)
+	StartWorker(ctx, dispatcher, 0)
+
+	time.Sleep(20 * time.Millisecond)
+	dispatcher.mu.Lock()
+	defer dispatcher.mu.Unlock()
+	assert.Zero(t, dispatcher.calls)
+}
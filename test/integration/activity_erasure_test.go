@@ -0,0 +1,37 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The activity feed describes a lead or customer by name, so erasing the
+// person has to blank those descriptions too. The entries themselves stay: the
+// feed still shows that the record was created and later erased.
+func TestCustomerErasureScrubsItsActivityFeed(t *testing.T) {
+	db := setupErasureDB(t)
+	activity := service.NewActivityService(repository.NewActivityRepository(db))
+	customers := service.NewCustomerService(repository.NewCustomerRepository(db), repository.NewUserRepository(db), nil, nil,
+		service.WithActivityFeed(activity))
+
+	customer := &models.Customer{
+		FirstName: "Feed", LastName: "Subject", Email: "feed-subject@example.com", Company: "Feed Industries",
+	}
+	require.NoError(t, customers.Create(customer))
+
+	require.NoError(t, customers.Delete(customer.ID))
+
+	assertColumnsFreeOf(t, db, "activity_events", "Feed", "Subject", "Feed Industries")
+
+	events, _, err := activity.Feed(models.ActivityFilter{EntityType: models.AuditEntityCustomer, EntityID: customer.ID}, "", 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, models.ActivityCustomerDeleted, events[0].Type)
+	assert.Equal(t, models.ActivityCustomerCreated, events[1].Type)
+	assert.Equal(t, models.AuditErasedValue, events[1].Description)
+}
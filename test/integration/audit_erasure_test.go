@@ -0,0 +1,91 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The audit trail quotes field values in its diffs, so an erasure that left it
+// alone would leave the person's data one table over. These tests pin down
+// that erasing a record scrubs its trail in the same transaction, while the
+// events themselves — who changed what, and when — survive.
+
+func recordAuditHistory(t *testing.T, audit service.AuditService, entityType string, id uint, created, updated interface{}) {
+	t.Helper()
+	adminID := uint(1)
+	actor := models.AuditActor{Type: models.AuditActorUser, UserID: &adminID}
+	require.NoError(t, audit.Record(actor, entityType, id, models.AuditActionCreate, nil, created))
+	require.NoError(t, audit.Record(actor, entityType, id, models.AuditActionUpdate, service.AuditSnapshot(created), updated))
+}
+
+func TestCustomerErasureScrubsItsAuditTrail(t *testing.T) {
+	db := setupErasureDB(t)
+	customerRepo := repository.NewCustomerRepository(db)
+	audit := service.NewAuditService(repository.NewAuditRepository(db))
+
+	customer := seedCustomer(t, db, "audited-customer@example.com")
+	updated := *customer
+	updated.Email = "audited-customer-new@example.com"
+	updated.Phone = "+40 721 999 999"
+	recordAuditHistory(t, audit, models.AuditEntityCustomer, customer.ID, customer, &updated)
+
+	require.NoError(t, customerRepo.Delete(customer.ID))
+
+	assertColumnsFreeOf(t, db, "audit_events",
+		"audited-customer@example.com", "audited-customer-new@example.com",
+		"Erasure", "Subject", "+40 721", "Subject Industries", "12 Privacy Lane",
+		"Bucharest", "Prefers to be called")
+
+	events, total, err := audit.History(models.AuditEntityCustomer, customer.ID, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total, "the events survive the erasure")
+	update := events[0]
+	assert.Equal(t, models.AuditChange{Before: models.AuditErasedValue, After: models.AuditErasedValue}, update.Changes["email"],
+		"the trail still shows that the email changed, just not what it said")
+	assert.Equal(t, models.AuditChange{Before: models.AuditErasedValue, After: models.AuditErasedValue}, update.Changes["phone"])
+}
+
+func TestUserErasureScrubsItsAuditTrailButKeepsTheRole(t *testing.T) {
+	db := setupErasureDB(t)
+	userRepo := repository.NewUserRepository(db)
+	audit := service.NewAuditService(repository.NewAuditRepository(db))
+
+	user := seedUser(t, db, "audited-user@example.com")
+	updated := *user
+	updated.Role = models.RoleAdmin
+	recordAuditHistory(t, audit, models.AuditEntityUser, user.ID, user, &updated)
+
+	require.NoError(t, userRepo.Delete(user.ID))
+
+	assertColumnsFreeOf(t, db, "audit_events", "audited-user@example.com", "Erasure", "Subject")
+
+	events, _, err := audit.History(models.AuditEntityUser, user.ID, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, models.AuditChange{Before: string(models.RoleSales), After: string(models.RoleAdmin)}, events[0].Changes["role"],
+		"a role change is business history, not personal data")
+}
+
+// A cascade erases the other half of a converted pair, and the trail of that
+// half has to be scrubbed just the same.
+func TestLeadCascadeScrubsTheAuditTrailOfBothRecords(t *testing.T) {
+	db := setupLeadErasureDB(t)
+	customerRepo := repository.NewCustomerRepositoryWithLeadErasure(db)
+	audit := service.NewAuditService(repository.NewAuditRepository(db))
+
+	owner := seedLeadOwner(t, db)
+	lead := seedLead(t, db, owner.ID, "audited-lead@example.com")
+	customer := convertLead(t, db, lead)
+	actor := models.AuditActor{Type: models.AuditActorUser, UserID: &owner.ID}
+	require.NoError(t, audit.Record(actor, models.AuditEntityLead, lead.ID, models.AuditActionCreate, nil, lead))
+	require.NoError(t, audit.Record(actor, models.AuditEntityCustomer, customer.ID, models.AuditActionCreate, nil, customer))
+
+	require.NoError(t, customerRepo.Delete(customer.ID))
+
+	assertColumnsFreeOf(t, db, "audit_events", leadPersonalData(lead)...)
+}
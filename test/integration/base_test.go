@@ -31,6 +31,10 @@ type BaseIntegrationTestSuite struct {
 	server      *httptest.Server
 	client      *http.Client
 	baseURL     string
+
+	// inboundService is the email gateway, for the suites that feed it a
+	// Maildir. Unknown senders are quarantined.
+	inboundService service.InboundEmailService
 }
 
 func (suite *BaseIntegrationTestSuite) SetupSuite() {
@@ -77,10 +81,16 @@ func (suite *BaseIntegrationTestSuite) SetupSuite() {
 	suite.authService = service.NewAuthService(userRepo, apiKeyRepo, suite.cfg.JWT)
 	userService := service.NewUserService(userRepo)
 	txManager := utils.NewTransactionManager(suite.db)
-	leadService := service.NewLeadService(leadRepo, customerRepo, txManager)
-	customerService := service.NewCustomerService(customerRepo, userRepo)
-	ticketService := service.NewTicketService(ticketRepo, customerRepo, userRepo)
+	leadService := service.NewLeadService(leadRepo, customerRepo, nil, txManager)
+	customerService := service.NewCustomerService(customerRepo, userRepo, nil, nil)
+	ticketCommentRepo := repository.NewTicketCommentRepository(suite.db)
+	slaService := service.NewSLAService(repository.NewSLARepository(suite.db), nil)
+	ticketService := service.NewTicketServiceWithSLA(ticketRepo, customerRepo, userRepo, nil, ticketCommentRepo, slaService)
+	ticketCommentService := service.NewTicketCommentService(ticketCommentRepo, ticketRepo)
 	apiKeyService := service.NewAPIKeyService(apiKeyRepo, "test-api-key-secret")
+	suite.inboundService = service.NewInboundEmailService(repository.NewInboundEmailRepository(suite.db),
+		customerRepo, ticketRepo, leadRepo, ticketService, ticketCommentService, nil,
+		config.InboundEmailConfig{UnknownSender: models.InboundUnknownSenderQuarantine})
 
 	// Setup handlers
 	authHandler := handler.NewAuthHandler(suite.authService, userService)
@@ -88,7 +98,10 @@ func (suite *BaseIntegrationTestSuite) SetupSuite() {
 	leadHandler := handler.NewLeadHandler(leadService)
 	customerHandler := handler.NewCustomerHandler(customerService)
 	ticketHandler := handler.NewTicketHandler(ticketService, customerService)
+	ticketCommentHandler := handler.NewTicketCommentHandler(ticketCommentService, ticketService, customerService)
 	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	slaHandler := handler.NewSLAHandler(slaService)
+	inboundEmailHandler := handler.NewInboundEmailHandler(suite.inboundService)
 
 	// Setup router with middleware
 	gin.SetMode(gin.TestMode)
@@ -113,7 +126,10 @@ func (suite *BaseIntegrationTestSuite) SetupSuite() {
 	handler.SetupLeadRoutes(protected, leadHandler)
 	handler.SetupCustomerRoutes(protected, customerHandler)
 	handler.SetupTicketRoutes(protected, ticketHandler)
+	handler.SetupTicketCommentRoutes(protected, ticketCommentHandler)
 	handler.SetupAPIKeyRoutes(protected, apiKeyHandler)
+	handler.SetupSLARoutes(protected, slaHandler)
+	handler.SetupInboundEmailRoutes(protected, inboundEmailHandler)
 
 	// Start test server
 	suite.server = httptest.NewServer(suite.router)
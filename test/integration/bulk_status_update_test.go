@@ -4,6 +4,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/florinel-chis/gophercrm/internal/config"
 	apperrors "github.com/florinel-chis/gophercrm/internal/errors"
 	"github.com/florinel-chis/gophercrm/internal/models"
 	"github.com/florinel-chis/gophercrm/internal/repository"
@@ -34,8 +35,9 @@ func setupBulkStatusDB(t *testing.T) *gorm.DB {
 		&models.Lead{},
 		&models.Customer{},
 		&models.Ticket{},
+		&models.TicketComment{},
 		&models.Task{},
-		&models.APIKey{},
+		&models.APIKey{}, &models.APIKeyUsage{},
 		&models.RefreshToken{},
 		&models.BulkOperation{},
 		&models.BulkOperationItem{},
@@ -55,7 +57,9 @@ func newBulkStatusService(db *gorm.DB) service.BulkOperationService {
 		repository.NewTaskRepository(db),
 		repository.NewTicketRepository(db),
 		utils.NewTransactionManager(db),
+		nil,
 		logger,
+		config.BulkConfig{},
 	)
 }
 
@@ -156,7 +160,7 @@ func TestBulkSetLeadStatus_AdminUpdatesEveryLead(t *testing.T) {
 	first := seedStatusLead(t, db, owner.ID, "lead-a@example.com", models.LeadStatusNew)
 	second := seedStatusLead(t, db, owner.ID, "lead-b@example.com", models.LeadStatusNew)
 
-	result, err := svc.BulkSetLeadStatus(admin.ID, models.RoleAdmin,
+	result, err := svc.BulkSetLeadStatus(admin.ID, models.BuiltInPermissions(models.RoleAdmin),
 		[]uint{first.ID, second.ID}, models.LeadStatusQualified)
 
 	require.NoError(t, err)
@@ -176,7 +180,7 @@ func TestBulkSetLeadStatus_SalesNotOwner_LeavesEarlierLeadsUnchanged(t *testing.
 	mine := seedStatusLead(t, db, sales.ID, "mine@example.com", models.LeadStatusNew)
 	theirs := seedStatusLead(t, db, other.ID, "theirs@example.com", models.LeadStatusNew)
 
-	result, err := svc.BulkSetLeadStatus(sales.ID, models.RoleSales,
+	result, err := svc.BulkSetLeadStatus(sales.ID, models.BuiltInPermissions(models.RoleSales),
 		[]uint{mine.ID, theirs.ID}, models.LeadStatusQualified)
 
 	require.Error(t, err)
@@ -197,7 +201,7 @@ func TestBulkSetLeadStatus_SalesOwnsEveryLead_Succeeds(t *testing.T) {
 	first := seedStatusLead(t, db, sales.ID, "own-a@example.com", models.LeadStatusNew)
 	second := seedStatusLead(t, db, sales.ID, "own-b@example.com", models.LeadStatusContacted)
 
-	result, err := svc.BulkSetLeadStatus(sales.ID, models.RoleSales,
+	result, err := svc.BulkSetLeadStatus(sales.ID, models.BuiltInPermissions(models.RoleSales),
 		[]uint{first.ID, second.ID}, models.LeadStatusContacted)
 
 	require.NoError(t, err)
@@ -212,7 +216,7 @@ func TestBulkSetLeadStatus_MissingID_ChangesNothing(t *testing.T) {
 	admin := seedStatusUser(t, db, "admin-missing@example.com", models.RoleAdmin)
 	lead := seedStatusLead(t, db, admin.ID, "present@example.com", models.LeadStatusNew)
 
-	result, err := svc.BulkSetLeadStatus(admin.ID, models.RoleAdmin,
+	result, err := svc.BulkSetLeadStatus(admin.ID, models.BuiltInPermissions(models.RoleAdmin),
 		[]uint{lead.ID, 4242}, models.LeadStatusQualified)
 
 	require.Error(t, err)
@@ -231,7 +235,7 @@ func TestBulkSetLeadStatus_DeletedLead_IsNotFound(t *testing.T) {
 	lead := seedStatusLead(t, db, admin.ID, "deleted@example.com", models.LeadStatusNew)
 	require.NoError(t, db.Delete(&models.Lead{}, lead.ID).Error)
 
-	_, err := svc.BulkSetLeadStatus(admin.ID, models.RoleAdmin, []uint{lead.ID}, models.LeadStatusQualified)
+	_, err := svc.BulkSetLeadStatus(admin.ID, models.BuiltInPermissions(models.RoleAdmin), []uint{lead.ID}, models.LeadStatusQualified)
 
 	require.Error(t, err)
 	assert.True(t, apperrors.IsNotFound(err))
@@ -244,7 +248,7 @@ func TestBulkSetLeadStatus_SupportRole_Forbidden(t *testing.T) {
 	support := seedStatusUser(t, db, "support-leads@example.com", models.RoleSupport)
 	lead := seedStatusLead(t, db, support.ID, "support-lead@example.com", models.LeadStatusNew)
 
-	_, err := svc.BulkSetLeadStatus(support.ID, models.RoleSupport, []uint{lead.ID}, models.LeadStatusQualified)
+	_, err := svc.BulkSetLeadStatus(support.ID, models.BuiltInPermissions(models.RoleSupport), []uint{lead.ID}, models.LeadStatusQualified)
 
 	require.Error(t, err)
 	assert.True(t, errors.Is(err, apperrors.ErrForbidden))
@@ -256,7 +260,7 @@ func TestBulkSetLeadStatus_RejectsEmptyAndOversizedLists(t *testing.T) {
 	svc := newBulkStatusService(db)
 	admin := seedStatusUser(t, db, "admin-limits@example.com", models.RoleAdmin)
 
-	_, err := svc.BulkSetLeadStatus(admin.ID, models.RoleAdmin, nil, models.LeadStatusQualified)
+	_, err := svc.BulkSetLeadStatus(admin.ID, models.BuiltInPermissions(models.RoleAdmin), nil, models.LeadStatusQualified)
 	require.Error(t, err)
 	appErr, ok := apperrors.AsAppError(err)
 	require.True(t, ok)
@@ -266,7 +270,7 @@ func TestBulkSetLeadStatus_RejectsEmptyAndOversizedLists(t *testing.T) {
 	for i := range tooMany {
 		tooMany[i] = uint(i + 1)
 	}
-	_, err = svc.BulkSetLeadStatus(admin.ID, models.RoleAdmin, tooMany, models.LeadStatusQualified)
+	_, err = svc.BulkSetLeadStatus(admin.ID, models.BuiltInPermissions(models.RoleAdmin), tooMany, models.LeadStatusQualified)
 	require.Error(t, err)
 	appErr, ok = apperrors.AsAppError(err)
 	require.True(t, ok)
@@ -280,7 +284,7 @@ func TestBulkSetLeadStatus_RejectsInvalidStatus(t *testing.T) {
 	admin := seedStatusUser(t, db, "admin-status@example.com", models.RoleAdmin)
 	lead := seedStatusLead(t, db, admin.ID, "invalid-status@example.com", models.LeadStatusNew)
 
-	_, err := svc.BulkSetLeadStatus(admin.ID, models.RoleAdmin, []uint{lead.ID}, models.LeadStatus("archived"))
+	_, err := svc.BulkSetLeadStatus(admin.ID, models.BuiltInPermissions(models.RoleAdmin), []uint{lead.ID}, models.LeadStatus("archived"))
 
 	require.Error(t, err)
 	appErr, ok := apperrors.AsAppError(err)
@@ -299,13 +303,21 @@ func TestBulkSetTicketStatus_SupportUpdatesOwnTickets(t *testing.T) {
 	first := seedStatusTicket(t, db, &support.ID, models.TicketStatusOpen)
 	second := seedStatusTicket(t, db, &support.ID, models.TicketStatusOpen)
 
-	result, err := svc.BulkSetTicketStatus(support.ID, models.RoleSupport,
+	result, err := svc.BulkSetTicketStatus(support.ID, models.BuiltInPermissions(models.RoleSupport),
 		[]uint{first.ID, second.ID}, models.TicketStatusInProgress)
 
 	require.NoError(t, err)
 	assert.Equal(t, 2, result.Updated)
 	assert.Equal(t, models.TicketStatusInProgress, ticketStatus(t, db, first.ID))
 	assert.Equal(t, models.TicketStatusInProgress, ticketStatus(t, db, second.ID))
+
+	// Each moved ticket gets the same status-change entry a single update writes.
+	var entries []models.TicketComment
+	require.NoError(t, db.Where("ticket_id = ?", first.ID).Find(&entries).Error)
+	require.Len(t, entries, 1)
+	assert.Equal(t, models.TicketCommentKindStatusChange, entries[0].Kind)
+	assert.Equal(t, models.TicketStatusOpen, entries[0].FromStatus)
+	assert.Equal(t, models.TicketStatusInProgress, entries[0].ToStatus)
 }
 
 func TestBulkSetTicketStatus_SupportNotAssigned_LeavesEarlierTicketsUnchanged(t *testing.T) {
@@ -318,7 +330,7 @@ func TestBulkSetTicketStatus_SupportNotAssigned_LeavesEarlierTicketsUnchanged(t
 	theirs := seedStatusTicket(t, db, &other.ID, models.TicketStatusOpen)
 	unassigned := seedStatusTicket(t, db, nil, models.TicketStatusOpen)
 
-	_, err := svc.BulkSetTicketStatus(support.ID, models.RoleSupport,
+	_, err := svc.BulkSetTicketStatus(support.ID, models.BuiltInPermissions(models.RoleSupport),
 		[]uint{mine.ID, theirs.ID, unassigned.ID}, models.TicketStatusResolved)
 
 	require.Error(t, err)
@@ -336,11 +348,11 @@ func TestBulkSetTicketStatus_SalesAndCustomerAreForbidden(t *testing.T) {
 	customer := seedStatusUser(t, db, "customer-tickets@example.com", models.RoleCustomer)
 	ticket := seedStatusTicket(t, db, &sales.ID, models.TicketStatusOpen)
 
-	_, err := svc.BulkSetTicketStatus(sales.ID, models.RoleSales, []uint{ticket.ID}, models.TicketStatusResolved)
+	_, err := svc.BulkSetTicketStatus(sales.ID, models.BuiltInPermissions(models.RoleSales), []uint{ticket.ID}, models.TicketStatusResolved)
 	require.Error(t, err)
 	assert.True(t, errors.Is(err, apperrors.ErrForbidden))
 
-	_, err = svc.BulkSetTicketStatus(customer.ID, models.RoleCustomer, []uint{ticket.ID}, models.TicketStatusResolved)
+	_, err = svc.BulkSetTicketStatus(customer.ID, models.BuiltInPermissions(models.RoleCustomer), []uint{ticket.ID}, models.TicketStatusResolved)
 	require.Error(t, err)
 	assert.True(t, errors.Is(err, apperrors.ErrForbidden))
 
@@ -357,7 +369,7 @@ func TestBulkSetTicketStatus_ReopeningClosedTicket_RollsBackTheBatch(t *testing.
 	open := seedStatusTicket(t, db, &admin.ID, models.TicketStatusOpen)
 	closed := seedStatusTicket(t, db, &admin.ID, models.TicketStatusClosed)
 
-	_, err := svc.BulkSetTicketStatus(admin.ID, models.RoleAdmin,
+	_, err := svc.BulkSetTicketStatus(admin.ID, models.BuiltInPermissions(models.RoleAdmin),
 		[]uint{open.ID, closed.ID}, models.TicketStatusInProgress)
 
 	require.Error(t, err)
@@ -374,7 +386,7 @@ func TestBulkSetTicketStatus_MissingID_ChangesNothing(t *testing.T) {
 	admin := seedStatusUser(t, db, "admin-ticket-missing@example.com", models.RoleAdmin)
 	ticket := seedStatusTicket(t, db, &admin.ID, models.TicketStatusOpen)
 
-	_, err := svc.BulkSetTicketStatus(admin.ID, models.RoleAdmin,
+	_, err := svc.BulkSetTicketStatus(admin.ID, models.BuiltInPermissions(models.RoleAdmin),
 		[]uint{ticket.ID, 9999}, models.TicketStatusResolved)
 
 	require.Error(t, err)
@@ -394,7 +406,7 @@ func TestBulkSetTaskStatus_AdminUpdatesAnyTask(t *testing.T) {
 	first := seedStatusTask(t, db, assignee.ID, models.TaskStatusPending)
 	second := seedStatusTask(t, db, assignee.ID, models.TaskStatusPending)
 
-	result, err := svc.BulkSetTaskStatus(admin.ID, models.RoleAdmin,
+	result, err := svc.BulkSetTaskStatus(admin.ID, models.BuiltInPermissions(models.RoleAdmin),
 		[]uint{first.ID, second.ID}, models.TaskStatusInProgress)
 
 	require.NoError(t, err)
@@ -412,7 +424,7 @@ func TestBulkSetTaskStatus_NotAssigned_LeavesEarlierTasksUnchanged(t *testing.T)
 	mine := seedStatusTask(t, db, support.ID, models.TaskStatusPending)
 	theirs := seedStatusTask(t, db, other.ID, models.TaskStatusPending)
 
-	_, err := svc.BulkSetTaskStatus(support.ID, models.RoleSupport,
+	_, err := svc.BulkSetTaskStatus(support.ID, models.BuiltInPermissions(models.RoleSupport),
 		[]uint{mine.ID, theirs.ID}, models.TaskStatusInProgress)
 
 	require.Error(t, err)
@@ -434,7 +446,7 @@ func TestBulkSetTaskStatus_CompletedTaskInBatch_RollsBackTheBatch(t *testing.T)
 	completed := seedStatusTask(t, db, admin.ID, models.TaskStatusCompleted)
 	trailing := seedStatusTask(t, db, admin.ID, models.TaskStatusPending)
 
-	result, err := svc.BulkSetTaskStatus(admin.ID, models.RoleAdmin,
+	result, err := svc.BulkSetTaskStatus(admin.ID, models.BuiltInPermissions(models.RoleAdmin),
 		[]uint{pending.ID, completed.ID, trailing.ID}, models.TaskStatusInProgress)
 
 	require.Error(t, err)
@@ -457,7 +469,7 @@ func TestBulkSetTaskStatus_CompletedToCompleted_IsAllowed(t *testing.T) {
 	completed := seedStatusTask(t, db, admin.ID, models.TaskStatusCompleted)
 	pending := seedStatusTask(t, db, admin.ID, models.TaskStatusPending)
 
-	result, err := svc.BulkSetTaskStatus(admin.ID, models.RoleAdmin,
+	result, err := svc.BulkSetTaskStatus(admin.ID, models.BuiltInPermissions(models.RoleAdmin),
 		[]uint{completed.ID, pending.ID}, models.TaskStatusCompleted)
 
 	require.NoError(t, err)
@@ -472,7 +484,7 @@ func TestBulkSetTaskStatus_MissingID_ChangesNothing(t *testing.T) {
 	admin := seedStatusUser(t, db, "admin-task-missing@example.com", models.RoleAdmin)
 	task := seedStatusTask(t, db, admin.ID, models.TaskStatusPending)
 
-	_, err := svc.BulkSetTaskStatus(admin.ID, models.RoleAdmin,
+	_, err := svc.BulkSetTaskStatus(admin.ID, models.BuiltInPermissions(models.RoleAdmin),
 		[]uint{task.ID, 7777}, models.TaskStatusCancelled)
 
 	require.Error(t, err)
@@ -489,7 +501,7 @@ func TestBulkSetTaskStatus_DuplicateIDsCountOnce(t *testing.T) {
 	admin := seedStatusUser(t, db, "admin-dupes@example.com", models.RoleAdmin)
 	task := seedStatusTask(t, db, admin.ID, models.TaskStatusPending)
 
-	result, err := svc.BulkSetTaskStatus(admin.ID, models.RoleAdmin,
+	result, err := svc.BulkSetTaskStatus(admin.ID, models.BuiltInPermissions(models.RoleAdmin),
 		[]uint{task.ID, task.ID}, models.TaskStatusInProgress)
 
 	require.NoError(t, err)
@@ -507,10 +519,10 @@ func TestBulkSetTaskStatus_RecordsTheOperation(t *testing.T) {
 	task := seedStatusTask(t, db, admin.ID, models.TaskStatusPending)
 	completed := seedStatusTask(t, db, admin.ID, models.TaskStatusCompleted)
 
-	_, err := svc.BulkSetTaskStatus(admin.ID, models.RoleAdmin, []uint{task.ID}, models.TaskStatusInProgress)
+	_, err := svc.BulkSetTaskStatus(admin.ID, models.BuiltInPermissions(models.RoleAdmin), []uint{task.ID}, models.TaskStatusInProgress)
 	require.NoError(t, err)
 
-	_, err = svc.BulkSetTaskStatus(admin.ID, models.RoleAdmin, []uint{completed.ID}, models.TaskStatusPending)
+	_, err = svc.BulkSetTaskStatus(admin.ID, models.BuiltInPermissions(models.RoleAdmin), []uint{completed.ID}, models.TaskStatusPending)
 	require.Error(t, err)
 
 	operations, total, err := svc.GetUserBulkOperations(admin.ID, 0, 10)
@@ -1,6 +1,7 @@
 package integration
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -179,6 +180,11 @@ func sweepDatabaseFor(t *testing.T, db *gorm.DB, needles []string) []personalDat
 
 		for _, row := range rows {
 			for column, value := range row {
+				// Columns of a type the driver does not know, longtext
+				// among them, come back boxed.
+				if boxed, ok := value.(*interface{}); ok && boxed != nil {
+					value = *boxed
+				}
 				if value == nil {
 					continue
 				}
@@ -337,6 +343,61 @@ func TestLeadErasureLeavesNoPersonalDataInAnyTable(t *testing.T) {
 	assertNoPersonalDataAnywhere(t, db, subject.identifiers())
 }
 
+// --- Imports -----------------------------------------------------------------
+
+// An import keeps the file it was uploaded with until it is started, and the
+// cells of every row it could not create after that. Both quote the person
+// without being linked to their record by anything but the email.
+func TestLeadErasureLeavesNoPersonalDataInImports(t *testing.T) {
+	db := setupFullSchemaDB(t)
+	subject := newErasureSubject("import-sweep")
+	bystander := newErasureSubject("import-bystander")
+
+	owner := seedLeadOwner(t, db)
+	lead := subject.asLead(t, db, owner.ID)
+
+	header := "first_name,last_name,email,phone\n"
+	row := func(s erasureSubject) string {
+		return fmt.Sprintf("%s,%s,%s,%s\n", s.FirstName, s.LastName, s.Email, s.Phone)
+	}
+	pending := &models.Import{
+		UserID: owner.ID, EntityType: models.AuditEntityLead, FileName: "leads.csv", Delimiter: ",",
+		RowCount: 1, Content: header + row(subject),
+	}
+	other := &models.Import{
+		UserID: owner.ID, EntityType: models.AuditEntityLead, FileName: "others.csv", Delimiter: ",",
+		RowCount: 1, Content: header + row(bystander),
+	}
+	require.NoError(t, db.Create(pending).Error)
+	require.NoError(t, db.Create(other).Error)
+
+	operation := &models.BulkOperation{
+		UserID: owner.ID, ResourceType: "leads", Type: models.BulkCreate,
+		Status: models.StatusPartial, TotalItems: 2, FailureCount: 2,
+	}
+	require.NoError(t, db.Create(operation).Error)
+	started := &models.Import{
+		UserID: owner.ID, EntityType: models.AuditEntityLead, FileName: "started.csv", Delimiter: ",",
+		RowCount: 2, OperationID: &operation.ID,
+	}
+	require.NoError(t, db.Create(started).Error)
+	for i, s := range []erasureSubject{subject, bystander} {
+		data, err := json.Marshal(models.ImportFailedRow{Row: i + 2, Values: []string{s.FirstName, s.LastName, s.Email, s.Phone}})
+		require.NoError(t, err)
+		require.NoError(t, db.Create(&models.BulkOperationItem{
+			OperationID: operation.ID, Status: models.StatusFailed, Error: "owner_id: not allowed", Data: string(data),
+		}).Error)
+	}
+
+	require.Equal(t, []string{"bulk_operation_items", "imports", "leads"}, tablesHolding(t, db, subject.identifiers()))
+
+	require.NoError(t, repository.NewLeadRepository(db).Delete(lead.ID))
+
+	assertNoPersonalDataAnywhere(t, db, subject.identifiers())
+	assert.Equal(t, []string{"bulk_operation_items", "imports"}, tablesHolding(t, db, bystander.identifiers()),
+		"the file and the failed row of somebody else must be untouched")
+}
+
 // --- The conversion link -----------------------------------------------------
 
 // THE test for the leak. A conversion leaves the same person described twice, in
@@ -40,11 +40,23 @@ func setupErasureDB(t *testing.T) *gorm.DB {
 	require.NoError(t, db.AutoMigrate(
 		&models.User{},
 		&models.Customer{},
-		&models.APIKey{},
+		&models.APIKey{}, &models.APIKeyUsage{},
 		&models.RefreshToken{},
 		&models.PasswordResetToken{},
+		&models.TwoFactorRecoveryCode{},
+		&models.Session{},
+		&models.RevokedToken{},
 		&models.Ticket{},
 		&models.Task{},
+		&models.AuditEvent{},
+		&models.ActivityEvent{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.CustomFieldDefinition{},
+		&models.CustomFieldValue{},
+		&models.BulkOperation{},
+		&models.BulkOperationItem{},
+		&models.Import{},
 	))
 	return db
 }
@@ -238,12 +250,13 @@ func TestUserErasureDestroysCredentialsThatWouldOutliveTheAccount(t *testing.T)
 	user := seedUser(t, db, "credentials@example.com")
 	survivor := seedUser(t, db, "survivor@example.com")
 
-	require.NoError(t, db.Create(&models.APIKey{
-		Name: "ci", KeyHash: "hash-erased", Prefix: "gcrm_abc", UserID: user.ID, IsActive: true,
-	}).Error)
-	require.NoError(t, db.Create(&models.APIKey{
-		Name: "ci", KeyHash: "hash-survivor", Prefix: "gcrm_xyz", UserID: survivor.ID, IsActive: true,
-	}).Error)
+	erasedKey := &models.APIKey{Name: "ci", KeyHash: "hash-erased", Prefix: "gcrm_abc", UserID: user.ID, IsActive: true}
+	require.NoError(t, db.Create(erasedKey).Error)
+	survivorKey := &models.APIKey{Name: "ci", KeyHash: "hash-survivor", Prefix: "gcrm_xyz", UserID: survivor.ID, IsActive: true}
+	require.NoError(t, db.Create(survivorKey).Error)
+	// The usage log holds the addresses the key was used from.
+	require.NoError(t, db.Create(&models.APIKeyUsage{APIKeyID: erasedKey.ID, Method: "GET", Endpoint: "/api/v1/leads", Status: 200, IPAddress: "203.0.113.9"}).Error)
+	require.NoError(t, db.Create(&models.APIKeyUsage{APIKeyID: survivorKey.ID, Method: "GET", Endpoint: "/api/v1/leads", Status: 200, IPAddress: "198.51.100.4"}).Error)
 	require.NoError(t, db.Create(&models.RefreshToken{
 		UserID: user.ID, TokenHash: "refresh-erased", ExpiresAt: time.Now().Add(24 * time.Hour),
 	}).Error)
@@ -256,6 +269,12 @@ func TestUserErasureDestroysCredentialsThatWouldOutliveTheAccount(t *testing.T)
 	require.NoError(t, db.Create(&models.PasswordResetToken{
 		UserID: survivor.ID, TokenHash: "reset-survivor", ExpiresAt: time.Now().Add(time.Hour),
 	}).Error)
+	require.NoError(t, db.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"two_factor_enabled": true, "totp_secret": "sealed-secret", "totp_last_step": 42,
+		"sso_issuer": "https://login.example.com", "sso_subject": "subject-erased",
+	}).Error)
+	require.NoError(t, db.Create(&models.TwoFactorRecoveryCode{UserID: user.ID, CodeHash: "recovery-erased"}).Error)
+	require.NoError(t, db.Create(&models.TwoFactorRecoveryCode{UserID: survivor.ID, CodeHash: "recovery-survivor"}).Error)
 
 	require.NoError(t, userRepo.Delete(user.ID))
 
@@ -265,6 +284,10 @@ func TestUserErasureDestroysCredentialsThatWouldOutliveTheAccount(t *testing.T)
 	require.NoError(t, db.Unscoped().Model(&models.APIKey{}).Where("user_id = ?", user.ID).Count(&keys).Error)
 	assert.Zero(t, keys, "API keys of an erased user must not survive")
 
+	var usage int64
+	require.NoError(t, db.Model(&models.APIKeyUsage{}).Where("api_key_id = ?", erasedKey.ID).Count(&usage).Error)
+	assert.Zero(t, usage, "the usage log of an erased user's keys must not survive")
+
 	var tokens int64
 	require.NoError(t, db.Unscoped().Model(&models.RefreshToken{}).Where("user_id = ?", user.ID).Count(&tokens).Error)
 	assert.Zero(t, tokens, "refresh tokens of an erased user must not survive")
@@ -273,13 +296,26 @@ func TestUserErasureDestroysCredentialsThatWouldOutliveTheAccount(t *testing.T)
 	require.NoError(t, db.Unscoped().Model(&models.PasswordResetToken{}).Where("user_id = ?", user.ID).Count(&resets).Error)
 	assert.Zero(t, resets, "password reset tokens of an erased user must not survive")
 
+	var recoveryCodes int64
+	require.NoError(t, db.Model(&models.TwoFactorRecoveryCode{}).Where("user_id = ?", user.ID).Count(&recoveryCodes).Error)
+	assert.Zero(t, recoveryCodes, "recovery codes of an erased user must not survive")
+	var erased models.User
+	require.NoError(t, db.Unscoped().First(&erased, user.ID).Error)
+	assert.Empty(t, erased.TOTPSecret, "the TOTP secret of an erased user must not survive")
+	assert.False(t, erased.TwoFactorEnabled)
+	assert.Empty(t, erased.SSOSubject, "the link to the identity provider account must not survive")
+
 	// Another user's credentials must be untouched.
 	require.NoError(t, db.Unscoped().Model(&models.APIKey{}).Where("user_id = ?", survivor.ID).Count(&keys).Error)
 	assert.Equal(t, int64(1), keys)
+	require.NoError(t, db.Model(&models.APIKeyUsage{}).Where("api_key_id = ?", survivorKey.ID).Count(&usage).Error)
+	assert.Equal(t, int64(1), usage)
 	require.NoError(t, db.Unscoped().Model(&models.RefreshToken{}).Where("user_id = ?", survivor.ID).Count(&tokens).Error)
 	assert.Equal(t, int64(1), tokens)
 	require.NoError(t, db.Unscoped().Model(&models.PasswordResetToken{}).Where("user_id = ?", survivor.ID).Count(&resets).Error)
 	assert.Equal(t, int64(1), resets)
+	require.NoError(t, db.Model(&models.TwoFactorRecoveryCode{}).Where("user_id = ?", survivor.ID).Count(&recoveryCodes).Error)
+	assert.Equal(t, int64(1), recoveryCodes)
 }
 
 // The purge is unconditional: if it cannot run, the erasure FAILS. It used to
@@ -292,7 +328,7 @@ func TestUserErasureFailsAndRollsBackWhenCredentialsCannotBePurged(t *testing.T)
 	db := setupDB(t)
 	// Deliberately incomplete schema: refresh_tokens is missing, so the purge
 	// statement errors out exactly like a failing query would.
-	require.NoError(t, db.AutoMigrate(&models.User{}, &models.APIKey{}))
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.APIKey{}, &models.APIKeyUsage{}))
 	userRepo := repository.NewUserRepository(db)
 
 	user := seedUser(t, db, "purge-failure@example.com")
@@ -444,7 +480,7 @@ func TestErasingACustomerThatDoesNotExistReportsNotFound(t *testing.T) {
 	db := setupErasureDB(t)
 	customerService := service.NewCustomerService(
 		repository.NewCustomerRepository(db),
-		repository.NewUserRepository(db),
+		repository.NewUserRepository(db), nil, nil,
 	)
 
 	err := customerService.Delete(99999)
@@ -769,7 +805,7 @@ func TestErasedCustomerEmailCanBeUsedAgain(t *testing.T) {
 	db := setupErasureDB(t)
 	customerRepo := repository.NewCustomerRepository(db)
 	userRepo := repository.NewUserRepository(db)
-	customerService := service.NewCustomerService(customerRepo, userRepo)
+	customerService := service.NewCustomerService(customerRepo, userRepo, nil, nil)
 
 	original := &models.Customer{FirstName: "First", LastName: "Tenant", Email: "reusable-customer@example.com"}
 	require.NoError(t, customerService.Create(original))
@@ -25,14 +25,25 @@ func setupFormErasureDB(t *testing.T) *gorm.DB {
 		&models.User{},
 		&models.Lead{},
 		&models.Customer{},
-		&models.APIKey{},
+		&models.APIKey{}, &models.APIKeyUsage{},
 		&models.RefreshToken{},
 		&models.PasswordResetToken{},
+		&models.TwoFactorRecoveryCode{},
+		&models.Session{},
+		&models.RevokedToken{},
 		&models.Ticket{},
 		&models.Task{},
 		&models.Form{},
 		&models.FormSubmission{},
 		&models.FormConfirmationToken{},
+		&models.AuditEvent{},
+		&models.ActivityEvent{},
+		&models.WebhookDelivery{},
+		&models.CustomFieldDefinition{},
+		&models.CustomFieldValue{},
+		&models.BulkOperation{},
+		&models.BulkOperationItem{},
+		&models.Import{},
 	))
 	return db
 }
@@ -36,14 +36,25 @@ func setupLeadErasureDB(t *testing.T) *gorm.DB {
 		&models.User{},
 		&models.Lead{},
 		&models.Customer{},
-		&models.APIKey{},
+		&models.APIKey{}, &models.APIKeyUsage{},
 		&models.RefreshToken{},
 		&models.PasswordResetToken{},
+		&models.TwoFactorRecoveryCode{},
+		&models.Session{},
+		&models.RevokedToken{},
 		&models.Ticket{},
 		&models.Task{},
 		&models.Form{},
 		&models.FormSubmission{},
 		&models.FormConfirmationToken{},
+		&models.AuditEvent{},
+		&models.ActivityEvent{},
+		&models.WebhookDelivery{},
+		&models.CustomFieldDefinition{},
+		&models.CustomFieldValue{},
+		&models.BulkOperation{},
+		&models.BulkOperationItem{},
+		&models.Import{},
 	))
 	return db
 }
@@ -105,7 +116,7 @@ func convertLead(t *testing.T, db *gorm.DB, lead *models.Lead) *models.Customer
 	t.Helper()
 	leadService := service.NewLeadService(
 		repository.NewLeadRepository(db),
-		repository.NewCustomerRepository(db),
+		repository.NewCustomerRepository(db), nil,
 		utils.NewTransactionManager(db),
 	)
 	customer, err := leadService.ConvertToCustomer(lead.ID, &models.Customer{})
@@ -270,7 +281,7 @@ func TestCustomerServiceErasureFollowsTheConversionLink(t *testing.T) {
 	db := setupLeadErasureDB(t)
 	customerService := service.NewCustomerService(
 		repository.NewCustomerRepositoryWithLeadErasure(db),
-		repository.NewUserRepository(db),
+		repository.NewUserRepository(db), nil, nil,
 	)
 
 	owner := seedLeadOwner(t, db)
@@ -434,7 +445,7 @@ func TestLeadServiceErasureOfAMissingLeadIsNotFound(t *testing.T) {
 	db := setupLeadErasureDB(t)
 	leadService := service.NewLeadService(
 		repository.NewLeadRepository(db),
-		repository.NewCustomerRepository(db),
+		repository.NewCustomerRepository(db), nil,
 		utils.NewTransactionManager(db),
 	)
 
@@ -450,7 +461,7 @@ func TestLeadServiceErasureIsNotRepeatable(t *testing.T) {
 	leadRepo := repository.NewLeadRepository(db)
 	leadService := service.NewLeadService(
 		leadRepo,
-		repository.NewCustomerRepository(db),
+		repository.NewCustomerRepository(db), nil,
 		utils.NewTransactionManager(db),
 	)
 
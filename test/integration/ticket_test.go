@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/florinel-chis/gophercrm/internal/inbound"
 	"github.com/florinel-chis/gophercrm/internal/models"
 	"github.com/florinel-chis/gophercrm/internal/utils"
 	"github.com/stretchr/testify/assert"
@@ -326,6 +329,131 @@ func (suite *TicketIntegrationTestSuite) TestTicketStatusTransitions() {
 	assert.Equal(suite.T(), http.StatusBadRequest, resp.StatusCode)
 }
 
+// The thread a customer sees is their own ticket's public entries: staff
+// comments and automatic status changes, never the internal notes.
+func (suite *TicketIntegrationTestSuite) TestTicketConversationThread() {
+	customerUser := suite.CreateUser("thread-customer@example.com", "password123", models.RoleCustomer)
+	customerToken := suite.GetAuthToken("thread-customer@example.com", "password123")
+	customer := &models.Customer{FirstName: "Thread", Email: "thread-customer@example.com", UserID: &customerUser.ID}
+	suite.Require().NoError(suite.db.Create(customer).Error)
+	ticket := &models.Ticket{
+		Title:       "Thread Ticket",
+		Description: "Testing the conversation thread",
+		Status:      models.TicketStatusOpen,
+		CustomerID:  customer.ID,
+	}
+	suite.Require().NoError(suite.db.Create(ticket).Error)
+
+	send := func(method, path, token string, payload interface{}) *http.Response {
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest(method, fmt.Sprintf("%s/api/v1%s", suite.baseURL, path), bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := suite.client.Do(req)
+		suite.Require().NoError(err)
+		return resp
+	}
+	commentsPath := fmt.Sprintf("/tickets/%d/comments", ticket.ID)
+
+	resp := send("POST", commentsPath, suite.adminToken, map[string]string{"body": "Looking into it"})
+	assert.Equal(suite.T(), http.StatusCreated, resp.StatusCode)
+	resp = send("POST", commentsPath, suite.adminToken, map[string]string{"body": "Known firmware bug", "visibility": "internal"})
+	assert.Equal(suite.T(), http.StatusCreated, resp.StatusCode)
+	resp = send("PUT", fmt.Sprintf("/tickets/%d", ticket.ID), suite.adminToken, map[string]string{"status": "resolved"})
+	assert.Equal(suite.T(), http.StatusOK, resp.StatusCode)
+	resp = send("POST", commentsPath, customerToken, map[string]string{"body": "Thanks!"})
+	assert.Equal(suite.T(), http.StatusCreated, resp.StatusCode)
+	resp = send("POST", commentsPath, customerToken, map[string]string{"body": "psst", "visibility": "internal"})
+	assert.Equal(suite.T(), http.StatusForbidden, resp.StatusCode)
+
+	type threadResponse struct {
+		Data struct {
+			Comments []models.TicketComment `json:"comments"`
+			Total    int64                  `json:"total"`
+		} `json:"data"`
+	}
+	read := func(token string) threadResponse {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("%s/api/v1%s", suite.baseURL, commentsPath), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := suite.client.Do(req)
+		suite.Require().NoError(err)
+		defer resp.Body.Close()
+		suite.Require().Equal(http.StatusOK, resp.StatusCode)
+		var thread threadResponse
+		suite.Require().NoError(json.NewDecoder(resp.Body).Decode(&thread))
+		return thread
+	}
+
+	staff := read(suite.adminToken)
+	assert.Equal(suite.T(), int64(4), staff.Data.Total)
+
+	public := read(customerToken)
+	suite.Require().Len(public.Data.Comments, 3)
+	assert.Equal(suite.T(), "Looking into it", public.Data.Comments[0].Body)
+	assert.Equal(suite.T(), models.TicketCommentKindStatusChange, public.Data.Comments[1].Kind)
+	assert.Equal(suite.T(), models.TicketStatusOpen, public.Data.Comments[1].FromStatus)
+	assert.Equal(suite.T(), models.TicketStatusResolved, public.Data.Comments[1].ToStatus)
+	assert.Equal(suite.T(), "Thanks!", public.Data.Comments[2].Body)
+}
+
+// A ticket opened at a priority with an SLA policy carries its deadlines, the
+// first staff reply stops the first-response clock, and the dashboard report
+// counts the result.
+func (suite *TicketIntegrationTestSuite) TestTicketSLADeadlines() {
+	send := func(method, path, token string, payload interface{}) map[string]interface{} {
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest(method, fmt.Sprintf("%s/api/v1%s", suite.baseURL, path), bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := suite.client.Do(req)
+		suite.Require().NoError(err)
+		defer resp.Body.Close()
+		suite.Require().Less(resp.StatusCode, 300, "%s %s", method, path)
+		var decoded utils.APIResponse
+		if resp.StatusCode != http.StatusNoContent {
+			suite.Require().NoError(json.NewDecoder(resp.Body).Decode(&decoded))
+		}
+		data, _ := decoded.Data.(map[string]interface{})
+		return data
+	}
+
+	send("PUT", "/sla-policies/urgent", suite.adminToken, map[string]interface{}{
+		"first_response_minutes": 30, "resolution_minutes": 240,
+	})
+	defer send("DELETE", "/sla-policies/urgent", suite.adminToken, nil)
+
+	created := send("POST", "/tickets", suite.supportToken, map[string]interface{}{
+		"title": "SLA Ticket", "description": "Site down", "priority": "urgent", "customer_id": suite.customerID,
+	})
+	ticketID := uint(created["id"].(float64))
+	opened, err := time.Parse(time.RFC3339, created["created_at"].(string))
+	suite.Require().NoError(err)
+	due, err := time.Parse(time.RFC3339, created["first_response_due_at"].(string))
+	suite.Require().NoError(err)
+	assert.WithinDuration(suite.T(), opened.Add(30*time.Minute), due, time.Second)
+
+	send("POST", fmt.Sprintf("/tickets/%d/comments", ticketID), suite.supportToken, map[string]string{"body": "On it"})
+
+	var ticket models.Ticket
+	suite.Require().NoError(suite.db.First(&ticket, ticketID).Error)
+	assert.NotNil(suite.T(), ticket.FirstRespondedAt)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("%s/api/v1/dashboard/sla/by-priority", suite.baseURL), nil)
+	req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+	resp, err := suite.client.Do(req)
+	suite.Require().NoError(err)
+	defer resp.Body.Close()
+	suite.Require().Equal(http.StatusOK, resp.StatusCode)
+	var report struct {
+		Data []models.SLAAttainment `json:"data"`
+	}
+	suite.Require().NoError(json.NewDecoder(resp.Body).Decode(&report))
+	suite.Require().Len(report.Data, 1)
+	assert.Equal(suite.T(), models.TicketPriorityUrgent, report.Data[0].Priority)
+	assert.Equal(suite.T(), int64(1), report.Data[0].FirstResponse.Met)
+	assert.Equal(suite.T(), int64(1), report.Data[0].Resolution.Pending)
+}
+
 func (suite *TicketIntegrationTestSuite) TestTicketValidation() {
 	// Test 1: Missing required fields
 	createReq := map[string]interface{}{
@@ -376,6 +504,95 @@ func (suite *TicketIntegrationTestSuite) TestTicketValidation() {
 	assert.Equal(suite.T(), http.StatusNotFound, resp.StatusCode)
 }
 
+func (suite *TicketIntegrationTestSuite) TestInboundEmailGateway() {
+	maildir, err := inbound.OpenMaildir(suite.T().TempDir())
+	suite.Require().NoError(err)
+	deliver := func(raw string) {
+		_, err := maildir.Deliver([]byte(strings.ReplaceAll(raw, "\n", "\r\n")))
+		suite.Require().NoError(err)
+		handled, err := maildir.Poll(suite.inboundService)
+		suite.Require().NoError(err)
+		suite.Require().Equal(1, handled)
+	}
+
+	// A known customer opens a ticket.
+	deliver(`From: John Doe <John.Doe@example.com>
+Subject: Fwd: Invoice missing
+Message-ID: <first@mail.example.com>
+
+Where is my invoice?
+`)
+	var ticket models.Ticket
+	suite.Require().NoError(suite.db.Where("title = ?", "Invoice missing").First(&ticket).Error)
+	assert.Equal(suite.T(), suite.customerID, ticket.CustomerID)
+	assert.Equal(suite.T(), "Where is my invoice?", ticket.Description)
+
+	// Redelivery of the same message changes nothing.
+	deliver(`From: john.doe@example.com
+Subject: Invoice missing
+Message-ID: <first@mail.example.com>
+
+Where is my invoice?
+`)
+	var count int64
+	suite.db.Model(&models.Ticket{}).Where("title = ?", "Invoice missing").Count(&count)
+	assert.Equal(suite.T(), int64(1), count)
+
+	// Answering the first message is not enough to thread: the headers are
+	// the sender's to write. This one opens a ticket of its own.
+	deliver(`From: john.doe@example.com
+Subject: Re: Invoice missing
+Message-ID: <forged@mail.example.com>
+In-Reply-To: <first@mail.example.com>
+
+Close this ticket.
+`)
+	suite.db.Model(&models.Ticket{}).Where("title = ?", "Invoice missing").Count(&count)
+	assert.Equal(suite.T(), int64(2), count)
+
+	// A reply that quotes the ticket's token threads onto it, quote stripped.
+	deliver(fmt.Sprintf(`From: john.doe@example.com
+Subject: Re: Invoice missing
+Message-ID: <second@mail.example.com>
+In-Reply-To: <first@mail.example.com>
+
+Found it, thanks.
+
+On Mon, 2 Mar 2026, Support <support@example.com> wrote:
+> Subject: Invoice missing %s
+> It is attached.
+`, inbound.TicketToken(ticket.ID, ticket.ReplyToken)))
+	var comments []models.TicketComment
+	suite.Require().NoError(suite.db.Where("ticket_id = ?", ticket.ID).Find(&comments).Error)
+	suite.Require().Len(comments, 1)
+	assert.Equal(suite.T(), models.TicketCommentKindEmail, comments[0].Kind)
+	assert.Equal(suite.T(), "Found it, thanks.", comments[0].Body)
+
+	// A stranger quoting the ticket token is quarantined, not threaded.
+	deliver(fmt.Sprintf(`From: stranger@elsewhere.test
+Subject: Re: %s
+
+Let me in.
+`, inbound.TicketToken(ticket.ID, ticket.ReplyToken)))
+	var quarantined models.InboundEmail
+	suite.Require().NoError(suite.db.Where("status = ?", models.InboundEmailQuarantined).First(&quarantined).Error)
+	assert.Equal(suite.T(), "stranger@elsewhere.test", quarantined.FromAddress)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("%s/api/v1/inbound-emails?status=quarantined", suite.baseURL), nil)
+	req.Header.Set("Authorization", "Bearer "+suite.supportToken)
+	resp, err := suite.client.Do(req)
+	suite.Require().NoError(err)
+	defer resp.Body.Close()
+	assert.Equal(suite.T(), http.StatusOK, resp.StatusCode)
+
+	req, _ = http.NewRequest("DELETE", fmt.Sprintf("%s/api/v1/inbound-emails/%d", suite.baseURL, quarantined.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+	resp, err = suite.client.Do(req)
+	suite.Require().NoError(err)
+	defer resp.Body.Close()
+	assert.Equal(suite.T(), http.StatusNoContent, resp.StatusCode)
+}
+
 func TestTicketIntegrationTestSuite(t *testing.T) {
 	suite.Run(t, new(TicketIntegrationTestSuite))
 }
\ No newline at end of file
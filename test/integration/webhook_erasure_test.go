@@ -0,0 +1,67 @@
+package integration
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/florinel-chis/gophercrm/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// A queued webhook payload is a copy of the record, waiting to be shipped to a
+// third party. Erasing the person must stop that copy from leaving: the
+// payload is blanked and the delivery dead-lettered. The deleted event, which
+// only carries the id, still goes out, signed.
+func TestCustomerErasureScrubsItsQueuedWebhookPayloads(t *testing.T) {
+	db := setupErasureDB(t)
+
+	var received [][]byte
+	var signatures []string
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, body)
+		signatures = append(signatures, r.Header.Get(webhook.SignatureHeader))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer endpoint.Close()
+
+	webhooks := service.NewWebhookService(repository.NewWebhookRepository(db),
+		utils.NewSecretBox("test-master-secret", "webhook-secret"), webhook.NewSender(time.Second),
+		config.WebhookConfig{MaxAttempts: 3, TimeoutSeconds: 1})
+	secret, err := webhooks.Create(&models.WebhookSubscription{
+		Name: "Warehouse", URL: endpoint.URL, Active: true,
+		Events: []models.WebhookEvent{models.WebhookCustomerCreated, models.WebhookCustomerDeleted},
+	})
+	require.NoError(t, err)
+
+	customers := service.NewCustomerService(repository.NewCustomerRepository(db), repository.NewUserRepository(db), nil, nil,
+		service.WithWebhooks(webhooks))
+	customer := &models.Customer{
+		FirstName: "Hook", LastName: "Subject", Email: "hook-subject@example.com", Company: "Hook Industries",
+	}
+	require.NoError(t, customers.Create(customer))
+	require.NoError(t, customers.Delete(customer.ID))
+
+	assertColumnsFreeOf(t, db, "webhook_deliveries", "Hook", "Subject", "hook-subject@example.com")
+
+	var created models.WebhookDelivery
+	require.NoError(t, db.Where("event = ?", models.WebhookCustomerCreated).First(&created).Error)
+	assert.Equal(t, models.WebhookDeliveryFailed, created.Status)
+	assert.Equal(t, models.AuditErasedValue, created.Payload)
+
+	attempted, err := webhooks.DeliverDue(time.Now().UTC())
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempted, "only the deleted event is still sent")
+	require.Len(t, received, 1)
+	assert.Contains(t, string(received[0]), `"type":"customer.deleted"`)
+	assert.NoError(t, webhook.Verify(secret, signatures[0], received[0], time.Minute, time.Now()))
+}
@@ -48,7 +48,7 @@ func (suite *APIKeyIntegrationTestSuite) SetupSuite() {
 	suite.NoError(err)
 	
 	// Migrate the schema
-	err = db.AutoMigrate(&models.User{}, &models.APIKey{})
+	err = db.AutoMigrate(&models.User{}, &models.APIKey{}, &models.APIKeyUsage{})
 	suite.NoError(err)
 	
 	suite.db = db
@@ -91,6 +91,7 @@ func (suite *APIKeyIntegrationTestSuite) SetupSuite() {
 	// Setup routes
 	api := suite.router.Group("/api/v1")
 	protected := api.Group("")
+	protected.Use(middleware.LogAPIKeyUsage(suite.apiKeyService))
 	protected.Use(middleware.Auth(suite.authService))
 	{
 		protected.POST("/api-keys", apiKeyHandler.Create)
@@ -98,6 +99,7 @@ func (suite *APIKeyIntegrationTestSuite) SetupSuite() {
 		protected.GET("/api-keys/:id", apiKeyHandler.Get)
 		protected.PUT("/api-keys/:id", apiKeyHandler.Update)
 		protected.DELETE("/api-keys/:id", apiKeyHandler.Revoke)
+		protected.GET("/api-keys/:id/usage", apiKeyHandler.Usage)
 		
 		// Test endpoint that accepts API key auth
 		protected.GET("/test-api-key", func(c *gin.Context) {
@@ -126,7 +128,8 @@ func (suite *APIKeyIntegrationTestSuite) TestCreateAPIKey() {
 		{
 			name: "successful creation",
 			payload: map[string]interface{}{
-				"name": "Production API Key",
+				"name":   "Production API Key",
+				"scopes": []string{"leads:read"},
 			},
 			authHeader:     "Bearer " + suite.authToken,
 			expectedStatus: http.StatusCreated,
@@ -144,8 +147,36 @@ func (suite *APIKeyIntegrationTestSuite) TestCreateAPIKey() {
 				assert.True(t, ok)
 				assert.Equal(t, "Production API Key", apiKey["name"])
 				assert.Equal(t, true, apiKey["is_active"])
+				assert.Equal(t, []interface{}{"leads:read"}, apiKey["scopes"])
 			},
 		},
+		{
+			name: "missing scopes",
+			payload: map[string]interface{}{
+				"name": "Unscoped Key",
+			},
+			authHeader:     "Bearer " + suite.authToken,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "unknown scope",
+			payload: map[string]interface{}{
+				"name":   "Unknown Scope Key",
+				"scopes": []string{"invoices:read"},
+			},
+			authHeader:     "Bearer " + suite.authToken,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid network",
+			payload: map[string]interface{}{
+				"name":          "Bad Network Key",
+				"scopes":        []string{"leads:read"},
+				"allowed_cidrs": []string{"10.0.0.0/40"},
+			},
+			authHeader:     "Bearer " + suite.authToken,
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name: "missing name",
 			payload: map[string]interface{}{},
@@ -155,7 +186,8 @@ func (suite *APIKeyIntegrationTestSuite) TestCreateAPIKey() {
 		{
 			name: "short name",
 			payload: map[string]interface{}{
-				"name": "ab",
+				"name":   "ab",
+				"scopes": []string{"leads:read"},
 			},
 			authHeader:     "Bearer " + suite.authToken,
 			expectedStatus: http.StatusBadRequest,
@@ -163,7 +195,8 @@ func (suite *APIKeyIntegrationTestSuite) TestCreateAPIKey() {
 		{
 			name: "no authentication",
 			payload: map[string]interface{}{
-				"name": "Test Key",
+				"name":   "Test Key",
+				"scopes": []string{"leads:read"},
 			},
 			authHeader:     "",
 			expectedStatus: http.StatusUnauthorized,
@@ -347,11 +380,56 @@ func (suite *APIKeyIntegrationTestSuite) TestAPIKeyAuthentication() {
 	}
 }
 
+// A key is held to its scopes and networks, and every request it makes,
+// refused or not, lands in its usage log.
+func (suite *APIKeyIntegrationTestSuite) TestRestrictedAPIKey() {
+	key, apiKey, err := suite.apiKeyService.GenerateRestricted(suite.testUser.ID, "Key Reader", nil, models.APIKeyRestrictions{
+		Scopes:       []models.APIKeyScope{"api_keys:read"},
+		AllowedCIDRs: []string{"192.0.2.0/24"},
+	})
+	suite.Require().NoError(err)
+
+	call := func(method, path, remoteAddr string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "ApiKey "+key)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(suite.T(), http.StatusOK, call(http.MethodGet, "/api/v1/api-keys", "192.0.2.10:5000"))
+	assert.Equal(suite.T(), http.StatusForbidden, call(http.MethodPost, "/api/v1/api-keys", "192.0.2.10:5000"), "read scope does not allow writes")
+	assert.Equal(suite.T(), http.StatusForbidden, call(http.MethodGet, "/api/v1/test-api-key", "192.0.2.10:5000"), "a route standing for no entity needs a * scope")
+	assert.Equal(suite.T(), http.StatusForbidden, call(http.MethodGet, "/api/v1/api-keys", "198.51.100.1:5000"), "outside the allowed networks")
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/api-keys/%d/usage", apiKey.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+suite.authToken)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusOK, w.Code)
+
+	var apiResp utils.APIResponse
+	suite.NoError(json.Unmarshal(w.Body.Bytes(), &apiResp))
+	data := apiResp.Data.(map[string]interface{})
+	assert.Equal(suite.T(), float64(4), data["total"])
+	usage := data["usage"].([]interface{})
+	suite.Require().Len(usage, 4)
+	newest := usage[0].(map[string]interface{})
+	assert.Equal(suite.T(), "GET", newest["method"])
+	assert.Equal(suite.T(), "/api/v1/api-keys", newest["endpoint"])
+	assert.Equal(suite.T(), float64(http.StatusForbidden), newest["status"])
+	assert.Equal(suite.T(), "198.51.100.1", newest["ip_address"])
+	oldest := usage[3].(map[string]interface{})
+	assert.Equal(suite.T(), float64(http.StatusOK), oldest["status"])
+}
+
 func (suite *APIKeyIntegrationTestSuite) TestCreateAPIKeyWithExpiry() {
 	expires := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
 
 	body, _ := json.Marshal(map[string]interface{}{
 		"name":       "Expiring Key",
+		"scopes":     []string{"leads:read"},
 		"expires_at": expires.Format(time.RFC3339),
 	})
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/api-keys", bytes.NewBuffer(body))
@@ -380,6 +458,7 @@ func (suite *APIKeyIntegrationTestSuite) TestCreateAPIKeyWithExpiry() {
 func (suite *APIKeyIntegrationTestSuite) TestCreateAPIKeyWithPastExpiry() {
 	body, _ := json.Marshal(map[string]interface{}{
 		"name":       "Stale Key",
+		"scopes":     []string{"leads:read"},
 		"expires_at": time.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
 	})
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/api-keys", bytes.NewBuffer(body))
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strconv"
 	"testing"
 
 	"github.com/florinel-chis/gophercrm/internal/config"
@@ -58,8 +59,9 @@ func (suite *AuthSessionIntegrationTestSuite) SetupSuite() {
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	suite.NoError(err)
 	suite.NoError(db.AutoMigrate(
-		&models.User{}, &models.APIKey{},
-		&models.RefreshToken{}, &models.PasswordResetToken{},
+		&models.User{}, &models.APIKey{}, &models.APIKeyUsage{},
+		&models.RefreshToken{}, &models.PasswordResetToken{}, &models.TwoFactorRecoveryCode{},
+		&models.Session{}, &models.RevokedToken{},
 	))
 	suite.db = db
 
@@ -76,7 +78,7 @@ func (suite *AuthSessionIntegrationTestSuite) SetupSuite() {
 	}
 	authService := service.NewAuthServiceWithSessions(
 		userRepo, apiKeyRepo, refreshRepo, resetRepo, suite.mailer,
-		jwtConfig, "http://localhost:5173", "")
+		jwtConfig, "http://localhost:5173", "", service.WithSessionStore(repository.NewSessionRepository(db)))
 	suite.userService = service.NewUserService(userRepo)
 
 	gin.SetMode(gin.TestMode)
@@ -84,6 +86,7 @@ func (suite *AuthSessionIntegrationTestSuite) SetupSuite() {
 	suite.router.Use(middleware.ErrorHandler())
 
 	authHandler := handler.NewAuthHandler(authService, suite.userService)
+	sessionHandler := handler.NewSessionHandler(authService)
 
 	api := suite.router.Group("/api/v1")
 	{
@@ -98,6 +101,8 @@ func (suite *AuthSessionIntegrationTestSuite) SetupSuite() {
 		{
 			protected.POST("/auth/logout", authHandler.Logout)
 			protected.POST("/auth/change-password", authHandler.ChangePassword)
+			protected.GET("/auth/sessions", sessionHandler.List)
+			protected.DELETE("/auth/sessions/:id", sessionHandler.Revoke)
 		}
 	}
 }
@@ -125,6 +130,15 @@ func (suite *AuthSessionIntegrationTestSuite) postJSON(path, bearer string, payl
 	return w
 }
 
+func (suite *AuthSessionIntegrationTestSuite) request(method, path, bearer, userAgent string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+bearer)
+	req.Header.Set("User-Agent", userAgent)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
 func (suite *AuthSessionIntegrationTestSuite) decodeData(w *httptest.ResponseRecorder) map[string]interface{} {
 	var response utils.APIResponse
 	suite.NoError(json.Unmarshal(w.Body.Bytes(), &response))
@@ -175,6 +189,10 @@ func (suite *AuthSessionIntegrationTestSuite) TestSessionLifecycle() {
 	w = suite.postJSON("/api/v1/auth/logout", newAccess, nil)
 	suite.Equal(http.StatusOK, w.Code)
 
+	// The access token it was made with is refused at once.
+	w = suite.request(http.MethodGet, "/api/v1/auth/sessions", newAccess, "")
+	suite.Equal(http.StatusUnauthorized, w.Code)
+
 	// The rotated refresh token is now dead too.
 	w = suite.postJSON("/api/v1/auth/refresh", "", map[string]string{"refresh_token": newRefresh})
 	suite.Equal(http.StatusUnauthorized, w.Code)
@@ -269,6 +287,66 @@ func (suite *AuthSessionIntegrationTestSuite) TestChangePasswordFlow() {
 	suite.Equal(http.StatusUnauthorized, w.Code)
 }
 
+// TestEndStolenSession logs in from two devices, lists the sessions from one
+// and ends the other, whose access token stops working immediately.
+func (suite *AuthSessionIntegrationTestSuite) TestEndStolenSession() {
+	user := &models.User{
+		Email:     "twodevices@example.com",
+		FirstName: "Two",
+		LastName:  "Devices",
+		Role:      models.RoleCustomer,
+	}
+	suite.NoError(suite.userService.Register(user, "Password123!"))
+
+	login := func(userAgent string) (string, string) {
+		body, _ := json.Marshal(map[string]string{"email": "twodevices@example.com", "password": "Password123!"})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", userAgent)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		suite.Require().Equal(http.StatusOK, w.Code)
+		data := suite.decodeData(w)
+		accessToken, _ := data["token"].(string)
+		refreshToken, _ := data["refresh_token"].(string)
+		return accessToken, refreshToken
+	}
+	const laptop = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"
+	const phone = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36"
+	stolenAccess, stolenRefresh := login(phone)
+	ownAccess, _ := login(laptop)
+
+	w := suite.request(http.MethodGet, "/api/v1/auth/sessions", ownAccess, laptop)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var response struct {
+		Data []models.Session `json:"data"`
+	}
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	suite.Require().Len(response.Data, 2)
+	var stolenID uint
+	for _, session := range response.Data {
+		if session.Device == "Chrome on Android" {
+			suite.False(session.Current)
+			stolenID = session.ID
+		} else {
+			suite.True(session.Current)
+			suite.Equal("Chrome on Windows", session.Device)
+		}
+	}
+	suite.Require().NotZero(stolenID)
+
+	w = suite.request(http.MethodDelete, "/api/v1/auth/sessions/"+strconv.FormatUint(uint64(stolenID), 10), ownAccess, laptop)
+	suite.Equal(http.StatusOK, w.Code)
+
+	w = suite.request(http.MethodGet, "/api/v1/auth/sessions", stolenAccess, phone)
+	suite.Equal(http.StatusUnauthorized, w.Code)
+	w = suite.postJSON("/api/v1/auth/refresh", "", map[string]string{"refresh_token": stolenRefresh})
+	suite.Equal(http.StatusUnauthorized, w.Code)
+
+	w = suite.request(http.MethodGet, "/api/v1/auth/sessions", ownAccess, laptop)
+	suite.Equal(http.StatusOK, w.Code)
+}
+
 func TestAuthSessionIntegrationTestSuite(t *testing.T) {
 	suite.Run(t, new(AuthSessionIntegrationTestSuite))
 }
@@ -56,7 +56,8 @@ func (suite *CustomerIntegrationTestSuite) SetupSuite() {
 	suite.NoError(err)
 	
 	// Migrate the schema
-	err = db.AutoMigrate(&models.User{}, &models.APIKey{}, &models.Customer{})
+	err = db.AutoMigrate(&models.User{}, &models.APIKey{}, &models.Customer{}, &models.AuditEvent{}, &models.ActivityEvent{}, &models.WebhookDelivery{},
+		&models.CustomFieldDefinition{}, &models.CustomFieldValue{}, &models.BulkOperation{}, &models.BulkOperationItem{}, &models.Import{})
 	suite.NoError(err)
 	
 	suite.db = db
@@ -73,7 +74,7 @@ func (suite *CustomerIntegrationTestSuite) SetupSuite() {
 	}
 	suite.authService = service.NewAuthService(userRepo, apiKeyRepo, jwtConfig)
 	suite.userService = service.NewUserService(userRepo)
-	suite.customerService = service.NewCustomerService(customerRepo, userRepo)
+	suite.customerService = service.NewCustomerService(customerRepo, userRepo, nil, nil)
 	
 	// Initialize handlers
 	authHandler := handler.NewAuthHandler(suite.authService, suite.userService)
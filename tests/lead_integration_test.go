@@ -50,7 +50,8 @@ func (suite *LeadIntegrationTestSuite) SetupSuite() {
 	
 	// Migrate the schema
 	err = db.AutoMigrate(&models.User{}, &models.APIKey{}, &models.Lead{}, &models.Customer{},
-		&models.Form{}, &models.FormSubmission{}, &models.FormConfirmationToken{})
+		&models.Form{}, &models.FormSubmission{}, &models.FormConfirmationToken{}, &models.AuditEvent{}, &models.ActivityEvent{}, &models.WebhookDelivery{},
+		&models.CustomFieldDefinition{}, &models.CustomFieldValue{}, &models.BulkOperation{}, &models.BulkOperationItem{}, &models.Import{})
 	suite.NoError(err)
 	
 	suite.db = db
@@ -71,7 +72,7 @@ func (suite *LeadIntegrationTestSuite) SetupSuite() {
 	suite.authService = service.NewAuthService(userRepo, apiKeyRepo, jwtConfig)
 	suite.userService = service.NewUserService(userRepo)
 	txManager := utils.NewTransactionManager(db)
-	suite.leadService = service.NewLeadService(leadRepo, customerRepo, txManager)
+	suite.leadService = service.NewLeadService(leadRepo, customerRepo, nil, txManager)
 	
 	// Initialize handlers
 	authHandler := handler.NewAuthHandler(suite.authService, suite.userService)
@@ -0,0 +1,175 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	"github.com/florinel-chis/gophercrm/internal/handler"
+	"github.com/florinel-chis/gophercrm/internal/middleware"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/oidc"
+	"github.com/florinel-chis/gophercrm/internal/oidc/oidctest"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const ssoIntegrationSecret = "integration-test-secret"
+
+// SSOIntegrationTestSuite signs staff in over HTTP through a mock OpenID
+// Connect provider, the way the frontend drives the flow.
+type SSOIntegrationTestSuite struct {
+	suite.Suite
+	db     *gorm.DB
+	idp    *oidctest.Server
+	router *gin.Engine
+}
+
+func TestSSOIntegrationTestSuite(t *testing.T) {
+	suite.Run(t, new(SSOIntegrationTestSuite))
+}
+
+func (suite *SSOIntegrationTestSuite) SetupSuite() {
+	suite.idp = oidctest.NewServer("gophercrm", "client-secret")
+}
+
+func (suite *SSOIntegrationTestSuite) TearDownSuite() {
+	suite.idp.Close()
+}
+
+func (suite *SSOIntegrationTestSuite) SetupTest() {
+	suite.NoError(utils.InitLogger(&config.LoggingConfig{Level: "error", Format: "json"}))
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	suite.Require().NoError(err)
+	suite.Require().NoError(db.AutoMigrate(&models.User{}, &models.APIKey{}, &models.RefreshToken{},
+		&models.SSOLoginState{}, &models.Role{}))
+	suite.db = db
+
+	oidcConfig := config.OIDCConfig{
+		Issuer:          suite.idp.URL,
+		ClientID:        "gophercrm",
+		ClientSecret:    "client-secret",
+		RedirectURL:     "http://localhost:5173/auth/sso/callback",
+		RoleClaim:       "groups",
+		RoleMapping:     []config.OIDCRoleMapping{{Value: "crm-admins", Role: "admin"}},
+		DefaultRole:     "sales",
+		Domains:         []string{"example.com"},
+		EnforceDomains:  true,
+		JITProvisioning: true,
+	}
+
+	userRepo := repository.NewUserRepository(db)
+	authService := service.NewAuthServiceWithSessions(
+		userRepo, repository.NewAPIKeyRepository(db), repository.NewRefreshTokenRepository(db), nil, nil,
+		config.JWTConfig{Secret: ssoIntegrationSecret, ExpiryHours: 1, RefreshTokenDays: 30},
+		"http://localhost:5173", ssoIntegrationSecret, service.WithSSOEnforcement(oidcConfig.Domains))
+	provider := oidc.NewProvider(oidc.Config{
+		Issuer:       oidcConfig.Issuer,
+		ClientID:     oidcConfig.ClientID,
+		ClientSecret: oidcConfig.ClientSecret,
+		RedirectURL:  oidcConfig.RedirectURL,
+		Scopes:       []string{"email", "profile"},
+	})
+	ssoService := service.NewSSOService(provider, repository.NewSSORepository(db), userRepo,
+		service.NewRoleService(repository.NewRoleRepository(db)), authService, nil, oidcConfig, ssoIntegrationSecret)
+
+	gin.SetMode(gin.TestMode)
+	suite.router = gin.New()
+	suite.router.Use(middleware.ErrorHandler())
+
+	authHandler := handler.NewAuthHandler(authService, service.NewUserService(userRepo))
+	ssoHandler := handler.NewSSOHandler(ssoService)
+
+	api := suite.router.Group("/api/v1")
+	api.POST("/auth/login", authHandler.Login)
+	api.GET("/auth/sso", ssoHandler.Config)
+	api.POST("/auth/sso/start", ssoHandler.Start)
+	api.POST("/auth/sso/callback", ssoHandler.Callback)
+	protected := api.Group("")
+	protected.Use(middleware.Auth(authService))
+	protected.GET("/me", func(c *gin.Context) {
+		user, _ := c.Get("user")
+		utils.RespondSuccess(c, http.StatusOK, user)
+	})
+}
+
+func (suite *SSOIntegrationTestSuite) TearDownTest() {
+	sqlDB, _ := suite.db.DB()
+	sqlDB.Close()
+}
+
+func (suite *SSOIntegrationTestSuite) request(method, path, bearer string, payload interface{}) (int, map[string]interface{}) {
+	b, _ := json.Marshal(payload)
+	req := httptest.NewRequest(method, path, bytes.NewBuffer(b))
+	req.Header.Set("Content-Type", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	var response utils.APIResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	data, _ := response.Data.(map[string]interface{})
+	return w.Code, data
+}
+
+// signIn starts an attempt, lets the mock provider sign identity in and
+// posts its redirect back, as the frontend's callback page does.
+func (suite *SSOIntegrationTestSuite) signIn(identity oidctest.Identity) (int, map[string]interface{}) {
+	status, start := suite.request(http.MethodPost, "/api/v1/auth/sso/start", "", nil)
+	suite.Require().Equal(http.StatusOK, status)
+
+	code, state, err := suite.idp.Authorize(start["authorization_url"].(string), identity)
+	suite.Require().NoError(err)
+	return suite.request(http.MethodPost, "/api/v1/auth/sso/callback", "",
+		map[string]string{"session": start["session"].(string), "state": state, "code": code})
+}
+
+func (suite *SSOIntegrationTestSuite) TestSignInProvisionsAndAuthenticates() {
+	status, cfg := suite.request(http.MethodGet, "/api/v1/auth/sso", "", nil)
+	suite.Require().Equal(http.StatusOK, status)
+	suite.Equal(true, cfg["enabled"])
+
+	status, signedIn := suite.signIn(oidctest.Identity{
+		Subject: "emp-42", Email: "lin@example.com", EmailVerified: true, GivenName: "Lin", FamilyName: "Ko",
+		Claims: map[string]interface{}{"groups": []string{"crm-admins"}},
+	})
+	suite.Require().Equal(http.StatusOK, status)
+	suite.NotEmpty(signedIn["refresh_token"])
+
+	status, me := suite.request(http.MethodGet, "/api/v1/me", signedIn["token"].(string), nil)
+	suite.Require().Equal(http.StatusOK, status)
+	suite.Equal("lin@example.com", me["email"])
+	suite.Equal("admin", me["role"])
+
+	// Enforcement: the provisioned account has no usable password, and the
+	// domain cannot log in with one anyway.
+	status, _ = suite.request(http.MethodPost, "/api/v1/auth/login", "",
+		map[string]string{"email": "lin@example.com", "password": "Str0ng!Passw0rd"})
+	suite.Equal(http.StatusForbidden, status)
+}
+
+func (suite *SSOIntegrationTestSuite) TestRejectedSignIns() {
+	status, _ := suite.signIn(oidctest.Identity{
+		Subject: "ext-1", Email: "vendor@vendor.net", EmailVerified: true,
+	})
+	suite.Equal(http.StatusForbidden, status, "a domain that is not allowed")
+
+	status, _ = suite.request(http.MethodPost, "/api/v1/auth/sso/callback", "",
+		map[string]string{"session": "made-up", "state": "made-up", "code": "made-up"})
+	suite.Equal(http.StatusUnauthorized, status, "a callback without an attempt")
+
+	var count int64
+	suite.Require().NoError(suite.db.Model(&models.User{}).Count(&count).Error)
+	suite.Zero(count)
+}
@@ -0,0 +1,211 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/florinel-chis/gophercrm/internal/config"
+	"github.com/florinel-chis/gophercrm/internal/handler"
+	"github.com/florinel-chis/gophercrm/internal/middleware"
+	"github.com/florinel-chis/gophercrm/internal/models"
+	"github.com/florinel-chis/gophercrm/internal/repository"
+	"github.com/florinel-chis/gophercrm/internal/service"
+	"github.com/florinel-chis/gophercrm/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const twoFactorIntegrationPassword = "Str0ng!Passw0rd"
+
+// TwoFactorIntegrationTestSuite walks the two-factor enrollment and the
+// two-step login over HTTP, against real repositories on SQLite.
+type TwoFactorIntegrationTestSuite struct {
+	suite.Suite
+	db            *gorm.DB
+	router        *gin.Engine
+	requiredRoles []string
+}
+
+func TestTwoFactorIntegrationTestSuite(t *testing.T) {
+	suite.Run(t, new(TwoFactorIntegrationTestSuite))
+}
+
+func (suite *TwoFactorIntegrationTestSuite) SetupTest() {
+	suite.NoError(utils.InitLogger(&config.LoggingConfig{Level: "error", Format: "json"}))
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	suite.Require().NoError(err)
+	suite.Require().NoError(db.AutoMigrate(&models.User{}, &models.APIKey{}, &models.RefreshToken{},
+		&models.TwoFactorRecoveryCode{}))
+	suite.db = db
+	suite.requiredRoles = nil
+
+	userRepo := repository.NewUserRepository(db)
+	twoFactorService := service.NewTwoFactorService(userRepo, repository.NewTwoFactorRepository(db),
+		utils.NewSecretBox("integration-test-secret", "totp-secret"), "integration-test-secret",
+		func() []string { return suite.requiredRoles })
+	authService := service.NewAuthServiceWithSessions(
+		userRepo, repository.NewAPIKeyRepository(db), repository.NewRefreshTokenRepository(db), nil, nil,
+		config.JWTConfig{Secret: "integration-test-secret", ExpiryHours: 1, RefreshTokenDays: 30},
+		"http://localhost:5173", "integration-test-secret", service.WithTwoFactor(twoFactorService))
+
+	gin.SetMode(gin.TestMode)
+	suite.router = gin.New()
+	suite.router.Use(middleware.ErrorHandler())
+
+	authHandler := handler.NewAuthHandler(authService, service.NewUserService(userRepo))
+	twoFactorHandler := handler.NewTwoFactorHandler(twoFactorService)
+
+	api := suite.router.Group("/api/v1")
+	api.POST("/auth/login", authHandler.Login)
+	api.POST("/auth/login/2fa", authHandler.LoginTwoFactor)
+	api.POST("/auth/login/2fa/setup", authHandler.LoginTwoFactorSetup)
+	protected := api.Group("")
+	protected.Use(middleware.Auth(authService))
+	protected.GET("/auth/2fa", twoFactorHandler.Status)
+	protected.POST("/auth/2fa/setup", twoFactorHandler.Setup)
+	protected.POST("/auth/2fa/verify", twoFactorHandler.Verify)
+	protected.POST("/auth/2fa/disable", twoFactorHandler.Disable)
+	protected.POST("/auth/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+}
+
+func (suite *TwoFactorIntegrationTestSuite) TearDownTest() {
+	sqlDB, _ := suite.db.DB()
+	sqlDB.Close()
+}
+
+func (suite *TwoFactorIntegrationTestSuite) createUser(email string, role models.UserRole) {
+	suite.Require().NoError(service.NewUserService(repository.NewUserRepository(suite.db)).Register(&models.User{
+		Email: email, FirstName: "Two", LastName: "Factor", Role: role,
+	}, twoFactorIntegrationPassword))
+}
+
+func (suite *TwoFactorIntegrationTestSuite) request(method, path, bearer string, payload interface{}) (int, map[string]interface{}) {
+	b, _ := json.Marshal(payload)
+	req := httptest.NewRequest(method, path, bytes.NewBuffer(b))
+	req.Header.Set("Content-Type", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	var response utils.APIResponse
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	data, _ := response.Data.(map[string]interface{})
+	return w.Code, data
+}
+
+func (suite *TwoFactorIntegrationTestSuite) login(email string) map[string]interface{} {
+	status, data := suite.request(http.MethodPost, "/api/v1/auth/login", "",
+		map[string]string{"email": email, "password": twoFactorIntegrationPassword})
+	suite.Require().Equal(http.StatusOK, status)
+	return data
+}
+
+// codeAt returns the code of secret offset steps from now. Each step can be
+// used once, so a test that presents several codes moves forward through the
+// steps the validation window allows.
+func (suite *TwoFactorIntegrationTestSuite) codeAt(secret string, offset int64) string {
+	code, err := utils.TOTPCode(secret, utils.TOTPStep(time.Now())+offset)
+	suite.Require().NoError(err)
+	return code
+}
+
+func toStrings(values interface{}) []string {
+	list, _ := values.([]interface{})
+	out := make([]string, 0, len(list))
+	for _, v := range list {
+		out = append(out, v.(string))
+	}
+	return out
+}
+
+func (suite *TwoFactorIntegrationTestSuite) TestEnrollAndLogInWithTwoFactors() {
+	suite.createUser("ops@example.com", models.RoleAdmin)
+	token := suite.login("ops@example.com")["token"].(string)
+
+	status, setup := suite.request(http.MethodPost, "/api/v1/auth/2fa/setup", token, nil)
+	suite.Require().Equal(http.StatusOK, status)
+	secret := setup["secret"].(string)
+	suite.Contains(setup["otpauth_uri"], "otpauth://totp/GopherCRM:ops@example.com")
+
+	status, _ = suite.request(http.MethodPost, "/api/v1/auth/2fa/verify", token, map[string]string{"code": "abcdef"})
+	suite.Equal(http.StatusBadRequest, status)
+	status, verified := suite.request(http.MethodPost, "/api/v1/auth/2fa/verify", token,
+		map[string]string{"code": suite.codeAt(secret, 0)})
+	suite.Require().Equal(http.StatusOK, status)
+	recovery := toStrings(verified["recovery_codes"])
+	suite.Len(recovery, 10)
+
+	// The password alone now yields a challenge, not tokens.
+	challenge := suite.login("ops@example.com")
+	suite.Equal(true, challenge["two_factor_required"])
+	suite.Equal(false, challenge["setup_required"])
+	suite.Nil(challenge["token"])
+
+	// The challenge is not an access token.
+	status, _ = suite.request(http.MethodGet, "/api/v1/auth/2fa", challenge["challenge"].(string), nil)
+	suite.Equal(http.StatusUnauthorized, status)
+
+	code := suite.codeAt(secret, 1)
+	status, completed := suite.request(http.MethodPost, "/api/v1/auth/login/2fa", "",
+		map[string]string{"challenge": challenge["challenge"].(string), "code": code})
+	suite.Require().Equal(http.StatusOK, status)
+	suite.NotEmpty(completed["token"])
+	suite.NotEmpty(completed["refresh_token"])
+
+	status, _ = suite.request(http.MethodPost, "/api/v1/auth/login/2fa", "",
+		map[string]string{"challenge": challenge["challenge"].(string), "code": code})
+	suite.Equal(http.StatusUnauthorized, status, "a code is accepted once")
+
+	status, _ = suite.request(http.MethodPost, "/api/v1/auth/login/2fa", "",
+		map[string]string{"challenge": challenge["challenge"].(string), "code": recovery[0]})
+	suite.Equal(http.StatusOK, status)
+	status, _ = suite.request(http.MethodPost, "/api/v1/auth/login/2fa", "",
+		map[string]string{"challenge": challenge["challenge"].(string), "code": recovery[0]})
+	suite.Equal(http.StatusUnauthorized, status, "a recovery code is accepted once")
+
+	status, current := suite.request(http.MethodGet, "/api/v1/auth/2fa", completed["token"].(string), nil)
+	suite.Require().Equal(http.StatusOK, status)
+	suite.Equal(true, current["enabled"])
+	suite.Equal(float64(9), current["recovery_codes_remaining"])
+
+	status, _ = suite.request(http.MethodPost, "/api/v1/auth/2fa/disable", completed["token"].(string),
+		map[string]string{"password": twoFactorIntegrationPassword, "code": recovery[1]})
+	suite.Require().Equal(http.StatusOK, status)
+	suite.NotEmpty(suite.login("ops@example.com")["token"], "a password login issues tokens again")
+}
+
+func (suite *TwoFactorIntegrationTestSuite) TestRequiredRoleEnrollsAtLogin() {
+	suite.requiredRoles = []string{"admin"}
+	suite.createUser("admin@example.com", models.RoleAdmin)
+	suite.createUser("sales@example.com", models.RoleSales)
+
+	suite.NotEmpty(suite.login("sales@example.com")["token"], "roles that are not listed are unaffected")
+
+	challenge := suite.login("admin@example.com")
+	suite.Equal(true, challenge["setup_required"])
+	suite.Nil(challenge["token"])
+
+	status, setup := suite.request(http.MethodPost, "/api/v1/auth/login/2fa/setup", "",
+		map[string]string{"challenge": challenge["challenge"].(string)})
+	suite.Require().Equal(http.StatusOK, status)
+
+	status, completed := suite.request(http.MethodPost, "/api/v1/auth/login/2fa", "",
+		map[string]string{"challenge": challenge["challenge"].(string), "code": suite.codeAt(setup["secret"].(string), 0)})
+	suite.Require().Equal(http.StatusOK, status)
+	suite.NotEmpty(completed["token"])
+	recovery := toStrings(completed["recovery_codes"])
+	suite.Len(recovery, 10, "the enrolling login returns the recovery codes")
+
+	status, _ = suite.request(http.MethodPost, "/api/v1/auth/2fa/disable", completed["token"].(string),
+		map[string]string{"password": twoFactorIntegrationPassword, "code": recovery[0]})
+	suite.Equal(http.StatusForbidden, status, "a required second factor cannot be switched off")
+}